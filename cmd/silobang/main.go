@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"silobang/internal/audit"
 	"silobang/internal/auth"
@@ -12,14 +13,30 @@ import (
 	"silobang/internal/constants"
 	"silobang/internal/database"
 	"silobang/internal/logger"
-	"silobang/internal/prompts"
 	"silobang/internal/queries"
 	"silobang/internal/server"
+	"silobang/internal/services"
 	"silobang/internal/version"
 	"silobang/web"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "seed":
+			os.Exit(runSeed(os.Args[2:]))
+		case "recover-admin":
+			os.Exit(runRecoverAdmin(os.Args[2:]))
+		case "service":
+			os.Exit(runService(os.Args[2:]))
+		case "backup":
+			os.Exit(runBackup(os.Args[2:]))
+		case "restore":
+			os.Exit(runRestore(os.Args[2:]))
+		}
+	}
+
 	// 0. Version flag
 	showVersion := flag.Bool("version", false, "print version and exit")
 	flag.Parse()
@@ -28,6 +45,13 @@ func main() {
 		os.Exit(0)
 	}
 
+	serve(nil)
+}
+
+// serve starts the server with the discovered config and blocks until it
+// shuts down on a signal or when stop is closed. Fatal errors exit the
+// process.
+func serve(stop <-chan struct{}) {
 	// 1. Initialize debug logger
 	log := logger.NewLogger(constants.DefaultLogLevel)
 	log.Info("%s version %s starting", constants.AppDisplayName, version.Version)
@@ -44,37 +68,112 @@ func main() {
 	// 3. Create application instance
 	app := server.NewApp(cfg, log)
 
-	// 4. If working_directory is set and valid, initialize it
+	// Readiness reports "initialization in progress" until the report completes
+	startup := services.NewStartupRecorder(constants.StartupTriggerBoot)
+	app.SetStartupRecorder(startup)
+
+	// Embedded query defaults until the working directory's presets load
+	app.QueriesConfig = queries.GetDefaultConfig()
+
+	// 4. If working_directory is set and valid, initialize it. Each subsystem
+	// starts on its own: a failure is reported by /readyz and only stops the
+	// subsystems depending on it, the server is still started.
 	if cfg.WorkingDirectory != "" {
 		log.Info("Initializing working directory: %s", cfg.WorkingDirectory)
-		if err := config.InitializeWorkingDirectory(cfg.WorkingDirectory); err != nil {
-			log.Error("Failed to initialize working directory: %v", err)
-			cfg.WorkingDirectory = "" // Clear invalid path
-		} else {
-			// Open orchestrator DB
-			orchPath := filepath.Join(cfg.WorkingDirectory, constants.InternalDir, constants.OrchestratorDB)
+		startSubsystems(app, cfg, startup, log)
+	} else {
+		log.Warn("Working directory not set - configure via dashboard")
+		startup.Record(constants.StartupStepWorkingDirectory, time.Now(), constants.StartupStepSkipped, "not configured")
+		log.Debug("Using embedded query defaults (no working directory)")
+	}
+
+	// Persist the startup report (kept in memory only without a working directory)
+	if err := app.Services.Health.SaveStartupReport(startup); err != nil {
+		log.Warn("Failed to save startup report: %v", err)
+	}
+
+	// 5. Load embedded web frontend
+	webFS, err := web.GetDistFS()
+	if err != nil {
+		log.Warn("Failed to load embedded web frontend: %v", err)
+		// Continue without frontend - API still works
+	}
+
+	// 6. Start HTTP server
+	port := cfg.Port
+	if port == 0 {
+		port = constants.DefaultPort
+	}
+
+	addr := fmt.Sprintf(":%d", port)
+	srv := server.NewServer(app, addr, webFS)
+
+	log.Info("Starting SiloBang server on port %d", port)
+	if err := srv.Run(stop); err != nil {
+		log.Error("Server error: %v", err)
+		os.Exit(1)
+	}
+}
+
+// startSubsystems initializes the configured working directory, starting
+// each subsystem under a supervisor that records it to startup.
+func startSubsystems(app *server.App, cfg *config.Config, startup *services.StartupRecorder, log *logger.Logger) {
+	supervisor := services.NewSupervisor(startup, cfg.Features, log)
+	workDir := cfg.WorkingDirectory
+
+	if !supervisor.Start(services.Subsystem{
+		Name:     constants.StartupStepWorkingDirectory,
+		Required: true,
+		Start: func() (string, error) {
+			return "", config.InitializeWorkingDirectory(workDir)
+		},
+	}) {
+		cfg.WorkingDirectory = "" // Clear invalid path
+	}
+
+	// Open orchestrator DB
+	supervisor.Start(services.Subsystem{
+		Name:     constants.StartupStepOrchestratorDB,
+		Required: true,
+		Requires: []string{constants.StartupStepWorkingDirectory},
+		Start: func() (string, error) {
+			orchPath := filepath.Join(workDir, constants.InternalDir, constants.OrchestratorDB)
 			orchDB, err := database.InitOrchestratorDB(orchPath)
 			if err != nil {
-				log.Error("Failed to open orchestrator database: %v", err)
-				os.Exit(1)
+				return "", err
 			}
 			app.OrchestratorDB = orchDB
+			return "", nil
+		},
+	})
 
-			// Initialize audit logger
-			app.AuditLogger = audit.NewLogger(orchDB, cfg.Audit.MaxLogSizeBytes, cfg.Audit.PurgePercentage)
+	// Initialize audit logger
+	supervisor.Start(services.Subsystem{
+		Name:     constants.StartupStepAudit,
+		Requires: []string{constants.StartupStepOrchestratorDB},
+		Start: func() (string, error) {
+			app.AuditLogger = audit.NewLogger(app.OrchestratorDB, cfg.Audit.MaxLogSizeBytes, cfg.Audit.PurgePercentage)
+			app.AuditLogger.SetRetention(cfg.Audit.Retention())
+			app.AuditLogger.SetQueue(cfg.Audit.QueueSize, cfg.Audit.OverflowPolicy)
 			log.Debug("Audit logger initialized")
+			return "", nil
+		},
+	})
 
-			// Re-initialize services now that orchestrator DB is available
-			// (AuthService requires the DB and returns nil without it)
-			app.SetOrchestratorDB(orchDB)
-			app.ReinitServices()
+	// Re-initialize services now that orchestrator DB is available
+	// (AuthService requires the DB and returns nil without it)
+	app.ReinitServices()
 
-			// Bootstrap auth: create admin user if no users exist
-			authStore := auth.NewStore(orchDB, cfg.Auth.MaxLoginAttempts, cfg.Auth.LockoutDurationMins, cfg.Auth.SessionDuration())
+	// Bootstrap auth: create admin user if no users exist
+	supervisor.Start(services.Subsystem{
+		Name:     constants.StartupStepAuthBootstrap,
+		Required: true,
+		Requires: []string{constants.StartupStepOrchestratorDB},
+		Start: func() (string, error) {
+			authStore := auth.NewStore(app.OrchestratorDB, cfg.Auth.MaxLoginAttempts, cfg.Auth.LockoutDurationMins, cfg.Auth.SessionDuration())
 			bootstrapResult, err := auth.Bootstrap(authStore, log)
 			if err != nil {
-				log.Error("Auth bootstrap failed: %v", err)
-				os.Exit(1)
+				return "", err
 			}
 			if bootstrapResult != nil {
 				fmt.Println("╔══════════════════════════════════════════════════════════════╗")
@@ -88,91 +187,85 @@ func main() {
 				log.Info("Auth: bootstrap complete — admin account created")
 			}
 
-			// Enable file logging now that workdir is available
-			if err := log.SetWorkDir(cfg.WorkingDirectory); err != nil {
-				log.Warn("Failed to enable file logging: %v", err)
-			} else {
-				log.Info("File logging enabled in %s", cfg.WorkingDirectory)
-			}
+			// Record edits made to the config file while the server was stopped
+			app.Services.Config.RecordStartup(bootstrapResult != nil)
+			return "", nil
+		},
+	})
 
-			// Discover existing topics
-			topics, err := config.DiscoverTopics(cfg.WorkingDirectory)
-			if err != nil {
-				log.Warn("Topic discovery failed: %v", err)
-			} else {
-				log.Info("Discovered %d topic(s)", len(topics))
-				for _, t := range topics {
-					app.RegisterTopic(t.Name, t.Healthy, t.Error)
-					if t.Healthy {
-						log.Debug("  - %s (healthy)", t.Name)
-						// Index to orchestrator
-						if err := config.IndexTopicToOrchestrator(t.Path, t.Name, app.OrchestratorDB); err != nil {
-							log.Warn("Failed to index topic %s: %v", t.Name, err)
-						}
-					} else {
-						log.Warn("  - %s (unhealthy: %s)", t.Name, t.Error)
-					}
-				}
-			}
-
-			// Reconcile: purge orphaned asset_index entries for topics no longer on disk
-		reconcileResult, reconcileErr := app.Services.Reconcile.Reconcile()
-		if reconcileErr != nil {
-			log.Warn("Reconciliation failed: %v", reconcileErr)
-		} else if reconcileResult.TopicsRemoved > 0 {
-			log.Info("Reconciliation: removed %d orphaned topic(s), purged %d index entries",
-				reconcileResult.TopicsRemoved, reconcileResult.EntriesPurged)
+	// Enable file logging now that workdir is available
+	if cfg.WorkingDirectory != "" {
+		if err := log.SetWorkDir(cfg.WorkingDirectory); err != nil {
+			log.Warn("Failed to enable file logging: %v", err)
+		} else {
+			log.Info("File logging enabled in %s", cfg.WorkingDirectory)
 		}
+	}
 
-		// Load queries from .internal/queries/ directory
-			queriesConfig, err := queries.LoadQueries(cfg.WorkingDirectory, log)
-			if err != nil {
-				log.Warn("Failed to load queries: %v, using defaults", err)
-				queriesConfig = queries.GetDefaultConfig()
-			}
-			app.QueriesConfig = queriesConfig
+	// Search: topic databases opened from here on keep or drop their index
+	database.SetSearchIndexing(cfg.Features.Enabled(constants.FeatureSearch))
+	supervisor.Start(services.SearchSubsystem(app))
 
-			// Initialize prompts manager with base URL
-			port := cfg.Port
-			if port == 0 {
-				port = constants.DefaultPort
+	// Discover existing topics and index them to the orchestrator
+	supervisor.Start(services.TopicDiscoverySubsystem(app, workDir, log))
+
+	// Reconcile: purge orphaned asset_index entries for topics no longer on disk
+	supervisor.Start(services.Subsystem{
+		Name:     constants.StartupStepReconcile,
+		Requires: []string{constants.StartupStepTopicDiscovery},
+		Start: func() (string, error) {
+			reconcileResult, err := app.Services.Reconcile.Reconcile()
+			if err != nil {
+				return "", err
 			}
-			baseURL := fmt.Sprintf("http://localhost:%d", port)
-			promptsManager := prompts.NewManager(cfg.WorkingDirectory, baseURL)
-			if err := promptsManager.EnsurePromptsDir(cfg.WorkingDirectory, log); err != nil {
-				log.Warn("Failed to initialize prompts directory: %v", err)
+			if reconcileResult.TopicsRemoved > 0 {
+				log.Info("Reconciliation: removed %d orphaned topic(s), purged %d index entries",
+					reconcileResult.TopicsRemoved, reconcileResult.EntriesPurged)
 			}
-			if err := promptsManager.LoadPrompts(log); err != nil {
-				log.Warn("Failed to load prompts: %v", err)
+			return "", nil
+		},
+	})
+
+	// Integrity: flag .dat regions no recorded asset explains (e.g. after a crash)
+	supervisor.Start(services.Subsystem{
+		Name:     constants.StartupStepIntegrityScan,
+		Feature:  constants.FeatureIntegrityScan,
+		Requires: []string{constants.StartupStepTopicDiscovery},
+		Start: func() (string, error) {
+			app.Services.Integrity.ScanAll()
+			return "", nil
+		},
+	})
+
+	// Stats cache: serve the persisted cache immediately, rebuild it in the background
+	supervisor.Start(services.Subsystem{
+		Name:     constants.StartupStepStatsCache,
+		Required: true,
+		Requires: []string{constants.StartupStepTopicDiscovery},
+		Start: func() (string, error) {
+			if app.Services.StatsCache.Restore() {
+				go app.Services.StatsCache.Reconcile()
+				return "restored, reconciling in background", nil
 			}
-			app.PromptsManager = promptsManager
-		}
-	} else {
-		log.Warn("Working directory not set - configure via dashboard")
-		// Use embedded defaults when no working directory
-		app.QueriesConfig = queries.GetDefaultConfig()
-		log.Debug("Using embedded query defaults (no working directory)")
-	}
+			app.Services.StatsCache.BuildAll()
+			return "built", nil
+		},
+	})
 
-	// 5. Load embedded web frontend
-	webFS, err := web.GetDistFS()
-	if err != nil {
-		log.Warn("Failed to load embedded web frontend: %v", err)
-		// Continue without frontend - API still works
-	}
+	// Load queries from .internal/queries/ directory
+	supervisor.Start(services.QueriesSubsystem(app, workDir, log))
 
-	// 6. Start HTTP server
+	// Initialize prompts manager with base URL
 	port := cfg.Port
 	if port == 0 {
 		port = constants.DefaultPort
 	}
-
-	addr := fmt.Sprintf(":%d", port)
-	srv := server.NewServer(app, addr, webFS)
-
-	log.Info("Starting SiloBang server on port %d", port)
-	if err := srv.Start(); err != nil {
-		log.Error("Server error: %v", err)
-		os.Exit(1)
+	scheme := "http"
+	if cfg.HTTP.TLSEnabled() {
+		scheme = "https"
 	}
+	baseURL := fmt.Sprintf("%s://localhost:%d", scheme, port)
+	supervisor.Start(services.PromptsSubsystem(app, workDir, baseURL, log))
+
+	supervisor.Start(services.PreviewsSubsystem())
 }
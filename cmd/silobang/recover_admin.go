@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// runRecoverAdmin implements "silobang recover-admin": the break-glass path
+// for when every admin credential is lost. It requires filesystem access to
+// the working directory and prints a one-time token that can be exchanged
+// once via POST /api/auth/recover for new bootstrap admin credentials.
+// It can run while the server is up.
+func runRecoverAdmin(args []string) int {
+	fs := flag.NewFlagSet("recover-admin", flag.ExitOnError)
+	workDir := fs.String("workdir", "", "working directory holding the orchestrator database (default: configured working directory)")
+	fs.Parse(args)
+
+	log := logger.NewLogger(constants.DefaultLogLevel)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Error("Failed to load config: %v", err)
+		return 1
+	}
+	if *workDir != "" {
+		abs, err := filepath.Abs(*workDir)
+		if err != nil {
+			log.Error("Invalid working directory: %v", err)
+			return 1
+		}
+		cfg.WorkingDirectory = abs
+	}
+	if cfg.WorkingDirectory == "" {
+		log.Error("No working directory configured; pass --workdir")
+		return 1
+	}
+
+	// Never create a fresh database here: recovery only makes sense for an
+	// instance that already has users
+	orchPath := filepath.Join(cfg.WorkingDirectory, constants.InternalDir, constants.OrchestratorDB)
+	if _, err := os.Stat(orchPath); err != nil {
+		log.Error("No orchestrator database at %s: %v", orchPath, err)
+		return 1
+	}
+
+	orchDB, err := database.InitOrchestratorDB(orchPath)
+	if err != nil {
+		log.Error("Failed to open orchestrator database: %v", err)
+		return 1
+	}
+	defer orchDB.Close()
+
+	store := auth.NewStore(orchDB, cfg.Auth.MaxLoginAttempts, cfg.Auth.LockoutDurationMins, cfg.Auth.SessionDuration())
+	admin, err := store.GetBootstrapUser()
+	if err != nil {
+		log.Error("No bootstrap admin found; start the server once to create it: %v", err)
+		return 1
+	}
+
+	token, expiresAt, err := auth.IssueRecoveryToken(store)
+	if err != nil {
+		log.Error("Failed to issue recovery token: %v", err)
+		return 1
+	}
+
+	auditLogger := audit.NewLogger(orchDB, cfg.Audit.MaxLogSizeBytes, cfg.Audit.PurgePercentage)
+	defer auditLogger.Stop()
+	if err := auditLogger.Log(constants.AuditActionAdminRecoveryIssued, constants.AuthRecoveryCLIAddress, "", audit.AdminRecoveryIssuedDetails{
+		TokenPrefix: auth.ExtractTokenPrefix(token),
+		ExpiresAt:   expiresAt,
+	}); err != nil {
+		log.Error("Failed to write audit entry: %v", err)
+		return 1
+	}
+
+	log.Warn("Auth: BREAK-GLASS recovery token issued for bootstrap user '%s' (expires %s)",
+		admin.Username, time.Unix(expiresAt, 0).Format(constants.LogTimestampFormat))
+
+	fmt.Println("╔══════════════════════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                       BREAK-GLASS ADMIN RECOVERY TOKEN                       ║")
+	fmt.Println("║  Single use. Anyone holding it can take over the admin account.              ║")
+	fmt.Println("╠══════════════════════════════════════════════════════════════════════════════╣")
+	fmt.Printf("║  Token   : %-66s║\n", token)
+	fmt.Printf("║  User    : %-66s║\n", admin.Username)
+	fmt.Printf("║  Expires : %-66s║\n", time.Unix(expiresAt, 0).Format(constants.LogTimestampFormat))
+	fmt.Println("╚══════════════════════════════════════════════════════════════════════════════╝")
+	fmt.Println()
+	fmt.Println("Exchange it for new admin credentials with:")
+	fmt.Printf("  curl -X POST http://localhost:%d/api/auth/recover -d '{\"token\":\"%s\"}'\n", cfg.Port, token)
+	return 0
+}
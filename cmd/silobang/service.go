@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+	"silobang/internal/service"
+)
+
+const serviceUsage = `usage: silobang service <command> [flags]
+
+commands:
+  install    register and start silobang as a systemd unit or Windows service
+  uninstall  stop and remove the service
+  status     show whether the service is installed and running
+  run        serve under the service manager (used by installed services)`
+
+// runService implements "silobang service": registering the server with
+// the platform service manager. Installed services find their config in
+// the directory chosen at install time, by default the first of the
+// system-wide and per-user directories that holds a config file.
+func runService(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, serviceUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "install":
+		return runServiceInstall(args[1:])
+	case "uninstall":
+		return runServiceUninstall(args[1:])
+	case "status":
+		return runServiceStatus(args[1:])
+	case "run":
+		return runServiceRun(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown service command %q\n\n%s\n", args[0], serviceUsage)
+		return 2
+	}
+}
+
+func runServiceInstall(args []string) int {
+	fs := flag.NewFlagSet("service install", flag.ExitOnError)
+	name := fs.String("name", constants.ServiceName, "service name")
+	configDir := fs.String("config-dir", "", "config directory the service reads (default: discovered)")
+	workDir := fs.String("workdir", "", "process working directory (default: configured working directory, else the config directory)")
+	user := fs.String("user", "", "systemd only: account to run the service as (default: root)")
+	fs.Parse(args)
+
+	log := logger.NewLogger(constants.DefaultLogLevel)
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		log.Error("Failed to locate the silobang binary: %v", err)
+		return 1
+	}
+
+	dir := *configDir
+	if dir == "" {
+		dir = config.DiscoverConfigDir()
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		log.Error("Invalid config directory: %v", err)
+		return 1
+	}
+
+	wd := *workDir
+	if wd == "" {
+		wd = configuredWorkDir(dir)
+	}
+	if wd == "" {
+		wd = dir
+	}
+	if wd, err = filepath.Abs(wd); err != nil {
+		log.Error("Invalid working directory: %v", err)
+		return 1
+	}
+
+	cfg := service.Config{Name: *name, Executable: exe, ConfigDir: dir, WorkDir: wd, User: *user}
+	if err := service.Install(cfg); err != nil {
+		log.Error("Failed to install service: %v", err)
+		return 1
+	}
+	fmt.Printf("Installed service %s\n", cfg.Name)
+	fmt.Printf("  Config directory  : %s\n", cfg.ConfigDir)
+	fmt.Printf("  Working directory : %s\n", cfg.WorkDir)
+	return 0
+}
+
+// configuredWorkDir returns the working_directory of the config file in
+// dir, or "" when there is none. A missing config file is not created.
+func configuredWorkDir(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, constants.ConfigFile)); err != nil {
+		return ""
+	}
+	os.Setenv(constants.ServiceConfigDirEnv, dir)
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return ""
+	}
+	return cfg.WorkingDirectory
+}
+
+func runServiceUninstall(args []string) int {
+	fs := flag.NewFlagSet("service uninstall", flag.ExitOnError)
+	name := fs.String("name", constants.ServiceName, "service name")
+	fs.Parse(args)
+
+	log := logger.NewLogger(constants.DefaultLogLevel)
+
+	if err := service.Uninstall(*name); err != nil {
+		if errors.Is(err, service.ErrNotInstalled) {
+			log.Error("Service %s is not installed", *name)
+		} else {
+			log.Error("Failed to uninstall service: %v", err)
+		}
+		return 1
+	}
+	fmt.Printf("Uninstalled service %s\n", *name)
+	return 0
+}
+
+func runServiceStatus(args []string) int {
+	fs := flag.NewFlagSet("service status", flag.ExitOnError)
+	name := fs.String("name", constants.ServiceName, "service name")
+	fs.Parse(args)
+
+	log := logger.NewLogger(constants.DefaultLogLevel)
+
+	status, err := service.QueryStatus(*name)
+	if err != nil {
+		log.Error("Failed to query service: %v", err)
+		return 1
+	}
+	fmt.Printf("Service   : %s\n", status.Name)
+	fmt.Printf("Installed : %t\n", status.Installed)
+	fmt.Printf("State     : %s\n", status.State)
+	if status.Location != "" {
+		fmt.Printf("Location  : %s\n", status.Location)
+	}
+	return 0
+}
+
+// runServiceRun serves under the service manager with the directories
+// recorded at install time.
+func runServiceRun(args []string) int {
+	fs := flag.NewFlagSet("service run", flag.ExitOnError)
+	name := fs.String("name", constants.ServiceName, "service name")
+	configDir := fs.String("config-dir", "", "config directory (default: SILOBANG_CONFIG_DIR or the per-user directory)")
+	workDir := fs.String("workdir", "", "process working directory")
+	logFile := fs.String("log-file", "", "append server output to this file instead of stdout")
+	fs.Parse(args)
+
+	if *logFile != "" {
+		f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, constants.FilePermissions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open log file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		os.Stdout = f
+		os.Stderr = f
+	}
+	if *configDir != "" {
+		os.Setenv(constants.ServiceConfigDirEnv, *configDir)
+	}
+	if *workDir != "" {
+		if err := os.Chdir(*workDir); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to enter working directory: %v\n", err)
+			return 1
+		}
+	}
+
+	if err := service.Run(*name, serve); err != nil {
+		fmt.Fprintf(os.Stderr, "service error: %v\n", err)
+		return 1
+	}
+	return 0
+}
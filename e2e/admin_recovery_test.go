@@ -0,0 +1,129 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+type recoveryResponse struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	APIKey   string `json:"api_key"`
+}
+
+// issueRecoveryToken issues a token directly against the orchestrator DB,
+// as the recover-admin CLI command does.
+func (ts *TestServer) issueRecoveryToken(t *testing.T) string {
+	t.Helper()
+	store := auth.NewStore(ts.GetOrchestratorDB(t), constants.AuthMaxLoginAttempts,
+		constants.AuthLockoutDurationMins, constants.AuthSessionDuration)
+	token, _, err := auth.IssueRecoveryToken(store)
+	if err != nil {
+		t.Fatalf("failed to issue recovery token: %v", err)
+	}
+	return token
+}
+
+// TestAdminRecovery_ExchangeToken verifies a recovery token yields working
+// admin credentials exactly once and revokes the old API key.
+func TestAdminRecovery_ExchangeToken(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	oldKey := ts.APIKey
+
+	token := ts.issueRecoveryToken(t)
+
+	resp, err := ts.UnauthenticatedPOST("/api/auth/recover", map[string]string{"token": token})
+	if err != nil {
+		t.Fatalf("recover request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var creds recoveryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&creds); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if creds.Username != constants.AuthBootstrapUsername || creds.APIKey == "" || creds.Password == "" {
+		t.Fatalf("unexpected credentials: %+v", creds)
+	}
+
+	// Old key is revoked, new key and password work
+	resp, err = ts.RequestWithAPIKey(http.MethodGet, "/api/auth/me", oldKey, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected old API key to be rejected, got %d", resp.StatusCode)
+	}
+
+	resp, err = ts.RequestWithAPIKey(http.MethodGet, "/api/auth/me", creds.APIKey, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected new API key to work, got %d", resp.StatusCode)
+	}
+	ts.LoginUser(t, creds.Username, creds.Password)
+
+	// Token is single use
+	resp, err = ts.UnauthenticatedPOST("/api/auth/recover", map[string]string{"token": token})
+	if err != nil {
+		t.Fatalf("recover request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 on token reuse, got %d", resp.StatusCode)
+	}
+
+	// Both the exchange and the rejected reuse are audited
+	ts.APIKey = creds.APIKey
+	for _, action := range []string{constants.AuditActionAdminRecovered, constants.AuditActionAdminRecoveryFailed} {
+		var result AuditQueryResponse
+		if err := ts.GetJSON("/api/audit?action="+action, &result); err != nil {
+			t.Fatalf("failed to query audit: %v", err)
+		}
+		if len(result.Entries) != 1 {
+			t.Errorf("expected 1 %s entry, got %d", action, len(result.Entries))
+		}
+	}
+}
+
+// TestAdminRecovery_InvalidToken verifies unknown tokens are rejected and
+// leave the admin credentials untouched.
+func TestAdminRecovery_InvalidToken(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	resp, err := ts.UnauthenticatedPOST("/api/auth/recover", map[string]string{"token": constants.RecoveryTokenPrefix + "bogus"})
+	if err != nil {
+		t.Fatalf("recover request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+	var errResp ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	if errResp.Code != constants.ErrCodeAuthRecoveryInvalid {
+		t.Errorf("expected code %s, got %s", constants.ErrCodeAuthRecoveryInvalid, errResp.Code)
+	}
+
+	// Admin key still works
+	resp, err = ts.GET("/api/auth/me")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected admin key to still work, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,171 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+type apiKeyResponse struct {
+	ID         int64           `json:"id"`
+	Label      string          `json:"label"`
+	Primary    bool            `json:"primary"`
+	Key        string          `json:"key"`
+	KeyPrefix  string          `json:"key_prefix"`
+	ExpiresAt  *int64          `json:"expires_at"`
+	Status     string          `json:"status"`
+	Previous   *apiKeyResponse `json:"previous"`
+	GraceUntil int64           `json:"grace_until"`
+}
+
+// apiKeyRequest sends a request authenticated with apiKey and decodes the
+// response into target, returning the status code.
+func (ts *TestServer) apiKeyRequest(t *testing.T, method, path, apiKey string, body, target interface{}) int {
+	t.Helper()
+	resp, err := ts.RequestWithAPIKey(method, path, apiKey, body)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if target != nil {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		json.Unmarshal(bodyBytes, target)
+	}
+	return resp.StatusCode
+}
+
+// TestAPIKeys_LabelledKeysAndRotation covers issuing labelled keys, the
+// expiry header, grace-period rotation and the distinct error code of an
+// expired key.
+func TestAPIKeys_LabelledKeysAndRotation(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	user := ts.CreateTestUser(t, "keyholder", "secure-password-12345")
+
+	var created apiKeyResponse
+	status := ts.apiKeyRequest(t, http.MethodPost, "/api/auth/me/api-keys", user.APIKey,
+		map[string]interface{}{"label": "build-server", "ttl_days": 30}, &created)
+	if status != http.StatusCreated || created.Key == "" || created.ExpiresAt == nil {
+		t.Fatalf("create failed: %d %+v", status, created)
+	}
+	if *created.ExpiresAt < time.Now().Add(29*24*time.Hour).Unix() {
+		t.Errorf("expected expiry about 30 days out, got %d", *created.ExpiresAt)
+	}
+
+	// The new key authenticates as the user and reports its expiry
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/auth/me", created.Key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 with the labelled key, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get(constants.HeaderAPIKeyExpiresAt); got != fmt.Sprint(*created.ExpiresAt) {
+		t.Errorf("expected %s %d, got %q", constants.HeaderAPIKeyExpiresAt, *created.ExpiresAt, got)
+	}
+
+	// Rotation issues a new key; the old one works until the grace ends
+	var rotated apiKeyResponse
+	status = ts.apiKeyRequest(t, http.MethodPost, fmt.Sprintf("/api/auth/me/api-keys/%d/rotate", created.ID), user.APIKey,
+		map[string]interface{}{"grace_secs": 3600}, &rotated)
+	if status != http.StatusOK || rotated.Key == "" || rotated.Previous == nil || rotated.Previous.ID != created.ID {
+		t.Fatalf("rotate failed: %d %+v", status, rotated)
+	}
+	if rotated.GraceUntil > time.Now().Add(time.Hour+time.Minute).Unix() {
+		t.Errorf("expected the grace to end within an hour, got %d", rotated.GraceUntil)
+	}
+	for _, key := range []string{created.Key, rotated.Key} {
+		if status := ts.apiKeyRequest(t, http.MethodGet, "/api/auth/me", key, nil, nil); status != http.StatusOK {
+			t.Errorf("expected 200 during the grace period, got %d", status)
+		}
+	}
+	if status := ts.apiKeyRequest(t, http.MethodPost, fmt.Sprintf("/api/auth/me/api-keys/%d/rotate", created.ID), user.APIKey, nil, nil); status != http.StatusNotFound {
+		t.Errorf("expected 404 rotating a key twice, got %d", status)
+	}
+
+	// Once the grace is over the old key is refused as expired
+	if _, err := ts.GetOrchestratorDB(t).Exec(`UPDATE auth_api_keys SET expires_at = ? WHERE id = ?`,
+		time.Now().Add(-time.Minute).Unix(), created.ID); err != nil {
+		t.Fatal(err)
+	}
+	var errResp ErrorResponse
+	if status := ts.apiKeyRequest(t, http.MethodGet, "/api/auth/me", created.Key, nil, &errResp); status != http.StatusUnauthorized || errResp.Code != constants.ErrCodeAuthAPIKeyExpired {
+		t.Errorf("expected 401 %s, got %d %s", constants.ErrCodeAuthAPIKeyExpired, status, errResp.Code)
+	}
+
+	// Revocation takes effect at once and is listed
+	if status := ts.apiKeyRequest(t, http.MethodDelete, fmt.Sprintf("/api/auth/me/api-keys/%d", rotated.ID), user.APIKey, nil, nil); status != http.StatusOK {
+		t.Fatalf("revoke failed: %d", status)
+	}
+	errResp = ErrorResponse{}
+	if status := ts.apiKeyRequest(t, http.MethodGet, "/api/auth/me", rotated.Key, nil, &errResp); status != http.StatusUnauthorized || errResp.Code != constants.ErrCodeAuthRequired {
+		t.Errorf("expected 401 %s with a revoked key, got %d %s", constants.ErrCodeAuthRequired, status, errResp.Code)
+	}
+
+	var list struct {
+		APIKeys []apiKeyResponse `json:"api_keys"`
+	}
+	ts.apiKeyRequest(t, http.MethodGet, "/api/auth/me/api-keys", user.APIKey, nil, &list)
+	statuses := map[string]string{}
+	for _, k := range list.APIKeys {
+		if k.Key != "" {
+			t.Error("the plaintext key must not be listed")
+		}
+		statuses[fmt.Sprintf("%s/%d", k.Label, k.ID)] = k.Status
+	}
+	want := map[string]string{
+		"primary/0": constants.AuthAPIKeyStatusActive,
+		fmt.Sprintf("build-server/%d", created.ID): constants.AuthAPIKeyStatusExpired,
+		fmt.Sprintf("build-server/%d", rotated.ID): constants.AuthAPIKeyStatusRevoked,
+	}
+	if fmt.Sprint(statuses) != fmt.Sprint(want) {
+		t.Errorf("expected keys %v, got %v", want, statuses)
+	}
+}
+
+// TestAPIKeys_RotatePrimary verifies rotating the key on the account keeps
+// the old key for the grace period and that admins manage other users'
+// keys.
+func TestAPIKeys_RotatePrimary(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	user := ts.CreateTestUser(t, "rotator", "secure-password-12345")
+
+	var rotated apiKeyResponse
+	status := ts.apiKeyRequest(t, http.MethodPost, fmt.Sprintf("/api/auth/users/%d/api-keys/primary/rotate", user.ID), ts.APIKey,
+		map[string]interface{}{"ttl_days": 7}, &rotated)
+	if status != http.StatusOK || !rotated.Primary || rotated.ExpiresAt == nil || rotated.Previous == nil {
+		t.Fatalf("rotate failed: %d %+v", status, rotated)
+	}
+	for _, key := range []string{user.APIKey, rotated.Key} {
+		if status := ts.apiKeyRequest(t, http.MethodGet, "/api/auth/me", key, nil, nil); status != http.StatusOK {
+			t.Errorf("expected 200 during the grace period, got %d", status)
+		}
+	}
+
+	// The key on the account cannot be revoked, only rotated
+	if status := ts.apiKeyRequest(t, http.MethodDelete, "/api/auth/me/api-keys/primary", rotated.Key, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("expected 400 revoking the key on the account, got %d", status)
+	}
+
+	// Without manage_users, other users' keys are off limits
+	if status := ts.apiKeyRequest(t, http.MethodGet, "/api/auth/users/1/api-keys", rotated.Key, nil, nil); status != http.StatusForbidden {
+		t.Errorf("expected 403 listing another user's keys, got %d", status)
+	}
+
+	// Expired keys on the account are refused with the distinct code too
+	if _, err := ts.GetOrchestratorDB(t).Exec(`UPDATE auth_users SET api_key_expires_at = ? WHERE id = ?`,
+		time.Now().Add(-time.Minute).Unix(), user.ID); err != nil {
+		t.Fatal(err)
+	}
+	var errResp ErrorResponse
+	if status := ts.apiKeyRequest(t, http.MethodGet, "/api/auth/me", rotated.Key, nil, &errResp); status != http.StatusUnauthorized || errResp.Code != constants.ErrCodeAuthAPIKeyExpired {
+		t.Errorf("expected 401 %s, got %d %s", constants.ErrCodeAuthAPIKeyExpired, status, errResp.Code)
+	}
+}
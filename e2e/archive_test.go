@@ -0,0 +1,179 @@
+package e2e
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+// archiveResult mirrors the response of POST /api/topics/:name/archive.
+type archiveResult struct {
+	Archive string   `json:"archive"`
+	DryRun  bool     `json:"dry_run"`
+	Hashes  []string `json:"hashes"`
+	Bytes   int64    `json:"bytes"`
+}
+
+// recallResult mirrors the response of POST /api/assets/:hash/recall.
+type recallResult struct {
+	Status      string `json:"status"`
+	RecallError string `json:"recall_error"`
+}
+
+// TestArchive_StubAndRecall verifies an idle asset is moved to a filesystem
+// archive and replaced by a stub that refuses downloads, that compaction
+// reclaims its entry, and that a recall waits for the file and brings the
+// asset back.
+func TestArchive_StubAndRecall(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "footage")
+
+	content := []byte("raw footage nobody watched")
+	idle := ts.UploadFileExpectSuccess(t, "footage", "idle.bin", content, "")
+	recent := ts.UploadFileExpectSuccess(t, "footage", "recent.bin", []byte("fresh footage"), "")
+	old := time.Now().AddDate(-2, 0, 0).Unix()
+	if _, err := ts.GetTopicDB(t, "footage").Exec("UPDATE assets SET created_at = ? WHERE asset_id = ?", old, idle.Hash); err != nil {
+		t.Fatalf("failed to backdate asset: %v", err)
+	}
+
+	archiveDir := t.TempDir()
+	ts.App.Config.Archives = map[string]config.PluginConfig{
+		"tape": {Type: constants.PluginArchiveFS, Options: map[string]string{"path": archiveDir}},
+	}
+	ts.App.Config.TopicArchive = map[string]config.ArchivePolicy{"footage": {Archive: "tape", IdleDays: 365}}
+
+	var dryRun archiveResult
+	if err := ts.PostJSON("/api/topics/footage/archive", map[string]bool{"dry_run": true}, &dryRun); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if !dryRun.DryRun || len(dryRun.Hashes) != 1 || dryRun.Hashes[0] != idle.Hash {
+		t.Fatalf("unexpected dry run result: %+v", dryRun)
+	}
+
+	var archived archiveResult
+	if err := ts.PostJSON("/api/topics/footage/archive", nil, &archived); err != nil {
+		t.Fatalf("archive pass failed: %v", err)
+	}
+	if archived.DryRun || archived.Archive != "tape" || len(archived.Hashes) != 1 || archived.Bytes != int64(len(content)) {
+		t.Fatalf("unexpected archive result: %+v", archived)
+	}
+	archivedPath := filepath.Join(archiveDir, "footage", idle.Hash)
+	if data, err := os.ReadFile(archivedPath); err != nil || !bytes.Equal(data, content) {
+		t.Fatalf("expected the asset in the archive, got %q, %v", data, err)
+	}
+
+	if resp := ts.DownloadAssetExpectError(t, idle.Hash, http.StatusConflict); resp.Code != constants.ErrCodeRetrievalRequired {
+		t.Errorf("expected RETRIEVAL_REQUIRED, got %+v", resp)
+	}
+	if !bytes.Equal(ts.DownloadAsset(t, recent.Hash), []byte("fresh footage")) {
+		t.Error("recent asset should still be served")
+	}
+
+	var listed struct {
+		Assets []struct {
+			Hash   string `json:"hash"`
+			Status string `json:"status"`
+		} `json:"assets"`
+	}
+	if err := ts.GetJSON("/api/topics/footage/archive", &listed); err != nil {
+		t.Fatalf("list archive failed: %v", err)
+	}
+	if len(listed.Assets) != 1 || listed.Assets[0].Hash != idle.Hash || listed.Assets[0].Status != constants.ArchiveStatusArchived {
+		t.Fatalf("unexpected archived assets: %+v", listed)
+	}
+
+	// The stub's .dat entry is reclaimed like a deleted one
+	status := ts.compactTopic(t, "footage")
+	if status.LastResult.Error != "" || status.LastResult.BytesReclaimed != int64(constants.HeaderSize+len(content)) {
+		t.Fatalf("unexpected compaction result: %+v", status.LastResult)
+	}
+
+	// The archive's tooling moved the file away: the recall leaves a marker
+	// and waits
+	staged := filepath.Join(t.TempDir(), idle.Hash)
+	if err := os.Rename(archivedPath, staged); err != nil {
+		t.Fatalf("failed to move archived file: %v", err)
+	}
+	var recall recallResult
+	if err := ts.PostJSON("/api/assets/"+idle.Hash+"/recall", nil, &recall); err != nil {
+		t.Fatalf("recall failed: %v", err)
+	}
+	if recall.Status != constants.ArchiveStatusRecalling {
+		t.Fatalf("expected the recall to wait for the file, got %+v", recall)
+	}
+	if _, err := os.Stat(archivedPath + constants.ArchiveFSRecallSuffix); err != nil {
+		t.Errorf("expected a recall marker: %v", err)
+	}
+	ts.DownloadAssetExpectError(t, idle.Hash, http.StatusConflict)
+
+	// Once the file is back the asset is written into the topic again
+	if err := os.Rename(staged, archivedPath); err != nil {
+		t.Fatalf("failed to restore archived file: %v", err)
+	}
+	ts.App.Services.Archive.AdvanceRecalls()
+	if !bytes.Equal(ts.DownloadAsset(t, idle.Hash), content) {
+		t.Error("recalled asset content mismatch")
+	}
+	if _, err := os.Stat(archivedPath); !os.IsNotExist(err) {
+		t.Errorf("expected the archived copy removed after the recall, got %v", err)
+	}
+	resp, err := ts.POST("/api/assets/"+idle.Hash+"/recall", nil)
+	if err != nil {
+		t.Fatalf("recall request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected 409 for a recall of a stored asset, got %d", resp.StatusCode)
+	}
+
+	// The dry run is audited too
+	for action, want := range map[string]int{
+		constants.AuditActionAssetsArchived:       2,
+		constants.AuditActionAssetRecallRequested: 1,
+		constants.AuditActionAssetRecalled:        1,
+	} {
+		if n := ts.countAudit(t, action); n != want {
+			t.Errorf("expected %d %s entries, got %d", want, action, n)
+		}
+	}
+}
+
+// TestArchive_DeleteStub verifies deleting an archived asset also removes
+// its archived copy.
+func TestArchive_DeleteStub(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "footage")
+
+	asset := ts.UploadFileExpectSuccess(t, "footage", "idle.bin", []byte("to be archived"), "")
+	if _, err := ts.GetTopicDB(t, "footage").Exec("UPDATE assets SET created_at = 1"); err != nil {
+		t.Fatalf("failed to backdate asset: %v", err)
+	}
+	archiveDir := t.TempDir()
+	ts.App.Config.Archives = map[string]config.PluginConfig{
+		"tape": {Type: constants.PluginArchiveFS, Options: map[string]string{"path": archiveDir}},
+	}
+	ts.App.Config.TopicArchive = map[string]config.ArchivePolicy{"footage": {Archive: "tape", IdleDays: 30}}
+
+	var archived archiveResult
+	if err := ts.PostJSON("/api/topics/footage/archive", nil, &archived); err != nil || len(archived.Hashes) != 1 {
+		t.Fatalf("archive pass failed: %+v, %v", archived, err)
+	}
+
+	if status, body := ts.deleteAsset(t, asset.Hash); status != http.StatusOK {
+		t.Fatalf("delete failed: %d %s", status, body)
+	}
+	if _, err := os.Stat(filepath.Join(archiveDir, "footage", asset.Hash)); !os.IsNotExist(err) {
+		t.Errorf("expected the archived copy removed, got %v", err)
+	}
+	if status := ts.compactionStatus(t, "footage"); status.Tombstones != 1 {
+		t.Errorf("expected only the archived entry tombstoned, got %d", status.Tombstones)
+	}
+}
@@ -0,0 +1,121 @@
+package e2e
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+type assetsByNameResponse struct {
+	Count  int `json:"count"`
+	Assets []struct {
+		Hash       string `json:"hash"`
+		OriginName string `json:"origin_name"`
+		Extension  string `json:"extension"`
+		CreatedAt  int64  `json:"created_at"`
+	} `json:"assets"`
+}
+
+// TestAssetsByName_VersionsAndLatest verifies assets are found by filename
+// or origin name, newest first, and that latest=true downloads the newest.
+func TestAssetsByName_VersionsAndLatest(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+	ts.CreateTopic(t, "other")
+
+	v1 := ts.UploadFileExpectSuccess(t, "models", "robot.glb", []byte("robot v1"), "").Hash
+	v2 := ts.UploadFileExpectSuccess(t, "models", "robot.glb", []byte("robot v2"), "").Hash
+	texture := ts.UploadFileExpectSuccess(t, "models", "robot.png", []byte("robot texture"), "").Hash
+	ts.UploadFileExpectSuccess(t, "other", "robot.glb", []byte("robot elsewhere"), "")
+
+	db := ts.GetTopicDB(t, "models")
+	for hash, createdAt := range map[string]int{v1: 1000, v2: 2000, texture: 1500} {
+		if _, err := db.Exec(`UPDATE assets SET created_at = ? WHERE asset_id = ?`, createdAt, hash); err != nil {
+			t.Fatalf("failed to set created_at: %v", err)
+		}
+	}
+
+	var byFilename assetsByNameResponse
+	if err := ts.GetJSON("/api/topics/models/assets/by-name/robot.glb", &byFilename); err != nil {
+		t.Fatalf("lookup by filename failed: %v", err)
+	}
+	if byFilename.Count != 2 || byFilename.Assets[0].Hash != v2 || byFilename.Assets[1].Hash != v1 {
+		t.Fatalf("expected v2 then v1, got %+v", byFilename)
+	}
+
+	var byOrigin assetsByNameResponse
+	if err := ts.GetJSON("/api/topics/models/assets/by-name/robot", &byOrigin); err != nil {
+		t.Fatalf("lookup by origin name failed: %v", err)
+	}
+	if byOrigin.Count != 3 || byOrigin.Assets[1].Hash != texture {
+		t.Errorf("expected both versions and the texture, newest first, got %+v", byOrigin)
+	}
+
+	var none assetsByNameResponse
+	if err := ts.GetJSON("/api/topics/models/assets/by-name/missing.glb", &none); err != nil || none.Count != 0 {
+		t.Errorf("expected no matches, got %+v, %v", none, err)
+	}
+
+	resp, err := ts.GET("/api/topics/models/assets/by-name/robot.glb?latest=true")
+	if err != nil {
+		t.Fatalf("latest download failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "robot v2" {
+		t.Errorf("expected the newest version, got %d %q", resp.StatusCode, body)
+	}
+
+	// A quarantined newest version is skipped
+	if status, body := ts.postAsset(t, v2, "quarantine", map[string]string{"reason": "broken export"}); status != http.StatusOK {
+		t.Fatalf("quarantine failed: %d %s", status, body)
+	}
+	resp, err = ts.GET("/api/topics/models/assets/by-name/robot.glb?latest=true")
+	if err != nil {
+		t.Fatalf("latest download failed: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "robot v1" {
+		t.Errorf("expected the newest unquarantined version, got %q", body)
+	}
+
+	resp, err = ts.GET("/api/topics/models/assets/by-name/missing.glb?latest=true")
+	if err != nil {
+		t.Fatalf("latest request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("latest without a match: expected 404, got %d", resp.StatusCode)
+	}
+}
+
+// TestAssetsByName_RequiresTopicGrant verifies lookups honour the topic
+// constraints of the query and download grants.
+func TestAssetsByName_RequiresTopicGrant(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+	ts.UploadFileExpectSuccess(t, "models", "robot.glb", []byte("robot"), "")
+
+	user := ts.CreateTestUserWithGrants(t, "downloader", "downloader-pass-123", []map[string]interface{}{
+		{"action": constants.AuthActionDownload, "constraints": map[string]interface{}{"allowed_topics": []string{"models"}}},
+	})
+
+	for path, status := range map[string]int{
+		"/api/topics/models/assets/by-name/robot.glb":             http.StatusForbidden,
+		"/api/topics/models/assets/by-name/robot.glb?latest=true": http.StatusOK,
+	} {
+		resp, err := ts.RequestWithAPIKey(http.MethodGet, path, user.APIKey, nil)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("%s: expected %d, got %d", path, status, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package e2e
+
+import (
+	"bytes"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// TestAssetCache_RepeatDownloadsServedFromMemory verifies small assets are
+// cached after the first download and counted in monitoring.
+func TestAssetCache_RepeatDownloadsServedFromMemory(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "thumbs")
+
+	content := []byte(`{"width":128,"height":128}`)
+	upload := ts.UploadFileExpectSuccess(t, "thumbs", "thumb.json", content, "")
+
+	for i := 0; i < 3; i++ {
+		if got := ts.DownloadAsset(t, upload.Hash); !bytes.Equal(got, content) {
+			t.Fatalf("download %d returned wrong content: %q", i, got)
+		}
+	}
+
+	mon := ts.GetMonitoring(t)
+	if mon.AssetCache == nil {
+		t.Fatal("expected asset_cache in monitoring response")
+	}
+	if !mon.AssetCache.Enabled {
+		t.Error("expected asset cache to be enabled by default")
+	}
+	if mon.AssetCache.Entries != 1 || mon.AssetCache.Bytes != int64(len(content)) {
+		t.Errorf("expected one cached asset of %d bytes, got %+v", len(content), mon.AssetCache)
+	}
+	if mon.AssetCache.Hits != 2 || mon.AssetCache.Misses != 1 {
+		t.Errorf("expected 2 hits and 1 miss, got %+v", mon.AssetCache)
+	}
+	if mon.AssetCache.MaxAssetBytes != constants.AssetCacheDefaultMaxAssetBytes {
+		t.Errorf("expected default max_asset_bytes, got %d", mon.AssetCache.MaxAssetBytes)
+	}
+}
+
+// TestAssetCache_LargeAssetsStreamedFromDisk verifies assets over the
+// per-asset limit are never held in memory.
+func TestAssetCache_LargeAssetsStreamedFromDisk(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "renders")
+
+	content := bytes.Repeat([]byte("r"), constants.AssetCacheDefaultMaxAssetBytes+1)
+	upload := ts.UploadFileExpectSuccess(t, "renders", "render.bin", content, "")
+
+	for i := 0; i < 2; i++ {
+		if got := ts.DownloadAsset(t, upload.Hash); !bytes.Equal(got, content) {
+			t.Fatalf("download %d returned wrong content", i)
+		}
+	}
+
+	mon := ts.GetMonitoring(t)
+	if mon.AssetCache == nil {
+		t.Fatal("expected asset_cache in monitoring response")
+	}
+	if mon.AssetCache.Entries != 0 || mon.AssetCache.Hits != 0 {
+		t.Errorf("large asset should not be cached, got %+v", mon.AssetCache)
+	}
+}
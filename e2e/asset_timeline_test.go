@@ -0,0 +1,131 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// assetTimeline mirrors the response of GET /api/assets/:hash/timeline.
+type assetTimeline struct {
+	Hash   string `json:"hash"`
+	Topic  string `json:"topic"`
+	Events []struct {
+		Timestamp int64  `json:"timestamp"`
+		Kind      string `json:"kind"`
+		Action    string `json:"action"`
+		Actor     *struct {
+			Username  string `json:"username"`
+			Processor string `json:"processor"`
+		} `json:"actor"`
+	} `json:"events"`
+	Total         int64 `json:"total"`
+	AuditIncluded bool  `json:"audit_included"`
+}
+
+// TestAssetTimeline_MergesHistory verifies uploads, metadata changes,
+// downloads and derived assets appear in one feed, newest first.
+func TestAssetTimeline_MergesHistory(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "art")
+
+	parent := ts.UploadFileExpectSuccess(t, "art", "parent.png", []byte("timeline parent"), "")
+	ts.SetMetadata(t, parent.Hash, "artist", "alice")
+	ts.SetMetadata(t, parent.Hash, "artist", "bob")
+	ts.DownloadAsset(t, parent.Hash)
+	ts.DownloadAsset(t, parent.Hash)
+	ts.UploadFileExpectSuccess(t, "art", "child.png", []byte("timeline child"), parent.Hash)
+
+	var timeline assetTimeline
+	if err := ts.GetJSON("/api/assets/"+parent.Hash+"/timeline", &timeline); err != nil {
+		t.Fatalf("timeline request failed: %v", err)
+	}
+	if timeline.Hash != parent.Hash || timeline.Topic != "art" || !timeline.AuditIncluded {
+		t.Fatalf("unexpected timeline header: %+v", timeline)
+	}
+
+	counts := map[string]int{}
+	for _, event := range timeline.Events {
+		counts[event.Kind]++
+	}
+	want := map[string]int{"upload": 1, "metadata": 2, "download": 2, "derived": 1}
+	for kind, n := range want {
+		if counts[kind] != n {
+			t.Errorf("expected %d %s events, got %d (%v)", n, kind, counts[kind], counts)
+		}
+	}
+	if timeline.Total != int64(len(timeline.Events)) {
+		t.Errorf("expected total %d, got %d", len(timeline.Events), timeline.Total)
+	}
+
+	last := timeline.Events[len(timeline.Events)-1]
+	if last.Kind != "upload" {
+		t.Errorf("expected the upload to be the oldest event, got %s", last.Kind)
+	}
+	for i := 1; i < len(timeline.Events); i++ {
+		if timeline.Events[i].Timestamp > timeline.Events[i-1].Timestamp {
+			t.Fatalf("events not newest first at %d", i)
+		}
+	}
+	for _, event := range timeline.Events {
+		if event.Kind == "metadata" && (event.Actor == nil || event.Actor.Processor != "test" || event.Actor.Username == "") {
+			t.Errorf("metadata event should name the processor and user: %+v", event.Actor)
+		}
+	}
+
+	var downloads assetTimeline
+	if err := ts.GetJSON("/api/assets/"+parent.Hash+"/timeline?kind=download", &downloads); err != nil {
+		t.Fatalf("timeline request failed: %v", err)
+	}
+	if len(downloads.Events) != 2 || downloads.Total != 2 {
+		t.Fatalf("expected 2 download events, got %d (total %d)", len(downloads.Events), downloads.Total)
+	}
+	if downloads.Events[0].Actor == nil || downloads.Events[0].Actor.Username == "" {
+		t.Errorf("download event should name the user")
+	}
+
+	// Pages concatenate to the full feed
+	var paged []string
+	for offset := 0; offset < len(timeline.Events); offset += 3 {
+		var page assetTimeline
+		if err := ts.GetJSON(fmt.Sprintf("/api/assets/%s/timeline?limit=3&offset=%d", parent.Hash, offset), &page); err != nil {
+			t.Fatalf("timeline page request failed: %v", err)
+		}
+		for _, event := range page.Events {
+			paged = append(paged, event.Kind+event.Action)
+		}
+	}
+	if len(paged) != len(timeline.Events) {
+		t.Fatalf("expected %d paged events, got %d", len(timeline.Events), len(paged))
+	}
+	for i, event := range timeline.Events {
+		if paged[i] != event.Kind+event.Action {
+			t.Errorf("page order differs at %d: %s vs %s", i, paged[i], event.Kind+event.Action)
+		}
+	}
+}
+
+// TestAssetTimeline_Errors verifies invalid filters and unknown assets are
+// rejected.
+func TestAssetTimeline_Errors(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "art")
+	asset := ts.UploadFileExpectSuccess(t, "art", "a.png", []byte("a"), "")
+
+	cases := map[string]int{
+		"/api/assets/" + asset.Hash + "/timeline?kind=bogus":   http.StatusBadRequest,
+		"/api/assets/" + fmt.Sprintf("%064x", 1) + "/timeline": http.StatusNotFound,
+	}
+	for path, status := range cases {
+		resp, err := ts.GET(path)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("%s: expected %d, got %d", path, status, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package e2e
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+)
+
+// exportAudit fetches /api/audit/export with the given query and API key.
+func (ts *TestServer) exportAudit(t *testing.T, query, apiKey string) (int, http.Header, []byte) {
+	t.Helper()
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/audit/export?"+query, apiKey, nil)
+	if err != nil {
+		t.Fatalf("export request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header, body
+}
+
+// TestAuditExport_FormatsAndFilters checks the export streams every matching
+// entry past the query page size, in both formats, and is itself audited.
+func TestAuditExport_FormatsAndFilters(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	total := constants.AuditMaxQueryLimit + 5
+	for i := 0; i < total; i++ {
+		ts.App.AuditLogger.Log(constants.AuditActionLogout, "10.0.0.1", "admin", nil)
+	}
+
+	status, header, body := ts.exportAudit(t, "action=logout", ts.APIKey)
+	if status != http.StatusOK || header.Get("Content-Type") != constants.AuditExportJSONLMIME {
+		t.Fatalf("expected a JSONL export, got %d %s: %s", status, header.Get("Content-Type"), body)
+	}
+	if !strings.Contains(header.Get("Content-Disposition"), ".jsonl") {
+		t.Errorf("expected a .jsonl attachment, got %q", header.Get("Content-Disposition"))
+	}
+	lines := 0
+	var lastID int64
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	for scanner.Scan() {
+		var entry audit.Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		if entry.Action != constants.AuditActionLogout || entry.ID <= lastID {
+			t.Fatalf("expected logout entries oldest first, got %+v after %d", entry, lastID)
+		}
+		lastID = entry.ID
+		lines++
+	}
+	if lines != total {
+		t.Errorf("expected %d entries, got %d", total, lines)
+	}
+
+	status, header, body = ts.exportAudit(t, "format=csv&action=logout&limit=1", ts.APIKey)
+	if status != http.StatusOK || header.Get("Content-Type") != constants.AuditExportCSVMIME {
+		t.Fatalf("expected a CSV export, got %d: %s", status, body)
+	}
+	records, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV does not parse: %v", err)
+	}
+	if len(records) != total+1 || records[0][0] != "id" || records[1][2] != constants.AuditActionLogout {
+		t.Errorf("expected a header and %d rows, got %d rows", total, len(records))
+	}
+
+	if status, _, _ := ts.exportAudit(t, "format=xml", ts.APIKey); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", status)
+	}
+
+	ts.App.AuditLogger.Flush()
+	var exported int
+	if err := ts.App.OrchestratorDB.QueryRow(`SELECT COUNT(*) FROM audit_log WHERE action = ?`,
+		constants.AuditActionAuditExported).Scan(&exported); err != nil || exported != 2 {
+		t.Errorf("expected 2 audit_exported entries, got %d (%v)", exported, err)
+	}
+}
+
+// TestAuditExport_Access checks view_audit is required, and that callers
+// without can_view_all only export their own entries.
+func TestAuditExport_Access(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "export-access")
+
+	viewer := ts.CreateTestUserWithGrants(t, "viewer-own", "ViewerPass123!", []map[string]interface{}{
+		{"action": constants.AuthActionViewAudit, "constraints_json": `{"can_view_all": false}`},
+	})
+	ts.App.AuditLogger.Log(constants.AuditActionLogout, "10.0.0.2", viewer.Username, nil)
+
+	status, _, body := ts.exportAudit(t, "format=jsonl&filter=others", viewer.APIKey)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	lines := 0
+	for scanner.Scan() {
+		var entry audit.Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid JSON line: %v", err)
+		}
+		if entry.Username != viewer.Username {
+			t.Errorf("expected only the viewer's entries, got one by %q", entry.Username)
+		}
+		lines++
+	}
+	if lines == 0 {
+		t.Error("expected the viewer's own entries")
+	}
+
+	other := ts.CreateTestUserWithGrants(t, "no-audit", "NoAuditPass123!", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	if status, _, _ := ts.exportAudit(t, "", other.APIKey); status != http.StatusForbidden {
+		t.Errorf("expected 403 without view_audit, got %d", status)
+	}
+}
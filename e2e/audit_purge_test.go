@@ -0,0 +1,139 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+)
+
+// PurgeReportResponse mirrors audit.PurgeReport
+type PurgeReportResponse struct {
+	Trigger      string             `json:"trigger"`
+	DryRun       bool               `json:"dry_run"`
+	Deleted      int64              `json:"deleted"`
+	HeldEntries  int64              `json:"held_entries"`
+	SizeLimitHit bool               `json:"size_limit_hit"`
+	Ranges       []audit.PurgeRange `json:"ranges"`
+}
+
+// TestAuditPurge_PreviewHoldsAndRun covers retention overrides, the preview,
+// legal holds and the audit record of a manual purge.
+func TestAuditPurge_PreviewHoldsAndRun(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.App.AuditLogger.SetRetention(audit.RetentionPolicy{
+		DefaultDays: 90,
+		ActionDays:  map[string]int{constants.AuditActionLoginSuccess: 730},
+	})
+
+	// Backdate entries: two old downloads (one held), an old login within its retention
+	orchDB := ts.GetOrchestratorDB(t)
+	old := time.Now().Add(-120 * 24 * time.Hour).Unix()
+	for _, e := range []struct{ action, username string }{
+		{constants.AuditActionDownloaded, "alice"},
+		{constants.AuditActionDownloaded, "bob"},
+		{constants.AuditActionLoginSuccess, "alice"},
+	} {
+		if _, err := orchDB.Exec(`INSERT INTO audit_log (timestamp, action, ip_address, username) VALUES (?, ?, '127.0.0.1', ?)`,
+			old, e.action, e.username); err != nil {
+			t.Fatalf("failed to insert entry: %v", err)
+		}
+	}
+
+	var hold audit.LegalHold
+	if err := ts.PostJSON("/api/audit/holds", map[string]interface{}{
+		"name": "case-42", "reason": "litigation", "username": "bob",
+	}, &hold); err != nil || hold.ID == 0 {
+		t.Fatalf("failed to create hold: %v %+v", err, hold)
+	}
+
+	resp, _ := ts.POST("/api/audit/holds", map[string]interface{}{"name": "", "action": "nope"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid hold, got %d", resp.StatusCode)
+	}
+
+	var preview PurgeReportResponse
+	if err := ts.GetJSON("/api/audit/purge/preview", &preview); err != nil {
+		t.Fatalf("preview failed: %v", err)
+	}
+	if !preview.DryRun || preview.Deleted != 1 || preview.HeldEntries != 1 {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+
+	var report PurgeReportResponse
+	if err := ts.PostJSON("/api/audit/purge", nil, &report); err != nil {
+		t.Fatalf("purge failed: %v", err)
+	}
+	if report.DryRun || report.Deleted != 1 || report.Trigger != constants.AuditPurgeTriggerManual ||
+		len(report.Ranges) != 1 || report.Ranges[0].Action != constants.AuditActionDownloaded {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	var remaining int
+	orchDB.QueryRow(`SELECT COUNT(*) FROM audit_log WHERE timestamp = ?`, old).Scan(&remaining)
+	if remaining != 2 {
+		t.Errorf("expected held download and login to remain, got %d", remaining)
+	}
+
+	var logged int
+	orchDB.QueryRow(`SELECT COUNT(*) FROM audit_log WHERE action IN (?, ?)`,
+		constants.AuditActionAuditPurged, constants.AuditActionAuditHoldCreated).Scan(&logged)
+	if logged != 2 {
+		t.Errorf("expected audit_purged and audit_hold_created entries, got %d", logged)
+	}
+
+	// Releasing the hold makes its entries purgeable
+	resp, err := ts.DELETE(fmt.Sprintf("/api/audit/holds/%d", hold.ID))
+	if err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 on release, got %d", resp.StatusCode)
+	}
+	resp, _ = ts.DELETE(fmt.Sprintf("/api/audit/holds/%d", hold.ID))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 on second release, got %d", resp.StatusCode)
+	}
+
+	if err := ts.GetJSON("/api/audit/purge/preview", &preview); err != nil {
+		t.Fatalf("preview failed: %v", err)
+	}
+	if preview.Deleted != 1 || preview.HeldEntries != 0 {
+		t.Errorf("expected released entry to be purgeable, got %+v", preview)
+	}
+}
+
+// TestAuditPurge_RequiresManageConfig verifies purge controls are admin-only.
+func TestAuditPurge_RequiresManageConfig(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	viewer := ts.CreateTestUserWithGrants(t, "audit-viewer", "ViewerPass123!", []map[string]interface{}{
+		{
+			"action":           constants.AuthActionViewAudit,
+			"constraints_json": `{"can_view_all": true}`,
+		},
+	})
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/api/audit/purge/preview"},
+		{http.MethodPost, "/api/audit/purge"},
+		{http.MethodGet, "/api/audit/holds"},
+	} {
+		resp, err := ts.RequestWithAPIKey(req.method, req.path, viewer.APIKey, nil)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403, got %d", req.method, req.path, resp.StatusCode)
+		}
+	}
+}
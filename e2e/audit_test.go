@@ -186,20 +186,59 @@ func TestAuditActionsEndpoint(t *testing.T) {
 	expectedActions := []string{
 		// Core operations
 		"connected", "adding_topic", "querying",
-		"adding_file", "verified", "downloaded", "downloaded_bulk",
-		"reconcile_topic_removed",
+		"adding_file", "verified", "downloaded", "downloaded_bulk", "download_cancelled",
+		"reconcile_topic_removed", "sync_diff",
 		// Authentication
 		"login_success", "login_failed", "logout",
 		// User management
 		"user_created", "user_updated", "api_key_regenerated",
 		// Grant management
-		"grant_created", "grant_updated", "grant_revoked",
+		"grant_created", "grant_updated", "grant_revoked", "grant_batch", "auth_imported",
 		// Metadata
-		"metadata_set", "metadata_batch", "metadata_apply",
+		"metadata_set", "metadata_batch", "metadata_apply", "metadata_import",
 		// Configuration
 		"config_changed",
 		// Disk Usage
 		"disk_limit_hit",
+		// Audit Retention
+		"audit_purged", "audit_hold_created", "audit_hold_released", "audit_exported",
+		// Quarantine
+		"asset_quarantined", "asset_released",
+		// Asset Deletion
+		"asset_deleted",
+		// Deletion Requests
+		"deletion_requested", "deletion_approved", "deletion_rejected",
+		"deletion_cancelled", "deletion_expired", "deletion_completed",
+		// Archive Tier
+		"assets_archived", "asset_recall_requested", "asset_recalled",
+		// Trash
+		"asset_trashed", "asset_restored",
+		// Retention Policies
+		"retention_applied",
+		// Watch Folders
+		"watch_folder_ingested",
+		// Lineage
+		"lineage_reparented",
+		// Collections
+		"collection_created", "collection_updated", "collection_deleted", "collection_assets",
+		// Admin recovery
+		"admin_recovery_issued", "admin_recovered", "admin_recovery_failed",
+		// Service accounts
+		"service_account_created", "service_account_token_rotated", "service_account_disabled",
+		// Webhooks
+		"webhook_created", "webhook_updated", "webhook_deleted",
+		// Rules
+		"rule_created", "rule_updated", "rule_deleted", "rule_executed",
+		// Debug
+		"debug_bundle",
+		// Backup
+		"backup_created",
+		// Workspace tokens
+		"workspace_token_created", "workspace_token_exchanged", "workspace_token_revoked",
+		// API keys
+		"api_key_created", "api_key_rotated", "api_key_revoked",
+		// Link exports
+		"link_export_selected", "link_export_released",
 	}
 
 	if len(result.Actions) != len(expectedActions) {
@@ -0,0 +1,122 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+type activityResponse struct {
+	Username string `json:"username"`
+	Total    int64  `json:"total"`
+	Events   []struct {
+		Timestamp int64                  `json:"timestamp"`
+		Kind      string                 `json:"kind"`
+		Action    string                 `json:"action"`
+		IPAddress string                 `json:"ip_address"`
+		Details   map[string]interface{} `json:"details"`
+	} `json:"events"`
+	TopActions []struct {
+		Action string `json:"action"`
+		Count  int64  `json:"count"`
+	} `json:"top_actions"`
+}
+
+// TestUserActivity_Timeline verifies logins, sessions, API-key usage samples
+// and audited actions of one user appear in a single timeline.
+func TestUserActivity_Timeline(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "activity")
+
+	user := ts.CreateTestUserWithGrants(t, "suspect", "suspect-password-123", []map[string]interface{}{
+		{"action": constants.AuthActionUpload},
+		{"action": constants.AuthActionQuery},
+	})
+	ts.LoginUser(t, user.Username, user.Password)
+
+	// Two API-key requests within the sample interval yield one sample
+	for i := 0; i < 2; i++ {
+		resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/topics", user.APIKey, nil)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	var activity activityResponse
+	if err := ts.GetJSON(fmt.Sprintf("/api/auth/users/%d/activity", user.ID), &activity); err != nil {
+		t.Fatalf("get activity: %v", err)
+	}
+	if activity.Username != "suspect" {
+		t.Errorf("expected username suspect, got %q", activity.Username)
+	}
+
+	kinds := map[string]int{}
+	for _, e := range activity.Events {
+		kinds[e.Kind]++
+		if e.Kind == constants.AuthActivityKindAPIKey && e.Details["path"] != "/api/topics" {
+			t.Errorf("unexpected api key sample: %+v", e)
+		}
+	}
+	if kinds[constants.AuthActivityKindAPIKey] != 1 {
+		t.Errorf("expected 1 api key sample, got %d", kinds[constants.AuthActivityKindAPIKey])
+	}
+	if kinds[constants.AuthActivityKindSession] != 1 || kinds[constants.AuthActivityKindLogin] != 1 {
+		t.Errorf("expected a session and a login event, got %v", kinds)
+	}
+	if len(activity.TopActions) == 0 || activity.TopActions[0].Action != constants.AuditActionLoginSuccess {
+		t.Errorf("unexpected top actions: %+v", activity.TopActions)
+	}
+
+	// Kind filter and pagination
+	var logins activityResponse
+	if err := ts.GetJSON(fmt.Sprintf("/api/auth/users/%d/activity?kind=login&limit=1", user.ID), &logins); err != nil {
+		t.Fatalf("get activity: %v", err)
+	}
+	if logins.Total != 1 || len(logins.Events) != 1 || logins.Events[0].Action != constants.AuditActionLoginSuccess {
+		t.Errorf("unexpected login events: %+v", logins)
+	}
+
+	resp, err := ts.GET(fmt.Sprintf("/api/auth/users/%d/activity?kind=bogus", user.ID))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid kind, got %d", resp.StatusCode)
+	}
+}
+
+// TestUserActivity_RequiresAuditVisibility verifies user managers without
+// unrestricted audit access cannot read other users' timelines.
+func TestUserActivity_RequiresAuditVisibility(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	target := ts.CreateTestUser(t, "target", "target-password-123")
+	manager := ts.CreateTestUserWithGrants(t, "manager", "manager-password-123", []map[string]interface{}{
+		{"action": constants.AuthActionManageUsers},
+		{"action": constants.AuthActionViewAudit, "constraints_json": `{"can_view_all":false}`},
+	})
+
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, fmt.Sprintf("/api/auth/users/%d/activity", target.ID), manager.APIKey, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", resp.StatusCode)
+	}
+
+	resp, err = ts.RequestWithAPIKey(http.MethodGet, fmt.Sprintf("/api/auth/users/%d/activity", manager.ID), manager.APIKey, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected own timeline to be visible, got %d", resp.StatusCode)
+	}
+}
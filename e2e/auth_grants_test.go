@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"testing"
 
 	"silobang/internal/constants"
@@ -151,6 +152,172 @@ func TestUpdateGrantConstraints(t *testing.T) {
 	}
 }
 
+// listActiveGrants returns the user's active grants keyed by action.
+func listActiveGrants(t *testing.T, ts *TestServer, userID int64) map[string]map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := ts.GetJSON(fmt.Sprintf("/api/auth/users/%d/grants", userID), &body); err != nil {
+		t.Fatalf("GET grants failed: %v", err)
+	}
+	grants := make(map[string]map[string]interface{})
+	list, _ := body["grants"].([]interface{})
+	for _, g := range list {
+		grant := g.(map[string]interface{})
+		if grant["is_active"] == true {
+			grants[grant["action"].(string)] = grant
+		}
+	}
+	return grants
+}
+
+// TestGrantBatch verifies many grants across users are created in one call
+// and audited as a single entry.
+func TestGrantBatch(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	alice := ts.CreateTestUser(t, "batchalice", "secure-password-12345")
+	bob := ts.CreateTestUser(t, "batchbob", "secure-password-12345")
+
+	resp, err := ts.POST("/api/auth/grants/batch", map[string]interface{}{
+		"grants": []map[string]interface{}{
+			{"user_id": alice.ID, "action": constants.AuthActionUpload},
+			{"user_id": alice.ID, "action": constants.AuthActionDownload},
+			{"user_id": bob.ID, "action": constants.AuthActionQuery},
+		},
+	})
+	if err != nil {
+		t.Fatalf("batch request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		t.Fatalf("expected 201, got %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var body map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body["count"] != float64(3) {
+		t.Errorf("expected count=3, got %v", body["count"])
+	}
+
+	if grants := listActiveGrants(t, ts, alice.ID); len(grants) != 2 {
+		t.Errorf("expected 2 grants for alice, got %d", len(grants))
+	}
+	if grants := listActiveGrants(t, ts, bob.ID); grants[constants.AuthActionQuery] == nil {
+		t.Error("expected query grant for bob")
+	}
+
+	var audit AuditQueryResponse
+	if err := ts.GetJSON("/api/audit?action="+constants.AuditActionGrantBatch, &audit); err != nil {
+		t.Fatalf("failed to query audit: %v", err)
+	}
+	if len(audit.Entries) != 1 {
+		t.Fatalf("expected 1 grant_batch entry, got %d", len(audit.Entries))
+	}
+	details, _ := audit.Entries[0].Details.(map[string]interface{})
+	if details["mode"] != constants.AuthGrantBatchModeList || details["count"] != float64(3) {
+		t.Errorf("unexpected audit details: %+v", details)
+	}
+}
+
+// TestGrantBatch_Atomic verifies an invalid entry rejects the whole batch.
+func TestGrantBatch_Atomic(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	user := ts.CreateTestUser(t, "batchatomic", "secure-password-12345")
+
+	resp, err := ts.POST("/api/auth/grants/batch", map[string]interface{}{
+		"grants": []map[string]interface{}{
+			{"user_id": user.ID, "action": constants.AuthActionUpload},
+			{"user_id": user.ID, "action": "not_an_action"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("batch request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+	if grants := listActiveGrants(t, ts, user.ID); len(grants) != 0 {
+		t.Errorf("expected no grants after rejected batch, got %d", len(grants))
+	}
+}
+
+// TestCopyGrants verifies copying another user's grants with a constraint override.
+func TestCopyGrants(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	source := ts.CreateTestUserWithGrants(t, "copysource", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionUpload, "constraints_json": `{"allowed_extensions":["png"]}`},
+		{"action": constants.AuthActionDownload},
+	})
+	target := ts.CreateTestUser(t, "copytarget", "secure-password-12345")
+
+	override := `{"allowed_extensions":["jpg"]}`
+	resp, err := ts.POST(fmt.Sprintf("/api/auth/users/%d/grants/copy-from/%d", target.ID, source.ID), map[string]interface{}{
+		"overrides": map[string]interface{}{constants.AuthActionUpload: override},
+	})
+	if err != nil {
+		t.Fatalf("copy request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		t.Fatalf("expected 201, got %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	grants := listActiveGrants(t, ts, target.ID)
+	if len(grants) != 2 {
+		t.Fatalf("expected 2 copied grants, got %d", len(grants))
+	}
+	if grants[constants.AuthActionUpload]["constraints_json"] != override {
+		t.Errorf("expected overridden constraints, got %v", grants[constants.AuthActionUpload]["constraints_json"])
+	}
+
+	var audit AuditQueryResponse
+	if err := ts.GetJSON("/api/audit?action="+constants.AuditActionGrantBatch, &audit); err != nil {
+		t.Fatalf("failed to query audit: %v", err)
+	}
+	if len(audit.Entries) != 1 {
+		t.Fatalf("expected 1 grant_batch entry, got %d", len(audit.Entries))
+	}
+	details, _ := audit.Entries[0].Details.(map[string]interface{})
+	if details["mode"] != constants.AuthGrantBatchModeCopy || details["source_user_id"] != float64(source.ID) {
+		t.Errorf("unexpected audit details: %+v", details)
+	}
+}
+
+// TestCopyGrants_Invalid verifies copying from self or from a user without
+// grants is rejected.
+func TestCopyGrants_Invalid(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	empty := ts.CreateTestUser(t, "copyempty", "secure-password-12345")
+	target := ts.CreateTestUser(t, "copyself", "secure-password-12345")
+
+	for _, path := range []string{
+		fmt.Sprintf("/api/auth/users/%d/grants/copy-from/%d", target.ID, target.ID),
+		fmt.Sprintf("/api/auth/users/%d/grants/copy-from/%d", target.ID, empty.ID),
+	} {
+		resp, err := ts.POST(path, nil)
+		if err != nil {
+			t.Fatalf("copy request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, resp.StatusCode)
+		}
+	}
+}
+
 // =============================================================================
 // Constraint Enforcement
 // =============================================================================
@@ -274,6 +441,67 @@ func TestUploadConstraint_AllowedTopics(t *testing.T) {
 	}
 }
 
+// TestQueryConstraint_AllowedTopics verifies a topic-scoped query grant
+// limits the topics a query fans out over, and download grants the assets.
+func TestQueryConstraint_AllowedTopics(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "q-open")
+	ts.CreateTopic(t, "q-closed")
+	open := ts.UploadFileExpectSuccess(t, "q-open", "open.bin", []byte("open content"), "").Hash
+	closed := ts.UploadFileExpectSuccess(t, "q-closed", "closed.bin", []byte("closed content"), "").Hash
+
+	user := ts.CreateTestUserWithGrants(t, "scopeduser", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_topics":["q-open"]}`},
+		{"action": constants.AuthActionDownload, "constraints_json": `{"allowed_topics":["q-open"]}`},
+	})
+	elsewhere := ts.CreateTestUserWithGrants(t, "elsewhere", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_topics":["q-missing"]}`},
+	})
+
+	oldKey := ts.APIKey
+	ts.APIKey = user.APIKey
+	defer func() { ts.APIKey = oldKey }()
+
+	// Without named topics the fan-out skips topics outside the grant
+	result := ts.ExecuteQuery(t, "recent-imports", nil, nil)
+	topicCol := slices.Index(result.Columns, "_topic")
+	if result.RowCount != 1 || topicCol < 0 || result.Rows[0][topicCol] != "q-open" {
+		t.Errorf("expected only the q-open asset, got %+v", result)
+	}
+
+	resp, err := ts.POST("/api/query/recent-imports", map[string]interface{}{
+		"topics": []string{"q-open", "q-closed"},
+	})
+	if err != nil {
+		t.Fatalf("query request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 when naming a topic outside the grant, got %d", resp.StatusCode)
+	}
+
+	resp, err = ts.RequestWithAPIKey(http.MethodPost, "/api/query/recent-imports", elsewhere.APIKey, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("query request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 when no topic is allowed, got %d", resp.StatusCode)
+	}
+
+	for hash, want := range map[string]int{open: http.StatusOK, closed: http.StatusForbidden} {
+		resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/assets/"+hash+"/download", user.APIKey, nil)
+		if err != nil {
+			t.Fatalf("download request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("download of %s: expected %d, got %d", hash, want, resp.StatusCode)
+		}
+	}
+}
+
 // TestNoGrantUser_Forbidden verifies user with no grants is denied on protected actions
 func TestNoGrantUser_Forbidden(t *testing.T) {
 	ts := StartTestServer(t)
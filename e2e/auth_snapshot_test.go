@@ -0,0 +1,219 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+type snapshotImportResponse struct {
+	DryRun  bool `json:"dry_run"`
+	Changes []struct {
+		Type     string `json:"type"`
+		Username string `json:"username"`
+		Action   string `json:"action"`
+	} `json:"changes"`
+	Credentials []struct {
+		Username string `json:"username"`
+		APIKey   string `json:"api_key"`
+	} `json:"credentials"`
+}
+
+type snapshotExportResponse struct {
+	Version int `json:"version"`
+	Users   []struct {
+		Username string `json:"username"`
+		Active   *bool  `json:"active"`
+		Grants   []struct {
+			Action      string                 `json:"action"`
+			Constraints map[string]interface{} `json:"constraints"`
+		} `json:"grants"`
+	} `json:"users"`
+}
+
+// importSnapshot POSTs a YAML snapshot to /api/auth/import and decodes
+// the result.
+func (ts *TestServer) importSnapshot(t *testing.T, query, snapshot string) snapshotImportResponse {
+	t.Helper()
+	resp, err := ts.POSTRaw("/api/auth/import"+query, constants.ContentTypeYAML, []byte(snapshot))
+	if err != nil {
+		t.Fatalf("import request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("import: expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	var result snapshotImportResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	return result
+}
+
+// countChanges returns the number of changes of the given type for username.
+func (r snapshotImportResponse) countChanges(changeType, username string) int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Type == changeType && c.Username == username {
+			n++
+		}
+	}
+	return n
+}
+
+const testAuthSnapshot = `
+version: 1
+roles:
+  - name: reader
+    grants:
+      - action: query
+      - action: download
+        constraints:
+          allowed_topics: [assets]
+users:
+  - username: alice
+    display_name: Alice
+    roles: [reader]
+  - username: ingest-bot
+    account_type: service
+    grants:
+      - action: upload
+`
+
+// TestAuthSnapshot_ExportImportRoundTrip verifies an import plans its
+// changes on a dry run, applies them with roles expanded, issues keys to
+// created accounts, and is idempotent.
+func TestAuthSnapshot_ExportImportRoundTrip(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	ts.CreateTestUserWithGrants(t, "alice", "alice-password-123", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+		{"action": constants.AuthActionUpload},
+	})
+
+	// YAML by default, without the bootstrap user
+	resp, err := ts.GET("/api/auth/export")
+	if err != nil {
+		t.Fatalf("export request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(constants.HeaderContentType) != constants.ContentTypeYAML {
+		t.Fatalf("export: expected 200 YAML, got %d %s", resp.StatusCode, resp.Header.Get(constants.HeaderContentType))
+	}
+	if !strings.Contains(string(body), "username: alice") || strings.Contains(string(body), constants.AuthBootstrapUsername) {
+		t.Errorf("unexpected export:\n%s", body)
+	}
+
+	plan := ts.importSnapshot(t, "?dry_run=true", testAuthSnapshot)
+	if !plan.DryRun || len(plan.Credentials) != 0 {
+		t.Fatalf("expected a dry run without credentials, got %+v", plan)
+	}
+	if plan.countChanges(constants.AuthSnapshotChangeUserUpdated, "alice") != 1 ||
+		plan.countChanges(constants.AuthSnapshotChangeGrantRevoked, "alice") != 1 ||
+		plan.countChanges(constants.AuthSnapshotChangeGrantCreated, "alice") != 1 ||
+		plan.countChanges(constants.AuthSnapshotChangeUserCreated, "ingest-bot") != 1 ||
+		plan.countChanges(constants.AuthSnapshotChangeGrantCreated, "ingest-bot") != 1 {
+		t.Fatalf("unexpected plan: %+v", plan.Changes)
+	}
+
+	var before snapshotExportResponse
+	if err := ts.GetJSON("/api/auth/export?format=json", &before); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if len(before.Users) != 1 {
+		t.Fatalf("dry run wrote changes: %+v", before.Users)
+	}
+
+	applied := ts.importSnapshot(t, "", testAuthSnapshot)
+	if len(applied.Changes) != len(plan.Changes) {
+		t.Errorf("applied %d changes, planned %d", len(applied.Changes), len(plan.Changes))
+	}
+	if len(applied.Credentials) != 1 || applied.Credentials[0].Username != "ingest-bot" {
+		t.Fatalf("expected a credential for ingest-bot, got %+v", applied.Credentials)
+	}
+	resp, err = ts.RequestWithAPIKey(http.MethodGet, "/api/auth/me", applied.Credentials[0].APIKey, nil)
+	if err != nil {
+		t.Fatalf("me request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("issued key rejected: %d", resp.StatusCode)
+	}
+
+	var after snapshotExportResponse
+	if err := ts.GetJSON("/api/auth/export?format=json", &after); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if len(after.Users) != 2 || after.Users[0].Username != "alice" || len(after.Users[0].Grants) != 2 {
+		t.Fatalf("unexpected export after import: %+v", after.Users)
+	}
+
+	if again := ts.importSnapshot(t, "", testAuthSnapshot); len(again.Changes) != 0 {
+		t.Errorf("expected a repeated import to change nothing, got %+v", again.Changes)
+	}
+
+	var audit AuditQueryResponse
+	if err := ts.GetJSON("/api/audit?action="+constants.AuditActionAuthImported, &audit); err != nil {
+		t.Fatalf("audit query failed: %v", err)
+	}
+	if len(audit.Entries) != 1 {
+		t.Errorf("expected 1 %s audit entry, got %d", constants.AuditActionAuthImported, len(audit.Entries))
+	}
+}
+
+// TestAuthSnapshot_PruneAndValidation verifies prune disables accounts
+// missing from the snapshot and that invalid snapshots write nothing.
+func TestAuthSnapshot_PruneAndValidation(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	ts.CreateTestUserWithGrants(t, "alice", "alice-password-123", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	ts.CreateTestUser(t, "bob", "bob-password-12345")
+
+	invalid := map[string]string{
+		"unknown role":    "version: 1\nusers:\n  - username: carol\n    roles: [writer]\n",
+		"unknown field":   "version: 1\nusers:\n  - username: carol\n    password: secret\n",
+		"invalid action":  "version: 1\nusers:\n  - username: carol\n    grants:\n      - action: fly\n",
+		"service action":  "version: 1\nusers:\n  - username: carol\n    account_type: service\n    grants:\n      - action: manage_users\n",
+		"bootstrap user":  "version: 1\nusers:\n  - username: " + constants.AuthBootstrapUsername + "\n",
+		"wrong version":   "version: 2\nusers: []\n",
+		"duplicate users": "version: 1\nusers:\n  - username: carol\n  - username: carol\n",
+	}
+	for name, snapshot := range invalid {
+		resp, err := ts.POSTRaw("/api/auth/import", constants.ContentTypeYAML, []byte(snapshot))
+		if err != nil {
+			t.Fatalf("%s: import request failed: %v", name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < http.StatusBadRequest {
+			t.Errorf("%s: expected an error, got %d", name, resp.StatusCode)
+		}
+	}
+
+	// Snapshots are also accepted as JSON
+	pruned := ts.importSnapshot(t, "?prune=true",
+		`{"version": 1, "users": [{"username": "alice", "grants": [{"action": "query"}]}]}`)
+	if pruned.countChanges(constants.AuthSnapshotChangeUserDisabled, "bob") != 1 || len(pruned.Changes) != 2 {
+		t.Fatalf("expected bob disabled and alice's display name cleared, got %+v", pruned.Changes)
+	}
+
+	var snap snapshotExportResponse
+	if err := ts.GetJSON("/api/auth/export?format=json", &snap); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	for _, u := range snap.Users {
+		if u.Username == "carol" {
+			t.Error("an invalid snapshot created carol")
+		}
+		if active := u.Active != nil && *u.Active; active != (u.Username == "alice") {
+			t.Errorf("user %s: unexpected active=%v", u.Username, active)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+type usageResponse struct {
+	Username     string  `json:"username"`
+	Window       string  `json:"window"`
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	BytesIn      int64   `json:"bytes_in"`
+	BytesOut     int64   `json:"bytes_out"`
+	TopEndpoints []struct {
+		Endpoint string `json:"endpoint"`
+		Requests int64  `json:"requests"`
+		Errors   int64  `json:"errors"`
+	} `json:"top_endpoints"`
+}
+
+// getUsageWithAPIKey fetches path with apiKey and decodes the usage report.
+func (ts *TestServer) getUsageWithAPIKey(t *testing.T, path, apiKey string) (int, usageResponse) {
+	t.Helper()
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, path, apiKey, nil)
+	if err != nil {
+		t.Fatalf("usage request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var usage usageResponse
+	json.NewDecoder(resp.Body).Decode(&usage)
+	return resp.StatusCode, usage
+}
+
+// TestUserUsage_Analytics verifies a user's requests are aggregated per
+// endpoint with errors and bytes, and that admins can read any user's usage.
+func TestUserUsage_Analytics(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "usage")
+	hash := ts.UploadFileExpectSuccess(t, "usage", "file.bin", []byte("usage content"), "").Hash
+
+	user := ts.CreateTestUserWithGrants(t, "integrator", "integrator-password-123", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := ts.RequestWithAPIKey(http.MethodPost, "/api/query/recent-imports", user.APIKey,
+			map[string]interface{}{"topics": []string{"usage"}})
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	// No download grant: counted as an error
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/assets/"+hash+"/download", user.APIKey, nil)
+	if err != nil {
+		t.Fatalf("download request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 without a download grant, got %d", resp.StatusCode)
+	}
+
+	status, usage := ts.getUsageWithAPIKey(t, "/api/auth/me/usage", user.APIKey)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if usage.Window != constants.AuthUsageDefaultWindow || usage.Requests != 3 || usage.Errors != 1 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+	if usage.BytesIn == 0 || usage.BytesOut == 0 {
+		t.Errorf("expected bytes in both directions, got in=%d out=%d", usage.BytesIn, usage.BytesOut)
+	}
+	if len(usage.TopEndpoints) != 2 || usage.TopEndpoints[0].Endpoint != "POST /api/query/recent-imports" || usage.TopEndpoints[0].Requests != 2 ||
+		usage.TopEndpoints[1].Endpoint != "GET /api/assets/:hash/download" || usage.TopEndpoints[1].Errors != 1 {
+		t.Errorf("unexpected top endpoints: %+v", usage.TopEndpoints)
+	}
+
+	if status, _ := ts.getUsageWithAPIKey(t, "/api/auth/me/usage?window=2d", user.APIKey); status != http.StatusBadRequest {
+		t.Errorf("invalid window: expected 400, got %d", status)
+	}
+
+	// Admin variant, including the usage requests made so far
+	var admin usageResponse
+	if err := ts.GetJSON(fmt.Sprintf("/api/auth/users/%d/usage?window=7d", user.ID), &admin); err != nil {
+		t.Fatalf("admin usage request failed: %v", err)
+	}
+	if admin.Username != "integrator" || admin.Window != "7d" || admin.Requests != 5 || admin.Errors != 2 {
+		t.Errorf("unexpected admin usage: %+v", admin)
+	}
+
+	if status, _ := ts.getUsageWithAPIKey(t, "/api/auth/users/1/usage", user.APIKey); status != http.StatusForbidden {
+		t.Errorf("non-admin reading another user's usage: expected 403, got %d", status)
+	}
+}
@@ -0,0 +1,147 @@
+package e2e
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/services"
+)
+
+// downloadBackup writes a backup through POST /api/backup and returns the
+// archive.
+func (ts *TestServer) downloadBackup(t *testing.T) []byte {
+	t.Helper()
+	resp, err := ts.POST("/api/backup", nil)
+	if err != nil {
+		t.Fatalf("POST /api/backup failed: %v", err)
+	}
+	defer resp.Body.Close()
+	archive, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read backup: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, archive)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != constants.BackupContentType {
+		t.Errorf("expected Content-Type %s, got %s", constants.BackupContentType, ct)
+	}
+	return archive
+}
+
+// TestBackup_RestoreRoundTrip verifies a backup restores into a working
+// directory holding the same databases and .dat files, and is audited.
+func TestBackup_RestoreRoundTrip(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+	ts.CreateTopic(t, "textures")
+
+	first := ts.UploadFileExpectSuccess(t, "models", "a.bin", GenerateTestFile(1024), "").Hash
+	ts.UploadFileExpectSuccess(t, "models", "b.bin", GenerateTestFile(2048), "")
+	ts.UploadFileExpectSuccess(t, "textures", "c.bin", GenerateTestFile(512), "")
+
+	archive := ts.downloadBackup(t)
+
+	target := filepath.Join(t.TempDir(), "restored")
+	manifest, err := services.RestoreBackup(bytes.NewReader(archive), target)
+	if err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if len(manifest.Topics) != 2 || manifest.Topics[0] != "models" || manifest.Topics[1] != "textures" {
+		t.Errorf("unexpected topics %v", manifest.Topics)
+	}
+	// Orchestrator, two topic databases and a .dat file per topic
+	if len(manifest.Files) != 5 {
+		t.Errorf("expected 5 files, got %+v", manifest.Files)
+	}
+
+	for _, rel := range []string{"models/000001.dat", "textures/000001.dat"} {
+		original, err := os.ReadFile(filepath.Join(ts.WorkDir, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored, err := os.ReadFile(filepath.Join(target, rel))
+		if err != nil {
+			t.Fatalf("%s was not restored: %v", rel, err)
+		}
+		if !bytes.Equal(original, restored) {
+			t.Errorf("%s differs from the original", rel)
+		}
+	}
+
+	db, err := database.OpenDatabase(filepath.Join(target, "models", constants.InternalDir, "models.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM assets WHERE asset_id = ?", first).Scan(&count)
+	if count != 1 {
+		t.Errorf("expected the restored topic database to hold %s", first)
+	}
+	if _, err := os.Stat(filepath.Join(target, constants.InternalDir, constants.OrchestratorDB)); err != nil {
+		t.Errorf("orchestrator database was not restored: %v", err)
+	}
+	if _, err := os.Stat(target + constants.RestoreStagingSuffix); !os.IsNotExist(err) {
+		t.Errorf("expected the staging directory to be gone, got %v", err)
+	}
+
+	var audited int
+	ts.App.OrchestratorDB.QueryRow("SELECT COUNT(*) FROM audit_log WHERE action = ?", constants.AuditActionBackupCreated).Scan(&audited)
+	if audited != 1 {
+		t.Errorf("expected one backup_created audit entry, got %d", audited)
+	}
+}
+
+// TestBackup_RestoreRejectsDamagedArchive verifies an archive whose content
+// no longer matches its manifest, or that was cut short, is rejected without
+// touching the target directory.
+func TestBackup_RestoreRejectsDamagedArchive(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+	content := GenerateTestFile(4096)
+	ts.UploadFileExpectSuccess(t, "models", "a.bin", content, "")
+
+	archive := ts.downloadBackup(t)
+
+	tampered := bytes.Clone(archive)
+	at := bytes.Index(tampered, content)
+	if at < 0 {
+		t.Fatal("asset content not found in the archive")
+	}
+	tampered[at+100] ^= 0xff
+
+	cases := map[string][]byte{
+		"tampered":  tampered,
+		"truncated": archive[:len(archive)/2],
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "restored")
+			if _, err := services.RestoreBackup(bytes.NewReader(data), target); err == nil {
+				t.Fatal("expected the restore to fail")
+			}
+			if _, err := os.Stat(target); !os.IsNotExist(err) {
+				t.Errorf("expected no target directory, got %v", err)
+			}
+			if _, err := os.Stat(target + constants.RestoreStagingSuffix); !os.IsNotExist(err) {
+				t.Errorf("expected no staging directory, got %v", err)
+			}
+		})
+	}
+
+	// A non-empty target is refused
+	target := t.TempDir()
+	os.WriteFile(filepath.Join(target, "keep.txt"), []byte("x"), 0644)
+	if _, err := services.RestoreBackup(bytes.NewReader(archive), target); err == nil {
+		t.Error("expected a restore into a non-empty directory to fail")
+	}
+}
@@ -0,0 +1,128 @@
+package e2e
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"silobang/internal/config"
+	"silobang/internal/storage"
+)
+
+// memoryBucket is an in-memory S3-compatible bucket serving path-style
+// object requests.
+type memoryBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (b *memoryBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		http.Error(w, "unsigned request", http.StatusForbidden)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		b.objects[r.URL.Path] = body
+	case http.MethodDelete:
+		delete(b.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		data, ok := b.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}
+}
+
+// TestBlobStore_OffloadAndReadThrough verifies sealed .dat files move to the
+// topic's blob store and stay readable through downloads, bulk downloads and
+// a restart.
+func TestBlobStore_OffloadAndReadThrough(t *testing.T) {
+	bucket := &memoryBucket{objects: map[string][]byte{}}
+	s3 := httptest.NewServer(bucket)
+	defer s3.Close()
+
+	ts := StartTestServer(t)
+	ts.App.Config.MaxDatSize = 1048576
+	ts.App.Config.BlobStores = map[string]config.BlobStoreConfig{
+		"archive": {Endpoint: s3.URL, Bucket: "silobang", Region: "us-east-1", Prefix: "test/", AccessKeyID: "key", SecretAccessKey: "secret"},
+	}
+	ts.App.Config.TopicBlobStores = map[string]string{"cold": "archive"}
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "cold")
+	ts.CreateTopic(t, "hot")
+
+	contents := make([][]byte, 3)
+	hashes := make([]string, 3)
+	for i := range contents {
+		contents[i] = GenerateTestFile(600 * 1024)
+		hashes[i] = ts.UploadFileExpectSuccess(t, "cold", "file.bin", contents[i], "").Hash
+	}
+	ts.UploadFileExpectSuccess(t, "hot", "file.bin", []byte("hot content"), "")
+
+	result, err := ts.App.Services.BlobStores.Offload("cold")
+	if err != nil {
+		t.Fatalf("offload failed: %v", err)
+	}
+	sealed := []string{storage.FormatDatFilename(1), storage.FormatDatFilename(2)}
+	if strings.Join(result.DatFiles, ",") != strings.Join(sealed, ",") || len(result.Errors) != 0 {
+		t.Fatalf("expected %v offloaded, got %+v", sealed, result)
+	}
+	for _, name := range sealed {
+		if _, err := os.Stat(filepath.Join(ts.WorkDir, "cold", name)); !os.IsNotExist(err) {
+			t.Errorf("expected local %s to be removed, stat err: %v", name, err)
+		}
+		if _, ok := bucket.objects["/silobang/test/cold/"+name]; !ok {
+			t.Errorf("expected %s in the bucket", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(ts.WorkDir, "cold", storage.FormatDatFilename(3))); err != nil {
+		t.Errorf("expected the newest .dat file to stay local: %v", err)
+	}
+
+	// Topics without a blob store are left alone
+	if result, err := ts.App.Services.BlobStores.Offload("hot"); err != nil || result != nil {
+		t.Errorf("expected no offload for a local topic, got %+v, %v", result, err)
+	}
+
+	for i, hash := range hashes {
+		if got := ts.DownloadAsset(t, hash); !bytes.Equal(got, contents[i]) {
+			t.Errorf("asset %d changed after offload", i)
+		}
+	}
+
+	zipBytes := ts.BulkDownloadExpectSuccess(t, BulkDownloadRequest{Mode: "ids", AssetIDs: hashes[:2]})
+	if files := ListZIPFiles(t, zipBytes); len(files) < 2 {
+		t.Errorf("expected both offloaded assets in the archive, got %v", files)
+	}
+
+	var report integrityReport
+	if err := ts.PostJSON("/api/topics/cold/integrity", nil, &report); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(report.Findings) != 0 {
+		t.Errorf("expected a clean scan with offloaded files, got %+v", report.Findings)
+	}
+
+	// Uploads continue in the local file, and a restart keeps the topic healthy
+	extra := ts.UploadFileExpectSuccess(t, "cold", "extra.bin", []byte("after offload"), "")
+	ts.Restart(t)
+	ts.DownloadAsset(t, extra.Hash)
+	if got := ts.DownloadAsset(t, hashes[0]); !bytes.Equal(got, contents[0]) {
+		t.Error("offloaded asset changed after restart")
+	}
+}
@@ -0,0 +1,114 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+type bomEntry struct {
+	Hash     string                 `json:"hash"`
+	Topic    string                 `json:"topic"`
+	Size     int64                  `json:"size"`
+	Depth    int                    `json:"depth"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+type bomResponse struct {
+	Root        bomEntry   `json:"root"`
+	Sources     []bomEntry `json:"sources"`
+	SourceCount int        `json:"source_count"`
+	TotalSize   int64      `json:"total_size"`
+	Topics      []string   `json:"topics"`
+}
+
+// TestAssetBOM verifies the bill of materials follows lineage across topics
+// and the SPDX export describes the same graph.
+func TestAssetBOM(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "bom-raw")
+	ts.CreateTopic(t, "bom-builds")
+
+	source := ts.UploadFileExpectSuccess(t, "bom-raw", "texture.png", []byte("raw texture"), "")
+	atlas := ts.UploadFileExpectSuccess(t, "bom-builds", "atlas.png", []byte("atlas built"), source.Hash)
+	bundle := ts.UploadFileExpectSuccess(t, "bom-builds", "bundle.zip", []byte("bundle built"), atlas.Hash)
+	ts.SetMetadata(t, source.Hash, "license", "cc0")
+
+	resp, err := ts.GET("/api/assets/" + bundle.Hash + "/bom")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var bom bomResponse
+	if err := json.NewDecoder(resp.Body).Decode(&bom); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	if bom.Root.Hash != bundle.Hash || bom.SourceCount != 2 {
+		t.Fatalf("expected root bundle with 2 sources, got %+v", bom)
+	}
+	if bom.Sources[1].Hash != source.Hash || bom.Sources[1].Topic != "bom-raw" || bom.Sources[1].Depth != 2 {
+		t.Errorf("unexpected deepest source: %+v", bom.Sources[1])
+	}
+	if bom.Sources[1].Metadata["license"] != "cc0" {
+		t.Errorf("expected metadata snapshot, got %v", bom.Sources[1].Metadata)
+	}
+	if bom.TotalSize != source.Size+atlas.Size {
+		t.Errorf("total_size = %d, want %d", bom.TotalSize, source.Size+atlas.Size)
+	}
+
+	spdxResp, err := ts.GET("/api/assets/" + bundle.Hash + "/bom?format=spdx")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer spdxResp.Body.Close()
+	if !strings.Contains(spdxResp.Header.Get("Content-Disposition"), constants.SPDXFileExt) {
+		t.Errorf("expected SPDX attachment, got %q", spdxResp.Header.Get("Content-Disposition"))
+	}
+
+	var doc struct {
+		SPDXVersion string `json:"spdxVersion"`
+		Files       []struct {
+			SPDXID string `json:"SPDXID"`
+		} `json:"files"`
+	}
+	if err := json.NewDecoder(spdxResp.Body).Decode(&doc); err != nil {
+		t.Fatalf("failed to decode SPDX: %v", err)
+	}
+	if doc.SPDXVersion != constants.SPDXVersion || len(doc.Files) != 3 {
+		t.Errorf("expected SPDX document with 3 files, got %+v", doc)
+	}
+}
+
+// TestAssetBOM_TopicRestricted verifies a caller must be able to read every
+// topic in the lineage.
+func TestAssetBOM_TopicRestricted(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "bom-secret")
+	ts.CreateTopic(t, "bom-public")
+
+	source := ts.UploadFileExpectSuccess(t, "bom-secret", "secret.bin", []byte("secret source"), "")
+	derived := ts.UploadFileExpectSuccess(t, "bom-public", "derived.bin", []byte("public derived"), source.Hash)
+
+	user := ts.CreateTestUserWithGrants(t, "bomuser", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionMetadata, "constraints_json": `{"allowed_topics":["bom-public"]}`},
+	})
+
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/assets/"+derived.Hash+"/bom", user.APIKey, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", resp.StatusCode)
+	}
+}
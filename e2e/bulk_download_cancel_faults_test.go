@@ -0,0 +1,109 @@
+//go:build faultinject
+
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// readSSEEvent returns the next event of an SSE stream.
+func readSSEEvent(t *testing.T, r *bufio.Reader) BulkDownloadSSEEvent {
+	t.Helper()
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("SSE stream ended: %v", err)
+		}
+		if data, ok := bytes.CutPrefix(line, []byte("data: ")); ok {
+			var event BulkDownloadSSEEvent
+			if err := json.Unmarshal(data, &event); err != nil {
+				t.Fatalf("failed to parse SSE event: %v", err)
+			}
+			return event
+		}
+	}
+}
+
+// TestBulkDownloadCancel_InProgress verifies cancelling a running SSE
+// download stops it, removes the partial ZIP, emits a cancelled event and
+// audits the progress made.
+func TestBulkDownloadCancel_InProgress(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "cancel-topic")
+	a := ts.UploadFileExpectSuccess(t, "cancel-topic", "a.txt", []byte("first asset"), "")
+	b := ts.UploadFileExpectSuccess(t, "cancel-topic", "b.txt", []byte("second asset"), "")
+
+	// Every progress event is delayed, so the download is still running
+	// when the cancel request arrives
+	ts.AddFault(t, FaultRule{Kind: "slow_stream", Route: "/api/download/bulk/start", DelayMs: 200})
+
+	resp, err := ts.BulkDownloadSSE(t, "ids", "", nil, nil, []string{a.Hash, b.Hash}, false, "original")
+	if err != nil {
+		t.Fatalf("SSE request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	stream := bufio.NewReader(resp.Body)
+
+	start := readSSEEvent(t, stream)
+	if start.Type != "download_start" {
+		t.Fatalf("expected download_start, got %s", start.Type)
+	}
+	downloadID := start.Data["download_id"].(string)
+
+	status, body := ts.cancelBulkDownload(t, downloadID)
+	if status != http.StatusOK || body["status"] != "cancelled" {
+		t.Fatalf("expected 200 cancelled, got %d %v", status, body)
+	}
+	if body["total_assets"] != float64(2) || body["processed_assets"].(float64) >= 2 {
+		t.Errorf("unexpected progress: %v", body)
+	}
+
+	var cancelled *BulkDownloadSSEEvent
+	for cancelled == nil {
+		event := readSSEEvent(t, stream)
+		switch event.Type {
+		case "cancelled":
+			cancelled = &event
+		case "complete", "error":
+			t.Fatalf("expected cancelled, got %s", event.Type)
+		}
+	}
+	if cancelled.Data["download_id"] != downloadID || cancelled.Data["reason"] != constants.BulkDownloadCancelReasonRequested {
+		t.Errorf("unexpected cancelled event: %v", cancelled.Data)
+	}
+
+	// The partial ZIP is gone and the session reports the cancellation
+	zipPath := filepath.Join(ts.App.Config.WorkingDirectory, constants.InternalDir, constants.BulkDownloadTempDir, downloadID+".zip")
+	if _, err := os.Stat(zipPath); !os.IsNotExist(err) {
+		t.Errorf("expected partial ZIP to be removed, stat: %v", err)
+	}
+	errResp := ts.FetchBulkDownloadZIPExpectError(t, downloadID, http.StatusGone)
+	if errResp.Code != constants.ErrCodeDownloadCancelled {
+		t.Errorf("expected %s, got %s", constants.ErrCodeDownloadCancelled, errResp.Code)
+	}
+
+	var audit AuditQueryResponse
+	if err := ts.GetJSON("/api/audit?action="+constants.AuditActionDownloadCancelled, &audit); err != nil {
+		t.Fatalf("audit query failed: %v", err)
+	}
+	if len(audit.Entries) != 1 {
+		t.Fatalf("expected 1 download_cancelled entry, got %d", len(audit.Entries))
+	}
+	details, _ := audit.Entries[0].Details.(map[string]interface{})
+	if details["download_id"] != downloadID || details["reason"] != constants.BulkDownloadCancelReasonRequested ||
+		details["total_assets"] != float64(2) {
+		t.Errorf("unexpected audit details: %v", details)
+	}
+	if details["processed_assets"] != body["processed_assets"] || details["processed_bytes"] != body["processed_bytes"] {
+		t.Errorf("audit progress %v does not match cancel response %v", details, body)
+	}
+}
@@ -0,0 +1,62 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// cancelBulkDownload sends DELETE /api/download/bulk/:id/cancel.
+func (ts *TestServer) cancelBulkDownload(t *testing.T, downloadID string) (int, map[string]interface{}) {
+	t.Helper()
+	resp, err := ts.DELETE("/api/download/bulk/" + downloadID + "/cancel")
+	if err != nil {
+		t.Fatalf("cancel request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]interface{}
+	data, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(data, &body)
+	return resp.StatusCode, body
+}
+
+// TestBulkDownloadCancel_Errors verifies unknown and finished downloads
+// cannot be cancelled.
+func TestBulkDownloadCancel_Errors(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "cancel-topic")
+	a := ts.UploadFileExpectSuccess(t, "cancel-topic", "a.txt", []byte("a"), "")
+
+	if status, body := ts.cancelBulkDownload(t, "0123456789abcdef"); status != http.StatusNotFound || body["code"] != constants.ErrCodeDownloadSessionNotFound {
+		t.Errorf("unknown download: expected 404 %s, got %d %v", constants.ErrCodeDownloadSessionNotFound, status, body)
+	}
+
+	resp, err := ts.BulkDownloadSSE(t, "ids", "", nil, nil, []string{a.Hash}, false, "original")
+	if err != nil {
+		t.Fatalf("SSE request failed: %v", err)
+	}
+	events := ParseBulkDownloadSSEEvents(t, resp)
+	resp.Body.Close()
+	downloadID := GetDownloadIDFromEvents(t, events)
+
+	if status, body := ts.cancelBulkDownload(t, downloadID); status != http.StatusConflict || body["code"] != constants.ErrCodeDownloadNotInProgress {
+		t.Errorf("finished download: expected 409 %s, got %d %v", constants.ErrCodeDownloadNotInProgress, status, body)
+	}
+
+	// The finished ZIP is unaffected
+	ts.FetchBulkDownloadZIP(t, downloadID)
+
+	resp, err = ts.GET("/api/download/bulk/" + downloadID + "/cancel")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,133 @@
+package e2e
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/envelope"
+)
+
+// newBulkRecipient generates an X25519 key pair for an encrypted download.
+func newBulkRecipient(t *testing.T, id string) (*ecdh.PrivateKey, BulkRecipient) {
+	t.Helper()
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return priv, BulkRecipient{ID: id, PublicKey: base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes())}
+}
+
+// decryptZIPEntry decrypts an encrypted archive entry as the given recipient.
+func decryptZIPEntry(t *testing.T, zipBytes []byte, keys BulkKeysManifest, recipient int, priv *ecdh.PrivateKey, path string) []byte {
+	t.Helper()
+	for _, entry := range keys.Entries {
+		if entry.Path != path {
+			continue
+		}
+		r := keys.Recipients[recipient]
+		key, err := envelope.UnwrapKey(priv, r.EphemeralPublicKey, path, entry.WrappedKeys[r.ID])
+		if err != nil {
+			t.Fatalf("unwrap %s as %s: %v", path, r.ID, err)
+		}
+		plaintext, err := envelope.Open(key, ExtractZIPFile(t, zipBytes, path))
+		if err != nil {
+			t.Fatalf("decrypt %s: %v", path, err)
+		}
+		return plaintext
+	}
+	t.Fatalf("no key entry for %s", path)
+	return nil
+}
+
+// TestBulkDownload_Encrypted verifies every recipient can decrypt assets and
+// metadata, and that no plaintext entry is written.
+func TestBulkDownload_Encrypted(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	content := bytes.Repeat([]byte("confidential "), 10000)
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "secret.txt", content, "")
+
+	alicePriv, alice := newBulkRecipient(t, "alice")
+	bobPriv, bob := newBulkRecipient(t, "bob")
+
+	zipBytes := ts.BulkDownloadExpectSuccess(t, BulkDownloadRequest{
+		Mode:            "ids",
+		AssetIDs:        []string{upload.Hash},
+		IncludeMetadata: true,
+		Recipients:      []BulkRecipient{alice, bob},
+	})
+
+	manifest := ExtractZIPManifest(t, zipBytes)
+	if !manifest.Encrypted || manifest.AssetCount != 1 {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	assetPath := "assets/secret.txt" + constants.BulkDownloadEncryptedSuffix
+	if manifest.Assets[0].Filename != assetPath {
+		t.Errorf("expected filename %s, got %s", assetPath, manifest.Assets[0].Filename)
+	}
+
+	var keys BulkKeysManifest
+	if err := json.Unmarshal(ExtractZIPFile(t, zipBytes, constants.BulkDownloadKeysFilename), &keys); err != nil {
+		t.Fatalf("parse keys manifest: %v", err)
+	}
+	if keys.Scheme != constants.EnvelopeScheme || len(keys.Recipients) != 2 || len(keys.Entries) != 2 {
+		t.Fatalf("unexpected keys manifest: %+v", keys)
+	}
+
+	for i, priv := range []*ecdh.PrivateKey{alicePriv, bobPriv} {
+		if got := decryptZIPEntry(t, zipBytes, keys, i, priv, assetPath); !bytes.Equal(got, content) {
+			t.Errorf("recipient %d: decrypted asset does not match", i)
+		}
+	}
+
+	metadata := decryptZIPEntry(t, zipBytes, keys, 0, alicePriv, "metadata/secret.json"+constants.BulkDownloadEncryptedSuffix)
+	var metaFile map[string]interface{}
+	if err := json.Unmarshal(metadata, &metaFile); err != nil {
+		t.Errorf("decrypted metadata is not JSON: %v", err)
+	}
+
+	// Only the manifests are stored in plaintext
+	reader, _ := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	for _, f := range reader.File {
+		if f.Name == constants.ManifestFilename || f.Name == constants.BulkDownloadKeysFilename {
+			continue
+		}
+		data := ExtractZIPFile(t, zipBytes, f.Name)
+		if bytes.Contains(data, []byte("confidential")) {
+			t.Errorf("entry %s contains plaintext", f.Name)
+		}
+	}
+}
+
+// TestBulkDownload_EncryptedInvalidRecipient verifies bad recipients are
+// rejected before any data is streamed.
+func TestBulkDownload_EncryptedInvalidRecipient(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "a.txt", []byte("data"), "")
+
+	_, alice := newBulkRecipient(t, "alice")
+	for name, recipients := range map[string][]BulkRecipient{
+		"bad key":      {{ID: "x", PublicKey: "bm90IGEga2V5"}},
+		"missing id":   {{PublicKey: alice.PublicKey}},
+		"duplicate id": {alice, alice},
+	} {
+		errResp := ts.BulkDownloadExpectError(t, BulkDownloadRequest{
+			Mode:       "ids",
+			AssetIDs:   []string{upload.Hash},
+			Recipients: recipients,
+		}, 400)
+		if errResp.Code != constants.ErrCodeInvalidRequest {
+			t.Errorf("%s: expected %s, got %s", name, constants.ErrCodeInvalidRequest, errResp.Code)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package e2e
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/envelope"
+)
+
+// extractTar returns the entries of a tar archive by name, gunzipping it
+// first when gzipped is set.
+func extractTar(t *testing.T, data []byte, gzipped bool) map[string][]byte {
+	t.Helper()
+	var r io.Reader = bytes.NewReader(data)
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatalf("expected a gzip stream: %v", err)
+		}
+		r = gz
+	}
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatalf("reading tar: %v", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("reading %s: %v", header.Name, err)
+		}
+		entries[header.Name] = content
+	}
+}
+
+// TestBulkDownload_TarFormats verifies direct downloads as tar and tar.gz
+// carry the assets, metadata and manifest with matching headers, that
+// encrypted entries fit their tar headers, and that inbox exports stay ZIP.
+func TestBulkDownload_TarFormats(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	content := bytes.Repeat([]byte("simulation output "), 5000)
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "run.dat", content, "")
+	other := ts.UploadFileExpectSuccess(t, "test-topic", "notes.txt", []byte("notes"), "")
+
+	for _, format := range []string{constants.ArchiveFormatTar, constants.ArchiveFormatTarGz} {
+		resp, err := ts.BulkDownload(t, BulkDownloadRequest{
+			Mode:            "ids",
+			AssetIDs:        []string{upload.Hash, other.Hash},
+			IncludeMetadata: true,
+			ArchiveFormat:   format,
+		})
+		if err != nil {
+			t.Fatalf("bulk download request failed: %v", err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", format, resp.StatusCode, data)
+		}
+		wantType := constants.MimeTypeTar
+		if format == constants.ArchiveFormatTarGz {
+			wantType = constants.MimeTypeGzip
+		}
+		if got := resp.Header.Get(constants.HeaderContentType); got != wantType {
+			t.Errorf("%s: expected Content-Type %s, got %s", format, wantType, got)
+		}
+		if got := resp.Header.Get(constants.HeaderContentDisposition); !strings.Contains(got, "download."+format) {
+			t.Errorf("%s: unexpected Content-Disposition %q", format, got)
+		}
+
+		entries := extractTar(t, data, format == constants.ArchiveFormatTarGz)
+		if !bytes.Equal(entries["assets/run.dat"], content) || string(entries["assets/notes.txt"]) != "notes" {
+			t.Errorf("%s: asset content mismatch", format)
+		}
+		if _, ok := entries["metadata/run.json"]; !ok {
+			t.Errorf("%s: expected a metadata entry, got %d entries", format, len(entries))
+		}
+		var manifest BulkDownloadManifest
+		if err := json.Unmarshal(entries[constants.ManifestFilename], &manifest); err != nil || manifest.AssetCount != 2 {
+			t.Errorf("%s: unexpected manifest %+v, %v", format, manifest, err)
+		}
+	}
+
+	// Encrypted entries are larger than the assets; their headers must say so
+	priv, alice := newBulkRecipient(t, "alice")
+	resp, err := ts.BulkDownload(t, BulkDownloadRequest{
+		Mode:          "ids",
+		AssetIDs:      []string{upload.Hash},
+		Recipients:    []BulkRecipient{alice},
+		ArchiveFormat: constants.ArchiveFormatTar,
+	})
+	if err != nil {
+		t.Fatalf("bulk download request failed: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	entries := extractTar(t, data, false)
+	var keys BulkKeysManifest
+	if err := json.Unmarshal(entries[constants.BulkDownloadKeysFilename], &keys); err != nil || len(keys.Entries) != 1 {
+		t.Fatalf("unexpected keys manifest %+v, %v", keys, err)
+	}
+	path := keys.Entries[0].Path
+	key, err := envelope.UnwrapKey(priv, keys.Recipients[0].EphemeralPublicKey, path, keys.Entries[0].WrappedKeys["alice"])
+	if err != nil {
+		t.Fatalf("unwrap: %v", err)
+	}
+	if plaintext, err := envelope.Open(key, entries[path]); err != nil || !bytes.Equal(plaintext, content) {
+		t.Errorf("expected the encrypted tar entry to decrypt, got %v", err)
+	}
+
+	errResp := ts.BulkDownloadExpectError(t, BulkDownloadRequest{Mode: "ids", AssetIDs: []string{upload.Hash}, ArchiveFormat: "rar"}, http.StatusBadRequest)
+	if errResp.Code != constants.ErrCodeInvalidRequest {
+		t.Errorf("expected %s for an unknown format, got %s", constants.ErrCodeInvalidRequest, errResp.Code)
+	}
+	ts.BulkDownloadExpectError(t, BulkDownloadRequest{
+		Mode:          "ids",
+		AssetIDs:      []string{upload.Hash},
+		ArchiveFormat: constants.ArchiveFormatTar,
+		Destination:   constants.ExportDestinationInbox,
+	}, http.StatusBadRequest)
+}
+
+// TestBulkDownloadSSE_TarFormat verifies the SSE flow builds and serves the
+// requested archive format.
+func TestBulkDownloadSSE_TarFormat(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "file1.txt", []byte("Hello tar"), "")
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/download/bulk/start?mode=ids&asset_ids="+upload.Hash+"&filename_format=original&archive_format=tar.gz", nil)
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("SSE request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	downloadID := GetDownloadIDFromEvents(t, ParseBulkDownloadSSEEvents(t, resp))
+
+	fetch, err := ts.GET("/api/download/bulk/" + downloadID)
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	data, _ := io.ReadAll(fetch.Body)
+	fetch.Body.Close()
+	if fetch.StatusCode != http.StatusOK || fetch.Header.Get(constants.HeaderContentType) != constants.MimeTypeGzip {
+		t.Fatalf("expected a gzip archive, got %d %s", fetch.StatusCode, fetch.Header.Get(constants.HeaderContentType))
+	}
+	if entries := extractTar(t, data, true); string(entries["assets/file1.txt"]) != "Hello tar" {
+		t.Errorf("expected the asset in the archive, got %d entries", len(entries))
+	}
+}
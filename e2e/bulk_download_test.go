@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"silobang/internal/constants"
 )
@@ -641,6 +644,60 @@ func TestBulkDownload_LargeAsset(t *testing.T) {
 	}
 }
 
+// TestBulkDownload_StreamedWithoutTempFile verifies the direct bulk
+// download writes no temporary ZIP, and stops and is audited as cancelled
+// when the client disconnects mid-stream.
+func TestBulkDownload_StreamedWithoutTempFile(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	var hashes []string
+	for i := 0; i < 24; i++ {
+		hashes = append(hashes, ts.UploadFileExpectSuccess(t, "test-topic", fmt.Sprintf("part%02d.bin", i), GenerateTestFile(2<<20), "").Hash)
+	}
+
+	zipBytes := ts.BulkDownloadExpectSuccess(t, BulkDownloadRequest{Mode: "ids", AssetIDs: hashes[:2]})
+	if manifest := ExtractZIPManifest(t, zipBytes); manifest.AssetCount != 2 {
+		t.Errorf("expected 2 assets, got %d", manifest.AssetCount)
+	}
+	tempDir := filepath.Join(ts.App.Config.WorkingDirectory, constants.InternalDir, constants.BulkDownloadTempDir)
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("expected no temporary ZIP, found %d entries", len(entries))
+	}
+
+	resp, err := ts.BulkDownload(t, BulkDownloadRequest{Mode: "ids", AssetIDs: hashes})
+	if err != nil {
+		t.Fatalf("bulk download request failed: %v", err)
+	}
+	if _, err := io.ReadFull(resp.Body, make([]byte, 64<<10)); err != nil {
+		t.Fatalf("failed to read the start of the stream: %v", err)
+	}
+	resp.Body.Close()
+
+	var audit AuditQueryResponse
+	deadline := time.Now().Add(10 * time.Second)
+	for len(audit.Entries) == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+		if err := ts.GetJSON("/api/audit?action="+constants.AuditActionDownloadCancelled, &audit); err != nil {
+			t.Fatalf("audit query failed: %v", err)
+		}
+	}
+	if len(audit.Entries) != 1 {
+		t.Fatalf("expected 1 download_cancelled entry, got %d", len(audit.Entries))
+	}
+	details, _ := audit.Entries[0].Details.(map[string]interface{})
+	if details["reason"] != constants.BulkDownloadCancelReasonDisconnected || details["total_assets"] != float64(len(hashes)) {
+		t.Errorf("unexpected audit details: %v", details)
+	}
+	if processed, _ := details["processed_assets"].(float64); processed >= float64(len(hashes)) {
+		t.Errorf("expected the stream to stop early, processed %v assets", processed)
+	}
+	if _, ok := details["download_id"]; ok {
+		t.Errorf("expected no download_id for a streamed download: %v", details)
+	}
+}
+
 // TestBulkDownload_QueryModeEmptyAssetIDs tests that mode=ids requires asset_ids
 func TestBulkDownload_QueryModeEmptyAssetIDs(t *testing.T) {
 	ts := StartTestServer(t)
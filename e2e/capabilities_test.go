@@ -0,0 +1,141 @@
+package e2e
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+type capabilityLimits struct {
+	MaxFileSizeBytes  int64    `json:"max_file_size_bytes"`
+	AllowedExtensions []string `json:"allowed_extensions"`
+	DailyCountLimit   int64    `json:"daily_count_limit"`
+	UsedCountToday    int64    `json:"used_count_today"`
+	QuotaExceeded     bool     `json:"quota_exceeded"`
+}
+
+type capabilitiesResponse struct {
+	CanUploadTopics   []string                    `json:"can_upload_topics"`
+	CanDownloadTopics []string                    `json:"can_download_topics"`
+	CanMetadataTopics []string                    `json:"can_metadata_topics"`
+	CanRunPresets     []string                    `json:"can_run_presets"`
+	CanBulkDownload   bool                        `json:"can_bulk_download"`
+	CanCreateTopics   bool                        `json:"can_create_topics"`
+	CanManageUsers    bool                        `json:"can_manage_users"`
+	CanViewAllAudit   bool                        `json:"can_view_all_audit"`
+	CanManageConfig   bool                        `json:"can_manage_config"`
+	CanDebug          bool                        `json:"can_debug"`
+	Limits            map[string]capabilityLimits `json:"limits"`
+}
+
+// TestCapabilities_ReflectGrantConstraints verifies the capability map of a
+// constrained user matches what the endpoints enforce.
+func TestCapabilities_ReflectGrantConstraints(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "cap-allowed")
+	ts.CreateTopic(t, "cap-other")
+
+	user := ts.CreateTestUserWithGrants(t, "capuser", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionUpload, "constraints_json": `{"allowed_topics":["cap-allowed"],"allowed_extensions":["bin"],"max_file_size_bytes":1024,"daily_count_limit":1}`},
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_presets":["recent-imports"]}`},
+		{"action": constants.AuthActionDownload},
+	})
+
+	var caps capabilitiesResponse
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/auth/me/capabilities", user.APIKey, nil)
+	decodePreflight(t, resp, err, http.StatusOK, &caps)
+
+	if !slices.Equal(caps.CanUploadTopics, []string{"cap-allowed"}) {
+		t.Errorf("expected upload topics [cap-allowed], got %v", caps.CanUploadTopics)
+	}
+	if !slices.Equal(caps.CanDownloadTopics, []string{"cap-allowed", "cap-other"}) {
+		t.Errorf("expected download on both topics, got %v", caps.CanDownloadTopics)
+	}
+	if len(caps.CanMetadataTopics) != 0 {
+		t.Errorf("expected no metadata topics, got %v", caps.CanMetadataTopics)
+	}
+	if !slices.Equal(caps.CanRunPresets, []string{"recent-imports"}) {
+		t.Errorf("expected presets [recent-imports], got %v", caps.CanRunPresets)
+	}
+	if caps.CanBulkDownload || caps.CanCreateTopics || caps.CanManageUsers || caps.CanManageConfig || caps.CanDebug {
+		t.Errorf("expected management capabilities to be denied, got %+v", caps)
+	}
+
+	upload, ok := caps.Limits[constants.AuthActionUpload]
+	if !ok {
+		t.Fatalf("expected upload limits, got %v", caps.Limits)
+	}
+	if upload.MaxFileSizeBytes != 1024 || !slices.Equal(upload.AllowedExtensions, []string{"bin"}) || upload.DailyCountLimit != 1 {
+		t.Errorf("unexpected upload limits: %+v", upload)
+	}
+	if _, ok := caps.Limits[constants.AuthActionMetadata]; ok {
+		t.Error("expected no limits for an action without a grant")
+	}
+
+	// Using up the daily quota removes the upload capability
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", "one.bin")
+	part.Write([]byte("capabilities quota"))
+	writer.Close()
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/topics/cap-allowed/assets", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set(constants.HeaderXAPIKey, user.APIKey)
+	uploadResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	uploadResp.Body.Close()
+	if uploadResp.StatusCode != http.StatusOK {
+		t.Fatalf("expected upload to succeed, got %d", uploadResp.StatusCode)
+	}
+
+	resp, err = ts.RequestWithAPIKey(http.MethodGet, "/api/auth/me/capabilities", user.APIKey, nil)
+	decodePreflight(t, resp, err, http.StatusOK, &caps)
+	if len(caps.CanUploadTopics) != 0 {
+		t.Errorf("expected no upload topics after quota is used, got %v", caps.CanUploadTopics)
+	}
+	if upload := caps.Limits[constants.AuthActionUpload]; !upload.QuotaExceeded || upload.UsedCountToday != 1 {
+		t.Errorf("expected exceeded upload quota with 1 use, got %+v", upload)
+	}
+}
+
+// TestCapabilities_AdminHasEverything verifies the bootstrap admin is granted
+// every capability.
+func TestCapabilities_AdminHasEverything(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "cap-admin")
+
+	var caps capabilitiesResponse
+	if err := ts.GetJSON("/api/auth/me/capabilities", &caps); err != nil {
+		t.Fatalf("GET capabilities failed: %v", err)
+	}
+
+	if !slices.Contains(caps.CanUploadTopics, "cap-admin") || len(caps.CanRunPresets) == 0 {
+		t.Errorf("expected admin to upload and query everywhere, got %+v", caps)
+	}
+	if !caps.CanBulkDownload || !caps.CanCreateTopics || !caps.CanManageUsers || !caps.CanViewAllAudit || !caps.CanManageConfig || !caps.CanDebug {
+		t.Errorf("expected every management capability, got %+v", caps)
+	}
+}
+
+// TestCapabilities_RequiresAuth verifies anonymous callers are rejected.
+func TestCapabilities_RequiresAuth(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	resp, err := ts.UnauthenticatedGET("/api/auth/me/capabilities")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,97 @@
+package e2e
+
+import (
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type chunkDedupStatus struct {
+	Running bool `json:"running"`
+	Report  *struct {
+		SampleSize int `json:"sample_size"`
+		Topics     []struct {
+			Topic         string  `json:"topic"`
+			SampledAssets int     `json:"sampled_assets"`
+			SavingsRatio  float64 `json:"savings_ratio"`
+			Error         string  `json:"error"`
+		} `json:"topics"`
+		Overall struct {
+			SampledBytes int64 `json:"sampled_bytes"`
+			SavingsBytes int64 `json:"savings_bytes"`
+		} `json:"overall"`
+	} `json:"report"`
+}
+
+// TestChunkDedup_AnalysisReport runs the analysis over near-duplicate assets
+// and checks the report exposes the savings.
+func TestChunkDedup_AnalysisReport(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "dedup")
+
+	base := make([]byte, 256*1024)
+	rand.New(rand.NewSource(7)).Read(base)
+	ts.UploadFileExpectSuccess(t, "dedup", "a.bin", base, "")
+	ts.UploadFileExpectSuccess(t, "dedup", "b.bin", append([]byte("prefix"), base...), "")
+
+	var initial chunkDedupStatus
+	if err := ts.GetJSON("/api/stats/chunk-dedup", &initial); err != nil {
+		t.Fatalf("get status failed: %v", err)
+	}
+	if initial.Report != nil {
+		t.Fatal("expected no report before the first analysis")
+	}
+
+	resp, err := ts.POST("/api/stats/chunk-dedup", map[string]interface{}{"sample_size": 10})
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+
+	var status chunkDedupStatus
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		status = chunkDedupStatus{}
+		if err := ts.GetJSON("/api/stats/chunk-dedup", &status); err != nil {
+			t.Fatalf("get status failed: %v", err)
+		}
+		if !status.Running && status.Report != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("analysis did not finish in time")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if status.Report.SampleSize != 10 || len(status.Report.Topics) != 1 {
+		t.Fatalf("unexpected report: %+v", status.Report)
+	}
+	topic := status.Report.Topics[0]
+	if topic.Error != "" || topic.SampledAssets != 2 {
+		t.Errorf("unexpected topic result: %+v", topic)
+	}
+	if status.Report.Overall.SavingsBytes <= 0 {
+		t.Error("expected savings for near-duplicate assets")
+	}
+}
+
+// TestChunkDedup_RejectsInvalidSampleSize verifies request validation.
+func TestChunkDedup_RejectsInvalidSampleSize(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	resp, err := ts.POST("/api/stats/chunk-dedup", map[string]interface{}{"sample_size": -5})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", resp.StatusCode)
+	}
+}
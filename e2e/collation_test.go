@@ -0,0 +1,81 @@
+package e2e
+
+import (
+	"slices"
+	"testing"
+
+	"silobang/internal/config"
+)
+
+// originNames returns the origin_name column of a query result.
+func originNames(t *testing.T, result QueryResponse) []string {
+	t.Helper()
+	col := slices.Index(result.Columns, "origin_name")
+	if col < 0 {
+		t.Fatalf("origin_name column missing: %v", result.Columns)
+	}
+	names := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		names = append(names, row[col].(string))
+	}
+	return names
+}
+
+// TestCollation_ByOriginNameFoldsPerTopic verifies that by-origin-name folds
+// case and accents on a topic with a topic_collation, while another topic
+// keeps byte-wise matching.
+func TestCollation_ByOriginNameFoldsPerTopic(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "names-de")
+	ts.CreateTopic(t, "names-plain")
+
+	for _, topic := range []string{"names-de", "names-plain"} {
+		ts.UploadFileExpectSuccess(t, topic, "Müller.txt", []byte(topic+" müller"), "")
+		ts.UploadFileExpectSuccess(t, topic, "MULLER-scan.txt", []byte(topic+" muller"), "")
+		ts.UploadFileExpectSuccess(t, topic, "Zebra.txt", []byte(topic+" zebra"), "")
+		ts.UploadFileExpectSuccess(t, topic, "Äpfel.txt", []byte(topic+" apfel"), "")
+	}
+
+	ts.App.Config.TopicCollation = map[string]config.CollationConfig{
+		"names-de": {Locale: "de", FoldCase: true, FoldDiacritics: true},
+	}
+
+	folded := originNames(t, ts.ExecuteQuery(t, "by-origin-name", []string{"names-de"}, map[string]interface{}{"name": "muller"}))
+	if len(folded) != 2 {
+		t.Errorf("expected Müller and MULLER-scan on the collated topic, got %v", folded)
+	}
+
+	plain := originNames(t, ts.ExecuteQuery(t, "by-origin-name", []string{"names-plain"}, map[string]interface{}{"name": "muller"}))
+	if len(plain) != 1 || plain[0] != "MULLER-scan" {
+		t.Errorf("expected byte-wise match on MULLER-scan only, got %v", plain)
+	}
+
+	// Collated order places Äpfel with the a's; byte-wise it sorts after Zebra
+	sorted := originNames(t, ts.ExecuteQuery(t, "by-origin-name", []string{"names-de"}, map[string]interface{}{"name": "e"}))
+	if len(sorted) != 4 || sorted[0] != "Äpfel" {
+		t.Errorf("expected Äpfel first on the collated topic, got %v", sorted)
+	}
+}
+
+// TestCollation_JapaneseKanaFolding verifies katakana, hiragana and
+// half-width names match each other on a ja topic.
+func TestCollation_JapaneseKanaFolding(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "names-ja")
+
+	ts.UploadFileExpectSuccess(t, "names-ja", "カタログ.pdf", []byte("katakana"), "")
+	ts.UploadFileExpectSuccess(t, "names-ja", "かたろぐ_v2.pdf", []byte("hiragana"), "")
+	ts.UploadFileExpectSuccess(t, "names-ja", "ｶﾀﾛｸﾞ_old.pdf", []byte("half-width"), "")
+	ts.UploadFileExpectSuccess(t, "names-ja", "請求書.pdf", []byte("kanji"), "")
+
+	ts.App.Config.TopicCollation = map[string]config.CollationConfig{
+		"names-ja": {Locale: "ja", FoldCase: true},
+	}
+
+	names := originNames(t, ts.ExecuteQuery(t, "by-origin-name", []string{"names-ja"}, map[string]interface{}{"name": "カタログ"}))
+	if len(names) != 3 {
+		t.Errorf("expected the three catalog names to match, got %v", names)
+	}
+}
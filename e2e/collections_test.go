@@ -0,0 +1,314 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// collectionResponse mirrors a single collection in API responses
+type collectionResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	AssetCount  int64    `json:"asset_count"`
+	AssetIDs    []string `json:"asset_ids"`
+}
+
+// createCollection creates a collection and fails the test on error
+func createCollection(t *testing.T, ts *TestServer, topic, name, description string) {
+	t.Helper()
+	resp, err := ts.POST("/api/topics/"+topic+"/collections", map[string]string{
+		"name":        name,
+		"description": description,
+	})
+	if err != nil {
+		t.Fatalf("create collection request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("create collection failed with status %d: %s", resp.StatusCode, string(body))
+	}
+}
+
+// addToCollection adds assets to a collection and returns the number of new memberships
+func addToCollection(t *testing.T, ts *TestServer, topic, name string, hashes []string) int64 {
+	t.Helper()
+	resp, err := ts.POST("/api/topics/"+topic+"/collections/"+name+"/assets", map[string]interface{}{
+		"asset_ids": hashes,
+	})
+	if err != nil {
+		t.Fatalf("add to collection request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("add to collection failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	var result struct {
+		Changed int64 `json:"changed"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	return result.Changed
+}
+
+// TestCollections_CRUD tests create, list, get, update and delete of collections
+func TestCollections_CRUD(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+
+	createCollection(t, ts, "models", "heroes", "main characters")
+	createCollection(t, ts, "models", "props", "")
+
+	// Duplicate name is a conflict
+	resp, err := ts.POST("/api/topics/models/collections", map[string]string{"name": "heroes"})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected 409 for duplicate collection, got %d", resp.StatusCode)
+	}
+
+	// Invalid name is rejected
+	resp, err = ts.POST("/api/topics/models/collections", map[string]string{"name": "Bad Name"})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	var errResp ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || errResp.Code != constants.ErrCodeInvalidCollectionName {
+		t.Errorf("expected 400 %s, got %d %s", constants.ErrCodeInvalidCollectionName, resp.StatusCode, errResp.Code)
+	}
+
+	// List
+	var list struct {
+		Collections []collectionResponse `json:"collections"`
+	}
+	if err := ts.GetJSON("/api/topics/models/collections", &list); err != nil {
+		t.Fatalf("list collections failed: %v", err)
+	}
+	if len(list.Collections) != 2 {
+		t.Fatalf("expected 2 collections, got %d", len(list.Collections))
+	}
+	if list.Collections[0].Name != "heroes" || list.Collections[0].Description != "main characters" {
+		t.Errorf("unexpected first collection: %+v", list.Collections[0])
+	}
+
+	// Update
+	resp, err = ts.PATCH("/api/topics/models/collections/props", map[string]string{"description": "set dressing"})
+	if err != nil {
+		t.Fatalf("update request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 on update, got %d", resp.StatusCode)
+	}
+
+	var detail collectionResponse
+	if err := ts.GetJSON("/api/topics/models/collections/props", &detail); err != nil {
+		t.Fatalf("get collection failed: %v", err)
+	}
+	if detail.Description != "set dressing" {
+		t.Errorf("expected updated description, got %q", detail.Description)
+	}
+
+	// Delete
+	resp, err = ts.DELETE("/api/topics/models/collections/props")
+	if err != nil {
+		t.Fatalf("delete request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 on delete, got %d", resp.StatusCode)
+	}
+
+	resp, err = ts.GET("/api/topics/models/collections/props")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", resp.StatusCode)
+	}
+}
+
+// TestCollections_Membership tests many-to-many membership and same-topic restriction
+func TestCollections_Membership(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+	ts.CreateTopic(t, "textures")
+
+	a := ts.UploadFileExpectSuccess(t, "models", "a.glb", []byte("asset a"), "")
+	b := ts.UploadFileExpectSuccess(t, "models", "b.glb", []byte("asset b"), "")
+	other := ts.UploadFileExpectSuccess(t, "textures", "c.png", []byte("asset c"), "")
+
+	createCollection(t, ts, "models", "heroes", "")
+	createCollection(t, ts, "models", "favorites", "")
+
+	if added := addToCollection(t, ts, "models", "heroes", []string{a.Hash, b.Hash}); added != 2 {
+		t.Errorf("expected 2 added, got %d", added)
+	}
+	// Same asset can belong to several collections
+	if added := addToCollection(t, ts, "models", "favorites", []string{a.Hash}); added != 1 {
+		t.Errorf("expected 1 added, got %d", added)
+	}
+	// Re-adding is idempotent
+	if added := addToCollection(t, ts, "models", "heroes", []string{a.Hash}); added != 0 {
+		t.Errorf("expected 0 added on re-add, got %d", added)
+	}
+	// Assets from another topic are reported as missing
+	if added := addToCollection(t, ts, "models", "heroes", []string{other.Hash}); added != 0 {
+		t.Errorf("expected cross-topic asset to be ignored, got %d added", added)
+	}
+
+	var detail collectionResponse
+	if err := ts.GetJSON("/api/topics/models/collections/heroes", &detail); err != nil {
+		t.Fatalf("get collection failed: %v", err)
+	}
+	if detail.AssetCount != 2 || len(detail.AssetIDs) != 2 {
+		t.Fatalf("expected 2 members, got count=%d ids=%d", detail.AssetCount, len(detail.AssetIDs))
+	}
+
+	// Remove one
+	resp, err := ts.RequestWithAPIKey(http.MethodDelete, "/api/topics/models/collections/heroes/assets", ts.APIKey, map[string]interface{}{
+		"asset_ids": []string{b.Hash},
+	})
+	if err != nil {
+		t.Fatalf("remove request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 on remove, got %d", resp.StatusCode)
+	}
+
+	if err := ts.GetJSON("/api/topics/models/collections/heroes", &detail); err != nil {
+		t.Fatalf("get collection failed: %v", err)
+	}
+	if detail.AssetCount != 1 || detail.AssetIDs[0] != a.Hash {
+		t.Errorf("expected only asset a to remain, got %+v", detail)
+	}
+
+	// Deleting a collection keeps the assets
+	resp, err = ts.DELETE("/api/topics/models/collections/heroes")
+	if err != nil {
+		t.Fatalf("delete request failed: %v", err)
+	}
+	resp.Body.Close()
+	if got := ts.DownloadAsset(t, a.Hash); string(got) != "asset a" {
+		t.Errorf("asset content changed after collection delete")
+	}
+}
+
+// TestCollections_QueryFilter tests narrowing query results to a collection
+func TestCollections_QueryFilter(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+
+	a := ts.UploadFileExpectSuccess(t, "models", "a.glb", []byte("asset a"), "")
+	ts.UploadFileExpectSuccess(t, "models", "b.glb", []byte("asset b"), "")
+
+	createCollection(t, ts, "models", "heroes", "")
+	addToCollection(t, ts, "models", "heroes", []string{a.Hash})
+
+	resp, err := ts.POST("/api/query/recent-imports", map[string]interface{}{
+		"collection": "heroes",
+	})
+	if err != nil {
+		t.Fatalf("query request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("query failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result QueryResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatalf("failed to parse query response: %v", err)
+	}
+	if result.RowCount != 1 {
+		t.Fatalf("expected 1 row, got %d", result.RowCount)
+	}
+	if result.Rows[0][0] != a.Hash {
+		t.Errorf("expected asset a in results, got %v", result.Rows[0][0])
+	}
+
+	// The preset's LIMIT applies to the collection, not to the whole topic:
+	// the newest asset is not a member, yet the newest member is returned
+	if _, err := ts.GetTopicDB(t, "models").Exec(`UPDATE assets SET created_at = created_at - 60 WHERE asset_id = ?`, a.Hash); err != nil {
+		t.Fatalf("failed to set created_at: %v", err)
+	}
+	if err := ts.PostJSON("/api/query/recent-imports", map[string]interface{}{
+		"collection": "heroes",
+		"params":     map[string]interface{}{"limit": 1},
+	}, &result); err != nil {
+		t.Fatalf("limited query failed: %v", err)
+	}
+	if result.RowCount != 1 || result.Rows[0][0] != a.Hash {
+		t.Errorf("expected asset a within the limit, got %v", result.Rows)
+	}
+}
+
+// TestCollections_BulkDownloadPaths tests collection filters and directories in bulk ZIPs
+func TestCollections_BulkDownloadPaths(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+
+	a := ts.UploadFileExpectSuccess(t, "models", "a.glb", []byte("asset a"), "")
+	b := ts.UploadFileExpectSuccess(t, "models", "b.glb", []byte("asset b"), "")
+	c := ts.UploadFileExpectSuccess(t, "models", "c.glb", []byte("asset c"), "")
+
+	createCollection(t, ts, "models", "heroes", "")
+	createCollection(t, ts, "models", "props", "")
+	addToCollection(t, ts, "models", "heroes", []string{a.Hash})
+	addToCollection(t, ts, "models", "props", []string{b.Hash})
+
+	// Collection paths without filter: uncategorized assets stay at the root
+	zipBytes := ts.BulkDownloadExpectSuccess(t, BulkDownloadRequest{
+		Mode:            "ids",
+		AssetIDs:        []string{a.Hash, b.Hash, c.Hash},
+		CollectionPaths: true,
+	})
+	files := ListZIPFiles(t, zipBytes)
+	sort.Strings(files)
+	expected := []string{"assets/c.glb", "assets/heroes/a.glb", "assets/props/b.glb", "manifest.json"}
+	if len(files) != len(expected) {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+	for i := range expected {
+		if files[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], files[i])
+		}
+	}
+
+	// Collection filter restricts the archive to members
+	zipBytes = ts.BulkDownloadExpectSuccess(t, BulkDownloadRequest{
+		Mode:       "query",
+		Preset:     "recent-imports",
+		Collection: "props",
+	})
+	manifest := ExtractZIPManifest(t, zipBytes)
+	if manifest.AssetCount != 1 || manifest.Assets[0].Hash != b.Hash {
+		t.Errorf("expected only asset b, got %+v", manifest.Assets)
+	}
+
+	// Filter on a collection with no members yields an empty download
+	createCollection(t, ts, "models", "empty", "")
+	ts.BulkDownloadExpectError(t, BulkDownloadRequest{
+		Mode:       "ids",
+		AssetIDs:   []string{a.Hash},
+		Collection: "empty",
+	}, http.StatusBadRequest)
+}
@@ -0,0 +1,276 @@
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// compactionStatus mirrors GET /api/topics/:name/compaction
+type compactionStatus struct {
+	Topic            string `json:"topic"`
+	Running          bool   `json:"running"`
+	Tombstones       int    `json:"tombstones"`
+	ReclaimableBytes int64  `json:"reclaimable_bytes"`
+	DatFiles         []struct {
+		DatFile    string `json:"dat_file"`
+		Tombstones int    `json:"tombstones"`
+		DeadBytes  int64  `json:"dead_bytes"`
+	} `json:"dat_files"`
+	LastResult *struct {
+		Trigger        string `json:"trigger"`
+		EntriesMoved   int    `json:"entries_moved"`
+		BytesReclaimed int64  `json:"bytes_reclaimed"`
+		Error          string `json:"error"`
+		DatFiles       []struct {
+			DatFile string `json:"dat_file"`
+			Error   string `json:"error"`
+		} `json:"dat_files"`
+	} `json:"last_result"`
+}
+
+// deleteAsset deletes an asset permanently: DELETE /api/assets/:hash moves
+// it to the trash, then DELETE /api/trash/:hash purges it. Returns the
+// status and body of the first request that fails, or of the purge.
+func (ts *TestServer) deleteAsset(t *testing.T, hash string) (int, []byte) {
+	t.Helper()
+	if status, data := ts.deleteRequest(t, "/api/assets/"+hash); status != http.StatusOK {
+		return status, data
+	}
+	return ts.deleteRequest(t, "/api/trash/"+hash)
+}
+
+// deleteRequest sends a DELETE request and returns the status and body.
+func (ts *TestServer) deleteRequest(t *testing.T, path string) (int, []byte) {
+	t.Helper()
+	resp, err := ts.DELETE(path)
+	if err != nil {
+		t.Fatalf("delete request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+func (ts *TestServer) compactionStatus(t *testing.T, topic string) compactionStatus {
+	t.Helper()
+	var status compactionStatus
+	if err := ts.GetJSON("/api/topics/"+topic+"/compaction", &status); err != nil {
+		t.Fatalf("get compaction status failed: %v", err)
+	}
+	return status
+}
+
+// compactTopic starts a compaction run and waits for it to finish.
+func (ts *TestServer) compactTopic(t *testing.T, topic string) compactionStatus {
+	t.Helper()
+	resp, err := ts.POST("/api/topics/"+topic+"/compaction", nil)
+	if err != nil {
+		t.Fatalf("compaction request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		status := ts.compactionStatus(t, topic)
+		if !status.Running && status.LastResult != nil {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("compaction did not finish in time")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// TestDeleteAsset_RefusedWhileReferenced verifies referenced assets cannot be
+// deleted, and that deleting a derived asset releases its parent.
+func TestDeleteAsset_RefusedWhileReferenced(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	parent := ts.UploadFileExpectSuccess(t, "assets", "base.txt", []byte("base content"), "")
+	child := ts.UploadFileExpectSuccess(t, "assets", "child.txt", []byte("child content"), parent.Hash)
+
+	status, body := ts.deleteAsset(t, parent.Hash)
+	if status != http.StatusConflict || !strings.Contains(string(body), constants.ErrCodeAssetReferenced) {
+		t.Fatalf("expected 409 %s, got %d: %s", constants.ErrCodeAssetReferenced, status, body)
+	}
+	ts.DownloadAsset(t, parent.Hash)
+
+	if status, body := ts.deleteAsset(t, child.Hash); status != http.StatusOK {
+		t.Fatalf("delete child: expected 200, got %d: %s", status, body)
+	}
+	ts.DownloadAssetExpectError(t, child.Hash, http.StatusNotFound)
+	if status, _ := ts.deleteAsset(t, child.Hash); status != http.StatusNotFound {
+		t.Errorf("deleting twice: expected 404, got %d", status)
+	}
+
+	if status, body := ts.deleteAsset(t, parent.Hash); status != http.StatusOK {
+		t.Fatalf("delete released parent: expected 200, got %d: %s", status, body)
+	}
+
+	var audit AuditQueryResponse
+	if err := ts.GetJSON("/api/audit?action="+constants.AuditActionAssetDeleted, &audit); err != nil {
+		t.Fatalf("audit query failed: %v", err)
+	}
+	if len(audit.Entries) != 2 {
+		t.Errorf("expected 2 %s audit entries, got %d", constants.AuditActionAssetDeleted, len(audit.Entries))
+	}
+
+	// Deleted content can be uploaded again
+	reuploaded := ts.UploadFileExpectSuccess(t, "assets", "base.txt", []byte("base content"), "")
+	if reuploaded.Skipped {
+		t.Error("re-upload of deleted content was deduplicated")
+	}
+	ts.DownloadAsset(t, parent.Hash)
+}
+
+// TestCompaction_ReclaimsDeletedEntries verifies compaction removes a .dat
+// file holding tombstones, keeps its live assets downloadable and leaves the
+// topic verifiable.
+func TestCompaction_ReclaimsDeletedEntries(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "compact")
+
+	contents := [][]byte{
+		bytes.Repeat([]byte("a"), 4096),
+		bytes.Repeat([]byte("b"), 8192),
+		bytes.Repeat([]byte("c"), 2048),
+	}
+	hashes := make([]string, len(contents))
+	for i, content := range contents {
+		hashes[i] = ts.UploadFileExpectSuccess(t, "compact", "file.bin", content, "").Hash
+	}
+
+	if status, body := ts.deleteAsset(t, hashes[1]); status != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", status, body)
+	}
+
+	status := ts.compactionStatus(t, "compact")
+	deadBytes := int64(constants.HeaderSize) + int64(len(contents[1]))
+	if status.Tombstones != 1 || status.ReclaimableBytes != deadBytes || len(status.DatFiles) != 1 {
+		t.Fatalf("unexpected status before compaction: %+v", status)
+	}
+
+	// Deleted entries are not integrity gaps
+	var report integrityReport
+	if err := ts.PostJSON("/api/topics/compact/integrity", nil, &report); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(report.Findings) != 0 {
+		t.Errorf("expected a clean scan with tombstones, got %+v", report.Findings)
+	}
+
+	status = ts.compactTopic(t, "compact")
+	result := status.LastResult
+	if result.Error != "" || len(result.DatFiles) != 1 || result.DatFiles[0].Error != "" {
+		t.Fatalf("compaction failed: %+v", result)
+	}
+	if result.EntriesMoved != 2 || result.BytesReclaimed != deadBytes {
+		t.Errorf("expected 2 entries moved and %d bytes reclaimed, got %+v", deadBytes, result)
+	}
+	if status.Tombstones != 0 {
+		t.Errorf("expected no tombstones left, got %d", status.Tombstones)
+	}
+	if _, err := os.Stat(filepath.Join(ts.WorkDir, "compact", "000001.dat")); !os.IsNotExist(err) {
+		t.Errorf("expected the compacted file to be removed, stat err: %v", err)
+	}
+
+	for _, i := range []int{0, 2} {
+		if got := ts.DownloadAsset(t, hashes[i]); !bytes.Equal(got, contents[i]) {
+			t.Errorf("asset %d changed after compaction", i)
+		}
+	}
+	ts.DownloadAssetExpectError(t, hashes[1], http.StatusNotFound)
+
+	// Uploads continue after the moved entries
+	extra := ts.UploadFileExpectSuccess(t, "compact", "extra.bin", []byte("after compaction"), "")
+	ts.DownloadAsset(t, extra.Hash)
+
+	resp, err := ts.GET("/api/verify")
+	if err != nil {
+		t.Fatalf("verify request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	complete := findEvent(parseSSEEvents(t, resp), "complete")
+	if complete == nil || complete.Data["topics_valid"] != float64(1) || complete.Data["index_valid"] != true {
+		t.Errorf("expected a valid topic and index after compaction, got %+v", complete)
+	}
+}
+
+// TestCompaction_StreamReportsProgress verifies the stream sends the current
+// status and the events of a run.
+func TestCompaction_StreamReportsProgress(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "stream")
+
+	kept := ts.UploadFileExpectSuccess(t, "stream", "kept.bin", []byte("kept content"), "").Hash
+	gone := ts.UploadFileExpectSuccess(t, "stream", "gone.bin", []byte("deleted content"), "").Hash
+	if status, body := ts.deleteAsset(t, gone); status != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", status, body)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/topics/stream/compaction/stream", nil)
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var types []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var event VerifyEvent
+		if err := json.Unmarshal([]byte(line[6:]), &event); err != nil {
+			t.Fatalf("failed to parse event %q: %v", line, err)
+		}
+		types = append(types, event.Type)
+
+		if event.Type == "status" {
+			if event.Data["tombstones"] != float64(1) {
+				t.Errorf("expected 1 tombstone in status, got %v", event.Data["tombstones"])
+			}
+			if resp, err := ts.POST("/api/topics/stream/compaction", nil); err != nil {
+				t.Fatalf("compaction request failed: %v", err)
+			} else {
+				resp.Body.Close()
+			}
+		}
+		if event.Type == constants.CompactionEventComplete || event.Type == constants.CompactionEventError {
+			break
+		}
+	}
+
+	want := []string{"status", constants.CompactionEventStart, constants.CompactionEventDatComplete, constants.CompactionEventComplete}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Errorf("expected events %v, got %v", want, types)
+	}
+	ts.DownloadAsset(t, kept)
+}
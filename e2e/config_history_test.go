@@ -0,0 +1,128 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"silobang/internal/audit"
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+type configHistoryResponse struct {
+	At      int64                  `json:"at"`
+	Config  map[string]interface{} `json:"config"`
+	Changes []audit.ConfigChange   `json:"changes"`
+	Partial bool                   `json:"partial"`
+}
+
+func changedKeys(change audit.ConfigChange) map[string]interface{} {
+	keys := map[string]interface{}{}
+	for _, fc := range change.Details.Changes {
+		keys[fc.Key] = fc.After
+	}
+	return keys
+}
+
+// TestConfigHistory_DiffsSourcesAndReplay checks config_changed entries carry
+// a redacted diff with their source, and the history endpoint replays them.
+func TestConfigHistory_DiffsSourcesAndReplay(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	if status, body := ts.setStoragePolicy(t, "txt", map[string]interface{}{"max_size_bytes": 16}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	if status, body := ts.setStoragePolicy(t, "bin", map[string]interface{}{"max_size_bytes": 32}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	resp, err := ts.RequestWithAPIKey(http.MethodDelete, "/api/storage-policies/bin", ts.APIKey, nil)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete storage policy failed: %v", err)
+	}
+	resp.Body.Close()
+
+	// Edits made while the server was stopped are recorded at the next start
+	ts.App.Config.Federation.Peers = []config.FederationPeer{{Name: "berlin", URL: "https://berlin.example.com", APIKey: "peer-secret-key"}}
+	ts.App.Services.Config.RecordStartup(false)
+
+	var history configHistoryResponse
+	if err := ts.GetJSON("/api/config/history", &history); err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	if len(history.Changes) != 5 || history.Partial {
+		t.Fatalf("expected 5 recorded changes, got %d (partial=%v)", len(history.Changes), history.Partial)
+	}
+
+	sources := []string{}
+	for _, change := range history.Changes {
+		sources = append(sources, change.Details.Source)
+	}
+	if got := strings.Join(sources, ","); got != "cli,api,api,api,bootstrap" {
+		t.Errorf("unexpected sources newest first: %s", got)
+	}
+
+	bootstrap := history.Changes[4]
+	if !bootstrap.Details.IsBootstrap || changedKeys(bootstrap)["working_directory"] != ts.WorkDir {
+		t.Errorf("expected the bootstrap entry to set working_directory, got %+v", bootstrap.Details)
+	}
+	if keys := changedKeys(history.Changes[3]); len(keys) != 1 || fmt.Sprint(keys["storage_policies.txt.max_size_bytes"]) != "16" {
+		t.Errorf("expected only the txt policy in the diff, got %+v", history.Changes[3].Details.Changes)
+	}
+	deleted := history.Changes[1].Details.Changes
+	if len(deleted) != 1 || deleted[0].Key != "storage_policies.bin.max_size_bytes" || deleted[0].After != nil || fmt.Sprint(deleted[0].Before) != "32" {
+		t.Errorf("expected the bin policy removal, got %+v", deleted)
+	}
+	cli := changedKeys(history.Changes[0])
+	if cli["federation.peers[0].api_key"] != constants.ConfigRedactedValue || cli["federation.peers[0].name"] != "berlin" {
+		t.Errorf("expected the peer with a redacted key, got %+v", cli)
+	}
+
+	// Replayed state
+	if fmt.Sprint(history.Config["storage_policies.txt.max_size_bytes"]) != "16" {
+		t.Errorf("expected the txt policy in the replayed config, got %v", history.Config["storage_policies.txt.max_size_bytes"])
+	}
+	if _, ok := history.Config["storage_policies.bin.max_size_bytes"]; ok {
+		t.Error("expected the deleted bin policy to be absent")
+	}
+	if history.Config["working_directory"] != ts.WorkDir || history.Config["federation.peers[0].api_key"] != constants.ConfigRedactedValue {
+		t.Errorf("unexpected replayed config %v", history.Config)
+	}
+
+	// Before the first entry only defaults remain
+	var early configHistoryResponse
+	if err := ts.GetJSON(fmt.Sprintf("/api/config/history?at=%d&limit=1", bootstrap.Timestamp-1), &early); err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	if len(early.Changes) != 0 || early.Config["working_directory"] != "" {
+		t.Errorf("expected the default config, got %d changes and %v", len(early.Changes), early.Config["working_directory"])
+	}
+	var limited configHistoryResponse
+	if err := ts.GetJSON("/api/config/history?limit=1", &limited); err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	if len(limited.Changes) != 1 || limited.Changes[0].ID != history.Changes[0].ID {
+		t.Errorf("expected only the newest change, got %+v", limited.Changes)
+	}
+
+	// Credentials never reach the audit log
+	ts.App.AuditLogger.Flush()
+	var count int
+	if err := ts.App.OrchestratorDB.QueryRow(`SELECT COUNT(*) FROM audit_log WHERE details_json LIKE '%peer-secret-key%'`).Scan(&count); err != nil || count != 0 {
+		t.Errorf("expected no audit entry with the peer key, got %d (%v)", count, err)
+	}
+
+	viewer := ts.CreateTestUserWithGrants(t, "viewer", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	resp, err = ts.RequestWithAPIKey(http.MethodGet, "/api/config/history", viewer.APIKey, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 without manage_config, got %d", resp.StatusCode)
+	}
+}
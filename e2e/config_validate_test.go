@@ -0,0 +1,91 @@
+package e2e
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+type configCheck struct {
+	Name    string `json:"name"`
+	Field   string `json:"field"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type configValidationReport struct {
+	Valid           bool          `json:"valid"`
+	RequiresRestart bool          `json:"requires_restart"`
+	RestartFields   []string      `json:"restart_fields"`
+	Checks          []configCheck `json:"checks"`
+}
+
+// TestConfigValidate_DryRun verifies that validation reports problems without
+// applying the candidate working directory.
+func TestConfigValidate_DryRun(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	var report configValidationReport
+	if err := ts.PostJSON("/api/config/validate", map[string]interface{}{
+		"working_directory": missing,
+	}, &report); err != nil {
+		t.Fatalf("validate request failed: %v", err)
+	}
+
+	if report.Valid {
+		t.Fatalf("expected invalid report, got %+v", report)
+	}
+	var dirError bool
+	for _, c := range report.Checks {
+		if c.Name == "working_directory" && c.Status == "error" {
+			dirError = true
+		}
+	}
+	if !dirError {
+		t.Errorf("expected working_directory error, got %+v", report.Checks)
+	}
+
+	// Nothing was applied
+	var status struct {
+		WorkingDirectory string `json:"working_directory"`
+	}
+	if err := ts.GetJSON("/api/config", &status); err != nil {
+		t.Fatalf("get config failed: %v", err)
+	}
+	if status.WorkingDirectory != ts.WorkDir {
+		t.Errorf("working directory changed to %q", status.WorkingDirectory)
+	}
+}
+
+// TestConfigValidate_ValidCandidate verifies a writable directory passes.
+func TestConfigValidate_ValidCandidate(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	var report configValidationReport
+	if err := ts.PostJSON("/api/config/validate", map[string]interface{}{
+		"working_directory": t.TempDir(),
+	}, &report); err != nil {
+		t.Fatalf("validate request failed: %v", err)
+	}
+	if !report.Valid {
+		t.Errorf("expected valid report, got %+v", report.Checks)
+	}
+}
+
+// TestConfigValidate_RequiresAuth verifies the endpoint is protected once auth is set up.
+func TestConfigValidate_RequiresAuth(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	resp, err := ts.UnauthenticatedPOST("/api/config/validate", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", resp.StatusCode)
+	}
+}
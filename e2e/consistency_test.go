@@ -0,0 +1,156 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// readAfterWrite uploads content and immediately reads it back through the
+// query, download and stats layers. acked counts the uploads of topic that
+// returned before this one did; the topic stats must already cover them.
+func (ts *TestServer) readAfterWrite(topic, filename string, content []byte, acked *atomic.Int64) error {
+	resp, err := ts.UploadFile(topic, filename, content, "")
+	if err != nil {
+		return fmt.Errorf("upload: %w", err)
+	}
+	var uploaded UploadResponse
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upload: status %d: %s", resp.StatusCode, body)
+	}
+	json.Unmarshal(body, &uploaded)
+	seen := acked.Add(1)
+
+	// Query
+	resp, err = ts.POST("/api/query/by-hash", map[string]interface{}{
+		"params": map[string]interface{}{"hash": uploaded.Hash},
+		"topics": []string{topic},
+	})
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+	var result QueryResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || result.RowCount != 1 {
+		return fmt.Errorf("query: status %d, %d rows for %s", resp.StatusCode, result.RowCount, uploaded.Hash)
+	}
+
+	// Download
+	resp, err = ts.GET("/api/assets/" + uploaded.Hash + "/download")
+	if err != nil {
+		return fmt.Errorf("download: %w", err)
+	}
+	downloaded, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(downloaded, content) {
+		return fmt.Errorf("download: status %d, %d bytes for %s", resp.StatusCode, len(downloaded), uploaded.Hash)
+	}
+
+	// Stats
+	var topics struct {
+		Topics []struct {
+			Name  string                 `json:"name"`
+			Stats map[string]interface{} `json:"stats"`
+		} `json:"topics"`
+	}
+	if err := ts.GetJSON("/api/topics", &topics); err != nil {
+		return fmt.Errorf("stats: %w", err)
+	}
+	for _, info := range topics.Topics {
+		if info.Name != topic {
+			continue
+		}
+		if count, _ := info.Stats["file_count"].(float64); int64(count) < seen {
+			return fmt.Errorf("stats: file_count %v after %d acknowledged uploads", count, seen)
+		}
+		return nil
+	}
+	return fmt.Errorf("stats: topic %s not listed", topic)
+}
+
+// TestConsistency_ReadAfterWriteConcurrent verifies that once an upload
+// returns, the asset is visible to queries, downloads and topic stats, with
+// concurrent uploads to the same and other topics.
+func TestConsistency_ReadAfterWriteConcurrent(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	topics := []string{"renders", "sources"}
+	for _, topic := range topics {
+		ts.CreateTopic(t, topic)
+	}
+
+	const workers, perWorker = 8, 5
+	acked := map[string]*atomic.Int64{}
+	for _, topic := range topics {
+		acked[topic] = &atomic.Int64{}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			topic := topics[w%len(topics)]
+			for i := 0; i < perWorker; i++ {
+				content := append([]byte(fmt.Sprintf("worker %d asset %d ", w, i)), GenerateTestFile(256)...)
+				if err := ts.readAfterWrite(topic, fmt.Sprintf("w%d-%d.bin", w, i), content, acked[topic]); err != nil {
+					errs <- fmt.Errorf("worker %d upload %d: %w", w, i, err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var indexed int
+	ts.GetOrchestratorDB(t).QueryRow("SELECT COUNT(*) FROM asset_index").Scan(&indexed)
+	if indexed != workers*perWorker {
+		t.Errorf("expected %d indexed assets, got %d", workers*perWorker, indexed)
+	}
+}
+
+// TestConsistency_RetryIndexesStoredAsset verifies an asset stored in its
+// topic but missing from the orchestrator index, as left by a failed
+// orchestrator commit, is indexed by retrying the upload rather than stored
+// twice.
+func TestConsistency_RetryIndexesStoredAsset(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "renders")
+
+	content := GenerateTestFile(1024)
+	hash := ts.UploadFileExpectSuccess(t, "renders", "frame.bin", content, "").Hash
+
+	if _, err := ts.GetOrchestratorDB(t).Exec("DELETE FROM asset_index WHERE hash = ?", hash); err != nil {
+		t.Fatal(err)
+	}
+	ts.DownloadAssetExpectError(t, hash, http.StatusNotFound)
+
+	retried := ts.UploadFileExpectSuccess(t, "renders", "frame.bin", content, "")
+	if retried.Status != constants.UploadStatusDeduplicated || retried.Hash != hash {
+		t.Fatalf("expected the retry to deduplicate, got %+v", retried)
+	}
+	if !bytes.Equal(ts.DownloadAsset(t, hash), content) {
+		t.Error("downloaded content differs after the retry")
+	}
+
+	var stored int
+	ts.GetTopicDB(t, "renders").QueryRow("SELECT COUNT(*) FROM assets WHERE asset_id = ?", hash).Scan(&stored)
+	if stored != 1 {
+		t.Errorf("expected the asset stored once, got %d", stored)
+	}
+}
@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// TestDebug_ProfilingEndpoints verifies the debug routes stay hidden until
+// enabled, then serve pprof, runtime metrics and a profile bundle to
+// holders of the debug grant only.
+func TestDebug_ProfilingEndpoints(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	ts.notificationRequest(t, http.MethodGet, "/api/admin/debug/metrics", ts.APIKey, nil, http.StatusNotFound, nil)
+	ts.notificationRequest(t, http.MethodGet, "/api/admin/debug/pprof/heap", ts.APIKey, nil, http.StatusNotFound, nil)
+
+	ts.App.Config.Debug.Enabled = true
+
+	var metrics struct {
+		Info struct {
+			GoVersion  string `json:"go_version"`
+			Goroutines int    `json:"goroutines"`
+		} `json:"info"`
+		Metrics map[string]interface{} `json:"metrics"`
+	}
+	ts.notificationRequest(t, http.MethodGet, "/api/admin/debug/metrics", ts.APIKey, nil, http.StatusOK, &metrics)
+	if !strings.HasPrefix(metrics.Info.GoVersion, "go") || metrics.Info.Goroutines == 0 {
+		t.Errorf("unexpected process info: %+v", metrics.Info)
+	}
+	if _, ok := metrics.Metrics["/sched/goroutines:goroutines"].(float64); !ok {
+		t.Errorf("expected the goroutine count metric, got %v", metrics.Metrics["/sched/goroutines:goroutines"])
+	}
+	if pauses, ok := metrics.Metrics["/sched/pauses/total/gc:seconds"].(map[string]interface{}); !ok || pauses["p99"] == nil {
+		t.Errorf("expected a histogram summary, got %v", metrics.Metrics["/sched/pauses/total/gc:seconds"])
+	}
+
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/admin/debug/pprof/heap", ts.APIKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	heap, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(heap) == 0 {
+		t.Fatalf("expected a heap profile, got %d (%d bytes)", resp.StatusCode, len(heap))
+	}
+	ts.notificationRequest(t, http.MethodGet, "/api/admin/debug/pprof/no-such-profile", ts.APIKey, nil, http.StatusNotFound, nil)
+	ts.notificationRequest(t, http.MethodGet, "/api/admin/debug/bundle?seconds=0", ts.APIKey, nil, http.StatusBadRequest, nil)
+
+	resp, err = ts.RequestWithAPIKey(http.MethodGet, "/api/admin/debug/bundle?seconds=1", ts.APIKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected a bundle, got %d: %s", resp.StatusCode, bundle)
+	}
+	if !strings.Contains(resp.Header.Get("Content-Disposition"), constants.DebugBundleFilenamePrefix) {
+		t.Errorf("unexpected Content-Disposition %q", resp.Header.Get("Content-Disposition"))
+	}
+	zr, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		t.Fatalf("bundle is not a zip: %v", err)
+	}
+	entries := make(map[string]bool)
+	for _, f := range zr.File {
+		entries[f.Name] = true
+	}
+	for _, name := range []string{"cpu.pprof", "heap.pprof", "goroutine.pprof", "goroutines.txt", "metrics.json", "info.json"} {
+		if !entries[name] {
+			t.Errorf("bundle is missing %s, has %v", name, entries)
+		}
+	}
+
+	ts.App.AuditLogger.Flush()
+	var count int
+	ts.App.OrchestratorDB.QueryRow("SELECT COUNT(*) FROM audit_log WHERE action = ?", constants.AuditActionDebugBundle).Scan(&count)
+	if count != 1 {
+		t.Errorf("expected one debug_bundle audit entry, got %d", count)
+	}
+
+	configAdmin := ts.CreateTestUserWithGrants(t, "config-admin", "ConfigAdmin123!", []map[string]interface{}{
+		{"action": constants.AuthActionManageConfig},
+	})
+	ts.notificationRequest(t, http.MethodGet, "/api/admin/debug/metrics", configAdmin.APIKey, nil, http.StatusForbidden, nil)
+	ts.notificationRequest(t, http.MethodGet, "/api/admin/debug/pprof/", configAdmin.APIKey, nil, http.StatusForbidden, nil)
+}
@@ -3,7 +3,11 @@ package e2e
 import (
 	"encoding/json"
 	"io"
+	"net/http"
+	"strings"
 	"testing"
+
+	"silobang/internal/constants"
 )
 
 func TestDuplicateDetection(t *testing.T) {
@@ -107,3 +111,56 @@ func TestDuplicateDetection(t *testing.T) {
 		t.Errorf("Expected 0 asset entries in topic-2 (deduplicated), got %d", count)
 	}
 }
+
+// TestUploadStatus verifies the explicit status and reason reported for new,
+// same-topic duplicate, cross-topic duplicate and rejected uploads.
+func TestUploadStatus(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "status-1")
+	ts.CreateTopic(t, "status-2")
+
+	content := GenerateTestFile(2000)
+
+	created := ts.UploadFileExpectSuccess(t, "status-1", "a.bin", content, "")
+	if created.Status != constants.UploadStatusCreated || created.Reason != constants.UploadReasonNewContent || created.Skipped {
+		t.Errorf("new upload: %+v", created)
+	}
+
+	dedup := ts.UploadFileExpectSuccess(t, "status-1", "a.bin", content, "")
+	if dedup.Status != constants.UploadStatusDeduplicated || dedup.Reason != constants.UploadReasonSameTopic {
+		t.Errorf("same-topic duplicate: %+v", dedup)
+	}
+	if !dedup.Skipped {
+		t.Error("deprecated skipped flag should still be set for duplicates")
+	}
+
+	aliased := ts.UploadFileExpectSuccess(t, "status-2", "a.bin", content, "")
+	if aliased.Status != constants.UploadStatusAliased || aliased.Reason != constants.UploadReasonOtherTopic {
+		t.Errorf("cross-topic duplicate: %+v", aliased)
+	}
+	if aliased.ExistingTopic != "status-1" {
+		t.Errorf("expected existing_topic status-1, got %q", aliased.ExistingTopic)
+	}
+
+	resp, err := ts.UploadFile("status-1", "child.bin", GenerateTestFile(100), strings.Repeat("0", constants.HashLength))
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for missing parent, got %d", resp.StatusCode)
+	}
+	var rejected struct {
+		Error  bool   `json:"error"`
+		Code   string `json:"code"`
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rejected); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !rejected.Error || rejected.Status != constants.UploadStatusRejected || rejected.Reason != constants.ErrCodeParentNotFound {
+		t.Errorf("rejected upload: %+v", rejected)
+	}
+}
@@ -0,0 +1,93 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+)
+
+type assetMetadataResponse struct {
+	ComputedMetadata      map[string]interface{}           `json:"computed_metadata"`
+	MetadataWithProcessor []database.MetadataWithProcessor `json:"metadata_with_processor"`
+}
+
+func (ts *TestServer) assetMetadata(t *testing.T, hash string) assetMetadataResponse {
+	t.Helper()
+	var meta assetMetadataResponse
+	if err := ts.GetJSON("/api/assets/"+hash+"/metadata", &meta); err != nil {
+		t.Fatalf("metadata request failed: %v", err)
+	}
+	return meta
+}
+
+// TestDefaultMetadata_StampedAtUpload verifies the configured default
+// metadata is expanded and recorded on new assets like any other metadata.
+func TestDefaultMetadata_StampedAtUpload(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.App.Config.Metadata.Defaults = map[string]string{
+		"site":     "berlin",
+		"pipeline": "v2",
+		"stamp":    "{{username}}@{{topic}}/{{filename}}",
+	}
+	ts.App.Config.Metadata.TopicDefaults = map[string]map[string]string{
+		"licensed": {"license": "CC-BY-4.0", "pipeline": "v3", "site": ""},
+	}
+	ts.CreateTopic(t, "plain")
+	ts.CreateTopic(t, "licensed")
+
+	before := time.Now().Add(-time.Second)
+	plain := ts.UploadFileExpectSuccess(t, "plain", "model.glb", []byte("plain asset"), "")
+	licensed := ts.UploadFileExpectSuccess(t, "licensed", "photo.jpg", []byte("licensed asset"), "")
+
+	meta := ts.assetMetadata(t, plain.Hash)
+	want := map[string]interface{}{
+		"site":     "berlin",
+		"pipeline": "v2",
+		"stamp":    constants.AuthBootstrapUsername + "@plain/model.glb",
+	}
+	if len(meta.ComputedMetadata) != len(want) {
+		t.Errorf("expected %d keys, got %v", len(want), meta.ComputedMetadata)
+	}
+	for key, value := range want {
+		if meta.ComputedMetadata[key] != value {
+			t.Errorf("%s: expected %v, got %v", key, value, meta.ComputedMetadata[key])
+		}
+	}
+	for _, m := range meta.MetadataWithProcessor {
+		if m.Processor != constants.ProcessorDefaults {
+			t.Errorf("%s: expected processor %s, got %s", m.Key, constants.ProcessorDefaults, m.Processor)
+		}
+	}
+
+	// Topic defaults override and drop global keys
+	meta = ts.assetMetadata(t, licensed.Hash)
+	if meta.ComputedMetadata["license"] != "CC-BY-4.0" || meta.ComputedMetadata["pipeline"] != "v3" {
+		t.Errorf("expected topic defaults to apply, got %v", meta.ComputedMetadata)
+	}
+	if _, ok := meta.ComputedMetadata["site"]; ok {
+		t.Errorf("expected site to be dropped for the topic, got %v", meta.ComputedMetadata)
+	}
+
+	// Re-uploading existing content does not stamp again
+	ts.App.Config.Metadata.Defaults["site"] = "paris"
+	if result := ts.UploadFileExpectSuccess(t, "plain", "model.glb", []byte("plain asset"), ""); result.Status != constants.UploadStatusDeduplicated {
+		t.Fatalf("expected deduplicated upload, got %s", result.Status)
+	}
+	meta = ts.assetMetadata(t, plain.Hash)
+	if meta.ComputedMetadata["site"] != "berlin" {
+		t.Errorf("expected existing asset to keep site=berlin, got %v", meta.ComputedMetadata["site"])
+	}
+
+	// upload_time is the commit time in UTC
+	ts.App.Config.Metadata.Defaults = map[string]string{"uploaded": "{{upload_time}}"}
+	ts.App.Config.Metadata.TopicDefaults = nil
+	fresh := ts.UploadFileExpectSuccess(t, "plain", "new.txt", []byte("fresh asset"), "")
+	meta = ts.assetMetadata(t, fresh.Hash)
+	uploaded, err := time.Parse(time.RFC3339, meta.ComputedMetadata["uploaded"].(string))
+	if err != nil || uploaded.Before(before.Truncate(time.Second)) || uploaded.Location() != time.UTC {
+		t.Errorf("expected an RFC 3339 UTC upload time, got %v (%v)", meta.ComputedMetadata["uploaded"], err)
+	}
+}
@@ -0,0 +1,284 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// deletionRequest mirrors a request of /api/deletion-requests.
+type deletionRequest struct {
+	ID          int64    `json:"id"`
+	Status      string   `json:"status"`
+	Mode        string   `json:"mode"`
+	Topics      []string `json:"topics"`
+	AssetCount  int      `json:"asset_count"`
+	RequestedBy string   `json:"requested_by"`
+	DecidedBy   string   `json:"decided_by"`
+	Comment     string   `json:"comment"`
+	Deleted     int      `json:"deleted"`
+	Failed      int      `json:"failed"`
+	Assets      []struct {
+		Hash   string `json:"hash"`
+		Status string `json:"status"`
+	} `json:"assets"`
+}
+
+// deletionRequestCall sends a deletion request API call as the given user
+// and returns the status, the request (wrapped under "request" by the
+// actions, bare for GET /:id) and the error code.
+func (ts *TestServer) deletionRequestCall(t *testing.T, method, path, apiKey string, body interface{}) (int, deletionRequest, string) {
+	t.Helper()
+	var result struct {
+		Request deletionRequest `json:"request"`
+		deletionRequest
+		Code string `json:"code"`
+	}
+	status := ts.apiKeyRequest(t, method, path, apiKey, body, &result)
+	if result.Request.ID == 0 {
+		result.Request = result.deletionRequest
+	}
+	return status, result.Request, result.Code
+}
+
+// waitDeletionCompleted polls a request until its deletion has run.
+func (ts *TestServer) waitDeletionCompleted(t *testing.T, id int64, apiKey string) deletionRequest {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		_, req, _ := ts.deletionRequestCall(t, http.MethodGet, fmt.Sprintf("/api/deletion-requests/%d", id), apiKey, nil)
+		if req.Status == constants.DeletionRequestStatusCompleted {
+			return req
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("deletion request %d not completed, status %s", id, req.Status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// countAudit returns the number of audit entries of an action.
+func (ts *TestServer) countAudit(t *testing.T, action string) int {
+	t.Helper()
+	var audit AuditQueryResponse
+	if err := ts.GetJSON("/api/audit?action="+action, &audit); err != nil {
+		t.Fatalf("audit query failed: %v", err)
+	}
+	return len(audit.Entries)
+}
+
+// notificationEvents returns the events in a user's notification feed.
+func (ts *TestServer) notificationEvents(t *testing.T, apiKey string) map[string]int {
+	t.Helper()
+	var feed NotificationFeedResponse
+	ts.notificationRequest(t, http.MethodGet, "/api/notifications", apiKey, nil, http.StatusOK, &feed)
+	events := make(map[string]int)
+	for _, n := range feed.Notifications {
+		events[n.Event]++
+	}
+	return events
+}
+
+// setupDeletionUsers creates two users who may delete in "assets" and one
+// who may only query.
+func setupDeletionUsers(t *testing.T, ts *TestServer) (alice, bob, eve TestUserInfo) {
+	t.Helper()
+	deleter := func() []map[string]interface{} {
+		return []map[string]interface{}{
+			{"action": constants.AuthActionManageTopics, "constraints_json": `{"can_delete":true,"allowed_topics":["assets"]}`},
+			{"action": constants.AuthActionQuery},
+		}
+	}
+	alice = ts.CreateTestUserWithGrants(t, "alice", "secure-password-12345", deleter())
+	bob = ts.CreateTestUserWithGrants(t, "bob", "secure-password-12345", deleter())
+	eve = ts.CreateTestUserWithGrants(t, "eve", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	return alice, bob, eve
+}
+
+// TestDeletionRequests_ApprovalWorkflow verifies proposed assets are kept
+// until another privileged user approves, and deleted once approved.
+func TestDeletionRequests_ApprovalWorkflow(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+	alice, bob, eve := setupDeletionUsers(t, ts)
+
+	first := ts.UploadFileExpectSuccess(t, "assets", "a.bin", []byte("first doomed asset"), "").Hash
+	second := ts.UploadFileExpectSuccess(t, "assets", "b.bin", []byte("second doomed asset"), "").Hash
+	kept := ts.UploadFileExpectSuccess(t, "assets", "c.bin", []byte("kept asset"), "").Hash
+	missing := strings.Repeat("0", constants.HashLength)
+
+	var created struct {
+		Request  deletionRequest `json:"request"`
+		NotFound []string        `json:"not_found"`
+	}
+	status := ts.apiKeyRequest(t, http.MethodPost, "/api/deletion-requests", alice.APIKey, map[string]interface{}{
+		"mode":      "ids",
+		"asset_ids": []string{first, second, second, missing},
+		"reason":    "superseded",
+	}, &created)
+	if status != http.StatusOK {
+		t.Fatalf("create: expected 200, got %d", status)
+	}
+	req := created.Request
+	if req.Status != constants.DeletionRequestStatusPending || req.AssetCount != 2 || req.RequestedBy != "alice" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	if len(created.NotFound) != 1 || created.NotFound[0] != missing {
+		t.Errorf("expected %s not found, got %v", missing, created.NotFound)
+	}
+	ts.DownloadAsset(t, first)
+
+	path := fmt.Sprintf("/api/deletion-requests/%d", req.ID)
+
+	// Without delete rights nothing can be proposed, seen or decided
+	if status, _, _ := ts.deletionRequestCall(t, http.MethodPost, "/api/deletion-requests", eve.APIKey, map[string]interface{}{
+		"mode": "ids", "asset_ids": []string{kept},
+	}); status != http.StatusForbidden {
+		t.Errorf("create without delete rights: expected 403, got %d", status)
+	}
+	if status, _, code := ts.deletionRequestCall(t, http.MethodGet, path, eve.APIKey, nil); status != http.StatusNotFound {
+		t.Errorf("get by outsider: expected 404, got %d %s", status, code)
+	}
+	if status, _, _ := ts.deletionRequestCall(t, http.MethodPost, path+"/approve", eve.APIKey, nil); status != http.StatusForbidden {
+		t.Errorf("approve by outsider: expected 403, got %d", status)
+	}
+	var list struct {
+		Requests []deletionRequest `json:"requests"`
+	}
+	ts.apiKeyRequest(t, http.MethodGet, "/api/deletion-requests", eve.APIKey, nil, &list)
+	if len(list.Requests) != 0 {
+		t.Errorf("outsider should see no requests, got %d", len(list.Requests))
+	}
+
+	// The requester cannot approve their own request
+	if status, _, code := ts.deletionRequestCall(t, http.MethodPost, path+"/approve", alice.APIKey, nil); status != http.StatusForbidden ||
+		code != constants.ErrCodeDeletionSelfApproval {
+		t.Errorf("self approval: expected 403 %s, got %d %s", constants.ErrCodeDeletionSelfApproval, status, code)
+	}
+
+	if events := ts.notificationEvents(t, bob.APIKey); events[constants.NotificationEventDeletionRequested] != 1 {
+		t.Errorf("approver should be notified of the request, got %v", events)
+	}
+	if events := ts.notificationEvents(t, eve.APIKey); events[constants.NotificationEventDeletionRequested] != 0 {
+		t.Errorf("outsider should not be notified, got %v", events)
+	}
+
+	status, approved, code := ts.deletionRequestCall(t, http.MethodPost, path+"/approve", bob.APIKey, map[string]string{"comment": "ok"})
+	if status != http.StatusOK || approved.DecidedBy != "bob" {
+		t.Fatalf("approve: expected 200 decided by bob, got %d %s %+v", status, code, approved)
+	}
+	done := ts.waitDeletionCompleted(t, req.ID, bob.APIKey)
+	if done.Deleted != 2 || done.Failed != 0 {
+		t.Errorf("expected 2 deleted and 0 failed, got %+v", done)
+	}
+	for _, a := range done.Assets {
+		if a.Status != constants.DeletionAssetStatusDeleted {
+			t.Errorf("asset %s: expected deleted, got %s", a.Hash, a.Status)
+		}
+	}
+
+	ts.DownloadAssetExpectError(t, first, http.StatusNotFound)
+	ts.DownloadAssetExpectError(t, second, http.StatusNotFound)
+	ts.DownloadAsset(t, kept)
+
+	if status, _, code := ts.deletionRequestCall(t, http.MethodPost, path+"/reject", bob.APIKey, nil); status != http.StatusConflict ||
+		code != constants.ErrCodeDeletionRequestNotPending {
+		t.Errorf("decide twice: expected 409 %s, got %d %s", constants.ErrCodeDeletionRequestNotPending, status, code)
+	}
+
+	events := ts.notificationEvents(t, alice.APIKey)
+	if events[constants.NotificationEventDeletionApproved] != 1 || events[constants.NotificationEventDeletionCompleted] != 1 {
+		t.Errorf("requester should be notified of approval and completion, got %v", events)
+	}
+	for action, want := range map[string]int{
+		constants.AuditActionDeletionRequested: 1,
+		constants.AuditActionDeletionApproved:  1,
+		constants.AuditActionDeletionCompleted: 1,
+		constants.AuditActionAssetDeleted:      2,
+	} {
+		if got := ts.countAudit(t, action); got != want {
+			t.Errorf("expected %d %s audit entries, got %d", want, action, got)
+		}
+	}
+}
+
+// TestDeletionRequests_RejectCancelExpire verifies rejected, cancelled and
+// expired requests delete nothing.
+func TestDeletionRequests_RejectCancelExpire(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+	alice, bob, _ := setupDeletionUsers(t, ts)
+
+	hash := ts.UploadFileExpectSuccess(t, "assets", "a.bin", []byte("contested asset"), "").Hash
+	propose := func() deletionRequest {
+		t.Helper()
+		status, req, code := ts.deletionRequestCall(t, http.MethodPost, "/api/deletion-requests", alice.APIKey, map[string]interface{}{
+			"mode": "query", "preset": "recent-imports", "topics": []string{"assets"},
+		})
+		if status != http.StatusOK || req.AssetCount != 1 || req.Mode != "query" {
+			t.Fatalf("create: expected 200 with 1 asset, got %d %s %+v", status, code, req)
+		}
+		return req
+	}
+
+	rejected := propose()
+	status, req, _ := ts.deletionRequestCall(t, http.MethodPost, fmt.Sprintf("/api/deletion-requests/%d/reject", rejected.ID),
+		bob.APIKey, map[string]string{"comment": "still in use"})
+	if status != http.StatusOK || req.Status != constants.DeletionRequestStatusRejected || req.Comment != "still in use" {
+		t.Fatalf("reject: unexpected %d %+v", status, req)
+	}
+
+	cancelled := propose()
+	cancelPath := fmt.Sprintf("/api/deletion-requests/%d/cancel", cancelled.ID)
+	if status, _, _ := ts.deletionRequestCall(t, http.MethodPost, cancelPath, bob.APIKey, nil); status != http.StatusForbidden {
+		t.Errorf("cancel by another user: expected 403, got %d", status)
+	}
+	if status, req, _ := ts.deletionRequestCall(t, http.MethodPost, cancelPath, alice.APIKey, nil); status != http.StatusOK ||
+		req.Status != constants.DeletionRequestStatusCancelled {
+		t.Errorf("cancel: unexpected %d %+v", status, req)
+	}
+
+	expired := propose()
+	if _, err := ts.GetOrchestratorDB(t).Exec(`UPDATE deletion_requests SET expires_at = ? WHERE id = ?`,
+		time.Now().Add(-time.Minute).Unix(), expired.ID); err != nil {
+		t.Fatalf("failed to backdate request: %v", err)
+	}
+	if status, _, code := ts.deletionRequestCall(t, http.MethodPost, fmt.Sprintf("/api/deletion-requests/%d/approve", expired.ID),
+		bob.APIKey, nil); status != http.StatusConflict || code != constants.ErrCodeDeletionRequestNotPending {
+		t.Errorf("approve after the window: expected 409 %s, got %d %s", constants.ErrCodeDeletionRequestNotPending, status, code)
+	}
+
+	var list struct {
+		Requests []deletionRequest `json:"requests"`
+	}
+	ts.apiKeyRequest(t, http.MethodGet, "/api/deletion-requests?status="+constants.DeletionRequestStatusExpired, alice.APIKey, nil, &list)
+	if len(list.Requests) != 1 || list.Requests[0].ID != expired.ID {
+		t.Errorf("expected the expired request listed, got %+v", list.Requests)
+	}
+
+	ts.DownloadAsset(t, hash)
+	events := ts.notificationEvents(t, alice.APIKey)
+	if events[constants.NotificationEventDeletionRejected] != 1 || events[constants.NotificationEventDeletionExpired] != 1 {
+		t.Errorf("requester should be notified of rejection and expiry, got %v", events)
+	}
+	for _, action := range []string{constants.AuditActionDeletionRejected, constants.AuditActionDeletionCancelled, constants.AuditActionDeletionExpired} {
+		if got := ts.countAudit(t, action); got != 1 {
+			t.Errorf("expected 1 %s audit entry, got %d", action, got)
+		}
+	}
+	if got := ts.countAudit(t, constants.AuditActionAssetDeleted); got != 0 {
+		t.Errorf("expected no asset deleted, got %d", got)
+	}
+
+	if status := ts.apiKeyRequest(t, http.MethodGet, "/api/deletion-requests?status=bogus", alice.APIKey, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("unknown status: expected 400, got %d", status)
+	}
+}
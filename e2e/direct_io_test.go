@@ -0,0 +1,37 @@
+package e2e
+
+import (
+	"bytes"
+	"testing"
+
+	"silobang/internal/config"
+)
+
+// TestDirectIO_UploadAndDownload verifies assets round-trip when the working
+// directory is configured for O_DIRECT access.
+func TestDirectIO_UploadAndDownload(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.App.Config.StorageIO = map[string]config.StorageIOConfig{
+		ts.App.Config.WorkingDirectory: {DirectIO: true},
+	}
+	if !ts.App.Config.DirectIO() {
+		t.Fatal("expected direct I/O to be enabled for the working directory")
+	}
+	ts.CreateTopic(t, "raw")
+
+	// Sizes leave the .dat end unaligned between appends
+	var hashes []string
+	var contents [][]byte
+	for i, size := range []int{13, 5000, 2<<20 + 7} {
+		content := bytes.Repeat([]byte{byte('a' + i)}, size)
+		hashes = append(hashes, ts.UploadFileExpectSuccess(t, "raw", "blob.bin", content, "").Hash)
+		contents = append(contents, content)
+	}
+
+	for i, hash := range hashes {
+		if got := ts.DownloadAsset(t, hash); !bytes.Equal(got, contents[i]) {
+			t.Errorf("asset %d: downloaded %d bytes, expected %d matching bytes", i, len(got), len(contents[i]))
+		}
+	}
+}
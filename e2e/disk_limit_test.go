@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"silobang/internal/constants"
+	"silobang/internal/services"
 )
 
 // =============================================================================
@@ -28,6 +29,28 @@ func TestDiskLimit_UploadRejectedWhenExceeded(t *testing.T) {
 	}
 }
 
+// TestDiskLimit_UploadRejectedWhenBodyDoesNotFit leaves a few MB below the
+// disk limit and verifies that a larger upload is rejected with STORAGE_FULL
+// before its body is written, while a small upload still fits.
+func TestDiskLimit_UploadRejectedWhenBodyDoesNotFit(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "disk-limit-headroom")
+
+	used, err := services.GetDiskUsageBytes(ts.App.Config.WorkingDirectory)
+	if err != nil {
+		t.Fatalf("GetDiskUsageBytes failed: %v", err)
+	}
+	ts.App.Config.MaxDiskUsage = int64(used) + 8<<20
+
+	errResp := ts.UploadFileExpectError(t, "disk-limit-headroom", "large.bin", make([]byte, 32<<20), "", http.StatusInsufficientStorage)
+	if errResp.Code != constants.ErrCodeStorageFull {
+		t.Errorf("Expected error code %s, got %s", constants.ErrCodeStorageFull, errResp.Code)
+	}
+
+	ts.UploadFileExpectSuccess(t, "disk-limit-headroom", "small.bin", []byte("fits-in-headroom"), "")
+}
+
 // =============================================================================
 // Disk Limit — Topic Creation Rejection
 // =============================================================================
@@ -0,0 +1,112 @@
+package e2e
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// downloadWithHeaders performs an authenticated asset download with extra request headers
+func downloadWithHeaders(t *testing.T, ts *TestServer, hash string, headers map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest("GET", ts.URL+"/api/assets/"+hash+"/download", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("download request failed: %v", err)
+	}
+	return resp
+}
+
+// TestDownloadCache_ValidatorHeaders verifies ETag, Last-Modified and Cache-Control on downloads
+func TestDownloadCache_ValidatorHeaders(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "model.glb", []byte("cacheable content"), "")
+
+	resp := downloadWithHeaders(t, ts, upload.Hash, nil)
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("ETag"); got != `"`+upload.Hash+`"` {
+		t.Errorf("expected ETag to equal the quoted hash, got %q", got)
+	}
+	if resp.Header.Get("Last-Modified") == "" {
+		t.Error("expected Last-Modified header")
+	}
+	if got := resp.Header.Get("Cache-Control"); got != constants.CacheControlAsset {
+		t.Errorf("expected Cache-Control %q, got %q", constants.CacheControlAsset, got)
+	}
+}
+
+// TestDownloadCache_ConditionalRequests verifies 304 handling for If-None-Match and If-Modified-Since
+func TestDownloadCache_ConditionalRequests(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "model.glb", []byte("conditional content"), "")
+
+	first := downloadWithHeaders(t, ts, upload.Hash, nil)
+	first.Body.Close()
+	lastModified := first.Header.Get("Last-Modified")
+
+	// Matching ETag → 304 with empty body
+	resp := downloadWithHeaders(t, ts, upload.Hash, map[string]string{"If-None-Match": `"` + upload.Hash + `"`})
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 for matching ETag, got %d", resp.StatusCode)
+	}
+	if len(body) != 0 {
+		t.Errorf("expected empty body on 304, got %d bytes", len(body))
+	}
+
+	// Non-matching ETag → full response
+	resp = downloadWithHeaders(t, ts, upload.Hash, map[string]string{"If-None-Match": `"deadbeef"`})
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "conditional content" {
+		t.Errorf("expected 200 with content for stale ETag, got %d", resp.StatusCode)
+	}
+
+	// If-Modified-Since equal to Last-Modified → 304
+	resp = downloadWithHeaders(t, ts, upload.Hash, map[string]string{"If-Modified-Since": lastModified})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 for If-Modified-Since, got %d", resp.StatusCode)
+	}
+}
+
+// TestDownloadCache_ConditionalStillRequiresAuth verifies validators don't bypass authentication
+func TestDownloadCache_ConditionalStillRequiresAuth(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "model.glb", []byte("secret content"), "")
+
+	req, _ := http.NewRequest("GET", ts.URL+"/api/assets/"+upload.Hash+"/download", nil)
+	req.Header.Set("If-None-Match", `"`+upload.Hash+`"`)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,115 @@
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// storageEvent mirrors an event of /api/events/stream, with its SSE id.
+type storageEvent struct {
+	SSEID     string                 `json:"-"`
+	ID        int64                  `json:"id"`
+	Type      string                 `json:"type"`
+	Actor     string                 `json:"actor"`
+	Action    string                 `json:"action"`
+	Data      map[string]interface{} `json:"data"`
+	Timestamp int64                  `json:"timestamp"`
+}
+
+// openEventStream opens /api/events/stream with query and, when not empty,
+// Last-Event-ID. The stream is closed when the test ends.
+func (ts *TestServer) openEventStream(t *testing.T, query, lastEventID string) func() storageEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/events/stream"+query, nil)
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	if lastEventID != "" {
+		req.Header.Set(constants.HeaderLastEventID, lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	return func() storageEvent {
+		t.Helper()
+		var id string
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "id: ") {
+				id = line[4:]
+				continue
+			}
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var event storageEvent
+			if err := json.Unmarshal([]byte(line[6:]), &event); err != nil {
+				t.Fatalf("failed to parse event %q: %v", line, err)
+			}
+			event.SSEID = id
+			return event
+		}
+		t.Fatalf("stream ended: %v", scanner.Err())
+		return storageEvent{}
+	}
+}
+
+// TestEventStream_LiveAndResumed verifies storage events are streamed as
+// they happen, filtered by type, and replayed after Last-Event-ID.
+func TestEventStream_LiveAndResumed(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	next := ts.openEventStream(t, "?types=asset.created,metadata.changed", "")
+	if event := next(); event.Type != constants.EventStreamEventConnected {
+		t.Fatalf("expected the connected event first, got %+v", event)
+	}
+
+	first := ts.UploadFileExpectSuccess(t, "assets", "first.bin", []byte("first content"), "").Hash
+	created := next()
+	if created.Type != constants.EventTypeAssetCreated || created.Data["hash"] != first || created.SSEID != strconv.FormatInt(created.ID, 10) {
+		t.Fatalf("unexpected asset.created event: %+v", created)
+	}
+
+	// Recorded while no stream is open: topics are filtered out, the
+	// rest is replayed in order
+	ts.CreateTopic(t, "more")
+	second := ts.UploadFileExpectSuccess(t, "assets", "second.bin", []byte("second content"), "").Hash
+	ts.SetMetadata(t, second, "label", "two")
+
+	resumed := ts.openEventStream(t, "?types=asset.created,metadata.changed", created.SSEID)
+	if event := resumed(); event.Type != constants.EventStreamEventConnected {
+		t.Fatalf("expected the connected event first, got %+v", event)
+	}
+	if event := resumed(); event.Type != constants.EventTypeAssetCreated || event.Data["hash"] != second {
+		t.Errorf("expected the missed upload, got %+v", event)
+	}
+	if event := resumed(); event.Type != constants.EventTypeMetadataChanged || event.Data["hash"] != second {
+		t.Errorf("expected the missed metadata change, got %+v", event)
+	}
+
+	resp, err := ts.GET("/api/events/stream?types=asset.exploded")
+	if err != nil {
+		t.Fatalf("stream request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown type, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,214 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// ExportResponse is one entry of the export inbox
+type ExportResponse struct {
+	ID           string   `json:"id"`
+	Status       string   `json:"status"`
+	Mode         string   `json:"mode"`
+	Topics       []string `json:"topics"`
+	AssetCount   int      `json:"asset_count"`
+	FailedAssets int      `json:"failed_assets"`
+	SizeBytes    int64    `json:"size_bytes"`
+	Error        string   `json:"error"`
+	CreatedAt    int64    `json:"created_at"`
+	ExpiresAt    int64    `json:"expires_at"`
+}
+
+// ExportInboxResponse is the response of GET /api/exports
+type ExportInboxResponse struct {
+	Exports       []ExportResponse `json:"exports"`
+	UsedBytes     int64            `json:"used_bytes"`
+	MaxBytes      int64            `json:"max_bytes"`
+	RetentionDays int              `json:"retention_days"`
+}
+
+// startInboxExport directs a bulk download to the export inbox.
+func (ts *TestServer) startInboxExport(t *testing.T, req BulkDownloadRequest) ExportResponse {
+	t.Helper()
+	req.Destination = constants.ExportDestinationInbox
+	resp, err := ts.BulkDownload(t, req)
+	if err != nil {
+		t.Fatalf("bulk download request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", resp.StatusCode, data)
+	}
+	var result struct {
+		Export ExportResponse `json:"export"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("failed to parse export response: %v", err)
+	}
+	return result.Export
+}
+
+// waitForExport polls the inbox until the export has finished building.
+func (ts *TestServer) waitForExport(t *testing.T, id string) ExportResponse {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		var inbox ExportInboxResponse
+		if err := ts.GetJSON("/api/exports", &inbox); err != nil {
+			t.Fatalf("GET /api/exports failed: %v", err)
+		}
+		for _, e := range inbox.Exports {
+			if e.ID == id && e.Status != constants.ExportStatusProcessing {
+				return e
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("export %s did not finish in time", id)
+	return ExportResponse{}
+}
+
+// fetchExport downloads an export, optionally with a Range header.
+func (ts *TestServer) fetchExport(t *testing.T, id, rangeHeader string, expectedStatus int) []byte {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/exports/"+id, nil)
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/exports/%s failed: %v", id, err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != expectedStatus {
+		t.Fatalf("GET /api/exports/%s: expected %d, got %d: %s", id, expectedStatus, resp.StatusCode, data)
+	}
+	return data
+}
+
+// TestExports_InboxLifecycle verifies a bulk download directed to the inbox
+// is built in the background, survives a restart, can be resumed with Range
+// requests and is removed on delete.
+func TestExports_InboxLifecycle(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	first := ts.UploadFileExpectSuccess(t, "test-topic", "a.txt", bytes.Repeat([]byte("a"), 4096), "")
+	second := ts.UploadFileExpectSuccess(t, "test-topic", "b.txt", bytes.Repeat([]byte("b"), 4096), "")
+
+	export := ts.startInboxExport(t, BulkDownloadRequest{Mode: "ids", AssetIDs: []string{first.Hash, second.Hash}})
+	if export.ID == "" || export.Status != constants.ExportStatusProcessing || export.AssetCount != 2 {
+		t.Fatalf("unexpected queued export: %+v", export)
+	}
+
+	ready := ts.waitForExport(t, export.ID)
+	if ready.Status != constants.ExportStatusReady || ready.SizeBytes == 0 {
+		t.Fatalf("expected ready export, got %+v", ready)
+	}
+
+	full := ts.fetchExport(t, export.ID, "", http.StatusOK)
+	if int64(len(full)) != ready.SizeBytes {
+		t.Errorf("downloaded %d bytes, export reports %d", len(full), ready.SizeBytes)
+	}
+	if manifest := ExtractZIPManifest(t, full); manifest.AssetCount != 2 {
+		t.Errorf("expected 2 assets in manifest, got %d", manifest.AssetCount)
+	}
+
+	// Resume from an offset
+	rest := ts.fetchExport(t, export.ID, "bytes=100-", http.StatusPartialContent)
+	if !bytes.Equal(rest, full[100:]) {
+		t.Errorf("ranged download does not match the tail of the export")
+	}
+
+	var feed NotificationFeedResponse
+	if err := ts.GetJSON("/api/notifications", &feed); err != nil {
+		t.Fatalf("GET /api/notifications failed: %v", err)
+	}
+	if len(feed.Notifications) != 1 || feed.Notifications[0].Event != constants.NotificationEventExportReady ||
+		feed.Notifications[0].Details["export_id"] != export.ID {
+		t.Errorf("expected an export_ready notification, got %+v", feed.Notifications)
+	}
+
+	// The inbox survives a restart
+	ts.Restart(t)
+	var inbox ExportInboxResponse
+	if err := ts.GetJSON("/api/exports", &inbox); err != nil {
+		t.Fatalf("GET /api/exports failed: %v", err)
+	}
+	if len(inbox.Exports) != 1 || inbox.Exports[0].ID != export.ID || inbox.UsedBytes != ready.SizeBytes {
+		t.Fatalf("unexpected inbox after restart: %+v", inbox)
+	}
+	if inbox.MaxBytes != ts.App.Config.Exports.MaxInboxBytes || inbox.RetentionDays != ts.App.Config.Exports.RetentionDays {
+		t.Errorf("unexpected inbox limits: %+v", inbox)
+	}
+	if again := ts.fetchExport(t, export.ID, "", http.StatusOK); !bytes.Equal(again, full) {
+		t.Errorf("export changed across restart")
+	}
+
+	resp, err := ts.DELETE("/api/exports/" + export.ID)
+	if err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("DELETE: expected 200, got %d", resp.StatusCode)
+	}
+	ts.fetchExport(t, export.ID, "", http.StatusNotFound)
+}
+
+// TestExports_InboxQuota verifies exports that would overflow the user's
+// inbox are rejected before any work is done.
+func TestExports_InboxQuota(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "big.bin", bytes.Repeat([]byte("x"), 8192), "")
+	ts.App.Config.Exports.MaxInboxBytes = 4096
+
+	errResp := ts.BulkDownloadExpectError(t, BulkDownloadRequest{
+		Mode:        "ids",
+		AssetIDs:    []string{upload.Hash},
+		Destination: constants.ExportDestinationInbox,
+	}, http.StatusInsufficientStorage)
+	if errResp.Code != constants.ErrCodeExportInboxFull {
+		t.Errorf("expected %s, got %s", constants.ErrCodeExportInboxFull, errResp.Code)
+	}
+
+	var inbox ExportInboxResponse
+	if err := ts.GetJSON("/api/exports", &inbox); err != nil {
+		t.Fatalf("GET /api/exports failed: %v", err)
+	}
+	if len(inbox.Exports) != 0 {
+		t.Errorf("rejected export was recorded: %+v", inbox.Exports)
+	}
+}
+
+// TestExports_InvalidDestination verifies unknown destinations are rejected.
+func TestExports_InvalidDestination(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "a.txt", []byte("content"), "")
+	errResp := ts.BulkDownloadExpectError(t, BulkDownloadRequest{
+		Mode:        "ids",
+		AssetIDs:    []string{upload.Hash},
+		Destination: "mailbox",
+	}, http.StatusBadRequest)
+	if errResp.Code != constants.ErrCodeInvalidRequest {
+		t.Errorf("expected %s, got %s", constants.ErrCodeInvalidRequest, errResp.Code)
+	}
+}
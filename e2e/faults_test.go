@@ -0,0 +1,230 @@
+//go:build faultinject
+
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// These tests run only against test builds: go test -tags faultinject ./e2e/...
+
+// FaultRule mirrors a rule of the fault injection admin API
+type FaultRule struct {
+	ID                 string `json:"id"`
+	Kind               string `json:"kind"`
+	Route              string `json:"route"`
+	Method             string `json:"method,omitempty"`
+	DelayMs            int    `json:"delay_ms,omitempty"`
+	Status             int    `json:"status,omitempty"`
+	TruncateAfterBytes int64  `json:"truncate_after_bytes,omitempty"`
+	Skip               int    `json:"skip,omitempty"`
+	Times              int    `json:"times,omitempty"`
+	Matched            int    `json:"matched"`
+	Fired              int    `json:"fired"`
+}
+
+// AddFault installs a fault rule and returns it with its ID
+func (ts *TestServer) AddFault(t *testing.T, rule FaultRule) FaultRule {
+	t.Helper()
+	resp, err := ts.POST(constants.FaultsAPIPath, rule)
+	if err != nil {
+		t.Fatalf("add fault request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("add fault failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var added FaultRule
+	if err := json.Unmarshal(bodyBytes, &added); err != nil {
+		t.Fatalf("failed to parse fault rule: %v", err)
+	}
+	return added
+}
+
+// ListFaults returns the active fault rules
+func (ts *TestServer) ListFaults(t *testing.T) []FaultRule {
+	t.Helper()
+	var result struct {
+		Rules []FaultRule `json:"rules"`
+	}
+	if err := ts.GetJSON(constants.FaultsAPIPath, &result); err != nil {
+		t.Fatalf("list faults failed: %v", err)
+	}
+	return result.Rules
+}
+
+// TestFaults_ErrorThenRecovery verifies an error fault fires a fixed number
+// of times so a client retry succeeds deterministically.
+func TestFaults_ErrorThenRecovery(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "faults-topic")
+	upload := ts.UploadFileExpectSuccess(t, "faults-topic", "a.txt", []byte("fault content"), "")
+
+	ts.AddFault(t, FaultRule{Kind: "error", Route: "/api/assets/", Method: http.MethodGet, Status: http.StatusBadGateway, Times: 2})
+
+	for i := 0; i < 2; i++ {
+		errResp := ts.DownloadAssetExpectError(t, upload.Hash, http.StatusBadGateway)
+		if errResp.Code != constants.ErrCodeFaultInjected {
+			t.Errorf("attempt %d: expected %s, got %+v", i+1, constants.ErrCodeFaultInjected, errResp)
+		}
+	}
+
+	if got := ts.DownloadAsset(t, upload.Hash); string(got) != "fault content" {
+		t.Errorf("retry after faults returned %q", got)
+	}
+
+	rules := ts.ListFaults(t)
+	if len(rules) != 1 || rules[0].Matched != 3 || rules[0].Fired != 2 {
+		t.Errorf("unexpected rule counters: %+v", rules)
+	}
+
+	// Other routes are untouched
+	ts.GetTopics(t)
+}
+
+// TestFaults_Latency verifies latency faults delay matching requests only.
+func TestFaults_Latency(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	ts.AddFault(t, FaultRule{Kind: "latency", Route: "/api/topics", DelayMs: 150, Times: 1})
+
+	start := time.Now()
+	ts.GetTopics(t)
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("faulted request took %v, want >= 150ms", elapsed)
+	}
+
+	start = time.Now()
+	ts.GetTopics(t)
+	if elapsed := time.Since(start); elapsed >= 150*time.Millisecond {
+		t.Errorf("request after the fault was exhausted took %v", elapsed)
+	}
+}
+
+// TestFaults_TruncatedDownload verifies a truncated download fails on the
+// client side and the retry receives the full asset.
+func TestFaults_TruncatedDownload(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "faults-topic")
+	content := GenerateTestFile(64 * 1024)
+	upload := ts.UploadFileExpectSuccess(t, "faults-topic", "big.bin", content, "")
+
+	ts.AddFault(t, FaultRule{Kind: "truncate", Route: "/api/assets/" + upload.Hash + "/download", TruncateAfterBytes: 1000, Times: 1})
+
+	resp, err := ts.GET("/api/assets/" + upload.Hash + "/download")
+	if err != nil {
+		t.Fatalf("download request failed: %v", err)
+	}
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if readErr == nil {
+		t.Fatalf("expected truncated body to fail, read %d bytes", len(body))
+	}
+	if len(body) != 1000 || !bytes.Equal(body, content[:1000]) {
+		t.Errorf("expected the first 1000 bytes before the abort, got %d", len(body))
+	}
+
+	if got := ts.DownloadAsset(t, upload.Hash); !bytes.Equal(got, content) {
+		t.Errorf("retry returned %d bytes, want %d", len(got), len(content))
+	}
+}
+
+// TestFaults_SlowSSEStream verifies slow_stream faults space out SSE writes
+// while the stream still completes.
+func TestFaults_SlowSSEStream(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "faults-topic")
+	a := ts.UploadFileExpectSuccess(t, "faults-topic", "a.txt", []byte("a"), "")
+	b := ts.UploadFileExpectSuccess(t, "faults-topic", "b.txt", []byte("b"), "")
+
+	const delayMs = 30
+	ts.AddFault(t, FaultRule{Kind: "slow_stream", Route: "/api/download/bulk/start", DelayMs: delayMs})
+
+	start := time.Now()
+	resp, err := ts.BulkDownloadSSE(t, "ids", "", nil, nil, []string{a.Hash, b.Hash}, false, "original")
+	if err != nil {
+		t.Fatalf("SSE request failed: %v", err)
+	}
+	events := ParseBulkDownloadSSEEvents(t, resp)
+	resp.Body.Close()
+	elapsed := time.Since(start)
+
+	if FindBulkDownloadSSEEvent(events, "complete") == nil {
+		t.Fatalf("stream did not complete, events: %+v", events)
+	}
+	// Every event is at least one delayed write
+	if min := time.Duration(len(events)*delayMs) * time.Millisecond; elapsed < min {
+		t.Errorf("%d events took %v, want >= %v", len(events), elapsed, min)
+	}
+}
+
+// TestFaults_AdminOnly verifies only config managers can change fault rules
+// and rules can be removed individually or all at once.
+func TestFaults_AdminOnly(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	user := ts.CreateTestUser(t, "faultuser", "faultuser-password-123")
+
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, constants.FaultsAPIPath, user.APIKey, FaultRule{Kind: "error", Route: "/api/"})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for non-admin, got %d", resp.StatusCode)
+	}
+
+	resp, err = ts.POST(constants.FaultsAPIPath, FaultRule{Kind: "error", Route: "/api/", Status: http.StatusNotFound})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for non-5xx error fault, got %d", resp.StatusCode)
+	}
+
+	// A catch-all fault does not lock the admin out of the fault API
+	first := ts.AddFault(t, FaultRule{Kind: "error", Route: "/"})
+	ts.AddFault(t, FaultRule{Kind: "latency", Route: "/api/topics", DelayMs: 10})
+
+	resp, err = ts.DELETE(constants.FaultsAPIPath + "/" + first.ID)
+	if err != nil {
+		t.Fatalf("delete request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 removing %s, got %d", first.ID, resp.StatusCode)
+	}
+
+	resp, err = ts.DELETE(constants.FaultsAPIPath + "/" + first.ID)
+	if err != nil {
+		t.Fatalf("delete request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 removing %s twice, got %d", first.ID, resp.StatusCode)
+	}
+
+	resp, err = ts.DELETE(constants.FaultsAPIPath)
+	if err != nil {
+		t.Fatalf("reset request failed: %v", err)
+	}
+	resp.Body.Close()
+	if rules := ts.ListFaults(t); len(rules) != 0 {
+		t.Errorf("expected no rules after reset, got %+v", rules)
+	}
+}
@@ -0,0 +1,64 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// TestFeatures_DisabledSubsystems verifies subsystems turned off by
+// features.disabled are reported by /readyz without failing readiness, and
+// that their endpoints answer FEATURE_DISABLED.
+func TestFeatures_DisabledSubsystems(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.App.Config.Features.Disabled = []string{constants.FeaturePreviews, constants.FeatureSearch, constants.FeaturePrompts}
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "inbox")
+	asset := ts.UploadFileExpectSuccess(t, "inbox", "cover.png", []byte("not really a png"), "")
+
+	status, ready := ts.getReadiness(t)
+	if status != http.StatusOK || !ready.Ready {
+		t.Fatalf("expected ready with features disabled, got %d: %+v", status, ready.Components)
+	}
+	for _, name := range []string{constants.StartupStepPreviews, constants.StartupStepSearch, constants.StartupStepPrompts} {
+		if ready.Subsystems[name] != constants.SubsystemStateDisabled {
+			t.Errorf("expected %s disabled, got %v", name, ready.Subsystems)
+		}
+	}
+	for _, name := range []string{constants.StartupStepOrchestratorDB, constants.StartupStepTopicDiscovery, constants.StartupStepStatsCache} {
+		if ready.Subsystems[name] != constants.SubsystemStateRunning {
+			t.Errorf("expected %s running, got %v", name, ready.Subsystems)
+		}
+	}
+
+	ts.search(t, ts.APIKey, "q=cover", http.StatusServiceUnavailable)
+	for _, path := range []string{"/api/assets/" + asset.Hash + "/preview", "/api/prompts"} {
+		resp, err := ts.GET(path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		var errResp ErrorResponse
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable || errResp.Code != constants.ErrCodeFeatureDisabled {
+			t.Errorf("GET %s: expected 503 %s, got %d %s", path, constants.ErrCodeFeatureDisabled, resp.StatusCode, errResp.Code)
+		}
+	}
+
+	// Monitoring lists every subsystem with its detail
+	var monitoring struct {
+		Subsystems []struct {
+			Name   string `json:"name"`
+			State  string `json:"state"`
+			Detail string `json:"detail"`
+		} `json:"subsystems"`
+	}
+	if err := ts.GetJSON("/api/monitoring", &monitoring); err != nil {
+		t.Fatalf("monitoring request failed: %v", err)
+	}
+	if len(monitoring.Subsystems) != len(ready.Subsystems) {
+		t.Errorf("expected %d subsystems in monitoring, got %+v", len(ready.Subsystems), monitoring.Subsystems)
+	}
+}
@@ -0,0 +1,81 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// TestFederatedQuery_TopicDifference verifies a federated preset joins two
+// topic databases in one query.
+func TestFederatedQuery_TopicDifference(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "fq-source")
+	ts.CreateTopic(t, "fq-processed")
+
+	ts.UploadFileExpectSuccess(t, "fq-source", "shared.bin", []byte("source shared"), "")
+	pending := ts.UploadFileExpectSuccess(t, "fq-source", "pending.bin", []byte("source pending"), "").Hash
+	ts.UploadFileExpectSuccess(t, "fq-processed", "shared.bin", []byte("processed shared"), "")
+
+	result := ts.ExecuteQuery(t, "topic-difference", nil, map[string]interface{}{
+		"topic_a": "fq-source",
+		"topic_b": "fq-processed",
+	})
+
+	if result.RowCount != 1 {
+		t.Fatalf("expected 1 row, got %d: %v", result.RowCount, result.Rows)
+	}
+	if result.Rows[0][0] != pending {
+		t.Errorf("expected %s, got %v", pending, result.Rows[0][0])
+	}
+
+	drift := ts.ExecuteQuery(t, "index-drift", nil, map[string]interface{}{"topic": "fq-source"})
+	if drift.RowCount != 0 {
+		t.Errorf("expected no index drift, got %v", drift.Rows)
+	}
+
+	errResp := ts.ExecuteQueryExpectError(t, "topic-difference", nil, map[string]interface{}{
+		"topic_a": "fq-source",
+		"topic_b": "fq-missing",
+	}, http.StatusNotFound)
+	if errResp.Code != constants.ErrCodeTopicNotFound {
+		t.Errorf("expected %s, got %s", constants.ErrCodeTopicNotFound, errResp.Code)
+	}
+}
+
+// TestFederatedQuery_AllowedTopics verifies every attached topic is checked
+// against the caller's query grant.
+func TestFederatedQuery_AllowedTopics(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "fq-open")
+	ts.CreateTopic(t, "fq-closed")
+
+	user := ts.CreateTestUserWithGrants(t, "fquser", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_topics":["fq-open"]}`},
+	})
+
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, "/api/query/topic-difference", user.APIKey, map[string]interface{}{
+		"params": map[string]interface{}{"topic_a": "fq-open", "topic_b": "fq-closed"},
+	})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for a restricted topic, got %d", resp.StatusCode)
+	}
+
+	resp, err = ts.RequestWithAPIKey(http.MethodPost, "/api/query/index-drift", user.APIKey, map[string]interface{}{
+		"params": map[string]interface{}{"topic": "fq-open"},
+	})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 for an allowed topic, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,116 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+// FederatedQueryResponse is the response of POST /api/federation/query/:preset
+type FederatedQueryResponse struct {
+	RowCount  int             `json:"row_count"`
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	Partial   bool            `json:"partial"`
+	Instances []struct {
+		Name      string `json:"name"`
+		Status    string `json:"status"`
+		LatencyMs int64  `json:"latency_ms"`
+		RowCount  int    `json:"row_count"`
+		Error     string `json:"error"`
+	} `json:"instances"`
+}
+
+// TestFederation_ScatterGather verifies a coordinator merges a preset's rows
+// from itself and its peers, reporting unreachable peers without failing.
+func TestFederation_ScatterGather(t *testing.T) {
+	coordinator := StartTestServer(t)
+	coordinator.ConfigureWorkDir(t)
+	coordinator.CreateTopic(t, "shots")
+	local := coordinator.UploadFileExpectSuccess(t, "shots", "paris.png", []byte("paris content"), "")
+
+	peer := StartTestServer(t)
+	peer.ConfigureWorkDir(t)
+	peer.CreateTopic(t, "shots")
+	remote := peer.UploadFileExpectSuccess(t, "shots", "berlin.png", []byte("berlin content"), "")
+	peerUser := peer.CreateTestUserWithGrants(t, "federation", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+
+	coordinator.App.Config.Federation.InstanceName = "paris"
+	coordinator.App.Config.Federation.Peers = []config.FederationPeer{
+		{Name: "berlin", URL: peer.URL, APIKey: peerUser.APIKey},
+		{Name: "offline", URL: "http://127.0.0.1:1", APIKey: "unused"},
+	}
+
+	var result FederatedQueryResponse
+	if err := coordinator.PostJSON("/api/federation/query/recent-imports", map[string]interface{}{}, &result); err != nil {
+		t.Fatalf("federated query failed: %v", err)
+	}
+
+	if result.Columns[0] != constants.FederationOriginColumn {
+		t.Fatalf("expected origin column first, got %v", result.Columns)
+	}
+	if result.RowCount != 2 {
+		t.Fatalf("expected 2 rows, got %d: %v", result.RowCount, result.Rows)
+	}
+	origins := map[string]interface{}{}
+	for _, row := range result.Rows {
+		origins[row[0].(string)] = row[1]
+	}
+	if origins["paris"] != local.Hash || origins["berlin"] != remote.Hash {
+		t.Errorf("unexpected rows by origin: %v", origins)
+	}
+
+	if !result.Partial || len(result.Instances) != 3 {
+		t.Fatalf("expected partial result over 3 instances, got %+v", result)
+	}
+	if berlin := result.Instances[1]; berlin.Name != "berlin" || berlin.Status != constants.FederationPeerStatusOK || berlin.RowCount != 1 {
+		t.Errorf("unexpected berlin status: %+v", berlin)
+	}
+	if offline := result.Instances[2]; offline.Status != constants.FederationPeerStatusError || offline.Error == "" {
+		t.Errorf("expected offline peer to fail, got %+v", offline)
+	}
+
+	var health struct {
+		InstanceName string `json:"instance_name"`
+		Peers        []struct {
+			Name       string `json:"name"`
+			Status     string `json:"status"`
+			StatusCode int    `json:"status_code"`
+		} `json:"peers"`
+	}
+	if err := coordinator.GetJSON("/api/federation/peers", &health); err != nil {
+		t.Fatalf("peer health failed: %v", err)
+	}
+	if health.InstanceName != "paris" || len(health.Peers) != 2 {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+	if health.Peers[0].Status != constants.FederationPeerStatusOK || health.Peers[1].Status != constants.FederationPeerStatusError {
+		t.Errorf("unexpected peer health: %+v", health.Peers)
+	}
+}
+
+// TestFederation_Disabled verifies federated queries are rejected until
+// peers are configured.
+func TestFederation_Disabled(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	resp, err := ts.POST("/api/federation/query/count", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+	var errResp ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	if errResp.Code != constants.ErrCodeFederationDisabled {
+		t.Errorf("expected %s, got %s", constants.ErrCodeFederationDisabled, errResp.Code)
+	}
+}
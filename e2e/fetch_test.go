@@ -0,0 +1,129 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// fetchURLResult is one URL of a fetch response.
+type fetchURLResult struct {
+	URL      string `json:"url"`
+	Success  bool   `json:"success"`
+	Status   string `json:"status"`
+	Hash     string `json:"hash"`
+	Filename string `json:"filename"`
+	Code     string `json:"code"`
+}
+
+// fetchAssets POSTs URLs to the fetch endpoint of a topic and returns the
+// status and the per-URL results.
+func (ts *TestServer) fetchAssets(t *testing.T, topic, apiKey string, urls []string) (int, []fetchURLResult) {
+	t.Helper()
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, "/api/topics/"+topic+"/assets/fetch", apiKey, map[string]interface{}{"urls": urls})
+	if err != nil {
+		t.Fatalf("fetch request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	var result struct {
+		Results []fetchURLResult `json:"results"`
+	}
+	json.Unmarshal(body, &result)
+	return resp.StatusCode, result.Results
+}
+
+// TestFetch_ImportsURLsWithPerURLResults verifies fetched files are stored
+// and deduplicated like uploads, with one result per URL.
+func TestFetch_ImportsURLsWithPerURLResults(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+	ts.App.Config.Fetch.AllowPrivateNetworks = true // the remote below listens on loopback
+
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/models/cube.obj", "/mirror/cube.obj":
+			w.Write([]byte("v 0 0 0\nv 1 0 0\nv 0 1 0\n"))
+		case "/notes.txt":
+			w.Write([]byte("fetched notes"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer remote.Close()
+
+	status, results := ts.fetchAssets(t, "assets", ts.APIKey, []string{
+		remote.URL + "/models/cube.obj",
+		remote.URL + "/notes.txt",
+		remote.URL + "/mirror/cube.obj",
+		remote.URL + "/missing.bin",
+		"ftp://example.com/file.bin",
+	})
+	if status != http.StatusOK || len(results) != 5 {
+		t.Fatalf("expected 200 with 5 results, got %d: %+v", status, results)
+	}
+
+	want := []struct {
+		success bool
+		status  string
+		code    string
+	}{
+		{true, constants.UploadStatusCreated, ""},
+		{true, constants.UploadStatusCreated, ""},
+		{true, constants.UploadStatusDeduplicated, ""},
+		{false, constants.UploadStatusRejected, constants.ErrCodeFetchFailed},
+		{false, constants.UploadStatusRejected, constants.ErrCodeInvalidFetchURL},
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Success != w.success || r.Status != w.status || r.Code != w.code {
+			t.Errorf("result %d: expected %+v, got %+v", i, w, r)
+		}
+	}
+	if results[0].Filename != "cube.obj" || results[2].Hash != results[0].Hash {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if got := ts.DownloadAsset(t, results[1].Hash); string(got) != "fetched notes" {
+		t.Errorf("expected fetched content, got %q", got)
+	}
+}
+
+// TestFetch_RefusesPrivateAddressesAndUngrantedUploads verifies loopback
+// remotes are blocked by default and fetched files are checked against the
+// caller's upload grant.
+func TestFetch_RefusesPrivateAddressesAndUngrantedUploads(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("remote content"))
+	}))
+	defer remote.Close()
+
+	status, results := ts.fetchAssets(t, "assets", ts.APIKey, []string{remote.URL + "/a.txt"})
+	if status != http.StatusOK || len(results) != 1 || results[0].Code != constants.ErrCodeFetchBlocked {
+		t.Fatalf("expected FETCH_BLOCKED, got %d: %+v", status, results)
+	}
+
+	ts.App.Config.Fetch.AllowPrivateNetworks = true
+	uploader := ts.CreateTestUserWithGrants(t, "pngonly", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionUpload, "constraints_json": `{"allowed_extensions":["png"]}`},
+	})
+	status, results = ts.fetchAssets(t, "assets", uploader.APIKey, []string{remote.URL + "/a.txt"})
+	if status != http.StatusOK || len(results) != 1 || results[0].Success || results[0].Code != constants.ErrCodeAuthConstraintViolation {
+		t.Errorf("expected the txt file to be denied, got %d: %+v", status, results)
+	}
+
+	if status, _ := ts.fetchAssets(t, "missing", ts.APIKey, []string{remote.URL + "/a.txt"}); status != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown topic, got %d", status)
+	}
+	if status, _ := ts.fetchAssets(t, "assets", ts.APIKey, nil); status != http.StatusBadRequest {
+		t.Errorf("expected 400 without URLs, got %d", status)
+	}
+}
@@ -0,0 +1,230 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// uploadWithRelativePath uploads a file with a relative_path form field.
+func (ts *TestServer) uploadWithRelativePath(t *testing.T, topicName, filename, relativePath string, content []byte) (int, map[string]interface{}) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, err := writer.CreateFormFile(constants.FormFieldFile, filename)
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	part.Write(content)
+	writer.WriteField(constants.FormFieldRelativePath, relativePath)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", ts.URL+"/api/topics/"+topicName+"/assets", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("upload request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	var result map[string]interface{}
+	json.Unmarshal(bodyBytes, &result)
+	return resp.StatusCode, result
+}
+
+// TestFolderUpload_PathPreservedInBulkDownload verifies relative paths are
+// stored as metadata, queryable, and rebuilt in ZIPs with filename_format=path.
+func TestFolderUpload_PathPreservedInBulkDownload(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "textures")
+
+	files := []struct {
+		filename string
+		path     string
+		content  string
+	}{
+		{"oak.png", "textures/wood/oak.png", "oak"},
+		{"pine.png", "textures\\wood\\pine.png", "pine"},
+		{"oak.png", "textures/stone/oak.png", "stone oak"},
+	}
+	hashes := make([]string, 0, len(files))
+	for _, f := range files {
+		status, result := ts.uploadWithRelativePath(t, "textures", f.filename, f.path, []byte(f.content))
+		if status != http.StatusOK {
+			t.Fatalf("upload of %s failed with %d: %v", f.path, status, result)
+		}
+		hashes = append(hashes, result["hash"].(string))
+	}
+	loose := ts.UploadFileExpectSuccess(t, "textures", "readme.txt", []byte("loose"), "")
+	hashes = append(hashes, loose.Hash)
+
+	// Stored as metadata, normalized to forward slashes
+	var meta struct {
+		ComputedMetadata map[string]interface{} `json:"computed_metadata"`
+	}
+	if err := ts.GetJSON("/api/assets/"+hashes[1]+"/metadata", &meta); err != nil {
+		t.Fatalf("metadata request failed: %v", err)
+	}
+	if meta.ComputedMetadata[constants.MetadataKeyRelativePath] != "textures/wood/pine.png" {
+		t.Errorf("unexpected relative_path metadata: %v", meta.ComputedMetadata)
+	}
+
+	// Exposed in queries
+	result := ts.ExecuteQuery(t, "by-relative-path", []string{"textures"}, map[string]interface{}{"prefix": "textures/wood/"})
+	if result.RowCount != 2 {
+		t.Fatalf("expected 2 assets under textures/wood/, got %d", result.RowCount)
+	}
+	col := slices.Index(result.Columns, "relative_path")
+	if col < 0 || result.Rows[0][col] != "textures/wood/oak.png" {
+		t.Errorf("expected relative_path column sorted by path, got %v %v", result.Columns, result.Rows)
+	}
+
+	// Rebuilt as folders in the ZIP
+	zipBytes := ts.BulkDownloadExpectSuccess(t, BulkDownloadRequest{
+		Mode:           "ids",
+		AssetIDs:       hashes,
+		FilenameFormat: constants.FilenameFormatPath,
+	})
+	names := ListZIPFiles(t, zipBytes)
+	for _, want := range []string{
+		"assets/textures/wood/oak.png",
+		"assets/textures/wood/pine.png",
+		"assets/textures/stone/oak.png",
+		"assets/readme.txt",
+	} {
+		if !slices.Contains(names, want) {
+			t.Errorf("expected %s in ZIP, got %v", want, names)
+		}
+	}
+	if got := ExtractZIPFile(t, zipBytes, "assets/textures/stone/oak.png"); string(got) != "stone oak" {
+		t.Errorf("unexpected content for stone/oak.png: %q", got)
+	}
+}
+
+// TestFolderUpload_RejectsTraversal verifies relative paths cannot escape the folder.
+func TestFolderUpload_RejectsTraversal(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "textures")
+
+	status, result := ts.uploadWithRelativePath(t, "textures", "passwd", "../../etc/passwd", []byte("x"))
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for traversal, got %d: %v", status, result)
+	}
+	if result["code"] != constants.ErrCodeInvalidRequest {
+		t.Errorf("expected INVALID_REQUEST, got %v", result["code"])
+	}
+}
+
+// uploadFolderBatch uploads several files in one request, each with its
+// relative_path form field.
+func (ts *TestServer) uploadFolderBatch(t *testing.T, topicName string, filenames, relativePaths []string, contents [][]byte) (int, map[string]interface{}) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for i, filename := range filenames {
+		part, err := writer.CreateFormFile(constants.FormFieldFile, filename)
+		if err != nil {
+			t.Fatalf("failed to create form file: %v", err)
+		}
+		part.Write(contents[i])
+	}
+	for _, relativePath := range relativePaths {
+		writer.WriteField(constants.FormFieldRelativePath, relativePath)
+	}
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", ts.URL+"/api/topics/"+topicName+"/assets", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("upload request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	var result map[string]interface{}
+	json.Unmarshal(bodyBytes, &result)
+	return resp.StatusCode, result
+}
+
+// TestFolderUpload_MultiFileUpload verifies a request with several files
+// records each file's relative path and reports each file on its own.
+func TestFolderUpload_MultiFileUpload(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "textures")
+
+	status, result := ts.uploadFolderBatch(t, "textures",
+		[]string{"oak.png", "readme.txt", "passwd"},
+		[]string{"textures/wood/oak.png", "", "../etc/passwd"},
+		[][]byte{[]byte("oak"), []byte("readme"), []byte("x")})
+	if status != http.StatusOK {
+		t.Fatalf("multi-file upload failed with %d: %v", status, result)
+	}
+	if result["total"] != float64(3) || result["succeeded"] != float64(2) || result["failed"] != float64(1) {
+		t.Fatalf("unexpected counts: %v", result)
+	}
+
+	results := result["results"].([]interface{})
+	oak := results[0].(map[string]interface{})
+	if oak["status"] != constants.UploadStatusCreated || oak["relative_path"] != "textures/wood/oak.png" {
+		t.Errorf("unexpected result for oak.png: %v", oak)
+	}
+	if readme := results[1].(map[string]interface{}); readme["relative_path"] != nil {
+		t.Errorf("expected no relative_path for readme.txt, got %v", readme)
+	}
+	if passwd := results[2].(map[string]interface{}); passwd["code"] != constants.ErrCodeInvalidRequest {
+		t.Errorf("expected INVALID_REQUEST for a traversal path, got %v", passwd)
+	}
+
+	var meta struct {
+		ComputedMetadata map[string]interface{} `json:"computed_metadata"`
+	}
+	if err := ts.GetJSON("/api/assets/"+oak["hash"].(string)+"/metadata", &meta); err != nil {
+		t.Fatalf("metadata request failed: %v", err)
+	}
+	if meta.ComputedMetadata[constants.MetadataKeyRelativePath] != "textures/wood/oak.png" {
+		t.Errorf("unexpected relative_path metadata: %v", meta.ComputedMetadata)
+	}
+
+	// Paths must pair up with files
+	status, result = ts.uploadFolderBatch(t, "textures",
+		[]string{"a.png", "b.png"}, []string{"textures/a.png"},
+		[][]byte{[]byte("a"), []byte("b")})
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unpaired relative_path, got %d: %v", status, result)
+	}
+}
+
+// TestFolderUpload_PathNotRecordedFailsUpload verifies an asset is not
+// stored when its relative path cannot be recorded with it.
+func TestFolderUpload_PathNotRecordedFailsUpload(t *testing.T) {
+	ts := startTestServerCustomConfig(t, func(ts *TestServer) {
+		ts.App.Config.Metadata.MaxValueBytes = 10
+	})
+	ts.CreateTopic(t, "textures")
+
+	status, result := ts.uploadWithRelativePath(t, "textures", "oak.png", "textures/wood/oak.png", []byte("oak"))
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 when relative_path cannot be recorded, got %d: %v", status, result)
+	}
+
+	// Nothing was committed, so the same content is new
+	upload := ts.UploadFileExpectSuccess(t, "textures", "oak.png", []byte("oak"), "")
+	if upload.Status != constants.UploadStatusCreated {
+		t.Errorf("expected the content to be stored as new, got %s", upload.Status)
+	}
+}
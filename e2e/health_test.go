@@ -0,0 +1,140 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// readinessResponse mirrors GET /readyz
+type readinessResponse struct {
+	Ready      bool `json:"ready"`
+	Components []struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	} `json:"components"`
+	Subsystems map[string]string `json:"subsystems"`
+}
+
+func (ts *TestServer) getReadiness(t *testing.T) (int, readinessResponse) {
+	t.Helper()
+	resp, err := ts.UnauthenticatedGET(constants.ReadinessPath)
+	if err != nil {
+		t.Fatalf("readyz request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result readinessResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode readyz response: %v", err)
+	}
+	return resp.StatusCode, result
+}
+
+func componentStatus(r readinessResponse, name string) string {
+	for _, c := range r.Components {
+		if c.Name == name {
+			return c.Status
+		}
+	}
+	return ""
+}
+
+// TestHealth_LivenessAlwaysUp verifies /healthz answers without a working directory or credentials.
+func TestHealth_LivenessAlwaysUp(t *testing.T) {
+	ts := StartTestServer(t)
+
+	resp, err := ts.UnauthenticatedGET(constants.HealthPath)
+	if err != nil {
+		t.Fatalf("healthz request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+}
+
+// TestHealth_ReadinessFollowsInitialization verifies /readyz reports 503 with
+// reasons until the working directory is initialized, then 200.
+func TestHealth_ReadinessFollowsInitialization(t *testing.T) {
+	ts := StartTestServer(t)
+
+	status, ready := ts.getReadiness(t)
+	if status != http.StatusServiceUnavailable || ready.Ready {
+		t.Fatalf("expected 503 before configuration, got %d ready=%v", status, ready.Ready)
+	}
+	if componentStatus(ready, constants.HealthComponentWorkingDirectory) != constants.HealthStatusFailed {
+		t.Errorf("expected working_directory to fail, got %+v", ready.Components)
+	}
+	if componentStatus(ready, constants.HealthComponentOrchestratorDB) != constants.HealthStatusFailed {
+		t.Errorf("expected orchestrator_db to fail, got %+v", ready.Components)
+	}
+
+	ts.ConfigureWorkDir(t)
+
+	status, ready = ts.getReadiness(t)
+	if status != http.StatusOK || !ready.Ready {
+		t.Fatalf("expected 200 after configuration, got %d: %+v", status, ready.Components)
+	}
+	for _, c := range ready.Components {
+		if c.Status == constants.HealthStatusFailed {
+			t.Errorf("component %s failed: %s", c.Name, c.Reason)
+		}
+	}
+}
+
+// TestHealth_StartupReportPersisted verifies the initialization report is
+// stored and listed after boot.
+func TestHealth_StartupReportPersisted(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	var reports struct {
+		Current *struct {
+			ID        int64  `json:"id"`
+			Trigger   string `json:"trigger"`
+			Completed bool   `json:"completed"`
+			Steps     []struct {
+				Name   string `json:"name"`
+				Status string `json:"status"`
+			} `json:"steps"`
+		} `json:"current"`
+		History []struct {
+			ID      int64  `json:"id"`
+			Trigger string `json:"trigger"`
+		} `json:"history"`
+	}
+	if err := ts.GetJSON("/api/health/startup", &reports); err != nil {
+		t.Fatalf("startup report request failed: %v", err)
+	}
+
+	if reports.Current == nil || !reports.Current.Completed || reports.Current.Trigger != constants.StartupTriggerConfigAPI {
+		t.Fatalf("unexpected current report: %+v", reports.Current)
+	}
+	steps := map[string]string{}
+	for _, step := range reports.Current.Steps {
+		steps[step.Name] = step.Status
+	}
+	for _, name := range []string{constants.StartupStepOrchestratorDB, constants.StartupStepTopicDiscovery, constants.StartupStepStatsCache} {
+		if steps[name] != constants.StartupStepOK {
+			t.Errorf("expected step %s ok, got %q", name, steps[name])
+		}
+	}
+
+	if len(reports.History) != 1 || reports.History[0].ID != reports.Current.ID {
+		t.Errorf("expected the current report in history, got %+v", reports.History)
+	}
+
+	// Requires credentials
+	resp, err := ts.UnauthenticatedGET("/api/health/startup")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,125 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// idempotentRequest sends a request with an Idempotency-Key header and
+// returns the status, the replay marker and the body.
+func (ts *TestServer) idempotentRequest(t *testing.T, method, path, key, contentType string, body []byte) (int, bool, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.Header.Set(constants.HeaderContentType, contentType)
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	req.Header.Set(constants.HeaderIdempotencyKey, key)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header.Get(constants.HeaderIdempotentReplayed) == "true", data
+}
+
+// idempotentUpload uploads content with a fresh multipart boundary, as a
+// client rebuilding the request on retry would.
+func (ts *TestServer) idempotentUpload(t *testing.T, topic, key string, content []byte) (int, bool, UploadResponse) {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", "retry.bin")
+	part.Write(content)
+	writer.Close()
+
+	status, replayed, data := ts.idempotentRequest(t, http.MethodPost, "/api/topics/"+topic+"/assets", key, writer.FormDataContentType(), buf.Bytes())
+	var upload UploadResponse
+	json.Unmarshal(data, &upload)
+	return status, replayed, upload
+}
+
+// TestIdempotency_UploadRetryReplays verifies a retried upload replays the
+// first response instead of reporting a duplicate.
+func TestIdempotency_UploadRetryReplays(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	content := []byte("uploaded once")
+	status, replayed, first := ts.idempotentUpload(t, "test-topic", "upload-1", content)
+	if status != http.StatusOK || replayed || first.Status != constants.UploadStatusCreated {
+		t.Fatalf("first upload: status %d, replayed %v, %+v", status, replayed, first)
+	}
+
+	status, replayed, retry := ts.idempotentUpload(t, "test-topic", "upload-1", content)
+	if status != http.StatusOK || !replayed {
+		t.Fatalf("retry: status %d, replayed %v", status, replayed)
+	}
+	if retry != first {
+		t.Errorf("expected replayed response %+v, got %+v", first, retry)
+	}
+
+	// Reusing the key for different content is a conflict
+	status, _, _ = ts.idempotentUpload(t, "test-topic", "upload-1", []byte("different content"))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a reused key, got %d", status)
+	}
+
+	// Without the key the same content is reported as already stored
+	again := ts.UploadFileExpectSuccess(t, "test-topic", "retry.bin", content, "")
+	if again.Status == constants.UploadStatusCreated {
+		t.Errorf("expected a non-created status without idempotency key, got %+v", again)
+	}
+}
+
+// TestIdempotency_BatchMetadataRetry verifies a retried batch is applied once
+// and that failed requests release their key.
+func TestIdempotency_BatchMetadataRetry(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "a.txt", []byte("batch content"), "")
+
+	batch, _ := json.Marshal(BatchMetadataRequest{
+		Operations: []BatchMetadataOperation{{Hash: upload.Hash, Op: "set", Key: "review_status", Value: "approved"}},
+		Processor:  "agent",
+	})
+
+	for i := 0; i < 2; i++ {
+		status, replayed, data := ts.idempotentRequest(t, http.MethodPost, "/api/metadata/batch", "batch-1", constants.ContentTypeJSON, batch)
+		if status != http.StatusOK || replayed != (i == 1) {
+			t.Fatalf("attempt %d: status %d, replayed %v: %s", i, status, replayed, data)
+		}
+	}
+
+	history := ts.ExecuteQuery(t, "metadata-history", []string{"test-topic"}, map[string]interface{}{"hash": upload.Hash})
+	if history.RowCount != 1 {
+		t.Errorf("expected the batch to be applied once, got %d log entries", history.RowCount)
+	}
+
+	// Responses are kept for client errors, so the same invalid request
+	// replays its error
+	status, _, _ := ts.idempotentRequest(t, http.MethodPost, "/api/metadata/batch", "batch-2", constants.ContentTypeJSON, []byte(`{`))
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid JSON, got %d", status)
+	}
+	status, replayed, _ := ts.idempotentRequest(t, http.MethodPost, "/api/metadata/batch", "batch-2", constants.ContentTypeJSON, []byte(`{`))
+	if status != http.StatusBadRequest || !replayed {
+		t.Errorf("expected replayed 400, got %d (replayed %v)", status, replayed)
+	}
+
+	status, _, _ = ts.idempotentRequest(t, http.MethodPost, "/api/metadata/batch", "", constants.ContentTypeJSON, batch)
+	if status != http.StatusOK {
+		t.Errorf("expected requests without a key to pass through, got %d", status)
+	}
+}
@@ -0,0 +1,121 @@
+package e2e
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/storage"
+)
+
+type integrityFinding struct {
+	DatFile     string `json:"dat_file"`
+	Kind        string `json:"kind"`
+	StartOffset int64  `json:"start_offset"`
+	EndOffset   int64  `json:"end_offset"`
+	AssetID     string `json:"asset_id"`
+}
+
+type integrityReport struct {
+	Topic    string             `json:"topic"`
+	Extents  int                `json:"extents"`
+	Findings []integrityFinding `json:"findings"`
+}
+
+// topicIntegrityIssues returns the integrity_issues count reported for a topic.
+func (ts *TestServer) topicIntegrityIssues(t *testing.T, name string) int {
+	t.Helper()
+	var topics TopicsResponse
+	if err := ts.GetJSON("/api/topics", &topics); err != nil {
+		t.Fatalf("list topics: %v", err)
+	}
+	for _, topic := range topics.Topics {
+		if topic.Name == name {
+			return topic.IntegrityIssues
+		}
+	}
+	t.Fatalf("topic %s not listed", name)
+	return 0
+}
+
+// TestTopicIntegrity_OrphanedEntry simulates a crash between the .dat append
+// and the database commit and verifies the scan flags the orphaned bytes.
+func TestTopicIntegrity_OrphanedEntry(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "integrity")
+
+	ts.UploadFileExpectSuccess(t, "integrity", "kept.bin", []byte("committed asset"), "")
+
+	var clean integrityReport
+	if err := ts.PostJSON("/api/topics/integrity/integrity", nil, &clean); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if clean.Extents != 1 || len(clean.Findings) != 0 {
+		t.Fatalf("expected a clean scan, got %+v", clean)
+	}
+
+	orphanHash := storage.ComputeBlake3Hex([]byte("orphaned asset"))
+	datPath := filepath.Join(ts.WorkDir, "integrity", "000001.dat")
+	orphanOffset, err := storage.AppendEntry(datPath, orphanHash, []byte("orphaned asset"))
+	if err != nil {
+		t.Fatalf("append orphan: %v", err)
+	}
+
+	var report integrityReport
+	if err := ts.PostJSON("/api/topics/integrity/integrity", nil, &report); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(report.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", report.Findings)
+	}
+	f := report.Findings[0]
+	if f.Kind != constants.IntegrityKindTrailing || f.StartOffset != orphanOffset || f.AssetID != orphanHash {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+
+	var stored struct {
+		Findings []integrityFinding `json:"findings"`
+	}
+	if err := ts.GetJSON("/api/topics/integrity/integrity", &stored); err != nil {
+		t.Fatalf("get findings: %v", err)
+	}
+	if len(stored.Findings) != 1 {
+		t.Errorf("expected stored finding, got %+v", stored.Findings)
+	}
+
+	if n := ts.topicIntegrityIssues(t, "integrity"); n != 1 {
+		t.Errorf("integrity_issues = %d, want 1", n)
+	}
+}
+
+// TestTopicIntegrity_RequiresVerify verifies the endpoint is gated by the
+// verify action.
+func TestTopicIntegrity_RequiresVerify(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "integrity")
+
+	user := ts.CreateTestUserWithGrants(t, "reader", "reader-password-123", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, "/api/topics/integrity/integrity", user.APIKey, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", resp.StatusCode)
+	}
+
+	resp, err = ts.UnauthenticatedGET("/api/topics/integrity/integrity")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// LimitsResponse mirrors the fields of GET /api/limits used by these tests
+type LimitsResponse struct {
+	QueryMaxRows          int `json:"query_max_rows"`
+	BatchMaxOperations    int `json:"batch_max_operations"`
+	MetadataMaxValueBytes int `json:"metadata_max_value_bytes"`
+	MetadataMaxKeyLength  int `json:"metadata_max_key_length"`
+}
+
+// TestLimits_HotUpdate verifies limits are reported, changed at runtime and
+// enforced without a restart.
+func TestLimits_HotUpdate(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "limits-topic")
+	for i := 0; i < 3; i++ {
+		ts.UploadFileExpectSuccess(t, "limits-topic", fmt.Sprintf("f%d.txt", i), []byte(fmt.Sprintf("content %d", i)), "")
+	}
+
+	var limits LimitsResponse
+	if err := ts.GetJSON("/api/limits", &limits); err != nil {
+		t.Fatalf("GET /api/limits failed: %v", err)
+	}
+	if limits.QueryMaxRows != constants.QueryDefaultMaxRows || limits.MetadataMaxKeyLength != constants.MaxMetadataKeyLength {
+		t.Errorf("unexpected default limits: %+v", limits)
+	}
+
+	// Insane values are rejected as a whole
+	resp, err := ts.RequestWithAPIKey(http.MethodPut, "/api/limits", ts.APIKey, map[string]interface{}{
+		"query_max_rows":       2,
+		"batch_max_operations": 0,
+	})
+	if err != nil {
+		t.Fatalf("PUT /api/limits failed: %v", err)
+	}
+	var errResp ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || errResp.Code != constants.ErrCodeInvalidLimits {
+		t.Fatalf("expected 400 %s, got %d %+v", constants.ErrCodeInvalidLimits, resp.StatusCode, errResp)
+	}
+
+	resp, err = ts.RequestWithAPIKey(http.MethodPut, "/api/limits", ts.APIKey, map[string]interface{}{
+		"query_max_rows":           2,
+		"metadata_max_value_bytes": 8,
+	})
+	if err != nil {
+		t.Fatalf("PUT /api/limits failed: %v", err)
+	}
+	var updated struct {
+		Limits  LimitsResponse `json:"limits"`
+		Changed []string       `json:"changed"`
+	}
+	json.NewDecoder(resp.Body).Decode(&updated)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || updated.Limits.QueryMaxRows != 2 || len(updated.Changed) != 2 {
+		t.Fatalf("unexpected update response %d: %+v", resp.StatusCode, updated)
+	}
+
+	result := ts.ExecuteQuery(t, "recent-imports", []string{"limits-topic"}, map[string]interface{}{"days": 1})
+	if result.RowCount != 2 || !result.Truncated {
+		t.Errorf("expected 2 truncated rows, got %d (truncated %v)", result.RowCount, result.Truncated)
+	}
+
+	upload := ts.UploadFileExpectSuccess(t, "limits-topic", "meta.txt", []byte("meta"), "")
+	resp, err = ts.POST("/api/assets/"+upload.Hash+"/metadata", map[string]interface{}{
+		"op": "set", "key": "note", "value": "longer than eight bytes",
+	})
+	if err != nil {
+		t.Fatalf("metadata request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for metadata value over the new limit, got %d", resp.StatusCode)
+	}
+}
+
+// TestLimits_UpdateRequiresManageConfig verifies any user may read limits but
+// only manage_config may change them.
+func TestLimits_UpdateRequiresManageConfig(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	user := ts.CreateTestUserWithGrants(t, "limits-reader", "ReaderPass123!", []map[string]interface{}{
+		{
+			"action":           constants.AuthActionQuery,
+			"constraints_json": `{}`,
+		},
+	})
+
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/limits", user.APIKey, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 reading limits, got %d", resp.StatusCode)
+	}
+
+	resp, err = ts.RequestWithAPIKey(http.MethodPut, "/api/limits", user.APIKey, map[string]interface{}{"query_max_rows": 5})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 changing limits, got %d", resp.StatusCode)
+	}
+}
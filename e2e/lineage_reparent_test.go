@@ -0,0 +1,239 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/queries"
+)
+
+// lineageReparentResponse mirrors POST /api/lineage/reparent, including the
+// error fields of a rejected request.
+type lineageReparentResponse struct {
+	Error   bool   `json:"error"`
+	Code    string `json:"code"`
+	DryRun  bool   `json:"dry_run"`
+	Changed int    `json:"changed"`
+	Topics  []string
+	Items   []struct {
+		Hash      string  `json:"hash"`
+		OldParent *string `json:"old_parent"`
+		NewParent *string `json:"new_parent"`
+		Changed   bool    `json:"changed"`
+	} `json:"items"`
+	Errors []struct {
+		Index int    `json:"index"`
+		Code  string `json:"code"`
+	} `json:"errors"`
+	Result *lineageReparentResponse `json:"result"`
+}
+
+// reparent posts a re-parenting request and decodes the response.
+func (ts *TestServer) reparent(t *testing.T, body interface{}) (int, lineageReparentResponse) {
+	t.Helper()
+	resp, err := ts.POST("/api/lineage/reparent", body)
+	if err != nil {
+		t.Fatalf("reparent request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var out lineageReparentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("failed to decode reparent response: %v", err)
+	}
+	return resp.StatusCode, out
+}
+
+// parentOf reads an asset's parent straight from its topic database.
+func parentOf(t *testing.T, ts *TestServer, topic, hash string) string {
+	t.Helper()
+	var parent *string
+	if err := ts.GetTopicDB(t, topic).QueryRow("SELECT parent_id FROM assets WHERE asset_id = ?", hash).Scan(&parent); err != nil {
+		t.Fatalf("failed to read parent of %s: %v", hash, err)
+	}
+	if parent == nil {
+		return ""
+	}
+	return *parent
+}
+
+// TestLineageReparent_Pairs verifies pairs are applied across topics with
+// their references, change log and timeline events.
+func TestLineageReparent_Pairs(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "sources")
+	ts.CreateTopic(t, "derived")
+
+	base := ts.UploadFileExpectSuccess(t, "sources", "base.bin", []byte("base"), "")
+	mid := ts.UploadFileExpectSuccess(t, "derived", "mid.bin", []byte("mid"), "")
+	leaf := ts.UploadFileExpectSuccess(t, "derived", "leaf.bin", []byte("leaf"), base.Hash)
+
+	changes := map[string]interface{}{
+		"changes": []map[string]string{
+			{"hash": mid.Hash, "parent": base.Hash},
+			{"hash": leaf.Hash, "parent": mid.Hash},
+		},
+		"reason": "migration order",
+	}
+
+	dryRun := map[string]interface{}{"dry_run": true}
+	for k, v := range changes {
+		dryRun[k] = v
+	}
+	status, plan := ts.reparent(t, dryRun)
+	if status != http.StatusOK || !plan.DryRun || plan.Changed != 2 || len(plan.Topics) != 2 {
+		t.Fatalf("unexpected dry run: %d %+v", status, plan)
+	}
+	if parentOf(t, ts, "derived", leaf.Hash) != base.Hash {
+		t.Fatal("dry run changed a parent")
+	}
+
+	status, result := ts.reparent(t, changes)
+	if status != http.StatusOK || result.DryRun || result.Changed != 2 {
+		t.Fatalf("unexpected result: %d %+v", status, result)
+	}
+	if got := parentOf(t, ts, "derived", mid.Hash); got != base.Hash {
+		t.Errorf("expected mid parent %s, got %q", base.Hash, got)
+	}
+	if got := parentOf(t, ts, "derived", leaf.Hash); got != mid.Hash {
+		t.Errorf("expected leaf parent %s, got %q", mid.Hash, got)
+	}
+
+	// Lineage references follow the new parents
+	if refs := ts.GetReferences(t, base.Hash); refs.Count != 1 || refs.References[0].Holder != mid.Hash {
+		t.Errorf("expected base held by mid only, got %+v", refs.References)
+	}
+	if refs := ts.GetReferences(t, mid.Hash); refs.Count != 1 || refs.References[0].Holder != leaf.Hash {
+		t.Errorf("expected mid held by leaf only, got %+v", refs.References)
+	}
+
+	// The change log keeps the old parent and rejects edits
+	db := ts.GetTopicDB(t, "derived")
+	var oldParent, reason string
+	if err := db.QueryRow("SELECT old_parent_id, reason FROM lineage_changes WHERE asset_id = ?", leaf.Hash).Scan(&oldParent, &reason); err != nil {
+		t.Fatalf("failed to read change log: %v", err)
+	}
+	if oldParent != base.Hash || reason != "migration order" {
+		t.Errorf("unexpected change log entry: %s %q", oldParent, reason)
+	}
+	if _, err := db.Exec("UPDATE lineage_changes SET reason = 'edited'"); err == nil {
+		t.Error("expected the change log to reject updates")
+	}
+	if _, err := db.Exec("DELETE FROM lineage_changes"); err == nil {
+		t.Error("expected the change log to reject deletes")
+	}
+
+	var timeline struct {
+		Events []struct {
+			Kind    string                 `json:"kind"`
+			Details map[string]interface{} `json:"details"`
+		} `json:"events"`
+	}
+	if err := ts.GetJSON("/api/assets/"+leaf.Hash+"/timeline?kind="+constants.AssetTimelineKindLineage, &timeline); err != nil {
+		t.Fatalf("timeline request failed: %v", err)
+	}
+	if len(timeline.Events) != 1 || timeline.Events[0].Details["old_parent_id"] != base.Hash ||
+		timeline.Events[0].Details["new_parent_id"] != mid.Hash {
+		t.Errorf("unexpected lineage events: %+v", timeline.Events)
+	}
+
+	// Applying the same changes again is a no-op
+	if status, again := ts.reparent(t, changes); status != http.StatusOK || again.Changed != 0 {
+		t.Errorf("expected nothing to change, got %d %+v", status, again)
+	}
+}
+
+// TestLineageReparent_RejectsInvalid verifies a set with any invalid change
+// is rejected whole.
+func TestLineageReparent_RejectsInvalid(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "art")
+
+	a := ts.UploadFileExpectSuccess(t, "art", "a.bin", []byte("a"), "")
+	b := ts.UploadFileExpectSuccess(t, "art", "b.bin", []byte("b"), a.Hash)
+	c := ts.UploadFileExpectSuccess(t, "art", "c.bin", []byte("c"), "")
+	d := ts.UploadFileExpectSuccess(t, "art", "d.bin", []byte("d"), "")
+	missing := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	status, resp := ts.reparent(t, map[string]interface{}{
+		"changes": []map[string]string{
+			{"hash": c.Hash, "parent": a.Hash}, // valid
+			{"hash": a.Hash, "parent": b.Hash}, // b descends from a
+			{"hash": d.Hash, "parent": missing},
+			{"hash": b.Hash, "parent": b.Hash},
+			{"hash": "not-a-hash", "parent": a.Hash},
+			{"hash": c.Hash, "parent": ""},
+		},
+	})
+	if status != http.StatusBadRequest || resp.Code != constants.ErrCodeLineageReparentInvalid || resp.Result == nil {
+		t.Fatalf("expected 400 %s, got %d %+v", constants.ErrCodeLineageReparentInvalid, status, resp)
+	}
+	want := []string{
+		constants.ErrCodeLineageCycle,
+		constants.ErrCodeParentNotFound,
+		constants.ErrCodeLineageCycle,
+		constants.ErrCodeInvalidHash,
+		constants.ErrCodeInvalidRequest,
+	}
+	if len(resp.Result.Errors) != len(want) {
+		t.Fatalf("expected %d errors, got %+v", len(want), resp.Result.Errors)
+	}
+	for i, code := range want {
+		if resp.Result.Errors[i].Index != i+1 || resp.Result.Errors[i].Code != code {
+			t.Errorf("error %d: expected %s at index %d, got %+v", i, code, i+1, resp.Result.Errors[i])
+		}
+	}
+	if parentOf(t, ts, "art", c.Hash) != "" {
+		t.Error("a valid change was applied despite the rejected ones")
+	}
+
+	// Cycles formed within the set are caught too
+	status, resp = ts.reparent(t, map[string]interface{}{
+		"changes": []map[string]string{
+			{"hash": c.Hash, "parent": d.Hash},
+			{"hash": d.Hash, "parent": c.Hash},
+		},
+	})
+	if status != http.StatusBadRequest || resp.Result == nil || len(resp.Result.Errors) != 2 {
+		t.Errorf("expected both changes of the cycle rejected, got %d %+v", status, resp)
+	}
+
+	if status, _ := ts.reparent(t, map[string]interface{}{}); status != http.StatusBadRequest {
+		t.Errorf("expected 400 without changes, got %d", status)
+	}
+}
+
+// TestLineageReparent_FromQuery verifies changes can be mapped from the rows
+// of a query preset.
+func TestLineageReparent_FromQuery(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.App.QueriesConfig.Presets["parent-by-name"] = queries.Preset{
+		Description: "Children named after their parent",
+		SQL: `SELECT c.asset_id, p.asset_id AS parent_hash FROM assets c
+			JOIN assets p ON p.origin_name || '-child' = c.origin_name`,
+	}
+	ts.CreateTopic(t, "imports")
+
+	// Uploaded children first, as a migration might
+	child := ts.UploadFileExpectSuccess(t, "imports", "scan-child.bin", []byte("child"), "")
+	parent := ts.UploadFileExpectSuccess(t, "imports", "scan.bin", []byte("parent"), "")
+
+	status, result := ts.reparent(t, map[string]interface{}{
+		"query_preset":  "parent-by-name",
+		"parent_column": "parent_hash",
+	})
+	if status != http.StatusOK || result.Changed != 1 {
+		t.Fatalf("unexpected result: %d %+v", status, result)
+	}
+	if got := parentOf(t, ts, "imports", child.Hash); got != parent.Hash {
+		t.Errorf("expected parent %s, got %q", parent.Hash, got)
+	}
+
+	if status, _ := ts.reparent(t, map[string]interface{}{"query_preset": "parent-by-name"}); status != http.StatusBadRequest {
+		t.Errorf("expected 400 without parent_column, got %d", status)
+	}
+}
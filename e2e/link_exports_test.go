@@ -0,0 +1,173 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+type linkSelectResponse struct {
+	Export struct {
+		ID         string `json:"id"`
+		Path       string `json:"path"`
+		AssetCount int    `json:"asset_count"`
+		Entries    []struct {
+			Hash   string `json:"hash"`
+			Path   string `json:"path"`
+			Method string `json:"method"`
+		} `json:"entries"`
+	} `json:"export"`
+	Linked    int `json:"linked"`
+	Unchanged int `json:"unchanged"`
+	Released  int `json:"released"`
+}
+
+// linkRequest posts to a link export route and decodes the response into
+// target, returning the status code.
+func (ts *TestServer) linkRequest(t *testing.T, path string, body, target interface{}) int {
+	t.Helper()
+	resp, err := ts.POST(path, body)
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if target != nil {
+		json.Unmarshal(data, target)
+	}
+	return resp.StatusCode
+}
+
+// TestLinkExports_SelectAndRelease covers creating link exports, incremental
+// selection, releasing links and the references keeping linked assets alive
+// through deletion attempts and compaction.
+func TestLinkExports_SelectAndRelease(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "renders")
+
+	shipData := []byte("ship mesh data")
+	ship := ts.UploadFileExpectSuccess(t, "renders", "ship.glb", shipData, "").Hash
+	hull := ts.UploadFileExpectSuccess(t, "renders", "hull.glb", []byte("hull mesh data"), "").Hash
+	scrap := ts.UploadFileExpectSuccess(t, "renders", "scrap.glb", []byte("scrap mesh data"), "").Hash
+
+	var farm linkSelectResponse
+	status := ts.linkRequest(t, "/api/exports/links", map[string]interface{}{
+		"label":     "farm",
+		"mode":      "ids",
+		"asset_ids": []string{ship},
+	}, &farm)
+	if status != http.StatusCreated || farm.Linked != 1 || farm.Export.ID == "" {
+		t.Fatalf("create failed: %d %+v", status, farm)
+	}
+	if len(farm.Export.Entries) != 1 || farm.Export.Entries[0].Path != "ship.glb" || farm.Export.Entries[0].Method != constants.LinkExportMethodHardlink {
+		t.Fatalf("expected ship.glb hard linked, got %+v", farm.Export.Entries)
+	}
+	shipLink := filepath.Join(farm.Export.Path, "ship.glb")
+	if data, err := os.ReadFile(shipLink); err != nil || !bytes.Equal(data, shipData) {
+		t.Fatalf("expected the link to hold the asset, got %q %v", data, err)
+	}
+
+	// Selecting again only links what is new
+	var selected linkSelectResponse
+	status = ts.linkRequest(t, "/api/exports/links/"+farm.Export.ID+"/select", map[string]interface{}{
+		"mode":      "ids",
+		"asset_ids": []string{ship, hull},
+	}, &selected)
+	if status != http.StatusOK || selected.Linked != 1 || selected.Unchanged != 1 || selected.Export.AssetCount != 2 {
+		t.Fatalf("select failed: %d %+v", status, selected)
+	}
+
+	// A second export shares the extracted copy
+	var preview linkSelectResponse
+	ts.linkRequest(t, "/api/exports/links", map[string]interface{}{
+		"filename_format": constants.FilenameFormatHash,
+		"mode":            "ids",
+		"asset_ids":       []string{ship},
+	}, &preview)
+	previewLink := filepath.Join(preview.Export.Path, ship+".glb")
+	farmInfo, _ := os.Stat(shipLink)
+	previewInfo, err := os.Stat(previewLink)
+	if err != nil || !os.SameFile(farmInfo, previewInfo) {
+		t.Fatalf("expected both exports to link the same copy: %v", err)
+	}
+
+	// Linked assets cannot be deleted; compaction leaves links intact
+	if status, body := ts.deleteAsset(t, ship); status != http.StatusConflict {
+		t.Fatalf("expected 409 deleting a linked asset, got %d: %s", status, body)
+	}
+	if status, body := ts.deleteAsset(t, scrap); status != http.StatusOK {
+		t.Fatalf("delete unlinked asset: expected 200, got %d: %s", status, body)
+	}
+	ts.compactTopic(t, "renders")
+	if data, err := os.ReadFile(shipLink); err != nil || !bytes.Equal(data, shipData) {
+		t.Fatalf("expected the link to survive compaction, got %q %v", data, err)
+	}
+
+	// Releasing removes only this export's link
+	var released struct {
+		Released int `json:"released"`
+	}
+	status = ts.linkRequest(t, "/api/exports/links/"+farm.Export.ID+"/release", map[string]interface{}{
+		"asset_ids": []string{ship},
+	}, &released)
+	if status != http.StatusOK || released.Released != 1 {
+		t.Fatalf("release failed: %d %+v", status, released)
+	}
+	if _, err := os.Stat(shipLink); !os.IsNotExist(err) {
+		t.Errorf("expected the released link to be removed, got %v", err)
+	}
+	if data, err := os.ReadFile(previewLink); err != nil || !bytes.Equal(data, shipData) {
+		t.Errorf("expected the other export's link to remain, got %q %v", data, err)
+	}
+
+	// A replacing selection refreshes the export to exactly the selection
+	var replaced linkSelectResponse
+	ts.linkRequest(t, "/api/exports/links/"+farm.Export.ID+"/select", map[string]interface{}{
+		"mode":      "ids",
+		"asset_ids": []string{ship},
+		"replace":   true,
+	}, &replaced)
+	if replaced.Linked != 1 || replaced.Released != 1 || replaced.Export.AssetCount != 1 {
+		t.Errorf("expected hull replaced by ship, got %+v", replaced)
+	}
+
+	// Deleting the exports frees the asset and the extracted copies
+	for _, id := range []string{farm.Export.ID, preview.Export.ID} {
+		resp, err := ts.DELETE("/api/exports/links/" + id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("delete export: expected 200, got %d", resp.StatusCode)
+		}
+	}
+	if _, err := os.Stat(farm.Export.Path); !os.IsNotExist(err) {
+		t.Errorf("expected the export directory to be removed, got %v", err)
+	}
+	objects := filepath.Join(filepath.Dir(filepath.Dir(farm.Export.Path)), constants.LinkExportObjectsDir, ship[:2], ship)
+	if _, err := os.Stat(objects); !os.IsNotExist(err) {
+		t.Errorf("expected the unused copy to be removed, got %v", err)
+	}
+	if status, body := ts.deleteAsset(t, ship); status != http.StatusOK {
+		t.Errorf("delete released asset: expected 200, got %d: %s", status, body)
+	}
+
+	var errResp ErrorResponse
+	resp, err := ts.GET("/api/exports/links/" + farm.Export.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	if resp.StatusCode != http.StatusNotFound || errResp.Code != constants.ErrCodeLinkExportNotFound {
+		t.Errorf("expected 404 %s, got %d %s", constants.ErrCodeLinkExportNotFound, resp.StatusCode, errResp.Code)
+	}
+}
@@ -0,0 +1,56 @@
+package e2e
+
+import (
+	"slices"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// TestMediaInfo_RecordedOnUpload verifies uploads made with
+// metadata.media_info get their sniffed content type and image dimensions as
+// media metadata, queryable with the by-content-type preset.
+func TestMediaInfo_RecordedOnUpload(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "mixed")
+	ts.App.Config.Metadata.MediaInfo = true
+
+	// The extension does not decide the content type
+	image := ts.UploadFileExpectSuccess(t, "mixed", "photo.bin", testPNG(t, 64, 48), "")
+	text := ts.UploadFileExpectSuccess(t, "mixed", "notes.txt", []byte("plain notes"), "")
+
+	// Uploads made with media_info off are not inspected
+	ts.App.Config.Metadata.MediaInfo = false
+	ts.UploadFileExpectSuccess(t, "mixed", "later.txt", []byte("more notes"), "")
+
+	var meta struct {
+		ComputedMetadata map[string]interface{} `json:"computed_metadata"`
+	}
+	if err := ts.GetJSON("/api/assets/"+image.Hash+"/metadata", &meta); err != nil {
+		t.Fatalf("metadata request failed: %v", err)
+	}
+	m := meta.ComputedMetadata
+	if m[constants.MetadataKeyMediaContentType] != "image/png" || m[constants.MetadataKeyMediaWidth] != float64(64) || m[constants.MetadataKeyMediaHeight] != float64(48) {
+		t.Errorf("unexpected media metadata: %v", m)
+	}
+	if _, ok := m[constants.MetadataKeyMediaDurationSecs]; ok {
+		t.Errorf("images have no duration: %v", m)
+	}
+
+	// A family matches every type in it
+	for _, tc := range []struct {
+		contentType string
+		want        string
+	}{
+		{"image/png", image.Hash},
+		{"image", image.Hash},
+		{"text/plain", text.Hash},
+	} {
+		result := ts.ExecuteQuery(t, "by-content-type", []string{"mixed"}, map[string]interface{}{"type": tc.contentType})
+		col := slices.Index(result.Columns, "asset_id")
+		if result.RowCount != 1 || col < 0 || result.Rows[0][col] != tc.want {
+			t.Errorf("by-content-type %s: expected %s, got %v", tc.contentType, tc.want, result.Rows)
+		}
+	}
+}
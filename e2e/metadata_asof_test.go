@@ -0,0 +1,143 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// queryAsOf runs a preset with as_of and returns the status and result.
+func (ts *TestServer) queryAsOf(t *testing.T, preset string, params map[string]interface{}, asOf int64) (int, QueryResponse) {
+	t.Helper()
+	resp, err := ts.POST("/api/query/"+preset, map[string]interface{}{
+		"params": params,
+		"as_of":  asOf,
+	})
+	if err != nil {
+		t.Fatalf("query request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var result QueryResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result
+}
+
+// TestMetadataAsOf_TimeTravel verifies asset metadata and metadata-aware
+// presets can be read as they were at a past time.
+func TestMetadataAsOf_TimeTravel(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "library")
+
+	released := ts.UploadFileExpectSuccess(t, "library", "released.bin", []byte("released content"), "").Hash
+	later := ts.UploadFileExpectSuccess(t, "library", "later.bin", []byte("later content"), "").Hash
+	ts.SetMetadata(t, released, "status", "draft")
+	ts.SetMetadata(t, released, "status", "final")
+	ts.SetMetadata(t, later, "status", "draft")
+
+	// Spread the history over known times: released at 1000, reviewed as
+	// draft at 1500, the later asset at 2500, finalized at 3000
+	db := ts.GetTopicDB(t, "library")
+	for _, stmt := range []string{
+		`UPDATE assets SET created_at = 1000 WHERE asset_id = '` + released + `'`,
+		`UPDATE assets SET created_at = 2500 WHERE asset_id = '` + later + `'`,
+		`UPDATE metadata_log SET timestamp = 1500 WHERE asset_id = '` + released + `' AND value_text = 'draft'`,
+		`UPDATE metadata_log SET timestamp = 3000 WHERE asset_id = '` + released + `' AND value_text = 'final'`,
+		`UPDATE metadata_log SET timestamp = 2500 WHERE asset_id = '` + later + `'`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to rewrite history: %v", err)
+		}
+	}
+
+	var meta struct {
+		ComputedMetadata map[string]interface{} `json:"computed_metadata"`
+	}
+	if err := ts.GetJSON("/api/assets/"+released+"/metadata?as_of=2000", &meta); err != nil {
+		t.Fatalf("get metadata as_of failed: %v", err)
+	}
+	if meta.ComputedMetadata["status"] != "draft" {
+		t.Errorf("expected status draft at 2000, got %v", meta.ComputedMetadata)
+	}
+	if current := ts.GetAssetMetadata(t, released); !strings.Contains(toJSON(current), `"status":"final"`) {
+		t.Errorf("expected current status final, got %v", current)
+	}
+
+	for query, status := range map[string]int{"as_of=500": http.StatusNotFound, "as_of=yesterday": http.StatusBadRequest} {
+		resp, err := ts.GET("/api/assets/" + released + "/metadata?" + query)
+		if err != nil {
+			t.Fatalf("get metadata request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("%s: expected %d, got %d", query, status, resp.StatusCode)
+		}
+	}
+
+	// At 2000 only the released asset existed, still a draft
+	status, result := ts.queryAsOf(t, "with-metadata", map[string]interface{}{"key": "status"}, 2000)
+	if status != http.StatusOK || result.RowCount != 1 {
+		t.Fatalf("expected 1 row at 2000, got %d %+v", status, result)
+	}
+	jsonCol := -1
+	for i, c := range result.Columns {
+		if c == constants.MetadataJSONColumn {
+			jsonCol = i
+		}
+	}
+	if jsonCol < 0 || !strings.Contains(toJSON(result.Rows[0][jsonCol]), "draft") {
+		t.Errorf("expected the draft status at 2000, got %+v", result.Rows)
+	}
+
+	if _, result := ts.queryAsOf(t, "with-metadata", map[string]interface{}{"key": "status"}, 4000); result.RowCount != 2 {
+		t.Errorf("expected 2 rows at 4000, got %d", result.RowCount)
+	}
+
+	// as_of combines with a collection filter
+	createCollection(t, ts, "library", "drafts", "")
+	addToCollection(t, ts, "library", "drafts", []string{later})
+	var scoped QueryResponse
+	if err := ts.PostJSON("/api/query/with-metadata", map[string]interface{}{
+		"params":     map[string]interface{}{"key": "status"},
+		"as_of":      4000,
+		"collection": "drafts",
+	}, &scoped); err != nil {
+		t.Fatalf("as_of query in a collection failed: %v", err)
+	}
+	if scoped.RowCount != 1 || scoped.Rows[0][0] != later {
+		t.Errorf("expected only the later asset in the collection, got %+v", scoped.Rows)
+	}
+
+	// as_of only applies to presets that read metadata
+	status, _ = ts.queryAsOf(t, "orphans", nil, 2000)
+	if status != http.StatusBadRequest {
+		t.Errorf("as_of on a preset without metadata: expected 400, got %d", status)
+	}
+
+	var listing struct {
+		Presets []struct {
+			Name         string `json:"name"`
+			SupportsAsOf bool   `json:"supports_as_of"`
+		} `json:"presets"`
+	}
+	if err := ts.GetJSON("/api/queries", &listing); err != nil {
+		t.Fatalf("list presets failed: %v", err)
+	}
+	for _, p := range listing.Presets {
+		if (p.Name == "with-metadata" && !p.SupportsAsOf) || (p.Name == "orphans" && p.SupportsAsOf) {
+			t.Errorf("preset %s: unexpected supports_as_of = %v", p.Name, p.SupportsAsOf)
+		}
+	}
+}
+
+// toJSON renders v as JSON for substring checks.
+func toJSON(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,246 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// metadataImportResponse mirrors the response of POST /api/metadata/import.
+type metadataImportResponse struct {
+	Success   bool   `json:"success"`
+	ImportID  string `json:"import_id"`
+	ResultURL string `json:"result_url"`
+	KeyColumn string `json:"key_column"`
+	Summary   struct {
+		Rows       int `json:"rows"`
+		Operations int `json:"operations"`
+		Applied    int `json:"applied"`
+		NotFound   int `json:"not_found"`
+		Ambiguous  int `json:"ambiguous"`
+		Invalid    int `json:"invalid"`
+		Skipped    int `json:"skipped"`
+	} `json:"summary"`
+	Rows []struct {
+		Line       int      `json:"line"`
+		Key        string   `json:"key"`
+		Topic      string   `json:"topic"`
+		Hash       string   `json:"hash"`
+		Status     string   `json:"status"`
+		Applied    int      `json:"applied"`
+		Candidates []string `json:"candidates"`
+	} `json:"rows"`
+}
+
+// importMetadataCSV posts a CSV sheet and returns the status and body.
+func (ts *TestServer) importMetadataCSV(t *testing.T, contentType string, body []byte) (int, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/metadata/import", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	req.Header.Set(constants.HeaderContentType, contentType)
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("import request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+// TestMetadataImportByOriginName resolves rows by filename, reports unknown
+// and ambiguous names, applies the rest and serves the per-row result.
+func TestMetadataImportByOriginName(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "props")
+	ts.CreateTopic(t, "chars")
+
+	barrel := ts.UploadFileExpectSuccess(t, "props", "barrel.fbx", []byte("barrel mesh"), "")
+	crate := ts.UploadFileExpectSuccess(t, "props", "crate.fbx", []byte("crate mesh"), "")
+	ts.UploadFileExpectSuccess(t, "props", "hero.fbx", []byte("hero prop"), "")
+	heroChar := ts.UploadFileExpectSuccess(t, "chars", "hero.fbx", []byte("hero character"), "")
+
+	sheet := "origin_name,artist,lod\n" +
+		"barrel.fbx,alice,2\n" +
+		"crate,bob,\n" +
+		"hero.fbx,carol,1\n" +
+		"missing.fbx,dave,0\n" +
+		"crate.fbx,,\n"
+
+	status, body := ts.importMetadataCSV(t, "text/csv", []byte(sheet))
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+
+	var result metadataImportResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.KeyColumn != constants.MetadataImportColumnOriginName {
+		t.Errorf("expected key_column=origin_name, got %q", result.KeyColumn)
+	}
+	if result.Summary.Rows != 5 || result.Summary.Applied != 2 || result.Summary.NotFound != 1 ||
+		result.Summary.Ambiguous != 1 || result.Summary.Skipped != 1 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+	if result.Success {
+		t.Error("expected success=false with unresolved rows")
+	}
+
+	wantStatus := []string{
+		constants.MetadataImportStatusApplied,
+		constants.MetadataImportStatusApplied,
+		constants.MetadataImportStatusAmbiguous,
+		constants.MetadataImportStatusNotFound,
+		constants.MetadataImportStatusSkipped,
+	}
+	for i, row := range result.Rows {
+		if row.Status != wantStatus[i] {
+			t.Errorf("row %d (%s): expected %s, got %s", i, row.Key, wantStatus[i], row.Status)
+		}
+		if row.Line != i+2 {
+			t.Errorf("row %d: expected line %d, got %d", i, i+2, row.Line)
+		}
+	}
+	if result.Rows[1].Hash != crate.Hash || result.Rows[1].Applied != 1 {
+		t.Errorf("crate row: expected one value applied to %s, got %+v", crate.Hash, result.Rows[1])
+	}
+	if len(result.Rows[2].Candidates) != 2 {
+		t.Errorf("expected two candidates for hero.fbx, got %v", result.Rows[2].Candidates)
+	}
+
+	meta := ts.GetAssetMetadata(t, barrel.Hash)["computed_metadata"].(map[string]interface{})
+	if meta["artist"] != "alice" || meta["lod"] != float64(2) {
+		t.Errorf("unexpected barrel metadata: %v", meta)
+	}
+	meta, _ = ts.GetAssetMetadata(t, crate.Hash)["computed_metadata"].(map[string]interface{})
+	if meta["artist"] != "bob" || meta["lod"] != nil {
+		t.Errorf("empty cells should not be applied: %v", meta)
+	}
+
+	// The result file lists every row
+	resp, err := ts.GET(result.ResultURL)
+	if err != nil {
+		t.Fatalf("result request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for result file, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get(constants.HeaderContentType); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("expected text/csv, got %q", ct)
+	}
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse result CSV: %v", err)
+	}
+	if len(records) != 6 || records[0][1] != constants.MetadataImportColumnOriginName {
+		t.Fatalf("unexpected result CSV: %v", records)
+	}
+	if records[3][4] != constants.MetadataImportStatusAmbiguous || !strings.Contains(records[3][7], heroChar.Hash) {
+		t.Errorf("expected ambiguous row listing candidates, got %v", records[3])
+	}
+
+	// A topic column resolves the ambiguity
+	status, body = ts.importMetadataCSV(t, "text/csv", []byte("origin_name,topic,artist\nhero.fbx,chars,carol\n"))
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	meta = ts.GetAssetMetadata(t, heroChar.Hash)["computed_metadata"].(map[string]interface{})
+	if meta["artist"] != "carol" {
+		t.Errorf("expected artist on chars/hero.fbx, got %v", meta)
+	}
+}
+
+// TestMetadataImportByHashMultipart imports a sheet keyed by hash sent as a
+// multipart upload and reports unknown and malformed hashes.
+func TestMetadataImportByHashMultipart(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "textures")
+
+	upload := ts.UploadFileExpectSuccess(t, "textures", "wall.png", []byte("wall texture"), "")
+
+	sheet := "\ufeffhash,resolution\n" +
+		upload.Hash + ",2048\n" +
+		strings.Repeat("0", constants.HashLength) + ",512\n" +
+		"not-a-hash,256\n"
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, _ := mw.CreateFormFile(constants.FormFieldFile, "sheet.csv")
+	part.Write([]byte(sheet))
+	mw.Close()
+
+	status, body := ts.importMetadataCSV(t, mw.FormDataContentType(), buf.Bytes())
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+
+	var result metadataImportResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.Summary.Applied != 1 || result.Summary.NotFound != 1 || result.Summary.Invalid != 1 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+
+	meta := ts.GetAssetMetadata(t, upload.Hash)
+	if computed := meta["computed_metadata"].(map[string]interface{}); computed["resolution"] != float64(2048) {
+		t.Errorf("unexpected metadata: %v", computed)
+	}
+}
+
+// TestMetadataImportRejectsInvalidSheets verifies malformed sheets are
+// rejected before anything is written.
+func TestMetadataImportRejectsInvalidSheets(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	cases := map[string]string{
+		"empty":          "",
+		"no key column":  "name,artist\nbarrel,alice\n",
+		"both keys":      "hash,origin_name,artist\n",
+		"no value":       "origin_name,topic\nbarrel,props\n",
+		"ragged row":     "origin_name,artist\nbarrel,alice,extra\n",
+		"no data rows":   "origin_name,artist\n",
+		"duplicate keys": "origin_name,artist,artist\nbarrel,a,b\n",
+	}
+	for name, sheet := range cases {
+		status, body := ts.importMetadataCSV(t, "text/csv", []byte(sheet))
+		if status != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, status, body)
+		}
+	}
+
+	// Result files are private and unknown IDs are 404
+	resp, err := ts.GET("/api/metadata/import/0123456789abcdef")
+	if err != nil {
+		t.Fatalf("result request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown import, got %d", resp.StatusCode)
+	}
+	resp, err = ts.GET("/api/metadata/import/..%2F..%2Fconfig")
+	if err != nil {
+		t.Fatalf("result request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for malformed import ID, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,168 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// setupSelectionAsset uploads an asset carrying a small and a large metadata value.
+func setupSelectionAsset(t *testing.T, ts *TestServer) string {
+	t.Helper()
+	ts.CreateTopic(t, "renders")
+	upload := ts.UploadFileExpectSuccess(t, "renders", "shot.bin", []byte("render output"), "")
+	ts.SetMetadata(t, upload.Hash, "status", "approved")
+	ts.SetMetadata(t, upload.Hash, "thumbnail", "large-inline-blob")
+	return upload.Hash
+}
+
+// TestMetadataSelection_Query verifies metadata and metadata_keys on query bodies.
+func TestMetadataSelection_Query(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	setupSelectionAsset(t, ts)
+
+	run := func(body map[string]interface{}) QueryResponse {
+		t.Helper()
+		body["topics"] = []string{"renders"}
+		body["params"] = map[string]interface{}{"key": "status"}
+		var result QueryResponse
+		if err := ts.PostJSON("/api/query/with-metadata", body, &result); err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		return result
+	}
+	metadataColumn := func(result QueryResponse) int {
+		for i, col := range result.Columns {
+			if col == constants.MetadataJSONColumn {
+				return i
+			}
+		}
+		return -1
+	}
+
+	result := run(map[string]interface{}{"metadata_keys": []string{"status"}})
+	col := metadataColumn(result)
+	if col < 0 || result.RowCount != 1 {
+		t.Fatalf("expected one row with metadata column, got %+v", result)
+	}
+	if got := result.Rows[0][col]; got != `{"status":"approved"}` {
+		t.Errorf("expected only status, got %v", got)
+	}
+
+	result = run(map[string]interface{}{"metadata": "keys"})
+	if got := result.Rows[0][metadataColumn(result)]; got != `["status","thumbnail"]` {
+		t.Errorf("expected key names, got %v", got)
+	}
+
+	result = run(map[string]interface{}{"metadata": "none"})
+	if metadataColumn(result) >= 0 || len(result.Rows[0]) != len(result.Columns) {
+		t.Errorf("expected metadata column dropped, got %v", result.Columns)
+	}
+
+	resp, err := ts.POST("/api/query/with-metadata", map[string]interface{}{
+		"params": map[string]interface{}{"key": "status"}, "metadata": "partial",
+	})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	var errResp ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || errResp.Code != constants.ErrCodeInvalidMetadataSelection {
+		t.Errorf("expected 400 %s, got %d %s", constants.ErrCodeInvalidMetadataSelection, resp.StatusCode, errResp.Code)
+	}
+}
+
+// TestMetadataSelection_AssetInfo verifies ?metadata= and ?metadata_keys= on asset info.
+func TestMetadataSelection_AssetInfo(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	hash := setupSelectionAsset(t, ts)
+	path := "/api/assets/" + hash + "/metadata"
+
+	var info map[string]interface{}
+	if err := ts.GetJSON(path+"?metadata_keys=status", &info); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	computed, _ := info["computed_metadata"].(map[string]interface{})
+	withProcessor, _ := info["metadata_with_processor"].([]interface{})
+	if len(computed) != 1 || computed["status"] != "approved" || len(withProcessor) != 1 {
+		t.Errorf("expected only status, got %v", info)
+	}
+
+	info = nil
+	if err := ts.GetJSON(path+"?metadata=keys", &info); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	keys, _ := info["metadata_keys"].([]interface{})
+	if _, ok := info["computed_metadata"]; ok || len(keys) != 2 || info["asset"] == nil {
+		t.Errorf("expected asset and key names only, got %v", info)
+	}
+
+	info = nil
+	if err := ts.GetJSON(path+"?metadata=none", &info); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if _, ok := info["computed_metadata"]; ok || info["metadata_keys"] != nil || info["asset"] == nil {
+		t.Errorf("expected asset info only, got %v", info)
+	}
+}
+
+// TestMetadataSelection_BulkDownload verifies metadata files and the manifest
+// honour the selection.
+func TestMetadataSelection_BulkDownload(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	hash := setupSelectionAsset(t, ts)
+
+	zipBytes := ts.BulkDownloadExpectSuccess(t, BulkDownloadRequest{
+		Mode:            "ids",
+		AssetIDs:        []string{hash},
+		IncludeMetadata: true,
+		FilenameFormat:  "original",
+		MetadataKeys:    []string{"status"},
+	})
+
+	manifest := ExtractZIPManifest(t, zipBytes)
+	if manifest.MetadataMode != constants.MetadataModeFull || len(manifest.MetadataKeys) != 1 {
+		t.Errorf("expected selection recorded in manifest, got %q %v", manifest.MetadataMode, manifest.MetadataKeys)
+	}
+
+	var file struct {
+		ComputedMetadata map[string]interface{} `json:"computed_metadata"`
+	}
+	if err := json.Unmarshal(ExtractZIPFile(t, zipBytes, "metadata/shot.json"), &file); err != nil {
+		t.Fatalf("failed to parse metadata file: %v", err)
+	}
+	if len(file.ComputedMetadata) != 1 || file.ComputedMetadata["status"] != "approved" {
+		t.Errorf("expected only status in metadata file, got %v", file.ComputedMetadata)
+	}
+
+	zipBytes = ts.BulkDownloadExpectSuccess(t, BulkDownloadRequest{
+		Mode:            "ids",
+		AssetIDs:        []string{hash},
+		IncludeMetadata: true,
+		FilenameFormat:  "original",
+		Metadata:        constants.MetadataModeKeys,
+	})
+	var keysFile map[string]interface{}
+	if err := json.Unmarshal(ExtractZIPFile(t, zipBytes, "metadata/shot.json"), &keysFile); err != nil {
+		t.Fatalf("failed to parse metadata file: %v", err)
+	}
+	if _, ok := keysFile["computed_metadata"]; ok || len(keysFile["metadata_keys"].([]interface{})) != 2 {
+		t.Errorf("expected key names only, got %v", keysFile)
+	}
+
+	errResp := ts.BulkDownloadExpectError(t, BulkDownloadRequest{
+		Mode:         "ids",
+		AssetIDs:     []string{hash},
+		Metadata:     constants.MetadataModeNone,
+		MetadataKeys: []string{"status"},
+	}, http.StatusBadRequest)
+	if errResp.Code != constants.ErrCodeInvalidMetadataSelection {
+		t.Errorf("expected %s, got %s", constants.ErrCodeInvalidMetadataSelection, errResp.Code)
+	}
+}
@@ -129,6 +129,34 @@ func TestMonitoringEndpoint_IndexedHashes(t *testing.T) {
 	}
 }
 
+// TestMonitoringEndpoint_AuditQueue verifies the audit write queue is
+// reported with its configured capacity and counters
+func TestMonitoringEndpoint_AuditQueue(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "mon-audit")
+
+	// Wait for the queued entries to be written
+	ts.App.AuditLogger.Flush()
+
+	mon := ts.GetMonitoring(t)
+	if mon.AuditQueue == nil {
+		t.Fatal("expected audit_queue in monitoring response")
+	}
+	if mon.AuditQueue.Capacity != constants.AuditQueueDefaultSize {
+		t.Errorf("Expected Capacity=%d, got %d", constants.AuditQueueDefaultSize, mon.AuditQueue.Capacity)
+	}
+	if mon.AuditQueue.OverflowPolicy != constants.AuditOverflowBlock {
+		t.Errorf("Expected OverflowPolicy=%s, got %s", constants.AuditOverflowBlock, mon.AuditQueue.OverflowPolicy)
+	}
+	if mon.AuditQueue.Written == 0 {
+		t.Error("Expected Written > 0 after creating a topic")
+	}
+	if mon.AuditQueue.Dropped != 0 || mon.AuditQueue.Failed != 0 {
+		t.Errorf("Expected no dropped or failed entries, got %+v", mon.AuditQueue)
+	}
+}
+
 // =============================================================================
 // GET /api/monitoring — Log Files Summary
 // =============================================================================
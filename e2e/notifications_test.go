@@ -0,0 +1,332 @@
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// NotificationFeedResponse is the response of GET /api/notifications
+type NotificationFeedResponse struct {
+	Notifications []struct {
+		ID      int64                  `json:"id"`
+		Topic   string                 `json:"topic"`
+		Event   string                 `json:"event"`
+		AssetID *string                `json:"asset_id"`
+		Actor   string                 `json:"actor"`
+		Details map[string]interface{} `json:"details"`
+		ReadAt  *int64                 `json:"read_at"`
+	} `json:"notifications"`
+	Total         int64            `json:"total"`
+	Unread        int64            `json:"unread"`
+	UnreadByEvent map[string]int64 `json:"unread_by_event"`
+}
+
+// notificationRequest sends a request as the given user and decodes the
+// JSON response, failing unless the expected status is returned.
+func (ts *TestServer) notificationRequest(t *testing.T, method, path, apiKey string, body interface{}, expectedStatus int, target interface{}) {
+	t.Helper()
+	resp, err := ts.RequestWithAPIKey(method, path, apiKey, body)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != expectedStatus {
+		t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expectedStatus, resp.StatusCode, data)
+	}
+	if target != nil {
+		if err := json.Unmarshal(data, target); err != nil {
+			t.Fatalf("%s %s: failed to parse response: %v", method, path, err)
+		}
+	}
+}
+
+// TestNotifications_SubscribeAndFeed verifies subscribers see uploads and
+// changes to the metadata keys they follow.
+func TestNotifications_SubscribeAndFeed(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	watcher := ts.CreateTestUserWithGrants(t, "watcher", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	// The query grant given by the admin is in the feed already
+	ts.notificationRequest(t, http.MethodPost, "/api/notifications/read", watcher.APIKey, nil, http.StatusOK, nil)
+
+	var subResp struct {
+		Subscription struct {
+			Topic        string   `json:"topic"`
+			Events       []string `json:"events"`
+			MetadataKeys []string `json:"metadata_keys"`
+		} `json:"subscription"`
+	}
+	ts.notificationRequest(t, http.MethodPost, "/api/topics/test-topic/subscribe", watcher.APIKey,
+		map[string]interface{}{"metadata_keys": []string{"review_status"}}, http.StatusOK, &subResp)
+	if len(subResp.Subscription.Events) != 2 || subResp.Subscription.MetadataKeys[0] != "review_status" {
+		t.Fatalf("unexpected subscription: %+v", subResp.Subscription)
+	}
+
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "scan.png", []byte("scan content"), "")
+	ts.SetMetadata(t, upload.Hash, "caption", "ignored")
+	ts.SetMetadata(t, upload.Hash, "review_status", "approved")
+
+	// Re-uploading existing content is not a new asset
+	ts.UploadFileExpectSuccess(t, "test-topic", "scan.png", []byte("scan content"), "")
+
+	var feed NotificationFeedResponse
+	ts.notificationRequest(t, http.MethodGet, "/api/notifications", watcher.APIKey, nil, http.StatusOK, &feed)
+	if feed.Total != 3 || feed.Unread != 2 {
+		t.Fatalf("expected 2 unread notifications, got %+v", feed)
+	}
+	changed, added := feed.Notifications[0], feed.Notifications[1]
+	if changed.Event != constants.NotificationEventMetadataChanged || *changed.AssetID != upload.Hash {
+		t.Errorf("unexpected metadata notification: %+v", changed)
+	}
+	if keys, _ := changed.Details["keys"].([]interface{}); len(keys) != 1 || keys[0] != "review_status" {
+		t.Errorf("expected only review_status, got %v", changed.Details["keys"])
+	}
+	if added.Event != constants.NotificationEventAssetAdded || *added.AssetID != upload.Hash || added.Actor != "admin" {
+		t.Errorf("unexpected asset notification: %+v", added)
+	}
+	if added.Details["origin_name"] != "scan.png" {
+		t.Errorf("expected origin_name scan.png, got %v", added.Details["origin_name"])
+	}
+
+	// The admin acted, so the admin is not notified even if subscribed
+	var adminFeed NotificationFeedResponse
+	if err := ts.GetJSON("/api/notifications", &adminFeed); err != nil || adminFeed.Total != 0 {
+		t.Errorf("admin feed: %+v, %v", adminFeed, err)
+	}
+
+	var marked struct {
+		Marked int64 `json:"marked"`
+	}
+	ts.notificationRequest(t, http.MethodPost, "/api/notifications/read", watcher.APIKey,
+		map[string]interface{}{"ids": []int64{added.ID}}, http.StatusOK, &marked)
+	if marked.Marked != 1 {
+		t.Errorf("expected 1 marked, got %d", marked.Marked)
+	}
+	ts.notificationRequest(t, http.MethodGet, "/api/notifications?unread=true", watcher.APIKey, nil, http.StatusOK, &feed)
+	if feed.Total != 1 || feed.Notifications[0].ID != changed.ID {
+		t.Errorf("expected only the metadata notification unread, got %+v", feed)
+	}
+
+	var subs struct {
+		Subscriptions []map[string]interface{} `json:"subscriptions"`
+	}
+	ts.notificationRequest(t, http.MethodGet, "/api/notifications/subscriptions", watcher.APIKey, nil, http.StatusOK, &subs)
+	if len(subs.Subscriptions) != 1 || subs.Subscriptions[0]["topic"] != "test-topic" {
+		t.Errorf("unexpected subscriptions: %+v", subs.Subscriptions)
+	}
+
+	ts.notificationRequest(t, http.MethodDelete, "/api/topics/test-topic/subscribe", watcher.APIKey, nil, http.StatusOK, nil)
+	ts.notificationRequest(t, http.MethodDelete, "/api/topics/test-topic/subscribe", watcher.APIKey, nil, http.StatusNotFound, nil)
+}
+
+// TestNotifications_SubscribeRequiresTopicAccess verifies users can only
+// subscribe to topics they may query.
+func TestNotifications_SubscribeRequiresTopicAccess(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "secret")
+	ts.CreateTopic(t, "public")
+
+	user := ts.CreateTestUserWithGrants(t, "limited", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_topics":["public"]}`},
+	})
+
+	ts.notificationRequest(t, http.MethodPost, "/api/topics/secret/subscribe", user.APIKey, nil, http.StatusForbidden, nil)
+	ts.notificationRequest(t, http.MethodPost, "/api/topics/missing/subscribe", user.APIKey, nil, http.StatusNotFound, nil)
+	ts.notificationRequest(t, http.MethodPost, "/api/topics/public/subscribe", user.APIKey,
+		map[string]interface{}{"events": []string{"bogus"}}, http.StatusBadRequest, nil)
+	ts.notificationRequest(t, http.MethodPost, "/api/topics/public/subscribe", user.APIKey, nil, http.StatusOK, nil)
+}
+
+// TestNotifications_WebhookDelivery verifies pending notifications are
+// posted to the user's webhook and not sent twice.
+func TestNotifications_WebhookDelivery(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	var mu sync.Mutex
+	var deliveries []map[string]interface{}
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		deliveries = append(deliveries, body)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	watcher := ts.CreateTestUserWithGrants(t, "watcher", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	ts.notificationRequest(t, http.MethodPost, "/api/topics/test-topic/subscribe", watcher.APIKey,
+		map[string]interface{}{"events": []string{constants.NotificationEventAssetAdded}}, http.StatusOK, nil)
+
+	ts.notificationRequest(t, http.MethodPut, "/api/notifications/preferences", watcher.APIKey,
+		map[string]interface{}{"webhook_url": "not a url"}, http.StatusBadRequest, nil)
+	ts.notificationRequest(t, http.MethodPut, "/api/notifications/preferences", watcher.APIKey,
+		map[string]interface{}{"email": "watcher@example.com"}, http.StatusBadRequest, nil)
+
+	var prefsResp struct {
+		Preferences struct {
+			Delivery   string `json:"delivery"`
+			WebhookURL string `json:"webhook_url"`
+		} `json:"preferences"`
+		EmailEnabled bool `json:"email_enabled"`
+	}
+	ts.notificationRequest(t, http.MethodPut, "/api/notifications/preferences", watcher.APIKey,
+		map[string]interface{}{"webhook_url": hook.URL}, http.StatusOK, &prefsResp)
+	if prefsResp.Preferences.Delivery != constants.NotificationDeliveryImmediate || prefsResp.Preferences.WebhookURL != hook.URL || prefsResp.EmailEnabled {
+		t.Fatalf("unexpected preferences: %+v", prefsResp)
+	}
+
+	upload := ts.UploadFileExpectSuccess(t, "test-topic", "a.txt", []byte("webhook content"), "")
+
+	ts.App.Services.Notification.DeliverPending(time.Now())
+	ts.App.Services.Notification.DeliverPending(time.Now())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(deliveries) != 1 {
+		t.Fatalf("expected 1 webhook delivery, got %d", len(deliveries))
+	}
+	notifications, _ := deliveries[0]["notifications"].([]interface{})
+	if deliveries[0]["username"] != "watcher" || len(notifications) != 1 {
+		t.Fatalf("unexpected delivery: %+v", deliveries[0])
+	}
+	if n := notifications[0].(map[string]interface{}); n["asset_id"] != upload.Hash || n["event"] != constants.NotificationEventAssetAdded {
+		t.Errorf("unexpected notification: %+v", n)
+	}
+}
+
+// TestNotifications_GrantChanges verifies users are notified when their
+// grants are given, changed or revoked by someone else.
+func TestNotifications_GrantChanges(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	user := ts.CreateTestUserWithGrants(t, "grantee", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	grantID := int64(listActiveGrants(t, ts, user.ID)[constants.AuthActionQuery]["id"].(float64))
+
+	resp, err := ts.PATCH(fmt.Sprintf("/api/auth/grants/%d", grantID), map[string]interface{}{
+		"constraints_json": `{"allowed_topics":["public"]}`,
+	})
+	if err != nil {
+		t.Fatalf("PATCH grant failed: %v", err)
+	}
+	resp.Body.Close()
+	resp, err = ts.DELETE(fmt.Sprintf("/api/auth/grants/%d", grantID))
+	if err != nil {
+		t.Fatalf("DELETE grant failed: %v", err)
+	}
+	resp.Body.Close()
+
+	var feed NotificationFeedResponse
+	ts.notificationRequest(t, http.MethodGet, "/api/notifications", user.APIKey, nil, http.StatusOK, &feed)
+	want := []string{constants.NotificationEventGrantRevoked, constants.NotificationEventGrantUpdated, constants.NotificationEventGrantCreated}
+	if len(feed.Notifications) != len(want) {
+		t.Fatalf("expected %d notifications, got %+v", len(want), feed.Notifications)
+	}
+	for i, n := range feed.Notifications {
+		if n.Event != want[i] || n.Actor != "admin" || n.Details["action"] != constants.AuthActionQuery || n.Details["grant_id"] != float64(grantID) {
+			t.Errorf("notification %d: unexpected %+v", i, n)
+		}
+	}
+	if feed.Notifications[1].Details["constraints_json"] != `{"allowed_topics":["public"]}` {
+		t.Errorf("expected the new constraints, got %v", feed.Notifications[1].Details)
+	}
+	if feed.Unread != 3 || feed.UnreadByEvent[constants.NotificationEventGrantCreated] != 1 || len(feed.UnreadByEvent) != 3 {
+		t.Errorf("unexpected unread counts: %d %v", feed.Unread, feed.UnreadByEvent)
+	}
+
+	// The admin made the changes and is not notified
+	var adminFeed NotificationFeedResponse
+	if err := ts.GetJSON("/api/notifications", &adminFeed); err != nil || adminFeed.Total != 0 {
+		t.Errorf("admin feed: %+v, %v", adminFeed, err)
+	}
+}
+
+// TestNotifications_Stream verifies new notifications and read markers are
+// pushed to the user's open streams.
+func TestNotifications_Stream(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	user := ts.CreateTestUserWithGrants(t, "streamer", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/notifications/stream", nil)
+	req.Header.Set(constants.HeaderXAPIKey, user.APIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	next := func() VerifyEvent {
+		t.Helper()
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var event VerifyEvent
+			if err := json.Unmarshal([]byte(line[6:]), &event); err != nil {
+				t.Fatalf("failed to parse event %q: %v", line, err)
+			}
+			return event
+		}
+		t.Fatalf("stream ended: %v", scanner.Err())
+		return VerifyEvent{}
+	}
+
+	if event := next(); event.Type != constants.NotificationStreamEventUnread || event.Data["unread"] != float64(1) {
+		t.Fatalf("expected the unread counts first, got %+v", event)
+	}
+
+	grantResp, err := ts.POST(fmt.Sprintf("/api/auth/users/%d/grants", user.ID), map[string]interface{}{
+		"action": constants.AuthActionDownload,
+	})
+	if err != nil {
+		t.Fatalf("create grant failed: %v", err)
+	}
+	grantResp.Body.Close()
+
+	event := next()
+	notification, _ := event.Data["notification"].(map[string]interface{})
+	if event.Type != constants.NotificationStreamEventNotification || event.Data["unread"] != float64(2) ||
+		notification["event"] != constants.NotificationEventGrantCreated {
+		t.Fatalf("expected the grant notification, got %+v", event)
+	}
+
+	ts.notificationRequest(t, http.MethodPost, "/api/notifications/read", user.APIKey, nil, http.StatusOK, nil)
+	if event := next(); event.Type != constants.NotificationStreamEventRead || event.Data["marked"] != float64(2) || event.Data["unread"] != float64(0) {
+		t.Errorf("expected the read marker, got %+v", event)
+	}
+}
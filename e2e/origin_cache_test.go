@@ -0,0 +1,166 @@
+package e2e
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// downloadWithKey downloads an asset and returns the status, body and
+// Content-Disposition header.
+func (ts *TestServer) downloadWithKey(t *testing.T, apiKey, hash string) (int, []byte, string) {
+	t.Helper()
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/assets/"+hash+"/download", apiKey, nil)
+	if err != nil {
+		t.Fatalf("download request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data, resp.Header.Get(constants.HeaderContentDisposition)
+}
+
+func (ts *TestServer) originCacheStatus(t *testing.T) services.OriginCacheStatus {
+	t.Helper()
+	var status services.OriginCacheStatus
+	if err := ts.GetJSON("/api/origin-cache", &status); err != nil {
+		t.Fatalf("GET /api/origin-cache failed: %v", err)
+	}
+	return status
+}
+
+// TestOriginCache_FetchThroughAndEvict verifies a cache instance fetches
+// missing assets from its origin into the cache topic, serves them locally
+// afterwards, evicts the least used fetched asset past its size limit and
+// fetches it again when next downloaded.
+func TestOriginCache_FetchThroughAndEvict(t *testing.T) {
+	origin := StartTestServer(t)
+	origin.ConfigureWorkDir(t)
+	origin.CreateTopic(t, "masters")
+	contents := [][]byte{GenerateTestFile(400 << 10), GenerateTestFile(400 << 10), GenerateTestFile(400 << 10)}
+	hashes := make([]string, len(contents))
+	for i, name := range []string{"hero.png", "villain.png", "sidekick.png"} {
+		hashes[i] = origin.UploadFileExpectSuccess(t, "masters", name, contents[i], "").Hash
+	}
+	originUser := origin.CreateTestUserWithGrants(t, "edge-berlin", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionDownload},
+	})
+
+	cache := StartTestServer(t)
+	cache.ConfigureWorkDir(t)
+	cache.App.Config.Origin = config.OriginConfig{
+		URL:           origin.URL,
+		APIKey:        originUser.APIKey,
+		Topic:         "from-hq",
+		MaxCacheBytes: 1 << 20, // two of the three assets
+		Eviction:      constants.OriginEvictionLFU,
+		TimeoutSecs:   30,
+	}
+
+	// A user who may not download from the cache topic triggers no fetch
+	cache.CreateTopic(t, "local")
+	outsider := cache.CreateTestUserWithGrants(t, "outsider", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionDownload, "constraints_json": `{"allowed_topics":["local"]}`},
+	})
+	if status, _, _ := cache.downloadWithKey(t, outsider.APIKey, hashes[0]); status != http.StatusForbidden {
+		t.Fatalf("expected 403 for a user without access to the cache topic, got %d", status)
+	}
+
+	status, body, disposition := cache.downloadWithKey(t, cache.APIKey, hashes[0])
+	if status != http.StatusOK || !bytes.Equal(body, contents[0]) {
+		t.Fatalf("expected the fetched asset to be served, got %d (%d bytes)", status, len(body))
+	}
+	if !strings.Contains(disposition, "hero.png") {
+		t.Errorf("expected the origin file name to be kept, got %q", disposition)
+	}
+	info, err := cache.App.Services.Asset.GetInfo(hashes[0])
+	if err != nil || info.TopicName != "from-hq" {
+		t.Fatalf("expected the asset to be stored in the cache topic, got %+v, %v", info, err)
+	}
+
+	// Served locally from now on
+	for i := 0; i < 2; i++ {
+		if status, body, _ := cache.downloadWithKey(t, cache.APIKey, hashes[0]); status != http.StatusOK || !bytes.Equal(body, contents[0]) {
+			t.Fatalf("expected a cache hit, got %d", status)
+		}
+	}
+	if status, _, _ := cache.downloadWithKey(t, cache.APIKey, hashes[1]); status != http.StatusOK {
+		t.Fatalf("second fetch failed: %d", status)
+	}
+	got := cache.originCacheStatus(t)
+	if got.Hits != 2 || got.Misses != 2 || got.Entries != 2 || got.Bytes != 800<<10 || got.HitRatio != 0.5 {
+		t.Fatalf("unexpected status after two fetches and two hits: %+v", got)
+	}
+
+	// Past the limit the least downloaded fetched asset goes
+	if status, _, _ := cache.downloadWithKey(t, cache.APIKey, hashes[2]); status != http.StatusOK {
+		t.Fatalf("third fetch failed: %d", status)
+	}
+	got = cache.originCacheStatus(t)
+	if got.Evictions != 1 || got.Entries != 2 || got.Bytes > got.MaxBytes {
+		t.Fatalf("expected one eviction back under the limit, got %+v", got)
+	}
+	if _, err := cache.App.Services.Asset.GetInfo(hashes[1]); err == nil {
+		t.Errorf("expected the least downloaded asset to be evicted")
+	}
+	if _, err := cache.App.Services.Asset.GetInfo(hashes[0]); err != nil {
+		t.Errorf("expected the most downloaded asset to stay cached: %v", err)
+	}
+	if len(got.Recent) != 2 || got.Recent[0].Hash != hashes[2] {
+		t.Errorf("expected the newest fetch first, got %+v", got.Recent)
+	}
+
+	// An evicted asset is fetched again
+	if status, body, _ := cache.downloadWithKey(t, cache.APIKey, hashes[1]); status != http.StatusOK || !bytes.Equal(body, contents[1]) {
+		t.Fatalf("expected the evicted asset to be fetched again, got %d", status)
+	}
+	if got := cache.originCacheStatus(t); got.Misses != 4 || got.Evictions != 2 {
+		t.Errorf("expected a fourth miss and a second eviction, got %+v", got)
+	}
+
+	// Unknown to the origin too
+	missing := strings.Repeat("0", constants.HashLength)
+	if status, _, _ := cache.downloadWithKey(t, cache.APIKey, missing); status != http.StatusNotFound {
+		t.Errorf("expected 404 for an asset the origin lacks, got %d", status)
+	}
+	var monitoring struct {
+		OriginCache *services.OriginCacheStatus `json:"origin_cache"`
+	}
+	if err := cache.GetJSON("/api/monitoring", &monitoring); err != nil || monitoring.OriginCache == nil || monitoring.OriginCache.FetchErrors != 0 {
+		t.Errorf("expected the origin cache in monitoring without fetch errors, got %+v, %v", monitoring.OriginCache, err)
+	}
+}
+
+// TestOriginCache_RejectsBadContent verifies content that does not hash to
+// the requested hash is never stored, and that an unreachable origin is
+// reported as a gateway error.
+func TestOriginCache_RejectsBadContent(t *testing.T) {
+	liar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not what was asked for"))
+	}))
+	defer liar.Close()
+
+	cache := StartTestServer(t)
+	cache.ConfigureWorkDir(t)
+	cache.App.Config.Origin = config.OriginConfig{URL: liar.URL, APIKey: "unused", Topic: "from-hq", Eviction: constants.OriginEvictionLRU, TimeoutSecs: 30}
+
+	hash := strings.Repeat("a", constants.HashLength)
+	status, body, _ := cache.downloadWithKey(t, cache.APIKey, hash)
+	if status != http.StatusBadGateway || !strings.Contains(string(body), constants.ErrCodeOriginHashMismatch) {
+		t.Fatalf("expected 502 %s, got %d %s", constants.ErrCodeOriginHashMismatch, status, body)
+	}
+	if got := cache.originCacheStatus(t); got.Entries != 0 || got.FetchErrors != 1 {
+		t.Errorf("expected nothing cached and one fetch error, got %+v", got)
+	}
+
+	cache.App.Config.Origin.URL = "http://127.0.0.1:1"
+	if status, _, _ := cache.downloadWithKey(t, cache.APIKey, hash); status != http.StatusBadGateway {
+		t.Errorf("expected 502 for an unreachable origin, got %d", status)
+	}
+}
@@ -0,0 +1,218 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/plugin"
+)
+
+// The backends below are compiled into the test binary the way a fork would
+// add its own: registered from init and selected by type in the config.
+
+// memoryBlobStore keeps .dat files in memory, shared by every topic view.
+type memoryBlobStore struct {
+	name, topic string
+}
+
+var memoryBlobs = struct {
+	sync.Mutex
+	files map[string][]byte
+}{files: map[string][]byte{}}
+
+func (s *memoryBlobStore) key(datFile string) string {
+	return s.name + "/" + s.topic + "/" + datFile
+}
+
+func (s *memoryBlobStore) Name() string { return s.name }
+
+func (s *memoryBlobStore) Open(datFile string, offset, length int64) (io.ReadCloser, error) {
+	memoryBlobs.Lock()
+	defer memoryBlobs.Unlock()
+	data, ok := memoryBlobs.files[s.key(datFile)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", datFile, fs.ErrNotExist)
+	}
+	return io.NopCloser(bytes.NewReader(data[offset : offset+length])), nil
+}
+
+func (s *memoryBlobStore) Size(datFile string) (int64, error) {
+	memoryBlobs.Lock()
+	defer memoryBlobs.Unlock()
+	data, ok := memoryBlobs.files[s.key(datFile)]
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", datFile, fs.ErrNotExist)
+	}
+	return int64(len(data)), nil
+}
+
+func (s *memoryBlobStore) Put(datFile string, r io.ReadSeeker, size int64) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	memoryBlobs.Lock()
+	defer memoryBlobs.Unlock()
+	memoryBlobs.files[s.key(datFile)] = data
+	return nil
+}
+
+func (s *memoryBlobStore) Delete(datFile string) error {
+	memoryBlobs.Lock()
+	defer memoryBlobs.Unlock()
+	delete(memoryBlobs.files, s.key(datFile))
+	return nil
+}
+
+// markerScanner flags files containing its marker option.
+type markerScanner struct {
+	marker []byte
+}
+
+func (s *markerScanner) Scan(ctx context.Context, path string) (plugin.ScanResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return plugin.ScanResult{}, err
+	}
+	if bytes.Contains(data, s.marker) {
+		return plugin.ScanResult{Infected: true, Detail: "marker found"}, nil
+	}
+	return plugin.ScanResult{}, nil
+}
+
+// trustingProvider accepts the username of a header as is.
+type trustingProvider struct {
+	header string
+}
+
+func (p *trustingProvider) Authenticate(r *http.Request) (string, error) {
+	return r.Header.Get(p.header), nil
+}
+
+// recordingNotifier sends notifications to a channel.
+var recordedNotifications = make(chan plugin.Notification, 64)
+
+type recordingNotifier struct{}
+
+func (recordingNotifier) Notify(ctx context.Context, n plugin.Notification) error {
+	recordedNotifications <- n
+	return nil
+}
+
+func init() {
+	plugin.RegisterBlobStore("e2e-memory", func(cfg plugin.BlobStoreConfig) (plugin.BlobStore, error) {
+		return &memoryBlobStore{name: cfg.Name, topic: cfg.Topic}, nil
+	})
+	plugin.RegisterScanner("e2e-marker", func(cfg plugin.ScannerConfig) (plugin.ContentScanner, error) {
+		marker, err := cfg.Options.Require("marker")
+		if err != nil {
+			return nil, err
+		}
+		return &markerScanner{marker: []byte(marker)}, nil
+	})
+	plugin.RegisterAuthProvider("e2e-trusting", func(opts plugin.Options) (plugin.AuthProvider, error) {
+		return &trustingProvider{header: opts.Get("header", "X-E2E-User")}, nil
+	})
+	plugin.RegisterNotifier("e2e-recording", func(opts plugin.Options) (plugin.Notifier, error) {
+		return recordingNotifier{}, nil
+	})
+}
+
+// TestPlugins_CustomBackends runs uploads, offloads, logins and
+// notifications through backends registered from outside the internal
+// packages.
+func TestPlugins_CustomBackends(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.App.Config.MaxDatSize = 1048576
+	ts.App.Config.BlobStores = map[string]config.BlobStoreConfig{"memory": {Type: "e2e-memory"}}
+	ts.App.Config.TopicBlobStores = map[string]string{"cold": "memory"}
+	ts.App.Config.Scan = config.ScanConfig{Type: "e2e-marker", Options: map[string]string{"marker": "EVIL"}, TimeoutSecs: 5}
+	ts.App.Config.AuthProviders = []config.PluginConfig{{Type: "e2e-trusting"}}
+	ts.App.Config.Notifiers = []config.PluginConfig{{Type: "e2e-recording"}}
+	if errs := ts.App.Config.FieldErrors(); len(errs) != 0 {
+		t.Fatalf("expected the plugin configuration to validate, got %v", errs)
+	}
+	ts.ConfigureWorkDir(t)
+	ts.Restart(t)
+	ts.CreateTopic(t, "cold")
+
+	// Scanner
+	if status, body := ts.setStoragePolicy(t, "exe", map[string]interface{}{"scan": true}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	errResp := ts.UploadFileExpectError(t, "cold", "tool.exe", []byte("an EVIL payload"), "", http.StatusUnprocessableEntity)
+	if errResp.Code != constants.ErrCodeUploadScanRejected || !strings.Contains(errResp.Message, "marker found") {
+		t.Errorf("expected the scanner to reject the upload, got %+v", errResp)
+	}
+	ts.UploadFileExpectSuccess(t, "cold", "tool.exe", []byte("a harmless payload"), "")
+
+	// Blob store
+	contents := make([][]byte, 3)
+	hashes := make([]string, 3)
+	for i := range contents {
+		contents[i] = GenerateTestFile(600 * 1024)
+		hashes[i] = ts.UploadFileExpectSuccess(t, "cold", "file.bin", contents[i], "").Hash
+	}
+	result, err := ts.App.Services.BlobStores.Offload("cold")
+	if err != nil || len(result.DatFiles) == 0 || len(result.Errors) != 0 {
+		t.Fatalf("offload failed: %+v, %v", result, err)
+	}
+	for i, hash := range hashes {
+		if got := ts.DownloadAsset(t, hash); !bytes.Equal(got, contents[i]) {
+			t.Errorf("asset %d changed after offload to the plugin store", i)
+		}
+	}
+
+	// Auth provider
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/auth/me", nil)
+	req.Header.Set("X-E2E-User", "viewer")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an unknown user, got %d", resp.StatusCode)
+	}
+
+	viewer := ts.CreateTestUserWithGrants(t, "viewer", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `"username":"viewer"`) {
+		t.Errorf("expected the provider to log in viewer, got %d: %s", resp.StatusCode, body)
+	}
+
+	// Notifier: the grant given to the new user is in their feed
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case n := <-recordedNotifications:
+			if n.UserID != viewer.ID {
+				continue
+			}
+			if n.Username != "viewer" || n.Event != constants.NotificationEventGrantCreated {
+				t.Errorf("unexpected notification %+v", n)
+			}
+			return
+		case <-deadline:
+			t.Fatal("the notifier did not receive the grant notification")
+		}
+	}
+}
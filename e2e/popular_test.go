@@ -0,0 +1,120 @@
+package e2e
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/services"
+)
+
+type relatedResponse struct {
+	Hash               string                     `json:"hash"`
+	Topic              string                     `json:"topic"`
+	DownloadedTogether []services.DiscoveredAsset `json:"downloaded_together"`
+	Similar            []database.SimilarAsset    `json:"similar"`
+}
+
+func discoveredHashes(assets []services.DiscoveredAsset) []string {
+	hashes := []string{}
+	for _, a := range assets {
+		hashes = append(hashes, a.Hash)
+	}
+	return hashes
+}
+
+// TestPopularAndRelated checks download rankings per topic, downloaded
+// together and similar assets, and that topics outside a query grant stay
+// hidden.
+func TestPopularAndRelated(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "props")
+	ts.CreateTopic(t, "secret")
+
+	tree := ts.UploadFileExpectSuccess(t, "props", "tree.glb", []byte("tree mesh"), "").Hash
+	bush := ts.UploadFileExpectSuccess(t, "props", "bush.glb", []byte("bush mesh"), "").Hash
+	rock := ts.UploadFileExpectSuccess(t, "props", "rock.glb", []byte("rock mesh"), "").Hash
+	hidden := ts.UploadFileExpectSuccess(t, "secret", "hidden.glb", []byte("hidden mesh"), "").Hash
+
+	for _, hash := range []string{tree, bush} {
+		ts.SetMetadata(t, hash, "style", "low-poly")
+		ts.SetMetadata(t, hash, "biome", "forest")
+	}
+	ts.SetMetadata(t, rock, "style", "low-poly")
+
+	for _, hash := range []string{tree, tree, tree, bush, hidden} {
+		ts.DownloadAsset(t, hash)
+	}
+	ts.App.AuditLogger.Flush()
+
+	var popular services.PopularAssets
+	if err := ts.GetJSON("/api/popular", &popular); err != nil {
+		t.Fatalf("popular failed: %v", err)
+	}
+	props := popular.Topics["props"]
+	if len(props) != 2 || props[0].Hash != tree || props[0].Downloads != 3 || props[1].Hash != bush {
+		t.Fatalf("expected tree then bush, got %+v", props)
+	}
+	if props[0].OriginName != "tree" || props[0].Users != 1 {
+		t.Errorf("expected the asset name and one user, got %+v", props[0])
+	}
+	if len(popular.Topics["secret"]) != 1 || popular.Days != 30 {
+		t.Errorf("expected the secret topic over 30 days, got %+v", popular)
+	}
+
+	// Results are cached until the stats cache expires them
+	ts.DownloadAsset(t, bush)
+	ts.App.AuditLogger.Flush()
+	var again services.PopularAssets
+	if err := ts.GetJSON("/api/popular?topic=props", &again); err != nil {
+		t.Fatalf("popular failed: %v", err)
+	}
+	if len(again.Topics) != 1 || again.Topics["props"][1].Downloads != 1 {
+		t.Errorf("expected the cached props ranking only, got %+v", again.Topics)
+	}
+
+	var related relatedResponse
+	if err := ts.GetJSON("/api/assets/"+tree+"/related", &related); err != nil {
+		t.Fatalf("related failed: %v", err)
+	}
+	if got := discoveredHashes(related.DownloadedTogether); len(got) != 2 || !slices.Contains(got, bush) || !slices.Contains(got, hidden) {
+		t.Errorf("expected bush and hidden downloaded together, got %v", got)
+	}
+	if len(related.Similar) != 2 || related.Similar[0].AssetID != bush || len(related.Similar[0].SharedKeys) != 2 || related.Similar[1].AssetID != rock {
+		t.Errorf("expected bush then rock as similar, got %+v", related.Similar)
+	}
+
+	viewer := ts.CreateTestUserWithGrants(t, "viewer", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_topics":["props"]}`},
+	})
+	oldKey := ts.APIKey
+	ts.APIKey = viewer.APIKey
+	defer func() { ts.APIKey = oldKey }()
+
+	var scoped services.PopularAssets
+	if err := ts.GetJSON("/api/popular", &scoped); err != nil {
+		t.Fatalf("popular failed: %v", err)
+	}
+	if _, ok := scoped.Topics["secret"]; ok || len(scoped.Topics["props"]) != 2 {
+		t.Errorf("expected only props for the viewer, got %+v", scoped.Topics)
+	}
+	var scopedRelated relatedResponse
+	if err := ts.GetJSON("/api/assets/"+tree+"/related", &scopedRelated); err != nil {
+		t.Fatalf("related failed: %v", err)
+	}
+	if got := discoveredHashes(scopedRelated.DownloadedTogether); len(got) != 1 || got[0] != bush {
+		t.Errorf("expected only bush for the viewer, got %v", got)
+	}
+
+	resp, err := ts.GET("/api/assets/" + hidden + "/related")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for an asset outside the grant, got %d", resp.StatusCode)
+	}
+}
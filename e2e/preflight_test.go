@@ -0,0 +1,194 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+type preflightItem struct {
+	Hash   string `json:"hash"`
+	Topic  string `json:"topic"`
+	Reason string `json:"reason"`
+	Code   string `json:"code"`
+}
+
+type preflightResult struct {
+	Action        string          `json:"action"`
+	Allowed       bool            `json:"allowed"`
+	Total         int             `json:"total"`
+	AllowedCount  int             `json:"allowed_count"`
+	DeniedCount   int             `json:"denied_count"`
+	RequestDenied *preflightItem  `json:"request_denied"`
+	DeniedTopics  []preflightItem `json:"denied_topics"`
+	Denied        []preflightItem `json:"denied"`
+	NotFound      []string        `json:"not_found"`
+}
+
+type preflightDeniedResponse struct {
+	Error     bool             `json:"error"`
+	Code      string           `json:"code"`
+	Preflight *preflightResult `json:"preflight"`
+}
+
+// decodePreflight reads a response with the expected status into target.
+func decodePreflight(t *testing.T, resp *http.Response, err error, expectedStatus int, target interface{}) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != expectedStatus {
+		t.Fatalf("expected status %d, got %d", expectedStatus, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+}
+
+// setupPreflightTopics uploads one asset to an allowed and a forbidden topic
+// and creates a user whose metadata grant only covers the allowed one.
+func setupPreflightTopics(t *testing.T, ts *TestServer) (allowedHash, forbiddenHash string, user TestUserInfo) {
+	t.Helper()
+	ts.CreateTopic(t, "pf-allowed")
+	ts.CreateTopic(t, "pf-forbidden")
+	allowedHash = ts.UploadFileExpectSuccess(t, "pf-allowed", "a.bin", []byte("preflight allowed"), "").Hash
+	forbiddenHash = ts.UploadFileExpectSuccess(t, "pf-forbidden", "b.bin", []byte("preflight forbidden"), "").Hash
+
+	user = ts.CreateTestUserWithGrants(t, "pfuser", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionMetadata, "constraints_json": `{"allowed_topics":["pf-allowed"]}`},
+	})
+	return allowedHash, forbiddenHash, user
+}
+
+// TestPreflight_BatchMetadataRejectedBeforeExecution verifies a batch touching
+// a forbidden topic is rejected whole, with the denied assets listed.
+func TestPreflight_BatchMetadataRejectedBeforeExecution(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	allowedHash, forbiddenHash, user := setupPreflightTopics(t, ts)
+
+	req := BatchMetadataRequest{
+		Operations: []BatchMetadataOperation{
+			{Hash: allowedHash, Op: "set", Key: "status", Value: "ok"},
+			{Hash: forbiddenHash, Op: "set", Key: "status", Value: "ok"},
+		},
+	}
+
+	var denied preflightDeniedResponse
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, "/api/metadata/batch", user.APIKey, req)
+	decodePreflight(t, resp, err, http.StatusForbidden, &denied)
+
+	if denied.Code != constants.ErrCodeAuthPreflightDenied {
+		t.Errorf("expected code %s, got %s", constants.ErrCodeAuthPreflightDenied, denied.Code)
+	}
+	if denied.Preflight == nil || denied.Preflight.DeniedCount != 1 || denied.Preflight.AllowedCount != 1 {
+		t.Fatalf("expected 1 allowed and 1 denied, got %+v", denied.Preflight)
+	}
+	if denied.Preflight.Denied[0].Hash != forbiddenHash || denied.Preflight.Denied[0].Topic != "pf-forbidden" {
+		t.Errorf("unexpected denied item: %+v", denied.Preflight.Denied[0])
+	}
+
+	// Nothing was written, not even for the allowed asset
+	computed, _ := ts.GetAssetMetadata(t, allowedHash)["computed_metadata"].(map[string]interface{})
+	if _, ok := computed["status"]; ok {
+		t.Error("expected no metadata written when preflight denies the batch")
+	}
+
+	// Trimmed batch succeeds
+	req.Operations = req.Operations[:1]
+	var ok BatchMetadataResponse
+	resp, err = ts.RequestWithAPIKey(http.MethodPost, "/api/metadata/batch", user.APIKey, req)
+	decodePreflight(t, resp, err, http.StatusOK, &ok)
+	if ok.Succeeded != 1 {
+		t.Errorf("expected 1 succeeded, got %d", ok.Succeeded)
+	}
+}
+
+// TestPreflight_Endpoint verifies the standalone check reports denied and
+// missing assets without executing anything.
+func TestPreflight_Endpoint(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	allowedHash, forbiddenHash, user := setupPreflightTopics(t, ts)
+	missingHash := strings.Repeat("0", constants.HashLength)
+
+	var result preflightResult
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, "/api/auth/me/preflight", user.APIKey, map[string]interface{}{
+		"action": constants.AuthActionMetadata,
+		"hashes": []string{allowedHash, forbiddenHash, missingHash},
+	})
+	decodePreflight(t, resp, err, http.StatusOK, &result)
+
+	if result.Allowed {
+		t.Error("expected allowed=false")
+	}
+	if result.Total != 2 || result.DeniedCount != 1 {
+		t.Errorf("expected total=2 denied=1, got total=%d denied=%d", result.Total, result.DeniedCount)
+	}
+	if len(result.DeniedTopics) != 1 || result.DeniedTopics[0].Topic != "pf-forbidden" {
+		t.Errorf("expected pf-forbidden in denied_topics, got %+v", result.DeniedTopics)
+	}
+	if len(result.NotFound) != 1 || result.NotFound[0] != missingHash {
+		t.Errorf("expected missing hash in not_found, got %v", result.NotFound)
+	}
+
+	// A user without any metadata grant gets a request-level denial only
+	nogrant := ts.CreateTestUser(t, "pfnogrant", "secure-password-12345")
+	var bare preflightResult
+	resp, err = ts.RequestWithAPIKey(http.MethodPost, "/api/auth/me/preflight", nogrant.APIKey, map[string]interface{}{
+		"action": constants.AuthActionMetadata,
+		"hashes": []string{allowedHash},
+	})
+	decodePreflight(t, resp, err, http.StatusOK, &bare)
+
+	if bare.RequestDenied == nil || bare.RequestDenied.Code != constants.ErrCodeAuthForbidden {
+		t.Errorf("expected request_denied with %s, got %+v", constants.ErrCodeAuthForbidden, bare.RequestDenied)
+	}
+	if len(bare.Denied) != 0 {
+		t.Errorf("expected no per-asset details without a grant, got %+v", bare.Denied)
+	}
+}
+
+// TestPreflight_BulkDownloadMaxAssets verifies the per-request asset limit is
+// checked against the resolved asset count before the ZIP is streamed.
+func TestPreflight_BulkDownloadMaxAssets(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "pf-bulk")
+	h1 := ts.UploadFileExpectSuccess(t, "pf-bulk", "one.bin", []byte("bulk one"), "").Hash
+	h2 := ts.UploadFileExpectSuccess(t, "pf-bulk", "two.bin", []byte("bulk two"), "").Hash
+
+	user := ts.CreateTestUserWithGrants(t, "pfbulk", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionBulkDownload, "constraints_json": `{"max_assets_per_request":1}`},
+	})
+
+	bulkReq := BulkDownloadRequest{Mode: "ids", AssetIDs: []string{h1, h2}}
+
+	var denied preflightDeniedResponse
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, "/api/download/bulk", user.APIKey, bulkReq)
+	decodePreflight(t, resp, err, http.StatusForbidden, &denied)
+
+	if denied.Code != constants.ErrCodeAuthPreflightDenied {
+		t.Errorf("expected code %s, got %s", constants.ErrCodeAuthPreflightDenied, denied.Code)
+	}
+	if denied.Preflight == nil || denied.Preflight.RequestDenied == nil {
+		t.Fatalf("expected request-level denial, got %+v", denied.Preflight)
+	}
+	if denied.Preflight.DeniedCount != 2 {
+		t.Errorf("expected both assets denied, got %d", denied.Preflight.DeniedCount)
+	}
+
+	var result preflightResult
+	resp, err = ts.RequestWithAPIKey(http.MethodPost, "/api/auth/me/preflight", user.APIKey, map[string]interface{}{
+		"action":        constants.AuthActionBulkDownload,
+		"bulk_download": BulkDownloadRequest{Mode: "ids", AssetIDs: []string{h1}},
+	})
+	decodePreflight(t, resp, err, http.StatusOK, &result)
+	if !result.Allowed || result.Total != 1 {
+		t.Errorf("expected single-asset request to be allowed, got %+v", result)
+	}
+}
@@ -0,0 +1,132 @@
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+const previewCubeOBJ = `v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+v 0 0 1
+v 1 0 1
+v 1 1 1
+v 0 1 1
+f 1 2 3 4
+f 5 6 7 8
+f 1 2 6 5
+f 4 3 7 8
+`
+
+// TestPreview_ModelRenderedOnUploadAndCached verifies OBJ models get a PNG
+// wireframe preview, rendered in the background on upload with
+// previews.on_upload and cached per size under .internal/previews.
+func TestPreview_ModelRenderedOnUploadAndCached(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+	ts.App.Config.Previews.OnUpload = true
+
+	upload := ts.UploadFileExpectSuccess(t, "models", "cube.obj", []byte(previewCubeOBJ), "")
+	cached := func(size int) string {
+		return filepath.Join(ts.WorkDir, constants.InternalDir, constants.PreviewsDir, upload.Hash[:2],
+			fmt.Sprintf("%s-%d", upload.Hash, size))
+	}
+
+	// The default-size preview is rendered without being requested
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(cached(constants.PreviewDefaultSize)); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the default preview to be rendered on upload")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	resp, body := downloadBody(t, ts, "/api/assets/"+upload.Hash+"/preview?size=64", ts.APIKey)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get(constants.HeaderContentType); ct != "image/png" {
+		t.Errorf("expected image/png, got %q", ct)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil || format != "png" || cfg.Width != 64 || cfg.Height != 64 {
+		t.Fatalf("expected a 64x64 png, got %dx%d %s: %v", cfg.Width, cfg.Height, format, err)
+	}
+
+	// The second request is served from the cache
+	onDisk, err := os.ReadFile(cached(64))
+	if err != nil || !bytes.Equal(onDisk, body) {
+		t.Fatalf("expected the preview to be cached: %v", err)
+	}
+	if resp, again := downloadBody(t, ts, "/api/assets/"+upload.Hash+"/preview?size=64", ts.APIKey); resp.StatusCode != http.StatusOK || !bytes.Equal(again, body) {
+		t.Errorf("expected the cached preview, got %d", resp.StatusCode)
+	}
+}
+
+// TestPreview_ForcedWatermarkAndConditionalRequests verifies previews carry
+// the watermark a download grant forces, and that conditional requests for
+// plain previews are answered before anything is rendered.
+func TestPreview_ForcedWatermarkAndConditionalRequests(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "marketing")
+	ts.enableWatermarks()
+
+	upload := ts.UploadFileExpectSuccess(t, "marketing", "hero.png", testPNG(t, 200, 200), "")
+	path := "/api/assets/" + upload.Hash + "/preview?size=64"
+
+	resp, plain := downloadBody(t, ts, path, ts.APIKey)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(constants.HeaderXWatermark) != "" {
+		t.Fatalf("expected a plain preview, got %d: %s", resp.StatusCode, plain)
+	}
+
+	reviewer := ts.CreateTestUserWithGrants(t, "agency-reviewer", "ReviewerPass123!", []map[string]interface{}{
+		{
+			"action":           constants.AuthActionDownload,
+			"constraints_json": `{"watermark": "review"}`,
+		},
+	})
+	resp, body := downloadBody(t, ts, path, reviewer.APIKey)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get(constants.HeaderXWatermark); got != "review" {
+		t.Errorf("expected forced profile review, got %q", got)
+	}
+	if resp.Header.Get(constants.HeaderETag) != "" {
+		t.Error("watermarked preview must not carry the preview ETag")
+	}
+	if bytes.Equal(body, plain) {
+		t.Error("expected the reviewer's preview to be watermarked")
+	}
+
+	// A matching validator is answered without rendering the size
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/assets/"+upload.Hash+"/preview?size=32", nil)
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	req.Header.Set("If-None-Match", `"`+upload.Hash+`-preview-32"`)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", resp.StatusCode)
+	}
+	cached := filepath.Join(ts.WorkDir, constants.InternalDir, constants.PreviewsDir, upload.Hash[:2], upload.Hash+"-32")
+	if _, err := os.Stat(cached); !os.IsNotExist(err) {
+		t.Errorf("expected no preview rendered for a conditional hit, got %v", err)
+	}
+}
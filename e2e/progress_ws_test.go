@@ -0,0 +1,362 @@
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// progressEvent mirrors the progress WebSocket event envelope
+type progressEvent struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// wsTestClient is a minimal WebSocket client for e2e tests
+type wsTestClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// dialProgressSocket opens the progress WebSocket authenticated via ?token=
+func dialProgressSocket(t *testing.T, ts *TestServer, token string) *wsTestClient {
+	t.Helper()
+	u, _ := url.Parse(ts.URL)
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+
+	keyBytes := make([]byte, 16)
+	rand.Read(keyBytes)
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	fmt.Fprintf(conn, "GET /api/ws/progress?%s=%s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\nAccept-Encoding: gzip\r\n\r\n",
+		constants.AuthQueryParamToken, url.QueryEscape(token), u.Host, key)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		conn.Close()
+		t.Fatalf("handshake read failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(resp.Body)
+		conn.Close()
+		t.Fatalf("expected 101, got %d: %s", resp.StatusCode, string(body))
+	}
+
+	c := &wsTestClient{conn: conn, br: br}
+	t.Cleanup(func() { conn.Close() })
+	return c
+}
+
+// send writes a masked text frame containing v as JSON
+func (c *wsTestClient) send(t *testing.T, v interface{}) {
+	t.Helper()
+	payload, _ := json.Marshal(v)
+
+	frame := []byte{0x81}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	default:
+		frame = append(frame, 0x80|126, byte(n>>8), byte(n))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatalf("ws write failed: %v", err)
+	}
+}
+
+// next returns the next text event, skipping control frames
+func (c *wsTestClient) next(t *testing.T) progressEvent {
+	t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(c.br, header[:]); err != nil {
+			t.Fatalf("ws read failed: %v", err)
+		}
+		length := int(header[1] & 0x7F)
+		switch length {
+		case 126:
+			var ext [2]byte
+			io.ReadFull(c.br, ext[:])
+			length = int(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			io.ReadFull(c.br, ext[:])
+			length = int(binary.BigEndian.Uint64(ext[:]))
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.br, payload); err != nil {
+			t.Fatalf("ws read payload failed: %v", err)
+		}
+		if header[0]&0x0F != 0x1 {
+			continue
+		}
+		var ev progressEvent
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			t.Fatalf("invalid event JSON: %v", err)
+		}
+		return ev
+	}
+}
+
+// waitFor reads events until one of the given type arrives
+func (c *wsTestClient) waitFor(t *testing.T, eventType string) progressEvent {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		ev := c.next(t)
+		if ev.Type == eventType {
+			return ev
+		}
+	}
+	t.Fatalf("event %q not received", eventType)
+	return progressEvent{}
+}
+
+// uploadWithID uploads a file tagged with an upload_id
+func uploadWithID(t *testing.T, ts *TestServer, topic, filename string, content []byte, uploadID string) *http.Response {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/topics/"+topic+"/assets?upload_id="+uploadID, &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("upload request failed: %v", err)
+	}
+	return resp
+}
+
+// TestProgressSocket_RequiresAuth tests that the handshake is rejected without credentials
+func TestProgressSocket_RequiresAuth(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/ws/progress", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", resp.StatusCode)
+	}
+}
+
+// TestProgressSocket_UploadProgress tests upload progress and completion events
+func TestProgressSocket_UploadProgress(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+
+	ws := dialProgressSocket(t, ts, ts.APIKey)
+	if ev := ws.next(t); ev.Type != "connected" {
+		t.Fatalf("expected connected event, got %s", ev.Type)
+	}
+
+	content := bytes.Repeat([]byte("x"), 3*constants.WSUploadProgressBytes)
+	resp := uploadWithID(t, ts, "models", "big.bin", content, "up-1")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload failed with status %d", resp.StatusCode)
+	}
+
+	var progress struct {
+		UploadID      string `json:"upload_id"`
+		BytesReceived int64  `json:"bytes_received"`
+	}
+	ev := ws.waitFor(t, "upload_progress")
+	json.Unmarshal(ev.Data, &progress)
+	if progress.UploadID != "up-1" || progress.BytesReceived <= 0 {
+		t.Errorf("unexpected progress event: %s", ev.Data)
+	}
+
+	var complete struct {
+		UploadID string `json:"upload_id"`
+		Hash     string `json:"hash"`
+		Status   string `json:"status"`
+	}
+	ev = ws.waitFor(t, "upload_complete")
+	json.Unmarshal(ev.Data, &complete)
+	if complete.UploadID != "up-1" || len(complete.Hash) != constants.HashLength || complete.Status != constants.UploadStatusCreated {
+		t.Errorf("unexpected complete event: %s", ev.Data)
+	}
+
+	// Invalid upload IDs are rejected before the body is read
+	resp = uploadWithID(t, ts, "models", "x.bin", []byte("x"), "bad%20id")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid upload_id, got %d", resp.StatusCode)
+	}
+}
+
+// TestProgressSocket_Commands tests ping and cancel of unknown operations
+func TestProgressSocket_Commands(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	ws := dialProgressSocket(t, ts, ts.APIKey)
+	ws.waitFor(t, "connected")
+
+	ws.send(t, map[string]string{"type": "ping"})
+	ws.waitFor(t, "pong")
+
+	ws.send(t, map[string]string{"type": "cancel", "id": "nope"})
+	ev := ws.waitFor(t, "error")
+	var errData struct {
+		Code string `json:"code"`
+	}
+	json.Unmarshal(ev.Data, &errData)
+	if errData.Code != constants.ErrCodeOperationNotFound {
+		t.Errorf("expected %s, got %s", constants.ErrCodeOperationNotFound, errData.Code)
+	}
+
+	ws.send(t, map[string]string{"type": "bogus"})
+	ev = ws.waitFor(t, "error")
+	json.Unmarshal(ev.Data, &errData)
+	if errData.Code != constants.ErrCodeInvalidWSCommand {
+		t.Errorf("expected %s, got %s", constants.ErrCodeInvalidWSCommand, errData.Code)
+	}
+}
+
+// TestProgressSocket_BulkDownload tests starting a bulk download over the socket
+func TestProgressSocket_BulkDownload(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+
+	a := ts.UploadFileExpectSuccess(t, "models", "a.glb", []byte("asset a"), "")
+	b := ts.UploadFileExpectSuccess(t, "models", "b.glb", []byte("asset b"), "")
+
+	ws := dialProgressSocket(t, ts, ts.APIKey)
+	ws.waitFor(t, "connected")
+
+	ws.send(t, map[string]interface{}{
+		"type": "bulk_download",
+		"request": map[string]interface{}{
+			"mode":      "ids",
+			"asset_ids": []string{a.Hash, b.Hash},
+		},
+	})
+
+	ws.waitFor(t, "download_start")
+	ev := ws.waitFor(t, "complete")
+	var complete struct {
+		DownloadURL string `json:"download_url"`
+		TotalAssets int    `json:"total_assets"`
+	}
+	json.Unmarshal(ev.Data, &complete)
+	if complete.TotalAssets != 2 || !strings.HasPrefix(complete.DownloadURL, "/api/download/bulk/") {
+		t.Fatalf("unexpected complete event: %s", ev.Data)
+	}
+
+	resp, err := ts.GET(complete.DownloadURL)
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	zipBytes, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 fetching ZIP, got %d", resp.StatusCode)
+	}
+	if manifest := ExtractZIPManifest(t, zipBytes); manifest.AssetCount != 2 {
+		t.Errorf("expected 2 assets in ZIP, got %d", manifest.AssetCount)
+	}
+
+	// Invalid requests are reported as error events
+	ws.send(t, map[string]interface{}{
+		"type":    "bulk_download",
+		"request": map[string]interface{}{"mode": "ids"},
+	})
+	ws.waitFor(t, "error")
+}
+
+// TestProgressSocket_CancelUpload tests cancelling an in-flight upload by its upload_id
+func TestProgressSocket_CancelUpload(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+
+	ws := dialProgressSocket(t, ts, ts.APIKey)
+	ws.waitFor(t, "connected")
+
+	// Stream the body through a pipe so the upload stays in flight
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/topics/models/assets?upload_id=slow-1", pr)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+
+	respCh := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			respCh <- nil
+			return
+		}
+		respCh <- resp
+	}()
+
+	part, _ := writer.CreateFormFile("file", "slow.bin")
+	chunk := bytes.Repeat([]byte("y"), constants.WSUploadProgressBytes)
+	part.Write(chunk)
+	ws.waitFor(t, "upload_progress")
+
+	ws.send(t, map[string]string{"type": "cancel", "id": "slow-1"})
+	ev := ws.waitFor(t, "cancelled")
+	var cancelled struct {
+		ID   string `json:"id"`
+		Kind string `json:"kind"`
+	}
+	json.Unmarshal(ev.Data, &cancelled)
+	if cancelled.ID != "slow-1" || cancelled.Kind != "upload" {
+		t.Errorf("unexpected cancelled event: %s", ev.Data)
+	}
+
+	// The next chunk observes the cancellation and the server aborts
+	go func() {
+		part.Write(chunk)
+		writer.Close()
+		pw.Close()
+	}()
+
+	resp := <-respCh
+	if resp == nil {
+		t.Fatal("upload request failed")
+	}
+	defer resp.Body.Close()
+	var errResp ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	if errResp.Code != constants.ErrCodeOperationCancelled {
+		t.Errorf("expected %s, got %d %s", constants.ErrCodeOperationCancelled, resp.StatusCode, errResp.Code)
+	}
+}
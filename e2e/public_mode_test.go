@@ -0,0 +1,194 @@
+package e2e
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+// enablePublicMode turns on anonymous read-only access for the test server.
+func (ts *TestServer) enablePublicMode(perMin, burst int) {
+	ts.App.Config.Public = config.PublicConfig{
+		Enabled:          true,
+		AllowedPresets:   []string{"recent-imports"},
+		MaxDownloadBytes: 16,
+		RateLimitPerMin:  perMin,
+		RateLimitBurst:   burst,
+	}
+}
+
+// TestPublicMode_ReadOnlyWhitelist verifies anonymous callers reach only the
+// whitelisted read endpoints and mutating endpoints still require auth.
+func TestPublicMode_ReadOnlyWhitelist(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "demo")
+
+	small := ts.UploadFileExpectSuccess(t, "demo", "small.txt", []byte("tiny"), "")
+	large := ts.UploadFileExpectSuccess(t, "demo", "large.txt", bytes.Repeat([]byte("x"), 64), "")
+
+	// Disabled by default: anonymous access is rejected
+	resp, err := ts.UnauthenticatedGET("/api/topics")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 before public mode, got %d", resp.StatusCode)
+	}
+
+	ts.enablePublicMode(600, 100)
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"list topics", http.MethodGet, "/api/topics", nil, http.StatusOK},
+		{"list presets", http.MethodGet, "/api/queries", nil, http.StatusOK},
+		{"allowed preset", http.MethodPost, "/api/query/recent-imports", map[string]interface{}{"topics": []string{"demo"}}, http.StatusOK},
+		{"other preset", http.MethodPost, "/api/query/by-hash", map[string]interface{}{"params": map[string]string{"hash": "ab"}}, http.StatusForbidden},
+		{"small download", http.MethodGet, "/api/assets/" + small.Hash + "/download", nil, http.StatusOK},
+		{"large download", http.MethodGet, "/api/assets/" + large.Hash + "/download", nil, http.StatusForbidden},
+		{"metadata read", http.MethodGet, "/api/assets/" + small.Hash + "/metadata", nil, http.StatusUnauthorized},
+		{"create topic", http.MethodPost, "/api/topics", map[string]string{"name": "anon"}, http.StatusUnauthorized},
+		{"set metadata", http.MethodPost, "/api/assets/" + small.Hash + "/metadata", map[string]interface{}{"op": "set", "key": "k", "value": "v"}, http.StatusUnauthorized},
+		{"read config", http.MethodGet, "/api/config", nil, http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var resp *http.Response
+			var err error
+			if tc.method == http.MethodGet {
+				resp, err = ts.UnauthenticatedGET(tc.path)
+			} else {
+				resp, err = ts.UnauthenticatedPOST(tc.path, tc.body)
+			}
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.status {
+				t.Errorf("expected %d, got %d", tc.status, resp.StatusCode)
+			}
+		})
+	}
+
+	// Anonymous preset listing is narrowed to the whitelist
+	var presets struct {
+		Presets []struct {
+			Name string `json:"name"`
+		} `json:"presets"`
+	}
+	resp, err = ts.UnauthenticatedGET("/api/queries")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	err = decodeJSON(resp.Body, &presets)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("failed to decode presets: %v", err)
+	}
+	if len(presets.Presets) != 1 || presets.Presets[0].Name != "recent-imports" {
+		t.Errorf("expected only recent-imports, got %+v", presets.Presets)
+	}
+
+	// Authenticated callers are unaffected by the size cap
+	if got := ts.DownloadAsset(t, large.Hash); len(got) != 64 {
+		t.Errorf("expected 64 bytes for authenticated download, got %d", len(got))
+	}
+}
+
+// TestPublicMode_NoAllowedPresets verifies an empty allowed_presets exposes
+// no preset to anonymous callers rather than all of them.
+func TestPublicMode_NoAllowedPresets(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "demo")
+	ts.enablePublicMode(600, 100)
+	ts.App.Config.Public.AllowedPresets = nil
+
+	resp, err := ts.UnauthenticatedPOST("/api/query/recent-imports", map[string]interface{}{"topics": []string{"demo"}})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for a preset without allowed_presets, got %d", resp.StatusCode)
+	}
+
+	var presets struct {
+		Presets []struct {
+			Name string `json:"name"`
+		} `json:"presets"`
+	}
+	resp, err = ts.UnauthenticatedGET("/api/queries")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	err = decodeJSON(resp.Body, &presets)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("failed to decode presets: %v", err)
+	}
+	if len(presets.Presets) != 0 {
+		t.Errorf("expected no presets, got %+v", presets.Presets)
+	}
+
+	// Authenticated callers still run every preset their grants allow
+	ts.ExecuteQuery(t, "recent-imports", []string{"demo"}, nil)
+}
+
+// TestPublicMode_RateLimit verifies anonymous requests are throttled per IP
+// while authenticated requests are not.
+func TestPublicMode_RateLimit(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.enablePublicMode(1, 3)
+
+	for i := 0; i < 3; i++ {
+		resp, err := ts.UnauthenticatedGET("/api/topics")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, resp.StatusCode)
+		}
+	}
+
+	resp, err := ts.UnauthenticatedGET("/api/topics")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get(constants.HeaderRetryAfter) == "" {
+		t.Error("expected Retry-After header")
+	}
+	var errResp ErrorResponse
+	err = decodeJSON(resp.Body, &errResp)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("failed to decode error: %v", err)
+	}
+	if errResp.Code != constants.ErrCodeAuthRateLimited {
+		t.Errorf("expected code %s, got %s", constants.ErrCodeAuthRateLimited, errResp.Code)
+	}
+
+	resp, err = ts.GET("/api/topics")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("authenticated request should not be limited, got %d", resp.StatusCode)
+	}
+}
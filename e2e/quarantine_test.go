@@ -0,0 +1,205 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/storage"
+)
+
+// quarantineEntry mirrors an entry of /api/quarantine.
+type quarantineEntry struct {
+	Hash          string  `json:"hash"`
+	Topic         string  `json:"topic"`
+	Source        string  `json:"source"`
+	Reason        string  `json:"reason"`
+	QuarantinedBy string  `json:"quarantined_by"`
+	ReleasedBy    *string `json:"released_by"`
+	Justification *string `json:"justification"`
+}
+
+// postAsset POSTs body to /api/assets/:hash/:action and returns the status
+// and body.
+func (ts *TestServer) postAsset(t *testing.T, hash, action string, body interface{}) (int, []byte) {
+	t.Helper()
+	resp, err := ts.POST("/api/assets/"+hash+"/"+action, body)
+	if err != nil {
+		t.Fatalf("%s request failed: %v", action, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+func (ts *TestServer) listQuarantine(t *testing.T, query string) []quarantineEntry {
+	t.Helper()
+	var result struct {
+		Entries []quarantineEntry `json:"entries"`
+	}
+	if err := ts.GetJSON("/api/quarantine"+query, &result); err != nil {
+		t.Fatalf("list quarantine failed: %v", err)
+	}
+	return result.Entries
+}
+
+// TestQuarantine_WithheldUntilReleased verifies a quarantined asset is
+// refused on download, dropped from queries and bulk exports, and served
+// again once released with a justification.
+func TestQuarantine_WithheldUntilReleased(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	flagged := ts.UploadFileExpectSuccess(t, "assets", "flagged.bin", []byte("suspicious content"), "").Hash
+	clean := ts.UploadFileExpectSuccess(t, "assets", "clean.bin", []byte("harmless content"), "").Hash
+
+	if status, body := ts.postAsset(t, flagged, "quarantine", map[string]string{"reason": "reported by user"}); status != http.StatusOK {
+		t.Fatalf("quarantine: expected 200, got %d: %s", status, body)
+	}
+
+	errResp := ts.DownloadAssetExpectError(t, flagged, http.StatusLocked)
+	if errResp.Code != constants.ErrCodeAssetQuarantined {
+		t.Errorf("expected %s, got %s", constants.ErrCodeAssetQuarantined, errResp.Code)
+	}
+	ts.DownloadAsset(t, clean)
+
+	// Queries leave it out unless asked to include it
+	if result := ts.ExecuteQuery(t, "recent-imports", nil, nil); result.RowCount != 1 {
+		t.Errorf("expected 1 row without quarantined assets, got %d", result.RowCount)
+	}
+	resp, err := ts.POST("/api/query/recent-imports", map[string]interface{}{"include_quarantined": true})
+	if err != nil {
+		t.Fatalf("query request failed: %v", err)
+	}
+	var included QueryResponse
+	json.NewDecoder(resp.Body).Decode(&included)
+	resp.Body.Close()
+	if included.RowCount != 2 {
+		t.Errorf("expected 2 rows with include_quarantined, got %d", included.RowCount)
+	}
+
+	bulkErr := ts.BulkDownloadExpectError(t, BulkDownloadRequest{Mode: "ids", AssetIDs: []string{flagged}}, http.StatusBadRequest)
+	if bulkErr.Code != constants.ErrCodeBulkDownloadEmpty {
+		t.Errorf("expected %s for a quarantined-only export, got %s", constants.ErrCodeBulkDownloadEmpty, bulkErr.Code)
+	}
+
+	entries := ts.listQuarantine(t, "")
+	if len(entries) != 1 || entries[0].Hash != flagged || entries[0].Source != constants.QuarantineSourceAdmin ||
+		entries[0].QuarantinedBy != constants.AuthBootstrapUsername {
+		t.Fatalf("unexpected quarantine list: %+v", entries)
+	}
+
+	// Release requires a justification
+	if status, _ := ts.postAsset(t, flagged, "release", map[string]string{}); status != http.StatusBadRequest {
+		t.Errorf("release without justification: expected 400, got %d", status)
+	}
+	if status, body := ts.postAsset(t, flagged, "release", map[string]string{"justification": "reviewed, false positive"}); status != http.StatusOK {
+		t.Fatalf("release: expected 200, got %d: %s", status, body)
+	}
+	if status, _ := ts.postAsset(t, flagged, "release", map[string]string{"justification": "again"}); status != http.StatusConflict {
+		t.Errorf("second release: expected 409, got %d", status)
+	}
+
+	if got := ts.DownloadAsset(t, flagged); string(got) != "suspicious content" {
+		t.Errorf("released asset content mismatch: %q", got)
+	}
+	if len(ts.listQuarantine(t, "")) != 0 {
+		t.Error("released entry still listed as active")
+	}
+	history := ts.listQuarantine(t, "?include_released=true")
+	if len(history) != 1 || history[0].Justification == nil || *history[0].Justification != "reviewed, false positive" {
+		t.Errorf("expected the release justification in history, got %+v", history)
+	}
+
+	var audit AuditQueryResponse
+	if err := ts.GetJSON("/api/audit?action="+constants.AuditActionAssetReleased, &audit); err != nil {
+		t.Fatalf("audit query failed: %v", err)
+	}
+	if len(audit.Entries) != 1 {
+		t.Errorf("expected 1 %s audit entry, got %d", constants.AuditActionAssetReleased, len(audit.Entries))
+	}
+}
+
+// TestQuarantine_ScanQuarantinesInfected verifies flagged uploads are stored
+// quarantined instead of rejected when scan.quarantine_infected is set.
+func TestQuarantine_ScanQuarantinesInfected(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "bin")
+	ts.App.Config.Scan.Command = []string{"sh", "-c", "if grep -q EICAR; then exit 1; fi"}
+	ts.App.Config.Scan.QuarantineInfected = true
+
+	if status, body := ts.setStoragePolicy(t, "exe", map[string]interface{}{"scan": true}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+
+	resp, err := ts.UploadFile("bin", "tool.exe", []byte("X5O EICAR test payload"), "")
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	var upload struct {
+		Hash        string `json:"hash"`
+		Status      string `json:"status"`
+		Quarantined bool   `json:"quarantined"`
+	}
+	json.NewDecoder(resp.Body).Decode(&upload)
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices || upload.Status != constants.UploadStatusCreated || !upload.Quarantined {
+		t.Fatalf("expected a created, quarantined upload, got %d %+v", resp.StatusCode, upload)
+	}
+
+	ts.DownloadAssetExpectError(t, upload.Hash, http.StatusLocked)
+	entries := ts.listQuarantine(t, "?topic=bin")
+	if len(entries) != 1 || entries[0].Source != constants.QuarantineSourceScan {
+		t.Errorf("expected one scan quarantine, got %+v", entries)
+	}
+}
+
+// TestQuarantine_VerifyQuarantinesCorrupt verifies a failed DAT check
+// quarantines the entries whose content no longer matches their recorded
+// hash, and only those.
+func TestQuarantine_VerifyQuarantinesCorrupt(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	corrupt := ts.UploadFileExpectSuccess(t, "assets", "first.bin", GenerateTestFile(1024), "").Hash
+	intact := ts.UploadFileExpectSuccess(t, "assets", "second.bin", GenerateTestFile(2048), "").Hash
+
+	// Garble the first entry's hash field (offset 14), which breaks the
+	// DAT file's hash chain and the entry's own content check
+	datFile, err := os.OpenFile(filepath.Join(ts.WorkDir, "assets", storage.FormatDatFilename(1)), os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open dat file: %v", err)
+	}
+	datFile.WriteAt([]byte("0000000"), 14)
+	datFile.Close()
+
+	resp, err := ts.GET("/api/verify")
+	if err != nil {
+		t.Fatalf("Verify request failed: %v", err)
+	}
+	events := parseSSEEvents(t, resp)
+	resp.Body.Close()
+
+	datComplete := findEvent(events, "dat_complete")
+	if datComplete == nil {
+		t.Fatal("No dat_complete event")
+	}
+	quarantined, _ := datComplete.Data["quarantined"].([]interface{})
+	if len(quarantined) != 1 || quarantined[0] != corrupt {
+		t.Fatalf("expected only %s quarantined, got %v", corrupt, datComplete.Data["quarantined"])
+	}
+
+	entries := ts.listQuarantine(t, "")
+	if len(entries) != 1 || entries[0].Source != constants.QuarantineSourceIntegrity || entries[0].QuarantinedBy != "" {
+		t.Errorf("expected one integrity quarantine, got %+v", entries)
+	}
+	ts.DownloadAssetExpectError(t, corrupt, http.StatusLocked)
+	ts.DownloadAsset(t, intact)
+}
@@ -0,0 +1,199 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// buildQuery posts a structured query to the query builder.
+func (ts *TestServer) buildQuery(t *testing.T, body map[string]interface{}) (int, QueryResponse, ErrorResponse) {
+	t.Helper()
+	resp, err := ts.POST("/api/query/build", body)
+	if err != nil {
+		t.Fatalf("build request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result QueryResponse
+	var errResp ErrorResponse
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusOK {
+		json.Unmarshal(data, &result)
+	} else {
+		json.Unmarshal(data, &errResp)
+	}
+	return resp.StatusCode, result, errResp
+}
+
+// column returns the values of a named column of a query result.
+func (r QueryResponse) column(name string) []interface{} {
+	idx := -1
+	for i, c := range r.Columns {
+		if c == name {
+			idx = i
+		}
+	}
+	values := make([]interface{}, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		if idx >= 0 {
+			values = append(values, row[idx])
+		}
+	}
+	return values
+}
+
+// TestQueryBuilder_Schema verifies the schema lists the entities, their
+// operators and the metadata keys in use.
+func TestQueryBuilder_Schema(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+	hash := ts.UploadFileExpectSuccess(t, "models", "ship.glb", []byte("ship"), "").Hash
+	ts.SetMetadata(t, hash, "author", "ana")
+
+	var schema struct {
+		Entities []struct {
+			Name    string `json:"name"`
+			Columns []struct {
+				Field     string   `json:"field"`
+				Type      string   `json:"type"`
+				Operators []string `json:"operators"`
+			} `json:"columns"`
+			Keys []struct {
+				Key    string `json:"key"`
+				Assets int64  `json:"assets"`
+			} `json:"keys"`
+		} `json:"entities"`
+		Operators []struct {
+			Name string `json:"name"`
+		} `json:"operators"`
+		Limits struct {
+			MaxLimit int `json:"max_limit"`
+		} `json:"limits"`
+	}
+	if err := ts.GetJSON("/api/query/schema", &schema); err != nil {
+		t.Fatalf("schema request failed: %v", err)
+	}
+
+	entities := map[string]bool{}
+	for _, e := range schema.Entities {
+		entities[e.Name] = true
+		if e.Name == "metadata" && (len(e.Keys) != 1 || e.Keys[0].Key != "author" || e.Keys[0].Assets != 1) {
+			t.Errorf("expected the author key on one asset, got %+v", e.Keys)
+		}
+	}
+	for _, name := range []string{"assets", "metadata", "lineage"} {
+		if !entities[name] {
+			t.Errorf("entity %s missing from %+v", name, schema.Entities)
+		}
+	}
+	if len(schema.Operators) == 0 || schema.Limits.MaxLimit != constants.QueryDefaultMaxRows {
+		t.Errorf("unexpected operators or limits: %+v %+v", schema.Operators, schema.Limits)
+	}
+}
+
+// TestQueryBuilder_FilterTree covers nested filters over asset columns,
+// metadata and lineage, sorting across topics and rejected trees.
+func TestQueryBuilder_FilterTree(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+	ts.CreateTopic(t, "textures")
+
+	ship := ts.UploadFileExpectSuccess(t, "models", "ship.glb", GenerateTestFile(300), "").Hash
+	hull := ts.UploadFileExpectSuccess(t, "models", "hull.glb", GenerateTestFile(100), ship).Hash
+	rivet := ts.UploadFileExpectSuccess(t, "models", "rivet.glb", GenerateTestFile(50), hull).Hash
+	paint := ts.UploadFileExpectSuccess(t, "textures", "paint_50%.png", GenerateTestFile(200), "").Hash
+	ts.SetMetadata(t, ship, "polys", 12000)
+	ts.SetMetadata(t, hull, "polys", 800)
+	ts.SetMetadata(t, paint, "author", "ana")
+
+	// Sorted across topics by size
+	status, result, errResp := ts.buildQuery(t, map[string]interface{}{
+		"select": []string{"origin_name", "asset_size"},
+		"sort":   []map[string]interface{}{{"field": "asset_size", "desc": true}},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("build failed: %d %+v", status, errResp)
+	}
+	if want := []string{"asset_id", "origin_name", "asset_size", "_topic"}; len(result.Columns) != len(want) || result.Columns[1] != want[1] || result.Columns[3] != want[3] {
+		t.Errorf("expected columns %v, got %v", want, result.Columns)
+	}
+	names := result.column("origin_name")
+	if len(names) != 4 || names[0] != "ship" || names[1] != "paint_50%" || names[3] != "rivet" {
+		t.Errorf("expected rows by size across topics, got %v", names)
+	}
+
+	// Nested tree over metadata, asset columns and lineage
+	status, result, errResp = ts.buildQuery(t, map[string]interface{}{
+		"topics": []string{"models"},
+		"select": []string{"origin_name", "metadata.polys", "lineage.child_count"},
+		"filter": map[string]interface{}{
+			"op": "or",
+			"children": []map[string]interface{}{
+				{"op": "and", "children": []map[string]interface{}{
+					{"op": "gte", "field": "metadata.polys", "value": 1000},
+					{"op": "eq", "field": "extension", "value": "glb"},
+				}},
+				{"op": "not", "children": []map[string]interface{}{
+					{"op": "exists", "field": "metadata.polys"},
+				}},
+			},
+		},
+		"sort": []map[string]interface{}{{"field": "origin_name"}},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("build failed: %d %+v", status, errResp)
+	}
+	if names := result.column("origin_name"); len(names) != 2 || names[0] != "rivet" || names[1] != "ship" {
+		t.Errorf("expected rivet and ship, got %v", names)
+	}
+	if counts := result.column("lineage.child_count"); len(counts) != 2 || counts[1] != float64(1) {
+		t.Errorf("expected ship to have one child, got %v", counts)
+	}
+
+	// Lineage descendants and LIKE wildcards taken literally
+	for name, tc := range map[string]struct {
+		filter map[string]interface{}
+		want   []interface{}
+	}{
+		"descendants": {map[string]interface{}{"op": "eq", "field": "lineage.descendant_of", "value": ship}, []interface{}{hull, rivet}},
+		"wildcard":    {map[string]interface{}{"op": "contains", "field": "origin_name", "value": "50%"}, []interface{}{paint}},
+		"in":          {map[string]interface{}{"op": "in", "field": "metadata.author", "value": []interface{}{"ana", "bo"}}, []interface{}{paint}},
+		"injection":   {map[string]interface{}{"op": "eq", "field": "origin_name", "value": "x' OR '1'='1"}, []interface{}{}},
+	} {
+		status, result, errResp := ts.buildQuery(t, map[string]interface{}{
+			"select": []string{"asset_id"},
+			"filter": tc.filter,
+			"sort":   []map[string]interface{}{{"field": "asset_size", "desc": true}},
+		})
+		if status != http.StatusOK {
+			t.Errorf("%s: build failed: %d %+v", name, status, errResp)
+			continue
+		}
+		if got := result.column("asset_id"); len(got) != len(tc.want) || (len(got) > 0 && got[0] != tc.want[0]) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, got)
+		}
+	}
+
+	// Invalid trees are refused before anything runs
+	for name, body := range map[string]map[string]interface{}{
+		"unknown field":    {"filter": map[string]interface{}{"op": "eq", "field": "blob_name", "value": "001.dat"}},
+		"wrong operator":   {"filter": map[string]interface{}{"op": "contains", "field": "asset_size", "value": "1"}},
+		"wrong type":       {"filter": map[string]interface{}{"op": "gt", "field": "asset_size", "value": "big"}},
+		"empty and":        {"filter": map[string]interface{}{"op": "and"}},
+		"quoted key":       {"select": []string{`metadata.a"b`}},
+		"filter-only":      {"select": []string{"lineage.descendant_of"}},
+		"limit too large":  {"limit": constants.QueryDefaultMaxRows + 1},
+		"unknown operator": {"filter": map[string]interface{}{"op": "regexp", "field": "origin_name", "value": ".*"}},
+	} {
+		status, _, errResp := ts.buildQuery(t, body)
+		if status != http.StatusBadRequest || errResp.Code != constants.ErrCodeInvalidQueryFilter {
+			t.Errorf("%s: expected 400 %s, got %d %s", name, constants.ErrCodeInvalidQueryFilter, status, errResp.Code)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// presetValidation mirrors the response of POST /api/queries/validate.
+type presetValidation struct {
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+	Topics   []string `json:"topics"`
+	Columns  []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"columns"`
+	Plan []struct {
+		Detail string `json:"detail"`
+	} `json:"plan"`
+	Cost *struct {
+		Opcodes   int `json:"opcodes"`
+		FullScans int `json:"full_scans"`
+	} `json:"cost"`
+}
+
+// validatePreset posts a preset definition to the sandbox.
+func (ts *TestServer) validatePreset(t *testing.T, body map[string]interface{}) (int, presetValidation) {
+	t.Helper()
+	resp, err := ts.POST("/api/queries/validate", body)
+	if err != nil {
+		t.Fatalf("validate request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var report presetValidation
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusOK {
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+	}
+	return resp.StatusCode, report
+}
+
+// TestQueryValidate_Sandbox verifies preset definitions are checked and
+// compiled against a topic without reading rows or registering the preset.
+func TestQueryValidate_Sandbox(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+	ts.UploadFileExpectSuccess(t, "models", "ship.glb", []byte("ship"), "")
+
+	status, report := ts.validatePreset(t, map[string]interface{}{
+		"name":       "sandboxed-assets",
+		"definition": "description: Assets by extension\nsql: SELECT asset_id, asset_size FROM assets WHERE extension = :ext ORDER BY created_at DESC\nparams:\n  - name: ext\n    required: true\n",
+		"topic":      "models",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if !report.Valid || len(report.Errors) != 0 {
+		t.Fatalf("expected valid preset, got %+v", report)
+	}
+	if len(report.Columns) != 3 || report.Columns[0].Name != "asset_id" || report.Columns[2].Name != "_topic" {
+		t.Errorf("unexpected columns: %+v", report.Columns)
+	}
+	if report.Cost == nil || report.Cost.Opcodes == 0 || len(report.Plan) == 0 {
+		t.Errorf("expected plan and cost, got %+v %+v", report.Plan, report.Cost)
+	}
+
+	// The preset is not registered
+	var presets struct {
+		Presets []struct {
+			Name string `json:"name"`
+		} `json:"presets"`
+	}
+	if err := ts.GetJSON("/api/queries", &presets); err != nil {
+		t.Fatalf("list presets failed: %v", err)
+	}
+	for _, p := range presets.Presets {
+		if p.Name == "sandboxed-assets" {
+			t.Error("validated preset should not be registered")
+		}
+	}
+
+	// Problems are reported, not executed
+	status, report = ts.validatePreset(t, map[string]interface{}{
+		"definition": "sql: DELETE FROM assets WHERE asset_id = :hash",
+		"topic":      "models",
+	})
+	if status != http.StatusOK || report.Valid || len(report.Errors) < 2 {
+		t.Errorf("expected read-only and undeclared param errors, got %d %+v", status, report)
+	}
+	status, report = ts.validatePreset(t, map[string]interface{}{
+		"definition": "sql: SELECT nope FROM assets",
+		"topic":      "models",
+	})
+	if status != http.StatusOK || report.Valid || len(report.Errors) != 1 || !strings.Contains(report.Errors[0], "nope") {
+		t.Errorf("expected compile error, got %d %+v", status, report)
+	}
+	if len(ts.ExecuteQuery(t, "recent-imports", []string{"models"}, nil).Rows) != 1 {
+		t.Error("asset should survive a sandboxed DELETE")
+	}
+
+	// Bad requests
+	if status, _ := ts.validatePreset(t, map[string]interface{}{"definition": "sql: SELECT 1", "topic": "missing"}); status != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown topic, got %d", status)
+	}
+	if status, _ := ts.validatePreset(t, map[string]interface{}{"topic": "models"}); status != http.StatusBadRequest {
+		t.Errorf("expected 400 without definition, got %d", status)
+	}
+}
@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+// enableRateLimit turns on request rate limiting with a small query budget.
+func (ts *TestServer) enableRateLimit(perMin, burst int) {
+	ts.App.Config.RateLimit.Enabled = true
+	ts.App.Config.RateLimit.Queries = config.RateLimitRule{PerMin: perMin, Burst: burst}
+}
+
+// runCountQuery executes the "count" preset and returns the response.
+func runCountQuery(t *testing.T, ts *TestServer, apiKey string) *http.Response {
+	t.Helper()
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, "/api/query/count", apiKey, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("query request failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+// TestRateLimit_QueriesPerUser verifies queries are throttled per user with
+// X-RateLimit headers, and that other request classes are unaffected.
+func TestRateLimit_QueriesPerUser(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "rl-topic")
+	ts.enableRateLimit(1, 2)
+
+	for i := 0; i < 2; i++ {
+		resp := runCountQuery(t, ts, ts.APIKey)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("query %d: expected 200, got %d", i+1, resp.StatusCode)
+		}
+		if got := resp.Header.Get(constants.HeaderRateLimitLimit); got != "2" {
+			t.Errorf("expected %s 2, got %q", constants.HeaderRateLimitLimit, got)
+		}
+		if got := resp.Header.Get(constants.HeaderRateLimitRemaining); got != strconv.Itoa(1-i) {
+			t.Errorf("query %d: expected %s %d, got %q", i+1, constants.HeaderRateLimitRemaining, 1-i, got)
+		}
+	}
+
+	resp := runCountQuery(t, ts, ts.APIKey)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get(constants.HeaderRetryAfter) == "" || resp.Header.Get(constants.HeaderRateLimitReset) == "" {
+		t.Error("expected Retry-After and X-RateLimit-Reset headers")
+	}
+
+	// Uploads have their own budget
+	ts.UploadFileExpectSuccess(t, "rl-topic", "a.bin", GenerateTestFile(64), "")
+
+	// Another user has their own bucket
+	other := ts.CreateTestUserWithGrants(t, "rlother", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	if resp := runCountQuery(t, ts, other.APIKey); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected another user's query to succeed, got %d", resp.StatusCode)
+	}
+}
+
+// TestRateLimit_GrantOverride verifies rate_limit_per_min/rate_limit_burst
+// grant constraints override the configured rate for that user.
+func TestRateLimit_GrantOverride(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "rl-topic")
+	ts.enableRateLimit(1, 1)
+
+	script := ts.CreateTestUserWithGrants(t, "rlscript", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery, "constraints_json": `{"rate_limit_per_min":60,"rate_limit_burst":5}`},
+	})
+
+	for i := 0; i < 5; i++ {
+		if resp := runCountQuery(t, ts, script.APIKey); resp.StatusCode != http.StatusOK {
+			t.Fatalf("query %d: expected 200, got %d", i+1, resp.StatusCode)
+		}
+	}
+	resp := runCountQuery(t, ts, script.APIKey)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected 429 after the overridden burst, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get(constants.HeaderRateLimitLimit); got != "5" {
+		t.Errorf("expected %s 5, got %q", constants.HeaderRateLimitLimit, got)
+	}
+}
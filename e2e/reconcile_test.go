@@ -108,6 +108,7 @@ func TestReconcile_PurgesOrphanedTopicAfterFolderRemoved(t *testing.T) {
 	}
 
 	// --- Step 7: Verify audit log ---
+	ts.App.AuditLogger.Flush()
 	var auditCount int
 	err = orchDB2.QueryRow("SELECT COUNT(*) FROM audit_log WHERE action = ?",
 		constants.AuditActionReconcileTopicRemoved).Scan(&auditCount)
@@ -333,6 +334,7 @@ func TestReconcile_AuditLogPreserved(t *testing.T) {
 	ts.UploadFileExpectSuccess(t, "audit-test", "file.txt", []byte("data"), "")
 
 	// Check audit entries before reconciliation (adding_topic + adding_file)
+	ts.App.AuditLogger.Flush()
 	orchDB := ts.GetOrchestratorDB(t)
 	var auditBefore int
 	err := orchDB.QueryRow("SELECT COUNT(*) FROM audit_log").Scan(&auditBefore)
@@ -349,6 +351,7 @@ func TestReconcile_AuditLogPreserved(t *testing.T) {
 	}
 
 	// Audit entries should have INCREASED (original entries preserved + reconcile entry added)
+	ts.App.AuditLogger.Flush()
 	orchDB2 := ts.GetOrchestratorDB(t)
 	var auditAfter int
 	err = orchDB2.QueryRow("SELECT COUNT(*) FROM audit_log").Scan(&auditAfter)
@@ -0,0 +1,144 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// AssetReferencesResponse mirrors GET /api/assets/:hash/references
+type AssetReferencesResponse struct {
+	Hash       string         `json:"hash"`
+	Topic      string         `json:"topic"`
+	Count      int            `json:"reference_count"`
+	ByKind     map[string]int `json:"by_kind"`
+	Topics     []string       `json:"topics"`
+	Deletable  bool           `json:"deletable"`
+	References []struct {
+		Hash   string `json:"hash"`
+		Kind   string `json:"kind"`
+		Topic  string `json:"topic"`
+		Holder string `json:"holder"`
+	} `json:"references"`
+}
+
+// GetReferences fetches the references of an asset
+func (ts *TestServer) GetReferences(t *testing.T, hash string) AssetReferencesResponse {
+	t.Helper()
+	var refs AssetReferencesResponse
+	if err := ts.GetJSON("/api/assets/"+hash+"/references", &refs); err != nil {
+		t.Fatalf("GET references failed: %v", err)
+	}
+	return refs
+}
+
+// TestReferences_CollectionsAndLineage verifies collection memberships and
+// derived assets are registered and released as references.
+func TestReferences_CollectionsAndLineage(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "sources")
+	ts.CreateTopic(t, "derived")
+
+	parent := ts.UploadFileExpectSuccess(t, "sources", "base.txt", []byte("base"), "")
+	child := ts.UploadFileExpectSuccess(t, "derived", "child.txt", []byte("child"), parent.Hash)
+
+	createCollection(t, ts, "sources", "picks", "")
+	addToCollection(t, ts, "sources", "picks", []string{parent.Hash})
+
+	refs := ts.GetReferences(t, parent.Hash)
+	if refs.Topic != "sources" || refs.Count != 2 || refs.Deletable {
+		t.Fatalf("unexpected references: %+v", refs)
+	}
+	if refs.ByKind[constants.ReferenceKindCollection] != 1 || refs.ByKind[constants.ReferenceKindLineage] != 1 {
+		t.Errorf("unexpected by_kind: %v", refs.ByKind)
+	}
+	if len(refs.Topics) != 2 {
+		t.Errorf("expected holder topics [derived sources], got %v", refs.Topics)
+	}
+
+	if refs := ts.GetReferences(t, child.Hash); refs.Count != 0 || !refs.Deletable {
+		t.Errorf("child should be unreferenced: %+v", refs)
+	}
+
+	// Removing the membership releases the collection reference
+	body, _ := json.Marshal(map[string]interface{}{"asset_ids": []string{parent.Hash}})
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/api/topics/sources/collections/picks/assets", bytes.NewReader(body))
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("remove from collection failed: %v", err)
+	}
+	resp.Body.Close()
+
+	refs = ts.GetReferences(t, parent.Hash)
+	if refs.Count != 1 || refs.References[0].Kind != constants.ReferenceKindLineage || refs.References[0].Holder != child.Hash {
+		t.Errorf("expected only the lineage reference, got %+v", refs)
+	}
+
+	// Deleting a collection releases all of its references
+	addToCollection(t, ts, "sources", "picks", []string{parent.Hash})
+	resp, err = ts.DELETE("/api/topics/sources/collections/picks")
+	if err != nil {
+		t.Fatalf("delete collection failed: %v", err)
+	}
+	resp.Body.Close()
+	if refs := ts.GetReferences(t, parent.Hash); refs.Count != 1 {
+		t.Errorf("expected 1 reference after collection delete, got %+v", refs)
+	}
+}
+
+// TestReferences_RebuiltOnRestart verifies the registry is rebuilt from the
+// topic databases when topics are indexed.
+func TestReferences_RebuiltOnRestart(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "refs")
+
+	parent := ts.UploadFileExpectSuccess(t, "refs", "a.txt", []byte("a"), "")
+	ts.UploadFileExpectSuccess(t, "refs", "b.txt", []byte("b"), parent.Hash)
+	createCollection(t, ts, "refs", "keep", "")
+	addToCollection(t, ts, "refs", "keep", []string{parent.Hash})
+
+	// Lose the registry, then re-index the topic as startup does
+	if _, err := ts.GetOrchestratorDB(t).Exec("DELETE FROM asset_references"); err != nil {
+		t.Fatalf("failed to clear registry: %v", err)
+	}
+	ts.Restart(t)
+	resp, err := ts.POST("/api/config", map[string]string{"working_directory": ts.WorkDir})
+	if err != nil {
+		t.Fatalf("config request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if refs := ts.GetReferences(t, parent.Hash); refs.Count != 2 {
+		t.Errorf("expected 2 rebuilt references, got %+v", refs)
+	}
+}
+
+// TestReferences_Errors verifies validation of the references endpoint.
+func TestReferences_Errors(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	resp, err := ts.GET("/api/assets/" + string(bytes.Repeat([]byte("a"), constants.HashLength)) + "/references")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown asset, got %d", resp.StatusCode)
+	}
+
+	resp, err = ts.UnauthenticatedGET("/api/assets/" + string(bytes.Repeat([]byte("a"), constants.HashLength)) + "/references")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without auth, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,295 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/zeebo/blake3"
+
+	"silobang/internal/constants"
+)
+
+type uploadSession struct {
+	ID       string `json:"id"`
+	Topic    string `json:"topic"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	Offset   int64  `json:"offset"`
+}
+
+// openUploadSession opens a resumable upload session and returns it.
+func (ts *TestServer) openUploadSession(t *testing.T, body map[string]interface{}) uploadSession {
+	t.Helper()
+	resp, err := ts.POST("/api/uploads", body)
+	if err != nil {
+		t.Fatalf("open session failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		data, _ := io.ReadAll(resp.Body)
+		t.Fatalf("expected 201, got %d: %s", resp.StatusCode, data)
+	}
+	var result struct {
+		Session uploadSession `json:"session"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if got := resp.Header.Get(constants.HeaderLocation); got != "/api/uploads/"+result.Session.ID {
+		t.Errorf("unexpected Location header %q", got)
+	}
+	return result.Session
+}
+
+// appendChunk sends a PATCH with the body at offset and returns the status,
+// the Upload-Offset response header and the body.
+func (ts *TestServer) appendChunk(t *testing.T, id string, offset int64, body io.Reader) (int, int64, []byte, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPatch, ts.URL+"/api/uploads/"+id, body)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.Header.Set(constants.HeaderContentType, constants.MimeTypeOffsetOctetStream)
+	req.Header.Set(constants.HeaderUploadOffset, strconv.FormatInt(offset, 10))
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	received, _ := strconv.ParseInt(resp.Header.Get(constants.HeaderUploadOffset), 10, 64)
+	return resp.StatusCode, received, data, nil
+}
+
+// getUploadSession returns the status and the session.
+func (ts *TestServer) getUploadSession(t *testing.T, id string) (int, uploadSession) {
+	t.Helper()
+	resp, err := ts.GET("/api/uploads/" + id)
+	if err != nil {
+		t.Fatalf("get session failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var session uploadSession
+	json.NewDecoder(resp.Body).Decode(&session)
+	return resp.StatusCode, session
+}
+
+// finalizeUpload finalizes a session and returns the status and body.
+func (ts *TestServer) finalizeUpload(t *testing.T, id string) (int, []byte) {
+	t.Helper()
+	resp, err := ts.POST("/api/uploads/"+id+"/finalize", nil)
+	if err != nil {
+		t.Fatalf("finalize failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+func blake3Hex(content []byte) string {
+	sum := blake3.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// failingReader returns its data, then an error, as a dropped connection
+// would.
+type failingReader struct {
+	data []byte
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errors.New("connection dropped")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// TestResumableUpload_ChunkedWithInterruptionAndRestart uploads a file in
+// chunks, survives a dropped chunk and a server restart, and finalizes it
+// into the same asset a single-shot upload would store.
+func TestResumableUpload_ChunkedWithInterruptionAndRestart(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	content := GenerateTestFile(1024 * 1024)
+	session := ts.openUploadSession(t, map[string]interface{}{
+		"topic":    "test-topic",
+		"filename": "video.bin",
+		"size":     len(content),
+		"hash":     blake3Hex(content),
+	})
+	if session.Offset != 0 || session.Size != int64(len(content)) {
+		t.Fatalf("unexpected new session %+v", session)
+	}
+
+	status, offset, _, err := ts.appendChunk(t, session.ID, 0, bytes.NewReader(content[:300*1024]))
+	if err != nil || status != http.StatusOK || offset != 300*1024 {
+		t.Fatalf("first chunk: status %d, offset %d, err %v", status, offset, err)
+	}
+
+	// A chunk at the wrong offset is refused with the offset to resume from
+	status, offset, data, _ := ts.appendChunk(t, session.ID, 0, bytes.NewReader(content[:10]))
+	if status != http.StatusConflict || offset != 300*1024 {
+		t.Fatalf("expected 409 at offset %d, got %d at %d: %s", 300*1024, status, offset, data)
+	}
+	var apiErr ErrorResponse
+	json.Unmarshal(data, &apiErr)
+	if apiErr.Code != constants.ErrCodeUploadOffsetMismatch {
+		t.Errorf("expected %s, got %s", constants.ErrCodeUploadOffsetMismatch, apiErr.Code)
+	}
+
+	// A dropped chunk keeps the bytes that arrived
+	ts.appendChunk(t, session.ID, 300*1024, &failingReader{data: content[300*1024 : 600*1024]})
+	var received int64
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, current := ts.getUploadSession(t, session.ID)
+		received = current.Offset
+		if received > 300*1024 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if received <= 300*1024 || received > 600*1024 {
+		t.Fatalf("expected the interrupted chunk to advance the offset, got %d", received)
+	}
+
+	ts.Restart(t)
+
+	status, current := ts.getUploadSession(t, session.ID)
+	if status != http.StatusOK || current.Offset != received {
+		t.Fatalf("expected the session at %d after restart, got %d: %+v", received, status, current)
+	}
+
+	// The handler of the dropped chunk may still hold the session briefly
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		status, offset, data, err = ts.appendChunk(t, session.ID, received, bytes.NewReader(content[received:]))
+		if status != http.StatusConflict || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil || status != http.StatusOK || offset != int64(len(content)) {
+		t.Fatalf("last chunk: status %d, offset %d, err %v: %s", status, offset, err, data)
+	}
+
+	status, data = ts.finalizeUpload(t, session.ID)
+	if status != http.StatusOK {
+		t.Fatalf("finalize: expected 200, got %d: %s", status, data)
+	}
+	var upload UploadResponse
+	json.Unmarshal(data, &upload)
+	if upload.Status != constants.UploadStatusCreated || upload.Hash != blake3Hex(content) {
+		t.Fatalf("unexpected upload result %+v", upload)
+	}
+	if got := ts.DownloadAsset(t, upload.Hash); !bytes.Equal(got, content) {
+		t.Error("downloaded asset differs from the uploaded content")
+	}
+
+	if status, _ := ts.getUploadSession(t, session.ID); status != http.StatusNotFound {
+		t.Errorf("expected the finalized session to be gone, got %d", status)
+	}
+	entries, _ := os.ReadDir(filepath.Join(ts.WorkDir, constants.InternalDir, constants.UploadSessionsDir))
+	if len(entries) != 0 {
+		t.Errorf("expected no staged files after finalize, found %d", len(entries))
+	}
+
+	// Finalizing identical content deduplicates like a normal upload
+	again := ts.openUploadSession(t, map[string]interface{}{
+		"topic":    "test-topic",
+		"filename": "copy.bin",
+		"size":     len(content),
+	})
+	ts.appendChunk(t, again.ID, 0, bytes.NewReader(content))
+	status, data = ts.finalizeUpload(t, again.ID)
+	json.Unmarshal(data, &upload)
+	if status != http.StatusOK || upload.Status != constants.UploadStatusDeduplicated {
+		t.Errorf("expected a deduplicated upload, got %d: %s", status, data)
+	}
+}
+
+// TestResumableUpload_Errors covers refused chunks, incomplete and corrupted
+// sessions, and aborts.
+func TestResumableUpload_Errors(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "test-topic")
+
+	resp, _ := ts.POST("/api/uploads", map[string]interface{}{
+		"topic": "missing", "filename": "a.bin", "size": 10,
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown topic: expected 404, got %d", resp.StatusCode)
+	}
+
+	content := []byte("0123456789")
+	session := ts.openUploadSession(t, map[string]interface{}{
+		"topic":    "test-topic",
+		"filename": "a.bin",
+		"size":     len(content),
+		"hash":     blake3Hex([]byte("something else")),
+	})
+
+	// Wrong content type
+	req, _ := http.NewRequest(http.MethodPatch, ts.URL+"/api/uploads/"+session.ID, bytes.NewReader(content))
+	req.Header.Set(constants.HeaderContentType, "application/octet-stream")
+	req.Header.Set(constants.HeaderUploadOffset, "0")
+	req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("patch failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415, got %d", resp.StatusCode)
+	}
+
+	// Chunks past the declared size are refused whole
+	if status, offset, _, _ := ts.appendChunk(t, session.ID, 0, bytes.NewReader(append(content, 'x'))); status != http.StatusBadRequest || offset != 0 {
+		t.Errorf("overflow: expected 400 at offset 0, got %d at %d", status, offset)
+	}
+
+	ts.appendChunk(t, session.ID, 0, bytes.NewReader(content[:4]))
+	status, data := ts.finalizeUpload(t, session.ID)
+	var apiErr ErrorResponse
+	json.Unmarshal(data, &apiErr)
+	if status != http.StatusConflict || apiErr.Code != constants.ErrCodeUploadIncomplete {
+		t.Errorf("incomplete: expected 409 %s, got %d: %s", constants.ErrCodeUploadIncomplete, status, data)
+	}
+
+	ts.appendChunk(t, session.ID, 4, bytes.NewReader(content[4:]))
+	status, data = ts.finalizeUpload(t, session.ID)
+	json.Unmarshal(data, &apiErr)
+	if status != http.StatusUnprocessableEntity || apiErr.Code != constants.ErrCodeUploadHashMismatch {
+		t.Errorf("hash mismatch: expected 422 %s, got %d: %s", constants.ErrCodeUploadHashMismatch, status, data)
+	}
+	if status, _ := ts.getUploadSession(t, session.ID); status != http.StatusNotFound {
+		t.Errorf("expected the corrupted session to be discarded, got %d", status)
+	}
+
+	aborted := ts.openUploadSession(t, map[string]interface{}{
+		"topic": "test-topic", "filename": "b.bin", "size": 5,
+	})
+	resp, _ = ts.DELETE("/api/uploads/" + aborted.ID)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("abort: expected 200, got %d", resp.StatusCode)
+	}
+	if status, _, _, _ := ts.appendChunk(t, aborted.ID, 0, bytes.NewReader([]byte("hello"))); status != http.StatusNotFound {
+		t.Errorf("expected 404 after abort, got %d", status)
+	}
+}
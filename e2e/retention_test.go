@@ -0,0 +1,68 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+// retentionResult mirrors the response of POST /api/topics/:name/retention.
+type retentionResult struct {
+	Action string   `json:"action"`
+	DryRun bool     `json:"dry_run"`
+	Hashes []string `json:"hashes"`
+	Bytes  int64    `json:"bytes"`
+}
+
+// TestRetention_DryRunThenTrash verifies a dry run only reports the assets
+// over a topic's limit and a real pass moves them to the trash, both audited.
+func TestRetention_DryRunThenTrash(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "logs")
+	ts.CreateTopic(t, "kept")
+
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		ts.UploadFileExpectSuccess(t, "logs", name, []byte("log "+name), "")
+	}
+	ts.App.Config.TopicRetention = map[string]config.RetentionConfig{"logs": {MaxAssets: 1}}
+
+	resp, err := ts.POST("/api/topics/kept/retention", nil)
+	if err != nil {
+		t.Fatalf("retention request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for a topic without a policy, got %d", resp.StatusCode)
+	}
+
+	var dryRun retentionResult
+	if err := ts.PostJSON("/api/topics/logs/retention", map[string]bool{"dry_run": true}, &dryRun); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if !dryRun.DryRun || dryRun.Action != constants.RetentionActionTrash || len(dryRun.Hashes) != 2 {
+		t.Fatalf("unexpected dry run result: %+v", dryRun)
+	}
+	if entries := ts.listTrash(t); len(entries) != 0 {
+		t.Errorf("dry run trashed %d asset(s)", len(entries))
+	}
+
+	var applied retentionResult
+	if err := ts.PostJSON("/api/topics/logs/retention", nil, &applied); err != nil {
+		t.Fatalf("retention pass failed: %v", err)
+	}
+	if applied.DryRun || len(applied.Hashes) != 2 || applied.Bytes != dryRun.Bytes {
+		t.Fatalf("unexpected retention result: %+v", applied)
+	}
+	for _, hash := range applied.Hashes {
+		ts.DownloadAssetExpectError(t, hash, http.StatusGone)
+	}
+	if entries := ts.listTrash(t); len(entries) != 2 {
+		t.Errorf("expected 2 trashed assets, got %d", len(entries))
+	}
+	if n := ts.countAudit(t, constants.AuditActionRetentionApplied); n != 2 {
+		t.Errorf("expected 2 retention_applied entries, got %d", n)
+	}
+}
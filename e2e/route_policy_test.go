@@ -0,0 +1,143 @@
+package e2e
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+type routeExplanation struct {
+	Pattern      string   `json:"pattern"`
+	Methods      []string `json:"methods"`
+	Auth         string   `json:"auth"`
+	Action       string   `json:"action"`
+	TopicName    string   `json:"topic"`
+	AuditActions []string `json:"audit_actions"`
+	Allowed      *bool    `json:"allowed"`
+	DeniedCode   string   `json:"denied_code"`
+}
+
+// explainRoute asks the explain endpoint about method and path as user.
+func explainRoute(t *testing.T, ts *TestServer, user TestUserInfo, method, path string, expectedStatus int) routeExplanation {
+	t.Helper()
+	q := url.Values{constants.RouteExplainMethod: {method}, constants.RouteExplainPath: {path}}
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/auth/me/explain?"+q.Encode(), user.APIKey, nil)
+	var ex routeExplanation
+	decodePreflight(t, resp, err, expectedStatus, &ex)
+	return ex
+}
+
+// TestRoutePolicy_ExplainMatchesEnforcement verifies the explain endpoint
+// reports the outcome the route's policy enforces on the same request.
+func TestRoutePolicy_ExplainMatchesEnforcement(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "rp-allowed")
+	ts.CreateTopic(t, "rp-other")
+
+	user := ts.CreateTestUserWithGrants(t, "rpuser", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_topics":["rp-allowed"]}`},
+		{"action": constants.AuthActionVerify},
+	})
+
+	allowed := explainRoute(t, ts, user, http.MethodPost, "/api/sync/diff?topic=rp-allowed", http.StatusOK)
+	if allowed.Pattern != "/api/sync/diff" || allowed.Action != constants.AuthActionQuery || allowed.TopicName != "rp-allowed" {
+		t.Errorf("unexpected explanation: %+v", allowed)
+	}
+	if allowed.Allowed == nil || !*allowed.Allowed {
+		t.Errorf("expected the allowed topic to be admitted, got %+v", allowed)
+	}
+
+	denied := explainRoute(t, ts, user, http.MethodPost, "/api/sync/diff?topic=rp-other", http.StatusOK)
+	if denied.Allowed == nil || *denied.Allowed || denied.DeniedCode != constants.ErrCodeAuthConstraintViolation {
+		t.Errorf("expected the other topic to be denied by its constraint, got %+v", denied)
+	}
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, "/api/sync/diff?topic=rp-other", user.APIKey, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for the denied topic, got %d", resp.StatusCode)
+	}
+
+	// Subtree patterns, methods and audit actions come from the route table
+	hold := explainRoute(t, ts, user, http.MethodDelete, "/api/audit/holds/7", http.StatusOK)
+	if hold.Pattern != "/api/audit/holds/" || hold.Action != constants.AuthActionManageConfig ||
+		!slices.Equal(hold.AuditActions, []string{constants.AuditActionAuditHoldReleased}) {
+		t.Errorf("unexpected hold release explanation: %+v", hold)
+	}
+	if hold.Allowed == nil || *hold.Allowed {
+		t.Errorf("expected manage_config to be denied, got %+v", hold)
+	}
+
+	wrongMethod := explainRoute(t, ts, user, http.MethodPost, "/api/monitoring", http.StatusOK)
+	if wrongMethod.Allowed == nil || *wrongMethod.Allowed || !slices.Equal(wrongMethod.Methods, []string{http.MethodGet}) {
+		t.Errorf("expected POST to be refused, got %+v", wrongMethod)
+	}
+	resp, err = ts.RequestWithAPIKey(http.MethodPost, "/api/monitoring", user.APIKey, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != http.MethodGet {
+		t.Errorf("expected 405 with Allow: GET, got %d %q", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+
+	// Routes that authorize in their handler report no outcome
+	topics := explainRoute(t, ts, user, http.MethodGet, "/api/topics/rp-allowed/assets", http.StatusOK)
+	if topics.Auth != constants.RouteAuthHandler || topics.Allowed != nil {
+		t.Errorf("expected a handler-authorized route, got %+v", topics)
+	}
+
+	explainRoute(t, ts, user, http.MethodGet, "/unknown", http.StatusNotFound)
+}
+
+// TestRoutePolicy_Unauthenticated verifies declared routes reject requests
+// without credentials before running their handler.
+func TestRoutePolicy_Unauthenticated(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	for _, path := range []string{"/api/monitoring", "/api/audit/actions", "/api/auth/me/explain?path=/api/monitoring"} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", path, resp.StatusCode)
+		}
+	}
+}
+
+// TestRoutePolicy_CapabilitiesListEndpoints verifies the capability map
+// lists the declared routes whose policy admits the user.
+func TestRoutePolicy_CapabilitiesListEndpoints(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	user := ts.CreateTestUserWithGrants(t, "rpcaps", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionVerify},
+	})
+
+	var caps struct {
+		Endpoints []string `json:"endpoints"`
+	}
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, "/api/auth/me/capabilities", user.APIKey, nil)
+	decodePreflight(t, resp, err, http.StatusOK, &caps)
+
+	for _, want := range []string{"GET /api/quarantine", "GET /api/storage-policies", "GET /api/auth/me/explain"} {
+		if !slices.Contains(caps.Endpoints, want) {
+			t.Errorf("expected %q in %v", want, caps.Endpoints)
+		}
+	}
+	for _, unwanted := range []string{"GET /api/monitoring", "POST /api/sync/diff", "POST /api/audit/purge"} {
+		if slices.Contains(caps.Endpoints, unwanted) {
+			t.Errorf("expected %q to be absent from %v", unwanted, caps.Endpoints)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/services"
+)
+
+// runRules flushes the audit log and runs one scheduler tick at now.
+func (ts *TestServer) runRules(now time.Time) {
+	ts.App.AuditLogger.Flush()
+	ts.App.Services.Rules.RunDue(now)
+}
+
+// TestRules_ScheduleTagWithPreviewAndHistory verifies a schedule rule tags
+// the assets its preset and metadata condition select once due, that dry
+// runs and previews change nothing, and that runs are recorded and audited.
+func TestRules_ScheduleTagWithPreviewAndHistory(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "renders")
+
+	large := ts.UploadFileExpectSuccess(t, "renders", "large.bin", []byte("a render well over the size threshold"), "")
+	ts.UploadFileExpectSuccess(t, "renders", "small.bin", []byte("tiny"), "")
+	draft := ts.UploadFileExpectSuccess(t, "renders", "draft.bin", []byte("another render over the size threshold"), "")
+	ts.SetMetadata(t, large.Hash, "status", "final")
+	ts.SetMetadata(t, draft.Hash, "status", "draft")
+
+	rule := map[string]interface{}{
+		"name":      "flag-large-renders",
+		"trigger":   map[string]interface{}{"type": constants.RuleTriggerSchedule, "interval_secs": 3600},
+		"condition": map[string]interface{}{"preset": "large-files", "params": map[string]interface{}{"min_size": 10}, "topics": []string{"renders"}, "metadata": map[string]string{"status": "final"}},
+		"action":    map[string]interface{}{"type": constants.RuleActionTag, "key": "review", "value": "size"},
+	}
+	invalid := []map[string]interface{}{
+		{"name": "no-preset", "trigger": rule["trigger"], "condition": map[string]interface{}{"topics": []string{"renders"}}, "action": rule["action"]},
+		{"name": "too-often", "trigger": map[string]interface{}{"type": constants.RuleTriggerSchedule, "interval_secs": 5}, "condition": rule["condition"], "action": rule["action"]},
+		{"name": "no-topics", "trigger": rule["trigger"], "condition": map[string]interface{}{"preset": "large-files"}, "action": rule["action"]},
+		{"name": "move", "trigger": rule["trigger"], "condition": rule["condition"], "action": map[string]interface{}{"type": "move_tier"}},
+	}
+	for _, body := range invalid {
+		ts.notificationRequest(t, http.MethodPost, "/api/rules", ts.APIKey, body, http.StatusBadRequest, nil)
+	}
+
+	var preview struct {
+		Run database.RuleRun `json:"run"`
+	}
+	ts.notificationRequest(t, http.MethodPost, "/api/rules/preview", ts.APIKey, rule, http.StatusOK, &preview)
+	if !preview.Run.DryRun || preview.Run.Matched != 1 || preview.Run.Applied != 1 || len(preview.Run.Assets) != 1 || preview.Run.Assets[0] != large.Hash {
+		t.Fatalf("expected the preview to match the large final render, got %+v", preview.Run)
+	}
+
+	var created struct {
+		Rule database.Rule `json:"rule"`
+	}
+	ts.notificationRequest(t, http.MethodPost, "/api/rules", ts.APIKey, rule, http.StatusOK, &created)
+	if created.Rule.ID == 0 || !created.Rule.Enabled {
+		t.Fatalf("unexpected created rule: %+v", created.Rule)
+	}
+	ts.notificationRequest(t, http.MethodPost, "/api/rules", ts.APIKey, rule, http.StatusBadRequest, nil)
+	rulePath := fmt.Sprintf("/api/rules/%d", created.Rule.ID)
+
+	var dry struct {
+		Run database.RuleRun `json:"run"`
+	}
+	ts.notificationRequest(t, http.MethodPost, rulePath+"/run", ts.APIKey, map[string]interface{}{"dry_run": true}, http.StatusOK, &dry)
+	if !dry.Run.DryRun || dry.Run.Applied != 1 || dry.Run.Trigger != constants.RuleRunManual {
+		t.Errorf("unexpected dry run: %+v", dry.Run)
+	}
+	review := func() interface{} {
+		meta, err := ts.App.Services.Metadata.Get(large.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return meta.ComputedMetadata["review"]
+	}
+	if got := review(); got != nil {
+		t.Fatalf("expected the dry run not to tag, got review=%v", got)
+	}
+
+	// Not due until an interval after creation
+	now := time.Unix(created.Rule.CreatedAt, 0)
+	ts.runRules(now.Add(time.Minute))
+	if got := review(); got != nil {
+		t.Fatalf("expected the rule not to run before its interval, got review=%v", got)
+	}
+	ts.runRules(now.Add(time.Hour))
+	if got := review(); got != "size" {
+		t.Fatalf("expected the scheduled run to tag the asset, got review=%v", got)
+	}
+
+	var again struct {
+		Run database.RuleRun `json:"run"`
+	}
+	ts.notificationRequest(t, http.MethodPost, rulePath+"/run", ts.APIKey, nil, http.StatusOK, &again)
+	if again.Run.Matched != 1 || again.Run.Applied != 0 || again.Run.Skipped != 1 {
+		t.Errorf("expected the tagged asset to be skipped, got %+v", again.Run)
+	}
+
+	var status services.RuleStatus
+	ts.notificationRequest(t, http.MethodGet, rulePath, ts.APIKey, nil, http.StatusOK, &status)
+	if len(status.RecentRuns) != 3 || status.RecentRuns[1].Trigger != constants.RuleRunSchedule || status.RecentRuns[1].Applied != 1 {
+		t.Fatalf("expected the dry, scheduled and manual runs, got %+v", status.RecentRuns)
+	}
+	if status.NextRunAt != status.LastRunAt+3600 {
+		t.Errorf("expected next_run_at an interval after last_run_at, got %+v", status.Rule)
+	}
+
+	// A disabled rule is not run by the scheduler
+	ts.notificationRequest(t, http.MethodPut, rulePath, ts.APIKey, map[string]interface{}{"enabled": false}, http.StatusOK, nil)
+	ts.runRules(now.Add(48 * time.Hour))
+	var disabled services.RuleStatus
+	ts.notificationRequest(t, http.MethodGet, rulePath, ts.APIKey, nil, http.StatusOK, &disabled)
+	if len(disabled.RecentRuns) != 3 || disabled.NextRunAt != 0 {
+		t.Errorf("expected no run while disabled, got %+v", disabled)
+	}
+
+	ts.App.AuditLogger.Flush()
+	counts := map[string]int{
+		constants.AuditActionRuleCreated:  1,
+		constants.AuditActionRuleUpdated:  1,
+		constants.AuditActionRuleExecuted: 1,
+	}
+	for action, want := range counts {
+		var count int
+		ts.App.OrchestratorDB.QueryRow("SELECT COUNT(*) FROM audit_log WHERE action = ?", action).Scan(&count)
+		if count != want {
+			t.Errorf("expected %d %s audit entries, got %d", want, action, count)
+		}
+	}
+	var systemTags int
+	ts.App.OrchestratorDB.QueryRow("SELECT COUNT(*) FROM audit_log WHERE action = ? AND actor_type = ?",
+		constants.AuditActionMetadataSet, constants.AuditActorSystem).Scan(&systemTags)
+	if systemTags != 1 {
+		t.Errorf("expected the tag to be audited as the system actor, got %d entries", systemTags)
+	}
+
+	ts.notificationRequest(t, http.MethodDelete, rulePath, ts.APIKey, nil, http.StatusOK, nil)
+	ts.notificationRequest(t, http.MethodGet, rulePath, ts.APIKey, nil, http.StatusNotFound, nil)
+}
+
+// TestRules_EventRulesQuarantineAndNotify verifies event rules act on the
+// assets of new audit entries only, and that notify rules tell each user
+// about the matched assets they can read.
+func TestRules_EventRulesQuarantineAndNotify(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "inbox")
+	ts.CreateTopic(t, "private")
+	before := ts.UploadFileExpectSuccess(t, "inbox", "before.bin", []byte("uploaded before the rule"), "")
+
+	reviewer := ts.CreateTestUserWithGrants(t, "reviewer", "ReviewerPass123!", []map[string]interface{}{
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_topics":["inbox"]}`},
+	})
+	ts.App.AuditLogger.Flush()
+
+	ts.notificationRequest(t, http.MethodPost, "/api/rules", ts.APIKey, map[string]interface{}{
+		"name":      "quarantine-inbox",
+		"trigger":   map[string]interface{}{"type": constants.RuleTriggerEvent, "events": []string{constants.AuditActionAddingFile}},
+		"condition": map[string]interface{}{"topics": []string{"inbox"}},
+		"action":    map[string]interface{}{"type": constants.RuleActionQuarantine, "reason": "awaiting review"},
+	}, http.StatusOK, nil)
+	ts.notificationRequest(t, http.MethodPost, "/api/rules", ts.APIKey, map[string]interface{}{
+		"name":    "tell-reviewer",
+		"trigger": map[string]interface{}{"type": constants.RuleTriggerEvent, "events": []string{constants.AuditActionAddingFile}},
+		"action":  map[string]interface{}{"type": constants.RuleActionNotify, "users": []string{"reviewer"}},
+	}, http.StatusOK, nil)
+	ts.notificationRequest(t, http.MethodPost, "/api/rules", ts.APIKey, map[string]interface{}{
+		"name":    "unknown-user",
+		"trigger": map[string]interface{}{"type": constants.RuleTriggerEvent, "events": []string{constants.AuditActionAddingFile}},
+		"action":  map[string]interface{}{"type": constants.RuleActionNotify, "users": []string{"nobody"}},
+	}, http.StatusBadRequest, nil)
+
+	inbox := ts.UploadFileExpectSuccess(t, "inbox", "new.bin", []byte("uploaded after the rule"), "")
+	private := ts.UploadFileExpectSuccess(t, "private", "secret.bin", []byte("not readable by the reviewer"), "")
+	ts.runRules(time.Now())
+
+	for hash, want := range map[string]bool{inbox.Hash: true, before.Hash: false, private.Hash: false} {
+		entry, err := database.GetActiveQuarantine(ts.App.OrchestratorDB, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (entry != nil) != want {
+			t.Errorf("asset %s: expected quarantined=%t, got %+v", hash, want, entry)
+		} else if entry != nil && (entry.Source != constants.QuarantineSourceRule || entry.Reason != "awaiting review") {
+			t.Errorf("unexpected quarantine entry: %+v", entry)
+		}
+	}
+
+	ruleMatched := func() []database.Notification {
+		var feed services.NotificationFeed
+		ts.notificationRequest(t, http.MethodGet, "/api/notifications", reviewer.APIKey, nil, http.StatusOK, &feed)
+		var matched []database.Notification
+		for _, n := range feed.Notifications {
+			if n.Event == constants.NotificationEventRuleMatched {
+				matched = append(matched, n)
+			}
+		}
+		return matched
+	}
+	notifications := ruleMatched()
+	if len(notifications) != 1 {
+		t.Fatalf("expected one rule_matched notification, got %+v", notifications)
+	}
+	var details struct {
+		Rule     string   `json:"rule"`
+		AssetIDs []string `json:"asset_ids"`
+	}
+	json.Unmarshal(notifications[0].Details, &details)
+	if details.Rule != "tell-reviewer" || len(details.AssetIDs) != 1 || details.AssetIDs[0] != inbox.Hash {
+		t.Errorf("expected only the readable inbox asset, got %+v", details)
+	}
+
+	// Entries are consumed once
+	ts.runRules(time.Now())
+	if notifications := ruleMatched(); len(notifications) != 1 {
+		t.Errorf("expected no new notification, got %+v", notifications)
+	}
+}
+
+// TestRules_RequireTheGrantsOfTheirAction verifies rules are managed with
+// manage_config, and that a rule may only do what its author could by hand.
+func TestRules_RequireTheGrantsOfTheirAction(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	configAdmin := ts.CreateTestUserWithGrants(t, "config-admin", "ConfigAdmin123!", []map[string]interface{}{
+		{"action": constants.AuthActionManageConfig},
+	})
+	trigger := map[string]interface{}{"type": constants.RuleTriggerEvent, "events": []string{constants.AuditActionAddingFile}}
+	for _, action := range []map[string]interface{}{
+		{"type": constants.RuleActionTombstone},
+		{"type": constants.RuleActionQuarantine},
+		{"type": constants.RuleActionTag, "key": "flag", "value": "yes"},
+	} {
+		ts.notificationRequest(t, http.MethodPost, "/api/rules", configAdmin.APIKey, map[string]interface{}{
+			"name":      "forbidden-" + action["type"].(string),
+			"trigger":   trigger,
+			"condition": map[string]interface{}{"topics": []string{"assets"}},
+			"action":    action,
+		}, http.StatusForbidden, nil)
+	}
+	ts.notificationRequest(t, http.MethodPost, "/api/rules", configAdmin.APIKey, map[string]interface{}{
+		"name":    "notify-admin",
+		"trigger": trigger,
+		"action":  map[string]interface{}{"type": constants.RuleActionNotify, "users": []string{"config-admin"}},
+	}, http.StatusOK, nil)
+
+	viewer := ts.CreateTestUserWithGrants(t, "viewer", "ViewerPass123!", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	ts.notificationRequest(t, http.MethodGet, "/api/rules", viewer.APIKey, nil, http.StatusForbidden, nil)
+	ts.notificationRequest(t, http.MethodPost, "/api/rules/preview", viewer.APIKey, map[string]interface{}{}, http.StatusForbidden, nil)
+}
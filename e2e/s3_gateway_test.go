@@ -0,0 +1,248 @@
+package e2e
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+type s3Credentials struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	Region          string `json:"region"`
+	Endpoint        string `json:"endpoint"`
+}
+
+type s3ListResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	KeyCount              int
+	Contents              []struct {
+		Key  string
+		ETag string
+		Size int64
+	}
+}
+
+// enableS3 turns the S3 gateway on for the test server.
+func (ts *TestServer) enableS3() {
+	ts.App.Config.S3 = config.S3Config{Enabled: true, Region: constants.S3DefaultRegion}
+}
+
+// s3CredentialsFor regenerates a user's API key and returns the S3
+// credentials issued with it, which are not shown again.
+func (ts *TestServer) s3CredentialsFor(t *testing.T, user TestUserInfo) s3Credentials {
+	t.Helper()
+	resp, err := ts.POST(fmt.Sprintf("/api/auth/users/%d/api-key", user.ID), nil)
+	var body struct {
+		S3Credentials *s3Credentials `json:"s3_credentials"`
+	}
+	decodePreflight(t, resp, err, http.StatusOK, &body)
+	if body.S3Credentials == nil {
+		t.Fatal("expected s3_credentials with the regenerated API key")
+	}
+	return *body.S3Credentials
+}
+
+// s3Request sends a gateway request signed with Signature Version 4 the
+// way S3 SDKs sign it: host, payload hash and date headers, sorted query.
+func (ts *TestServer) s3Request(t *testing.T, creds s3Credentials, method, path, rawQuery string, body []byte, headers map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.URL.RawQuery = rawQuery
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	canonical := strings.Join([]string{
+		method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + req.Header.Get("X-Amz-Content-Sha256"),
+		"x-amz-date:" + amzDate,
+		"",
+		"host;x-amz-content-sha256;x-amz-date",
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	scope := now.Format("20060102") + "/" + creds.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range []string{now.Format("20060102"), creds.Region, "s3", "aws4_request"} {
+		key = hmacSum(key, part)
+	}
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="+hex.EncodeToString(hmacSum(key, stringToSign)))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	return resp
+}
+
+func hmacSum(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// expectS3Error checks the status and S3 error code of a response.
+func expectS3Error(t *testing.T, resp *http.Response, status int, code string) {
+	t.Helper()
+	defer resp.Body.Close()
+	var e struct{ Code string }
+	xml.NewDecoder(resp.Body).Decode(&e)
+	if resp.StatusCode != status || e.Code != code {
+		t.Errorf("expected %d %s, got %d %s", status, code, resp.StatusCode, e.Code)
+	}
+}
+
+// TestS3Gateway_PutListGetHead verifies objects written through the gateway
+// are listed, read back and addressed by filename or hash.
+func TestS3Gateway_PutListGetHead(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.enableS3()
+	ts.CreateTopic(t, "s3-assets")
+
+	user := ts.CreateTestUserWithGrants(t, "s3user", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionUpload},
+		{"action": constants.AuthActionDownload},
+		{"action": constants.AuthActionQuery},
+	})
+	creds := ts.s3CredentialsFor(t, user)
+	if !strings.HasPrefix(creds.AccessKeyID, constants.S3AccessKeyPrefix) || creds.Region != constants.S3DefaultRegion ||
+		!strings.HasSuffix(creds.Endpoint, "/s3") {
+		t.Fatalf("unexpected credentials: %+v", creds)
+	}
+
+	model := []byte("glTF model bytes for the gateway")
+	resp := ts.s3Request(t, creds, http.MethodPut, "/s3/s3-assets/model.glb", "", model, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == "" {
+		t.Fatalf("expected the upload to succeed, got %d", resp.StatusCode)
+	}
+	hash := strings.Trim(resp.Header.Get("ETag"), `"`)
+	ts.s3Request(t, creds, http.MethodPut, "/s3/s3-assets/readme", "", []byte("notes"), nil).Body.Close()
+
+	// Paginated ListObjectsV2
+	resp = ts.s3Request(t, creds, http.MethodGet, "/s3/s3-assets", "list-type=2&max-keys=1", nil, nil)
+	var page s3ListResult
+	xml.NewDecoder(resp.Body).Decode(&page)
+	resp.Body.Close()
+	if !page.IsTruncated || page.KeyCount != 1 || page.Contents[0].Key != "model.glb" || page.Contents[0].Size != int64(len(model)) {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	resp = ts.s3Request(t, creds, http.MethodGet, "/s3/s3-assets",
+		"continuation-token="+page.NextContinuationToken+"&list-type=2&max-keys=1", nil, nil)
+	page = s3ListResult{}
+	xml.NewDecoder(resp.Body).Decode(&page)
+	resp.Body.Close()
+	if page.IsTruncated || len(page.Contents) != 1 || page.Contents[0].Key != "readme" {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+
+	// Reads by filename, by hash and by range
+	for _, path := range []string{"/s3/s3-assets/model.glb", "/s3/s3-assets/" + hash} {
+		resp = ts.s3Request(t, creds, http.MethodGet, path, "", nil, nil)
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !bytes.Equal(data, model) {
+			t.Errorf("%s: expected the model, got %d %q", path, resp.StatusCode, data)
+		}
+	}
+	resp = ts.s3Request(t, creds, http.MethodGet, "/s3/s3-assets/model.glb", "", nil, map[string]string{"Range": "bytes=0-3"})
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent || string(data) != "glTF" {
+		t.Errorf("expected the first four bytes, got %d %q", resp.StatusCode, data)
+	}
+
+	resp = ts.s3Request(t, creds, http.MethodHead, "/s3/s3-assets/model.glb", "", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ContentLength != int64(len(model)) {
+		t.Errorf("expected HEAD to report the size, got %d %d", resp.StatusCode, resp.ContentLength)
+	}
+
+	expectS3Error(t, ts.s3Request(t, creds, http.MethodGet, "/s3/s3-assets/missing.glb", "", nil, nil), http.StatusNotFound, constants.S3ErrNoSuchKey)
+	expectS3Error(t, ts.s3Request(t, creds, http.MethodGet, "/s3/no-such-topic", "list-type=2", nil, nil), http.StatusNotFound, constants.S3ErrNoSuchBucket)
+}
+
+// TestS3Gateway_AuthAndGrants verifies requests are refused without a valid
+// signature and outside the topics the user's grants cover.
+func TestS3Gateway_AuthAndGrants(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.enableS3()
+	ts.CreateTopic(t, "s3-allowed")
+	ts.CreateTopic(t, "s3-denied")
+
+	user := ts.CreateTestUserWithGrants(t, "s3limited", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionUpload, "constraints_json": `{"allowed_topics":["s3-allowed"]}`},
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_topics":["s3-allowed"]}`},
+	})
+	creds := ts.s3CredentialsFor(t, user)
+
+	resp := ts.s3Request(t, creds, http.MethodGet, "/s3/", "", nil, nil)
+	var buckets struct {
+		Buckets []struct{ Name string } `xml:"Buckets>Bucket"`
+	}
+	xml.NewDecoder(resp.Body).Decode(&buckets)
+	resp.Body.Close()
+	if len(buckets.Buckets) != 1 || buckets.Buckets[0].Name != "s3-allowed" {
+		t.Errorf("expected only the allowed topic, got %+v", buckets.Buckets)
+	}
+
+	expectS3Error(t, ts.s3Request(t, creds, http.MethodPut, "/s3/s3-denied/a.txt", "", []byte("a"), nil), http.StatusForbidden, constants.S3ErrAccessDenied)
+
+	// Reading needs the download grant
+	ts.s3Request(t, creds, http.MethodPut, "/s3/s3-allowed/a.txt", "", []byte("a"), nil).Body.Close()
+	expectS3Error(t, ts.s3Request(t, creds, http.MethodGet, "/s3/s3-allowed/a.txt", "", nil, nil), http.StatusForbidden, constants.S3ErrAccessDenied)
+
+	// Keys must survive upload filename sanitization
+	expectS3Error(t, ts.s3Request(t, creds, http.MethodPut, "/s3/s3-allowed/dir/a.txt", "", []byte("a"), nil), http.StatusBadRequest, constants.S3ErrInvalidArgument)
+
+	forged := creds
+	forged.SecretAccessKey = strings.Repeat("0", len(creds.SecretAccessKey))
+	expectS3Error(t, ts.s3Request(t, forged, http.MethodGet, "/s3/s3-allowed", "list-type=2", nil, nil), http.StatusForbidden, constants.S3ErrSignatureMismatch)
+
+	unknown := creds
+	unknown.AccessKeyID = constants.S3AccessKeyPrefix + strings.Repeat("9", constants.S3AccessKeyIDDigits)
+	expectS3Error(t, ts.s3Request(t, unknown, http.MethodGet, "/s3/s3-allowed", "list-type=2", nil, nil), http.StatusForbidden, constants.S3ErrInvalidAccessKeyID)
+
+	// Regenerating the API key rotates the secret
+	rotated := ts.s3CredentialsFor(t, user)
+	if rotated.AccessKeyID != creds.AccessKeyID || rotated.SecretAccessKey == creds.SecretAccessKey {
+		t.Fatalf("expected a new secret for the same access key, got %+v", rotated)
+	}
+	expectS3Error(t, ts.s3Request(t, creds, http.MethodGet, "/s3/s3-allowed", "list-type=2", nil, nil), http.StatusForbidden, constants.S3ErrSignatureMismatch)
+
+	resp, err := http.Get(ts.URL + "/s3/s3-allowed")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	expectS3Error(t, resp, http.StatusForbidden, constants.S3ErrAccessDenied)
+}
@@ -0,0 +1,139 @@
+package e2e
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/storage"
+)
+
+// scrubStatus mirrors GET /api/maintenance/scrub.
+type scrubStatus struct {
+	Enabled bool `json:"enabled"`
+	Running bool `json:"running"`
+	LastRun *struct {
+		Trigger       string `json:"trigger"`
+		AssetsChecked int    `json:"assets_checked"`
+		Corrupt       int    `json:"corrupt"`
+		Missing       int    `json:"missing"`
+		Topics        []struct {
+			Topic       string `json:"topic"`
+			Quarantined int    `json:"quarantined"`
+		} `json:"topics"`
+	} `json:"last_run"`
+	UnhealthyTotal int `json:"unhealthy_total"`
+	Unhealthy      []struct {
+		Hash   string `json:"hash"`
+		Topic  string `json:"topic"`
+		Status string `json:"status"`
+	} `json:"unhealthy"`
+}
+
+// runScrub starts a scrub pass and waits for it to finish.
+func (ts *TestServer) runScrub(t *testing.T) scrubStatus {
+	t.Helper()
+	resp, err := ts.POST("/api/maintenance/scrub", nil)
+	if err != nil {
+		t.Fatalf("POST /api/maintenance/scrub failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		var status scrubStatus
+		if err := ts.GetJSON("/api/maintenance/scrub", &status); err != nil {
+			t.Fatalf("GET /api/maintenance/scrub failed: %v", err)
+		}
+		if !status.Running && status.LastRun != nil {
+			return status
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("scrub pass did not finish in time")
+	return scrubStatus{}
+}
+
+// TestScrub_FindsCorruptAndMissingAssets verifies a scrub pass flags an asset
+// whose content was altered on disk and one whose .dat file is gone,
+// quarantines the corrupt one, audits each topic, and forgets findings once
+// the damaged assets are no longer stored.
+func TestScrub_FindsCorruptAndMissingAssets(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+	ts.CreateTopic(t, "lost")
+
+	corrupt := ts.UploadFileExpectSuccess(t, "assets", "first.bin", GenerateTestFile(1024), "").Hash
+	intact := ts.UploadFileExpectSuccess(t, "assets", "second.bin", GenerateTestFile(2048), "").Hash
+	missing := ts.UploadFileExpectSuccess(t, "lost", "gone.bin", GenerateTestFile(512), "").Hash
+
+	var initial scrubStatus
+	if err := ts.GetJSON("/api/maintenance/scrub", &initial); err != nil {
+		t.Fatalf("GET /api/maintenance/scrub failed: %v", err)
+	}
+	if !initial.Enabled || initial.Running || initial.LastRun != nil || initial.UnhealthyTotal != 0 {
+		t.Fatalf("unexpected initial status %+v", initial)
+	}
+
+	// Flip content bytes of the first entry, leaving its header intact
+	datFile, err := os.OpenFile(filepath.Join(ts.WorkDir, "assets", storage.FormatDatFilename(1)), os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open dat file: %v", err)
+	}
+	datFile.WriteAt([]byte("garbage"), int64(constants.HeaderSize)+100)
+	datFile.Close()
+	if err := os.Remove(filepath.Join(ts.WorkDir, "lost", storage.FormatDatFilename(1))); err != nil {
+		t.Fatalf("Failed to remove dat file: %v", err)
+	}
+
+	status := ts.runScrub(t)
+	run := status.LastRun
+	if run.Trigger != constants.ScrubTriggerManual || run.AssetsChecked != 3 || run.Corrupt != 1 || run.Missing != 1 {
+		t.Fatalf("unexpected pass result %+v", run)
+	}
+	found := map[string]string{}
+	for _, e := range status.Unhealthy {
+		found[e.Hash] = e.Status
+	}
+	if status.UnhealthyTotal != 2 || found[corrupt] != constants.AssetHealthCorrupt || found[missing] != constants.AssetHealthMissing {
+		t.Fatalf("expected the corrupt and missing assets flagged, got %+v", status.Unhealthy)
+	}
+
+	// Only the corrupt asset is quarantined
+	entries := ts.listQuarantine(t, "")
+	if len(entries) != 1 || entries[0].Hash != corrupt || entries[0].Source != constants.QuarantineSourceIntegrity {
+		t.Errorf("expected the corrupt asset quarantined, got %+v", entries)
+	}
+	ts.DownloadAssetExpectError(t, corrupt, http.StatusLocked)
+	ts.DownloadAsset(t, intact)
+
+	var audit AuditQueryResponse
+	if err := ts.GetJSON("/api/audit?action="+constants.AuditActionVerified, &audit); err != nil {
+		t.Fatalf("failed to query audit: %v", err)
+	}
+	topics := map[string]bool{}
+	for _, e := range audit.Entries {
+		if details, ok := e.Details.(map[string]interface{}); ok && details["source"] == constants.ScrubSource {
+			topics[details["topic"].(string)] = true
+		}
+	}
+	if !topics["assets"] || !topics["lost"] {
+		t.Errorf("expected a verified entry per topic, got %+v", audit.Entries)
+	}
+
+	// Findings of assets no longer stored are dropped by the next pass
+	if code, body := ts.deleteAsset(t, missing); code != http.StatusOK {
+		t.Fatalf("delete failed with %d: %s", code, body)
+	}
+	status = ts.runScrub(t)
+	if status.UnhealthyTotal != 1 || status.Unhealthy[0].Hash != corrupt {
+		t.Errorf("expected only the corrupt asset left, got %+v", status.Unhealthy)
+	}
+}
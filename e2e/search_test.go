@@ -0,0 +1,107 @@
+package e2e
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/services"
+)
+
+// search runs GET /api/search with query as the raw query string.
+func (ts *TestServer) search(t *testing.T, apiKey, query string, expectedStatus int) services.SearchResults {
+	t.Helper()
+	var results services.SearchResults
+	ts.notificationRequest(t, http.MethodGet, "/api/search?"+query, apiKey, nil, expectedStatus, &results)
+	return results
+}
+
+func searchHashes(results services.SearchResults) []string {
+	hashes := make([]string, len(results.Results))
+	for i, r := range results.Results {
+		hashes[i] = r.Hash
+	}
+	return hashes
+}
+
+// TestSearch_NamesAndMetadataAcrossTopics verifies search matches origin
+// names and metadata values as they are written, ranks name matches first,
+// highlights matches, and leaves out deleted, quarantined and unreadable
+// assets.
+func TestSearch_NamesAndMetadataAcrossTopics(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "inbox")
+	ts.CreateTopic(t, "archive")
+
+	if !database.FTS5Supported(ts.App.OrchestratorDB) {
+		ts.search(t, ts.APIKey, "q=dragon", http.StatusServiceUnavailable)
+		t.Skip("built without the sqlite_fts5 tag")
+	}
+
+	named := ts.UploadFileExpectSuccess(t, "inbox", "dragon-sketch.png", []byte("dragon sketch"), "")
+	tagged := ts.UploadFileExpectSuccess(t, "archive", "model.glb", []byte("a model"), "")
+	other := ts.UploadFileExpectSuccess(t, "archive", "castle.glb", []byte("a castle"), "")
+	ts.SetMetadata(t, tagged.Hash, "creature", "red dragon")
+
+	for _, query := range []string{"", "q=", "q=" + strings.Repeat("x", 201)} {
+		ts.search(t, ts.APIKey, query, http.StatusBadRequest)
+	}
+
+	results := ts.search(t, ts.APIKey, "q=drag", http.StatusOK)
+	if got := searchHashes(results); len(got) != 2 || got[0] != named.Hash || got[1] != tagged.Hash {
+		t.Fatalf("expected the named asset before the tagged one, got %+v", results.Results)
+	}
+	if results.Results[0].Topic != "inbox" || results.Results[1].Topic != "archive" {
+		t.Errorf("expected results tagged with their topics, got %+v", results.Results)
+	}
+	if !strings.Contains(results.Results[1].Snippet, "<mark>dragon</mark>") {
+		t.Errorf("expected a highlighted metadata snippet, got %q", results.Results[1].Snippet)
+	}
+	if got := searchHashes(ts.search(t, ts.APIKey, "q="+url.QueryEscape("red dragon"), http.StatusOK)); len(got) != 1 || got[0] != tagged.Hash {
+		t.Errorf("expected every word to match, got %v", got)
+	}
+	if got := searchHashes(ts.search(t, ts.APIKey, "q=drag&topic=archive", http.StatusOK)); len(got) != 1 || got[0] != tagged.Hash {
+		t.Errorf("expected only the archive match, got %v", got)
+	}
+	if got := ts.search(t, ts.APIKey, "q="+url.QueryEscape(`dragon" OR castle`), http.StatusOK); len(got.Results) != 0 {
+		t.Errorf("expected query syntax to be searched as text, got %+v", got.Results)
+	}
+
+	// Metadata changes are indexed as they are written
+	ts.DeleteMetadata(t, tagged.Hash, "creature")
+	ts.SetMetadata(t, other.Hash, "creature", "dragon")
+	if got := searchHashes(ts.search(t, ts.APIKey, "q=dragon&topic=archive", http.StatusOK)); len(got) != 1 || got[0] != other.Hash {
+		t.Errorf("expected the index to follow metadata writes, got %v", got)
+	}
+
+	viewer := ts.CreateTestUserWithGrants(t, "viewer", "ViewerPass123!", []map[string]interface{}{
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_topics":["inbox"]}`},
+	})
+	limited := ts.search(t, viewer.APIKey, "q=dragon", http.StatusOK)
+	if got := searchHashes(limited); len(got) != 1 || got[0] != named.Hash || len(limited.TopicsSearched) != 1 {
+		t.Errorf("expected only the readable topic to be searched, got %+v", limited)
+	}
+
+	if status, body := ts.postAsset(t, named.Hash, "quarantine", map[string]string{"reason": "review"}); status != http.StatusOK {
+		t.Fatalf("quarantine failed: %d %s", status, body)
+	}
+	if status, body := ts.deleteAsset(t, other.Hash); status != http.StatusOK {
+		t.Fatalf("delete failed: %d %s", status, body)
+	}
+	if got := ts.search(t, ts.APIKey, "q=dragon", http.StatusOK); len(got.Results) != 0 {
+		t.Errorf("expected quarantined and deleted assets to be left out, got %+v", got.Results)
+	}
+
+	// The index survives a restart
+	ts.Restart(t)
+	if got := searchHashes(ts.search(t, ts.APIKey, "q=castle", http.StatusOK)); len(got) != 0 {
+		t.Errorf("expected the deleted asset to stay out after a restart, got %v", got)
+	}
+	if got := searchHashes(ts.search(t, ts.APIKey, "q=model", http.StatusOK)); len(got) != 1 || got[0] != tagged.Hash {
+		t.Errorf("expected the index to be kept across restarts, got %v", got)
+	}
+}
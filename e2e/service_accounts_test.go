@@ -0,0 +1,227 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// createServiceAccount creates a service account as admin and returns the response body.
+func (ts *TestServer) createServiceAccount(t *testing.T, body map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	resp, err := ts.POST("/api/auth/service-accounts", body)
+	if err != nil {
+		t.Fatalf("create service account request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		t.Fatalf("expected 201, got %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return result
+}
+
+// TestServiceAccountLifecycle covers creation, scoped use, listing, rotation and disabling.
+func TestServiceAccountLifecycle(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "renders")
+	ts.CreateTopic(t, "private")
+
+	upload := ts.UploadFileExpectSuccess(t, "renders", "frame.bin", []byte("frame data"), "")
+
+	created := ts.createServiceAccount(t, map[string]interface{}{
+		"name":         "thumbnailer",
+		"display_name": "Thumbnail worker",
+		"scopes": []map[string]interface{}{
+			{"action": constants.AuthActionMetadata, "constraints_json": `{"allowed_topics":["renders"],"allowed_key_prefixes":["thumb."]}`},
+			{"action": constants.AuthActionDownload, "constraints_json": `{"allowed_topics":["renders"]}`},
+		},
+	})
+
+	token, _ := created["token"].(string)
+	if token == "" {
+		t.Fatal("expected a token in the create response")
+	}
+	account := created["account"].(map[string]interface{})
+	accountID := int64(account["id"].(float64))
+	if account["account_type"] != constants.AuthAccountTypeService {
+		t.Errorf("expected account_type=service, got %v", account["account_type"])
+	}
+
+	// Writes inside the key namespace succeed
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, "/api/assets/"+upload.Hash+"/metadata", token, map[string]interface{}{
+		"op": "set", "key": "thumb.width", "value": 128, "processor": "thumbnailer", "processor_version": "1.0",
+	})
+	if err != nil {
+		t.Fatalf("metadata request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for key inside namespace, got %d", resp.StatusCode)
+	}
+
+	// Writes outside it are denied
+	resp, err = ts.RequestWithAPIKey(http.MethodPost, "/api/assets/"+upload.Hash+"/metadata", token, map[string]interface{}{
+		"op": "set", "key": "status", "value": "done", "processor": "thumbnailer", "processor_version": "1.0",
+	})
+	if err != nil {
+		t.Fatalf("metadata request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for key outside namespace, got %d", resp.StatusCode)
+	}
+
+	// Actions outside the scopes are denied
+	resp, err = ts.RequestWithAPIKey(http.MethodGet, "/api/auth/users", token, nil)
+	if err != nil {
+		t.Fatalf("users request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for manage_users, got %d", resp.StatusCode)
+	}
+
+	// Service accounts are listed separately from users
+	var accounts struct {
+		ServiceAccounts []map[string]interface{} `json:"service_accounts"`
+	}
+	if err := ts.GetJSON("/api/auth/service-accounts", &accounts); err != nil {
+		t.Fatalf("list service accounts failed: %v", err)
+	}
+	if len(accounts.ServiceAccounts) != 1 || accounts.ServiceAccounts[0]["username"] != "thumbnailer" {
+		t.Errorf("unexpected service accounts: %v", accounts.ServiceAccounts)
+	}
+	var users struct {
+		Users []map[string]interface{} `json:"users"`
+	}
+	if err := ts.GetJSON("/api/auth/users", &users); err != nil {
+		t.Fatalf("list users failed: %v", err)
+	}
+	for _, u := range users.Users {
+		if u["username"] == "thumbnailer" {
+			t.Error("service account should not be listed among users")
+		}
+	}
+
+	// The service account's writes are tagged as service activity in the audit log
+	var auditResp struct {
+		Entries []map[string]interface{} `json:"entries"`
+	}
+	if err := ts.GetJSON("/api/audit?actor_type=service", &auditResp); err != nil {
+		t.Fatalf("audit query failed: %v", err)
+	}
+	if len(auditResp.Entries) == 0 {
+		t.Fatal("expected audit entries for the service account")
+	}
+	for _, e := range auditResp.Entries {
+		if e["username"] != "thumbnailer" || e["actor_type"] != constants.AuditActorService {
+			t.Errorf("unexpected entry in service filter: %v", e)
+		}
+	}
+
+	// Rotation invalidates the old token
+	var rotated map[string]interface{}
+	if err := ts.PostJSON(fmt.Sprintf("/api/auth/service-accounts/%d/rotate", accountID), nil, &rotated); err != nil {
+		t.Fatalf("rotate failed: %v", err)
+	}
+	newToken, _ := rotated["token"].(string)
+	if newToken == "" || newToken == token {
+		t.Fatalf("expected a new token, got %q", newToken)
+	}
+	resp, _ = ts.RequestWithAPIKey(http.MethodGet, "/api/assets/"+upload.Hash+"/metadata", token, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 with the rotated-out token, got %d", resp.StatusCode)
+	}
+	resp, _ = ts.RequestWithAPIKey(http.MethodGet, "/api/assets/"+upload.Hash+"/metadata", newToken, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 with the new token, got %d", resp.StatusCode)
+	}
+
+	// Disabling rejects the token
+	resp, err = ts.DELETE(fmt.Sprintf("/api/auth/service-accounts/%d", accountID))
+	if err != nil {
+		t.Fatalf("disable request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 on disable, got %d", resp.StatusCode)
+	}
+	resp, _ = ts.RequestWithAPIKey(http.MethodGet, "/api/assets/"+upload.Hash+"/metadata", newToken, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 after disable, got %d", resp.StatusCode)
+	}
+}
+
+// TestServiceAccountRejectsBroadScopes verifies admin-level actions cannot be granted.
+func TestServiceAccountRejectsBroadScopes(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	resp, err := ts.POST("/api/auth/service-accounts", map[string]interface{}{
+		"name":   "overreach",
+		"scopes": []map[string]interface{}{{"action": constants.AuthActionManageUsers}},
+	})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+
+	// Nor added later through the grant endpoints
+	created := ts.createServiceAccount(t, map[string]interface{}{
+		"name":   "indexer",
+		"scopes": []map[string]interface{}{{"action": constants.AuthActionQuery}},
+	})
+	accountID := int64(created["account"].(map[string]interface{})["id"].(float64))
+	resp2, err := ts.POST(fmt.Sprintf("/api/auth/users/%d/grants", accountID), map[string]interface{}{
+		"action": constants.AuthActionManageConfig,
+	})
+	if err != nil {
+		t.Fatalf("grant request failed: %v", err)
+	}
+	defer resp2.Body.Close()
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 granting manage_config to a service account, got %d", resp2.StatusCode)
+	}
+}
+
+// TestServiceAccountCannotLogin verifies service accounts have no password login.
+func TestServiceAccountCannotLogin(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	ts.createServiceAccount(t, map[string]interface{}{
+		"name":   "importer",
+		"scopes": []map[string]interface{}{{"action": constants.AuthActionUpload}},
+	})
+
+	for _, password := range []string{"secure-password-12345", "x"} {
+		resp, err := ts.UnauthenticatedPOST("/api/auth/login", map[string]interface{}{
+			"username": "importer",
+			"password": password,
+		})
+		if err != nil {
+			t.Fatalf("login request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected 401 for service account login, got %d", resp.StatusCode)
+		}
+	}
+}
@@ -93,6 +93,12 @@ func (ts *TestServer) Cleanup() {
 		ts.Server.Close()
 	}
 	if ts.App != nil {
+		if ts.App.Services.Notification != nil {
+			ts.App.Services.Notification.Stop()
+		}
+		if ts.App.Services.WatchFolders != nil {
+			ts.App.Services.WatchFolders.Stop()
+		}
 		ts.App.CloseAllTopicDBs()
 		if ts.App.OrchestratorDB != nil {
 			ts.App.OrchestratorDB.Close()
@@ -109,6 +115,9 @@ func (ts *TestServer) Shutdown() {
 		ts.Server = nil
 	}
 	if ts.App != nil {
+		if ts.App.Services.Notification != nil {
+			ts.App.Services.Notification.Stop()
+		}
 		ts.App.CloseAllTopicDBs()
 		if ts.App.OrchestratorDB != nil {
 			ts.App.OrchestratorDB.Close()
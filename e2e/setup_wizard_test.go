@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// setupStepResponse is the body of a completed setup step.
+type setupStepResponse struct {
+	Bootstrap *struct {
+		APIKey string `json:"api_key"`
+	} `json:"bootstrap"`
+	RequiresRestart bool                 `json:"requires_restart"`
+	Setup           services.SetupStatus `json:"setup"`
+}
+
+// postSetupStep posts body to a setup step and decodes the response.
+func (ts *TestServer) postSetupStep(t *testing.T, step string, body interface{}) (int, setupStepResponse, string) {
+	t.Helper()
+	resp, err := ts.POST("/api/setup/"+step, body)
+	if err != nil {
+		t.Fatalf("setup step %s failed: %v", step, err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	var result setupStepResponse
+	json.Unmarshal(raw, &result)
+	return resp.StatusCode, result, string(raw)
+}
+
+// setupStepStatus returns the status of the named step.
+func setupStepStatus(status services.SetupStatus, name string) string {
+	for _, step := range status.Steps {
+		if step.Name == name {
+			return step.Status
+		}
+	}
+	return ""
+}
+
+// TestSetupWizard_WalksFirstRun checks a new instance is initialized step by
+// step, each step validated and reported in the status.
+func TestSetupWizard_WalksFirstRun(t *testing.T) {
+	ts := StartTestServer(t)
+	t.Setenv(constants.ServiceConfigDirEnv, ts.ConfigDir)
+
+	resp, err := ts.UnauthenticatedGET("/api/setup/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status services.SetupStatus
+	json.NewDecoder(resp.Body).Decode(&status)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || status.Complete || status.NextStep != constants.SetupStepWorkingDirectory || len(status.Steps) != 4 {
+		t.Fatalf("expected a fresh setup starting at the working directory, got %d %+v", resp.StatusCode, status)
+	}
+
+	if code, _, body := ts.postSetupStep(t, "topic", map[string]string{"name": "early"}); code != http.StatusConflict {
+		t.Errorf("expected 409 for a topic before the working directory, got %d: %s", code, body)
+	}
+	missing := filepath.Join(ts.WorkDir, "missing")
+	if code, _, body := ts.postSetupStep(t, "working-directory", map[string]string{"working_directory": missing}); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a missing directory, got %d: %s", code, body)
+	}
+
+	code, result, body := ts.postSetupStep(t, "working-directory", map[string]string{"working_directory": ts.WorkDir})
+	if code != http.StatusOK || result.Bootstrap == nil {
+		t.Fatalf("expected the working directory set with bootstrap credentials, got %d: %s", code, body)
+	}
+	ts.APIKey = result.Bootstrap.APIKey
+	if result.Setup.NextStep != constants.SetupStepCredentials {
+		t.Errorf("expected credentials next, got %+v", result.Setup)
+	}
+
+	if code, _, _ := ts.postSetupStep(t, "credentials", map[string]bool{"saved": false}); code != http.StatusBadRequest {
+		t.Errorf("expected 400 without saved, got %d", code)
+	}
+	if code, result, body = ts.postSetupStep(t, "credentials", map[string]bool{"saved": true}); code != http.StatusOK || result.Setup.NextStep != constants.SetupStepFirstTopic {
+		t.Fatalf("expected first_topic next, got %d: %s", code, body)
+	}
+
+	code, result, body = ts.postSetupStep(t, "topic", map[string]string{"name": "first-topic"})
+	if code != http.StatusOK || !result.Setup.Complete || result.Setup.NextStep != constants.SetupStepTLS {
+		t.Fatalf("expected setup complete with TLS offered, got %d: %s", code, body)
+	}
+
+	// TLS is optional: invalid files are refused, skipping finishes the wizard
+	if code, _, body := ts.postSetupStep(t, "tls", map[string]string{"cert_file": missing, "key_file": missing}); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unreadable certificate files, got %d: %s", code, body)
+	}
+	code, result, _ = ts.postSetupStep(t, "tls", map[string]bool{"skip": true})
+	if code != http.StatusOK || result.Setup.NextStep != "" || setupStepStatus(result.Setup, constants.SetupStepTLS) != constants.SetupStatusSkipped {
+		t.Fatalf("expected TLS skipped, got %d %+v", code, result.Setup)
+	}
+	code, result, body = ts.postSetupStep(t, "tls", map[string]bool{"self_signed": true})
+	if code != http.StatusOK || !result.RequiresRestart || setupStepStatus(result.Setup, constants.SetupStepTLS) != constants.SetupStatusComplete {
+		t.Fatalf("expected TLS configured for the next restart, got %d: %s", code, body)
+	}
+	if !ts.App.Config.HTTP.TLSSelfSigned {
+		t.Error("expected tls_self_signed saved to the config")
+	}
+
+	// Steps after the working directory need credentials
+	resp, err = ts.UnauthenticatedPOST("/api/setup/credentials", map[string]bool{"saved": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,84 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+type monitoringStreamsResponse struct {
+	Streams struct {
+		Open       int            `json:"open"`
+		ByEndpoint map[string]int `json:"by_endpoint"`
+		Rejected   int            `json:"rejected"`
+	} `json:"streams"`
+}
+
+// TestSSELimits_CapsAndMonitoring verifies SSE streams are capped per user
+// and server-wide with graceful rejections, and counted in monitoring.
+func TestSSELimits_CapsAndMonitoring(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.App.Config.HTTP.MaxSSEConnections = 2
+	ts.App.Config.HTTP.MaxSSEPerClient = 1
+
+	auditGrant := []map[string]interface{}{{"action": constants.AuthActionViewAudit}}
+	bob := ts.CreateTestUserWithGrants(t, "bob", "bob-password-12345", auditGrant)
+	carol := ts.CreateTestUserWithGrants(t, "carol", "carol-password-1234", auditGrant)
+
+	first, err := ts.GET("/api/audit/stream")
+	if err != nil || first.StatusCode != http.StatusOK {
+		t.Fatalf("first stream: %v %v", err, first)
+	}
+	defer first.Body.Close()
+
+	resp, err := ts.GET("/api/audit/stream")
+	if err != nil {
+		t.Fatalf("second stream request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("second stream of the same user: expected 429, got %d", resp.StatusCode)
+	}
+
+	second, err := ts.RequestWithAPIKey(http.MethodGet, "/api/audit/stream", bob.APIKey, nil)
+	if err != nil || second.StatusCode != http.StatusOK {
+		t.Fatalf("stream of another user: %v %v", err, second)
+	}
+	defer second.Body.Close()
+
+	resp, err = ts.RequestWithAPIKey(http.MethodGet, "/api/audit/stream", carol.APIKey, nil)
+	if err != nil {
+		t.Fatalf("third stream request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get(constants.HeaderRetryAfter) == "" {
+		t.Errorf("stream over the server-wide cap: expected 503 with Retry-After, got %d", resp.StatusCode)
+	}
+
+	var info monitoringStreamsResponse
+	if err := ts.GetJSON("/api/monitoring", &info); err != nil {
+		t.Fatalf("monitoring request failed: %v", err)
+	}
+	if info.Streams.Open != 2 || info.Streams.ByEndpoint[constants.SSEEndpointAuditStream] != 2 || info.Streams.Rejected != 2 {
+		t.Errorf("unexpected stream stats: %+v", info.Streams)
+	}
+
+	// Closing a stream frees its slot once the server notices
+	second.Body.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if err := ts.GetJSON("/api/monitoring", &info); err != nil {
+			t.Fatalf("monitoring request failed: %v", err)
+		}
+		if info.Streams.Open == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 1 open stream after closing one, got %d", info.Streams.Open)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
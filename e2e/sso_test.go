@@ -0,0 +1,311 @@
+package e2e
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+// noRedirectClient returns redirects instead of following them.
+var noRedirectClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// fakeIdP is a minimal OpenID Connect provider issuing RS256 ID tokens.
+type fakeIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]fakeIdPGrant
+	issued int
+}
+
+type fakeIdPGrant struct {
+	claims    map[string]interface{}
+	challenge string
+}
+
+func startFakeIdP(t *testing.T, clientID, clientSecret string) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdP{key: key, codes: map[string]fakeIdPGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(constants.OIDCDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA", "kid": "k1", "use": "sig",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		r.ParseForm()
+		idp.mu.Lock()
+		grant, ok := idp.codes[r.Form.Get("code")]
+		delete(idp.codes, r.Form.Get("code"))
+		idp.mu.Unlock()
+
+		verifier := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if user != clientID || pass != clientSecret || !ok ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != grant.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		claims := map[string]interface{}{
+			"iss":            idp.server.URL,
+			"aud":            clientID,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(5 * time.Minute).Unix(),
+			"email_verified": true,
+		}
+		for k, v := range grant.claims {
+			claims[k] = v
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"id_token":     idp.sign(t, claims),
+			"access_token": "unused",
+			"token_type":   "Bearer",
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *fakeIdP) sign(t *testing.T, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// signIn starts a sign-in at the server, authenticates at the provider as
+// the holder of claims and follows the callback. Returns the location the
+// browser lands on.
+func (idp *fakeIdP) signIn(t *testing.T, ts *TestServer, returnTo string, claims map[string]interface{}) *url.URL {
+	t.Helper()
+	resp, err := noRedirectClient.Get(ts.URL + "/api/auth/oidc/login?redirect=" + url.QueryEscape(returnTo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("login: expected 302, got %d", resp.StatusCode)
+	}
+	authorize, _ := url.Parse(resp.Header.Get("Location"))
+	q := authorize.Query()
+
+	// The provider authenticates the user and sends them back with a code
+	idp.mu.Lock()
+	idp.issued++
+	code := fmt.Sprintf("code-%d", idp.issued)
+	grantClaims := map[string]interface{}{"nonce": q.Get("nonce")}
+	for k, v := range claims {
+		grantClaims[k] = v
+	}
+	idp.codes[code] = fakeIdPGrant{claims: grantClaims, challenge: q.Get("code_challenge")}
+	idp.mu.Unlock()
+
+	return ts.ssoCallback(t, q.Get("redirect_uri")+"?state="+url.QueryEscape(q.Get("state"))+"&code="+code)
+}
+
+func (ts *TestServer) ssoCallback(t *testing.T, callback string) *url.URL {
+	t.Helper()
+	resp, err := noRedirectClient.Get(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("callback: expected 302, got %d", resp.StatusCode)
+	}
+	landing, _ := url.Parse(resp.Header.Get("Location"))
+	return landing
+}
+
+// exchangeSSOCode trades the login code of a landing URL for a session.
+func (ts *TestServer) exchangeSSOCode(t *testing.T, landing *url.URL) (int, string, int64) {
+	t.Helper()
+	fragment, _ := url.ParseQuery(landing.Fragment)
+	resp, err := ts.UnauthenticatedPOST("/api/auth/oidc/exchange", map[string]string{"code": fragment.Get(constants.OIDCCodeFragment)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Token string `json:"token"`
+		User  struct {
+			ID int64 `json:"id"`
+		} `json:"user"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body.Token, body.User.ID
+}
+
+func ssoError(landing *url.URL) string {
+	fragment, _ := url.ParseQuery(landing.Fragment)
+	return fragment.Get(constants.OIDCErrorFragment)
+}
+
+// TestSSO_SignIn covers signing in through an OpenID Connect provider:
+// provisioning on first sign-in, returning users, linking local users and
+// the sign-ins that are refused.
+func TestSSO_SignIn(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	idp := startFakeIdP(t, "silobang", "s3cret")
+
+	ts.App.Config.OIDC = config.OIDCConfig{
+		Issuer:           idp.server.URL,
+		ClientID:         "silobang",
+		ClientSecret:     "s3cret",
+		RedirectURL:      ts.URL + "/api/auth/oidc/callback",
+		UsernameClaim:    constants.OIDCDefaultUsernameClaim,
+		DisplayNameClaim: constants.OIDCDefaultNameClaim,
+		AllowedDomains:   []string{"example.com"},
+	}
+
+	var status struct {
+		SSO struct {
+			Enabled  bool   `json:"enabled"`
+			LoginURL string `json:"login_url"`
+		} `json:"sso"`
+	}
+	if err := ts.GetJSON("/api/auth/status", &status); err != nil || !status.SSO.Enabled || status.SSO.LoginURL != constants.OIDCLoginPath {
+		t.Fatalf("expected SSO in the auth status, got %+v %v", status, err)
+	}
+
+	// First sign-in provisions a user without grants
+	ana := map[string]interface{}{"sub": "u-1", "preferred_username": "Ana.Lopez", "name": "Ana López", "email": "ana@example.com"}
+	landing := idp.signIn(t, ts, "/assets?topic=renders", ana)
+	if landing.Path != "/assets" || landing.Query().Get("topic") != "renders" {
+		t.Fatalf("expected to land on the return path, got %s", landing)
+	}
+	code, token, anaID := ts.exchangeSSOCode(t, landing)
+	if code != http.StatusOK || token == "" {
+		t.Fatalf("exchange failed: %d", code)
+	}
+	resp, err := ts.RequestWithSessionToken("GET", "/api/auth/me", token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var me struct {
+		User struct {
+			Username    string `json:"username"`
+			DisplayName string `json:"display_name"`
+		} `json:"user"`
+		Grants []interface{} `json:"grants"`
+	}
+	json.NewDecoder(resp.Body).Decode(&me)
+	resp.Body.Close()
+	if me.User.Username != "ana-lopez" || me.User.DisplayName != "Ana López" || len(me.Grants) != 0 {
+		t.Errorf("expected provisioned ana-lopez without grants, got %+v", me)
+	}
+
+	// Login codes are single use
+	if code, _, _ := ts.exchangeSSOCode(t, landing); code != http.StatusUnauthorized {
+		t.Errorf("replayed login code: expected 401, got %d", code)
+	}
+
+	// Returning users keep their account, even under a new username
+	ana["preferred_username"] = "ana"
+	if _, _, id := ts.exchangeSSOCode(t, idp.signIn(t, ts, "/", ana)); id != anaID {
+		t.Errorf("expected the returning user %d, got %d", anaID, id)
+	}
+
+	// Local users are linked only when configured
+	bob := ts.CreateTestUser(t, "bob", "secure-password-12345")
+	bobClaims := map[string]interface{}{"sub": "u-2", "preferred_username": "bob", "email": "bob@example.com"}
+	if got := ssoError(idp.signIn(t, ts, "/", bobClaims)); got != constants.ErrCodeAuthSSOUsernameTaken {
+		t.Errorf("expected %s, got %q", constants.ErrCodeAuthSSOUsernameTaken, got)
+	}
+	ts.App.Config.OIDC.LinkExistingUsers = true
+	// Linking needs a verified email; a missing claim is not one
+	for name, verified := range map[string]interface{}{"unverified": false, "missing": nil} {
+		unverified := map[string]interface{}{"sub": "u-2", "preferred_username": "bob", "email": "bob@example.com", "email_verified": verified}
+		if got := ssoError(idp.signIn(t, ts, "/", unverified)); got != constants.ErrCodeAuthSSODenied {
+			t.Errorf("%s email: expected %s, got %q", name, constants.ErrCodeAuthSSODenied, got)
+		}
+	}
+	if _, _, id := ts.exchangeSSOCode(t, idp.signIn(t, ts, "/", bobClaims)); id != bob.ID {
+		t.Errorf("expected bob (%d) linked, got %d", bob.ID, id)
+	}
+	// A second identity cannot take over a linked user
+	impostor := map[string]interface{}{"sub": "u-3", "preferred_username": "bob", "email": "bob2@example.com"}
+	if got := ssoError(idp.signIn(t, ts, "/", impostor)); got != constants.ErrCodeAuthSSOUsernameTaken {
+		t.Errorf("impostor: expected %s, got %q", constants.ErrCodeAuthSSOUsernameTaken, got)
+	}
+
+	// Administrators are never linked, whoever holds their name at the provider
+	ts.CreateTestUserWithGrants(t, "carol", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionManageUsers},
+	})
+	for i, name := range []string{constants.AuthBootstrapUsername, "carol"} {
+		takeover := map[string]interface{}{"sub": fmt.Sprintf("admin-%d", i), "preferred_username": name, "email": name + "@example.com"}
+		if got := ssoError(idp.signIn(t, ts, "/", takeover)); got != constants.ErrCodeAuthSSOUsernameTaken {
+			t.Errorf("takeover of %s: expected %s, got %q", name, constants.ErrCodeAuthSSOUsernameTaken, got)
+		}
+	}
+
+	// Refused sign-ins
+	for name, tc := range map[string]struct {
+		claims map[string]interface{}
+		want   string
+	}{
+		"foreign domain": {map[string]interface{}{"sub": "u-4", "preferred_username": "eve", "email": "eve@evil.test"}, constants.ErrCodeAuthSSODenied},
+		"unverified":     {map[string]interface{}{"sub": "u-5", "preferred_username": "carl", "email": "carl@example.com", "email_verified": false}, constants.ErrCodeAuthSSODenied},
+		"wrong audience": {map[string]interface{}{"sub": "u-6", "preferred_username": "dan", "email": "dan@example.com", "aud": "other-app"}, constants.ErrCodeAuthSSOInvalid},
+	} {
+		if got := ssoError(idp.signIn(t, ts, "/", tc.claims)); got != tc.want {
+			t.Errorf("%s: expected %s, got %q", name, tc.want, got)
+		}
+	}
+	ts.App.Config.OIDC.AutoProvision = new(bool)
+	if got := ssoError(idp.signIn(t, ts, "/", map[string]interface{}{"sub": "u-7", "preferred_username": "fay", "email": "fay@example.com"})); got != constants.ErrCodeAuthSSODenied {
+		t.Errorf("auto_provision off: expected %s, got %q", constants.ErrCodeAuthSSODenied, got)
+	}
+
+	// Unknown states and foreign return paths go nowhere
+	if got := ssoError(ts.ssoCallback(t, ts.URL+"/api/auth/oidc/callback?state=forged&code=x")); got != constants.ErrCodeAuthSSOInvalid {
+		t.Errorf("forged state: expected %s, got %q", constants.ErrCodeAuthSSOInvalid, got)
+	}
+	if landing := idp.signIn(t, ts, "https://evil.test/phish", ana); landing.Host != "" || landing.Path != "/" {
+		t.Errorf("expected a foreign return path to land on /, got %s", landing)
+	}
+}
@@ -567,6 +567,41 @@ func TestMonitoringEndpointIncludesServiceInfo(t *testing.T) {
 	}
 }
 
+// TestStatsCachePersistedAcrossRestart verifies the cache is restored from the
+// orchestrator DB after a restart, flagged stale in monitoring, and cleared by
+// reconciliation.
+func TestStatsCachePersistedAcrossRestart(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "persist-topic")
+	ts.UploadFileExpectSuccess(t, "persist-topic", "file1.bin", GenerateTestFile(2048), "")
+
+	if mon := ts.GetMonitoring(t); mon.StatsCache == nil || mon.StatsCache.Stale || mon.StatsCache.ReconciledAt == 0 {
+		t.Fatalf("expected a fresh cache before restart, got %+v", mon.StatsCache)
+	}
+
+	ts.Restart(t)
+	cache := ts.App.Services.StatsCache
+	if !cache.Restore() {
+		t.Fatal("expected persisted cache to be restored")
+	}
+
+	mon := ts.GetMonitoring(t)
+	if mon.StatsCache == nil || !mon.StatsCache.Stale || mon.StatsCache.RestoredAt == 0 {
+		t.Errorf("expected stale restored cache, got %+v", mon.StatsCache)
+	}
+	if mon.Service == nil || mon.Service.StorageSummary.TotalAssetSize != 2048 {
+		t.Errorf("expected restored service info, got %+v", mon.Service)
+	}
+
+	cache.Reconcile()
+
+	mon = ts.GetMonitoring(t)
+	if mon.StatsCache == nil || mon.StatsCache.Stale || mon.StatsCache.ReconciledAt == 0 {
+		t.Errorf("expected reconciled cache, got %+v", mon.StatsCache)
+	}
+}
+
 // TestMonitoringServiceInfoMatchesTopicsServiceInfo verifies that the service
 // info returned by the monitoring endpoint is consistent with the topics endpoint.
 func TestMonitoringServiceInfoMatchesTopicsServiceInfo(t *testing.T) {
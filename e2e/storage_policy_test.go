@@ -0,0 +1,201 @@
+package e2e
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"image"
+	"io"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// setStoragePolicy PUTs a policy and returns the status and body.
+func (ts *TestServer) setStoragePolicy(t *testing.T, ext string, policy map[string]interface{}) (int, []byte) {
+	t.Helper()
+	resp, err := ts.RequestWithAPIKey(http.MethodPut, "/api/storage-policies/"+ext, ts.APIKey, policy)
+	if err != nil {
+		t.Fatalf("set storage policy failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, body
+}
+
+// TestStoragePolicy_MaxSizeAndTopicInfo verifies a per-extension size limit
+// applies to uploads and the effective policies are listed in topic info.
+func TestStoragePolicy_MaxSizeAndTopicInfo(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	if status, body := ts.setStoragePolicy(t, ".TXT", map[string]interface{}{"max_size_bytes": 16, "compression": true}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+
+	errResp := ts.UploadFileExpectError(t, "assets", "notes.txt", bytes.Repeat([]byte("x"), 17), "", http.StatusRequestEntityTooLarge)
+	if errResp.Code != constants.ErrCodeAssetTooLarge {
+		t.Errorf("expected %s, got %s", constants.ErrCodeAssetTooLarge, errResp.Code)
+	}
+	ts.UploadFileExpectSuccess(t, "assets", "notes.txt", []byte("short note"), "")
+	ts.UploadFileExpectSuccess(t, "assets", "mesh.obj", bytes.Repeat([]byte("v 0 0 0\n"), 8), "")
+
+	var effective StoragePolicy
+	if err := ts.GetJSON("/api/storage-policies/txt", &effective); err != nil {
+		t.Fatalf("get policy failed: %v", err)
+	}
+	if !effective.Compression || effective.MaxSizeBytes != 16 {
+		t.Errorf("unexpected effective policy: %+v", effective)
+	}
+
+	topics := ts.GetTopics(t)
+	if len(topics.Topics) != 1 || len(topics.Topics[0].StoragePolicies) != 2 {
+		t.Fatalf("expected two policies in topic info, got %+v", topics.Topics)
+	}
+	obj, txt := topics.Topics[0].StoragePolicies[0], topics.Topics[0].StoragePolicies[1]
+	if obj.Extension != "obj" || obj.Compression || obj.MaxSizeBytes <= 16 {
+		t.Errorf("expected default policy for obj, got %+v", obj)
+	}
+	if txt.Extension != "txt" || !txt.Compression || txt.MaxSizeBytes != 16 {
+		t.Errorf("expected configured policy for txt, got %+v", txt)
+	}
+
+	// Invalid policies are rejected and removing one restores the default
+	if status, _ := ts.setStoragePolicy(t, "a.b", map[string]interface{}{}); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid extension, got %d", status)
+	}
+	if status, _ := ts.setStoragePolicy(t, "exe", map[string]interface{}{"scan": true}); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for scan without scanner, got %d", status)
+	}
+	resp, err := ts.DELETE("/api/storage-policies/txt")
+	if err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 for delete, got %d", resp.StatusCode)
+	}
+	ts.UploadFileExpectSuccess(t, "assets", "long.txt", bytes.Repeat([]byte("x"), 17), "")
+}
+
+// TestStoragePolicy_CompressedDownload verifies downloads are gzip-encoded
+// only for extensions with compression on and clients that accept it.
+func TestStoragePolicy_CompressedDownload(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	content := bytes.Repeat([]byte("compressible line\n"), 200)
+	upload := ts.UploadFileExpectSuccess(t, "assets", "log.txt", content, "")
+
+	download := func(acceptEncoding string) (*http.Response, []byte) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/assets/"+upload.Hash+"/download", nil)
+		req.Header.Set(constants.HeaderXAPIKey, ts.APIKey)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("download failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, body
+	}
+
+	if resp, _ := download("gzip"); resp.Header.Get("Content-Encoding") != "" {
+		t.Errorf("expected no encoding with compression off, got %q", resp.Header.Get("Content-Encoding"))
+	}
+
+	if status, body := ts.setStoragePolicy(t, "txt", map[string]interface{}{"compression": true}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+
+	resp, body := download("gzip, deflate")
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip encoding, got %q", resp.Header.Get("Content-Encoding"))
+	}
+	if len(body) >= len(content) {
+		t.Errorf("expected compressed body smaller than %d, got %d", len(content), len(body))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("invalid gzip body: %v", err)
+	}
+	plain, _ := io.ReadAll(zr)
+	if !bytes.Equal(plain, content) {
+		t.Error("decompressed body does not match the upload")
+	}
+
+	if resp, body := download("identity"); resp.Header.Get("Content-Encoding") != "" || !bytes.Equal(body, content) {
+		t.Errorf("expected identity download for clients without gzip, got %q", resp.Header.Get("Content-Encoding"))
+	}
+}
+
+// TestStoragePolicy_Preview verifies image previews are scaled down and
+// refused when the extension's policy disables them.
+func TestStoragePolicy_Preview(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "images")
+
+	upload := ts.UploadFileExpectSuccess(t, "images", "photo.png", testPNG(t, 400, 200), "")
+	path := "/api/assets/" + upload.Hash + "/preview?size=100"
+
+	resp, body := downloadBody(t, ts, path, ts.APIKey)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("invalid preview: %v", err)
+	}
+	if format != "png" || cfg.Width != 100 || cfg.Height != 50 {
+		t.Errorf("expected 100x50 png, got %dx%d %s", cfg.Width, cfg.Height, format)
+	}
+
+	if resp, _ := downloadBody(t, ts, "/api/assets/"+upload.Hash+"/preview?size=0", ts.APIKey); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid size, got %d", resp.StatusCode)
+	}
+
+	if status, body := ts.setStoragePolicy(t, "png", map[string]interface{}{"preview": false}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	resp, body = downloadBody(t, ts, path, ts.APIKey)
+	var errResp ErrorResponse
+	json.Unmarshal(body, &errResp)
+	if resp.StatusCode != http.StatusUnprocessableEntity || errResp.Code != constants.ErrCodePreviewUnavailable {
+		t.Errorf("expected 422 %s, got %d %s", constants.ErrCodePreviewUnavailable, resp.StatusCode, errResp.Code)
+	}
+}
+
+// TestStoragePolicy_ScanRejectsUpload verifies uploads of scanned extensions
+// are refused when the scanner flags them and nothing is stored.
+func TestStoragePolicy_ScanRejectsUpload(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "bin")
+	ts.App.Config.Scan.Command = []string{"sh", "-c", "if grep -q EICAR; then exit 1; fi"}
+
+	if status, body := ts.setStoragePolicy(t, "exe", map[string]interface{}{"scan": true}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+
+	errResp := ts.UploadFileExpectError(t, "bin", "tool.exe", []byte("X5O EICAR test payload"), "", http.StatusUnprocessableEntity)
+	if errResp.Code != constants.ErrCodeUploadScanRejected {
+		t.Errorf("expected %s, got %s", constants.ErrCodeUploadScanRejected, errResp.Code)
+	}
+	ts.UploadFileExpectSuccess(t, "bin", "clean.exe", []byte("harmless binary"), "")
+
+	// Extensions without a scan policy are not scanned
+	ts.UploadFileExpectSuccess(t, "bin", "readme.txt", []byte("mentions EICAR"), "")
+
+	var count int
+	if err := ts.GetTopicDB(t, "bin").QueryRow("SELECT COUNT(*) FROM assets").Scan(&count); err != nil {
+		t.Fatalf("count assets: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 stored assets, got %d", count)
+	}
+}
@@ -0,0 +1,153 @@
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+type syncDiffLine struct {
+	Hash          string `json:"hash"`
+	Status        string `json:"status"`
+	Topic         string `json:"topic"`
+	ExpectedTopic string `json:"expected_topic"`
+	Summary       *struct {
+		Total     int `json:"total"`
+		Present   int `json:"present"`
+		Missing   int `json:"missing"`
+		Elsewhere int `json:"elsewhere"`
+		Invalid   int `json:"invalid"`
+	} `json:"summary"`
+}
+
+// postSyncDiff sends a manifest and parses the NDJSON response.
+func (ts *TestServer) postSyncDiff(t *testing.T, query, contentType, apiKey string, body []byte) []syncDiffLine {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/sync/diff"+query, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	req.Header.Set(constants.HeaderContentType, contentType)
+	req.Header.Set(constants.HeaderXAPIKey, apiKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get(constants.HeaderContentType); ct != constants.ContentTypeNDJSON {
+		t.Errorf("expected content type %s, got %s", constants.ContentTypeNDJSON, ct)
+	}
+
+	var lines []syncDiffLine
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line syncDiffLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 || lines[len(lines)-1].Summary == nil {
+		t.Fatalf("expected a trailing summary line, got %+v", lines)
+	}
+	return lines
+}
+
+// TestSyncDiff verifies JSON, text and multipart manifests are diffed against
+// the index and hashes in other topics are reported as elsewhere.
+func TestSyncDiff(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "sync-a")
+	ts.CreateTopic(t, "sync-b")
+
+	inA := ts.UploadFileExpectSuccess(t, "sync-a", "a.bin", []byte("asset in a"), "")
+	inB := ts.UploadFileExpectSuccess(t, "sync-b", "b.bin", []byte("asset in b"), "")
+	missing := strings.Repeat("f", constants.HashLength)
+
+	jsonBody, _ := json.Marshal(map[string]interface{}{"hashes": []string{inA.Hash, inB.Hash, missing}})
+	textBody := []byte("# local store\n" + inA.Hash + "\n" + inB.Hash + "\n" + missing + "\n")
+
+	var multipartBody bytes.Buffer
+	mw := multipart.NewWriter(&multipartBody)
+	part, _ := mw.CreateFormFile(constants.SyncDiffManifestField, "manifest.txt")
+	part.Write(textBody)
+	mw.Close()
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{"json", constants.ContentTypeJSON, jsonBody},
+		{"text", constants.ContentTypeText, textBody},
+		{"multipart", mw.FormDataContentType(), multipartBody.Bytes()},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lines := ts.postSyncDiff(t, "?topic=sync-a", tc.contentType, ts.APIKey, tc.body)
+			if len(lines) != 4 {
+				t.Fatalf("expected 3 results and a summary, got %d lines", len(lines))
+			}
+
+			if lines[0].Status != constants.SyncDiffStatusPresent || lines[0].Topic != "sync-a" {
+				t.Errorf("asset in expected topic: %+v", lines[0])
+			}
+			if lines[1].Status != constants.SyncDiffStatusElsewhere || lines[1].Topic != "sync-b" {
+				t.Errorf("asset in other topic: %+v", lines[1])
+			}
+			if lines[2].Status != constants.SyncDiffStatusMissing {
+				t.Errorf("unknown asset: %+v", lines[2])
+			}
+
+			summary := lines[3].Summary
+			if summary.Total != 3 || summary.Present != 1 || summary.Elsewhere != 1 || summary.Missing != 1 {
+				t.Errorf("unexpected summary: %+v", summary)
+			}
+		})
+	}
+}
+
+// TestSyncDiff_TopicVisibility verifies callers restricted to some topics do
+// not learn the names of other topics.
+func TestSyncDiff_TopicVisibility(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "sync-open")
+	ts.CreateTopic(t, "sync-secret")
+
+	secret := ts.UploadFileExpectSuccess(t, "sync-secret", "s.bin", []byte("secret asset"), "")
+
+	user := ts.CreateTestUserWithGrants(t, "syncer", "syncer-password-123", []map[string]interface{}{
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_topics":["sync-open"]}`},
+	})
+
+	lines := ts.postSyncDiff(t, "?topic=sync-open", constants.ContentTypeText, user.APIKey, []byte(secret.Hash+"\n"))
+	if lines[0].Status != constants.SyncDiffStatusElsewhere {
+		t.Errorf("expected elsewhere, got %+v", lines[0])
+	}
+	if lines[0].Topic != "" {
+		t.Errorf("topic outside the caller's grants should be hidden, got %q", lines[0].Topic)
+	}
+
+	// Expected topic outside the grant is denied outright
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, "/api/sync/diff?topic=sync-secret", user.APIKey, []string{secret.Hash})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,108 @@
+package e2e
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// browse runs GET /api/topics/:name/assets with query as the raw query string.
+func (ts *TestServer) browse(t *testing.T, apiKey, topic, query string, expectedStatus int) services.AssetPage {
+	t.Helper()
+	var page services.AssetPage
+	ts.notificationRequest(t, http.MethodGet, "/api/topics/"+topic+"/assets?"+query, apiKey, nil, expectedStatus, &page)
+	return page
+}
+
+// browseAll follows next_cursor from the first page to the last and returns
+// the hashes in order.
+func (ts *TestServer) browseAll(t *testing.T, topic, query string) []string {
+	t.Helper()
+	var hashes []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatalf("pagination did not end")
+		}
+		page := ts.browse(t, ts.APIKey, topic, query+"&cursor="+url.QueryEscape(cursor), http.StatusOK)
+		for _, a := range page.Assets {
+			hashes = append(hashes, a.Hash)
+		}
+		if page.NextCursor == "" {
+			return hashes
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// TestTopicAssets_SortFilterAndPaginate verifies the topic asset browser
+// sorts, filters and pages through a topic with a stable cursor, counts the
+// matching assets and leaves out quarantined ones.
+func TestTopicAssets_SortFilterAndPaginate(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+
+	// Same created_at for a and b, so ties are broken across pages
+	a := ts.UploadFileExpectSuccess(t, "models", "alpha.glb", []byte("aaaa"), "").Hash
+	b := ts.UploadFileExpectSuccess(t, "models", "bravo.glb", []byte("bb"), "").Hash
+	c := ts.UploadFileExpectSuccess(t, "models", "charlie.png", []byte("cccccc"), "").Hash
+	d := ts.UploadFileExpectSuccess(t, "models", "delta.png", []byte("d"), "").Hash
+	db := ts.GetTopicDB(t, "models")
+	for hash, createdAt := range map[string]int{a: 1000, b: 1000, c: 2000, d: 3000} {
+		if _, err := db.Exec(`UPDATE assets SET created_at = ? WHERE asset_id = ?`, createdAt, hash); err != nil {
+			t.Fatalf("failed to set created_at: %v", err)
+		}
+	}
+	ts.SetMetadata(t, c, "reviewed", "yes")
+	ts.SetMetadata(t, b, "reviewed", "no")
+
+	first := ts.browse(t, ts.APIKey, "models", "limit=2", http.StatusOK)
+	if first.Total != 4 || len(first.Assets) != 2 || first.Assets[0].Hash != d || first.Assets[1].Hash != c || first.NextCursor == "" {
+		t.Fatalf("expected the two newest assets and a cursor, got %+v", first)
+	}
+	if got := ts.browseAll(t, "models", "limit=1&sort=created_at&order=asc"); len(got) != 4 || got[2] != c || got[3] != d {
+		t.Errorf("expected every asset once, oldest first, got %v", got)
+	}
+	if got := ts.browseAll(t, "models", "limit=1&sort=size"); len(got) != 4 || got[0] != c || got[1] != a || got[2] != b || got[3] != d {
+		t.Errorf("expected largest first, got %v", got)
+	}
+	if got := ts.browseAll(t, "models", "limit=3&sort=name"); len(got) != 4 || got[0] != a || got[3] != d {
+		t.Errorf("expected names in ascending order, got %v", got)
+	}
+
+	if page := ts.browse(t, ts.APIKey, "models", "extension=PNG", http.StatusOK); page.Total != 2 || len(page.Assets) != 2 {
+		t.Errorf("expected the two png assets, got %+v", page)
+	}
+	if page := ts.browse(t, ts.APIKey, "models", "has_key=reviewed&since=1500", http.StatusOK); page.Total != 1 || page.Assets[0].Hash != c {
+		t.Errorf("expected only the reviewed asset created after 1500, got %+v", page)
+	}
+	if page := ts.browse(t, ts.APIKey, "models", "since=1000&until=2000&sort=name&order=desc", http.StatusOK); page.Total != 3 || page.Assets[0].Hash != c {
+		t.Errorf("expected the date range to include both bounds, got %+v", page)
+	}
+
+	// Metadata key changes are reflected
+	ts.DeleteMetadata(t, b, "reviewed")
+	if page := ts.browse(t, ts.APIKey, "models", "has_key=reviewed", http.StatusOK); page.Total != 1 {
+		t.Errorf("expected the deleted key to stop matching, got %+v", page)
+	}
+
+	for _, query := range []string{"sort=hash", "order=up", "since=yesterday", "since=3000&until=1000", "cursor=garbage", "sort=size&cursor=" + first.NextCursor} {
+		ts.browse(t, ts.APIKey, "models", query, http.StatusBadRequest)
+	}
+
+	if status, body := ts.postAsset(t, d, "quarantine", map[string]string{"reason": "review"}); status != http.StatusOK {
+		t.Fatalf("quarantine failed: %d %s", status, body)
+	}
+	if got := ts.browseAll(t, "models", "limit=2"); len(got) != 3 || got[0] != c {
+		t.Errorf("expected the quarantined asset to be left out, got %v", got)
+	}
+
+	viewer := ts.CreateTestUserWithGrants(t, "viewer", "ViewerPass123!", []map[string]interface{}{
+		{"action": constants.AuthActionQuery, "constraints_json": `{"allowed_topics":["other"]}`},
+	})
+	ts.browse(t, viewer.APIKey, "models", "", http.StatusForbidden)
+}
@@ -0,0 +1,96 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// createTopicStatus POSTs /api/topics and returns the status and error code.
+func (ts *TestServer) createTopicStatus(t *testing.T, name string) (int, string) {
+	t.Helper()
+	resp, err := ts.POST("/api/topics", map[string]string{"name": name})
+	if err != nil {
+		t.Fatalf("create topic request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var errResp ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	return resp.StatusCode, errResp.Code
+}
+
+// TestTopicCreation_ReservationHeld verifies a name reserved by another
+// creation is refused until the reservation is released or expires.
+func TestTopicCreation_ReservationHeld(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	now := time.Now().Unix()
+	if _, err := ts.App.OrchestratorDB.Exec(`INSERT INTO topic_reservations (name, token, reserved_at, expires_at) VALUES ('busy', 'other', ?, ?)`,
+		now, now+60); err != nil {
+		t.Fatalf("failed to reserve name: %v", err)
+	}
+
+	status, code := ts.createTopicStatus(t, "busy")
+	if status != http.StatusConflict || code != constants.ErrCodeTopicCreationInProgress {
+		t.Fatalf("expected 409 %s, got %d %s", constants.ErrCodeTopicCreationInProgress, status, code)
+	}
+	if _, err := os.Stat(filepath.Join(ts.WorkDir, "busy")); !os.IsNotExist(err) {
+		t.Error("refused creation touched the filesystem")
+	}
+
+	// A reservation left by a creator that died expires
+	if _, err := ts.App.OrchestratorDB.Exec(`UPDATE topic_reservations SET expires_at = ? WHERE name = 'busy'`, now-1); err != nil {
+		t.Fatalf("failed to expire reservation: %v", err)
+	}
+	ts.CreateTopic(t, "busy")
+
+	var remaining int
+	ts.App.OrchestratorDB.QueryRow(`SELECT COUNT(*) FROM topic_reservations`).Scan(&remaining)
+	if remaining != 0 {
+		t.Errorf("expected the reservation released after creation, %d left", remaining)
+	}
+	if status, code := ts.createTopicStatus(t, "busy"); status != http.StatusConflict || code != constants.ErrCodeTopicAlreadyExists {
+		t.Errorf("expected 409 %s for an existing topic, got %d %s", constants.ErrCodeTopicAlreadyExists, status, code)
+	}
+}
+
+// TestTopicCreation_RetryAfterInterrupted verifies the partial folder of an
+// interrupted creation is removed by a retry, while a foreign folder of the
+// same name is left alone.
+func TestTopicCreation_RetryAfterInterrupted(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+
+	internalPath := filepath.Join(ts.WorkDir, "partial", constants.InternalDir)
+	if err := os.MkdirAll(internalPath, constants.DirPermissions); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(internalPath, constants.TopicCreatingMarker), nil, constants.FilePermissions)
+	os.WriteFile(filepath.Join(internalPath, "partial.db"), []byte("half-written"), constants.FilePermissions)
+
+	ts.CreateTopic(t, "partial")
+	if _, err := os.Stat(filepath.Join(internalPath, constants.TopicCreatingMarker)); !os.IsNotExist(err) {
+		t.Error("creation marker left behind")
+	}
+	content := []byte("content after retry")
+	hash := ts.UploadFileExpectSuccess(t, "partial", "file.bin", content, "").Hash
+	if got := ts.DownloadAsset(t, hash); string(got) != string(content) {
+		t.Errorf("recreated topic not functional: %q", got)
+	}
+
+	if err := os.MkdirAll(filepath.Join(ts.WorkDir, "foreign"), constants.DirPermissions); err != nil {
+		t.Fatal(err)
+	}
+	if status, code := ts.createTopicStatus(t, "foreign"); status != http.StatusConflict || code != constants.ErrCodeTopicAlreadyExists {
+		t.Errorf("expected 409 %s for a foreign folder, got %d %s", constants.ErrCodeTopicAlreadyExists, status, code)
+	}
+	if _, err := os.Stat(filepath.Join(ts.WorkDir, "foreign")); err != nil {
+		t.Errorf("foreign folder removed: %v", err)
+	}
+}
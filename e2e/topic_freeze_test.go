@@ -0,0 +1,112 @@
+package e2e
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// freezeTopic POSTs to the freeze or unfreeze endpoint of a topic and
+// returns the status and body.
+func (ts *TestServer) freezeTopic(t *testing.T, topic, apiKey string, frozen bool) (int, []byte) {
+	t.Helper()
+	path := "/api/topics/" + topic + "/unfreeze"
+	if frozen {
+		path = "/api/topics/" + topic + "/freeze"
+	}
+	resp, err := ts.RequestWithAPIKey(http.MethodPost, path, apiKey, nil)
+	if err != nil {
+		t.Fatalf("freeze request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, body
+}
+
+// TestTopicFreeze_RefusesWritesKeepsReads verifies a frozen topic refuses
+// uploads and metadata writes with 409 while downloads and queries keep
+// working, until it is unfrozen.
+func TestTopicFreeze_RefusesWritesKeepsReads(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+	ts.CreateTopic(t, "drafts")
+
+	content := []byte("finalized dataset entry")
+	upload := ts.UploadFileExpectSuccess(t, "assets", "entry.bin", content, "")
+	draft := ts.UploadFileExpectSuccess(t, "drafts", "draft.bin", []byte("work in progress"), "")
+
+	if status, body := ts.freezeTopic(t, "assets", ts.APIKey, true); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+
+	errResp := ts.UploadFileExpectError(t, "assets", "late.bin", []byte("too late"), "", http.StatusConflict)
+	if errResp.Code != constants.ErrCodeTopicFrozen {
+		t.Errorf("expected %s for upload, got %s", constants.ErrCodeTopicFrozen, errResp.Code)
+	}
+	errResp = ts.SetMetadataExpectError(t, upload.Hash, "label", "final", http.StatusConflict)
+	if errResp.Code != constants.ErrCodeTopicFrozen {
+		t.Errorf("expected %s for metadata, got %s", constants.ErrCodeTopicFrozen, errResp.Code)
+	}
+
+	// A batch touching the frozen topic is refused as a whole
+	errResp = ts.BatchSetMetadataExpectError(t, BatchMetadataRequest{Operations: []BatchMetadataOperation{
+		{Hash: draft.Hash, Op: constants.BatchMetadataOpSet, Key: "label", Value: "draft"},
+		{Hash: upload.Hash, Op: constants.BatchMetadataOpSet, Key: "label", Value: "final"},
+	}}, http.StatusConflict)
+	if errResp.Code != constants.ErrCodeTopicFrozen {
+		t.Errorf("expected %s for batch, got %s", constants.ErrCodeTopicFrozen, errResp.Code)
+	}
+	if got := ts.GetAssetMetadata(t, draft.Hash); got["label"] != nil {
+		t.Errorf("refused batch should write nothing, got %v", got)
+	}
+
+	if got := ts.DownloadAsset(t, upload.Hash); string(got) != string(content) {
+		t.Errorf("expected frozen asset to download, got %q", got)
+	}
+	ts.ExecuteQuery(t, "count", []string{"assets"}, nil)
+	ts.SetMetadata(t, draft.Hash, "label", "draft")
+
+	topics := ts.GetTopics(t)
+	for _, topic := range topics.Topics {
+		if topic.Frozen != (topic.Name == "assets") {
+			t.Errorf("unexpected frozen flag for %s: %v", topic.Name, topic.Frozen)
+		}
+	}
+
+	if status, body := ts.freezeTopic(t, "assets", ts.APIKey, false); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	ts.UploadFileExpectSuccess(t, "assets", "late.bin", []byte("too late"), "")
+	ts.SetMetadata(t, upload.Hash, "label", "final")
+}
+
+// TestTopicFreeze_RequiresCanFreeze verifies freezing needs a manage_topics
+// grant with can_freeze.
+func TestTopicFreeze_RequiresCanFreeze(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	creator := ts.CreateTestUserWithGrants(t, "topiccreator", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionManageTopics, "constraints_json": `{"can_create":true}`},
+	})
+	curator := ts.CreateTestUserWithGrants(t, "curator", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionManageTopics, "constraints_json": `{"can_freeze":true,"allowed_topics":["assets"]}`},
+	})
+
+	if status, body := ts.freezeTopic(t, "assets", creator.APIKey, true); status != http.StatusForbidden {
+		t.Errorf("expected 403 without can_freeze, got %d: %s", status, body)
+	}
+	if status, body := ts.freezeTopic(t, "assets", curator.APIKey, true); status != http.StatusOK {
+		t.Fatalf("expected 200 with can_freeze, got %d: %s", status, body)
+	}
+	if status, body := ts.freezeTopic(t, "missing", ts.APIKey, true); status != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown topic, got %d: %s", status, body)
+	}
+	if status, body := ts.freezeTopic(t, "assets", curator.APIKey, false); status != http.StatusOK {
+		t.Errorf("expected 200 for unfreeze, got %d: %s", status, body)
+	}
+}
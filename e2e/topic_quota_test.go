@@ -0,0 +1,91 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// patchTopicQuota PATCHes the quota of a topic and returns the status and body.
+func (ts *TestServer) patchTopicQuota(t *testing.T, topic string, quota map[string]interface{}) (int, []byte) {
+	t.Helper()
+	resp, err := ts.RequestWithAPIKey(http.MethodPatch, "/api/topics/"+topic, ts.APIKey, map[string]interface{}{"quota": quota})
+	if err != nil {
+		t.Fatalf("patch topic failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, body
+}
+
+// TestTopicQuota_HardLimitRefusesUploads verifies uploads past a hard quota
+// are refused with 413 while duplicates still succeed, and quota usage is
+// reported in the topic stats.
+func TestTopicQuota_HardLimitRefusesUploads(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	status, body := ts.patchTopicQuota(t, "assets", map[string]interface{}{"soft_bytes": 10, "hard_bytes": 64, "hard_assets": 2})
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+
+	first := bytes.Repeat([]byte("a"), 40)
+	ts.UploadFileExpectSuccess(t, "assets", "first.bin", first, "")
+
+	errResp := ts.UploadFileExpectError(t, "assets", "big.bin", bytes.Repeat([]byte("b"), 25), "", http.StatusRequestEntityTooLarge)
+	if errResp.Code != constants.ErrCodeTopicQuotaExceeded {
+		t.Errorf("expected %s, got %s", constants.ErrCodeTopicQuotaExceeded, errResp.Code)
+	}
+	ts.UploadFileExpectSuccess(t, "assets", "second.bin", []byte("small"), "")
+
+	errResp = ts.UploadFileExpectError(t, "assets", "third.bin", []byte("tiny"), "", http.StatusRequestEntityTooLarge)
+	if errResp.Code != constants.ErrCodeTopicQuotaExceeded {
+		t.Errorf("expected %s for the asset limit, got %s", constants.ErrCodeTopicQuotaExceeded, errResp.Code)
+	}
+	// Duplicates store nothing new
+	ts.UploadFileExpectSuccess(t, "assets", "again.bin", first, "")
+
+	topics := ts.GetTopics(t)
+	if len(topics.Topics) != 1 {
+		t.Fatalf("expected one topic, got %+v", topics.Topics)
+	}
+	quota, ok := topics.Topics[0].Stats["quota"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected quota in topic stats, got %v", topics.Topics[0].Stats)
+	}
+	if quota["assets"] != float64(2) || quota["bytes"] != float64(45) || quota["soft_exceeded"] != true || quota["hard_reached"] != true {
+		t.Errorf("unexpected quota usage: %v", quota)
+	}
+
+	// Raising the limit lets uploads through again
+	if status, body := ts.patchTopicQuota(t, "assets", map[string]interface{}{"hard_assets": 0}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	ts.UploadFileExpectSuccess(t, "assets", "third.bin", []byte("tiny"), "")
+}
+
+// TestTopicQuota_RejectsInvalid verifies invalid quotas and unknown topics
+// are rejected.
+func TestTopicQuota_RejectsInvalid(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	status, body := ts.patchTopicQuota(t, "assets", map[string]interface{}{"soft_assets": 5, "hard_assets": 4})
+	if status != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", status, body)
+	}
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Code != constants.ErrCodeInvalidTopicQuota {
+		t.Errorf("expected %s, got %s", constants.ErrCodeInvalidTopicQuota, body)
+	}
+	if status, _ := ts.patchTopicQuota(t, "missing", map[string]interface{}{"hard_assets": 1}); status != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown topic, got %d", status)
+	}
+}
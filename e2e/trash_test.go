@@ -0,0 +1,178 @@
+package e2e
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// trashEntry mirrors an entry of /api/trash.
+type trashEntry struct {
+	Hash       string `json:"hash"`
+	Topic      string `json:"topic"`
+	OriginName string `json:"origin_name"`
+	Size       int64  `json:"size"`
+	TrashedBy  string `json:"trashed_by"`
+	TrashedAt  int64  `json:"trashed_at"`
+	PurgeAt    *int64 `json:"purge_at"`
+}
+
+func (ts *TestServer) listTrash(t *testing.T) []trashEntry {
+	t.Helper()
+	var result struct {
+		Entries []trashEntry `json:"entries"`
+	}
+	if err := ts.GetJSON("/api/trash", &result); err != nil {
+		t.Fatalf("list trash failed: %v", err)
+	}
+	return result.Entries
+}
+
+// restoreAsset POSTs /api/trash/:hash/restore and returns the status.
+func (ts *TestServer) restoreAsset(t *testing.T, hash string) int {
+	t.Helper()
+	resp, err := ts.POST("/api/trash/"+hash+"/restore", nil)
+	if err != nil {
+		t.Fatalf("restore request failed: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// TestTrash_DeleteRestorePurge verifies a deleted asset is withheld while in
+// the trash, served again once restored, and gone once purged.
+func TestTrash_DeleteRestorePurge(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	trashed := ts.UploadFileExpectSuccess(t, "assets", "old.bin", []byte("outdated content"), "").Hash
+	kept := ts.UploadFileExpectSuccess(t, "assets", "kept.bin", []byte("current content"), "").Hash
+
+	if status, body := ts.deleteRequest(t, "/api/assets/"+trashed); status != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", status, body)
+	}
+
+	errResp := ts.DownloadAssetExpectError(t, trashed, http.StatusGone)
+	if errResp.Code != constants.ErrCodeAssetTrashed {
+		t.Errorf("expected %s, got %s", constants.ErrCodeAssetTrashed, errResp.Code)
+	}
+	ts.DownloadAsset(t, kept)
+
+	if result := ts.ExecuteQuery(t, "recent-imports", nil, nil); result.RowCount != 1 {
+		t.Errorf("expected 1 row without trashed assets, got %d", result.RowCount)
+	}
+	bulkErr := ts.BulkDownloadExpectError(t, BulkDownloadRequest{Mode: "ids", AssetIDs: []string{trashed}}, http.StatusBadRequest)
+	if bulkErr.Code != constants.ErrCodeBulkDownloadEmpty {
+		t.Errorf("expected %s for a trashed-only export, got %s", constants.ErrCodeBulkDownloadEmpty, bulkErr.Code)
+	}
+
+	entries := ts.listTrash(t)
+	if len(entries) != 1 || entries[0].Hash != trashed || entries[0].OriginName != "old" ||
+		entries[0].TrashedBy != constants.AuthBootstrapUsername || entries[0].PurgeAt == nil {
+		t.Fatalf("unexpected trash list: %+v", entries)
+	}
+	wantPurgeAt := entries[0].TrashedAt + int64(constants.TrashDefaultRetentionDays*24*60*60)
+	if *entries[0].PurgeAt != wantPurgeAt {
+		t.Errorf("expected purge_at %d, got %d", wantPurgeAt, *entries[0].PurgeAt)
+	}
+
+	// Restore serves it again
+	if status := ts.restoreAsset(t, trashed); status != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d", status)
+	}
+	if status := ts.restoreAsset(t, trashed); status != http.StatusConflict {
+		t.Errorf("second restore: expected 409, got %d", status)
+	}
+	if got := ts.DownloadAsset(t, trashed); string(got) != "outdated content" {
+		t.Errorf("restored asset content mismatch: %q", got)
+	}
+
+	// Purging needs the asset in the trash first
+	if status, _ := ts.deleteRequest(t, "/api/trash/"+kept); status != http.StatusConflict {
+		t.Errorf("purge of an asset not in the trash: expected 409, got %d", status)
+	}
+	if status, body := ts.deleteAsset(t, trashed); status != http.StatusOK {
+		t.Fatalf("delete and purge: expected 200, got %d: %s", status, body)
+	}
+	ts.DownloadAssetExpectError(t, trashed, http.StatusNotFound)
+	if len(ts.listTrash(t)) != 0 {
+		t.Error("purged asset still listed in the trash")
+	}
+	if status := ts.compactionStatus(t, "assets"); status.Tombstones != 1 {
+		t.Errorf("expected 1 tombstone after the purge, got %d", status.Tombstones)
+	}
+
+	if n := ts.countAudit(t, constants.AuditActionAssetTrashed); n != 2 {
+		t.Errorf("expected 2 %s audit entries, got %d", constants.AuditActionAssetTrashed, n)
+	}
+	if n := ts.countAudit(t, constants.AuditActionAssetRestored); n != 1 {
+		t.Errorf("expected 1 %s audit entry, got %d", constants.AuditActionAssetRestored, n)
+	}
+	if n := ts.countAudit(t, constants.AuditActionAssetDeleted); n != 1 {
+		t.Errorf("expected 1 %s audit entry, got %d", constants.AuditActionAssetDeleted, n)
+	}
+}
+
+// TestTrash_UploadRestores verifies uploading trashed content again takes it
+// out of the trash.
+func TestTrash_UploadRestores(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	hash := ts.UploadFileExpectSuccess(t, "assets", "doc.txt", []byte("document"), "").Hash
+	if status, body := ts.deleteRequest(t, "/api/assets/"+hash); status != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", status, body)
+	}
+
+	if result := ts.UploadFileExpectSuccess(t, "assets", "doc.txt", []byte("document"), ""); !result.Skipped {
+		t.Error("expected the trashed content to be deduplicated")
+	}
+	if got := ts.DownloadAsset(t, hash); string(got) != "document" {
+		t.Errorf("content mismatch after re-upload: %q", got)
+	}
+	if len(ts.listTrash(t)) != 0 {
+		t.Error("re-uploaded asset still listed in the trash")
+	}
+}
+
+// TestTrash_RetentionPurgesExpired verifies assets trashed longer than
+// trash.retention_days are deleted permanently by the scheduled purge.
+func TestTrash_RetentionPurgesExpired(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "assets")
+
+	expired := ts.UploadFileExpectSuccess(t, "assets", "expired.bin", []byte("expired content"), "").Hash
+	recent := ts.UploadFileExpectSuccess(t, "assets", "recent.bin", []byte("recent content"), "").Hash
+	for _, hash := range []string{expired, recent} {
+		if status, body := ts.deleteRequest(t, "/api/assets/"+hash); status != http.StatusOK {
+			t.Fatalf("delete: expected 200, got %d: %s", status, body)
+		}
+	}
+
+	ts.App.Config.Trash.RetentionDays = 7
+	eightDaysAgo := time.Now().Add(-8 * 24 * time.Hour).Unix()
+	if _, err := ts.GetOrchestratorDB(t).Exec("UPDATE asset_trash SET trashed_at = ? WHERE hash = ?", eightDaysAgo, expired); err != nil {
+		t.Fatalf("failed to age trash entry: %v", err)
+	}
+
+	purged, err := ts.App.Services.Trash.PurgeExpired()
+	if err != nil {
+		t.Fatalf("PurgeExpired failed: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 asset purged, got %d", purged)
+	}
+
+	ts.DownloadAssetExpectError(t, expired, http.StatusNotFound)
+	ts.DownloadAssetExpectError(t, recent, http.StatusGone)
+	if entries := ts.listTrash(t); len(entries) != 1 || entries[0].Hash != recent {
+		t.Errorf("expected only the recent asset in the trash, got %+v", entries)
+	}
+}
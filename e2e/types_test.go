@@ -3,6 +3,8 @@ package e2e
 // UploadResponse represents the JSON response from asset upload
 type UploadResponse struct {
 	Hash          string `json:"hash"`
+	Status        string `json:"status"`
+	Reason        string `json:"reason"`
 	Skipped       bool   `json:"skipped"`
 	ExistingTopic string `json:"existing_topic,omitempty"`
 	Blob          string `json:"blob,omitempty"`
@@ -15,6 +17,8 @@ type QueryResponse struct {
 	RowCount int             `json:"row_count"`
 	Columns  []string        `json:"columns"`
 	Rows     [][]interface{} `json:"rows"`
+
+	Truncated bool `json:"truncated"`
 }
 
 // TopicInfo represents a single topic in the topics list
@@ -23,6 +27,21 @@ type TopicInfo struct {
 	Healthy bool                   `json:"healthy"`
 	Error   string                 `json:"error,omitempty"`
 	Stats   map[string]interface{} `json:"stats,omitempty"`
+
+	IntegrityIssues int `json:"integrity_issues,omitempty"`
+
+	StoragePolicies []StoragePolicy `json:"storage_policies,omitempty"`
+
+	Frozen bool `json:"frozen,omitempty"`
+}
+
+// StoragePolicy is the effective storage policy of one extension
+type StoragePolicy struct {
+	Extension    string `json:"extension"`
+	Compression  bool   `json:"compression"`
+	Preview      bool   `json:"preview"`
+	Scan         bool   `json:"scan"`
+	MaxSizeBytes int64  `json:"max_size_bytes"`
 }
 
 // TopicsResponse represents the JSON response from GET /api/topics
@@ -87,6 +106,33 @@ type BulkDownloadRequest struct {
 	AssetIDs        []string               `json:"asset_ids,omitempty"`
 	IncludeMetadata bool                   `json:"include_metadata"`
 	FilenameFormat  string                 `json:"filename_format,omitempty"`
+	Collection      string                 `json:"collection,omitempty"`
+	CollectionPaths bool                   `json:"collection_paths,omitempty"`
+	Recipients      []BulkRecipient        `json:"recipients,omitempty"`
+	Metadata        string                 `json:"metadata,omitempty"`
+	MetadataKeys    []string               `json:"metadata_keys,omitempty"`
+	Destination     string                 `json:"destination,omitempty"`
+	ArchiveFormat   string                 `json:"archive_format,omitempty"`
+}
+
+// BulkRecipient is a public key an encrypted bulk download is wrapped to
+type BulkRecipient struct {
+	ID        string `json:"id"`
+	PublicKey string `json:"public_key"`
+}
+
+// BulkKeysManifest represents the keys.json content of an encrypted ZIP
+type BulkKeysManifest struct {
+	Scheme     string `json:"scheme"`
+	Recipients []struct {
+		ID                 string `json:"id"`
+		EphemeralPublicKey string `json:"ephemeral_public_key"`
+	} `json:"recipients"`
+	Entries []struct {
+		Path        string            `json:"path"`
+		Hash        string            `json:"hash"`
+		WrappedKeys map[string]string `json:"wrapped_keys"`
+	} `json:"entries"`
 }
 
 // BulkDownloadManifest represents the manifest.json content in ZIP
@@ -95,6 +141,9 @@ type BulkDownloadManifest struct {
 	AssetCount      int                      `json:"asset_count"`
 	TotalSize       int64                    `json:"total_size"`
 	IncludeMetadata bool                     `json:"include_metadata"`
+	MetadataMode    string                   `json:"metadata_mode,omitempty"`
+	MetadataKeys    []string                 `json:"metadata_keys,omitempty"`
+	Encrypted       bool                     `json:"encrypted,omitempty"`
 	Assets          []BulkDownloadAssetInfo  `json:"assets"`
 	FailedAssets    []BulkDownloadFailedInfo `json:"failed_assets,omitempty"`
 }
@@ -267,6 +316,43 @@ type MonitoringResponse struct {
 	Application MonitoringApplication `json:"application"`
 	Logs        MonitoringLogs        `json:"logs"`
 	Service     *ServiceInfo          `json:"service,omitempty"`
+	StatsCache  *StatsCacheStatus     `json:"stats_cache,omitempty"`
+	AssetCache  *AssetCacheStatus     `json:"asset_cache,omitempty"`
+	AuditQueue  *AuditQueueStatus     `json:"audit_queue,omitempty"`
+}
+
+// AuditQueueStatus reports the audit write queue depth and counters
+type AuditQueueStatus struct {
+	Depth          int    `json:"depth"`
+	Capacity       int    `json:"capacity"`
+	OverflowPolicy string `json:"overflow_policy"`
+	Written        uint64 `json:"written"`
+	Dropped        uint64 `json:"dropped"`
+	Failed         uint64 `json:"failed"`
+	Retries        uint64 `json:"retries"`
+	Blocked        uint64 `json:"blocked"`
+	LastError      string `json:"last_error,omitempty"`
+}
+
+// AssetCacheStatus reports the in-memory asset cache size and hit ratio
+type AssetCacheStatus struct {
+	Enabled       bool    `json:"enabled"`
+	Entries       int     `json:"entries"`
+	Bytes         int64   `json:"bytes"`
+	MaxBytes      int64   `json:"max_bytes"`
+	MaxAssetBytes int64   `json:"max_asset_bytes"`
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRatio      float64 `json:"hit_ratio"`
+	Evictions     uint64  `json:"evictions"`
+}
+
+// StatsCacheStatus reports whether cached stats are awaiting reconciliation
+type StatsCacheStatus struct {
+	Initialized  bool  `json:"initialized"`
+	Stale        bool  `json:"stale"`
+	RestoredAt   int64 `json:"restored_at,omitempty"`
+	ReconciledAt int64 `json:"reconciled_at,omitempty"`
 }
 
 // MonitoringSystem holds OS-level resource metrics
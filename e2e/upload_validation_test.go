@@ -0,0 +1,90 @@
+package e2e
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// TestValidation_StrictAndLenientTopics verifies uploads of validated
+// extensions are rejected with their errors in strict topics, stored marked
+// invalid in lenient ones, stamped with the verdict, and counted in the
+// monitoring report.
+func TestValidation_StrictAndLenientTopics(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "models")
+	ts.CreateTopic(t, "drafts")
+
+	schema := filepath.Join(t.TempDir(), "gltf.schema.json")
+	os.WriteFile(schema, []byte(`{
+		"type": "object",
+		"required": ["asset"],
+		"properties": {"asset": {"type": "object", "required": ["version"]}}
+	}`), 0644)
+	ts.App.Config.Validation = config.ValidationConfig{
+		Validators:  map[string]config.PluginConfig{"gltf": {Type: constants.PluginValidatorJSON, Options: map[string]string{"schema": schema}}},
+		TopicModes:  map[string]string{"drafts": constants.ValidationModeLenient},
+		TimeoutSecs: constants.ValidationDefaultTimeoutSecs,
+	}
+
+	valid := ts.UploadFileExpectSuccess(t, "models", "scene.gltf", []byte(`{"asset": {"version": "2.0"}}`), "")
+	meta := ts.GetAssetMetadata(t, valid.Hash)["computed_metadata"].(map[string]interface{})
+	if meta[constants.MetadataKeyValidationStatus] != constants.ValidationStatusValid || meta[constants.MetadataKeyValidationValidator] != constants.PluginValidatorJSON {
+		t.Errorf("expected the valid verdict in the metadata, got %v", meta)
+	}
+
+	errResp := ts.UploadFileExpectError(t, "models", "broken.gltf", []byte(`{"asset": {}}`), "", http.StatusUnprocessableEntity)
+	if errResp.Code != constants.ErrCodeUploadInvalid || !strings.Contains(errResp.Message, `/asset: missing required property "version"`) {
+		t.Errorf("expected %s with the schema error, got %s: %s", constants.ErrCodeUploadInvalid, errResp.Code, errResp.Message)
+	}
+	ts.UploadFileExpectError(t, "models", "truncated.gltf", []byte(`{"asset": `), "", http.StatusUnprocessableEntity)
+
+	// Lenient topics keep invalid uploads and say why
+	draft := ts.UploadFileExpectSuccess(t, "drafts", "broken.gltf", []byte(`{"asset": {}}`), "")
+	meta = ts.GetAssetMetadata(t, draft.Hash)["computed_metadata"].(map[string]interface{})
+	if meta[constants.MetadataKeyValidationStatus] != constants.ValidationStatusInvalid ||
+		!strings.Contains(meta[constants.MetadataKeyValidationErrors].(string), "version") {
+		t.Errorf("expected the invalid verdict and errors in the metadata, got %v", meta)
+	}
+
+	// Extensions without a validator are stored as-is
+	other := ts.UploadFileExpectSuccess(t, "models", "notes.txt", []byte(`{"notes": true}`), "")
+	if meta, _ := ts.GetAssetMetadata(t, other.Hash)["computed_metadata"].(map[string]interface{}); meta[constants.MetadataKeyValidationStatus] != nil {
+		t.Error("expected no verdict on an extension without a validator")
+	}
+
+	// A validator that cannot run fails the upload closed
+	os.Remove(schema)
+	errResp = ts.UploadFileExpectError(t, "drafts", "later.gltf", []byte(`{"asset": {"version": "2.0"}}`), "", http.StatusServiceUnavailable)
+	if errResp.Code != constants.ErrCodeUploadValidationFailed {
+		t.Errorf("expected %s, got %s", constants.ErrCodeUploadValidationFailed, errResp.Code)
+	}
+
+	var mon struct {
+		Validation *services.ValidationStatus `json:"validation"`
+	}
+	ts.notificationRequest(t, http.MethodGet, "/api/monitoring", ts.APIKey, nil, http.StatusOK, &mon)
+	if mon.Validation == nil {
+		t.Fatal("expected validation in the monitoring report")
+	}
+	gltf := mon.Validation.ByExtension["gltf"]
+	if gltf.Validated != 5 || gltf.Valid != 1 || gltf.Invalid != 3 || gltf.Rejected != 2 || gltf.Failed != 1 || gltf.RejectionRate != 0.6 {
+		t.Errorf("unexpected gltf counts %+v", gltf)
+	}
+	if models := mon.Validation.ByTopic["models"]; models.Validated != 3 || models.Rejected != 2 {
+		t.Errorf("unexpected models counts %+v", models)
+	}
+	if drafts := mon.Validation.ByTopic["drafts"]; drafts.Invalid != 1 || drafts.Rejected != 0 || drafts.Failed != 1 {
+		t.Errorf("unexpected drafts counts %+v", drafts)
+	}
+	if mon.Validation.Totals.Validated != 5 || mon.Validation.Mode != constants.ValidationModeStrict {
+		t.Errorf("unexpected totals %+v in mode %s", mon.Validation.Totals, mon.Validation.Mode)
+	}
+}
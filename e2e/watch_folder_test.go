@@ -0,0 +1,295 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+func (ts *TestServer) watchFolderRequest(t *testing.T, method, path, apiKey string, body interface{}) (int, []byte) {
+	t.Helper()
+	resp, err := ts.RequestWithAPIKey(method, "/api/watch-folders"+path, apiKey, body)
+	if err != nil {
+		t.Fatalf("watch folder request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+func (ts *TestServer) scanWatchFolder(t *testing.T, name string) services.WatchFolder {
+	t.Helper()
+	status, body := ts.watchFolderRequest(t, http.MethodPost, "/"+name+"/scan", ts.APIKey, nil)
+	if status != http.StatusOK {
+		t.Fatalf("scan failed with %d: %s", status, body)
+	}
+	var folder services.WatchFolder
+	if err := json.Unmarshal(body, &folder); err != nil {
+		t.Fatalf("failed to decode scan response: %v", err)
+	}
+	return folder
+}
+
+// watchFolderDir creates a directory under a fresh watch_folder_roots
+// entry, where watch folders may be set through the API.
+func (ts *TestServer) watchFolderDir(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	ts.App.Config.WatchFolderRoots = []string{root}
+	dir := filepath.Join(root, "drops")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// dropFile writes a file into a watch folder, settled unless fresh is set.
+func dropFile(t *testing.T, dir, rel string, content []byte, fresh bool) {
+	t.Helper()
+	path := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !fresh {
+		old := time.Now().Add(-2 * time.Minute)
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// TestWatchFolder_IngestDebounceAndQuarantine drops files into a watched
+// folder and checks they are stored, moved, left settling or quarantined.
+func TestWatchFolder_IngestDebounceAndQuarantine(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "drops")
+	dir := ts.watchFolderDir(t)
+
+	// Folders outside watch_folder_roots are refused, through symlinks too
+	outside := t.TempDir()
+	escape := filepath.Join(filepath.Dir(dir), "escape")
+	if err := os.Symlink(outside, escape); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bad := range []map[string]interface{}{
+		{"path": "relative/dir", "topic": "drops"},
+		{"path": ts.WorkDir, "topic": "drops"},
+		{"path": filepath.Join(dir, "missing"), "topic": "drops"},
+		{"path": dir, "topic": "drops", "after_ingest": "copy"},
+		{"path": outside, "topic": "drops"},
+		{"path": escape, "topic": "drops"},
+	} {
+		status, body := ts.watchFolderRequest(t, http.MethodPut, "/drops", ts.APIKey, bad)
+		if status != http.StatusBadRequest || !strings.Contains(string(body), constants.ErrCodeInvalidWatchFolder) {
+			t.Errorf("expected %v to be rejected, got %d: %s", bad, status, body)
+		}
+	}
+	if status, body := ts.watchFolderRequest(t, http.MethodPut, "/drops", ts.APIKey, map[string]interface{}{
+		"path": dir, "topic": "drops", "settle_secs": 60,
+	}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	if status, body := ts.setStoragePolicy(t, "txt", map[string]interface{}{"max_size_bytes": 16}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+
+	dropFile(t, dir, "alpha.txt", []byte("alpha"), false)
+	dropFile(t, dir, "textures/wood/oak.png", []byte("oak texture"), false)
+	dropFile(t, dir, "copying.txt", []byte("still copying"), true)
+	dropFile(t, dir, ".partial", []byte("hidden"), false)
+	dropFile(t, dir, "large.txt", bytes.Repeat([]byte("x"), 17), false)
+
+	folder := ts.scanWatchFolder(t, "drops")
+	if folder.Stats.Ingested != 2 || folder.Stats.Failed != 1 || folder.Stats.Pending != 1 || folder.Stats.Quarantined != 1 {
+		t.Errorf("unexpected stats after first scan: %+v", folder.Stats)
+	}
+	if len(folder.Failures) != 1 || folder.Failures[0].Path != "large.txt" || !strings.Contains(folder.Failures[0].Error, "exceeds") {
+		t.Errorf("expected large.txt to be quarantined with the reason, got %+v", folder.Failures)
+	}
+	for _, rel := range []string{"processed/alpha.txt", "processed/textures/wood/oak.png", "copying.txt", ".partial", "failed/large.txt", "failed/large.txt.error"} {
+		if !fileExists(filepath.Join(dir, rel)) {
+			t.Errorf("expected %s to exist", rel)
+		}
+	}
+	for _, rel := range []string{"alpha.txt", "textures/wood/oak.png", "large.txt"} {
+		if fileExists(filepath.Join(dir, rel)) {
+			t.Errorf("expected %s to be moved", rel)
+		}
+	}
+
+	oak := blake3Hex([]byte("oak texture"))
+	if got := ts.DownloadAsset(t, oak); string(got) != "oak texture" {
+		t.Errorf("unexpected content of the ingested file: %q", got)
+	}
+	if meta := ts.assetMetadata(t, oak); meta.ComputedMetadata[constants.MetadataKeyRelativePath] != "textures/wood/oak.png" {
+		t.Errorf("expected the folder structure as relative_path, got %v", meta.ComputedMetadata)
+	}
+
+	// The same content again is deduplicated and kept next to the first copy
+	dropFile(t, dir, "alpha.txt", []byte("alpha"), false)
+	folder = ts.scanWatchFolder(t, "drops")
+	if folder.Stats.Deduplicated != 1 || !fileExists(filepath.Join(dir, "processed/alpha-1.txt")) {
+		t.Errorf("expected a deduplicated ingest moved to processed/alpha-1.txt, got %+v", folder.Stats)
+	}
+
+	// Failed files are retried once the cause is fixed
+	if status, body := ts.setStoragePolicy(t, "txt", map[string]interface{}{}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	status, body := ts.watchFolderRequest(t, http.MethodPost, "/drops/retry", ts.APIKey, nil)
+	if status != http.StatusOK || !strings.Contains(string(body), `"requeued":1`) {
+		t.Fatalf("expected one file requeued, got %d: %s", status, body)
+	}
+	folder = ts.scanWatchFolder(t, "drops")
+	if folder.Stats.Ingested != 3 || folder.Stats.Quarantined != 0 || len(folder.Failures) != 0 {
+		t.Errorf("expected the retried file to be ingested, got %+v %+v", folder.Stats, folder.Failures)
+	}
+	if fileExists(filepath.Join(dir, "failed/large.txt.error")) {
+		t.Error("expected the error note to be removed on retry")
+	}
+
+	// Delete mode removes ingested files
+	if status, body := ts.watchFolderRequest(t, http.MethodPut, "/drops", ts.APIKey, map[string]interface{}{
+		"path": dir, "topic": "drops", "settle_secs": 60, "after_ingest": constants.WatchFolderAfterDelete, "paused": true,
+	}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	dropFile(t, dir, "beta.txt", []byte("beta"), false)
+	ts.scanWatchFolder(t, "drops")
+	if fileExists(filepath.Join(dir, "beta.txt")) || fileExists(filepath.Join(dir, "processed/beta.txt")) {
+		t.Error("expected beta.txt to be deleted after ingestion")
+	}
+	if got := ts.DownloadAsset(t, blake3Hex([]byte("beta"))); string(got) != "beta" {
+		t.Errorf("unexpected content of the ingested file: %q", got)
+	}
+
+	var list struct {
+		Folders []services.WatchFolder `json:"folders"`
+	}
+	if err := ts.GetJSON("/api/watch-folders", &list); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(list.Folders) != 1 || !list.Folders[0].Paused || list.Folders[0].Stats.Ingested != 4 {
+		t.Errorf("unexpected folder list %+v", list.Folders)
+	}
+
+	viewer := ts.CreateTestUserWithGrants(t, "viewer", "secure-password-12345", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	if status, _ := ts.watchFolderRequest(t, http.MethodGet, "/drops", viewer.APIKey, nil); status != http.StatusForbidden {
+		t.Errorf("expected 403 without manage_config, got %d", status)
+	}
+
+	if status, body := ts.watchFolderRequest(t, http.MethodDelete, "/drops", ts.APIKey, nil); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	if status, _ := ts.watchFolderRequest(t, http.MethodGet, "/drops", ts.APIKey, nil); status != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", status)
+	}
+	if !fileExists(filepath.Join(dir, "copying.txt")) {
+		t.Error("expected files to be left in place when the folder is removed")
+	}
+}
+
+// TestWatchFolder_EventsTriggerScansAndReport drops a file into a watched
+// folder and checks it is ingested on the file system event, before any
+// periodic scan, and reported by monitoring and the audit log.
+func TestWatchFolder_EventsTriggerScansAndReport(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "drops")
+	dir := ts.watchFolderDir(t)
+
+	if status, body := ts.watchFolderRequest(t, http.MethodPut, "/drops", ts.APIKey, map[string]interface{}{
+		"path": dir, "topic": "drops", "settle_secs": 60,
+	}); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+
+	// Setting the folder scans it shortly after, which starts watching it
+	waitForWatchFolder(t, ts, "drops", func(f services.WatchFolder) bool { return f.Stats.Watching || f.Stats.LastScanAt > 0 })
+	if folder := ts.getWatchFolder(t, "drops"); !folder.Stats.Watching {
+		t.Skip("file system events are not available")
+	}
+
+	// Watched folders are rescanned only every minute, so this is the event
+	dropFile(t, dir, "models/crate.obj", []byte("v 0 0 0"), false)
+	folder := waitForWatchFolder(t, ts, "drops", func(f services.WatchFolder) bool { return f.Stats.Ingested == 1 })
+	if !fileExists(filepath.Join(dir, "processed/models/crate.obj")) {
+		t.Errorf("expected the ingested file to be moved, got %+v", folder.Stats)
+	}
+
+	var monitoring struct {
+		Watch *services.WatchFolderStatus `json:"watch_folders"`
+	}
+	if err := ts.GetJSON("/api/monitoring", &monitoring); err != nil {
+		t.Fatalf("monitoring failed: %v", err)
+	}
+	if w := monitoring.Watch; w == nil || !w.Events || w.Ingested != 1 || w.IngestedBytes != 7 || len(w.Folders) != 1 {
+		t.Errorf("unexpected watch folder monitoring: %+v", monitoring.Watch)
+	}
+
+	var entries AuditQueryResponse
+	deadline := time.Now().Add(5 * time.Second)
+	for len(entries.Entries) == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+		if err := ts.GetJSON("/api/audit?action="+constants.AuditActionWatchFolderIngested, &entries); err != nil {
+			t.Fatalf("audit query failed: %v", err)
+		}
+	}
+	if len(entries.Entries) != 1 {
+		t.Fatalf("expected one %s entry, got %d", constants.AuditActionWatchFolderIngested, len(entries.Entries))
+	}
+	details, _ := entries.Entries[0].Details.(map[string]interface{})
+	if details["folder"] != "drops" || details["trigger"] != constants.WatchFolderTriggerEvent || details["ingested"] != float64(1) {
+		t.Errorf("unexpected audit details: %v", entries.Entries[0].Details)
+	}
+}
+
+func (ts *TestServer) getWatchFolder(t *testing.T, name string) services.WatchFolder {
+	t.Helper()
+	status, body := ts.watchFolderRequest(t, http.MethodGet, "/"+name, ts.APIKey, nil)
+	if status != http.StatusOK {
+		t.Fatalf("get failed with %d: %s", status, body)
+	}
+	var folder services.WatchFolder
+	if err := json.Unmarshal(body, &folder); err != nil {
+		t.Fatalf("failed to decode folder: %v", err)
+	}
+	return folder
+}
+
+// waitForWatchFolder polls a folder until done accepts it.
+func waitForWatchFolder(t *testing.T, ts *TestServer, name string, done func(services.WatchFolder) bool) services.WatchFolder {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		folder := ts.getWatchFolder(t, name)
+		if done(folder) {
+			return folder
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("watch folder %s did not reach the expected state: %+v", name, folder.Stats)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,167 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+// testPNG returns a solid-colour PNG of the given size.
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: 20, G: 80, B: 160, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// enableWatermarks configures a text and a tiled watermark profile.
+func (ts *TestServer) enableWatermarks() {
+	ts.App.Config.Watermarks = map[string]config.WatermarkConfig{
+		"review": {Text: "REVIEW COPY", Position: constants.WatermarkPositionBottomRight, Opacity: 0.8, Scale: 0.5},
+		"draft":  {Text: "DRAFT", Position: constants.WatermarkPositionTile, Opacity: 0.5, Scale: 0.2},
+	}
+}
+
+// downloadBody downloads path with the given API key and returns the response and body.
+func downloadBody(t *testing.T, ts *TestServer, path, apiKey string) (*http.Response, []byte) {
+	t.Helper()
+	resp, err := ts.RequestWithAPIKey(http.MethodGet, path, apiKey, nil)
+	if err != nil {
+		t.Fatalf("download request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	return resp, body
+}
+
+// TestWatermark_PerRequestProfile verifies ?watermark= stamps image downloads
+// while the stored asset and plain downloads stay untouched.
+func TestWatermark_PerRequestProfile(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "marketing")
+	ts.enableWatermarks()
+
+	original := testPNG(t, 240, 120)
+	upload := ts.UploadFileExpectSuccess(t, "marketing", "hero.png", original, "")
+	path := "/api/assets/" + upload.Hash + "/download"
+
+	resp, body := downloadBody(t, ts, path+"?watermark=review", ts.APIKey)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get(constants.HeaderXWatermark); got != "review" {
+		t.Errorf("expected X-Watermark review, got %q", got)
+	}
+	if resp.Header.Get(constants.HeaderETag) != "" {
+		t.Error("watermarked response must not carry the asset ETag")
+	}
+	if bytes.Equal(body, original) {
+		t.Fatal("expected watermarked bytes to differ from the original")
+	}
+	img, err := png.Decode(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("watermarked body is not a png: %v", err)
+	}
+	if img.Bounds().Dx() != 240 || img.Bounds().Dy() != 120 {
+		t.Errorf("expected dimensions preserved, got %v", img.Bounds())
+	}
+
+	// The original is still served byte-for-byte under its hash
+	resp, body = downloadBody(t, ts, path, ts.APIKey)
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(body, original) {
+		t.Fatalf("expected pristine original, got status %d", resp.StatusCode)
+	}
+	if resp.Header.Get(constants.HeaderXWatermark) != "" {
+		t.Error("plain download must not report a watermark")
+	}
+	if resp.Header.Get(constants.HeaderETag) != `"`+upload.Hash+`"` {
+		t.Error("plain download must keep the asset ETag")
+	}
+
+	// Unknown profiles are rejected
+	resp, body = downloadBody(t, ts, path+"?watermark=nope", ts.APIKey)
+	var errResp ErrorResponse
+	json.Unmarshal(body, &errResp)
+	if resp.StatusCode != http.StatusBadRequest || errResp.Code != constants.ErrCodeWatermarkNotFound {
+		t.Errorf("expected 400 %s, got %d %s", constants.ErrCodeWatermarkNotFound, resp.StatusCode, errResp.Code)
+	}
+
+	// Non-image assets pass through unchanged
+	doc := ts.UploadFileExpectSuccess(t, "marketing", "brief.txt", []byte("campaign brief"), "")
+	resp, body = downloadBody(t, ts, "/api/assets/"+doc.Hash+"/download?watermark=review", ts.APIKey)
+	if resp.StatusCode != http.StatusOK || string(body) != "campaign brief" {
+		t.Errorf("expected non-image asset unchanged, got %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get(constants.HeaderXWatermark) != "" {
+		t.Error("non-image download must not report a watermark")
+	}
+}
+
+// TestWatermark_ForcedByGrantAndPublicMode verifies a watermark forced by a
+// download grant or by public mode applies regardless of the query parameter.
+func TestWatermark_ForcedByGrantAndPublicMode(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "marketing")
+	ts.enableWatermarks()
+
+	original := testPNG(t, 200, 200)
+	upload := ts.UploadFileExpectSuccess(t, "marketing", "hero.png", original, "")
+	path := "/api/assets/" + upload.Hash + "/download"
+
+	reviewer := ts.CreateTestUserWithGrants(t, "agency-reviewer", "ReviewerPass123!", []map[string]interface{}{
+		{
+			"action":           constants.AuthActionDownload,
+			"constraints_json": `{"watermark": "review"}`,
+		},
+	})
+
+	for _, query := range []string{"", "?watermark=draft"} {
+		resp, body := downloadBody(t, ts, path+query, reviewer.APIKey)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+		}
+		if got := resp.Header.Get(constants.HeaderXWatermark); got != "review" {
+			t.Errorf("query %q: expected forced profile review, got %q", query, got)
+		}
+		if bytes.Equal(body, original) {
+			t.Errorf("query %q: expected watermarked bytes", query)
+		}
+	}
+
+	// Anonymous public downloads get the public profile
+	ts.enablePublicMode(600, 100)
+	ts.App.Config.Public.MaxDownloadBytes = int64(len(original))
+	ts.App.Config.Public.Watermark = "draft"
+
+	resp, err := ts.UnauthenticatedGET(path)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(constants.HeaderXWatermark) != "draft" || bytes.Equal(body, original) {
+		t.Errorf("expected anonymous download watermarked with draft, got %d %q",
+			resp.StatusCode, resp.Header.Get(constants.HeaderXWatermark))
+	}
+}
@@ -0,0 +1,171 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/services"
+)
+
+// webhookReceiver records the deliveries it accepts and fails requests
+// while failing is set.
+type webhookReceiver struct {
+	mu         sync.Mutex
+	failing    bool
+	attempts   int
+	deliveries []services.WebhookPayload
+	headers    []http.Header
+	bodies     [][]byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.attempts++
+	if rcv.failing {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var payload services.WebhookPayload
+	json.Unmarshal(body, &payload)
+	rcv.deliveries = append(rcv.deliveries, payload)
+	rcv.headers = append(rcv.headers, r.Header.Clone())
+	rcv.bodies = append(rcv.bodies, body)
+}
+
+// runWebhooks flushes the audit log and runs one delivery tick at now.
+func (ts *TestServer) runWebhooks(now time.Time) {
+	ts.App.AuditLogger.Flush()
+	ts.App.Services.Webhooks.RunPending(now)
+}
+
+// TestWebhooks_DeliverSignedAuditEvents verifies selected audit entries are
+// posted once each, in order and signed, and that failures are retried.
+func TestWebhooks_DeliverSignedAuditEvents(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "before-hook")
+
+	rcv := &webhookReceiver{}
+	receiver := httptest.NewServer(rcv)
+	defer receiver.Close()
+
+	ts.notificationRequest(t, http.MethodPost, "/api/webhooks", ts.APIKey,
+		map[string]interface{}{"name": "bad", "url": "ftp://example.com"}, http.StatusBadRequest, nil)
+	ts.notificationRequest(t, http.MethodPost, "/api/webhooks", ts.APIKey,
+		map[string]interface{}{"name": "bad", "url": receiver.URL, "events": []string{"no_such_action"}}, http.StatusBadRequest, nil)
+
+	const secret = "pipeline-shared-secret"
+	var created struct {
+		Webhook database.Webhook `json:"webhook"`
+		Secret  string           `json:"secret"`
+	}
+	ts.notificationRequest(t, http.MethodPost, "/api/webhooks", ts.APIKey, map[string]interface{}{
+		"name":   "pipeline",
+		"url":    receiver.URL,
+		"secret": secret,
+		"events": []string{constants.AuditActionAddingFile, constants.AuditActionAddingTopic},
+	}, http.StatusOK, &created)
+	if created.Secret != secret || created.Webhook.ID == 0 || !created.Webhook.Active || len(created.Webhook.Events) != 2 {
+		t.Fatalf("unexpected created webhook: %+v", created)
+	}
+	hookPath := fmt.Sprintf("/api/webhooks/%d", created.Webhook.ID)
+
+	// Only entries logged after the webhook was created are delivered
+	ts.CreateTopic(t, "after-hook")
+	upload := ts.UploadFileExpectSuccess(t, "after-hook", "a.bin", []byte("webhook payload"), "")
+	ts.SetMetadata(t, upload.Hash, "label", "not selected")
+
+	now := time.Now()
+	ts.runWebhooks(now)
+	ts.runWebhooks(now)
+
+	rcv.mu.Lock()
+	if len(rcv.deliveries) != 2 {
+		rcv.mu.Unlock()
+		t.Fatalf("expected 2 deliveries, got %d: %+v", len(rcv.deliveries), rcv.deliveries)
+	}
+	if rcv.deliveries[0].Event != constants.AuditActionAddingTopic || rcv.deliveries[1].Event != constants.AuditActionAddingFile {
+		t.Errorf("expected adding_topic then adding_file, got %+v", rcv.deliveries)
+	}
+	var details struct {
+		Hash      string `json:"hash"`
+		TopicName string `json:"topic_name"`
+	}
+	json.Unmarshal(rcv.deliveries[1].Details, &details)
+	if details.Hash != upload.Hash || details.TopicName != "after-hook" || rcv.deliveries[1].WebhookID != created.Webhook.ID {
+		t.Errorf("unexpected adding_file payload: %+v", rcv.deliveries[1])
+	}
+	for i, h := range rcv.headers {
+		timestamp, _ := strconv.ParseInt(h.Get(constants.WebhookHeaderTimestamp), 10, 64)
+		if want := services.SignWebhookPayload(secret, timestamp, rcv.bodies[i]); h.Get(constants.WebhookHeaderSignature) != want {
+			t.Errorf("delivery %d: expected signature %s, got %s", i, want, h.Get(constants.WebhookHeaderSignature))
+		}
+		if h.Get(constants.WebhookHeaderEvent) != rcv.deliveries[i].Event || h.Get(constants.WebhookHeaderDelivery) == "" {
+			t.Errorf("delivery %d: unexpected headers %v", i, h)
+		}
+	}
+	rcv.failing = true
+	rcv.mu.Unlock()
+
+	// A failed delivery is retried after the backoff
+	ts.UploadFileExpectSuccess(t, "after-hook", "b.bin", []byte("retried payload"), "")
+	ts.runWebhooks(now)
+	ts.runWebhooks(now)
+
+	var status services.WebhookStatus
+	ts.notificationRequest(t, http.MethodGet, hookPath, ts.APIKey, nil, http.StatusOK, &status)
+	if status.Deliveries.Pending != 1 || status.Deliveries.Delivered != 2 || len(status.RecentDeliveries) != 3 {
+		t.Fatalf("expected 1 pending and 2 delivered, got %+v", status)
+	}
+	if d := status.RecentDeliveries[0]; d.Attempts != 1 || d.LastStatus != http.StatusInternalServerError || d.NextAttemptAt <= now.Unix() {
+		t.Errorf("expected one failed attempt scheduled for retry, got %+v", d)
+	}
+
+	rcv.mu.Lock()
+	rcv.failing = false
+	if rcv.attempts != 3 {
+		t.Errorf("expected no retry before the backoff, got %d attempts", rcv.attempts)
+	}
+	rcv.mu.Unlock()
+	ts.runWebhooks(now.Add(constants.WebhookRetryBaseDelay))
+
+	ts.notificationRequest(t, http.MethodGet, hookPath, ts.APIKey, nil, http.StatusOK, &status)
+	if status.Deliveries.Pending != 0 || status.Deliveries.Delivered != 3 || status.RecentDeliveries[0].Attempts != 2 {
+		t.Fatalf("expected the retry to be delivered, got %+v", status)
+	}
+
+	// Secrets are not listed, and changes are audited
+	var list map[string][]map[string]interface{}
+	ts.notificationRequest(t, http.MethodGet, "/api/webhooks", ts.APIKey, nil, http.StatusOK, &list)
+	if len(list["webhooks"]) != 1 || list["webhooks"][0]["secret"] != nil {
+		t.Errorf("expected one webhook without its secret, got %+v", list)
+	}
+	ts.notificationRequest(t, http.MethodPut, hookPath, ts.APIKey, map[string]interface{}{"active": false}, http.StatusOK, nil)
+	ts.notificationRequest(t, http.MethodDelete, hookPath, ts.APIKey, nil, http.StatusOK, nil)
+	ts.notificationRequest(t, http.MethodGet, hookPath, ts.APIKey, nil, http.StatusNotFound, nil)
+
+	ts.App.AuditLogger.Flush()
+	for _, action := range []string{constants.AuditActionWebhookCreated, constants.AuditActionWebhookUpdated, constants.AuditActionWebhookDeleted} {
+		var count int
+		ts.App.OrchestratorDB.QueryRow("SELECT COUNT(*) FROM audit_log WHERE action = ?", action).Scan(&count)
+		if count != 1 {
+			t.Errorf("expected one %s audit entry, got %d", action, count)
+		}
+	}
+
+	viewer := ts.CreateTestUserWithGrants(t, "viewer", "ViewerPass123!", []map[string]interface{}{
+		{"action": constants.AuthActionQuery},
+	})
+	ts.notificationRequest(t, http.MethodGet, "/api/webhooks", viewer.APIKey, nil, http.StatusForbidden, nil)
+}
@@ -0,0 +1,255 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+type workspaceTokenResponse struct {
+	ID            int64  `json:"id"`
+	Pipeline      string `json:"pipeline"`
+	Token         string `json:"token"`
+	TokenPrefix   string `json:"token_prefix"`
+	ExpiresAt     int64  `json:"expires_at"`
+	LastUsedAt    *int64 `json:"last_used_at"`
+	ExchangeCount int64  `json:"exchange_count"`
+	Status        string `json:"status"`
+}
+
+type jobTokenResponse struct {
+	Token     string   `json:"token"`
+	ExpiresAt int64    `json:"expires_at"`
+	Pipeline  string   `json:"pipeline"`
+	Actions   []string `json:"actions"`
+}
+
+// createWorkspaceToken issues a workspace token as admin.
+func (ts *TestServer) createWorkspaceToken(t *testing.T, body map[string]interface{}) workspaceTokenResponse {
+	t.Helper()
+	resp, err := ts.POST("/api/auth/workspace-tokens", body)
+	if err != nil {
+		t.Fatalf("create workspace token request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		t.Fatalf("expected 201, got %d: %s", resp.StatusCode, bodyBytes)
+	}
+	var created workspaceTokenResponse
+	json.NewDecoder(resp.Body).Decode(&created)
+	return created
+}
+
+// exchangeWorkspaceToken trades a workspace token for a job token and
+// returns the status code with the decoded response.
+func (ts *TestServer) exchangeWorkspaceToken(t *testing.T, token string, body map[string]interface{}) (int, jobTokenResponse) {
+	t.Helper()
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/auth/workspace-tokens/exchange", bytes.NewReader(jsonBody))
+	req.Header.Set(constants.HeaderAuthorization, constants.AuthBearerPrefix+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("exchange request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var job jobTokenResponse
+	json.NewDecoder(resp.Body).Decode(&job)
+	return resp.StatusCode, job
+}
+
+// uploadWithBearer uploads a file authenticated with a bearer token.
+func (ts *TestServer) uploadWithBearer(t *testing.T, token, topic, filename string, content []byte) int {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/topics/"+topic+"/assets", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set(constants.HeaderAuthorization, constants.AuthBearerPrefix+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("upload request failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// TestWorkspaceTokens_Lifecycle covers issuing a token, exchanging it for a
+// scoped job token, pipeline attribution in the audit log, listing with last
+// use and bulk revocation.
+func TestWorkspaceTokens_Lifecycle(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "builds")
+	ts.CreateTopic(t, "releases")
+
+	created := ts.createWorkspaceToken(t, map[string]interface{}{
+		"pipeline":    "nightly-ci",
+		"description": "Nightly build",
+		"ttl_hours":   24,
+		"scopes": []map[string]interface{}{
+			{"action": constants.AuthActionUpload, "constraints_json": `{"allowed_topics":["builds"]}`},
+		},
+	})
+	if created.Token == "" || created.Status != constants.AuthWorkspaceTokenStatusActive {
+		t.Fatalf("unexpected create response: %+v", created)
+	}
+	if created.ExpiresAt < time.Now().Add(23*time.Hour).Unix() {
+		t.Errorf("expected expiry about a day out, got %d", created.ExpiresAt)
+	}
+
+	// The workspace token itself is not accepted by the API
+	if status := ts.uploadWithBearer(t, created.Token, "builds", "direct.bin", []byte("direct")); status != http.StatusUnauthorized {
+		t.Errorf("expected 401 uploading with the workspace token, got %d", status)
+	}
+
+	status, job := ts.exchangeWorkspaceToken(t, created.Token, map[string]interface{}{"job": "build #42", "ttl_secs": 600})
+	if status != http.StatusOK || job.Token == "" || job.Pipeline != "nightly-ci" {
+		t.Fatalf("exchange failed: %d %+v", status, job)
+	}
+	if job.ExpiresAt > time.Now().Add(11*time.Minute).Unix() {
+		t.Errorf("expected the job token to expire within ttl_secs, got %d", job.ExpiresAt)
+	}
+
+	// Scoped: uploads to builds only, nothing else
+	if status := ts.uploadWithBearer(t, job.Token, "builds", "artifact.bin", []byte("artifact")); status != http.StatusOK {
+		t.Fatalf("expected 200 uploading to an allowed topic, got %d", status)
+	}
+	if status := ts.uploadWithBearer(t, job.Token, "releases", "artifact.bin", []byte("artifact")); status != http.StatusForbidden {
+		t.Errorf("expected 403 uploading to another topic, got %d", status)
+	}
+
+	// The pipeline's activity is attributed to it in the audit log
+	var auditResp struct {
+		Entries []map[string]interface{} `json:"entries"`
+	}
+	if err := ts.GetJSON("/api/audit?actor_type=pipeline", &auditResp); err != nil {
+		t.Fatalf("audit query failed: %v", err)
+	}
+	actions := map[string]bool{}
+	for _, e := range auditResp.Entries {
+		if e["username"] != "nightly-ci" {
+			t.Errorf("unexpected entry in pipeline filter: %v", e)
+		}
+		actions[e["action"].(string)] = true
+	}
+	if !actions[constants.AuditActionWorkspaceTokenExchanged] || !actions[constants.AuditActionAddingFile] {
+		t.Errorf("expected exchange and upload entries for the pipeline, got %v", actions)
+	}
+
+	// Listed with its last use; pipelines are not users
+	var list struct {
+		WorkspaceTokens []workspaceTokenResponse `json:"workspace_tokens"`
+	}
+	if err := ts.GetJSON("/api/auth/workspace-tokens?pipeline=nightly-ci", &list); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(list.WorkspaceTokens) != 1 || list.WorkspaceTokens[0].ExchangeCount != 1 || list.WorkspaceTokens[0].LastUsedAt == nil {
+		t.Fatalf("unexpected list: %+v", list.WorkspaceTokens)
+	}
+	if list.WorkspaceTokens[0].Token != "" {
+		t.Error("the plaintext token must not be listed")
+	}
+	var users struct {
+		Users []map[string]interface{} `json:"users"`
+	}
+	ts.GetJSON("/api/auth/users", &users)
+	for _, u := range users.Users {
+		if u["username"] == "nightly-ci" {
+			t.Error("pipeline account should not be listed among users")
+		}
+	}
+
+	// Bulk revocation by pipeline stops the job token and further exchanges
+	second := ts.createWorkspaceToken(t, map[string]interface{}{
+		"pipeline":  "nightly-ci",
+		"ttl_hours": 1,
+		"scopes":    []map[string]interface{}{{"action": constants.AuthActionDownload}},
+	})
+	var revoked struct {
+		Revoked []int64 `json:"revoked"`
+	}
+	if err := ts.PostJSON("/api/auth/workspace-tokens/revoke", map[string]interface{}{"pipeline": "nightly-ci"}, &revoked); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if len(revoked.Revoked) != 2 {
+		t.Fatalf("expected both tokens revoked, got %v", revoked.Revoked)
+	}
+	if status := ts.uploadWithBearer(t, job.Token, "builds", "late.bin", []byte("late")); status != http.StatusUnauthorized {
+		t.Errorf("expected 401 with a revoked job token, got %d", status)
+	}
+	if status, _ := ts.exchangeWorkspaceToken(t, second.Token, nil); status != http.StatusUnauthorized {
+		t.Errorf("expected 401 exchanging a revoked token, got %d", status)
+	}
+
+	ts.GetJSON("/api/auth/workspace-tokens", &list)
+	for _, wt := range list.WorkspaceTokens {
+		if wt.Status != constants.AuthWorkspaceTokenStatusRevoked {
+			t.Errorf("expected token %d revoked, got %s", wt.ID, wt.Status)
+		}
+	}
+}
+
+// TestWorkspaceTokens_Expiry verifies expired workspace and job tokens are
+// rejected.
+func TestWorkspaceTokens_Expiry(t *testing.T) {
+	ts := StartTestServer(t)
+	ts.ConfigureWorkDir(t)
+	ts.CreateTopic(t, "builds")
+
+	created := ts.createWorkspaceToken(t, map[string]interface{}{
+		"pipeline":  "deploy",
+		"ttl_hours": 1,
+		"scopes":    []map[string]interface{}{{"action": constants.AuthActionUpload}},
+	})
+	status, job := ts.exchangeWorkspaceToken(t, created.Token, nil)
+	if status != http.StatusOK {
+		t.Fatalf("exchange failed: %d", status)
+	}
+	// Without ttl_secs the job token gets the default lifetime, capped by
+	// the workspace token's own expiry
+	if job.ExpiresAt > created.ExpiresAt {
+		t.Errorf("job token outlives its workspace token: %d > %d", job.ExpiresAt, created.ExpiresAt)
+	}
+
+	db := ts.GetOrchestratorDB(t)
+	past := time.Now().Add(-time.Minute).Unix()
+	if _, err := db.Exec(`UPDATE auth_job_tokens SET expires_at = ?`, past); err != nil {
+		t.Fatal(err)
+	}
+	if status := ts.uploadWithBearer(t, job.Token, "builds", "a.bin", []byte("a")); status != http.StatusUnauthorized {
+		t.Errorf("expected 401 with an expired job token, got %d", status)
+	}
+
+	if _, err := db.Exec(`UPDATE auth_workspace_tokens SET expires_at = ?`, past); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := ts.exchangeWorkspaceToken(t, created.Token, nil); status != http.StatusUnauthorized {
+		t.Errorf("expected 401 exchanging an expired token, got %d", status)
+	}
+
+	// TTLs beyond the maximum and names of existing users are refused
+	for name, body := range map[string]map[string]interface{}{
+		"ttl":  {"pipeline": "deploy", "ttl_hours": 24 * 365, "scopes": []map[string]interface{}{{"action": constants.AuthActionUpload}}},
+		"user": {"pipeline": constants.AuthBootstrapUsername, "ttl_hours": 1, "scopes": []map[string]interface{}{{"action": constants.AuthActionUpload}}},
+	} {
+		resp, err := ts.POST("/api/auth/workspace-tokens", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusCreated {
+			t.Errorf("%s: expected the token to be refused", name)
+		}
+	}
+}
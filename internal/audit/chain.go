@@ -0,0 +1,262 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/zeebo/blake3"
+
+	"silobang/internal/constants"
+)
+
+// Each entry stores the hash of the entry before it (prev_hash) and its own
+// hash over prev_hash and its columns (entry_hash), so editing, removing or
+// reordering entries breaks the chain. Entries written before chaining have
+// empty hashes. Purges record the runs they remove in audit_chain_gaps with
+// the hashes at both ends, so the chain stays verifiable across them.
+
+// ChainLink describes the first entry where the hash chain breaks.
+type ChainLink struct {
+	ID       int64  `json:"id"` // entry, or first missing id for a gap
+	Reason   string `json:"reason"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// ChainReport is the result of walking the audit hash chain.
+type ChainReport struct {
+	Intact        bool       `json:"intact"`
+	Verified      int64      `json:"verified"`       // chained entries checked
+	LegacyEntries int64      `json:"legacy_entries"` // entries written before chaining
+	PurgedRuns    int64      `json:"purged_runs"`    // recorded purge gaps crossed
+	PurgedEntries int64      `json:"purged_entries"`
+	HeadID        int64      `json:"head_id"`
+	HeadHash      string     `json:"head_hash"`
+	Broken        *ChainLink `json:"broken,omitempty"`
+}
+
+// chainGap is a run of entries removed by a purge.
+type chainGap struct {
+	firstID, lastID         int64
+	firstPrevHash, lastHash string
+}
+
+// hashEntry returns the chain hash of an entry.
+func hashEntry(prevHash string, id, timestamp int64, action, ipAddress, username, actorType string, detailsJSON sql.NullString) string {
+	var details interface{}
+	if detailsJSON.Valid {
+		details = detailsJSON.String
+	}
+	// An array keeps field boundaries unambiguous
+	data, _ := json.Marshal([]interface{}{prevHash, id, timestamp, action, ipAddress, username, actorType, details})
+	sum := blake3.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// chainHead returns the id and hash of the newest entry ever written,
+// whether it is still stored or was removed by a purge.
+func chainHead(tx *sql.Tx) (int64, string, error) {
+	var id int64
+	err := tx.QueryRow(`SELECT seq FROM sqlite_sequence WHERE name = ?`, constants.AuditLogTableName).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read audit sequence: %w", err)
+	}
+
+	var hash string
+	err = tx.QueryRow(`SELECT entry_hash FROM audit_log WHERE id = ?`, id).Scan(&hash)
+	if err == sql.ErrNoRows {
+		err = tx.QueryRow(`SELECT last_hash FROM audit_chain_gaps WHERE last_id = ?`, id).Scan(&hash)
+		if err == sql.ErrNoRows {
+			return id, "", nil
+		}
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read audit chain head: %w", err)
+	}
+	return id, hash, nil
+}
+
+// recordGaps records the runs of consecutive ids among the entries returned
+// by selection (id first), before they are deleted.
+func recordGaps(tx *sql.Tx, selection string, args []interface{}, purgedAt int64) error {
+	_, err := tx.Exec(`
+		INSERT INTO audit_chain_gaps (first_id, last_id, first_prev_hash, last_hash, purged_at)
+		SELECT r.first_id, r.last_id, f.prev_hash, l.entry_hash, ?
+		FROM (
+			SELECT MIN(id) AS first_id, MAX(id) AS last_id FROM (
+				SELECT id, id - ROW_NUMBER() OVER (ORDER BY id) AS run FROM (`+selection+`)
+			) GROUP BY run
+		) r
+		JOIN audit_log f ON f.id = r.first_id
+		JOIN audit_log l ON l.id = r.last_id
+	`, append([]interface{}{purgedAt}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to record purged chain runs: %w", err)
+	}
+	return nil
+}
+
+// mergeGaps joins adjacent purge gaps into one, so repeated purges of the
+// oldest entries leave a single row.
+func mergeGaps(tx *sql.Tx) error {
+	gaps, err := loadGaps(tx)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(gaps); {
+		j := i
+		for j+1 < len(gaps) && gaps[j+1].firstID == gaps[j].lastID+1 && gaps[j+1].firstPrevHash == gaps[j].lastHash {
+			j++
+		}
+		if j > i {
+			if _, err := tx.Exec(`DELETE FROM audit_chain_gaps WHERE first_id > ? AND first_id <= ?`,
+				gaps[i].firstID, gaps[j].firstID); err != nil {
+				return fmt.Errorf("failed to merge purged chain runs: %w", err)
+			}
+			if _, err := tx.Exec(`UPDATE audit_chain_gaps SET last_id = ?, last_hash = ? WHERE first_id = ?`,
+				gaps[j].lastID, gaps[j].lastHash, gaps[i].firstID); err != nil {
+				return fmt.Errorf("failed to merge purged chain runs: %w", err)
+			}
+		}
+		i = j + 1
+	}
+	return nil
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx.
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func loadGaps(q queryer) ([]chainGap, error) {
+	rows, err := q.Query(`SELECT first_id, last_id, first_prev_hash, last_hash FROM audit_chain_gaps ORDER BY first_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load purged chain runs: %w", err)
+	}
+	defer rows.Close()
+
+	var gaps []chainGap
+	for rows.Next() {
+		var g chainGap
+		if err := rows.Scan(&g.firstID, &g.lastID, &g.firstPrevHash, &g.lastHash); err != nil {
+			return nil, err
+		}
+		gaps = append(gaps, g)
+	}
+	return gaps, rows.Err()
+}
+
+// VerifyChain walks the audit log oldest first and reports the first broken
+// link: an entry whose hash does not match its contents, whose prev_hash
+// does not match the entry before it, or ids missing without a recorded
+// purge. Gaps among entries written before chaining are not checked.
+func VerifyChain(db *sql.DB) (*ChainReport, error) {
+	// One read transaction sees the log, the gaps and the sequence consistently
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin chain verification: %w", err)
+	}
+	defer tx.Rollback()
+
+	gaps, err := loadGaps(tx)
+	if err != nil {
+		return nil, err
+	}
+	gapAt := make(map[int64]chainGap, len(gaps))
+	for _, g := range gaps {
+		gapAt[g.firstID] = g
+	}
+
+	report := &ChainReport{}
+	expected := ""
+	var lastID int64
+	chained := false
+
+	// crossGaps follows recorded gaps from lastID+1 up to before id, and
+	// returns false when ids in between are missing without one.
+	crossGaps := func(id int64) bool {
+		for lastID+1 < id {
+			g, ok := gapAt[lastID+1]
+			if !ok || g.lastID >= id {
+				if chained {
+					report.Broken = &ChainLink{ID: lastID + 1, Reason: fmt.Sprintf("entries %d to %d are missing without a recorded purge", lastID+1, id-1)}
+					return false
+				}
+				lastID = id - 1 // before chaining, gaps are unrecorded
+				return true
+			}
+			if g.firstPrevHash != expected {
+				report.Broken = &ChainLink{ID: g.firstID, Reason: "purged run does not follow the previous entry", Expected: expected, Actual: g.firstPrevHash}
+				return false
+			}
+			report.PurgedRuns++
+			report.PurgedEntries += g.lastID - g.firstID + 1
+			expected = g.lastHash
+			lastID = g.lastID
+		}
+		return true
+	}
+
+	rows, err := tx.Query(`SELECT id, timestamp, action, ip_address, username, actor_type, details_json, prev_hash, entry_hash
+		FROM audit_log ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Entry
+		var detailsJSON sql.NullString
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Action, &e.IPAddress, &e.Username, &e.ActorType,
+			&detailsJSON, &e.PrevHash, &e.EntryHash); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if !crossGaps(e.ID) {
+			return report, nil
+		}
+		lastID = e.ID
+
+		if e.EntryHash == "" {
+			if chained {
+				report.Broken = &ChainLink{ID: e.ID, Reason: "entry has no hash"}
+				return report, nil
+			}
+			report.LegacyEntries++
+			continue
+		}
+		chained = true
+
+		if e.PrevHash != expected {
+			report.Broken = &ChainLink{ID: e.ID, Reason: "prev_hash does not match the previous entry", Expected: expected, Actual: e.PrevHash}
+			return report, nil
+		}
+		if actual := hashEntry(e.PrevHash, e.ID, e.Timestamp, e.Action, e.IPAddress, e.Username, e.ActorType, detailsJSON); actual != e.EntryHash {
+			report.Broken = &ChainLink{ID: e.ID, Reason: "entry does not match its hash", Expected: e.EntryHash, Actual: actual}
+			return report, nil
+		}
+		report.Verified++
+		expected = e.EntryHash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The newest entries may only be missing if a purge recorded them
+	var seq int64
+	if err := tx.QueryRow(`SELECT seq FROM sqlite_sequence WHERE name = ?`, constants.AuditLogTableName).Scan(&seq); err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to read audit sequence: %w", err)
+	}
+	if !crossGaps(seq + 1) {
+		return report, nil
+	}
+
+	report.Intact = true
+	report.HeadID = lastID
+	report.HeadHash = expected
+	return report, nil
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+func verifyChain(t *testing.T, logger *Logger) *ChainReport {
+	t.Helper()
+	logger.Flush()
+	report, err := VerifyChain(logger.db)
+	if err != nil {
+		t.Fatalf("VerifyChain failed: %v", err)
+	}
+	return report
+}
+
+func TestVerifyChain_DetectsTampering(t *testing.T) {
+	logger, db := newTestLogger(t)
+	for _, user := range []string{"alice", "bob", "carol", "dave", "erin"} {
+		logger.Log(constants.AuditActionLogout, "10.0.0.1", user, LogoutDetails{})
+	}
+
+	report := verifyChain(t, logger)
+	if !report.Intact || report.Verified != 5 || report.HeadID != 5 || report.HeadHash == "" {
+		t.Fatalf("expected an intact chain of 5, got %+v", report)
+	}
+	entries, _ := Query(db, QueryOptions{Limit: 1})
+	if entries[0].EntryHash != report.HeadHash || entries[0].PrevHash == "" {
+		t.Errorf("expected queried entries to carry their hashes, got %+v", entries[0])
+	}
+
+	if _, err := db.Exec(`UPDATE audit_log SET username = 'mallory' WHERE id = 3`); err != nil {
+		t.Fatal(err)
+	}
+	report = verifyChain(t, logger)
+	if report.Intact || report.Broken == nil || report.Broken.ID != 3 || report.Verified != 2 {
+		t.Fatalf("expected the chain to break at 3, got %+v", report)
+	}
+	db.Exec(`UPDATE audit_log SET username = 'carol' WHERE id = 3`)
+
+	if _, err := db.Exec(`DELETE FROM audit_log WHERE id = 4`); err != nil {
+		t.Fatal(err)
+	}
+	report = verifyChain(t, logger)
+	if report.Intact || report.Broken.ID != 4 || !strings.Contains(report.Broken.Reason, "missing") {
+		t.Fatalf("expected entry 4 to be reported missing, got %+v", report.Broken)
+	}
+
+	// Removing the newest entry is caught through the sequence
+	db.Exec(`DELETE FROM audit_log WHERE id >= 4`)
+	report = verifyChain(t, logger)
+	if report.Intact || report.Broken.ID != 4 {
+		t.Fatalf("expected entries 4 to 5 to be reported missing, got %+v", report.Broken)
+	}
+}
+
+func TestVerifyChain_AcrossPurges(t *testing.T) {
+	logger, db := newTestLogger(t)
+	logger.SetRetention(RetentionPolicy{ActionDays: map[string]int{constants.AuditActionDownloaded: 30}})
+
+	// Entries 1 and 2 predate chaining
+	insertAged(t, db, constants.AuditActionDownloaded, "alice", 40)
+	insertAged(t, db, constants.AuditActionLoginSuccess, "alice", 1)
+
+	aged := func(action string, daysAgo int) pendingEntry {
+		return pendingEntry{
+			timestamp: time.Now().Add(-time.Duration(daysAgo) * 24 * time.Hour).Unix(),
+			action:    action,
+			ipAddress: "127.0.0.1",
+			username:  "alice",
+		}
+	}
+	if _, err := logger.insertBatch([]pendingEntry{
+		aged(constants.AuditActionDownloaded, 40),  // 3
+		aged(constants.AuditActionLoginSuccess, 1), // 4
+		aged(constants.AuditActionDownloaded, 40),  // 5
+		aged(constants.AuditActionDownloaded, 40),  // 6
+		aged(constants.AuditActionLoginSuccess, 1), // 7
+	}); err != nil {
+		t.Fatalf("insertBatch failed: %v", err)
+	}
+
+	if report, err := logger.Purge(constants.AuditPurgeTriggerManual, "10.0.0.1", "admin"); err != nil || report.Deleted != 4 {
+		t.Fatalf("expected 4 entries purged, got %+v (%v)", report, err)
+	}
+
+	report := verifyChain(t, logger)
+	if !report.Intact || report.LegacyEntries != 1 || report.Verified != 3 || report.PurgedRuns != 3 || report.PurgedEntries != 4 {
+		t.Fatalf("expected an intact chain across 3 purged runs, got %+v", report)
+	}
+
+	if _, err := db.Exec(`UPDATE audit_chain_gaps SET last_hash = 'forged' WHERE first_id = 5`); err != nil {
+		t.Fatal(err)
+	}
+	report = verifyChain(t, logger)
+	if report.Intact || report.Broken.ID != 7 {
+		t.Fatalf("expected the entry after the forged run to break, got %+v", report.Broken)
+	}
+}
+
+func TestMergeGaps(t *testing.T) {
+	_, db := newTestLogger(t)
+	for _, g := range []chainGap{{1, 3, "", "a"}, {4, 4, "a", "b"}, {5, 9, "b", "c"}, {11, 12, "x", "y"}} {
+		if _, err := db.Exec(`INSERT INTO audit_chain_gaps (first_id, last_id, first_prev_hash, last_hash, purged_at) VALUES (?, ?, ?, ?, 0)`,
+			g.firstID, g.lastID, g.firstPrevHash, g.lastHash); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tx, _ := db.Begin()
+	if err := mergeGaps(tx); err != nil {
+		t.Fatalf("mergeGaps failed: %v", err)
+	}
+	tx.Commit()
+
+	gaps, err := loadGaps(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gaps) != 2 || gaps[0] != (chainGap{1, 9, "", "c"}) || gaps[1].firstID != 11 {
+		t.Errorf("expected 1-9 merged and 11-12 kept, got %+v", gaps)
+	}
+}
@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"silobang/internal/constants"
+)
+
+// ConfigChange is a config_changed entry with its details decoded.
+type ConfigChange struct {
+	ID        int64                `json:"id"`
+	Timestamp int64                `json:"timestamp"`
+	IPAddress string               `json:"ip_address,omitempty"`
+	Username  string               `json:"username,omitempty"`
+	Details   ConfigChangedDetails `json:"details"`
+}
+
+// ListConfigChanges returns the config_changed entries written at or before
+// until (0 = all), oldest first. Numbers in change values are decoded as
+// json.Number, so they compare equal to the values of a flattened config.
+func ListConfigChanges(db *sql.DB, until int64) ([]ConfigChange, error) {
+	query := `SELECT id, timestamp, ip_address, username, details_json FROM audit_log WHERE action = ?`
+	args := []interface{}{constants.AuditActionConfigChanged}
+	if until > 0 {
+		query += " AND timestamp <= ?"
+		args = append(args, until)
+	}
+	query += " ORDER BY id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query config changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []ConfigChange{}
+	for rows.Next() {
+		var change ConfigChange
+		var detailsJSON sql.NullString
+		if err := rows.Scan(&change.ID, &change.Timestamp, &change.IPAddress, &change.Username, &detailsJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan config change: %w", err)
+		}
+		if detailsJSON.Valid {
+			dec := json.NewDecoder(bytes.NewReader([]byte(detailsJSON.String)))
+			dec.UseNumber()
+			dec.Decode(&change.Details)
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+
+	"silobang/internal/constants"
+)
+
+// DownloadCount is how often an asset was downloaded, from downloaded entries.
+type DownloadCount struct {
+	Hash      string `json:"asset_id"`
+	Topic     string `json:"topic"`
+	Downloads int64  `json:"downloads"`
+	Users     int64  `json:"users"` // distinct users, anonymous downloads excluded
+}
+
+// TopDownloads returns the most downloaded assets of each topic since the
+// given Unix time, at most limit per topic, most downloaded first.
+func TopDownloads(db *sql.DB, since int64, limit int) (map[string][]DownloadCount, error) {
+	rows, err := db.Query(`
+		SELECT topic, hash, downloads, users FROM (
+			SELECT json_extract(details_json, '$.topic') AS topic,
+			       json_extract(details_json, '$.hash') AS hash,
+			       COUNT(*) AS downloads,
+			       COUNT(DISTINCT NULLIF(username, '')) AS users,
+			       ROW_NUMBER() OVER (
+			           PARTITION BY json_extract(details_json, '$.topic')
+			           ORDER BY COUNT(*) DESC, json_extract(details_json, '$.hash')
+			       ) AS rank
+			FROM audit_log
+			WHERE action = ? AND timestamp >= ?
+			GROUP BY topic, hash
+		)
+		WHERE rank <= ? AND topic IS NOT NULL AND hash IS NOT NULL
+		ORDER BY topic, rank
+	`, constants.AuditActionDownloaded, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count downloads: %w", err)
+	}
+	defer rows.Close()
+
+	byTopic := make(map[string][]DownloadCount)
+	for rows.Next() {
+		var c DownloadCount
+		if err := rows.Scan(&c.Topic, &c.Hash, &c.Downloads, &c.Users); err != nil {
+			return nil, fmt.Errorf("failed to scan download count: %w", err)
+		}
+		byTopic[c.Topic] = append(byTopic[c.Topic], c)
+	}
+	return byTopic, rows.Err()
+}
+
+// CoDownloads returns the assets that users who downloaded hash also
+// downloaded within window seconds of it, ranked by how many of those users
+// did. Anonymous downloads are not linked to each other.
+func CoDownloads(db *sql.DB, hash string, window int64, limit int) ([]DownloadCount, error) {
+	rows, err := db.Query(`
+		WITH mine AS (
+			SELECT username, timestamp FROM audit_log
+			WHERE action = ? AND username != '' AND json_extract(details_json, '$.hash') = ?
+		)
+		SELECT json_extract(o.details_json, '$.hash') AS hash,
+		       json_extract(o.details_json, '$.topic') AS topic,
+		       COUNT(DISTINCT o.id) AS downloads,
+		       COUNT(DISTINCT o.username) AS users
+		FROM mine m
+		JOIN audit_log o ON o.username = m.username AND o.action = ?
+		     AND o.timestamp BETWEEN m.timestamp - ? AND m.timestamp + ?
+		WHERE hash != ? AND topic IS NOT NULL
+		GROUP BY hash
+		ORDER BY users DESC, downloads DESC, hash
+		LIMIT ?
+	`, constants.AuditActionDownloaded, hash, constants.AuditActionDownloaded, window, window, hash, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find co-downloads: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []DownloadCount{}
+	for rows.Next() {
+		var c DownloadCount
+		if err := rows.Scan(&c.Hash, &c.Topic, &c.Downloads, &c.Users); err != nil {
+			return nil, fmt.Errorf("failed to scan co-download: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// DownloadedSince returns the assets of topic downloaded since the given
+// Unix time.
+func DownloadedSince(db *sql.DB, topic string, since int64) (map[string]bool, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT json_extract(details_json, '$.hash') FROM audit_log
+		WHERE action = ? AND timestamp >= ? AND json_extract(details_json, '$.topic') = ?
+	`, constants.AuditActionDownloaded, since, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list downloads: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]bool)
+	for rows.Next() {
+		var hash sql.NullString
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan download: %w", err)
+		}
+		if hash.Valid {
+			hashes[hash.String] = true
+		}
+	}
+	return hashes, rows.Err()
+}
+
+// DownloadsPurgedSince reports whether a purge removed downloaded entries
+// recorded since the given Unix time, leaving the log unable to tell which
+// assets were downloaded since then.
+func DownloadsPurgedSince(db *sql.DB, since int64) (bool, error) {
+	var purged bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM audit_log a, json_each(a.details_json, '$.ranges') r
+			WHERE a.action = ? AND json_extract(r.value, '$.action') = ?
+			  AND json_extract(r.value, '$.last_timestamp') >= ?
+		)
+	`, constants.AuditActionAuditPurged, constants.AuditActionDownloaded, since).Scan(&purged)
+	if err != nil {
+		return false, fmt.Errorf("failed to check purged downloads: %w", err)
+	}
+	return purged, nil
+}
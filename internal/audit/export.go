@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"silobang/internal/constants"
+)
+
+// exportColumns is the CSV header; details_json holds the raw details.
+var exportColumns = []string{"id", "timestamp", "action", "ip_address", "username", "actor_type", "details_json", "prev_hash", "entry_hash"}
+
+// IsValidExportFormat checks if an export format is supported
+func IsValidExportFormat(format string) bool {
+	return format == constants.AuditExportFormatCSV || format == constants.AuditExportFormatJSONL
+}
+
+// Export writes every entry matching opts to w, oldest first, as CSV or
+// JSON lines, and returns the number of entries written. Limit and Offset
+// are ignored. Rows are read and written one at a time, so the whole log
+// can be exported without holding it in memory.
+func Export(db *sql.DB, opts QueryOptions, format string, w io.Writer) (int64, error) {
+	if !IsValidExportFormat(format) {
+		return 0, fmt.Errorf("unsupported export format %q", format)
+	}
+
+	where, args := queryFilter(opts)
+	rows, err := db.Query(`SELECT id, timestamp, action, ip_address, username, actor_type, details_json, prev_hash, entry_hash
+		FROM audit_log`+where+` ORDER BY id`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	buf := bufio.NewWriter(w)
+	var csvWriter *csv.Writer
+	if format == constants.AuditExportFormatCSV {
+		csvWriter = csv.NewWriter(buf)
+		if err := csvWriter.Write(exportColumns); err != nil {
+			return 0, err
+		}
+	}
+	enc := json.NewEncoder(buf)
+
+	var written int64
+	for rows.Next() {
+		var entry Entry
+		var detailsJSON sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.Timestamp, &entry.Action,
+			&entry.IPAddress, &entry.Username, &entry.ActorType, &detailsJSON, &entry.PrevHash, &entry.EntryHash); err != nil {
+			return written, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+
+		if csvWriter != nil {
+			err = csvWriter.Write([]string{
+				strconv.FormatInt(entry.ID, 10),
+				strconv.FormatInt(entry.Timestamp, 10),
+				entry.Action,
+				entry.IPAddress,
+				entry.Username,
+				entry.ActorType,
+				detailsJSON.String,
+				entry.PrevHash,
+				entry.EntryHash,
+			})
+		} else {
+			if detailsJSON.Valid && json.Valid([]byte(detailsJSON.String)) {
+				entry.Details = json.RawMessage(detailsJSON.String)
+			}
+			err = enc.Encode(entry)
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to write audit export: %w", err)
+		}
+		written++
+	}
+	if err := rows.Err(); err != nil {
+		return written, err
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return written, fmt.Errorf("failed to write audit export: %w", err)
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		return written, fmt.Errorf("failed to write audit export: %w", err)
+	}
+	return written, nil
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestExportCSVAndJSONL(t *testing.T) {
+	logger, db := newTestLogger(t)
+
+	for _, hash := range []string{"aaa", "bbb", "ccc"} {
+		logger.Log(constants.AuditActionDownloaded, "127.0.0.1", "alice", DownloadedDetails{Hash: hash, Topic: "models, \"v2\""})
+	}
+	logger.Log(constants.AuditActionLogout, "127.0.0.1", "bob", nil)
+	logger.Flush()
+
+	opts := QueryOptions{Action: constants.AuditActionDownloaded, Limit: 1}
+
+	var csvOut bytes.Buffer
+	n, err := Export(db, opts, constants.AuditExportFormatCSV, &csvOut)
+	if err != nil || n != 3 {
+		t.Fatalf("CSV export: got %d entries, err %v", n, err)
+	}
+	records, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV export does not parse: %v", err)
+	}
+	if len(records) != 4 || records[0][6] != "details_json" {
+		t.Fatalf("expected a header and 3 rows, got %v", records)
+	}
+	var details DownloadedDetails
+	if err := json.Unmarshal([]byte(records[1][6]), &details); err != nil || details.Hash != "aaa" || details.Topic != "models, \"v2\"" {
+		t.Errorf("expected the oldest entry first with its details, got %v (%v)", records[1], err)
+	}
+
+	var jsonlOut bytes.Buffer
+	if n, err := Export(db, opts, constants.AuditExportFormatJSONL, &jsonlOut); err != nil || n != 3 {
+		t.Fatalf("JSONL export: got %d entries, err %v", n, err)
+	}
+	hashes := []string{}
+	scanner := bufio.NewScanner(&jsonlOut)
+	for scanner.Scan() {
+		var entry struct {
+			Action  string            `json:"action"`
+			Details DownloadedDetails `json:"details"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		hashes = append(hashes, entry.Details.Hash)
+	}
+	if len(hashes) != 3 || hashes[0] != "aaa" || hashes[2] != "ccc" {
+		t.Errorf("expected aaa, bbb, ccc, got %v", hashes)
+	}
+
+	if _, err := Export(db, opts, "xml", &bytes.Buffer{}); err == nil {
+		t.Error("expected an unsupported format to fail")
+	}
+}
@@ -0,0 +1,135 @@
+package audit
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// ErrHoldNotFound is returned when a legal hold does not exist or was already released
+var ErrHoldNotFound = errors.New("legal hold not found")
+
+// LegalHold protects the audit entries it matches from every purge until it
+// is released. Nil criteria match any value.
+type LegalHold struct {
+	ID         int64   `json:"id"`
+	Name       string  `json:"name"`
+	Reason     string  `json:"reason"`
+	Username   *string `json:"username"`
+	Action     *string `json:"action"`
+	Since      *int64  `json:"since"`
+	Until      *int64  `json:"until"`
+	CreatedBy  string  `json:"created_by"`
+	CreatedAt  int64   `json:"created_at"`
+	ReleasedBy *string `json:"released_by,omitempty"`
+	ReleasedAt *int64  `json:"released_at,omitempty"`
+}
+
+// heldClause matches audit_log rows (aliased a) covered by an active legal hold
+const heldClause = `EXISTS (
+	SELECT 1 FROM audit_legal_holds h
+	WHERE h.released_at IS NULL
+	  AND (h.username IS NULL OR h.username = a.username)
+	  AND (h.action IS NULL OR h.action = a.action)
+	  AND (h.since IS NULL OR a.timestamp >= h.since)
+	  AND (h.until IS NULL OR a.timestamp <= h.until)
+)`
+
+// Validate checks the hold's name, reason and criteria.
+func (h *LegalHold) Validate() error {
+	if h.Name == "" || len(h.Name) > constants.AuditHoldNameMaxLength {
+		return fmt.Errorf("name must be 1 to %d characters", constants.AuditHoldNameMaxLength)
+	}
+	if len(h.Reason) > constants.AuditHoldReasonMaxLength {
+		return fmt.Errorf("reason must be at most %d characters", constants.AuditHoldReasonMaxLength)
+	}
+	if h.Action != nil && !IsValidAction(*h.Action) {
+		return fmt.Errorf("invalid action type: %s", *h.Action)
+	}
+	if h.Since != nil && h.Until != nil && *h.Since > *h.Until {
+		return fmt.Errorf("since must not be after until")
+	}
+	return nil
+}
+
+// CreateHold stores a new legal hold and returns it with its ID set.
+func CreateHold(db *sql.DB, hold LegalHold) (*LegalHold, error) {
+	if err := hold.Validate(); err != nil {
+		return nil, err
+	}
+	hold.CreatedAt = time.Now().Unix()
+	result, err := db.Exec(`
+		INSERT INTO audit_legal_holds (name, reason, username, action, since, until, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, hold.Name, hold.Reason, hold.Username, hold.Action, hold.Since, hold.Until, hold.CreatedBy, hold.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create legal hold: %w", err)
+	}
+	hold.ID, _ = result.LastInsertId()
+	return &hold, nil
+}
+
+// ListHolds returns legal holds, newest first. Released holds are included
+// only when includeReleased is set.
+func ListHolds(db *sql.DB, includeReleased bool) ([]LegalHold, error) {
+	query := `SELECT id, name, reason, username, action, since, until, created_by, created_at, released_by, released_at
+              FROM audit_legal_holds`
+	if !includeReleased {
+		query += " WHERE released_at IS NULL"
+	}
+	query += " ORDER BY id DESC"
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legal holds: %w", err)
+	}
+	defer rows.Close()
+
+	holds := []LegalHold{}
+	for rows.Next() {
+		var h LegalHold
+		if err := rows.Scan(&h.ID, &h.Name, &h.Reason, &h.Username, &h.Action, &h.Since, &h.Until,
+			&h.CreatedBy, &h.CreatedAt, &h.ReleasedBy, &h.ReleasedAt); err != nil {
+			return nil, err
+		}
+		holds = append(holds, h)
+	}
+	return holds, rows.Err()
+}
+
+// ReleaseHold marks an active hold released. Entries it protected become
+// eligible for the next purge. Returns ErrHoldNotFound for unknown or
+// already released holds.
+func ReleaseHold(db *sql.DB, id int64, releasedBy string) (*LegalHold, error) {
+	result, err := db.Exec(`
+		UPDATE audit_legal_holds SET released_by = ?, released_at = ?
+		WHERE id = ? AND released_at IS NULL
+	`, releasedBy, time.Now().Unix(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release legal hold: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrHoldNotFound
+	}
+
+	var h LegalHold
+	err = db.QueryRow(`
+		SELECT id, name, reason, username, action, since, until, created_by, created_at, released_by, released_at
+		FROM audit_legal_holds WHERE id = ?
+	`, id).Scan(&h.ID, &h.Name, &h.Reason, &h.Username, &h.Action, &h.Since, &h.Until,
+		&h.CreatedBy, &h.CreatedAt, &h.ReleasedBy, &h.ReleasedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// CountHeld returns how many audit entries are protected by active holds.
+func CountHeld(db *sql.DB) (int64, error) {
+	var n int64
+	err := db.QueryRow(`SELECT COUNT(*) FROM audit_log a WHERE ` + heldClause).Scan(&n)
+	return n, err
+}
@@ -43,18 +43,64 @@ func (s *subscription) close() {
 	}
 }
 
-// Logger provides thread-safe audit logging with pub/sub for SSE streaming
+// Logger provides thread-safe audit logging with pub/sub for SSE streaming.
+//
+// Log only validates and enqueues an entry; a background writer inserts
+// queued entries in batches and retries when the orchestrator DB is briefly
+// locked, so request handling never waits on SQLite. The queue is bounded:
+// when it is full, Log either blocks until the writer catches up or drops
+// the oldest queued entry, depending on the overflow policy. Subscribers are
+// notified once an entry is stored, and Stop flushes the queue.
 type Logger struct {
 	db              *sql.DB
-	mu              sync.Mutex
+	mu              sync.Mutex // serializes writes and purges
 	subscribers     map[chan Entry]*subscription
 	subMu           sync.RWMutex
 	stopClean       chan struct{} // For cleanup goroutine shutdown
-	maxLogSizeBytes int64        // Configurable max audit log size
-	purgePercentage int          // Configurable purge percentage when limit hit
+	stopOnce        sync.Once
+	maxLogSizeBytes int64           // Configurable max audit log size
+	purgePercentage int             // Configurable purge percentage when limit hit
+	retention       RetentionPolicy // Per-action retention, guarded by mu
+
+	queueMu    sync.Mutex
+	queueCond  *sync.Cond // signalled whenever the queue or closing changes
+	queue      []pendingEntry
+	inflight   int // entries at the head of queue being written
+	capacity   int
+	policy     string
+	closing    bool
+	nextSeq    uint64
+	counters   QueueStats    // Written, Dropped, Failed, Retries, Blocked, LastError
+	writerDone chan struct{} // closed when the writer has drained the queue
 }
 
-// NewLogger creates a new audit logger and starts the cleanup goroutine
+// pendingEntry is an entry waiting to be written.
+type pendingEntry struct {
+	seq         uint64
+	timestamp   int64
+	action      string
+	ipAddress   string
+	username    string
+	details     interface{}
+	detailsJSON sql.NullString
+}
+
+// QueueStats reports the state of the write queue.
+type QueueStats struct {
+	Depth          int    `json:"depth"`
+	Capacity       int    `json:"capacity"`
+	OverflowPolicy string `json:"overflow_policy"`
+	Written        uint64 `json:"written"`
+	Dropped        uint64 `json:"dropped"` // discarded by drop_oldest or logged after Stop
+	Failed         uint64 `json:"failed"`  // discarded after every write attempt failed
+	Retries        uint64 `json:"retries"`
+	Blocked        uint64 `json:"blocked"` // Log calls that waited for room under the block policy
+	LastError      string `json:"last_error,omitempty"`
+}
+
+// NewLogger creates a new audit logger and starts the cleanup goroutine and
+// the queue writer. The queue starts with the default capacity and the
+// block policy; see SetQueue.
 func NewLogger(db *sql.DB, maxLogSizeBytes int64, purgePercentage int) *Logger {
 	l := &Logger{
 		db:              db,
@@ -62,20 +108,54 @@ func NewLogger(db *sql.DB, maxLogSizeBytes int64, purgePercentage int) *Logger {
 		stopClean:       make(chan struct{}),
 		maxLogSizeBytes: maxLogSizeBytes,
 		purgePercentage: purgePercentage,
+		capacity:        constants.AuditQueueDefaultSize,
+		policy:          constants.AuditOverflowBlock,
+		writerDone:      make(chan struct{}),
 	}
+	l.queueCond = sync.NewCond(&l.queueMu)
 
 	// Start cleanup goroutine for log size management
 	go l.cleanupLoop()
+	go l.writeLoop()
 
 	return l
 }
 
-// Stop stops the cleanup goroutine (call during graceful shutdown)
+// SetQueue changes the queue capacity and overflow policy (block or
+// drop_oldest). Entries already queued beyond a smaller capacity are kept.
+func (l *Logger) SetQueue(capacity int, policy string) {
+	l.queueMu.Lock()
+	defer l.queueMu.Unlock()
+	if capacity > 0 {
+		l.capacity = capacity
+	}
+	if policy != "" {
+		l.policy = policy
+	}
+	l.queueCond.Broadcast()
+}
+
+// Stop stops the cleanup goroutine and flushes queued entries, waiting at
+// most AuditShutdownFlushTimeout (call during graceful shutdown).
 func (l *Logger) Stop() {
-	close(l.stopClean)
+	l.stopOnce.Do(func() {
+		close(l.stopClean)
+
+		l.queueMu.Lock()
+		l.closing = true
+		l.queueCond.Broadcast()
+		l.queueMu.Unlock()
+
+		select {
+		case <-l.writerDone:
+		case <-time.After(constants.AuditShutdownFlushTimeout):
+		}
+	})
 }
 
-// Log records an audit entry (thread-safe, append-only)
+// Log validates an audit entry and queues it for writing (thread-safe,
+// append-only). Write failures are retried in the background, so the
+// returned error only reports invalid entries or a stopped logger.
 func (l *Logger) Log(action string, ipAddress string, username string, details interface{}) error {
 	if !IsValidAction(action) {
 		return fmt.Errorf("invalid action type: %s", action)
@@ -90,33 +170,220 @@ func (l *Logger) Log(action string, ipAddress string, username string, details i
 		detailsJSON = sql.NullString{String: string(jsonBytes), Valid: true}
 	}
 
-	timestamp := time.Now().Unix()
+	entry := pendingEntry{
+		timestamp:   time.Now().Unix(),
+		action:      action,
+		ipAddress:   ipAddress,
+		username:    username,
+		details:     details,
+		detailsJSON: detailsJSON,
+	}
+
+	l.queueMu.Lock()
+	defer l.queueMu.Unlock()
+
+	blocked := false
+	for !l.closing && len(l.queue) >= l.capacity {
+		if l.policy == constants.AuditOverflowDropOldest {
+			// Entries being written cannot be recalled; when the whole
+			// queue is in flight the new entry is the oldest droppable one
+			if len(l.queue) == l.inflight {
+				l.counters.Dropped++
+				return nil
+			}
+			l.queue = append(l.queue[:l.inflight], l.queue[l.inflight+1:]...)
+			l.counters.Dropped++
+			continue
+		}
+		if !blocked {
+			blocked = true
+			l.counters.Blocked++
+		}
+		l.queueCond.Wait()
+	}
+	if l.closing {
+		l.counters.Dropped++
+		return fmt.Errorf("audit logger stopped")
+	}
+
+	l.nextSeq++
+	entry.seq = l.nextSeq
+	l.queue = append(l.queue, entry)
+	l.queueCond.Broadcast()
+	return nil
+}
+
+// Flush waits until every entry logged before the call has been written or
+// discarded, at most AuditFlushTimeout. Readers that must see their own
+// writes, like the audit API, call it before querying.
+func (l *Logger) Flush() {
+	l.queueMu.Lock()
+	defer l.queueMu.Unlock()
+
+	target := l.nextSeq
+	timedOut := false
+	timer := time.AfterFunc(constants.AuditFlushTimeout, func() {
+		l.queueMu.Lock()
+		timedOut = true
+		l.queueCond.Broadcast()
+		l.queueMu.Unlock()
+	})
+	defer timer.Stop()
+
+	for !timedOut && len(l.queue) > 0 && l.queue[0].seq <= target {
+		l.queueCond.Wait()
+	}
+}
+
+// QueueStats returns the current queue depth and counters.
+func (l *Logger) QueueStats() QueueStats {
+	l.queueMu.Lock()
+	defer l.queueMu.Unlock()
+
+	stats := l.counters
+	stats.Depth = len(l.queue)
+	stats.Capacity = l.capacity
+	stats.OverflowPolicy = l.policy
+	return stats
+}
+
+// writeLoop writes queued entries in batches until Stop has been called and
+// the queue is empty.
+func (l *Logger) writeLoop() {
+	defer close(l.writerDone)
+
+	for {
+		l.queueMu.Lock()
+		for len(l.queue) == 0 && !l.closing {
+			l.queueCond.Wait()
+		}
+		if len(l.queue) == 0 {
+			l.queueMu.Unlock()
+			return
+		}
+		n := min(len(l.queue), constants.AuditWriteBatchSize)
+		batch := append([]pendingEntry(nil), l.queue[:n]...)
+		l.inflight = n
+		l.queueMu.Unlock()
+
+		entries, retries, err := l.writeBatch(batch)
+
+		l.queueMu.Lock()
+		l.queue = l.queue[n:]
+		l.inflight = 0
+		l.counters.Retries += uint64(retries)
+		if err != nil {
+			l.counters.Failed += uint64(n)
+			l.counters.LastError = err.Error()
+		} else {
+			l.counters.Written += uint64(n)
+		}
+		l.queueCond.Broadcast()
+		l.queueMu.Unlock()
+
+		for _, entry := range entries {
+			l.notifySubscribers(entry)
+		}
+	}
+}
+
+// writeBatch inserts a batch in one transaction, retrying with exponential
+// backoff while the database is locked or otherwise failing. Returns the
+// stored entries and the number of retries.
+func (l *Logger) writeBatch(batch []pendingEntry) ([]Entry, int, error) {
+	backoff := constants.AuditWriteRetryBackoff
+	var err error
+	for attempt := 1; attempt <= constants.AuditWriteMaxAttempts; attempt++ {
+		var entries []Entry
+		if entries, err = l.insertBatch(batch); err == nil {
+			return entries, attempt - 1, nil
+		}
+		if attempt == constants.AuditWriteMaxAttempts {
+			break
+		}
+		time.Sleep(backoff)
+		backoff = min(backoff*2, constants.AuditWriteMaxBackoff)
+	}
+	return nil, constants.AuditWriteMaxAttempts - 1, err
+}
 
+// insertBatch writes a batch in one transaction.
+func (l *Logger) insertBatch(batch []pendingEntry) ([]Entry, error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	result, err := l.db.Exec(`
-		INSERT INTO audit_log (timestamp, action, ip_address, username, details_json)
-		VALUES (?, ?, ?, ?, ?)
-	`, timestamp, action, ipAddress, username, detailsJSON)
+	tx, err := l.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin audit write: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Ids are assigned here rather than by SQLite because they are hashed
+	lastID, prevHash, err := chainHead(tx)
 	if err != nil {
-		return fmt.Errorf("failed to insert audit log: %w", err)
+		return nil, err
 	}
 
-	id, _ := result.LastInsertId()
+	actorTypes := make(map[string]string)
+	entries := make([]Entry, len(batch))
+	for i, p := range batch {
+		actorType, ok := actorTypes[p.username]
+		if !ok {
+			actorType = actorTypeOf(tx, p.username)
+			actorTypes[p.username] = actorType
+		}
+
+		id := lastID + int64(i) + 1
+		entryHash := hashEntry(prevHash, id, p.timestamp, p.action, p.ipAddress, p.username, actorType, p.detailsJSON)
+		_, err := tx.Exec(`
+			INSERT INTO audit_log (id, timestamp, action, ip_address, username, actor_type, details_json, prev_hash, entry_hash)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, id, p.timestamp, p.action, p.ipAddress, p.username, actorType, p.detailsJSON, prevHash, entryHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert audit log: %w", err)
+		}
 
-	// Notify subscribers (non-blocking)
-	entry := Entry{
-		ID:        id,
-		Timestamp: timestamp,
-		Action:    action,
-		IPAddress: ipAddress,
-		Username:  username,
-		Details:   details,
+		entries[i] = Entry{
+			ID:        id,
+			Timestamp: p.timestamp,
+			Action:    p.action,
+			IPAddress: p.ipAddress,
+			Username:  p.username,
+			ActorType: actorType,
+			Details:   p.details,
+			PrevHash:  prevHash,
+			EntryHash: entryHash,
+		}
+		prevHash = entryHash
 	}
-	l.notifySubscribers(entry)
 
-	return nil
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// actorTypeOf classifies the principal behind an entry. Entries without a
+// username come from the server itself; service and pipeline accounts are
+// looked up in the auth tables, which live in the same orchestrator
+// database. Unknown usernames (anonymous visitors, failed logins) count as
+// users.
+func actorTypeOf(tx *sql.Tx, username string) string {
+	if username == "" {
+		return constants.AuditActorSystem
+	}
+
+	var accountType string
+	err := tx.QueryRow(`SELECT account_type FROM auth_users WHERE username = ?`, username).Scan(&accountType)
+	if err == nil {
+		switch accountType {
+		case constants.AuthAccountTypeService:
+			return constants.AuditActorService
+		case constants.AuthAccountTypePipeline:
+			return constants.AuditActorPipeline
+		}
+	}
+	return constants.AuditActorUser
 }
 
 // Subscribe returns a channel that receives new audit entries
@@ -149,7 +416,7 @@ func (l *Logger) notifySubscribers(entry Entry) {
 	}
 }
 
-// cleanupLoop periodically checks and enforces the log size limit
+// cleanupLoop periodically purges expired entries and enforces the log size limit
 func (l *Logger) cleanupLoop() {
 	ticker := time.NewTicker(time.Duration(constants.AuditCleanupIntervalMins) * time.Minute)
 	defer ticker.Stop()
@@ -159,69 +426,7 @@ func (l *Logger) cleanupLoop() {
 		case <-l.stopClean:
 			return
 		case <-ticker.C:
-			l.enforceLogSizeLimit()
+			l.runScheduledPurge()
 		}
 	}
 }
-
-// enforceLogSizeLimit checks the audit log size and purges oldest entries if needed
-func (l *Logger) enforceLogSizeLimit() {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	// Get current database size using SQLite pragmas
-	var pageCount, pageSize int64
-	if err := l.db.QueryRow("SELECT page_count FROM pragma_page_count()").Scan(&pageCount); err != nil {
-		return
-	}
-	if err := l.db.QueryRow("SELECT page_size FROM pragma_page_size()").Scan(&pageSize); err != nil {
-		return
-	}
-
-	totalSize := pageCount * pageSize
-	if totalSize < l.maxLogSizeBytes {
-		return // Under limit, nothing to do
-	}
-
-	// Calculate how many entries to purge
-	var totalEntries int64
-	if err := l.db.QueryRow("SELECT COUNT(*) FROM audit_log").Scan(&totalEntries); err != nil {
-		return
-	}
-
-	// Purge percentage of entries (or minimum threshold)
-	purgeCount := totalEntries * int64(l.purgePercentage) / 100
-	if purgeCount < int64(constants.AuditMinPurgeEntries) {
-		purgeCount = int64(constants.AuditMinPurgeEntries)
-	}
-
-	// Don't purge more than we have
-	if purgeCount > totalEntries {
-		purgeCount = totalEntries / 2 // Keep at least half
-	}
-
-	if purgeCount <= 0 {
-		return
-	}
-
-	// Transactional delete of oldest entries (lowest IDs)
-	tx, err := l.db.Begin()
-	if err != nil {
-		return
-	}
-	defer tx.Rollback()
-
-	_, err = tx.Exec(`
-		DELETE FROM audit_log
-		WHERE id IN (
-			SELECT id FROM audit_log
-			ORDER BY id ASC
-			LIMIT ?
-		)
-	`, purgeCount)
-	if err != nil {
-		return
-	}
-
-	tx.Commit()
-}
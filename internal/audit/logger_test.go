@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 
@@ -17,6 +18,8 @@ func createTestDB(t *testing.T) *sql.DB {
 	if err != nil {
 		t.Fatalf("failed to open in-memory DB: %v", err)
 	}
+	// Each connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
 
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS audit_log (
@@ -25,10 +28,33 @@ func createTestDB(t *testing.T) *sql.DB {
 			action TEXT NOT NULL,
 			ip_address TEXT NOT NULL,
 			username TEXT NOT NULL DEFAULT '',
+			actor_type TEXT NOT NULL DEFAULT 'user',
 			details_json TEXT,
-			created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+			created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+			prev_hash TEXT NOT NULL DEFAULT '',
+			entry_hash TEXT NOT NULL DEFAULT ''
 		);
 		CREATE INDEX IF NOT EXISTS idx_audit_action ON audit_log(action);
+		CREATE TABLE IF NOT EXISTS audit_chain_gaps (
+			first_id INTEGER PRIMARY KEY,
+			last_id INTEGER NOT NULL,
+			first_prev_hash TEXT NOT NULL,
+			last_hash TEXT NOT NULL,
+			purged_at INTEGER NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS audit_legal_holds (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			username TEXT,
+			action TEXT,
+			since INTEGER,
+			until INTEGER,
+			created_by TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			released_by TEXT,
+			released_at INTEGER
+		);
 	`)
 	if err != nil {
 		t.Fatalf("failed to create audit_log table: %v", err)
@@ -69,6 +95,7 @@ func TestLogAndQueryNewActions(t *testing.T) {
 		{constants.AuditActionMetadataSet, MetadataSetDetails{Hash: "abc123def456", Op: "set", Key: "tag"}},
 		{constants.AuditActionMetadataBatch, MetadataBatchDetails{OperationCount: 50, Succeeded: 48, Failed: 2, Processor: "api"}},
 		{constants.AuditActionMetadataApply, MetadataApplyDetails{QueryPreset: "all_assets", Op: "set", Key: "status", OperationCount: 100, Succeeded: 95, Failed: 5, Processor: "pipeline"}},
+		{constants.AuditActionMetadataImport, MetadataImportDetails{ImportID: "0123456789abcdef", RowCount: 40, OperationCount: 120, Succeeded: 118, Failed: 2, NotFound: 3, Ambiguous: 1, Processor: "csv-import"}},
 		{constants.AuditActionConfigChanged, ConfigChangedDetails{WorkingDirectory: "/data/silobang", IsBootstrap: true}},
 	}
 
@@ -80,6 +107,7 @@ func TestLogAndQueryNewActions(t *testing.T) {
 	}
 
 	// Verify each action was stored and is queryable
+	logger.Flush()
 	for _, tc := range testCases {
 		t.Run(tc.action, func(t *testing.T) {
 			entries, err := Query(db, QueryOptions{Action: tc.action})
@@ -135,6 +163,7 @@ func TestLogDetailsSerialization(t *testing.T) {
 		t.Fatalf("Log failed: %v", err)
 	}
 
+	logger.Flush()
 	entries, err := Query(db, QueryOptions{Action: constants.AuditActionMetadataApply})
 	if err != nil {
 		t.Fatalf("Query failed: %v", err)
@@ -187,6 +216,7 @@ func TestLogLoginFailedDetailsSerialization(t *testing.T) {
 		t.Fatalf("Log failed: %v", err)
 	}
 
+	logger.Flush()
 	entries, err := Query(db, QueryOptions{Action: constants.AuditActionLoginFailed})
 	if err != nil {
 		t.Fatalf("Query failed: %v", err)
@@ -230,6 +260,7 @@ func TestLogUserUpdatedFieldsChanged(t *testing.T) {
 		t.Fatalf("Log failed: %v", err)
 	}
 
+	logger.Flush()
 	entries, err := Query(db, QueryOptions{Action: constants.AuditActionUserUpdated})
 	if err != nil {
 		t.Fatalf("Query failed: %v", err)
@@ -276,6 +307,7 @@ func TestLogGrantCreatedConstraintsFlag(t *testing.T) {
 		t.Fatalf("Log failed: %v", err)
 	}
 
+	logger.Flush()
 	entries, err := Query(db, QueryOptions{Action: constants.AuditActionGrantCreated})
 	if err != nil {
 		t.Fatalf("Query failed: %v", err)
@@ -308,6 +340,7 @@ func TestLogConfigChangedBootstrapFlag(t *testing.T) {
 		t.Fatalf("Log failed: %v", err)
 	}
 
+	logger.Flush()
 	entries, err := Query(db, QueryOptions{Action: constants.AuditActionConfigChanged})
 	if err != nil {
 		t.Fatalf("Query failed: %v", err)
@@ -322,6 +355,86 @@ func TestLogConfigChangedBootstrapFlag(t *testing.T) {
 	}
 }
 
+func TestListConfigChanges(t *testing.T) {
+	logger, db := newTestLogger(t)
+
+	logger.Log(constants.AuditActionConfigChanged, "", "", ConfigChangedDetails{
+		WorkingDirectory: "/data/project",
+		IsBootstrap:      true,
+		Source:           constants.ConfigChangeSourceBootstrap,
+		Changes:          []ConfigFieldChange{{Key: "working_directory", After: "/data/project"}},
+	})
+	logger.Log(constants.AuditActionLoginSuccess, "127.0.0.1", "admin", LoginSuccessDetails{})
+	logger.Log(constants.AuditActionConfigChanged, "127.0.0.1", "admin", ConfigChangedDetails{
+		WorkingDirectory: "/data/project",
+		Source:           constants.ConfigChangeSourceAPI,
+		Changes:          []ConfigFieldChange{{Key: "batch.max_operations", Before: 1000, After: 5000}},
+		Warnings:         []string{"disk_space: low"},
+	})
+	logger.Flush()
+
+	changes, err := ListConfigChanges(db, 0)
+	if err != nil {
+		t.Fatalf("ListConfigChanges failed: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 config changes, got %d", len(changes))
+	}
+	if changes[0].Details.Source != constants.ConfigChangeSourceBootstrap || changes[0].ID >= changes[1].ID {
+		t.Errorf("expected the bootstrap entry first, got %+v", changes)
+	}
+	got := changes[1]
+	if got.Username != "admin" || len(got.Details.Warnings) != 1 || len(got.Details.Changes) != 1 {
+		t.Fatalf("unexpected entry %+v", got)
+	}
+	if after := got.Details.Changes[0].After; after != json.Number("5000") {
+		t.Errorf("expected numbers decoded as json.Number, got %#v", after)
+	}
+
+	if changes, _ := ListConfigChanges(db, changes[0].Timestamp-1); len(changes) != 0 {
+		t.Errorf("expected no changes before the first entry, got %d", len(changes))
+	}
+}
+
+func TestTopDownloadsAndCoDownloads(t *testing.T) {
+	logger, db := newTestLogger(t)
+
+	download := func(username, topic, hash string) {
+		logger.Log(constants.AuditActionDownloaded, "127.0.0.1", username, DownloadedDetails{Hash: hash, Topic: topic})
+	}
+	download("alice", "models", "aaa")
+	download("alice", "models", "bbb")
+	download("bob", "models", "aaa")
+	download("bob", "textures", "ccc")
+	download("", "models", "aaa")
+	download("carol", "models", "bbb")
+	logger.Flush()
+
+	top, err := TopDownloads(db, 0, 1)
+	if err != nil {
+		t.Fatalf("TopDownloads failed: %v", err)
+	}
+	if len(top["models"]) != 1 || top["models"][0].Hash != "aaa" || top["models"][0].Downloads != 3 || top["models"][0].Users != 2 {
+		t.Errorf("unexpected models ranking %+v", top["models"])
+	}
+	if len(top["textures"]) != 1 || top["textures"][0].Hash != "ccc" {
+		t.Errorf("unexpected textures ranking %+v", top["textures"])
+	}
+
+	together, err := CoDownloads(db, "aaa", constants.DiscoveryCoDownloadWindowSecs, 10)
+	if err != nil {
+		t.Fatalf("CoDownloads failed: %v", err)
+	}
+	if len(together) != 2 || together[0].Hash != "bbb" && together[0].Hash != "ccc" {
+		t.Fatalf("expected bbb and ccc downloaded together with aaa, got %+v", together)
+	}
+	for _, c := range together {
+		if c.Users != 1 {
+			t.Errorf("expected one shared user for %s, got %d", c.Hash, c.Users)
+		}
+	}
+}
+
 func TestLogNilDetails(t *testing.T) {
 	logger, db := newTestLogger(t)
 
@@ -330,6 +443,7 @@ func TestLogNilDetails(t *testing.T) {
 		t.Fatalf("Log with nil details failed: %v", err)
 	}
 
+	logger.Flush()
 	entries, err := Query(db, QueryOptions{Action: constants.AuditActionLogout})
 	if err != nil {
 		t.Fatalf("Query failed: %v", err)
@@ -343,6 +457,50 @@ func TestLogNilDetails(t *testing.T) {
 	}
 }
 
+func TestLogActorType(t *testing.T) {
+	logger, db := newTestLogger(t)
+
+	_, err := db.Exec(`
+		CREATE TABLE auth_users (username TEXT NOT NULL, account_type TEXT NOT NULL DEFAULT 'user');
+		INSERT INTO auth_users (username, account_type) VALUES ('alice', 'user'), ('thumbnailer', 'service'), ('nightly-ci', 'pipeline');
+	`)
+	if err != nil {
+		t.Fatalf("failed to create auth_users: %v", err)
+	}
+
+	cases := map[string]string{
+		"alice":       constants.AuditActorUser,
+		"thumbnailer": constants.AuditActorService,
+		"nightly-ci":  constants.AuditActorPipeline,
+		"":            constants.AuditActorSystem,
+		"anonymous":   constants.AuditActorUser,
+	}
+	for username := range cases {
+		if err := logger.Log(constants.AuditActionConnected, "127.0.0.1", username, nil); err != nil {
+			t.Fatalf("Log(%q) failed: %v", username, err)
+		}
+	}
+
+	logger.Flush()
+	entries, err := Query(db, QueryOptions{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for _, e := range entries {
+		if want := cases[e.Username]; e.ActorType != want {
+			t.Errorf("actor_type for %q = %q, want %q", e.Username, e.ActorType, want)
+		}
+	}
+
+	services, err := Query(db, QueryOptions{ActorType: constants.AuditActorService})
+	if err != nil {
+		t.Fatalf("Query(actor_type) failed: %v", err)
+	}
+	if len(services) != 1 || services[0].Username != "thumbnailer" {
+		t.Errorf("actor_type filter returned %+v, want only thumbnailer", services)
+	}
+}
+
 func TestLogEmptyStructDetails(t *testing.T) {
 	logger, db := newTestLogger(t)
 
@@ -352,6 +510,7 @@ func TestLogEmptyStructDetails(t *testing.T) {
 		t.Fatalf("Log with empty struct failed: %v", err)
 	}
 
+	logger.Flush()
 	entries, err := Query(db, QueryOptions{Action: constants.AuditActionLogout})
 	if err != nil {
 		t.Fatalf("Query failed: %v", err)
@@ -424,6 +583,7 @@ func TestLogAllOriginalActionsStillWork(t *testing.T) {
 	}
 
 	// Verify all were stored
+	logger.Flush()
 	for _, tc := range originalCases {
 		entries, err := Query(db, QueryOptions{Action: tc.action})
 		if err != nil {
@@ -498,6 +658,11 @@ func TestDetailStructsJSONTags(t *testing.T) {
 			MetadataApplyDetails{QueryPreset: "q", Op: "set", Key: "k", OperationCount: 1, Succeeded: 1, Failed: 0, Processor: "p"},
 			[]string{"query_preset", "op", "key", "operation_count", "succeeded", "failed", "processor"},
 		},
+		{
+			"MetadataImportDetails",
+			MetadataImportDetails{ImportID: "i", RowCount: 1, OperationCount: 1, Succeeded: 1, Failed: 0, NotFound: 0, Ambiguous: 0, Processor: "p"},
+			[]string{"import_id", "row_count", "operation_count", "succeeded", "failed", "not_found", "ambiguous", "processor"},
+		},
 		{
 			"ConfigChangedDetails",
 			ConfigChangedDetails{WorkingDirectory: "/d", IsBootstrap: true},
@@ -525,3 +690,137 @@ func TestDetailStructsJSONTags(t *testing.T) {
 		})
 	}
 }
+
+// stallWriter holds the write lock and waits until the writer has taken
+// the first queued entry, so further entries stay queued. The returned
+// function releases the writer.
+func stallWriter(t *testing.T, logger *Logger) func() {
+	t.Helper()
+	logger.mu.Lock()
+	if err := logger.Log(constants.AuditActionConnected, "127.0.0.1", "", nil); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		logger.queueMu.Lock()
+		inflight := logger.inflight
+		logger.queueMu.Unlock()
+		if inflight > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("writer did not pick up the entry")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return logger.mu.Unlock
+}
+
+func TestLogQueueDropOldest(t *testing.T) {
+	logger, db := newTestLogger(t)
+	logger.SetQueue(2, constants.AuditOverflowDropOldest)
+
+	release := stallWriter(t, logger)
+	for _, user := range []string{"a", "b", "c"} {
+		if err := logger.Log(constants.AuditActionLogout, "127.0.0.1", user, nil); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+
+	stats := logger.QueueStats()
+	if stats.Depth != 2 || stats.Dropped != 2 || stats.OverflowPolicy != constants.AuditOverflowDropOldest {
+		t.Errorf("unexpected stats while stalled: %+v", stats)
+	}
+
+	release()
+	logger.Flush()
+
+	// The in-flight entry and the newest entry survive
+	entries, err := Query(db, QueryOptions{Action: constants.AuditActionLogout})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Username != "c" {
+		t.Errorf("expected only the newest logout, got %+v", entries)
+	}
+	if stats := logger.QueueStats(); stats.Written != 2 || stats.Depth != 0 {
+		t.Errorf("unexpected stats after flush: %+v", stats)
+	}
+}
+
+func TestLogQueueBlocks(t *testing.T) {
+	logger, _ := newTestLogger(t)
+	logger.SetQueue(1, constants.AuditOverflowBlock)
+
+	release := stallWriter(t, logger)
+	done := make(chan struct{})
+	go func() {
+		logger.Log(constants.AuditActionLogout, "127.0.0.1", "a", nil)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Log should block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Log did not resume once the writer caught up")
+	}
+
+	logger.Flush()
+	if stats := logger.QueueStats(); stats.Blocked != 1 || stats.Dropped != 0 || stats.Written != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestLogRetriesWhileTableUnavailable(t *testing.T) {
+	logger, db := newTestLogger(t)
+
+	if _, err := db.Exec(`ALTER TABLE audit_log RENAME TO audit_log_hidden`); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	if err := logger.Log(constants.AuditActionLogout, "127.0.0.1", "a", nil); err != nil {
+		t.Fatalf("Log should queue despite the failing table: %v", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if _, err := db.Exec(`ALTER TABLE audit_log_hidden RENAME TO audit_log`); err != nil {
+		t.Fatalf("rename back failed: %v", err)
+	}
+
+	logger.Flush()
+	if n := countEntries(t, db, constants.AuditActionLogout); n != 1 {
+		t.Errorf("expected the entry after retrying, got %d", n)
+	}
+	if stats := logger.QueueStats(); stats.Retries == 0 || stats.Failed != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestLogStopFlushesQueue(t *testing.T) {
+	db := createTestDB(t)
+	t.Cleanup(func() { db.Close() })
+	logger := NewLogger(db, constants.AuditMaxLogSizeBytes, constants.AuditPurgePercentage)
+
+	release := stallWriter(t, logger)
+	for _, user := range []string{"a", "b"} {
+		logger.Log(constants.AuditActionLogout, "127.0.0.1", user, nil)
+	}
+	time.AfterFunc(20*time.Millisecond, release)
+
+	logger.Stop()
+	if n := countEntries(t, db, ""); n != 3 {
+		t.Errorf("expected every queued entry written on Stop, got %d", n)
+	}
+
+	if err := logger.Log(constants.AuditActionLogout, "127.0.0.1", "c", nil); err == nil {
+		t.Error("Log after Stop should fail")
+	}
+	if stats := logger.QueueStats(); stats.Dropped != 1 {
+		t.Errorf("expected the late entry counted as dropped, got %+v", stats)
+	}
+}
@@ -0,0 +1,260 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// RetentionPolicy controls how long audit entries are kept. Entries older
+// than their action's retention are purged; 0 days keeps entries until the
+// size limit is hit.
+type RetentionPolicy struct {
+	DefaultDays int
+	ActionDays  map[string]int // per-action overrides of DefaultDays
+}
+
+// PurgeRange describes the entries of one action removed by a purge step.
+type PurgeRange struct {
+	Reason         string `json:"reason"` // "retention" | "size"
+	Action         string `json:"action"`
+	Count          int64  `json:"count"`
+	FirstID        int64  `json:"first_id"`
+	LastID         int64  `json:"last_id"`
+	FirstTimestamp int64  `json:"first_timestamp"`
+	LastTimestamp  int64  `json:"last_timestamp"`
+}
+
+// PurgeReport describes a purge run, or what a run would remove when DryRun is set.
+type PurgeReport struct {
+	Trigger      string       `json:"trigger,omitempty"`
+	DryRun       bool         `json:"dry_run"`
+	RanAt        int64        `json:"ran_at"`
+	Deleted      int64        `json:"deleted"`
+	HeldEntries  int64        `json:"held_entries"`
+	SizeLimitHit bool         `json:"size_limit_hit"`
+	Ranges       []PurgeRange `json:"ranges"`
+}
+
+// SetRetention replaces the retention policy used by subsequent purges.
+func (l *Logger) SetRetention(policy RetentionPolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.retention = policy
+}
+
+// PreviewPurge reports what a purge would remove now without deleting anything.
+func (l *Logger) PreviewPurge() (*PurgeReport, error) {
+	return l.purge("", true)
+}
+
+// Purge removes expired entries, then the oldest entries if the size limit
+// is hit, and records the run as an audit_purged entry.
+func (l *Logger) Purge(trigger, ipAddress, username string) (*PurgeReport, error) {
+	report, err := l.purge(trigger, false)
+	if err != nil {
+		return nil, err
+	}
+	l.logPurge(report, ipAddress, username)
+	l.Flush() // the caller may read the audit_purged entry right away
+	return report, nil
+}
+
+// runScheduledPurge is called by the cleanup loop. Runs that remove nothing
+// are not recorded.
+func (l *Logger) runScheduledPurge() {
+	report, err := l.purge(constants.AuditPurgeTriggerScheduled, false)
+	if err != nil || report.Deleted == 0 {
+		return
+	}
+	l.logPurge(report, "system", "system")
+}
+
+// logPurge records report as an audit_purged entry.
+func (l *Logger) logPurge(report *PurgeReport, ipAddress, username string) {
+	l.Log(constants.AuditActionAuditPurged, ipAddress, username, AuditPurgedDetails{
+		Trigger:      report.Trigger,
+		Deleted:      report.Deleted,
+		HeldEntries:  report.HeldEntries,
+		SizeLimitHit: report.SizeLimitHit,
+		Ranges:       report.Ranges,
+	})
+}
+
+// purge runs both purge steps in one transaction, rolled back when dryRun is
+// set. Queued entries are written first so the run sees every entry.
+func (l *Logger) purge(trigger string, dryRun bool) (*PurgeReport, error) {
+	l.Flush()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	report := &PurgeReport{
+		Trigger: trigger,
+		DryRun:  dryRun,
+		RanAt:   now.Unix(),
+		Ranges:  []PurgeRange{},
+	}
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin purge: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := l.purgeExpired(tx, now, report); err != nil {
+		return nil, err
+	}
+	if err := l.purgeOverSize(tx, report); err != nil {
+		return nil, err
+	}
+	if err := mergeGaps(tx); err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM audit_log a WHERE ` + heldClause).Scan(&report.HeldEntries); err != nil {
+		return nil, fmt.Errorf("failed to count held entries: %w", err)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit purge: %w", err)
+	}
+	return report, nil
+}
+
+// purgeExpired deletes unheld entries older than their action's retention.
+func (l *Logger) purgeExpired(tx *sql.Tx, now time.Time, report *PurgeReport) error {
+	var conds []string
+	var args []interface{}
+	cutoff := func(days int) int64 {
+		return now.Add(-time.Duration(days) * 24 * time.Hour).Unix()
+	}
+
+	overrides := slices.Sorted(maps.Keys(l.retention.ActionDays))
+	for _, action := range overrides {
+		conds = append(conds, "(a.action = ? AND a.timestamp < ?)")
+		args = append(args, action, cutoff(l.retention.ActionDays[action]))
+	}
+	if l.retention.DefaultDays > 0 {
+		cond := "a.timestamp < ?"
+		if len(overrides) > 0 {
+			cond = "(a.action NOT IN (" + placeholders(len(overrides)) + ") AND a.timestamp < ?)"
+			for _, action := range overrides {
+				args = append(args, action)
+			}
+		}
+		conds = append(conds, cond)
+		args = append(args, cutoff(l.retention.DefaultDays))
+	}
+	if len(conds) == 0 {
+		return nil
+	}
+
+	selection := `SELECT a.id, a.action, a.timestamp FROM audit_log a
+		WHERE (` + strings.Join(conds, " OR ") + `) AND NOT ` + heldClause
+	return deleteSelection(tx, selection, args, constants.AuditPurgeReasonRetention, report)
+}
+
+// purgeOverSize deletes the oldest unheld entries when the database exceeds
+// the size limit. Actions with a retention override are kept for their
+// whole retention window.
+func (l *Logger) purgeOverSize(tx *sql.Tx, report *PurgeReport) error {
+	// Get current database size using SQLite pragmas
+	var pageCount, pageSize int64
+	if err := tx.QueryRow("SELECT page_count FROM pragma_page_count()").Scan(&pageCount); err != nil {
+		return fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := tx.QueryRow("SELECT page_size FROM pragma_page_size()").Scan(&pageSize); err != nil {
+		return fmt.Errorf("failed to read page size: %w", err)
+	}
+	if pageCount*pageSize < l.maxLogSizeBytes {
+		return nil // Under limit, nothing to do
+	}
+	report.SizeLimitHit = true
+
+	where := "NOT " + heldClause
+	var args []interface{}
+	if len(l.retention.ActionDays) > 0 {
+		overrides := slices.Sorted(maps.Keys(l.retention.ActionDays))
+		where = "a.action NOT IN (" + placeholders(len(overrides)) + ") AND " + where
+		for _, action := range overrides {
+			args = append(args, action)
+		}
+	}
+
+	var totalEntries int64
+	if err := tx.QueryRow("SELECT COUNT(*) FROM audit_log a WHERE "+where, args...).Scan(&totalEntries); err != nil {
+		return fmt.Errorf("failed to count purgeable entries: %w", err)
+	}
+
+	// Purge percentage of entries (or minimum threshold)
+	purgeCount := totalEntries * int64(l.purgePercentage) / 100
+	if purgeCount < int64(constants.AuditMinPurgeEntries) {
+		purgeCount = int64(constants.AuditMinPurgeEntries)
+	}
+
+	// Don't purge more than we have
+	if purgeCount > totalEntries {
+		purgeCount = totalEntries / 2 // Keep at least half
+	}
+	if purgeCount <= 0 {
+		return nil
+	}
+
+	selection := "SELECT a.id, a.action, a.timestamp FROM audit_log a WHERE " + where + " ORDER BY a.id ASC LIMIT ?"
+	return deleteSelection(tx, selection, append(args, purgeCount), constants.AuditPurgeReasonSize, report)
+}
+
+// deleteSelection deletes the rows returned by selection (id, action,
+// timestamp) and adds one range per action to report.
+func deleteSelection(tx *sql.Tx, selection string, args []interface{}, reason string, report *PurgeReport) error {
+	rows, err := tx.Query(`
+		SELECT action, COUNT(*), MIN(id), MAX(id), MIN(timestamp), MAX(timestamp)
+		FROM (`+selection+`)
+		GROUP BY action ORDER BY action
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to select %s purge: %w", reason, err)
+	}
+	var ranges []PurgeRange
+	for rows.Next() {
+		r := PurgeRange{Reason: reason}
+		if err := rows.Scan(&r.Action, &r.Count, &r.FirstID, &r.LastID, &r.FirstTimestamp, &r.LastTimestamp); err != nil {
+			rows.Close()
+			return err
+		}
+		ranges = append(ranges, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	if err := recordGaps(tx, selection, args, report.RanAt); err != nil {
+		return err
+	}
+	result, err := tx.Exec(`DELETE FROM audit_log WHERE id IN (SELECT id FROM (`+selection+`))`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s purge: %w", reason, err)
+	}
+	deleted, _ := result.RowsAffected()
+	report.Deleted += deleted
+	report.Ranges = append(report.Ranges, ranges...)
+	return nil
+}
+
+// placeholders returns n comma-separated SQL parameter placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,231 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// insertAged inserts an audit entry with a timestamp daysAgo days in the past.
+func insertAged(t *testing.T, db *sql.DB, action, username string, daysAgo int) {
+	t.Helper()
+	ts := time.Now().Add(-time.Duration(daysAgo) * 24 * time.Hour).Unix()
+	if _, err := db.Exec(`INSERT INTO audit_log (timestamp, action, ip_address, username) VALUES (?, ?, '127.0.0.1', ?)`,
+		ts, action, username); err != nil {
+		t.Fatalf("failed to insert entry: %v", err)
+	}
+}
+
+// countEntries counts audit entries, optionally restricted to one action.
+func countEntries(t *testing.T, db *sql.DB, action string) int {
+	t.Helper()
+	var n int
+	query := "SELECT COUNT(*) FROM audit_log"
+	args := []interface{}{}
+	if action != "" {
+		query += " WHERE action = ?"
+		args = append(args, action)
+	}
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("failed to count entries: %v", err)
+	}
+	return n
+}
+
+func TestPurgeRetentionPerAction(t *testing.T) {
+	logger, db := newTestLogger(t)
+	logger.SetRetention(RetentionPolicy{
+		DefaultDays: 30,
+		ActionDays:  map[string]int{constants.AuditActionLoginSuccess: 730},
+	})
+
+	insertAged(t, db, constants.AuditActionDownloaded, "alice", 40)
+	insertAged(t, db, constants.AuditActionDownloaded, "alice", 35)
+	insertAged(t, db, constants.AuditActionDownloaded, "alice", 1)
+	insertAged(t, db, constants.AuditActionLoginSuccess, "alice", 40)
+	insertAged(t, db, constants.AuditActionLoginSuccess, "alice", 800)
+
+	report, err := logger.Purge(constants.AuditPurgeTriggerManual, "10.0.0.1", "admin")
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if report.Deleted != 3 || report.SizeLimitHit || report.DryRun {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.Ranges) != 2 {
+		t.Fatalf("expected 2 ranges, got %+v", report.Ranges)
+	}
+	downloads := report.Ranges[0]
+	if downloads.Action != constants.AuditActionDownloaded || downloads.Count != 2 ||
+		downloads.Reason != constants.AuditPurgeReasonRetention || downloads.FirstID != 1 || downloads.LastID != 2 {
+		t.Errorf("unexpected downloaded range: %+v", downloads)
+	}
+	if report.Ranges[1].Action != constants.AuditActionLoginSuccess || report.Ranges[1].Count != 1 {
+		t.Errorf("unexpected login_success range: %+v", report.Ranges[1])
+	}
+
+	if n := countEntries(t, db, constants.AuditActionDownloaded); n != 1 {
+		t.Errorf("expected 1 download left, got %d", n)
+	}
+	if n := countEntries(t, db, constants.AuditActionLoginSuccess); n != 1 {
+		t.Errorf("expected 1 login left, got %d", n)
+	}
+
+	// The run itself is recorded
+	var username, detailsJSON string
+	err = db.QueryRow(`SELECT username, details_json FROM audit_log WHERE action = ?`, constants.AuditActionAuditPurged).
+		Scan(&username, &detailsJSON)
+	if err != nil {
+		t.Fatalf("audit_purged entry not found: %v", err)
+	}
+	var details AuditPurgedDetails
+	if err := json.Unmarshal([]byte(detailsJSON), &details); err != nil {
+		t.Fatalf("failed to parse details: %v", err)
+	}
+	if username != "admin" || details.Trigger != constants.AuditPurgeTriggerManual || details.Deleted != 3 || len(details.Ranges) != 2 {
+		t.Errorf("unexpected audit_purged entry: %s %+v", username, details)
+	}
+}
+
+func TestPurgeRespectsLegalHolds(t *testing.T) {
+	logger, db := newTestLogger(t)
+	logger.SetRetention(RetentionPolicy{DefaultDays: 30})
+
+	insertAged(t, db, constants.AuditActionDownloaded, "alice", 60)
+	insertAged(t, db, constants.AuditActionDownloaded, "alice", 50)
+	insertAged(t, db, constants.AuditActionDownloaded, "bob", 60)
+
+	alice := "alice"
+	hold, err := CreateHold(db, LegalHold{Name: "case-42", Username: &alice, CreatedBy: "admin"})
+	if err != nil {
+		t.Fatalf("CreateHold failed: %v", err)
+	}
+
+	report, err := logger.Purge(constants.AuditPurgeTriggerManual, "10.0.0.1", "admin")
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if report.Deleted != 1 || report.HeldEntries != 2 {
+		t.Errorf("expected 1 deleted and 2 held, got %+v", report)
+	}
+
+	if _, err := ReleaseHold(db, hold.ID, "admin"); err != nil {
+		t.Fatalf("ReleaseHold failed: %v", err)
+	}
+	if _, err := ReleaseHold(db, hold.ID, "admin"); err != ErrHoldNotFound {
+		t.Errorf("expected ErrHoldNotFound on second release, got %v", err)
+	}
+
+	report, err = logger.Purge(constants.AuditPurgeTriggerManual, "10.0.0.1", "admin")
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if report.Deleted != 2 || report.HeldEntries != 0 {
+		t.Errorf("expected held entries purged after release, got %+v", report)
+	}
+}
+
+func TestPreviewPurgeDeletesNothing(t *testing.T) {
+	logger, db := newTestLogger(t)
+	logger.SetRetention(RetentionPolicy{DefaultDays: 30})
+
+	insertAged(t, db, constants.AuditActionDownloaded, "alice", 60)
+	insertAged(t, db, constants.AuditActionDownloaded, "alice", 1)
+
+	report, err := logger.PreviewPurge()
+	if err != nil {
+		t.Fatalf("PreviewPurge failed: %v", err)
+	}
+	if !report.DryRun || report.Deleted != 1 || len(report.Ranges) != 1 {
+		t.Errorf("unexpected preview: %+v", report)
+	}
+	if n := countEntries(t, db, ""); n != 2 {
+		t.Errorf("preview must not delete or log, got %d entries", n)
+	}
+}
+
+func TestPurgeSizeLimitSkipsOverridesAndHolds(t *testing.T) {
+	db := createTestDB(t)
+	logger := NewLogger(db, 1, constants.AuditPurgePercentage) // always over the limit
+	t.Cleanup(func() {
+		logger.Stop()
+		db.Close()
+	})
+	logger.SetRetention(RetentionPolicy{ActionDays: map[string]int{constants.AuditActionLoginSuccess: 730}})
+
+	for i := 0; i < 6; i++ {
+		insertAged(t, db, constants.AuditActionDownloaded, "bob", 10-i)
+	}
+	insertAged(t, db, constants.AuditActionLoginSuccess, "bob", 20)
+	insertAged(t, db, constants.AuditActionQuerying, "carol", 20)
+	querying := constants.AuditActionQuerying
+	if _, err := CreateHold(db, LegalHold{Name: "keep-queries", Action: &querying, CreatedBy: "admin"}); err != nil {
+		t.Fatalf("CreateHold failed: %v", err)
+	}
+
+	logger.runScheduledPurge()
+	logger.Flush()
+
+	// 6 eligible downloads: the minimum batch exceeds them, so half are purged
+	if n := countEntries(t, db, constants.AuditActionDownloaded); n != 3 {
+		t.Errorf("expected 3 downloads left, got %d", n)
+	}
+	if n := countEntries(t, db, constants.AuditActionLoginSuccess); n != 1 {
+		t.Errorf("login_success within retention must be kept, got %d", n)
+	}
+	if n := countEntries(t, db, constants.AuditActionQuerying); n != 1 {
+		t.Errorf("held entry must be kept, got %d", n)
+	}
+
+	var username string
+	var detailsJSON string
+	if err := db.QueryRow(`SELECT username, details_json FROM audit_log WHERE action = ?`, constants.AuditActionAuditPurged).
+		Scan(&username, &detailsJSON); err != nil {
+		t.Fatalf("audit_purged entry not found: %v", err)
+	}
+	if username != "system" || !strings.Contains(detailsJSON, `"size_limit_hit":true`) || !strings.Contains(detailsJSON, `"reason":"size"`) {
+		t.Errorf("unexpected audit_purged entry: %s %s", username, detailsJSON)
+	}
+}
+
+func TestScheduledPurgeWithNothingToDoIsNotLogged(t *testing.T) {
+	logger, db := newTestLogger(t)
+	logger.SetRetention(RetentionPolicy{DefaultDays: 30})
+	insertAged(t, db, constants.AuditActionDownloaded, "alice", 1)
+
+	logger.runScheduledPurge()
+
+	if n := countEntries(t, db, constants.AuditActionAuditPurged); n != 0 {
+		t.Errorf("expected no audit_purged entry, got %d", n)
+	}
+}
+
+func TestLegalHoldValidate(t *testing.T) {
+	invalid := "not_an_action"
+	since, until := int64(200), int64(100)
+	tests := []struct {
+		name string
+		hold LegalHold
+	}{
+		{"empty name", LegalHold{}},
+		{"long name", LegalHold{Name: strings.Repeat("n", constants.AuditHoldNameMaxLength+1)}},
+		{"long reason", LegalHold{Name: "h", Reason: strings.Repeat("r", constants.AuditHoldReasonMaxLength+1)}},
+		{"invalid action", LegalHold{Name: "h", Action: &invalid}},
+		{"inverted range", LegalHold{Name: "h", Since: &since, Until: &until}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.hold.Validate(); err == nil {
+				t.Errorf("expected validation error")
+			}
+		})
+	}
+
+	if err := (&LegalHold{Name: "h", Until: &until}).Validate(); err != nil {
+		t.Errorf("expected valid hold, got %v", err)
+	}
+}
@@ -15,6 +15,7 @@ type QueryOptions struct {
 	Action             string
 	IPAddress          string
 	Username           string // Filter by specific username
+	ActorType          string // Filter by actor type: "user", "service", "pipeline" or "system"
 	Since              int64  // Unix timestamp
 	Until              int64  // Unix timestamp
 	Filter             string // "me" | "others" | "" (for ME/OTHERS filtering)
@@ -22,6 +23,14 @@ type QueryOptions struct {
 	RequestingUsername  string // Username of the requesting client (used with Filter)
 }
 
+// IsValidActorType checks if an actor type filter value is valid
+func IsValidActorType(actorType string) bool {
+	return actorType == constants.AuditActorUser ||
+		actorType == constants.AuditActorService ||
+		actorType == constants.AuditActorPipeline ||
+		actorType == constants.AuditActorSystem
+}
+
 // IsValidFilter checks if a filter value is valid
 func IsValidFilter(filter string) bool {
 	return filter == constants.AuditFilterMe ||
@@ -29,34 +38,31 @@ func IsValidFilter(filter string) bool {
 		filter == constants.AuditFilterAll
 }
 
-// Query retrieves audit log entries with filters
-func Query(db *sql.DB, opts QueryOptions) ([]Entry, error) {
-	// Apply defaults and limits
-	if opts.Limit <= 0 {
-		opts.Limit = constants.AuditDefaultQueryLimit
-	}
-	if opts.Limit > constants.AuditMaxQueryLimit {
-		opts.Limit = constants.AuditMaxQueryLimit
-	}
-
-	query := `SELECT id, timestamp, action, ip_address, username, details_json
-              FROM audit_log WHERE 1=1`
+// queryFilter returns the WHERE clause and arguments selecting the entries
+// matching opts.
+func queryFilter(opts QueryOptions) (string, []interface{}) {
+	where := " WHERE 1=1"
 	args := []interface{}{}
 
 	if opts.Action != "" {
-		query += " AND action = ?"
+		where += " AND action = ?"
 		args = append(args, opts.Action)
 	}
 
 	// Handle explicit username filter
 	if opts.Username != "" {
-		query += " AND username = ?"
+		where += " AND username = ?"
 		args = append(args, opts.Username)
 	}
 
+	if opts.ActorType != "" {
+		where += " AND actor_type = ?"
+		args = append(args, opts.ActorType)
+	}
+
 	// Handle IP filtering: explicit IPAddress takes precedence over Filter
 	if opts.IPAddress != "" {
-		query += " AND ip_address = ?"
+		where += " AND ip_address = ?"
 		args = append(args, opts.IPAddress)
 	}
 
@@ -64,23 +70,38 @@ func Query(db *sql.DB, opts QueryOptions) ([]Entry, error) {
 	if opts.Filter != "" && opts.RequestingUsername != "" {
 		switch opts.Filter {
 		case constants.AuditFilterMe:
-			query += " AND username = ?"
+			where += " AND username = ?"
 			args = append(args, opts.RequestingUsername)
 		case constants.AuditFilterOthers:
-			query += " AND username != ?"
+			where += " AND username != ?"
 			args = append(args, opts.RequestingUsername)
 		}
 	}
 
 	if opts.Since > 0 {
-		query += " AND timestamp >= ?"
+		where += " AND timestamp >= ?"
 		args = append(args, opts.Since)
 	}
 	if opts.Until > 0 {
-		query += " AND timestamp <= ?"
+		where += " AND timestamp <= ?"
 		args = append(args, opts.Until)
 	}
+	return where, args
+}
+
+// Query retrieves audit log entries with filters
+func Query(db *sql.DB, opts QueryOptions) ([]Entry, error) {
+	// Apply defaults and limits
+	if opts.Limit <= 0 {
+		opts.Limit = constants.AuditDefaultQueryLimit
+	}
+	if opts.Limit > constants.AuditMaxQueryLimit {
+		opts.Limit = constants.AuditMaxQueryLimit
+	}
 
+	where, args := queryFilter(opts)
+	query := `SELECT id, timestamp, action, ip_address, username, actor_type, details_json, prev_hash, entry_hash
+              FROM audit_log` + where
 	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
 	args = append(args, opts.Limit, opts.Offset)
 
@@ -96,7 +117,7 @@ func Query(db *sql.DB, opts QueryOptions) ([]Entry, error) {
 		var detailsJSON sql.NullString
 
 		err := rows.Scan(&entry.ID, &entry.Timestamp, &entry.Action,
-			&entry.IPAddress, &entry.Username, &detailsJSON)
+			&entry.IPAddress, &entry.Username, &entry.ActorType, &detailsJSON, &entry.PrevHash, &entry.EntryHash)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan audit log: %w", err)
 		}
@@ -119,10 +140,10 @@ func GetEntry(db *sql.DB, id int64) (*Entry, error) {
 	var detailsJSON sql.NullString
 
 	err := db.QueryRow(`
-		SELECT id, timestamp, action, ip_address, username, details_json
+		SELECT id, timestamp, action, ip_address, username, actor_type, details_json, prev_hash, entry_hash
 		FROM audit_log WHERE id = ?
 	`, id).Scan(&entry.ID, &entry.Timestamp, &entry.Action,
-		&entry.IPAddress, &entry.Username, &detailsJSON)
+		&entry.IPAddress, &entry.Username, &entry.ActorType, &detailsJSON, &entry.PrevHash, &entry.EntryHash)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -142,48 +163,156 @@ func GetEntry(db *sql.DB, id int64) (*Entry, error) {
 
 // Count returns total number of audit entries matching filters
 func Count(db *sql.DB, opts QueryOptions) (int64, error) {
-	query := `SELECT COUNT(*) FROM audit_log WHERE 1=1`
-	args := []interface{}{}
+	where, args := queryFilter(opts)
+	query := `SELECT COUNT(*) FROM audit_log` + where
 
-	if opts.Action != "" {
-		query += " AND action = ?"
-		args = append(args, opts.Action)
-	}
+	var count int64
+	err := db.QueryRow(query, args...).Scan(&count)
+	return count, err
+}
 
-	// Handle explicit username filter
+// MentionOptions filters audit entries mentioning a value
+type MentionOptions struct {
+	Limit          int      // Newest entries returned (the count covers all)
+	Since          int64    // Unix timestamp, inclusive (0 = unbounded)
+	Until          int64    // Unix timestamp, inclusive (0 = unbounded)
+	Username       string   // Only entries recorded under this username
+	Actions        []string // Only these actions
+	ExcludeActions []string // Never these actions
+	ExcludeID      int64    // Skip this entry
+}
+
+// mentionFilter returns the WHERE clause and arguments selecting entries
+// whose details contain text.
+func mentionFilter(text string, opts MentionOptions) (string, []interface{}) {
+	where := " WHERE instr(details_json, ?) > 0"
+	args := []interface{}{text}
+
+	if opts.Since > 0 {
+		where += " AND timestamp >= ?"
+		args = append(args, opts.Since)
+	}
+	if opts.Until > 0 {
+		where += " AND timestamp <= ?"
+		args = append(args, opts.Until)
+	}
 	if opts.Username != "" {
-		query += " AND username = ?"
+		where += " AND username = ?"
 		args = append(args, opts.Username)
 	}
+	if len(opts.Actions) > 0 {
+		where += " AND action IN (" + placeholders(len(opts.Actions)) + ")"
+		for _, a := range opts.Actions {
+			args = append(args, a)
+		}
+	}
+	if len(opts.ExcludeActions) > 0 {
+		where += " AND action NOT IN (" + placeholders(len(opts.ExcludeActions)) + ")"
+		for _, a := range opts.ExcludeActions {
+			args = append(args, a)
+		}
+	}
+	if opts.ExcludeID > 0 {
+		where += " AND id != ?"
+		args = append(args, opts.ExcludeID)
+	}
+	return where, args
+}
 
-	// Handle IP filtering
-	if opts.IPAddress != "" {
-		query += " AND ip_address = ?"
-		args = append(args, opts.IPAddress)
+// QueryMentions returns the newest entries whose details contain text (an
+// asset hash, say), newest first, and the number of matching entries.
+func QueryMentions(db *sql.DB, text string, opts MentionOptions) ([]Entry, int64, error) {
+	where, args := mentionFilter(text, opts)
+
+	var total int64
+	if err := db.QueryRow(`SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit mentions: %w", err)
+	}
+	if opts.Limit <= 0 || total == 0 {
+		return []Entry{}, total, nil
 	}
 
-	// Apply ME/OTHERS filter using username
-	if opts.Filter != "" && opts.RequestingUsername != "" {
-		switch opts.Filter {
-		case constants.AuditFilterMe:
-			query += " AND username = ?"
-			args = append(args, opts.RequestingUsername)
-		case constants.AuditFilterOthers:
-			query += " AND username != ?"
-			args = append(args, opts.RequestingUsername)
+	rows, err := db.Query(`SELECT id, timestamp, action, ip_address, username, actor_type, details_json, prev_hash, entry_hash
+		FROM audit_log`+where+` ORDER BY id DESC LIMIT ?`, append(args, opts.Limit)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit mentions: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		var detailsJSON sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.Timestamp, &entry.Action,
+			&entry.IPAddress, &entry.Username, &entry.ActorType, &detailsJSON, &entry.PrevHash, &entry.EntryHash); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if detailsJSON.Valid {
+			var details interface{}
+			json.Unmarshal([]byte(detailsJSON.String), &details)
+			entry.Details = details
 		}
+		entries = append(entries, entry)
 	}
 
-	if opts.Since > 0 {
-		query += " AND timestamp >= ?"
-		args = append(args, opts.Since)
+	return entries, total, rows.Err()
+}
+
+// FirstMention returns the oldest entry of action whose details contain
+// text, or nil when there is none.
+func FirstMention(db *sql.DB, text, action string) (*Entry, error) {
+	var id int64
+	err := db.QueryRow(`SELECT id FROM audit_log WHERE action = ? AND instr(details_json, ?) > 0 ORDER BY id LIMIT 1`,
+		action, text).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	if opts.Until > 0 {
-		query += " AND timestamp <= ?"
-		args = append(args, opts.Until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit mentions: %w", err)
 	}
+	return GetEntry(db, id)
+}
 
-	var count int64
-	err := db.QueryRow(query, args...).Scan(&count)
-	return count, err
+// QueryAfter returns up to limit entries of actions recorded after the entry
+// afterID, oldest first. A non-empty username restricts them to the entries
+// recorded under it.
+func QueryAfter(db *sql.DB, afterID int64, actions []string, username string, limit int) ([]Entry, error) {
+	if len(actions) == 0 || limit <= 0 {
+		return []Entry{}, nil
+	}
+
+	where := " WHERE id > ? AND action IN (" + placeholders(len(actions)) + ")"
+	args := []interface{}{afterID}
+	for _, a := range actions {
+		args = append(args, a)
+	}
+	if username != "" {
+		where += " AND username = ?"
+		args = append(args, username)
+	}
+
+	rows, err := db.Query(`SELECT id, timestamp, action, ip_address, username, actor_type, details_json, prev_hash, entry_hash
+		FROM audit_log`+where+` ORDER BY id LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		var detailsJSON sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.Timestamp, &entry.Action,
+			&entry.IPAddress, &entry.Username, &entry.ActorType, &detailsJSON, &entry.PrevHash, &entry.EntryHash); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if detailsJSON.Valid {
+			var details interface{}
+			json.Unmarshal([]byte(detailsJSON.String), &details)
+			entry.Details = details
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
 }
@@ -0,0 +1,53 @@
+package audit
+
+import "database/sql"
+
+// Store reads the audit log. Entries are written by the Logger, which
+// extends the hash chain in its own transactions.
+//
+// SQLiteStore is the only implementation; services reach the log through
+// AppState.GetAuditStore so another backend can replace it.
+type Store interface {
+	Query(opts QueryOptions) ([]Entry, error)
+	GetEntry(id int64) (*Entry, error)
+	Count(opts QueryOptions) (int64, error)
+	QueryMentions(text string, opts MentionOptions) ([]Entry, int64, error)
+	FirstMention(text, action string) (*Entry, error)
+	QueryAfter(afterID int64, actions []string, username string, limit int) ([]Entry, error)
+}
+
+// SQLiteStore is the Store of a SQLite orchestrator database.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+var _ Store = (*SQLiteStore)(nil)
+
+// NewSQLiteStore returns the audit log stored in db.
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+func (s *SQLiteStore) Query(opts QueryOptions) ([]Entry, error) {
+	return Query(s.db, opts)
+}
+
+func (s *SQLiteStore) GetEntry(id int64) (*Entry, error) {
+	return GetEntry(s.db, id)
+}
+
+func (s *SQLiteStore) Count(opts QueryOptions) (int64, error) {
+	return Count(s.db, opts)
+}
+
+func (s *SQLiteStore) QueryMentions(text string, opts MentionOptions) ([]Entry, int64, error) {
+	return QueryMentions(s.db, text, opts)
+}
+
+func (s *SQLiteStore) FirstMention(text, action string) (*Entry, error) {
+	return FirstMention(s.db, text, action)
+}
+
+func (s *SQLiteStore) QueryAfter(afterID int64, actions []string, username string, limit int) ([]Entry, error) {
+	return QueryAfter(s.db, afterID, actions, username, limit)
+}
@@ -11,7 +11,10 @@ type Entry struct {
 	Action    string      `json:"action"`
 	IPAddress string      `json:"ip_address"`
 	Username  string      `json:"username"`
+	ActorType string      `json:"actor_type"`
 	Details   interface{} `json:"details,omitempty"`
+	PrevHash  string      `json:"prev_hash"`  // entry_hash of the previous entry, empty before chaining
+	EntryHash string      `json:"entry_hash"` // hash over prev_hash and this entry's columns
 }
 
 // Event follows the existing SSE event pattern for real-time streaming
@@ -37,34 +40,54 @@ type AddingTopicDetails struct {
 
 // QueryingDetails holds details for querying action
 type QueryingDetails struct {
-	Preset   string   `json:"preset"`
-	Topics   []string `json:"topics,omitempty"`
-	RowCount int      `json:"row_count"`
+	Preset    string   `json:"preset"`
+	Topics    []string `json:"topics,omitempty"`
+	RowCount  int      `json:"row_count"`
+	Instances []string `json:"instances,omitempty"` // federated queries: instances that answered
+}
+
+// SyncDiffDetails holds details for sync_diff action
+type SyncDiffDetails struct {
+	Topic     string `json:"topic,omitempty"`
+	Total     int    `json:"total"`
+	Present   int    `json:"present"`
+	Missing   int    `json:"missing"`
+	Elsewhere int    `json:"elsewhere"`
+	Invalid   int    `json:"invalid"`
 }
 
 // AddingFileDetails holds details for adding_file action
 type AddingFileDetails struct {
-	Hash      string `json:"hash"`
-	TopicName string `json:"topic_name"`
-	Filename  string `json:"filename"`
-	Size      int64  `json:"size"`
-	Skipped   bool   `json:"skipped"`
+	Hash         string `json:"hash"`
+	TopicName    string `json:"topic_name"`
+	Filename     string `json:"filename"`
+	RelativePath string `json:"relative_path,omitempty"`
+	Size         int64  `json:"size"`
+	Skipped      bool   `json:"skipped"`
 }
 
-// VerifiedDetails holds details for verified action
+// VerifiedDetails holds details for verified action. The scrubber records
+// one entry per topic with Source set and the asset counts filled in.
 type VerifiedDetails struct {
-	TopicsChecked int  `json:"topics_checked"`
-	TopicsValid   int  `json:"topics_valid"`
-	IndexValid    bool `json:"index_valid"`
-	DurationMs    int  `json:"duration_ms"`
+	TopicsChecked int    `json:"topics_checked"`
+	TopicsValid   int    `json:"topics_valid"`
+	IndexValid    bool   `json:"index_valid"`
+	DurationMs    int    `json:"duration_ms"`
+	Source        string `json:"source,omitempty"`
+	Topic         string `json:"topic,omitempty"`
+	AssetsChecked int    `json:"assets_checked,omitempty"`
+	Corrupt       int    `json:"corrupt,omitempty"`
+	Missing       int    `json:"missing,omitempty"`
+	BytesRead     int64  `json:"bytes_read,omitempty"`
 }
 
 // DownloadedDetails holds details for downloaded action
 type DownloadedDetails struct {
-	Hash     string `json:"hash"`
-	Topic    string `json:"topic"`
-	Filename string `json:"filename"`
-	Size     int64  `json:"size"`
+	Hash      string `json:"hash"`
+	Topic     string `json:"topic"`
+	Filename  string `json:"filename"`
+	Size      int64  `json:"size"`
+	Watermark string `json:"watermark,omitempty"` // profile applied to the served bytes
 }
 
 // DownloadedBulkDetails holds details for downloaded_bulk action
@@ -74,12 +97,29 @@ type DownloadedBulkDetails struct {
 	TotalSize  int64    `json:"total_size"`
 	Topics     []string `json:"topics,omitempty"`
 	Preset     string   `json:"preset,omitempty"`
+	Recipients []string `json:"recipients,omitempty"` // encrypted archives: recipient IDs
+	ExportID   string   `json:"export_id,omitempty"`  // built into the requester's export inbox
+}
+
+// DownloadCancelledDetails holds details for download_cancelled action
+type DownloadCancelledDetails struct {
+	DownloadID      string   `json:"download_id,omitempty"` // empty for a ZIP streamed by POST /api/download/bulk
+	Mode            string   `json:"mode"`
+	Reason          string   `json:"reason"` // "requested" or "disconnected"
+	TotalAssets     int      `json:"total_assets"`
+	TotalBytes      int64    `json:"total_bytes"`
+	ProcessedAssets int      `json:"processed_assets"` // assets written before cancellation
+	ProcessedBytes  int64    `json:"processed_bytes"`  // asset bytes written before cancellation
+	ZIPBytes        int64    `json:"zip_bytes"`        // size of the partial ZIP removed, or sent when streamed
+	Topics          []string `json:"topics,omitempty"`
+	Preset          string   `json:"preset,omitempty"`
 }
 
 // ReconcileTopicRemovedDetails holds details for reconcile_topic_removed action
 type ReconcileTopicRemovedDetails struct {
-	TopicName     string `json:"topic_name"`
-	EntriesPurged int64  `json:"entries_purged"`
+	TopicName          string `json:"topic_name"`
+	EntriesPurged      int64  `json:"entries_purged"`
+	DanglingReferences int    `json:"dangling_references,omitempty"` // references from other topics to the removed assets
 }
 
 // =============================================================================
@@ -89,6 +129,7 @@ type ReconcileTopicRemovedDetails struct {
 // LoginSuccessDetails holds details for login_success action
 type LoginSuccessDetails struct {
 	UserAgent string `json:"user_agent"`
+	Method    string `json:"method,omitempty"` // "sso" for single sign-on; empty for passwords
 }
 
 // LoginFailedDetails holds details for login_failed action
@@ -96,11 +137,37 @@ type LoginFailedDetails struct {
 	AttemptedUsername string `json:"attempted_username"`
 	Reason           string `json:"reason"`
 	UserAgent        string `json:"user_agent"`
+	Method            string `json:"method,omitempty"` // "sso" for single sign-on; empty for passwords
 }
 
 // LogoutDetails holds details for logout action
 type LogoutDetails struct{}
 
+// =============================================================================
+// Detail Structs — Admin Recovery
+// =============================================================================
+
+// AdminRecoveryIssuedDetails holds details for admin_recovery_issued action
+type AdminRecoveryIssuedDetails struct {
+	TokenPrefix string `json:"token_prefix"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+// AdminRecoveredDetails holds details for admin_recovered action
+type AdminRecoveredDetails struct {
+	TokenPrefix    string `json:"token_prefix"`
+	TargetUserID   int64  `json:"target_user_id"`
+	TargetUsername string `json:"target_username"`
+	UserAgent      string `json:"user_agent"`
+}
+
+// AdminRecoveryFailedDetails holds details for admin_recovery_failed action
+type AdminRecoveryFailedDetails struct {
+	TokenPrefix string `json:"token_prefix"`
+	Reason      string `json:"reason"`
+	UserAgent   string `json:"user_agent"`
+}
+
 // =============================================================================
 // Detail Structs — User Management
 // =============================================================================
@@ -124,6 +191,30 @@ type APIKeyRegeneratedDetails struct {
 	TargetUsername string `json:"target_username"`
 }
 
+// =============================================================================
+// Detail Structs — Service Accounts
+// =============================================================================
+
+// ServiceAccountCreatedDetails holds details for service_account_created action
+type ServiceAccountCreatedDetails struct {
+	AccountID   int64    `json:"account_id"`
+	AccountName string   `json:"account_name"`
+	Actions     []string `json:"actions"`
+}
+
+// ServiceAccountRotatedDetails holds details for service_account_token_rotated action
+type ServiceAccountRotatedDetails struct {
+	AccountID   int64  `json:"account_id"`
+	AccountName string `json:"account_name"`
+	TokenPrefix string `json:"token_prefix"`
+}
+
+// ServiceAccountDisabledDetails holds details for service_account_disabled action
+type ServiceAccountDisabledDetails struct {
+	AccountID   int64  `json:"account_id"`
+	AccountName string `json:"account_name"`
+}
+
 // =============================================================================
 // Detail Structs — Grant Management
 // =============================================================================
@@ -151,6 +242,28 @@ type GrantRevokedDetails struct {
 	Action       string `json:"action"`
 }
 
+// GrantBatchDetails holds details for grant_batch action: every grant
+// created by one batch or copy request
+type GrantBatchDetails struct {
+	Mode          string   `json:"mode"` // "list" or "copy"
+	SourceUserID  int64    `json:"source_user_id,omitempty"`
+	TargetUserIDs []int64  `json:"target_user_ids"`
+	GrantIDs      []int64  `json:"grant_ids"`
+	Actions       []string `json:"actions"`
+	Count         int      `json:"count"`
+}
+
+// AuthImportedDetails holds details for auth_imported action: the changes
+// one auth snapshot import applied
+type AuthImportedDetails struct {
+	Prune         bool     `json:"prune"`
+	UsersCreated  []string `json:"users_created,omitempty"`
+	UsersUpdated  []string `json:"users_updated,omitempty"`
+	UsersDisabled []string `json:"users_disabled,omitempty"`
+	GrantsCreated int      `json:"grants_created"`
+	GrantsRevoked int      `json:"grants_revoked"`
+}
+
 // =============================================================================
 // Detail Structs — Metadata Operations
 // =============================================================================
@@ -181,14 +294,71 @@ type MetadataApplyDetails struct {
 	Processor      string `json:"processor"`
 }
 
+// MetadataImportDetails holds details for metadata_import action
+type MetadataImportDetails struct {
+	ImportID       string `json:"import_id"`
+	RowCount       int    `json:"row_count"`
+	OperationCount int    `json:"operation_count"`
+	Succeeded      int    `json:"succeeded"`
+	Failed         int    `json:"failed"`
+	NotFound       int    `json:"not_found"`
+	Ambiguous      int    `json:"ambiguous"`
+	Processor      string `json:"processor"`
+}
+
+// =============================================================================
+// Detail Structs — Collections
+// =============================================================================
+
+// CollectionCreatedDetails holds details for collection_created action
+type CollectionCreatedDetails struct {
+	TopicName  string `json:"topic_name"`
+	Collection string `json:"collection"`
+}
+
+// CollectionUpdatedDetails holds details for collection_updated action
+type CollectionUpdatedDetails struct {
+	TopicName  string `json:"topic_name"`
+	Collection string `json:"collection"`
+}
+
+// CollectionDeletedDetails holds details for collection_deleted action
+type CollectionDeletedDetails struct {
+	TopicName   string `json:"topic_name"`
+	Collection  string `json:"collection"`
+	AssetsFreed int64  `json:"assets_freed"`
+}
+
+// CollectionAssetsDetails holds details for collection_assets action
+type CollectionAssetsDetails struct {
+	TopicName  string `json:"topic_name"`
+	Collection string `json:"collection"`
+	Op         string `json:"op"` // "add" | "remove"
+	Requested  int    `json:"requested"`
+	Changed    int64  `json:"changed"`
+}
+
 // =============================================================================
 // Detail Structs — Configuration
 // =============================================================================
 
 // ConfigChangedDetails holds details for config_changed action
 type ConfigChangedDetails struct {
-	WorkingDirectory string `json:"working_directory"`
-	IsBootstrap      bool   `json:"is_bootstrap"`
+	WorkingDirectory string              `json:"working_directory"`
+	IsBootstrap      bool                `json:"is_bootstrap"`
+	Fields           []string            `json:"fields,omitempty"`   // runtime changes: config fields updated
+	Source           string              `json:"source,omitempty"`   // api, bootstrap or cli
+	Changes          []ConfigFieldChange `json:"changes,omitempty"`  // changed keys, sorted
+	Warnings         []string            `json:"warnings,omitempty"` // validation warnings of the new config
+}
+
+// ConfigFieldChange is one changed config key, such as
+// storage_policies.txt.compression or federation.peers[0].url. Before or
+// After is absent when the key was added or removed.
+type ConfigFieldChange struct {
+	Key    string      `json:"key"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
 }
 
 // =============================================================================
@@ -200,6 +370,311 @@ type DiskLimitHitDetails struct {
 	Operation      string `json:"operation"`
 	DiskUsedBytes  uint64 `json:"disk_used_bytes"`
 	DiskLimitBytes int64  `json:"disk_limit_bytes"`
+	RequiredBytes  int64  `json:"required_bytes,omitempty"` // upload size when rejected for lack of headroom
+	HeadroomBytes  int64  `json:"headroom_bytes,omitempty"`
+}
+
+// =============================================================================
+// Detail Structs — Audit Retention
+// =============================================================================
+
+// AuditPurgedDetails holds details for audit_purged action
+type AuditPurgedDetails struct {
+	Trigger      string       `json:"trigger"` // "scheduled" | "manual"
+	Deleted      int64        `json:"deleted"`
+	HeldEntries  int64        `json:"held_entries"`
+	SizeLimitHit bool         `json:"size_limit_hit"`
+	Ranges       []PurgeRange `json:"ranges"`
+}
+
+// AuditExportedDetails holds details for audit_exported action
+type AuditExportedDetails struct {
+	Format  string `json:"format"` // "csv" | "jsonl"
+	Entries int64  `json:"entries"`
+	Action  string `json:"action,omitempty"`
+	Since   int64  `json:"since,omitempty"`
+	Until   int64  `json:"until,omitempty"`
+}
+
+// AuditHoldCreatedDetails holds details for audit_hold_created action
+type AuditHoldCreatedDetails struct {
+	HoldID   int64   `json:"hold_id"`
+	Name     string  `json:"name"`
+	Username *string `json:"username,omitempty"`
+	Action   *string `json:"action,omitempty"`
+	Since    *int64  `json:"since,omitempty"`
+	Until    *int64  `json:"until,omitempty"`
+}
+
+// AuditHoldReleasedDetails holds details for audit_hold_released action
+type AuditHoldReleasedDetails struct {
+	HoldID int64  `json:"hold_id"`
+	Name   string `json:"name"`
+}
+
+// AssetQuarantinedDetails holds details for asset_quarantined action
+type AssetQuarantinedDetails struct {
+	Hash   string `json:"hash"`
+	Topic  string `json:"topic"`
+	Source string `json:"source"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// AssetReleasedDetails holds details for asset_released action
+type AssetReleasedDetails struct {
+	Hash          string `json:"hash"`
+	Topic         string `json:"topic"`
+	Source        string `json:"source"`
+	Justification string `json:"justification"`
+}
+
+// AssetDeletedDetails holds details for asset_deleted action
+type AssetDeletedDetails struct {
+	Hash       string `json:"hash"`
+	Topic      string `json:"topic"`
+	Size       int64  `json:"size"`
+	OriginName string `json:"origin_name,omitempty"`
+	Extension  string `json:"extension,omitempty"`
+	DatFile    string `json:"dat_file"`
+}
+
+// AssetsArchivedDetails holds details for assets_archived action
+type AssetsArchivedDetails struct {
+	Topic     string   `json:"topic"`
+	Archive   string   `json:"archive"`
+	Trigger   string   `json:"trigger"` // "scheduled" | "manual"
+	DryRun    bool     `json:"dry_run"`
+	Hashes    []string `json:"hashes"` // assets archived, or that a dry run would archive
+	Bytes     int64    `json:"bytes"`
+	Skipped   []string `json:"skipped,omitempty"` // idle assets that could not be archived
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// AssetRecallDetails holds details for asset_recall_requested and
+// asset_recalled actions
+type AssetRecallDetails struct {
+	Hash    string `json:"hash"`
+	Topic   string `json:"topic"`
+	Archive string `json:"archive"`
+	Size    int64  `json:"size"`
+	DatFile string `json:"dat_file,omitempty"` // where a recalled asset was written back
+}
+
+// DeletionRequestedDetails holds details for deletion_requested action
+type DeletionRequestedDetails struct {
+	RequestID  int64    `json:"request_id"`
+	Mode       string   `json:"mode"`             // "ids" | "query"
+	Preset     string   `json:"preset,omitempty"` // for mode "query"
+	Topics     []string `json:"topics"`
+	AssetCount int      `json:"asset_count"`
+	Bytes      int64    `json:"bytes"`
+	Reason     string   `json:"reason,omitempty"`
+	ExpiresAt  int64    `json:"expires_at"`
+}
+
+// DeletionDecidedDetails holds details for deletion_approved,
+// deletion_rejected, deletion_cancelled and deletion_expired actions
+type DeletionDecidedDetails struct {
+	RequestID   int64    `json:"request_id"`
+	RequestedBy string   `json:"requested_by"`
+	Topics      []string `json:"topics"`
+	AssetCount  int      `json:"asset_count"`
+	Comment     string   `json:"comment,omitempty"`
+}
+
+// DeletionCompletedDetails holds details for deletion_completed action. Each
+// deleted asset also has its own asset_deleted entry.
+type DeletionCompletedDetails struct {
+	RequestID  int64  `json:"request_id"`
+	ApprovedBy string `json:"approved_by"`
+	Deleted    int    `json:"deleted"`
+	Failed     int    `json:"failed"`
+	Bytes      int64  `json:"bytes"` // size of the deleted assets
+}
+
+// AssetTrashedDetails holds details for asset_trashed action
+type AssetTrashedDetails struct {
+	Hash       string `json:"hash"`
+	Topic      string `json:"topic"`
+	Size       int64  `json:"size"`
+	OriginName string `json:"origin_name,omitempty"`
+	Extension  string `json:"extension,omitempty"`
+}
+
+// AssetRestoredDetails holds details for asset_restored action
+type AssetRestoredDetails struct {
+	Hash      string `json:"hash"`
+	Topic     string `json:"topic"`
+	TrashedBy string `json:"trashed_by"`
+	TrashedAt int64  `json:"trashed_at"`
+}
+
+// RetentionAppliedDetails holds details for retention_applied action
+type RetentionAppliedDetails struct {
+	Topic     string   `json:"topic"`
+	Action    string   `json:"action"`  // "trash" | "delete"
+	Trigger   string   `json:"trigger"` // "scheduled" | "manual"
+	DryRun    bool     `json:"dry_run"`
+	Hashes    []string `json:"hashes"` // assets removed, or that a dry run would remove
+	Bytes     int64    `json:"bytes"`
+	Skipped   []string `json:"skipped,omitempty"` // assets over a limit that could not be removed
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// WatchFolderIngestedDetails holds details for watch_folder_ingested action,
+// one per scan that ingested or quarantined files
+type WatchFolderIngestedDetails struct {
+	Folder       string `json:"folder"`
+	Topic        string `json:"topic"`
+	Trigger      string `json:"trigger"` // "event" | "scheduled" | "manual"
+	Ingested     int    `json:"ingested"`
+	Deduplicated int    `json:"deduplicated"`
+	Failed       int    `json:"failed"` // files moved to failed/
+	Bytes        int64  `json:"bytes"`
+}
+
+// LineageReparentedDetails holds details for lineage_reparented action
+type LineageReparentedDetails struct {
+	Topics      []string `json:"topics"`
+	Changed     int      `json:"changed"`
+	Unchanged   int      `json:"unchanged"`
+	QueryPreset string   `json:"query_preset,omitempty"`
+	Reason      string   `json:"reason,omitempty"`
+}
+
+// WebhookDetails holds details for webhook_created, webhook_updated and
+// webhook_deleted actions
+type WebhookDetails struct {
+	WebhookID int64    `json:"webhook_id"`
+	Name      string   `json:"name"`
+	Events    []string `json:"events,omitempty"` // empty = every action
+	Active    bool     `json:"active"`
+}
+
+// RuleDetails holds details for rule_created, rule_updated and
+// rule_deleted actions
+type RuleDetails struct {
+	RuleID  int64  `json:"rule_id"`
+	Name    string `json:"name"`
+	Trigger string `json:"trigger"`
+	Action  string `json:"action"`
+	Enabled bool   `json:"enabled"`
+}
+
+// RuleExecutedDetails holds details for rule_executed action
+type RuleExecutedDetails struct {
+	RuleID  int64  `json:"rule_id"`
+	Name    string `json:"name"`
+	RunID   int64  `json:"run_id"`
+	Trigger string `json:"trigger"` // schedule, event or manual
+	Action  string `json:"action"`
+	Matched int    `json:"matched"`
+	Applied int    `json:"applied"`
+	Failed  int    `json:"failed"`
+}
+
+// DebugBundleDetails holds details for debug_bundle action
+type DebugBundleDetails struct {
+	Seconds int   `json:"seconds"` // CPU profile length
+	Size    int64 `json:"size"`
+}
+
+// BackupCreatedDetails holds details for backup_created action
+type BackupCreatedDetails struct {
+	Topics     int    `json:"topics"`
+	Files      int    `json:"files"`
+	Bytes      int64  `json:"bytes"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"` // Set when the archive could not be completed
+}
+
+// =============================================================================
+// Detail Structs — Workspace Tokens
+// =============================================================================
+
+// WorkspaceTokenCreatedDetails holds details for workspace_token_created action
+type WorkspaceTokenCreatedDetails struct {
+	TokenID     int64    `json:"token_id"`
+	Pipeline    string   `json:"pipeline"`
+	TokenPrefix string   `json:"token_prefix"`
+	Actions     []string `json:"actions"`
+	ExpiresAt   int64    `json:"expires_at"`
+}
+
+// WorkspaceTokenExchangedDetails holds details for workspace_token_exchanged
+// action. The entry is recorded under the pipeline's username.
+type WorkspaceTokenExchangedDetails struct {
+	TokenID        int64  `json:"token_id"`
+	TokenPrefix    string `json:"token_prefix"`
+	Job            string `json:"job,omitempty"`
+	JobTokenPrefix string `json:"job_token_prefix"`
+	ExpiresAt      int64  `json:"expires_at"`
+}
+
+// WorkspaceTokenRevokedDetails holds details for workspace_token_revoked action
+type WorkspaceTokenRevokedDetails struct {
+	TokenIDs []int64 `json:"token_ids"`
+	Pipeline string  `json:"pipeline,omitempty"`
+	All      bool    `json:"all,omitempty"`
+}
+
+// =============================================================================
+// Detail Structs — API Keys
+// =============================================================================
+
+// APIKeyCreatedDetails holds details for api_key_created action
+type APIKeyCreatedDetails struct {
+	TargetUserID   int64  `json:"target_user_id"`
+	TargetUsername string `json:"target_username"`
+	KeyID          int64  `json:"key_id"`
+	Label          string `json:"label"`
+	KeyPrefix      string `json:"key_prefix"`
+	ExpiresAt      *int64 `json:"expires_at,omitempty"`
+}
+
+// APIKeyRotatedDetails holds details for api_key_rotated action. The old
+// key keeps working until GraceUntil.
+type APIKeyRotatedDetails struct {
+	TargetUserID   int64  `json:"target_user_id"`
+	TargetUsername string `json:"target_username"`
+	Label          string `json:"label"`
+	OldKeyPrefix   string `json:"old_key_prefix"`
+	NewKeyPrefix   string `json:"new_key_prefix"`
+	GraceUntil     int64  `json:"grace_until"`
+	ExpiresAt      *int64 `json:"expires_at,omitempty"`
+}
+
+// APIKeyRevokedDetails holds details for api_key_revoked action
+type APIKeyRevokedDetails struct {
+	TargetUserID   int64  `json:"target_user_id"`
+	TargetUsername string `json:"target_username"`
+	KeyID          int64  `json:"key_id"`
+	Label          string `json:"label"`
+	KeyPrefix      string `json:"key_prefix"`
+}
+
+// =============================================================================
+// Detail Structs — Link Exports
+// =============================================================================
+
+// LinkExportSelectedDetails holds details for link_export_selected action
+type LinkExportSelectedDetails struct {
+	ExportID   string   `json:"export_id"`
+	Mode       string   `json:"mode"`
+	Preset     string   `json:"preset,omitempty"`
+	Topics     []string `json:"topics,omitempty"`
+	Linked     int      `json:"linked"`
+	Unchanged  int      `json:"unchanged"`
+	Released   int      `json:"released,omitempty"` // dropped by a replacing selection
+	Failed     int      `json:"failed,omitempty"`
+	LinkedSize int64    `json:"linked_size"`
+}
+
+// LinkExportReleasedDetails holds details for link_export_released action
+type LinkExportReleasedDetails struct {
+	ExportID string `json:"export_id"`
+	Released int    `json:"released"`
+	Deleted  bool   `json:"deleted"` // the whole export was removed
 }
 
 // =============================================================================
@@ -217,27 +692,98 @@ func ValidActions() []string {
 		constants.AuditActionVerified,
 		constants.AuditActionDownloaded,
 		constants.AuditActionDownloadedBulk,
+		constants.AuditActionDownloadCancelled,
 		constants.AuditActionReconcileTopicRemoved,
+		constants.AuditActionSyncDiff,
 		// Authentication
 		constants.AuditActionLoginSuccess,
 		constants.AuditActionLoginFailed,
 		constants.AuditActionLogout,
+		// Admin recovery
+		constants.AuditActionAdminRecoveryIssued,
+		constants.AuditActionAdminRecovered,
+		constants.AuditActionAdminRecoveryFailed,
 		// User management
 		constants.AuditActionUserCreated,
 		constants.AuditActionUserUpdated,
 		constants.AuditActionAPIKeyRegenerated,
+		// Service accounts
+		constants.AuditActionServiceAccountCreated,
+		constants.AuditActionServiceAccountRotated,
+		constants.AuditActionServiceAccountDisabled,
 		// Grant management
 		constants.AuditActionGrantCreated,
 		constants.AuditActionGrantUpdated,
 		constants.AuditActionGrantRevoked,
+		constants.AuditActionGrantBatch,
+		constants.AuditActionAuthImported,
 		// Metadata
 		constants.AuditActionMetadataSet,
 		constants.AuditActionMetadataBatch,
 		constants.AuditActionMetadataApply,
+		constants.AuditActionMetadataImport,
+		// Collections
+		constants.AuditActionCollectionCreated,
+		constants.AuditActionCollectionUpdated,
+		constants.AuditActionCollectionDeleted,
+		constants.AuditActionCollectionAssets,
 		// Configuration
 		constants.AuditActionConfigChanged,
 		// Disk Usage
 		constants.AuditActionDiskLimitHit,
+		// Audit Retention
+		constants.AuditActionAuditPurged,
+		constants.AuditActionAuditHoldCreated,
+		constants.AuditActionAuditHoldReleased,
+		constants.AuditActionAuditExported,
+		// Quarantine
+		constants.AuditActionAssetQuarantined,
+		constants.AuditActionAssetReleased,
+		// Asset Deletion
+		constants.AuditActionAssetDeleted,
+		// Deletion Requests
+		constants.AuditActionDeletionRequested,
+		constants.AuditActionDeletionApproved,
+		constants.AuditActionDeletionRejected,
+		constants.AuditActionDeletionCancelled,
+		constants.AuditActionDeletionExpired,
+		constants.AuditActionDeletionCompleted,
+		// Archive Tier
+		constants.AuditActionAssetsArchived,
+		constants.AuditActionAssetRecallRequested,
+		constants.AuditActionAssetRecalled,
+		// Trash
+		constants.AuditActionAssetTrashed,
+		constants.AuditActionAssetRestored,
+		constants.AuditActionRetentionApplied,
+		// Watch Folders
+		constants.AuditActionWatchFolderIngested,
+		// Lineage
+		constants.AuditActionLineageReparented,
+		// Webhooks
+		constants.AuditActionWebhookCreated,
+		constants.AuditActionWebhookUpdated,
+		constants.AuditActionWebhookDeleted,
+		// Rules
+		constants.AuditActionRuleCreated,
+		constants.AuditActionRuleUpdated,
+		constants.AuditActionRuleDeleted,
+		constants.AuditActionRuleExecuted,
+		// Debug
+		constants.AuditActionDebugBundle,
+		// Backup
+		constants.AuditActionBackupCreated,
+		// Workspace Tokens
+		constants.AuditActionWorkspaceTokenCreated,
+		constants.AuditActionWorkspaceTokenExchanged,
+		constants.AuditActionWorkspaceTokenRevoked,
+		// API Keys
+		constants.AuditActionAPIKeyCreated,
+		constants.AuditActionAPIKeyRotated,
+		constants.AuditActionAPIKeyRevoked,
+		// Link exports
+		constants.AuditActionLinkExportSelected,
+		constants.AuditActionLinkExportReleased,
 	}
 }
 
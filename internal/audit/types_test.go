@@ -23,21 +23,74 @@ func collectAuditActionConstants() []string {
 		constants.AuditActionVerified,
 		constants.AuditActionDownloaded,
 		constants.AuditActionDownloadedBulk,
+		constants.AuditActionDownloadCancelled,
 		constants.AuditActionReconcileTopicRemoved,
+		constants.AuditActionSyncDiff,
 		constants.AuditActionLoginSuccess,
 		constants.AuditActionLoginFailed,
 		constants.AuditActionLogout,
+		constants.AuditActionAdminRecoveryIssued,
+		constants.AuditActionAdminRecovered,
+		constants.AuditActionAdminRecoveryFailed,
 		constants.AuditActionUserCreated,
 		constants.AuditActionUserUpdated,
 		constants.AuditActionAPIKeyRegenerated,
+		constants.AuditActionServiceAccountCreated,
+		constants.AuditActionServiceAccountRotated,
+		constants.AuditActionServiceAccountDisabled,
 		constants.AuditActionGrantCreated,
 		constants.AuditActionGrantUpdated,
 		constants.AuditActionGrantRevoked,
+		constants.AuditActionGrantBatch,
+		constants.AuditActionAuthImported,
 		constants.AuditActionMetadataSet,
 		constants.AuditActionMetadataBatch,
 		constants.AuditActionMetadataApply,
+		constants.AuditActionMetadataImport,
+		constants.AuditActionCollectionCreated,
+		constants.AuditActionCollectionUpdated,
+		constants.AuditActionCollectionDeleted,
+		constants.AuditActionCollectionAssets,
 		constants.AuditActionConfigChanged,
 		constants.AuditActionDiskLimitHit,
+		constants.AuditActionAuditPurged,
+		constants.AuditActionAuditHoldCreated,
+		constants.AuditActionAuditHoldReleased,
+		constants.AuditActionAuditExported,
+		constants.AuditActionAssetQuarantined,
+		constants.AuditActionAssetReleased,
+		constants.AuditActionAssetDeleted,
+		constants.AuditActionDeletionRequested,
+		constants.AuditActionDeletionApproved,
+		constants.AuditActionDeletionRejected,
+		constants.AuditActionDeletionCancelled,
+		constants.AuditActionDeletionExpired,
+		constants.AuditActionDeletionCompleted,
+		constants.AuditActionAssetsArchived,
+		constants.AuditActionAssetRecallRequested,
+		constants.AuditActionAssetRecalled,
+		constants.AuditActionAssetTrashed,
+		constants.AuditActionAssetRestored,
+		constants.AuditActionRetentionApplied,
+		constants.AuditActionWatchFolderIngested,
+		constants.AuditActionLineageReparented,
+		constants.AuditActionWebhookCreated,
+		constants.AuditActionWebhookUpdated,
+		constants.AuditActionWebhookDeleted,
+		constants.AuditActionRuleCreated,
+		constants.AuditActionRuleUpdated,
+		constants.AuditActionRuleDeleted,
+		constants.AuditActionRuleExecuted,
+		constants.AuditActionDebugBundle,
+		constants.AuditActionBackupCreated,
+		constants.AuditActionWorkspaceTokenCreated,
+		constants.AuditActionWorkspaceTokenExchanged,
+		constants.AuditActionWorkspaceTokenRevoked,
+		constants.AuditActionAPIKeyCreated,
+		constants.AuditActionAPIKeyRotated,
+		constants.AuditActionAPIKeyRevoked,
+		constants.AuditActionLinkExportSelected,
+		constants.AuditActionLinkExportReleased,
 	}
 }
 
@@ -113,6 +166,7 @@ func TestIsValidAction_AllNewActionsValid(t *testing.T) {
 		"metadata_set",
 		"metadata_batch",
 		"metadata_apply",
+		"metadata_import",
 		"config_changed",
 	}
 
@@ -190,12 +244,17 @@ func TestDetailStructsSerialization(t *testing.T) {
 		{"AddingFileDetails", AddingFileDetails{Hash: "abc", TopicName: "t", Filename: "f", Size: 100, Skipped: false}},
 		{"VerifiedDetails", VerifiedDetails{TopicsChecked: 1, TopicsValid: 1, IndexValid: true, DurationMs: 50}},
 		{"DownloadedDetails", DownloadedDetails{Hash: "abc", Topic: "t", Filename: "f", Size: 100}},
-		{"DownloadedBulkDetails", DownloadedBulkDetails{Mode: "stream", AssetCount: 5, TotalSize: 500}},
+		{"DownloadedBulkDetails", DownloadedBulkDetails{Mode: "stream", AssetCount: 5, TotalSize: 500, Recipients: []string{"partner"}}},
 		{"ReconcileTopicRemovedDetails", ReconcileTopicRemovedDetails{TopicName: "old", EntriesPurged: 10}},
+		{"SyncDiffDetails", SyncDiffDetails{Topic: "t", Total: 3, Present: 1, Missing: 1, Elsewhere: 1}},
 		// Authentication
 		{"LoginSuccessDetails", LoginSuccessDetails{UserAgent: "Mozilla/5.0"}},
 		{"LoginFailedDetails", LoginFailedDetails{AttemptedUsername: "admin", Reason: "invalid_credentials", UserAgent: "curl"}},
 		{"LogoutDetails", LogoutDetails{}},
+		// Admin recovery
+		{"AdminRecoveryIssuedDetails", AdminRecoveryIssuedDetails{TokenPrefix: "mbr_abcd", ExpiresAt: 1700000000}},
+		{"AdminRecoveredDetails", AdminRecoveredDetails{TokenPrefix: "mbr_abcd", TargetUserID: 1, TargetUsername: "admin", UserAgent: "curl"}},
+		{"AdminRecoveryFailedDetails", AdminRecoveryFailedDetails{TokenPrefix: "mbr_abcd", Reason: "invalid_token", UserAgent: "curl"}},
 		// User management
 		{"UserCreatedDetails", UserCreatedDetails{CreatedUserID: 1, CreatedUsername: "newuser"}},
 		{"UserUpdatedDetails", UserUpdatedDetails{TargetUserID: 1, TargetUsername: "user", FieldsChanged: []string{"display_name"}}},
@@ -204,6 +263,8 @@ func TestDetailStructsSerialization(t *testing.T) {
 		{"GrantCreatedDetails", GrantCreatedDetails{GrantID: 1, TargetUserID: 2, Action: "read", HasConstraints: true}},
 		{"GrantUpdatedDetails", GrantUpdatedDetails{GrantID: 1, TargetUserID: 2, Action: "write", HasConstraints: false}},
 		{"GrantRevokedDetails", GrantRevokedDetails{GrantID: 1, TargetUserID: 2, Action: "read"}},
+		{"GrantBatchDetails", GrantBatchDetails{Mode: "copy", SourceUserID: 1, TargetUserIDs: []int64{2}, GrantIDs: []int64{3}, Actions: []string{"read"}, Count: 1}},
+		{"AuthImportedDetails", AuthImportedDetails{Prune: true, UsersCreated: []string{"alice"}, UsersDisabled: []string{"bob"}, GrantsCreated: 2, GrantsRevoked: 1}},
 		// Metadata
 		{"MetadataSetDetails", MetadataSetDetails{Hash: "abc", Op: "set", Key: "tag"}},
 		{"MetadataBatchDetails", MetadataBatchDetails{OperationCount: 10, Succeeded: 8, Failed: 2, Processor: "api"}},
@@ -212,6 +273,26 @@ func TestDetailStructsSerialization(t *testing.T) {
 		{"ConfigChangedDetails", ConfigChangedDetails{WorkingDirectory: "/data", IsBootstrap: true}},
 		// Disk Usage
 		{"DiskLimitHitDetails", DiskLimitHitDetails{Operation: "upload", DiskUsedBytes: 5000000000, DiskLimitBytes: 4000000000}},
+		// Audit Retention
+		{"AuditPurgedDetails", AuditPurgedDetails{Trigger: "scheduled", Deleted: 10, Ranges: []PurgeRange{{Reason: "retention", Action: "downloaded", Count: 10}}}},
+		{"AuditHoldCreatedDetails", AuditHoldCreatedDetails{HoldID: 1, Name: "case-42"}},
+		{"AuditHoldReleasedDetails", AuditHoldReleasedDetails{HoldID: 1, Name: "case-42"}},
+		// Quarantine
+		{"AssetQuarantinedDetails", AssetQuarantinedDetails{Hash: "abc", Topic: "t", Source: "admin", Reason: "malware"}},
+		{"AssetReleasedDetails", AssetReleasedDetails{Hash: "abc", Topic: "t", Source: "admin", Justification: "false positive"}},
+		// Asset Deletion
+		{"AssetDeletedDetails", AssetDeletedDetails{Hash: "abc", Topic: "t", Size: 42, DatFile: "001.dat"}},
+		// Deletion Requests
+		{"DeletionRequestedDetails", DeletionRequestedDetails{RequestID: 1, Mode: "ids", Topics: []string{"t"}, AssetCount: 2, Bytes: 42, ExpiresAt: 1700000000}},
+		{"DeletionDecidedDetails", DeletionDecidedDetails{RequestID: 1, RequestedBy: "alice", Topics: []string{"t"}, AssetCount: 2, Comment: "ok"}},
+		{"DeletionCompletedDetails", DeletionCompletedDetails{RequestID: 1, ApprovedBy: "bob", Deleted: 2, Bytes: 42}},
+		// Trash
+		{"AssetTrashedDetails", AssetTrashedDetails{Hash: "abc", Topic: "t", Size: 42, OriginName: "model"}},
+		{"AssetRestoredDetails", AssetRestoredDetails{Hash: "abc", Topic: "t", TrashedBy: "alice", TrashedAt: 1700000000}},
+		// Retention Policies
+		{"RetentionAppliedDetails", RetentionAppliedDetails{Topic: "t", Action: "trash", Trigger: "scheduled", Hashes: []string{"abc"}, Bytes: 42}},
+		// Watch Folders
+		{"WatchFolderIngestedDetails", WatchFolderIngestedDetails{Folder: "drops", Topic: "t", Trigger: "event", Ingested: 1, Bytes: 42}},
 	}
 
 	for _, tt := range tests {
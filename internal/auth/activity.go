@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// ActivityEvent is one entry of a user's activity timeline. Events come from
+// sessions, sampled API-key usage and audit entries recorded under the
+// user's name.
+type ActivityEvent struct {
+	Timestamp int64       `json:"timestamp"`
+	Kind      string      `json:"kind"`             // constants.AuthActivityKind*
+	Action    string      `json:"action,omitempty"` // audit action, or HTTP method for api_key samples
+	IPAddress string      `json:"ip_address"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// ActionCount is the number of audit entries of one action.
+type ActionCount struct {
+	Action string `json:"action"`
+	Count  int64  `json:"count"`
+}
+
+// ActivityOptions filters and paginates a user's timeline.
+type ActivityOptions struct {
+	Limit  int
+	Offset int
+	Since  int64  // Unix timestamp, inclusive (0 = unbounded)
+	Until  int64  // Unix timestamp, inclusive (0 = unbounded)
+	Kind   string // One of constants.AuthActivityKind* ("" = all)
+}
+
+// IsValidActivityKind reports whether kind is a timeline event kind.
+func IsValidActivityKind(kind string) bool {
+	switch kind {
+	case constants.AuthActivityKindSession, constants.AuthActivityKindLogin,
+		constants.AuthActivityKindAPIKey, constants.AuthActivityKindAudit:
+		return true
+	}
+	return false
+}
+
+// ============================================================================
+// API Key Usage Samples
+// ============================================================================
+
+// RecordAPIKeyUsage stores a usage sample for a request authenticated with
+// the user's API key. The key prefix is taken from the user's current key,
+// the only one that can authenticate.
+func (s *Store) RecordAPIKeyUsage(userID int64, ipAddress, userAgent, method, path string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO auth_api_key_usage (user_id, key_prefix, ip_address, user_agent, method, path, used_at)
+		SELECT id, COALESCE(api_key_prefix, ''), ?, ?, ?, ?, ? FROM auth_users WHERE id = ?
+	`, ipAddress, userAgent, method, path, time.Now().Unix(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to record api key usage: %w", err)
+	}
+	return nil
+}
+
+// CleanupAPIKeyUsage removes usage samples older than the given unix time.
+// Returns the number of samples removed.
+func (s *Store) CleanupAPIKeyUsage(before int64) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM auth_api_key_usage WHERE used_at < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup api key usage: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ============================================================================
+// Activity Timeline
+// ============================================================================
+
+// activityUnion selects every timeline source for one user as
+// (ts, kind, action, ip_address, details). Its arguments are built by
+// activityArgs.
+const activityUnion = `
+	SELECT created_at AS ts, '` + constants.AuthActivityKindSession + `' AS kind, '' AS action, ip_address,
+	       json_object('token_prefix', token_prefix, 'user_agent', user_agent,
+	                   'last_active_at', last_active_at, 'expires_at', expires_at) AS details
+	FROM auth_sessions WHERE user_id = ?
+	UNION ALL
+	SELECT used_at, '` + constants.AuthActivityKindAPIKey + `', method, ip_address,
+	       json_object('path', path, 'key_prefix', key_prefix, 'user_agent', user_agent)
+	FROM auth_api_key_usage WHERE user_id = ?
+	UNION ALL
+	SELECT timestamp,
+	       CASE WHEN action IN (?, ?, ?) THEN '` + constants.AuthActivityKindLogin + `' ELSE '` + constants.AuthActivityKindAudit + `' END,
+	       action, ip_address, details_json
+	FROM audit_log WHERE username = ?`
+
+// activityArgs returns the arguments of activityUnion.
+func activityArgs(userID int64, username string) []interface{} {
+	return []interface{}{
+		userID,
+		userID,
+		constants.AuditActionLoginSuccess, constants.AuditActionLoginFailed, constants.AuditActionLogout,
+		username,
+	}
+}
+
+// activityFilter returns the WHERE clause and arguments applied to the union.
+func activityFilter(opts ActivityOptions) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if opts.Since > 0 {
+		conds = append(conds, "ts >= ?")
+		args = append(args, opts.Since)
+	}
+	if opts.Until > 0 {
+		conds = append(conds, "ts <= ?")
+		args = append(args, opts.Until)
+	}
+	if opts.Kind != "" {
+		conds = append(conds, "kind = ?")
+		args = append(args, opts.Kind)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// ListActivity returns one page of a user's timeline, newest first, and the
+// total number of matching events.
+func (s *Store) ListActivity(userID int64, username string, opts ActivityOptions) ([]ActivityEvent, int64, error) {
+	where, filterArgs := activityFilter(opts)
+	args := append(activityArgs(userID, username), filterArgs...)
+
+	var total int64
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM (`+activityUnion+`)`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count activity: %w", err)
+	}
+
+	rows, err := s.db.Query(`SELECT ts, kind, action, ip_address, details FROM (`+activityUnion+`)`+where+
+		` ORDER BY ts DESC, kind ASC LIMIT ? OFFSET ?`, append(args, opts.Limit, opts.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query activity: %w", err)
+	}
+	defer rows.Close()
+
+	events := []ActivityEvent{}
+	for rows.Next() {
+		var e ActivityEvent
+		var details sql.NullString
+		if err := rows.Scan(&e.Timestamp, &e.Kind, &e.Action, &e.IPAddress, &details); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan activity: %w", err)
+		}
+		if details.Valid {
+			var v interface{}
+			if json.Unmarshal([]byte(details.String), &v) == nil {
+				e.Details = v
+			}
+		}
+		events = append(events, e)
+	}
+	return events, total, rows.Err()
+}
+
+// TopAuditActions returns the most frequent audit actions recorded under the
+// username within [since, until] (0 = unbounded).
+func (s *Store) TopAuditActions(username string, since, until int64, limit int) ([]ActionCount, error) {
+	query := `SELECT action, COUNT(*) AS n FROM audit_log WHERE username = ?`
+	args := []interface{}{username}
+	if since > 0 {
+		query += " AND timestamp >= ?"
+		args = append(args, since)
+	}
+	if until > 0 {
+		query += " AND timestamp <= ?"
+		args = append(args, until)
+	}
+	query += " GROUP BY action ORDER BY n DESC, action ASC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top actions: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []ActionCount{}
+	for rows.Next() {
+		var c ActionCount
+		if err := rows.Scan(&c.Action, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan top actions: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// insertAuditEntry writes an audit_log row directly.
+func insertAuditEntry(t *testing.T, store *Store, ts int64, action, username string) {
+	t.Helper()
+	if _, err := store.db.Exec(`INSERT INTO audit_log (timestamp, action, ip_address, username, details_json) VALUES (?, ?, ?, ?, ?)`,
+		ts, action, "10.0.0.1", username, `{"note":"x"}`); err != nil {
+		t.Fatalf("insert audit entry: %v", err)
+	}
+}
+
+func TestListActivity_MergesSources(t *testing.T) {
+	store := setupTestStore(t)
+	user, err := store.CreateUser("alice", "Alice", "hash", nil)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := store.UpdateUserAPIKey(user.ID, "keyhash", "sb_abcde"); err != nil {
+		t.Fatalf("UpdateUserAPIKey: %v", err)
+	}
+
+	now := time.Now().Unix()
+	insertAuditEntry(t, store, now-300, constants.AuditActionLoginSuccess, "alice")
+	insertAuditEntry(t, store, now-200, constants.AuditActionDownloaded, "alice")
+	insertAuditEntry(t, store, now-100, constants.AuditActionDownloaded, "alice")
+	insertAuditEntry(t, store, now-50, constants.AuditActionDownloaded, "bob")
+	if _, err := store.CreateSession("tokhash", "sess_abc", user.ID, "10.0.0.2", "browser"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if err := store.RecordAPIKeyUsage(user.ID, "10.0.0.3", "curl", "GET", "/api/topics"); err != nil {
+		t.Fatalf("RecordAPIKeyUsage: %v", err)
+	}
+
+	events, total, err := store.ListActivity(user.ID, "alice", ActivityOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListActivity: %v", err)
+	}
+	if total != 5 || len(events) != 5 {
+		t.Fatalf("expected 5 events, got total=%d len=%d: %+v", total, len(events), events)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Timestamp > events[i-1].Timestamp {
+			t.Errorf("events not newest first: %+v", events)
+		}
+	}
+
+	kinds := map[string]int{}
+	for _, e := range events {
+		kinds[e.Kind]++
+	}
+	want := map[string]int{
+		constants.AuthActivityKindSession: 1,
+		constants.AuthActivityKindAPIKey:  1,
+		constants.AuthActivityKindLogin:   1,
+		constants.AuthActivityKindAudit:   2,
+	}
+	for kind, n := range want {
+		if kinds[kind] != n {
+			t.Errorf("kind %s: got %d events, want %d", kind, kinds[kind], n)
+		}
+	}
+
+	apiKey, _, err := store.ListActivity(user.ID, "alice", ActivityOptions{Limit: 10, Kind: constants.AuthActivityKindAPIKey})
+	if err != nil {
+		t.Fatalf("ListActivity(kind): %v", err)
+	}
+	if len(apiKey) != 1 || apiKey[0].Action != "GET" || apiKey[0].IPAddress != "10.0.0.3" {
+		t.Fatalf("unexpected api key events: %+v", apiKey)
+	}
+	details, _ := apiKey[0].Details.(map[string]interface{})
+	if details["key_prefix"] != "sb_abcde" || details["path"] != "/api/topics" {
+		t.Errorf("unexpected api key details: %+v", apiKey[0].Details)
+	}
+}
+
+func TestListActivity_Pagination(t *testing.T) {
+	store := setupTestStore(t)
+	user, _ := store.CreateUser("alice", "Alice", "hash", nil)
+	for i := int64(1); i <= 5; i++ {
+		insertAuditEntry(t, store, 1000+i, constants.AuditActionQuerying, "alice")
+	}
+
+	page, total, err := store.ListActivity(user.ID, "alice", ActivityOptions{Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatalf("ListActivity: %v", err)
+	}
+	if total != 5 || len(page) != 2 || page[0].Timestamp != 1003 || page[1].Timestamp != 1002 {
+		t.Errorf("unexpected page (total=%d): %+v", total, page)
+	}
+
+	window, total, err := store.ListActivity(user.ID, "alice", ActivityOptions{Limit: 10, Since: 1002, Until: 1004})
+	if err != nil {
+		t.Fatalf("ListActivity: %v", err)
+	}
+	if total != 3 || len(window) != 3 {
+		t.Errorf("expected 3 events in window, got total=%d: %+v", total, window)
+	}
+}
+
+func TestTopAuditActions(t *testing.T) {
+	store := setupTestStore(t)
+	for i := 0; i < 3; i++ {
+		insertAuditEntry(t, store, 1000, constants.AuditActionDownloaded, "alice")
+	}
+	insertAuditEntry(t, store, 1000, constants.AuditActionQuerying, "alice")
+	insertAuditEntry(t, store, 1000, constants.AuditActionQuerying, "bob")
+
+	top, err := store.TopAuditActions("alice", 0, 0, 10)
+	if err != nil {
+		t.Fatalf("TopAuditActions: %v", err)
+	}
+	if len(top) != 2 || top[0].Action != constants.AuditActionDownloaded || top[0].Count != 3 || top[1].Count != 1 {
+		t.Errorf("unexpected top actions: %+v", top)
+	}
+}
+
+func TestCleanupAPIKeyUsage(t *testing.T) {
+	store := setupTestStore(t)
+	user, _ := store.CreateUser("alice", "Alice", "hash", nil)
+	if err := store.RecordAPIKeyUsage(user.ID, "10.0.0.1", "", "GET", "/api/topics"); err != nil {
+		t.Fatalf("RecordAPIKeyUsage: %v", err)
+	}
+
+	removed, err := store.CleanupAPIKeyUsage(time.Now().Unix() - 60)
+	if err != nil || removed != 0 {
+		t.Fatalf("recent sample removed: removed=%d err=%v", removed, err)
+	}
+	removed, err = store.CleanupAPIKeyUsage(time.Now().Unix() + 60)
+	if err != nil || removed != 1 {
+		t.Errorf("expected 1 sample removed, got %d (err=%v)", removed, err)
+	}
+}
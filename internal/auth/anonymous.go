@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"encoding/json"
+
+	"silobang/internal/constants"
+)
+
+// NewAnonymousIdentity builds the identity used for unauthenticated requests
+// when public read-only mode is enabled. It is never persisted: its grants are
+// synthesised from config, so it passes through the regular policy evaluator.
+// Queries are limited to allowedPresets (no preset when empty, see
+// evaluateQuery) and downloads to assets no larger than maxDownloadBytes.
+func NewAnonymousIdentity(allowedPresets []string, maxDownloadBytes int64) *Identity {
+	return &Identity{
+		User: &User{
+			Username:    constants.PublicAnonymousUsername,
+			DisplayName: constants.PublicAnonymousUsername,
+			IsActive:    true,
+		},
+		Method: constants.AuthMethodAnonymous,
+		Grants: []Grant{
+			anonymousGrant(constants.AuthActionQuery, QueryConstraints{AllowedPresets: allowedPresets}),
+			anonymousGrant(constants.AuthActionDownload, DownloadConstraints{MaxFileSizeBytes: maxDownloadBytes}),
+		},
+	}
+}
+
+// IsAnonymous reports whether the identity is the synthetic public identity.
+// Anonymous identities have no user row, so they must not record quota usage.
+func (i *Identity) IsAnonymous() bool {
+	return i != nil && i.Method == constants.AuthMethodAnonymous
+}
+
+func anonymousGrant(action string, constraints interface{}) Grant {
+	data, _ := json.Marshal(constraints)
+	raw := string(data)
+	return Grant{Action: action, ConstraintsJSON: &raw, IsActive: true}
+}
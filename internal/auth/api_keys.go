@@ -0,0 +1,328 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// ErrAPIKeyNotFound is returned when a labelled API key does not exist, does
+// not belong to the user or is no longer active.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKey is one of a user's API keys: the key on the account (Primary, no
+// ID) or a labelled key. The plaintext key is never stored.
+type APIKey struct {
+	ID         int64  `json:"id,omitempty"`
+	UserID     int64  `json:"user_id"`
+	Label      string `json:"label"`
+	Primary    bool   `json:"primary,omitempty"`
+	KeyPrefix  string `json:"key_prefix"`
+	CreatedBy  *int64 `json:"created_by,omitempty"`
+	CreatedAt  int64  `json:"created_at,omitempty"`
+	ExpiresAt  *int64 `json:"expires_at,omitempty"` // nil never expires
+	RevokedAt  *int64 `json:"revoked_at,omitempty"`
+	RevokedBy  *int64 `json:"revoked_by,omitempty"`
+	ReplacedBy *int64 `json:"replaced_by,omitempty"` // set on rotated keys
+	Status     string `json:"status"`                // "active", "expired" or "revoked"
+}
+
+// apiKeyStatus derives the status of a key from its expiry and revocation.
+func apiKeyStatus(expiresAt, revokedAt *int64) string {
+	switch {
+	case revokedAt != nil:
+		return constants.AuthAPIKeyStatusRevoked
+	case expiresAt != nil && *expiresAt <= time.Now().Unix():
+		return constants.AuthAPIKeyStatusExpired
+	default:
+		return constants.AuthAPIKeyStatusActive
+	}
+}
+
+// PrimaryAPIKey describes the key on a user's account. Returns nil if the
+// user has none.
+func PrimaryAPIKey(u *UserWithSensitive) *APIKey {
+	if u.APIKeyHash == "" {
+		return nil
+	}
+	return &APIKey{
+		UserID:    u.ID,
+		Label:     constants.AuthAPIKeyPrimaryLabel,
+		Primary:   true,
+		KeyPrefix: u.APIKeyPrefix,
+		ExpiresAt: u.APIKeyExpiresAt,
+		Status:    apiKeyStatus(u.APIKeyExpiresAt, nil),
+	}
+}
+
+// ============================================================================
+// API Key Operations
+// ============================================================================
+
+// apiKeyColumns are the columns scanned by scanAPIKey.
+const apiKeyColumns = `
+	id, user_id, label, key_prefix, created_by, created_at,
+	expires_at, revoked_at, revoked_by, replaced_by`
+
+// ResolveAPIKey finds the user a hashed API key belongs to, whether it is
+// the key on the account or a labelled key, along with the key. The key may
+// be expired or revoked; callers check its Status. Returns sql.ErrNoRows if
+// no key matches.
+func (s *Store) ResolveAPIKey(keyHash string) (*UserWithSensitive, *APIKey, error) {
+	user, err := s.GetUserByAPIKeyHash(keyHash)
+	if err == nil {
+		return user, PrimaryAPIKey(user), nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, nil, err
+	}
+
+	key, err := scanAPIKey(s.db.QueryRow(`SELECT `+apiKeyColumns+` FROM auth_api_keys WHERE key_hash = ?`, keyHash))
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err = s.GetUserByID(key.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, key, nil
+}
+
+// ListAPIKeys returns the labelled API keys of a user, newest first,
+// including expired and revoked keys not yet purged.
+func (s *Store) ListAPIKeys(userID int64) ([]APIKey, error) {
+	rows, err := s.db.Query(`SELECT `+apiKeyColumns+` FROM auth_api_keys WHERE user_id = ? ORDER BY id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// GetAPIKey retrieves a labelled API key of a user. Returns
+// ErrAPIKeyNotFound if the user has no such key.
+func (s *Store) GetAPIKey(userID, id int64) (*APIKey, error) {
+	key, err := scanAPIKey(s.db.QueryRow(`SELECT `+apiKeyColumns+` FROM auth_api_keys WHERE id = ? AND user_id = ?`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// CountActiveAPIKeys returns the number of labelled keys of a user that are
+// neither expired nor revoked.
+func (s *Store) CountActiveAPIKeys(userID int64) (int, error) {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM auth_api_keys
+		WHERE user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+	`, userID, time.Now().Unix()).Scan(&count)
+	return count, err
+}
+
+// CreateAPIKey stores a hashed labelled API key for a user.
+func (s *Store) CreateAPIKey(userID int64, label, keyHash, keyPrefix string, expiresAt *int64, createdBy int64) (*APIKey, error) {
+	return insertAPIKey(s.db, userID, label, keyHash, keyPrefix, expiresAt, createdBy)
+}
+
+// RotateAPIKey replaces an active labelled key with a new key of the same
+// label, in one transaction. The old key points at its replacement and
+// expires at graceUntil unless it expires sooner. Returns the new key and
+// the old key as updated, or ErrAPIKeyNotFound if the user has no such
+// active key or it was already rotated.
+func (s *Store) RotateAPIKey(userID, id int64, keyHash, keyPrefix string, expiresAt *int64, graceUntil, rotatedBy int64) (*APIKey, *APIKey, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	old, err := scanAPIKey(tx.QueryRow(`SELECT `+apiKeyColumns+` FROM auth_api_keys WHERE id = ? AND user_id = ?`, id, userID))
+	if err == sql.ErrNoRows || (err == nil && (old.Status != constants.AuthAPIKeyStatusActive || old.ReplacedBy != nil)) {
+		return nil, nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	created, err := insertAPIKey(tx, userID, old.Label, keyHash, keyPrefix, expiresAt, rotatedBy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if old.ExpiresAt == nil || *old.ExpiresAt > graceUntil {
+		old.ExpiresAt = &graceUntil
+	}
+	old.ReplacedBy = &created.ID
+	if _, err := tx.Exec(`UPDATE auth_api_keys SET expires_at = ?, replaced_by = ? WHERE id = ?`,
+		*old.ExpiresAt, created.ID, old.ID); err != nil {
+		return nil, nil, fmt.Errorf("failed to expire rotated api key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit api key rotation: %w", err)
+	}
+	old.Status = apiKeyStatus(old.ExpiresAt, nil)
+	return created, old, nil
+}
+
+// RotatePrimaryAPIKey replaces the key on a user's account, in one
+// transaction. The old key is kept as a labelled key that expires at
+// graceUntil unless it expires sooner. Returns the old key as kept, or nil
+// if the account had no key.
+func (s *Store) RotatePrimaryAPIKey(userID int64, keyHash, keyPrefix string, expiresAt *int64, graceUntil, rotatedBy int64) (*APIKey, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldHash, oldPrefix sql.NullString
+	var oldExpiresAt sql.NullInt64
+	if err := tx.QueryRow(`SELECT api_key_hash, api_key_prefix, api_key_expires_at FROM auth_users WHERE id = ?`, userID).
+		Scan(&oldHash, &oldPrefix, &oldExpiresAt); err != nil {
+		return nil, err
+	}
+
+	var old *APIKey
+	if oldHash.Valid && oldHash.String != "" {
+		until := graceUntil
+		if oldExpiresAt.Valid && oldExpiresAt.Int64 < until {
+			until = oldExpiresAt.Int64
+		}
+		if old, err = insertAPIKey(tx, userID, constants.AuthAPIKeyPrimaryLabel, oldHash.String, oldPrefix.String, &until, rotatedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE auth_users SET api_key_hash = ?, api_key_prefix = ?, api_key_expires_at = ?, updated_at = ?
+		WHERE id = ?
+	`, keyHash, keyPrefix, expiresAt, time.Now().Unix(), userID); err != nil {
+		return nil, fmt.Errorf("failed to replace api key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit api key rotation: %w", err)
+	}
+	return old, nil
+}
+
+// RevokeAPIKey revokes a labelled key of a user that is not revoked yet.
+// Returns ErrAPIKeyNotFound if there is no such key.
+func (s *Store) RevokeAPIKey(userID, id, revokedBy int64) (*APIKey, error) {
+	now := time.Now().Unix()
+	result, err := s.db.Exec(`
+		UPDATE auth_api_keys SET revoked_at = ?, revoked_by = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL
+	`, now, revokedBy, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n != 1 {
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrAPIKeyNotFound
+	}
+	return s.GetAPIKey(userID, id)
+}
+
+// RevokeAllAPIKeys revokes every labelled key of a user that is not revoked
+// yet. Returns the number of keys revoked.
+func (s *Store) RevokeAllAPIKeys(userID, revokedBy int64) (int64, error) {
+	result, err := s.db.Exec(`
+		UPDATE auth_api_keys SET revoked_at = ?, revoked_by = ?
+		WHERE user_id = ? AND revoked_at IS NULL
+	`, time.Now().Unix(), revokedBy, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke api keys: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// CleanupAPIKeys purges labelled keys revoked or expired before the given
+// unix time. Returns the number of keys removed.
+func (s *Store) CleanupAPIKeys(before int64) (int64, error) {
+	result, err := s.db.Exec(`
+		DELETE FROM auth_api_keys WHERE revoked_at < ? OR expires_at < ?
+	`, before, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup api keys: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// insertAPIKey stores a labelled API key.
+func insertAPIKey(db execer, userID int64, label, keyHash, keyPrefix string, expiresAt *int64, createdBy int64) (*APIKey, error) {
+	now := time.Now().Unix()
+	result, err := db.Exec(`
+		INSERT INTO auth_api_keys (user_id, label, key_hash, key_prefix, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, userID, label, keyHash, keyPrefix, createdBy, now, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key id: %w", err)
+	}
+
+	return &APIKey{
+		ID:        id,
+		UserID:    userID,
+		Label:     label,
+		KeyPrefix: keyPrefix,
+		CreatedBy: &createdBy,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+		Status:    apiKeyStatus(expiresAt, nil),
+	}, nil
+}
+
+// scanAPIKey scans a row of apiKeyColumns and derives the key's status.
+func scanAPIKey(row rowScanner) (*APIKey, error) {
+	var k APIKey
+	var createdBy, expiresAt, revokedAt, revokedBy, replacedBy sql.NullInt64
+
+	err := row.Scan(&k.ID, &k.UserID, &k.Label, &k.KeyPrefix, &createdBy, &k.CreatedAt,
+		&expiresAt, &revokedAt, &revokedBy, &replacedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan api key: %w", err)
+	}
+
+	for _, f := range []struct {
+		src sql.NullInt64
+		dst **int64
+	}{
+		{createdBy, &k.CreatedBy},
+		{expiresAt, &k.ExpiresAt},
+		{revokedAt, &k.RevokedAt},
+		{revokedBy, &k.RevokedBy},
+		{replacedBy, &k.ReplacedBy},
+	} {
+		if f.src.Valid {
+			v := f.src.Int64
+			*f.dst = &v
+		}
+	}
+
+	k.Status = apiKeyStatus(k.ExpiresAt, k.RevokedAt)
+	return &k, nil
+}
@@ -18,7 +18,7 @@ type BootstrapResult struct {
 // Bootstrap creates the initial admin user if no users exist.
 // Returns the plaintext credentials that must be shown to the operator once.
 // Returns nil if users already exist (no bootstrap needed).
-func Bootstrap(store *Store, log *logger.Logger) (*BootstrapResult, error) {
+func Bootstrap(store AccountStore, log *logger.Logger) (*BootstrapResult, error) {
 	count, err := store.CountUsers()
 	if err != nil {
 		return nil, fmt.Errorf("failed to check user count: %w", err)
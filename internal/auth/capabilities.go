@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"encoding/json"
+
+	"silobang/internal/constants"
+)
+
+// Capabilities is the normalized view of what an identity may do, computed
+// by running the same policy evaluation the request handlers use. Clients
+// should drive their UI from it rather than interpreting grants themselves.
+//
+// Topic and preset lists only name topics and presets that exist. An action
+// whose daily quota is used up is reported as unavailable until the quota
+// resets, matching what the handlers would answer.
+type Capabilities struct {
+	CanUploadTopics   []string                 `json:"can_upload_topics"`
+	CanDownloadTopics []string                 `json:"can_download_topics"`
+	CanMetadataTopics []string                 `json:"can_metadata_topics"`
+	CanQueryTopics    []string                 `json:"can_query_topics"`
+	CanRunPresets     []string                 `json:"can_run_presets"`
+	CanBulkDownload   bool                     `json:"can_bulk_download"`
+	CanVerify         bool                     `json:"can_verify"`
+	CanCreateTopics   bool                     `json:"can_create_topics"`
+	CanManageUsers    bool                     `json:"can_manage_users"`
+	CanCreateUsers    bool                     `json:"can_create_users"`
+	CanEditUsers      bool                     `json:"can_edit_users"`
+	CanViewAudit      bool                     `json:"can_view_audit"`
+	CanViewAllAudit   bool                     `json:"can_view_all_audit"`
+	CanStreamAudit    bool                     `json:"can_stream_audit"`
+	CanManageConfig   bool                     `json:"can_manage_config"`
+	CanDebug          bool                     `json:"can_debug"`
+	Limits            map[string]*ActionLimits `json:"limits"`
+
+	// Endpoints lists the API routes with a declared policy that lets the
+	// identity in, as "METHOD pattern". The server fills it from its route
+	// table; routes scoped to a topic are listed when some topic is allowed.
+	Endpoints []string `json:"endpoints,omitempty"`
+}
+
+// ActionLimits summarizes the constraints of the grant that authorizes an
+// action, together with today's quota usage. Zero values mean no limit.
+type ActionLimits struct {
+	MaxFileSizeBytes    int64    `json:"max_file_size_bytes,omitempty"`
+	AllowedExtensions   []string `json:"allowed_extensions,omitempty"`
+	MaxAssetsPerRequest int      `json:"max_assets_per_request,omitempty"`
+	DailyCountLimit     int64    `json:"daily_count_limit,omitempty"`
+	DailyVolumeBytes    int64    `json:"daily_volume_bytes,omitempty"`
+	Watermark           string   `json:"watermark,omitempty"`
+	RateLimitPerMin     int      `json:"rate_limit_per_min,omitempty"` // overrides the configured rate limit
+	RateLimitBurst      int      `json:"rate_limit_burst,omitempty"`
+	UsedCountToday      int64    `json:"used_count_today"`
+	UsedBytesToday      int64    `json:"used_bytes_today"`
+	QuotaExceeded       bool     `json:"quota_exceeded"`
+}
+
+// grantLimits decodes the limit fields shared by the per-action constraint types.
+type grantLimits struct {
+	MaxFileSizeBytes    int64    `json:"max_file_size_bytes"`
+	AllowedExtensions   []string `json:"allowed_extensions"`
+	MaxAssetsPerRequest int      `json:"max_assets_per_request"`
+	DailyCountLimit     int64    `json:"daily_count_limit"`
+	DailyVolumeBytes    int64    `json:"daily_volume_bytes"`
+	Watermark           string   `json:"watermark"`
+	RateLimitPerMin     int      `json:"rate_limit_per_min"`
+	RateLimitBurst      int      `json:"rate_limit_burst"`
+}
+
+// Capabilities evaluates every action for the identity against the given
+// topic and preset names.
+func (e *PolicyEvaluator) Capabilities(identity *Identity, topics, presets []string) *Capabilities {
+	caps := &Capabilities{
+		CanUploadTopics:   e.allowedTopics(identity, constants.AuthActionUpload, topics),
+		CanDownloadTopics: e.allowedTopics(identity, constants.AuthActionDownload, topics),
+		CanMetadataTopics: e.allowedTopics(identity, constants.AuthActionMetadata, topics),
+		CanQueryTopics:    e.allowedTopics(identity, constants.AuthActionQuery, topics),
+		CanRunPresets:     []string{},
+		Limits:            make(map[string]*ActionLimits),
+	}
+
+	for _, preset := range presets {
+		if e.Evaluate(identity, &ActionContext{Action: constants.AuthActionQuery, PresetName: preset}).Allowed {
+			caps.CanRunPresets = append(caps.CanRunPresets, preset)
+		}
+	}
+
+	caps.CanBulkDownload = e.allows(identity, &ActionContext{Action: constants.AuthActionBulkDownload})
+	caps.CanVerify = e.allows(identity, &ActionContext{Action: constants.AuthActionVerify})
+	caps.CanCreateTopics = e.allows(identity, &ActionContext{Action: constants.AuthActionManageTopics, SubAction: "create"})
+	caps.CanManageUsers = e.allows(identity, &ActionContext{Action: constants.AuthActionManageUsers})
+	caps.CanCreateUsers = e.allows(identity, &ActionContext{Action: constants.AuthActionManageUsers, SubAction: "create"})
+	caps.CanEditUsers = e.allows(identity, &ActionContext{Action: constants.AuthActionManageUsers, SubAction: "edit"})
+	caps.CanStreamAudit = e.allows(identity, &ActionContext{Action: constants.AuthActionViewAudit, SubAction: "stream"})
+	caps.CanManageConfig = e.allows(identity, &ActionContext{Action: constants.AuthActionManageConfig})
+	caps.CanDebug = e.allows(identity, &ActionContext{Action: constants.AuthActionDebug})
+
+	if audit := e.Evaluate(identity, &ActionContext{Action: constants.AuthActionViewAudit}); audit.Allowed {
+		caps.CanViewAudit = true
+		caps.CanViewAllAudit = CanViewAllAudit(identity, audit.MatchedGrant)
+	}
+
+	for _, action := range []string{
+		constants.AuthActionUpload,
+		constants.AuthActionDownload,
+		constants.AuthActionQuery,
+		constants.AuthActionMetadata,
+		constants.AuthActionBulkDownload,
+		constants.AuthActionVerify,
+	} {
+		if limits := e.actionLimits(identity, action); limits != nil {
+			caps.Limits[action] = limits
+		}
+	}
+
+	return caps
+}
+
+// CanViewAllAudit reports whether the view_audit grant matched for identity
+// allows viewing every user's actions. Bootstrap users and grants without
+// constraints are unrestricted; malformed constraints fail closed.
+func CanViewAllAudit(identity *Identity, grant *Grant) bool {
+	if identity.User.IsBootstrap {
+		return true
+	}
+	if grant == nil {
+		return false
+	}
+	if grant.ConstraintsJSON == nil {
+		return true
+	}
+
+	var c ViewAuditConstraints
+	if err := json.Unmarshal([]byte(*grant.ConstraintsJSON), &c); err != nil {
+		return false
+	}
+	return c.CanViewAll
+}
+
+func (e *PolicyEvaluator) allows(identity *Identity, ctx *ActionContext) bool {
+	return e.Evaluate(identity, ctx).Allowed
+}
+
+// allowedTopics returns the topics the identity may use for action.
+func (e *PolicyEvaluator) allowedTopics(identity *Identity, action string, topics []string) []string {
+	allowed := []string{}
+	for _, topic := range topics {
+		if e.allows(identity, &ActionContext{Action: action, TopicName: topic}) {
+			allowed = append(allowed, topic)
+		}
+	}
+	return allowed
+}
+
+// actionLimits returns the limits of the grant that would authorize action,
+// or nil when the identity holds no active grant for it. When every grant is
+// over quota, the first active grant is reported.
+func (e *PolicyEvaluator) actionLimits(identity *Identity, action string) *ActionLimits {
+	result := e.Evaluate(identity, &ActionContext{Action: action})
+	grant := result.MatchedGrant
+	if grant == nil {
+		if result.DeniedCode != constants.ErrCodeAuthQuotaExceeded {
+			return nil
+		}
+		for i := range identity.Grants {
+			if g := &identity.Grants[i]; g.Action == action && g.IsActive {
+				grant = g
+				break
+			}
+		}
+		if grant == nil {
+			return nil
+		}
+	}
+
+	limits := &ActionLimits{QuotaExceeded: !result.Allowed}
+	if grant.ConstraintsJSON != nil && *grant.ConstraintsJSON != "" {
+		var c grantLimits
+		if err := json.Unmarshal([]byte(*grant.ConstraintsJSON), &c); err != nil {
+			e.logger.Warn("Failed to parse constraints for grant %d: %v", grant.ID, err)
+		}
+		limits.MaxFileSizeBytes = c.MaxFileSizeBytes
+		limits.AllowedExtensions = c.AllowedExtensions
+		limits.MaxAssetsPerRequest = c.MaxAssetsPerRequest
+		limits.DailyCountLimit = c.DailyCountLimit
+		limits.DailyVolumeBytes = c.DailyVolumeBytes
+		limits.Watermark = c.Watermark
+		limits.RateLimitPerMin = c.RateLimitPerMin
+		limits.RateLimitBurst = c.RateLimitBurst
+	}
+
+	if usage, err := e.store.GetTodayUsage(identity.User.ID, action); err == nil {
+		limits.UsedCountToday = usage.RequestCount
+		limits.UsedBytesToday = usage.TotalBytes
+	}
+	return limits
+}
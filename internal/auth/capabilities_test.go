@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"slices"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestCapabilities_TopicAndPresetConstraints(t *testing.T) {
+	eval, _ := setupEvaluator(t)
+
+	user := &User{ID: 1, Username: "caps", IsActive: true}
+	identity := makeIdentity(user, []Grant{
+		{ID: 1, Action: constants.AuthActionUpload, IsActive: true,
+			ConstraintsJSON: marshalConstraints(t, UploadConstraints{AllowedTopics: []string{"a"}, MaxFileSizeBytes: 100})},
+		{ID: 2, Action: constants.AuthActionQuery, IsActive: true,
+			ConstraintsJSON: marshalConstraints(t, QueryConstraints{AllowedPresets: []string{"p1"}})},
+		{ID: 3, Action: constants.AuthActionViewAudit, IsActive: true,
+			ConstraintsJSON: marshalConstraints(t, ViewAuditConstraints{CanStream: true})},
+		{ID: 4, Action: constants.AuthActionDownload, IsActive: false},
+	})
+
+	caps := eval.Capabilities(identity, []string{"a", "b"}, []string{"p1", "p2"})
+
+	if !slices.Equal(caps.CanUploadTopics, []string{"a"}) {
+		t.Errorf("expected upload topics [a], got %v", caps.CanUploadTopics)
+	}
+	if !slices.Equal(caps.CanQueryTopics, []string{"a", "b"}) {
+		t.Errorf("expected query on all topics, got %v", caps.CanQueryTopics)
+	}
+	if !slices.Equal(caps.CanRunPresets, []string{"p1"}) {
+		t.Errorf("expected presets [p1], got %v", caps.CanRunPresets)
+	}
+	if len(caps.CanDownloadTopics) != 0 {
+		t.Errorf("inactive grant should not allow downloads, got %v", caps.CanDownloadTopics)
+	}
+	if !caps.CanViewAudit || caps.CanViewAllAudit || !caps.CanStreamAudit {
+		t.Errorf("expected own-only streaming audit access, got %+v", caps)
+	}
+	if caps.Limits[constants.AuthActionUpload] == nil || caps.Limits[constants.AuthActionUpload].MaxFileSizeBytes != 100 {
+		t.Errorf("expected upload max size 100, got %+v", caps.Limits[constants.AuthActionUpload])
+	}
+	if _, ok := caps.Limits[constants.AuthActionDownload]; ok {
+		t.Error("expected no download limits without an active grant")
+	}
+}
+
+func TestCapabilities_QuotaExhausted(t *testing.T) {
+	eval, store := setupEvaluator(t)
+
+	user := &User{ID: 1, Username: "caps", IsActive: true}
+	identity := makeIdentity(user, []Grant{
+		{ID: 1, Action: constants.AuthActionVerify, IsActive: true,
+			ConstraintsJSON: marshalConstraints(t, VerifyConstraints{DailyCountLimit: 1})},
+	})
+	if err := store.IncrementQuota(user.ID, constants.AuthActionVerify, 1, 0); err != nil {
+		t.Fatalf("IncrementQuota failed: %v", err)
+	}
+
+	caps := eval.Capabilities(identity, nil, nil)
+
+	if caps.CanVerify {
+		t.Error("expected verify to be unavailable once the quota is used")
+	}
+	limits := caps.Limits[constants.AuthActionVerify]
+	if limits == nil || !limits.QuotaExceeded || limits.UsedCountToday != 1 || limits.DailyCountLimit != 1 {
+		t.Errorf("expected exhausted verify quota, got %+v", limits)
+	}
+}
+
+func TestCanViewAllAudit(t *testing.T) {
+	user := &User{ID: 1, IsActive: true}
+	ownOnly := marshalConstraints(t, ViewAuditConstraints{CanViewAll: false})
+	malformed := "{"
+
+	if !CanViewAllAudit(makeIdentity(user, nil), &Grant{}) {
+		t.Error("grant without constraints should view all")
+	}
+	if CanViewAllAudit(makeIdentity(user, nil), &Grant{ConstraintsJSON: ownOnly}) {
+		t.Error("can_view_all=false should be own-only")
+	}
+	if CanViewAllAudit(makeIdentity(user, nil), &Grant{ConstraintsJSON: &malformed}) {
+		t.Error("malformed constraints should fail closed")
+	}
+	if !CanViewAllAudit(makeIdentity(&User{ID: 2, IsBootstrap: true}, nil), nil) {
+		t.Error("bootstrap user should view all")
+	}
+}
@@ -7,14 +7,20 @@ type UploadConstraints struct {
 	MaxFileSizeBytes  int64    `json:"max_file_size_bytes,omitempty"`
 	DailyCountLimit   int64    `json:"daily_count_limit,omitempty"`
 	DailyVolumeBytes  int64    `json:"daily_volume_bytes,omitempty"`
-	AllowedTopics     []string `json:"allowed_topics,omitempty"` // empty = all allowed
+	AllowedTopics     []string `json:"allowed_topics,omitempty"`     // empty = all allowed
+	RateLimitPerMin   int      `json:"rate_limit_per_min,omitempty"` // overrides rate_limit.uploads for the holder
+	RateLimitBurst    int      `json:"rate_limit_burst,omitempty"`
 }
 
 // DownloadConstraints defines limits for the download action.
 type DownloadConstraints struct {
+	MaxFileSizeBytes int64    `json:"max_file_size_bytes,omitempty"`
 	DailyCountLimit  int64    `json:"daily_count_limit,omitempty"`
 	DailyVolumeBytes int64    `json:"daily_volume_bytes,omitempty"`
 	AllowedTopics    []string `json:"allowed_topics,omitempty"`
+	Watermark        string   `json:"watermark,omitempty"`          // watermark profile forced on image downloads
+	RateLimitPerMin  int      `json:"rate_limit_per_min,omitempty"` // overrides rate_limit.downloads for the holder
+	RateLimitBurst   int      `json:"rate_limit_burst,omitempty"`
 }
 
 // QueryConstraints defines limits for the query action.
@@ -22,6 +28,8 @@ type QueryConstraints struct {
 	AllowedPresets  []string `json:"allowed_presets,omitempty"` // empty = all presets
 	DailyCountLimit int64    `json:"daily_count_limit,omitempty"`
 	AllowedTopics   []string `json:"allowed_topics,omitempty"`
+	RateLimitPerMin int      `json:"rate_limit_per_min,omitempty"` // overrides rate_limit.queries for the holder
+	RateLimitBurst  int      `json:"rate_limit_burst,omitempty"`
 }
 
 // ManageUsersConstraints defines what user management operations are allowed.
@@ -37,13 +45,15 @@ type ManageUsersConstraints struct {
 type ManageTopicsConstraints struct {
 	CanCreate     bool     `json:"can_create"`
 	CanDelete     bool     `json:"can_delete"`
+	CanFreeze     bool     `json:"can_freeze"` // freezing and unfreezing topics
 	AllowedTopics []string `json:"allowed_topics,omitempty"`
 }
 
 // MetadataConstraints defines limits for metadata operations.
 type MetadataConstraints struct {
-	DailyCountLimit int64    `json:"daily_count_limit,omitempty"`
-	AllowedTopics   []string `json:"allowed_topics,omitempty"`
+	DailyCountLimit    int64    `json:"daily_count_limit,omitempty"`
+	AllowedTopics      []string `json:"allowed_topics,omitempty"`
+	AllowedKeyPrefixes []string `json:"allowed_key_prefixes,omitempty"` // key namespaces writes may touch; empty = all keys
 }
 
 // BulkDownloadConstraints defines limits for bulk download operations.
@@ -12,12 +12,12 @@ import (
 // PolicyEvaluator evaluates authorization policies for requests.
 // It implements the 3-phase evaluation: grant check → constraint check → quota check.
 type PolicyEvaluator struct {
-	store  *Store
+	store  GrantStore
 	logger *logger.Logger
 }
 
 // NewPolicyEvaluator creates a new policy evaluator.
-func NewPolicyEvaluator(store *Store, log *logger.Logger) *PolicyEvaluator {
+func NewPolicyEvaluator(store GrantStore, log *logger.Logger) *PolicyEvaluator {
 	return &PolicyEvaluator{store: store, logger: log}
 }
 
@@ -67,10 +67,43 @@ func (e *PolicyEvaluator) Evaluate(identity *Identity, ctx *ActionContext) *Poli
 	return denied(lastCode, lastReason)
 }
 
+// HasTopicAccess reports whether the identity holds an active grant for the
+// action that covers topicName. Quotas and other per-request constraints are
+// not checked; this is for work done on a user's behalf outside a request,
+// such as notification fan-out.
+func (e *PolicyEvaluator) HasTopicAccess(identity *Identity, action, topicName string) bool {
+	if identity == nil || identity.User == nil || !identity.User.IsActive {
+		return false
+	}
+
+	for _, g := range identity.Grants {
+		if g.Action != action || !g.IsActive {
+			continue
+		}
+		if g.ConstraintsJSON == nil || *g.ConstraintsJSON == "" || *g.ConstraintsJSON == "{}" || *g.ConstraintsJSON == "null" {
+			return true
+		}
+		var c struct {
+			AllowedTopics []string `json:"allowed_topics"`
+		}
+		if err := json.Unmarshal([]byte(*g.ConstraintsJSON), &c); err != nil {
+			e.logger.Warn("Failed to parse constraints for grant %d: %v", g.ID, err)
+			continue
+		}
+		if checkAllowedTopics(c.AllowedTopics, topicName) == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // evaluateGrant checks constraints and quotas for a single grant.
 func (e *PolicyEvaluator) evaluateGrant(identity *Identity, grant *Grant, ctx *ActionContext) *PolicyResult {
-	// No constraints = unrestricted (but still check quotas if any exist in constraints)
-	if grant.ConstraintsJSON == nil || *grant.ConstraintsJSON == "" || *grant.ConstraintsJSON == "{}" || *grant.ConstraintsJSON == "null" {
+	// No constraints = unrestricted (but still check quotas if any exist in
+	// constraints). The anonymous identity's grants are always checked, since
+	// an empty preset list means none for it
+	if grant.ConstraintsJSON == nil || *grant.ConstraintsJSON == "" || *grant.ConstraintsJSON == "null" ||
+		(*grant.ConstraintsJSON == "{}" && !identity.IsAnonymous()) {
 		return allowed(grant)
 	}
 
@@ -94,7 +127,7 @@ func (e *PolicyEvaluator) evaluateGrant(identity *Identity, grant *Grant, ctx *A
 	case constants.AuthActionVerify:
 		return e.evaluateVerify(identity, grant, ctx)
 	default:
-		// For actions without specific constraint types (manage_config),
+		// For actions without specific constraint types (manage_config, debug),
 		// having the grant is sufficient
 		return allowed(grant)
 	}
@@ -164,6 +197,11 @@ func (e *PolicyEvaluator) evaluateDownload(identity *Identity, grant *Grant, ctx
 		return result
 	}
 
+	if c.MaxFileSizeBytes > 0 && ctx.VolumeBytes > c.MaxFileSizeBytes {
+		return denied(constants.ErrCodeAuthConstraintViolation,
+			fmt.Sprintf("file size %d exceeds download limit %d", ctx.VolumeBytes, c.MaxFileSizeBytes))
+	}
+
 	if c.DailyCountLimit > 0 || c.DailyVolumeBytes > 0 {
 		usage, err := e.store.GetTodayUsage(identity.User.ID, ctx.Action)
 		if err != nil {
@@ -189,7 +227,12 @@ func (e *PolicyEvaluator) evaluateQuery(identity *Identity, grant *Grant, ctx *A
 		return denied(constants.ErrCodeAuthConstraintViolation, "malformed grant constraints")
 	}
 
-	// Check allowed presets
+	// Check allowed presets. An empty list allows every preset, except for
+	// the anonymous identity, whose public mode must name what it exposes
+	if len(c.AllowedPresets) == 0 && ctx.PresetName != "" && identity.IsAnonymous() {
+		return denied(constants.ErrCodeAuthConstraintViolation,
+			fmt.Sprintf("query preset %q not allowed in public mode", ctx.PresetName))
+	}
 	if len(c.AllowedPresets) > 0 && ctx.PresetName != "" {
 		if !containsString(c.AllowedPresets, ctx.PresetName) {
 			return denied(constants.ErrCodeAuthConstraintViolation,
@@ -260,6 +303,10 @@ func (e *PolicyEvaluator) evaluateManageTopics(grant *Grant, ctx *ActionContext)
 		if !c.CanDelete {
 			return denied(constants.ErrCodeAuthConstraintViolation, "topic deletion not permitted")
 		}
+	case "freeze":
+		if !c.CanFreeze {
+			return denied(constants.ErrCodeAuthConstraintViolation, "topic freezing not permitted")
+		}
 	}
 
 	return allowed(grant)
@@ -276,6 +323,10 @@ func (e *PolicyEvaluator) evaluateMetadata(identity *Identity, grant *Grant, ctx
 		return result
 	}
 
+	if result := checkAllowedKeyPrefixes(c.AllowedKeyPrefixes, ctx.MetadataKeys); result != nil {
+		return result
+	}
+
 	if c.DailyCountLimit > 0 {
 		usage, err := e.store.GetTodayUsage(identity.User.ID, ctx.Action)
 		if err != nil {
@@ -380,6 +431,27 @@ func containsString(list []string, target string) bool {
 	return false
 }
 
+// checkAllowedKeyPrefixes denies when any key falls outside the allowed namespaces.
+func checkAllowedKeyPrefixes(prefixes []string, keys []string) *PolicyResult {
+	if len(prefixes) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		inNamespace := false
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				inNamespace = true
+				break
+			}
+		}
+		if !inNamespace {
+			return denied(constants.ErrCodeAuthConstraintViolation,
+				fmt.Sprintf("metadata key %q not in allowed namespaces", key))
+		}
+	}
+	return nil
+}
+
 func checkAllowedTopics(allowedTopics []string, topicName string) *PolicyResult {
 	if len(allowedTopics) > 0 && topicName != "" {
 		if !containsString(allowedTopics, topicName) {
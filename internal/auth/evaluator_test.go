@@ -340,6 +340,35 @@ func TestEvaluateDownload_AllowedTopics(t *testing.T) {
 	}
 }
 
+func TestEvaluateMetadata_AllowedKeyPrefixes(t *testing.T) {
+	eval, _ := setupEvaluator(t)
+
+	user := &User{ID: 1, Username: "tagger", IsActive: true, AccountType: constants.AuthAccountTypeService}
+	constraints := MetadataConstraints{AllowedKeyPrefixes: []string{"thumb.", "exif."}}
+
+	grants := []Grant{{ID: 1, UserID: 1, Action: constants.AuthActionMetadata, IsActive: true,
+		ConstraintsJSON: marshalConstraints(t, constraints)}}
+	identity := makeIdentity(user, grants)
+
+	result := eval.Evaluate(identity, &ActionContext{Action: constants.AuthActionMetadata,
+		MetadataKeys: []string{"thumb.width", "exif.camera"}})
+	if !result.Allowed {
+		t.Fatalf("keys inside the namespaces should be allowed: %s", result.Reason)
+	}
+
+	result = eval.Evaluate(identity, &ActionContext{Action: constants.AuthActionMetadata,
+		MetadataKeys: []string{"thumb.width", "status"}})
+	if result.Allowed {
+		t.Fatal("a key outside the namespaces should be denied")
+	}
+
+	// Reads carry no keys and are not restricted by namespace
+	result = eval.Evaluate(identity, &ActionContext{Action: constants.AuthActionMetadata})
+	if !result.Allowed {
+		t.Fatalf("metadata read should be allowed: %s", result.Reason)
+	}
+}
+
 func TestEvaluateDownload_DailyCountLimit(t *testing.T) {
 	eval, store := setupEvaluator(t)
 
@@ -359,6 +388,59 @@ func TestEvaluateDownload_DailyCountLimit(t *testing.T) {
 	}
 }
 
+func TestEvaluateDownload_MaxFileSize(t *testing.T) {
+	eval, _ := setupEvaluator(t)
+
+	user := &User{ID: 1, Username: "dl-size", IsActive: true}
+	constraints := DownloadConstraints{MaxFileSizeBytes: 1024}
+
+	grants := []Grant{{ID: 1, UserID: 1, Action: constants.AuthActionDownload, IsActive: true,
+		ConstraintsJSON: marshalConstraints(t, constraints)}}
+	identity := makeIdentity(user, grants)
+
+	result := eval.Evaluate(identity, &ActionContext{Action: constants.AuthActionDownload, VolumeBytes: 1024})
+	if !result.Allowed {
+		t.Fatalf("download at size limit should succeed: %s", result.Reason)
+	}
+
+	result = eval.Evaluate(identity, &ActionContext{Action: constants.AuthActionDownload, VolumeBytes: 1025})
+	if result.Allowed {
+		t.Fatal("download over size limit should be denied")
+	}
+	if result.DeniedCode != constants.ErrCodeAuthConstraintViolation {
+		t.Errorf("expected code %q, got %q", constants.ErrCodeAuthConstraintViolation, result.DeniedCode)
+	}
+}
+
+func TestEvaluate_AnonymousIdentity(t *testing.T) {
+	eval, _ := setupEvaluator(t)
+
+	identity := NewAnonymousIdentity([]string{"recent-imports"}, 1024)
+	if !identity.IsAnonymous() {
+		t.Fatal("expected anonymous identity")
+	}
+
+	result := eval.Evaluate(identity, &ActionContext{Action: constants.AuthActionQuery, PresetName: "recent-imports"})
+	if !result.Allowed {
+		t.Fatalf("allowed preset should succeed: %s", result.Reason)
+	}
+
+	result = eval.Evaluate(identity, &ActionContext{Action: constants.AuthActionQuery, PresetName: "by-hash"})
+	if result.Allowed {
+		t.Fatal("preset outside the public list should be denied")
+	}
+
+	result = eval.Evaluate(identity, &ActionContext{Action: constants.AuthActionDownload, VolumeBytes: 2048})
+	if result.Allowed {
+		t.Fatal("download over the public size cap should be denied")
+	}
+
+	result = eval.Evaluate(identity, &ActionContext{Action: constants.AuthActionUpload, FileSize: 1})
+	if result.Allowed {
+		t.Fatal("anonymous upload should be denied")
+	}
+}
+
 // ============================================================================
 // Query Constraint Tests
 // ============================================================================
@@ -453,6 +535,31 @@ func TestEvaluateManageTopics_SubActions(t *testing.T) {
 	}
 }
 
+func TestEvaluateManageTopics_Freeze(t *testing.T) {
+	eval, _ := setupEvaluator(t)
+
+	user := &User{ID: 1, Username: "curator", IsActive: true}
+	constraints := ManageTopicsConstraints{CanFreeze: true, AllowedTopics: []string{"datasets"}}
+
+	grants := []Grant{{ID: 1, UserID: 1, Action: constants.AuthActionManageTopics, IsActive: true,
+		ConstraintsJSON: marshalConstraints(t, constraints)}}
+	identity := makeIdentity(user, grants)
+
+	result := eval.Evaluate(identity, &ActionContext{
+		Action: constants.AuthActionManageTopics, SubAction: "freeze", TopicName: "datasets",
+	})
+	if !result.Allowed {
+		t.Fatalf("topic freeze should be allowed: %s", result.Reason)
+	}
+
+	result = eval.Evaluate(identity, &ActionContext{
+		Action: constants.AuthActionManageTopics, SubAction: "delete", TopicName: "datasets",
+	})
+	if result.Allowed {
+		t.Fatal("topic delete should be denied")
+	}
+}
+
 func TestEvaluateManageTopics_AllowedTopics(t *testing.T) {
 	eval, _ := setupEvaluator(t)
 
@@ -652,6 +759,33 @@ func TestIncrementQuota_ViaEvaluator(t *testing.T) {
 	}
 }
 
+func TestHasTopicAccess(t *testing.T) {
+	eval, _ := setupEvaluator(t)
+
+	user := &User{ID: 1, Username: "reader", IsActive: true}
+	grants := []Grant{
+		{ID: 1, UserID: 1, Action: constants.AuthActionQuery, IsActive: true,
+			ConstraintsJSON: marshalConstraints(t, QueryConstraints{AllowedTopics: []string{"public"}, DailyCountLimit: 1})},
+		{ID: 2, UserID: 1, Action: constants.AuthActionDownload, IsActive: false},
+	}
+	identity := makeIdentity(user, grants)
+
+	if !eval.HasTopicAccess(identity, constants.AuthActionQuery, "public") {
+		t.Error("expected access to allowed topic")
+	}
+	if eval.HasTopicAccess(identity, constants.AuthActionQuery, "private") {
+		t.Error("expected no access to topic outside allowed list")
+	}
+	if eval.HasTopicAccess(identity, constants.AuthActionDownload, "public") {
+		t.Error("inactive grant should not give access")
+	}
+
+	user.IsActive = false
+	if eval.HasTopicAccess(identity, constants.AuthActionQuery, "public") {
+		t.Error("disabled user should not have access")
+	}
+}
+
 // ============================================================================
 // Helper
 // ============================================================================
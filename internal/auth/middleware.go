@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"cmp"
 	"context"
 	"net/http"
 	"strings"
@@ -8,6 +9,7 @@ import (
 
 	"silobang/internal/constants"
 	"silobang/internal/logger"
+	"silobang/plugin"
 )
 
 // contextKey is an unexported type for context keys in this package.
@@ -15,6 +17,7 @@ type contextKey int
 
 const (
 	identityContextKey contextKey = iota
+	failureContextKey
 )
 
 // StoreProvider is a function that returns the current auth store.
@@ -23,10 +26,17 @@ const (
 // system is initialised after the server starts (e.g. POST /api/config).
 type StoreProvider func() *Store
 
+// ExternalProvider is an auth provider plugin enabled in auth_providers.
+type ExternalProvider struct {
+	Type     string
+	Provider plugin.AuthProvider
+}
+
 // Middleware provides HTTP middleware for authentication.
 type Middleware struct {
-	getStore StoreProvider
-	logger   *logger.Logger
+	getStore  StoreProvider
+	providers []ExternalProvider
+	logger    *logger.Logger
 }
 
 // NewMiddleware creates a new auth middleware with a dynamic store provider.
@@ -34,33 +44,44 @@ func NewMiddleware(provider StoreProvider, log *logger.Logger) *Middleware {
 	return &Middleware{getStore: provider, logger: log}
 }
 
+// SetProviders sets the auth provider plugins tried after API keys and
+// session tokens. Call before serving requests.
+func (m *Middleware) SetProviders(providers []ExternalProvider) {
+	m.providers = providers
+}
+
 // Authenticate extracts and validates the identity from the request.
 // Sets Identity on context. Handlers that require auth use RequireAuth to check.
 // This middleware always calls next — it does not block unauthenticated requests.
 // Individual handlers decide whether auth is required for their endpoint.
 func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		identity := m.resolveIdentity(r)
+		identity, failure := m.resolveIdentity(r)
 		ctx := context.WithValue(r.Context(), identityContextKey, identity)
+		if failure != "" {
+			ctx = context.WithValue(ctx, failureContextKey, failure)
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
 // resolveIdentity attempts to extract a valid identity from the request.
-// Tries API key first, then session token.
+// Tries API key first, then session and job tokens, then the auth provider
+// plugins.
 // Returns nil if the store is not yet available (auth not initialised).
-func (m *Middleware) resolveIdentity(r *http.Request) *Identity {
+// Without an identity, failure is the error code explaining why a credential
+// was refused when clients can act on it (an expired API key), else empty.
+func (m *Middleware) resolveIdentity(r *http.Request) (identity *Identity, failure string) {
 	store := m.getStore()
 	if store == nil {
 		// Auth system not yet initialised (no orchestrator DB)
-		return nil
+		return nil, ""
 	}
 
 	// Priority 1: X-API-Key header
 	if apiKey := r.Header.Get(constants.HeaderXAPIKey); apiKey != "" {
-		identity := m.resolveAPIKey(store, apiKey)
-		if identity != nil {
-			return identity
+		if identity, failure = m.resolveAPIKey(store, apiKey); identity != nil {
+			return identity, ""
 		}
 	}
 
@@ -69,14 +90,20 @@ func (m *Middleware) resolveIdentity(r *http.Request) *Identity {
 		if strings.HasPrefix(authHeader, constants.AuthBearerPrefix) {
 			token := strings.TrimPrefix(authHeader, constants.AuthBearerPrefix)
 			if IsAPIKey(token) {
-				identity := m.resolveAPIKey(store, token)
-				if identity != nil {
-					return identity
+				var keyFailure string
+				if identity, keyFailure = m.resolveAPIKey(store, token); identity != nil {
+					return identity, ""
 				}
+				failure = cmp.Or(failure, keyFailure)
 			} else if IsSessionToken(token) {
 				identity := m.resolveSession(store, token)
 				if identity != nil {
-					return identity
+					return identity, ""
+				}
+			} else if IsJobToken(token) {
+				identity := m.resolveJobToken(store, token)
+				if identity != nil {
+					return identity, ""
 				}
 			}
 		}
@@ -86,34 +113,58 @@ func (m *Middleware) resolveIdentity(r *http.Request) *Identity {
 	// which cannot set custom headers)
 	if token := r.URL.Query().Get(constants.AuthQueryParamToken); token != "" {
 		if IsAPIKey(token) {
-			identity := m.resolveAPIKey(store, token)
-			if identity != nil {
-				return identity
+			var keyFailure string
+			if identity, keyFailure = m.resolveAPIKey(store, token); identity != nil {
+				return identity, ""
 			}
+			failure = cmp.Or(failure, keyFailure)
 		} else if IsSessionToken(token) {
 			identity := m.resolveSession(store, token)
 			if identity != nil {
-				return identity
+				return identity, ""
+			}
+		} else if IsJobToken(token) {
+			identity := m.resolveJobToken(store, token)
+			if identity != nil {
+				return identity, ""
 			}
 		}
 	}
 
-	return nil
+	// Priority 4: auth provider plugins, in configuration order
+	for _, p := range m.providers {
+		if identity := m.resolveProvider(store, p, r); identity != nil {
+			return identity, ""
+		}
+	}
+
+	return nil, failure
 }
 
-// resolveAPIKey looks up a user by their API key hash.
-func (m *Middleware) resolveAPIKey(store *Store, apiKey string) *Identity {
+// resolveAPIKey looks up a user by the hash of the key on their account or
+// of one of their labelled keys. An expired key yields no identity and the
+// failure constants.ErrCodeAuthAPIKeyExpired.
+func (m *Middleware) resolveAPIKey(store *Store, apiKey string) (*Identity, string) {
 	keyHash := HashToken(apiKey)
 
-	user, err := store.GetUserByAPIKeyHash(keyHash)
+	user, key, err := store.ResolveAPIKey(keyHash)
 	if err != nil {
 		m.logger.Debug("Auth: API key lookup failed: %v", err)
-		return nil
+		return nil, ""
+	}
+
+	switch key.Status {
+	case constants.AuthAPIKeyStatusExpired:
+		m.logger.Debug("Auth: API key %s of user %s expired at %d", key.KeyPrefix, user.Username, *key.ExpiresAt)
+		return nil, constants.ErrCodeAuthAPIKeyExpired
+	case constants.AuthAPIKeyStatusRevoked:
+		m.logger.Debug("Auth: API key %s of user %s is revoked", key.KeyPrefix, user.Username)
+		return nil, ""
 	}
 
 	if !user.IsActive {
 		m.logger.Debug("Auth: API key user %s is inactive", user.Username)
-		return nil
+		return nil, ""
 	}
 
 	// Check account lockout
@@ -121,10 +172,51 @@ func (m *Middleware) resolveAPIKey(store *Store, apiKey string) *Identity {
 		now := time.Now().Unix()
 		if now < *user.LockedUntil {
 			m.logger.Debug("Auth: API key user %s is locked until %d", user.Username, *user.LockedUntil)
-			return nil
+			return nil, ""
 		}
 	}
 
+	grants, err := store.GetActiveGrantsForUser(user.ID)
+	if err != nil {
+		m.logger.Error("Auth: failed to load grants for user %s: %v", user.Username, err)
+		return nil, ""
+	}
+
+	return &Identity{
+		User:            &user.User,
+		Method:          constants.AuthMethodAPIKey,
+		Grants:          grants,
+		APIKeyExpiresAt: key.ExpiresAt,
+	}, ""
+}
+
+// resolveProvider looks up the user an auth provider plugin authenticated
+// the request as.
+func (m *Middleware) resolveProvider(store *Store, p ExternalProvider, r *http.Request) *Identity {
+	username, err := p.Provider.Authenticate(r)
+	if err != nil {
+		m.logger.Warn("Auth: %s provider rejected request: %v", p.Type, err)
+		return nil
+	}
+	if username == "" {
+		return nil
+	}
+
+	user, err := store.GetUserByUsername(username)
+	if err != nil {
+		m.logger.Debug("Auth: %s provider user %s lookup failed: %v", p.Type, username, err)
+		return nil
+	}
+
+	if !user.IsActive {
+		m.logger.Debug("Auth: %s provider user %s is inactive", p.Type, user.Username)
+		return nil
+	}
+	if user.LockedUntil != nil && time.Now().Unix() < *user.LockedUntil {
+		m.logger.Debug("Auth: %s provider user %s is locked until %d", p.Type, user.Username, *user.LockedUntil)
+		return nil
+	}
+
 	grants, err := store.GetActiveGrantsForUser(user.ID)
 	if err != nil {
 		m.logger.Error("Auth: failed to load grants for user %s: %v", user.Username, err)
@@ -133,7 +225,7 @@ func (m *Middleware) resolveAPIKey(store *Store, apiKey string) *Identity {
 
 	return &Identity{
 		User:   &user.User,
-		Method: "api_key",
+		Method: constants.AuthMethodPlugin,
 		Grants: grants,
 	}
 }
@@ -164,11 +256,41 @@ func (m *Middleware) resolveSession(store *Store, token string) *Identity {
 
 	return &Identity{
 		User:   user,
-		Method: "session",
+		Method: constants.AuthMethodSession,
 		Grants: grants,
 	}
 }
 
+// resolveJobToken looks up the workspace token a CI job token was exchanged
+// for. The identity is the pipeline account holding the workspace token's
+// scopes as its grants.
+func (m *Middleware) resolveJobToken(store *Store, token string) *Identity {
+	wt, err := store.GetJobToken(HashToken(token))
+	if err != nil {
+		m.logger.Debug("Auth: job token lookup failed: %v", err)
+		return nil
+	}
+	if wt == nil {
+		return nil
+	}
+
+	user, err := store.GetUserByID(wt.AccountID)
+	if err != nil {
+		m.logger.Debug("Auth: pipeline account %d lookup failed: %v", wt.AccountID, err)
+		return nil
+	}
+
+	if err := store.TouchWorkspaceToken(wt.ID); err != nil {
+		m.logger.Warn("Auth: failed to touch workspace token %d: %v", wt.ID, err)
+	}
+
+	return &Identity{
+		User:   &user.User,
+		Method: constants.AuthMethodWorkspaceToken,
+		Grants: wt.Grants(),
+	}
+}
+
 // GetIdentity retrieves the authenticated identity from the request context.
 // Returns nil if no identity is present (unauthenticated request).
 func GetIdentity(r *http.Request) *Identity {
@@ -176,6 +298,13 @@ func GetIdentity(r *http.Request) *Identity {
 	return identity
 }
 
+// GetAuthFailure returns the error code explaining why the credential of an
+// unauthenticated request was refused, or "" when there is none to report.
+func GetAuthFailure(r *http.Request) string {
+	failure, _ := r.Context().Value(failureContextKey).(string)
+	return failure
+}
+
 // RequireAuth is a helper that extracts the identity and returns false if not present.
 // Handlers use this to enforce authentication:
 //
@@ -0,0 +1,409 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512" // SHA-384 and SHA-512 for RS384/512 and ES384/512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// ErrOIDCProvider wraps failures talking to the identity provider, as
+// opposed to tokens it issued that do not verify.
+var ErrOIDCProvider = errors.New("identity provider error")
+
+// OIDCProvider is an OpenID Connect provider's discovered endpoints and
+// signing keys. Keys are fetched on first use and again when a token is
+// signed by a key not seen yet.
+type OIDCProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	client *http.Client
+
+	mu            sync.Mutex
+	keys          map[string]crypto.PublicKey // by key ID
+	keysFetchedAt time.Time
+}
+
+// OIDCClaims are the claims of a verified ID token.
+type OIDCClaims map[string]interface{}
+
+// String returns a string claim, or "" when missing or not a string.
+func (c OIDCClaims) String(name string) string {
+	v, _ := c[name].(string)
+	return v
+}
+
+// Subject returns the sub claim, the provider's stable user identifier.
+func (c OIDCClaims) Subject() string {
+	return c.String("sub")
+}
+
+// EmailVerified reports whether the provider vouches for the email claim.
+// A missing email_verified claim counts as unverified.
+func (c OIDCClaims) EmailVerified() bool {
+	switch v := c[constants.OIDCEmailVerifiedClaim].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// GenerateOIDCSecret returns a random value for a sign-in state, nonce,
+// PKCE verifier or login code.
+func GenerateOIDCSecret() (string, error) {
+	return generateBase62(constants.OIDCRandomBytes)
+}
+
+// DiscoverOIDCProvider fetches the provider's discovery document and checks
+// it describes the configured issuer.
+func DiscoverOIDCProvider(ctx context.Context, client *http.Client, issuer string) (*OIDCProvider, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	p := &OIDCProvider{client: client}
+	if err := p.getJSON(ctx, issuer+constants.OIDCDiscoveryPath, p); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: discovery document is for issuer %q", ErrOIDCProvider, p.Issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: discovery document lacks endpoints", ErrOIDCProvider)
+	}
+	return p, nil
+}
+
+// AuthCodeURL returns the provider URL starting an authorization code flow
+// with PKCE (S256).
+func (p *OIDCProvider) AuthCodeURL(clientID, redirectURL string, scopes []string, state, nonce, verifier string) string {
+	if !slices.Contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", clientID)
+	q.Set("redirect_uri", redirectURL)
+	q.Set("scope", strings.Join(scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.AuthorizationEndpoint + sep + q.Encode()
+}
+
+// Exchange redeems an authorization code at the token endpoint and returns
+// the raw ID token. Confidential clients authenticate with
+// client_secret_basic; public clients (no secret) send their client ID.
+func (p *OIDCProvider) Exchange(ctx context.Context, clientID, clientSecret, redirectURL, code, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("code_verifier", verifier)
+	if clientSecret == "" {
+		form.Set("client_id", clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOIDCProvider, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: token request failed: %v", ErrOIDCProvider, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, constants.OIDCMaxResponseBytes)).Decode(&body); err != nil {
+		return "", fmt.Errorf("%w: token endpoint returned %d", ErrOIDCProvider, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("%w: token endpoint returned %d: %s %s", ErrOIDCProvider, resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: token response has no id_token", ErrOIDCProvider)
+	}
+	return body.IDToken, nil
+}
+
+// VerifyIDToken checks an ID token's signature against the provider's keys
+// and its issuer, audience, expiry and nonce, then returns its claims.
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, raw, clientID, nonce string, now time.Time) (OIDCClaims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed ID token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed ID token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed ID token signature: %w", err)
+	}
+
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWS(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims OIDCClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed ID token claims: %w", err)
+	}
+
+	if strings.TrimSuffix(claims.String("iss"), "/") != strings.TrimSuffix(p.Issuer, "/") {
+		return nil, fmt.Errorf("ID token issued by %q", claims.String("iss"))
+	}
+	var audiences []string
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+	}
+	if !slices.Contains(audiences, clientID) {
+		return nil, errors.New("ID token not issued for this client")
+	}
+	if azp := claims.String("azp"); len(audiences) > 1 && azp != clientID {
+		return nil, errors.New("ID token authorized for another party")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.Add(-constants.OIDCClockSkew).Unix() >= int64(exp) {
+		return nil, errors.New("ID token expired")
+	}
+	if claims.String("nonce") != nonce {
+		return nil, errors.New("ID token nonce mismatch")
+	}
+	if claims.Subject() == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+	return claims, nil
+}
+
+// key returns the signing key of a key ID, refetching the provider's keys
+// when it is unknown. An empty key ID matches the only key of a key set.
+func (p *OIDCProvider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lookup := func() crypto.PublicKey {
+		if kid == "" && len(p.keys) == 1 {
+			for _, k := range p.keys {
+				return k
+			}
+		}
+		return p.keys[kid]
+	}
+
+	if k := lookup(); k != nil {
+		return k, nil
+	}
+	if time.Since(p.keysFetchedAt) < constants.OIDCJWKSRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	p.keys = make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped; tokens they sign fail below
+		if k, err := jwk.publicKey(); err == nil {
+			p.keys[jwk.Kid] = k
+		}
+	}
+	p.keysFetchedAt = time.Now()
+
+	if k := lookup(); k != nil {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOIDCProvider, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOIDCProvider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: GET %s returned %d", ErrOIDCProvider, target, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, constants.OIDCMaxResponseBytes)).Decode(v); err != nil {
+		return fmt.Errorf("%w: GET %s: %v", ErrOIDCProvider, target, err)
+	}
+	return nil
+}
+
+// jsonWebKey is an RSA or EC public key of a JWK set.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC point")
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// verifyJWS checks a JWS signature made with one of the RS* or ES*
+// algorithms. Other algorithms, "none" included, are refused.
+func verifyJWS(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	var cryptoHash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		cryptoHash = crypto.SHA256
+	case "RS384", "ES384":
+		cryptoHash = crypto.SHA384
+	case "RS512", "ES512":
+		cryptoHash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	h := cryptoHash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch {
+	case strings.HasPrefix(alg, "RS"):
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key does not match algorithm %s", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, cryptoHash, digest, signature); err != nil {
+			return errors.New("invalid ID token signature")
+		}
+		return nil
+
+	case strings.HasPrefix(alg, "ES"):
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key does not match algorithm %s", alg)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid ID token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid ID token signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported signing algorithm %q", alg)
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
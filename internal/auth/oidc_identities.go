@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// OIDCIdentity links a user of an OpenID Connect provider, identified by
+// the provider's issuer and subject, to a local user.
+type OIDCIdentity struct {
+	Issuer      string `json:"issuer"`
+	Subject     string `json:"subject"`
+	UserID      int64  `json:"user_id"`
+	Email       string `json:"email,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+	LastLoginAt int64  `json:"last_login_at"`
+}
+
+// ============================================================================
+// OpenID Connect Identity Storage
+// ============================================================================
+
+// GetOIDCIdentity returns the identity of an issuer's subject, or nil when
+// it is not linked to a user yet.
+func (s *Store) GetOIDCIdentity(issuer, subject string) (*OIDCIdentity, error) {
+	var id OIDCIdentity
+	err := s.db.QueryRow(`
+		SELECT issuer, subject, user_id, email, created_at, last_login_at
+		FROM auth_oidc_identities WHERE issuer = ? AND subject = ?
+	`, issuer, subject).Scan(&id.Issuer, &id.Subject, &id.UserID, &id.Email, &id.CreatedAt, &id.LastLoginAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OIDC identity: %w", err)
+	}
+	return &id, nil
+}
+
+// HasOIDCIdentity reports whether a user is linked to an identity of the
+// issuer.
+func (s *Store) HasOIDCIdentity(issuer string, userID int64) (bool, error) {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM auth_oidc_identities WHERE issuer = ? AND user_id = ?
+	`, issuer, userID).Scan(&count)
+	return count > 0, err
+}
+
+// LinkOIDCIdentity links an identity to an existing user.
+func (s *Store) LinkOIDCIdentity(issuer, subject string, userID int64, email string) error {
+	now := time.Now().Unix()
+	_, err := s.db.Exec(`
+		INSERT INTO auth_oidc_identities (issuer, subject, user_id, email, created_at, last_login_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, issuer, subject, userID, email, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to link OIDC identity: %w", err)
+	}
+	return nil
+}
+
+// CreateOIDCUser creates a user for an identity and links it in one
+// transaction. The password hash is left empty so the user can only sign
+// in through the provider.
+func (s *Store) CreateOIDCUser(username, displayName, issuer, subject, email string) (*User, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin OIDC user creation: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	result, err := tx.Exec(`
+		INSERT INTO auth_users (username, display_name, password_hash, is_active, is_bootstrap, created_at, updated_at)
+		VALUES (?, ?, '', 1, 0, ?, ?)
+	`, username, displayName, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user id: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO auth_oidc_identities (issuer, subject, user_id, email, created_at, last_login_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, issuer, subject, id, email, now, now); err != nil {
+		return nil, fmt.Errorf("failed to link OIDC identity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit OIDC user creation: %w", err)
+	}
+
+	return &User{
+		ID:          id,
+		Username:    username,
+		DisplayName: displayName,
+		IsActive:    true,
+		AccountType: constants.AuthAccountTypeUser,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// TouchOIDCIdentity records a sign-in of an identity and the email the
+// provider reported for it.
+func (s *Store) TouchOIDCIdentity(issuer, subject, email string) error {
+	_, err := s.db.Exec(`
+		UPDATE auth_oidc_identities SET email = ?, last_login_at = ?
+		WHERE issuer = ? AND subject = ?
+	`, email, time.Now().Unix(), issuer, subject)
+	return err
+}
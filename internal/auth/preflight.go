@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"sort"
+
+	"silobang/internal/constants"
+)
+
+// PreflightTarget is one asset a bulk operation would touch.
+type PreflightTarget struct {
+	Hash  string
+	Topic string
+	Size  int64
+}
+
+// PreflightItem describes an asset or topic that would be denied.
+type PreflightItem struct {
+	Hash   string `json:"hash,omitempty"`
+	Topic  string `json:"topic,omitempty"`
+	Reason string `json:"reason"`
+	Code   string `json:"code"`
+}
+
+// PreflightResult reports which targets of a bulk operation the caller's
+// grants would allow. Allowed is true only when every target passes.
+type PreflightResult struct {
+	Action        string          `json:"action"`
+	Allowed       bool            `json:"allowed"`
+	Total         int             `json:"total"`
+	AllowedCount  int             `json:"allowed_count"`
+	DeniedCount   int             `json:"denied_count"`
+	RequestDenied *PreflightItem  `json:"request_denied,omitempty"`
+	DeniedTopics  []PreflightItem `json:"denied_topics"`
+	Denied        []PreflightItem `json:"denied"`
+	NotFound      []string        `json:"not_found"`
+}
+
+// Preflight evaluates a bulk operation against the identity's grants without
+// executing it or consuming quota. The request as a whole is checked first
+// (asset count, volume, quotas), then each distinct topic. A request-level
+// denial denies every target.
+func (e *PolicyEvaluator) Preflight(identity *Identity, action string, targets []PreflightTarget, notFound []string) *PreflightResult {
+	result := &PreflightResult{
+		Action:       action,
+		Total:        len(targets),
+		DeniedTopics: []PreflightItem{},
+		Denied:       []PreflightItem{},
+		NotFound:     notFound,
+	}
+	if result.NotFound == nil {
+		result.NotFound = []string{}
+	}
+
+	var volume int64
+	for _, t := range targets {
+		volume += t.Size
+	}
+
+	request := e.Evaluate(identity, &ActionContext{
+		Action:      action,
+		AssetCount:  len(targets),
+		VolumeBytes: volume,
+	})
+	if !request.Allowed {
+		result.RequestDenied = &PreflightItem{Reason: request.Reason, Code: request.DeniedCode}
+		for _, t := range targets {
+			result.Denied = append(result.Denied, PreflightItem{
+				Hash: t.Hash, Topic: t.Topic, Reason: request.Reason, Code: request.DeniedCode,
+			})
+		}
+		result.DeniedCount = len(result.Denied)
+		return result
+	}
+
+	// Evaluate each topic once
+	topicResults := make(map[string]*PolicyResult)
+	for _, t := range targets {
+		if _, ok := topicResults[t.Topic]; ok {
+			continue
+		}
+		topicResults[t.Topic] = e.Evaluate(identity, &ActionContext{Action: action, TopicName: t.Topic})
+	}
+
+	for _, t := range targets {
+		if r := topicResults[t.Topic]; !r.Allowed {
+			result.Denied = append(result.Denied, PreflightItem{
+				Hash: t.Hash, Topic: t.Topic, Reason: r.Reason, Code: r.DeniedCode,
+			})
+		}
+	}
+
+	topics := make([]string, 0, len(topicResults))
+	for topic, r := range topicResults {
+		if !r.Allowed {
+			topics = append(topics, topic)
+		}
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		r := topicResults[topic]
+		result.DeniedTopics = append(result.DeniedTopics, PreflightItem{Topic: topic, Reason: r.Reason, Code: r.DeniedCode})
+	}
+
+	result.DeniedCount = len(result.Denied)
+	result.AllowedCount = result.Total - result.DeniedCount
+	result.Allowed = result.DeniedCount == 0
+	return result
+}
+
+// QuotaExceeded reports whether the denial is due to a quota rather than a
+// missing grant or constraint, so callers can answer 429 instead of 403.
+func (r *PreflightResult) QuotaExceeded() bool {
+	if r.RequestDenied != nil {
+		return r.RequestDenied.Code == constants.ErrCodeAuthQuotaExceeded
+	}
+	for _, item := range r.DeniedTopics {
+		if item.Code == constants.ErrCodeAuthQuotaExceeded {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestPreflight_DeniesTargetsInForbiddenTopic(t *testing.T) {
+	eval, _ := setupEvaluator(t)
+
+	user := &User{ID: 1, Username: "meta", IsActive: true}
+	constraints := marshalConstraints(t, MetadataConstraints{AllowedTopics: []string{"allowed"}})
+	identity := makeIdentity(user, []Grant{
+		{ID: 1, Action: constants.AuthActionMetadata, IsActive: true, ConstraintsJSON: constraints},
+	})
+
+	targets := []PreflightTarget{
+		{Hash: "a", Topic: "allowed"},
+		{Hash: "b", Topic: "forbidden"},
+		{Hash: "c", Topic: "forbidden"},
+	}
+	result := eval.Preflight(identity, constants.AuthActionMetadata, targets, []string{"missing"})
+
+	if result.Allowed {
+		t.Fatal("expected preflight to be denied")
+	}
+	if result.Total != 3 || result.AllowedCount != 1 || result.DeniedCount != 2 {
+		t.Errorf("expected total=3 allowed=1 denied=2, got %d/%d/%d", result.Total, result.AllowedCount, result.DeniedCount)
+	}
+	if len(result.DeniedTopics) != 1 || result.DeniedTopics[0].Topic != "forbidden" {
+		t.Errorf("expected one denied topic 'forbidden', got %+v", result.DeniedTopics)
+	}
+	for _, item := range result.Denied {
+		if item.Code != constants.ErrCodeAuthConstraintViolation {
+			t.Errorf("expected code %q, got %q", constants.ErrCodeAuthConstraintViolation, item.Code)
+		}
+	}
+	if len(result.NotFound) != 1 || result.NotFound[0] != "missing" {
+		t.Errorf("expected not_found [missing], got %v", result.NotFound)
+	}
+	if result.QuotaExceeded() {
+		t.Error("constraint denial should not be reported as quota exceeded")
+	}
+}
+
+func TestPreflight_AllAllowed(t *testing.T) {
+	eval, _ := setupEvaluator(t)
+
+	user := &User{ID: 1, Username: "meta", IsActive: true}
+	identity := makeIdentity(user, []Grant{
+		{ID: 1, Action: constants.AuthActionMetadata, IsActive: true},
+	})
+
+	result := eval.Preflight(identity, constants.AuthActionMetadata, []PreflightTarget{
+		{Hash: "a", Topic: "one"},
+		{Hash: "b", Topic: "two"},
+	}, nil)
+
+	if !result.Allowed {
+		t.Fatalf("expected allowed, got %+v", result)
+	}
+	if result.AllowedCount != 2 || len(result.Denied) != 0 || result.NotFound == nil {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestPreflight_RequestLevelDenialDeniesAll(t *testing.T) {
+	eval, _ := setupEvaluator(t)
+
+	user := &User{ID: 1, Username: "bulk", IsActive: true}
+	constraints := marshalConstraints(t, BulkDownloadConstraints{MaxAssetsPerRequest: 1})
+	identity := makeIdentity(user, []Grant{
+		{ID: 1, Action: constants.AuthActionBulkDownload, IsActive: true, ConstraintsJSON: constraints},
+	})
+
+	result := eval.Preflight(identity, constants.AuthActionBulkDownload, []PreflightTarget{
+		{Hash: "a", Topic: "t", Size: 10},
+		{Hash: "b", Topic: "t", Size: 10},
+	}, nil)
+
+	if result.Allowed {
+		t.Fatal("expected denial for exceeding max assets per request")
+	}
+	if result.RequestDenied == nil || result.RequestDenied.Code != constants.ErrCodeAuthConstraintViolation {
+		t.Fatalf("expected request-level constraint violation, got %+v", result.RequestDenied)
+	}
+	if result.DeniedCount != 2 || result.AllowedCount != 0 {
+		t.Errorf("expected every target denied, got allowed=%d denied=%d", result.AllowedCount, result.DeniedCount)
+	}
+}
+
+func TestPreflight_QuotaExceeded(t *testing.T) {
+	eval, store := setupEvaluator(t)
+
+	user, _ := store.CreateUser("pf-quota", "PF Quota", "hash", nil)
+	constraints := marshalConstraints(t, MetadataConstraints{DailyCountLimit: 1})
+	identity := makeIdentity(user, []Grant{
+		{ID: 1, Action: constants.AuthActionMetadata, IsActive: true, ConstraintsJSON: constraints},
+	})
+	store.IncrementQuota(user.ID, constants.AuthActionMetadata, 1, 0)
+
+	result := eval.Preflight(identity, constants.AuthActionMetadata, []PreflightTarget{{Hash: "a", Topic: "t"}}, nil)
+
+	if result.Allowed {
+		t.Fatal("expected denial when quota is exhausted")
+	}
+	if !result.QuotaExceeded() {
+		t.Errorf("expected quota exceeded, got %+v", result.RequestDenied)
+	}
+}
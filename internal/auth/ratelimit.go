@@ -0,0 +1,35 @@
+package auth
+
+import "encoding/json"
+
+// RateLimitOverride returns the rate limit set by the rate_limit_per_min and
+// rate_limit_burst constraints of the identity's active grants for action.
+// With several such grants the most generous value applies; a value no
+// grant sets is 0. ok is false when no grant sets either.
+func RateLimitOverride(identity *Identity, action string) (perMin, burst int, ok bool) {
+	if identity == nil {
+		return 0, 0, false
+	}
+
+	for _, g := range identity.Grants {
+		if g.Action != action || !g.IsActive || g.ConstraintsJSON == nil || *g.ConstraintsJSON == "" {
+			continue
+		}
+		var c struct {
+			RateLimitPerMin int `json:"rate_limit_per_min"`
+			RateLimitBurst  int `json:"rate_limit_burst"`
+		}
+		if err := json.Unmarshal([]byte(*g.ConstraintsJSON), &c); err != nil {
+			continue
+		}
+		if c.RateLimitPerMin > 0 {
+			perMin = max(perMin, c.RateLimitPerMin)
+			ok = true
+		}
+		if c.RateLimitBurst > 0 {
+			burst = max(burst, c.RateLimitBurst)
+			ok = true
+		}
+	}
+	return perMin, burst, ok
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestRateLimitOverride_MostGenerousActiveGrant(t *testing.T) {
+	user := &User{ID: 1, Username: "script", IsActive: true}
+	identity := makeIdentity(user, []Grant{
+		{ID: 1, Action: constants.AuthActionQuery, IsActive: true,
+			ConstraintsJSON: marshalConstraints(t, QueryConstraints{RateLimitPerMin: 10, RateLimitBurst: 2})},
+		{ID: 2, Action: constants.AuthActionQuery, IsActive: true,
+			ConstraintsJSON: marshalConstraints(t, QueryConstraints{RateLimitPerMin: 30})},
+		{ID: 3, Action: constants.AuthActionQuery, IsActive: false,
+			ConstraintsJSON: marshalConstraints(t, QueryConstraints{RateLimitPerMin: 1000})},
+		{ID: 4, Action: constants.AuthActionUpload, IsActive: true},
+	})
+
+	perMin, burst, ok := RateLimitOverride(identity, constants.AuthActionQuery)
+	if !ok || perMin != 30 || burst != 2 {
+		t.Errorf("expected 30/min burst 2, got %d/min burst %d ok=%v", perMin, burst, ok)
+	}
+	if _, _, ok := RateLimitOverride(identity, constants.AuthActionUpload); ok {
+		t.Error("a grant without rate limit constraints should not override")
+	}
+	if _, _, ok := RateLimitOverride(nil, constants.AuthActionQuery); ok {
+		t.Error("nil identity should not override")
+	}
+}
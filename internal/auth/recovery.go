@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+// ErrInvalidRecoveryToken is returned when a recovery token is unknown,
+// expired or already used.
+var ErrInvalidRecoveryToken = errors.New("invalid, expired or already used recovery token")
+
+// GenerateRecoveryToken creates a new break-glass token with the mbr_ prefix.
+// Returns the plaintext token (printed once to the operator's console).
+func GenerateRecoveryToken() (string, error) {
+	encoded, err := generateBase62(constants.AuthRecoveryTokenBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate recovery token: %w", err)
+	}
+	return constants.RecoveryTokenPrefix + encoded, nil
+}
+
+// IsRecoveryToken checks if a token has the recovery token prefix.
+func IsRecoveryToken(token string) bool {
+	return strings.HasPrefix(token, constants.RecoveryTokenPrefix)
+}
+
+// IssueRecoveryToken creates a single-use recovery token and stores its hash.
+// Only callers with filesystem access to the orchestrator DB can do this,
+// which is what makes it a break-glass path rather than an API feature.
+// Returns the plaintext token and its expiry (unix seconds).
+func IssueRecoveryToken(store *Store) (string, int64, error) {
+	token, err := GenerateRecoveryToken()
+	if err != nil {
+		return "", 0, err
+	}
+
+	expiresAt, err := store.CreateRecoveryToken(HashToken(token), ExtractTokenPrefix(token), constants.AuthRecoveryTokenTTL)
+	if err != nil {
+		return "", 0, err
+	}
+	return token, expiresAt, nil
+}
+
+// RecoverBootstrapAdmin exchanges a recovery token for fresh bootstrap admin
+// credentials. The bootstrap user is re-enabled and unlocked, its sessions and
+// labelled API keys are revoked, any missing unconstrained grants are
+// restored, and a new password and API key are generated, all in one
+// transaction with consuming the token. Returns ErrInvalidRecoveryToken if
+// the token cannot be used.
+func RecoverBootstrapAdmin(store *Store, token, ipAddress string, log *logger.Logger) (*BootstrapResult, int64, error) {
+	if !IsRecoveryToken(token) {
+		return nil, 0, ErrInvalidRecoveryToken
+	}
+
+	password, err := GeneratePassword()
+	if err != nil {
+		return nil, 0, err
+	}
+	apiKey, err := GenerateAPIKey()
+	if err != nil {
+		return nil, 0, err
+	}
+	passwordHash, err := HashPassword(password)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	user, revokedKeys, err := store.RecoverBootstrapUser(HashToken(token), ipAddress, RecoveryReset{
+		PasswordHash: passwordHash,
+		APIKeyHash:   HashToken(apiKey),
+		APIKeyPrefix: ExtractTokenPrefix(apiKey),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	log.Warn("Auth: BREAK-GLASS RECOVERY — credentials for bootstrap user '%s' (id=%d) reset from ip=%s, %d labelled API keys revoked",
+		user.Username, user.ID, ipAddress, revokedKeys)
+
+	return &BootstrapResult{
+		Username: user.Username,
+		Password: password,
+		APIKey:   apiKey,
+	}, user.ID, nil
+}
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+func setupBootstrapped(t *testing.T) (*Store, *BootstrapResult) {
+	t.Helper()
+	store := setupTestStore(t)
+	result, err := Bootstrap(store, logger.NewLogger(logger.LevelError))
+	if err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	return store, result
+}
+
+func TestRecoverBootstrapAdmin_ResetsCredentials(t *testing.T) {
+	store, original := setupBootstrapped(t)
+	log := logger.NewLogger(logger.LevelError)
+
+	admin, _ := store.GetBootstrapUser()
+	store.UpdateUser(admin.ID, admin.DisplayName, false)
+	for i := 0; i < constants.AuthMaxLoginAttempts; i++ {
+		store.IncrementFailedLogin(admin.ID)
+	}
+
+	token, _, err := IssueRecoveryToken(store)
+	if err != nil {
+		t.Fatalf("IssueRecoveryToken failed: %v", err)
+	}
+	if !IsRecoveryToken(token) {
+		t.Fatalf("expected %s prefix, got %q", constants.RecoveryTokenPrefix, token)
+	}
+
+	result, userID, err := RecoverBootstrapAdmin(store, token, "127.0.0.1", log)
+	if err != nil {
+		t.Fatalf("RecoverBootstrapAdmin failed: %v", err)
+	}
+	if userID != admin.ID || result.Username != constants.AuthBootstrapUsername {
+		t.Errorf("expected bootstrap user %d, got %d/%s", admin.ID, userID, result.Username)
+	}
+	if result.APIKey == original.APIKey || result.Password == original.Password {
+		t.Error("expected new credentials")
+	}
+
+	recovered, _ := store.GetUserByID(admin.ID)
+	if !recovered.IsActive {
+		t.Error("expected bootstrap user to be re-enabled")
+	}
+	if recovered.LockedUntil != nil || recovered.FailedLoginCount != 0 {
+		t.Error("expected lockout to be cleared")
+	}
+	if err := VerifyPassword(result.Password, recovered.PasswordHash); err != nil {
+		t.Error("new password does not verify")
+	}
+	if recovered.APIKeyHash != HashToken(result.APIKey) {
+		t.Error("new API key not stored")
+	}
+}
+
+func TestRecoverBootstrapAdmin_SingleUse(t *testing.T) {
+	store, _ := setupBootstrapped(t)
+	log := logger.NewLogger(logger.LevelError)
+
+	token, _, _ := IssueRecoveryToken(store)
+	if _, _, err := RecoverBootstrapAdmin(store, token, "127.0.0.1", log); err != nil {
+		t.Fatalf("first use failed: %v", err)
+	}
+	if _, _, err := RecoverBootstrapAdmin(store, token, "127.0.0.1", log); err != ErrInvalidRecoveryToken {
+		t.Errorf("expected ErrInvalidRecoveryToken on reuse, got %v", err)
+	}
+}
+
+func TestRecoverBootstrapAdmin_InvalidTokens(t *testing.T) {
+	store, _ := setupBootstrapped(t)
+	log := logger.NewLogger(logger.LevelError)
+
+	first, _, _ := IssueRecoveryToken(store)
+	second, _, _ := IssueRecoveryToken(store)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong prefix", constants.APIKeyPrefix + "abc"},
+		{"unknown token", constants.RecoveryTokenPrefix + "unknown"},
+		{"superseded token", first},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := RecoverBootstrapAdmin(store, tt.token, "127.0.0.1", log); err != ErrInvalidRecoveryToken {
+				t.Errorf("expected ErrInvalidRecoveryToken, got %v", err)
+			}
+		})
+	}
+
+	if _, _, err := RecoverBootstrapAdmin(store, second, "127.0.0.1", log); err != nil {
+		t.Errorf("latest token should still work: %v", err)
+	}
+}
+
+func TestRecoverBootstrapAdmin_ExpiredToken(t *testing.T) {
+	store, _ := setupBootstrapped(t)
+	log := logger.NewLogger(logger.LevelError)
+
+	token, err := GenerateRecoveryToken()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryToken failed: %v", err)
+	}
+	if _, err := store.CreateRecoveryToken(HashToken(token), ExtractTokenPrefix(token), -1); err != nil {
+		t.Fatalf("CreateRecoveryToken failed: %v", err)
+	}
+
+	if _, _, err := RecoverBootstrapAdmin(store, token, "127.0.0.1", log); err != ErrInvalidRecoveryToken {
+		t.Errorf("expected ErrInvalidRecoveryToken for expired token, got %v", err)
+	}
+}
+
+func TestRecoverBootstrapAdmin_RestoresGrants(t *testing.T) {
+	store, _ := setupBootstrapped(t)
+	log := logger.NewLogger(logger.LevelError)
+
+	admin, _ := store.GetBootstrapUser()
+	grants, _ := store.GetActiveGrantsForUser(admin.ID)
+	for _, g := range grants {
+		if g.Action == constants.AuthActionManageUsers {
+			store.RevokeGrant(g.ID, admin.ID)
+		}
+	}
+
+	token, _, _ := IssueRecoveryToken(store)
+	if _, _, err := RecoverBootstrapAdmin(store, token, "127.0.0.1", log); err != nil {
+		t.Fatalf("RecoverBootstrapAdmin failed: %v", err)
+	}
+
+	grants, _ = store.GetActiveGrantsForUser(admin.ID)
+	actions := make(map[string]bool)
+	for _, g := range grants {
+		actions[g.Action] = true
+	}
+	for _, action := range constants.AllAuthActions {
+		if !actions[action] {
+			t.Errorf("expected grant for %s after recovery", action)
+		}
+	}
+	if len(grants) != len(constants.AllAuthActions) {
+		t.Errorf("expected %d grants (no duplicates), got %d", len(constants.AllAuthActions), len(grants))
+	}
+}
+
+func TestRecoverBootstrapAdmin_RevokesLabelledAPIKeys(t *testing.T) {
+	store, _ := setupBootstrapped(t)
+	log := logger.NewLogger(logger.LevelError)
+	admin, _ := store.GetBootstrapUser()
+
+	minted, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey failed: %v", err)
+	}
+	if _, err := store.CreateAPIKey(admin.ID, "left behind", HashToken(minted), ExtractTokenPrefix(minted), nil, admin.ID); err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	m := NewMiddleware(func() *Store { return store }, log)
+	if identity, _ := m.resolveAPIKey(store, minted); identity == nil {
+		t.Fatal("expected the labelled key to work before recovery")
+	}
+
+	token, _, _ := IssueRecoveryToken(store)
+	result, _, err := RecoverBootstrapAdmin(store, token, "127.0.0.1", log)
+	if err != nil {
+		t.Fatalf("RecoverBootstrapAdmin failed: %v", err)
+	}
+
+	if identity, _ := m.resolveAPIKey(store, minted); identity != nil {
+		t.Error("expected the labelled key created before recovery to be rejected")
+	}
+	if _, key, err := store.ResolveAPIKey(HashToken(minted)); err != nil || key.Status != constants.AuthAPIKeyStatusRevoked {
+		t.Errorf("expected the labelled key to be revoked, got %+v (%v)", key, err)
+	}
+	if identity, _ := m.resolveAPIKey(store, result.APIKey); identity == nil {
+		t.Error("expected the new API key to work")
+	}
+}
+
+// A recovery that fails part-way must leave the account and the token as
+// they were, so the operator can retry with the same token.
+func TestRecoverBootstrapAdmin_RollsBackOnFailure(t *testing.T) {
+	store, original := setupBootstrapped(t)
+	log := logger.NewLogger(logger.LevelError)
+	admin, _ := store.GetBootstrapUser()
+
+	if _, err := store.CreateSession("session-hash", "mbs_test", admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if _, err := store.db.Exec(`CREATE TRIGGER fail_session_delete BEFORE DELETE ON auth_sessions
+		BEGIN SELECT RAISE(ABORT, 'injected failure'); END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+
+	token, _, _ := IssueRecoveryToken(store)
+	if _, _, err := RecoverBootstrapAdmin(store, token, "127.0.0.1", log); err == nil || err == ErrInvalidRecoveryToken {
+		t.Fatalf("expected the injected failure, got %v", err)
+	}
+	unchanged, _ := store.GetUserByID(admin.ID)
+	if unchanged.APIKeyHash != HashToken(original.APIKey) || unchanged.PasswordHash != admin.PasswordHash {
+		t.Error("expected the credentials to be left as they were")
+	}
+
+	if _, err := store.db.Exec(`DROP TRIGGER fail_session_delete`); err != nil {
+		t.Fatalf("failed to drop trigger: %v", err)
+	}
+	result, _, err := RecoverBootstrapAdmin(store, token, "127.0.0.1", log)
+	if err != nil {
+		t.Fatalf("expected the token to still work after the failed attempt, got %v", err)
+	}
+	if recovered, _ := store.GetUserByID(admin.ID); recovered.APIKeyHash != HashToken(result.APIKey) {
+		t.Error("new API key not stored")
+	}
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// ErrS3CredentialsUnavailable is returned for access keys that do not map
+// to an active user with an API key.
+var ErrS3CredentialsUnavailable = errors.New("no S3 credentials for this access key")
+
+// S3Credentials are the Signature Version 4 credentials of a user's API key
+// for the S3 gateway. The secret is an HMAC of the stored API key hash under
+// the server signing key, so regenerating the API key rotates it, and the
+// auth database alone is not enough to compute it.
+type S3Credentials struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+// LoadS3SigningKey reads the server key S3 secrets are derived with from
+// path, creating it with random bytes on first use. The file is kept out
+// of the orchestrator database and of backups.
+func LoadS3SigningKey(path string) ([]byte, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, constants.SecretFilePermissions)
+	if err == nil {
+		key := make([]byte, constants.S3SigningKeyBytes)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			f.Close()
+			os.Remove(path)
+			return nil, fmt.Errorf("failed to generate S3 signing key: %w", err)
+		}
+		if _, err := f.Write(key); err != nil {
+			f.Close()
+			os.Remove(path)
+			return nil, fmt.Errorf("failed to write S3 signing key: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			os.Remove(path)
+			return nil, fmt.Errorf("failed to write S3 signing key: %w", err)
+		}
+		return key, nil
+	}
+	if !os.IsExist(err) {
+		return nil, fmt.Errorf("failed to create S3 signing key: %w", err)
+	}
+
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read S3 signing key: %w", err)
+	}
+	if len(key) != constants.S3SigningKeyBytes {
+		return nil, fmt.Errorf("S3 signing key %s is %d bytes, expected %d", path, len(key), constants.S3SigningKeyBytes)
+	}
+	return key, nil
+}
+
+// S3CredentialsFor returns the S3 credentials of user under signingKey, or
+// false when the user has no API key.
+func S3CredentialsFor(user *UserWithSensitive, signingKey []byte) (*S3Credentials, bool) {
+	if user.APIKeyHash == "" {
+		return nil, false
+	}
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(constants.S3SecretKeyLabel))
+	mac.Write([]byte{0})
+	mac.Write([]byte(user.APIKeyHash))
+	return &S3Credentials{
+		AccessKeyID:     fmt.Sprintf("%s%0*d", constants.S3AccessKeyPrefix, constants.S3AccessKeyIDDigits, user.ID),
+		SecretAccessKey: hex.EncodeToString(mac.Sum(nil))[:constants.S3SecretKeyLength],
+	}, true
+}
+
+// ResolveS3AccessKey returns the identity an access key ID stands for and
+// the secret its requests are signed with. Inactive and locked accounts,
+// and accounts whose API key expired, are refused, as for API keys.
+func ResolveS3AccessKey(store AccountStore, signingKey []byte, accessKeyID string) (*Identity, string, error) {
+	digits, ok := strings.CutPrefix(accessKeyID, constants.S3AccessKeyPrefix)
+	if !ok || len(digits) != constants.S3AccessKeyIDDigits {
+		return nil, "", ErrS3CredentialsUnavailable
+	}
+	userID, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return nil, "", ErrS3CredentialsUnavailable
+	}
+
+	user, err := store.GetUserByID(userID)
+	if err != nil {
+		return nil, "", ErrS3CredentialsUnavailable
+	}
+	if !user.IsActive || (user.LockedUntil != nil && time.Now().Unix() < *user.LockedUntil) {
+		return nil, "", ErrS3CredentialsUnavailable
+	}
+	// Credentials derive from the key on the account and expire with it
+	if user.APIKeyExpiresAt != nil && time.Now().Unix() >= *user.APIKeyExpiresAt {
+		return nil, "", ErrS3CredentialsUnavailable
+	}
+	creds, ok := S3CredentialsFor(user, signingKey)
+	if !ok {
+		return nil, "", ErrS3CredentialsUnavailable
+	}
+
+	grants, err := store.GetActiveGrantsForUser(user.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load grants: %w", err)
+	}
+	return &Identity{User: &user.User, Method: constants.AuthMethodS3, Grants: grants}, creds.SecretAccessKey, nil
+}
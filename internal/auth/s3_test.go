@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestLoadS3SigningKey_CreatesOnceAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), constants.S3SigningKeyFile)
+
+	key, err := LoadS3SigningKey(path)
+	if err != nil {
+		t.Fatalf("LoadS3SigningKey failed: %v", err)
+	}
+	if len(key) != constants.S3SigningKeyBytes {
+		t.Fatalf("expected %d bytes, got %d", constants.S3SigningKeyBytes, len(key))
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != constants.SecretFilePermissions {
+		t.Fatalf("expected key file with mode %v, got %v (%v)", constants.SecretFilePermissions, info.Mode().Perm(), err)
+	}
+
+	again, err := LoadS3SigningKey(path)
+	if err != nil {
+		t.Fatalf("second LoadS3SigningKey failed: %v", err)
+	}
+	if string(again) != string(key) {
+		t.Fatal("expected the key to be read back, not regenerated")
+	}
+
+	os.WriteFile(path, []byte("short"), constants.SecretFilePermissions)
+	if _, err := LoadS3SigningKey(path); err == nil {
+		t.Fatal("expected a truncated key file to be refused")
+	}
+}
+
+// The secret must not be computable from the auth database alone.
+func TestS3CredentialsFor_DependsOnSigningKey(t *testing.T) {
+	user := &UserWithSensitive{User: User{ID: 7}, APIKeyHash: HashToken("mbk_test")}
+
+	a, ok := S3CredentialsFor(user, []byte("server-key-a"))
+	if !ok {
+		t.Fatal("expected credentials for a user with an API key")
+	}
+	b, _ := S3CredentialsFor(user, []byte("server-key-b"))
+	if a.AccessKeyID != b.AccessKeyID || a.SecretAccessKey == b.SecretAccessKey {
+		t.Fatalf("expected the same access key with different secrets, got %+v and %+v", a, b)
+	}
+	if len(a.SecretAccessKey) != constants.S3SecretKeyLength {
+		t.Fatalf("expected a %d character secret, got %q", constants.S3SecretKeyLength, a.SecretAccessKey)
+	}
+
+	if _, ok := S3CredentialsFor(&UserWithSensitive{User: User{ID: 8}}, []byte("server-key-a")); ok {
+		t.Fatal("expected no credentials without an API key")
+	}
+}
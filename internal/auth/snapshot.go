@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// Snapshot is a declarative description of the accounts of an instance and
+// their active grants, exported and imported as YAML or JSON. It never holds
+// secrets: passwords, API keys and tokens stay with each instance.
+type Snapshot struct {
+	Version int            `json:"version" yaml:"version"`
+	Roles   []SnapshotRole `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Users   []SnapshotUser `json:"users" yaml:"users"`
+}
+
+// SnapshotRole is a named set of grants that users of a snapshot take on by
+// listing it under roles. Roles only exist in snapshots: an import expands
+// them into per-user grants.
+type SnapshotRole struct {
+	Name   string          `json:"name" yaml:"name"`
+	Grants []SnapshotGrant `json:"grants" yaml:"grants"`
+}
+
+// SnapshotUser is one account of a snapshot.
+type SnapshotUser struct {
+	Username    string          `json:"username" yaml:"username"`
+	DisplayName string          `json:"display_name,omitempty" yaml:"display_name,omitempty"`
+	AccountType string          `json:"account_type,omitempty" yaml:"account_type,omitempty"` // "user" (default) or "service"
+	Active      *bool           `json:"active,omitempty" yaml:"active,omitempty"`             // true when omitted
+	Roles       []string        `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Grants      []SnapshotGrant `json:"grants,omitempty" yaml:"grants,omitempty"`
+}
+
+// SnapshotGrant is one active grant of a snapshot user. Constraints take the
+// shape of the grant's constraints JSON.
+type SnapshotGrant struct {
+	Action      string                 `json:"action" yaml:"action"`
+	Constraints map[string]interface{} `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+}
+
+// ConstraintsJSON returns the constraints as stored on a grant, nil when
+// there are none. Keys are sorted, so equal constraints give equal JSON.
+func (g SnapshotGrant) ConstraintsJSON() (*string, error) {
+	if len(g.Constraints) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(g.Constraints)
+	if err != nil {
+		return nil, fmt.Errorf("constraints of %s: %w", g.Action, err)
+	}
+	s := string(data)
+	return &s, nil
+}
+
+// SnapshotGrantOf converts a stored grant. Constraints JSON that does not
+// decode to an object is dropped.
+func SnapshotGrantOf(g Grant) SnapshotGrant {
+	sg := SnapshotGrant{Action: g.Action}
+	if g.ConstraintsJSON != nil {
+		var constraints map[string]interface{}
+		if json.Unmarshal([]byte(*g.ConstraintsJSON), &constraints) == nil && len(constraints) > 0 {
+			sg.Constraints = constraints
+		}
+	}
+	return sg
+}
+
+// SnapshotNewAccount is an account an import creates, with the hash of the
+// API key (or token) issued to it.
+type SnapshotNewAccount struct {
+	Username     string
+	DisplayName  string
+	AccountType  string
+	IsActive     bool
+	APIKeyHash   string
+	APIKeyPrefix string
+	Grants       []GrantSpec // UserID is ignored
+}
+
+// SnapshotAccountUpdate changes the profile of an existing account.
+type SnapshotAccountUpdate struct {
+	UserID      int64
+	DisplayName string
+	IsActive    bool
+}
+
+// SnapshotChanges are the writes of one import.
+type SnapshotChanges struct {
+	Create       []SnapshotNewAccount
+	Update       []SnapshotAccountUpdate
+	RevokeGrants []Grant
+	CreateGrants []GrantSpec
+}
+
+// ApplySnapshot writes the changes of an import in one transaction, logging
+// every grant change. Created accounts get an empty password hash, so they
+// authenticate with their API key until a password is set. Returns the IDs
+// of the created accounts by username.
+func (s *Store) ApplySnapshot(changes SnapshotChanges, changedBy int64) (map[string]int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin snapshot import: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	created := make(map[string]int64, len(changes.Create))
+	for _, a := range changes.Create {
+		result, err := tx.Exec(`
+			INSERT INTO auth_users (username, display_name, password_hash, api_key_hash, api_key_prefix,
+			                        is_active, is_bootstrap, created_at, updated_at, created_by, account_type)
+			VALUES (?, ?, '', ?, ?, ?, 0, ?, ?, ?, ?)
+		`, a.Username, a.DisplayName, a.APIKeyHash, a.APIKeyPrefix, a.IsActive, now, now, changedBy, a.AccountType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user %s: %w", a.Username, err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user id: %w", err)
+		}
+		created[a.Username] = id
+
+		specs := make([]GrantSpec, len(a.Grants))
+		for i, g := range a.Grants {
+			specs[i] = GrantSpec{UserID: id, Action: g.Action, ConstraintsJSON: g.ConstraintsJSON}
+		}
+		if _, err := insertGrants(tx, specs, changedBy, now); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, u := range changes.Update {
+		if _, err := tx.Exec(`UPDATE auth_users SET display_name = ?, is_active = ?, updated_at = ? WHERE id = ?`,
+			u.DisplayName, u.IsActive, now, u.UserID); err != nil {
+			return nil, fmt.Errorf("failed to update user %d: %w", u.UserID, err)
+		}
+		if !u.IsActive {
+			if _, err := tx.Exec(`DELETE FROM auth_sessions WHERE user_id = ?`, u.UserID); err != nil {
+				return nil, fmt.Errorf("failed to end sessions of user %d: %w", u.UserID, err)
+			}
+		}
+	}
+
+	for _, g := range changes.RevokeGrants {
+		if _, err := tx.Exec(`UPDATE auth_grants SET is_active = 0 WHERE id = ?`, g.ID); err != nil {
+			return nil, fmt.Errorf("failed to revoke grant %d: %w", g.ID, err)
+		}
+		writeGrantLog(tx, g.ID, g.UserID, g.Action, constants.AuthGrantChangeRevoked, g.ConstraintsJSON, nil, changedBy)
+	}
+
+	if _, err := insertGrants(tx, changes.CreateGrants, changedBy, now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit snapshot import: %w", err)
+	}
+	return created, nil
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestSnapshotGrant_ConstraintsJSONIsCanonical(t *testing.T) {
+	stored := `{ "daily_count_limit": 5, "allowed_topics": ["b", "a"] }`
+	g := SnapshotGrantOf(Grant{Action: constants.AuthActionDownload, ConstraintsJSON: &stored})
+
+	got, err := g.ConstraintsJSON()
+	if err != nil {
+		t.Fatalf("ConstraintsJSON: %v", err)
+	}
+	if got == nil || *got != `{"allowed_topics":["b","a"],"daily_count_limit":5}` {
+		t.Errorf("unexpected constraints: %v", got)
+	}
+
+	if got, _ := (SnapshotGrant{Action: constants.AuthActionQuery}).ConstraintsJSON(); got != nil {
+		t.Errorf("expected nil constraints, got %q", *got)
+	}
+}
+
+func TestApplySnapshot_CreatesUpdatesAndRevokes(t *testing.T) {
+	store := setupTestStore(t)
+	admin, err := store.CreateUser("admin", "Admin", "hash", nil)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	bob, err := store.CreateUser("bob", "Bob", "hash", nil)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	grant, err := store.CreateGrant(bob.ID, constants.AuthActionQuery, nil, admin.ID)
+	if err != nil {
+		t.Fatalf("CreateGrant: %v", err)
+	}
+
+	created, err := store.ApplySnapshot(SnapshotChanges{
+		Create: []SnapshotNewAccount{{
+			Username:     "alice",
+			AccountType:  constants.AuthAccountTypeUser,
+			IsActive:     true,
+			APIKeyHash:   "keyhash",
+			APIKeyPrefix: "prefix",
+			Grants:       []GrantSpec{{Action: constants.AuthActionDownload}},
+		}},
+		Update:       []SnapshotAccountUpdate{{UserID: bob.ID, DisplayName: "Robert", IsActive: false}},
+		RevokeGrants: []Grant{*grant},
+		CreateGrants: []GrantSpec{{UserID: bob.ID, Action: constants.AuthActionUpload}},
+	}, admin.ID)
+	if err != nil {
+		t.Fatalf("ApplySnapshot: %v", err)
+	}
+
+	alice, err := store.GetUserByAPIKeyHash("keyhash")
+	if err != nil || alice.ID != created["alice"] || !alice.IsActive {
+		t.Fatalf("expected alice to authenticate by API key, got %+v, %v", alice, err)
+	}
+	if grants, _ := store.GetActiveGrantsForUser(alice.ID); len(grants) != 1 || grants[0].Action != constants.AuthActionDownload {
+		t.Errorf("unexpected grants of alice: %+v", grants)
+	}
+
+	updated, _ := store.GetUserByID(bob.ID)
+	if updated.DisplayName != "Robert" || updated.IsActive {
+		t.Errorf("expected bob renamed and disabled, got %+v", updated.User)
+	}
+	if grants, _ := store.GetActiveGrantsForUser(bob.ID); len(grants) != 1 || grants[0].Action != constants.AuthActionUpload {
+		t.Errorf("unexpected grants of bob: %+v", grants)
+	}
+	if log, _ := store.GetGrantLog(bob.ID, 10); len(log) != 3 {
+		t.Errorf("expected 3 grant log entries for bob, got %d", len(log))
+	}
+}
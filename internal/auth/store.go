@@ -54,12 +54,62 @@ func (s *Store) CreateUser(username, displayName, passwordHash string, createdBy
 		DisplayName: displayName,
 		IsActive:    true,
 		IsBootstrap: false,
+		AccountType: constants.AuthAccountTypeUser,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 		CreatedBy:   createdBy,
 	}, nil
 }
 
+// CreateServiceAccount inserts a service account with its API key and scope
+// grants in one transaction. The password hash is left empty so no password
+// ever verifies against it. The UserID of each scope is ignored.
+func (s *Store) CreateServiceAccount(name, displayName, apiKeyHash, apiKeyPrefix string, scopes []GrantSpec, createdBy int64) (*User, []Grant, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin service account creation: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	result, err := tx.Exec(`
+		INSERT INTO auth_users (username, display_name, password_hash, api_key_hash, api_key_prefix,
+		                        is_active, is_bootstrap, created_at, updated_at, created_by, account_type)
+		VALUES (?, ?, '', ?, ?, 1, 0, ?, ?, ?, ?)
+	`, name, displayName, apiKeyHash, apiKeyPrefix, now, now, createdBy, constants.AuthAccountTypeService)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create service account: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get service account id: %w", err)
+	}
+
+	for i := range scopes {
+		scopes[i].UserID = id
+	}
+	grants, err := insertGrants(tx, scopes, createdBy, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit service account: %w", err)
+	}
+
+	return &User{
+		ID:          id,
+		Username:    name,
+		DisplayName: displayName,
+		IsActive:    true,
+		AccountType: constants.AuthAccountTypeService,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CreatedBy:   &createdBy,
+	}, grants, nil
+}
+
 // CreateBootstrapUser inserts the initial admin user with is_bootstrap=1.
 func (s *Store) CreateBootstrapUser(username, displayName, passwordHash, apiKeyHash, apiKeyPrefix string) (*User, error) {
 	now := time.Now().Unix()
@@ -83,6 +133,7 @@ func (s *Store) CreateBootstrapUser(username, displayName, passwordHash, apiKeyH
 		DisplayName: displayName,
 		IsActive:    true,
 		IsBootstrap: true,
+		AccountType: constants.AuthAccountTypeUser,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}, nil
@@ -93,7 +144,7 @@ func (s *Store) GetUserByID(id int64) (*UserWithSensitive, error) {
 	return s.scanUser(s.db.QueryRow(`
 		SELECT id, username, display_name, password_hash, api_key_hash, api_key_prefix,
 		       is_active, is_bootstrap, created_at, updated_at, created_by,
-		       failed_login_count, locked_until
+		       failed_login_count, locked_until, account_type, api_key_expires_at
 		FROM auth_users WHERE id = ?
 	`, id))
 }
@@ -103,7 +154,7 @@ func (s *Store) GetUserByUsername(username string) (*UserWithSensitive, error) {
 	return s.scanUser(s.db.QueryRow(`
 		SELECT id, username, display_name, password_hash, api_key_hash, api_key_prefix,
 		       is_active, is_bootstrap, created_at, updated_at, created_by,
-		       failed_login_count, locked_until
+		       failed_login_count, locked_until, account_type, api_key_expires_at
 		FROM auth_users WHERE username = ?
 	`, username))
 }
@@ -113,17 +164,27 @@ func (s *Store) GetUserByAPIKeyHash(keyHash string) (*UserWithSensitive, error)
 	return s.scanUser(s.db.QueryRow(`
 		SELECT id, username, display_name, password_hash, api_key_hash, api_key_prefix,
 		       is_active, is_bootstrap, created_at, updated_at, created_by,
-		       failed_login_count, locked_until
+		       failed_login_count, locked_until, account_type, api_key_expires_at
 		FROM auth_users WHERE api_key_hash = ?
 	`, keyHash))
 }
 
-// ListUsers returns all users (without sensitive fields).
+// ListUsers returns all human users (without sensitive fields).
 func (s *Store) ListUsers() ([]User, error) {
+	return s.listAccounts(constants.AuthAccountTypeUser)
+}
+
+// ListServiceAccounts returns all service accounts (without sensitive fields).
+func (s *Store) ListServiceAccounts() ([]User, error) {
+	return s.listAccounts(constants.AuthAccountTypeService)
+}
+
+// listAccounts returns the accounts of one account type.
+func (s *Store) listAccounts(accountType string) ([]User, error) {
 	rows, err := s.db.Query(`
-		SELECT id, username, display_name, is_active, is_bootstrap, created_at, updated_at, created_by
-		FROM auth_users ORDER BY id ASC
-	`)
+		SELECT id, username, display_name, is_active, is_bootstrap, account_type, created_at, updated_at, created_by
+		FROM auth_users WHERE account_type = ? ORDER BY id ASC
+	`, accountType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -133,7 +194,7 @@ func (s *Store) ListUsers() ([]User, error) {
 	for rows.Next() {
 		var u User
 		if err := rows.Scan(&u.ID, &u.Username, &u.DisplayName, &u.IsActive,
-			&u.IsBootstrap, &u.CreatedAt, &u.UpdatedAt, &u.CreatedBy); err != nil {
+			&u.IsBootstrap, &u.AccountType, &u.CreatedAt, &u.UpdatedAt, &u.CreatedBy); err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
 		users = append(users, u)
@@ -160,13 +221,19 @@ func (s *Store) UpdateUserPassword(id int64, passwordHash string) error {
 	return err
 }
 
-// UpdateUserAPIKey updates a user's API key hash and prefix.
+// UpdateUserAPIKey replaces a user's API key with one that never expires.
 func (s *Store) UpdateUserAPIKey(id int64, apiKeyHash, apiKeyPrefix string) error {
+	return s.SetUserAPIKey(id, apiKeyHash, apiKeyPrefix, nil)
+}
+
+// SetUserAPIKey replaces a user's API key, expiring at expiresAt (nil never
+// expires).
+func (s *Store) SetUserAPIKey(id int64, apiKeyHash, apiKeyPrefix string, expiresAt *int64) error {
 	now := time.Now().Unix()
 	_, err := s.db.Exec(`
-		UPDATE auth_users SET api_key_hash = ?, api_key_prefix = ?, updated_at = ?
+		UPDATE auth_users SET api_key_hash = ?, api_key_prefix = ?, api_key_expires_at = ?, updated_at = ?
 		WHERE id = ?
-	`, apiKeyHash, apiKeyPrefix, now, id)
+	`, apiKeyHash, apiKeyPrefix, expiresAt, now, id)
 	return err
 }
 
@@ -198,6 +265,16 @@ func (s *Store) ResetFailedLogin(id int64) error {
 	return err
 }
 
+// GetBootstrapUser retrieves the bootstrap admin (the first is_bootstrap user).
+func (s *Store) GetBootstrapUser() (*UserWithSensitive, error) {
+	return s.scanUser(s.db.QueryRow(`
+		SELECT id, username, display_name, password_hash, api_key_hash, api_key_prefix,
+		       is_active, is_bootstrap, created_at, updated_at, created_by,
+		       failed_login_count, locked_until, account_type, api_key_expires_at
+		FROM auth_users WHERE is_bootstrap = 1 ORDER BY id LIMIT 1
+	`))
+}
+
 // CountUsers returns the total number of users.
 func (s *Store) CountUsers() (int64, error) {
 	var count int64
@@ -210,13 +287,13 @@ func (s *Store) scanUser(row *sql.Row) (*UserWithSensitive, error) {
 	var u UserWithSensitive
 	var apiKeyHash, apiKeyPrefix sql.NullString
 	var createdBy sql.NullInt64
-	var lockedUntil sql.NullInt64
+	var lockedUntil, apiKeyExpiresAt sql.NullInt64
 
 	err := row.Scan(
 		&u.ID, &u.Username, &u.DisplayName, &u.PasswordHash,
 		&apiKeyHash, &apiKeyPrefix,
 		&u.IsActive, &u.IsBootstrap, &u.CreatedAt, &u.UpdatedAt, &createdBy,
-		&u.FailedLoginCount, &lockedUntil,
+		&u.FailedLoginCount, &lockedUntil, &u.AccountType, &apiKeyExpiresAt,
 	)
 	if err != nil {
 		return nil, err
@@ -234,6 +311,9 @@ func (s *Store) scanUser(row *sql.Row) (*UserWithSensitive, error) {
 	if lockedUntil.Valid {
 		u.LockedUntil = &lockedUntil.Int64
 	}
+	if apiKeyExpiresAt.Valid {
+		u.APIKeyExpiresAt = &apiKeyExpiresAt.Int64
+	}
 
 	return &u, nil
 }
@@ -274,6 +354,57 @@ func (s *Store) CreateGrant(userID int64, action string, constraintsJSON *string
 	return grant, nil
 }
 
+// CreateGrants inserts several grants in one transaction: either all are
+// created or none are.
+func (s *Store) CreateGrants(specs []GrantSpec, createdBy int64) ([]Grant, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin grant batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	grants, err := insertGrants(tx, specs, createdBy, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit grant batch: %w", err)
+	}
+	return grants, nil
+}
+
+// insertGrants inserts grants and their changelog entries within tx.
+func insertGrants(tx *sql.Tx, specs []GrantSpec, createdBy, now int64) ([]Grant, error) {
+	grants := make([]Grant, 0, len(specs))
+	for _, spec := range specs {
+		result, err := tx.Exec(`
+			INSERT INTO auth_grants (user_id, action, constraints_json, is_active, created_at, created_by)
+			VALUES (?, ?, ?, 1, ?, ?)
+		`, spec.UserID, spec.Action, spec.ConstraintsJSON, now, createdBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create grant: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get grant id: %w", err)
+		}
+
+		writeGrantLog(tx, id, spec.UserID, spec.Action, constants.AuthGrantChangeCreated, nil, spec.ConstraintsJSON, createdBy)
+
+		grants = append(grants, Grant{
+			ID:              id,
+			UserID:          spec.UserID,
+			Action:          spec.Action,
+			ConstraintsJSON: spec.ConstraintsJSON,
+			IsActive:        true,
+			CreatedAt:       now,
+			CreatedBy:       createdBy,
+		})
+	}
+	return grants, nil
+}
+
 // GetGrantByID retrieves a grant by ID.
 func (s *Store) GetGrantByID(id int64) (*Grant, error) {
 	var g Grant
@@ -375,9 +506,20 @@ func (s *Store) RevokeGrant(grantID int64, changedBy int64) error {
 // logGrantChange inserts an entry into the append-only grant changelog.
 func (s *Store) logGrantChange(grantID int64, userID int64, action, changeType string,
 	oldConstraints, newConstraints *string, changedBy int64) {
+	writeGrantLog(s.db, grantID, userID, action, changeType, oldConstraints, newConstraints, changedBy)
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// writeGrantLog inserts a changelog entry through db or an open transaction.
+func writeGrantLog(ex execer, grantID int64, userID int64, action, changeType string,
+	oldConstraints, newConstraints *string, changedBy int64) {
 
 	now := time.Now().Unix()
-	s.db.Exec(`
+	ex.Exec(`
 		INSERT INTO auth_grant_log (grant_id, user_id, action, change_type,
 		                            old_constraints_json, new_constraints_json, changed_by, timestamp)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
@@ -531,7 +673,7 @@ func (s *Store) GetSessionByTokenHash(tokenHash string) (*Session, *User, error)
 	err := s.db.QueryRow(`
 		SELECT s.token_hash, s.token_prefix, s.user_id, s.ip_address, s.user_agent,
 		       s.created_at, s.expires_at, s.last_active_at,
-		       u.id, u.username, u.display_name, u.is_active, u.is_bootstrap, u.created_at, u.updated_at
+		       u.id, u.username, u.display_name, u.is_active, u.is_bootstrap, u.account_type, u.created_at, u.updated_at
 		FROM auth_sessions s
 		JOIN auth_users u ON s.user_id = u.id
 		WHERE s.token_hash = ? AND s.expires_at > ? AND u.is_active = 1
@@ -540,7 +682,7 @@ func (s *Store) GetSessionByTokenHash(tokenHash string) (*Session, *User, error)
 		&session.IPAddress, &session.UserAgent,
 		&session.CreatedAt, &session.ExpiresAt, &session.LastActiveAt,
 		&user.ID, &user.Username, &user.DisplayName, &user.IsActive, &user.IsBootstrap,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.AccountType, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil, nil
@@ -589,3 +731,156 @@ func (s *Store) CleanupExpiredSessions() (int64, error) {
 	}
 	return result.RowsAffected()
 }
+
+// ============================================================================
+// Recovery Token Operations
+// ============================================================================
+
+// CreateRecoveryToken stores a hashed break-glass recovery token.
+// Any earlier unused tokens are discarded so only one is outstanding.
+// Returns the token's expiry as a unix timestamp.
+func (s *Store) CreateRecoveryToken(tokenHash, tokenPrefix string, ttl time.Duration) (int64, error) {
+	now := time.Now().Unix()
+	expiresAt := now + int64(ttl.Seconds())
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM auth_recovery_tokens WHERE used_at IS NULL`); err != nil {
+		return 0, fmt.Errorf("failed to discard outstanding recovery tokens: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO auth_recovery_tokens (token_hash, token_prefix, created_at, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, tokenHash, tokenPrefix, now, expiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create recovery token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit recovery token: %w", err)
+	}
+	return expiresAt, nil
+}
+
+// RecoveryReset holds the new credentials a recovery gives the bootstrap
+// admin.
+type RecoveryReset struct {
+	PasswordHash string
+	APIKeyHash   string
+	APIKeyPrefix string
+}
+
+// RecoverBootstrapUser consumes a recovery token and resets the bootstrap
+// admin in one transaction: new password and API key, labelled API keys
+// revoked, re-enabled, unlocked, sessions ended and a full grant restored
+// for every action it lacks. Nothing is written, the token included, if
+// any step fails, so the operator can retry with the same token. Returns
+// ErrInvalidRecoveryToken if the token cannot be used, and the user as it
+// was before the reset with the number of labelled keys revoked.
+func (s *Store) RecoverBootstrapUser(tokenHash, ipAddress string, reset RecoveryReset) (*UserWithSensitive, int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin recovery: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	result, err := tx.Exec(`
+		UPDATE auth_recovery_tokens SET used_at = ?, used_ip = ?
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?
+	`, now, ipAddress, tokenHash, now)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to consume recovery token: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, 0, fmt.Errorf("failed to consume recovery token: %w", err)
+	} else if n != 1 {
+		return nil, 0, ErrInvalidRecoveryToken
+	}
+
+	user, err := s.scanUser(tx.QueryRow(`
+		SELECT id, username, display_name, password_hash, api_key_hash, api_key_prefix,
+		       is_active, is_bootstrap, created_at, updated_at, created_by,
+		       failed_login_count, locked_until, account_type, api_key_expires_at
+		FROM auth_users WHERE is_bootstrap = 1 ORDER BY id LIMIT 1
+	`))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find bootstrap user: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE auth_users SET password_hash = ?, api_key_hash = ?, api_key_prefix = ?, api_key_expires_at = NULL,
+		                      is_active = 1, failed_login_count = 0, locked_until = NULL, updated_at = ?
+		WHERE id = ?
+	`, reset.PasswordHash, reset.APIKeyHash, reset.APIKeyPrefix, now, user.ID); err != nil {
+		return nil, 0, fmt.Errorf("failed to reset bootstrap user: %w", err)
+	}
+
+	// Labelled keys minted by whoever held the account would keep working
+	result, err = tx.Exec(`
+		UPDATE auth_api_keys SET revoked_at = ?, revoked_by = ?
+		WHERE user_id = ? AND revoked_at IS NULL
+	`, now, user.ID, user.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to revoke labelled API keys: %w", err)
+	}
+	revokedKeys, err := result.RowsAffected()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to revoke labelled API keys: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM auth_sessions WHERE user_id = ?`, user.ID); err != nil {
+		return nil, 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	missing, err := missingFullGrants(tx, user.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := insertGrants(tx, missing, user.ID, now); err != nil {
+		return nil, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit recovery: %w", err)
+	}
+	return user, revokedKeys, nil
+}
+
+// missingFullGrants returns a grant spec for every action the user holds no
+// active, unconstrained grant for.
+func missingFullGrants(tx *sql.Tx, userID int64) ([]GrantSpec, error) {
+	rows, err := tx.Query(`
+		SELECT action FROM auth_grants
+		WHERE user_id = ? AND is_active = 1 AND (constraints_json IS NULL OR constraints_json = '')
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load grants: %w", err)
+	}
+	defer rows.Close()
+
+	unrestricted := make(map[string]bool)
+	for rows.Next() {
+		var action string
+		if err := rows.Scan(&action); err != nil {
+			return nil, fmt.Errorf("failed to scan grant: %w", err)
+		}
+		unrestricted[action] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load grants: %w", err)
+	}
+
+	var specs []GrantSpec
+	for _, action := range constants.AllAuthActions {
+		if !unrestricted[action] {
+			specs = append(specs, GrantSpec{UserID: userID, Action: action})
+		}
+	}
+	return specs, nil
+}
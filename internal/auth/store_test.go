@@ -236,6 +236,46 @@ func TestListUsersEmpty(t *testing.T) {
 	}
 }
 
+func TestCreateServiceAccount(t *testing.T) {
+	store := setupTestStore(t)
+
+	admin, _ := store.CreateUser("admin", "Admin", "hash", nil)
+	constraints := `{"allowed_key_prefixes":["thumb."]}`
+
+	account, grants, err := store.CreateServiceAccount("thumbnailer", "Thumbnailer", "keyhash", "mbk_abcd",
+		[]GrantSpec{
+			{Action: constants.AuthActionDownload},
+			{Action: constants.AuthActionMetadata, ConstraintsJSON: &constraints},
+		}, admin.ID)
+	if err != nil {
+		t.Fatalf("CreateServiceAccount failed: %v", err)
+	}
+	if !account.IsServiceAccount() {
+		t.Errorf("expected account_type %q, got %q", constants.AuthAccountTypeService, account.AccountType)
+	}
+	if len(grants) != 2 || grants[0].UserID != account.ID || grants[1].UserID != account.ID {
+		t.Fatalf("unexpected grants: %+v", grants)
+	}
+
+	stored, err := store.GetUserByAPIKeyHash("keyhash")
+	if err != nil {
+		t.Fatalf("GetUserByAPIKeyHash failed: %v", err)
+	}
+	if !stored.IsServiceAccount() || stored.PasswordHash != "" {
+		t.Errorf("expected passwordless service account, got type=%q hash=%q", stored.AccountType, stored.PasswordHash)
+	}
+
+	// Users and service accounts are listed separately
+	users, _ := store.ListUsers()
+	if len(users) != 1 || users[0].Username != "admin" {
+		t.Errorf("ListUsers should only return humans, got %+v", users)
+	}
+	accounts, _ := store.ListServiceAccounts()
+	if len(accounts) != 1 || accounts[0].Username != "thumbnailer" {
+		t.Errorf("ListServiceAccounts returned %+v", accounts)
+	}
+}
+
 func TestUpdateUser(t *testing.T) {
 	store := setupTestStore(t)
 
@@ -452,6 +492,61 @@ func TestCreateGrantLogsToChangelog(t *testing.T) {
 	}
 }
 
+func TestCreateGrants(t *testing.T) {
+	store := setupTestStore(t)
+
+	alice, _ := store.CreateUser("alice", "Alice", "hash", nil)
+	bob, _ := store.CreateUser("bob", "Bob", "hash", nil)
+	constraints := `{"allowed_extensions":["png"]}`
+
+	grants, err := store.CreateGrants([]GrantSpec{
+		{UserID: alice.ID, Action: constants.AuthActionUpload, ConstraintsJSON: &constraints},
+		{UserID: bob.ID, Action: constants.AuthActionDownload},
+	}, alice.ID)
+	if err != nil {
+		t.Fatalf("CreateGrants failed: %v", err)
+	}
+	if len(grants) != 2 || grants[0].ID == 0 || grants[0].ID == grants[1].ID {
+		t.Fatalf("unexpected grants: %+v", grants)
+	}
+
+	active, _ := store.GetActiveGrantsForUser(bob.ID)
+	if len(active) != 1 || active[0].Action != constants.AuthActionDownload {
+		t.Errorf("expected bob's download grant, got %+v", active)
+	}
+	log, _ := store.GetGrantLog(alice.ID, 10)
+	if len(log) != 1 || log[0].ChangeType != constants.AuthGrantChangeCreated {
+		t.Errorf("expected a changelog entry for alice, got %+v", log)
+	}
+}
+
+func TestCreateGrantsRollsBackOnFailure(t *testing.T) {
+	store := setupTestStore(t)
+
+	user, _ := store.CreateUser("atomic", "Atomic", "hash", nil)
+	if _, err := store.db.Exec(`CREATE TRIGGER fail_grant BEFORE INSERT ON auth_grants
+		WHEN NEW.action = 'boom' BEGIN SELECT RAISE(ABORT, 'boom'); END`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+
+	_, err := store.CreateGrants([]GrantSpec{
+		{UserID: user.ID, Action: constants.AuthActionUpload},
+		{UserID: user.ID, Action: "boom"},
+	}, user.ID)
+	if err == nil {
+		t.Fatal("expected CreateGrants to fail")
+	}
+
+	all, _ := store.GetAllGrantsForUser(user.ID)
+	if len(all) != 0 {
+		t.Errorf("expected no grants after failed batch, got %+v", all)
+	}
+	log, _ := store.GetGrantLog(user.ID, 10)
+	if len(log) != 0 {
+		t.Errorf("expected no changelog entries after failed batch, got %+v", log)
+	}
+}
+
 func TestGetGrantByID(t *testing.T) {
 	store := setupTestStore(t)
 
@@ -0,0 +1,46 @@
+package auth
+
+// UserStore persists user, service and pipeline accounts.
+type UserStore interface {
+	CreateUser(username, displayName, passwordHash string, createdBy *int64) (*User, error)
+	CreateServiceAccount(name, displayName, apiKeyHash, apiKeyPrefix string, scopes []GrantSpec, createdBy int64) (*User, []Grant, error)
+	CreateBootstrapUser(username, displayName, passwordHash, apiKeyHash, apiKeyPrefix string) (*User, error)
+	GetUserByID(id int64) (*UserWithSensitive, error)
+	GetUserByUsername(username string) (*UserWithSensitive, error)
+	GetUserByAPIKeyHash(keyHash string) (*UserWithSensitive, error)
+	GetBootstrapUser() (*UserWithSensitive, error)
+	ListUsers() ([]User, error)
+	ListServiceAccounts() ([]User, error)
+	CountUsers() (int64, error)
+	UpdateUser(id int64, displayName string, isActive bool) error
+	UpdateUserPassword(id int64, passwordHash string) error
+	UpdateUserAPIKey(id int64, apiKeyHash, apiKeyPrefix string) error
+	SetUserAPIKey(id int64, apiKeyHash, apiKeyPrefix string, expiresAt *int64) error
+	IncrementFailedLogin(id int64) error
+	ResetFailedLogin(id int64) error
+}
+
+// GrantStore persists grants, their change log and the daily usage counted
+// against their quotas.
+type GrantStore interface {
+	CreateGrant(userID int64, action string, constraintsJSON *string, createdBy int64) (*Grant, error)
+	CreateGrants(specs []GrantSpec, createdBy int64) ([]Grant, error)
+	GetGrantByID(id int64) (*Grant, error)
+	GetActiveGrantsForUser(userID int64) ([]Grant, error)
+	GetAllGrantsForUser(userID int64) ([]Grant, error)
+	UpdateGrantConstraints(grantID int64, newConstraintsJSON *string, changedBy int64) error
+	RevokeGrant(grantID int64, changedBy int64) error
+	GetGrantLog(userID int64, limit int) ([]GrantLogEntry, error)
+	GetTodayUsage(userID int64, action string) (*QuotaUsage, error)
+	IncrementQuota(userID int64, action string, countDelta int64, bytesDelta int64) error
+	GetAllQuotaUsage(userID int64) ([]QuotaUsage, error)
+}
+
+// AccountStore holds accounts together with their grants. Store, over the
+// SQLite orchestrator database, is the only implementation.
+type AccountStore interface {
+	UserStore
+	GrantStore
+}
+
+var _ AccountStore = (*Store)(nil)
@@ -3,6 +3,8 @@
 // each user has per-action grants with optional JSON constraints and daily quotas.
 package auth
 
+import "silobang/internal/constants"
+
 // User represents an authenticated user in the system.
 // Sensitive fields (password hash, API key hash) are excluded from JSON serialization.
 type User struct {
@@ -11,6 +13,7 @@ type User struct {
 	DisplayName      string `json:"display_name"`
 	IsActive         bool   `json:"is_active"`
 	IsBootstrap      bool   `json:"is_bootstrap"`
+	AccountType      string `json:"account_type"` // "user", "service" or "pipeline"
 	CreatedAt        int64  `json:"created_at"`
 	UpdatedAt        int64  `json:"updated_at"`
 	CreatedBy        *int64 `json:"created_by,omitempty"`
@@ -18,13 +21,24 @@ type User struct {
 	LockedUntil      *int64 `json:"-"`
 }
 
+// IsServiceAccount reports whether the user is a non-interactive service account.
+func (u *User) IsServiceAccount() bool {
+	return u.AccountType == constants.AuthAccountTypeService
+}
+
+// IsPipeline reports whether the user is the account of a CI pipeline.
+func (u *User) IsPipeline() bool {
+	return u.AccountType == constants.AuthAccountTypePipeline
+}
+
 // UserWithSensitive includes password hash and API key fields for internal use.
 // These fields must never be serialized to JSON or returned in API responses.
 type UserWithSensitive struct {
 	User
-	PasswordHash string `json:"-"`
-	APIKeyHash   string `json:"-"`
-	APIKeyPrefix string `json:"api_key_prefix,omitempty"`
+	PasswordHash    string `json:"-"`
+	APIKeyHash      string `json:"-"`
+	APIKeyPrefix    string `json:"api_key_prefix,omitempty"`
+	APIKeyExpiresAt *int64 `json:"api_key_expires_at,omitempty"` // nil never expires
 }
 
 // Grant represents a single permission grant for a user.
@@ -39,6 +53,13 @@ type Grant struct {
 	CreatedBy       int64   `json:"created_by"`
 }
 
+// GrantSpec describes a grant to create in a batch.
+type GrantSpec struct {
+	UserID          int64
+	Action          string
+	ConstraintsJSON *string
+}
+
 // GrantLogEntry represents an immutable record of a permission change.
 // These entries form an append-only audit trail of all grant modifications.
 type GrantLogEntry struct {
@@ -81,6 +102,10 @@ type Identity struct {
 	User   *User   `json:"user"`
 	Method string  `json:"method"` // "session", "api_key"
 	Grants []Grant `json:"grants"`
+
+	// APIKeyExpiresAt is the expiry of the API key an api_key identity
+	// authenticated with; nil never expires
+	APIKeyExpiresAt *int64 `json:"-"`
 }
 
 // ActionContext carries the context for a policy evaluation.
@@ -94,6 +119,7 @@ type ActionContext struct {
 	AssetCount int    // for bulk_download: number of assets
 	VolumeBytes int64 // for download: estimated volume
 	SubAction  string // for manage_users: "create", "edit", "disable"
+	MetadataKeys []string // for metadata writes: keys being set or deleted
 }
 
 // PolicyResult represents the outcome of a policy evaluation.
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"fmt"
+)
+
+// UsageCounters are the request totals of one user over some period.
+// Bytes are request and response bodies as seen by the API, before
+// compression.
+type UsageCounters struct {
+	Requests int64 `json:"requests"`
+	Errors   int64 `json:"errors"`
+	BytesIn  int64 `json:"bytes_in"`
+	BytesOut int64 `json:"bytes_out"`
+}
+
+// Add accumulates other into c.
+func (c *UsageCounters) Add(other UsageCounters) {
+	c.Requests += other.Requests
+	c.Errors += other.Errors
+	c.BytesIn += other.BytesIn
+	c.BytesOut += other.BytesOut
+}
+
+// ErrorRate is the share of requests that failed, 0 when there were none.
+func (c UsageCounters) ErrorRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.Errors) / float64(c.Requests)
+}
+
+// UsageRecord is the usage of one user on one endpoint within one bucket.
+type UsageRecord struct {
+	UserID      int64
+	BucketStart int64 // Unix timestamp, multiple of constants.AuthUsageBucket
+	Endpoint    string
+	UsageCounters
+}
+
+// EndpointUsage is the usage of one endpoint pattern over a window.
+type EndpointUsage struct {
+	Endpoint string `json:"endpoint"`
+	UsageCounters
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// AddUsage adds the records to the aggregated usage table in one
+// transaction, merging records into existing rows of the same bucket.
+func (s *Store) AddUsage(records []UsageRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin usage transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO auth_usage (user_id, bucket_start, endpoint, requests, errors, bytes_in, bytes_out)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, bucket_start, endpoint) DO UPDATE SET
+			requests = requests + excluded.requests,
+			errors = errors + excluded.errors,
+			bytes_in = bytes_in + excluded.bytes_in,
+			bytes_out = bytes_out + excluded.bytes_out
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare usage insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		if _, err := stmt.Exec(r.UserID, r.BucketStart, r.Endpoint, r.Requests, r.Errors, r.BytesIn, r.BytesOut); err != nil {
+			return fmt.Errorf("failed to record usage: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetUsageTotals returns a user's usage in buckets starting at or after since.
+func (s *Store) GetUsageTotals(userID, since int64) (UsageCounters, error) {
+	var c UsageCounters
+	err := s.db.QueryRow(`
+		SELECT COALESCE(SUM(requests), 0), COALESCE(SUM(errors), 0), COALESCE(SUM(bytes_in), 0), COALESCE(SUM(bytes_out), 0)
+		FROM auth_usage WHERE user_id = ? AND bucket_start >= ?
+	`, userID, since).Scan(&c.Requests, &c.Errors, &c.BytesIn, &c.BytesOut)
+	if err != nil {
+		return c, fmt.Errorf("failed to query usage totals: %w", err)
+	}
+	return c, nil
+}
+
+// TopUsageEndpoints returns a user's most requested endpoints in buckets
+// starting at or after since.
+func (s *Store) TopUsageEndpoints(userID, since int64, limit int) ([]EndpointUsage, error) {
+	rows, err := s.db.Query(`
+		SELECT endpoint, SUM(requests) AS n, SUM(errors), SUM(bytes_in), SUM(bytes_out)
+		FROM auth_usage WHERE user_id = ? AND bucket_start >= ?
+		GROUP BY endpoint ORDER BY n DESC, endpoint ASC LIMIT ?
+	`, userID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	endpoints := []EndpointUsage{}
+	for rows.Next() {
+		var e EndpointUsage
+		if err := rows.Scan(&e.Endpoint, &e.Requests, &e.Errors, &e.BytesIn, &e.BytesOut); err != nil {
+			return nil, fmt.Errorf("failed to scan top endpoints: %w", err)
+		}
+		e.ErrorRate = e.UsageCounters.ErrorRate()
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, rows.Err()
+}
+
+// CleanupUsage removes usage buckets starting before the given unix time.
+// Returns the number of rows removed.
+func (s *Store) CleanupUsage(before int64) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM auth_usage WHERE bucket_start < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup usage: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestUsage_AggregatesPerEndpoint(t *testing.T) {
+	store := setupTestStore(t)
+	user, err := store.CreateUser("alice", "Alice", "hash", nil)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	const hour = 3600
+	records := []UsageRecord{
+		{UserID: user.ID, BucketStart: 10 * hour, Endpoint: "GET /api/topics", UsageCounters: UsageCounters{Requests: 3, BytesOut: 300}},
+		{UserID: user.ID, BucketStart: 11 * hour, Endpoint: "GET /api/topics", UsageCounters: UsageCounters{Requests: 2, Errors: 1, BytesOut: 100}},
+		{UserID: user.ID, BucketStart: 11 * hour, Endpoint: "POST /api/topics/:topic/assets", UsageCounters: UsageCounters{Requests: 1, BytesIn: 5000}},
+	}
+	if err := store.AddUsage(records); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+	// A second flush into the same bucket adds to its row
+	if err := store.AddUsage(records[2:]); err != nil {
+		t.Fatalf("AddUsage: %v", err)
+	}
+
+	totals, err := store.GetUsageTotals(user.ID, 0)
+	if err != nil {
+		t.Fatalf("GetUsageTotals: %v", err)
+	}
+	if totals != (UsageCounters{Requests: 7, Errors: 1, BytesIn: 10000, BytesOut: 400}) {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+
+	top, err := store.TopUsageEndpoints(user.ID, 11*hour, 10)
+	if err != nil {
+		t.Fatalf("TopUsageEndpoints: %v", err)
+	}
+	if len(top) != 2 || top[0].Endpoint != "GET /api/topics" || top[0].Requests != 2 || top[0].ErrorRate != 0.5 {
+		t.Fatalf("unexpected top endpoints: %+v", top)
+	}
+	if top[1].Requests != 2 || top[1].BytesIn != 10000 {
+		t.Errorf("unexpected upload endpoint usage: %+v", top[1])
+	}
+
+	removed, err := store.CleanupUsage(11 * hour)
+	if err != nil {
+		t.Fatalf("CleanupUsage: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("expected 1 bucket removed, got %d", removed)
+	}
+}
@@ -37,7 +37,7 @@ func ValidateConstraintsJSON(action string, constraintsJSON *string) error {
 		target = &ViewAuditConstraints{}
 	case constants.AuthActionVerify:
 		target = &VerifyConstraints{}
-	case constants.AuthActionManageConfig:
+	case constants.AuthActionManageConfig, constants.AuthActionDebug:
 		return fmt.Errorf("action %q does not support constraints", action)
 	default:
 		return fmt.Errorf("unknown action: %s", action)
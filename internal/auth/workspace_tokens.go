@@ -0,0 +1,420 @@
+package auth
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// ErrPipelineNameTaken is returned when a workspace token is issued to a
+// pipeline whose name already belongs to a user or service account.
+var ErrPipelineNameTaken = errors.New("name belongs to an account that is not a pipeline")
+
+// WorkspaceScope is one action a workspace token allows, with the same
+// constraints a grant of that action takes.
+type WorkspaceScope struct {
+	Action          string  `json:"action"`
+	ConstraintsJSON *string `json:"constraints_json,omitempty"`
+}
+
+// WorkspaceToken is a CI credential issued to a pipeline. The plaintext
+// token is never stored; jobs exchange it for job tokens.
+type WorkspaceToken struct {
+	ID            int64            `json:"id"`
+	AccountID     int64            `json:"account_id"`
+	Pipeline      string           `json:"pipeline"`
+	Description   string           `json:"description"`
+	TokenPrefix   string           `json:"token_prefix"`
+	Scopes        []WorkspaceScope `json:"scopes"`
+	CreatedBy     int64            `json:"created_by"`
+	CreatedAt     int64            `json:"created_at"`
+	ExpiresAt     int64            `json:"expires_at"`
+	LastUsedAt    *int64           `json:"last_used_at,omitempty"`
+	LastUsedIP    string           `json:"last_used_ip,omitempty"`
+	ExchangeCount int64            `json:"exchange_count"`
+	RevokedAt     *int64           `json:"revoked_at,omitempty"`
+	RevokedBy     *int64           `json:"revoked_by,omitempty"`
+	Status        string           `json:"status"` // "active", "expired" or "revoked"
+}
+
+// WorkspaceTokenRevocation selects the workspace tokens to revoke: the
+// listed IDs, every token of a pipeline, or all tokens.
+type WorkspaceTokenRevocation struct {
+	IDs      []int64
+	Pipeline string
+	All      bool
+}
+
+// GenerateWorkspaceToken creates a new workspace token with the mbw_ prefix.
+// Returns the plaintext token (shown once to the admin).
+func GenerateWorkspaceToken() (string, error) {
+	encoded, err := generateBase62(constants.AuthWorkspaceTokenBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate workspace token: %w", err)
+	}
+	return constants.WorkspaceTokenPrefix + encoded, nil
+}
+
+// GenerateJobToken creates a new job token with the mbj_ prefix.
+// Returns the plaintext token (sent to the CI job).
+func GenerateJobToken() (string, error) {
+	encoded, err := generateBase62(constants.AuthJobTokenBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate job token: %w", err)
+	}
+	return constants.JobTokenPrefix + encoded, nil
+}
+
+// IsWorkspaceToken checks if a token has the workspace token prefix.
+func IsWorkspaceToken(token string) bool {
+	return strings.HasPrefix(token, constants.WorkspaceTokenPrefix)
+}
+
+// IsJobToken checks if a token has the job token prefix.
+func IsJobToken(token string) bool {
+	return strings.HasPrefix(token, constants.JobTokenPrefix)
+}
+
+// Grants returns the scopes of the token as grants of its pipeline account,
+// for the identity of a job token.
+func (t *WorkspaceToken) Grants() []Grant {
+	grants := make([]Grant, 0, len(t.Scopes))
+	for _, scope := range t.Scopes {
+		grants = append(grants, Grant{
+			UserID:          t.AccountID,
+			Action:          scope.Action,
+			ConstraintsJSON: scope.ConstraintsJSON,
+			IsActive:        true,
+			CreatedAt:       t.CreatedAt,
+			CreatedBy:       t.CreatedBy,
+		})
+	}
+	return grants
+}
+
+// ============================================================================
+// Workspace Token Operations
+// ============================================================================
+
+// EnsurePipelineAccount returns the account of a pipeline, creating it on
+// first use. Pipeline accounts have no password, API key or grants.
+// Returns ErrPipelineNameTaken if the name belongs to another account type.
+func (s *Store) EnsurePipelineAccount(name string, createdBy int64) (*User, error) {
+	existing, err := s.GetUserByUsername(name)
+	if err == nil {
+		if !existing.IsPipeline() {
+			return nil, ErrPipelineNameTaken
+		}
+		return &existing.User, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	result, err := s.db.Exec(`
+		INSERT INTO auth_users (username, display_name, password_hash,
+		                        is_active, is_bootstrap, created_at, updated_at, created_by, account_type)
+		VALUES (?, ?, '', 1, 0, ?, ?, ?, ?)
+	`, name, name, now, now, createdBy, constants.AuthAccountTypePipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipeline account: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pipeline account id: %w", err)
+	}
+
+	return &User{
+		ID:          id,
+		Username:    name,
+		DisplayName: name,
+		IsActive:    true,
+		AccountType: constants.AuthAccountTypePipeline,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CreatedBy:   &createdBy,
+	}, nil
+}
+
+// CreateWorkspaceToken stores a hashed workspace token for a pipeline account.
+func (s *Store) CreateWorkspaceToken(account *User, description, tokenHash, tokenPrefix string, scopes []WorkspaceScope, ttl time.Duration, createdBy int64) (*WorkspaceToken, error) {
+	scopesJSON, err := json.Marshal(scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode scopes: %w", err)
+	}
+
+	now := time.Now().Unix()
+	expiresAt := now + int64(ttl.Seconds())
+	result, err := s.db.Exec(`
+		INSERT INTO auth_workspace_tokens (account_id, description, token_hash, token_prefix,
+		                                   scopes_json, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, account.ID, description, tokenHash, tokenPrefix, string(scopesJSON), createdBy, now, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace token id: %w", err)
+	}
+
+	return &WorkspaceToken{
+		ID:          id,
+		AccountID:   account.ID,
+		Pipeline:    account.Username,
+		Description: description,
+		TokenPrefix: tokenPrefix,
+		Scopes:      scopes,
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		ExpiresAt:   expiresAt,
+		Status:      constants.AuthWorkspaceTokenStatusActive,
+	}, nil
+}
+
+// workspaceTokenColumns are the columns scanned by scanWorkspaceToken.
+const workspaceTokenColumns = `
+	t.id, t.account_id, u.username, t.description, t.token_prefix, t.scopes_json,
+	t.created_by, t.created_at, t.expires_at, t.last_used_at, t.last_used_ip,
+	t.exchange_count, t.revoked_at, t.revoked_by`
+
+// GetWorkspaceTokenByHash retrieves a workspace token by its hashed token,
+// whatever its status. Returns nil if there is no such token.
+func (s *Store) GetWorkspaceTokenByHash(tokenHash string) (*WorkspaceToken, error) {
+	t, err := scanWorkspaceToken(s.db.QueryRow(`
+		SELECT `+workspaceTokenColumns+`
+		FROM auth_workspace_tokens t
+		JOIN auth_users u ON t.account_id = u.id
+		WHERE t.token_hash = ?
+	`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// ListWorkspaceTokens returns all workspace tokens, newest first, optionally
+// only those of one pipeline.
+func (s *Store) ListWorkspaceTokens(pipeline string) ([]WorkspaceToken, error) {
+	query := `SELECT ` + workspaceTokenColumns + `
+		FROM auth_workspace_tokens t
+		JOIN auth_users u ON t.account_id = u.id`
+	var args []interface{}
+	if pipeline != "" {
+		query += ` WHERE u.username = ?`
+		args = append(args, pipeline)
+	}
+	query += ` ORDER BY t.id DESC`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspace tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []WorkspaceToken{}
+	for rows.Next() {
+		t, err := scanWorkspaceToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *t)
+	}
+	return tokens, rows.Err()
+}
+
+// RecordWorkspaceTokenExchange stores a job token exchanged for an active
+// workspace token and records the exchange on the workspace token. Returns
+// false if the workspace token was revoked or expired meanwhile.
+func (s *Store) RecordWorkspaceTokenExchange(workspaceTokenID int64, jobTokenHash, jobTokenPrefix, job, ipAddress string, expiresAt int64) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	result, err := tx.Exec(`
+		UPDATE auth_workspace_tokens
+		SET exchange_count = exchange_count + 1, last_used_at = ?, last_used_ip = ?
+		WHERE id = ? AND revoked_at IS NULL AND expires_at > ?
+	`, now, ipAddress, workspaceTokenID, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to record exchange: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n != 1 {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO auth_job_tokens (token_hash, token_prefix, workspace_token_id, job, ip_address, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, jobTokenHash, jobTokenPrefix, workspaceTokenID, job, ipAddress, now, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create job token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit job token: %w", err)
+	}
+	return true, nil
+}
+
+// GetJobToken resolves a hashed job token to its workspace token. Returns
+// nil unless the job token and its workspace token are both unexpired, the
+// workspace token is not revoked and the pipeline account is active.
+func (s *Store) GetJobToken(tokenHash string) (*WorkspaceToken, error) {
+	now := time.Now().Unix()
+	t, err := scanWorkspaceToken(s.db.QueryRow(`
+		SELECT `+workspaceTokenColumns+`
+		FROM auth_job_tokens j
+		JOIN auth_workspace_tokens t ON j.workspace_token_id = t.id
+		JOIN auth_users u ON t.account_id = u.id
+		WHERE j.token_hash = ? AND j.expires_at > ?
+		  AND t.revoked_at IS NULL AND t.expires_at > ? AND u.is_active = 1
+	`, tokenHash, now, now))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// TouchWorkspaceToken records a use of a workspace token through one of its
+// job tokens. Writes are skipped while the last recorded use is younger
+// than constants.AuthWorkspaceTokenTouchInterval. last_used_ip keeps the
+// address of the last exchange.
+func (s *Store) TouchWorkspaceToken(id int64) error {
+	now := time.Now().Unix()
+	_, err := s.db.Exec(`
+		UPDATE auth_workspace_tokens SET last_used_at = ?
+		WHERE id = ? AND (last_used_at IS NULL OR last_used_at <= ?)
+	`, now, id, now-int64(constants.AuthWorkspaceTokenTouchInterval.Seconds()))
+	return err
+}
+
+// RevokeWorkspaceTokens revokes the selected workspace tokens that are not
+// revoked yet and deletes their job tokens, in one transaction. Returns the
+// IDs of the tokens revoked.
+func (s *Store) RevokeWorkspaceTokens(sel WorkspaceTokenRevocation, revokedBy int64) ([]int64, error) {
+	var where string
+	var args []interface{}
+	switch {
+	case sel.All:
+		where = `1 = 1`
+	case sel.Pipeline != "":
+		where = `account_id = (SELECT id FROM auth_users WHERE username = ? AND account_type = ?)`
+		args = append(args, sel.Pipeline, constants.AuthAccountTypePipeline)
+	case len(sel.IDs) > 0:
+		where = `id IN (?` + strings.Repeat(`, ?`, len(sel.IDs)-1) + `)`
+		for _, id := range sel.IDs {
+			args = append(args, id)
+		}
+	default:
+		return nil, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id FROM auth_workspace_tokens WHERE revoked_at IS NULL AND `+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select workspace tokens: %w", err)
+	}
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan workspace token id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	for _, id := range ids {
+		if _, err := tx.Exec(`UPDATE auth_workspace_tokens SET revoked_at = ?, revoked_by = ? WHERE id = ?`, now, revokedBy, id); err != nil {
+			return nil, fmt.Errorf("failed to revoke workspace token: %w", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM auth_job_tokens WHERE workspace_token_id = ?`, id); err != nil {
+			return nil, fmt.Errorf("failed to delete job tokens: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit revocation: %w", err)
+	}
+	return ids, nil
+}
+
+// CleanupExpiredJobTokens removes expired job tokens from the database.
+// Returns the number of job tokens removed.
+func (s *Store) CleanupExpiredJobTokens() (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM auth_job_tokens WHERE expires_at <= ?`, time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup job tokens: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWorkspaceToken scans a row of workspaceTokenColumns and derives the
+// token's status.
+func scanWorkspaceToken(row rowScanner) (*WorkspaceToken, error) {
+	var t WorkspaceToken
+	var scopesJSON string
+	var lastUsedAt, revokedAt, revokedBy sql.NullInt64
+	var lastUsedIP sql.NullString
+
+	err := row.Scan(&t.ID, &t.AccountID, &t.Pipeline, &t.Description, &t.TokenPrefix, &scopesJSON,
+		&t.CreatedBy, &t.CreatedAt, &t.ExpiresAt, &lastUsedAt, &lastUsedIP,
+		&t.ExchangeCount, &revokedAt, &revokedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan workspace token: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(scopesJSON), &t.Scopes); err != nil {
+		return nil, fmt.Errorf("failed to decode scopes of workspace token %d: %w", t.ID, err)
+	}
+	if lastUsedAt.Valid {
+		t.LastUsedAt = &lastUsedAt.Int64
+	}
+	t.LastUsedIP = lastUsedIP.String
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Int64
+	}
+	if revokedBy.Valid {
+		t.RevokedBy = &revokedBy.Int64
+	}
+
+	switch {
+	case t.RevokedAt != nil:
+		t.Status = constants.AuthWorkspaceTokenStatusRevoked
+	case t.ExpiresAt <= time.Now().Unix():
+		t.Status = constants.AuthWorkspaceTokenStatusExpired
+	default:
+		t.Status = constants.AuthWorkspaceTokenStatusActive
+	}
+	return &t, nil
+}
@@ -0,0 +1,191 @@
+// Package collate folds names for locale-aware matching and sorting without
+// ICU. A folded string is a sort key: comparing keys byte-wise orders names
+// the way the locale expects, and a substring of a folded name matches the
+// folded search term regardless of case, accents or kana type.
+//
+// Folding follows the strength levels of the Unicode Collation Algorithm:
+// case folding also ignores width and, for Japanese, hiragana/katakana
+// differences (tertiary differences); diacritic folding also ignores
+// Japanese voiced marks (secondary differences).
+package collate
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"silobang/internal/constants"
+)
+
+// Options selects how names are folded.
+type Options struct {
+	Locale         string // BCP 47 language tag; "" = language-neutral
+	FoldCase       bool   // ignore case and character width
+	FoldDiacritics bool   // ignore accents and other diacritic marks
+}
+
+var localeRegex = regexp.MustCompile(constants.CollationLocaleRegex)
+
+// ValidLocale reports whether locale is empty or a well-formed language tag.
+func ValidLocale(locale string) bool {
+	return locale == "" || localeRegex.MatchString(locale)
+}
+
+// language returns the primary language subtag of the locale.
+func (o Options) language() string {
+	lang, _, _ := strings.Cut(strings.ToLower(o.Locale), "-")
+	return lang
+}
+
+// Spec encodes the options as a string for the silo_fold SQL function.
+func (o Options) Spec() string {
+	spec := o.Locale
+	if o.FoldCase {
+		spec += constants.CollationSpecSeparator + constants.CollationFlagCase
+	}
+	if o.FoldDiacritics {
+		spec += constants.CollationSpecSeparator + constants.CollationFlagDiacritics
+	}
+	return spec
+}
+
+// ParseSpec decodes a string produced by Spec. Unknown flags are ignored.
+func ParseSpec(spec string) Options {
+	parts := strings.Split(spec, constants.CollationSpecSeparator)
+	opts := Options{Locale: parts[0]}
+	for _, flag := range parts[1:] {
+		switch flag {
+		case constants.CollationFlagCase:
+			opts.FoldCase = true
+		case constants.CollationFlagDiacritics:
+			opts.FoldDiacritics = true
+		}
+	}
+	return opts
+}
+
+// Fold returns the folded form of s.
+func (o Options) Fold(s string) string {
+	lang := o.language()
+	japanese := lang == "ja"
+
+	var b strings.Builder
+	b.Grow(len(s))
+	var last rune = -1 // last rune written, for composing voiced kana marks
+
+	write := func(r rune) {
+		b.WriteRune(r)
+		last = r
+	}
+
+	for _, r := range s {
+		if japanese {
+			if o.FoldCase {
+				r = foldWidth(r)
+				r = foldKana(r)
+			}
+			// Compose kana followed by a combining voiced mark, as in
+			// decomposed (NFD) filenames, so both forms compare equal
+			if r == voicedMark || r == semiVoicedMark {
+				if o.FoldDiacritics {
+					continue
+				}
+				if composed, ok := composeVoiced(last, r); ok {
+					trimLastRune(&b, last)
+					write(composed)
+					continue
+				}
+			}
+			if o.FoldDiacritics {
+				if base, ok := unvoiced[r]; ok {
+					r = base
+				}
+			}
+		} else if o.FoldCase {
+			r = foldWidth(r)
+		}
+
+		if o.FoldDiacritics {
+			if unicode.Is(unicode.Mn, r) {
+				continue
+			}
+			if expansion, ok := ligatures[r]; ok {
+				for _, e := range expansion {
+					write(o.foldCase(e, lang))
+				}
+				continue
+			}
+			if base, ok := latinBase[r]; ok {
+				r = base
+			}
+		}
+
+		if o.FoldCase {
+			if r == 'ß' || r == 'ẞ' {
+				write('s')
+				write('s')
+				continue
+			}
+			r = o.foldCase(r, lang)
+		}
+		write(r)
+	}
+	return b.String()
+}
+
+// foldCase lowercases r, honoring the Turkish and Azerbaijani dotted and
+// dotless i.
+func (o Options) foldCase(r rune, lang string) rune {
+	if !o.FoldCase {
+		return r
+	}
+	if lang == "tr" || lang == "az" {
+		return unicode.TurkishCase.ToLower(r)
+	}
+	return unicode.ToLower(r)
+}
+
+// trimLastRune removes the final rune r from b.
+func trimLastRune(b *strings.Builder, r rune) {
+	s := b.String()
+	s = s[:len(s)-len(string(r))]
+	b.Reset()
+	b.WriteString(s)
+}
+
+// foldWidth maps full-width ASCII and the ideographic space to ASCII, and
+// half-width katakana to full-width.
+func foldWidth(r rune) rune {
+	switch {
+	case r >= 0xFF01 && r <= 0xFF5E:
+		return r - 0xFEE0
+	case r == 0x3000:
+		return ' '
+	case r >= 0xFF66 && r <= 0xFF9D:
+		return halfWidthKatakana[r-0xFF66]
+	case r == 0xFF9E:
+		return voicedMark
+	case r == 0xFF9F:
+		return semiVoicedMark
+	}
+	return r
+}
+
+// foldKana maps katakana to the matching hiragana.
+func foldKana(r rune) rune {
+	if r >= 0x30A1 && r <= 0x30F6 {
+		return r - 0x60
+	}
+	return r
+}
+
+// composeVoiced combines a kana with a following combining voiced or
+// semi-voiced mark.
+func composeVoiced(base, mark rune) (rune, bool) {
+	if mark == semiVoicedMark {
+		r, ok := semiVoiced[base]
+		return r, ok
+	}
+	r, ok := voiced[base]
+	return r, ok
+}
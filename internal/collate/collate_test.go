@@ -0,0 +1,78 @@
+package collate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	all := Options{FoldCase: true, FoldDiacritics: true}
+	tests := []struct {
+		name string
+		opts Options
+		in   string
+		want string
+	}{
+		{"no folding", Options{}, "Müller.PNG", "Müller.PNG"},
+		{"case only", Options{FoldCase: true}, "Müller.PNG", "müller.png"},
+		{"diacritics only", Options{FoldDiacritics: true}, "Müller Ærø", "Muller AEro"},
+		{"case and diacritics", all, "Crème Brûlée", "creme brulee"},
+		{"decomposed accents", all, "Mu\u0308ller", "muller"},
+		{"sharp s", Options{Locale: "de", FoldCase: true}, "Straße", "strasse"},
+		{"full-width ascii", all, "ＡＢＣ１２３", "abc123"},
+		{"turkish dotted i", Options{Locale: "tr", FoldCase: true}, "İSTANBUL", "istanbul"},
+		{"turkish dotless i", Options{Locale: "tr", FoldCase: true}, "ISPARTA", "ısparta"},
+		{"katakana to hiragana", Options{Locale: "ja", FoldCase: true}, "カタカナ", "かたかな"},
+		{"half-width katakana", Options{Locale: "ja", FoldCase: true}, "ｶﾀｶﾅ", "かたかな"},
+		{"half-width voiced", Options{Locale: "ja", FoldCase: true}, "ｶﾞｲﾄﾞ", "がいど"},
+		{"decomposed voiced", Options{Locale: "ja"}, "か\u3099", "が"},
+		{"voiced marks ignored", Options{Locale: "ja", FoldCase: true, FoldDiacritics: true}, "ガイド", "かいと"},
+		{"kana untouched outside ja", all, "カタカナ", "カタカナ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Fold(tt.in); got != tt.want {
+				t.Errorf("Fold(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFold_SortsAccentedWithBaseLetter(t *testing.T) {
+	opts := Options{Locale: "de", FoldCase: true, FoldDiacritics: true}
+	// Byte-wise, "Äpfel" sorts after "Zebra"; folded it sorts with "a"
+	if strings.Compare(opts.Fold("Äpfel"), opts.Fold("Birne")) >= 0 {
+		t.Error("expected Äpfel to sort before Birne")
+	}
+	if strings.Compare(opts.Fold("apfel"), opts.Fold("Birne")) >= 0 {
+		t.Error("expected case not to affect order")
+	}
+}
+
+func TestSpecRoundTrip(t *testing.T) {
+	for _, opts := range []Options{
+		{},
+		{Locale: "ja"},
+		{Locale: "de", FoldCase: true},
+		{Locale: "pt-BR", FoldCase: true, FoldDiacritics: true},
+		{FoldDiacritics: true},
+	} {
+		if got := ParseSpec(opts.Spec()); got != opts {
+			t.Errorf("ParseSpec(%q) = %+v, want %+v", opts.Spec(), got, opts)
+		}
+	}
+}
+
+func TestValidLocale(t *testing.T) {
+	for _, locale := range []string{"", "de", "ja", "pt-BR", "zh-Hant-TW"} {
+		if !ValidLocale(locale) {
+			t.Errorf("expected %q to be valid", locale)
+		}
+	}
+	for _, locale := range []string{"d", "german!", "de_DE", "de-"} {
+		if ValidLocale(locale) {
+			t.Errorf("expected %q to be invalid", locale)
+		}
+	}
+}
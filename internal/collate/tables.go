@@ -0,0 +1,74 @@
+package collate
+
+// Combining voiced and semi-voiced sound marks (dakuten, handakuten).
+const (
+	voicedMark     = '\u3099'
+	semiVoicedMark = '\u309A'
+)
+
+// halfWidthKatakana lists the full-width forms of U+FF66 to U+FF9D.
+var halfWidthKatakana = []rune("ヲァィゥェォャュョッーアイウエオカキクケコサシスセソタチツテトナニヌネノハヒフヘホマミムメモヤユヨラリルレロワン")
+
+// Hiragana with their voiced and semi-voiced forms. Katakana are derived.
+const (
+	voicedPairs     = "かがきぎくぐけげこごさざしじすずせぜそぞただちぢつづてでとどはばひびふぶへべほぼうゔ"
+	semiVoicedPairs = "はぱひぴふぷへぺほぽ"
+)
+
+// latinGroups maps each base letter to its precomposed accented forms.
+var latinGroups = map[rune]string{
+	'a': "àáâãäåāăą", 'A': "ÀÁÂÃÄÅĀĂĄ",
+	'c': "çćĉċč", 'C': "ÇĆĈĊČ",
+	'd': "ďđ", 'D': "ĎĐ",
+	'e': "èéêëēĕėęě", 'E': "ÈÉÊËĒĔĖĘĚ",
+	'g': "ĝğġģ", 'G': "ĜĞĠĢ",
+	'h': "ĥħ", 'H': "ĤĦ",
+	'i': "ìíîïĩīĭįı", 'I': "ÌÍÎÏĨĪĬĮİ",
+	'j': "ĵ", 'J': "Ĵ",
+	'k': "ķ", 'K': "Ķ",
+	'l': "ĺļľŀł", 'L': "ĹĻĽĿŁ",
+	'n': "ñńņňŉ", 'N': "ÑŃŅŇ",
+	'o': "òóôõöøōŏő", 'O': "ÒÓÔÕÖØŌŎŐ",
+	'r': "ŕŗř", 'R': "ŔŖŘ",
+	's': "śŝşš", 'S': "ŚŜŞŠ",
+	't': "ţťŧ", 'T': "ŢŤŦ",
+	'u': "ùúûüũūŭůűų", 'U': "ÙÚÛÜŨŪŬŮŰŲ",
+	'w': "ŵ", 'W': "Ŵ",
+	'y': "ýÿŷ", 'Y': "ÝŶŸ",
+	'z': "źżž", 'Z': "ŹŻŽ",
+}
+
+// ligatures expand to their component letters when folding diacritics.
+var ligatures = map[rune]string{
+	'æ': "ae", 'Æ': "AE",
+	'œ': "oe", 'Œ': "OE",
+	'ĳ': "ij", 'Ĳ': "IJ",
+}
+
+var (
+	latinBase  = make(map[rune]rune)
+	voiced     = make(map[rune]rune) // base kana → voiced
+	semiVoiced = make(map[rune]rune) // base kana → semi-voiced
+	unvoiced   = make(map[rune]rune) // voiced or semi-voiced kana → base
+)
+
+func init() {
+	for base, accented := range latinGroups {
+		for _, r := range accented {
+			latinBase[r] = base
+		}
+	}
+
+	addPairs := func(pairs string, target map[rune]rune) {
+		runes := []rune(pairs)
+		for i := 0; i+1 < len(runes); i += 2 {
+			base, marked := runes[i], runes[i+1]
+			for _, offset := range []rune{0, 0x60} { // hiragana, katakana
+				target[base+offset] = marked + offset
+				unvoiced[marked+offset] = base + offset
+			}
+		}
+	}
+	addPairs(voicedPairs, voiced)
+	addPairs(semiVoicedPairs, semiVoiced)
+}
@@ -2,22 +2,57 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+	"silobang/internal/audit"
+	"silobang/internal/collate"
 	"silobang/internal/constants"
 	"silobang/internal/logger"
+	"silobang/internal/sanitize"
+	"silobang/internal/watermark"
+	"silobang/plugin"
 )
 
+var topicNameRegex = regexp.MustCompile(constants.TopicNameRegex)
+
+// placeholderRegex matches {{...}} placeholders in default metadata values.
+var placeholderRegex = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+
+var defaultMetadataPlaceholders = []string{
+	constants.DefaultMetadataPlaceholderUsername,
+	constants.DefaultMetadataPlaceholderTopic,
+	constants.DefaultMetadataPlaceholderUploadTime,
+	constants.DefaultMetadataPlaceholderFilename,
+	constants.DefaultMetadataPlaceholderExtension,
+	constants.DefaultMetadataPlaceholderHash,
+}
+
 // AuthConfig holds user-configurable authentication settings.
 type AuthConfig struct {
 	MaxLoginAttempts        int `yaml:"max_login_attempts"`
 	LockoutDurationMins     int `yaml:"lockout_duration_mins"`
 	SessionDurationHours    int `yaml:"session_duration_hours"`
 	SessionMaxDurationHours int `yaml:"session_max_duration_hours"`
+
+	// API key expiration policy for keys created or rotated through the API
+	// keys endpoints
+	APIKeyTTLDays            int `yaml:"api_key_ttl_days"`             // default lifetime; 0 = never expire
+	APIKeyMaxTTLDays         int `yaml:"api_key_max_ttl_days"`         // longest lifetime a key may be given; 0 = no limit
+	APIKeyRotationGraceHours int `yaml:"api_key_rotation_grace_hours"` // how long a rotated key keeps working by default
+}
+
+// APIKeyRotationGrace returns the default rotation grace period as time.Duration.
+func (c *AuthConfig) APIKeyRotationGrace() time.Duration {
+	return time.Duration(c.APIKeyRotationGraceHours) * time.Hour
 }
 
 // SessionDuration returns the session duration as time.Duration.
@@ -30,6 +65,32 @@ func (c *AuthConfig) SessionMaxDuration() time.Duration {
 	return time.Duration(c.SessionMaxDurationHours) * time.Hour
 }
 
+// OIDCConfig enables single sign-on through an OpenID Connect provider.
+// Users signing in are matched to local accounts by the provider's subject;
+// their grants stay managed locally. Disabled when Issuer is empty.
+type OIDCConfig struct {
+	Issuer            string   `yaml:"issuer"`              // e.g. https://login.example.com/realms/corp
+	ClientID          string   `yaml:"client_id"`           // client registered at the provider
+	ClientSecret      string   `yaml:"client_secret"`       // empty for public clients
+	RedirectURL       string   `yaml:"redirect_url"`        // https://<host>/api/auth/oidc/callback, as registered
+	Scopes            []string `yaml:"scopes"`              // requested scopes; openid is always included
+	UsernameClaim     string   `yaml:"username_claim"`      // claim mapped to the username; falls back to the email's local part
+	DisplayNameClaim  string   `yaml:"display_name_claim"`  // claim mapped to the display name of provisioned users
+	AllowedDomains    []string `yaml:"allowed_domains"`     // email domains allowed to sign in; empty = any
+	AutoProvision     *bool    `yaml:"auto_provision"`      // create users, without grants, on first sign-in; default true
+	LinkExistingUsers bool     `yaml:"link_existing_users"` // link a first sign-in to the local user of the same username
+}
+
+// Enabled reports whether an OpenID Connect provider is configured.
+func (c *OIDCConfig) Enabled() bool {
+	return c.Issuer != ""
+}
+
+// Provisions reports whether unknown identities get a user on first sign-in.
+func (c *OIDCConfig) Provisions() bool {
+	return c.AutoProvision == nil || *c.AutoProvision
+}
+
 // BulkDownloadConfig holds user-configurable bulk download settings.
 type BulkDownloadConfig struct {
 	SessionTTLMins int `yaml:"session_ttl_mins"`
@@ -38,13 +99,46 @@ type BulkDownloadConfig struct {
 
 // AuditConfig holds user-configurable audit log settings.
 type AuditConfig struct {
-	MaxLogSizeBytes int64 `yaml:"max_log_size_bytes"`
-	PurgePercentage int   `yaml:"purge_percentage"`
+	MaxLogSizeBytes      int64          `yaml:"max_log_size_bytes"`
+	PurgePercentage      int            `yaml:"purge_percentage"`
+	DefaultRetentionDays int            `yaml:"default_retention_days"` // 0 = entries are only purged when the size limit is hit
+	RetentionDays        map[string]int `yaml:"retention_days"`         // per-action overrides, e.g. login_success: 730
+	QueueSize            int            `yaml:"queue_size"`             // entries buffered in memory before the overflow policy applies
+	OverflowPolicy       string         `yaml:"overflow_policy"`        // block or drop_oldest
+}
+
+// Retention returns the audit retention policy described by the config.
+func (c *AuditConfig) Retention() audit.RetentionPolicy {
+	return audit.RetentionPolicy{
+		DefaultDays: c.DefaultRetentionDays,
+		ActionDays:  maps.Clone(c.RetentionDays),
+	}
 }
 
 // MetadataConfig holds user-configurable metadata settings.
 type MetadataConfig struct {
-	MaxValueBytes int `yaml:"max_value_bytes"`
+	MaxValueBytes int                          `yaml:"max_value_bytes"`
+	Defaults      map[string]string            `yaml:"defaults"`       // stamped on every new asset
+	TopicDefaults map[string]map[string]string `yaml:"topic_defaults"` // keyed by topic name; an empty value drops a global default
+	MediaInfo     bool                         `yaml:"media_info"`     // record the sniffed content type and media properties of new uploads
+}
+
+// DefaultsFor returns the metadata template stamped on new assets in topic:
+// the global defaults overlaid with the topic's own. Values still hold their
+// placeholders.
+func (c *MetadataConfig) DefaultsFor(topic string) map[string]string {
+	defaults := maps.Clone(c.Defaults)
+	if defaults == nil {
+		defaults = make(map[string]string)
+	}
+	for key, value := range c.TopicDefaults[topic] {
+		if value == "" {
+			delete(defaults, key)
+		} else {
+			defaults[key] = value
+		}
+	}
+	return defaults
 }
 
 // BatchConfig holds user-configurable batch operation settings.
@@ -52,23 +146,644 @@ type BatchConfig struct {
 	MaxOperations int `yaml:"max_operations"`
 }
 
+// QueryConfig holds limits on query preset results.
+type QueryConfig struct {
+	MaxRows          int `yaml:"max_rows"`           // rows returned by a preset query; the rest are truncated
+	FederatedMaxRows int `yaml:"federated_max_rows"` // rows returned by federated presets and cross-instance queries
+}
+
 // MonitoringConfig holds user-configurable monitoring settings.
 type MonitoringConfig struct {
 	LogFileMaxReadBytes int64 `yaml:"log_file_max_read_bytes"`
 }
 
+// PublicConfig holds settings for anonymous read-only access, intended for
+// public demo instances. When enabled, requests without credentials may list
+// topics, run the listed query presets and download assets up to a size cap;
+// every other endpoint still requires authentication.
+type PublicConfig struct {
+	Enabled           bool     `yaml:"enabled"`
+	AllowedPresets    []string `yaml:"allowed_presets"`     // query presets anonymous users may run; empty = none
+	MaxDownloadBytes  int64    `yaml:"max_download_bytes"`  // largest asset an anonymous user may download
+	RateLimitPerMin   int      `yaml:"rate_limit_per_min"`  // anonymous requests per client IP per minute
+	RateLimitBurst    int      `yaml:"rate_limit_burst"`    // requests an idle client IP may make at once
+	TrustForwardedFor bool     `yaml:"trust_forwarded_for"` // key rate limits on X-Forwarded-For (only behind a trusted proxy)
+	Watermark         string   `yaml:"watermark"`           // watermark profile forced on anonymous image downloads; empty = none
+}
+
+// RateLimitConfig throttles uploads, queries and downloads with a token
+// bucket per user, or per client IP for unauthenticated requests. Grants can
+// override the rate of their holder.
+type RateLimitConfig struct {
+	Enabled           bool          `yaml:"enabled"`
+	TrustForwardedFor bool          `yaml:"trust_forwarded_for"` // key unauthenticated clients on X-Forwarded-For (only behind a trusted proxy)
+	Uploads           RateLimitRule `yaml:"uploads"`
+	Queries           RateLimitRule `yaml:"queries"`
+	Downloads         RateLimitRule `yaml:"downloads"` // single, preview and bulk downloads
+}
+
+// RateLimitRule is the token bucket of one class of requests.
+type RateLimitRule struct {
+	PerMin int `yaml:"per_min"` // requests per minute, refilled continuously
+	Burst  int `yaml:"burst"`   // requests an idle client may make at once
+}
+
+// Rule returns the rule of a constants.RateLimitClass*.
+func (c *RateLimitConfig) Rule(class string) RateLimitRule {
+	switch class {
+	case constants.RateLimitClassUploads:
+		return c.Uploads
+	case constants.RateLimitClassQueries:
+		return c.Queries
+	default:
+		return c.Downloads
+	}
+}
+
+// WatermarkConfig is a named watermark profile applied to image downloads.
+// Exactly one of Text or Image is set.
+type WatermarkConfig struct {
+	Text     string  `yaml:"text"`
+	Image    string  `yaml:"image"`    // path to a PNG or JPEG overlay
+	Position string  `yaml:"position"` // center, top-left, top-right, bottom-left, bottom-right or tile
+	Opacity  float64 `yaml:"opacity"`  // 0 < opacity <= 1
+	Scale    float64 `yaml:"scale"`    // stamp width relative to the image width, 0 < scale <= 1
+}
+
+// StoragePolicyConfig overrides how assets with one extension are stored and
+// served. Unset fields fall back to the "*" policy, then to the defaults.
+type StoragePolicyConfig struct {
+	Compression  *bool `yaml:"compression,omitempty" json:"compression,omitempty"`       // gzip downloads for clients that accept it
+	Preview      *bool `yaml:"preview,omitempty" json:"preview,omitempty"`               // serve downscaled previews of images
+	Scan         *bool `yaml:"scan,omitempty" json:"scan,omitempty"`                     // run scan.command on uploads
+	MaxSizeBytes int64 `yaml:"max_size_bytes,omitempty" json:"max_size_bytes,omitempty"` // 0 = limited by max_dat_size only
+}
+
+// StoragePolicy is the effective handling of one extension.
+type StoragePolicy struct {
+	Extension    string `json:"extension"`
+	Compression  bool   `json:"compression"`
+	Preview      bool   `json:"preview"`
+	Scan         bool   `json:"scan"`
+	MaxSizeBytes int64  `json:"max_size_bytes"` // largest asset accepted on upload
+}
+
+// ScanConfig is the scanner run on uploads whose storage policy enables
+// scanning. The default command type writes the upload to the command's
+// stdin; other types are registered with the plugin package.
+type ScanConfig struct {
+	Type        string            `yaml:"type,omitempty"`    // plugin scanner type; empty = command
+	Command     []string          `yaml:"command"`           // program and arguments, e.g. [clamdscan, --no-summary, -]
+	Options     map[string]string `yaml:"options,omitempty"` // settings of a plugin scanner
+	TimeoutSecs int               `yaml:"timeout_secs"`
+
+	// QuarantineInfected stores flagged uploads quarantined instead of
+	// rejecting them, so they can be inspected and released.
+	QuarantineInfected bool `yaml:"quarantine_infected"`
+}
+
+// Timeout returns the scan timeout as time.Duration.
+func (c *ScanConfig) Timeout() time.Duration {
+	return time.Duration(c.TimeoutSecs) * time.Second
+}
+
+// ScannerType returns the plugin type of the scanner.
+func (c *ScanConfig) ScannerType() string {
+	if c.Type == "" {
+		return constants.PluginScannerCommand
+	}
+	return c.Type
+}
+
+// Enabled reports whether a scanner is configured: a command, or a scanner
+// of another type.
+func (c *ScanConfig) Enabled() bool {
+	return c.ScannerType() != constants.PluginScannerCommand || len(c.Command) > 0
+}
+
+// PluginConfig returns the settings passed to the scanner's plugin factory.
+func (c *ScanConfig) PluginConfig() plugin.ScannerConfig {
+	return plugin.ScannerConfig{Command: c.Command, Options: c.Options}
+}
+
+// ValidationConfig is the content validation run on uploads before they are
+// stored, with one validator per extension. Strict topics reject invalid
+// uploads; lenient topics store them marked invalid in their metadata.
+type ValidationConfig struct {
+	Validators  map[string]PluginConfig `yaml:"validators"`  // keyed by extension
+	Mode        string                  `yaml:"mode"`        // strict (the default) or lenient
+	TopicModes  map[string]string       `yaml:"topic_modes"` // topic name -> strict or lenient
+	TimeoutSecs int                     `yaml:"timeout_secs"`
+}
+
+// Timeout returns the validation timeout as time.Duration.
+func (c *ValidationConfig) Timeout() time.Duration {
+	return time.Duration(c.TimeoutSecs) * time.Second
+}
+
+// ModeFor returns the validation mode of a topic: its own, then mode, then
+// strict.
+func (c *ValidationConfig) ModeFor(topic string) string {
+	if mode := c.TopicModes[topic]; mode != "" {
+		return mode
+	}
+	if c.Mode != "" {
+		return c.Mode
+	}
+	return constants.ValidationModeStrict
+}
+
+// CollationConfig enables locale-aware origin-name matching and sorting for
+// a topic. Topics without one keep byte-wise behavior.
+type CollationConfig struct {
+	Locale         string `yaml:"locale"`          // BCP 47 language tag, e.g. de or ja; empty = language-neutral
+	FoldCase       bool   `yaml:"fold_case"`       // ignore case and character width (and kana type for ja)
+	FoldDiacritics bool   `yaml:"fold_diacritics"` // ignore accents (and voiced marks for ja)
+}
+
+// RetentionConfig is the retention policy of one topic. The oldest assets
+// over any limit are removed; a zero limit is not enforced.
+type RetentionConfig struct {
+	MaxAgeDays    int    `yaml:"max_age_days"`
+	MaxTotalBytes int64  `yaml:"max_total_bytes"`
+	MaxAssets     int    `yaml:"max_assets"`
+	Action        string `yaml:"action"`  // trash (default) or delete
+	DryRun        bool   `yaml:"dry_run"` // only audit what would be removed
+}
+
+// Removal returns how assets over the limits are removed, trash unless
+// the policy deletes them.
+func (c RetentionConfig) Removal() string {
+	if c.Action == constants.RetentionActionDelete {
+		return constants.RetentionActionDelete
+	}
+	return constants.RetentionActionTrash
+}
+
+// MaxAge returns the maximum age of an asset as time.Duration, or 0 when
+// age is not limited.
+func (c RetentionConfig) MaxAge() time.Duration {
+	return time.Duration(c.MaxAgeDays) * 24 * time.Hour
+}
+
+// TopicQuotaConfig bounds the stored bytes and assets of one topic. Uploads
+// past a hard limit are refused; passing a soft limit is only reported. A
+// zero limit is not enforced.
+type TopicQuotaConfig struct {
+	SoftBytes  int64 `yaml:"soft_bytes,omitempty" json:"soft_bytes,omitempty"`
+	HardBytes  int64 `yaml:"hard_bytes,omitempty" json:"hard_bytes,omitempty"`
+	SoftAssets int64 `yaml:"soft_assets,omitempty" json:"soft_assets,omitempty"`
+	HardAssets int64 `yaml:"hard_assets,omitempty" json:"hard_assets,omitempty"`
+}
+
+// IsZero reports whether the quota sets no limit.
+func (c TopicQuotaConfig) IsZero() bool {
+	return c == TopicQuotaConfig{}
+}
+
+// StorageIOConfig tunes how DAT files in one working directory are
+// accessed.
+type StorageIOConfig struct {
+	DirectIO bool `yaml:"direct_io"` // append and read with O_DIRECT, bypassing the page cache
+}
+
+// BlobStoreConfig is a store holding the sealed .dat files of the topics
+// assigned to it in topic_blob_stores: by default an S3-compatible bucket
+// (AWS S3, MinIO, ...) with objects stored path-style under
+// <prefix><topic>/<dat file>, or a store type registered with the plugin
+// package.
+type BlobStoreConfig struct {
+	Type            string            `yaml:"type,omitempty"` // plugin blob store type; empty = s3
+	Endpoint        string            `yaml:"endpoint"`       // e.g. https://s3.eu-west-1.amazonaws.com or http://minio:9000
+	Bucket          string            `yaml:"bucket"`
+	Region          string            `yaml:"region"`
+	Prefix          string            `yaml:"prefix"` // prepended to object keys; empty or ending in /
+	AccessKeyID     string            `yaml:"access_key_id"`
+	SecretAccessKey string            `yaml:"secret_access_key"`
+	Options         map[string]string `yaml:"options,omitempty"` // settings of a plugin blob store
+}
+
+// StoreType returns the plugin type of the store.
+func (c BlobStoreConfig) StoreType() string {
+	if c.Type == "" {
+		return constants.PluginBlobStoreS3
+	}
+	return c.Type
+}
+
+// PluginConfig returns the settings passed to the store's plugin factory
+// for the store name and one topic.
+func (c BlobStoreConfig) PluginConfig(name, topic string) plugin.BlobStoreConfig {
+	return plugin.BlobStoreConfig{
+		Name:            name,
+		Topic:           topic,
+		Endpoint:        c.Endpoint,
+		Bucket:          c.Bucket,
+		Region:          c.Region,
+		Prefix:          c.Prefix,
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		Options:         c.Options,
+	}
+}
+
+// PluginConfig selects a backend registered with the plugin package, such
+// as an entry of archives, auth_providers or notifiers.
+type PluginConfig struct {
+	Type    string            `yaml:"type"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// WatchFolderConfig is a server-side directory whose files are ingested
+// into a topic. Files are picked up once they have not been modified for
+// settle_secs, so copies still in progress are left alone.
+type WatchFolderConfig struct {
+	Path        string `yaml:"path" json:"path"`                                     // absolute path outside the working directory
+	Topic       string `yaml:"topic" json:"topic"`                                   // topic the files are stored in
+	AfterIngest string `yaml:"after_ingest,omitempty" json:"after_ingest,omitempty"` // move (to processed/, the default) or delete
+	SettleSecs  int    `yaml:"settle_secs,omitempty" json:"settle_secs,omitempty"`   // 0 = 10 seconds
+	Paused      bool   `yaml:"paused,omitempty" json:"paused,omitempty"`             // skipped by the periodic scan
+}
+
+// AfterIngestMode returns what happens to ingested files, defaulting to move.
+func (c WatchFolderConfig) AfterIngestMode() string {
+	if c.AfterIngest == "" {
+		return constants.WatchFolderAfterMove
+	}
+	return c.AfterIngest
+}
+
+// Settle returns how long a file must be left unmodified before it is
+// ingested.
+func (c WatchFolderConfig) Settle() time.Duration {
+	if c.SettleSecs == 0 {
+		return constants.WatchFolderDefaultSettleSecs * time.Second
+	}
+	return time.Duration(c.SettleSecs) * time.Second
+}
+
+// NotificationsConfig holds settings for topic notification delivery.
+// Email delivery is disabled unless smtp.host is set.
+type NotificationsConfig struct {
+	DigestIntervalMins int        `yaml:"digest_interval_mins"`
+	WebhookTimeoutSecs int        `yaml:"webhook_timeout_secs"`
+	RetentionDays      int        `yaml:"retention_days"` // feed entries older than this are deleted
+	SMTP               SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig holds the outgoing mail server used for notification emails.
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// DigestInterval returns the digest interval as time.Duration.
+func (c *NotificationsConfig) DigestInterval() time.Duration {
+	return time.Duration(c.DigestIntervalMins) * time.Minute
+}
+
+// WebhookTimeout returns the webhook request timeout as time.Duration.
+func (c *NotificationsConfig) WebhookTimeout() time.Duration {
+	return time.Duration(c.WebhookTimeoutSecs) * time.Second
+}
+
+// EmailEnabled reports whether an SMTP server is configured.
+func (c *NotificationsConfig) EmailEnabled() bool {
+	return c.SMTP.Host != ""
+}
+
+// IdempotencyConfig holds settings for Idempotency-Key handling on mutating
+// endpoints.
+type IdempotencyConfig struct {
+	TTLHours         int   `yaml:"ttl_hours"`          // how long a key replays its response
+	MaxResponseBytes int64 `yaml:"max_response_bytes"` // larger responses are not stored
+}
+
+// TTL returns the key lifetime as time.Duration.
+func (c *IdempotencyConfig) TTL() time.Duration {
+	return time.Duration(c.TTLHours) * time.Hour
+}
+
+// ExportsConfig holds limits on the per-user export inbox, where bulk
+// downloads can be built in the background and fetched later.
+type ExportsConfig struct {
+	RetentionDays int   `yaml:"retention_days"`  // exports are deleted this many days after they were requested
+	MaxInboxBytes int64 `yaml:"max_inbox_bytes"` // total size of one user's exports
+}
+
+// Retention returns the export lifetime as time.Duration.
+func (c *ExportsConfig) Retention() time.Duration {
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// DeletionRequestsConfig holds how long a deletion request waits for a
+// decision.
+type DeletionRequestsConfig struct {
+	ApprovalWindowHours int `yaml:"approval_window_hours"` // pending requests expire after this many hours
+}
+
+// ApprovalWindow returns the approval window as time.Duration.
+func (c *DeletionRequestsConfig) ApprovalWindow() time.Duration {
+	return time.Duration(c.ApprovalWindowHours) * time.Hour
+}
+
+// ArchivePolicy exports the assets of one topic that nobody has downloaded
+// for IdleDays to an entry of archives, leaving stubs in the topic.
+type ArchivePolicy struct {
+	Archive  string `yaml:"archive"`   // name of the archive in archives
+	IdleDays int    `yaml:"idle_days"` // days without a download, counted from upload
+	DryRun   bool   `yaml:"dry_run"`   // only audit what would be archived
+}
+
+// IdleTime returns how long an asset goes undownloaded before it is
+// archived, as time.Duration.
+func (c ArchivePolicy) IdleTime() time.Duration {
+	return time.Duration(c.IdleDays) * 24 * time.Hour
+}
+
+// TrashConfig holds how long deleted assets are kept in the trash before
+// they are purged.
+type TrashConfig struct {
+	RetentionDays int `yaml:"retention_days"` // trashed assets are purged this many days after deletion
+}
+
+// Retention returns how long an asset stays in the trash as time.Duration.
+func (c *TrashConfig) Retention() time.Duration {
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// PreviewsConfig holds when asset previews are generated. Previews are
+// always rendered on first request and cached.
+type PreviewsConfig struct {
+	OnUpload bool `yaml:"on_upload"` // render the default-size preview of new uploads in the background
+}
+
+// AssetCacheConfig sizes the in-memory cache of small, frequently
+// downloaded assets.
+type AssetCacheConfig struct {
+	Disabled      bool  `yaml:"disabled"`
+	MaxBytes      int64 `yaml:"max_bytes"`       // total asset data kept in memory
+	MaxAssetBytes int64 `yaml:"max_asset_bytes"` // larger assets are always read from disk
+}
+
+// ScrubConfig schedules the integrity scrubber, which re-reads every stored
+// asset and checks it against its hash.
+type ScrubConfig struct {
+	Disabled       bool  `yaml:"disabled"`
+	IntervalHours  int   `yaml:"interval_hours"`    // time between scheduled passes
+	MaxBytesPerSec int64 `yaml:"max_bytes_per_sec"` // read rate limit; 0 reads at full speed
+}
+
+// Interval returns the time between scheduled passes as time.Duration.
+func (c *ScrubConfig) Interval() time.Duration {
+	return time.Duration(c.IntervalHours) * time.Hour
+}
+
+// HTTPConfig tunes the listener for many long-lived connections. HTTP/2
+// multiplexes a client's requests and event streams over one connection,
+// but browsers only speak it over TLS; without a certificate it is served
+// in cleartext to clients that know to use it, such as a reverse proxy.
+//
+// With TLS the server terminates HTTPS itself, reloading the certificate
+// when its files change. tls_self_signed generates a certificate in the
+// config directory instead, for installs without a domain or CA.
+type HTTPConfig struct {
+	TLSCertFile          string `yaml:"tls_cert_file"` // serve HTTPS when set with tls_key_file
+	TLSKeyFile           string `yaml:"tls_key_file"`
+	TLSSelfSigned        bool   `yaml:"tls_self_signed"`    // serve HTTPS with a generated certificate
+	RedirectHTTPPort     int    `yaml:"redirect_http_port"` // plain HTTP port redirecting to HTTPS; 0 disables
+	DisableHTTP2         bool   `yaml:"disable_http2"`
+	MaxConcurrentStreams int    `yaml:"max_concurrent_streams"` // HTTP/2 streams per client connection
+	MaxSSEConnections    int    `yaml:"max_sse_connections"`    // open SSE streams, server-wide
+	MaxSSEPerClient      int    `yaml:"max_sse_per_client"`     // open SSE streams per user
+}
+
+// TLSEnabled reports whether a certificate and key are configured or a
+// self-signed certificate is requested.
+func (c *HTTPConfig) TLSEnabled() bool {
+	return (c.TLSCertFile != "" && c.TLSKeyFile != "") || c.TLSSelfSigned
+}
+
+// TLSFiles returns the certificate and key files the server loads: the
+// configured ones, or the self-signed pair in the config directory.
+func (c *HTTPConfig) TLSFiles() (certFile, keyFile string) {
+	if c.TLSSelfSigned {
+		dir := GetConfigDir()
+		return filepath.Join(dir, constants.TLSSelfSignedCertFile), filepath.Join(dir, constants.TLSSelfSignedKeyFile)
+	}
+	return c.TLSCertFile, c.TLSKeyFile
+}
+
+// S3Config exposes topics as buckets over a subset of the S3 API, for
+// tools that speak S3 but not the silobang API. Requests are signed with
+// credentials derived from the user's API key.
+type S3Config struct {
+	Enabled bool   `yaml:"enabled"`
+	Region  string `yaml:"region"` // region clients sign requests for
+	Port    int    `yaml:"port"`   // dedicated listener serving S3 at its root; 0 = only under /s3/ on the main port
+}
+
+// DebugConfig exposes the Go profiler and runtime metrics under
+// /api/admin/debug to users holding the debug grant. Disabled by default,
+// since profiles reveal the command line and internals of the process.
+type DebugConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// FeaturesConfig turns off optional subsystems for lean deployments.
+// Changes take effect when the working directory is next initialized.
+type FeaturesConfig struct {
+	Disabled []string `yaml:"disabled"` // e.g. [previews, search]; see constants.OptionalFeatures
+}
+
+// Enabled reports whether the feature is not disabled.
+func (c *FeaturesConfig) Enabled(feature string) bool {
+	return !slices.Contains(c.Disabled, feature)
+}
+
+// FederationConfig makes this instance a query federation coordinator.
+// Presets are run locally and on every peer over HTTP with the peer's API key;
+// federation is disabled when no peers are configured.
+type FederationConfig struct {
+	InstanceName string           `yaml:"instance_name"` // origin reported for this instance's rows
+	TimeoutSecs  int              `yaml:"timeout_secs"`  // per-peer request timeout
+	Peers        []FederationPeer `yaml:"peers"`
+}
+
+// FederationPeer is a remote silobang instance queried by the coordinator.
+type FederationPeer struct {
+	Name   string `yaml:"name"`
+	URL    string `yaml:"url"`     // base URL, e.g. https://berlin.example.com:2369
+	APIKey string `yaml:"api_key"` // key of a peer user holding the query grant
+}
+
+// Timeout returns the per-peer request timeout as time.Duration.
+func (c *FederationConfig) Timeout() time.Duration {
+	return time.Duration(c.TimeoutSecs) * time.Second
+}
+
+// Enabled reports whether any peers are configured.
+func (c *FederationConfig) Enabled() bool {
+	return len(c.Peers) > 0
+}
+
+// OriginConfig makes this instance a cache of an origin instance: downloads
+// of assets it lacks are fetched from the origin, verified against their
+// hash and stored in Topic before being served. Disabled when URL is empty.
+type OriginConfig struct {
+	URL           string `yaml:"url"`             // base URL, e.g. https://hq.example.com:2369
+	APIKey        string `yaml:"api_key"`         // key of an origin user holding the download grant
+	Topic         string `yaml:"topic"`           // local topic storing fetched assets, created on first fetch
+	MaxCacheBytes int64  `yaml:"max_cache_bytes"` // fetched bytes kept before eviction; 0 = unlimited
+	Eviction      string `yaml:"eviction"`        // lru or lfu
+	TimeoutSecs   int    `yaml:"timeout_secs"`    // per-fetch timeout, body included
+}
+
+// Timeout returns the per-fetch timeout as time.Duration.
+func (c *OriginConfig) Timeout() time.Duration {
+	return time.Duration(c.TimeoutSecs) * time.Second
+}
+
+// Enabled reports whether an origin is configured.
+func (c *OriginConfig) Enabled() bool {
+	return c.URL != ""
+}
+
+// FetchConfig controls importing files from remote URLs
+// (POST /api/topics/:name/assets/fetch).
+type FetchConfig struct {
+	AllowedSchemes       []string `yaml:"allowed_schemes"`        // default https and http
+	AllowPrivateNetworks bool     `yaml:"allow_private_networks"` // allow loopback, private and link-local addresses
+	MaxURLs              int      `yaml:"max_urls"`               // per request
+	MaxSizeBytes         int64    `yaml:"max_size_bytes"`         // per file; 0 = the extension's storage policy limit
+	Retries              *int     `yaml:"retries"`                // attempts after the first; default 2
+	TimeoutSecs          int      `yaml:"timeout_secs"`           // per attempt, body included
+}
+
+// RetryCount returns how many times a failed fetch is retried.
+func (c *FetchConfig) RetryCount() int {
+	if c.Retries == nil {
+		return constants.FetchDefaultRetries
+	}
+	return *c.Retries
+}
+
+// Timeout returns the per-attempt timeout as time.Duration.
+func (c *FetchConfig) Timeout() time.Duration {
+	return time.Duration(c.TimeoutSecs) * time.Second
+}
+
 // Config holds all application configuration.
 type Config struct {
-	WorkingDirectory string             `yaml:"working_directory"`
-	Port             int                `yaml:"port"`
-	MaxDatSize       int64              `yaml:"max_dat_size"`
-	MaxDiskUsage     int64              `yaml:"max_disk_usage"`
-	Auth             AuthConfig         `yaml:"auth"`
-	BulkDownload     BulkDownloadConfig `yaml:"bulk_download"`
-	Audit            AuditConfig        `yaml:"audit"`
-	Metadata         MetadataConfig     `yaml:"metadata"`
-	Batch            BatchConfig        `yaml:"batch"`
-	Monitoring       MonitoringConfig   `yaml:"monitoring"`
+	WorkingDirectory string                         `yaml:"working_directory"`
+	Port             int                            `yaml:"port"`
+	MaxDatSize       int64                          `yaml:"max_dat_size"`
+	MaxDiskUsage     int64                          `yaml:"max_disk_usage"`
+	Auth             AuthConfig                     `yaml:"auth"`
+	OIDC             OIDCConfig                     `yaml:"oidc"`
+	BulkDownload     BulkDownloadConfig             `yaml:"bulk_download"`
+	Audit            AuditConfig                    `yaml:"audit"`
+	Metadata         MetadataConfig                 `yaml:"metadata"`
+	Batch            BatchConfig                    `yaml:"batch"`
+	Query            QueryConfig                    `yaml:"query"`
+	Monitoring       MonitoringConfig               `yaml:"monitoring"`
+	Public           PublicConfig                   `yaml:"public"`
+	RateLimit        RateLimitConfig                `yaml:"rate_limit"`
+	Notifications    NotificationsConfig            `yaml:"notifications"`
+	Federation       FederationConfig               `yaml:"federation"`
+	Origin           OriginConfig                   `yaml:"origin"`
+	Fetch            FetchConfig                    `yaml:"fetch"`
+	Idempotency      IdempotencyConfig              `yaml:"idempotency"`
+	Exports          ExportsConfig                  `yaml:"exports"`
+	DeletionRequests DeletionRequestsConfig         `yaml:"deletion_requests"`
+	Trash            TrashConfig                    `yaml:"trash"`
+	Previews         PreviewsConfig                 `yaml:"previews"`
+	AssetCache       AssetCacheConfig               `yaml:"asset_cache"`
+	Scrub            ScrubConfig                    `yaml:"scrub"`
+	Watermarks       map[string]WatermarkConfig     `yaml:"watermarks"`
+	TopicCollation   map[string]CollationConfig     `yaml:"topic_collation"`   // keyed by topic name
+	TopicRetention   map[string]RetentionConfig     `yaml:"topic_retention"`   // keyed by topic name
+	TopicQuotas      map[string]TopicQuotaConfig    `yaml:"topic_quotas"`      // keyed by topic name
+	FrozenTopics     []string                       `yaml:"frozen_topics"`     // topics refusing uploads and metadata writes
+	StoragePolicies  map[string]StoragePolicyConfig `yaml:"storage_policies"`  // keyed by extension, or "*"
+	StorageIO        map[string]StorageIOConfig     `yaml:"storage_io"`        // keyed by working directory path
+	BlobStores       map[string]BlobStoreConfig     `yaml:"blob_stores"`       // keyed by store name
+	TopicBlobStores  map[string]string              `yaml:"topic_blob_stores"` // topic name -> blob store name
+	Archives         map[string]PluginConfig        `yaml:"archives"`          // keyed by archive name
+	TopicArchive     map[string]ArchivePolicy       `yaml:"topic_archive"`     // keyed by topic name
+	Scan             ScanConfig                     `yaml:"scan"`
+	Validation       ValidationConfig               `yaml:"validation"`
+	AuthProviders    []PluginConfig                 `yaml:"auth_providers"`     // tried in order after API keys and sessions
+	Notifiers        []PluginConfig                 `yaml:"notifiers"`          // receive every stored notification
+	WatchFolders     map[string]WatchFolderConfig   `yaml:"watch_folders"`      // keyed by folder name
+	WatchFolderRoots []string                       `yaml:"watch_folder_roots"` // directories watch folders set through the API must be under
+	HTTP             HTTPConfig                     `yaml:"http"`
+	S3               S3Config                       `yaml:"s3"`
+	Debug            DebugConfig                    `yaml:"debug"`
+	Features         FeaturesConfig                 `yaml:"features"`
+}
+
+// StoragePolicy returns the effective policy for files with extension ext:
+// the extension's own policy, then the "*" policy, then the defaults. The
+// size limit never exceeds what fits in a DAT file.
+func (cfg *Config) StoragePolicy(ext string) StoragePolicy {
+	datSize := cfg.MaxDatSize
+	if datSize == 0 {
+		datSize = constants.DefaultMaxDatSize
+	}
+	limit := datSize - int64(constants.HeaderSize)
+
+	ext = sanitize.Extension(ext)
+	policy := StoragePolicy{
+		Extension:    ext,
+		Compression:  constants.StoragePolicyDefaultCompression,
+		Preview:      constants.StoragePolicyDefaultPreview,
+		Scan:         constants.StoragePolicyDefaultScan,
+		MaxSizeBytes: limit,
+	}
+
+	for _, key := range []string{constants.StoragePolicyAnyExtension, ext} {
+		override, ok := cfg.StoragePolicies[key]
+		if !ok {
+			continue
+		}
+		if override.Compression != nil {
+			policy.Compression = *override.Compression
+		}
+		if override.Preview != nil {
+			policy.Preview = *override.Preview
+		}
+		if override.Scan != nil {
+			policy.Scan = *override.Scan
+		}
+		if override.MaxSizeBytes > 0 {
+			policy.MaxSizeBytes = min(override.MaxSizeBytes, limit)
+		}
+	}
+	return policy
+}
+
+// IsTopicFrozen reports whether a topic is frozen: its assets and metadata
+// are read-only while downloads and queries keep working.
+func (cfg *Config) IsTopicFrozen(topicName string) bool {
+	return slices.Contains(cfg.FrozenTopics, topicName)
+}
+
+// DirectIO reports whether DAT files in the current working directory are
+// accessed with O_DIRECT.
+func (cfg *Config) DirectIO() bool {
+	if cfg.WorkingDirectory == "" {
+		return false
+	}
+	for dir, c := range cfg.StorageIO {
+		if filepath.Clean(dir) == filepath.Clean(cfg.WorkingDirectory) {
+			return c.DirectIO
+		}
+	}
+	return false
 }
 
 // ApplyDefaults fills zero-valued fields with constant defaults.
@@ -93,6 +808,20 @@ func (cfg *Config) ApplyDefaults() {
 	if cfg.Auth.SessionMaxDurationHours == 0 {
 		cfg.Auth.SessionMaxDurationHours = int(constants.AuthSessionMaxDuration.Hours())
 	}
+	if cfg.Auth.APIKeyRotationGraceHours == 0 {
+		cfg.Auth.APIKeyRotationGraceHours = int(constants.AuthAPIKeyRotationGrace.Hours())
+	}
+
+	// OpenID Connect defaults
+	if len(cfg.OIDC.Scopes) == 0 {
+		cfg.OIDC.Scopes = []string{"openid", "profile", "email"}
+	}
+	if cfg.OIDC.UsernameClaim == "" {
+		cfg.OIDC.UsernameClaim = constants.OIDCDefaultUsernameClaim
+	}
+	if cfg.OIDC.DisplayNameClaim == "" {
+		cfg.OIDC.DisplayNameClaim = constants.OIDCDefaultNameClaim
+	}
 
 	// Bulk download defaults
 	if cfg.BulkDownload.SessionTTLMins == 0 {
@@ -109,6 +838,12 @@ func (cfg *Config) ApplyDefaults() {
 	if cfg.Audit.PurgePercentage == 0 {
 		cfg.Audit.PurgePercentage = constants.AuditPurgePercentage
 	}
+	if cfg.Audit.QueueSize == 0 {
+		cfg.Audit.QueueSize = constants.AuditQueueDefaultSize
+	}
+	if cfg.Audit.OverflowPolicy == "" {
+		cfg.Audit.OverflowPolicy = constants.AuditOverflowBlock
+	}
 
 	// Metadata defaults
 	if cfg.Metadata.MaxValueBytes == 0 {
@@ -120,70 +855,836 @@ func (cfg *Config) ApplyDefaults() {
 		cfg.Batch.MaxOperations = constants.BatchMetadataMaxOperations
 	}
 
+	// Query defaults
+	if cfg.Query.MaxRows == 0 {
+		cfg.Query.MaxRows = constants.QueryDefaultMaxRows
+	}
+	if cfg.Query.FederatedMaxRows == 0 {
+		cfg.Query.FederatedMaxRows = constants.FederatedQueryMaxRows
+	}
+
 	// Monitoring defaults
 	if cfg.Monitoring.LogFileMaxReadBytes == 0 {
 		cfg.Monitoring.LogFileMaxReadBytes = constants.MonitoringLogFileMaxReadBytes
 	}
+
+	// Public read-only mode defaults
+	if cfg.Public.MaxDownloadBytes == 0 {
+		cfg.Public.MaxDownloadBytes = constants.PublicDefaultMaxDownloadBytes
+	}
+	if cfg.Public.RateLimitPerMin == 0 {
+		cfg.Public.RateLimitPerMin = constants.PublicDefaultRateLimitPerMin
+	}
+	if cfg.Public.RateLimitBurst == 0 {
+		cfg.Public.RateLimitBurst = constants.PublicDefaultRateLimitBurst
+	}
+
+	// Rate limit defaults
+	for _, d := range []struct {
+		rule          *RateLimitRule
+		perMin, burst int
+	}{
+		{&cfg.RateLimit.Uploads, constants.RateLimitDefaultUploadsPerMin, constants.RateLimitDefaultUploadsBurst},
+		{&cfg.RateLimit.Queries, constants.RateLimitDefaultQueriesPerMin, constants.RateLimitDefaultQueriesBurst},
+		{&cfg.RateLimit.Downloads, constants.RateLimitDefaultDownloadsPerMin, constants.RateLimitDefaultDownloadsBurst},
+	} {
+		if d.rule.PerMin == 0 {
+			d.rule.PerMin = d.perMin
+		}
+		if d.rule.Burst == 0 {
+			d.rule.Burst = d.burst
+		}
+	}
+
+	// Watermark defaults
+	for name, wm := range cfg.Watermarks {
+		if wm.Position == "" {
+			wm.Position = constants.WatermarkDefaultPosition
+		}
+		if wm.Opacity == 0 {
+			wm.Opacity = constants.WatermarkDefaultOpacity
+		}
+		if wm.Scale == 0 {
+			wm.Scale = constants.WatermarkDefaultScale
+		}
+		cfg.Watermarks[name] = wm
+	}
+
+	// Notification defaults
+	if cfg.Notifications.DigestIntervalMins == 0 {
+		cfg.Notifications.DigestIntervalMins = constants.NotificationDefaultDigestIntervalMins
+	}
+	if cfg.Notifications.WebhookTimeoutSecs == 0 {
+		cfg.Notifications.WebhookTimeoutSecs = constants.NotificationDefaultWebhookTimeoutSecs
+	}
+	if cfg.Notifications.RetentionDays == 0 {
+		cfg.Notifications.RetentionDays = constants.NotificationDefaultRetentionDays
+	}
+	if cfg.Notifications.SMTP.Host != "" && cfg.Notifications.SMTP.Port == 0 {
+		cfg.Notifications.SMTP.Port = constants.NotificationDefaultSMTPPort
+	}
+
+	// Federation defaults
+	if cfg.Federation.InstanceName == "" {
+		cfg.Federation.InstanceName = constants.FederationDefaultInstanceName
+	}
+	if cfg.Federation.TimeoutSecs == 0 {
+		cfg.Federation.TimeoutSecs = constants.FederationDefaultTimeoutSecs
+	}
+
+	// Origin cache defaults
+	if cfg.Origin.Topic == "" {
+		cfg.Origin.Topic = constants.OriginDefaultTopic
+	}
+	if cfg.Origin.Eviction == "" {
+		cfg.Origin.Eviction = constants.OriginEvictionLRU
+	}
+	if cfg.Origin.TimeoutSecs == 0 {
+		cfg.Origin.TimeoutSecs = constants.OriginDefaultTimeoutSecs
+	}
+
+	// Remote fetch defaults
+	if len(cfg.Fetch.AllowedSchemes) == 0 {
+		cfg.Fetch.AllowedSchemes = slices.Clone(constants.FetchDefaultSchemes)
+	}
+	if cfg.Fetch.MaxURLs == 0 {
+		cfg.Fetch.MaxURLs = constants.FetchDefaultMaxURLs
+	}
+	if cfg.Fetch.TimeoutSecs == 0 {
+		cfg.Fetch.TimeoutSecs = constants.FetchDefaultTimeoutSecs
+	}
+
+	// Idempotency defaults
+	if cfg.Idempotency.TTLHours == 0 {
+		cfg.Idempotency.TTLHours = constants.IdempotencyDefaultTTLHours
+	}
+	if cfg.Idempotency.MaxResponseBytes == 0 {
+		cfg.Idempotency.MaxResponseBytes = constants.IdempotencyDefaultMaxResponseBytes
+	}
+
+	// Export inbox defaults
+	if cfg.Exports.RetentionDays == 0 {
+		cfg.Exports.RetentionDays = constants.ExportDefaultRetentionDays
+	}
+	if cfg.Exports.MaxInboxBytes == 0 {
+		cfg.Exports.MaxInboxBytes = constants.ExportDefaultMaxInboxBytes
+	}
+
+	// Deletion request defaults
+	if cfg.DeletionRequests.ApprovalWindowHours == 0 {
+		cfg.DeletionRequests.ApprovalWindowHours = constants.DeletionRequestDefaultWindowHours
+	}
+
+	// Trash defaults
+	if cfg.Trash.RetentionDays == 0 {
+		cfg.Trash.RetentionDays = constants.TrashDefaultRetentionDays
+	}
+
+	// Asset cache defaults
+	if cfg.AssetCache.MaxBytes == 0 {
+		cfg.AssetCache.MaxBytes = constants.AssetCacheDefaultMaxBytes
+	}
+	if cfg.AssetCache.MaxAssetBytes == 0 {
+		cfg.AssetCache.MaxAssetBytes = constants.AssetCacheDefaultMaxAssetBytes
+	}
+
+	// Scrubber defaults
+	if cfg.Scrub.IntervalHours == 0 {
+		cfg.Scrub.IntervalHours = constants.ScrubDefaultIntervalHours
+	}
+
+	// Upload scan defaults
+	if cfg.Scan.TimeoutSecs == 0 {
+		cfg.Scan.TimeoutSecs = constants.ScanDefaultTimeoutSecs
+	}
+
+	// Upload validation defaults
+	if cfg.Validation.TimeoutSecs == 0 {
+		cfg.Validation.TimeoutSecs = constants.ValidationDefaultTimeoutSecs
+	}
+
+	// HTTP/2 and SSE defaults
+	if cfg.HTTP.MaxConcurrentStreams == 0 {
+		cfg.HTTP.MaxConcurrentStreams = constants.HTTP2DefaultMaxConcurrentStreams
+	}
+	if cfg.HTTP.MaxSSEConnections == 0 {
+		cfg.HTTP.MaxSSEConnections = constants.SSEDefaultMaxConnections
+	}
+	if cfg.HTTP.MaxSSEPerClient == 0 {
+		cfg.HTTP.MaxSSEPerClient = constants.SSEDefaultMaxPerClient
+	}
+
+	// S3 gateway defaults
+	if cfg.S3.Region == "" {
+		cfg.S3.Region = constants.S3DefaultRegion
+	}
+
+	// Blob store defaults
+	for name, store := range cfg.BlobStores {
+		if store.Region == "" {
+			store.Region = constants.S3DefaultRegion
+			cfg.BlobStores[name] = store
+		}
+	}
+
+	// Archive defaults
+	for topic, policy := range cfg.TopicArchive {
+		if policy.IdleDays == 0 {
+			policy.IdleDays = constants.ArchiveDefaultIdleDays
+			cfg.TopicArchive[topic] = policy
+		}
+	}
 }
 
-// validate checks that all configurable values are within acceptable ranges.
-func (cfg *Config) validate() error {
-	var errs []string
+// FieldError describes a single configuration value that is out of range.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors checks that all configurable values are within acceptable ranges
+// and returns one entry per offending field. Used by validate and by the
+// config dry-run endpoint.
+func (cfg *Config) FieldErrors() []FieldError {
+	var errs []FieldError
+	add := func(field, message string) {
+		errs = append(errs, FieldError{Field: field, Message: message})
+	}
 
 	// Auth validation
 	if cfg.Auth.MaxLoginAttempts < 1 {
-		errs = append(errs, "auth.max_login_attempts must be >= 1")
+		add("auth.max_login_attempts", "auth.max_login_attempts must be >= 1")
 	}
 	if cfg.Auth.LockoutDurationMins < 1 {
-		errs = append(errs, "auth.lockout_duration_mins must be >= 1")
+		add("auth.lockout_duration_mins", "auth.lockout_duration_mins must be >= 1")
 	}
 	if cfg.Auth.SessionDurationHours < 1 {
-		errs = append(errs, "auth.session_duration_hours must be >= 1")
+		add("auth.session_duration_hours", "auth.session_duration_hours must be >= 1")
 	}
 	if cfg.Auth.SessionMaxDurationHours < cfg.Auth.SessionDurationHours {
-		errs = append(errs, "auth.session_max_duration_hours must be >= auth.session_duration_hours")
+		add("auth.session_max_duration_hours", "auth.session_max_duration_hours must be >= auth.session_duration_hours")
+	}
+	if cfg.Auth.APIKeyTTLDays < 0 {
+		add("auth.api_key_ttl_days", "auth.api_key_ttl_days must be >= 0")
+	}
+	if cfg.Auth.APIKeyMaxTTLDays < 0 {
+		add("auth.api_key_max_ttl_days", "auth.api_key_max_ttl_days must be >= 0")
+	} else if cfg.Auth.APIKeyMaxTTLDays > 0 && (cfg.Auth.APIKeyTTLDays == 0 || cfg.Auth.APIKeyTTLDays > cfg.Auth.APIKeyMaxTTLDays) {
+		add("auth.api_key_ttl_days", "auth.api_key_ttl_days must be between 1 and auth.api_key_max_ttl_days")
+	}
+	if cfg.Auth.APIKeyRotationGraceHours < 0 || cfg.Auth.APIKeyRotationGrace() > constants.AuthAPIKeyMaxRotationGrace {
+		add("auth.api_key_rotation_grace_hours", fmt.Sprintf("auth.api_key_rotation_grace_hours must be between 0 and %d",
+			int(constants.AuthAPIKeyMaxRotationGrace.Hours())))
 	}
 
 	// Bulk download validation
 	if cfg.BulkDownload.SessionTTLMins < 1 {
-		errs = append(errs, "bulk_download.session_ttl_mins must be >= 1")
+		add("bulk_download.session_ttl_mins", "bulk_download.session_ttl_mins must be >= 1")
 	}
 	if cfg.BulkDownload.MaxAssets < 1 {
-		errs = append(errs, "bulk_download.max_assets must be >= 1")
+		add("bulk_download.max_assets", "bulk_download.max_assets must be >= 1")
 	}
 
 	// Audit validation
 	if cfg.Audit.MaxLogSizeBytes < 1048576 {
-		errs = append(errs, "audit.max_log_size_bytes must be >= 1048576 (1MB)")
+		add("audit.max_log_size_bytes", "audit.max_log_size_bytes must be >= 1048576 (1MB)")
 	}
 	if cfg.Audit.PurgePercentage < 1 || cfg.Audit.PurgePercentage > 100 {
-		errs = append(errs, "audit.purge_percentage must be between 1 and 100")
+		add("audit.purge_percentage", "audit.purge_percentage must be between 1 and 100")
+	}
+	if cfg.Audit.DefaultRetentionDays < 0 || cfg.Audit.DefaultRetentionDays > constants.AuditMaxRetentionDays {
+		add("audit.default_retention_days", fmt.Sprintf("audit.default_retention_days must be between 0 and %d", constants.AuditMaxRetentionDays))
+	}
+	if cfg.Audit.QueueSize < 1 || cfg.Audit.QueueSize > constants.AuditQueueMaxSize {
+		add("audit.queue_size", fmt.Sprintf("audit.queue_size must be between 1 and %d", constants.AuditQueueMaxSize))
+	}
+	if cfg.Audit.OverflowPolicy != constants.AuditOverflowBlock && cfg.Audit.OverflowPolicy != constants.AuditOverflowDropOldest {
+		add("audit.overflow_policy", fmt.Sprintf("audit.overflow_policy must be %q or %q", constants.AuditOverflowBlock, constants.AuditOverflowDropOldest))
+	}
+	for _, action := range slices.Sorted(maps.Keys(cfg.Audit.RetentionDays)) {
+		field := "audit.retention_days." + action
+		if !audit.IsValidAction(action) {
+			add(field, fmt.Sprintf("%s: unknown audit action %q", field, action))
+		} else if days := cfg.Audit.RetentionDays[action]; days < 1 || days > constants.AuditMaxRetentionDays {
+			add(field, fmt.Sprintf("%s must be between 1 and %d", field, constants.AuditMaxRetentionDays))
+		}
 	}
 
 	// Metadata validation
 	if cfg.Metadata.MaxValueBytes < 1 {
-		errs = append(errs, "metadata.max_value_bytes must be >= 1")
+		add("metadata.max_value_bytes", "metadata.max_value_bytes must be >= 1")
+	} else if cfg.Metadata.MaxValueBytes > constants.MetadataMaxValueBytesCeiling {
+		add("metadata.max_value_bytes", fmt.Sprintf("metadata.max_value_bytes must be <= %d", constants.MetadataMaxValueBytesCeiling))
+	}
+	if len(cfg.Metadata.Defaults) > constants.DefaultMetadataMaxKeys {
+		add("metadata.defaults", fmt.Sprintf("metadata.defaults must list at most %d keys", constants.DefaultMetadataMaxKeys))
+	}
+	validateDefaultMetadata(add, "metadata.defaults", cfg.Metadata.Defaults, cfg.Metadata.MaxValueBytes)
+	for _, topic := range slices.Sorted(maps.Keys(cfg.Metadata.TopicDefaults)) {
+		field := "metadata.topic_defaults." + topic
+		if !topicNameRegex.MatchString(topic) {
+			add(field, fmt.Sprintf("%s: invalid topic name", field))
+		}
+		validateDefaultMetadata(add, field, cfg.Metadata.TopicDefaults[topic], cfg.Metadata.MaxValueBytes)
+		if n := len(cfg.Metadata.DefaultsFor(topic)); n > constants.DefaultMetadataMaxKeys {
+			add(field, fmt.Sprintf("%s: %d default keys with metadata.defaults, at most %d allowed", field, n, constants.DefaultMetadataMaxKeys))
+		}
 	}
 
 	// Batch validation
 	if cfg.Batch.MaxOperations < 1 {
-		errs = append(errs, "batch.max_operations must be >= 1")
+		add("batch.max_operations", "batch.max_operations must be >= 1")
+	} else if cfg.Batch.MaxOperations > constants.BatchMaxOperationsCeiling {
+		add("batch.max_operations", fmt.Sprintf("batch.max_operations must be <= %d", constants.BatchMaxOperationsCeiling))
+	}
+
+	// Query validation
+	if cfg.Query.MaxRows < 1 || cfg.Query.MaxRows > constants.QueryMaxRowsCeiling {
+		add("query.max_rows", fmt.Sprintf("query.max_rows must be between 1 and %d", constants.QueryMaxRowsCeiling))
+	}
+	if cfg.Query.FederatedMaxRows < 1 || cfg.Query.FederatedMaxRows > constants.QueryMaxRowsCeiling {
+		add("query.federated_max_rows", fmt.Sprintf("query.federated_max_rows must be between 1 and %d", constants.QueryMaxRowsCeiling))
 	}
 
 	// Monitoring validation
 	if cfg.Monitoring.LogFileMaxReadBytes < 1024 {
-		errs = append(errs, "monitoring.log_file_max_read_bytes must be >= 1024 (1KB)")
+		add("monitoring.log_file_max_read_bytes", "monitoring.log_file_max_read_bytes must be >= 1024 (1KB)")
+	}
+
+	// Public read-only mode validation
+	if cfg.Public.MaxDownloadBytes < 1 {
+		add("public.max_download_bytes", "public.max_download_bytes must be >= 1")
+	}
+	if cfg.Public.RateLimitPerMin < 1 {
+		add("public.rate_limit_per_min", "public.rate_limit_per_min must be >= 1")
+	}
+	if cfg.Public.RateLimitBurst < 1 {
+		add("public.rate_limit_burst", "public.rate_limit_burst must be >= 1")
+	}
+
+	// Rate limit validation
+	for _, class := range constants.RateLimitClasses {
+		rule := cfg.RateLimit.Rule(class)
+		if rule.PerMin < 1 {
+			add("rate_limit."+class+".per_min", "rate_limit."+class+".per_min must be >= 1")
+		}
+		if rule.Burst < 1 {
+			add("rate_limit."+class+".burst", "rate_limit."+class+".burst must be >= 1")
+		}
+	}
+
+	if cfg.Public.Watermark != "" {
+		if _, ok := cfg.Watermarks[cfg.Public.Watermark]; !ok {
+			add("public.watermark", fmt.Sprintf("public.watermark: unknown watermark profile %q", cfg.Public.Watermark))
+		}
+	}
+
+	// Watermark validation
+	for _, name := range slices.Sorted(maps.Keys(cfg.Watermarks)) {
+		wm := cfg.Watermarks[name]
+		field := "watermarks." + name
+		if len(name) > constants.WatermarkMaxNameLength {
+			add(field, fmt.Sprintf("%s: profile name must be at most %d characters", field, constants.WatermarkMaxNameLength))
+		}
+		if (wm.Text == "") == (wm.Image == "") {
+			add(field, field+": exactly one of text or image must be set")
+		}
+		if len(wm.Text) > constants.WatermarkMaxTextLength {
+			add(field+".text", fmt.Sprintf("%s.text must be at most %d characters", field, constants.WatermarkMaxTextLength))
+		}
+		if wm.Image != "" {
+			if info, err := os.Stat(wm.Image); err != nil || info.IsDir() {
+				add(field+".image", fmt.Sprintf("%s.image: file not found: %s", field, wm.Image))
+			}
+		}
+		if !watermark.ValidPosition(wm.Position) {
+			add(field+".position", fmt.Sprintf("%s.position: unknown position %q", field, wm.Position))
+		}
+		if wm.Opacity <= 0 || wm.Opacity > 1 {
+			add(field+".opacity", field+".opacity must be > 0 and <= 1")
+		}
+		if wm.Scale <= 0 || wm.Scale > 1 {
+			add(field+".scale", field+".scale must be > 0 and <= 1")
+		}
+	}
+
+	// Topic collation validation
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicCollation)) {
+		field := "topic_collation." + topic
+		if !topicNameRegex.MatchString(topic) {
+			add(field, fmt.Sprintf("%s: invalid topic name", field))
+		}
+		if locale := cfg.TopicCollation[topic].Locale; !collate.ValidLocale(locale) {
+			add(field+".locale", fmt.Sprintf("%s.locale: invalid language tag %q", field, locale))
+		}
+	}
+
+	// Topic retention validation
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicRetention)) {
+		policy := cfg.TopicRetention[topic]
+		field := "topic_retention." + topic
+		if !topicNameRegex.MatchString(topic) {
+			add(field, fmt.Sprintf("%s: invalid topic name", field))
+		}
+		if policy.MaxAgeDays < 0 || policy.MaxTotalBytes < 0 || policy.MaxAssets < 0 {
+			add(field, fmt.Sprintf("%s: limits must be >= 0", field))
+		} else if policy.MaxAgeDays == 0 && policy.MaxTotalBytes == 0 && policy.MaxAssets == 0 {
+			add(field, fmt.Sprintf("%s: set at least one of max_age_days, max_total_bytes or max_assets", field))
+		}
+		if policy.Action != "" && policy.Action != constants.RetentionActionTrash && policy.Action != constants.RetentionActionDelete {
+			add(field+".action", fmt.Sprintf("%s.action must be %q or %q", field, constants.RetentionActionTrash, constants.RetentionActionDelete))
+		}
+	}
+
+	// Topic quota validation
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicQuotas)) {
+		quota := cfg.TopicQuotas[topic]
+		field := "topic_quotas." + topic
+		if !topicNameRegex.MatchString(topic) {
+			add(field, fmt.Sprintf("%s: invalid topic name", field))
+		}
+		if quota.SoftBytes < 0 || quota.HardBytes < 0 || quota.SoftAssets < 0 || quota.HardAssets < 0 {
+			add(field, fmt.Sprintf("%s: limits must be >= 0", field))
+			continue
+		}
+		if quota.SoftBytes > 0 && quota.HardBytes > 0 && quota.SoftBytes > quota.HardBytes {
+			add(field+".soft_bytes", fmt.Sprintf("%s.soft_bytes must be <= hard_bytes", field))
+		}
+		if quota.SoftAssets > 0 && quota.HardAssets > 0 && quota.SoftAssets > quota.HardAssets {
+			add(field+".soft_assets", fmt.Sprintf("%s.soft_assets must be <= hard_assets", field))
+		}
+	}
+
+	// Frozen topic validation
+	for i, topic := range cfg.FrozenTopics {
+		if !topicNameRegex.MatchString(topic) {
+			add("frozen_topics", fmt.Sprintf("frozen_topics: invalid topic name %q", topic))
+		} else if slices.Index(cfg.FrozenTopics, topic) != i {
+			add("frozen_topics", fmt.Sprintf("frozen_topics: %s is listed twice", topic))
+		}
+	}
+
+	// Storage policy validation
+	if len(cfg.StoragePolicies) > constants.StoragePolicyMaxEntries {
+		add("storage_policies", fmt.Sprintf("storage_policies must list at most %d extensions", constants.StoragePolicyMaxEntries))
+	}
+	for _, ext := range slices.Sorted(maps.Keys(cfg.StoragePolicies)) {
+		policy := cfg.StoragePolicies[ext]
+		field := "storage_policies." + ext
+		if ext != constants.StoragePolicyAnyExtension && (ext == "" || sanitize.Extension(ext) != ext) {
+			add(field, fmt.Sprintf("%s: extension must be lower-case letters and digits without a dot, or %q", field, constants.StoragePolicyAnyExtension))
+		}
+		if policy.MaxSizeBytes < 0 || policy.MaxSizeBytes > cfg.MaxDatSize-int64(constants.HeaderSize) {
+			add(field+".max_size_bytes", fmt.Sprintf("%s.max_size_bytes must be between 0 and %d (max_dat_size minus the entry header)", field, cfg.MaxDatSize-int64(constants.HeaderSize)))
+		}
+		if policy.Scan != nil && *policy.Scan && !cfg.Scan.Enabled() {
+			add(field+".scan", fmt.Sprintf("%s.scan requires scan.command or scan.type to be set", field))
+		}
+	}
+	if cfg.Scan.TimeoutSecs < 1 {
+		add("scan.timeout_secs", "scan.timeout_secs must be >= 1")
+	}
+	if cfg.Scan.Enabled() {
+		if _, err := plugin.NewScanner(cfg.Scan.ScannerType(), cfg.Scan.PluginConfig()); err != nil {
+			add("scan.type", fmt.Sprintf("scan: %v", err))
+		}
+	}
+
+	// Upload validation
+	for _, ext := range slices.Sorted(maps.Keys(cfg.Validation.Validators)) {
+		field := "validation.validators." + ext
+		if ext == "" || sanitize.Extension(ext) != ext {
+			add(field, fmt.Sprintf("%s: extension must be lower-case letters and digits without a dot", field))
+		}
+		v := cfg.Validation.Validators[ext]
+		if _, err := plugin.NewValidator(v.Type, v.Options); err != nil {
+			add(field, fmt.Sprintf("%s: %v", field, err))
+		}
+	}
+	validMode := func(mode string) bool {
+		return mode == constants.ValidationModeStrict || mode == constants.ValidationModeLenient
+	}
+	if cfg.Validation.Mode != "" && !validMode(cfg.Validation.Mode) {
+		add("validation.mode", fmt.Sprintf("validation.mode must be %q or %q", constants.ValidationModeStrict, constants.ValidationModeLenient))
+	}
+	for _, topic := range slices.Sorted(maps.Keys(cfg.Validation.TopicModes)) {
+		field := "validation.topic_modes." + topic
+		if !topicNameRegex.MatchString(topic) {
+			add(field, fmt.Sprintf("%s: invalid topic name", field))
+		}
+		if !validMode(cfg.Validation.TopicModes[topic]) {
+			add(field, fmt.Sprintf("%s must be %q or %q", field, constants.ValidationModeStrict, constants.ValidationModeLenient))
+		}
+	}
+	if cfg.Validation.TimeoutSecs < 1 {
+		add("validation.timeout_secs", "validation.timeout_secs must be >= 1")
+	}
+
+	// Storage I/O validation
+	for _, dir := range slices.Sorted(maps.Keys(cfg.StorageIO)) {
+		if !filepath.IsAbs(dir) {
+			add("storage_io."+dir, fmt.Sprintf("storage_io.%s: working directory must be an absolute path", dir))
+		}
+	}
+
+	// Blob store validation
+	for _, name := range slices.Sorted(maps.Keys(cfg.BlobStores)) {
+		store := cfg.BlobStores[name]
+		field := "blob_stores." + name
+		if name == constants.BlobStoreLocal || !topicNameRegex.MatchString(name) {
+			add(field, fmt.Sprintf("%s: name must be lower-case letters, digits, hyphens and underscores, and not %q", field, constants.BlobStoreLocal))
+		}
+		if store.StoreType() != constants.PluginBlobStoreS3 {
+			if _, err := plugin.NewBlobStore(store.StoreType(), store.PluginConfig(name, "")); err != nil {
+				add(field+".type", fmt.Sprintf("%s: %v", field, err))
+			}
+			continue
+		}
+		if u, err := url.Parse(store.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(field+".endpoint", fmt.Sprintf("%s.endpoint must be an http or https URL", field))
+		}
+		if store.Bucket == "" {
+			add(field+".bucket", fmt.Sprintf("%s.bucket is required", field))
+		}
+		if store.Prefix != "" && !strings.HasSuffix(store.Prefix, "/") {
+			add(field+".prefix", fmt.Sprintf("%s.prefix must end with /", field))
+		}
+		if store.AccessKeyID == "" || store.SecretAccessKey == "" {
+			add(field, fmt.Sprintf("%s: access_key_id and secret_access_key are required", field))
+		}
+	}
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicBlobStores)) {
+		field := "topic_blob_stores." + topic
+		if !topicNameRegex.MatchString(topic) {
+			add(field, fmt.Sprintf("%s: invalid topic name", field))
+		}
+		if _, ok := cfg.BlobStores[cfg.TopicBlobStores[topic]]; !ok {
+			add(field, fmt.Sprintf("%s: unknown blob store %q", field, cfg.TopicBlobStores[topic]))
+		}
+	}
+
+	// Plugin validation
+	for i, p := range cfg.AuthProviders {
+		if _, err := plugin.NewAuthProvider(p.Type, p.Options); err != nil {
+			field := fmt.Sprintf("auth_providers[%d]", i)
+			add(field, fmt.Sprintf("%s: %v", field, err))
+		}
+	}
+	for i, p := range cfg.Notifiers {
+		if _, err := plugin.NewNotifier(p.Type, p.Options); err != nil {
+			field := fmt.Sprintf("notifiers[%d]", i)
+			add(field, fmt.Sprintf("%s: %v", field, err))
+		}
+	}
+
+	// Watch folder validation
+	if len(cfg.WatchFolders) > constants.WatchFolderMaxEntries {
+		add("watch_folders", fmt.Sprintf("watch_folders must list at most %d folders", constants.WatchFolderMaxEntries))
+	}
+	for i, root := range cfg.WatchFolderRoots {
+		if !filepath.IsAbs(root) {
+			add("watch_folder_roots", fmt.Sprintf("watch_folder_roots[%d] must be an absolute path", i))
+		}
+	}
+	watchedPaths := map[string]string{}
+	for _, name := range slices.Sorted(maps.Keys(cfg.WatchFolders)) {
+		folder := cfg.WatchFolders[name]
+		field := "watch_folders." + name
+		if !topicNameRegex.MatchString(name) {
+			add(field, fmt.Sprintf("%s: name must be lower-case letters, digits, hyphens and underscores", field))
+		}
+		if !topicNameRegex.MatchString(folder.Topic) {
+			add(field+".topic", fmt.Sprintf("%s.topic must be a valid topic name", field))
+		}
+		if folder.AfterIngest != "" && folder.AfterIngest != constants.WatchFolderAfterMove && folder.AfterIngest != constants.WatchFolderAfterDelete {
+			add(field+".after_ingest", fmt.Sprintf("%s.after_ingest must be %q or %q", field, constants.WatchFolderAfterMove, constants.WatchFolderAfterDelete))
+		}
+		if folder.SettleSecs < 0 || folder.SettleSecs > constants.WatchFolderMaxSettleSecs {
+			add(field+".settle_secs", fmt.Sprintf("%s.settle_secs must be between 0 and %d", field, constants.WatchFolderMaxSettleSecs))
+		}
+		if !filepath.IsAbs(folder.Path) {
+			add(field+".path", fmt.Sprintf("%s.path must be an absolute path", field))
+			continue
+		}
+		path := filepath.Clean(folder.Path)
+		if cfg.WorkingDirectory != "" && (pathWithin(path, cfg.WorkingDirectory) || pathWithin(cfg.WorkingDirectory, path)) {
+			add(field+".path", fmt.Sprintf("%s.path must not contain or be inside the working directory", field))
+		}
+		for other, otherPath := range watchedPaths {
+			if pathWithin(path, otherPath) || pathWithin(otherPath, path) {
+				add(field+".path", fmt.Sprintf("%s.path overlaps watch_folders.%s", field, other))
+			}
+		}
+		watchedPaths[name] = path
+	}
+
+	// Notification validation
+	if cfg.Notifications.DigestIntervalMins < 1 {
+		add("notifications.digest_interval_mins", "notifications.digest_interval_mins must be >= 1")
+	}
+	if cfg.Notifications.WebhookTimeoutSecs < 1 {
+		add("notifications.webhook_timeout_secs", "notifications.webhook_timeout_secs must be >= 1")
+	}
+	if cfg.Notifications.RetentionDays < 1 {
+		add("notifications.retention_days", "notifications.retention_days must be >= 1")
+	}
+	if cfg.Notifications.SMTP.Host != "" {
+		if cfg.Notifications.SMTP.Port < constants.MinPort || cfg.Notifications.SMTP.Port > constants.MaxPort {
+			add("notifications.smtp.port", fmt.Sprintf("notifications.smtp.port must be between %d and %d", constants.MinPort, constants.MaxPort))
+		}
+		if cfg.Notifications.SMTP.From == "" {
+			add("notifications.smtp.from", "notifications.smtp.from is required when notifications.smtp.host is set")
+		}
+	}
+
+	// Federation validation
+	if cfg.Federation.TimeoutSecs < 1 {
+		add("federation.timeout_secs", "federation.timeout_secs must be >= 1")
+	}
+	if len(cfg.Federation.Peers) > constants.FederationMaxPeers {
+		add("federation.peers", fmt.Sprintf("federation.peers must list at most %d peers", constants.FederationMaxPeers))
+	}
+	peerNames := map[string]bool{cfg.Federation.InstanceName: true}
+	for i, peer := range cfg.Federation.Peers {
+		field := fmt.Sprintf("federation.peers[%d]", i)
+		if peer.Name == "" {
+			add(field+".name", field+".name is required")
+		} else if peerNames[peer.Name] {
+			add(field+".name", fmt.Sprintf("%s.name %q is already used by another instance", field, peer.Name))
+		}
+		peerNames[peer.Name] = true
+		if u, err := url.Parse(peer.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(field+".url", field+".url must be an absolute http(s) URL")
+		}
+		if peer.APIKey == "" {
+			add(field+".api_key", field+".api_key is required")
+		}
+	}
+
+	// OpenID Connect validation
+	if cfg.OIDC.Enabled() {
+		if u, err := url.Parse(cfg.OIDC.Issuer); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("oidc.issuer", "oidc.issuer must be an absolute http(s) URL")
+		}
+		if cfg.OIDC.ClientID == "" {
+			add("oidc.client_id", "oidc.client_id is required when oidc.issuer is set")
+		}
+		if u, err := url.Parse(cfg.OIDC.RedirectURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("oidc.redirect_url", "oidc.redirect_url must be an absolute http(s) URL")
+		}
+		for i, domain := range cfg.OIDC.AllowedDomains {
+			if domain == "" || strings.Contains(domain, "@") {
+				add(fmt.Sprintf("oidc.allowed_domains[%d]", i), "oidc.allowed_domains entries must be bare domains, e.g. example.com")
+			}
+		}
+	}
+
+	// Origin cache validation
+	if cfg.Origin.Enabled() {
+		if u, err := url.Parse(cfg.Origin.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("origin.url", "origin.url must be an absolute http(s) URL")
+		}
+		if cfg.Origin.APIKey == "" {
+			add("origin.api_key", "origin.api_key is required when origin.url is set")
+		}
+	}
+	if !topicNameRegex.MatchString(cfg.Origin.Topic) {
+		add("origin.topic", "origin.topic must be a valid topic name")
+	}
+	if cfg.Origin.MaxCacheBytes != 0 && cfg.Origin.MaxCacheBytes < constants.OriginMinCacheBytes {
+		add("origin.max_cache_bytes", fmt.Sprintf("origin.max_cache_bytes must be 0 (unlimited) or >= %d", constants.OriginMinCacheBytes))
+	}
+	if cfg.Origin.Eviction != constants.OriginEvictionLRU && cfg.Origin.Eviction != constants.OriginEvictionLFU {
+		add("origin.eviction", fmt.Sprintf("origin.eviction must be %q or %q", constants.OriginEvictionLRU, constants.OriginEvictionLFU))
+	}
+	if cfg.Origin.TimeoutSecs < 1 {
+		add("origin.timeout_secs", "origin.timeout_secs must be >= 1")
+	}
+
+	// Remote fetch validation
+	for _, scheme := range cfg.Fetch.AllowedSchemes {
+		if scheme != "http" && scheme != "https" {
+			add("fetch.allowed_schemes", fmt.Sprintf("fetch.allowed_schemes: unsupported scheme %q (http or https)", scheme))
+		}
+	}
+	if cfg.Fetch.MaxURLs < 1 {
+		add("fetch.max_urls", "fetch.max_urls must be >= 1")
+	}
+	if cfg.Fetch.MaxSizeBytes < 0 {
+		add("fetch.max_size_bytes", "fetch.max_size_bytes must be >= 0")
+	}
+	if retries := cfg.Fetch.RetryCount(); retries < 0 || retries > constants.FetchMaxRetries {
+		add("fetch.retries", fmt.Sprintf("fetch.retries must be between 0 and %d", constants.FetchMaxRetries))
+	}
+	if cfg.Fetch.TimeoutSecs < 1 {
+		add("fetch.timeout_secs", "fetch.timeout_secs must be >= 1")
+	}
+
+	// Idempotency validation
+	if cfg.Idempotency.TTLHours < 1 {
+		add("idempotency.ttl_hours", "idempotency.ttl_hours must be >= 1")
+	}
+	if cfg.Idempotency.MaxResponseBytes < 1024 {
+		add("idempotency.max_response_bytes", "idempotency.max_response_bytes must be >= 1024 (1KB)")
+	}
+
+	// Export inbox validation
+	if cfg.Exports.RetentionDays < 1 {
+		add("exports.retention_days", "exports.retention_days must be >= 1")
+	}
+	if cfg.Exports.MaxInboxBytes < 1048576 {
+		add("exports.max_inbox_bytes", "exports.max_inbox_bytes must be >= 1048576 (1MB)")
+	}
+
+	// Deletion request validation
+	if cfg.DeletionRequests.ApprovalWindowHours < 1 {
+		add("deletion_requests.approval_window_hours", "deletion_requests.approval_window_hours must be >= 1")
+	}
+
+	// Trash validation
+	if cfg.Trash.RetentionDays < 1 {
+		add("trash.retention_days", "trash.retention_days must be >= 1")
+	}
+
+	// Asset cache validation
+	if cfg.AssetCache.MaxBytes < 0 {
+		add("asset_cache.max_bytes", "asset_cache.max_bytes must be >= 0")
+	}
+	if cfg.AssetCache.MaxAssetBytes < 1 || cfg.AssetCache.MaxAssetBytes > cfg.AssetCache.MaxBytes {
+		add("asset_cache.max_asset_bytes", "asset_cache.max_asset_bytes must be between 1 and asset_cache.max_bytes")
+	}
+
+	// Archive validation
+	for _, name := range slices.Sorted(maps.Keys(cfg.Archives)) {
+		field := "archives." + name
+		if !topicNameRegex.MatchString(name) {
+			add(field, fmt.Sprintf("%s: name must be lower-case letters, digits, hyphens and underscores", field))
+		}
+		if _, err := plugin.NewArchive(cfg.Archives[name].Type, cfg.Archives[name].Options); err != nil {
+			add(field, fmt.Sprintf("%s: %v", field, err))
+		}
+	}
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicArchive)) {
+		policy := cfg.TopicArchive[topic]
+		field := "topic_archive." + topic
+		if !topicNameRegex.MatchString(topic) {
+			add(field, fmt.Sprintf("%s: invalid topic name", field))
+		}
+		if _, ok := cfg.Archives[policy.Archive]; !ok {
+			add(field+".archive", fmt.Sprintf("%s.archive: unknown archive %q", field, policy.Archive))
+		}
+		if policy.IdleDays < 1 {
+			add(field+".idle_days", fmt.Sprintf("%s.idle_days must be >= 1", field))
+		}
+	}
+
+	// Scrubber validation
+	if cfg.Scrub.IntervalHours < 1 {
+		add("scrub.interval_hours", "scrub.interval_hours must be >= 1")
+	}
+	if cfg.Scrub.MaxBytesPerSec < 0 {
+		add("scrub.max_bytes_per_sec", "scrub.max_bytes_per_sec must be >= 0")
+	}
+
+	// HTTP/2 and SSE validation
+	if (cfg.HTTP.TLSCertFile == "") != (cfg.HTTP.TLSKeyFile == "") {
+		add("http.tls_cert_file", "http.tls_cert_file and http.tls_key_file must be set together")
+	}
+	if cfg.HTTP.TLSSelfSigned && (cfg.HTTP.TLSCertFile != "" || cfg.HTTP.TLSKeyFile != "") {
+		add("http.tls_self_signed", "http.tls_self_signed cannot be combined with http.tls_cert_file and http.tls_key_file")
+	}
+	if p := cfg.HTTP.RedirectHTTPPort; p != 0 {
+		port := cfg.Port
+		if port == 0 {
+			port = constants.DefaultPort
+		}
+		switch {
+		case p < 1 || p > 65535:
+			add("http.redirect_http_port", "http.redirect_http_port must be between 1 and 65535")
+		case !cfg.HTTP.TLSEnabled():
+			add("http.redirect_http_port", "http.redirect_http_port requires TLS")
+		case p == port:
+			add("http.redirect_http_port", "http.redirect_http_port must differ from port")
+		}
+	}
+	if cfg.HTTP.MaxConcurrentStreams < 1 {
+		add("http.max_concurrent_streams", "http.max_concurrent_streams must be >= 1")
+	}
+	if cfg.HTTP.MaxSSEConnections < 1 {
+		add("http.max_sse_connections", "http.max_sse_connections must be >= 1")
+	}
+	if cfg.HTTP.MaxSSEPerClient < 1 || cfg.HTTP.MaxSSEPerClient > cfg.HTTP.MaxSSEConnections {
+		add("http.max_sse_per_client", "http.max_sse_per_client must be between 1 and http.max_sse_connections")
+	}
+
+	// S3 gateway validation
+	if p := cfg.S3.Port; p != 0 {
+		port := cfg.Port
+		if port == 0 {
+			port = constants.DefaultPort
+		}
+		switch {
+		case p < 1 || p > 65535:
+			add("s3.port", "s3.port must be between 1 and 65535")
+		case p == port || p == cfg.HTTP.RedirectHTTPPort:
+			add("s3.port", "s3.port must differ from port and http.redirect_http_port")
+		}
+	}
+
+	// Feature flag validation
+	for i, feature := range cfg.Features.Disabled {
+		if !slices.Contains(constants.OptionalFeatures, feature) {
+			add(fmt.Sprintf("features.disabled[%d]", i), fmt.Sprintf("features.disabled entries must be one of: %s", strings.Join(constants.OptionalFeatures, ", ")))
+		}
 	}
 
 	// Disk usage validation (0 = unlimited, otherwise must be >= minimum)
 	if cfg.MaxDiskUsage != constants.DefaultMaxDiskUsageBytes && cfg.MaxDiskUsage < constants.MinMaxDiskUsageBytes {
-		errs = append(errs, fmt.Sprintf("max_disk_usage must be 0 (unlimited) or >= %d (1GB)", constants.MinMaxDiskUsageBytes))
+		add("max_disk_usage", fmt.Sprintf("max_disk_usage must be 0 (unlimited) or >= %d (1GB)", constants.MinMaxDiskUsageBytes))
 	}
 
-	if len(errs) > 0 {
-		return fmt.Errorf("config validation failed:\n  - %s", strings.Join(errs, "\n  - "))
+	return errs
+}
+
+// validateDefaultMetadata checks the keys of a default metadata template and
+// that its values only use known placeholders.
+func validateDefaultMetadata(add func(field, message string), field string, defaults map[string]string, maxValueBytes int) {
+	for _, key := range slices.Sorted(maps.Keys(defaults)) {
+		keyField := field + "." + key
+		if key == "" || len(key) > constants.MaxMetadataKeyLength {
+			add(keyField, fmt.Sprintf("%s: key must be 1 to %d characters", keyField, constants.MaxMetadataKeyLength))
+		}
+		value := defaults[key]
+		if len(value) > maxValueBytes {
+			add(keyField, fmt.Sprintf("%s must be at most %d bytes (metadata.max_value_bytes)", keyField, maxValueBytes))
+		}
+		for _, placeholder := range placeholderRegex.FindAllString(value, -1) {
+			if !slices.Contains(defaultMetadataPlaceholders, placeholder) {
+				add(keyField, fmt.Sprintf("%s: unknown placeholder %s", keyField, placeholder))
+			}
+		}
+	}
+}
+
+// pathWithin reports whether path is dir or inside it. Both are clean
+// absolute paths.
+func pathWithin(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// validate checks that all configurable values are within acceptable ranges.
+func (cfg *Config) validate() error {
+	fieldErrs := cfg.FieldErrors()
+	if len(fieldErrs) == 0 {
+		return nil
+	}
+
+	errs := make([]string, len(fieldErrs))
+	for i, fe := range fieldErrs {
+		errs[i] = fe.Message
 	}
-	return nil
+	return fmt.Errorf("config validation failed:\n  - %s", strings.Join(errs, "\n  - "))
 }
 
 // LogEffectiveValues logs all effective configuration values at startup.
@@ -194,13 +1695,181 @@ func (cfg *Config) LogEffectiveValues(log *logger.Logger) {
 	log.Info("config: auth.lockout_duration_mins=%d", cfg.Auth.LockoutDurationMins)
 	log.Info("config: auth.session_duration_hours=%d", cfg.Auth.SessionDurationHours)
 	log.Info("config: auth.session_max_duration_hours=%d", cfg.Auth.SessionMaxDurationHours)
+	log.Info("config: auth.api_key_ttl_days=%d", cfg.Auth.APIKeyTTLDays)
+	log.Info("config: auth.api_key_max_ttl_days=%d", cfg.Auth.APIKeyMaxTTLDays)
+	log.Info("config: auth.api_key_rotation_grace_hours=%d", cfg.Auth.APIKeyRotationGraceHours)
 	log.Info("config: bulk_download.session_ttl_mins=%d", cfg.BulkDownload.SessionTTLMins)
 	log.Info("config: bulk_download.max_assets=%d", cfg.BulkDownload.MaxAssets)
 	log.Info("config: audit.max_log_size_bytes=%d", cfg.Audit.MaxLogSizeBytes)
 	log.Info("config: audit.purge_percentage=%d", cfg.Audit.PurgePercentage)
+	log.Info("config: audit.default_retention_days=%d", cfg.Audit.DefaultRetentionDays)
+	log.Info("config: audit.queue_size=%d", cfg.Audit.QueueSize)
+	log.Info("config: audit.overflow_policy=%s", cfg.Audit.OverflowPolicy)
+	for _, action := range slices.Sorted(maps.Keys(cfg.Audit.RetentionDays)) {
+		log.Info("config: audit.retention_days.%s=%d", action, cfg.Audit.RetentionDays[action])
+	}
 	log.Info("config: metadata.max_value_bytes=%d", cfg.Metadata.MaxValueBytes)
+	log.Info("config: metadata.media_info=%t", cfg.Metadata.MediaInfo)
+	for _, key := range slices.Sorted(maps.Keys(cfg.Metadata.Defaults)) {
+		log.Info("config: metadata.defaults.%s=%q", key, cfg.Metadata.Defaults[key])
+	}
+	for _, topic := range slices.Sorted(maps.Keys(cfg.Metadata.TopicDefaults)) {
+		for _, key := range slices.Sorted(maps.Keys(cfg.Metadata.TopicDefaults[topic])) {
+			log.Info("config: metadata.topic_defaults.%s.%s=%q", topic, key, cfg.Metadata.TopicDefaults[topic][key])
+		}
+	}
 	log.Info("config: batch.max_operations=%d", cfg.Batch.MaxOperations)
+	log.Info("config: query.max_rows=%d", cfg.Query.MaxRows)
+	log.Info("config: query.federated_max_rows=%d", cfg.Query.FederatedMaxRows)
 	log.Info("config: monitoring.log_file_max_read_bytes=%d", cfg.Monitoring.LogFileMaxReadBytes)
+	log.Info("config: idempotency.ttl_hours=%d", cfg.Idempotency.TTLHours)
+	log.Info("config: idempotency.max_response_bytes=%d", cfg.Idempotency.MaxResponseBytes)
+	log.Info("config: exports.retention_days=%d", cfg.Exports.RetentionDays)
+	log.Info("config: exports.max_inbox_bytes=%d", cfg.Exports.MaxInboxBytes)
+	log.Info("config: deletion_requests.approval_window_hours=%d", cfg.DeletionRequests.ApprovalWindowHours)
+	log.Info("config: trash.retention_days=%d", cfg.Trash.RetentionDays)
+	log.Info("config: previews.on_upload=%t", cfg.Previews.OnUpload)
+	log.Info("config: asset_cache.disabled=%t", cfg.AssetCache.Disabled)
+	log.Info("config: asset_cache.max_bytes=%d", cfg.AssetCache.MaxBytes)
+	log.Info("config: asset_cache.max_asset_bytes=%d", cfg.AssetCache.MaxAssetBytes)
+	for _, name := range slices.Sorted(maps.Keys(cfg.Archives)) {
+		a := cfg.Archives[name]
+		log.Info("config: archives.%s type=%s options=%v", name, a.Type, slices.Sorted(maps.Keys(a.Options)))
+	}
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicArchive)) {
+		a := cfg.TopicArchive[topic]
+		log.Info("config: topic_archive.%s archive=%s idle_days=%d dry_run=%v", topic, a.Archive, a.IdleDays, a.DryRun)
+	}
+	log.Info("config: scrub.disabled=%t scrub.interval_hours=%d scrub.max_bytes_per_sec=%d", cfg.Scrub.Disabled, cfg.Scrub.IntervalHours, cfg.Scrub.MaxBytesPerSec)
+	log.Info("config: http.tls=%t http.tls_self_signed=%t http.disable_http2=%t", cfg.HTTP.TLSEnabled(), cfg.HTTP.TLSSelfSigned, cfg.HTTP.DisableHTTP2)
+	log.Info("config: http.redirect_http_port=%d", cfg.HTTP.RedirectHTTPPort)
+	log.Info("config: http.max_concurrent_streams=%d", cfg.HTTP.MaxConcurrentStreams)
+	log.Info("config: http.max_sse_connections=%d", cfg.HTTP.MaxSSEConnections)
+	log.Info("config: http.max_sse_per_client=%d", cfg.HTTP.MaxSSEPerClient)
+	if cfg.S3.Enabled {
+		log.Info("config: s3.region=%s s3.port=%d", cfg.S3.Region, cfg.S3.Port)
+	}
+	if len(cfg.Features.Disabled) > 0 {
+		log.Info("config: features.disabled=%v", cfg.Features.Disabled)
+	}
+	if cfg.Debug.Enabled {
+		log.Info("config: debug.enabled=true (profiling endpoints under /api/admin/debug)")
+	}
+	if cfg.Federation.Enabled() {
+		log.Info("config: federation.instance_name=%s", cfg.Federation.InstanceName)
+		log.Info("config: federation.timeout_secs=%d", cfg.Federation.TimeoutSecs)
+		for _, peer := range cfg.Federation.Peers {
+			log.Info("config: federation.peer %s=%s", peer.Name, peer.URL)
+		}
+	}
+	if cfg.OIDC.Enabled() {
+		log.Info("config: oidc.issuer=%s oidc.client_id=%s", cfg.OIDC.Issuer, cfg.OIDC.ClientID)
+		log.Info("config: oidc.redirect_url=%s", cfg.OIDC.RedirectURL)
+		log.Info("config: oidc.scopes=%v", cfg.OIDC.Scopes)
+		log.Info("config: oidc.username_claim=%s oidc.display_name_claim=%s", cfg.OIDC.UsernameClaim, cfg.OIDC.DisplayNameClaim)
+		log.Info("config: oidc.allowed_domains=%v", cfg.OIDC.AllowedDomains)
+		log.Info("config: oidc.auto_provision=%t oidc.link_existing_users=%t", cfg.OIDC.Provisions(), cfg.OIDC.LinkExistingUsers)
+	}
+	if cfg.Origin.Enabled() {
+		log.Info("config: origin.url=%s", cfg.Origin.URL)
+		log.Info("config: origin.topic=%s", cfg.Origin.Topic)
+		log.Info("config: origin.max_cache_bytes=%d origin.eviction=%s", cfg.Origin.MaxCacheBytes, cfg.Origin.Eviction)
+		log.Info("config: origin.timeout_secs=%d", cfg.Origin.TimeoutSecs)
+	}
+	log.Info("config: fetch.allowed_schemes=%v fetch.allow_private_networks=%v", cfg.Fetch.AllowedSchemes, cfg.Fetch.AllowPrivateNetworks)
+	log.Info("config: fetch.max_urls=%d fetch.max_size_bytes=%d fetch.retries=%d fetch.timeout_secs=%d",
+		cfg.Fetch.MaxURLs, cfg.Fetch.MaxSizeBytes, cfg.Fetch.RetryCount(), cfg.Fetch.TimeoutSecs)
+	if cfg.Public.Enabled {
+		log.Warn("config: public.enabled=true — anonymous read-only access is on")
+		if len(cfg.Public.AllowedPresets) == 0 {
+			log.Warn("config: public.allowed_presets is empty — anonymous users cannot run any query preset")
+		} else {
+			log.Info("config: public.allowed_presets=%v", cfg.Public.AllowedPresets)
+		}
+		log.Info("config: public.max_download_bytes=%d", cfg.Public.MaxDownloadBytes)
+		log.Info("config: public.rate_limit_per_min=%d", cfg.Public.RateLimitPerMin)
+		log.Info("config: public.rate_limit_burst=%d", cfg.Public.RateLimitBurst)
+		log.Info("config: public.trust_forwarded_for=%v", cfg.Public.TrustForwardedFor)
+		if cfg.Public.Watermark != "" {
+			log.Info("config: public.watermark=%s", cfg.Public.Watermark)
+		}
+	}
+	if cfg.RateLimit.Enabled {
+		for _, class := range constants.RateLimitClasses {
+			rule := cfg.RateLimit.Rule(class)
+			log.Info("config: rate_limit.%s per_min=%d burst=%d", class, rule.PerMin, rule.Burst)
+		}
+		log.Info("config: rate_limit.trust_forwarded_for=%v", cfg.RateLimit.TrustForwardedFor)
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Watermarks)) {
+		wm := cfg.Watermarks[name]
+		source := "text"
+		if wm.Image != "" {
+			source = "image " + wm.Image
+		}
+		log.Info("config: watermarks.%s=%s position=%s opacity=%.2f scale=%.2f", name, source, wm.Position, wm.Opacity, wm.Scale)
+	}
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicCollation)) {
+		c := cfg.TopicCollation[topic]
+		log.Info("config: topic_collation.%s locale=%q fold_case=%v fold_diacritics=%v", topic, c.Locale, c.FoldCase, c.FoldDiacritics)
+	}
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicRetention)) {
+		r := cfg.TopicRetention[topic]
+		log.Info("config: topic_retention.%s max_age_days=%d max_total_bytes=%d max_assets=%d action=%s dry_run=%v", topic, r.MaxAgeDays, r.MaxTotalBytes, r.MaxAssets, r.Removal(), r.DryRun)
+	}
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicQuotas)) {
+		q := cfg.TopicQuotas[topic]
+		log.Info("config: topic_quotas.%s soft_bytes=%d hard_bytes=%d soft_assets=%d hard_assets=%d", topic, q.SoftBytes, q.HardBytes, q.SoftAssets, q.HardAssets)
+	}
+	if len(cfg.FrozenTopics) > 0 {
+		log.Info("config: frozen_topics=%v", cfg.FrozenTopics)
+	}
+	for _, ext := range slices.Sorted(maps.Keys(cfg.StoragePolicies)) {
+		p := cfg.StoragePolicy(ext)
+		log.Info("config: storage_policies.%s compression=%v preview=%v scan=%v max_size_bytes=%d", ext, p.Compression, p.Preview, p.Scan, p.MaxSizeBytes)
+	}
+	for _, dir := range slices.Sorted(maps.Keys(cfg.StorageIO)) {
+		log.Info("config: storage_io.%s direct_io=%v", dir, cfg.StorageIO[dir].DirectIO)
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.BlobStores)) {
+		store := cfg.BlobStores[name]
+		if store.StoreType() != constants.PluginBlobStoreS3 {
+			log.Info("config: blob_stores.%s type=%s options=%v", name, store.StoreType(), slices.Sorted(maps.Keys(store.Options)))
+			continue
+		}
+		log.Info("config: blob_stores.%s endpoint=%s bucket=%s region=%s prefix=%q", name, store.Endpoint, store.Bucket, store.Region, store.Prefix)
+	}
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicBlobStores)) {
+		log.Info("config: topic_blob_stores.%s=%s", topic, cfg.TopicBlobStores[topic])
+	}
+	if cfg.Scan.Enabled() {
+		log.Info("config: scan.type=%s command=%v timeout_secs=%d quarantine_infected=%v", cfg.Scan.ScannerType(), cfg.Scan.Command, cfg.Scan.TimeoutSecs, cfg.Scan.QuarantineInfected)
+	}
+	for _, ext := range slices.Sorted(maps.Keys(cfg.Validation.Validators)) {
+		v := cfg.Validation.Validators[ext]
+		log.Info("config: validation.validators.%s type=%s options=%v", ext, v.Type, slices.Sorted(maps.Keys(v.Options)))
+	}
+	if len(cfg.Validation.Validators) > 0 {
+		log.Info("config: validation mode=%s topic_modes=%v timeout_secs=%d", cfg.Validation.ModeFor(""), cfg.Validation.TopicModes, cfg.Validation.TimeoutSecs)
+	}
+	for i, p := range cfg.AuthProviders {
+		log.Info("config: auth_providers[%d] type=%s options=%v", i, p.Type, slices.Sorted(maps.Keys(p.Options)))
+	}
+	for i, p := range cfg.Notifiers {
+		log.Info("config: notifiers[%d] type=%s options=%v", i, p.Type, slices.Sorted(maps.Keys(p.Options)))
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.WatchFolders)) {
+		f := cfg.WatchFolders[name]
+		log.Info("config: watch_folders.%s path=%s topic=%s after_ingest=%s settle=%s paused=%v", name, f.Path, f.Topic, f.AfterIngestMode(), f.Settle(), f.Paused)
+	}
+	log.Info("config: notifications.digest_interval_mins=%d", cfg.Notifications.DigestIntervalMins)
+	log.Info("config: notifications.webhook_timeout_secs=%d", cfg.Notifications.WebhookTimeoutSecs)
+	log.Info("config: notifications.retention_days=%d", cfg.Notifications.RetentionDays)
+	if cfg.Notifications.EmailEnabled() {
+		log.Info("config: notifications.smtp=%s:%d from=%s", cfg.Notifications.SMTP.Host, cfg.Notifications.SMTP.Port, cfg.Notifications.SMTP.From)
+	} else {
+		log.Info("config: notifications.smtp=disabled")
+	}
 	if cfg.MaxDiskUsage > 0 {
 		log.Info("config: max_disk_usage=%d", cfg.MaxDiskUsage)
 	} else {
@@ -208,7 +1877,18 @@ func (cfg *Config) LogEffectiveValues(log *logger.Logger) {
 	}
 }
 
+// GetConfigDir returns the config directory: the one named by the
+// SILOBANG_CONFIG_DIR environment variable, which installed services set,
+// or the per-user directory.
 func GetConfigDir() string {
+	if dir := os.Getenv(constants.ServiceConfigDirEnv); dir != "" {
+		return dir
+	}
+	return UserConfigDir()
+}
+
+// UserConfigDir returns the per-user config directory under the home directory.
+func UserConfigDir() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return ""
@@ -216,6 +1896,38 @@ func GetConfigDir() string {
 	return filepath.Join(home, constants.ConfigDir)
 }
 
+// SystemConfigDir returns the platform's system-wide config directory:
+// %ProgramData%\SiloBang on Windows, /etc/silobang elsewhere.
+func SystemConfigDir() string {
+	if runtime.GOOS == "windows" {
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return filepath.Join(programData, constants.ServiceWindowsConfigDir)
+	}
+	return constants.ServiceSystemConfigDir
+}
+
+// DiscoverConfigDir picks the config directory a system service should
+// use: the SILOBANG_CONFIG_DIR override, then the first of the system-wide
+// and per-user directories that already holds a config file, and the
+// system-wide directory when neither does.
+func DiscoverConfigDir() string {
+	if dir := os.Getenv(constants.ServiceConfigDirEnv); dir != "" {
+		return dir
+	}
+	for _, dir := range []string{SystemConfigDir(), UserConfigDir()} {
+		if dir == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, constants.ConfigFile)); err == nil {
+			return dir
+		}
+	}
+	return SystemConfigDir()
+}
+
 func GetConfigPath() string {
 	return filepath.Join(GetConfigDir(), constants.ConfigFile)
 }
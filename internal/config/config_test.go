@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -49,6 +50,12 @@ func TestApplyDefaults_AllFieldsPopulated(t *testing.T) {
 	if cfg.Auth.SessionMaxDurationHours != int(constants.AuthSessionMaxDuration.Hours()) {
 		t.Errorf("Auth.SessionMaxDurationHours: got %d, want %d", cfg.Auth.SessionMaxDurationHours, int(constants.AuthSessionMaxDuration.Hours()))
 	}
+	if cfg.Auth.APIKeyTTLDays != 0 {
+		t.Errorf("Auth.APIKeyTTLDays: got %d, want 0 (never expire)", cfg.Auth.APIKeyTTLDays)
+	}
+	if cfg.Auth.APIKeyRotationGraceHours != int(constants.AuthAPIKeyRotationGrace.Hours()) {
+		t.Errorf("Auth.APIKeyRotationGraceHours: got %d, want %d", cfg.Auth.APIKeyRotationGraceHours, int(constants.AuthAPIKeyRotationGrace.Hours()))
+	}
 
 	// Bulk download
 	if cfg.BulkDownload.SessionTTLMins != constants.BulkDownloadSessionTTLMins {
@@ -80,6 +87,66 @@ func TestApplyDefaults_AllFieldsPopulated(t *testing.T) {
 	if cfg.Monitoring.LogFileMaxReadBytes != constants.MonitoringLogFileMaxReadBytes {
 		t.Errorf("Monitoring.LogFileMaxReadBytes: got %d, want %d", cfg.Monitoring.LogFileMaxReadBytes, constants.MonitoringLogFileMaxReadBytes)
 	}
+
+	// Notifications
+	if cfg.Notifications.DigestIntervalMins != constants.NotificationDefaultDigestIntervalMins {
+		t.Errorf("Notifications.DigestIntervalMins: got %d, want %d", cfg.Notifications.DigestIntervalMins, constants.NotificationDefaultDigestIntervalMins)
+	}
+	if cfg.Notifications.WebhookTimeoutSecs != constants.NotificationDefaultWebhookTimeoutSecs {
+		t.Errorf("Notifications.WebhookTimeoutSecs: got %d, want %d", cfg.Notifications.WebhookTimeoutSecs, constants.NotificationDefaultWebhookTimeoutSecs)
+	}
+	if cfg.Notifications.RetentionDays != constants.NotificationDefaultRetentionDays {
+		t.Errorf("Notifications.RetentionDays: got %d, want %d", cfg.Notifications.RetentionDays, constants.NotificationDefaultRetentionDays)
+	}
+	if cfg.Notifications.EmailEnabled() {
+		t.Error("Notifications email should be disabled by default")
+	}
+
+	// Federation
+	if cfg.Federation.InstanceName != constants.FederationDefaultInstanceName {
+		t.Errorf("Federation.InstanceName: got %q, want %q", cfg.Federation.InstanceName, constants.FederationDefaultInstanceName)
+	}
+	if cfg.Federation.TimeoutSecs != constants.FederationDefaultTimeoutSecs {
+		t.Errorf("Federation.TimeoutSecs: got %d, want %d", cfg.Federation.TimeoutSecs, constants.FederationDefaultTimeoutSecs)
+	}
+	if cfg.Federation.Enabled() {
+		t.Error("Federation should be disabled by default")
+	}
+
+	// Origin cache
+	if cfg.Origin.Topic != constants.OriginDefaultTopic || cfg.Origin.Eviction != constants.OriginEvictionLRU {
+		t.Errorf("Origin: got topic %q eviction %q", cfg.Origin.Topic, cfg.Origin.Eviction)
+	}
+	if cfg.Origin.TimeoutSecs != constants.OriginDefaultTimeoutSecs {
+		t.Errorf("Origin.TimeoutSecs: got %d, want %d", cfg.Origin.TimeoutSecs, constants.OriginDefaultTimeoutSecs)
+	}
+	if cfg.Origin.Enabled() {
+		t.Error("Origin cache should be disabled by default")
+	}
+
+	// Query
+	if cfg.Query.MaxRows != constants.QueryDefaultMaxRows {
+		t.Errorf("Query.MaxRows: got %d, want %d", cfg.Query.MaxRows, constants.QueryDefaultMaxRows)
+	}
+	if cfg.Query.FederatedMaxRows != constants.FederatedQueryMaxRows {
+		t.Errorf("Query.FederatedMaxRows: got %d, want %d", cfg.Query.FederatedMaxRows, constants.FederatedQueryMaxRows)
+	}
+
+	// Idempotency
+	if cfg.Idempotency.TTLHours != constants.IdempotencyDefaultTTLHours {
+		t.Errorf("Idempotency.TTLHours: got %d, want %d", cfg.Idempotency.TTLHours, constants.IdempotencyDefaultTTLHours)
+	}
+	if cfg.Idempotency.MaxResponseBytes != constants.IdempotencyDefaultMaxResponseBytes {
+		t.Errorf("Idempotency.MaxResponseBytes: got %d, want %d", cfg.Idempotency.MaxResponseBytes, constants.IdempotencyDefaultMaxResponseBytes)
+	}
+
+	// Export inbox
+	if cfg.Exports.RetentionDays != constants.ExportDefaultRetentionDays {
+		t.Errorf("Exports.RetentionDays: got %d, want %d", cfg.Exports.RetentionDays, constants.ExportDefaultRetentionDays)
+	}
+	if cfg.Exports.MaxInboxBytes != constants.ExportDefaultMaxInboxBytes {
+		t.Errorf("Exports.MaxInboxBytes: got %d, want %d", cfg.Exports.MaxInboxBytes, constants.ExportDefaultMaxInboxBytes)
+	}
 }
 
 func TestApplyDefaults_PreservesCustomValues(t *testing.T) {
@@ -169,6 +236,19 @@ func TestValidate_InvalidAuth(t *testing.T) {
 			},
 			"session_max_duration_hours must be >= auth.session_duration_hours",
 		},
+		{
+			"APIKeyTTLDays_beyond_max",
+			func(c *Config) {
+				c.Auth.APIKeyTTLDays = 90
+				c.Auth.APIKeyMaxTTLDays = 30
+			},
+			"api_key_ttl_days must be between 1 and auth.api_key_max_ttl_days",
+		},
+		{
+			"APIKeyRotationGraceHours_too_long",
+			func(c *Config) { c.Auth.APIKeyRotationGraceHours = 24 * 365 },
+			"api_key_rotation_grace_hours must be between 0 and",
+		},
 	}
 
 	for _, tt := range tests {
@@ -305,6 +385,795 @@ func TestValidate_InvalidMonitoring(t *testing.T) {
 	}
 }
 
+func TestValidate_InvalidNotifications(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.Notifications.SMTP.Host = "mail.example.com"
+	cfg.ApplyDefaults()
+
+	if cfg.Notifications.SMTP.Port != constants.NotificationDefaultSMTPPort {
+		t.Errorf("SMTP.Port: got %d, want %d", cfg.Notifications.SMTP.Port, constants.NotificationDefaultSMTPPort)
+	}
+
+	err := cfg.validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	if !strings.Contains(err.Error(), "notifications.smtp.from is required") {
+		t.Errorf("expected smtp.from error, got: %v", err)
+	}
+}
+
+func TestValidate_InvalidFederation(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.Federation.Peers = []FederationPeer{
+		{Name: "berlin", URL: "https://berlin.example.com", APIKey: "key"},
+		{Name: "berlin", URL: "ftp://madrid.example.com"},
+		{Name: constants.FederationDefaultInstanceName, URL: "https://local.example.com", APIKey: "key"},
+	}
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{"federation.peers[1].name", "federation.peers[1].url", "federation.peers[1].api_key", "federation.peers[2].name"} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+	if fields["federation.peers[0].name"] || fields["federation.peers[0].url"] {
+		t.Errorf("valid peer reported as invalid: %v", fields)
+	}
+}
+
+func TestValidate_InvalidOIDC(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.OIDC = OIDCConfig{Issuer: "login.example.com", RedirectURL: "/api/auth/oidc/callback", AllowedDomains: []string{"@example.com"}}
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{"oidc.issuer", "oidc.client_id", "oidc.redirect_url", "oidc.allowed_domains[0]"} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+
+	cfg.OIDC = OIDCConfig{Issuer: "https://login.example.com/realms/corp", ClientID: "silobang", RedirectURL: "https://silo.example.com/api/auth/oidc/callback"}
+	if err := cfg.validate(); err != nil {
+		t.Errorf("valid oidc reported as invalid: %v", err)
+	}
+	if !cfg.OIDC.Provisions() {
+		t.Error("expected auto_provision to default to true")
+	}
+}
+
+func TestValidate_InvalidFeatures(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.Features.Disabled = []string{constants.FeatureSearch, "thumbnails"}
+
+	errs := cfg.FieldErrors()
+	if len(errs) != 1 || errs[0].Field != "features.disabled[1]" {
+		t.Fatalf("expected one error for features.disabled[1], got %v", errs)
+	}
+
+	cfg.Features.Disabled = []string{constants.FeatureSearch, constants.FeaturePreviews}
+	if err := cfg.validate(); err != nil {
+		t.Errorf("valid features reported as invalid: %v", err)
+	}
+	if cfg.Features.Enabled(constants.FeatureSearch) || !cfg.Features.Enabled(constants.FeaturePrompts) {
+		t.Errorf("unexpected feature flags: %v", cfg.Features.Disabled)
+	}
+}
+
+func TestValidate_InvalidRateLimit(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	if cfg.RateLimit.Queries.PerMin != constants.RateLimitDefaultQueriesPerMin {
+		t.Errorf("expected default queries rate %d, got %d", constants.RateLimitDefaultQueriesPerMin, cfg.RateLimit.Queries.PerMin)
+	}
+
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.Uploads.Burst = 0
+	cfg.RateLimit.Downloads.PerMin = -5
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, f := range []string{"rate_limit.uploads.burst", "rate_limit.downloads.per_min"} {
+		if !fields[f] {
+			t.Errorf("expected an error for %s, got %v", f, fields)
+		}
+	}
+	if len(fields) != 2 {
+		t.Errorf("expected 2 field errors, got %v", fields)
+	}
+}
+
+func TestValidate_InvalidOrigin(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.Origin = OriginConfig{URL: "ftp://hq.example.com", Topic: "Not A Topic", MaxCacheBytes: 1024, Eviction: "fifo"}
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{"origin.url", "origin.api_key", "origin.topic", "origin.max_cache_bytes", "origin.eviction", "origin.timeout_secs"} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+
+	cfg.Origin = OriginConfig{URL: "https://hq.example.com:2369", APIKey: "key", Topic: "from-hq", Eviction: constants.OriginEvictionLFU, TimeoutSecs: 60}
+	if err := cfg.validate(); err != nil {
+		t.Errorf("valid origin reported as invalid: %v", err)
+	}
+}
+
+func TestValidate_InvalidIdempotency(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.Idempotency.TTLHours = -1
+	cfg.Idempotency.MaxResponseBytes = 100
+
+	err := cfg.validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"idempotency.ttl_hours", "idempotency.max_response_bytes"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %s error, got: %v", want, err)
+		}
+	}
+}
+
+func TestValidate_InvalidExports(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.Exports.RetentionDays = -1
+	cfg.Exports.MaxInboxBytes = 1024
+
+	err := cfg.validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"exports.retention_days", "exports.max_inbox_bytes"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %s error, got: %v", want, err)
+		}
+	}
+}
+
+func TestValidate_InvalidDeletionRequests(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	if cfg.DeletionRequests.ApprovalWindowHours != constants.DeletionRequestDefaultWindowHours {
+		t.Errorf("expected default window %d, got %d", constants.DeletionRequestDefaultWindowHours, cfg.DeletionRequests.ApprovalWindowHours)
+	}
+
+	cfg.DeletionRequests.ApprovalWindowHours = -1
+	errs := cfg.FieldErrors()
+	if len(errs) != 1 || errs[0].Field != "deletion_requests.approval_window_hours" {
+		t.Errorf("expected one error for deletion_requests.approval_window_hours, got %v", errs)
+	}
+}
+
+func TestValidate_InvalidTopicArchive(t *testing.T) {
+	cfg := &Config{}
+	cfg.Archives = map[string]PluginConfig{
+		"tape":    {Type: constants.PluginArchiveFS, Options: map[string]string{"path": "/mnt/tape-drop"}},
+		"glacier": {Type: constants.PluginArchiveS3, Options: map[string]string{"bucket": "cold"}},
+	}
+	cfg.TopicArchive = map[string]ArchivePolicy{
+		"photos":    {Archive: "tape"},
+		"Bad Topic": {Archive: "tape"},
+		"renders":   {Archive: "vault"},
+		"models":    {Archive: "tape", IdleDays: -1},
+	}
+	cfg.ApplyDefaults()
+	if days := cfg.TopicArchive["photos"].IdleDays; days != constants.ArchiveDefaultIdleDays {
+		t.Errorf("expected default idle_days %d, got %d", constants.ArchiveDefaultIdleDays, days)
+	}
+
+	got := map[string]bool{}
+	for _, e := range cfg.FieldErrors() {
+		got[e.Field] = true
+	}
+	want := []string{"archives.glacier", "topic_archive.Bad Topic", "topic_archive.renders.archive", "topic_archive.models.idle_days"}
+	if len(got) != len(want) {
+		t.Errorf("expected errors for %v, got %v", want, got)
+	}
+	for _, field := range want {
+		if !got[field] {
+			t.Errorf("expected an error for %s, got %v", field, got)
+		}
+	}
+}
+
+func TestValidate_InvalidTrash(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	if cfg.Trash.RetentionDays != constants.TrashDefaultRetentionDays {
+		t.Errorf("expected default retention %d, got %d", constants.TrashDefaultRetentionDays, cfg.Trash.RetentionDays)
+	}
+
+	cfg.Trash.RetentionDays = -1
+	errs := cfg.FieldErrors()
+	if len(errs) != 1 || errs[0].Field != "trash.retention_days" {
+		t.Errorf("expected one error for trash.retention_days, got %v", errs)
+	}
+}
+
+func TestValidate_InvalidTopicRetention(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.TopicRetention = map[string]RetentionConfig{
+		"photos":    {MaxAssets: 100, DryRun: true},
+		"Bad Topic": {MaxAgeDays: 30},
+		"renders":   {},
+		"models":    {MaxTotalBytes: 1 << 30, Action: "archive"},
+	}
+
+	got := map[string]bool{}
+	for _, e := range cfg.FieldErrors() {
+		got[e.Field] = true
+	}
+	want := []string{"topic_retention.Bad Topic", "topic_retention.renders", "topic_retention.models.action"}
+	if len(got) != len(want) {
+		t.Errorf("expected errors for %v, got %v", want, got)
+	}
+	for _, field := range want {
+		if !got[field] {
+			t.Errorf("expected an error for %s, got %v", field, got)
+		}
+	}
+	if removal := cfg.TopicRetention["photos"].Removal(); removal != constants.RetentionActionTrash {
+		t.Errorf("expected the trash action by default, got %s", removal)
+	}
+}
+
+func TestValidate_InvalidTopicQuotas(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.TopicQuotas = map[string]TopicQuotaConfig{
+		"photos":    {SoftBytes: 1 << 20, HardBytes: 1 << 30, HardAssets: 100},
+		"Bad Topic": {HardAssets: 10},
+		"renders":   {HardBytes: -1},
+		"models":    {SoftBytes: 2048, HardBytes: 1024, SoftAssets: 5, HardAssets: 4},
+	}
+
+	got := map[string]bool{}
+	for _, e := range cfg.FieldErrors() {
+		got[e.Field] = true
+	}
+	want := []string{"topic_quotas.Bad Topic", "topic_quotas.renders", "topic_quotas.models.soft_bytes", "topic_quotas.models.soft_assets"}
+	if len(got) != len(want) {
+		t.Errorf("expected errors for %v, got %v", want, got)
+	}
+	for _, field := range want {
+		if !got[field] {
+			t.Errorf("expected an error for %s, got %v", field, got)
+		}
+	}
+}
+
+func TestValidate_InvalidFrozenTopics(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.FrozenTopics = []string{"photos", "Bad Topic", "photos"}
+
+	errs := cfg.FieldErrors()
+	if len(errs) != 2 || errs[0].Field != "frozen_topics" || errs[1].Field != "frozen_topics" {
+		t.Errorf("expected two errors for frozen_topics, got %v", errs)
+	}
+	if !cfg.IsTopicFrozen("photos") || cfg.IsTopicFrozen("renders") {
+		t.Errorf("unexpected frozen state for %v", cfg.FrozenTopics)
+	}
+}
+
+func TestValidate_InvalidAssetCache(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.AssetCache.MaxBytes = 1024
+	cfg.AssetCache.MaxAssetBytes = 4096
+
+	err := cfg.validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	if !strings.Contains(err.Error(), "asset_cache.max_asset_bytes") {
+		t.Errorf("expected asset_cache.max_asset_bytes error, got: %v", err)
+	}
+}
+
+func TestValidate_InvalidHTTP(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.HTTP.TLSCertFile = "/etc/silobang/cert.pem"
+	cfg.HTTP.TLSSelfSigned = true
+	cfg.HTTP.MaxSSEPerClient = cfg.HTTP.MaxSSEConnections + 1
+
+	err := cfg.validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, field := range []string{"http.tls_cert_file", "http.tls_self_signed", "http.max_sse_per_client"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected %s error, got: %v", field, err)
+		}
+	}
+
+	plain := &Config{}
+	plain.ApplyDefaults()
+	plain.HTTP.RedirectHTTPPort = 8080
+	if err := plain.validate(); err == nil || !strings.Contains(err.Error(), "requires TLS") {
+		t.Errorf("expected a redirect without TLS to be rejected, got: %v", err)
+	}
+	plain.HTTP.TLSSelfSigned = true
+	plain.HTTP.RedirectHTTPPort = constants.DefaultPort
+	if err := plain.validate(); err == nil || !strings.Contains(err.Error(), "must differ from port") {
+		t.Errorf("expected a redirect on the server port to be rejected, got: %v", err)
+	}
+}
+
+func TestValidate_S3(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	if cfg.S3.Region != constants.S3DefaultRegion {
+		t.Errorf("expected default region %s, got %s", constants.S3DefaultRegion, cfg.S3.Region)
+	}
+
+	cfg.S3.Enabled = true
+	cfg.S3.Port = 9000
+	if err := cfg.validate(); err != nil {
+		t.Errorf("expected a dedicated port to be valid, got: %v", err)
+	}
+	for _, port := range []int{constants.DefaultPort, 70000} {
+		cfg.S3.Port = port
+		if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "s3.port") {
+			t.Errorf("expected s3.port %d to be rejected, got: %v", port, err)
+		}
+	}
+}
+
+func TestValidate_InvalidAuditQueue(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.Audit.QueueSize = -1
+	cfg.Audit.OverflowPolicy = "drop_newest"
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, field := range []string{"audit.queue_size", "audit.overflow_policy"} {
+		if !fields[field] {
+			t.Errorf("expected error for %s, got %v", field, cfg.FieldErrors())
+		}
+	}
+}
+
+func TestValidate_LimitCeilings(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.Query.MaxRows = constants.QueryMaxRowsCeiling + 1
+	cfg.Query.FederatedMaxRows = -1
+	cfg.Batch.MaxOperations = constants.BatchMaxOperationsCeiling + 1
+	cfg.Metadata.MaxValueBytes = constants.MetadataMaxValueBytesCeiling + 1
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{"query.max_rows", "query.federated_max_rows", "batch.max_operations", "metadata.max_value_bytes"} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+}
+
+func TestValidate_InvalidAuditRetention(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.Audit.DefaultRetentionDays = -1
+	cfg.Audit.RetentionDays = map[string]int{
+		constants.AuditActionLoginSuccess: 730,
+		constants.AuditActionDownloaded:   0,
+		"not_an_action":                   90,
+	}
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{"audit.default_retention_days", "audit.retention_days.downloaded", "audit.retention_days.not_an_action"} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+	if fields["audit.retention_days.login_success"] {
+		t.Errorf("valid override reported as invalid: %v", fields)
+	}
+}
+
+func TestValidate_InvalidWatermarks(t *testing.T) {
+	cfg := &Config{}
+	cfg.Watermarks = map[string]WatermarkConfig{
+		"review": {Text: "REVIEW COPY"},
+		"both":   {Text: "x", Image: "logo.png"},
+		"bad":    {Text: "x", Position: "middle", Opacity: 1.5, Scale: -1},
+		"logo":   {Image: filepath.Join(t.TempDir(), "missing.png")},
+	}
+	cfg.Public.Watermark = "unknown"
+	cfg.ApplyDefaults()
+
+	review := cfg.Watermarks["review"]
+	if review.Position != constants.WatermarkDefaultPosition || review.Opacity != constants.WatermarkDefaultOpacity ||
+		review.Scale != constants.WatermarkDefaultScale {
+		t.Errorf("defaults not applied: %+v", review)
+	}
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{
+		"public.watermark", "watermarks.both", "watermarks.bad.position",
+		"watermarks.bad.opacity", "watermarks.bad.scale", "watermarks.logo.image",
+	} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+	for field := range fields {
+		if strings.HasPrefix(field, "watermarks.review") {
+			t.Errorf("valid profile reported as invalid: %v", fields)
+		}
+	}
+}
+
+func TestValidate_InvalidStoragePolicies(t *testing.T) {
+	on := true
+	cfg := &Config{}
+	cfg.StoragePolicies = map[string]StoragePolicyConfig{
+		"png":  {Preview: &on},
+		".Bad": {},
+		"bin":  {MaxSizeBytes: constants.DefaultMaxDatSize},
+		"exe":  {Scan: &on},
+		"*":    {MaxSizeBytes: 1024},
+		"obj":  {MaxSizeBytes: -1},
+	}
+	cfg.ApplyDefaults()
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{
+		"storage_policies..Bad", "storage_policies.bin.max_size_bytes",
+		"storage_policies.exe.scan", "storage_policies.obj.max_size_bytes",
+	} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+	for field := range fields {
+		if strings.HasPrefix(field, "storage_policies.png") || strings.HasPrefix(field, "storage_policies.*") {
+			t.Errorf("valid policy reported as invalid: %v", fields)
+		}
+	}
+
+	// A scanner makes scan policies valid
+	cfg.Scan.Command = []string{"clamdscan", "-"}
+	for _, fe := range cfg.FieldErrors() {
+		if fe.Field == "storage_policies.exe.scan" {
+			t.Errorf("scan policy should be valid with a scanner: %s", fe.Message)
+		}
+	}
+}
+
+func TestStoragePolicy_Resolution(t *testing.T) {
+	on, off := true, false
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	limit := cfg.MaxDatSize - int64(constants.HeaderSize)
+
+	def := cfg.StoragePolicy("png")
+	if def.Compression != constants.StoragePolicyDefaultCompression || def.Preview != constants.StoragePolicyDefaultPreview ||
+		def.Scan != constants.StoragePolicyDefaultScan || def.MaxSizeBytes != limit {
+		t.Errorf("unexpected defaults: %+v", def)
+	}
+
+	cfg.StoragePolicies = map[string]StoragePolicyConfig{
+		"*":   {Compression: &on, MaxSizeBytes: 1 << 20},
+		"png": {Compression: &off, Preview: &off},
+		"bin": {MaxSizeBytes: cfg.MaxDatSize * 2},
+	}
+
+	txt := cfg.StoragePolicy("TXT")
+	if txt.Extension != "txt" || !txt.Compression || !txt.Preview || txt.MaxSizeBytes != 1<<20 {
+		t.Errorf("expected the \"*\" policy for txt, got %+v", txt)
+	}
+	png := cfg.StoragePolicy("png")
+	if png.Compression || png.Preview || png.MaxSizeBytes != 1<<20 {
+		t.Errorf("expected png to override \"*\" field by field, got %+v", png)
+	}
+	if bin := cfg.StoragePolicy("bin"); bin.MaxSizeBytes != limit {
+		t.Errorf("expected max size capped at %d, got %d", limit, bin.MaxSizeBytes)
+	}
+}
+
+func TestStorageIO_DirectIOPerWorkingDirectory(t *testing.T) {
+	cfg := &Config{}
+	cfg.StorageIO = map[string]StorageIOConfig{
+		"/mnt/nvmeof/silobang/": {DirectIO: true},
+		"/srv/silobang":         {},
+		"relative/dir":          {DirectIO: true},
+	}
+	cfg.ApplyDefaults()
+
+	if cfg.DirectIO() {
+		t.Error("expected direct I/O off without a working directory")
+	}
+	cfg.WorkingDirectory = "/mnt/nvmeof/silobang"
+	if !cfg.DirectIO() {
+		t.Error("expected direct I/O on for the listed working directory")
+	}
+	cfg.WorkingDirectory = "/srv/silobang"
+	if cfg.DirectIO() {
+		t.Error("expected direct I/O off where it is not enabled")
+	}
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	if !fields["storage_io.relative/dir"] || fields["storage_io./srv/silobang"] {
+		t.Errorf("expected only the relative path to be rejected, got %v", fields)
+	}
+}
+
+func TestValidate_BlobStores(t *testing.T) {
+	cfg := &Config{}
+	cfg.BlobStores = map[string]BlobStoreConfig{
+		"archive":  {Endpoint: "https://s3.example.com", Bucket: "silobang", Prefix: "prod/", AccessKeyID: "key", SecretAccessKey: "secret"},
+		"local":    {Endpoint: "http://minio:9000", Bucket: "b", AccessKeyID: "key", SecretAccessKey: "secret"},
+		"bad-conf": {Endpoint: "minio:9000", Prefix: "prod", AccessKeyID: "key"},
+	}
+	cfg.TopicBlobStores = map[string]string{
+		"photos": "archive",
+		"videos": "missing",
+	}
+	cfg.ApplyDefaults()
+
+	if region := cfg.BlobStores["archive"].Region; region != constants.S3DefaultRegion {
+		t.Errorf("expected region to default to %s, got %q", constants.S3DefaultRegion, region)
+	}
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{
+		"blob_stores.local",
+		"blob_stores.bad-conf",
+		"blob_stores.bad-conf.endpoint",
+		"blob_stores.bad-conf.bucket",
+		"blob_stores.bad-conf.prefix",
+		"topic_blob_stores.videos",
+	} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+	for field := range fields {
+		if strings.HasPrefix(field, "blob_stores.archive") || field == "topic_blob_stores.photos" {
+			t.Errorf("valid blob store reported as invalid: %v", fields)
+		}
+	}
+}
+
+func TestValidate_Plugins(t *testing.T) {
+	cfg := &Config{}
+	cfg.BlobStores = map[string]BlobStoreConfig{"tape": {Type: "tape-robot"}}
+	cfg.Scan = ScanConfig{Type: "no-such-scanner"}
+	cfg.AuthProviders = []PluginConfig{
+		{Type: constants.PluginAuthHeader, Options: map[string]string{"header": "X-Remote-User", "trusted_proxies": "10.0.0.0/8, ::1"}},
+		{Type: constants.PluginAuthHeader, Options: map[string]string{"header": "X-Remote-User"}},
+		{Type: "ldap"},
+	}
+	cfg.Notifiers = []PluginConfig{
+		{Type: constants.PluginNotifierWebhook, Options: map[string]string{"url": "https://chat.example.com/hook"}},
+		{Type: constants.PluginNotifierWebhook, Options: map[string]string{"url": "ftp://example.com"}},
+	}
+	cfg.ApplyDefaults()
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{"blob_stores.tape.type", "scan.type", "auth_providers[1]", "auth_providers[2]", "notifiers[1]"} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+	for _, valid := range []string{"auth_providers[0]", "notifiers[0]", "blob_stores.tape.endpoint"} {
+		if fields[valid] {
+			t.Errorf("unexpected error for %s: %v", valid, fields)
+		}
+	}
+	if !cfg.Scan.Enabled() {
+		t.Error("expected a scanner type without a command to enable scanning")
+	}
+}
+
+func TestValidate_Validation(t *testing.T) {
+	cfg := &Config{}
+	cfg.Validation = ValidationConfig{
+		Validators: map[string]PluginConfig{
+			"gltf": {Type: constants.PluginValidatorJSON, Options: map[string]string{"schema": "/etc/silobang/gltf.schema.json"}},
+			"json": {Type: constants.PluginValidatorJSON, Options: map[string]string{"schema": "schemas/asset.json"}},
+			".obj": {Type: "obj-lint"},
+		},
+		Mode:       "relaxed",
+		TopicModes: map[string]string{"drafts": constants.ValidationModeLenient, "Bad Topic": constants.ValidationModeStrict, "final": "off"},
+	}
+	cfg.ApplyDefaults()
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{"validation.validators.json", "validation.validators..obj", "validation.mode",
+		"validation.topic_modes.Bad Topic", "validation.topic_modes.final"} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+	for _, valid := range []string{"validation.validators.gltf", "validation.topic_modes.drafts", "validation.timeout_secs"} {
+		if fields[valid] {
+			t.Errorf("unexpected error for %s: %v", valid, fields)
+		}
+	}
+
+	if got := cfg.Validation.ModeFor("drafts"); got != constants.ValidationModeLenient {
+		t.Errorf("expected the topic mode, got %s", got)
+	}
+	cfg.Validation.Mode = ""
+	if got := cfg.Validation.ModeFor("other"); got != constants.ValidationModeStrict {
+		t.Errorf("expected strict by default, got %s", got)
+	}
+}
+
+func TestValidate_Scrub(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	if cfg.Scrub.IntervalHours != constants.ScrubDefaultIntervalHours || cfg.Scrub.Interval() != 168*time.Hour {
+		t.Errorf("expected the weekly default, got %d hours", cfg.Scrub.IntervalHours)
+	}
+
+	cfg.Scrub = ScrubConfig{IntervalHours: -1, MaxBytesPerSec: -5}
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{"scrub.interval_hours", "scrub.max_bytes_per_sec"} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+}
+
+func TestValidate_WatchFolders(t *testing.T) {
+	cfg := &Config{WorkingDirectory: "/srv/silobang"}
+	cfg.WatchFolders = map[string]WatchFolderConfig{
+		"scans":    {Path: "/mnt/dropbox/scans", Topic: "scans", AfterIngest: constants.WatchFolderAfterDelete, SettleSecs: 30},
+		"nested":   {Path: "/mnt/dropbox/scans/raw", Topic: "scans"},
+		"relative": {Path: "dropbox", Topic: "scans"},
+		"inside":   {Path: "/srv/silobang/drop", Topic: "scans"},
+		"parent":   {Path: "/srv", Topic: "scans"},
+		"options":  {Path: "/mnt/renders", Topic: "Renders", AfterIngest: "copy", SettleSecs: -1},
+		"Bad Name": {Path: "/mnt/other", Topic: "scans"},
+	}
+	cfg.WatchFolderRoots = []string{"/mnt/dropbox", "relative/root"}
+	cfg.ApplyDefaults()
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{
+		"watch_folders.scans.path", "watch_folders.relative.path", "watch_folders.inside.path", "watch_folders.parent.path",
+		"watch_folders.options.topic", "watch_folders.options.after_ingest", "watch_folders.options.settle_secs", "watch_folders.Bad Name",
+		"watch_folder_roots",
+	} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+	if fields["watch_folders.scans.settle_secs"] || fields["watch_folders.scans.after_ingest"] {
+		t.Errorf("unexpected errors for valid options: %v", fields)
+	}
+
+	if got := cfg.WatchFolders["nested"].Settle(); got != constants.WatchFolderDefaultSettleSecs*time.Second {
+		t.Errorf("expected the default settle time, got %s", got)
+	}
+	if got := cfg.WatchFolders["nested"].AfterIngestMode(); got != constants.WatchFolderAfterMove {
+		t.Errorf("expected files to be moved by default, got %s", got)
+	}
+}
+
+func TestValidate_InvalidTopicCollation(t *testing.T) {
+	cfg := &Config{}
+	cfg.TopicCollation = map[string]CollationConfig{
+		"photos-jp":  {Locale: "ja", FoldCase: true},
+		"Bad Topic":  {Locale: "de"},
+		"bad-locale": {Locale: "de_DE"},
+	}
+	cfg.ApplyDefaults()
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{"topic_collation.Bad Topic", "topic_collation.bad-locale.locale"} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+	for field := range fields {
+		if strings.HasPrefix(field, "topic_collation.photos-jp") {
+			t.Errorf("valid collation reported as invalid: %v", fields)
+		}
+	}
+}
+
+func TestMetadataDefaults(t *testing.T) {
+	cfg := &Config{}
+	cfg.Metadata.Defaults = map[string]string{
+		"site":  "berlin",
+		"owner": "{{user}}",
+		"stamp": "{{username}} {{topic}} {{upload_time}} {{filename}} {{extension}} {{hash}}",
+	}
+	cfg.Metadata.TopicDefaults = map[string]map[string]string{
+		"photos":    {"license": "CC-BY-4.0", "site": ""},
+		"Bad Topic": {"license": "MIT"},
+	}
+	cfg.ApplyDefaults()
+
+	photos := cfg.Metadata.DefaultsFor("photos")
+	if _, ok := photos["site"]; ok || photos["license"] != "CC-BY-4.0" || len(photos) != 3 {
+		t.Errorf("unexpected defaults for photos: %v", photos)
+	}
+	if other := cfg.Metadata.DefaultsFor("other"); other["site"] != "berlin" || len(other) != 3 {
+		t.Errorf("unexpected defaults for other topics: %v", other)
+	}
+	if cfg.Metadata.Defaults["site"] != "berlin" {
+		t.Error("DefaultsFor must not modify the global defaults")
+	}
+
+	fields := map[string]bool{}
+	for _, fe := range cfg.FieldErrors() {
+		fields[fe.Field] = true
+	}
+	for _, want := range []string{"metadata.defaults.owner", "metadata.topic_defaults.Bad Topic"} {
+		if !fields[want] {
+			t.Errorf("expected error for %s, got %v", want, fields)
+		}
+	}
+	for _, valid := range []string{"metadata.defaults.site", "metadata.defaults.stamp", "metadata.topic_defaults.photos"} {
+		if fields[valid] {
+			t.Errorf("valid default %s reported as invalid: %v", valid, fields)
+		}
+	}
+}
+
 func TestValidate_InvalidDiskUsage(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -338,6 +1207,51 @@ func TestValidate_InvalidDiskUsage(t *testing.T) {
 	}
 }
 
+func TestValidate_InvalidFetch(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*FetchConfig)
+		field  string
+	}{
+		{"ftp_scheme", func(f *FetchConfig) { f.AllowedSchemes = []string{"https", "ftp"} }, "fetch.allowed_schemes"},
+		{"zero_max_urls", func(f *FetchConfig) { f.MaxURLs = 0 }, "fetch.max_urls"},
+		{"negative_max_size", func(f *FetchConfig) { f.MaxSizeBytes = -1 }, "fetch.max_size_bytes"},
+		{"too_many_retries", func(f *FetchConfig) { n := constants.FetchMaxRetries + 1; f.Retries = &n }, "fetch.retries"},
+		{"zero_timeout", func(f *FetchConfig) { f.TimeoutSecs = 0 }, "fetch.timeout_secs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{}
+			cfg.ApplyDefaults()
+			tt.modify(&cfg.Fetch)
+
+			err := cfg.validate()
+			if err == nil || !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("expected %s error, got: %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestApplyDefaults_Fetch(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+
+	if cfg.Fetch.MaxURLs != constants.FetchDefaultMaxURLs {
+		t.Errorf("MaxURLs: got %d, want %d", cfg.Fetch.MaxURLs, constants.FetchDefaultMaxURLs)
+	}
+	if cfg.Fetch.RetryCount() != constants.FetchDefaultRetries {
+		t.Errorf("RetryCount: got %d, want %d", cfg.Fetch.RetryCount(), constants.FetchDefaultRetries)
+	}
+	if len(cfg.Fetch.AllowedSchemes) != len(constants.FetchDefaultSchemes) {
+		t.Errorf("AllowedSchemes: got %v, want %v", cfg.Fetch.AllowedSchemes, constants.FetchDefaultSchemes)
+	}
+	if err := cfg.validate(); err != nil {
+		t.Errorf("expected defaults to be valid, got: %v", err)
+	}
+}
+
 func TestApplyDefaults_MaxDiskUsage_ZeroByDefault(t *testing.T) {
 	cfg := &Config{}
 	cfg.ApplyDefaults()
@@ -370,6 +1284,28 @@ func TestValidate_MultipleErrors(t *testing.T) {
 	}
 }
 
+func TestFieldErrors_ReportsFieldNames(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	if errs := cfg.FieldErrors(); len(errs) != 0 {
+		t.Fatalf("expected no field errors for defaults, got %v", errs)
+	}
+
+	cfg.Audit.PurgePercentage = 200
+	cfg.MaxDiskUsage = 1
+
+	errs := cfg.FieldErrors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 field errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].Field != "audit.purge_percentage" {
+		t.Errorf("expected audit.purge_percentage, got %s", errs[0].Field)
+	}
+	if errs[1].Field != "max_disk_usage" {
+		t.Errorf("expected max_disk_usage, got %s", errs[1].Field)
+	}
+}
+
 // =============================================================================
 // Duration Helper Tests
 // =============================================================================
@@ -783,3 +1719,36 @@ func TestSaveConfig_RoundTrip(t *testing.T) {
 		t.Errorf("Monitoring.LogFileMaxReadBytes: got %d, want %d", loaded.Monitoring.LogFileMaxReadBytes, original.Monitoring.LogFileMaxReadBytes)
 	}
 }
+
+func TestConfigDir_EnvOverrideAndDiscovery(t *testing.T) {
+	home := setTestHome(t)
+	userDir := filepath.Join(home, constants.ConfigDir)
+
+	if GetConfigDir() != userDir {
+		t.Errorf("GetConfigDir: got %q, want %q", GetConfigDir(), userDir)
+	}
+
+	// Only a per-user config file exists
+	if _, err := os.Stat(filepath.Join(SystemConfigDir(), constants.ConfigFile)); err == nil {
+		t.Skip("a system-wide config file exists on this machine")
+	}
+	if DiscoverConfigDir() != SystemConfigDir() {
+		t.Errorf("DiscoverConfigDir without config files: got %q, want %q", DiscoverConfigDir(), SystemConfigDir())
+	}
+	if _, err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if DiscoverConfigDir() != userDir {
+		t.Errorf("DiscoverConfigDir: got %q, want %q", DiscoverConfigDir(), userDir)
+	}
+
+	override := t.TempDir()
+	t.Setenv(constants.ServiceConfigDirEnv, override)
+	if GetConfigDir() != override || DiscoverConfigDir() != override {
+		t.Errorf("expected %s to override the config directory, got %q and %q",
+			constants.ServiceConfigDirEnv, GetConfigDir(), DiscoverConfigDir())
+	}
+	if GetConfigPath() != filepath.Join(override, constants.ConfigFile) {
+		t.Errorf("GetConfigPath: got %q", GetConfigPath())
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+)
+
+// secretKeyMarkers flag config keys whose values are never written to the
+// audit log.
+var secretKeyMarkers = []string{"password", "secret", "token", "api_key", "access_key"}
+
+// Flatten returns the config as dotted YAML keys mapped to their leaf values
+// ("auth.max_login_attempts", "auth_providers[0].type"). Numbers are
+// json.Number so flattened values compare equal to decoded audit entries.
+func Flatten(cfg *Config) map[string]interface{} {
+	flat := map[string]interface{}{}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return flat
+	}
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return flat
+	}
+	// Round trip through JSON so every leaf has the type a decoded audit
+	// entry would have.
+	raw, err := json.Marshal(tree)
+	if err != nil {
+		return flat
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&tree); err != nil {
+		return flat
+	}
+	flattenInto(flat, "", tree)
+	return flat
+}
+
+func flattenInto(flat map[string]interface{}, prefix string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenInto(flat, key, child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenInto(flat, fmt.Sprintf("%s[%d]", prefix, i), child)
+		}
+	case nil:
+	default:
+		flat[prefix] = v
+	}
+}
+
+// IsSecretKey reports whether a flattened key holds a credential.
+func IsSecretKey(key string) bool {
+	last := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	for _, marker := range secretKeyMarkers {
+		if strings.Contains(last, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of a flattened config with credentials replaced.
+func Redact(flat map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(flat))
+	for key, value := range flat {
+		if IsSecretKey(key) {
+			value = constants.ConfigRedactedValue
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// Diff lists the keys that differ between two flattened configs, sorted by
+// key, with credentials redacted. A key missing on one side has a nil value
+// there.
+func Diff(before, after map[string]interface{}) []audit.ConfigFieldChange {
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	changes := []audit.ConfigFieldChange{}
+	for _, key := range keys {
+		old, hadOld := before[key]
+		value, hasNew := after[key]
+		if hadOld == hasNew && old == value {
+			continue
+		}
+		if IsSecretKey(key) {
+			if hadOld {
+				old = constants.ConfigRedactedValue
+			}
+			if hasNew {
+				value = constants.ConfigRedactedValue
+			}
+		}
+		changes = append(changes, audit.ConfigFieldChange{Key: key, Before: old, After: value})
+	}
+	return changes
+}
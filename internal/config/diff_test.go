@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestFlatten_DottedKeys(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	cfg.AuthProviders = []PluginConfig{{Type: "ldap"}}
+
+	flat := Flatten(cfg)
+	if got := flat["auth.max_login_attempts"]; got != json.Number(strconv.Itoa(constants.AuthMaxLoginAttempts)) {
+		t.Errorf("auth.max_login_attempts: got %#v", got)
+	}
+	if got := flat["auth_providers[0].type"]; got != "ldap" {
+		t.Errorf("auth_providers[0].type: got %#v", got)
+	}
+	if _, ok := flat["auth"]; ok {
+		t.Error("expected only leaf values to be flattened")
+	}
+}
+
+func TestDiff_RedactsSecrets(t *testing.T) {
+	before := Flatten(&Config{Port: 2369})
+	cfg := &Config{Port: 8080}
+	cfg.Federation.Peers = []FederationPeer{{Name: "berlin", APIKey: "peer-key"}}
+	cfg.BlobStores = map[string]BlobStoreConfig{"s3": {SecretAccessKey: "hunter2"}}
+	after := Flatten(cfg)
+
+	changes := Diff(before, after)
+	byKey := map[string]interface{}{}
+	for _, change := range changes {
+		byKey[change.Key] = change.After
+		if change.Before == "hunter2" || change.After == "hunter2" || change.After == "peer-key" {
+			t.Errorf("secret leaked in %+v", change)
+		}
+	}
+	if byKey["port"] != json.Number("8080") {
+		t.Errorf("expected port change, got %v", changes)
+	}
+	for _, key := range []string{"federation.peers[0].api_key", "blob_stores.s3.secret_access_key"} {
+		if byKey[key] != constants.ConfigRedactedValue {
+			t.Errorf("expected %s to be redacted, got %#v", key, byKey[key])
+		}
+	}
+
+	if changes := Diff(after, after); len(changes) != 0 {
+		t.Errorf("expected no changes for identical configs, got %v", changes)
+	}
+}
+
+func TestIsSecretKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"notifications.smtp.password":       true,
+		"blob_stores.s3.access_key_id":      true,
+		"scan.options.token":                true,
+		"http.tls_key_file":                 false,
+		"auth.max_login_attempts":           false,
+		"password_policy_not_nested.length": false,
+	} {
+		if got := IsSecretKey(key); got != want {
+			t.Errorf("IsSecretKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sync"
 
 	"silobang/internal/constants"
 	"silobang/internal/database"
@@ -18,15 +19,18 @@ type TopicInfo struct {
 	Error   string
 }
 
+// DiscoverTopics finds the topic folders in workingDir and checks their
+// health. Topics are probed concurrently, since verifying dat hashes reads
+// every DAT file, but are returned in directory order.
 func DiscoverTopics(workingDir string) ([]TopicInfo, error) {
 	entries, err := os.ReadDir(workingDir)
 	if err != nil {
 		return nil, err
 	}
 
-	var topics []TopicInfo
 	topicNamePattern := regexp.MustCompile(constants.TopicNameRegex)
 
+	var names []string
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
@@ -44,135 +48,246 @@ func DiscoverTopics(workingDir string) ([]TopicInfo, error) {
 			continue
 		}
 
-		topicPath := filepath.Join(workingDir, name)
-		internalPath := filepath.Join(topicPath, constants.InternalDir)
-		dbPath := filepath.Join(internalPath, name+".db")
+		names = append(names, name)
+	}
 
-		// Check if .internal directory exists
-		internalInfo, internalErr := os.Stat(internalPath)
-		if os.IsNotExist(internalErr) {
-			// No .internal directory, skip (not a topic folder)
-			continue
-		}
+	probed := make([]TopicInfo, len(names))
+	isTopic := make([]bool, len(names))
+	forEachTopic(len(names), func(i int) {
+		probed[i], isTopic[i] = probeTopic(workingDir, names[i])
+	})
 
-		if internalErr != nil {
-			topics = append(topics, TopicInfo{
-				Name:    name,
-				Path:    topicPath,
-				Healthy: false,
-				Error:   fmt.Sprintf("cannot access .internal: %v", internalErr),
-			})
-			continue
+	var topics []TopicInfo
+	for i, info := range probed {
+		if isTopic[i] {
+			topics = append(topics, info)
 		}
+	}
+	return topics, nil
+}
 
-		if !internalInfo.IsDir() {
-			topics = append(topics, TopicInfo{
-				Name:    name,
-				Path:    topicPath,
-				Healthy: false,
-				Error:   ".internal is not a directory",
-			})
-			continue
-		}
+// probeTopic checks the folder name in workingDir. It reports false when
+// the folder is not a topic at all.
+func probeTopic(workingDir, name string) (TopicInfo, bool) {
+	topicPath := filepath.Join(workingDir, name)
+	internalPath := filepath.Join(topicPath, constants.InternalDir)
+	dbPath := filepath.Join(internalPath, name+".db")
 
-		// Check if database file exists
-		_, dbErr := os.Stat(dbPath)
-		if os.IsNotExist(dbErr) {
-			topics = append(topics, TopicInfo{
-				Name:    name,
-				Path:    topicPath,
-				Healthy: false,
-				Error:   fmt.Sprintf("missing database file: %s.db", name),
-			})
-			continue
-		}
+	// Check if .internal directory exists
+	internalInfo, internalErr := os.Stat(internalPath)
+	if os.IsNotExist(internalErr) {
+		// No .internal directory, skip (not a topic folder)
+		return TopicInfo{}, false
+	}
 
-		if dbErr != nil {
-			topics = append(topics, TopicInfo{
-				Name:    name,
-				Path:    topicPath,
-				Healthy: false,
-				Error:   fmt.Sprintf("cannot access database: %v", dbErr),
-			})
-			continue
-		}
+	if internalErr != nil {
+		return TopicInfo{
+			Name:    name,
+			Path:    topicPath,
+			Healthy: false,
+			Error:   fmt.Sprintf("cannot access .internal: %v", internalErr),
+		}, true
+	}
 
-		// Verify dat hashes
-		topicDB, err := database.OpenDatabase(dbPath)
-		if err != nil {
-			topics = append(topics, TopicInfo{
-				Name:    name,
-				Path:    topicPath,
-				Healthy: false,
-				Error:   fmt.Sprintf("failed to open database: %v", err),
-			})
-			continue
-		}
+	if !internalInfo.IsDir() {
+		return TopicInfo{
+			Name:    name,
+			Path:    topicPath,
+			Healthy: false,
+			Error:   ".internal is not a directory",
+		}, true
+	}
 
-		mismatched, err := database.VerifyAllDatHashes(topicDB, topicPath)
-		topicDB.Close()
+	// A creation that never finished left a partial folder; it is not a
+	// topic, and creating the topic again removes it
+	if _, err := os.Stat(filepath.Join(internalPath, constants.TopicCreatingMarker)); err == nil {
+		return TopicInfo{}, false
+	}
 
-		if err != nil {
-			topics = append(topics, TopicInfo{
-				Name:    name,
-				Path:    topicPath,
-				Healthy: false,
-				Error:   fmt.Sprintf("failed to verify dat hashes: %v", err),
-			})
-			continue
-		}
+	// Check if database file exists
+	_, dbErr := os.Stat(dbPath)
+	if os.IsNotExist(dbErr) {
+		return TopicInfo{
+			Name:    name,
+			Path:    topicPath,
+			Healthy: false,
+			Error:   fmt.Sprintf("missing database file: %s.db", name),
+		}, true
+	}
 
-		if len(mismatched) > 0 {
-			topics = append(topics, TopicInfo{
-				Name:    name,
-				Path:    topicPath,
-				Healthy: false,
-				Error:   fmt.Sprintf("dat hash mismatch: %v", mismatched),
-			})
-			continue
-		}
+	if dbErr != nil {
+		return TopicInfo{
+			Name:    name,
+			Path:    topicPath,
+			Healthy: false,
+			Error:   fmt.Sprintf("cannot access database: %v", dbErr),
+		}, true
+	}
 
-		// Topic is healthy
-		topics = append(topics, TopicInfo{
+	// Verify dat hashes. Applying the schema first adds tables introduced
+	// since the topic was created.
+	topicDB, err := database.InitTopicDB(dbPath)
+	if err != nil {
+		return TopicInfo{
 			Name:    name,
 			Path:    topicPath,
-			Healthy: true,
-			Error:   "",
-		})
+			Healthy: false,
+			Error:   fmt.Sprintf("failed to open database: %v", err),
+		}, true
 	}
 
-	return topics, nil
+	mismatched, err := database.VerifyAllDatHashes(topicDB, topicPath)
+	topicDB.Close()
+
+	if err != nil {
+		return TopicInfo{
+			Name:    name,
+			Path:    topicPath,
+			Healthy: false,
+			Error:   fmt.Sprintf("failed to verify dat hashes: %v", err),
+		}, true
+	}
+
+	if len(mismatched) > 0 {
+		return TopicInfo{
+			Name:    name,
+			Path:    topicPath,
+			Healthy: false,
+			Error:   fmt.Sprintf("dat hash mismatch: %v", mismatched),
+		}, true
+	}
+
+	// Topic is healthy
+	return TopicInfo{
+		Name:    name,
+		Path:    topicPath,
+		Healthy: true,
+		Error:   "",
+	}, true
 }
 
 // IndexTopicToOrchestrator indexes all assets from a topic into the orchestrator database
+// and rebuilds the asset references the topic holds
 func IndexTopicToOrchestrator(topicPath string, topicName string, orchestratorDB *sql.DB) error {
+	index, err := readTopicIndex(topicPath, topicName)
+	if err != nil {
+		return err
+	}
+	return database.IndexTopic(orchestratorDB, topicName, index.assets, index.refs)
+}
+
+// TopicIndexError reports a topic that could not be indexed.
+type TopicIndexError struct {
+	Topic string
+	Err   error
+}
+
+func (e TopicIndexError) Error() string {
+	return fmt.Sprintf("topic %s: %v", e.Topic, e.Err)
+}
+
+func (e TopicIndexError) Unwrap() error {
+	return e.Err
+}
+
+// IndexTopicsToOrchestrator indexes the healthy topics into the orchestrator
+// database. Topic databases are read concurrently while a single writer
+// applies them in the order given, so when topics share an asset the first
+// one still wins. Returns one error per topic that failed, in that order.
+func IndexTopicsToOrchestrator(topics []TopicInfo, orchestratorDB *sql.DB) []TopicIndexError {
+	var healthy []TopicInfo
+	for _, t := range topics {
+		if t.Healthy {
+			healthy = append(healthy, t)
+		}
+	}
+
+	indexes := make([]*topicIndex, len(healthy))
+	readErrs := make([]error, len(healthy))
+	ready := make([]chan struct{}, len(healthy))
+	for i := range ready {
+		ready[i] = make(chan struct{})
+	}
+	go forEachTopic(len(healthy), func(i int) {
+		indexes[i], readErrs[i] = readTopicIndex(healthy[i].Path, healthy[i].Name)
+		close(ready[i])
+	})
+
+	var errs []TopicIndexError
+	for i, t := range healthy {
+		<-ready[i]
+		err := readErrs[i]
+		if err == nil {
+			err = database.IndexTopic(orchestratorDB, t.Name, indexes[i].assets, indexes[i].refs)
+		}
+		indexes[i] = nil
+		if err != nil {
+			errs = append(errs, TopicIndexError{Topic: t.Name, Err: err})
+		}
+	}
+	return errs
+}
+
+// topicIndex is what a topic contributes to the orchestrator database.
+type topicIndex struct {
+	assets []database.AssetIndexEntry
+	refs   []database.AssetReference
+}
+
+// readTopicIndex reads the assets of a topic and the references it holds.
+func readTopicIndex(topicPath string, topicName string) (*topicIndex, error) {
 	// Open topic database
 	topicDBPath := filepath.Join(topicPath, constants.InternalDir, topicName+".db")
 	topicDB, err := database.OpenDatabase(topicDBPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer topicDB.Close()
 
 	// Query all assets
 	rows, err := topicDB.Query("SELECT asset_id, blob_name FROM assets")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
-	// Index each asset (INSERT OR IGNORE for duplicates)
+	index := &topicIndex{}
 	for rows.Next() {
-		var hash, datFile string
-		if err := rows.Scan(&hash, &datFile); err != nil {
-			return err
+		var entry database.AssetIndexEntry
+		if err := rows.Scan(&entry.Hash, &entry.DatFile); err != nil {
+			return nil, err
 		}
+		index.assets = append(index.assets, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		// INSERT OR IGNORE - first topic wins
-		if err := database.InsertAssetIndexIgnore(orchestratorDB, hash, topicName, datFile); err != nil {
-			return err
-		}
+	// Collect the references this topic holds (collections, lineage)
+	index.refs, err = database.CollectTopicReferences(topicDB, topicName)
+	if err != nil {
+		return nil, err
 	}
+	return index, nil
+}
 
-	return rows.Err()
+// forEachTopic calls fn for 0..n-1 on up to constants.TopicDiscoveryWorkers
+// goroutines, taking indexes in order, and returns when all calls are done.
+func forEachTopic(n int, fn func(i int)) {
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(n, constants.TopicDiscoveryWorkers); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
 }
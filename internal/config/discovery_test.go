@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+)
+
+// createTestTopic creates a topic folder in dir holding assets with the
+// given hashes.
+func createTestTopic(t *testing.T, dir, name string, hashes ...string) {
+	t.Helper()
+	internalPath := filepath.Join(dir, name, constants.InternalDir)
+	if err := os.MkdirAll(internalPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	db, err := database.InitTopicDB(filepath.Join(internalPath, name+".db"))
+	if err != nil {
+		t.Fatalf("InitTopicDB failed: %v", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for _, hash := range hashes {
+		if err := database.InsertAsset(tx, database.Asset{AssetID: hash, BlobName: name + ".dat"}); err != nil {
+			t.Fatalf("InsertAsset failed: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscoverAndIndexTopics(t *testing.T) {
+	dir := t.TempDir()
+
+	// More topics than workers, with one asset shared by two of them
+	const n = 3 * constants.TopicDiscoveryWorkers
+	for i := 0; i < n; i++ {
+		hashes := []string{fmt.Sprintf("hash-%02d", i)}
+		if i == 3 || i == n-1 {
+			hashes = append(hashes, "shared")
+		}
+		createTestTopic(t, dir, fmt.Sprintf("topic-%02d", i), hashes...)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "notes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "broken", constants.InternalDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// An interrupted creation is not a topic
+	createTestTopic(t, dir, "partial")
+	if err := os.WriteFile(filepath.Join(dir, "partial", constants.InternalDir, constants.TopicCreatingMarker), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	topics, err := DiscoverTopics(dir)
+	if err != nil {
+		t.Fatalf("DiscoverTopics failed: %v", err)
+	}
+	if len(topics) != n+1 {
+		t.Fatalf("expected %d topics, got %d", n+1, len(topics))
+	}
+	if topics[0].Name != "broken" || topics[0].Healthy {
+		t.Errorf("expected unhealthy broken topic first, got %+v", topics[0])
+	}
+	for i, topic := range topics[1:] {
+		if want := fmt.Sprintf("topic-%02d", i); topic.Name != want || !topic.Healthy {
+			t.Errorf("topic %d: expected healthy %s, got %+v", i, want, topic)
+		}
+	}
+
+	orchDB, err := database.InitOrchestratorDB(filepath.Join(t.TempDir(), "orchestrator.db"))
+	if err != nil {
+		t.Fatalf("InitOrchestratorDB failed: %v", err)
+	}
+	defer orchDB.Close()
+
+	// A topic whose database cannot be read is reported, the rest indexed
+	missing := TopicInfo{Name: "missing", Path: filepath.Join(t.TempDir(), "missing"), Healthy: true}
+	errs := IndexTopicsToOrchestrator(append(topics, missing), orchDB)
+	if len(errs) != 1 || errs[0].Topic != "missing" {
+		t.Fatalf("expected only the missing topic to fail, got %v", errs)
+	}
+
+	for i := 0; i < n; i++ {
+		exists, topic, _, err := database.CheckHashExists(orchDB, fmt.Sprintf("hash-%02d", i))
+		if err != nil || !exists || topic != fmt.Sprintf("topic-%02d", i) {
+			t.Errorf("hash-%02d: expected indexed under topic-%02d, got %v %q %v", i, i, exists, topic, err)
+		}
+	}
+	if _, topic, _, _ := database.CheckHashExists(orchDB, "shared"); topic != "topic-03" {
+		t.Errorf("expected the first topic to own the shared asset, got %q", topic)
+	}
+}
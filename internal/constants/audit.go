@@ -1,5 +1,7 @@
 package constants
 
+import "time"
+
 // Audit Log Action Types
 const (
 	AuditActionConnected             = "connected"
@@ -9,7 +11,9 @@ const (
 	AuditActionVerified              = "verified"
 	AuditActionDownloaded            = "downloaded"
 	AuditActionDownloadedBulk        = "downloaded_bulk"
+	AuditActionDownloadCancelled     = "download_cancelled"
 	AuditActionReconcileTopicRemoved = "reconcile_topic_removed"
+	AuditActionSyncDiff              = "sync_diff"
 )
 
 // Audit Log Action Types — Authentication
@@ -19,6 +23,13 @@ const (
 	AuditActionLogout       = "logout"
 )
 
+// Audit Log Action Types — Admin Recovery (break-glass)
+const (
+	AuditActionAdminRecoveryIssued = "admin_recovery_issued"
+	AuditActionAdminRecovered      = "admin_recovered"
+	AuditActionAdminRecoveryFailed = "admin_recovery_failed"
+)
+
 // Audit Log Action Types — User Management
 const (
 	AuditActionUserCreated       = "user_created"
@@ -26,18 +37,36 @@ const (
 	AuditActionAPIKeyRegenerated = "api_key_regenerated"
 )
 
+// Audit Log Action Types — Service Accounts
+const (
+	AuditActionServiceAccountCreated  = "service_account_created"
+	AuditActionServiceAccountRotated  = "service_account_token_rotated"
+	AuditActionServiceAccountDisabled = "service_account_disabled"
+)
+
 // Audit Log Action Types — Grant Management
 const (
 	AuditActionGrantCreated = "grant_created"
 	AuditActionGrantUpdated = "grant_updated"
 	AuditActionGrantRevoked = "grant_revoked"
+	AuditActionGrantBatch   = "grant_batch"
+	AuditActionAuthImported = "auth_imported"
 )
 
 // Audit Log Action Types — Metadata
 const (
-	AuditActionMetadataSet   = "metadata_set"
-	AuditActionMetadataBatch = "metadata_batch"
-	AuditActionMetadataApply = "metadata_apply"
+	AuditActionMetadataSet    = "metadata_set"
+	AuditActionMetadataBatch  = "metadata_batch"
+	AuditActionMetadataApply  = "metadata_apply"
+	AuditActionMetadataImport = "metadata_import"
+)
+
+// Audit Log Action Types — Collections
+const (
+	AuditActionCollectionCreated = "collection_created"
+	AuditActionCollectionUpdated = "collection_updated"
+	AuditActionCollectionDeleted = "collection_deleted"
+	AuditActionCollectionAssets  = "collection_assets"
 )
 
 // Audit Log Action Types — Configuration
@@ -45,11 +74,127 @@ const (
 	AuditActionConfigChanged = "config_changed"
 )
 
+// Config Change Sources
+// config_changed entries record where a change came from: an API request,
+// the first-time setup of a working directory, or a config file edited
+// outside the server and picked up at startup. Values of keys that look like
+// secrets are replaced with ConfigRedactedValue.
+const (
+	ConfigChangeSourceAPI       = "api"
+	ConfigChangeSourceBootstrap = "bootstrap"
+	ConfigChangeSourceCLI       = "cli"
+	ConfigRedactedValue         = "[redacted]"
+	ConfigHistoryDefaultLimit   = 50
+	ConfigHistoryMaxLimit       = 500
+)
+
 // Audit Log Action Types — Disk Usage
 const (
 	AuditActionDiskLimitHit = "disk_limit_hit"
 )
 
+// Audit Log Action Types — Audit Retention
+const (
+	AuditActionAuditPurged       = "audit_purged"
+	AuditActionAuditHoldCreated  = "audit_hold_created"
+	AuditActionAuditHoldReleased = "audit_hold_released"
+	AuditActionAuditExported     = "audit_exported"
+)
+
+// Audit Log Action Types — Quarantine
+const (
+	AuditActionAssetQuarantined = "asset_quarantined"
+	AuditActionAssetReleased    = "asset_released"
+)
+
+// Audit Log Action Types — Asset Deletion
+const (
+	AuditActionAssetDeleted = "asset_deleted"
+)
+
+// Audit Log Action Types — Deletion Requests
+const (
+	AuditActionDeletionRequested = "deletion_requested"
+	AuditActionDeletionApproved  = "deletion_approved"
+	AuditActionDeletionRejected  = "deletion_rejected"
+	AuditActionDeletionCancelled = "deletion_cancelled"
+	AuditActionDeletionExpired   = "deletion_expired"
+	AuditActionDeletionCompleted = "deletion_completed"
+)
+
+// Audit Log Action Types — Archive Tier
+const (
+	AuditActionAssetsArchived       = "assets_archived"
+	AuditActionAssetRecallRequested = "asset_recall_requested"
+	AuditActionAssetRecalled        = "asset_recalled"
+)
+
+// Audit Log Action Types — Trash
+const (
+	AuditActionAssetTrashed  = "asset_trashed"
+	AuditActionAssetRestored = "asset_restored"
+)
+
+// Audit Log Action Types — Retention Policies
+const (
+	AuditActionRetentionApplied = "retention_applied"
+)
+
+// Audit Log Action Types — Watch Folders
+const (
+	AuditActionWatchFolderIngested = "watch_folder_ingested"
+)
+
+// Audit Log Action Types — Lineage
+const (
+	AuditActionLineageReparented = "lineage_reparented"
+)
+
+// Audit Log Action Types — Webhooks
+const (
+	AuditActionWebhookCreated = "webhook_created"
+	AuditActionWebhookUpdated = "webhook_updated"
+	AuditActionWebhookDeleted = "webhook_deleted"
+)
+
+// Audit Log Action Types — Rules
+const (
+	AuditActionRuleCreated  = "rule_created"
+	AuditActionRuleUpdated  = "rule_updated"
+	AuditActionRuleDeleted  = "rule_deleted"
+	AuditActionRuleExecuted = "rule_executed"
+)
+
+// Audit Log Action Types — Debug
+const (
+	AuditActionDebugBundle = "debug_bundle"
+)
+
+// Audit Log Action Types — Backup
+const (
+	AuditActionBackupCreated = "backup_created"
+)
+
+// Audit Log Action Types — Workspace Tokens
+const (
+	AuditActionWorkspaceTokenCreated   = "workspace_token_created"
+	AuditActionWorkspaceTokenExchanged = "workspace_token_exchanged"
+	AuditActionWorkspaceTokenRevoked   = "workspace_token_revoked"
+)
+
+// Audit Log Action Types — API Keys
+const (
+	AuditActionAPIKeyCreated = "api_key_created"
+	AuditActionAPIKeyRotated = "api_key_rotated"
+	AuditActionAPIKeyRevoked = "api_key_revoked"
+)
+
+// Audit Log Action Types — Link Exports
+const (
+	AuditActionLinkExportSelected = "link_export_selected"
+	AuditActionLinkExportReleased = "link_export_released"
+)
+
 // Audit Log Configuration
 const (
 	AuditLogTableName      = "audit_log"
@@ -58,6 +203,33 @@ const (
 	AuditSSEBufferSize     = 100
 )
 
+// Audit Log Export
+// GET /api/audit/export streams every entry matching the /api/audit
+// filters, oldest first, without pagination.
+const (
+	AuditExportFormatCSV   = "csv"
+	AuditExportFormatJSONL = "jsonl"
+	AuditExportCSVMIME     = "text/csv; charset=utf-8"
+	AuditExportJSONLMIME   = "application/x-ndjson"
+)
+
+// Audit Write Queue
+// Entries are queued in memory and written in batches by a background
+// writer, which retries while the orchestrator DB is locked. A full queue
+// either blocks the caller or drops the oldest queued entry.
+const (
+	AuditQueueDefaultSize     = 10000
+	AuditQueueMaxSize         = 1000000
+	AuditOverflowBlock        = "block"       // Log waits for room in the queue
+	AuditOverflowDropOldest   = "drop_oldest" // Log discards the oldest queued entry
+	AuditWriteBatchSize       = 256           // Entries inserted per transaction
+	AuditWriteMaxAttempts     = 8             // Attempts before a batch is discarded
+	AuditWriteRetryBackoff    = 50 * time.Millisecond
+	AuditWriteMaxBackoff      = 2 * time.Second
+	AuditFlushTimeout         = 5 * time.Second  // Longest a reader waits for pending entries
+	AuditShutdownFlushTimeout = 15 * time.Second // Longest Stop waits to drain the queue
+)
+
 // Audit Log Size Management
 const (
 	AuditMaxLogSizeBytes     = 10 * 1024 * 1024 * 1024 // 10GB limit
@@ -66,11 +238,36 @@ const (
 	AuditMinPurgeEntries     = 1000                     // Minimum purge batch
 )
 
+// Audit Purge
+// Purges first remove entries past their action's retention, then the oldest
+// entries when the size limit is hit. Entries matched by an active legal hold
+// are never removed.
+const (
+	AuditPurgeReasonRetention  = "retention"
+	AuditPurgeReasonSize       = "size"
+	AuditPurgeTriggerScheduled = "scheduled"
+	AuditPurgeTriggerManual    = "manual"
+	AuditMaxRetentionDays      = 36500
+	AuditHoldNameMaxLength     = 128
+	AuditHoldReasonMaxLength   = 1024
+)
+
 // Reconciliation
 const (
 	ReconcileIntervalMins = 5 // Periodic reconciliation check interval
 )
 
+// Audit Actor Types
+// Every entry records what kind of principal acted, so automation can be told
+// apart from people: entries without a username are written by the server
+// itself, entries of service accounts and CI pipelines are tagged as such.
+const (
+	AuditActorUser     = "user"
+	AuditActorService  = "service"
+	AuditActorPipeline = "pipeline"
+	AuditActorSystem   = "system"
+)
+
 // Audit Log Filter Types
 const (
 	AuditFilterMe     = "me"
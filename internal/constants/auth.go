@@ -14,6 +14,7 @@ const (
 	AuthActionViewAudit    = "view_audit"
 	AuthActionVerify       = "verify"
 	AuthActionManageConfig = "manage_config"
+	AuthActionDebug        = "debug" // Profiler and runtime metrics, when debug.enabled is set
 )
 
 // AllAuthActions returns all defined auth actions.
@@ -28,6 +29,36 @@ var AllAuthActions = []string{
 	AuthActionViewAudit,
 	AuthActionVerify,
 	AuthActionManageConfig,
+	AuthActionDebug,
+}
+
+// Auth Account Types
+// Service accounts are non-interactive principals for processors and other
+// automation: they authenticate only with a token, never with a password,
+// and may only hold grants for the actions in ServiceAccountActions.
+// Pipeline accounts are the identity of a CI pipeline; they hold no grants
+// of their own and act only through the job tokens of their workspace tokens.
+const (
+	AuthAccountTypeUser     = "user"
+	AuthAccountTypeService  = "service"
+	AuthAccountTypePipeline = "pipeline"
+)
+
+// ServiceAccountActions lists the actions a service account may be granted.
+var ServiceAccountActions = []string{
+	AuthActionUpload,
+	AuthActionDownload,
+	AuthActionQuery,
+	AuthActionMetadata,
+	AuthActionBulkDownload,
+	AuthActionVerify,
+}
+
+// AdminAuthActions lists the actions that make a user an administrator:
+// holders can change grants or server configuration.
+var AdminAuthActions = []string{
+	AuthActionManageUsers,
+	AuthActionManageConfig,
 }
 
 // Auth Grant Change Types
@@ -57,6 +88,10 @@ const (
 	ErrCodeAuthUsernameInvalid    = "AUTH_USERNAME_INVALID"
 	ErrCodeAuthInvalidConstraints = "AUTH_INVALID_CONSTRAINTS"
 	ErrCodeAuthGrantActionDenied  = "AUTH_GRANT_ACTION_DENIED"
+	ErrCodeAuthPreflightDenied    = "AUTH_PREFLIGHT_DENIED"
+	ErrCodeAuthRecoveryInvalid    = "AUTH_RECOVERY_INVALID"
+	ErrCodeAuthRateLimited        = "AUTH_RATE_LIMITED"
+	ErrCodeAuthServiceAccount     = "AUTH_SERVICE_ACCOUNT"
 )
 
 // Auth HTTP Headers
@@ -75,6 +110,7 @@ const (
 const (
 	APIKeyPrefix      = "mbk_"
 	SessionTokenPrefix = "mbs_"
+	RecoveryTokenPrefix = "mbr_"
 )
 
 // Auth Configuration
@@ -100,6 +136,100 @@ const (
 	AuthSessionCleanupInterval = 30 * time.Minute
 )
 
+// Auth Admin Recovery (break-glass)
+const (
+	AuthRecoveryTokenBytes = 32               // 256 bits of entropy
+	AuthRecoveryTokenTTL   = 15 * time.Minute // Token must be exchanged within this window
+	AuthRecoveryCLIAddress = "local-cli"      // Audit IP recorded for tokens issued from the CLI
+)
+
+// Public Read-Only Mode (anonymous demo access)
+const (
+	AuthMethodAnonymous           = "anonymous"      // Identity.Method for anonymous public requests
+	PublicAnonymousUsername       = "anonymous"      // Username recorded in audit entries
+	PublicDefaultRateLimitPerMin  = 60               // Anonymous requests per IP per minute
+	PublicDefaultRateLimitBurst   = 20               // Requests an idle IP may make at once
+	PublicDefaultMaxDownloadBytes = 10485760         // 10MB per anonymous asset download
+	PublicRateLimitIdleTTL        = 10 * time.Minute // Buckets idle this long are dropped
+	PublicRateLimitSweepInterval  = time.Minute      // Minimum time between idle-bucket sweeps
+)
+
+// API Rate Limiting
+// Uploads, queries and downloads each have a token bucket per user, or per
+// client IP for unauthenticated requests. The rate_limit_per_min and
+// rate_limit_burst constraints of a grant override the configured rate.
+const (
+	RateLimitClassUploads   = "uploads"
+	RateLimitClassQueries   = "queries"
+	RateLimitClassDownloads = "downloads"
+
+	RateLimitDefaultUploadsPerMin   = 120
+	RateLimitDefaultUploadsBurst    = 30
+	RateLimitDefaultQueriesPerMin   = 300
+	RateLimitDefaultQueriesBurst    = 60
+	RateLimitDefaultDownloadsPerMin = 600
+	RateLimitDefaultDownloadsBurst  = 120
+)
+
+// RateLimitClasses lists the classes of rate limited requests.
+var RateLimitClasses = []string{RateLimitClassUploads, RateLimitClassQueries, RateLimitClassDownloads}
+
+// Auth Grant Batches
+const (
+	AuthGrantBatchMaxSize  = 500    // Maximum grants created by one batch or copy request
+	AuthGrantBatchModeList = "list" // POST /api/auth/grants/batch
+	AuthGrantBatchModeCopy = "copy" // POST /api/auth/users/:id/grants/copy-from/:src
+)
+
+// Auth Identity Methods (Identity.Method)
+const (
+	AuthMethodAPIKey         = "api_key"
+	AuthMethodSession        = "session"
+	AuthMethodS3             = "s3_sigv4"        // S3 gateway request signed with the credentials of an API key
+	AuthMethodPlugin         = "plugin"          // Request accepted by an auth provider from auth_providers
+	AuthMethodWorkspaceToken = "workspace_token" // Request made with a job token exchanged for a workspace token
+)
+
+// Auth User Activity Timeline
+const (
+	AuthAPIKeyUsageSampleInterval = time.Minute         // At most one API-key usage sample per user and IP per interval
+	AuthAPIKeyUsageRetention      = 30 * 24 * time.Hour // Samples older than this are purged with expired sessions
+	AuthActivityDefaultLimit      = 100
+	AuthActivityMaxLimit          = 1000
+	AuthActivityTopActionsLimit   = 10
+	AuthActivityKindSession       = "session" // Session created by a login
+	AuthActivityKindLogin         = "login"   // Login success, login failure and logout audit entries
+	AuthActivityKindAPIKey        = "api_key" // Sampled request authenticated with the user's API key
+	AuthActivityKindAudit         = "audit"   // Any other audit entry recorded under the username
+)
+
+
+// Auth Snapshots (GET /api/auth/export, POST /api/auth/import)
+const (
+	AuthSnapshotVersion            = 1
+	AuthSnapshotMaxBytes           = 4 << 20 // Request body limit of an import
+	AuthSnapshotFormatYAML         = "yaml"
+	AuthSnapshotFormatJSON         = "json"
+	AuthSnapshotChangeUserCreated  = "user_created"
+	AuthSnapshotChangeUserUpdated  = "user_updated"
+	AuthSnapshotChangeUserDisabled = "user_disabled"
+	AuthSnapshotChangeGrantCreated = "grant_created"
+	AuthSnapshotChangeGrantRevoked = "grant_revoked"
+)
+
+// Auth API Usage Analytics
+const (
+	AuthUsageBucket              = time.Hour           // Usage is aggregated per user, endpoint and hour
+	AuthUsageFlushInterval       = 30 * time.Second    // Buffered usage is written at least this often
+	AuthUsageRetention           = 31 * 24 * time.Hour // Buckets older than this are purged with expired sessions
+	AuthUsageTopEndpointsLimit   = 10
+	AuthUsageDefaultWindow       = "24h"
+	AuthUsageEndpointHash        = ":hash"  // Path segment placeholder for asset hashes
+	AuthUsageEndpointID          = ":id"    // Path segment placeholder for numeric and random IDs
+	AuthUsageEndpointTopic       = ":topic" // Path segment placeholder for topic names
+	AuthUsageEndpointIDMinLength = 16       // Hex segments at least this long are treated as IDs
+)
+
 // Auth Audit Actions
 const (
 	AuditActionAuthLogin        = "auth_login"
@@ -121,3 +251,89 @@ const (
 const (
 	QuotaDateFormat = "2006-01-02"
 )
+
+// Workspace Tokens (CI pipelines)
+// An admin issues a workspace token to a pipeline; each CI job exchanges it
+// at start for a short-lived job token carrying the workspace token's scopes.
+// Workspace tokens are only accepted by the exchange endpoint.
+const (
+	WorkspaceTokenPrefix             = "mbw_"
+	JobTokenPrefix                   = "mbj_"
+	AuthWorkspaceTokenBytes          = 48                  // 384 bits of entropy
+	AuthJobTokenBytes                = 32                  // 256 bits of entropy
+	AuthWorkspaceTokenMaxTTL         = 90 * 24 * time.Hour // Longest lifetime an admin may give a workspace token
+	AuthJobTokenDefaultTTL           = time.Hour           // Job token lifetime when the exchange asks for none
+	AuthJobTokenMaxTTL               = 24 * time.Hour      // Job tokens never outlive their workspace token either
+	AuthWorkspaceTokenTouchInterval  = time.Minute         // At most one last_used_at update per token per interval
+	AuthWorkspaceTokenMaxDescription = 200
+	AuthJobNameMaxLength             = 200
+	AuthWorkspaceTokenStatusActive   = "active"
+	AuthWorkspaceTokenStatusExpired  = "expired"
+	AuthWorkspaceTokenStatusRevoked  = "revoked"
+)
+
+// Workspace Token Error Codes
+const (
+	ErrCodeAuthWorkspaceTokenInvalid = "AUTH_WORKSPACE_TOKEN_INVALID" // Unknown, expired or revoked workspace token
+	ErrCodeAuthPipelineAccount       = "AUTH_PIPELINE_ACCOUNT"        // Operation not possible on a pipeline account
+)
+
+// API Key Expiration and Rotation
+// Besides the key on their account, users hold labelled API keys. Keys
+// created or rotated through the API keys endpoints expire after
+// auth.api_key_ttl_days; rotating a key issues its replacement and keeps the
+// old key working for a grace period so clients can switch over.
+const (
+	AuthAPIKeyPrimaryLabel     = "primary"           // Label and path segment of the key on the account
+	AuthAPIKeyMaxLabelLength   = 64                  // Longest label
+	AuthAPIKeyMaxPerUser       = 20                  // Active labelled keys per user, the account key not counted
+	AuthAPIKeyRotationGrace    = 24 * time.Hour      // Default grace period of a rotated key
+	AuthAPIKeyMaxRotationGrace = 30 * 24 * time.Hour // Longest grace period
+	AuthAPIKeyRetention        = 30 * 24 * time.Hour // Expired and revoked keys are listed this long, then purged
+	AuthAPIKeyStatusActive     = "active"
+	AuthAPIKeyStatusExpired    = "expired"
+	AuthAPIKeyStatusRevoked    = "revoked"
+	HeaderAPIKeyExpiresAt      = "X-API-Key-Expires-At" // Unix time the key of an API-key request expires
+)
+
+// API Key Error Codes
+const (
+	ErrCodeAuthAPIKeyExpired  = "AUTH_API_KEY_EXPIRED"   // The API key expired; rotate it or ask for a new one
+	ErrCodeAuthAPIKeyNotFound = "AUTH_API_KEY_NOT_FOUND" // No such API key for the user
+	ErrCodeAuthAPIKeyLimit    = "AUTH_API_KEY_LIMIT"     // The user holds the maximum number of active keys
+)
+
+// OpenID Connect Single Sign-On
+// Users sign in at the identity provider configured under oidc. The callback
+// hands the browser a one-time login code in the URL fragment, which the UI
+// exchanges for a session token, so session tokens never appear in URLs.
+const (
+	OIDCDiscoveryPath        = "/.well-known/openid-configuration"
+	OIDCDefaultUsernameClaim = "preferred_username"
+	OIDCDefaultNameClaim     = "name"
+	OIDCEmailClaim           = "email"
+	OIDCEmailVerifiedClaim   = "email_verified"
+	OIDCLoginPath            = "/api/auth/oidc/login"
+	OIDCStateTTL             = 10 * time.Minute // How long a sign-in may take at the provider
+	OIDCLoginCodeTTL         = time.Minute      // How long the UI has to exchange a login code
+	OIDCMaxPendingLogins     = 10000            // Sign-ins in progress at once, per instance
+	OIDCClockSkew            = 2 * time.Minute  // Leeway on ID token expiry
+	OIDCProviderCacheTTL     = time.Hour        // Discovery documents are fetched again after this
+	OIDCJWKSRefreshInterval  = time.Minute      // Unknown key IDs refetch the keys at most this often
+	OIDCHTTPTimeout          = 10 * time.Second
+	OIDCMaxResponseBytes     = 1 << 20
+	OIDCRandomBytes          = 48          // State, nonce, PKCE verifier and login code entropy
+	OIDCLoginMethod          = "sso"       // Method recorded on login audit entries
+	OIDCCodeFragment         = "sso_code"  // URL fragment key of a login code
+	OIDCErrorFragment        = "sso_error" // URL fragment key of a sign-in error code
+)
+
+// OpenID Connect Error Codes
+const (
+	ErrCodeAuthSSONotConfigured = "AUTH_SSO_NOT_CONFIGURED" // No oidc.issuer configured
+	ErrCodeAuthSSOInvalid       = "AUTH_SSO_INVALID"        // Unknown or expired sign-in state or login code, or an invalid ID token
+	ErrCodeAuthSSOProviderError = "AUTH_SSO_PROVIDER_ERROR" // The identity provider failed or refused the sign-in
+	ErrCodeAuthSSODenied        = "AUTH_SSO_DENIED"         // The identity may not sign in (not provisioned, domain not allowed)
+	ErrCodeAuthSSOUsernameTaken = "AUTH_SSO_USERNAME_TAKEN" // The mapped username belongs to an account not linked to the identity
+	ErrCodeAuthSSOBusy          = "AUTH_SSO_BUSY"           // Too many sign-ins in progress
+)
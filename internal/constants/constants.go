@@ -1,5 +1,7 @@
 package constants
 
+import "time"
+
 // Application
 const (
 	AppName        = "silobang"
@@ -29,6 +31,16 @@ const (
 	DataStartOffset  = 110 // where asset data begins
 )
 
+// Direct I/O
+// Working directories listed under storage_io with direct_io enabled append
+// and read DAT files with O_DIRECT, bypassing the page cache. Transfers are
+// staged in aligned buffers; files on filesystems that refuse O_DIRECT fall
+// back to buffered I/O.
+const (
+	DirectIOAlignment  = 4096    // offset, length and buffer alignment; covers 512e and 4Kn devices
+	DirectIOBufferSize = 1 << 20 // bytes staged per direct transfer; a multiple of DirectIOAlignment
+)
+
 // Paths
 const (
 	ConfigDir      = ".config/silobang"
@@ -78,6 +90,69 @@ const (
 	DefaultPresetMediumLimit = "50"
 )
 
+// Federated Queries
+// Federated presets run on a dedicated read-only connection with the
+// orchestrator and selected topic databases ATTACHed as schemas.
+const (
+	FederatedOrchestratorAlias = "orchestrator"
+	FederatedMaxTopics         = 8 // SQLite allows 10 attached databases by default
+	FederatedQueryTimeout      = 30 * time.Second
+	FederatedQueryMaxRows      = 100000
+	FederatedCacheSizeKiB      = 16384 // page cache per attached database
+)
+
+// Preset Sandbox
+// POST /api/queries/validate checks a preset definition and compiles it
+// with EXPLAIN on a query-only connection without registering it.
+const (
+	PresetSandboxName     = "sandbox" // name checked when the request gives none
+	PresetSandboxMaxBytes = 1 << 20   // request body limit
+	PresetSandboxTimeout  = 10 * time.Second
+)
+
+// Query Builder
+// POST /api/query/build compiles a structured filter tree over assets, their
+// current metadata and lineage into a parameterized query run like a preset
+// on every selected topic. Grants see it as the preset QueryBuilderPreset,
+// and presets cannot take the names of the builder endpoints.
+const (
+	QueryBuilderPreset        = "build"
+	QueryBuilderSchemaPreset  = "schema"
+	QueryBuilderDefaultLimit  = 100
+	QueryBuilderMaxDepth      = 8   // nesting of and/or/not nodes
+	QueryBuilderMaxConditions = 64  // comparisons in one filter tree
+	QueryBuilderMaxInValues   = 256 // values of an in or not_in comparison
+	QueryBuilderMaxSelect     = 32  // selected fields
+	QueryBuilderMaxSort       = 4   // sort fields
+	QueryBuilderMaxSchemaKeys = 500 // most used metadata keys listed by the schema
+)
+
+// Name Collation
+// Topics listed under topic_collation match and sort origin names with
+// locale-aware case and diacritic folding. The query service passes the
+// topic's folding spec to presets as the :_collation parameter (NULL for
+// byte-wise topics), and preset SQL applies it with silo_fold(text, spec).
+const (
+	SQLiteDriverName        = "sqlite3_silobang" // sqlite3 with silo_fold registered
+	CollationFoldFunction   = "silo_fold"
+	CollationParam          = "_collation"
+	CollationSpecSeparator  = "+"
+	CollationFlagCase       = "case"
+	CollationFlagDiacritics = "diacritics"
+	CollationLocaleRegex    = `^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`
+)
+
+// Query Limits
+// Defaults and sanity ceilings for the result and payload limits under the
+// query, batch and metadata config sections. These limits are read on every
+// request and can be changed at runtime via PUT /api/limits.
+const (
+	QueryDefaultMaxRows          = 10000
+	QueryMaxRowsCeiling          = 10_000_000
+	BatchMaxOperationsCeiling    = 10_000_000
+	MetadataMaxValueBytesCeiling = 1 << 30 // 1GB
+)
+
 // Stat Format Types
 const (
 	StatFormatBytes  = "bytes"
@@ -96,6 +171,10 @@ const (
 // DatListRecentCount is the number of recent DAT files to include in stats
 const DatListRecentCount = 5
 
+// TopicDiscoveryWorkers bounds how many topics are probed (dat hash
+// verification) and read for orchestrator indexing at once during startup.
+const TopicDiscoveryWorkers = 8
+
 // Validation
 const (
 	TopicNameRegex  = `^[a-z0-9_-]+$`
@@ -104,6 +183,93 @@ const (
 	HashLength      = 64 // BLAKE3 hex string length (32 bytes = 64 hex chars)
 )
 
+// Topic Creation
+const (
+	TopicReservationTTL         = 5 * time.Minute // A reservation older than this was left by a creator that died
+	TopicReservationTokenLength = 32              // Hex length of the token identifying one creation
+	TopicCreatingMarker         = ".creating"     // File in .internal while the topic is being created
+)
+
+// Collections
+const (
+	CollectionNameRegex          = `^[a-z0-9_-]+$`
+	MinCollectionNameLen         = 1
+	MaxCollectionNameLen         = 64
+	MaxCollectionDescriptionLen  = 1024
+	MaxCollectionAssetsPerChange = 10000         // Maximum asset IDs per add/remove request
+	CollectionSQLParam           = "_collection" // bound to presets rewritten by Preset.Scoped
+)
+
+// Asset Reference Registry (holders that keep an asset alive)
+const (
+	ReferenceKindCollection = "collection" // Holder: collection name in the holder's topic
+	ReferenceKindLineage    = "lineage"    // Holder: hash of a derived asset naming this one as parent
+	ReferenceKindExport     = "export"     // Holder: link export ID; the topic is empty
+)
+
+// Asset Timeline
+// GET /api/assets/:hash/timeline merges the asset's upload, metadata log,
+// derived assets and the audit entries mentioning it, newest first.
+const (
+	AssetTimelineDefaultLimit = 100
+	AssetTimelineMaxLimit     = 1000
+	AssetTimelineKindUpload   = "upload"   // The asset was stored
+	AssetTimelineKindReupload = "reupload" // The same content was uploaded again and deduplicated
+	AssetTimelineKindMetadata = "metadata" // A metadata_log entry
+	AssetTimelineKindDownload = "download" // A single-asset download
+	AssetTimelineKindDerived  = "derived"  // An asset naming this one as parent was stored
+	AssetTimelineKindAudit    = "audit"    // Any other audit entry mentioning the asset
+	AssetTimelineKindLineage  = "lineage"  // The asset's parent was changed after upload
+)
+
+// Asset Lookup by Name
+// GET /api/topics/:name/assets/by-name/:origin_name lists the assets stored
+// under a filename or origin name, newest first.
+const (
+	AssetByNamePathPrefix  = "assets/by-name/" // Topic sub-path of the lookup
+	AssetByNameMaxResults  = 1000
+	AssetByNameLatestParam = "latest" // latest=true downloads the newest match
+)
+
+// Topic Asset Browser
+// GET /api/topics/:name/assets pages through a topic's assets in a sort
+// order, continuing from an opaque cursor rather than an offset.
+const (
+	TopicAssetsDefaultLimit = 50
+	TopicAssetsMaxLimit     = 1000
+	TopicAssetsOrderAsc     = "asc"
+	TopicAssetsOrderDesc    = "desc"
+)
+
+// Asset Discovery
+// GET /api/popular ranks each topic's assets by the downloads recorded in the
+// audit log; GET /api/assets/:hash/related lists assets downloaded together
+// with an asset and assets sharing its metadata values. Both are cached by
+// the stats cache.
+const (
+	DiscoveryDefaultDays          = 30
+	DiscoveryMaxDays              = 366
+	DiscoveryDefaultLimit         = 10
+	DiscoveryMaxLimit             = 100
+	DiscoveryCoDownloadWindowSecs = 3600 // Same user, within this long of a download of the asset
+	DiscoveryCacheTTL             = 10 * time.Minute
+)
+
+// Full-text Search
+// GET /api/search matches origin names, extensions and metadata keys and
+// values through an FTS5 index in each topic database, kept current by
+// triggers. Requires a build with the sqlite_fts5 tag.
+const (
+	SearchDefaultLimit    = 20
+	SearchMaxLimit        = 100
+	SearchMaxQueryLength  = 200
+	SearchMaxTerms        = 16
+	SearchSnippetTokens   = 12 // Tokens around the matches in a snippet
+	SearchHighlightStart  = "<mark>"
+	SearchHighlightEnd    = "</mark>"
+	SearchSnippetEllipsis = "…"
+)
+
 // Database pragmas (optimized for low memory: < 2GB RAM)
 var SQLitePragmas = []string{
 	"PRAGMA journal_mode=WAL",
@@ -130,6 +296,21 @@ const (
 	ShutdownTimeoutSecs = 10
 )
 
+// Service Management ("silobang service")
+const (
+	ServiceName              = AppName
+	ServiceDescription       = "SiloBang asset storage server"
+	ServiceConfigDirEnv      = "SILOBANG_CONFIG_DIR" // Overrides the config directory; set by installed services
+	ServiceSystemConfigDir   = "/etc/silobang"       // System-wide config directory on Unix
+	ServiceWindowsConfigDir  = "SiloBang"            // Config directory under %ProgramData% on Windows
+	ServiceSystemdUnitDir    = "/etc/systemd/system"
+	ServiceSystemdUnitSuffix = ".service"
+	ServiceRestartDelaySecs  = 5             // Wait before the service manager restarts a failed server
+	ServiceFailureResetSecs  = 86400         // Windows: period after which the failure count resets
+	ServiceLogFile           = "service.log" // Windows: server output, in the config directory
+	ServiceStateNotInstalled = "not-installed"
+)
+
 // Pagination
 const (
 	DefaultPageSize = 100
@@ -159,11 +340,158 @@ const (
 	BulkDownloadMetadataDir = "metadata"
 )
 
+// Bulk Download Archive Formats
+// archive_format selects the container of a bulk download. ZIP compresses
+// entries per the extension's storage policy; tar stores them as they are
+// and tar.gz compresses the whole stream.
+const (
+	ArchiveFormatZIP   = "zip"
+	ArchiveFormatTar   = "tar"
+	ArchiveFormatTarGz = "tar.gz"
+	MimeTypeTar        = "application/x-tar"
+	MimeTypeGzip       = "application/gzip"
+	TarEntryMode       = 0o644
+)
+
+// Watermarking
+// Download transforms stamping a configured text or overlay image on PNG and
+// JPEG assets. Watermarked bytes are produced per request and never stored,
+// so the original stays content-addressed and untouched.
+const (
+	WatermarkQueryParam          = "watermark"
+	WatermarkPositionCenter      = "center"
+	WatermarkPositionTopLeft     = "top-left"
+	WatermarkPositionTopRight    = "top-right"
+	WatermarkPositionBottomLeft  = "bottom-left"
+	WatermarkPositionBottomRight = "bottom-right"
+	WatermarkPositionTile        = "tile"
+	WatermarkDefaultPosition     = WatermarkPositionBottomRight
+	WatermarkDefaultOpacity      = 0.5
+	WatermarkDefaultScale        = 0.3 // Stamp width relative to the image width
+	WatermarkMarginRatio         = 0.02
+	WatermarkMaxTextLength       = 128
+	WatermarkMaxNameLength       = 64
+	WatermarkMaxSourceBytes      = 64 << 20   // Larger images are not transformed
+	WatermarkMaxPixels           = 50_000_000 // Decoded size guard
+	WatermarkJPEGQuality         = 90
+)
+
+// Storage Policies
+// Per-extension handling consulted by the upload and download pipelines.
+// Policies are keyed by lower-case extension without the dot; the "*" entry
+// applies to extensions without their own policy.
+const (
+	StoragePolicyAnyExtension       = "*"
+	StoragePolicyDefaultCompression = false // Downloads are sent as stored
+	StoragePolicyDefaultPreview     = true  // Previews are generated for supported images
+	StoragePolicyDefaultScan        = false // Uploads are not scanned
+	StoragePolicyMaxEntries         = 256
+)
+
+// Upload Scanning
+// An external scanner (e.g. clamdscan) reads the upload on stdin. Exit code 0
+// means clean and ScanExitInfected means rejected; anything else, including a
+// timeout, fails the upload closed.
+const (
+	ScanDefaultTimeoutSecs = 60
+	ScanExitInfected       = 1
+	ScanMaxOutputBytes     = 1024 // Scanner output kept for the rejection message
+)
+
+// Upload Validation
+// Validators registered with the plugin package parse uploads of the
+// extensions they are configured for. In strict mode invalid uploads are
+// rejected; in lenient mode they are stored and the verdict is recorded in
+// their metadata either way.
+const (
+	ValidationModeStrict         = "strict"  // Invalid uploads are rejected; the default
+	ValidationModeLenient        = "lenient" // Invalid uploads are stored and marked invalid
+	ValidationDefaultTimeoutSecs = 30
+	ValidationMaxErrors          = 20 // Errors reported per upload
+	ValidationMaxSchemaDepth     = 64 // Nested schemas followed, $ref included
+	ValidationErrorSeparator     = "; "
+
+	// Metadata recorded on validated uploads by the validation processor
+	MetadataKeyValidationStatus    = "validation_status" // ValidationStatusValid or ValidationStatusInvalid
+	MetadataKeyValidationValidator = "validation_validator"
+	MetadataKeyValidationErrors    = "validation_errors" // Set on invalid uploads only
+	ValidationStatusValid          = "valid"
+	ValidationStatusInvalid        = "invalid"
+	ProcessorValidation            = "validation"
+	ProcessorValidationVersion     = "1.0"
+)
+
+// Media Info
+// Uploads are inspected before they are stored. Their sniffed content type
+// and, for the formats recognized, image dimensions, GLB vertex and
+// material counts and audio/video durations are recorded in their metadata
+// by the media processor.
+const (
+	MetadataKeyMediaContentType  = "media_content_type"
+	MetadataKeyMediaWidth        = "media_width"  // Pixels, images only
+	MetadataKeyMediaHeight       = "media_height" // Pixels, images only
+	MetadataKeyMediaVertices     = "media_vertices"
+	MetadataKeyMediaMaterials    = "media_materials"
+	MetadataKeyMediaDurationSecs = "media_duration_secs"
+	ProcessorMedia               = "media"
+	ProcessorMediaVersion        = "1.0"
+
+	MediaSniffBytes      = 512      // Read to sniff the content type
+	MediaMaxGLBJSONBytes = 16 << 20 // Larger GLB JSON chunks are not parsed
+	MediaMaxBoxes        = 1024     // MP4 boxes walked looking for the movie header
+)
+
+// Quarantine
+// Quarantined assets are withheld from downloads, queries and bulk exports
+// until an admin releases them with a justification. The source records what
+// flagged the asset.
+const (
+	QuarantineSourceAdmin     = "admin"     // Flagged by a user through the API
+	QuarantineSourceIntegrity = "integrity" // Content failed its hash check during verification
+	QuarantineSourceScan      = "scan"      // Upload flagged by the scanner with scan.quarantine_infected set
+	QuarantineSourceRule      = "rule"      // Matched by a rule with the quarantine action
+
+	QuarantineMaxReasonLength = 1024
+)
+
+// Previews
+// Downscaled images and wireframes of 3D models for assets whose policy
+// enables previews. PNG, JPEG, GLB and OBJ sources are supported. Previews
+// are cached per hash and size under .internal/previews of the topic.
+const (
+	PreviewQueryParamSize = "size"
+	PreviewDefaultSize    = 256  // Longest edge in pixels
+	PreviewMaxSize        = 1024 // Largest size a client may request
+	PreviewMaxSourceBytes = 64 << 20
+	PreviewMaxPixels      = 50_000_000 // Decoded size guard
+	PreviewJPEGQuality    = 85
+)
+
+const (
+	PreviewMaxModelVertices = 2_000_000  // Parsed geometry guard
+	PreviewMaxModelEdges    = 1_000_000  // Edges past this are not drawn
+	PreviewsDir             = "previews" // Subdirectory under .internal
+	PreviewQueueSize        = 256        // Uploads awaiting preview generation
+)
+
 // Filename formats for bulk download
 const (
 	FilenameFormatHash         = "hash"
 	FilenameFormatOriginal     = "original"
 	FilenameFormatHashOriginal = "hash_original"
+	FilenameFormatPath         = "path" // Original name under the folders recorded at upload
+)
+
+// Bulk Download Encryption
+const (
+	BulkDownloadKeysFilename     = "keys.json" // Keys manifest at the ZIP root
+	BulkDownloadEncryptedSuffix  = ".enc"      // Appended to encrypted entry paths
+	BulkDownloadMaxRecipients    = 32
+	EnvelopeScheme               = "x25519-hkdf-sha256-aes256gcm-stream"
+	EnvelopeVersion              = 1
+	EnvelopeChunkSize            = 64 * 1024 // Plaintext bytes per sealed chunk
+	EnvelopeKDFInfo              = "silobang envelope v1"
+	EnvelopeRecipientIDMaxLength = 128
 )
 
 // Bulk Download SSE
@@ -176,6 +504,137 @@ const (
 	BulkDownloadFilePattern      = "*.zip"     // Pattern for cleanup glob
 )
 
+// Bulk Download Cancellation
+// DELETE /api/download/bulk/:id/cancel stops an in-progress download and
+// removes its partial ZIP. Cancellations are audited as download_cancelled
+// with one of these reasons.
+const (
+	BulkDownloadCancelWait               = 5 * time.Second // Longest a cancel request waits for the partial ZIP to be removed
+	BulkDownloadCancelReasonRequested    = "requested"     // Cancel endpoint or progress socket cancel command
+	BulkDownloadCancelReasonDisconnected = "disconnected"  // The SSE client, progress socket or streaming client went away
+)
+
+// Export Inbox
+// Bulk downloads directed to the inbox are built in the background and kept
+// under .internal/exports/<user_id>/ until deleted or expired.
+const (
+	ExportsDir                 = "exports" // Subdirectory under .internal
+	ExportDestinationInbox     = "inbox"   // BulkDownloadRequest.destination value
+	ExportDefaultRetentionDays = 7
+	ExportDefaultMaxInboxBytes = 10 << 30  // 10GB per user
+	ExportCleanupInterval      = time.Hour // How often expired exports are removed
+	ExportIDLength             = 16        // Length of random export ID
+	ExportFilenameFormat       = "silobang-export-%s.zip"
+	ExportInterruptedMessage   = "interrupted by server restart"
+
+	ExportStatusProcessing = "processing"
+	ExportStatusReady      = "ready"
+	ExportStatusFailed     = "failed"
+)
+
+// Link Exports
+// Directories of hard links under .internal/links/exports/<id>/ for
+// consumers mounting the host, pointing at extracted copies shared through
+// .internal/links/objects/.
+const (
+	LinkExportsDir        = "links"   // Subdirectory under .internal
+	LinkExportObjectsDir  = "objects" // Extracted copies, named by hash
+	LinkExportTreesDir    = "exports" // One directory of links per export
+	LinkExportLabelMaxLen = 128
+
+	LinkExportMethodHardlink = "hardlink"
+	LinkExportMethodCopy     = "copy" // Filesystem refused the hard link
+)
+
+// Resumable Uploads
+// Upload sessions stage received bytes under .internal/uploads/ until they
+// are finalized into a topic, aborted, or left idle past their expiry.
+const (
+	UploadSessionsDir            = "uploads" // Subdirectory under .internal
+	UploadSessionFileExt         = ".part"
+	UploadSessionTTL             = 24 * time.Hour // Expiry, extended by every chunk
+	UploadSessionCleanupInterval = time.Hour      // How often expired sessions are removed
+	UploadSessionIDLength        = 32             // Length of random session ID
+	UploadSessionMaxPerUser      = 100
+	MimeTypeOffsetOctetStream    = "application/offset+octet-stream" // PATCH body
+)
+
+// Watch Folders
+// Server-side directories whose files are ingested into a topic once they
+// have not been modified for the settle time. Ingested files are moved to
+// the processed/ subfolder or deleted; files that cannot be stored are moved
+// to failed/ with a .error note next to them.
+const (
+	WatchFoldersDir              = "watch" // Staging subdirectory under .internal
+	WatchFolderProcessedDir      = "processed"
+	WatchFolderFailedDir         = "failed"
+	WatchFolderErrorNoteExt      = ".error"
+	WatchFolderAfterMove         = "move"   // Move ingested files to processed/; the default
+	WatchFolderAfterDelete       = "delete" // Delete ingested files
+	WatchFolderDefaultSettleSecs = 10       // Files modified more recently are left for a later scan
+	WatchFolderMaxSettleSecs     = 3600
+	WatchFolderMaxEntries        = 64
+	WatchFolderMaxAttempts       = 5               // Failed ingestions of a file before it is moved to failed/
+	WatchFolderMaxListedFailures = 1000            // Quarantined files listed per folder
+	WatchFolderScanInterval      = 5 * time.Second // How often folders are scanned
+)
+
+// Folders are also watched for file system events where the platform allows
+// it. A folder is then scanned shortly after files appear in it, and the
+// periodic scan slows down to catch only events that were missed.
+const (
+	WatchFolderEventDelay       = time.Second // Quiet time after the last event before a scan
+	WatchFolderRescanInterval   = time.Minute // Periodic scan while events are received
+	WatchFolderTriggerEvent     = "event"
+	WatchFolderTriggerScheduled = "scheduled"
+	WatchFolderTriggerManual    = "manual"
+)
+
+// Asset Cache
+// Small assets are kept in memory after their first download so hot
+// thumbnails and config files are served without touching the DAT files.
+const (
+	AssetCacheDefaultMaxBytes      = 64 << 20 // 64MB of asset data
+	AssetCacheDefaultMaxAssetBytes = 1 << 20  // Assets up to 1MB are cached
+)
+
+// Progress WebSocket
+const (
+	WSMaxMessageSize        = 64 * 1024       // Maximum inbound message size (64KB)
+	WSSendBufferSize        = 256             // Outbound events buffered per connection
+	WSPingIntervalSecs      = 30              // Server ping interval
+	WSReadTimeoutSecs       = 75              // Close connection if nothing is read for this long
+	WSWriteTimeoutSecs      = 10              // Per-frame write deadline
+	WSUploadProgressBytes   = 1 * 1024 * 1024 // Emit upload progress every 1MB received
+	WSMaxConnectionsPerUser = 16              // Concurrent progress sockets per user
+	WSProgressIDMaxLen      = 64              // Maximum length of client-supplied upload IDs
+	WSProgressIDRegex       = `^[a-zA-Z0-9_-]+$`
+	QueryParamUploadID      = "upload_id"
+)
+
+// Upload Outcome
+// Every upload result carries a status and a reason. For rejected uploads the
+// reason is the error code.
+const (
+	UploadStatusCreated      = "created"      // Asset written to the topic
+	UploadStatusDeduplicated = "deduplicated" // Identical content already stored in the same topic
+	UploadStatusAliased      = "aliased"      // Identical content already stored in another topic
+	UploadStatusRejected     = "rejected"     // Upload refused; nothing was written
+
+	UploadReasonNewContent = "new_content"
+	UploadReasonSameTopic  = "duplicate_in_topic"
+	UploadReasonOtherTopic = "duplicate_in_other_topic"
+)
+
+// Upload Indexing
+// An upload is acknowledged only once the orchestrator indexes it. When the
+// orchestrator commit fails after the topic commit, the index entry is
+// retried before the upload is reported failed.
+const (
+	UploadIndexRetryAttempts = 3
+	UploadIndexRetryBackoff  = 50 * time.Millisecond // Doubled after each failed attempt
+)
+
 // Batch Metadata Operations
 const (
 	BatchMetadataMaxOperations = 100000   // Maximum operations per batch request
@@ -185,7 +644,61 @@ const (
 
 // Metadata Processors
 const (
-	ProcessorAPI = "api" // Direct API calls
+	ProcessorAPI             = "api"    // Direct API calls
+	ProcessorUpload          = "upload" // Metadata recorded from upload form fields
+	ProcessorUploadVersion   = "1.0"
+	ProcessorImport          = "csv-import" // Metadata imported from a CSV sheet
+	ProcessorImportVersion   = "1.0"
+	ProcessorDefaults        = "defaults" // Metadata stamped from the configured defaults at upload
+	ProcessorDefaultsVersion = "1.0"
+	ProcessorRules           = "rules" // Metadata written by rules with the tag action
+	ProcessorRulesVersion    = "1.0"
+)
+
+// Well-known metadata keys
+const (
+	MetadataKeyRelativePath = "relative_path" // Folder-relative path given at upload
+)
+
+// Default Metadata
+// metadata.defaults and metadata.topic_defaults are stamped on every new
+// asset when the upload commits. Their values may use these placeholders.
+const (
+	DefaultMetadataPlaceholderUsername   = "{{username}}"    // Uploading user; empty for generated seed data
+	DefaultMetadataPlaceholderTopic      = "{{topic}}"       // Topic the asset was uploaded to
+	DefaultMetadataPlaceholderUploadTime = "{{upload_time}}" // Commit time, RFC 3339 in UTC
+	DefaultMetadataPlaceholderFilename   = "{{filename}}"    // Sanitized original filename
+	DefaultMetadataPlaceholderExtension  = "{{extension}}"   // Sanitized extension without the dot
+	DefaultMetadataPlaceholderHash       = "{{hash}}"        // Asset hash
+	DefaultMetadataMaxKeys               = 100               // Keys per template, after topic overrides
+)
+
+// Metadata Import
+// POST /api/metadata/import resolves each row of a CSV sheet to an asset by
+// hash or origin name and applies the other columns as metadata. The per-row
+// result is kept at .internal/metadata_imports/<user_id>/<id>.csv.
+const (
+	MetadataImportDir             = "metadata_imports" // Subdirectory under .internal
+	MetadataImportMaxBytes        = 32 << 20           // Maximum CSV size (32MB)
+	MetadataImportMaxRows         = 100000             // Maximum data rows per sheet
+	MetadataImportBatchSize       = 1000               // Operations applied per transaction
+	MetadataImportLookupBatchSize = 500                // Hashes or names resolved per query
+	MetadataImportCandidateLimit  = 10                 // Matches listed for an ambiguous row
+	MetadataImportIDLength        = 16                 // Length of random import ID
+	MetadataImportRetention       = 7 * 24 * time.Hour // How long result files are kept
+	MetadataImportFilenameFormat  = "metadata-import-%s.csv"
+
+	MetadataImportColumnHash       = "hash"
+	MetadataImportColumnOriginName = "origin_name"
+	MetadataImportColumnTopic      = "topic"
+
+	MetadataImportStatusApplied   = "applied"   // Every value was written
+	MetadataImportStatusPartial   = "partial"   // Some values failed
+	MetadataImportStatusFailed    = "failed"    // No value was written
+	MetadataImportStatusNotFound  = "not_found" // No asset matched the row
+	MetadataImportStatusAmbiguous = "ambiguous" // Several assets matched the row
+	MetadataImportStatusInvalid   = "invalid"   // The row's hash is malformed
+	MetadataImportStatusSkipped   = "skipped"   // The row has no values
 )
 
 // Metadata Validation
@@ -194,6 +707,49 @@ const (
 	MaxMetadataValueBytes = 10485760 // Maximum bytes for metadata value (10MB)
 )
 
+// Metadata Selection (query results, asset info and bulk download metadata files)
+const (
+	MetadataModeParam     = "metadata"      // full (default), keys or none
+	MetadataKeysParam     = "metadata_keys" // comma-separated in query strings
+	MetadataModeFull      = "full"          // values, restricted to metadata_keys when given
+	MetadataModeKeys      = "keys"          // key names only
+	MetadataModeNone      = "none"          // no metadata
+	MetadataSelectMaxKeys = 256
+	MetadataJSONColumn    = "metadata_json" // query result column holding computed metadata
+)
+
+// Metadata Time Travel (GET /api/assets/:hash/metadata and metadata-aware presets)
+const (
+	MetadataAsOfParam    = "as_of"  // unix timestamp, in query strings and query requests
+	MetadataAsOfSQLParam = "_as_of" // bound to presets rewritten by Preset.Scoped
+)
+
+// Seed Data Generator
+const (
+	SeedTopicPrefix        = "seed-"
+	SeedMaxTopics          = 100
+	SeedDefaultTopics      = 5
+	SeedDefaultAssets      = 1000
+	SeedDefaultSeed        = 1
+	SeedDefaultMinSize     = 256        // 256 bytes
+	SeedDefaultMaxSize     = 256 * 1024 // 256KB
+	SeedLineageProbability = 0.25       // Chance an asset extends the previous asset's lineage chain
+	SeedMaxChainLength     = 6          // Maximum versions in a generated lineage chain
+	SeedProgressInterval   = 500        // Log progress every N assets
+	SeedProcessor          = "seed"
+	SeedProcessorVersion   = "1.0"
+)
+
+// Chunk Dedup Analysis
+const (
+	ChunkDedupMinChunkSize        = 2 * 1024          // Content-defined chunk lower bound (2KB)
+	ChunkDedupAvgChunkSize        = 8 * 1024          // Target average chunk size (8KB, power of two)
+	ChunkDedupMaxChunkSize        = 64 * 1024         // Content-defined chunk upper bound (64KB)
+	ChunkDedupDefaultSampleAssets = 200               // Assets sampled per topic when not specified
+	ChunkDedupMaxSampleAssets     = 10000             // Upper bound for sample_size
+	ChunkDedupMaxSampleBytes      = 256 * 1024 * 1024 // Stop sampling a topic after this many bytes (256MB)
+)
+
 // Verification
 const (
 	DefaultVerifyProgressInterval = 100 // Report progress every N entries
@@ -205,10 +761,290 @@ const (
 	MonitoringLogFileMaxReadBytes = 5 * 1024 * 1024 // 5MB cap per log file read
 )
 
+// Health and Readiness
+// /healthz answers as soon as HTTP is up; /readyz only once the working
+// directory, orchestrator DB, topic discovery and stats cache are in place.
+const (
+	HealthPath    = "/healthz"
+	ReadinessPath = "/readyz"
+
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded" // Serving, but with a reason worth surfacing
+	HealthStatusFailed   = "failed"   // Not ready
+
+	StartupStepOK      = "ok"
+	StartupStepWarning = "warning" // Completed with errors that do not block serving
+	StartupStepFailed  = "failed"
+	StartupStepSkipped = "skipped"
+
+	StartupTriggerBoot      = "boot"       // Initialized at process start
+	StartupTriggerConfigAPI = "config_api" // Initialized by POST /api/config
+
+	StartupStepWorkingDirectory = "working_directory"
+	StartupStepOrchestratorDB   = "orchestrator_db"
+	StartupStepAuthBootstrap    = "auth_bootstrap"
+	StartupStepTopicDiscovery   = "topic_discovery"
+	StartupStepReconcile        = "reconcile"
+	StartupStepIntegrityScan    = "integrity_scan"
+	StartupStepStatsCache       = "stats_cache"
+	StartupStepQueries          = "queries"
+	StartupStepPrompts          = "prompts"
+
+	HealthComponentStartup          = "startup"
+	HealthComponentWorkingDirectory = "working_directory"
+	HealthComponentOrchestratorDB   = "orchestrator_db"
+	HealthComponentTopicDiscovery   = "topic_discovery"
+	HealthComponentStatsCache       = "stats_cache"
+	HealthComponentTopics           = "topics"
+
+	StartupReportHistoryLimit   = 50 // Persisted reports kept in the orchestrator DB
+	StartupReportDefaultListing = 10
+)
+
+// Subsystems and Feature Flags
+// Startup runs each subsystem independently under a supervisor; a failure
+// only takes down the subsystems that depend on it. Optional subsystems can
+// be turned off with features.disabled for lean deployments.
+const (
+	StartupStepAudit    = "audit"
+	StartupStepSearch   = "search"
+	StartupStepPreviews = "previews"
+
+	SubsystemStateRunning  = "running"
+	SubsystemStateDegraded = "degraded" // Started with errors, e.g. on fallback defaults
+	SubsystemStateFailed   = "failed"
+	SubsystemStateDisabled = "disabled" // Turned off by features.disabled
+	SubsystemStateSkipped  = "skipped"  // A subsystem it requires is not running
+
+	FeaturePreviews      = "previews"       // Downscaled image previews
+	FeatureSearch        = "search"         // Full-text search index maintenance and queries
+	FeaturePrompts       = "prompts"        // Prompt templates under .internal/prompts
+	FeatureIntegrityScan = "integrity_scan" // Scan of .dat files for orphaned regions at startup
+
+	HealthComponentSubsystems = "subsystems"
+)
+
+// OptionalFeatures lists the features features.disabled may name.
+var OptionalFeatures = []string{FeaturePreviews, FeatureSearch, FeaturePrompts, FeatureIntegrityScan}
+
+// Notifications
+const (
+	NotificationEventAssetAdded      = "asset_added"      // A new asset was uploaded to a subscribed topic
+	NotificationEventMetadataChanged = "metadata_changed" // Metadata keys changed on assets of a subscribed topic
+	NotificationEventExportReady     = "export_ready"     // An inbox export finished building (sent to its owner)
+	NotificationEventExportFailed    = "export_failed"    // An inbox export could not be built (sent to its owner)
+	NotificationEventExportExpired   = "export_expired"   // An inbox export was deleted after retention (sent to its owner)
+	NotificationEventGrantCreated    = "grant_created"    // A permission grant was given to the user (sent to its holder)
+	NotificationEventGrantUpdated    = "grant_updated"    // The constraints of a grant of the user changed (sent to its holder)
+	NotificationEventGrantRevoked    = "grant_revoked"    // A grant of the user was revoked (sent to its holder)
+	NotificationEventRuleMatched     = "rule_matched"     // A notify rule matched assets (sent to the users it lists)
+
+	NotificationEventDeletionRequested = "deletion_requested" // A deletion request awaits a decision (sent to the users who may decide it)
+	NotificationEventDeletionApproved  = "deletion_approved"  // The user's deletion request was approved (sent to its requester)
+	NotificationEventDeletionRejected  = "deletion_rejected"  // The user's deletion request was rejected (sent to its requester)
+	NotificationEventDeletionExpired   = "deletion_expired"   // The user's deletion request expired undecided (sent to its requester)
+	NotificationEventDeletionCompleted = "deletion_completed" // An approved deletion request was executed (sent to its requester and approver)
+
+	NotificationDeliveryImmediate = "immediate" // Send each pending notification on the next delivery tick
+	NotificationDeliveryDigest    = "digest"    // Batch pending notifications into one message per digest interval
+
+	NotificationDefaultDigestIntervalMins = 60
+	NotificationDefaultWebhookTimeoutSecs = 10
+	NotificationDefaultRetentionDays      = 30
+	NotificationDefaultSMTPPort           = 587
+	NotificationDeliveryInterval          = 30 * time.Second // How often pending notifications are delivered
+	NotificationCleanupInterval           = time.Hour        // How often feed entries past retention are purged
+	NotificationMaxDeliveryAttempts       = 5                // Give up on webhook/email delivery after this many failures
+	NotificationMaxDeliveryBatch          = 500              // Notifications per webhook call or email
+	NotificationMaxMetadataKeys           = 50               // Metadata keys a subscription may filter on
+	NotificationMaxAssetIDs               = 20               // Asset IDs listed in one metadata_changed notification
+	NotificationFeedDefaultLimit          = 50
+	NotificationFeedMaxLimit              = 500
+	NotificationWebhookMaxURLLength       = 2048
+	NotificationEmailMaxLength            = 254
+	NotificationUserAgent                 = "silobang-notifications"
+
+	// Events of GET /api/notifications/stream
+	NotificationStreamEventUnread       = "unread"       // Sent first: the unread counts
+	NotificationStreamEventNotification = "notification" // A notification was added to the feed
+	NotificationStreamEventRead         = "read"         // Notifications were marked read
+	NotificationStreamBufferSize        = 64             // Events queued per stream before they are dropped
+)
+
+// NotificationEvents lists all events a subscription may select. Export,
+// grant, rule and deletion request events are always sent to the export's
+// owner, the grant's holder, the users a rule lists or the users involved in
+// the request, and cannot be subscribed to.
+var NotificationEvents = []string{NotificationEventAssetAdded, NotificationEventMetadataChanged}
+
+// Webhooks
+// Audit entries are POSTed to each active webhook whose events include the
+// entry's action, one delivery per entry, signed with the webhook's secret.
+const (
+	WebhookDeliveryInterval    = 5 * time.Second // How often new audit entries are queued and due deliveries sent
+	WebhookCleanupInterval     = time.Hour       // How often finished deliveries past retention are purged
+	WebhookDeliveryRetention   = 7 * 24 * time.Hour
+	WebhookTimeout             = 10 * time.Second // Per delivery attempt
+	WebhookMaxDeliveryAttempts = 8                // Give up on a delivery after this many failures
+	WebhookRetryBaseDelay      = 30 * time.Second // Delay after the first failure, doubled after each one
+	WebhookRetryMaxDelay       = time.Hour
+	WebhookMaxQueueBatch       = 500 // Audit entries queued per webhook per tick
+	WebhookMaxDeliveryBatch    = 100 // Deliveries sent per webhook per tick
+	WebhookMaxNameLength       = 100
+	WebhookMaxURLLength        = 2048
+	WebhookMinSecretLength     = 16
+	WebhookMaxSecretLength     = 256
+	WebhookSecretBytes         = 32 // Random bytes of a generated secret, hex-encoded
+	WebhookRecentDeliveries    = 20 // Deliveries listed by GET /api/webhooks/:id
+	WebhookUserAgent           = "silobang-webhooks"
+
+	// Request headers of a delivery. The signature is "sha256=" followed by
+	// the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret.
+	WebhookHeaderEvent     = "X-SiloBang-Event"
+	WebhookHeaderDelivery  = "X-SiloBang-Delivery"
+	WebhookHeaderTimestamp = "X-SiloBang-Timestamp"
+	WebhookHeaderSignature = "X-SiloBang-Signature"
+	WebhookSignaturePrefix = "sha256="
+)
+
+// Rules
+// Admin-defined automation stored in the orchestrator DB. A trigger (a
+// schedule or audit events) selects when a rule runs, its condition (a query
+// preset, topics and metadata values) which assets match, and its action
+// what is done to them. Actions run as the system actor.
+const (
+	RuleTriggerSchedule = "schedule" // Runs every interval_secs over the preset's results
+	RuleTriggerEvent    = "event"    // Runs over the assets named by new audit entries
+
+	RuleActionTag        = "tag"        // Set a metadata key
+	RuleActionNotify     = "notify"     // Notify the listed users of the matches
+	RuleActionTombstone  = "tombstone"  // Delete the asset, leaving a tombstone until compaction
+	RuleActionQuarantine = "quarantine" // Withhold the asset until released
+
+	RuleRunSchedule = "schedule" // Run triggers recorded in the run history
+	RuleRunEvent    = "event"
+	RuleRunManual   = "manual"
+
+	RuleTickInterval    = 10 * time.Second // How often due rules run
+	RuleCleanupInterval = time.Hour        // How often runs past retention are purged
+	RuleRunRetention    = 30 * 24 * time.Hour
+	RuleMinIntervalSecs = 60
+	RuleMaxIntervalSecs = 30 * 24 * 3600
+	RuleMaxAssetsPerRun = 1000 // Matches acted on per run; later runs pick up the rest
+	RuleMaxEventBatch   = 500  // Audit entries read per event rule per run
+	RuleMaxNameLength   = 100
+	RuleMaxMetadataKeys = 20 // Metadata values a condition may require
+	RuleMaxNotifyUsers  = 20
+	RuleRunSampleSize   = 100 // Matched hashes kept with a run
+	RuleRecentRuns      = 20  // Runs listed by GET /api/rules/:id
+)
+
+// RuleEventActions are the audit actions event rules can run on: their
+// details name one asset in a hash field.
+var RuleEventActions = []string{
+	AuditActionAddingFile,
+	AuditActionMetadataSet,
+	AuditActionAssetQuarantined,
+	AuditActionAssetReleased,
+}
+
+// Query Federation
+// A coordinator runs a preset locally and on every configured peer instance,
+// merging the rows under an origin column.
+const (
+	FederationDefaultInstanceName = "local"
+	FederationDefaultTimeoutSecs  = 30
+	FederationOriginColumn        = "origin_instance" // Prepended to merged result columns
+	FederationHealthPath          = "/api/queries"    // Peer probe: checks reachability and the configured API key
+	FederationQueryPath           = "/api/query/"
+	FederationMaxResponseBytes    = 64 << 20 // Largest peer response body read
+	FederationMaxPeers            = 32
+	FederationUserAgent           = "silobang-federation"
+
+	FederationPeerStatusOK    = "ok"
+	FederationPeerStatusError = "error"
+)
+
+// Origin Cache
+// An instance configured with an origin fetches the assets it lacks from the
+// origin when they are downloaded, verifies their hash and stores them in
+// the cache topic. Past max_cache_bytes, fetched assets are evicted by the
+// configured policy.
+const (
+	OriginDefaultTopic             = "origin-cache"
+	OriginDefaultTimeoutSecs       = 300
+	OriginMinCacheBytes      int64 = 1 << 20
+	OriginDownloadPath             = "/api/assets/%s/download"
+	OriginUserAgent                = "silobang-origin-cache"
+	OriginStatsRecentEntries       = 20 // Most recently fetched entries listed by the stats endpoint
+
+	OriginEvictionLRU = "lru" // Least recently downloaded first
+	OriginEvictionLFU = "lfu" // Fewest downloads first, then least recently downloaded
+)
+
+// Remote Fetch
+// POST /api/topics/:name/assets/fetch downloads files from URLs into a
+// topic, storing them exactly as uploads.
+const (
+	FetchDefaultMaxURLs     = 20
+	FetchDefaultRetries     = 2   // Attempts after the first, on network errors, 429 and 5xx
+	FetchDefaultTimeoutSecs = 300 // Per attempt, body included
+	FetchMaxRetries         = 10
+	FetchMaxURLLength       = 2048
+	FetchMaxRedirects       = 10
+	FetchRetryBaseDelay     = time.Second // Delay after the first failed attempt, doubled after each one
+	FetchRetryMaxDelay      = 30 * time.Second
+	FetchUserAgent          = "silobang-fetch"
+	FetchDefaultFilename    = "download" // When neither the response nor the URL names the file
+)
+
+// FetchDefaultSchemes are the URL schemes fetched by default.
+var FetchDefaultSchemes = []string{"https", "http"}
+
 // Disk Usage Limits
 const (
 	DefaultMaxDiskUsageBytes int64 = 0          // 0 = unlimited (no disk usage cap)
 	MinMaxDiskUsageBytes     int64 = 1073741824 // 1GB minimum when limit is set
+	StorageReserveBytes      int64 = 16 << 20   // free space uploads may not consume, kept for DB and log writes
+)
+
+// Config Validation (dry-run)
+const (
+	ConfigCheckOK             = "ok"      // Check passed
+	ConfigCheckWarning        = "warning" // Check passed with a caveat; applying is allowed
+	ConfigCheckError          = "error"   // Applying this config would fail or break the instance
+	ConfigCheckSkipped        = "skipped" // Check not applicable (e.g. no working directory)
+	ConfigWriteProbePattern   = ".silobang-write-test-*"
+	ConfigMinFreeDatMultiples = 2 // Warn when free space is below this many max_dat_size
+	MinPort                   = 1
+	MaxPort                   = 65535
+)
+
+// Setup Wizard
+// GET /api/setup/status walks new instances through initialization. Steps are
+// derived from the running state, except the confirmations and skips an
+// administrator records, which are kept in the orchestrator's setup_steps table.
+const (
+	SetupStepWorkingDirectory = "working_directory"
+	SetupStepCredentials      = "credentials" // Admin confirmed the bootstrap credentials are saved
+	SetupStepFirstTopic       = "first_topic"
+	SetupStepTLS              = "tls" // Optional
+
+	SetupStatusComplete   = "complete"
+	SetupStatusIncomplete = "incomplete"
+	SetupStatusSkipped    = "skipped" // Optional step declined
+)
+
+// Debug Endpoints
+// The Go profiler and runtime metrics are served under DebugRoutePrefix when
+// debug.enabled is set, to users holding the debug grant.
+const (
+	DebugRoutePrefix          = "/api/admin/debug/"
+	DebugPprofPrefix          = DebugRoutePrefix + "pprof/"
+	DebugBundleDefaultSeconds = 30  // CPU profile length of a support bundle
+	DebugBundleMaxSeconds     = 120 // Longest CPU profile a bundle may capture
+	DebugBundleFilenamePrefix = "silobang-debug-"
+	DebugGoroutineDumpDebug   = 2 // pprof debug level of the goroutine dump: full stacks
 )
 
 // Compression
@@ -220,9 +1056,10 @@ const (
 
 // Cache Control
 const (
-	CacheControlImmutable  = "public, max-age=86400, immutable"    // For immutable API endpoints (schema, prompts)
-	CacheControlStaticHash = "public, max-age=31536000, immutable" // For hashed static assets (JS, CSS with content hash)
-	CacheControlNoCache    = "no-cache"                            // For index.html (always revalidate)
+	CacheControlImmutable  = "public, max-age=86400, immutable"     // For immutable API endpoints (schema, prompts)
+	CacheControlStaticHash = "public, max-age=31536000, immutable"  // For hashed static assets (JS, CSS with content hash)
+	CacheControlNoCache    = "no-cache"                             // For index.html (always revalidate)
+	CacheControlAsset      = "private, max-age=31536000, immutable" // For content-addressed asset bytes (auth-protected, never change)
 )
 
 // Static Asset Compression
@@ -230,3 +1067,303 @@ const (
 	CompressedFileExtGzip   = ".gz" // Gzip pre-compressed static asset extension
 	CompressedFileExtBrotli = ".br" // Brotli pre-compressed static asset extension
 )
+
+// Bill of Materials (lineage manifest for derived assets)
+const (
+	BOMMaxAssets          = 10000 // Maximum ancestors walked for one manifest
+	BOMFormatJSON         = "json"
+	BOMFormatSPDX         = "spdx"
+	BOMQueryParamFormat   = "format"
+	SPDXVersion           = "SPDX-2.3"
+	SPDXDataLicense       = "CC0-1.0"
+	SPDXDocumentID        = "SPDXRef-DOCUMENT"
+	SPDXFileIDPrefix      = "SPDXRef-File-"
+	SPDXNamespacePrefix   = "urn:silobang:bom:"
+	SPDXChecksumAlgorithm = "BLAKE3"
+	SPDXNoAssertion       = "NOASSERTION"
+	SPDXFileExt           = ".spdx.json"
+)
+
+// Lineage Re-parenting (POST /api/lineage/reparent)
+const (
+	LineageReparentMaxReasonLength = 1024
+)
+
+// Sync Manifest Diff (POST /api/sync/diff)
+const (
+	SyncDiffBatchSize       = 500        // Hashes resolved per orchestrator lookup and flushed together
+	SyncDiffMaxEntries      = 10_000_000 // Manifest entries accepted per request
+	SyncDiffMaxLineBytes    = 4096       // Longest accepted line in a text manifest
+	SyncDiffQueryParamTopic = "topic"    // Expected topic for entries that do not name one
+	SyncDiffManifestField   = "manifest" // Multipart field holding an uploaded manifest file
+	SyncDiffStatusPresent   = "present"
+	SyncDiffStatusMissing   = "missing"
+	SyncDiffStatusElsewhere = "elsewhere" // Present, but in a topic other than the expected one
+	SyncDiffStatusInvalid   = "invalid"   // Not a well-formed hash
+)
+
+// Dat Integrity Scan (recorded asset extents vs. .dat file contents)
+const (
+	IntegrityKindGap        = "gap"         // Unrecorded bytes between two recorded extents
+	IntegrityKindTrailing   = "trailing"    // Unrecorded bytes after the last recorded extent
+	IntegrityKindOverlap    = "overlap"     // Two recorded extents share bytes
+	IntegrityKindBeyondEOF  = "beyond_eof"  // Recorded extent ends past the end of the file
+	IntegrityKindMissingDat = "missing_dat" // Recorded assets reference a .dat file that does not exist
+)
+
+// Asset Deletion and Compaction
+// Deleting an asset leaves a tombstone recording where its entry lies in its
+// .dat file. Compaction moves the live entries of a .dat file holding
+// tombstones to the end of the topic and removes the file. Scheduled passes
+// only compact files where deleted entries take at least
+// CompactionMinDeadPercent of the file; a triggered run compacts them all.
+const (
+	CompactionIntervalMins     = 60  // Scheduled compaction pass interval
+	CompactionMinDeadPercent   = 25  // Share of a .dat file deleted before a scheduled pass compacts it
+	CompactionProgressInterval = 100 // Entries moved between progress events
+	CompactionEventBufferSize  = 64  // Events buffered per stream subscriber
+	CompactionTriggerManual    = "manual"
+	CompactionTriggerScheduled = "scheduled"
+	CompactionEventStart       = "compaction_start"
+	CompactionEventDatProgress = "dat_progress"
+	CompactionEventDatComplete = "dat_complete"
+	CompactionEventComplete    = "compaction_complete"
+	CompactionEventError       = "compaction_error"
+)
+
+// Deletion Requests
+// A deletion request proposes a set of assets, chosen by hash or by query
+// preset, for permanent deletion. It stays pending until another user with
+// delete rights on every topic of the set approves or rejects it, or until
+// deletion_requests.approval_window_hours pass and it expires. Approved
+// requests are executed by the deletion job, which deletes each asset,
+// leaving a tombstone for its .dat entry, and records the outcome per asset.
+const (
+	DeletionRequestStatusPending   = "pending"
+	DeletionRequestStatusApproved  = "approved" // Approved; the deletion job is running or about to
+	DeletionRequestStatusCompleted = "completed"
+	DeletionRequestStatusRejected  = "rejected"
+	DeletionRequestStatusCancelled = "cancelled"
+	DeletionRequestStatusExpired   = "expired"
+
+	DeletionAssetStatusPending = "pending"
+	DeletionAssetStatusDeleted = "deleted"
+	DeletionAssetStatusFailed  = "failed"
+
+	DeletionRequestDefaultWindowHours = 72     // Hours a request waits for a decision before it expires
+	DeletionRequestMaxAssets          = 100000 // Assets one request may propose
+	DeletionRequestMaxReasonLen       = 1024
+	DeletionRequestSweepIntervalMins  = 5 // Expiry of undecided requests and resumption of interrupted jobs
+	DeletionRequestListLimit          = 100
+)
+
+// DeletionRequestStatuses lists the states of a deletion request.
+var DeletionRequestStatuses = []string{
+	DeletionRequestStatusPending, DeletionRequestStatusApproved, DeletionRequestStatusCompleted,
+	DeletionRequestStatusRejected, DeletionRequestStatusCancelled, DeletionRequestStatusExpired,
+}
+
+// Archive Tier
+// Topics assigned an archive in topic_archive export the assets nobody has
+// downloaded for idle_days to that archive: a drop directory picked up by
+// tape tooling, or a bucket in an archival storage class. The asset's row
+// stays as a stub pointing at ArchiveBlobName and its .dat entry is
+// tombstoned for compaction. Downloads of a stub return
+// RETRIEVAL_REQUIRED until a recall copies it back into the topic.
+const (
+	ArchiveIntervalMins      = 60        // Scheduled archive pass and recall polling
+	ArchiveMaxAssetsPerPass  = 1000      // Assets archived per topic and pass
+	ArchiveListMaxResults    = 1000      // Stubs listed per topic
+	ArchiveDefaultIdleDays   = 365       // Days without a download before an asset is archived
+	ArchiveBlobName          = "archive" // blob_name of stub rows; never a .dat file name
+	ArchiveTempPattern       = "archive-*.tmp"
+	ArchiveStatusArchived    = "archived"  // Only in the archive
+	ArchiveStatusRecalling   = "recalling" // Recall requested, waiting for the archive
+	ArchiveStatusStored      = "stored"    // Back in its topic; reported by recalls, never recorded
+	ArchiveTriggerScheduled  = "scheduled"
+	ArchiveTriggerManual     = "manual"
+	ArchiveS3DefaultClass    = "GLACIER"  // storage_class of s3 archives
+	ArchiveS3DefaultTier     = "Standard" // restore_tier of s3 archives
+	ArchiveS3DefaultDays     = 7          // restore_days: how long a restored copy stays readable
+	ArchiveS3ResponseTimeout = 30 * time.Second
+	ArchiveFSRecallSuffix    = ".recall" // Marker asking the filesystem archive's tooling to bring a file back
+	ArchiveRecallErrorMaxLen = 512
+)
+
+// Trash
+// Deleting an asset moves it to the trash: it stays stored but is withheld
+// from downloads, queries and bulk exports until restored. Trashed assets
+// are purged, as by a permanent deletion, once they have been in the trash
+// for trash.retention_days.
+const (
+	TrashPurgeIntervalMins    = 60   // Scheduled purge of expired trash
+	TrashDefaultRetentionDays = 30   // Days a trashed asset is kept before it is purged
+	TrashMaxPurgedPerPass     = 1000 // Assets purged per scheduled pass
+)
+
+// Storage Events
+// /api/events/stream delivers typed change events derived from the audit
+// log. An event's ID is the ID of its audit entry, so a client reconnecting
+// with Last-Event-ID is replayed what it missed, up to
+// EventReplayMaxEvents; older events must be fetched from /api/audit.
+const (
+	EventTypeAssetCreated    = "asset.created"
+	EventTypeAssetTrashed    = "asset.trashed"
+	EventTypeAssetRestored   = "asset.restored"
+	EventTypeAssetDeleted    = "asset.deleted"
+	EventTypeTopicCreated    = "topic.created"
+	EventTypeMetadataChanged = "metadata.changed"
+	EventTypeUserCreated     = "user.created"
+	EventTypeUserUpdated     = "user.updated"
+
+	EventReplayPageSize  = 500
+	EventReplayMaxEvents = 10000
+
+	EventStreamEventConnected       = "connected"        // Sent first: the event types delivered
+	EventStreamEventReplayTruncated = "replay_truncated" // Events after Last-Event-ID were skipped
+)
+
+// Retention Policies
+// A topic's retention policy bounds the age, total size and number of its
+// assets. The scheduled pass removes the oldest assets over a limit, moving
+// them to the trash or deleting them permanently. Assets something
+// references are kept.
+const (
+	RetentionIntervalMins     = 60   // Scheduled evaluation of the retention policies
+	RetentionMaxAssetsPerPass = 1000 // Assets removed per topic and pass
+	RetentionActionTrash      = "trash"
+	RetentionActionDelete     = "delete"
+	RetentionTriggerScheduled = "scheduled"
+	RetentionTriggerManual    = "manual"
+)
+
+// Integrity Scrubber
+// The scrubber re-reads every live asset of the healthy topics, recomputes
+// its BLAKE3 hash and records assets that no longer match or cannot be read
+// in the asset_health table. Corrupt assets are quarantined.
+const (
+	ScrubDefaultIntervalHours = 168 // Scheduled pass interval, weekly
+	ScrubMaxFindingsInStatus  = 100 // Unhealthy assets listed by GET /api/maintenance/scrub
+	ScrubTriggerManual        = "manual"
+	ScrubTriggerScheduled     = "scheduled"
+	ScrubSource               = "scrub" // Source of the scrubber's verified audit entries
+
+	AssetHealthCorrupt = "corrupt" // Content no longer matches its hash
+	AssetHealthMissing = "missing" // Content could not be read
+)
+
+// Backup and Restore
+// POST /api/backup and "silobang backup" write a tar archive of the working
+// directory: SQLite backup API snapshots of the orchestrator and topic
+// databases, the .dat files up to their length when the topic database was
+// snapshotted, and a manifest of every file's BLAKE3 hash written last.
+// "silobang restore" unpacks an archive next to an empty target directory and
+// moves it into place only once every hash and .dat hash chain checks out.
+const (
+	BackupFormatVersion  = 1
+	BackupManifestFile   = "backup-manifest.json"
+	BackupTempDir        = "backups" // Under .internal; database snapshots while an archive is written
+	BackupFilenamePrefix = "silobang-backup-"
+	BackupContentType    = "application/x-tar"
+	RestoreStagingSuffix = ".restoring" // Appended to the target directory while an archive is checked
+)
+
+// Blob Stores
+// Topics assigned to an S3-compatible blob store keep appending to a local
+// .dat file; sealed files (all but the newest) are uploaded by the offload
+// pass and their local copies removed. Reads fall back to the store for
+// files no longer on disk.
+const (
+	BlobStoreLocal               = "local" // Name of the topic directory store; reserved
+	BlobStoreOffloadIntervalMins = 10      // Scheduled offload pass interval
+	BlobStoreResponseTimeout     = 30 * time.Second
+)
+
+// Fault Injection (test builds only, -tags faultinject)
+const (
+	FaultsAPIPath      = "/api/admin/faults" // Admin API for fault rules; never faulted itself
+	FaultIDPrefix      = "fault-"
+	FaultMaxDelayMs    = 60_000 // Longest latency or per-write delay a rule may inject
+	FaultDefaultStatus = 503    // Status of error faults that do not set one
+)
+
+// Route Policies (declarative per-route authentication and authorization)
+const (
+	RouteAuthNone      = "none"     // Unauthenticated (health probes, login)
+	RouteAuthRequired  = "required" // A session or API key
+	RouteAuthPublic    = "public"   // Authenticated, or the anonymous identity in public mode
+	RouteAuthHandler   = "handler"  // The handler authenticates and authorizes itself
+	RouteExplainPath   = "path"     // Query param of the explain endpoint: request path, with its query
+	RouteExplainMethod = "method"   // Query param of the explain endpoint: HTTP method (default GET)
+)
+
+// S3 Gateway (path-style S3 API mapping buckets to topics and keys to filenames)
+const (
+	S3PathPrefix          = "/s3/" // Gateway mount on the main listener; a dedicated s3.port serves it at /
+	S3DefaultRegion       = "us-east-1"
+	S3Service             = "s3"
+	S3AccessKeyPrefix     = "SBAK" // Access key IDs are the prefix and the zero-padded user ID
+	S3AccessKeyIDDigits   = 16
+	S3SecretKeyLength     = 40                   // Secret access keys are truncated to the length of AWS ones
+	S3SecretKeyLabel      = "silobang-s3-secret" // HMAC message deriving the secret from the API key hash
+	S3SigningKeyFile      = "s3-signing.key"     // Server key secrets are derived with, under .internal/ and outside backups
+	S3SigningKeyBytes     = 32                   // Random bytes of the server key
+	S3MaxClockSkew        = 15 * time.Minute     // Signed requests older or newer than this are refused
+	S3MaxKeys             = 1000                 // Objects per listing page
+	S3MaxChunkHeaderBytes = 4096                 // Longest aws-chunked chunk header line
+	S3XMLNamespace        = "http://s3.amazonaws.com/doc/2006-03-01/"
+	S3StorageClass        = "STANDARD"
+	S3TimeFormat          = "2006-01-02T15:04:05.000Z"
+
+	// Signature Version 4
+	S3SigningAlgorithm    = "AWS4-HMAC-SHA256"
+	S3DateFormat          = "20060102T150405Z"
+	S3ScopeTerminator     = "aws4_request"
+	S3UnsignedPayload     = "UNSIGNED-PAYLOAD"
+	S3EmptyPayloadSHA256  = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	S3StreamingPayload    = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	S3StreamingTrailer    = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	S3StreamingUnsigned   = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	S3ChunkAlgorithm      = "AWS4-HMAC-SHA256-PAYLOAD"
+	S3HeaderContentSHA256 = "X-Amz-Content-Sha256"
+	S3HeaderDate          = "X-Amz-Date"
+	S3HeaderDecodedLength = "X-Amz-Decoded-Content-Length"
+	S3HeaderRequestID     = "X-Amz-Request-Id"
+	S3HeaderStorageClass  = "X-Amz-Storage-Class"
+	S3HeaderRestore       = "X-Amz-Restore" // Restore state of an archived object: ongoing-request="true" or "false"
+
+	// Error codes of the S3 API
+	S3ErrAccessDenied                 = "AccessDenied"
+	S3ErrAuthorizationHeaderMalformed = "AuthorizationHeaderMalformed"
+	S3ErrSignatureMismatch            = "SignatureDoesNotMatch"
+	S3ErrInvalidAccessKeyID           = "InvalidAccessKeyId"
+	S3ErrRequestTimeTooSkewed         = "RequestTimeTooSkewed"
+	S3ErrMissingSecurityHeader        = "MissingSecurityHeader"
+	S3ErrContentSHA256Mismatch        = "XAmzContentSHA256Mismatch"
+	S3ErrIncompleteBody               = "IncompleteBody"
+	S3ErrNoSuchBucket                 = "NoSuchBucket"
+	S3ErrNoSuchKey                    = "NoSuchKey"
+	S3ErrInvalidArgument              = "InvalidArgument"
+	S3ErrInvalidRequest               = "InvalidRequest"
+	S3ErrInvalidRange                 = "InvalidRange"
+	S3ErrInvalidObjectState           = "InvalidObjectState"
+	S3ErrEntityTooLarge               = "EntityTooLarge"
+	S3ErrMissingContentLength         = "MissingContentLength"
+	S3ErrNotImplemented               = "NotImplemented"
+	S3ErrServiceUnavailable           = "ServiceUnavailable"
+	S3ErrInternalError                = "InternalError"
+)
+
+// Plugins
+// Backends registered with the plugin package are selected by type in the
+// configuration. These are the types of the in-tree implementations.
+const (
+	PluginBlobStoreS3     = "s3"             // S3-compatible bucket; the blob_stores default
+	PluginScannerCommand  = "command"        // Program reading the upload on stdin; the scan default
+	PluginAuthHeader      = "header"         // Username in a header set by a trusted reverse proxy
+	PluginNotifierWebhook = "webhook"        // Each notification POSTed as JSON
+	PluginValidatorJSON   = "json"           // Uploads parsed as JSON, optionally checked against a JSON Schema
+	PluginArchiveFS       = "filesystem"     // Drop directory, e.g. staged for tape
+	PluginArchiveS3       = "s3"             // S3-compatible bucket with an archival storage class
+	PluginNotifierTimeout = 10 * time.Second // Bound on one Notify call
+)
@@ -17,10 +17,16 @@ const (
 	ErrCodeMetadataError      = "METADATA_ERROR"
 	ErrCodePresetNotFound     = "PRESET_NOT_FOUND"
 	ErrCodeQueryError         = "QUERY_ERROR"
+	ErrCodeQueryTimeout       = "QUERY_TIMEOUT"
 	ErrCodeMissingParam       = "MISSING_PARAM"
 	ErrCodeVerificationFailed = "VERIFICATION_FAILED"
 	ErrCodeStreamingError     = "STREAMING_ERROR"
 
+	// Collections
+	ErrCodeCollectionNotFound      = "COLLECTION_NOT_FOUND"
+	ErrCodeCollectionAlreadyExists = "COLLECTION_ALREADY_EXISTS"
+	ErrCodeInvalidCollectionName   = "INVALID_COLLECTION_NAME"
+
 	// Bulk Download
 	ErrCodeBulkDownloadEmpty     = "BULK_DOWNLOAD_EMPTY"
 	ErrCodeBulkDownloadTooLarge  = "BULK_DOWNLOAD_TOO_LARGE"
@@ -31,11 +37,28 @@ const (
 	ErrCodeDownloadSessionNotFound = "DOWNLOAD_SESSION_NOT_FOUND"
 	ErrCodeDownloadSessionExpired  = "DOWNLOAD_SESSION_EXPIRED"
 	ErrCodeDownloadInProgress      = "DOWNLOAD_IN_PROGRESS"
+	ErrCodeDownloadNotInProgress   = "DOWNLOAD_NOT_IN_PROGRESS"
+	ErrCodeDownloadCancelled       = "DOWNLOAD_CANCELLED"
+
+	// Progress WebSocket
+	ErrCodeOperationCancelled = "OPERATION_CANCELLED"
+	ErrCodeOperationNotFound  = "OPERATION_NOT_FOUND"
+	ErrCodeProgressIDInUse    = "PROGRESS_ID_IN_USE"
+	ErrCodeTooManyConnections = "TOO_MANY_CONNECTIONS"
+	ErrCodeInvalidWSCommand   = "INVALID_WS_COMMAND"
 
 	// Audit Log
-	ErrCodeAuditLogError       = "AUDIT_LOG_ERROR"
-	ErrCodeAuditInvalidAction  = "AUDIT_INVALID_ACTION"
-	ErrCodeAuditInvalidFilter  = "AUDIT_INVALID_FILTER"
+	ErrCodeAuditLogError      = "AUDIT_LOG_ERROR"
+	ErrCodeAuditInvalidAction = "AUDIT_INVALID_ACTION"
+	ErrCodeAuditInvalidFilter = "AUDIT_INVALID_FILTER"
+	ErrCodeAuditHoldNotFound  = "AUDIT_HOLD_NOT_FOUND"
+	ErrCodeAuditInvalidHold   = "AUDIT_INVALID_HOLD"
+
+	// Limits
+	ErrCodeInvalidLimits = "INVALID_LIMITS"
+
+	// Route Policies
+	ErrCodeRouteNotFound = "ROUTE_NOT_FOUND"
 
 	// Batch Metadata
 	ErrCodeBatchTooManyOperations = "BATCH_TOO_MANY_OPERATIONS"
@@ -43,8 +66,13 @@ const (
 	ErrCodeBatchPartialFailure    = "BATCH_PARTIAL_FAILURE"
 
 	// Metadata Validation
-	ErrCodeMetadataKeyTooLong   = "METADATA_KEY_TOO_LONG"
-	ErrCodeMetadataValueTooLong = "METADATA_VALUE_TOO_LONG"
+	ErrCodeMetadataKeyTooLong       = "METADATA_KEY_TOO_LONG"
+	ErrCodeMetadataValueTooLong     = "METADATA_VALUE_TOO_LONG"
+	ErrCodeInvalidMetadataSelection = "INVALID_METADATA_SELECTION"
+
+	// Metadata Import
+	ErrCodeMetadataImportInvalid  = "METADATA_IMPORT_INVALID"   // CSV cannot be parsed or has no usable columns
+	ErrCodeMetadataImportNotFound = "METADATA_IMPORT_NOT_FOUND" // Result file missing or expired
 
 	// Prompts
 	ErrCodePromptNotFound = "PROMPT_NOT_FOUND"
@@ -58,4 +86,146 @@ const (
 
 	// Disk Usage
 	ErrCodeDiskLimitExceeded = "DISK_LIMIT_EXCEEDED"
+	ErrCodeStorageFull       = "STORAGE_FULL" // Not enough headroom for the upload
+
+	// Chunk Dedup Analysis
+	ErrCodeAnalysisInProgress = "ANALYSIS_IN_PROGRESS"
+
+	// Sync Manifest Diff
+	ErrCodeInvalidManifest  = "INVALID_MANIFEST"
+	ErrCodeManifestTooLarge = "MANIFEST_TOO_LARGE"
+
+	// Notifications
+	ErrCodeSubscriptionNotFound = "SUBSCRIPTION_NOT_FOUND"
+
+	// Query Federation
+	ErrCodeFederationDisabled = "FEDERATION_DISABLED"
+	ErrCodePresetNotReadOnly  = "PRESET_NOT_READ_ONLY"
+
+	// Origin Cache
+	ErrCodeOriginFetchFailed  = "ORIGIN_FETCH_FAILED"  // Origin unreachable or refused the asset
+	ErrCodeOriginHashMismatch = "ORIGIN_HASH_MISMATCH" // Fetched bytes do not hash to the requested hash
+
+	// Remote Fetch
+	ErrCodeInvalidFetchURL = "INVALID_FETCH_URL" // Not an absolute URL with an allowed scheme
+	ErrCodeFetchBlocked    = "FETCH_BLOCKED"     // URL resolves to a loopback, private or link-local address
+	ErrCodeFetchFailed     = "FETCH_FAILED"      // Remote unreachable or refused the file after every attempt
+
+	// Idempotency Keys
+	ErrCodeIdempotencyKeyInvalid    = "IDEMPOTENCY_KEY_INVALID"
+	ErrCodeIdempotencyKeyConflict   = "IDEMPOTENCY_KEY_CONFLICT"    // Key reused with a different request
+	ErrCodeIdempotencyKeyInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS" // First request with the key has not finished
+
+	// Export Inbox
+	ErrCodeExportNotFound  = "EXPORT_NOT_FOUND"
+	ErrCodeExportNotReady  = "EXPORT_NOT_READY"  // Export is still building or failed
+	ErrCodeExportInboxFull = "EXPORT_INBOX_FULL" // User's inbox quota would be exceeded
+
+	// Link Exports
+	ErrCodeLinkExportNotFound = "LINK_EXPORT_NOT_FOUND"
+
+	// Resumable Uploads
+	ErrCodeUploadSessionNotFound = "UPLOAD_SESSION_NOT_FOUND"
+	ErrCodeUploadSessionBusy     = "UPLOAD_SESSION_BUSY"    // Another request is writing to or finalizing the session
+	ErrCodeUploadSessionLimit    = "UPLOAD_SESSION_LIMIT"   // User has too many open sessions
+	ErrCodeUploadOffsetMismatch  = "UPLOAD_OFFSET_MISMATCH" // Chunk does not start at the received byte count
+	ErrCodeUploadIncomplete      = "UPLOAD_INCOMPLETE"      // Finalized before all bytes were received
+	ErrCodeUploadHashMismatch    = "UPLOAD_HASH_MISMATCH"   // Received bytes do not hash to the declared BLAKE3
+
+	// Watch Folders
+	ErrCodeInvalidWatchFolder  = "INVALID_WATCH_FOLDER"
+	ErrCodeWatchFolderNotFound = "WATCH_FOLDER_NOT_FOUND"
+
+	// Webhooks
+	ErrCodeInvalidWebhook  = "INVALID_WEBHOOK"
+	ErrCodeWebhookNotFound = "WEBHOOK_NOT_FOUND"
+
+	// Rules
+	ErrCodeInvalidRule  = "INVALID_RULE"
+	ErrCodeRuleNotFound = "RULE_NOT_FOUND"
+
+	// Search
+	ErrCodeSearchUnavailable = "SEARCH_UNAVAILABLE" // Built without SQLite FTS5
+
+	// Feature Flags
+	ErrCodeFeatureDisabled = "FEATURE_DISABLED" // Turned off by features.disabled
+
+	// Debug Endpoints
+	ErrCodeDebugDisabled     = "DEBUG_DISABLED"      // debug.enabled is not set
+	ErrCodeProfileInProgress = "PROFILE_IN_PROGRESS" // Another CPU profile is being captured
+
+	// Setup Wizard
+	ErrCodeInvalidSetupStep = "INVALID_SETUP_STEP" // Step input failed validation
+	ErrCodeSetupStepBlocked = "SETUP_STEP_BLOCKED" // An earlier required step is incomplete
+
+	// Watermarking
+	ErrCodeWatermarkNotFound = "WATERMARK_NOT_FOUND"
+	ErrCodeWatermarkFailed   = "WATERMARK_FAILED" // Image could not be decoded or is too large to transform
+
+	// Storage Policies
+	ErrCodeInvalidStoragePolicy  = "INVALID_STORAGE_POLICY"
+	ErrCodeStoragePolicyNotFound = "STORAGE_POLICY_NOT_FOUND"
+	ErrCodeUploadScanRejected    = "UPLOAD_SCAN_REJECTED" // Scanner flagged the upload
+	ErrCodeUploadScanFailed      = "UPLOAD_SCAN_FAILED"   // Scanner could not run or timed out
+	ErrCodePreviewUnavailable    = "PREVIEW_UNAVAILABLE"  // Previews disabled or unsupported for the asset
+
+	// Upload Validation
+	ErrCodeUploadInvalid          = "UPLOAD_INVALID"           // Validator found the upload invalid in strict mode
+	ErrCodeUploadValidationFailed = "UPLOAD_VALIDATION_FAILED" // Validator could not run or timed out
+
+	// Upload Indexing
+	ErrCodeUploadIndexFailed = "UPLOAD_INDEX_FAILED" // Stored in the topic but not yet indexed; retrying the upload completes it
+
+	// Quarantine
+	ErrCodeAssetQuarantined    = "ASSET_QUARANTINED"     // Asset is withheld until released
+	ErrCodeAssetNotQuarantined = "ASSET_NOT_QUARANTINED" // Release of an asset that is not quarantined
+
+	// Asset Deletion
+	ErrCodeAssetReferenced      = "ASSET_REFERENCED"       // Deletion of an asset other holders keep alive
+	ErrCodeCompactionInProgress = "COMPACTION_IN_PROGRESS" // The topic is already being compacted
+
+	// Deletion Requests
+	ErrCodeDeletionRequestNotFound   = "DELETION_REQUEST_NOT_FOUND"
+	ErrCodeDeletionRequestNotPending = "DELETION_REQUEST_NOT_PENDING" // Decision on a request already decided, cancelled or expired
+	ErrCodeDeletionSelfApproval      = "DELETION_SELF_APPROVAL"       // The requester cannot decide their own request
+
+	// Archive Tier
+	ErrCodeRetrievalRequired        = "RETRIEVAL_REQUIRED"         // The asset is archived; recall it before downloading
+	ErrCodeAssetNotArchived         = "ASSET_NOT_ARCHIVED"         // Recall of an asset that is stored in its topic
+	ErrCodeArchivePolicyNotFound    = "ARCHIVE_POLICY_NOT_FOUND"   // The topic has no archive in topic_archive
+	ErrCodeArchiveHistoryIncomplete = "ARCHIVE_HISTORY_INCOMPLETE" // Audit purges removed downloads within the idle period
+
+	// Trash
+	ErrCodeAssetTrashed    = "ASSET_TRASHED"     // Asset is in the trash until restored or purged
+	ErrCodeAssetNotTrashed = "ASSET_NOT_TRASHED" // Restore or purge of an asset that is not in the trash
+
+	// Retention Policies
+	ErrCodeRetentionPolicyNotFound = "RETENTION_POLICY_NOT_FOUND" // The topic has no retention policy
+
+	// Topic Quotas
+	ErrCodeInvalidTopicQuota  = "INVALID_TOPIC_QUOTA"
+	ErrCodeTopicQuotaExceeded = "TOPIC_QUOTA_EXCEEDED" // Upload would pass the topic's hard quota
+
+	// Frozen Topics
+	ErrCodeTopicFrozen = "TOPIC_FROZEN" // Uploads and metadata writes to a frozen topic are refused
+
+	// Integrity Scrubber
+	ErrCodeScrubInProgress = "SCRUB_IN_PROGRESS" // A scrub pass is already running
+
+	// Backup
+	ErrCodeBackupInProgress = "BACKUP_IN_PROGRESS" // A backup is already being written
+
+	// Lineage Re-parenting
+	ErrCodeLineageReparentInvalid = "LINEAGE_REPARENT_INVALID" // At least one change failed validation; none were applied
+	ErrCodeLineageCycle           = "LINEAGE_CYCLE"            // The change would make an asset its own ancestor
+
+	// Query Builder
+	ErrCodeInvalidQueryFilter = "INVALID_QUERY_FILTER" // The filter tree, selection or sort of a built query is invalid
+
+	// Topic Creation
+	ErrCodeTopicCreationInProgress = "TOPIC_CREATION_IN_PROGRESS" // Another request holds the topic name reservation
+
+	// Fault Injection (test builds only)
+	ErrCodeFaultInjected = "FAULT_INJECTED"
+	ErrCodeFaultNotFound = "FAULT_NOT_FOUND"
 )
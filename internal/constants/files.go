@@ -4,14 +4,19 @@ import "os"
 
 // File Permissions
 const (
-	DirPermissions  os.FileMode = 0755 // Directory creation permissions
-	FilePermissions os.FileMode = 0644 // File creation permissions
+	DirPermissions        os.FileMode = 0755 // Directory creation permissions
+	FilePermissions       os.FileMode = 0644 // File creation permissions
+	SecretFilePermissions os.FileMode = 0600 // Private keys and other files only the owner may read
+	LinkObjectPermissions os.FileMode = 0444 // Extracted copies behind link exports, shared by every link
 )
 
 // Form Field Names (multipart form uploads)
 const (
-	FormFieldFile     = "file"
-	FormFieldParentID = "parent_id"
+	FormFieldFile         = "file"
+	FormFieldParentID     = "parent_id"
+	FormFieldRelativePath = "relative_path" // folder-relative path of the file, e.g. "textures/wood/oak.png"
+
+	MaxUploadBatchFiles = 1000 // Maximum files in one multi-file upload request
 )
 
 // Filename Sanitization
@@ -19,4 +24,6 @@ const (
 	MaxOriginNameLength     = 255 // Maximum allowed length for an asset origin name
 	MaxExtensionLength      = 32  // Maximum allowed length for a file extension
 	FilenameReplacementChar = "_" // Character used to replace invalid characters in filenames
+	MaxRelativePathLength   = 1024
+	MaxRelativePathDepth    = 32 // Maximum number of path segments
 )
@@ -10,9 +10,12 @@ const (
 
 // Content Types
 const (
-	ContentTypeJSON = "application/json"
-	ContentTypeSSE  = "text/event-stream"
-	ContentTypeText = "text/plain; charset=utf-8"
+	ContentTypeJSON   = "application/json"
+	ContentTypeSSE    = "text/event-stream"
+	ContentTypeText   = "text/plain; charset=utf-8"
+	ContentTypeNDJSON = "application/x-ndjson"
+	ContentTypeCSV    = "text/csv; charset=utf-8"
+	ContentTypeYAML   = "application/yaml"
 )
 
 // SSE (Server-Sent Events) Headers
@@ -22,10 +25,47 @@ const (
 	SSEXAccelBuffering = "no"
 )
 
+// HTTP/2 and SSE Connection Limits
+const (
+	HTTP2DefaultMaxConcurrentStreams = 250              // Streams per client connection
+	HTTP2PingInterval                = 30 * time.Second // Idle HTTP/2 connections are pinged to detect dead clients
+	SSEDefaultMaxConnections         = 1000             // Open SSE streams, server-wide
+	SSEDefaultMaxPerClient           = 32               // Open SSE streams per user
+	SSERetryAfterSecs                = 5                // Suggested retry delay when the server-wide cap is reached
+
+	// Endpoints reported in monitoring
+	SSEEndpointAuditStream   = "audit_stream"
+	SSEEndpointVerify        = "verify"
+	SSEEndpointBulkDownload  = "bulk_download"
+	SSEEndpointCompaction    = "compaction"
+	SSEEndpointNotifications = "notifications"
+	SSEEndpointEvents        = "events"
+)
+
+// TLS
+// Certificates are reloaded when their files change, so renewals apply
+// without a restart. Self-signed certificates are kept in the config
+// directory and regenerated shortly before they expire.
+const (
+	TLSReloadCheckInterval   = 10 * time.Second // Certificate files are checked for changes at most this often
+	TLSSelfSignedCertFile    = "tls-self-signed.crt"
+	TLSSelfSignedKeyFile     = "tls-self-signed.key"
+	TLSSelfSignedValidity    = 365 * 24 * time.Hour
+	TLSSelfSignedRenewBefore = 30 * 24 * time.Hour
+	HTTPSDefaultPort         = 443 // Omitted from redirect URLs
+)
+
+// WebSocket (RFC 6455)
+const (
+	WebSocketGUID         = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11" // Handshake key suffix
+	WebSocketVersion      = "13"
+	WebSocketUpgradeToken = "websocket"
+)
+
 // Content-Disposition Headers
 const (
 	ContentDispositionFormat = `attachment; filename="%s"`
-	BulkDownloadZipFilename  = "download.zip"
+	BulkDownloadFilenameBase = "download" // Followed by the archive format, e.g. download.tar.gz
 )
 
 // Transfer Encoding
@@ -41,4 +81,35 @@ const (
 	HeaderConnection         = "Connection"
 	HeaderXAccelBuffering    = "X-Accel-Buffering"
 	HeaderTransferEncoding   = "Transfer-Encoding"
+	HeaderETag               = "ETag"
+	HeaderLastModified       = "Last-Modified"
+	HeaderIfNoneMatch        = "If-None-Match"
+	HeaderIfModifiedSince    = "If-Modified-Since"
+	HeaderUpgrade            = "Upgrade"
+	HeaderSecWebSocketKey    = "Sec-WebSocket-Key"
+	HeaderSecWebSocketAccept = "Sec-WebSocket-Accept"
+	HeaderSecWebSocketVer    = "Sec-WebSocket-Version"
+	HeaderXUploadID          = "X-Upload-ID"
+	HeaderXWatermark         = "X-Watermark"
+	HeaderRetryAfter         = "Retry-After"
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	HeaderLocation           = "Location"
+	HeaderLastEventID        = "Last-Event-ID"
+	HeaderUploadOffset       = "Upload-Offset"
+	HeaderUploadLength       = "Upload-Length"
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"     // Requests a client may make at once (the bucket size)
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining" // Requests left before throttling
+	HeaderRateLimitReset     = "X-RateLimit-Reset"     // Seconds until the allowance is full again
+)
+
+// Idempotency Keys
+// Mutating requests carrying an Idempotency-Key header are recorded per user;
+// retries with the same key and request replay the stored response.
+const (
+	IdempotencyDefaultTTLHours         = 24
+	IdempotencyDefaultMaxResponseBytes = 1 << 20 // Larger responses are not stored; the key is released
+	IdempotencyKeyMaxLength            = 255
+	IdempotencySweepInterval           = 10 * time.Minute // Expired keys are purged at most this often
+	IdempotencySpoolPattern            = "silobang-idempotency-*"
 )
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"silobang/internal/constants"
+)
+
+// ArchivedAsset is an asset exported to an archive, whose row in its topic
+// is a stub until it is recalled.
+type ArchivedAsset struct {
+	Hash              string `json:"hash"`
+	Topic             string `json:"topic"`
+	Archive           string `json:"archive"`
+	ArchiveKey        string `json:"archive_key"`
+	Size              int64  `json:"size"`
+	ArchivedAt        int64  `json:"archived_at"`
+	ArchivedBy        string `json:"archived_by,omitempty"`
+	Status            string `json:"status"` // archived or recalling
+	RecallRequestedBy string `json:"recall_requested_by,omitempty"`
+	RecallRequestedAt int64  `json:"recall_requested_at,omitempty"`
+	RecallError       string `json:"recall_error,omitempty"`
+}
+
+const archivedAssetColumns = `hash, topic, archive, archive_key, size, archived_at, archived_by, status,
+	recall_requested_by, recall_requested_at, recall_error`
+
+// StubAsset turns an asset's row into a stub pointing at the archive and
+// leaves a tombstone for its .dat entry, using the provided transaction.
+// Its metadata is kept.
+func StubAsset(tx *sql.Tx, asset Asset, archivedAt int64, archivedBy string) error {
+	if err := insertTombstone(tx, asset, archivedAt, archivedBy); err != nil {
+		return err
+	}
+	return MoveAsset(tx, asset.AssetID, constants.ArchiveBlobName, 0)
+}
+
+// ListArchiveCandidates returns the assets stored in the topic that were
+// uploaded before the given Unix time, oldest first.
+func ListArchiveCandidates(db *sql.DB, before int64) ([]Asset, error) {
+	rows, err := db.Query(`
+		SELECT asset_id, asset_size, origin_name, parent_id, extension, blob_name, byte_offset, created_at
+		FROM assets WHERE created_at < ? AND blob_name != ? ORDER BY created_at, asset_id
+	`, before, constants.ArchiveBlobName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []Asset
+	for rows.Next() {
+		var asset Asset
+		var originName, parentID sql.NullString
+		if err := rows.Scan(&asset.AssetID, &asset.AssetSize, &originName, &parentID, &asset.Extension,
+			&asset.BlobName, &asset.ByteOffset, &asset.CreatedAt); err != nil {
+			return nil, err
+		}
+		asset.OriginName = originName.String
+		if parentID.Valid {
+			asset.ParentID = &parentID.String
+		}
+		assets = append(assets, asset)
+	}
+	return assets, rows.Err()
+}
+
+// InsertArchivedAssetTx records an archived asset using the provided
+// transaction.
+func InsertArchivedAssetTx(tx *sql.Tx, a ArchivedAsset) error {
+	if _, err := tx.Exec(`
+		INSERT INTO archived_assets (hash, topic, archive, archive_key, size, archived_at, archived_by, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, a.Hash, a.Topic, a.Archive, a.ArchiveKey, a.Size, a.ArchivedAt, a.ArchivedBy, constants.ArchiveStatusArchived); err != nil {
+		return fmt.Errorf("failed to record archived asset: %w", err)
+	}
+	return nil
+}
+
+// GetArchivedAsset returns the archive record of hash, or nil if the asset
+// is not archived.
+func GetArchivedAsset(db *sql.DB, hash string) (*ArchivedAsset, error) {
+	archived, err := scanArchivedAssets(db.Query("SELECT "+archivedAssetColumns+" FROM archived_assets WHERE hash = ?", hash))
+	if err != nil || len(archived) == 0 {
+		return nil, err
+	}
+	return &archived[0], nil
+}
+
+// ListArchivedAssets returns up to limit archived assets of a topic, most
+// recently archived first. status filters when not empty.
+func ListArchivedAssets(db *sql.DB, topic, status string, limit int) ([]ArchivedAsset, error) {
+	query := "SELECT " + archivedAssetColumns + " FROM archived_assets WHERE topic = ?"
+	args := []interface{}{topic}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY archived_at DESC, hash LIMIT ?"
+	args = append(args, limit)
+
+	archived, err := scanArchivedAssets(db.Query(query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived assets: %w", err)
+	}
+	return archived, nil
+}
+
+// ListRecallingAssets returns the archived assets with a recall under way,
+// oldest request first.
+func ListRecallingAssets(db *sql.DB) ([]ArchivedAsset, error) {
+	archived, err := scanArchivedAssets(db.Query("SELECT "+archivedAssetColumns+
+		" FROM archived_assets WHERE status = ? ORDER BY recall_requested_at, hash", constants.ArchiveStatusRecalling))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recalls: %w", err)
+	}
+	return archived, nil
+}
+
+// RequestRecall marks an archived asset as being recalled. A recall already
+// under way is left as it is. Returns the updated record, or nil if the
+// asset is not archived.
+func RequestRecall(db *sql.DB, hash, by string, at int64) (*ArchivedAsset, error) {
+	if _, err := db.Exec(`
+		UPDATE archived_assets SET status = ?, recall_requested_by = ?, recall_requested_at = ?, recall_error = ''
+		WHERE hash = ? AND status = ?
+	`, constants.ArchiveStatusRecalling, by, at, hash, constants.ArchiveStatusArchived); err != nil {
+		return nil, fmt.Errorf("failed to request recall: %w", err)
+	}
+	return GetArchivedAsset(db, hash)
+}
+
+// SetRecallError records why the last attempt to recall an asset failed.
+func SetRecallError(db *sql.DB, hash, msg string) error {
+	if _, err := db.Exec("UPDATE archived_assets SET recall_error = ? WHERE hash = ?", msg, hash); err != nil {
+		return fmt.Errorf("failed to record recall error: %w", err)
+	}
+	return nil
+}
+
+// DeleteArchivedAssetTx drops the archive record of hash, if any, once the
+// asset is recalled or deleted.
+func DeleteArchivedAssetTx(tx *sql.Tx, hash string) error {
+	if _, err := tx.Exec("DELETE FROM archived_assets WHERE hash = ?", hash); err != nil {
+		return fmt.Errorf("failed to drop archive record: %w", err)
+	}
+	return nil
+}
+
+func scanArchivedAssets(rows *sql.Rows, err error) ([]ArchivedAsset, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	archived := []ArchivedAsset{}
+	for rows.Next() {
+		var a ArchivedAsset
+		if err := rows.Scan(&a.Hash, &a.Topic, &a.Archive, &a.ArchiveKey, &a.Size, &a.ArchivedAt, &a.ArchivedBy, &a.Status,
+			&a.RecallRequestedBy, &a.RecallRequestedAt, &a.RecallError); err != nil {
+			return nil, err
+		}
+		archived = append(archived, a)
+	}
+	return archived, rows.Err()
+}
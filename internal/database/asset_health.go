@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// AssetHealthEntry is an asset the integrity scrubber found damaged.
+type AssetHealthEntry struct {
+	Hash       string `json:"hash"`
+	Topic      string `json:"topic"`
+	Status     string `json:"status"` // constants.AssetHealth*
+	Detail     string `json:"detail"`
+	DetectedAt int64  `json:"detected_at"`
+	CheckedAt  int64  `json:"checked_at"`
+}
+
+// ReplaceTopicAssetHealth replaces the damaged assets recorded for a topic
+// with those found by a pass at checkedAt. Assets already recorded keep the
+// time they were first found.
+func ReplaceTopicAssetHealth(db *sql.DB, topic string, entries []AssetHealthEntry, checkedAt int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT hash, detected_at FROM asset_health WHERE topic = ?", topic)
+	if err != nil {
+		return err
+	}
+	detected := make(map[string]int64)
+	for rows.Next() {
+		var hash string
+		var at int64
+		if err := rows.Scan(&hash, &at); err != nil {
+			rows.Close()
+			return err
+		}
+		detected[hash] = at
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM asset_health WHERE topic = ?", topic); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		detectedAt, ok := detected[e.Hash]
+		if !ok {
+			detectedAt = checkedAt
+		}
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO asset_health (hash, topic, status, detail, detected_at, checked_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, e.Hash, topic, e.Status, e.Detail, detectedAt, checkedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListAssetHealth returns up to limit damaged assets, most recently found
+// first, and how many are recorded in total.
+func ListAssetHealth(db *sql.DB, limit int) ([]AssetHealthEntry, int, error) {
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM asset_health").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Query(`
+		SELECT hash, topic, status, detail, detected_at, checked_at
+		FROM asset_health ORDER BY detected_at DESC, hash LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := make([]AssetHealthEntry, 0)
+	for rows.Next() {
+		var e AssetHealthEntry
+		if err := rows.Scan(&e.Hash, &e.Topic, &e.Status, &e.Detail, &e.DetectedAt, &e.CheckedAt); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
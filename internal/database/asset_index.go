@@ -0,0 +1,71 @@
+package database
+
+import "database/sql"
+
+// AssetIndex is the orchestrator's record of which topic and .dat file hold
+// each asset. Writes that must commit together with other orchestrator
+// tables take the caller's transaction.
+//
+// SQLiteAssetIndex is the only implementation; services reach the index
+// through AppState.GetAssetIndex so another backend can replace it.
+type AssetIndex interface {
+	// Lookup reports whether hash is indexed and where.
+	Lookup(hash string) (exists bool, topic string, datFile string, err error)
+	// LookupTopics maps the indexed hashes among hashes to their topic.
+	LookupTopics(hashes []string) (map[string]string, error)
+	// IndexTopic indexes the assets and references of a discovered topic.
+	IndexTopic(topic string, assets []AssetIndexEntry, refs []AssetReference) error
+	// ListTopics returns the topics holding indexed assets.
+	ListTopics() ([]string, error)
+	// DeleteTopic removes the entries of a topic and returns how many there were.
+	DeleteTopic(topic string) (int64, error)
+
+	// Insert, Delete and Move write within the caller's transaction.
+	Insert(tx *sql.Tx, hash, topic, datFile string) error
+	Delete(tx *sql.Tx, hash string) error
+	Move(tx *sql.Tx, hash, datFile string) error
+}
+
+// SQLiteAssetIndex is the AssetIndex of a SQLite orchestrator database.
+type SQLiteAssetIndex struct {
+	db *sql.DB
+}
+
+var _ AssetIndex = (*SQLiteAssetIndex)(nil)
+
+// NewSQLiteAssetIndex returns the asset index stored in db.
+func NewSQLiteAssetIndex(db *sql.DB) *SQLiteAssetIndex {
+	return &SQLiteAssetIndex{db: db}
+}
+
+func (x *SQLiteAssetIndex) Lookup(hash string) (bool, string, string, error) {
+	return CheckHashExists(x.db, hash)
+}
+
+func (x *SQLiteAssetIndex) LookupTopics(hashes []string) (map[string]string, error) {
+	return LookupHashTopics(x.db, hashes)
+}
+
+func (x *SQLiteAssetIndex) IndexTopic(topic string, assets []AssetIndexEntry, refs []AssetReference) error {
+	return IndexTopic(x.db, topic, assets, refs)
+}
+
+func (x *SQLiteAssetIndex) ListTopics() ([]string, error) {
+	return ListIndexedTopics(x.db)
+}
+
+func (x *SQLiteAssetIndex) DeleteTopic(topic string) (int64, error) {
+	return DeleteAssetIndexByTopic(x.db, topic)
+}
+
+func (x *SQLiteAssetIndex) Insert(tx *sql.Tx, hash, topic, datFile string) error {
+	return InsertAssetIndex(tx, hash, topic, datFile)
+}
+
+func (x *SQLiteAssetIndex) Delete(tx *sql.Tx, hash string) error {
+	return DeleteAssetIndex(tx, hash)
+}
+
+func (x *SQLiteAssetIndex) Move(tx *sql.Tx, hash, datFile string) error {
+	return MoveAssetIndex(tx, hash, datFile)
+}
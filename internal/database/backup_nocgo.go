@@ -0,0 +1,17 @@
+//go:build !cgo
+
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// BackupDatabase copies a consistent snapshot of db to a new database file at
+// destPath. The online backup API needs the cgo build of the SQLite driver,
+// so this build writes the snapshot with VACUUM INTO, which holds a read
+// transaction on db for the length of the copy.
+func BackupDatabase(ctx context.Context, db *sql.DB, destPath string) error {
+	_, err := db.ExecContext(ctx, "VACUUM INTO ?", destPath)
+	return err
+}
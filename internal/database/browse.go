@@ -0,0 +1,270 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// browseTriggers are the names of the triggers maintaining metadata_keys
+var browseTriggers = []string{
+	"metadata_keys_insert",
+	"metadata_keys_update",
+	"metadata_keys_delete",
+}
+
+// GetTopicBrowseSchema returns the SQL creating what the paginated asset
+// browser of a topic database reads: indexes for every sort order, alone
+// and after an extension filter, and metadata_keys, the current metadata
+// keys of each asset, kept current by triggers on metadata_computed.
+// Secondary indexes end with the rowid, which breaks ties between pages.
+func GetTopicBrowseSchema() string {
+	newKeys := `
+    DELETE FROM metadata_keys WHERE asset_id = new.asset_id;
+    INSERT OR IGNORE INTO metadata_keys (key, asset_id)
+    SELECT j.key, new.asset_id FROM json_each(new.metadata_json) j;`
+	return `
+CREATE INDEX IF NOT EXISTS idx_assets_size ON assets(asset_size);
+CREATE INDEX IF NOT EXISTS idx_assets_extension_created ON assets(extension, created_at);
+CREATE INDEX IF NOT EXISTS idx_assets_extension_size ON assets(extension, asset_size);
+CREATE INDEX IF NOT EXISTS idx_assets_extension_name ON assets(extension, origin_name);
+
+-- metadata_keys table (derived from metadata_computed)
+CREATE TABLE IF NOT EXISTS metadata_keys (
+    key TEXT NOT NULL,
+    asset_id TEXT NOT NULL,
+    PRIMARY KEY (key, asset_id)
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS idx_metadata_keys_asset ON metadata_keys(asset_id);
+
+CREATE TRIGGER IF NOT EXISTS metadata_keys_insert AFTER INSERT ON metadata_computed
+BEGIN` + newKeys + `
+END;
+
+CREATE TRIGGER IF NOT EXISTS metadata_keys_update AFTER UPDATE ON metadata_computed
+BEGIN` + newKeys + `
+END;
+
+CREATE TRIGGER IF NOT EXISTS metadata_keys_delete AFTER DELETE ON metadata_computed
+BEGIN
+    DELETE FROM metadata_keys WHERE asset_id = old.asset_id;
+END;
+`
+}
+
+// InitTopicBrowse creates the browse indexes and metadata_keys of a topic
+// database, filling metadata_keys from metadata_computed when its triggers
+// were missing.
+func InitTopicBrowse(db *sql.DB) error {
+	var existing int
+	if err := db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ("+placeholders(len(browseTriggers))+")",
+		stringArgs(browseTriggers)...,
+	).Scan(&existing); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(GetTopicBrowseSchema()); err != nil {
+		return err
+	}
+	if existing < len(browseTriggers) {
+		if _, err := tx.Exec("DELETE FROM metadata_keys"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO metadata_keys (key, asset_id)
+			SELECT j.key, m.asset_id FROM metadata_computed m, json_each(m.metadata_json) j
+		`); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Browse sort columns
+const (
+	BrowseSortCreated = "created_at"
+	BrowseSortSize    = "size"
+	BrowseSortName    = "name"
+)
+
+// browseSortColumns maps the browse sort orders to their columns
+var browseSortColumns = map[string]string{
+	BrowseSortCreated: "created_at",
+	BrowseSortSize:    "asset_size",
+	BrowseSortName:    "origin_name",
+}
+
+// IsBrowseSort reports whether sort is a sort order ListTopicAssets knows.
+func IsBrowseSort(sort string) bool {
+	_, ok := browseSortColumns[sort]
+	return ok
+}
+
+// BrowseFilter narrows and orders a page of a topic's assets.
+type BrowseFilter struct {
+	Sort           string // One of the BrowseSort columns
+	Descending     bool
+	Extension      string          // Exact extension (empty = any)
+	HasMetadataKey string          // Current metadata key the assets must have (empty = any)
+	Since          int64           // created_at lower bound, inclusive (0 = unbounded)
+	Until          int64           // created_at upper bound, inclusive (0 = unbounded)
+	After          *BrowseCursor   // Position of the last asset of the previous page
+	Exclude        map[string]bool // Assets left out, e.g. quarantined ones
+}
+
+// BrowseCursor is the position of an asset in a browse order: its sort
+// column value and its rowid.
+type BrowseCursor struct {
+	Int   int64  `json:"i,omitempty"` // created_at and size
+	Text  string `json:"t,omitempty"` // name
+	RowID int64  `json:"r"`
+}
+
+// BrowsedAsset is an asset of a browse page with its position.
+type BrowsedAsset struct {
+	Asset
+	Cursor BrowseCursor
+}
+
+// where returns the conditions and arguments of the filter, without the
+// cursor, so the same conditions count the total.
+func (f BrowseFilter) where() (string, []interface{}) {
+	conds := []string{"1 = 1"}
+	var args []interface{}
+	if f.Extension != "" {
+		conds = append(conds, "extension = ?")
+		args = append(args, f.Extension)
+	}
+	if f.HasMetadataKey != "" {
+		conds = append(conds, "asset_id IN (SELECT asset_id FROM metadata_keys WHERE key = ?)")
+		args = append(args, f.HasMetadataKey)
+	}
+	if f.Since > 0 {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, f.Since)
+	}
+	if f.Until > 0 {
+		conds = append(conds, "created_at <= ?")
+		args = append(args, f.Until)
+	}
+	if len(f.Exclude) > 0 {
+		excluded := make([]string, 0, len(f.Exclude))
+		for hash := range f.Exclude {
+			excluded = append(excluded, hash)
+		}
+		conds = append(conds, "asset_id NOT IN ("+placeholders(len(excluded))+")")
+		args = append(args, stringArgs(excluded)...)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// ListTopicAssets returns the next limit assets of a topic database in the
+// filter's order, after the filter's cursor, and the number of assets
+// matching the filter on every page. Both read indexes only, so pages stay
+// fast however deep they are.
+func ListTopicAssets(db *sql.DB, filter BrowseFilter, limit int) ([]BrowsedAsset, int64, error) {
+	column, ok := browseSortColumns[filter.Sort]
+	if !ok {
+		column = browseSortColumns[BrowseSortCreated]
+	}
+	where, args := filter.where()
+
+	var total int64
+	if err := db.QueryRow("SELECT COUNT(*) FROM assets WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	direction, compare := "ASC", ">"
+	if filter.Descending {
+		direction, compare = "DESC", "<"
+	}
+	if filter.After != nil {
+		where += " AND (" + column + ", rowid) " + compare + " (?, ?)"
+		if filter.Sort == BrowseSortName {
+			args = append(args, filter.After.Text, filter.After.RowID)
+		} else {
+			args = append(args, filter.After.Int, filter.After.RowID)
+		}
+	}
+	args = append(args, limit)
+
+	rows, err := db.Query(`
+		SELECT rowid, asset_id, asset_size, COALESCE(origin_name, ''), parent_id, extension, blob_name, byte_offset, created_at
+		FROM assets WHERE `+where+`
+		ORDER BY `+column+` `+direction+`, rowid `+direction+`
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	assets := make([]BrowsedAsset, 0)
+	for rows.Next() {
+		var a BrowsedAsset
+		var pid sql.NullString
+		if err := rows.Scan(
+			&a.Cursor.RowID,
+			&a.AssetID,
+			&a.AssetSize,
+			&a.OriginName,
+			&pid,
+			&a.Extension,
+			&a.BlobName,
+			&a.ByteOffset,
+			&a.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		if pid.Valid {
+			a.ParentID = &pid.String
+		}
+		switch filter.Sort {
+		case BrowseSortSize:
+			a.Cursor.Int = a.AssetSize
+		case BrowseSortName:
+			a.Cursor.Text = a.OriginName
+		default:
+			a.Cursor.Int = a.CreatedAt
+		}
+		assets = append(assets, a)
+	}
+	return assets, total, rows.Err()
+}
+
+// MetadataKeyCount is a current metadata key and the number of assets that
+// have it.
+type MetadataKeyCount struct {
+	Key    string
+	Assets int64
+}
+
+// ListMetadataKeys returns the limit current metadata keys held by the most
+// assets of a topic database, most used first.
+func ListMetadataKeys(db *sql.DB, limit int) ([]MetadataKeyCount, error) {
+	rows, err := db.Query(`
+		SELECT key, COUNT(*) AS assets FROM metadata_keys
+		GROUP BY key ORDER BY assets DESC, key LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []MetadataKeyCount
+	for rows.Next() {
+		var k MetadataKeyCount
+		if err := rows.Scan(&k.Key, &k.Assets); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
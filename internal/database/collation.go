@@ -0,0 +1,36 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/mattn/go-sqlite3"
+
+	"silobang/internal/collate"
+	"silobang/internal/constants"
+)
+
+func init() {
+	sql.Register(constants.SQLiteDriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc(constants.CollationFoldFunction, foldSQL, true)
+		},
+	})
+}
+
+// foldSQL implements silo_fold(text, spec). It returns text folded with the
+// collation spec, or text unchanged when spec is NULL so presets keep their
+// byte-wise behavior on topics without a collation.
+func foldSQL(value, spec interface{}) interface{} {
+	text, ok := value.(string)
+	if !ok {
+		if b, isBytes := value.([]byte); isBytes && b == nil {
+			return nil // NULL
+		}
+		return value
+	}
+	s, ok := spec.(string)
+	if !ok {
+		return text
+	}
+	return collate.ParseSpec(s).Fold(text)
+}
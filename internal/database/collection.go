@@ -0,0 +1,287 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// Collection represents a named grouping of assets within a topic
+type Collection struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	AssetCount  int64  `json:"asset_count"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+}
+
+// InsertCollection creates a new collection row
+func InsertCollection(db *sql.DB, name, description string, now int64) error {
+	_, err := db.Exec(`
+		INSERT INTO collections (name, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+	`, name, description, now, now)
+	return err
+}
+
+// GetCollection returns a single collection with its asset count, or nil if not found
+func GetCollection(db *sql.DB, name string) (*Collection, error) {
+	var c Collection
+	err := db.QueryRow(`
+		SELECT c.name, c.description, c.created_at, c.updated_at,
+			(SELECT COUNT(*) FROM collection_assets ca WHERE ca.collection = c.name)
+		FROM collections c WHERE c.name = ?
+	`, name).Scan(&c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt, &c.AssetCount)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// ListCollections returns all collections in the topic ordered by name
+func ListCollections(db *sql.DB) ([]Collection, error) {
+	rows, err := db.Query(`
+		SELECT c.name, c.description, c.created_at, c.updated_at, COUNT(ca.asset_id)
+		FROM collections c
+		LEFT JOIN collection_assets ca ON ca.collection = c.name
+		GROUP BY c.name
+		ORDER BY c.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := make([]Collection, 0)
+	for rows.Next() {
+		var c Collection
+		if err := rows.Scan(&c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt, &c.AssetCount); err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+
+	return collections, rows.Err()
+}
+
+// UpdateCollectionDescription updates the description of a collection.
+// Returns false if the collection does not exist.
+func UpdateCollectionDescription(db *sql.DB, name, description string, now int64) (bool, error) {
+	res, err := db.Exec(`
+		UPDATE collections SET description = ?, updated_at = ? WHERE name = ?
+	`, description, now, name)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// DeleteCollection removes a collection and its memberships in a single transaction.
+// Assets themselves are never touched. Returns the number of memberships removed
+// and false if the collection does not exist.
+func DeleteCollection(db *sql.DB, name string) (int64, bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM collection_assets WHERE collection = ?", name)
+	if err != nil {
+		return 0, false, err
+	}
+	freed, err := res.RowsAffected()
+	if err != nil {
+		return 0, false, err
+	}
+
+	res, err = tx.Exec("DELETE FROM collections WHERE name = ?", name)
+	if err != nil {
+		return 0, false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, false, err
+	}
+	if affected == 0 {
+		return 0, false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return freed, true, nil
+}
+
+// AddCollectionAssets adds assets to a collection atomically.
+// Assets already in the collection are ignored. Returns the number of new memberships.
+func AddCollectionAssets(db *sql.DB, name string, assetIDs []string, now int64) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO collection_assets (collection, asset_id, added_at) VALUES (?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var added int64
+	for _, id := range assetIDs {
+		res, err := stmt.Exec(name, id, now)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		added += n
+	}
+
+	if _, err := tx.Exec("UPDATE collections SET updated_at = ? WHERE name = ?", now, name); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// RemoveCollectionAssets removes assets from a collection atomically.
+// Returns the number of memberships removed.
+func RemoveCollectionAssets(db *sql.DB, name string, assetIDs []string, now int64) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("DELETE FROM collection_assets WHERE collection = ? AND asset_id = ?")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var removed int64
+	for _, id := range assetIDs {
+		res, err := stmt.Exec(name, id)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		removed += n
+	}
+
+	if _, err := tx.Exec("UPDATE collections SET updated_at = ? WHERE name = ?", now, name); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// GetCollectionAssetIDs returns the asset IDs in a collection ordered by the time they were added
+func GetCollectionAssetIDs(db *sql.DB, name string, limit, offset int) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT asset_id FROM collection_assets
+		WHERE collection = ?
+		ORDER BY added_at, asset_id
+		LIMIT ? OFFSET ?
+	`, name, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetCollectionAssetSet returns all asset IDs in a collection as a set for filtering
+func GetCollectionAssetSet(db *sql.DB, name string) (map[string]struct{}, error) {
+	rows, err := db.Query("SELECT asset_id FROM collection_assets WHERE collection = ?", name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	set := make(map[string]struct{})
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		set[id] = struct{}{}
+	}
+	return set, rows.Err()
+}
+
+// GetAssetCollections returns the names of all collections an asset belongs to, sorted by name
+func GetAssetCollections(db *sql.DB, assetID string) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT collection FROM collection_assets WHERE asset_id = ? ORDER BY collection
+	`, assetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// FilterExistingAssets returns the subset of asset IDs that exist in the topic's assets table
+func FilterExistingAssets(db *sql.DB, assetIDs []string) ([]string, []string, error) {
+	stmt, err := db.Prepare("SELECT EXISTS(SELECT 1 FROM assets WHERE asset_id = ?)")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stmt.Close()
+
+	var found, missing []string
+	for _, id := range assetIDs {
+		var exists bool
+		if err := stmt.QueryRow(id).Scan(&exists); err != nil {
+			return nil, nil, err
+		}
+		if exists {
+			found = append(found, id)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return found, missing, nil
+}
@@ -4,17 +4,18 @@ import (
 	"database/sql"
 	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"silobang/internal/constants"
 )
 
 // OpenDatabase opens a SQLite database at the given path and applies pragmas
 // Uses _txlock=immediate to ensure transactions acquire write locks immediately,
 // preventing race conditions in read-then-write operations like hash chain updates.
+// Connections have the silo_fold SQL function registered (see collation.go).
 func OpenDatabase(path string) (*sql.DB, error) {
 	// _txlock=immediate ensures that BEGIN starts with RESERVED lock,
 	// which serializes write transactions and prevents the hash chain race condition.
 	// This is critical for maintaining dat_hashes consistency during concurrent uploads.
-	db, err := sql.Open("sqlite3", path+"?_txlock=immediate")
+	db, err := sql.Open(constants.SQLiteDriverName, path+"?_txlock=immediate")
 	if err != nil {
 		return nil, err
 	}
@@ -40,6 +41,14 @@ func InitTopicDB(path string) (*sql.DB, error) {
 		db.Close()
 		return nil, err
 	}
+	if err := InitTopicBrowse(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := InitTopicSearch(db); err != nil {
+		db.Close()
+		return nil, err
+	}
 
 	return db, nil
 }
@@ -74,5 +83,21 @@ func migrateOrchestratorDB(db *sql.DB) error {
 	if err != nil && !strings.Contains(err.Error(), "duplicate column") {
 		return err
 	}
+
+	// Migration: add actor_type to audit_log and account_type to auth_users
+	// (added for service accounts)
+	for _, stmt := range []string{
+		`ALTER TABLE audit_log ADD COLUMN actor_type TEXT NOT NULL DEFAULT 'user'`,
+		`ALTER TABLE auth_users ADD COLUMN account_type TEXT NOT NULL DEFAULT 'user'`,
+		// Hash chain over audit entries; older entries keep empty hashes
+		`ALTER TABLE audit_log ADD COLUMN prev_hash TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE audit_log ADD COLUMN entry_hash TEXT NOT NULL DEFAULT ''`,
+		// Expiry of the API key on the account; NULL never expires
+		`ALTER TABLE auth_users ADD COLUMN api_key_expires_at INTEGER`,
+	} {
+		if _, err := db.Exec(stmt); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			return err
+		}
+	}
 	return nil
 }
@@ -101,8 +101,9 @@ func VerifyDatHash(db *sql.DB, datFile, topicPath string) (bool, error) {
 // VerifyAllDatHashes verifies all .dat files in the topic
 // Returns list of mismatched files (empty = all good)
 func VerifyAllDatHashes(db *sql.DB, topicPath string) ([]string, error) {
-	// Query all dat_hashes entries
-	rows, err := db.Query("SELECT dat_file FROM dat_hashes")
+	// Query all dat_hashes entries of local files; offloaded files were
+	// verified before upload
+	rows, err := db.Query("SELECT dat_file FROM dat_hashes WHERE dat_file NOT IN (SELECT dat_file FROM dat_offloads)")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,228 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"silobang/internal/constants"
+)
+
+// ErrDeletionRequestNotPending is returned when deciding a deletion request
+// that was already decided, cancelled or expired
+var ErrDeletionRequestNotPending = errors.New("deletion request is not pending")
+
+// DeletionRequest is a set of assets proposed for permanent deletion
+type DeletionRequest struct {
+	ID            int64    `json:"id"`
+	Status        string   `json:"status"`
+	Mode          string   `json:"mode"` // ids or query
+	Preset        string   `json:"preset,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
+	Topics        []string `json:"topics"`
+	AssetCount    int      `json:"asset_count"`
+	TotalBytes    int64    `json:"total_bytes"`
+	RequestedBy   string   `json:"requested_by"`
+	RequestedByID int64    `json:"-"`
+	RequestedAt   int64    `json:"requested_at"`
+	ExpiresAt     int64    `json:"expires_at"`
+	DecidedBy     string   `json:"decided_by,omitempty"`
+	DecidedByID   int64    `json:"-"`
+	DecidedAt     int64    `json:"decided_at,omitempty"`
+	Comment       string   `json:"comment,omitempty"`
+	CompletedAt   int64    `json:"completed_at,omitempty"`
+	Deleted       int      `json:"deleted"`
+	Failed        int      `json:"failed"`
+}
+
+// DeletionRequestAsset is one asset of a deletion request and the outcome
+// of its deletion
+type DeletionRequestAsset struct {
+	Hash   string `json:"hash"`
+	Topic  string `json:"topic"`
+	Size   int64  `json:"size"`
+	Status string `json:"status"` // pending, deleted or failed
+	Error  string `json:"error,omitempty"`
+}
+
+const deletionRequestColumns = `id, status, mode, preset, reason, topics_json, asset_count, total_bytes,
+	requested_by, requested_by_id, requested_at, expires_at, decided_by, decided_by_id, decided_at,
+	comment, completed_at, deleted, failed`
+
+// CreateDeletionRequest stores r as a pending request for assets and
+// returns its ID.
+func CreateDeletionRequest(db *sql.DB, r *DeletionRequest, assets []DeletionRequestAsset) (int64, error) {
+	topics, _ := json.Marshal(r.Topics)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO deletion_requests (status, mode, preset, reason, topics_json, asset_count, total_bytes,
+			requested_by, requested_by_id, requested_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, constants.DeletionRequestStatusPending, r.Mode, r.Preset, r.Reason, string(topics), r.AssetCount, r.TotalBytes,
+		r.RequestedBy, r.RequestedByID, r.RequestedAt, r.ExpiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create deletion request: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO deletion_request_assets (request_id, hash, topic, size) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, a := range assets {
+		if _, err := stmt.Exec(id, a.Hash, a.Topic, a.Size); err != nil {
+			return 0, fmt.Errorf("failed to record deletion request asset: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetDeletionRequest returns a deletion request, or nil if none has the ID.
+func GetDeletionRequest(db *sql.DB, id int64) (*DeletionRequest, error) {
+	requests, err := scanDeletionRequests(db.Query("SELECT "+deletionRequestColumns+" FROM deletion_requests WHERE id = ?", id))
+	if err != nil || len(requests) == 0 {
+		return nil, err
+	}
+	return &requests[0], nil
+}
+
+// ListDeletionRequests returns up to limit deletion requests, newest first.
+// status filters when not empty.
+func ListDeletionRequests(db *sql.DB, status string, limit int) ([]DeletionRequest, error) {
+	query := "SELECT " + deletionRequestColumns + " FROM deletion_requests"
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	requests, err := scanDeletionRequests(db.Query(query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deletion requests: %w", err)
+	}
+	return requests, nil
+}
+
+// ListExpiredDeletionRequests returns the pending requests whose approval
+// window ended at or before now, oldest first.
+func ListExpiredDeletionRequests(db *sql.DB, now int64) ([]DeletionRequest, error) {
+	requests, err := scanDeletionRequests(db.Query("SELECT "+deletionRequestColumns+
+		" FROM deletion_requests WHERE status = ? AND expires_at <= ? ORDER BY id",
+		constants.DeletionRequestStatusPending, now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired deletion requests: %w", err)
+	}
+	return requests, nil
+}
+
+// DecideDeletionRequest moves a pending request to status, recording who
+// decided and when. by is empty for requests that expire. Returns
+// ErrDeletionRequestNotPending when the request is no longer pending.
+func DecideDeletionRequest(db *sql.DB, id int64, status, by string, byID int64, comment string, at int64) error {
+	result, err := db.Exec(`
+		UPDATE deletion_requests SET status = ?, decided_by = ?, decided_by_id = ?, decided_at = ?, comment = ?
+		WHERE id = ? AND status = ?
+	`, status, by, byID, at, comment, id, constants.DeletionRequestStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to decide deletion request: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrDeletionRequestNotPending
+	}
+	return nil
+}
+
+// ListDeletionRequestAssets returns the assets of a request. status filters
+// when not empty.
+func ListDeletionRequestAssets(db *sql.DB, id int64, status string) ([]DeletionRequestAsset, error) {
+	query := "SELECT hash, topic, size, status, error FROM deletion_request_assets WHERE request_id = ?"
+	args := []interface{}{id}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY topic, hash"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deletion request assets: %w", err)
+	}
+	defer rows.Close()
+
+	assets := []DeletionRequestAsset{}
+	for rows.Next() {
+		var a DeletionRequestAsset
+		if err := rows.Scan(&a.Hash, &a.Topic, &a.Size, &a.Status, &a.Error); err != nil {
+			return nil, err
+		}
+		assets = append(assets, a)
+	}
+	return assets, rows.Err()
+}
+
+// SetDeletionAssetResult records the outcome of deleting one asset of a
+// request.
+func SetDeletionAssetResult(db *sql.DB, id int64, hash, status, errMsg string) error {
+	if _, err := db.Exec("UPDATE deletion_request_assets SET status = ?, error = ? WHERE request_id = ? AND hash = ?",
+		status, errMsg, id, hash); err != nil {
+		return fmt.Errorf("failed to record deletion result: %w", err)
+	}
+	return nil
+}
+
+// CompleteDeletionRequest marks an approved request completed, counting the
+// deleted and failed assets, and returns it.
+func CompleteDeletionRequest(db *sql.DB, id, at int64) (*DeletionRequest, error) {
+	if _, err := db.Exec(`
+		UPDATE deletion_requests SET status = ?, completed_at = ?,
+			deleted = (SELECT COUNT(*) FROM deletion_request_assets WHERE request_id = ? AND status = ?),
+			failed = (SELECT COUNT(*) FROM deletion_request_assets WHERE request_id = ? AND status = ?)
+		WHERE id = ? AND status = ?
+	`, constants.DeletionRequestStatusCompleted, at,
+		id, constants.DeletionAssetStatusDeleted,
+		id, constants.DeletionAssetStatusFailed,
+		id, constants.DeletionRequestStatusApproved); err != nil {
+		return nil, fmt.Errorf("failed to complete deletion request: %w", err)
+	}
+	return GetDeletionRequest(db, id)
+}
+
+func scanDeletionRequests(rows *sql.Rows, err error) ([]DeletionRequest, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []DeletionRequest{}
+	for rows.Next() {
+		var r DeletionRequest
+		var topics string
+		if err := rows.Scan(&r.ID, &r.Status, &r.Mode, &r.Preset, &r.Reason, &topics, &r.AssetCount, &r.TotalBytes,
+			&r.RequestedBy, &r.RequestedByID, &r.RequestedAt, &r.ExpiresAt, &r.DecidedBy, &r.DecidedByID, &r.DecidedAt,
+			&r.Comment, &r.CompletedAt, &r.Deleted, &r.Failed); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(topics), &r.Topics); err != nil {
+			return nil, fmt.Errorf("deletion request %d: invalid topics_json: %w", r.ID, err)
+		}
+		requests = append(requests, r)
+	}
+	return requests, rows.Err()
+}
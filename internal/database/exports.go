@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"silobang/internal/constants"
+)
+
+// Export is one entry of a user's export inbox
+type Export struct {
+	ID           string   `json:"id"`
+	UserID       int64    `json:"-"`
+	Status       string   `json:"status"`
+	Mode         string   `json:"mode"`
+	Preset       string   `json:"preset,omitempty"`
+	Topics       []string `json:"topics,omitempty"`
+	AssetCount   int      `json:"asset_count"`
+	FailedAssets int      `json:"failed_assets"`
+	SizeBytes    int64    `json:"size_bytes"`
+	Error        string   `json:"error,omitempty"`
+	CreatedAt    int64    `json:"created_at"`
+	CompletedAt  *int64   `json:"completed_at"`
+	ExpiresAt    int64    `json:"expires_at"`
+}
+
+const exportColumns = "id, user_id, status, mode, preset, topics_json, asset_count, failed_assets, size_bytes, error, created_at, completed_at, expires_at"
+
+// InsertExport adds a new export to a user's inbox
+func InsertExport(db *sql.DB, e Export) error {
+	var topics interface{}
+	if len(e.Topics) > 0 {
+		data, err := json.Marshal(e.Topics)
+		if err != nil {
+			return err
+		}
+		topics = string(data)
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO exports (id, user_id, status, mode, preset, topics_json, asset_count, size_bytes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.ID, e.UserID, e.Status, e.Mode, e.Preset, topics, e.AssetCount, e.SizeBytes, e.CreatedAt, e.ExpiresAt)
+	return err
+}
+
+// FinishExport records the outcome of building an export
+func FinishExport(db *sql.DB, e Export) error {
+	_, err := db.Exec(`
+		UPDATE exports SET status = ?, asset_count = ?, failed_assets = ?, size_bytes = ?, error = ?, completed_at = ?
+		WHERE id = ?
+	`, e.Status, e.AssetCount, e.FailedAssets, e.SizeBytes, e.Error, e.CompletedAt, e.ID)
+	return err
+}
+
+// GetExport returns a user's export, or nil if none
+func GetExport(db *sql.DB, userID int64, id string) (*Export, error) {
+	rows, err := db.Query("SELECT "+exportColumns+" FROM exports WHERE user_id = ? AND id = ?", userID, id)
+	if err != nil {
+		return nil, err
+	}
+	exports, err := scanExports(rows)
+	if err != nil || len(exports) == 0 {
+		return nil, err
+	}
+	return &exports[0], nil
+}
+
+// ListExports returns a user's exports, newest first
+func ListExports(db *sql.DB, userID int64) ([]Export, error) {
+	rows, err := db.Query("SELECT "+exportColumns+" FROM exports WHERE user_id = ? ORDER BY created_at DESC, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanExports(rows)
+}
+
+// ListExportsByStatus returns every export with the given status
+func ListExportsByStatus(db *sql.DB, status string) ([]Export, error) {
+	rows, err := db.Query("SELECT "+exportColumns+" FROM exports WHERE status = ? ORDER BY created_at", status)
+	if err != nil {
+		return nil, err
+	}
+	return scanExports(rows)
+}
+
+// ListExportsExpiredBefore returns exports whose expiry is at or before now
+func ListExportsExpiredBefore(db *sql.DB, now int64) ([]Export, error) {
+	rows, err := db.Query("SELECT "+exportColumns+" FROM exports WHERE expires_at <= ? ORDER BY expires_at", now)
+	if err != nil {
+		return nil, err
+	}
+	return scanExports(rows)
+}
+
+// SumExportBytes returns the total size of a user's processing and ready exports
+func SumExportBytes(db *sql.DB, userID int64) (int64, error) {
+	var total int64
+	err := db.QueryRow("SELECT COALESCE(SUM(size_bytes), 0) FROM exports WHERE user_id = ? AND status != ?",
+		userID, constants.ExportStatusFailed).Scan(&total)
+	return total, err
+}
+
+// DeleteExport removes a user's export. Returns false if it did not exist.
+func DeleteExport(db *sql.DB, userID int64, id string) (bool, error) {
+	res, err := db.Exec("DELETE FROM exports WHERE user_id = ? AND id = ?", userID, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+func scanExports(rows *sql.Rows) ([]Export, error) {
+	defer rows.Close()
+
+	exports := make([]Export, 0)
+	for rows.Next() {
+		var e Export
+		var topics sql.NullString
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Status, &e.Mode, &e.Preset, &topics, &e.AssetCount, &e.FailedAssets,
+			&e.SizeBytes, &e.Error, &e.CreatedAt, &e.CompletedAt, &e.ExpiresAt); err != nil {
+			return nil, err
+		}
+		if topics.Valid {
+			if err := json.Unmarshal([]byte(topics.String), &e.Topics); err != nil {
+				return nil, err
+			}
+		}
+		exports = append(exports, e)
+	}
+	return exports, rows.Err()
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// IdempotencyRecord is a stored idempotency key and, once the first request
+// completed, its response
+type IdempotencyRecord struct {
+	UserID        int64
+	Key           string
+	Method        string
+	Path          string
+	RequestDigest string
+	Status        *int // nil while the first request is in progress
+	ContentType   string
+	Body          []byte
+	CreatedAt     int64
+	ExpiresAt     int64
+}
+
+// ReserveIdempotencyKey claims an idempotency key for a new request. An
+// expired record for the key is replaced. Returns (nil, nil) when the key was
+// claimed, or the existing record when the key is already in use.
+func ReserveIdempotencyKey(db *sql.DB, rec IdempotencyRecord) (*IdempotencyRecord, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ? AND expires_at <= ?`,
+		rec.UserID, rec.Key, rec.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec(`
+		INSERT OR IGNORE INTO idempotency_keys (user_id, idempotency_key, method, path, request_digest, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, rec.UserID, rec.Key, rec.Method, rec.Path, rec.RequestDigest, rec.CreatedAt, rec.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return nil, tx.Commit()
+	}
+
+	existing := &IdempotencyRecord{}
+	var status sql.NullInt64
+	err = tx.QueryRow(`
+		SELECT user_id, idempotency_key, method, path, request_digest, response_status, response_content_type, response_body, created_at, expires_at
+		FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ?
+	`, rec.UserID, rec.Key).Scan(&existing.UserID, &existing.Key, &existing.Method, &existing.Path, &existing.RequestDigest,
+		&status, &existing.ContentType, &existing.Body, &existing.CreatedAt, &existing.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if status.Valid {
+		code := int(status.Int64)
+		existing.Status = &code
+	}
+	return existing, tx.Commit()
+}
+
+// CompleteIdempotencyKey stores the response of the request holding a key
+func CompleteIdempotencyKey(db *sql.DB, userID int64, key string, status int, contentType string, body []byte) error {
+	_, err := db.Exec(`
+		UPDATE idempotency_keys SET response_status = ?, response_content_type = ?, response_body = ?
+		WHERE user_id = ? AND idempotency_key = ?
+	`, status, contentType, body, userID, key)
+	return err
+}
+
+// DeleteIdempotencyKey releases a key so the request can be retried
+func DeleteIdempotencyKey(db *sql.DB, userID int64, key string) error {
+	_, err := db.Exec(`DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ?`, userID, key)
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys deletes keys expired at the given time and
+// returns how many were removed
+func DeleteExpiredIdempotencyKeys(db *sql.DB, now int64) (int64, error) {
+	res, err := db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= ?`, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
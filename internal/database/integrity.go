@@ -0,0 +1,113 @@
+package database
+
+import (
+	"database/sql"
+
+	"silobang/internal/constants"
+)
+
+// DatExtent is the byte range one recorded asset occupies in its .dat file:
+// [Offset, Offset+HeaderSize+Size).
+type DatExtent struct {
+	AssetID  string
+	BlobName string
+	Offset   int64
+	Size     int64
+}
+
+// IntegrityFinding is an unexplained or conflicting .dat region.
+type IntegrityFinding struct {
+	ID          int64  `json:"id"`
+	DatFile     string `json:"dat_file"`
+	Kind        string `json:"kind"`
+	StartOffset int64  `json:"start_offset"`
+	EndOffset   int64  `json:"end_offset"`
+	AssetID     string `json:"asset_id,omitempty"`
+	Detail      string `json:"detail"`
+	DetectedAt  int64  `json:"detected_at"`
+}
+
+// ListDatExtents returns every recorded asset extent ordered by .dat file and
+// offset. Entries of deleted and archived assets awaiting compaction are
+// recorded by their tombstones.
+func ListDatExtents(db *sql.DB) ([]DatExtent, error) {
+	rows, err := db.Query(`
+		SELECT asset_id, blob_name, byte_offset, asset_size FROM assets WHERE blob_name != ?
+		UNION ALL
+		SELECT asset_id, blob_name, byte_offset, asset_size FROM tombstones
+		ORDER BY blob_name, byte_offset
+	`, constants.ArchiveBlobName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var extents []DatExtent
+	for rows.Next() {
+		var e DatExtent
+		if err := rows.Scan(&e.AssetID, &e.BlobName, &e.Offset, &e.Size); err != nil {
+			return nil, err
+		}
+		extents = append(extents, e)
+	}
+	return extents, rows.Err()
+}
+
+// ReplaceIntegrityFindings atomically replaces the stored findings with the
+// result of a new scan.
+func ReplaceIntegrityFindings(db *sql.DB, findings []IntegrityFinding) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM dat_integrity"); err != nil {
+		return err
+	}
+
+	for _, f := range findings {
+		var assetID interface{}
+		if f.AssetID != "" {
+			assetID = f.AssetID
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO dat_integrity (dat_file, kind, start_offset, end_offset, asset_id, detail, detected_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, f.DatFile, f.Kind, f.StartOffset, f.EndOffset, assetID, f.Detail, f.DetectedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListIntegrityFindings returns the stored findings ordered by .dat file and
+// offset.
+func ListIntegrityFindings(db *sql.DB) ([]IntegrityFinding, error) {
+	rows, err := db.Query(`
+		SELECT id, dat_file, kind, start_offset, end_offset, COALESCE(asset_id, ''), detail, detected_at
+		FROM dat_integrity ORDER BY dat_file, start_offset, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	findings := []IntegrityFinding{}
+	for rows.Next() {
+		var f IntegrityFinding
+		if err := rows.Scan(&f.ID, &f.DatFile, &f.Kind, &f.StartOffset, &f.EndOffset, &f.AssetID, &f.Detail, &f.DetectedAt); err != nil {
+			return nil, err
+		}
+		findings = append(findings, f)
+	}
+	return findings, rows.Err()
+}
+
+// CountIntegrityFindings returns the number of stored findings.
+func CountIntegrityFindings(db *sql.DB) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM dat_integrity").Scan(&count)
+	return count, err
+}
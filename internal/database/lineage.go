@@ -0,0 +1,53 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// LineageChange is one entry of the append-only log of parents replaced
+// after upload.
+type LineageChange struct {
+	ID          int64   `json:"id"`
+	AssetID     string  `json:"asset_id"`
+	OldParentID *string `json:"old_parent_id"`
+	NewParentID *string `json:"new_parent_id"`
+	ChangedAt   int64   `json:"changed_at"`
+	ChangedBy   string  `json:"changed_by"`
+	Reason      string  `json:"reason"`
+}
+
+// UpdateAssetParent replaces an asset's parent and logs the previous one
+// using the provided transaction. An empty parent clears it.
+func UpdateAssetParent(tx *sql.Tx, change LineageChange) error {
+	if _, err := tx.Exec("UPDATE assets SET parent_id = ? WHERE asset_id = ?",
+		change.NewParentID, change.AssetID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		INSERT INTO lineage_changes (asset_id, old_parent_id, new_parent_id, changed_at, changed_by, reason)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, change.AssetID, change.OldParentID, change.NewParentID, change.ChangedAt, change.ChangedBy, change.Reason)
+	return err
+}
+
+// ListLineageChanges returns the parent changes of an asset, oldest first.
+func ListLineageChanges(db *sql.DB, assetID string) ([]LineageChange, error) {
+	rows, err := db.Query(`
+		SELECT id, asset_id, old_parent_id, new_parent_id, changed_at, changed_by, reason
+		FROM lineage_changes WHERE asset_id = ? ORDER BY id
+	`, assetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []LineageChange{}
+	for rows.Next() {
+		var c LineageChange
+		if err := rows.Scan(&c.ID, &c.AssetID, &c.OldParentID, &c.NewParentID, &c.ChangedAt, &c.ChangedBy, &c.Reason); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
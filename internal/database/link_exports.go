@@ -0,0 +1,170 @@
+package database
+
+import (
+	"database/sql"
+
+	"silobang/internal/constants"
+)
+
+// LinkExport is a directory of hard links kept for consumers on the same host
+type LinkExport struct {
+	ID             string `json:"id"`
+	UserID         int64  `json:"-"`
+	Label          string `json:"label,omitempty"`
+	FilenameFormat string `json:"filename_format"`
+	AssetCount     int    `json:"asset_count"`
+	SizeBytes      int64  `json:"size_bytes"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+}
+
+// LinkExportEntry is one asset linked into a link export
+type LinkExportEntry struct {
+	Hash     string `json:"hash"`
+	Topic    string `json:"topic"`
+	Path     string `json:"path"` // relative to the export directory
+	Size     int64  `json:"size"`
+	Method   string `json:"method"`
+	LinkedAt int64  `json:"linked_at"`
+}
+
+const linkExportColumns = `e.id, e.user_id, e.label, e.filename_format, e.created_at, e.updated_at,
+	(SELECT COUNT(*) FROM link_export_entries l WHERE l.export_id = e.id),
+	(SELECT COALESCE(SUM(size), 0) FROM link_export_entries l WHERE l.export_id = e.id)`
+
+// InsertLinkExport adds a new, empty link export
+func InsertLinkExport(db *sql.DB, e LinkExport) error {
+	_, err := db.Exec(`
+		INSERT INTO link_exports (id, user_id, label, filename_format, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, e.ID, e.UserID, e.Label, e.FilenameFormat, e.CreatedAt, e.UpdatedAt)
+	return err
+}
+
+// GetLinkExport returns a user's link export, or nil if none
+func GetLinkExport(db *sql.DB, userID int64, id string) (*LinkExport, error) {
+	rows, err := db.Query("SELECT "+linkExportColumns+" FROM link_exports e WHERE e.user_id = ? AND e.id = ?", userID, id)
+	if err != nil {
+		return nil, err
+	}
+	exports, err := scanLinkExports(rows)
+	if err != nil || len(exports) == 0 {
+		return nil, err
+	}
+	return &exports[0], nil
+}
+
+// ListLinkExports returns a user's link exports, newest first
+func ListLinkExports(db *sql.DB, userID int64) ([]LinkExport, error) {
+	rows, err := db.Query("SELECT "+linkExportColumns+" FROM link_exports e WHERE e.user_id = ? ORDER BY e.created_at DESC, e.id", userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanLinkExports(rows)
+}
+
+// TouchLinkExport records that a link export's entries changed
+func TouchLinkExport(db *sql.DB, id string, now int64) error {
+	_, err := db.Exec("UPDATE link_exports SET updated_at = ? WHERE id = ?", now, id)
+	return err
+}
+
+// DeleteLinkExport removes a link export. Its entries must be released first.
+func DeleteLinkExport(db *sql.DB, id string) error {
+	_, err := db.Exec("DELETE FROM link_exports WHERE id = ?", id)
+	return err
+}
+
+// AddLinkExportEntry records a linked asset and registers the export's
+// reference on it in a single transaction.
+func AddLinkExportEntry(db *sql.DB, exportID string, entry LinkExportEntry) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO link_export_entries (export_id, hash, topic, path, size, method, linked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, exportID, entry.Hash, entry.Topic, entry.Path, entry.Size, entry.Method, entry.LinkedAt); err != nil {
+		return err
+	}
+	if err := InsertAssetReference(tx, AssetReference{
+		Hash:      entry.Hash,
+		Kind:      constants.ReferenceKindExport,
+		Holder:    exportID,
+		CreatedAt: entry.LinkedAt,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveLinkExportEntry drops a linked asset and releases the export's
+// reference on it in a single transaction.
+func RemoveLinkExportEntry(db *sql.DB, exportID, hash string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM link_export_entries WHERE export_id = ? AND hash = ?", exportID, hash); err != nil {
+		return err
+	}
+	if err := DeleteAssetReference(tx, AssetReference{
+		Hash:   hash,
+		Kind:   constants.ReferenceKindExport,
+		Holder: exportID,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListLinkExportEntries returns the assets linked into an export, by path
+func ListLinkExportEntries(db *sql.DB, exportID string) ([]LinkExportEntry, error) {
+	rows, err := db.Query(`
+		SELECT hash, topic, path, size, method, linked_at
+		FROM link_export_entries WHERE export_id = ?
+		ORDER BY path
+	`, exportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]LinkExportEntry, 0)
+	for rows.Next() {
+		var e LinkExportEntry
+		if err := rows.Scan(&e.Hash, &e.Topic, &e.Path, &e.Size, &e.Method, &e.LinkedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// CountLinkExportEntries returns how many export entries link an asset.
+// Its extracted copy may be removed once none do.
+func CountLinkExportEntries(db *sql.DB, hash string) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM link_export_entries WHERE hash = ?", hash).Scan(&count)
+	return count, err
+}
+
+func scanLinkExports(rows *sql.Rows) ([]LinkExport, error) {
+	defer rows.Close()
+
+	exports := make([]LinkExport, 0)
+	for rows.Next() {
+		var e LinkExport
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Label, &e.FilenameFormat, &e.CreatedAt, &e.UpdatedAt,
+			&e.AssetCount, &e.SizeBytes); err != nil {
+			return nil, err
+		}
+		exports = append(exports, e)
+	}
+	return exports, rows.Err()
+}
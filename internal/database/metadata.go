@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -251,6 +252,59 @@ func GetMetadataWithProcessor(db *sql.DB, assetID string) ([]MetadataWithProcess
 	return result, nil
 }
 
+// GetMetadataAsOf rebuilds an asset's metadata as it was at the unix time
+// asOf by replaying its log entries written by then. Returns the computed
+// key values, preferring numbers as metadata_computed does, and the
+// processor info of each key.
+func GetMetadataAsOf(db *sql.DB, assetID string, asOf int64) (map[string]interface{}, []MetadataWithProcessor, error) {
+	rows, err := db.Query(`
+		SELECT op, key, value_text, value_num, processor, processor_version, timestamp
+		FROM metadata_log
+		WHERE asset_id = ? AND timestamp <= ?
+		ORDER BY id
+	`, assetID, asOf)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	computed := make(map[string]interface{})
+	withProcessor := make(map[string]MetadataWithProcessor)
+	for rows.Next() {
+		var op string
+		var entry MetadataWithProcessor
+		var valueText sql.NullString
+		var valueNum sql.NullFloat64
+
+		if err := rows.Scan(&op, &entry.Key, &valueText, &valueNum,
+			&entry.Processor, &entry.ProcessorVersion, &entry.Timestamp); err != nil {
+			return nil, nil, err
+		}
+
+		if op == "set" {
+			if valueNum.Valid {
+				computed[entry.Key] = valueNum.Float64
+			} else if valueText.Valid {
+				computed[entry.Key] = valueText.String
+			}
+			entry.Value = valueText.String
+			withProcessor[entry.Key] = entry
+		} else if op == "delete" {
+			delete(computed, entry.Key)
+			delete(withProcessor, entry.Key)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	result := make([]MetadataWithProcessor, 0, len(withProcessor))
+	for _, m := range withProcessor {
+		result = append(result, m)
+	}
+	return computed, result, nil
+}
+
 // GetMetadataLog queries all log entries for an asset
 func GetMetadataLog(db *sql.DB, assetID string) ([]MetadataLogEntry, error) {
 	rows, err := db.Query(`
@@ -283,3 +337,102 @@ func GetMetadataLog(db *sql.DB, assetID string) ([]MetadataLogEntry, error) {
 
 	return entries, rows.Err()
 }
+
+// GetMetadataLogPage returns the newest log entries of an asset within
+// [since, until] (0 = unbounded), at most limit, newest first, and the number
+// of matching entries.
+func GetMetadataLogPage(db *sql.DB, assetID string, since, until int64, limit int) ([]MetadataLogEntry, int64, error) {
+	where := " WHERE asset_id = ?"
+	args := []interface{}{assetID}
+	if since > 0 {
+		where += " AND timestamp >= ?"
+		args = append(args, since)
+	}
+	if until > 0 {
+		where += " AND timestamp <= ?"
+		args = append(args, until)
+	}
+
+	var total int64
+	if err := db.QueryRow(`SELECT COUNT(*) FROM metadata_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	if limit <= 0 || total == 0 {
+		return nil, total, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT id, asset_id, op, key, value_text, processor, processor_version, timestamp
+		FROM metadata_log`+where+`
+		ORDER BY id DESC LIMIT ?
+	`, append(args, limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []MetadataLogEntry
+	for rows.Next() {
+		var entry MetadataLogEntry
+		var valueText sql.NullString
+
+		if err := rows.Scan(&entry.ID, &entry.AssetID, &entry.Op, &entry.Key, &valueText,
+			&entry.Processor, &entry.ProcessorVersion, &entry.Timestamp); err != nil {
+			return nil, 0, err
+		}
+
+		if valueText.Valid {
+			entry.Value = valueText.String
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
+
+// SimilarAsset is an asset sharing computed metadata values with another.
+type SimilarAsset struct {
+	AssetID    string   `json:"asset_id"`
+	OriginName string   `json:"origin_name"`
+	Extension  string   `json:"extension"`
+	AssetSize  int64    `json:"asset_size"`
+	SharedKeys []string `json:"shared_keys"` // keys whose value is the same on both assets
+}
+
+// FindSimilarByMetadata returns the assets sharing the most computed metadata
+// values (same key, same value) with assetID, most shared first.
+func FindSimilarByMetadata(db *sql.DB, assetID string, limit int) ([]SimilarAsset, error) {
+	rows, err := db.Query(`
+		SELECT a.asset_id, a.origin_name, a.extension, a.asset_size, json_group_array(ov.key) AS shared_keys
+		FROM metadata_computed src, json_each(src.metadata_json) sv,
+		     metadata_computed other, json_each(other.metadata_json) ov
+		JOIN assets a ON a.asset_id = other.asset_id
+		WHERE src.asset_id = ? AND other.asset_id != src.asset_id
+		  AND ov.key = sv.key AND ov.value IS sv.value
+		GROUP BY a.asset_id
+		ORDER BY COUNT(*) DESC, a.created_at DESC, a.asset_id
+		LIMIT ?
+	`, assetID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	similar := []SimilarAsset{}
+	for rows.Next() {
+		var asset SimilarAsset
+		var originName sql.NullString
+		var keysJSON string
+		if err := rows.Scan(&asset.AssetID, &originName, &asset.Extension, &asset.AssetSize, &keysJSON); err != nil {
+			return nil, err
+		}
+		asset.OriginName = originName.String
+		if err := json.Unmarshal([]byte(keysJSON), &asset.SharedKeys); err != nil {
+			return nil, fmt.Errorf("failed to decode shared keys: %w", err)
+		}
+		slices.Sort(asset.SharedKeys)
+		similar = append(similar, asset)
+	}
+	return similar, rows.Err()
+}
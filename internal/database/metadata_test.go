@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
@@ -254,3 +255,96 @@ func TestInsertMetadataLog_MultipleValues(t *testing.T) {
 		t.Errorf("expected %d keys in computed, got %d", len(keys), len(parsed))
 	}
 }
+
+func TestGetMetadataAsOf_ReplaysLogUntilTime(t *testing.T) {
+	db := createTestTopicDB(t)
+
+	assetID := "dddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddd"
+	insertTestAsset(t, db, assetID)
+
+	entries := []MetadataLogEntry{
+		{Op: "set", Key: "status", Value: "draft", Timestamp: 1000},
+		{Op: "set", Key: "version", Value: "1", Timestamp: 1000},
+		{Op: "set", Key: "status", Value: "final", Timestamp: 2000},
+		{Op: "delete", Key: "version", Timestamp: 3000},
+	}
+	for _, entry := range entries {
+		entry.AssetID = assetID
+		entry.Processor = "test"
+		entry.ProcessorVersion = "1.0"
+		if _, err := InsertMetadataLog(db, entry); err != nil {
+			t.Fatalf("InsertMetadataLog failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		asOf int64
+		want map[string]interface{}
+	}{
+		{500, map[string]interface{}{}},
+		{1500, map[string]interface{}{"status": "draft", "version": float64(1)}},
+		{2000, map[string]interface{}{"status": "final", "version": float64(1)}},
+		{3500, map[string]interface{}{"status": "final"}},
+	}
+	for _, tt := range tests {
+		computed, withProcessor, err := GetMetadataAsOf(db, assetID, tt.asOf)
+		if err != nil {
+			t.Fatalf("GetMetadataAsOf(%d) failed: %v", tt.asOf, err)
+		}
+		if fmt.Sprint(computed) != fmt.Sprint(tt.want) {
+			t.Errorf("GetMetadataAsOf(%d) = %v, want %v", tt.asOf, computed, tt.want)
+		}
+		if len(withProcessor) != len(tt.want) {
+			t.Errorf("GetMetadataAsOf(%d): %d keys with processor, want %d", tt.asOf, len(withProcessor), len(tt.want))
+		}
+	}
+
+	// The latest state matches the computed view
+	current, err := GetMetadataComputed(db, assetID)
+	if err != nil {
+		t.Fatalf("GetMetadataComputed failed: %v", err)
+	}
+	latest, _, _ := GetMetadataAsOf(db, assetID, 3500)
+	if fmt.Sprint(current) != fmt.Sprint(latest) {
+		t.Errorf("latest replay %v differs from computed %v", latest, current)
+	}
+}
+
+func TestFindSimilarByMetadata(t *testing.T) {
+	db := createTestTopicDB(t)
+
+	source := strings.Repeat("a", 64)
+	near := strings.Repeat("b", 64)
+	far := strings.Repeat("c", 64)
+	unrelated := strings.Repeat("d", 64)
+	metadata := map[string]map[string]string{
+		source:    {"style": "low-poly", "biome": "forest", "author": "kim"},
+		near:      {"style": "low-poly", "biome": "forest", "author": "lee"},
+		far:       {"style": "low-poly", "biome": "desert"},
+		unrelated: {"style": "realistic"},
+	}
+	for assetID, values := range metadata {
+		insertTestAsset(t, db, assetID)
+		for key, value := range values {
+			if _, err := InsertMetadataLog(db, MetadataLogEntry{
+				AssetID: assetID, Op: "set", Key: key, Value: value, Processor: "test", ProcessorVersion: "1.0",
+			}); err != nil {
+				t.Fatalf("InsertMetadataLog failed: %v", err)
+			}
+		}
+	}
+
+	similar, err := FindSimilarByMetadata(db, source, 10)
+	if err != nil {
+		t.Fatalf("FindSimilarByMetadata failed: %v", err)
+	}
+	if len(similar) != 2 {
+		t.Fatalf("expected 2 similar assets, got %+v", similar)
+	}
+	if similar[0].AssetID != near || strings.Join(similar[0].SharedKeys, ",") != "biome,style" {
+		t.Errorf("expected %s first sharing biome and style, got %+v", near, similar[0])
+	}
+	if similar[1].AssetID != far || strings.Join(similar[1].SharedKeys, ",") != "style" {
+		t.Errorf("expected %s second sharing style, got %+v", far, similar[1])
+	}
+}
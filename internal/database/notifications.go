@@ -0,0 +1,433 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// NotificationSubscription is a user's subscription to events of one topic
+type NotificationSubscription struct {
+	UserID       int64    `json:"-"`
+	Topic        string   `json:"topic"`
+	Events       []string `json:"events"`
+	MetadataKeys []string `json:"metadata_keys,omitempty"` // empty = any key
+	CreatedAt    int64    `json:"created_at"`
+	UpdatedAt    int64    `json:"updated_at"`
+}
+
+// NotificationPreferences holds how a user's notifications are delivered
+type NotificationPreferences struct {
+	UserID          int64  `json:"-"`
+	Delivery        string `json:"delivery"`
+	WebhookURL      string `json:"webhook_url"`
+	Email           string `json:"email"`
+	LastDeliveredAt int64  `json:"last_delivered_at"`
+	UpdatedAt       int64  `json:"updated_at"`
+}
+
+// Notification is one entry of a user's notification feed
+type Notification struct {
+	ID        int64           `json:"id"`
+	UserID    int64           `json:"-"`
+	Topic     string          `json:"topic"`
+	Event     string          `json:"event"`
+	AssetID   *string         `json:"asset_id"`
+	Actor     string          `json:"actor"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	CreatedAt int64           `json:"created_at"`
+	ReadAt    *int64          `json:"read_at"`
+}
+
+// TopicSubscriber is a subscriber of a topic event, used for fan-out
+type TopicSubscriber struct {
+	UserID       int64
+	MetadataKeys []string
+	HasChannel   bool // webhook URL or email is set
+}
+
+// UpsertNotificationSubscription creates or replaces a user's subscription to a topic
+func UpsertNotificationSubscription(db *sql.DB, sub NotificationSubscription) error {
+	events, err := json.Marshal(sub.Events)
+	if err != nil {
+		return err
+	}
+	var keys interface{}
+	if len(sub.MetadataKeys) > 0 {
+		data, err := json.Marshal(sub.MetadataKeys)
+		if err != nil {
+			return err
+		}
+		keys = string(data)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO notification_subscriptions (user_id, topic, events_json, metadata_keys_json, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, topic) DO UPDATE SET
+			events_json = excluded.events_json,
+			metadata_keys_json = excluded.metadata_keys_json,
+			updated_at = excluded.updated_at
+	`, sub.UserID, sub.Topic, string(events), keys, sub.CreatedAt, sub.UpdatedAt)
+	return err
+}
+
+// GetNotificationSubscription returns a user's subscription to a topic, or nil if none
+func GetNotificationSubscription(db *sql.DB, userID int64, topic string) (*NotificationSubscription, error) {
+	rows, err := db.Query(`
+		SELECT user_id, topic, events_json, metadata_keys_json, created_at, updated_at
+		FROM notification_subscriptions WHERE user_id = ? AND topic = ?
+	`, userID, topic)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := scanNotificationSubscriptions(rows)
+	if err != nil || len(subs) == 0 {
+		return nil, err
+	}
+	return &subs[0], nil
+}
+
+// DeleteNotificationSubscription removes a user's subscription to a topic.
+// Returns false if the user was not subscribed.
+func DeleteNotificationSubscription(db *sql.DB, userID int64, topic string) (bool, error) {
+	res, err := db.Exec("DELETE FROM notification_subscriptions WHERE user_id = ? AND topic = ?", userID, topic)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// DeleteTopicNotificationSubscriptions removes every subscription to a topic
+func DeleteTopicNotificationSubscriptions(db *sql.DB, topic string) error {
+	_, err := db.Exec("DELETE FROM notification_subscriptions WHERE topic = ?", topic)
+	return err
+}
+
+// ListNotificationSubscriptions returns a user's subscriptions ordered by topic
+func ListNotificationSubscriptions(db *sql.DB, userID int64) ([]NotificationSubscription, error) {
+	rows, err := db.Query(`
+		SELECT user_id, topic, events_json, metadata_keys_json, created_at, updated_at
+		FROM notification_subscriptions WHERE user_id = ? ORDER BY topic
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanNotificationSubscriptions(rows)
+}
+
+func scanNotificationSubscriptions(rows *sql.Rows) ([]NotificationSubscription, error) {
+	defer rows.Close()
+
+	subs := make([]NotificationSubscription, 0)
+	for rows.Next() {
+		var sub NotificationSubscription
+		var events string
+		var keys sql.NullString
+		if err := rows.Scan(&sub.UserID, &sub.Topic, &events, &keys, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(events), &sub.Events); err != nil {
+			return nil, err
+		}
+		if keys.Valid {
+			if err := json.Unmarshal([]byte(keys.String), &sub.MetadataKeys); err != nil {
+				return nil, err
+			}
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// ListTopicSubscribers returns the active users subscribed to an event of a topic
+func ListTopicSubscribers(db *sql.DB, topic, event string) ([]TopicSubscriber, error) {
+	rows, err := db.Query(`
+		SELECT s.user_id, s.metadata_keys_json,
+			COALESCE(p.webhook_url, '') != '' OR COALESCE(p.email, '') != ''
+		FROM notification_subscriptions s
+		JOIN auth_users u ON u.id = s.user_id AND u.is_active = 1
+		LEFT JOIN notification_preferences p ON p.user_id = s.user_id
+		WHERE s.topic = ? AND EXISTS (SELECT 1 FROM json_each(s.events_json) WHERE value = ?)
+	`, topic, event)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscribers []TopicSubscriber
+	for rows.Next() {
+		var sub TopicSubscriber
+		var keys sql.NullString
+		if err := rows.Scan(&sub.UserID, &keys, &sub.HasChannel); err != nil {
+			return nil, err
+		}
+		if keys.Valid {
+			if err := json.Unmarshal([]byte(keys.String), &sub.MetadataKeys); err != nil {
+				return nil, err
+			}
+		}
+		subscribers = append(subscribers, sub)
+	}
+	return subscribers, rows.Err()
+}
+
+// GetNotificationPreferences returns a user's preferences, or nil if never set
+func GetNotificationPreferences(db *sql.DB, userID int64) (*NotificationPreferences, error) {
+	var p NotificationPreferences
+	err := db.QueryRow(`
+		SELECT user_id, delivery, webhook_url, email, last_delivered_at, updated_at
+		FROM notification_preferences WHERE user_id = ?
+	`, userID).Scan(&p.UserID, &p.Delivery, &p.WebhookURL, &p.Email, &p.LastDeliveredAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// UpsertNotificationPreferences stores a user's delivery preferences
+func UpsertNotificationPreferences(db *sql.DB, p NotificationPreferences) error {
+	_, err := db.Exec(`
+		INSERT INTO notification_preferences (user_id, delivery, webhook_url, email, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			delivery = excluded.delivery,
+			webhook_url = excluded.webhook_url,
+			email = excluded.email,
+			updated_at = excluded.updated_at
+	`, p.UserID, p.Delivery, p.WebhookURL, p.Email, p.UpdatedAt)
+	return err
+}
+
+// SetNotificationsLastDelivered records when a user's notifications were last sent
+func SetNotificationsLastDelivered(db *sql.DB, userID, deliveredAt int64) error {
+	_, err := db.Exec("UPDATE notification_preferences SET last_delivered_at = ? WHERE user_id = ?", deliveredAt, userID)
+	return err
+}
+
+// InsertNotifications adds notifications to users' feeds in one transaction.
+// Notifications for users without a delivery channel are stored as delivered.
+// The IDs of the stored notifications are set on the slice.
+func InsertNotifications(db *sql.DB, notifications []Notification, delivered map[int64]bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO notifications (user_id, topic, event, asset_id, actor, details_json, created_at, delivered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := range notifications {
+		n := &notifications[i]
+		var details, deliveredAt interface{}
+		if len(n.Details) > 0 {
+			details = string(n.Details)
+		}
+		if delivered[n.UserID] {
+			deliveredAt = n.CreatedAt
+		}
+		res, err := stmt.Exec(n.UserID, n.Topic, n.Event, n.AssetID, n.Actor, details, n.CreatedAt, deliveredAt)
+		if err != nil {
+			return err
+		}
+		if n.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const notificationColumns = "id, user_id, topic, event, asset_id, actor, details_json, created_at, read_at"
+
+// ListNotifications returns a page of a user's feed, newest first, and the
+// total number of matching notifications.
+func ListNotifications(db *sql.DB, userID int64, unreadOnly bool, limit, offset int) ([]Notification, int64, error) {
+	where := "user_id = ?"
+	if unreadOnly {
+		where += " AND read_at IS NULL"
+	}
+
+	var total int64
+	if err := db.QueryRow("SELECT COUNT(*) FROM notifications WHERE "+where, userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Query("SELECT "+notificationColumns+" FROM notifications WHERE "+where+
+		" ORDER BY id DESC LIMIT ? OFFSET ?", userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	notifications, err := scanNotifications(rows)
+	return notifications, total, err
+}
+
+// CountUnreadNotifications returns the number of unread notifications of a user
+func CountUnreadNotifications(db *sql.DB, userID int64) (int64, error) {
+	var count int64
+	err := db.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL", userID).Scan(&count)
+	return count, err
+}
+
+// CountUnreadNotificationsByEvent returns the number of unread notifications
+// of a user per event
+func CountUnreadNotificationsByEvent(db *sql.DB, userID int64) (map[string]int64, error) {
+	rows, err := db.Query("SELECT event, COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL GROUP BY event", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var event string
+		var count int64
+		if err := rows.Scan(&event, &count); err != nil {
+			return nil, err
+		}
+		counts[event] = count
+	}
+	return counts, rows.Err()
+}
+
+// MarkNotificationsRead marks a user's notifications as read. An empty ids
+// slice marks every unread notification. Returns the number marked.
+func MarkNotificationsRead(db *sql.DB, userID int64, ids []int64, now int64) (int64, error) {
+	query := "UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL"
+	args := []interface{}{now, userID}
+	if len(ids) > 0 {
+		query += " AND id IN (" + placeholders(len(ids)) + ")"
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ListUsersWithUndeliveredNotifications returns the preferences of every user
+// with notifications awaiting webhook or email delivery.
+func ListUsersWithUndeliveredNotifications(db *sql.DB) ([]NotificationPreferences, error) {
+	rows, err := db.Query(`
+		SELECT p.user_id, p.delivery, p.webhook_url, p.email, p.last_delivered_at, p.updated_at
+		FROM notification_preferences p
+		WHERE EXISTS (SELECT 1 FROM notifications n WHERE n.delivered_at IS NULL AND n.user_id = p.user_id)
+		ORDER BY p.user_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prefs []NotificationPreferences
+	for rows.Next() {
+		var p NotificationPreferences
+		if err := rows.Scan(&p.UserID, &p.Delivery, &p.WebhookURL, &p.Email, &p.LastDeliveredAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, p)
+	}
+	return prefs, rows.Err()
+}
+
+// ListUndeliveredNotifications returns up to limit of a user's undelivered
+// notifications, oldest first.
+func ListUndeliveredNotifications(db *sql.DB, userID int64, limit int) ([]Notification, error) {
+	rows, err := db.Query("SELECT "+notificationColumns+" FROM notifications WHERE user_id = ? AND delivered_at IS NULL"+
+		" ORDER BY id LIMIT ?", userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanNotifications(rows)
+}
+
+// MarkNotificationsDelivered marks notifications as sent
+func MarkNotificationsDelivered(db *sql.DB, ids []int64, now int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := []interface{}{now}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	_, err := db.Exec("UPDATE notifications SET delivered_at = ? WHERE id IN ("+placeholders(len(ids))+")", args...)
+	return err
+}
+
+// MarkUserNotificationsDelivered marks all of a user's undelivered
+// notifications as sent, e.g. once the user has no delivery channel left.
+func MarkUserNotificationsDelivered(db *sql.DB, userID, now int64) error {
+	_, err := db.Exec("UPDATE notifications SET delivered_at = ? WHERE user_id = ? AND delivered_at IS NULL", now, userID)
+	return err
+}
+
+// RecordNotificationDeliveryFailure counts a failed delivery attempt. Once
+// maxAttempts is reached the notifications are no longer retried.
+// Returns the number of notifications given up on.
+func RecordNotificationDeliveryFailure(db *sql.DB, ids []int64, maxAttempts int, now int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	args := []interface{}{maxAttempts, now}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	if _, err := db.Exec(`
+		UPDATE notifications SET
+			delivery_attempts = delivery_attempts + 1,
+			delivered_at = CASE WHEN delivery_attempts + 1 >= ? THEN ? ELSE NULL END
+		WHERE id IN (`+placeholders(len(ids))+")", args...); err != nil {
+		return 0, err
+	}
+
+	var abandoned int64
+	err := db.QueryRow("SELECT COUNT(*) FROM notifications WHERE delivered_at IS NOT NULL AND id IN ("+
+		placeholders(len(ids))+")", args[2:]...).Scan(&abandoned)
+	return abandoned, err
+}
+
+// DeleteNotificationsBefore removes feed entries created before the cutoff
+func DeleteNotificationsBefore(db *sql.DB, before int64) (int64, error) {
+	res, err := db.Exec("DELETE FROM notifications WHERE created_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanNotifications(rows *sql.Rows) ([]Notification, error) {
+	defer rows.Close()
+
+	notifications := make([]Notification, 0)
+	for rows.Next() {
+		var n Notification
+		var details sql.NullString
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Topic, &n.Event, &n.AssetID, &n.Actor, &details, &n.CreatedAt, &n.ReadAt); err != nil {
+			return nil, err
+		}
+		if details.Valid {
+			n.Details = json.RawMessage(details.String)
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// objectKeyExpr computes ObjectKey in SQL, so listings can be filtered and
+// ordered by key in the topic database.
+const objectKeyExpr = `CASE
+	WHEN origin_name = '' THEN asset_id
+	WHEN extension = '' THEN origin_name
+	ELSE origin_name || '.' || extension
+END`
+
+// ObjectKey is the name an asset is addressed by through the S3 gateway:
+// its filename as uploaded, or its hash when it was uploaded without a
+// usable name.
+func (a Asset) ObjectKey() string {
+	switch {
+	case a.OriginName == "":
+		return a.AssetID
+	case a.Extension == "":
+		return a.OriginName
+	default:
+		return a.OriginName + "." + a.Extension
+	}
+}
+
+// ListAssetsByObjectKey returns the newest asset stored under each object
+// key that starts with prefix and sorts after after, in byte order of the
+// keys, skipping the assets in exclude. At most limit keys are returned.
+func ListAssetsByObjectKey(db *sql.DB, prefix, after string, limit int, exclude map[string]bool) ([]Asset, error) {
+	rows, err := db.Query(`
+		SELECT asset_id, asset_size, origin_name, parent_id, extension, blob_name, byte_offset, created_at
+		FROM assets
+		WHERE (? = '' OR instr(`+objectKeyExpr+`, ?) = 1) AND `+objectKeyExpr+` > ?
+		ORDER BY `+objectKeyExpr+`, created_at DESC, asset_id
+	`, prefix, prefix, after)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []Asset
+	lastKey := ""
+	for rows.Next() && len(assets) < limit {
+		var asset Asset
+		var pid sql.NullString
+
+		err := rows.Scan(
+			&asset.AssetID,
+			&asset.AssetSize,
+			&asset.OriginName,
+			&pid,
+			&asset.Extension,
+			&asset.BlobName,
+			&asset.ByteOffset,
+			&asset.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if exclude[asset.AssetID] {
+			continue
+		}
+		// Rows of a key are ordered newest first; only the first is kept
+		key := asset.ObjectKey()
+		if len(assets) > 0 && key == lastKey {
+			continue
+		}
+		lastKey = key
+
+		if pid.Valid {
+			asset.ParentID = &pid.String
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// DatOffload records a sealed .dat file whose only copy is in a blob store.
+type DatOffload struct {
+	DatFile     string `json:"dat_file"`
+	BlobStore   string `json:"blob_store"`
+	Size        int64  `json:"size"`
+	OffloadedAt int64  `json:"offloaded_at"`
+}
+
+// InsertDatOffload records that a .dat file was moved to a blob store.
+func InsertDatOffload(db *sql.DB, o DatOffload) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO dat_offloads (dat_file, blob_store, size, offloaded_at)
+		VALUES (?, ?, ?, ?)
+	`, o.DatFile, o.BlobStore, o.Size, o.OffloadedAt)
+	return err
+}
+
+// ListDatOffloads returns the offloaded .dat files keyed by file name.
+func ListDatOffloads(db *sql.DB) (map[string]DatOffload, error) {
+	rows, err := db.Query("SELECT dat_file, blob_store, size, offloaded_at FROM dat_offloads")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offloads := map[string]DatOffload{}
+	for rows.Next() {
+		var o DatOffload
+		if err := rows.Scan(&o.DatFile, &o.BlobStore, &o.Size, &o.OffloadedAt); err != nil {
+			return nil, err
+		}
+		offloads[o.DatFile] = o
+	}
+	return offloads, rows.Err()
+}
+
+// GetDatOffload returns the offload record of a .dat file, or nil if the file
+// is local.
+func GetDatOffload(db *sql.DB, datFile string) (*DatOffload, error) {
+	var o DatOffload
+	err := db.QueryRow(`
+		SELECT dat_file, blob_store, size, offloaded_at FROM dat_offloads WHERE dat_file = ?
+	`, datFile).Scan(&o.DatFile, &o.BlobStore, &o.Size, &o.OffloadedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"strings"
 )
 
 // CheckHashExists queries asset_index for the given hash
@@ -20,6 +21,37 @@ func CheckHashExists(db *sql.DB, hash string) (exists bool, topic string, datFil
 	return true, t, df, nil
 }
 
+// LookupHashTopics resolves many hashes through asset_index in one query.
+// Returns a map of hash → topic containing only the hashes that are indexed.
+// Callers keep len(hashes) below SQLite's bound-parameter limit.
+func LookupHashTopics(db *sql.DB, hashes []string) (map[string]string, error) {
+	found := make(map[string]string, len(hashes))
+	if len(hashes) == 0 {
+		return found, nil
+	}
+
+	placeholders := strings.Repeat("?,", len(hashes))
+	args := make([]interface{}, len(hashes))
+	for i, h := range hashes {
+		args[i] = h
+	}
+
+	rows, err := db.Query("SELECT hash, topic FROM asset_index WHERE hash IN ("+placeholders[:len(placeholders)-1]+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash, topic string
+		if err := rows.Scan(&hash, &topic); err != nil {
+			return nil, err
+		}
+		found[hash] = topic
+	}
+	return found, rows.Err()
+}
+
 // InsertAssetIndex inserts into asset_index table using the provided transaction
 // Used for atomic writes as part of the write pipeline
 func InsertAssetIndex(tx *sql.Tx, hash, topic, datFile string) error {
@@ -34,12 +66,55 @@ func InsertAssetIndexIgnore(db *sql.DB, hash, topic, datFile string) error {
 	return err
 }
 
-// DeleteAssetIndex deletes from asset_index (for future use when deletion is supported)
+// AssetIndexEntry locates one asset of a topic for asset_index.
+type AssetIndexEntry struct {
+	Hash    string
+	DatFile string
+}
+
+// IndexTopic indexes a discovered topic in one transaction: its assets are
+// added with INSERT OR IGNORE (an asset already indexed under another topic
+// keeps that topic) and the references it holds replace the previous ones.
+func IndexTopic(db *sql.DB, topic string, assets []AssetIndexEntry, refs []AssetReference) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO asset_index (hash, topic, dat_file) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, asset := range assets {
+		if _, err := stmt.Exec(asset.Hash, topic, asset.DatFile); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM asset_references WHERE topic = ?", topic); err != nil {
+		return err
+	}
+	if err := insertAssetReferences(tx, refs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteAssetIndex deletes from asset_index using the provided transaction
 func DeleteAssetIndex(tx *sql.Tx, hash string) error {
 	_, err := tx.Exec("DELETE FROM asset_index WHERE hash = ?", hash)
 	return err
 }
 
+// MoveAssetIndex updates the .dat file of an indexed asset using the provided transaction
+func MoveAssetIndex(tx *sql.Tx, hash, datFile string) error {
+	_, err := tx.Exec("UPDATE asset_index SET dat_file = ? WHERE hash = ?", datFile, hash)
+	return err
+}
+
 // ListIndexedTopics returns all distinct topic names referenced in asset_index
 func ListIndexedTopics(db *sql.DB) ([]string, error) {
 	rows, err := db.Query("SELECT DISTINCT topic FROM asset_index")
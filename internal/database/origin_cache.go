@@ -0,0 +1,89 @@
+package database
+
+import (
+	"database/sql"
+
+	"silobang/internal/constants"
+)
+
+// OriginCacheEntry is an asset fetched from the origin instance
+type OriginCacheEntry struct {
+	Hash      string `json:"hash"`
+	Topic     string `json:"topic"`
+	Size      int64  `json:"size"`
+	FetchedAt int64  `json:"fetched_at"`
+	LastHitAt int64  `json:"last_hit_at"`
+	Hits      int64  `json:"hits"`
+}
+
+const originCacheColumns = "hash, topic, size, fetched_at, last_hit_at, hits"
+
+// InsertOriginCacheEntry records a fetched asset, replacing an entry left
+// by an earlier fetch of the same hash
+func InsertOriginCacheEntry(db *sql.DB, e OriginCacheEntry) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO origin_cache (hash, topic, size, fetched_at, last_hit_at, hits)
+		VALUES (?, ?, ?, ?, ?, 0)
+	`, e.Hash, e.Topic, e.Size, e.FetchedAt, e.FetchedAt)
+	return err
+}
+
+// RecordOriginCacheHit counts a download of a fetched asset. Returns false
+// if the hash was not fetched from the origin.
+func RecordOriginCacheHit(db *sql.DB, hash string, at int64) (bool, error) {
+	res, err := db.Exec("UPDATE origin_cache SET hits = hits + 1, last_hit_at = ? WHERE hash = ?", at, hash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// OriginCacheUsage returns the number and total size of fetched assets
+func OriginCacheUsage(db *sql.DB) (int, int64, error) {
+	var count int
+	var bytes int64
+	err := db.QueryRow("SELECT COUNT(*), COALESCE(SUM(size), 0) FROM origin_cache").Scan(&count, &bytes)
+	return count, bytes, err
+}
+
+// ListOriginCacheEvictionOrder returns up to limit entries in the order the
+// eviction policy gives them up: least recently hit first for lru, fewest
+// hits first for lfu
+func ListOriginCacheEvictionOrder(db *sql.DB, policy string, limit int) ([]OriginCacheEntry, error) {
+	order := "last_hit_at, fetched_at"
+	if policy == constants.OriginEvictionLFU {
+		order = "hits, last_hit_at, fetched_at"
+	}
+	return queryOriginCache(db, "SELECT "+originCacheColumns+" FROM origin_cache ORDER BY "+order+" LIMIT ?", limit)
+}
+
+// ListRecentOriginCacheEntries returns the limit most recently fetched entries,
+// newest first even within the same second
+func ListRecentOriginCacheEntries(db *sql.DB, limit int) ([]OriginCacheEntry, error) {
+	return queryOriginCache(db, "SELECT "+originCacheColumns+" FROM origin_cache ORDER BY fetched_at DESC, rowid DESC LIMIT ?", limit)
+}
+
+// DeleteOriginCacheEntry forgets a fetched asset
+func DeleteOriginCacheEntry(db *sql.DB, hash string) error {
+	_, err := db.Exec("DELETE FROM origin_cache WHERE hash = ?", hash)
+	return err
+}
+
+func queryOriginCache(db *sql.DB, query string, args ...interface{}) ([]OriginCacheEntry, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]OriginCacheEntry, 0)
+	for rows.Next() {
+		var e OriginCacheEntry
+		if err := rows.Scan(&e.Hash, &e.Topic, &e.Size, &e.FetchedAt, &e.LastHitAt, &e.Hits); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
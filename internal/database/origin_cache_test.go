@@ -0,0 +1,31 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestListRecentOriginCacheEntries_SameSecondNewestFirst(t *testing.T) {
+	db, err := InitOrchestratorDB(filepath.Join(t.TempDir(), "orchestrator.db"))
+	if err != nil {
+		t.Fatalf("failed to init orchestrator db: %v", err)
+	}
+	defer db.Close()
+
+	// Inserted in the opposite order of their hashes, in the same second
+	first := OriginCacheEntry{Hash: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Topic: "cache", Size: 1, FetchedAt: 1700000000}
+	second := OriginCacheEntry{Hash: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Topic: "cache", Size: 2, FetchedAt: 1700000000}
+	for _, e := range []OriginCacheEntry{first, second} {
+		if err := InsertOriginCacheEntry(db, e); err != nil {
+			t.Fatalf("failed to insert entry: %v", err)
+		}
+	}
+
+	entries, err := ListRecentOriginCacheEntries(db, 10)
+	if err != nil {
+		t.Fatalf("failed to list entries: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Hash != second.Hash || entries[1].Hash != first.Hash {
+		t.Errorf("expected the later fetch first, got %+v", entries)
+	}
+}
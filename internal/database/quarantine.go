@@ -0,0 +1,164 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrNotQuarantined is returned when releasing an asset without an active quarantine
+var ErrNotQuarantined = errors.New("asset is not quarantined")
+
+// QuarantineEntry is one quarantine of an asset. The entry is active until
+// it is released; released entries are kept as history.
+type QuarantineEntry struct {
+	ID            int64   `json:"id"`
+	Hash          string  `json:"hash"`
+	Topic         string  `json:"topic"`
+	Source        string  `json:"source"`
+	Reason        string  `json:"reason"`
+	QuarantinedBy string  `json:"quarantined_by"`
+	QuarantinedAt int64   `json:"quarantined_at"`
+	ReleasedBy    *string `json:"released_by,omitempty"`
+	ReleasedAt    *int64  `json:"released_at,omitempty"`
+	Justification *string `json:"justification,omitempty"`
+}
+
+const quarantineColumns = "id, hash, topic, source, reason, quarantined_by, quarantined_at, released_by, released_at, justification"
+
+// QuarantineAssetTx stores e as the active quarantine of e.Hash. When the
+// asset is already quarantined the existing entry is returned unchanged and
+// created is false.
+func QuarantineAssetTx(tx *sql.Tx, e QuarantineEntry) (entry *QuarantineEntry, created bool, err error) {
+	existing, err := scanQuarantine(tx.Query("SELECT "+quarantineColumns+" FROM asset_quarantine WHERE hash = ? AND released_at IS NULL", e.Hash))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(existing) > 0 {
+		return &existing[0], false, nil
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO asset_quarantine (hash, topic, source, reason, quarantined_by, quarantined_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, e.Hash, e.Topic, e.Source, e.Reason, e.QuarantinedBy, e.QuarantinedAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to quarantine asset: %w", err)
+	}
+	e.ID, _ = result.LastInsertId()
+	return &e, true, nil
+}
+
+// QuarantineAsset is QuarantineAssetTx in its own transaction.
+func QuarantineAsset(db *sql.DB, e QuarantineEntry) (*QuarantineEntry, bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	entry, created, err := QuarantineAssetTx(tx, e)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+	return entry, created, nil
+}
+
+// GetActiveQuarantine returns the active quarantine of hash, or nil if the
+// asset is not quarantined.
+func GetActiveQuarantine(db *sql.DB, hash string) (*QuarantineEntry, error) {
+	entries, err := scanQuarantine(db.Query("SELECT "+quarantineColumns+" FROM asset_quarantine WHERE hash = ? AND released_at IS NULL", hash))
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// ReleaseQuarantine ends the active quarantine of hash, recording who
+// released it and why. Returns ErrNotQuarantined when there is none.
+func ReleaseQuarantine(db *sql.DB, hash, releasedBy, justification string, releasedAt int64) (*QuarantineEntry, error) {
+	active, err := GetActiveQuarantine(db, hash)
+	if err != nil {
+		return nil, err
+	}
+	if active == nil {
+		return nil, ErrNotQuarantined
+	}
+
+	result, err := db.Exec(`
+		UPDATE asset_quarantine SET released_by = ?, released_at = ?, justification = ?
+		WHERE id = ? AND released_at IS NULL
+	`, releasedBy, releasedAt, justification, active.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release quarantine: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrNotQuarantined
+	}
+
+	active.ReleasedBy = &releasedBy
+	active.ReleasedAt = &releasedAt
+	active.Justification = &justification
+	return active, nil
+}
+
+// ListQuarantine returns quarantine entries, newest first. Released entries
+// are included only when includeReleased is set; topic filters when not empty.
+func ListQuarantine(db *sql.DB, topic string, includeReleased bool) ([]QuarantineEntry, error) {
+	query := "SELECT " + quarantineColumns + " FROM asset_quarantine WHERE 1=1"
+	var args []interface{}
+	if !includeReleased {
+		query += " AND released_at IS NULL"
+	}
+	if topic != "" {
+		query += " AND topic = ?"
+		args = append(args, topic)
+	}
+	query += " ORDER BY id DESC"
+
+	entries, err := scanQuarantine(db.Query(query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantine: %w", err)
+	}
+	return entries, nil
+}
+
+// ActiveQuarantineHashes returns the set of currently quarantined hashes.
+func ActiveQuarantineHashes(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query("SELECT hash FROM asset_quarantine WHERE released_at IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes[hash] = true
+	}
+	return hashes, rows.Err()
+}
+
+func scanQuarantine(rows *sql.Rows, err error) ([]QuarantineEntry, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []QuarantineEntry{}
+	for rows.Next() {
+		var e QuarantineEntry
+		if err := rows.Scan(&e.ID, &e.Hash, &e.Topic, &e.Source, &e.Reason, &e.QuarantinedBy, &e.QuarantinedAt,
+			&e.ReleasedBy, &e.ReleasedAt, &e.Justification); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
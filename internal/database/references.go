@@ -0,0 +1,224 @@
+package database
+
+import (
+	"database/sql"
+
+	"silobang/internal/constants"
+)
+
+// AssetReference is one holder keeping an asset alive
+type AssetReference struct {
+	Hash      string `json:"hash"`
+	Kind      string `json:"kind"`
+	Topic     string `json:"topic"`
+	Holder    string `json:"holder"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// InsertAssetReference registers a reference using the provided transaction.
+// Used by the write pipeline so lineage references commit with the index entry.
+func InsertAssetReference(tx *sql.Tx, ref AssetReference) error {
+	_, err := tx.Exec(`
+		INSERT OR IGNORE INTO asset_references (hash, kind, topic, holder, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, ref.Hash, ref.Kind, ref.Topic, ref.Holder, ref.CreatedAt)
+	return err
+}
+
+// DeleteAssetReference releases a reference using the provided transaction.
+// Used by asset deletion so the lineage reference goes with the index entry.
+func DeleteAssetReference(tx *sql.Tx, ref AssetReference) error {
+	_, err := tx.Exec(`
+		DELETE FROM asset_references WHERE hash = ? AND kind = ? AND topic = ? AND holder = ?
+	`, ref.Hash, ref.Kind, ref.Topic, ref.Holder)
+	return err
+}
+
+// AddAssetReferences registers references atomically. Existing references are ignored.
+func AddAssetReferences(db *sql.DB, refs []AssetReference) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertAssetReferences(tx, refs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveAssetReferences releases the references a holder has on the given hashes.
+func RemoveAssetReferences(db *sql.DB, kind, topic, holder string, hashes []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		DELETE FROM asset_references WHERE hash = ? AND kind = ? AND topic = ? AND holder = ?
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, hash := range hashes {
+		if _, err := stmt.Exec(hash, kind, topic, holder); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RemoveHolderReferences releases every reference held by a holder.
+func RemoveHolderReferences(db *sql.DB, kind, topic, holder string) (int64, error) {
+	res, err := db.Exec(`
+		DELETE FROM asset_references WHERE kind = ? AND topic = ? AND holder = ?
+	`, kind, topic, holder)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ReplaceTopicReferences replaces every reference held in a topic in a single
+// transaction. Used to rebuild the registry from the topic database.
+func ReplaceTopicReferences(db *sql.DB, topic string, refs []AssetReference) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM asset_references WHERE topic = ?", topic); err != nil {
+		return err
+	}
+	if err := insertAssetReferences(tx, refs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListAssetReferences returns every reference to an asset, grouped by kind and topic
+func ListAssetReferences(db *sql.DB, hash string) ([]AssetReference, error) {
+	rows, err := db.Query(`
+		SELECT hash, kind, topic, holder, created_at
+		FROM asset_references WHERE hash = ?
+		ORDER BY kind, topic, holder
+	`, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAssetReferences(rows)
+}
+
+// ListReferencesIntoTopic returns references held outside a topic on assets
+// stored in it. Those references dangle once the topic is gone.
+func ListReferencesIntoTopic(db *sql.DB, topic string) ([]AssetReference, error) {
+	rows, err := db.Query(`
+		SELECT r.hash, r.kind, r.topic, r.holder, r.created_at
+		FROM asset_references r
+		JOIN asset_index i ON i.hash = r.hash
+		WHERE i.topic = ? AND r.topic != ?
+		ORDER BY r.hash, r.kind, r.topic, r.holder
+	`, topic, topic)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAssetReferences(rows)
+}
+
+// DeleteReferencesByTopic removes the references held in a topic and the
+// references on assets stored in it. Must run before the topic's asset_index
+// entries are deleted. Returns the number of references removed.
+func DeleteReferencesByTopic(db *sql.DB, topic string) (int64, error) {
+	res, err := db.Exec(`
+		DELETE FROM asset_references
+		WHERE topic = ? OR hash IN (SELECT hash FROM asset_index WHERE topic = ?)
+	`, topic, topic)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CollectTopicReferences reads the references held in a topic database:
+// collection memberships and parent links of derived assets.
+func CollectTopicReferences(topicDB *sql.DB, topic string) ([]AssetReference, error) {
+	refs := make([]AssetReference, 0)
+
+	rows, err := topicDB.Query("SELECT asset_id, collection, added_at FROM collection_assets")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		ref := AssetReference{Kind: constants.ReferenceKindCollection, Topic: topic}
+		if err := rows.Scan(&ref.Hash, &ref.Holder, &ref.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	rows, err = topicDB.Query("SELECT parent_id, asset_id, created_at FROM assets WHERE parent_id IS NOT NULL AND parent_id != ''")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		ref := AssetReference{Kind: constants.ReferenceKindLineage, Topic: topic}
+		if err := rows.Scan(&ref.Hash, &ref.Holder, &ref.CreatedAt); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
+// insertAssetReferences inserts references within a transaction, ignoring duplicates
+func insertAssetReferences(tx *sql.Tx, refs []AssetReference) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO asset_references (hash, kind, topic, holder, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, ref := range refs {
+		if _, err := stmt.Exec(ref.Hash, ref.Kind, ref.Topic, ref.Holder, ref.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanAssetReferences reads reference rows
+func scanAssetReferences(rows *sql.Rows) ([]AssetReference, error) {
+	refs := make([]AssetReference, 0)
+	for rows.Next() {
+		var ref AssetReference
+		if err := rows.Scan(&ref.Hash, &ref.Kind, &ref.Topic, &ref.Holder, &ref.CreatedAt); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
@@ -0,0 +1,314 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// Rule is an automation rule: when its trigger fires, the action is applied
+// to the assets matching its condition
+type Rule struct {
+	ID          int64         `json:"id"`
+	Name        string        `json:"name"`
+	Enabled     bool          `json:"enabled"`
+	Trigger     RuleTrigger   `json:"trigger"`
+	Condition   RuleCondition `json:"condition"`
+	Action      RuleAction    `json:"action"`
+	LastAuditID int64         `json:"-"` // event rules: audit entries up to this id are processed
+	LastRunAt   int64         `json:"last_run_at"`
+	CreatedBy   string        `json:"created_by"`
+	CreatedAt   int64         `json:"created_at"`
+	UpdatedAt   int64         `json:"updated_at"`
+}
+
+// RuleTrigger selects when a rule runs
+type RuleTrigger struct {
+	Type         string   `json:"type"`                    // schedule or event
+	IntervalSecs int64    `json:"interval_secs,omitempty"` // schedule
+	Events       []string `json:"events,omitempty"`        // event: audit actions naming an asset
+}
+
+// RuleCondition selects the assets a rule acts on. Every set field must
+// match.
+type RuleCondition struct {
+	Preset   string                 `json:"preset,omitempty"` // query returning asset_id
+	Params   map[string]interface{} `json:"params,omitempty"`
+	Topics   []string               `json:"topics,omitempty"`   // empty = every topic
+	Metadata map[string]string      `json:"metadata,omitempty"` // key -> current value
+}
+
+// RuleAction is what a rule does to each matched asset
+type RuleAction struct {
+	Type   string   `json:"type"`             // tag, notify, tombstone or quarantine
+	Key    string   `json:"key,omitempty"`    // tag
+	Value  string   `json:"value,omitempty"`  // tag
+	Users  []string `json:"users,omitempty"`  // notify: usernames
+	Reason string   `json:"reason,omitempty"` // quarantine
+}
+
+// RuleRun is one execution of a rule, or a dry run reporting what it would do
+type RuleRun struct {
+	ID         int64    `json:"id"`
+	RuleID     int64    `json:"rule_id"`
+	Trigger    string   `json:"trigger"` // schedule, event or manual
+	DryRun     bool     `json:"dry_run"`
+	StartedAt  int64    `json:"started_at"`
+	DurationMs int64    `json:"duration_ms"`
+	Matched    int      `json:"matched"`
+	Applied    int      `json:"applied"`
+	Skipped    int      `json:"skipped"`
+	Failed     int      `json:"failed"`
+	Error      string   `json:"error,omitempty"`
+	Assets     []string `json:"assets"` // sample of the matched hashes
+}
+
+const ruleColumns = "id, name, enabled, trigger_json, condition_json, action_json, last_audit_id, last_run_at, created_by, created_at, updated_at"
+
+// InsertRule stores a new rule and sets its ID. Its cursor starts at the
+// newest audit entry and its schedule at its creation, so it first acts on
+// what happens after it was created.
+func InsertRule(db *sql.DB, r *Rule) error {
+	trigger, condition, action, err := marshalRule(r)
+	if err != nil {
+		return err
+	}
+	res, err := db.Exec(`
+		INSERT INTO rules (name, enabled, trigger_json, condition_json, action_json, last_audit_id, last_run_at, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, (SELECT COALESCE(MAX(id), 0) FROM audit_log), ?, ?, ?, ?)
+	`, r.Name, r.Enabled, trigger, condition, action, r.CreatedAt, r.CreatedBy, r.CreatedAt, r.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	r.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	r.LastRunAt = r.CreatedAt
+	return nil
+}
+
+// UpdateRule saves a rule's definition. When restart is set its cursor
+// moves to the newest audit entry and its schedule restarts from
+// r.UpdatedAt, so nothing that happened while it was disabled or defined
+// differently is acted on.
+func UpdateRule(db *sql.DB, r Rule, restart bool) error {
+	trigger, condition, action, err := marshalRule(&r)
+	if err != nil {
+		return err
+	}
+	cursor, lastRun := "last_audit_id", "last_run_at"
+	if restart {
+		cursor, lastRun = "(SELECT COALESCE(MAX(id), 0) FROM audit_log)", "updated_at"
+	}
+	_, err = db.Exec(`
+		UPDATE rules SET name = ?, enabled = ?, trigger_json = ?, condition_json = ?, action_json = ?, updated_at = ?,
+			last_audit_id = `+cursor+`, last_run_at = `+lastRun+`
+		WHERE id = ?
+	`, r.Name, r.Enabled, trigger, condition, action, r.UpdatedAt, r.ID)
+	return err
+}
+
+// GetRule returns a rule, or nil if none
+func GetRule(db *sql.DB, id int64) (*Rule, error) {
+	rows, err := db.Query("SELECT "+ruleColumns+" FROM rules WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := scanRules(rows)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	return &rules[0], nil
+}
+
+// GetRuleByName returns the rule with the given name, or nil if none
+func GetRuleByName(db *sql.DB, name string) (*Rule, error) {
+	rows, err := db.Query("SELECT "+ruleColumns+" FROM rules WHERE name = ?", name)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := scanRules(rows)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	return &rules[0], nil
+}
+
+// ListRules returns every rule ordered by ID
+func ListRules(db *sql.DB) ([]Rule, error) {
+	rows, err := db.Query("SELECT " + ruleColumns + " FROM rules ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	return scanRules(rows)
+}
+
+// DeleteRule removes a rule and its run history. Returns false if it did
+// not exist.
+func DeleteRule(db *sql.DB, id int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM rule_runs WHERE rule_id = ?", id); err != nil {
+		return false, err
+	}
+	res, err := tx.Exec("DELETE FROM rules WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, tx.Commit()
+}
+
+// AdvanceRuleCursor moves an event rule's cursor from prevCursor to cursor.
+// Returns false when the cursor was moved meanwhile (the rule was updated or
+// deleted), in which case the entries read must not be acted on.
+func AdvanceRuleCursor(db *sql.DB, id, prevCursor, cursor int64) (bool, error) {
+	res, err := db.Exec("UPDATE rules SET last_audit_id = ? WHERE id = ? AND last_audit_id = ?", cursor, id, prevCursor)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// SetRuleLastRun records when a rule last ran
+func SetRuleLastRun(db *sql.DB, id, at int64) error {
+	_, err := db.Exec("UPDATE rules SET last_run_at = ? WHERE id = ?", at, id)
+	return err
+}
+
+// ListRecentAuditEntries returns the newest limit audit entries whose action
+// is in events, newest first
+func ListRecentAuditEntries(db *sql.DB, events []string, limit int) ([]WebhookAuditEntry, error) {
+	args := make([]interface{}, 0, len(events)+1)
+	for _, e := range events {
+		args = append(args, e)
+	}
+	args = append(args, limit)
+
+	rows, err := db.Query(`
+		SELECT id, timestamp, action, ip_address, username, actor_type, details_json FROM audit_log
+		WHERE action IN (`+placeholders(len(events))+`)
+		ORDER BY id DESC LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]WebhookAuditEntry, 0)
+	for rows.Next() {
+		var e WebhookAuditEntry
+		var details sql.NullString
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Action, &e.IPAddress, &e.Username, &e.ActorType, &details); err != nil {
+			return nil, err
+		}
+		if details.Valid && details.String != "" {
+			e.Details = json.RawMessage(details.String)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// InsertRuleRun records a run and sets its ID
+func InsertRuleRun(db *sql.DB, run *RuleRun) error {
+	assets, err := json.Marshal(run.Assets)
+	if err != nil {
+		return err
+	}
+	res, err := db.Exec(`
+		INSERT INTO rule_runs (rule_id, trigger, dry_run, started_at, duration_ms, matched, applied, skipped, failed, error, assets_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.RuleID, run.Trigger, run.DryRun, run.StartedAt, run.DurationMs, run.Matched, run.Applied, run.Skipped,
+		run.Failed, run.Error, string(assets))
+	if err != nil {
+		return err
+	}
+	run.ID, err = res.LastInsertId()
+	return err
+}
+
+// ListRuleRuns returns the newest runs of a rule
+func ListRuleRuns(db *sql.DB, ruleID int64, limit int) ([]RuleRun, error) {
+	rows, err := db.Query(`
+		SELECT id, rule_id, trigger, dry_run, started_at, duration_ms, matched, applied, skipped, failed, error, assets_json
+		FROM rule_runs WHERE rule_id = ? ORDER BY id DESC LIMIT ?
+	`, ruleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]RuleRun, 0)
+	for rows.Next() {
+		var run RuleRun
+		var assets string
+		if err := rows.Scan(&run.ID, &run.RuleID, &run.Trigger, &run.DryRun, &run.StartedAt, &run.DurationMs,
+			&run.Matched, &run.Applied, &run.Skipped, &run.Failed, &run.Error, &assets); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(assets), &run.Assets); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// DeleteRuleRunsBefore removes runs started before the cutoff
+func DeleteRuleRunsBefore(db *sql.DB, before int64) (int64, error) {
+	res, err := db.Exec("DELETE FROM rule_runs WHERE started_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func marshalRule(r *Rule) (string, string, string, error) {
+	trigger, err := json.Marshal(r.Trigger)
+	if err != nil {
+		return "", "", "", err
+	}
+	condition, err := json.Marshal(r.Condition)
+	if err != nil {
+		return "", "", "", err
+	}
+	action, err := json.Marshal(r.Action)
+	if err != nil {
+		return "", "", "", err
+	}
+	return string(trigger), string(condition), string(action), nil
+}
+
+func scanRules(rows *sql.Rows) ([]Rule, error) {
+	defer rows.Close()
+
+	rules := make([]Rule, 0)
+	for rows.Next() {
+		var r Rule
+		var trigger, condition, action string
+		if err := rows.Scan(&r.ID, &r.Name, &r.Enabled, &trigger, &condition, &action, &r.LastAuditID, &r.LastRunAt,
+			&r.CreatedBy, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(trigger), &r.Trigger); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(condition), &r.Condition); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(action), &r.Action); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
@@ -59,6 +59,82 @@ CREATE TABLE IF NOT EXISTS dat_hashes (
     entry_count INTEGER NOT NULL DEFAULT 0,  -- number of entries in the .dat file
     updated_at INTEGER NOT NULL    -- unix timestamp
 );
+
+-- collections table (named groupings of assets within the topic)
+CREATE TABLE IF NOT EXISTS collections (
+    name TEXT PRIMARY KEY,              -- lowercase alphanumeric with - and _
+    description TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+-- collection_assets table (many-to-many membership)
+CREATE TABLE IF NOT EXISTS collection_assets (
+    collection TEXT NOT NULL,
+    asset_id TEXT NOT NULL,
+    added_at INTEGER NOT NULL,
+    PRIMARY KEY (collection, asset_id),
+    FOREIGN KEY (collection) REFERENCES collections(name) ON DELETE CASCADE,
+    FOREIGN KEY (asset_id) REFERENCES assets(asset_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_assets_asset ON collection_assets(asset_id);
+
+-- dat_integrity table (findings of the latest .dat extent scan, replaced on each scan)
+CREATE TABLE IF NOT EXISTS dat_integrity (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    dat_file TEXT NOT NULL,        -- e.g., "001.dat"
+    kind TEXT NOT NULL,            -- 'gap' | 'trailing' | 'overlap' | 'beyond_eof' | 'missing_dat'
+    start_offset INTEGER NOT NULL, -- first byte of the region
+    end_offset INTEGER NOT NULL,   -- one past the last byte of the region
+    asset_id TEXT,                 -- recorded asset involved, or orphaned entry hash if its header parses
+    detail TEXT NOT NULL DEFAULT '',
+    detected_at INTEGER NOT NULL   -- unix timestamp of the scan
+);
+
+CREATE INDEX IF NOT EXISTS idx_dat_integrity_dat ON dat_integrity(dat_file);
+
+-- tombstones table (deleted assets whose entries remain in a .dat file until it is compacted)
+CREATE TABLE IF NOT EXISTS tombstones (
+    blob_name TEXT NOT NULL,       -- .dat file holding the entry
+    byte_offset INTEGER NOT NULL,  -- offset of the entry header
+    asset_id TEXT NOT NULL,        -- hash of the deleted asset
+    asset_size INTEGER NOT NULL,   -- data bytes, excluding the header
+    deleted_at INTEGER NOT NULL,   -- unix timestamp
+    deleted_by TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (blob_name, byte_offset)
+);
+
+-- lineage_changes table (append-only log of parents replaced after upload)
+CREATE TABLE IF NOT EXISTS lineage_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    asset_id TEXT NOT NULL,        -- asset whose parent changed
+    old_parent_id TEXT,            -- NULL when it had none
+    new_parent_id TEXT,            -- NULL when the parent was removed
+    changed_at INTEGER NOT NULL,   -- unix timestamp
+    changed_by TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_lineage_changes_asset ON lineage_changes(asset_id);
+
+CREATE TRIGGER IF NOT EXISTS lineage_changes_no_update BEFORE UPDATE ON lineage_changes
+BEGIN
+    SELECT RAISE(ABORT, 'lineage_changes is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS lineage_changes_no_delete BEFORE DELETE ON lineage_changes
+BEGIN
+    SELECT RAISE(ABORT, 'lineage_changes is append-only');
+END;
+
+-- dat_offloads table (sealed .dat files moved to the topic's blob store; no local copy remains)
+CREATE TABLE IF NOT EXISTS dat_offloads (
+    dat_file TEXT PRIMARY KEY,     -- e.g., "000001.dat"
+    blob_store TEXT NOT NULL,      -- name of the store in blob_stores
+    size INTEGER NOT NULL,         -- bytes uploaded
+    offloaded_at INTEGER NOT NULL  -- unix timestamp
+);
 `
 }
 
@@ -73,6 +149,38 @@ CREATE TABLE IF NOT EXISTS asset_index (
 
 CREATE INDEX IF NOT EXISTS idx_asset_topic ON asset_index(topic);
 
+-- Asset reference registry: every holder that keeps an asset alive (collection
+-- memberships, derived assets naming it as parent). Rebuilt per topic when the
+-- topic is indexed; cleanup consults it before dropping index entries.
+CREATE TABLE IF NOT EXISTS asset_references (
+    hash TEXT NOT NULL,         -- referenced asset
+    kind TEXT NOT NULL,         -- 'collection' | 'lineage' | 'export'
+    topic TEXT NOT NULL,        -- topic the holder lives in ('' for exports)
+    holder TEXT NOT NULL,       -- collection name, derived asset hash or link export ID
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (hash, kind, topic, holder)
+);
+
+CREATE INDEX IF NOT EXISTS idx_asset_references_topic ON asset_references(topic);
+
+-- Topic name reservations: held while a topic's folder and database are
+-- being created, so concurrent creators of the same name cannot collide.
+-- Released once the topic is registered or its partial folder removed; an
+-- expired row is left by a creator that died and may be taken over.
+CREATE TABLE IF NOT EXISTS topic_reservations (
+    name TEXT PRIMARY KEY,
+    token TEXT NOT NULL,
+    reserved_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL
+);
+
+-- Persisted topic stats cache (restored on startup, then reconciled)
+CREATE TABLE IF NOT EXISTS stats_cache (
+    topic TEXT PRIMARY KEY,
+    stats_json TEXT NOT NULL,
+    computed_at INTEGER NOT NULL
+);
+
 -- Audit log table (append-only for immutability)
 CREATE TABLE IF NOT EXISTS audit_log (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -80,8 +188,11 @@ CREATE TABLE IF NOT EXISTS audit_log (
     action TEXT NOT NULL,
     ip_address TEXT NOT NULL,
     username TEXT NOT NULL DEFAULT '',
+    actor_type TEXT NOT NULL DEFAULT 'user',
     details_json TEXT,
-    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now'))
+    created_at INTEGER NOT NULL DEFAULT (strftime('%s', 'now')),
+    prev_hash TEXT NOT NULL DEFAULT '',
+    entry_hash TEXT NOT NULL DEFAULT ''
 );
 
 CREATE INDEX IF NOT EXISTS idx_audit_timestamp ON audit_log(timestamp DESC);
@@ -91,6 +202,139 @@ CREATE INDEX IF NOT EXISTS idx_audit_ip_timestamp ON audit_log(ip_address, times
 CREATE INDEX IF NOT EXISTS idx_audit_username ON audit_log(username);
 CREATE INDEX IF NOT EXISTS idx_audit_username_timestamp ON audit_log(username, timestamp DESC);
 
+-- Runs of consecutive audit entries removed by purges, so the hash chain
+-- can be verified across them: the run followed the entry hashed
+-- first_prev_hash and its last entry was hashed last_hash.
+CREATE TABLE IF NOT EXISTS audit_chain_gaps (
+    first_id INTEGER PRIMARY KEY,
+    last_id INTEGER NOT NULL,
+    first_prev_hash TEXT NOT NULL,
+    last_hash TEXT NOT NULL,
+    purged_at INTEGER NOT NULL
+);
+
+-- Legal holds: audit entries matching an active hold (released_at IS NULL)
+-- are never purged. NULL criteria match any value.
+CREATE TABLE IF NOT EXISTS audit_legal_holds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    username TEXT,
+    action TEXT,
+    since INTEGER,
+    until INTEGER,
+    created_by TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    released_by TEXT,
+    released_at INTEGER
+);
+
+-- Quarantined assets: an asset with an active row (released_at IS NULL) is
+-- withheld from downloads, queries and bulk exports. Released rows are kept
+-- as history, with the justification given for the release.
+CREATE TABLE IF NOT EXISTS asset_quarantine (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    hash TEXT NOT NULL,
+    topic TEXT NOT NULL,
+    source TEXT NOT NULL,       -- 'admin' | 'integrity' | 'scan' | 'rule'
+    reason TEXT NOT NULL DEFAULT '',
+    quarantined_by TEXT NOT NULL DEFAULT '',
+    quarantined_at INTEGER NOT NULL,
+    released_by TEXT,
+    released_at INTEGER,
+    justification TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_asset_quarantine_active ON asset_quarantine(hash) WHERE released_at IS NULL;
+
+-- Deletion requests: assets proposed for permanent deletion, frozen when the
+-- request is made. A request is pending until another user approves or
+-- rejects it, or its approval window passes; approved requests are executed
+-- by the deletion job, which records the outcome of each asset.
+CREATE TABLE IF NOT EXISTS deletion_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    status TEXT NOT NULL,                    -- 'pending' | 'approved' | 'completed' | 'rejected' | 'cancelled' | 'expired'
+    mode TEXT NOT NULL,                      -- 'ids' | 'query'
+    preset TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    topics_json TEXT NOT NULL DEFAULT '[]',
+    asset_count INTEGER NOT NULL,
+    total_bytes INTEGER NOT NULL,
+    requested_by TEXT NOT NULL,
+    requested_by_id INTEGER NOT NULL,
+    requested_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    decided_by TEXT NOT NULL DEFAULT '',
+    decided_by_id INTEGER NOT NULL DEFAULT 0,
+    decided_at INTEGER NOT NULL DEFAULT 0,
+    comment TEXT NOT NULL DEFAULT '',
+    completed_at INTEGER NOT NULL DEFAULT 0,
+    deleted INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_deletion_requests_status ON deletion_requests(status, expires_at);
+
+CREATE TABLE IF NOT EXISTS deletion_request_assets (
+    request_id INTEGER NOT NULL,
+    hash TEXT NOT NULL,
+    topic TEXT NOT NULL,
+    size INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'pending', -- 'pending' | 'deleted' | 'failed'
+    error TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (request_id, hash),
+    FOREIGN KEY (request_id) REFERENCES deletion_requests(id)
+);
+
+-- Archived assets: exported to an entry of archives after going
+-- undownloaded for the topic's idle_days. The asset's row in its topic stays
+-- as a stub whose blob_name is 'archive'; a recall copies the content back
+-- into the topic and drops the row here.
+CREATE TABLE IF NOT EXISTS archived_assets (
+    hash TEXT PRIMARY KEY,
+    topic TEXT NOT NULL,
+    archive TEXT NOT NULL,                   -- name in archives
+    archive_key TEXT NOT NULL,               -- object key in the archive
+    size INTEGER NOT NULL,
+    archived_at INTEGER NOT NULL,
+    archived_by TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'archived', -- 'archived' | 'recalling'
+    recall_requested_by TEXT NOT NULL DEFAULT '',
+    recall_requested_at INTEGER NOT NULL DEFAULT 0,
+    recall_error TEXT NOT NULL DEFAULT ''    -- last failure to bring the asset back
+);
+
+CREATE INDEX IF NOT EXISTS idx_archived_assets_topic ON archived_assets(topic, status);
+
+-- Trashed assets: deleted through the API but kept until restored or purged.
+-- A trashed asset is withheld from downloads, queries and bulk exports; its
+-- row is dropped when it is restored or purged.
+CREATE TABLE IF NOT EXISTS asset_trash (
+    hash TEXT PRIMARY KEY,
+    topic TEXT NOT NULL,
+    origin_name TEXT NOT NULL DEFAULT '',
+    extension TEXT NOT NULL DEFAULT '',
+    size INTEGER NOT NULL DEFAULT 0,
+    trashed_by TEXT NOT NULL DEFAULT '',
+    trashed_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_asset_trash_trashed_at ON asset_trash(trashed_at);
+
+-- Assets the integrity scrubber found damaged: content that no longer
+-- matches its hash, or that could not be read. A row is removed when a later
+-- pass over its topic finds the asset healthy or no longer stored.
+CREATE TABLE IF NOT EXISTS asset_health (
+    hash TEXT PRIMARY KEY,
+    topic TEXT NOT NULL,
+    status TEXT NOT NULL,              -- 'corrupt' | 'missing'
+    detail TEXT NOT NULL DEFAULT '',
+    detected_at INTEGER NOT NULL,      -- first pass that found the asset damaged
+    checked_at INTEGER NOT NULL        -- latest pass that found it damaged
+);
+
+CREATE INDEX IF NOT EXISTS idx_asset_health_topic ON asset_health(topic);
+
 -- ============================================================================
 -- AUTH TABLES
 -- ============================================================================
@@ -110,6 +354,8 @@ CREATE TABLE IF NOT EXISTS auth_users (
     created_by INTEGER,
     failed_login_count INTEGER NOT NULL DEFAULT 0,
     locked_until INTEGER,
+    account_type TEXT NOT NULL DEFAULT 'user',
+    api_key_expires_at INTEGER,
     FOREIGN KEY (created_by) REFERENCES auth_users(id)
 );
 
@@ -177,6 +423,368 @@ CREATE TABLE IF NOT EXISTS auth_sessions (
 
 CREATE INDEX IF NOT EXISTS idx_auth_sessions_user ON auth_sessions(user_id);
 CREATE INDEX IF NOT EXISTS idx_auth_sessions_expires ON auth_sessions(expires_at);
+
+-- Sampled API-key usage (at most one row per user, IP and sample interval)
+CREATE TABLE IF NOT EXISTS auth_api_key_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    key_prefix TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL,
+    user_agent TEXT,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    used_at INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES auth_users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_api_key_usage_user ON auth_api_key_usage(user_id, used_at DESC);
+
+-- Per-user API usage, aggregated per hour bucket and endpoint pattern
+CREATE TABLE IF NOT EXISTS auth_usage (
+    user_id INTEGER NOT NULL,
+    bucket_start INTEGER NOT NULL,
+    endpoint TEXT NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    errors INTEGER NOT NULL DEFAULT 0,
+    bytes_in INTEGER NOT NULL DEFAULT 0,
+    bytes_out INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, bucket_start, endpoint),
+    FOREIGN KEY (user_id) REFERENCES auth_users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_usage_bucket ON auth_usage(bucket_start);
+
+-- Break-glass admin recovery tokens (issued from the CLI, single use, hashed)
+CREATE TABLE IF NOT EXISTS auth_recovery_tokens (
+    token_hash TEXT PRIMARY KEY,
+    token_prefix TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    used_at INTEGER,
+    used_ip TEXT
+);
+
+-- CI workspace tokens, issued to a pipeline account and exchanged by each
+-- job for a short-lived job token. Scopes are a JSON array of
+-- {action, constraints_json}.
+CREATE TABLE IF NOT EXISTS auth_workspace_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    account_id INTEGER NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    token_hash TEXT NOT NULL UNIQUE,
+    token_prefix TEXT NOT NULL,
+    scopes_json TEXT NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    last_used_at INTEGER,
+    last_used_ip TEXT,
+    exchange_count INTEGER NOT NULL DEFAULT 0,
+    revoked_at INTEGER,
+    revoked_by INTEGER,
+    FOREIGN KEY (account_id) REFERENCES auth_users(id),
+    FOREIGN KEY (created_by) REFERENCES auth_users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_workspace_tokens_account ON auth_workspace_tokens(account_id);
+
+-- Job tokens exchanged for a workspace token (hashed, short-lived)
+CREATE TABLE IF NOT EXISTS auth_job_tokens (
+    token_hash TEXT PRIMARY KEY,
+    token_prefix TEXT NOT NULL,
+    workspace_token_id INTEGER NOT NULL,
+    job TEXT NOT NULL DEFAULT '',
+    ip_address TEXT,
+    created_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    FOREIGN KEY (workspace_token_id) REFERENCES auth_workspace_tokens(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_job_tokens_workspace ON auth_job_tokens(workspace_token_id);
+CREATE INDEX IF NOT EXISTS idx_auth_job_tokens_expires ON auth_job_tokens(expires_at);
+
+-- Labelled API keys besides the key on the account (hashed). A rotated key
+-- points at its replacement and expires at the end of its grace period.
+CREATE TABLE IF NOT EXISTS auth_api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    label TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    key_prefix TEXT NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    expires_at INTEGER,
+    revoked_at INTEGER,
+    revoked_by INTEGER,
+    replaced_by INTEGER,
+    FOREIGN KEY (user_id) REFERENCES auth_users(id),
+    FOREIGN KEY (created_by) REFERENCES auth_users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_api_keys_user ON auth_api_keys(user_id);
+
+-- Identities of an OpenID Connect provider linked to local users. A user has
+-- at most one identity per issuer.
+CREATE TABLE IF NOT EXISTS auth_oidc_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    last_login_at INTEGER NOT NULL,
+    PRIMARY KEY (issuer, subject),
+    UNIQUE (issuer, user_id),
+    FOREIGN KEY (user_id) REFERENCES auth_users(id)
+);
+
+-- Topic notification subscriptions (one per user and topic)
+CREATE TABLE IF NOT EXISTS notification_subscriptions (
+    user_id INTEGER NOT NULL,
+    topic TEXT NOT NULL,
+    events_json TEXT NOT NULL,
+    metadata_keys_json TEXT, -- NULL = any key
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+    PRIMARY KEY (user_id, topic),
+    FOREIGN KEY (user_id) REFERENCES auth_users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_subscriptions_topic ON notification_subscriptions(topic);
+
+-- Per-user notification delivery preferences
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER PRIMARY KEY,
+    delivery TEXT NOT NULL DEFAULT 'immediate',
+    webhook_url TEXT NOT NULL DEFAULT '',
+    email TEXT NOT NULL DEFAULT '',
+    last_delivered_at INTEGER NOT NULL DEFAULT 0,
+    updated_at INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES auth_users(id)
+);
+
+-- Notification feed. delivered_at stays NULL until sent by webhook or email.
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    topic TEXT NOT NULL,
+    event TEXT NOT NULL,
+    asset_id TEXT,
+    actor TEXT NOT NULL DEFAULT '',
+    details_json TEXT,
+    created_at INTEGER NOT NULL,
+    read_at INTEGER,
+    delivered_at INTEGER,
+    delivery_attempts INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (user_id) REFERENCES auth_users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_pending ON notifications(delivered_at, user_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_created ON notifications(created_at);
+
+-- Per-user export inbox: bulk downloads built in the background. The ZIP is
+-- stored at .internal/exports/<user_id>/<id>.zip until expires_at.
+CREATE TABLE IF NOT EXISTS exports (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL, -- processing, ready or failed
+    mode TEXT NOT NULL,
+    preset TEXT NOT NULL DEFAULT '',
+    topics_json TEXT,
+    asset_count INTEGER NOT NULL DEFAULT 0,
+    failed_assets INTEGER NOT NULL DEFAULT 0,
+    size_bytes INTEGER NOT NULL DEFAULT 0, -- estimate while processing, ZIP size once ready
+    error TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    completed_at INTEGER,
+    expires_at INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES auth_users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exports_user ON exports(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_exports_expires ON exports(expires_at);
+
+-- Link exports: directories of hard links for consumers on the same host,
+-- kept at .internal/links/exports/<id>/ until deleted. Links point at
+-- extracted copies under .internal/links/objects/, shared by every export
+-- selecting the asset and removed once no entry uses them. Each entry holds
+-- an 'export' reference, so its asset cannot be deleted while linked.
+CREATE TABLE IF NOT EXISTS link_exports (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    label TEXT NOT NULL DEFAULT '',
+    filename_format TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES auth_users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_exports_user ON link_exports(user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS link_export_entries (
+    export_id TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    topic TEXT NOT NULL,
+    path TEXT NOT NULL,                      -- relative to the export directory
+    size INTEGER NOT NULL,
+    method TEXT NOT NULL,                    -- 'hardlink' or 'copy' where links are unsupported
+    linked_at INTEGER NOT NULL,
+    PRIMARY KEY (export_id, hash),
+    UNIQUE (export_id, path),
+    FOREIGN KEY (export_id) REFERENCES link_exports(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_export_entries_hash ON link_export_entries(hash);
+
+-- Resumable upload sessions. Received bytes are staged at
+-- .internal/uploads/<id>.part until the session is finalized, aborted or
+-- expires.
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    topic TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    parent_id TEXT,
+    relative_path TEXT NOT NULL DEFAULT '',
+    size INTEGER NOT NULL,                   -- declared total size
+    received_bytes INTEGER NOT NULL DEFAULT 0,
+    hash TEXT NOT NULL DEFAULT '',           -- expected BLAKE3, checked at finalize when set
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES auth_users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_user ON upload_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires ON upload_sessions(expires_at);
+
+-- Idempotency keys of mutating requests. response_status stays NULL while the
+-- first request is in progress; later requests with the same key replay the
+-- stored response until expires_at.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id INTEGER NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    request_digest TEXT NOT NULL,
+    response_status INTEGER,
+    response_content_type TEXT NOT NULL DEFAULT '',
+    response_body BLOB,
+    created_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
+
+-- Outgoing webhooks: audit entries with an id above last_audit_id whose
+-- action is in events_json (NULL = every action) are queued as deliveries.
+CREATE TABLE IF NOT EXISTS webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,                    -- HMAC-SHA256 key of the signature header
+    events_json TEXT,
+    active INTEGER NOT NULL DEFAULT 1,
+    last_audit_id INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+-- One POST of an audit entry to a webhook. delivered_at or failed_at is set
+-- once it was accepted or given up on; until then it is retried from
+-- next_attempt_at.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    audit_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    payload_json TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL,
+    last_status INTEGER NOT NULL DEFAULT 0,  -- HTTP status of the last attempt, 0 = no response
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    delivered_at INTEGER,
+    failed_at INTEGER,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(webhook_id, delivered_at, failed_at, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created ON webhook_deliveries(created_at);
+
+-- Automation rules. The trigger, condition and action are JSON documents
+-- validated by the rules service. Schedule rules run once interval_secs has
+-- passed since last_run_at; event rules read the audit entries with an id
+-- above last_audit_id.
+CREATE TABLE IF NOT EXISTS rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    trigger_json TEXT NOT NULL,
+    condition_json TEXT NOT NULL,
+    action_json TEXT NOT NULL,
+    last_audit_id INTEGER NOT NULL DEFAULT 0,
+    last_run_at INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+-- Execution history of rules, dry runs included. assets_json keeps a
+-- sample of the matched hashes.
+CREATE TABLE IF NOT EXISTS rule_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rule_id INTEGER NOT NULL,
+    trigger TEXT NOT NULL,                   -- 'schedule' | 'event' | 'manual'
+    dry_run INTEGER NOT NULL DEFAULT 0,
+    started_at INTEGER NOT NULL,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    matched INTEGER NOT NULL DEFAULT 0,
+    applied INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,      -- already in the state the action sets
+    failed INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    assets_json TEXT NOT NULL DEFAULT '[]',
+    FOREIGN KEY (rule_id) REFERENCES rules(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rule_runs_rule ON rule_runs(rule_id, id);
+CREATE INDEX IF NOT EXISTS idx_rule_runs_started ON rule_runs(started_at);
+
+-- Assets fetched from the configured origin into the origin cache topic.
+-- Downloads served from the cache count as hits; hits and last_hit_at rank
+-- entries for eviction.
+CREATE TABLE IF NOT EXISTS origin_cache (
+    hash TEXT PRIMARY KEY,
+    topic TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    fetched_at INTEGER NOT NULL,
+    last_hit_at INTEGER NOT NULL,            -- fetched_at until the first hit
+    hits INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_origin_cache_last_hit ON origin_cache(last_hit_at);
+
+-- Setup wizard steps an administrator confirmed or skipped; the other steps
+-- are derived from the configuration and the topics.
+CREATE TABLE IF NOT EXISTS setup_steps (
+    step TEXT PRIMARY KEY,
+    status TEXT NOT NULL,
+    user_id INTEGER,
+    updated_at INTEGER NOT NULL
+);
+
+-- Startup reports: one row per initialization of the working directory
+-- (process boot or POST /api/config), newest kept up to a fixed limit.
+CREATE TABLE IF NOT EXISTS startup_reports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    trigger TEXT NOT NULL,
+    started_at INTEGER NOT NULL,
+    completed_at INTEGER NOT NULL,
+    report_json TEXT NOT NULL
+);
 `
 }
 
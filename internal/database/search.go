@@ -0,0 +1,198 @@
+package database
+
+import (
+	"database/sql"
+	"sync"
+	"sync/atomic"
+
+	"silobang/internal/constants"
+)
+
+// searchTriggers are the names of the triggers maintaining asset_search
+var searchTriggers = []string{
+	"asset_search_assets_insert",
+	"asset_search_assets_update",
+	"asset_search_assets_delete",
+	"asset_search_metadata_insert",
+	"asset_search_metadata_update",
+	"asset_search_metadata_delete",
+}
+
+// GetTopicSearchSchema returns the SQL creating the search index of a topic
+// database: an FTS5 table whose rowids mirror the rowids of assets, kept
+// current by triggers on every write to assets and metadata_computed, so
+// uploads, metadata writes, imports and deletions need no extra code.
+// Metadata is indexed as "key value" pairs. It needs SQLite built with FTS5.
+func GetTopicSearchSchema() string {
+	newMetadata := `COALESCE((SELECT group_concat(j.key || ' ' || j.value, ' ') FROM json_each(new.metadata_json) j), '')`
+	return `
+-- asset_search table (full-text index; rowid = assets.rowid)
+CREATE VIRTUAL TABLE IF NOT EXISTS asset_search USING fts5(
+    asset_id UNINDEXED,
+    origin_name,
+    extension,
+    metadata,                      -- "key value" pairs of metadata_computed
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS asset_search_assets_insert AFTER INSERT ON assets
+BEGIN
+    INSERT INTO asset_search (rowid, asset_id, origin_name, extension, metadata)
+    VALUES (new.rowid, new.asset_id, new.origin_name, new.extension,
+        COALESCE((SELECT group_concat(j.key || ' ' || j.value, ' ')
+                  FROM metadata_computed m, json_each(m.metadata_json) j WHERE m.asset_id = new.asset_id), ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS asset_search_assets_update AFTER UPDATE OF origin_name, extension ON assets
+BEGIN
+    UPDATE asset_search SET origin_name = new.origin_name, extension = new.extension WHERE rowid = new.rowid;
+END;
+
+CREATE TRIGGER IF NOT EXISTS asset_search_assets_delete AFTER DELETE ON assets
+BEGIN
+    DELETE FROM asset_search WHERE rowid = old.rowid;
+END;
+
+CREATE TRIGGER IF NOT EXISTS asset_search_metadata_insert AFTER INSERT ON metadata_computed
+BEGIN
+    UPDATE asset_search SET metadata = ` + newMetadata + `
+    WHERE rowid = (SELECT rowid FROM assets WHERE asset_id = new.asset_id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS asset_search_metadata_update AFTER UPDATE ON metadata_computed
+BEGIN
+    UPDATE asset_search SET metadata = ` + newMetadata + `
+    WHERE rowid = (SELECT rowid FROM assets WHERE asset_id = new.asset_id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS asset_search_metadata_delete AFTER DELETE ON metadata_computed
+BEGIN
+    UPDATE asset_search SET metadata = ''
+    WHERE rowid = (SELECT rowid FROM assets WHERE asset_id = old.asset_id);
+END;
+`
+}
+
+var (
+	fts5Once      sync.Once
+	fts5Supported bool
+)
+
+// FTS5Supported reports whether the SQLite library was built with FTS5,
+// which go-sqlite3 only includes with the sqlite_fts5 build tag.
+func FTS5Supported(db *sql.DB) bool {
+	fts5Once.Do(func() {
+		var used int
+		err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&used)
+		fts5Supported = err == nil && used == 1
+	})
+	return fts5Supported
+}
+
+// searchDisabled is set while the search feature is turned off.
+var searchDisabled atomic.Bool
+
+// SetSearchIndexing turns the search index of topic databases opened from
+// now on on or off. Off, they are treated like databases without FTS5.
+func SetSearchIndexing(enabled bool) {
+	searchDisabled.Store(!enabled)
+}
+
+// InitTopicSearch creates the search index of a topic database, filling it
+// from the existing assets when its triggers were missing. Without FTS5, or
+// with search indexing turned off, the triggers are dropped instead, so a
+// database indexed by another build stays writable; the index is rebuilt
+// once search is available again.
+func InitTopicSearch(db *sql.DB) error {
+	if searchDisabled.Load() || !FTS5Supported(db) {
+		for _, name := range searchTriggers {
+			if _, err := db.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var existing int
+	if err := db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ("+placeholders(len(searchTriggers))+")",
+		stringArgs(searchTriggers)...,
+	).Scan(&existing); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(GetTopicSearchSchema()); err != nil {
+		return err
+	}
+	if existing < len(searchTriggers) {
+		if _, err := tx.Exec("DELETE FROM asset_search"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO asset_search (rowid, asset_id, origin_name, extension, metadata)
+			SELECT a.rowid, a.asset_id, a.origin_name, a.extension,
+				COALESCE((SELECT group_concat(j.key || ' ' || j.value, ' ')
+				          FROM metadata_computed m, json_each(m.metadata_json) j WHERE m.asset_id = a.asset_id), '')
+			FROM assets a
+		`); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SearchHit is an asset matching a full-text query
+type SearchHit struct {
+	Hash       string  `json:"hash"`
+	OriginName string  `json:"origin_name"`
+	Extension  string  `json:"extension"`
+	AssetSize  int64   `json:"asset_size"`
+	CreatedAt  int64   `json:"created_at"`
+	Score      float64 `json:"score"`   // higher is more relevant
+	Snippet    string  `json:"snippet"` // best matching column, matches highlighted
+}
+
+// SearchAssets returns the limit assets of a topic database best matching an
+// FTS5 query. Origin names weigh more than extensions, which weigh more
+// than metadata.
+func SearchAssets(db *sql.DB, match string, limit int) ([]SearchHit, error) {
+	rows, err := db.Query(`
+		SELECT a.asset_id, COALESCE(a.origin_name, ''), a.extension, a.asset_size, a.created_at,
+			-bm25(asset_search, 0, 10.0, 2.0, 1.0),
+			snippet(asset_search, -1, ?, ?, ?, ?)
+		FROM asset_search
+		JOIN assets a ON a.rowid = asset_search.rowid
+		WHERE asset_search MATCH ?
+		ORDER BY bm25(asset_search, 0, 10.0, 2.0, 1.0)
+		LIMIT ?
+	`, constants.SearchHighlightStart, constants.SearchHighlightEnd, constants.SearchSnippetEllipsis,
+		constants.SearchSnippetTokens, match, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := make([]SearchHit, 0)
+	for rows.Next() {
+		var h SearchHit
+		if err := rows.Scan(&h.Hash, &h.OriginName, &h.Extension, &h.AssetSize, &h.CreatedAt, &h.Score, &h.Snippet); err != nil {
+			return nil, err
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SetSetupStep records an administrator's confirmation or skip of a setup
+// step, replacing any earlier one.
+func SetSetupStep(db *sql.DB, step, status string, userID int64) error {
+	_, err := db.Exec(`
+		INSERT INTO setup_steps (step, status, user_id, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(step) DO UPDATE SET status = excluded.status, user_id = excluded.user_id, updated_at = excluded.updated_at
+	`, step, status, userID, time.Now().Unix())
+	return err
+}
+
+// GetSetupSteps returns the recorded status of each confirmed or skipped step.
+func GetSetupSteps(db *sql.DB) (map[string]string, error) {
+	rows, err := db.Query(`SELECT step, status FROM setup_steps`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	steps := make(map[string]string)
+	for rows.Next() {
+		var step, status string
+		if err := rows.Scan(&step, &status); err != nil {
+			return nil, err
+		}
+		steps[step] = status
+	}
+	return steps, rows.Err()
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// StartupReportRow is a persisted startup report
+type StartupReportRow struct {
+	ID          int64
+	Trigger     string
+	StartedAt   int64
+	CompletedAt int64
+	ReportJSON  string
+}
+
+// InsertStartupReport stores a startup report and deletes all but the newest
+// keep reports. Returns the new report ID.
+func InsertStartupReport(db *sql.DB, row StartupReportRow, keep int) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO startup_reports (trigger, started_at, completed_at, report_json)
+		VALUES (?, ?, ?, ?)
+	`, row.Trigger, row.StartedAt, row.CompletedAt, row.ReportJSON)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM startup_reports
+		WHERE id NOT IN (SELECT id FROM startup_reports ORDER BY id DESC LIMIT ?)
+	`, keep); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// ListStartupReports returns the newest startup reports first
+func ListStartupReports(db *sql.DB, limit int) ([]StartupReportRow, error) {
+	rows, err := db.Query(`
+		SELECT id, trigger, started_at, completed_at, report_json
+		FROM startup_reports ORDER BY id DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []StartupReportRow
+	for rows.Next() {
+		var row StartupReportRow
+		if err := rows.Scan(&row.ID, &row.Trigger, &row.StartedAt, &row.CompletedAt, &row.ReportJSON); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// CachedTopicStats is a persisted stats cache entry.
+type CachedTopicStats struct {
+	Topic      string
+	StatsJSON  string
+	ComputedAt int64
+}
+
+// UpsertCachedTopicStats stores the cached stats of one topic.
+func UpsertCachedTopicStats(db *sql.DB, entry CachedTopicStats) error {
+	_, err := db.Exec(`
+		INSERT INTO stats_cache (topic, stats_json, computed_at) VALUES (?, ?, ?)
+		ON CONFLICT(topic) DO UPDATE SET stats_json = excluded.stats_json, computed_at = excluded.computed_at
+	`, entry.Topic, entry.StatsJSON, entry.ComputedAt)
+	return err
+}
+
+// DeleteCachedTopicStats removes the cached stats of one topic.
+func DeleteCachedTopicStats(db *sql.DB, topic string) error {
+	_, err := db.Exec("DELETE FROM stats_cache WHERE topic = ?", topic)
+	return err
+}
+
+// ReplaceCachedTopicStats atomically replaces all cached stats.
+func ReplaceCachedTopicStats(db *sql.DB, entries []CachedTopicStats) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM stats_cache"); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if _, err := tx.Exec("INSERT INTO stats_cache (topic, stats_json, computed_at) VALUES (?, ?, ?)",
+			e.Topic, e.StatsJSON, e.ComputedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListCachedTopicStats returns all cached stats entries.
+func ListCachedTopicStats(db *sql.DB) ([]CachedTopicStats, error) {
+	rows, err := db.Query("SELECT topic, stats_json, computed_at FROM stats_cache ORDER BY topic")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []CachedTopicStats
+	for rows.Next() {
+		var e CachedTopicStats
+		if err := rows.Scan(&e.Topic, &e.StatsJSON, &e.ComputedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
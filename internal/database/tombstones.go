@@ -0,0 +1,137 @@
+package database
+
+import (
+	"database/sql"
+
+	"silobang/internal/constants"
+)
+
+// Tombstone records the .dat entry of a deleted asset until compaction
+// removes it.
+type Tombstone struct {
+	AssetID    string `json:"asset_id"`
+	AssetSize  int64  `json:"asset_size"`
+	BlobName   string `json:"dat_file"`
+	ByteOffset int64  `json:"byte_offset"`
+	DeletedAt  int64  `json:"deleted_at"`
+	DeletedBy  string `json:"deleted_by"`
+}
+
+// TombstonedDat summarizes the deleted entries a .dat file still holds.
+type TombstonedDat struct {
+	DatFile    string `json:"dat_file"`
+	Tombstones int    `json:"tombstones"`
+	DeadBytes  int64  `json:"dead_bytes"` // entry headers included
+}
+
+// DeleteAsset removes an asset and its metadata from the topic database and
+// leaves a tombstone for its .dat entry, using the provided transaction.
+// An archived asset has no entry left to tombstone.
+func DeleteAsset(tx *sql.Tx, asset Asset, deletedAt int64, deletedBy string) error {
+	if asset.BlobName != constants.ArchiveBlobName {
+		if err := insertTombstone(tx, asset, deletedAt, deletedBy); err != nil {
+			return err
+		}
+	}
+
+	for _, stmt := range []string{
+		"DELETE FROM metadata_log WHERE asset_id = ?",
+		"DELETE FROM metadata_computed WHERE asset_id = ?",
+		"DELETE FROM assets WHERE asset_id = ?",
+	} {
+		if _, err := tx.Exec(stmt, asset.AssetID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertTombstone(tx *sql.Tx, asset Asset, deletedAt int64, deletedBy string) error {
+	_, err := tx.Exec(`
+		INSERT INTO tombstones (blob_name, byte_offset, asset_id, asset_size, deleted_at, deleted_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, asset.BlobName, asset.ByteOffset, asset.AssetID, asset.AssetSize, deletedAt, deletedBy)
+	return err
+}
+
+// ListTombstonedDats returns the local .dat files holding tombstones, in file
+// order. Files offloaded to a blob store are never compacted.
+func ListTombstonedDats(db *sql.DB) ([]TombstonedDat, error) {
+	rows, err := db.Query(`
+		SELECT blob_name, COUNT(*), COALESCE(SUM(asset_size), 0) + COUNT(*) * ?
+		FROM tombstones
+		WHERE blob_name NOT IN (SELECT dat_file FROM dat_offloads)
+		GROUP BY blob_name ORDER BY blob_name
+	`, constants.HeaderSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dats := []TombstonedDat{}
+	for rows.Next() {
+		var d TombstonedDat
+		if err := rows.Scan(&d.DatFile, &d.Tombstones, &d.DeadBytes); err != nil {
+			return nil, err
+		}
+		dats = append(dats, d)
+	}
+	return dats, rows.Err()
+}
+
+// ListAssetsInDat returns the assets stored in a .dat file in offset order.
+func ListAssetsInDat(db *sql.DB, datFile string) ([]Asset, error) {
+	rows, err := db.Query(`
+		SELECT asset_id, asset_size, origin_name, parent_id, extension, blob_name, byte_offset, created_at
+		FROM assets WHERE blob_name = ? ORDER BY byte_offset
+	`, datFile)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []Asset
+	for rows.Next() {
+		var asset Asset
+		var pid sql.NullString
+		if err := rows.Scan(
+			&asset.AssetID,
+			&asset.AssetSize,
+			&asset.OriginName,
+			&pid,
+			&asset.Extension,
+			&asset.BlobName,
+			&asset.ByteOffset,
+			&asset.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if pid.Valid {
+			asset.ParentID = &pid.String
+		}
+		assets = append(assets, asset)
+	}
+	return assets, rows.Err()
+}
+
+// MoveAsset records the new location of an asset's entry using the provided
+// transaction.
+func MoveAsset(tx *sql.Tx, assetID, blobName string, byteOffset int64) error {
+	_, err := tx.Exec("UPDATE assets SET blob_name = ?, byte_offset = ? WHERE asset_id = ?", blobName, byteOffset, assetID)
+	return err
+}
+
+// DropDatRecords forgets a compacted .dat file: its running hash, tombstones
+// and integrity findings.
+func DropDatRecords(tx *sql.Tx, datFile string) error {
+	for _, stmt := range []string{
+		"DELETE FROM dat_hashes WHERE dat_file = ?",
+		"DELETE FROM tombstones WHERE blob_name = ?",
+		"DELETE FROM dat_integrity WHERE dat_file = ?",
+	} {
+		if _, err := tx.Exec(stmt, datFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package database
 
 import (
 	"database/sql"
+	"strings"
+
+	"silobang/internal/constants"
 )
 
 // Asset represents an asset record in the database
@@ -98,6 +101,55 @@ func GetAssetsByParent(db *sql.DB, parentID string) ([]Asset, error) {
 	return assets, rows.Err()
 }
 
+// GetAssetsByOriginName queries the assets stored under originName, newest
+// first. A non-empty extension restricts matches to that extension.
+func GetAssetsByOriginName(db *sql.DB, originName, extension string, limit int) ([]Asset, error) {
+	query := `
+		SELECT asset_id, asset_size, origin_name, parent_id, extension, blob_name, byte_offset, created_at
+		FROM assets WHERE origin_name = ?`
+	args := []interface{}{originName}
+	if extension != "" {
+		query += " AND extension = ?"
+		args = append(args, extension)
+	}
+	query += " ORDER BY created_at DESC, asset_id LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []Asset
+	for rows.Next() {
+		var asset Asset
+		var pid sql.NullString
+
+		err := rows.Scan(
+			&asset.AssetID,
+			&asset.AssetSize,
+			&asset.OriginName,
+			&pid,
+			&asset.Extension,
+			&asset.BlobName,
+			&asset.ByteOffset,
+			&asset.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if pid.Valid {
+			asset.ParentID = &pid.String
+		}
+
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
+
 // ValidateParentExists checks if parent_id exists in ANY topic via orchestrator.db
 // Returns error if not found
 func ValidateParentExists(orchestratorDB *sql.DB, parentID string) error {
@@ -113,3 +165,178 @@ func ValidateParentExists(orchestratorDB *sql.DB, parentID string) error {
 
 	return nil
 }
+
+// GetAssetTotals returns the number of assets and their combined size
+func GetAssetTotals(db *sql.DB) (count int64, totalSize int64, err error) {
+	var size sql.NullInt64
+	err = db.QueryRow("SELECT COUNT(*), SUM(asset_size) FROM assets").Scan(&count, &size)
+	return count, size.Int64, err
+}
+
+// ListExtensions returns the distinct extensions of a topic's assets in
+// sorted order.
+func ListExtensions(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT DISTINCT extension FROM assets ORDER BY extension")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var extensions []string
+	for rows.Next() {
+		var ext string
+		if err := rows.Scan(&ext); err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, ext)
+	}
+
+	return extensions, rows.Err()
+}
+
+// SampleAssets returns up to limit assets ordered by hash. BLAKE3 hashes are
+// uniformly distributed, so this is a stable pseudo-random sample.
+// Only the fields needed to read asset data are populated. Archived
+// assets are left out.
+func SampleAssets(db *sql.DB, limit int) ([]Asset, error) {
+	rows, err := db.Query(`
+		SELECT asset_id, asset_size, blob_name, byte_offset
+		FROM assets WHERE blob_name != ? ORDER BY asset_id LIMIT ?
+	`, constants.ArchiveBlobName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []Asset
+	for rows.Next() {
+		var asset Asset
+		if err := rows.Scan(&asset.AssetID, &asset.AssetSize, &asset.BlobName, &asset.ByteOffset); err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
+
+// ListAssetExtents returns every asset ordered by .dat file and offset, so
+// reading them in order reads each file sequentially. Only the fields needed
+// to read asset data are populated.
+func ListAssetExtents(db *sql.DB) ([]Asset, error) {
+	rows, err := db.Query(`
+		SELECT asset_id, asset_size, blob_name, byte_offset
+		FROM assets ORDER BY blob_name, byte_offset
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []Asset
+	for rows.Next() {
+		var asset Asset
+		if err := rows.Scan(&asset.AssetID, &asset.AssetSize, &asset.BlobName, &asset.ByteOffset); err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
+
+// ListAssetsByAge returns every asset, oldest first. Only the hash, size
+// and creation time are populated.
+func ListAssetsByAge(db *sql.DB) ([]Asset, error) {
+	rows, err := db.Query(`
+		SELECT asset_id, asset_size, created_at
+		FROM assets ORDER BY created_at, asset_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []Asset
+	for rows.Next() {
+		var asset Asset
+		if err := rows.Scan(&asset.AssetID, &asset.AssetSize, &asset.CreatedAt); err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
+
+// FindAssetsByOriginNames returns the assets whose origin_name is one of
+// names. Only the hash, origin name and extension are populated.
+// Callers keep len(names) below SQLite's bound-parameter limit.
+func FindAssetsByOriginNames(db *sql.DB, names []string) ([]Asset, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.Repeat("?,", len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+
+	rows, err := db.Query(`
+		SELECT asset_id, origin_name, extension
+		FROM assets WHERE origin_name IN (`+placeholders[:len(placeholders)-1]+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []Asset
+	for rows.Next() {
+		var asset Asset
+		if err := rows.Scan(&asset.AssetID, &asset.OriginName, &asset.Extension); err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
+
+// GetAssetsByIDs returns the assets among ids, keyed by hash. Only the hash,
+// size, origin name and extension are populated.
+// Callers keep len(ids) below SQLite's bound-parameter limit.
+func GetAssetsByIDs(db *sql.DB, ids []string) (map[string]Asset, error) {
+	assets := make(map[string]Asset, len(ids))
+	if len(ids) == 0 {
+		return assets, nil
+	}
+
+	placeholders := strings.Repeat("?,", len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := db.Query(`
+		SELECT asset_id, asset_size, origin_name, extension
+		FROM assets WHERE asset_id IN (`+placeholders[:len(placeholders)-1]+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var asset Asset
+		var originName sql.NullString
+		if err := rows.Scan(&asset.AssetID, &asset.AssetSize, &originName, &asset.Extension); err != nil {
+			return nil, err
+		}
+		asset.OriginName = originName.String
+		assets[asset.AssetID] = asset
+	}
+
+	return assets, rows.Err()
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// ReserveTopicName claims name for a topic creation identified by token. An
+// expired reservation of the name is replaced. Returns false when another
+// creation holds the name.
+func ReserveTopicName(db *sql.DB, name, token string, now, expiresAt int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM topic_reservations WHERE name = ? AND expires_at <= ?`, name, now); err != nil {
+		return false, err
+	}
+
+	res, err := tx.Exec(`
+		INSERT OR IGNORE INTO topic_reservations (name, token, reserved_at, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, name, token, now, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	return true, tx.Commit()
+}
+
+// ReleaseTopicName drops the reservation of name if token still holds it.
+func ReleaseTopicName(db *sql.DB, name, token string) error {
+	_, err := db.Exec(`DELETE FROM topic_reservations WHERE name = ? AND token = ?`, name, token)
+	return err
+}
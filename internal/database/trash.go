@@ -0,0 +1,150 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrNotTrashed is returned when restoring or purging an asset that is not in the trash
+var ErrNotTrashed = errors.New("asset is not in the trash")
+
+// TrashEntry is a trashed asset. PurgeAt is filled in by the caller from
+// the retention in effect; it is not stored.
+type TrashEntry struct {
+	Hash       string `json:"hash"`
+	Topic      string `json:"topic"`
+	OriginName string `json:"origin_name"`
+	Extension  string `json:"extension"`
+	Size       int64  `json:"size"`
+	TrashedBy  string `json:"trashed_by"`
+	TrashedAt  int64  `json:"trashed_at"`
+	PurgeAt    *int64 `json:"purge_at,omitempty"`
+}
+
+const trashColumns = "hash, topic, origin_name, extension, size, trashed_by, trashed_at"
+
+// TrashAsset stores e as a trashed asset. When the asset is already in the
+// trash the existing entry is returned unchanged and created is false.
+func TrashAsset(db *sql.DB, e TrashEntry) (entry *TrashEntry, created bool, err error) {
+	result, err := db.Exec(`
+		INSERT INTO asset_trash (hash, topic, origin_name, extension, size, trashed_by, trashed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(hash) DO NOTHING
+	`, e.Hash, e.Topic, e.OriginName, e.Extension, e.Size, e.TrashedBy, e.TrashedAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to trash asset: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		existing, err := GetTrashEntry(db, e.Hash)
+		if err != nil || existing == nil {
+			return nil, false, err
+		}
+		return existing, false, nil
+	}
+	return &e, true, nil
+}
+
+// GetTrashEntry returns the trash entry of hash, or nil if the asset is not
+// in the trash.
+func GetTrashEntry(db *sql.DB, hash string) (*TrashEntry, error) {
+	entries, err := scanTrash(db.Query("SELECT "+trashColumns+" FROM asset_trash WHERE hash = ?", hash))
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// RestoreTrash takes hash out of the trash and returns the entry it had.
+// Returns ErrNotTrashed when the asset is not in the trash.
+func RestoreTrash(db *sql.DB, hash string) (*TrashEntry, error) {
+	entry, err := GetTrashEntry(db, hash)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrNotTrashed
+	}
+
+	result, err := db.Exec("DELETE FROM asset_trash WHERE hash = ?", hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore asset: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrNotTrashed
+	}
+	return entry, nil
+}
+
+// DeleteTrashEntryTx drops the trash entry of hash, if any, as part of the
+// permanent deletion of the asset.
+func DeleteTrashEntryTx(tx *sql.Tx, hash string) error {
+	if _, err := tx.Exec("DELETE FROM asset_trash WHERE hash = ?", hash); err != nil {
+		return fmt.Errorf("failed to delete trash entry: %w", err)
+	}
+	return nil
+}
+
+// ListTrash returns trashed assets, most recently trashed first. topic
+// filters when not empty.
+func ListTrash(db *sql.DB, topic string) ([]TrashEntry, error) {
+	query := "SELECT " + trashColumns + " FROM asset_trash"
+	var args []interface{}
+	if topic != "" {
+		query += " WHERE topic = ?"
+		args = append(args, topic)
+	}
+	query += " ORDER BY trashed_at DESC, hash"
+
+	entries, err := scanTrash(db.Query(query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+	return entries, nil
+}
+
+// ListExpiredTrash returns up to limit assets trashed before cutoff, oldest
+// first.
+func ListExpiredTrash(db *sql.DB, cutoff int64, limit int) ([]TrashEntry, error) {
+	entries, err := scanTrash(db.Query("SELECT "+trashColumns+" FROM asset_trash WHERE trashed_at < ? ORDER BY trashed_at LIMIT ?", cutoff, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired trash: %w", err)
+	}
+	return entries, nil
+}
+
+// TrashedHashes returns the set of trashed hashes.
+func TrashedHashes(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query("SELECT hash FROM asset_trash")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes[hash] = true
+	}
+	return hashes, rows.Err()
+}
+
+func scanTrash(rows *sql.Rows, err error) ([]TrashEntry, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []TrashEntry{}
+	for rows.Next() {
+		var e TrashEntry
+		if err := rows.Scan(&e.Hash, &e.Topic, &e.OriginName, &e.Extension, &e.Size, &e.TrashedBy, &e.TrashedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
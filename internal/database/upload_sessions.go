@@ -0,0 +1,94 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// UploadSession is a resumable upload in progress
+type UploadSession struct {
+	ID            string  `json:"id"`
+	UserID        int64   `json:"-"`
+	Topic         string  `json:"topic"`
+	Filename      string  `json:"filename"`
+	ParentID      *string `json:"parent_id,omitempty"`
+	RelativePath  string  `json:"relative_path,omitempty"`
+	Size          int64   `json:"size"`
+	ReceivedBytes int64   `json:"offset"`
+	Hash          string  `json:"hash,omitempty"`
+	CreatedAt     int64   `json:"created_at"`
+	UpdatedAt     int64   `json:"updated_at"`
+	ExpiresAt     int64   `json:"expires_at"`
+}
+
+const uploadSessionColumns = "id, user_id, topic, filename, parent_id, relative_path, size, received_bytes, hash, created_at, updated_at, expires_at"
+
+// InsertUploadSession records a new upload session
+func InsertUploadSession(db *sql.DB, u UploadSession) error {
+	_, err := db.Exec(`
+		INSERT INTO upload_sessions (id, user_id, topic, filename, parent_id, relative_path, size, received_bytes, hash, created_at, updated_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, u.ID, u.UserID, u.Topic, u.Filename, u.ParentID, u.RelativePath, u.Size, u.ReceivedBytes, u.Hash, u.CreatedAt, u.UpdatedAt, u.ExpiresAt)
+	return err
+}
+
+// GetUploadSession returns a user's upload session, or nil if none
+func GetUploadSession(db *sql.DB, userID int64, id string) (*UploadSession, error) {
+	rows, err := db.Query("SELECT "+uploadSessionColumns+" FROM upload_sessions WHERE user_id = ? AND id = ?", userID, id)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := scanUploadSessions(rows)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return &sessions[0], nil
+}
+
+// UpdateUploadSessionProgress records the bytes received so far and the
+// new expiry of a session
+func UpdateUploadSessionProgress(db *sql.DB, u UploadSession) error {
+	_, err := db.Exec("UPDATE upload_sessions SET received_bytes = ?, updated_at = ?, expires_at = ? WHERE id = ?",
+		u.ReceivedBytes, u.UpdatedAt, u.ExpiresAt, u.ID)
+	return err
+}
+
+// CountUploadSessions returns the number of open sessions of a user
+func CountUploadSessions(db *sql.DB, userID int64) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM upload_sessions WHERE user_id = ?", userID).Scan(&count)
+	return count, err
+}
+
+// ListUploadSessionsExpiredBefore returns sessions whose expiry is at or before now
+func ListUploadSessionsExpiredBefore(db *sql.DB, now int64) ([]UploadSession, error) {
+	rows, err := db.Query("SELECT "+uploadSessionColumns+" FROM upload_sessions WHERE expires_at <= ? ORDER BY expires_at", now)
+	if err != nil {
+		return nil, err
+	}
+	return scanUploadSessions(rows)
+}
+
+// DeleteUploadSession removes an upload session. Returns false if it did not exist.
+func DeleteUploadSession(db *sql.DB, id string) (bool, error) {
+	res, err := db.Exec("DELETE FROM upload_sessions WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+func scanUploadSessions(rows *sql.Rows) ([]UploadSession, error) {
+	defer rows.Close()
+
+	sessions := make([]UploadSession, 0)
+	for rows.Next() {
+		var u UploadSession
+		if err := rows.Scan(&u.ID, &u.UserID, &u.Topic, &u.Filename, &u.ParentID, &u.RelativePath, &u.Size,
+			&u.ReceivedBytes, &u.Hash, &u.CreatedAt, &u.UpdatedAt, &u.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, u)
+	}
+	return sessions, rows.Err()
+}
@@ -0,0 +1,328 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// Webhook is an outgoing webhook fed from the audit log
+type Webhook struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"-"`
+	Events      []string `json:"events"` // empty = every action
+	Active      bool     `json:"active"`
+	LastAuditID int64    `json:"-"` // audit entries up to this id are queued
+	CreatedBy   string   `json:"created_by"`
+	CreatedAt   int64    `json:"created_at"`
+	UpdatedAt   int64    `json:"updated_at"`
+}
+
+// WebhookDelivery is one POST of an audit entry to a webhook
+type WebhookDelivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     int64           `json:"-"`
+	AuditID       int64           `json:"audit_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"-"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt int64           `json:"next_attempt_at"`
+	LastStatus    int             `json:"last_status"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     int64           `json:"created_at"`
+	DeliveredAt   *int64          `json:"delivered_at"`
+	FailedAt      *int64          `json:"failed_at"`
+}
+
+// WebhookDeliveryCounts counts the stored deliveries of a webhook by state
+type WebhookDeliveryCounts struct {
+	Pending   int64 `json:"pending"`
+	Delivered int64 `json:"delivered"`
+	Failed    int64 `json:"failed"`
+}
+
+// WebhookAuditEntry is an audit entry to be queued for a webhook
+type WebhookAuditEntry struct {
+	ID        int64
+	Timestamp int64
+	Action    string
+	IPAddress string
+	Username  string
+	ActorType string
+	Details   json.RawMessage
+}
+
+const webhookColumns = "id, name, url, secret, events_json, active, last_audit_id, created_by, created_at, updated_at"
+
+// InsertWebhook stores a new webhook and sets its ID. Its cursor starts at
+// the newest audit entry, so only later entries are delivered.
+func InsertWebhook(db *sql.DB, w *Webhook) error {
+	events, err := marshalWebhookEvents(w.Events)
+	if err != nil {
+		return err
+	}
+	res, err := db.Exec(`
+		INSERT INTO webhooks (name, url, secret, events_json, active, last_audit_id, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, (SELECT COALESCE(MAX(id), 0) FROM audit_log), ?, ?, ?)
+	`, w.Name, w.URL, w.Secret, events, w.Active, w.CreatedBy, w.CreatedAt, w.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	w.ID, err = res.LastInsertId()
+	return err
+}
+
+// UpdateWebhook saves a webhook's settings. When skipBacklog is set its
+// cursor moves to the newest audit entry, so entries logged while it was
+// inactive are not delivered.
+func UpdateWebhook(db *sql.DB, w Webhook, skipBacklog bool) error {
+	events, err := marshalWebhookEvents(w.Events)
+	if err != nil {
+		return err
+	}
+	cursor := "last_audit_id"
+	if skipBacklog {
+		cursor = "(SELECT COALESCE(MAX(id), 0) FROM audit_log)"
+	}
+	_, err = db.Exec(`
+		UPDATE webhooks SET name = ?, url = ?, secret = ?, events_json = ?, active = ?, updated_at = ?,
+			last_audit_id = `+cursor+`
+		WHERE id = ?
+	`, w.Name, w.URL, w.Secret, events, w.Active, w.UpdatedAt, w.ID)
+	return err
+}
+
+// GetWebhook returns a webhook, or nil if none
+func GetWebhook(db *sql.DB, id int64) (*Webhook, error) {
+	rows, err := db.Query("SELECT "+webhookColumns+" FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := scanWebhooks(rows)
+	if err != nil || len(hooks) == 0 {
+		return nil, err
+	}
+	return &hooks[0], nil
+}
+
+// ListWebhooks returns every webhook ordered by ID
+func ListWebhooks(db *sql.DB) ([]Webhook, error) {
+	rows, err := db.Query("SELECT " + webhookColumns + " FROM webhooks ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhooks(rows)
+}
+
+// DeleteWebhook removes a webhook and its deliveries. Returns false if it
+// did not exist.
+func DeleteWebhook(db *sql.DB, id int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM webhook_deliveries WHERE webhook_id = ?", id); err != nil {
+		return false, err
+	}
+	res, err := tx.Exec("DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, tx.Commit()
+}
+
+// ListWebhookAuditEntries returns up to limit audit entries after the cursor
+// whose action is in events (empty = any), oldest first, and the ID of the
+// newest audit entry. When fewer than limit entries are returned, every
+// entry up to that ID was scanned.
+func ListWebhookAuditEntries(db *sql.DB, after int64, events []string, limit int) ([]WebhookAuditEntry, int64, error) {
+	var head int64
+	if err := db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM audit_log").Scan(&head); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT id, timestamp, action, ip_address, username, actor_type, details_json FROM audit_log WHERE id > ? AND id <= ?"
+	args := []interface{}{after, head}
+	if len(events) > 0 {
+		query += " AND action IN (" + placeholders(len(events)) + ")"
+		for _, e := range events {
+			args = append(args, e)
+		}
+	}
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := make([]WebhookAuditEntry, 0)
+	for rows.Next() {
+		var e WebhookAuditEntry
+		var details sql.NullString
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Action, &e.IPAddress, &e.Username, &e.ActorType, &details); err != nil {
+			return nil, 0, err
+		}
+		if details.Valid && details.String != "" {
+			e.Details = json.RawMessage(details.String)
+		}
+		entries = append(entries, e)
+	}
+	return entries, head, rows.Err()
+}
+
+// QueueWebhookDeliveries stores deliveries for a webhook and moves its cursor
+// from prevCursor to cursor in one transaction. Returns false without
+// queuing anything when the cursor was moved meanwhile (the webhook was
+// updated or deleted).
+func QueueWebhookDeliveries(db *sql.DB, webhookID, prevCursor, cursor int64, deliveries []WebhookDelivery) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE webhooks SET last_audit_id = ? WHERE id = ? AND last_audit_id = ?", cursor, webhookID, prevCursor)
+	if err != nil {
+		return false, err
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return false, err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO webhook_deliveries (webhook_id, audit_id, event, payload_json, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	for _, d := range deliveries {
+		if _, err := stmt.Exec(webhookID, d.AuditID, d.Event, string(d.Payload), d.NextAttemptAt, d.CreatedAt); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+const webhookDeliveryColumns = "id, webhook_id, audit_id, event, payload_json, attempts, next_attempt_at, last_status, last_error, created_at, delivered_at, failed_at"
+
+// ListDueWebhookDeliveries returns up to limit deliveries of a webhook that
+// are neither delivered nor given up on and are due at now, oldest first
+func ListDueWebhookDeliveries(db *sql.DB, webhookID, now int64, limit int) ([]WebhookDelivery, error) {
+	rows, err := db.Query(`
+		SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = ? AND delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?
+		ORDER BY id LIMIT ?
+	`, webhookID, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// ListWebhookDeliveries returns the newest deliveries of a webhook
+func ListWebhookDeliveries(db *sql.DB, webhookID int64, limit int) ([]WebhookDelivery, error) {
+	rows, err := db.Query("SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?",
+		webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// CountWebhookDeliveries counts a webhook's stored deliveries by state
+func CountWebhookDeliveries(db *sql.DB, webhookID int64) (WebhookDeliveryCounts, error) {
+	var c WebhookDeliveryCounts
+	err := db.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN delivered_at IS NULL AND failed_at IS NULL THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN delivered_at IS NOT NULL THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN failed_at IS NOT NULL THEN 1 ELSE 0 END), 0)
+		FROM webhook_deliveries WHERE webhook_id = ?
+	`, webhookID).Scan(&c.Pending, &c.Delivered, &c.Failed)
+	return c, err
+}
+
+// UpdateWebhookDeliveryAttempt records the outcome of a delivery attempt
+func UpdateWebhookDeliveryAttempt(db *sql.DB, d WebhookDelivery) error {
+	_, err := db.Exec(`
+		UPDATE webhook_deliveries SET attempts = ?, next_attempt_at = ?, last_status = ?, last_error = ?, delivered_at = ?, failed_at = ?
+		WHERE id = ?
+	`, d.Attempts, d.NextAttemptAt, d.LastStatus, d.LastError, d.DeliveredAt, d.FailedAt, d.ID)
+	return err
+}
+
+// DeleteFinishedWebhookDeliveriesBefore removes delivered and abandoned
+// deliveries created before the cutoff
+func DeleteFinishedWebhookDeliveriesBefore(db *sql.DB, before int64) (int64, error) {
+	res, err := db.Exec(`
+		DELETE FROM webhook_deliveries
+		WHERE created_at < ? AND (delivered_at IS NOT NULL OR failed_at IS NOT NULL)
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func marshalWebhookEvents(events []string) (interface{}, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func scanWebhooks(rows *sql.Rows) ([]Webhook, error) {
+	defer rows.Close()
+
+	hooks := make([]Webhook, 0)
+	for rows.Next() {
+		var w Webhook
+		var events sql.NullString
+		if err := rows.Scan(&w.ID, &w.Name, &w.URL, &w.Secret, &events, &w.Active, &w.LastAuditID,
+			&w.CreatedBy, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return nil, err
+		}
+		w.Events = []string{}
+		if events.Valid {
+			if err := json.Unmarshal([]byte(events.String), &w.Events); err != nil {
+				return nil, err
+			}
+		}
+		hooks = append(hooks, w)
+	}
+	return hooks, rows.Err()
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := make([]WebhookDelivery, 0)
+	for rows.Next() {
+		var d WebhookDelivery
+		var payload string
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.AuditID, &d.Event, &payload, &d.Attempts, &d.NextAttemptAt,
+			&d.LastStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt, &d.FailedAt); err != nil {
+			return nil, err
+		}
+		d.Payload = json.RawMessage(payload)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,300 @@
+// Package envelope implements the per-recipient envelope encryption used by
+// encrypted bulk downloads.
+//
+// Every entry is encrypted with its own random AES-256 key. Entry keys are
+// wrapped to each recipient: an ephemeral X25519 key pair is generated per
+// recipient, the shared secret is expanded with HKDF-SHA256 into a key
+// encryption key, and the entry key is sealed with AES-256-GCM using the
+// entry path as additional data.
+//
+// Entry data is split into chunks of constants.EnvelopeChunkSize plaintext
+// bytes, each sealed with AES-256-GCM. The 12-byte nonce is an 11-byte
+// big-endian chunk counter followed by 1 for the last chunk and 0 otherwise,
+// so truncated or reordered streams fail to decrypt.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"silobang/internal/constants"
+)
+
+const (
+	keySize   = 32
+	nonceSize = 12
+	tagSize   = 16
+)
+
+// Recipient is a party entries are wrapped to.
+type Recipient struct {
+	ID        string
+	PublicKey *ecdh.PublicKey
+}
+
+// RecipientHeader describes a recipient in the keys manifest.
+type RecipientHeader struct {
+	ID                 string `json:"id"`
+	PublicKey          string `json:"public_key"`           // base64 X25519 public key
+	EphemeralPublicKey string `json:"ephemeral_public_key"` // base64, used with the recipient's private key
+}
+
+// ParseRecipient decodes a base64 (standard or URL, padded or not) X25519
+// public key.
+func ParseRecipient(id, publicKey string) (Recipient, error) {
+	if id == "" {
+		return Recipient{}, errors.New("recipient id is required")
+	}
+	if len(id) > constants.EnvelopeRecipientIDMaxLength {
+		return Recipient{}, fmt.Errorf("recipient id exceeds %d characters", constants.EnvelopeRecipientIDMaxLength)
+	}
+	raw, err := DecodeKey(publicKey)
+	if err != nil {
+		return Recipient{}, fmt.Errorf("recipient %s: invalid public key encoding", id)
+	}
+	pub, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return Recipient{}, fmt.Errorf("recipient %s: public key must be 32 bytes of X25519", id)
+	}
+	return Recipient{ID: id, PublicKey: pub}, nil
+}
+
+// DecodeKey decodes base64 key material in any of the common encodings.
+func DecodeKey(s string) ([]byte, error) {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	return nil, errors.New("invalid base64")
+}
+
+// sealerRecipient holds a recipient's key encryption key for one archive.
+type sealerRecipient struct {
+	header RecipientHeader
+	kek    cipher.AEAD
+}
+
+// Sealer encrypts entries of one archive. Ephemeral keys are generated once
+// per Sealer, so every archive uses fresh key material.
+type Sealer struct {
+	recipients []sealerRecipient
+}
+
+// NewSealer derives a key encryption key for each recipient.
+func NewSealer(recipients []Recipient) (*Sealer, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("at least one recipient is required")
+	}
+
+	s := &Sealer{recipients: make([]sealerRecipient, 0, len(recipients))}
+	for _, r := range recipients {
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ephemeral key: %w", err)
+		}
+		shared, err := ephemeral.ECDH(r.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("recipient %s: key agreement failed: %w", r.ID, err)
+		}
+		kek, err := deriveKEK(shared, ephemeral.PublicKey().Bytes(), r.PublicKey.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		s.recipients = append(s.recipients, sealerRecipient{
+			header: RecipientHeader{
+				ID:                 r.ID,
+				PublicKey:          base64.StdEncoding.EncodeToString(r.PublicKey.Bytes()),
+				EphemeralPublicKey: base64.StdEncoding.EncodeToString(ephemeral.PublicKey().Bytes()),
+			},
+			kek: kek,
+		})
+	}
+	return s, nil
+}
+
+// Recipients returns the keys manifest headers of all recipients.
+func (s *Sealer) Recipients() []RecipientHeader {
+	headers := make([]RecipientHeader, len(s.recipients))
+	for i, r := range s.recipients {
+		headers[i] = r.header
+	}
+	return headers
+}
+
+// NewEntry generates a key for the entry at path and returns a writer that
+// encrypts to dst, along with the key wrapped to every recipient (keyed by
+// recipient ID). The writer must be closed to emit the final chunk.
+func (s *Sealer) NewEntry(dst io.Writer, path string) (io.WriteCloser, map[string]string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate entry key: %w", err)
+	}
+
+	wrapped := make(map[string]string, len(s.recipients))
+	for _, r := range s.recipients {
+		nonce := make([]byte, nonceSize)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		sealed := r.kek.Seal(nonce, nonce, key, []byte(path))
+		wrapped[r.header.ID] = base64.StdEncoding.EncodeToString(sealed)
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &streamWriter{dst: dst, aead: aead, buf: make([]byte, 0, constants.EnvelopeChunkSize)}, wrapped, nil
+}
+
+// UnwrapKey recovers an entry key with the recipient's private key, the
+// recipient's ephemeral public key from the keys manifest and the entry path.
+func UnwrapKey(priv *ecdh.PrivateKey, ephemeralPublicKey, path, wrapped string) ([]byte, error) {
+	rawEphemeral, err := DecodeKey(ephemeralPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral public key: %w", err)
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(rawEphemeral)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral public key: %w", err)
+	}
+	shared, err := priv.ECDH(ephemeral)
+	if err != nil {
+		return nil, fmt.Errorf("key agreement failed: %w", err)
+	}
+	kek, err := deriveKEK(shared, rawEphemeral, priv.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := DecodeKey(wrapped)
+	if err != nil || len(sealed) < nonceSize+tagSize {
+		return nil, errors.New("invalid wrapped key")
+	}
+	key, err := kek.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(path))
+	if err != nil {
+		return nil, errors.New("wrapped key does not match recipient or path")
+	}
+	return key, nil
+}
+
+// Open decrypts a complete entry stream with its key.
+func Open(key, ciphertext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	const sealedChunk = constants.EnvelopeChunkSize + tagSize
+	var plaintext []byte
+	var counter uint64
+	for {
+		n := min(len(ciphertext), sealedChunk)
+		final := n == len(ciphertext)
+		chunk, err := aead.Open(nil, chunkNonce(counter, final), ciphertext[:n], nil)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d: authentication failed", counter)
+		}
+		plaintext = append(plaintext, chunk...)
+		if final {
+			return plaintext, nil
+		}
+		ciphertext = ciphertext[n:]
+		counter++
+	}
+}
+
+// SealedSize returns the size of the sealed stream of a plaintext entry of
+// n bytes: every chunk, including a final empty one, carries a GCM tag.
+func SealedSize(n int64) int64 {
+	chunks := max((n+constants.EnvelopeChunkSize-1)/constants.EnvelopeChunkSize, 1)
+	return n + chunks*tagSize
+}
+
+// deriveKEK expands an X25519 shared secret into a key encryption key. The
+// salt binds the ephemeral and recipient public keys.
+func deriveKEK(shared, ephemeralPub, recipientPub []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephemeralPub...), recipientPub...)
+	kek, err := hkdf.Key(sha256.New, shared, salt, constants.EnvelopeKDFInfo, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("key derivation failed: %w", err)
+	}
+	return newGCM(kek)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce builds the nonce of a stream chunk.
+func chunkNonce(counter uint64, final bool) []byte {
+	nonce := make([]byte, nonceSize)
+	for i := 0; i < 8; i++ {
+		nonce[10-i] = byte(counter >> (8 * i))
+	}
+	if final {
+		nonce[nonceSize-1] = 1
+	}
+	return nonce
+}
+
+// streamWriter seals full chunks as data arrives. A full chunk is only
+// sealed once more data follows, so the last chunk is always marked final.
+type streamWriter struct {
+	dst     io.Writer
+	aead    cipher.AEAD
+	buf     []byte
+	counter uint64
+	closed  bool
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed envelope stream")
+	}
+	written := 0
+	for len(p) > 0 {
+		if len(w.buf) == constants.EnvelopeChunkSize {
+			if err := w.flush(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close seals the final chunk, which may be empty.
+func (w *streamWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.flush(true)
+}
+
+func (w *streamWriter) flush(final bool) error {
+	sealed := w.aead.Seal(nil, chunkNonce(w.counter, final), w.buf, nil)
+	if _, err := w.dst.Write(sealed); err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	w.counter++
+	return nil
+}
@@ -0,0 +1,137 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// newRecipient generates a key pair and the matching Recipient.
+func newRecipient(t *testing.T, id string) (*ecdh.PrivateKey, Recipient) {
+	t.Helper()
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	r, err := ParseRecipient(id, base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes()))
+	if err != nil {
+		t.Fatalf("ParseRecipient: %v", err)
+	}
+	return priv, r
+}
+
+// sealEntry encrypts data as one entry at path.
+func sealEntry(t *testing.T, s *Sealer, path string, data []byte) ([]byte, map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w, wrapped, err := s.NewEntry(&buf, path)
+	if err != nil {
+		t.Fatalf("NewEntry: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes(), wrapped
+}
+
+func TestSealAndOpen_RoundTrip(t *testing.T) {
+	alicePriv, alice := newRecipient(t, "alice")
+	bobPriv, bob := newRecipient(t, "bob")
+	sealer, err := NewSealer([]Recipient{alice, bob})
+	if err != nil {
+		t.Fatalf("NewSealer: %v", err)
+	}
+	headers := sealer.Recipients()
+
+	sizes := []int{0, 1, constants.EnvelopeChunkSize - 1, constants.EnvelopeChunkSize, 2*constants.EnvelopeChunkSize + 7}
+	for _, size := range sizes {
+		data := make([]byte, size)
+		rand.Read(data)
+		ciphertext, wrapped := sealEntry(t, sealer, "assets/file.bin.enc", data)
+		if got := SealedSize(int64(size)); got != int64(len(ciphertext)) {
+			t.Errorf("size %d: SealedSize = %d, sealed %d bytes", size, got, len(ciphertext))
+		}
+
+		for i, priv := range []*ecdh.PrivateKey{alicePriv, bobPriv} {
+			key, err := UnwrapKey(priv, headers[i].EphemeralPublicKey, "assets/file.bin.enc", wrapped[headers[i].ID])
+			if err != nil {
+				t.Fatalf("size %d, %s: UnwrapKey: %v", size, headers[i].ID, err)
+			}
+			plaintext, err := Open(key, ciphertext)
+			if err != nil {
+				t.Fatalf("size %d: Open: %v", size, err)
+			}
+			if !bytes.Equal(plaintext, data) {
+				t.Errorf("size %d: plaintext mismatch", size)
+			}
+		}
+	}
+}
+
+func TestUnwrapKey_Rejections(t *testing.T) {
+	alicePriv, alice := newRecipient(t, "alice")
+	eve, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	sealer, _ := NewSealer([]Recipient{alice})
+	header := sealer.Recipients()[0]
+	_, wrapped := sealEntry(t, sealer, "assets/a.enc", []byte("secret"))
+
+	if _, err := UnwrapKey(eve, header.EphemeralPublicKey, "assets/a.enc", wrapped["alice"]); err == nil {
+		t.Error("expected unwrap with another private key to fail")
+	}
+	if _, err := UnwrapKey(alicePriv, header.EphemeralPublicKey, "assets/b.enc", wrapped["alice"]); err == nil {
+		t.Error("expected unwrap bound to another path to fail")
+	}
+}
+
+func TestOpen_DetectsTampering(t *testing.T) {
+	alicePriv, alice := newRecipient(t, "alice")
+	sealer, _ := NewSealer([]Recipient{alice})
+	header := sealer.Recipients()[0]
+
+	data := make([]byte, 2*constants.EnvelopeChunkSize+10)
+	ciphertext, wrapped := sealEntry(t, sealer, "p", data)
+	key, err := UnwrapKey(alicePriv, header.EphemeralPublicKey, "p", wrapped["alice"])
+	if err != nil {
+		t.Fatalf("UnwrapKey: %v", err)
+	}
+
+	// Dropping the last chunk must not yield a shorter valid stream
+	truncated := ciphertext[:2*(constants.EnvelopeChunkSize+tagSize)]
+	if _, err := Open(key, truncated); err == nil {
+		t.Error("expected truncated stream to fail")
+	}
+
+	flipped := bytes.Clone(ciphertext)
+	flipped[5] ^= 1
+	if _, err := Open(key, flipped); err == nil {
+		t.Error("expected modified stream to fail")
+	}
+}
+
+func TestParseRecipient_Invalid(t *testing.T) {
+	tests := []struct {
+		name, id, key string
+	}{
+		{"empty id", "", base64.StdEncoding.EncodeToString(make([]byte, 32))},
+		{"bad base64", "a", "!!!"},
+		{"wrong length", "a", base64.StdEncoding.EncodeToString(make([]byte, 16))},
+	}
+	for _, tt := range tests {
+		if _, err := ParseRecipient(tt.id, tt.key); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+
+	// URL-safe unpadded keys are accepted
+	priv, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	if _, err := ParseRecipient("a", base64.RawURLEncoding.EncodeToString(priv.PublicKey().Bytes())); err != nil {
+		t.Errorf("raw URL encoding rejected: %v", err)
+	}
+}
@@ -0,0 +1,283 @@
+//go:build faultinject
+
+// Package faults injects deterministic server faults for resilience testing:
+// added latency, error responses, truncated bodies and slowed-down streams on
+// selected routes. It is only compiled into builds tagged faultinject, so
+// release binaries carry neither the rules nor the admin API that sets them.
+package faults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// Kind names the fault a rule injects.
+type Kind string
+
+const (
+	KindLatency    Kind = "latency"     // Delay the request before it is handled
+	KindError      Kind = "error"       // Answer with an error status instead of handling the request
+	KindTruncate   Kind = "truncate"    // Cut the response body and abort the connection
+	KindSlowStream Kind = "slow_stream" // Delay every write of the response body
+)
+
+// Rule describes one fault and the requests it applies to. A rule matches
+// requests whose path starts with Route (and whose method equals Method, if
+// set); it lets the first Skip matches through and then fires on the next
+// Times matches, or on every later match when Times is 0.
+type Rule struct {
+	ID                 string `json:"id"`
+	Kind               Kind   `json:"kind"`
+	Route              string `json:"route"`
+	Method             string `json:"method,omitempty"`
+	DelayMs            int    `json:"delay_ms,omitempty"`             // latency, slow_stream
+	Status             int    `json:"status,omitempty"`               // error
+	TruncateAfterBytes int64  `json:"truncate_after_bytes,omitempty"` // truncate
+	Skip               int    `json:"skip,omitempty"`
+	Times              int    `json:"times,omitempty"`
+
+	Matched int `json:"matched"` // Requests that matched the route so far
+	Fired   int `json:"fired"`   // Requests the fault was applied to so far
+}
+
+// Validate checks the rule and fills in defaults.
+func (r *Rule) Validate() error {
+	if !strings.HasPrefix(r.Route, "/") {
+		return fmt.Errorf("route must be a path starting with /")
+	}
+	r.Method = strings.ToUpper(r.Method)
+	if r.Skip < 0 || r.Times < 0 {
+		return fmt.Errorf("skip and times must not be negative")
+	}
+	if r.DelayMs < 0 || r.DelayMs > constants.FaultMaxDelayMs {
+		return fmt.Errorf("delay_ms must be between 0 and %d", constants.FaultMaxDelayMs)
+	}
+
+	switch r.Kind {
+	case KindLatency, KindSlowStream:
+		if r.DelayMs == 0 {
+			return fmt.Errorf("%s faults require delay_ms", r.Kind)
+		}
+	case KindError:
+		if r.Status == 0 {
+			r.Status = constants.FaultDefaultStatus
+		}
+		if r.Status < 500 || r.Status > 599 {
+			return fmt.Errorf("error faults require a 5xx status")
+		}
+	case KindTruncate:
+		if r.TruncateAfterBytes < 0 {
+			return fmt.Errorf("truncate_after_bytes must not be negative")
+		}
+	default:
+		return fmt.Errorf("unknown fault kind %q", r.Kind)
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to the request.
+func (r *Rule) matches(method, path string) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+	return strings.HasPrefix(path, r.Route)
+}
+
+// Plan is the combined effect of every rule that fired for one request.
+type Plan struct {
+	Delay         time.Duration // Sleep before handling the request
+	Status        int           // Non-zero: answer with this status and skip the handler
+	TruncateAfter int64         // Body bytes let through before aborting; -1 = no truncation
+	WriteDelay    time.Duration // Sleep before every body write
+}
+
+// WrapsResponse reports whether the response writer needs to be wrapped.
+func (p Plan) WrapsResponse() bool {
+	return p.TruncateAfter >= 0 || p.WriteDelay > 0
+}
+
+// Injector holds the active fault rules. It is safe for concurrent use.
+type Injector struct {
+	mu     sync.Mutex
+	rules  []*Rule
+	nextID int
+}
+
+// NewInjector creates an injector without rules.
+func NewInjector() *Injector {
+	return &Injector{}
+}
+
+// Add validates the rule, assigns it an ID and activates it.
+func (in *Injector) Add(rule Rule) (Rule, error) {
+	if err := rule.Validate(); err != nil {
+		return Rule{}, err
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.nextID++
+	rule.ID = fmt.Sprintf("%s%d", constants.FaultIDPrefix, in.nextID)
+	rule.Matched = 0
+	rule.Fired = 0
+	in.rules = append(in.rules, &rule)
+	return rule, nil
+}
+
+// List returns a snapshot of the rules in the order they were added.
+func (in *Injector) List() []Rule {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	rules := make([]Rule, 0, len(in.rules))
+	for _, r := range in.rules {
+		rules = append(rules, *r)
+	}
+	return rules
+}
+
+// Remove deletes the rule with the given ID. Returns false if it does not exist.
+func (in *Injector) Remove(id string) bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	for i, r := range in.rules {
+		if r.ID == id {
+			in.rules = append(in.rules[:i], in.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Reset deletes every rule and returns how many were removed.
+func (in *Injector) Reset() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	n := len(in.rules)
+	in.rules = nil
+	return n
+}
+
+// Match counts the request against every matching rule and returns the
+// combined plan of the rules that fire. Latencies add up, the first error
+// wins, the smallest truncation and the largest write delay apply.
+func (in *Injector) Match(method, path string) Plan {
+	plan := Plan{TruncateAfter: -1}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	for _, r := range in.rules {
+		if !r.matches(method, path) {
+			continue
+		}
+		r.Matched++
+		if r.Matched <= r.Skip || (r.Times > 0 && r.Fired >= r.Times) {
+			continue
+		}
+		r.Fired++
+
+		switch r.Kind {
+		case KindLatency:
+			plan.Delay += time.Duration(r.DelayMs) * time.Millisecond
+		case KindError:
+			if plan.Status == 0 {
+				plan.Status = r.Status
+			}
+		case KindTruncate:
+			if plan.TruncateAfter < 0 || r.TruncateAfterBytes < plan.TruncateAfter {
+				plan.TruncateAfter = r.TruncateAfterBytes
+			}
+		case KindSlowStream:
+			if d := time.Duration(r.DelayMs) * time.Millisecond; d > plan.WriteDelay {
+				plan.WriteDelay = d
+			}
+		}
+	}
+	return plan
+}
+
+// Sleep waits for d or until ctx is done. Returns false if ctx ended first.
+func Sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ErrTruncated is returned by Writer once the truncation limit is reached.
+var ErrTruncated = errors.New("response truncated by injected fault")
+
+// Writer applies the truncation and write delay of a plan to a response.
+// Once the body reaches the truncation limit every further write fails with
+// ErrTruncated; the caller aborts the connection after the handler returns
+// so the client sees an incomplete body rather than a short, complete one.
+type Writer struct {
+	http.ResponseWriter
+	ctx       context.Context
+	plan      Plan
+	written   int64
+	truncated bool
+}
+
+// NewWriter wraps w with the plan's response faults.
+func NewWriter(ctx context.Context, w http.ResponseWriter, plan Plan) *Writer {
+	return &Writer{ResponseWriter: w, ctx: ctx, plan: plan}
+}
+
+// Write delays and truncates the body as planned.
+func (fw *Writer) Write(b []byte) (int, error) {
+	if fw.truncated {
+		return 0, ErrTruncated
+	}
+	if fw.plan.WriteDelay > 0 && !Sleep(fw.ctx, fw.plan.WriteDelay) {
+		return 0, fw.ctx.Err()
+	}
+
+	if fw.plan.TruncateAfter >= 0 && fw.written+int64(len(b)) > fw.plan.TruncateAfter {
+		keep := fw.plan.TruncateAfter - fw.written
+		n, err := fw.ResponseWriter.Write(b[:keep])
+		fw.written += int64(n)
+		fw.truncated = true
+		fw.Flush()
+		if err != nil {
+			return n, err
+		}
+		return n, ErrTruncated
+	}
+
+	n, err := fw.ResponseWriter.Write(b)
+	fw.written += int64(n)
+	return n, err
+}
+
+// Flush forwards to the underlying writer so streaming responses keep working.
+func (fw *Writer) Flush() {
+	if f, ok := fw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (fw *Writer) Unwrap() http.ResponseWriter {
+	return fw.ResponseWriter
+}
+
+// Truncated reports whether the body was cut.
+func (fw *Writer) Truncated() bool {
+	return fw.truncated
+}
@@ -0,0 +1,146 @@
+//go:build faultinject
+
+package faults
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+func TestRuleValidate(t *testing.T) {
+	valid := []Rule{
+		{Kind: KindLatency, Route: "/api/", DelayMs: 10},
+		{Kind: KindError, Route: "/api/assets/"},
+		{Kind: KindTruncate, Route: "/api/assets/", TruncateAfterBytes: 0},
+		{Kind: KindSlowStream, Route: "/api/download/bulk/start", DelayMs: 5, Method: "get"},
+	}
+	for _, r := range valid {
+		if err := r.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", r, err)
+		}
+	}
+
+	invalid := []Rule{
+		{Kind: "explode", Route: "/api/"},
+		{Kind: KindLatency, Route: "/api/"},
+		{Kind: KindLatency, Route: "api", DelayMs: 10},
+		{Kind: KindError, Route: "/api/", Status: 404},
+		{Kind: KindTruncate, Route: "/api/", TruncateAfterBytes: -1},
+		{Kind: KindLatency, Route: "/api/", DelayMs: 10, Times: -1},
+	}
+	for _, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", r)
+		}
+	}
+
+	r := Rule{Kind: KindError, Route: "/api/", Method: "post"}
+	r.Validate()
+	if r.Status != constants.FaultDefaultStatus || r.Method != "POST" {
+		t.Errorf("defaults not applied: %+v", r)
+	}
+}
+
+func TestInjectorMatch_SkipAndTimes(t *testing.T) {
+	in := NewInjector()
+	rule, err := in.Add(Rule{Kind: KindError, Route: "/api/assets/", Method: "GET", Status: 502, Skip: 1, Times: 2})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	var statuses []int
+	for i := 0; i < 5; i++ {
+		statuses = append(statuses, in.Match("GET", "/api/assets/abc/download").Status)
+	}
+	want := []int{0, 502, 502, 0, 0}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("request statuses = %v, want %v", statuses, want)
+		}
+	}
+
+	// Other methods and routes do not count
+	if plan := in.Match("POST", "/api/assets/abc"); plan.Status != 0 {
+		t.Error("rule should not match another method")
+	}
+	in.Match("GET", "/api/topics")
+
+	rules := in.List()
+	if len(rules) != 1 || rules[0].Matched != 5 || rules[0].Fired != 2 {
+		t.Errorf("unexpected counters: %+v", rules)
+	}
+
+	if !in.Remove(rule.ID) || in.Remove(rule.ID) {
+		t.Error("rule should be removed exactly once")
+	}
+}
+
+func TestInjectorMatch_CombinesRules(t *testing.T) {
+	in := NewInjector()
+	in.Add(Rule{Kind: KindLatency, Route: "/api/", DelayMs: 10})
+	in.Add(Rule{Kind: KindLatency, Route: "/api/assets/", DelayMs: 20})
+	in.Add(Rule{Kind: KindTruncate, Route: "/api/", TruncateAfterBytes: 100})
+	in.Add(Rule{Kind: KindTruncate, Route: "/api/assets/", TruncateAfterBytes: 10})
+	in.Add(Rule{Kind: KindSlowStream, Route: "/api/", DelayMs: 5})
+
+	plan := in.Match("GET", "/api/assets/x")
+	if plan.Delay != 30*time.Millisecond || plan.TruncateAfter != 10 || plan.WriteDelay != 5*time.Millisecond {
+		t.Errorf("unexpected plan: %+v", plan)
+	}
+
+	plan = in.Match("GET", "/api/topics")
+	if plan.Delay != 10*time.Millisecond || plan.TruncateAfter != 100 {
+		t.Errorf("unexpected plan: %+v", plan)
+	}
+
+	if n := in.Reset(); n != 5 {
+		t.Errorf("Reset removed %d rules, want 5", n)
+	}
+	if plan := in.Match("GET", "/api/assets/x"); plan.WrapsResponse() || plan.Delay != 0 {
+		t.Errorf("expected empty plan after reset, got %+v", plan)
+	}
+}
+
+func TestWriter_Truncates(t *testing.T) {
+	rec := httptest.NewRecorder()
+	fw := NewWriter(context.Background(), rec, Plan{TruncateAfter: 5})
+
+	if n, err := fw.Write([]byte("abc")); n != 3 || err != nil {
+		t.Fatalf("first write = %d, %v", n, err)
+	}
+	if n, err := fw.Write([]byte("defgh")); n != 2 || !errors.Is(err, ErrTruncated) {
+		t.Fatalf("second write = %d, %v", n, err)
+	}
+	if _, err := fw.Write([]byte("ijk")); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("writes after truncation should fail, got %v", err)
+	}
+	if !fw.Truncated() || rec.Body.String() != "abcde" {
+		t.Errorf("body = %q, truncated = %v", rec.Body.String(), fw.Truncated())
+	}
+}
+
+func TestWriter_SlowStreamStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	fw := NewWriter(ctx, rec, Plan{TruncateAfter: -1, WriteDelay: 20 * time.Millisecond})
+
+	start := time.Now()
+	fw.Write([]byte("a"))
+	fw.Write([]byte("b"))
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("two slowed writes took %v, want >= 40ms", elapsed)
+	}
+
+	cancel()
+	if _, err := fw.Write([]byte("c")); err == nil {
+		t.Error("write after cancellation should fail")
+	}
+	if rec.Body.String() != "ab" {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,260 @@
+// Package media inspects uploads: it sniffs their content type and reads
+// image dimensions, GLB geometry counts and audio/video durations from the
+// headers of the formats it recognizes. Only headers and index structures
+// are read, never whole files.
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"image"
+	_ "image/gif" // image formats read by image.DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+
+	"silobang/internal/constants"
+)
+
+// Info is what inspecting a file found. Fields of properties the format does
+// not have, or that could not be read, are left zero.
+type Info struct {
+	ContentType string // sniffed MIME type, without parameters
+
+	// Dimensions of PNG, JPEG and GIF images, in pixels
+	Width  int
+	Height int
+
+	// Counts of GLB models: vertex positions over every mesh primitive and
+	// materials. Model is set when they were read.
+	Model     bool
+	Vertices  int
+	Materials int
+
+	Duration float64 // seconds of WAV, FLAC, MP4, MOV and M4A audio or video
+}
+
+// InspectFile inspects the file at path. Only failing to read it is an
+// error: content that is malformed is reported with what could be read.
+func InspectFile(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Inspect(f, stat.Size())
+}
+
+// Inspect inspects size bytes read from r.
+func Inspect(r io.ReaderAt, size int64) (*Info, error) {
+	head := make([]byte, min(size, constants.MediaSniffBytes))
+	if _, err := r.ReadAt(head, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	info := &Info{ContentType: sniff(head)}
+	switch {
+	case bytes.HasPrefix(head, []byte("glTF")):
+		inspectGLB(r, size, info)
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		inspectFLAC(head, info)
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		inspectWAV(r, size, info)
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		inspectMP4(r, size, info)
+	default:
+		if cfg, _, err := image.DecodeConfig(io.NewSectionReader(r, 0, size)); err == nil {
+			info.Width, info.Height = cfg.Width, cfg.Height
+		}
+	}
+	return info, nil
+}
+
+// sniff returns the content type of a file starting with head, for the
+// formats net/http recognizes and those inspected here.
+func sniff(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("glTF")):
+		return "model/gltf-binary"
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return "audio/flac"
+	case len(head) >= 12 && string(head[4:8]) == "ftyp" && string(head[8:12]) == "qt  ":
+		return "video/quicktime"
+	case len(head) >= 12 && string(head[4:8]) == "ftyp" && string(head[8:12]) == "M4A ":
+		return "audio/mp4"
+	}
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return constants.DefaultMimeType
+	}
+	return contentType
+}
+
+// inspectGLB counts the vertices and materials of a binary glTF model from
+// its JSON chunk.
+func inspectGLB(r io.ReaderAt, size int64, info *Info) {
+	var header [20]byte
+	if size < int64(len(header)) {
+		return
+	}
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return
+	}
+	length := int64(binary.LittleEndian.Uint32(header[12:16]))
+	if binary.LittleEndian.Uint32(header[16:20]) != 0x4E4F534A || // "JSON"
+		length > constants.MediaMaxGLBJSONBytes || 20+length > size {
+		return
+	}
+	chunk := make([]byte, length)
+	if _, err := r.ReadAt(chunk, 20); err != nil {
+		return
+	}
+
+	var doc struct {
+		Meshes []struct {
+			Primitives []struct {
+				Attributes map[string]int `json:"attributes"`
+			} `json:"primitives"`
+		} `json:"meshes"`
+		Accessors []struct {
+			Count int `json:"count"`
+		} `json:"accessors"`
+		Materials []json.RawMessage `json:"materials"`
+	}
+	if err := json.Unmarshal(chunk, &doc); err != nil {
+		return
+	}
+	info.Model = true
+	info.Materials = len(doc.Materials)
+	for _, mesh := range doc.Meshes {
+		for _, prim := range mesh.Primitives {
+			if i, ok := prim.Attributes["POSITION"]; ok && i >= 0 && i < len(doc.Accessors) {
+				info.Vertices += doc.Accessors[i].Count
+			}
+		}
+	}
+}
+
+// inspectFLAC reads the duration of a FLAC stream from its STREAMINFO
+// block, which always comes first.
+func inspectFLAC(head []byte, info *Info) {
+	// "fLaC", the block header, then sample rate (20 bits), channels (3),
+	// bits per sample (5) and total samples (36) from byte 18
+	if len(head) < 26 || head[4]&0x7f != 0 {
+		return
+	}
+	packed := binary.BigEndian.Uint64(head[18:26])
+	sampleRate := packed >> 44
+	samples := packed & (1<<36 - 1)
+	if sampleRate > 0 {
+		info.Duration = float64(samples) / float64(sampleRate)
+	}
+}
+
+// inspectWAV reads the duration of a WAV file from the byte rate of its
+// fmt chunk and the size of its data chunk.
+func inspectWAV(r io.ReaderAt, size int64, info *Info) {
+	var byteRate uint32
+	var header [8]byte
+	for off := int64(12); off+8 <= size; {
+		if _, err := r.ReadAt(header[:], off); err != nil {
+			return
+		}
+		length := int64(binary.LittleEndian.Uint32(header[4:8]))
+		switch string(header[0:4]) {
+		case "fmt ":
+			var format [12]byte
+			if _, err := r.ReadAt(format[:], off+8); err != nil {
+				return
+			}
+			byteRate = binary.LittleEndian.Uint32(format[8:12])
+		case "data":
+			if byteRate > 0 {
+				// Streams written without knowing their length claim more
+				// data than there is
+				info.Duration = float64(min(length, size-off-8)) / float64(byteRate)
+			}
+			return
+		}
+		off += 8 + length + length%2 // chunks are padded to even sizes
+	}
+}
+
+// inspectMP4 reads the duration of an MP4, MOV or M4A file from the movie
+// header (moov/mvhd).
+func inspectMP4(r io.ReaderAt, size int64, info *Info) {
+	moov, moovSize, ok := findBox(r, 0, size, "moov")
+	if !ok {
+		return
+	}
+	mvhd, mvhdSize, ok := findBox(r, moov, moov+moovSize, "mvhd")
+	if !ok || mvhdSize < 32 {
+		return
+	}
+
+	// version and flags, then creation and modification times, timescale
+	// and duration: 32-bit fields in version 0, times and duration 64-bit
+	// in version 1
+	var body [32]byte
+	if _, err := r.ReadAt(body[:min(mvhdSize, int64(len(body)))], mvhd); err != nil {
+		return
+	}
+	// A duration of all ones means it is unknown
+	var timescale uint32
+	var duration uint64
+	if body[0] == 1 {
+		timescale = binary.BigEndian.Uint32(body[20:24])
+		if duration = binary.BigEndian.Uint64(body[24:32]); duration == 1<<64-1 {
+			return
+		}
+	} else {
+		timescale = binary.BigEndian.Uint32(body[12:16])
+		if duration = uint64(binary.BigEndian.Uint32(body[16:20])); duration == 1<<32-1 {
+			return
+		}
+	}
+	if timescale > 0 {
+		info.Duration = float64(duration) / float64(timescale)
+	}
+}
+
+// findBox returns the offset and size of the body of the first box of type
+// kind between start and end, walking at most constants.MediaMaxBoxes.
+func findBox(r io.ReaderAt, start, end int64, kind string) (int64, int64, bool) {
+	var header [16]byte
+	off := start
+	for i := 0; i < constants.MediaMaxBoxes && off+8 <= end; i++ {
+		if _, err := r.ReadAt(header[:8], off); err != nil {
+			return 0, 0, false
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		headerSize := int64(8)
+		switch boxSize {
+		case 0: // extends to the end of its parent
+			boxSize = end - off
+		case 1: // 64-bit size follows the type
+			if _, err := r.ReadAt(header[8:16], off+8); err != nil {
+				return 0, 0, false
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if boxSize < headerSize || boxSize > end-off {
+			return 0, 0, false
+		}
+		if string(header[4:8]) == kind {
+			return off + headerSize, boxSize - headerSize, true
+		}
+		off += boxSize
+	}
+	return 0, 0, false
+}
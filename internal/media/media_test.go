@@ -0,0 +1,103 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func inspectBytes(t *testing.T, data []byte) *Info {
+	t.Helper()
+	info, err := Inspect(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	return info
+}
+
+func TestInspect_ImageDimensions(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 30))); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	info := inspectBytes(t, buf.Bytes())
+	if info.ContentType != "image/png" || info.Width != 40 || info.Height != 30 {
+		t.Errorf("expected 40x30 image/png, got %+v", info)
+	}
+}
+
+func TestInspect_TextHasNoParameters(t *testing.T) {
+	info := inspectBytes(t, []byte("v 0 0 0\nv 1 0 0\n"))
+	if info.ContentType != "text/plain" || info.Width != 0 || info.Model || info.Duration != 0 {
+		t.Errorf("expected plain text without properties, got %+v", info)
+	}
+	if empty := inspectBytes(t, nil); empty.ContentType != "text/plain" {
+		t.Errorf("unexpected content type of an empty file: %q", empty.ContentType)
+	}
+}
+
+func TestInspect_GLBCounts(t *testing.T) {
+	doc := []byte(`{"asset":{"version":"2.0"},"meshes":[{"primitives":[{"attributes":{"POSITION":0}},{"attributes":{"POSITION":1,"NORMAL":2}}]}],` +
+		`"accessors":[{"count":3},{"count":5},{"count":5}],"materials":[{},{}]}`)
+	var glb bytes.Buffer
+	binary.Write(&glb, binary.LittleEndian, []uint32{0x46546C67, 2, uint32(20 + len(doc)), uint32(len(doc)), 0x4E4F534A})
+	glb.Write(doc)
+
+	info := inspectBytes(t, glb.Bytes())
+	if info.ContentType != "model/gltf-binary" || !info.Model || info.Vertices != 8 || info.Materials != 2 {
+		t.Errorf("expected 8 vertices and 2 materials, got %+v", info)
+	}
+}
+
+func TestInspect_WAVDuration(t *testing.T) {
+	// 2 seconds of 8 kHz 16-bit mono
+	var wav bytes.Buffer
+	data := make([]byte, 32000)
+	wav.WriteString("RIFF")
+	binary.Write(&wav, binary.LittleEndian, uint32(36+len(data)))
+	wav.WriteString("WAVEfmt ")
+	binary.Write(&wav, binary.LittleEndian, []uint32{16})
+	binary.Write(&wav, binary.LittleEndian, []uint16{1, 1})
+	binary.Write(&wav, binary.LittleEndian, []uint32{8000, 16000})
+	binary.Write(&wav, binary.LittleEndian, []uint16{2, 16})
+	wav.WriteString("data")
+	binary.Write(&wav, binary.LittleEndian, uint32(len(data)))
+	wav.Write(data)
+
+	if info := inspectBytes(t, wav.Bytes()); info.ContentType != "audio/wave" || info.Duration != 2 {
+		t.Errorf("expected 2s audio/wave, got %+v", info)
+	}
+}
+
+func TestInspect_FLACDuration(t *testing.T) {
+	flac := []byte("fLaC")
+	flac = append(flac, 0x80, 0, 0, 34)      // last block, STREAMINFO, 34 bytes
+	flac = append(flac, make([]byte, 10)...) // block and frame sizes
+	var packed [8]byte
+	binary.BigEndian.PutUint64(packed[:], 44100<<44|1<<41|15<<36|88200) // 44.1 kHz, stereo, 16-bit
+	flac = append(flac, packed[:]...)
+	flac = append(flac, make([]byte, 16)...) // MD5
+
+	if info := inspectBytes(t, flac); info.ContentType != "audio/flac" || info.Duration != 2 {
+		t.Errorf("expected 2s audio/flac, got %+v", info)
+	}
+}
+
+func TestInspect_MP4Duration(t *testing.T) {
+	box := func(kind string, body []byte) []byte {
+		out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+		return append(append(out, kind...), body...)
+	}
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:16], 1000) // timescale
+	binary.BigEndian.PutUint32(mvhd[16:20], 4500) // duration
+	mp4 := box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41"))
+	mp4 = append(mp4, box("free", nil)...)
+	mp4 = append(mp4, box("moov", append(box("trak", nil), box("mvhd", mvhd)...))...)
+
+	if info := inspectBytes(t, mp4); info.ContentType != "video/mp4" || info.Duration != 4.5 {
+		t.Errorf("expected 4.5s video/mp4, got %+v", info)
+	}
+}
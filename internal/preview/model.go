@@ -0,0 +1,425 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"silobang/internal/constants"
+)
+
+// Wireframes are drawn from above and to the side, so that boxes show three
+// faces.
+const (
+	modelYaw   = 35 * math.Pi / 180
+	modelPitch = 25 * math.Pi / 180
+	modelInset = 0.05 // margin around the model, relative to the preview size
+)
+
+var (
+	modelBackground = color.RGBA{R: 0xf4, G: 0xf4, B: 0xf4, A: 0xff}
+	modelLine       = color.RGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+)
+
+// mesh is the geometry of a model: its vertex positions and the edges
+// between them. Models without edges are drawn as points.
+type mesh struct {
+	vertices [][3]float32
+	edges    [][2]uint32
+}
+
+// addVertex appends a vertex, failing once the model exceeds
+// constants.PreviewMaxModelVertices.
+func (m *mesh) addVertex(v [3]float32) error {
+	if len(m.vertices) >= constants.PreviewMaxModelVertices {
+		return ErrTooLarge
+	}
+	m.vertices = append(m.vertices, v)
+	return nil
+}
+
+// addEdge appends an edge between two vertices. Edges beyond
+// constants.PreviewMaxModelEdges are left out of the preview.
+func (m *mesh) addEdge(a, b uint32) {
+	if len(m.edges) < constants.PreviewMaxModelEdges {
+		m.edges = append(m.edges, [2]uint32{a, b})
+	}
+}
+
+// modelRenderer draws 3D models as PNG wireframes.
+type modelRenderer struct {
+	parse func(data []byte) (*mesh, error)
+}
+
+func (m modelRenderer) Render(r io.Reader, size int) ([]byte, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	geometry, err := m.parse(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(geometry.vertices) == 0 {
+		return nil, "", errors.New("model has no geometry")
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, drawWireframe(geometry, size)); err != nil {
+		return nil, "", fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// drawWireframe projects the mesh orthographically onto a size x size
+// image, scaled to fit.
+func drawWireframe(m *mesh, size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = modelBackground.R, modelBackground.G, modelBackground.B, modelBackground.A
+	}
+
+	sinYaw, cosYaw := math.Sincos(modelYaw)
+	sinPitch, cosPitch := math.Sincos(modelPitch)
+	points := make([][2]float64, len(m.vertices))
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i, v := range m.vertices {
+		x, y, z := float64(v[0]), float64(v[1]), float64(v[2])
+		x, z = x*cosYaw+z*sinYaw, z*cosYaw-x*sinYaw
+		y = y*cosPitch - z*sinPitch
+		points[i] = [2]float64{x, y}
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	// Scale the longest extent to the preview, centring the other; the
+	// image y axis points down
+	inset := float64(size) * modelInset
+	extent := math.Max(maxX-minX, maxY-minY)
+	scale := 0.0
+	if extent > 0 && !math.IsInf(extent, 0) && !math.IsNaN(extent) {
+		scale = (float64(size) - 1 - 2*inset) / extent
+	}
+	offsetX := (float64(size)-1)/2 - (minX+maxX)/2*scale
+	offsetY := (float64(size)-1)/2 + (minY+maxY)/2*scale
+	project := func(p [2]float64) (int, int) {
+		return int(math.Round(p[0]*scale + offsetX)), int(math.Round(offsetY - p[1]*scale))
+	}
+
+	if len(m.edges) == 0 {
+		for _, p := range points {
+			x, y := project(p)
+			img.SetRGBA(x, y, modelLine)
+		}
+		return img
+	}
+	for _, e := range m.edges {
+		x0, y0 := project(points[e[0]])
+		x1, y1 := project(points[e[1]])
+		drawLine(img, x0, y0, x1, y1)
+	}
+	return img
+}
+
+// drawLine draws a one-pixel line with Bresenham's algorithm. Points
+// outside the image are clipped by SetRGBA.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetRGBA(x0, y0, modelLine)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x0 += sx
+		} else {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// =============================================================================
+// OBJ
+// =============================================================================
+
+// parseOBJ reads the vertices, faces and lines of a Wavefront OBJ file.
+// Texture coordinates, normals and materials are ignored.
+func parseOBJ(data []byte) (*mesh, error) {
+	m := &mesh{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				return nil, errors.New("invalid OBJ vertex")
+			}
+			var v [3]float32
+			for i := range v {
+				f, err := strconv.ParseFloat(fields[i+1], 32)
+				if err != nil {
+					return nil, fmt.Errorf("invalid OBJ vertex: %w", err)
+				}
+				v[i] = float32(f)
+			}
+			if err := m.addVertex(v); err != nil {
+				return nil, err
+			}
+		case "f", "l":
+			var indices []uint32
+			for _, field := range fields[1:] {
+				ref, _, _ := strings.Cut(field, "/")
+				n, err := strconv.Atoi(ref)
+				if err != nil {
+					return nil, fmt.Errorf("invalid OBJ index: %w", err)
+				}
+				// Negative indices count back from the latest vertex
+				if n < 0 {
+					n += len(m.vertices) + 1
+				}
+				if n < 1 || n > len(m.vertices) {
+					return nil, fmt.Errorf("OBJ index %s out of range", ref)
+				}
+				indices = append(indices, uint32(n-1))
+			}
+			for i := 1; i < len(indices); i++ {
+				m.addEdge(indices[i-1], indices[i])
+			}
+			if fields[0] == "f" && len(indices) > 2 {
+				m.addEdge(indices[len(indices)-1], indices[0])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read OBJ: %w", err)
+	}
+	return m, nil
+}
+
+// =============================================================================
+// GLB
+// =============================================================================
+
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+
+	gltfFloat         = 5126
+	gltfUnsignedByte  = 5121
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+
+	gltfModeLines     = 1
+	gltfModeTriangles = 4
+)
+
+// gltfDocument is the part of a glTF document previews need.
+type gltfDocument struct {
+	Meshes []struct {
+		Primitives []struct {
+			Attributes map[string]int `json:"attributes"`
+			Indices    *int           `json:"indices"`
+			Mode       *int           `json:"mode"`
+		} `json:"primitives"`
+	} `json:"meshes"`
+	Accessors []struct {
+		BufferView    *int   `json:"bufferView"`
+		ByteOffset    int    `json:"byteOffset"`
+		ComponentType int    `json:"componentType"`
+		Count         int    `json:"count"`
+		Type          string `json:"type"`
+	} `json:"accessors"`
+	BufferViews []struct {
+		Buffer     int `json:"buffer"`
+		ByteOffset int `json:"byteOffset"`
+		ByteLength int `json:"byteLength"`
+		ByteStride int `json:"byteStride"`
+	} `json:"bufferViews"`
+}
+
+// parseGLB reads the triangles and lines of every mesh of a binary glTF
+// file. Node transforms are ignored: meshes are drawn in their own space.
+// Only data in the file's BIN chunk is read.
+func parseGLB(data []byte) (*mesh, error) {
+	if len(data) < 12 || binary.LittleEndian.Uint32(data[0:4]) != glbMagic {
+		return nil, errors.New("not a GLB file")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:8]); version != 2 {
+		return nil, fmt.Errorf("unsupported GLB version %d", version)
+	}
+
+	var jsonChunk, bin []byte
+	for off := 12; off+8 <= len(data); {
+		length := int(binary.LittleEndian.Uint32(data[off : off+4]))
+		kind := binary.LittleEndian.Uint32(data[off+4 : off+8])
+		off += 8
+		if length < 0 || length > len(data)-off {
+			return nil, errors.New("truncated GLB chunk")
+		}
+		switch kind {
+		case glbChunkJSON:
+			jsonChunk = data[off : off+length]
+		case glbChunkBIN:
+			bin = data[off : off+length]
+		}
+		off += length
+	}
+	if jsonChunk == nil {
+		return nil, errors.New("GLB has no JSON chunk")
+	}
+
+	var doc gltfDocument
+	if err := json.Unmarshal(jsonChunk, &doc); err != nil {
+		return nil, fmt.Errorf("invalid glTF JSON: %w", err)
+	}
+
+	// accessor returns the bytes of an accessor's elements, each stride
+	// apart, checking they lie within the BIN chunk
+	accessor := func(index, elemSize int) ([]byte, int, int, error) {
+		if index < 0 || index >= len(doc.Accessors) {
+			return nil, 0, 0, fmt.Errorf("glTF accessor %d out of range", index)
+		}
+		a := doc.Accessors[index]
+		if a.BufferView == nil || *a.BufferView < 0 || *a.BufferView >= len(doc.BufferViews) {
+			return nil, 0, 0, fmt.Errorf("glTF accessor %d has no buffer view", index)
+		}
+		view := doc.BufferViews[*a.BufferView]
+		if view.Buffer != 0 || bin == nil {
+			return nil, 0, 0, errors.New("glTF data outside the GLB file is not supported")
+		}
+		stride := view.ByteStride
+		if stride == 0 {
+			stride = elemSize
+		}
+		start := view.ByteOffset + a.ByteOffset
+		if a.Count < 0 || stride < elemSize || start < 0 || view.ByteOffset+view.ByteLength > len(bin) ||
+			(a.Count > 0 && start+(a.Count-1)*stride+elemSize > view.ByteOffset+view.ByteLength) {
+			return nil, 0, 0, fmt.Errorf("glTF accessor %d out of bounds", index)
+		}
+		return bin[start:], stride, a.Count, nil
+	}
+
+	m := &mesh{}
+	for _, gm := range doc.Meshes {
+		for _, prim := range gm.Primitives {
+			position, ok := prim.Attributes["POSITION"]
+			if !ok {
+				continue
+			}
+			if doc.Accessors[clampIndex(position, len(doc.Accessors))].ComponentType != gltfFloat {
+				return nil, errors.New("glTF positions must be floats")
+			}
+			raw, stride, count, err := accessor(position, 12)
+			if err != nil {
+				return nil, err
+			}
+			base := uint32(len(m.vertices))
+			for i := 0; i < count; i++ {
+				p := raw[i*stride:]
+				if err := m.addVertex([3]float32{
+					math.Float32frombits(binary.LittleEndian.Uint32(p[0:4])),
+					math.Float32frombits(binary.LittleEndian.Uint32(p[4:8])),
+					math.Float32frombits(binary.LittleEndian.Uint32(p[8:12])),
+				}); err != nil {
+					return nil, err
+				}
+			}
+
+			// Unindexed primitives use their vertices in order
+			indices := make([]uint32, 0, count)
+			if prim.Indices == nil {
+				for i := 0; i < count; i++ {
+					indices = append(indices, uint32(i))
+				}
+			} else {
+				componentType := doc.Accessors[clampIndex(*prim.Indices, len(doc.Accessors))].ComponentType
+				size := map[int]int{gltfUnsignedByte: 1, gltfUnsignedShort: 2, gltfUnsignedInt: 4}[componentType]
+				if size == 0 {
+					return nil, fmt.Errorf("unsupported glTF index type %d", componentType)
+				}
+				raw, stride, n, err := accessor(*prim.Indices, size)
+				if err != nil {
+					return nil, err
+				}
+				for i := 0; i < n; i++ {
+					p := raw[i*stride:]
+					var index uint32
+					switch size {
+					case 1:
+						index = uint32(p[0])
+					case 2:
+						index = uint32(binary.LittleEndian.Uint16(p))
+					default:
+						index = binary.LittleEndian.Uint32(p)
+					}
+					if index >= uint32(count) {
+						return nil, fmt.Errorf("glTF index %d out of range", index)
+					}
+					indices = append(indices, index)
+				}
+			}
+
+			mode := gltfModeTriangles
+			if prim.Mode != nil {
+				mode = *prim.Mode
+			}
+			switch mode {
+			case gltfModeTriangles:
+				for i := 0; i+2 < len(indices); i += 3 {
+					a, b, c := base+indices[i], base+indices[i+1], base+indices[i+2]
+					m.addEdge(a, b)
+					m.addEdge(b, c)
+					m.addEdge(c, a)
+				}
+			case gltfModeLines:
+				for i := 0; i+1 < len(indices); i += 2 {
+					m.addEdge(base+indices[i], base+indices[i+1])
+				}
+			}
+			// Other modes (points, strips, fans) are drawn as points only
+		}
+	}
+	return m, nil
+}
+
+// clampIndex returns index when it is within n items, else 0, for lookups
+// whose bounds are checked afterwards.
+func clampIndex(index, n int) int {
+	if index < 0 || index >= n {
+		return 0
+	}
+	return index
+}
@@ -0,0 +1,126 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+)
+
+const cubeOBJ = `# unit cube
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+v 0 0 1
+v 1 0 1
+v 1 1 1
+v 0 1 1
+f 1/1/1 2/2/1 3/3/1 4/4/1
+f 5 6 7 8
+f -8 -7 -3 -4
+l 3 7
+`
+
+func TestParseOBJ_FacesAndLines(t *testing.T) {
+	m, err := parseOBJ([]byte(cubeOBJ))
+	if err != nil {
+		t.Fatalf("parseOBJ failed: %v", err)
+	}
+	// Three quads of four edges each, plus one line
+	if len(m.vertices) != 8 || len(m.edges) != 13 {
+		t.Errorf("expected 8 vertices and 13 edges, got %d and %d", len(m.vertices), len(m.edges))
+	}
+	if m.edges[8] != [2]uint32{0, 1} {
+		t.Errorf("negative indices not resolved: %v", m.edges[8])
+	}
+	if _, err := parseOBJ([]byte("v 0 0 0\nf 1 2 3\n")); err == nil {
+		t.Error("expected an error for an out of range index")
+	}
+}
+
+// buildGLB returns a GLB file of one indexed triangle.
+func buildGLB(t *testing.T) []byte {
+	t.Helper()
+	var bin bytes.Buffer
+	for _, f := range []float32{0, 0, 0, 1, 0, 0, 0, 1, 0} {
+		binary.Write(&bin, binary.LittleEndian, math.Float32bits(f))
+	}
+	binary.Write(&bin, binary.LittleEndian, []uint16{0, 1, 2, 0})
+
+	doc, err := json.Marshal(map[string]interface{}{
+		"asset":  map[string]string{"version": "2.0"},
+		"meshes": []interface{}{map[string]interface{}{"primitives": []interface{}{map[string]interface{}{"attributes": map[string]int{"POSITION": 0}, "indices": 1}}}},
+		"accessors": []interface{}{
+			map[string]interface{}{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]interface{}{"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"},
+		},
+		"bufferViews": []interface{}{
+			map[string]int{"buffer": 0, "byteOffset": 0, "byteLength": 36},
+			map[string]int{"buffer": 0, "byteOffset": 36, "byteLength": 6},
+		},
+		"buffers": []interface{}{map[string]int{"byteLength": bin.Len()}},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode glTF: %v", err)
+	}
+	for len(doc)%4 != 0 {
+		doc = append(doc, ' ')
+	}
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, []uint32{glbMagic, 2, uint32(12 + 8 + len(doc) + 8 + bin.Len())})
+	binary.Write(&out, binary.LittleEndian, []uint32{uint32(len(doc)), glbChunkJSON})
+	out.Write(doc)
+	binary.Write(&out, binary.LittleEndian, []uint32{uint32(bin.Len()), glbChunkBIN})
+	out.Write(bin.Bytes())
+	return out.Bytes()
+}
+
+func TestParseGLB_Triangle(t *testing.T) {
+	m, err := parseGLB(buildGLB(t))
+	if err != nil {
+		t.Fatalf("parseGLB failed: %v", err)
+	}
+	if len(m.vertices) != 3 || len(m.edges) != 3 {
+		t.Fatalf("expected 3 vertices and 3 edges, got %d and %d", len(m.vertices), len(m.edges))
+	}
+	if m.vertices[1] != [3]float32{1, 0, 0} {
+		t.Errorf("unexpected vertex: %v", m.vertices[1])
+	}
+	if _, err := parseGLB([]byte("glTF but not really")); err == nil {
+		t.Error("expected an error for a malformed GLB")
+	}
+}
+
+func TestModelRenderer_DrawsWireframe(t *testing.T) {
+	out, contentType, err := For("obj").Render(strings.NewReader(cubeOBJ), 64)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil || contentType != "image/png" {
+		t.Fatalf("preview is not a PNG (%s): %v", contentType, err)
+	}
+	if b := img.Bounds(); b.Dx() != 64 || b.Dy() != 64 {
+		t.Errorf("expected 64x64 preview, got %dx%d", b.Dx(), b.Dy())
+	}
+	// Lines are drawn over the background
+	lines := 0
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r>>8 == uint32(modelLine.R) {
+				lines++
+			}
+		}
+	}
+	if lines == 0 {
+		t.Error("expected the wireframe to be drawn")
+	}
+	if _, _, err := For("obj").Render(strings.NewReader("# empty\n"), 64); err == nil {
+		t.Error("expected an error for a model without geometry")
+	}
+}
@@ -0,0 +1,143 @@
+// Package preview renders previews of assets: downscaled copies of PNG and
+// JPEG images and wireframe views of GLB and OBJ models. Renderers are
+// registered per extension; the stored asset is never modified.
+package preview
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sync"
+
+	"silobang/internal/constants"
+)
+
+// ErrTooLarge is returned when an image exceeds constants.PreviewMaxPixels
+// or a model constants.PreviewMaxModelVertices.
+var ErrTooLarge = errors.New("asset too large to preview")
+
+// Renderer renders the previews of one kind of asset.
+type Renderer interface {
+	// Render returns a preview of the asset read from r with its longest
+	// edge at most size pixels, and the preview's content type.
+	Render(r io.Reader, size int) ([]byte, string, error)
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]Renderer{
+		"png":  imageRenderer{contentType: "image/png"},
+		"jpg":  imageRenderer{contentType: "image/jpeg"},
+		"jpeg": imageRenderer{contentType: "image/jpeg"},
+		"glb":  modelRenderer{parse: parseGLB},
+		"obj":  modelRenderer{parse: parseOBJ},
+	}
+)
+
+// Register sets the renderer of an extension (lower case, without a dot),
+// replacing any built-in one.
+func Register(ext string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[ext] = r
+}
+
+// For returns the renderer of an extension, or nil when its assets have no
+// previews.
+func For(ext string) Renderer {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	return renderers[ext]
+}
+
+// imageRenderer downscales images, keeping their format.
+type imageRenderer struct {
+	contentType string
+}
+
+func (i imageRenderer) Render(r io.Reader, size int) ([]byte, string, error) {
+	data, err := Render(r, i.contentType, size)
+	return data, i.contentType, err
+}
+
+// Render decodes the image read from r and re-encodes it in its original
+// format with its longest edge at most size pixels. Smaller images keep
+// their dimensions.
+func Render(r io.Reader, contentType string, size int) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > constants.PreviewMaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	dst := fit(src, size)
+
+	var buf bytes.Buffer
+	switch contentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: constants.PreviewJPEGQuality})
+	default:
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fit scales src so that its longest edge is at most size pixels, averaging
+// the source pixels covered by each destination pixel.
+func fit(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if longest := max(width, height); longest > size {
+		width = max(1, width*size/longest)
+		height = max(1, height*size/longest)
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+	if width == b.Dx() && height == b.Dy() {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*b.Dy()/height, max((y+1)*b.Dy()/height, y*b.Dy()/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*b.Dx()/width, max((x+1)*b.Dx()/width, x*b.Dx()/width+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			off := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[off+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
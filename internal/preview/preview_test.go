@@ -0,0 +1,71 @@
+package preview
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// solidImage returns a w x h image filled with c.
+func solidImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestRender_DownscalesLongestEdge(t *testing.T) {
+	var src bytes.Buffer
+	if err := png.Encode(&src, solidImage(400, 100, color.RGBA{R: 200, A: 255})); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+
+	out, err := Render(&src, "image/png", 100)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("preview is not a PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 100 || b.Dy() != 25 {
+		t.Errorf("expected 100x25 preview, got %dx%d", b.Dx(), b.Dy())
+	}
+	if r, _, _, _ := img.At(50, 12).RGBA(); r>>8 != 200 {
+		t.Errorf("expected colour to be preserved, got red=%d", r>>8)
+	}
+}
+
+func TestRender_KeepsSmallImagesAndFormat(t *testing.T) {
+	var src bytes.Buffer
+	if err := jpeg.Encode(&src, solidImage(40, 30, color.White), nil); err != nil {
+		t.Fatalf("failed to encode jpeg: %v", err)
+	}
+
+	out, err := Render(&src, "image/jpeg", 256)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("failed to decode preview: %v", err)
+	}
+	if format != "jpeg" || cfg.Width != 40 || cfg.Height != 30 {
+		t.Errorf("expected unscaled 40x30 jpeg, got %dx%d %s", cfg.Width, cfg.Height, format)
+	}
+}
+
+func TestRender_RejectsNonImages(t *testing.T) {
+	if _, err := Render(bytes.NewReader([]byte("not an image")), "image/png", 64); err == nil {
+		t.Error("expected an error for undecodable data")
+	}
+	if For("txt") != nil {
+		t.Error("txt should not have previews")
+	}
+}
@@ -164,7 +164,7 @@ template: |
   GET {{base_url}}/api/queries
 
   This returns all available presets with their parameters, including:
-  - Basic queries (recent-imports, by-hash, large-files, by-extension, by-origin-name)
+  - Basic queries (recent-imports, by-hash, large-files, by-extension, by-content-type, by-origin-name, by-relative-path)
   - Metadata queries (without-metadata, with-metadata, by-processor)
   - Lineage queries (lineage, derived, orphans, roots-with-children)
   - Analytics queries (extension-summary, size-distribution, time-series)
@@ -346,6 +346,8 @@ template: |
   {
     "success": true,
     "hash": "a1b2c3d4e5f6...64-hex-chars",
+    "status": "created",
+    "reason": "new_content",
     "skipped": false,
     "size": 1234567
   }
@@ -356,11 +358,22 @@ template: |
   {
     "success": true,
     "hash": "a1b2c3d4e5f6...64-hex-chars",
+    "status": "aliased",
+    "reason": "duplicate_in_other_topic",
     "skipped": true,
     "existing_topic": "other-topic"
   }
   ` + "```" + `
 
+  ## Upload Status
+  Branch on **status**, not on skipped (deprecated, kept for one release):
+  | status | reason | Meaning |
+  |--------|--------|---------|
+  | created | new_content | Asset stored in the topic |
+  | deduplicated | duplicate_in_topic | Same content already in this topic |
+  | aliased | duplicate_in_other_topic | Same content already stored in existing_topic |
+  | rejected | error code | Upload refused (non-2xx response); nothing stored |
+
   ## Constraints
   - Topic name: lowercase alphanumeric, hyphens, underscores only (regex: ^[a-z0-9_-]+$)
   - Topic name length: 1-64 characters
@@ -371,8 +384,8 @@ template: |
   ## Duplicate Handling
   SiloBang uses content-addressed storage:
   - Files are hashed with BLAKE3 before storage
-  - If hash already exists anywhere, upload is skipped
-  - Response includes existing_topic if found elsewhere
+  - If hash already exists anywhere, nothing is written
+  - status is "deduplicated" (same topic) or "aliased" (other topic, see existing_topic)
   - Same content = same hash, regardless of filename
 
   ## Lineage (Parent-Child Relationships)
@@ -414,8 +427,11 @@ template: |
           )
 
       result = response.json()
-      if result.get('skipped'):
-          print(f"Skipped (exists in {result.get('existing_topic', 'same topic')}): {filepath}")
+      status = result.get('status')
+      if result.get('error'):
+          print(f"Rejected ({result['code']}): {filepath}")
+      elif status in ('deduplicated', 'aliased'):
+          print(f"Skipped ({status}, in {result['existing_topic']}): {filepath}")
       else:
           print(f"Uploaded: {filepath} -> {result['hash']}")
 
@@ -433,12 +449,12 @@ template: |
 
           try:
               result = upload_file(str(path))
-              if result.get('success'):
+              if not result.get('error'):
                   results["hashes"].append(result['hash'])
-                  if result.get('skipped'):
-                      results["skipped"] += 1
-                  else:
+                  if result['status'] == 'created':
                       results["uploaded"] += 1
+                  else:
+                      results["skipped"] += 1
               else:
                   results["failed"] += 1
           except Exception as e:
@@ -716,7 +732,7 @@ template: |
   GET {{base_url}}/api/queries
 
   This returns all presets you can use for filtering, including queries for:
-  - File properties (by-extension, large-files, recent-imports, by-origin-name)
+  - File properties (by-extension, by-content-type, large-files, recent-imports, by-origin-name, by-relative-path)
   - Metadata state (without-metadata, with-metadata, by-processor)
   - Lineage (lineage, derived, orphans, roots-with-children)
 
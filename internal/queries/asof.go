@@ -0,0 +1,32 @@
+package queries
+
+import (
+	"regexp"
+
+	"silobang/internal/constants"
+)
+
+// metadataTableRegex matches references to the metadata tables.
+var metadataTableRegex = regexp.MustCompile(`(?i)\bmetadata_(computed|log)\b`)
+
+// asOfParam is the :_as_of parameter as a unix timestamp.
+const asOfParam = `CAST(:` + constants.MetadataAsOfSQLParam + ` AS INTEGER)`
+
+// asOfComputed rebuilds computed metadata from the shadowed metadata_log,
+// the latest entry of each key winning as in database.UpdateMetadataComputed.
+// Like the real table, an asset whose keys were all deleted keeps a "{}" row.
+const asOfComputed = `metadata_computed AS (
+    SELECT asset_id,
+           json_group_object(key, CASE WHEN value_num IS NOT NULL THEN value_num ELSE value_text END)
+               FILTER (WHERE op = 'set') AS metadata_json,
+           MAX(timestamp) AS updated_at
+    FROM metadata_log
+    WHERE id IN (SELECT MAX(id) FROM metadata_log GROUP BY asset_id, key)
+    GROUP BY asset_id
+)`
+
+// UsesMetadata reports whether the preset reads metadata_computed or
+// metadata_log, which makes it answerable as of a past time.
+func (p *Preset) UsesMetadata() bool {
+	return metadataTableRegex.MatchString(sqlCommentRegex.ReplaceAllString(p.SQL, " "))
+}
@@ -0,0 +1,613 @@
+package queries
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"silobang/internal/constants"
+)
+
+// Query builder logical operators
+const (
+	OpAnd = "and"
+	OpOr  = "or"
+	OpNot = "not"
+)
+
+// Query builder comparison operators
+const (
+	OpEq         = "eq"
+	OpNe         = "ne"
+	OpLt         = "lt"
+	OpLte        = "lte"
+	OpGt         = "gt"
+	OpGte        = "gte"
+	OpIn         = "in"
+	OpNotIn      = "not_in"
+	OpContains   = "contains"
+	OpStartsWith = "starts_with"
+	OpEndsWith   = "ends_with"
+	OpExists     = "exists"
+	OpNotExists  = "not_exists"
+)
+
+// Query builder field types
+const (
+	FieldTypeText    = "text"
+	FieldTypeInteger = "integer"
+	FieldTypeAny     = "any" // metadata values: text, number or boolean
+)
+
+// Query builder entities
+const (
+	EntityAssets   = "assets"
+	EntityMetadata = "metadata"
+	EntityLineage  = "lineage"
+
+	// MetadataFieldPrefix names the current value of a metadata key, as in
+	// metadata.author
+	MetadataFieldPrefix = "metadata."
+)
+
+// BuilderOperator describes a comparison operator of the query builder.
+type BuilderOperator struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"` // none, scalar or list
+	Description string `json:"description"`
+}
+
+// builderOperators lists the comparison operators in the order the schema
+// reports them.
+var builderOperators = []BuilderOperator{
+	{OpEq, "scalar", "Equal. Metadata values compare by type: \"5\" does not match 5"},
+	{OpNe, "scalar", "Not equal, including assets without a value"},
+	{OpLt, "scalar", "Less than"},
+	{OpLte, "scalar", "Less than or equal"},
+	{OpGt, "scalar", "Greater than"},
+	{OpGte, "scalar", "Greater than or equal"},
+	{OpIn, "list", "Equal to one of the values"},
+	{OpNotIn, "list", "Equal to none of the values, including assets without a value"},
+	{OpContains, "scalar", "Text contains the value, ignoring ASCII case"},
+	{OpStartsWith, "scalar", "Text starts with the value, ignoring ASCII case"},
+	{OpEndsWith, "scalar", "Text ends with the value, ignoring ASCII case"},
+	{OpExists, "none", "The field has a value"},
+	{OpNotExists, "none", "The field has no value"},
+}
+
+// builderComparisons maps the operators comparing a field to one value to
+// their SQL.
+var builderComparisons = map[string]string{
+	OpEq:  "=",
+	OpNe:  "IS NOT",
+	OpLt:  "<",
+	OpLte: "<=",
+	OpGt:  ">",
+	OpGte: ">=",
+}
+
+// Operators accepted by each kind of field
+var (
+	textOperators         = []string{OpEq, OpNe, OpIn, OpNotIn, OpContains, OpStartsWith, OpEndsWith}
+	nullableTextOperators = append(slices.Clone(textOperators), OpExists, OpNotExists)
+	integerOperators      = []string{OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpIn, OpNotIn}
+	anyOperators          = []string{OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpIn, OpNotIn, OpContains, OpStartsWith, OpEndsWith, OpExists, OpNotExists}
+)
+
+// BuilderColumn describes a field the query builder filters, selects or
+// sorts on.
+type BuilderColumn struct {
+	Field       string   `json:"field"`
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	Operators   []string `json:"operators"`
+	Selectable  bool     `json:"selectable"`
+	Sortable    bool     `json:"sortable"`
+
+	expr  string                    // SQL over assets a (and metadata_computed mc)
+	match func(param string) string // condition of filter-only fields, given the bound value
+}
+
+// BuilderMetadataKey is a current metadata key and how many assets have it.
+type BuilderMetadataKey struct {
+	Key    string `json:"key"`
+	Assets int64  `json:"assets"`
+}
+
+// BuilderEntity is a group of query builder fields.
+type BuilderEntity struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Columns     []BuilderColumn      `json:"columns"`
+	Keys        []BuilderMetadataKey `json:"keys,omitempty"` // metadata only: the most used keys
+}
+
+// BuilderLimits are the bounds a builder query must stay within.
+type BuilderLimits struct {
+	MaxDepth      int `json:"max_depth"`
+	MaxConditions int `json:"max_conditions"`
+	MaxInValues   int `json:"max_in_values"`
+	MaxSelect     int `json:"max_select"`
+	MaxSort       int `json:"max_sort"`
+	MaxLimit      int `json:"max_limit"`
+}
+
+// BuilderSchema describes everything a builder query can use.
+type BuilderSchema struct {
+	Entities  []BuilderEntity   `json:"entities"`
+	Operators []BuilderOperator `json:"operators"`
+	Logical   []string          `json:"logical"`
+	Limits    BuilderLimits     `json:"limits"`
+}
+
+// builderEntities are the queryable entities. The metadata entity has a
+// single column standing for every metadata.<key> field.
+var builderEntities = []BuilderEntity{
+	{
+		Name:        EntityAssets,
+		Description: "Assets of the selected topics; each result row is one asset",
+		Columns: []BuilderColumn{
+			{Field: "asset_id", Type: FieldTypeText, Description: "BLAKE3 hash of the content", Operators: textOperators, Selectable: true, Sortable: true, expr: "a.asset_id"},
+			{Field: "asset_size", Type: FieldTypeInteger, Description: "Size in bytes", Operators: integerOperators, Selectable: true, Sortable: true, expr: "a.asset_size"},
+			{Field: "origin_name", Type: FieldTypeText, Description: "Original file name without extension", Operators: nullableTextOperators, Selectable: true, Sortable: true, expr: "a.origin_name"},
+			{Field: "extension", Type: FieldTypeText, Description: "File extension without dot", Operators: textOperators, Selectable: true, Sortable: true, expr: "a.extension"},
+			{Field: "parent_id", Type: FieldTypeText, Description: "Hash of the parent asset", Operators: nullableTextOperators, Selectable: true, Sortable: true, expr: "a.parent_id"},
+			{Field: "created_at", Type: FieldTypeInteger, Description: "Upload time (unix seconds)", Operators: integerOperators, Selectable: true, Sortable: true, expr: "a.created_at"},
+		},
+	},
+	{
+		Name:        EntityMetadata,
+		Description: "Current metadata values, as metadata.<key>",
+		Columns: []BuilderColumn{
+			{Field: MetadataFieldPrefix + "<key>", Type: FieldTypeAny, Description: "Current value of the key; no value when the asset lacks it", Operators: anyOperators, Selectable: true, Sortable: true},
+		},
+	},
+	{
+		Name:        EntityLineage,
+		Description: "Lineage of each asset within its topic",
+		Columns: []BuilderColumn{
+			{
+				Field: "lineage.child_count", Type: FieldTypeInteger, Description: "Assets whose parent is this asset",
+				Operators: integerOperators, Selectable: true, Sortable: true,
+				expr: "(SELECT COUNT(*) FROM assets c WHERE c.parent_id = a.asset_id)",
+			},
+			{
+				Field: "lineage.parent_changes", Type: FieldTypeInteger, Description: "Times the parent was replaced after upload",
+				Operators: integerOperators, Selectable: true, Sortable: true,
+				expr: "(SELECT COUNT(*) FROM lineage_changes lc WHERE lc.asset_id = a.asset_id)",
+			},
+			{
+				Field: "lineage.descendant_of", Type: FieldTypeText, Description: "Hash of an ancestor of the asset (filter only)",
+				Operators: []string{OpEq},
+				match: func(param string) string {
+					return "a.asset_id IN (WITH RECURSIVE descendants(id) AS (" +
+						"SELECT asset_id FROM assets WHERE parent_id = " + param +
+						" UNION SELECT x.asset_id FROM assets x JOIN descendants d ON x.parent_id = d.id" +
+						") SELECT id FROM descendants)"
+				},
+			},
+		},
+	},
+}
+
+// defaultBuilderSelect are the fields returned when a query selects none.
+var defaultBuilderSelect = []string{"asset_id", "origin_name", "extension", "asset_size", "created_at"}
+
+// Schema returns the builder schema with the given metadata keys and the
+// largest limit a query may ask for.
+func Schema(keys []BuilderMetadataKey, maxLimit int) *BuilderSchema {
+	entities := slices.Clone(builderEntities)
+	for i := range entities {
+		if entities[i].Name == EntityMetadata {
+			entities[i].Keys = keys
+		}
+	}
+	return &BuilderSchema{
+		Entities:  entities,
+		Operators: builderOperators,
+		Logical:   []string{OpAnd, OpOr, OpNot},
+		Limits: BuilderLimits{
+			MaxDepth:      constants.QueryBuilderMaxDepth,
+			MaxConditions: constants.QueryBuilderMaxConditions,
+			MaxInValues:   constants.QueryBuilderMaxInValues,
+			MaxSelect:     constants.QueryBuilderMaxSelect,
+			MaxSort:       constants.QueryBuilderMaxSort,
+			MaxLimit:      maxLimit,
+		},
+	}
+}
+
+// FilterNode is a node of a builder filter tree: and/or with children, not
+// with one child, or a comparison of a field with a value.
+type FilterNode struct {
+	Op       string       `json:"op"`
+	Field    string       `json:"field,omitempty"`
+	Value    interface{}  `json:"value,omitempty"`
+	Children []FilterNode `json:"children,omitempty"`
+}
+
+// SortField orders builder query results by a field.
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// BuilderQuery is a structured query over assets, their current metadata
+// and their lineage.
+type BuilderQuery struct {
+	Filter *FilterNode `json:"filter,omitempty"`
+	Select []string    `json:"select,omitempty"` // default: asset_id, origin_name, extension, asset_size, created_at
+	Sort   []SortField `json:"sort,omitempty"`   // default: created_at, newest first
+	Limit  int         `json:"limit,omitempty"`  // default: constants.QueryBuilderDefaultLimit
+}
+
+// CompiledQuery is a builder query compiled to a preset, with every value
+// the client sent bound as a parameter.
+type CompiledQuery struct {
+	Preset *Preset
+	Params map[string]string
+
+	columns []string // selected fields, then sort fields that were not selected
+	visible int      // selected fields
+	sort    []sortKey
+	limit   int
+}
+
+type sortKey struct {
+	column int
+	desc   bool
+}
+
+// builderCompiler collects the parameters of a query being compiled.
+type builderCompiler struct {
+	params     map[string]string
+	conditions int
+	metadata   bool // metadata_computed must be joined
+}
+
+// Compile checks the query and compiles it to a single SELECT over a topic
+// database. asset_id is always selected, first unless chosen elsewhere, so
+// results can be filtered by quarantine and collection.
+func (q *BuilderQuery) Compile(maxLimit int) (*CompiledQuery, error) {
+	c := &builderCompiler{params: make(map[string]string)}
+
+	limit := q.Limit
+	if limit == 0 {
+		limit = min(constants.QueryBuilderDefaultLimit, maxLimit)
+	}
+	if limit < 1 || limit > maxLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+	}
+
+	fields := q.Select
+	if len(fields) == 0 {
+		fields = defaultBuilderSelect
+	}
+	if len(fields) > constants.QueryBuilderMaxSelect {
+		return nil, fmt.Errorf("at most %d fields may be selected", constants.QueryBuilderMaxSelect)
+	}
+	if !slices.Contains(fields, "asset_id") {
+		fields = append([]string{"asset_id"}, fields...)
+	}
+
+	cq := &CompiledQuery{Params: c.params, limit: limit}
+	var exprs []string
+	for _, field := range fields {
+		if slices.Contains(cq.columns, field) {
+			return nil, fmt.Errorf("field %s selected twice", field)
+		}
+		col, err := c.column(field)
+		if err != nil {
+			return nil, err
+		}
+		if !col.Selectable {
+			return nil, fmt.Errorf("field %s can only be filtered on", field)
+		}
+		exprs = append(exprs, fmt.Sprintf("%s AS c%d", col.expr, len(exprs)))
+		cq.columns = append(cq.columns, field)
+	}
+	cq.visible = len(cq.columns)
+
+	sorts := q.Sort
+	if len(sorts) == 0 {
+		sorts = []SortField{{Field: "created_at", Desc: true}}
+	}
+	if len(sorts) > constants.QueryBuilderMaxSort {
+		return nil, fmt.Errorf("at most %d sort fields are allowed", constants.QueryBuilderMaxSort)
+	}
+	var orderBy []string
+	for _, s := range sorts {
+		col, err := c.column(s.Field)
+		if err != nil {
+			return nil, err
+		}
+		if !col.Sortable {
+			return nil, fmt.Errorf("field %s cannot be sorted on", s.Field)
+		}
+		idx := slices.Index(cq.columns, s.Field)
+		if idx == -1 {
+			idx = len(cq.columns)
+			exprs = append(exprs, fmt.Sprintf("%s AS c%d", col.expr, idx))
+			cq.columns = append(cq.columns, s.Field)
+		}
+		direction := "ASC"
+		if s.Desc {
+			direction = "DESC"
+		}
+		orderBy = append(orderBy, fmt.Sprintf("c%d %s", idx, direction))
+		cq.sort = append(cq.sort, sortKey{column: idx, desc: s.Desc})
+	}
+
+	where := ""
+	if q.Filter != nil {
+		cond, err := c.node(q.Filter, 1)
+		if err != nil {
+			return nil, err
+		}
+		where = " WHERE " + cond
+	}
+
+	from := " FROM assets a"
+	if c.metadata {
+		from += " LEFT JOIN metadata_computed mc ON mc.asset_id = a.asset_id"
+	}
+
+	cq.Preset = &Preset{
+		Description: "query builder",
+		SQL: "SELECT " + strings.Join(exprs, ", ") + from + where +
+			" ORDER BY " + strings.Join(orderBy, ", ") + ", a.rowid LIMIT " + strconv.Itoa(limit),
+	}
+	return cq, nil
+}
+
+// Finish shapes the rows the compiled query returned from each topic: it
+// names the columns after the fields, orders the rows across topics, keeps
+// the first limit and drops the fields only selected for sorting.
+func (cq *CompiledQuery) Finish(result *QueryResult) {
+	sort.SliceStable(result.Rows, func(i, j int) bool {
+		for _, k := range cq.sort {
+			if c := compareSQLiteValues(result.Rows[i][k.column], result.Rows[j][k.column]); c != 0 {
+				return (c < 0) != k.desc
+			}
+		}
+		return false
+	})
+	if len(result.Rows) > cq.limit {
+		result.Rows = result.Rows[:cq.limit]
+	}
+
+	// Rows end with the _topic column added per topic
+	for i, row := range result.Rows {
+		result.Rows[i] = append(row[:cq.visible], row[len(cq.columns):]...)
+	}
+	result.Columns = append(slices.Clone(cq.columns[:cq.visible]), "_topic")
+	result.RowCount = len(result.Rows)
+}
+
+// compareSQLiteValues orders scanned values as SQLite does: NULL, then
+// numbers, then text.
+func compareSQLiteValues(a, b interface{}) int {
+	rank := func(v interface{}) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case int64, float64:
+			return 1
+		}
+		return 2
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+	switch a := a.(type) {
+	case int64, float64:
+		x, y := toFloat(a), toFloat(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case nil:
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v interface{}) float64 {
+	if i, ok := v.(int64); ok {
+		return float64(i)
+	}
+	f, _ := v.(float64)
+	return f
+}
+
+// bind adds a parameter and returns its reference in the SQL.
+func (c *builderCompiler) bind(value string) string {
+	name := "f" + strconv.Itoa(len(c.params)+1)
+	c.params[name] = value
+	return ":" + name
+}
+
+// column resolves a field name to its column, binding the JSON path of
+// metadata fields.
+func (c *builderCompiler) column(field string) (*BuilderColumn, error) {
+	if key, ok := strings.CutPrefix(field, MetadataFieldPrefix); ok {
+		if err := validateBuilderMetadataKey(key); err != nil {
+			return nil, err
+		}
+		col := builderEntities[slices.IndexFunc(builderEntities, func(e BuilderEntity) bool { return e.Name == EntityMetadata })].Columns[0]
+		col.Field = field
+		col.expr = "json_extract(mc.metadata_json, " + c.bind(`$."`+key+`"`) + ")"
+		c.metadata = true
+		return &col, nil
+	}
+
+	for _, entity := range builderEntities {
+		for _, col := range entity.Columns {
+			if col.Field == field {
+				return &col, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown field %q", field)
+}
+
+// validateBuilderMetadataKey checks a metadata key can be quoted in a JSON
+// path.
+func validateBuilderMetadataKey(key string) error {
+	if key == "" || len(key) > constants.MaxMetadataKeyLength {
+		return fmt.Errorf("metadata key must be 1 to %d characters", constants.MaxMetadataKeyLength)
+	}
+	if strings.ContainsAny(key, `"\`) || strings.ContainsFunc(key, unicode.IsControl) {
+		return fmt.Errorf("metadata key %q cannot be queried: it contains quotes, backslashes or control characters", key)
+	}
+	return nil
+}
+
+// node compiles a filter tree node to a condition.
+func (c *builderCompiler) node(n *FilterNode, depth int) (string, error) {
+	if depth > constants.QueryBuilderMaxDepth {
+		return "", fmt.Errorf("filter nests deeper than %d levels", constants.QueryBuilderMaxDepth)
+	}
+
+	switch n.Op {
+	case OpAnd, OpOr:
+		if len(n.Children) == 0 {
+			return "", fmt.Errorf("%s needs at least one child", n.Op)
+		}
+		parts := make([]string, 0, len(n.Children))
+		for i := range n.Children {
+			part, err := c.node(&n.Children[i], depth+1)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
+		return "(" + strings.Join(parts, " "+strings.ToUpper(n.Op)+" ") + ")", nil
+	case OpNot:
+		if len(n.Children) != 1 {
+			return "", fmt.Errorf("not needs exactly one child")
+		}
+		part, err := c.node(&n.Children[0], depth+1)
+		if err != nil {
+			return "", err
+		}
+		return "(NOT " + part + ")", nil
+	}
+	return c.comparison(n)
+}
+
+// comparison compiles a comparison node to a condition.
+func (c *builderCompiler) comparison(n *FilterNode) (string, error) {
+	if !slices.ContainsFunc(builderOperators, func(op BuilderOperator) bool { return op.Name == n.Op }) {
+		return "", fmt.Errorf("unknown operator %q", n.Op)
+	}
+	if len(n.Children) > 0 {
+		return "", fmt.Errorf("%s takes a field and a value, not children", n.Op)
+	}
+	if n.Field == "" {
+		return "", fmt.Errorf("%s needs a field", n.Op)
+	}
+	c.conditions++
+	if c.conditions > constants.QueryBuilderMaxConditions {
+		return "", fmt.Errorf("filter has more than %d conditions", constants.QueryBuilderMaxConditions)
+	}
+
+	col, err := c.column(n.Field)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(col.Operators, n.Op) {
+		return "", fmt.Errorf("field %s does not support %s", n.Field, n.Op)
+	}
+
+	if col.match != nil {
+		value, ok := n.Value.(string)
+		if !ok {
+			return "", fmt.Errorf("field %s needs a text value", n.Field)
+		}
+		return col.match(c.bind(value)), nil
+	}
+
+	switch n.Op {
+	case OpExists:
+		return col.expr + " IS NOT NULL", nil
+	case OpNotExists:
+		return col.expr + " IS NULL", nil
+	case OpIn, OpNotIn:
+		values, ok := n.Value.([]interface{})
+		if !ok || len(values) == 0 || len(values) > constants.QueryBuilderMaxInValues {
+			return "", fmt.Errorf("%s on %s needs a list of 1 to %d values", n.Op, n.Field, constants.QueryBuilderMaxInValues)
+		}
+		refs := make([]string, 0, len(values))
+		for _, v := range values {
+			ref, err := c.value(col, v)
+			if err != nil {
+				return "", err
+			}
+			refs = append(refs, ref)
+		}
+		list := "(" + strings.Join(refs, ", ") + ")"
+		if n.Op == OpIn {
+			return col.expr + " IN " + list, nil
+		}
+		return "(" + col.expr + " IS NULL OR " + col.expr + " NOT IN " + list + ")", nil
+	case OpContains, OpStartsWith, OpEndsWith:
+		value, ok := n.Value.(string)
+		if !ok {
+			return "", fmt.Errorf("%s on %s needs a text value", n.Op, n.Field)
+		}
+		pattern := escapeLike(value)
+		if n.Op != OpStartsWith {
+			pattern = "%" + pattern
+		}
+		if n.Op != OpEndsWith {
+			pattern += "%"
+		}
+		return col.expr + " LIKE " + c.bind(pattern) + ` ESCAPE '\'`, nil
+	}
+
+	ref, err := c.value(col, n.Value)
+	if err != nil {
+		return "", err
+	}
+	return col.expr + " " + builderComparisons[n.Op] + " " + ref, nil
+}
+
+// value binds a comparison value of the field's type. Numbers and booleans
+// are cast back to numbers, as parameters are bound as text.
+func (c *builderCompiler) value(col *BuilderColumn, v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		if col.Type == FieldTypeInteger {
+			return "", fmt.Errorf("field %s needs a number", col.Field)
+		}
+		return c.bind(v), nil
+	case float64:
+		if col.Type == FieldTypeText {
+			return "", fmt.Errorf("field %s needs a text value", col.Field)
+		}
+		return "CAST(" + c.bind(strconv.FormatFloat(v, 'f', -1, 64)) + " AS NUMERIC)", nil
+	case bool:
+		if col.Type != FieldTypeAny {
+			return "", fmt.Errorf("field %s does not hold booleans", col.Field)
+		}
+		// json_extract returns JSON booleans as 1 and 0
+		number := "0"
+		if v {
+			number = "1"
+		}
+		return "CAST(" + c.bind(number) + " AS NUMERIC)", nil
+	}
+	return "", fmt.Errorf("field %s: value must be text, a number or a boolean", col.Field)
+}
+
+// escapeLike escapes the LIKE wildcards of a value for ESCAPE '\'.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
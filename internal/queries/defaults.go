@@ -206,18 +206,50 @@ LIMIT :limit`,
 				{Name: "limit", Default: constants.DefaultPresetLimit},
 			},
 		},
+		"by-content-type": {
+			Description: "Find assets by content type sniffed at upload (media_content_type), e.g. image/png, or a family such as image",
+			SQL: `SELECT a.asset_id, a.origin_name, a.extension, a.asset_size, a.parent_id, a.blob_name, a.created_at,
+       json_extract(mc.metadata_json, '$.media_content_type') AS content_type
+FROM assets a
+JOIN metadata_computed mc ON a.asset_id = mc.asset_id
+WHERE json_extract(mc.metadata_json, '$.media_content_type') = :type
+   OR json_extract(mc.metadata_json, '$.media_content_type') LIKE :type || '/%'
+ORDER BY a.created_at DESC
+LIMIT :limit`,
+			Params: []PresetParam{
+				{Name: "type", Required: true},
+				{Name: "limit", Default: constants.DefaultPresetLimit},
+			},
+		},
 		"by-origin-name": {
-			Description: "Search assets by original filename",
+			Description: "Search assets by original filename (locale-aware on topics with a topic_collation)",
 			SQL: `SELECT asset_id, origin_name, extension, asset_size, parent_id, blob_name, created_at
 FROM assets
-WHERE origin_name LIKE '%' || :name || '%'
-ORDER BY origin_name, created_at DESC
+WHERE CASE WHEN :_collation IS NULL
+  THEN origin_name LIKE '%' || :name || '%'
+  ELSE instr(silo_fold(origin_name, :_collation), silo_fold(:name, :_collation)) > 0
+END
+ORDER BY silo_fold(origin_name, :_collation), origin_name, created_at DESC
 LIMIT :limit`,
 			Params: []PresetParam{
 				{Name: "name", Required: true},
 				{Name: "limit", Default: constants.DefaultPresetLimit},
 			},
 		},
+		"by-relative-path": {
+			Description: "Find assets uploaded from a folder (relative_path prefix)",
+			SQL: `SELECT a.asset_id, a.origin_name, a.extension, a.asset_size, a.parent_id, a.blob_name, a.created_at,
+       json_extract(mc.metadata_json, '$.relative_path') AS relative_path
+FROM assets a
+JOIN metadata_computed mc ON a.asset_id = mc.asset_id
+WHERE json_extract(mc.metadata_json, '$.relative_path') LIKE COALESCE(:prefix, '') || '%'
+ORDER BY relative_path
+LIMIT :limit`,
+			Params: []PresetParam{
+				{Name: "prefix"},
+				{Name: "limit", Default: constants.DefaultPresetLimit},
+			},
+		},
 
 		// Lineage Analysis
 		"lineage": {
@@ -325,6 +357,46 @@ LIMIT :limit`,
 			},
 		},
 
+		// Federated Queries (orchestrator + attached topics)
+		"topic-difference": {
+			Description: "Assets in topic A with no asset of the same origin name and extension in topic B",
+			SQL: `SELECT a.asset_id, a.origin_name, a.extension, a.asset_size, a.created_at
+FROM topic_a.assets a
+WHERE NOT EXISTS (
+  SELECT 1 FROM topic_b.assets b
+  WHERE b.origin_name = a.origin_name AND b.extension = a.extension
+)
+ORDER BY a.created_at DESC
+LIMIT :limit`,
+			Params: []PresetParam{
+				{Name: "topic_a", Required: true},
+				{Name: "topic_b", Required: true},
+				{Name: "limit", Default: constants.DefaultPresetLimit},
+			},
+			Federated:   true,
+			TopicParams: []string{"topic_a", "topic_b"},
+		},
+		"index-drift": {
+			Description: "Hashes the orchestrator index and a topic database disagree on",
+			SQL: `WITH indexed AS (
+  SELECT hash FROM orchestrator.asset_index WHERE topic = :topic
+)
+SELECT i.hash AS asset_id, 'missing_from_topic' AS drift
+FROM indexed i
+WHERE NOT EXISTS (SELECT 1 FROM topic.assets a WHERE a.asset_id = i.hash)
+UNION ALL
+SELECT a.asset_id, 'missing_from_index' AS drift
+FROM topic.assets a
+WHERE NOT EXISTS (SELECT 1 FROM indexed i WHERE i.hash = a.asset_id)
+LIMIT :limit`,
+			Params: []PresetParam{
+				{Name: "topic", Required: true},
+				{Name: "limit", Default: constants.DefaultPresetLimit},
+			},
+			Federated:   true,
+			TopicParams: []string{"topic"},
+		},
+
 		// Storage Analysis
 		"dat-file-stats": {
 			Description: "Statistics per DAT file",
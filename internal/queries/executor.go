@@ -3,7 +3,10 @@ package queries
 import (
 	"database/sql"
 	"fmt"
+	"maps"
 	"regexp"
+
+	"silobang/internal/constants"
 )
 
 // QueryResult contains the result of a query execution
@@ -12,6 +15,9 @@ type QueryResult struct {
 	RowCount int             `json:"row_count"`
 	Columns  []string        `json:"columns"`
 	Rows     [][]interface{} `json:"rows"`
+
+	// Truncated is set when the result hit the row cap
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // QueryRequest contains parameters for executing a query
@@ -63,7 +69,8 @@ func ValidateParams(preset *Preset, params map[string]string) (map[string]string
 }
 
 // BuildQuery converts named parameters to positional parameters for SQLite
-// Returns the query with ? placeholders and the ordered argument slice
+// Returns the query with ?N placeholders and the ordered argument slice.
+// A parameter used more than once binds the same argument each time.
 func BuildQuery(sqlTemplate string, params map[string]string) (string, []interface{}) {
 	var args []interface{}
 	paramIndex := make(map[string]int) // Track which params we've seen
@@ -72,8 +79,10 @@ func BuildQuery(sqlTemplate string, params map[string]string) (string, []interfa
 	result := paramRegex.ReplaceAllStringFunc(sqlTemplate, func(match string) string {
 		paramName := match[1:] // Remove leading :
 
-		if _, seen := paramIndex[paramName]; !seen {
-			paramIndex[paramName] = argCounter
+		index, seen := paramIndex[paramName]
+		if !seen {
+			index = argCounter
+			paramIndex[paramName] = index
 			if value, exists := params[paramName]; exists {
 				args = append(args, value)
 			} else {
@@ -82,7 +91,7 @@ func BuildQuery(sqlTemplate string, params map[string]string) (string, []interfa
 			argCounter++
 		}
 
-		return "?"
+		return fmt.Sprintf("?%d", index+1)
 	})
 
 	return result, args
@@ -96,17 +105,34 @@ func ExecuteQuery(db *sql.DB, query string, args []interface{}) ([]string, [][]i
 	}
 	defer rows.Close()
 
+	columns, result, _, err := scanRows(rows, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return columns, result, nil
+}
+
+// scanRows reads all rows, or at most maxRows when maxRows > 0.
+// Reports whether rows were left unread.
+func scanRows(rows *sql.Rows, maxRows int) ([]string, [][]interface{}, bool, error) {
 	// Get column names
 	columns, err := rows.Columns()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get columns: %w", err)
+		return nil, nil, false, fmt.Errorf("failed to get columns: %w", err)
 	}
 
 	// Prepare result slice
-	var result [][]interface{}
+	result := [][]interface{}{}
+	truncated := false
 
 	// Scan rows
 	for rows.Next() {
+		if maxRows > 0 && len(result) >= maxRows {
+			truncated = true
+			break
+		}
+
 		// Create a slice of interface{} to hold the values
 		values := make([]interface{}, len(columns))
 		valuePtrs := make([]interface{}, len(columns))
@@ -115,7 +141,7 @@ func ExecuteQuery(db *sql.DB, query string, args []interface{}) ([]string, [][]i
 		}
 
 		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, nil, fmt.Errorf("failed to scan row: %w", err)
+			return nil, nil, false, fmt.Errorf("failed to scan row: %w", err)
 		}
 
 		// Convert []byte to string for JSON serialization
@@ -129,10 +155,10 @@ func ExecuteQuery(db *sql.DB, query string, args []interface{}) ([]string, [][]i
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, nil, fmt.Errorf("row iteration error: %w", err)
+		return nil, nil, false, fmt.Errorf("row iteration error: %w", err)
 	}
 
-	return columns, result, nil
+	return columns, result, truncated, nil
 }
 
 // ExecutePresetQuery executes a preset query against a single topic database
@@ -159,6 +185,13 @@ func ExecutePresetQuery(preset *Preset, params map[string]string, db *sql.DB, to
 // ExecuteCrossTopicQuery executes a preset query across multiple topics
 // Results are interleaved (not grouped by topic)
 func ExecuteCrossTopicQuery(preset *Preset, params map[string]string, topicDBs map[string]*sql.DB, topicNames []string) (*QueryResult, error) {
+	return ExecuteCollatedCrossTopicQuery(preset, params, topicDBs, topicNames, nil)
+}
+
+// ExecuteCollatedCrossTopicQuery is ExecuteCrossTopicQuery with a per-topic
+// collation spec bound to the :_collation parameter. Topics without an entry
+// leave it NULL.
+func ExecuteCollatedCrossTopicQuery(preset *Preset, params map[string]string, topicDBs map[string]*sql.DB, topicNames []string, collations map[string]string) (*QueryResult, error) {
 	var allColumns []string
 	var allRows [][]interface{}
 
@@ -168,7 +201,16 @@ func ExecuteCrossTopicQuery(preset *Preset, params map[string]string, topicDBs m
 			continue
 		}
 
-		columns, rows, err := ExecutePresetQuery(preset, params, db, topicName)
+		topicParams := params
+		if spec, ok := collations[topicName]; ok {
+			topicParams = maps.Clone(params)
+			if topicParams == nil {
+				topicParams = make(map[string]string)
+			}
+			topicParams[constants.CollationParam] = spec
+		}
+
+		columns, rows, err := ExecutePresetQuery(preset, topicParams, db, topicName)
 		if err != nil {
 			// Log error but continue with other topics
 			continue
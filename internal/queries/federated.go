@@ -0,0 +1,127 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"silobang/internal/constants"
+	_ "silobang/internal/database" // registers the sqlite3_silobang driver
+)
+
+// schemaAliasRegex matches names that are safe to use unquoted as SQLite
+// schema names in ATTACH and in preset SQL.
+var schemaAliasRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedSchemaAliases cannot be used for attached topics.
+var reservedSchemaAliases = map[string]bool{
+	"main":                               true,
+	"temp":                               true,
+	constants.FederatedOrchestratorAlias: true,
+}
+
+// FederatedSource is a database file attached to a federated query.
+// Preset SQL addresses its tables as <Alias>.<table>.
+type FederatedSource struct {
+	Alias string
+	Path  string
+}
+
+// ExecuteFederatedQuery runs a preset once on a dedicated connection with
+// every source ATTACHed read-only. The connection is query-only, its page
+// cache is capped per source and temporary b-trees go to disk, so large
+// set operations do not grow the process heap. The query is interrupted when
+// ctx is done. At most maxRows rows are returned; Truncated reports a cut.
+func ExecuteFederatedQuery(ctx context.Context, preset *Preset, params map[string]string, sources []FederatedSource, maxRows int) (*QueryResult, error) {
+	db, conn, err := openReadOnly(ctx, "", sources)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	defer conn.Close()
+
+	query, args := BuildQuery(preset.SQL, params)
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, result, truncated, err := scanRows(rows, maxRows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueryResult{
+		RowCount:  len(result),
+		Columns:   columns,
+		Rows:      result,
+		Truncated: truncated,
+	}, nil
+}
+
+// openReadOnly opens a dedicated query-only connection to mainPath (an
+// empty in-memory database when empty) with every source ATTACHed
+// read-only. The page cache is capped per source and temporary b-trees go
+// to disk. The caller closes both the connection and the database.
+func openReadOnly(ctx context.Context, mainPath string, sources []FederatedSource) (*sql.DB, *sql.Conn, error) {
+	dsn := ":memory:"
+	if mainPath != "" {
+		dsn = readOnlyURI(mainPath)
+	}
+	db, err := sql.Open(constants.SQLiteDriverName, dsn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open read-only connection: %w", err)
+	}
+
+	// ATTACH and pragmas are per connection, so pin one
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to open read-only connection: %w", err)
+	}
+
+	if err := prepareReadOnly(ctx, conn, sources); err != nil {
+		conn.Close()
+		db.Close()
+		return nil, nil, err
+	}
+	return db, conn, nil
+}
+
+// prepareReadOnly attaches the sources and makes conn query-only.
+func prepareReadOnly(ctx context.Context, conn *sql.Conn, sources []FederatedSource) error {
+	for _, src := range sources {
+		if !schemaAliasRegex.MatchString(src.Alias) {
+			return fmt.Errorf("invalid schema alias: %s", src.Alias)
+		}
+		if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS "+src.Alias, readOnlyURI(src.Path)); err != nil {
+			return fmt.Errorf("failed to attach %s: %w", src.Alias, err)
+		}
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA %s.cache_size = -%d", src.Alias, constants.FederatedCacheSizeKiB)); err != nil {
+			return fmt.Errorf("failed to limit cache for %s: %w", src.Alias, err)
+		}
+	}
+
+	for _, pragma := range []string{"PRAGMA temp_store = FILE", "PRAGMA query_only = ON"} {
+		if _, err := conn.ExecContext(ctx, pragma); err != nil {
+			return fmt.Errorf("failed to apply %q: %w", pragma, err)
+		}
+	}
+	return nil
+}
+
+// readOnlyURI builds a SQLite URI filename that opens path read-only.
+// Windows drive paths become file:///C:/...
+func readOnlyURI(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	u := url.URL{Scheme: "file", Path: p, RawQuery: "mode=ro"}
+	return u.String()
+}
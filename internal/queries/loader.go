@@ -247,6 +247,11 @@ func validatePreset(preset *Preset, name string) error {
 		return fmt.Errorf("preset name must match pattern: %s", constants.QueryNameRegex)
 	}
 
+	// The query builder endpoints share the preset path
+	if name == constants.QueryBuilderPreset || name == constants.QueryBuilderSchemaPreset {
+		return fmt.Errorf("preset name %s is reserved for the query builder", name)
+	}
+
 	if preset.SQL == "" {
 		return fmt.Errorf("preset SQL is required")
 	}
@@ -258,6 +263,45 @@ func validatePreset(preset *Preset, name string) error {
 		}
 	}
 
+	return validateTopicParams(preset)
+}
+
+// validateTopicParams checks that a federated preset's topic params are
+// declared params usable as SQLite schema names.
+func validateTopicParams(preset *Preset) error {
+	if !preset.Federated {
+		if len(preset.TopicParams) > 0 {
+			return fmt.Errorf("topic_params requires federated: true")
+		}
+		return nil
+	}
+
+	if len(preset.TopicParams) > constants.FederatedMaxTopics {
+		return fmt.Errorf("federated preset may attach at most %d topics", constants.FederatedMaxTopics)
+	}
+
+	declared := make(map[string]bool, len(preset.Params))
+	for _, param := range preset.Params {
+		declared[param.Name] = true
+	}
+
+	seen := make(map[string]bool, len(preset.TopicParams))
+	for _, name := range preset.TopicParams {
+		if !declared[name] {
+			return fmt.Errorf("topic param %s is not a declared param", name)
+		}
+		if !schemaAliasRegex.MatchString(name) {
+			return fmt.Errorf("topic param %s is not a valid schema name", name)
+		}
+		if reservedSchemaAliases[strings.ToLower(name)] {
+			return fmt.Errorf("topic param %s is a reserved schema name", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("topic param %s listed twice", name)
+		}
+		seen[name] = true
+	}
+
 	return nil
 }
 
@@ -3,7 +3,9 @@ package queries
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -28,6 +30,30 @@ type Preset struct {
 	Description string        `yaml:"description"`
 	SQL         string        `yaml:"sql"`
 	Params      []PresetParam `yaml:"params,omitempty"`
+	Federated   bool          `yaml:"federated,omitempty"`    // run once against the attached orchestrator and topic DBs
+	TopicParams []string      `yaml:"topic_params,omitempty"` // params naming topics to attach, each under the param's name
+}
+
+// sqlCommentRegex matches SQL line and block comments.
+var sqlCommentRegex = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+
+// sqlWriteKeywordRegex matches statements that modify data or schema.
+var sqlWriteKeywordRegex = regexp.MustCompile(`(?i)\b(insert|update|delete|replace\s+into|drop|alter|create|attach|detach|pragma|vacuum|reindex)\b`)
+
+// IsReadOnly reports whether the preset is a single SELECT (or WITH ...
+// SELECT) statement without data- or schema-modifying keywords. The check
+// is conservative: a write keyword anywhere, even in a string, fails it.
+func (p *Preset) IsReadOnly() bool {
+	sql := strings.TrimSpace(sqlCommentRegex.ReplaceAllString(p.SQL, " "))
+	sql = strings.TrimSpace(strings.TrimSuffix(sql, ";"))
+	if strings.Contains(sql, ";") {
+		return false
+	}
+	lower := strings.ToLower(sql)
+	if !strings.HasPrefix(lower, "select") && !strings.HasPrefix(lower, "with") {
+		return false
+	}
+	return !sqlWriteKeywordRegex.MatchString(sql)
 }
 
 // PresetParam defines a parameter for a preset query
@@ -42,6 +68,12 @@ type PresetInfo struct {
 	Name        string            `json:"name"`
 	Description string            `json:"description"`
 	Params      []PresetParamInfo `json:"params"`
+	Federated   bool              `json:"federated,omitempty"`
+	TopicParams []string          `json:"topic_params,omitempty"`
+
+	// SupportsAsOf is set for presets that read metadata, which accept an
+	// as_of timestamp
+	SupportsAsOf bool `json:"supports_as_of,omitempty"`
 }
 
 // PresetParamInfo contains parameter info for API responses
@@ -90,9 +122,12 @@ func (c *QueriesConfig) ListPresets() []PresetInfo {
 		}
 
 		result = append(result, PresetInfo{
-			Name:        name,
-			Description: preset.Description,
-			Params:      params,
+			Name:         name,
+			Description:  preset.Description,
+			Params:       params,
+			Federated:    preset.Federated,
+			TopicParams:  preset.TopicParams,
+			SupportsAsOf: !preset.Federated && preset.UsesMetadata(),
 		})
 	}
 
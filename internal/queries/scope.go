@@ -0,0 +1,93 @@
+package queries
+
+import (
+	"regexp"
+	"strings"
+
+	"silobang/internal/constants"
+)
+
+// leadingWithRegex matches a leading WITH [RECURSIVE] keyword.
+var leadingWithRegex = regexp.MustCompile(`(?i)^with(\s+recursive)?\s`)
+
+// collectionMembers selects the assets of the collection bound to the
+// :_collection parameter.
+const collectionMembers = `(SELECT asset_id FROM main.collection_assets WHERE collection = :` + constants.CollectionSQLParam + `)`
+
+// Scope narrows the rows a preset sees in the topic tables it reads.
+type Scope struct {
+	AsOf       bool // assets and metadata as of the :_as_of unix timestamp
+	Collection bool // only assets of the collection bound to :_collection
+}
+
+// Scoped returns a copy of the preset whose assets, metadata_log and
+// metadata_computed tables are shadowed by common table expressions holding
+// only the rows in scope. The preset's own filters, ORDER BY and LIMIT then
+// apply to the scoped rows. Federated presets, which read attached databases
+// by name, are returned unchanged.
+func (p *Preset) Scoped(scope Scope) *Preset {
+	out := *p
+	if p.Federated || scope == (Scope{}) {
+		return &out
+	}
+
+	// Skip leading whitespace and comments to find the statement keyword
+	sql := p.SQL
+	start := 0
+	for {
+		rest := strings.TrimLeft(sql[start:], " \t\r\n")
+		start = len(sql) - len(rest)
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				return &out
+			}
+			start += end + 1
+			continue
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest, "*/")
+			if end < 0 {
+				return &out
+			}
+			start += end + 2
+			continue
+		}
+		break
+	}
+
+	tables := scopedTables(scope)
+	body := sql[start:]
+	if m := leadingWithRegex.FindString(body); m != "" {
+		out.SQL = sql[:start] + m + tables + ",\n" + body[len(m):]
+	} else {
+		out.SQL = sql[:start] + "WITH " + tables + "\n" + body
+	}
+	return &out
+}
+
+// scopedTables returns the common table expressions shadowing the topic
+// tables with the rows in scope.
+func scopedTables(scope Scope) string {
+	var assets, log []string
+	if scope.AsOf {
+		assets = append(assets, "created_at <= "+asOfParam)
+		log = append(log, "timestamp <= "+asOfParam)
+	}
+	if scope.Collection {
+		assets = append(assets, "asset_id IN "+collectionMembers)
+		log = append(log, "asset_id IN "+collectionMembers)
+	}
+
+	tables := `assets AS (
+    SELECT * FROM main.assets WHERE ` + strings.Join(assets, " AND ") + `
+), metadata_log AS (
+    SELECT * FROM main.metadata_log WHERE ` + strings.Join(log, " AND ") + `
+), `
+	if scope.AsOf {
+		return tables + asOfComputed
+	}
+	return tables + `metadata_computed AS (
+    SELECT * FROM main.metadata_computed WHERE asset_id IN ` + collectionMembers + `
+)`
+}
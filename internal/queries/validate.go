@@ -0,0 +1,168 @@
+package queries
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"silobang/internal/constants"
+)
+
+// ColumnInfo describes a result column of a preset.
+type ColumnInfo struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"` // declared type; empty for computed expressions
+}
+
+// PlanStep is one line of SQLite's EXPLAIN QUERY PLAN output.
+type PlanStep struct {
+	ID     int    `json:"id"`
+	Parent int    `json:"parent"`
+	Detail string `json:"detail"`
+}
+
+// QueryCost summarizes how expensive a query is expected to be.
+type QueryCost struct {
+	Opcodes       int `json:"opcodes"`        // instructions in the compiled program
+	FullScans     int `json:"full_scans"`     // tables read row by row
+	IndexSearches int `json:"index_searches"` // tables read through an index
+	TempBTrees    int `json:"temp_b_trees"`   // sorts and groupings needing a temporary b-tree
+}
+
+// ExplainResult is what SQLite reports about a preset without running it.
+type ExplainResult struct {
+	Columns []ColumnInfo `json:"columns"`
+	Plan    []PlanStep   `json:"plan"`
+	Cost    QueryCost    `json:"cost"`
+}
+
+// ParsePresetDefinition parses the YAML of a single preset file. Unknown
+// fields are rejected so typos are reported instead of ignored.
+func ParsePresetDefinition(data []byte) (*Preset, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var preset Preset
+	if err := dec.Decode(&preset); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return &preset, nil
+}
+
+// CheckPreset returns the problems that would make the loader skip the
+// preset or its queries misbehave, and warnings about likely mistakes.
+func CheckPreset(preset *Preset, name string) (problems, warnings []string) {
+	if err := validatePreset(preset, name); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if preset.SQL != "" && !preset.IsReadOnly() {
+		problems = append(problems, "preset SQL must be a single SELECT statement without data- or schema-modifying keywords")
+	}
+
+	declared := make(map[string]bool, len(preset.Params))
+	for _, param := range preset.Params {
+		if param.Name == "" {
+			continue
+		}
+		if declared[param.Name] {
+			problems = append(problems, fmt.Sprintf("param %s declared twice", param.Name))
+		}
+		declared[param.Name] = true
+		if param.Required && param.Default != "" {
+			warnings = append(warnings, fmt.Sprintf("param %s is required, so its default is never used", param.Name))
+		}
+	}
+
+	sql := sqlCommentRegex.ReplaceAllString(preset.SQL, " ")
+	used := make(map[string]bool)
+	for _, match := range paramRegex.FindAllStringSubmatch(sql, -1) {
+		name := match[1]
+		if used[name] {
+			continue
+		}
+		used[name] = true
+		if !declared[name] && name != constants.CollationParam {
+			problems = append(problems, fmt.Sprintf("SQL uses undeclared param :%s, which is always NULL", name))
+		}
+	}
+	for _, param := range preset.Params {
+		if param.Name != "" && !used[param.Name] && !slices.Contains(preset.TopicParams, param.Name) {
+			warnings = append(warnings, fmt.Sprintf("param %s is declared but not used in the SQL", param.Name))
+		}
+	}
+
+	return problems, warnings
+}
+
+// ExplainPreset compiles a preset on a query-only connection to mainPath
+// (empty for federated presets) with sources attached, and reports its
+// result columns, query plan and cost. No rows are read.
+func ExplainPreset(ctx context.Context, preset *Preset, params map[string]string, mainPath string, sources []FederatedSource) (*ExplainResult, error) {
+	db, conn, err := openReadOnly(ctx, mainPath, sources)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	defer conn.Close()
+
+	query, args := BuildQuery(preset.SQL, params)
+	result := &ExplainResult{Columns: []ColumnInfo{}, Plan: []PlanStep{}}
+
+	// Compiled but never stepped, so only the column metadata is produced
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query compilation failed: %w", err)
+	}
+	types, err := rows.ColumnTypes()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	for _, ct := range types {
+		result.Columns = append(result.Columns, ColumnInfo{Name: ct.Name(), Type: ct.DatabaseTypeName()})
+	}
+
+	plan, err := conn.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("explain query plan failed: %w", err)
+	}
+	for plan.Next() {
+		var step PlanStep
+		var notUsed int
+		if err := plan.Scan(&step.ID, &step.Parent, &notUsed, &step.Detail); err != nil {
+			plan.Close()
+			return nil, fmt.Errorf("failed to scan query plan: %w", err)
+		}
+		result.Plan = append(result.Plan, step)
+
+		switch {
+		case strings.HasPrefix(step.Detail, "SCAN ") && !strings.HasPrefix(step.Detail, "SCAN CONSTANT ROW"):
+			result.Cost.FullScans++
+		case strings.HasPrefix(step.Detail, "SEARCH "):
+			result.Cost.IndexSearches++
+		case strings.Contains(step.Detail, "TEMP B-TREE"):
+			result.Cost.TempBTrees++
+		}
+	}
+	plan.Close()
+	if err := plan.Err(); err != nil {
+		return nil, fmt.Errorf("explain query plan failed: %w", err)
+	}
+
+	program, err := conn.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("explain failed: %w", err)
+	}
+	for program.Next() {
+		result.Cost.Opcodes++
+	}
+	program.Close()
+	if err := program.Err(); err != nil {
+		return nil, fmt.Errorf("explain failed: %w", err)
+	}
+
+	return result, nil
+}
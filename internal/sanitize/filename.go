@@ -87,6 +87,48 @@ func Extension(raw string) string {
 	return result
 }
 
+// RelativePath sanitizes a folder-relative file path such as a browser's
+// webkitRelativePath. Backslashes are treated as separators, empty and "."
+// segments are dropped and every remaining segment is sanitized like a
+// filename. Returns an empty string if the path contains a ".." segment,
+// exceeds the length or depth limits, or is empty after sanitization.
+func RelativePath(raw string) string {
+	if raw == "" || strings.Contains(raw, "\x00") || len(raw) > constants.MaxRelativePathLength {
+		return ""
+	}
+
+	segments := strings.Split(strings.ReplaceAll(raw, "\\", "/"), "/")
+	clean := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		switch strings.TrimSpace(segment) {
+		case "", ".":
+			continue
+		case "..":
+			return ""
+		}
+		s := strings.TrimSpace(Filename(segment))
+		if s == "" {
+			return ""
+		}
+		clean = append(clean, s)
+	}
+
+	if len(clean) == 0 || len(clean) > constants.MaxRelativePathDepth {
+		return ""
+	}
+	return strings.Join(clean, "/")
+}
+
+// RelativeDir returns the sanitized directory portion of a relative path,
+// or an empty string when the path has no directory or is invalid.
+func RelativeDir(raw string) string {
+	p := RelativePath(raw)
+	if i := strings.LastIndex(p, "/"); i > 0 {
+		return p[:i]
+	}
+	return ""
+}
+
 // ContentDispositionFilename sanitizes a filename for safe use in HTTP
 // Content-Disposition headers. It applies full filename sanitization and
 // additionally strips characters that could cause header injection.
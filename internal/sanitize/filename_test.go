@@ -230,6 +230,50 @@ func TestIsPathTraversal(t *testing.T) {
 	}
 }
 
+func TestRelativePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"nested", "textures/wood/oak.png", "textures/wood/oak.png"},
+		{"windows_separators", "textures\\wood\\oak.png", "textures/wood/oak.png"},
+		{"leading_slash", "/textures/oak.png", "textures/oak.png"},
+		{"dot_segments", "./textures/./oak.png", "textures/oak.png"},
+		{"double_slash", "textures//oak.png", "textures/oak.png"},
+		{"illegal_chars", "my:folder/oak.png", "my_folder/oak.png"},
+		{"file_only", "oak.png", "oak.png"},
+		{"traversal", "../etc/passwd", ""},
+		{"inner_traversal", "textures/../../oak.png", ""},
+		{"null_byte", "textures/oak\x00.png", ""},
+		{"only_dots", "./.", ""},
+		{"empty", "", ""},
+		{"too_deep", strings.Repeat("a/", constants.MaxRelativePathDepth) + "oak.png", ""},
+		{"too_long", strings.Repeat("a", constants.MaxRelativePathLength+1), ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := RelativePath(tc.input)
+			if result != tc.expected {
+				t.Errorf("RelativePath(%q) = %q, want %q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRelativeDir(t *testing.T) {
+	if got := RelativeDir("textures/wood/oak.png"); got != "textures/wood" {
+		t.Errorf("RelativeDir = %q, want textures/wood", got)
+	}
+	if got := RelativeDir("oak.png"); got != "" {
+		t.Errorf("RelativeDir of a bare file = %q, want empty", got)
+	}
+	if got := RelativeDir("../oak.png"); got != "" {
+		t.Errorf("RelativeDir of a traversal = %q, want empty", got)
+	}
+}
+
 // TestFilename_SecurityPayloads tests with real-world attack payloads
 func TestFilename_SecurityPayloads(t *testing.T) {
 	payloads := []string{
@@ -0,0 +1,148 @@
+package seed
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/server"
+)
+
+// newSeedTestApp creates an app with an initialized working directory in a temp dir.
+func newSeedTestApp(t *testing.T) *server.App {
+	t.Helper()
+	workDir := t.TempDir()
+
+	cfg := &config.Config{
+		WorkingDirectory: workDir,
+		MaxDatSize:       constants.DefaultMaxDatSize,
+	}
+	cfg.ApplyDefaults()
+
+	if err := config.InitializeWorkingDirectory(workDir); err != nil {
+		t.Fatalf("failed to initialize working directory: %v", err)
+	}
+
+	app := server.NewApp(cfg, logger.NewLogger(logger.LevelError))
+	orchDB, err := database.InitOrchestratorDB(filepath.Join(workDir, constants.InternalDir, constants.OrchestratorDB))
+	if err != nil {
+		t.Fatalf("failed to open orchestrator db: %v", err)
+	}
+	app.SetOrchestratorDB(orchDB)
+	app.ReinitServices()
+
+	t.Cleanup(func() {
+		app.CloseAllTopicDBs()
+		orchDB.Close()
+	})
+	return app
+}
+
+// assetHashes returns all asset IDs in the orchestrator index, in insertion order.
+func assetHashes(t *testing.T, app *server.App) []string {
+	t.Helper()
+	rows, err := app.OrchestratorDB.Query("SELECT hash FROM asset_index ORDER BY rowid")
+	if err != nil {
+		t.Fatalf("query asset_index: %v", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes
+}
+
+func TestTopicNames(t *testing.T) {
+	names := TopicNames(7)
+	if names[0] != "seed-models" || names[5] != "seed-models-2" || names[6] != "seed-textures-2" {
+		t.Errorf("unexpected topic names: %v", names)
+	}
+}
+
+func TestRun_Deterministic(t *testing.T) {
+	opts := Options{Topics: 3, Assets: 30, Seed: 42, MaxSize: 4096}
+
+	a := newSeedTestApp(t)
+	b := newSeedTestApp(t)
+
+	ra, err := Run(context.Background(), a.Services, opts, a.Logger)
+	if err != nil {
+		t.Fatalf("first run failed: %v", err)
+	}
+	if _, err := Run(context.Background(), b.Services, opts, b.Logger); err != nil {
+		t.Fatalf("second run failed: %v", err)
+	}
+
+	if ra.AssetsCreated != 30 {
+		t.Errorf("expected 30 assets created, got %d", ra.AssetsCreated)
+	}
+
+	ha, hb := assetHashes(t, a), assetHashes(t, b)
+	if len(ha) != len(hb) {
+		t.Fatalf("hash count differs: %d vs %d", len(ha), len(hb))
+	}
+	for i := range ha {
+		if ha[i] != hb[i] {
+			t.Fatalf("hash %d differs between runs with the same seed", i)
+		}
+	}
+}
+
+func TestRun_IdempotentRerun(t *testing.T) {
+	app := newSeedTestApp(t)
+	opts := Options{Topics: 2, Assets: 10, Seed: 7, MaxSize: 2048}
+
+	if _, err := Run(context.Background(), app.Services, opts, app.Logger); err != nil {
+		t.Fatalf("first run failed: %v", err)
+	}
+	result, err := Run(context.Background(), app.Services, opts, app.Logger)
+	if err != nil {
+		t.Fatalf("second run failed: %v", err)
+	}
+	if result.AssetsCreated != 0 || result.AssetsSkipped != 10 {
+		t.Errorf("expected all assets skipped on rerun, got created=%d skipped=%d", result.AssetsCreated, result.AssetsSkipped)
+	}
+}
+
+func TestRun_LineageAndMetadata(t *testing.T) {
+	app := newSeedTestApp(t)
+	result, err := Run(context.Background(), app.Services, Options{
+		Topics: 1, Assets: 40, Seed: 1, MaxSize: 1024, WithMetadata: true,
+	}, app.Logger)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	if result.LineageLinks == 0 {
+		t.Error("expected some lineage links")
+	}
+	if result.MetadataEntries < result.AssetsCreated*5 {
+		t.Errorf("expected at least 5 metadata entries per asset, got %d for %d assets", result.MetadataEntries, result.AssetsCreated)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	if err := (&Options{Topics: 0}).Validate(); err == nil {
+		t.Error("expected error for zero topics")
+	}
+	if err := (&Options{Topics: 1, MinSize: 100, MaxSize: 10}).Validate(); err == nil {
+		t.Error("expected error for min > max")
+	}
+	o := &Options{Topics: 1}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o.MinSize != constants.SeedDefaultMinSize || o.MaxSize != constants.SeedDefaultMaxSize {
+		t.Errorf("defaults not applied: %+v", o)
+	}
+}
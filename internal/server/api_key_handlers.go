@@ -0,0 +1,178 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// API Key Endpoints (expiration and rotation)
+// =============================================================================
+
+// /api/auth/me/api-keys[/{id|primary}[/rotate]] — The caller's own API keys
+func (s *Server) handleAuthMeAPIKeys(w http.ResponseWriter, r *http.Request, sub string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+	s.serveAPIKeys(w, r, identity, identity.User.ID, sub)
+}
+
+// /api/auth/users/{id}/api-keys[/{id|primary}[/rotate]] — Admin: a user's
+// API keys, requires manage_users (edit to change them)
+func (s *Server) handleUserAPIKeys(w http.ResponseWriter, r *http.Request, userID int64, sub string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	ctx := &auth.ActionContext{Action: constants.AuthActionManageUsers}
+	if r.Method != http.MethodGet {
+		ctx.SubAction = "edit"
+	}
+	if !s.authorize(w, identity, ctx) {
+		return
+	}
+
+	s.serveAPIKeys(w, r, identity, userID, sub)
+}
+
+// serveAPIKeys dispatches the API key routes of a user once the caller is
+// authorized: GET/POST on the collection, DELETE on a key and POST on
+// {key}/rotate.
+func (s *Server) serveAPIKeys(w http.ResponseWriter, r *http.Request, identity *auth.Identity, userID int64, sub string) {
+	keyRef, action, _ := strings.Cut(sub, "/")
+	switch {
+	case keyRef == "" && r.Method == http.MethodGet:
+		s.listAPIKeys(w, userID)
+	case keyRef == "" && r.Method == http.MethodPost:
+		s.createAPIKey(w, r, identity, userID)
+	case keyRef != "" && action == "" && r.Method == http.MethodDelete:
+		s.revokeAPIKey(w, r, identity, userID, keyRef)
+	case keyRef != "" && action == "rotate" && r.Method == http.MethodPost:
+		s.rotateAPIKey(w, r, identity, userID, keyRef)
+	case action != "" && action != "rotate":
+		http.NotFound(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) listAPIKeys(w http.ResponseWriter, userID int64) {
+	keys, err := s.app.Services.Auth.ListAPIKeys(userID)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"api_keys": keys,
+	})
+}
+
+func (s *Server) createAPIKey(w http.ResponseWriter, r *http.Request, identity *auth.Identity, userID int64) {
+	var req services.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	resp, err := s.app.Services.Auth.CreateAPIKey(identity, userID, req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionAPIKeyCreated, getClientIP(r), getAuditUsername(identity), audit.APIKeyCreatedDetails{
+			TargetUserID:   userID,
+			TargetUsername: s.apiKeyOwnerName(identity, userID),
+			KeyID:          resp.ID,
+			Label:          resp.Label,
+			KeyPrefix:      resp.KeyPrefix,
+			ExpiresAt:      resp.ExpiresAt,
+		})
+	}
+
+	WriteJSON(w, http.StatusCreated, resp)
+}
+
+// POST .../api-keys/{id|primary}/rotate — Body {grace_secs, ttl_days} is
+// optional.
+func (s *Server) rotateAPIKey(w http.ResponseWriter, r *http.Request, identity *auth.Identity, userID int64, keyRef string) {
+	var req services.RotateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	resp, err := s.app.Services.Auth.RotateAPIKey(identity, userID, keyRef, req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		details := audit.APIKeyRotatedDetails{
+			TargetUserID:   userID,
+			TargetUsername: s.apiKeyOwnerName(identity, userID),
+			Label:          resp.Label,
+			NewKeyPrefix:   resp.KeyPrefix,
+			GraceUntil:     resp.GraceUntil,
+			ExpiresAt:      resp.ExpiresAt,
+		}
+		if resp.Previous != nil {
+			details.OldKeyPrefix = resp.Previous.KeyPrefix
+		}
+		s.app.AuditLogger.Log(constants.AuditActionAPIKeyRotated, getClientIP(r), getAuditUsername(identity), details)
+	}
+
+	if resp.Primary {
+		WriteSuccess(w, struct {
+			*services.APIKeyRotationResponse
+			S3Credentials *s3CredentialsResponse `json:"s3_credentials,omitempty"`
+		}{resp, s.issuedS3Credentials(r, userID)})
+		return
+	}
+	WriteSuccess(w, resp)
+}
+
+func (s *Server) revokeAPIKey(w http.ResponseWriter, r *http.Request, identity *auth.Identity, userID int64, keyRef string) {
+	key, err := s.app.Services.Auth.RevokeAPIKey(identity, userID, keyRef)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionAPIKeyRevoked, getClientIP(r), getAuditUsername(identity), audit.APIKeyRevokedDetails{
+			TargetUserID:   userID,
+			TargetUsername: s.apiKeyOwnerName(identity, userID),
+			KeyID:          key.ID,
+			Label:          key.Label,
+			KeyPrefix:      key.KeyPrefix,
+		})
+	}
+
+	WriteSuccess(w, key)
+}
+
+// apiKeyOwnerName returns the username of the user whose keys are managed,
+// for audit details.
+func (s *Server) apiKeyOwnerName(identity *auth.Identity, userID int64) string {
+	if identity.User.ID == userID {
+		return identity.User.Username
+	}
+	if user, err := s.app.Services.Auth.GetUser(userID); err == nil {
+		return user.Username
+	}
+	return ""
+}
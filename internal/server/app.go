@@ -47,6 +47,11 @@ type App struct {
 	// Global topic creation mutex - serializes topic creation to prevent
 	// filesystem races when concurrent requests create the same topic
 	topicCreateMu sync.Mutex
+
+	// Latest initialization of the working directory (process boot or
+	// POST /api/config); nil until one begins
+	startup   *services.StartupRecorder
+	startupMu sync.RWMutex
 }
 
 // TopicHealth tracks the health status of a topic
@@ -79,6 +84,25 @@ func NewApp(cfg *config.Config, log *logger.Logger) *App {
 // Call this after the orchestrator DB becomes available so that
 // DB-dependent services (like AuthService) can be created.
 func (a *App) ReinitServices() {
+	// Only one delivery loop may run, or notifications are sent twice
+	if a.Services != nil && a.Services.Notification != nil {
+		a.Services.Notification.Stop()
+	}
+	if a.Services != nil && a.Services.Export != nil {
+		a.Services.Export.Stop()
+	}
+	if a.Services != nil && a.Services.Uploads != nil {
+		a.Services.Uploads.Stop()
+	}
+	if a.Services != nil && a.Services.WatchFolders != nil {
+		a.Services.WatchFolders.Stop()
+	}
+	if a.Services != nil && a.Services.Webhooks != nil {
+		a.Services.Webhooks.Stop()
+	}
+	if a.Services != nil && a.Services.Rules != nil {
+		a.Services.Rules.Stop()
+	}
 	a.Services = services.NewServices(a, a.Logger)
 }
 
@@ -261,6 +285,16 @@ func (a *App) GetOrchestratorDB() *sql.DB {
 	return a.OrchestratorDB
 }
 
+// GetAssetIndex returns the asset index of the orchestrator database.
+func (a *App) GetAssetIndex() database.AssetIndex {
+	return database.NewSQLiteAssetIndex(a.OrchestratorDB)
+}
+
+// GetAuditStore returns the audit log of the orchestrator database.
+func (a *App) GetAuditStore() audit.Store {
+	return audit.NewSQLiteStore(a.OrchestratorDB)
+}
+
 // SetOrchestratorDB sets the orchestrator database connection.
 func (a *App) SetOrchestratorDB(db *sql.DB) {
 	a.OrchestratorDB = db
@@ -311,6 +345,20 @@ func (a *App) GetStartedAt() time.Time {
 	return a.StartedAt
 }
 
+// GetStartupRecorder returns the latest initialization record.
+func (a *App) GetStartupRecorder() *services.StartupRecorder {
+	a.startupMu.RLock()
+	defer a.startupMu.RUnlock()
+	return a.startup
+}
+
+// SetStartupRecorder replaces the initialization record.
+func (a *App) SetStartupRecorder(r *services.StartupRecorder) {
+	a.startupMu.Lock()
+	defer a.startupMu.Unlock()
+	a.startup = r
+}
+
 // GetTopicWriteMu returns the write mutex for a topic, creating it lazily.
 // This mutex serializes all write operations (uploads) to a topic to prevent
 // byte offset collisions and duplicate detection races.
@@ -327,9 +375,9 @@ func (a *App) GetTopicWriteMu(topicName string) *sync.Mutex {
 }
 
 // GetTopicCreateMu returns the global topic creation mutex.
-// Topic creation involves filesystem operations (mkdir, DB init) that must be
-// serialized to prevent race conditions when concurrent requests try to create
-// the same topic simultaneously.
+// It serializes registering newly created topics; creators of the same name
+// are kept apart by the orchestrator's topic name reservation, taken before
+// any filesystem work (mkdir, DB init).
 func (a *App) GetTopicCreateMu() *sync.Mutex {
 	return &a.topicCreateMu
 }
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Archive Tier Handlers
+// =============================================================================
+
+// GET /api/topics/:name/archive - List the topic's archived assets
+// (requires query on the topic). ?status=archived|recalling filters.
+//
+// POST /api/topics/:name/archive - Archive the topic's idle assets now
+// (requires manage_topics delete on the topic). Body {dry_run} is optional;
+// a dry run only reports the assets that would be archived.
+func (s *Server) handleTopicArchive(w http.ResponseWriter, r *http.Request, topicName string) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listTopicArchive(w, r, topicName)
+	case http.MethodPost:
+		s.archiveTopic(w, r, topicName)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) listTopicArchive(w http.ResponseWriter, r *http.Request, topicName string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionQuery, TopicName: topicName}) {
+		return
+	}
+
+	archived, err := s.app.Services.Archive.List(topicName, r.URL.Query().Get("status"))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"topic":  topicName,
+		"assets": archived,
+	})
+}
+
+func (s *Server) archiveTopic(w http.ResponseWriter, r *http.Request, topicName string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageTopics,
+		SubAction: "delete",
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	var req struct {
+		DryRun bool `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	result, err := s.app.Services.Archive.Archive(topicName, req.DryRun, getAuditUsername(identity), getClientIP(r))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, result)
+}
+
+// POST /api/assets/:hash/recall - Bring an archived asset back into its
+// topic (requires download on the topic). The asset is written back right
+// away when the archive has it ready; otherwise the status stays recalling
+// and the recall is retried until it is.
+func (s *Server) recallAsset(w http.ResponseWriter, r *http.Request, hash string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	info, err := s.app.Services.Asset.GetInfo(hash)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionDownload, TopicName: info.TopicName}) {
+		return
+	}
+
+	result, err := s.app.Services.Archive.Recall(hash, getAuditUsername(identity), getClientIP(r))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, result)
+}
@@ -34,12 +34,114 @@ func (s *Server) handleAuditQuery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Determine CanViewAll from the matched grant's constraints.
-	// Bootstrap users (admins) have no grant constraints — they always get full access.
-	canViewAll := identity.User.IsBootstrap
-	if !canViewAll && result.MatchedGrant != nil {
-		canViewAll = extractCanViewAll(result.MatchedGrant)
+	opts, ok := s.parseAuditQueryOptions(w, r, identity, result.MatchedGrant)
+	if !ok {
+		return
+	}
+
+	// Include entries still waiting in the write queue
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Flush()
+	}
+
+	entries, err := s.app.GetAuditStore().Query(opts)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error(),
+			constants.ErrCodeAuditLogError)
+		return
+	}
+
+	total, _ := s.app.GetAuditStore().Count(opts)
+
+	// Default limit if not specified
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = constants.AuditDefaultQueryLimit
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"entries": entries,
+		"total":   total,
+		"limit":   limit,
+		"offset":  opts.Offset,
+	})
+}
+
+// handleAuditExport handles GET /api/audit/export - Stream every entry
+// matching the /api/audit filters (limit and offset aside), oldest first, as
+// ?format=csv or jsonl, for archiving outside the orchestrator DB.
+func (s *Server) handleAuditExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	result, ok := s.authorizeWithResult(w, identity, &auth.ActionContext{Action: constants.AuthActionViewAudit})
+	if !ok {
+		return
+	}
+
+	if s.app.OrchestratorDB == nil {
+		WriteError(w, http.StatusBadRequest, "Not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = constants.AuditExportFormatJSONL
+	}
+	if !audit.IsValidExportFormat(format) {
+		WriteError(w, http.StatusBadRequest, "Invalid format. Must be: csv or jsonl",
+			constants.ErrCodeAuditInvalidFilter)
+		return
+	}
+
+	opts, ok := s.parseAuditQueryOptions(w, r, identity, result.MatchedGrant)
+	if !ok {
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Flush()
+	}
+
+	contentType := constants.AuditExportJSONLMIME
+	if format == constants.AuditExportFormatCSV {
+		contentType = constants.AuditExportCSVMIME
 	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"audit-%d.%s\"", time.Now().Unix(), format))
+	w.WriteHeader(http.StatusOK)
+
+	// The status is sent, so a failure can only cut the stream short
+	written, err := audit.Export(s.app.OrchestratorDB, opts, format, w)
+	if err != nil {
+		s.logger.Error("Audit export failed after %d entries: %v", written, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionAuditExported, getClientIP(r), getAuditUsername(identity), audit.AuditExportedDetails{
+			Format:  format,
+			Entries: written,
+			Action:  opts.Action,
+			Since:   opts.Since,
+			Until:   opts.Until,
+		})
+	}
+}
+
+// parseAuditQueryOptions reads the /api/audit filters from the query string,
+// narrowing filter=others|all to the caller's own entries without
+// can_view_all. Writes a 400 and returns false on an invalid filter.
+func (s *Server) parseAuditQueryOptions(w http.ResponseWriter, r *http.Request, identity *auth.Identity, matchedGrant *auth.Grant) (audit.QueryOptions, bool) {
+	// Determine CanViewAll from the matched grant's constraints.
+	canViewAll := auth.CanViewAllAudit(identity, matchedGrant)
 
 	// Get requesting client IP and username for filter support
 	clientIP := getClientIP(r)
@@ -60,19 +162,28 @@ func (s *Server) handleAuditQuery(w http.ResponseWriter, r *http.Request) {
 		if !audit.IsValidAction(action) {
 			WriteError(w, http.StatusBadRequest, "Invalid action type",
 				constants.ErrCodeAuditInvalidAction)
-			return
+			return opts, false
 		}
 		opts.Action = action
 	}
 	opts.IPAddress = r.URL.Query().Get("ip")
 	opts.Username = r.URL.Query().Get("username")
 
+	if actorType := r.URL.Query().Get("actor_type"); actorType != "" {
+		if !audit.IsValidActorType(actorType) {
+			WriteError(w, http.StatusBadRequest, "Invalid actor_type. Must be: user, service, pipeline, or system",
+				constants.ErrCodeAuditInvalidFilter)
+			return opts, false
+		}
+		opts.ActorType = actorType
+	}
+
 	// Parse filter parameter for ME/OTHERS filtering
 	if filter := r.URL.Query().Get("filter"); filter != "" {
 		if !audit.IsValidFilter(filter) {
 			WriteError(w, http.StatusBadRequest, "Invalid filter. Must be: me, others, or empty",
 				constants.ErrCodeAuditInvalidFilter)
-			return
+			return opts, false
 		}
 		opts.Filter = filter
 	}
@@ -93,27 +204,7 @@ func (s *Server) handleAuditQuery(w http.ResponseWriter, r *http.Request) {
 		opts.Until, _ = strconv.ParseInt(until, 10, 64)
 	}
 
-	entries, err := audit.Query(s.app.OrchestratorDB, opts)
-	if err != nil {
-		WriteError(w, http.StatusInternalServerError, err.Error(),
-			constants.ErrCodeAuditLogError)
-		return
-	}
-
-	total, _ := audit.Count(s.app.OrchestratorDB, opts)
-
-	// Default limit if not specified
-	limit := opts.Limit
-	if limit <= 0 {
-		limit = constants.AuditDefaultQueryLimit
-	}
-
-	WriteSuccess(w, map[string]interface{}{
-		"entries": entries,
-		"total":   total,
-		"limit":   limit,
-		"offset":  opts.Offset,
-	})
+	return opts, true
 }
 
 // handleAuditStream handles GET /api/audit/stream - SSE stream of new audit entries
@@ -143,10 +234,7 @@ func (s *Server) handleAuditStream(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Determine CanViewAll from the matched grant's constraints.
-	canViewAll := identity.User.IsBootstrap
-	if !canViewAll && result.MatchedGrant != nil {
-		canViewAll = extractCanViewAll(result.MatchedGrant)
-	}
+	canViewAll := auth.CanViewAllAudit(identity, result.MatchedGrant)
 
 	// Get client IP and parse filter
 	clientIP := getClientIP(r)
@@ -166,6 +254,12 @@ func (s *Server) handleAuditStream(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	release := s.acquireSSE(w, r, constants.SSEEndpointAuditStream, identity)
+	if release == nil {
+		return
+	}
+	defer release()
+
 	sse, err := NewSSEWriter(w)
 	if err != nil {
 		WriteError(w, http.StatusInternalServerError, "Streaming not supported",
@@ -238,37 +332,32 @@ func (s *Server) handleAuditStream(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleAuditActions handles GET /api/audit/actions - List valid action types
-func (s *Server) handleAuditActions(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	identity := s.requireAuth(w, r)
-	if identity == nil {
-		return
-	}
-
-	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionViewAudit}) {
-		return
-	}
-
+func (s *Server) handleAuditActions(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
 	WriteSuccess(w, map[string]interface{}{
 		"actions": audit.ValidActions(),
 	})
 }
 
-// extractCanViewAll parses ViewAuditConstraints from a grant and returns the CanViewAll value.
-// Returns false if constraints are missing or malformed (fail-closed).
-func extractCanViewAll(grant *auth.Grant) bool {
-	if grant.ConstraintsJSON == nil {
-		// No constraints = unrestricted access (grant was created without constraints)
-		return true
+// handleAuditVerify handles GET /api/audit/verify - Walk the audit hash
+// chain and report the first broken link
+func (s *Server) handleAuditVerify(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if s.app.OrchestratorDB == nil {
+		WriteError(w, http.StatusBadRequest, "Not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	// Include entries still waiting in the write queue
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Flush()
 	}
 
-	var c auth.ViewAuditConstraints
-	if err := json.Unmarshal([]byte(*grant.ConstraintsJSON), &c); err != nil {
-		return false // Fail-closed: malformed constraints deny broad access
+	report, err := audit.VerifyChain(s.app.OrchestratorDB)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error(), constants.ErrCodeAuditLogError)
+		return
+	}
+	if !report.Intact {
+		s.logger.Warn("Audit chain broken at entry %d: %s", report.Broken.ID, report.Broken.Reason)
 	}
-	return c.CanViewAll
+	WriteSuccess(w, report)
 }
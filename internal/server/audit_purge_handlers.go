@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// createHoldRequest is the body of POST /api/audit/holds
+type createHoldRequest struct {
+	Name     string  `json:"name"`
+	Reason   string  `json:"reason"`
+	Username *string `json:"username"`
+	Action   *string `json:"action"`
+	Since    *int64  `json:"since"`
+	Until    *int64  `json:"until"`
+}
+
+// GET /api/audit/purge/preview - Report what a purge would remove now
+func (s *Server) handleAuditPurgePreview(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if s.app.AuditLogger == nil {
+		WriteError(w, http.StatusBadRequest, "Audit logging not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	report, err := s.app.AuditLogger.PreviewPurge()
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error(), constants.ErrCodeAuditLogError)
+		return
+	}
+
+	WriteSuccess(w, report)
+}
+
+// POST /api/audit/purge - Run a purge now and record it in the audit log
+func (s *Server) handleAuditPurge(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if s.app.AuditLogger == nil {
+		WriteError(w, http.StatusBadRequest, "Audit logging not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	report, err := s.app.AuditLogger.Purge(constants.AuditPurgeTriggerManual, getClientIP(r), getAuditUsername(identity))
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error(), constants.ErrCodeAuditLogError)
+		return
+	}
+
+	WriteSuccess(w, report)
+}
+
+// GET/POST /api/audit/holds - List or create legal holds
+func (s *Server) handleAuditHolds(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if s.app.OrchestratorDB == nil || s.app.AuditLogger == nil {
+		WriteError(w, http.StatusBadRequest, "Not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		holds, err := audit.ListHolds(s.app.OrchestratorDB, r.URL.Query().Get("include_released") == "true")
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err.Error(), constants.ErrCodeAuditLogError)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"holds": holds,
+		})
+		return
+	}
+
+	var req createHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	hold := audit.LegalHold{
+		Name:      strings.TrimSpace(req.Name),
+		Reason:    req.Reason,
+		Username:  req.Username,
+		Action:    req.Action,
+		Since:     req.Since,
+		Until:     req.Until,
+		CreatedBy: getAuditUsername(identity),
+	}
+	if err := hold.Validate(); err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), constants.ErrCodeAuditInvalidHold)
+		return
+	}
+
+	created, err := audit.CreateHold(s.app.OrchestratorDB, hold)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error(), constants.ErrCodeAuditLogError)
+		return
+	}
+
+	s.app.AuditLogger.Log(constants.AuditActionAuditHoldCreated, getClientIP(r), getAuditUsername(identity), audit.AuditHoldCreatedDetails{
+		HoldID:   created.ID,
+		Name:     created.Name,
+		Username: created.Username,
+		Action:   created.Action,
+		Since:    created.Since,
+		Until:    created.Until,
+	})
+
+	WriteSuccess(w, created)
+}
+
+// DELETE /api/audit/holds/:id - Release a legal hold
+func (s *Server) handleAuditHoldRelease(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if s.app.OrchestratorDB == nil || s.app.AuditLogger == nil {
+		WriteError(w, http.StatusBadRequest, "Not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	holdID, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/audit/holds/"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid hold ID", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	released, err := audit.ReleaseHold(s.app.OrchestratorDB, holdID, getAuditUsername(identity))
+	if errors.Is(err, audit.ErrHoldNotFound) {
+		WriteError(w, http.StatusNotFound, err.Error(), constants.ErrCodeAuditHoldNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error(), constants.ErrCodeAuditLogError)
+		return
+	}
+
+	s.app.AuditLogger.Log(constants.AuditActionAuditHoldReleased, getClientIP(r), getAuditUsername(identity), audit.AuditHoldReleasedDetails{
+		HoldID: released.ID,
+		Name:   released.Name,
+	})
+
+	WriteSuccess(w, released)
+}
@@ -2,10 +2,14 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
+	"gopkg.in/yaml.v3"
 	"silobang/internal/audit"
 	"silobang/internal/auth"
 	"silobang/internal/constants"
@@ -21,12 +25,50 @@ import (
 func (s *Server) requireAuth(w http.ResponseWriter, r *http.Request) *auth.Identity {
 	identity, ok := auth.RequireAuth(r)
 	if !ok {
+		if auth.GetAuthFailure(r) == constants.ErrCodeAuthAPIKeyExpired {
+			WriteError(w, http.StatusUnauthorized, "API key expired; rotate it or request a new one", constants.ErrCodeAuthAPIKeyExpired)
+			return nil
+		}
 		WriteError(w, http.StatusUnauthorized, "Authentication required", constants.ErrCodeAuthRequired)
 		return nil
 	}
+	s.sampleAPIKeyUsage(r, identity)
+	setAPIKeyExpiryHeader(w, identity)
 	return identity
 }
 
+// setAPIKeyExpiryHeader tells clients authenticated with an expiring API key
+// when it expires, so they can rotate it ahead of time.
+func setAPIKeyExpiryHeader(w http.ResponseWriter, identity *auth.Identity) {
+	if identity.APIKeyExpiresAt != nil {
+		w.Header().Set(constants.HeaderAPIKeyExpiresAt, strconv.FormatInt(*identity.APIKeyExpiresAt, 10))
+	}
+}
+
+// sampleAPIKeyUsage feeds requests authenticated with an API key to the
+// usage samples shown in the user's activity timeline.
+func (s *Server) sampleAPIKeyUsage(r *http.Request, identity *auth.Identity) {
+	if identity.Method == constants.AuthMethodAPIKey && s.app.Services.Auth != nil {
+		s.app.Services.Auth.SampleAPIKeyUsage(identity.User.ID, getClientIP(r), r.UserAgent(), r.Method, r.URL.Path)
+	}
+}
+
+// requireAuthOrPublic is requireAuth for read-only endpoints that public mode
+// exposes. Unauthenticated requests get the anonymous identity, whose grants
+// are limited by the public config, instead of a 401.
+func (s *Server) requireAuthOrPublic(w http.ResponseWriter, r *http.Request) *auth.Identity {
+	if identity, ok := auth.RequireAuth(r); ok {
+		s.sampleAPIKeyUsage(r, identity)
+		setAPIKeyExpiryHeader(w, identity)
+		return identity
+	}
+	// An expired key is reported rather than downgraded to anonymous access
+	if cfg := s.app.Config; cfg != nil && cfg.Public.Enabled && auth.GetAuthFailure(r) == "" {
+		return auth.NewAnonymousIdentity(cfg.Public.AllowedPresets, cfg.Public.MaxDownloadBytes)
+	}
+	return s.requireAuth(w, r)
+}
+
 // authorize evaluates a policy for the given identity and action context.
 // Returns true if allowed, writes the appropriate error response and returns false if denied.
 func (s *Server) authorize(w http.ResponseWriter, identity *auth.Identity, ctx *auth.ActionContext) bool {
@@ -56,6 +98,14 @@ func (s *Server) authorizeWithResult(w http.ResponseWriter, identity *auth.Ident
 	return result, true
 }
 
+// allowedFor reports whether the identity may perform an action, for
+// services that check actions on resources the request only selects.
+func (s *Server) allowedFor(identity *auth.Identity) func(*auth.ActionContext) bool {
+	return func(ctx *auth.ActionContext) bool {
+		return s.app.Services.Auth.GetEvaluator().Evaluate(identity, ctx).Allowed
+	}
+}
+
 // isAuthAvailable returns true if the auth system is initialized.
 // When false, auth endpoints should return 503.
 func (s *Server) isAuthAvailable() bool {
@@ -126,7 +176,8 @@ func (s *Server) handleAuthLogin(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GET /api/auth/status — Check whether the system is bootstrapped
+// GET /api/auth/status — Check whether the system is bootstrapped and
+// whether single sign-on is available
 func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -137,6 +188,7 @@ func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
 		WriteSuccess(w, map[string]interface{}{
 			"bootstrapped": false,
 			"configured":   false,
+			"sso":          map[string]interface{}{"enabled": false},
 		})
 		return
 	}
@@ -147,12 +199,76 @@ func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Lets the login page offer single sign-on
+	sso := map[string]interface{}{"enabled": false}
+	if s.app.Services.Auth.SSOEnabled() {
+		sso = map[string]interface{}{"enabled": true, "login_url": constants.OIDCLoginPath}
+	}
+
 	WriteSuccess(w, map[string]interface{}{
 		"bootstrapped": bootstrapped,
 		"configured":   true,
+		"sso":          sso,
 	})
 }
 
+// POST /api/auth/recover — Exchange a break-glass recovery token (issued by
+// "silobang recover-admin") for new bootstrap admin credentials
+func (s *Server) handleAuthRecover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.isAuthAvailable() {
+		WriteError(w, http.StatusServiceUnavailable, "Auth system not available", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if req.Token == "" {
+		WriteError(w, http.StatusBadRequest, "Recovery token is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	tokenPrefix := auth.ExtractTokenPrefix(req.Token)
+
+	result, err := s.app.Services.Auth.RecoverAdmin(req.Token, getClientIP(r))
+	if err != nil {
+		if s.app.AuditLogger != nil {
+			reason := "unknown"
+			if code, ok := services.IsServiceError(err); ok {
+				reason = code
+			}
+			s.app.AuditLogger.Log(constants.AuditActionAdminRecoveryFailed, getClientIP(r), "", audit.AdminRecoveryFailedDetails{
+				TokenPrefix: tokenPrefix,
+				Reason:      reason,
+				UserAgent:   r.UserAgent(),
+			})
+		}
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionAdminRecovered, getClientIP(r), result.Username, audit.AdminRecoveredDetails{
+			TokenPrefix:    tokenPrefix,
+			TargetUserID:   result.UserID,
+			TargetUsername: result.Username,
+			UserAgent:      r.UserAgent(),
+		})
+	}
+
+	WriteSuccess(w, result)
+}
+
 // =============================================================================
 // Protected Auth Endpoints
 // =============================================================================
@@ -235,6 +351,37 @@ func (s *Server) handleAuthMeQuota(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GET /api/auth/me/capabilities — What the current user may do, evaluated
+// server-side against existing topics and presets
+func (s *Server) handleAuthMeCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.isAuthAvailable() {
+		WriteError(w, http.StatusServiceUnavailable, "Auth system not available", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	var presets []string
+	if infos, err := s.app.Services.Query.ListPresets(); err == nil {
+		for _, info := range infos {
+			presets = append(presets, info.Name)
+		}
+	}
+	sort.Strings(presets)
+
+	caps := s.app.Services.Auth.GetEvaluator().Capabilities(identity, s.app.ListTopics(), presets)
+	caps.Endpoints = s.allowedEndpoints(identity)
+	WriteSuccess(w, caps)
+}
+
 // =============================================================================
 // User Management Endpoints (requires manage_users grant)
 // =============================================================================
@@ -305,7 +452,10 @@ func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	WriteJSON(w, http.StatusCreated, resp)
+	WriteJSON(w, http.StatusCreated, struct {
+		*services.CreateUserResponse
+		S3Credentials *s3CredentialsResponse `json:"s3_credentials,omitempty"`
+	}{resp, s.issuedS3Credentials(r, resp.User.ID)})
 }
 
 // /api/auth/users/{id} — GET or PATCH
@@ -438,11 +588,190 @@ func (s *Server) handleRegenerateAPIKey(w http.ResponseWriter, r *http.Request,
 		})
 	}
 
-	WriteSuccess(w, map[string]interface{}{
+	response := map[string]interface{}{
 		"api_key": apiKey,
+	}
+	if creds := s.issuedS3Credentials(r, userID); creds != nil {
+		response["s3_credentials"] = creds
+	}
+	WriteSuccess(w, response)
+}
+
+// =============================================================================
+// Service Account Endpoints (requires manage_users grant)
+// =============================================================================
+
+// /api/auth/service-accounts — GET (list) or POST (create)
+func (s *Server) handleServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listServiceAccounts(w, r)
+	case http.MethodPost:
+		s.createServiceAccount(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) listServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageUsers}) {
+		return
+	}
+
+	accounts, err := s.app.Services.Auth.ListServiceAccounts()
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"service_accounts": accounts,
+	})
+}
+
+func (s *Server) createServiceAccount(w http.ResponseWriter, r *http.Request) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageUsers,
+		SubAction: "create",
+	}) {
+		return
+	}
+
+	var req services.CreateServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	resp, err := s.app.Services.Auth.CreateServiceAccount(identity, req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		actions := make([]string, 0, len(resp.Scopes))
+		for _, g := range resp.Scopes {
+			actions = append(actions, g.Action)
+		}
+		s.app.AuditLogger.Log(constants.AuditActionServiceAccountCreated, getClientIP(r), getAuditUsername(identity), audit.ServiceAccountCreatedDetails{
+			AccountID:   resp.Account.ID,
+			AccountName: resp.Account.Username,
+			Actions:     actions,
+		})
+	}
+
+	WriteJSON(w, http.StatusCreated, resp)
+}
+
+// /api/auth/service-accounts/{id} — GET or DELETE (disable)
+func (s *Server) handleServiceAccountByID(w http.ResponseWriter, r *http.Request, accountID int64) {
+	switch r.Method {
+	case http.MethodGet:
+		s.getServiceAccount(w, r, accountID)
+	case http.MethodDelete:
+		s.disableServiceAccount(w, r, accountID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) getServiceAccount(w http.ResponseWriter, r *http.Request, accountID int64) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageUsers}) {
+		return
+	}
+
+	resp, err := s.app.Services.Auth.GetServiceAccount(accountID)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, resp)
+}
+
+func (s *Server) disableServiceAccount(w http.ResponseWriter, r *http.Request, accountID int64) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageUsers,
+		SubAction: "disable",
+	}) {
+		return
+	}
+
+	account, err := s.app.Services.Auth.DisableServiceAccount(identity, accountID)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionServiceAccountDisabled, getClientIP(r), getAuditUsername(identity), audit.ServiceAccountDisabledDetails{
+			AccountID:   account.ID,
+			AccountName: account.Username,
+		})
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"success": true,
 	})
 }
 
+// POST /api/auth/service-accounts/{id}/rotate — Replace the account's token
+func (s *Server) handleRotateServiceAccountToken(w http.ResponseWriter, r *http.Request, accountID int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageUsers,
+		SubAction: "edit",
+	}) {
+		return
+	}
+
+	resp, err := s.app.Services.Auth.RotateServiceAccountToken(identity, accountID)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionServiceAccountRotated, getClientIP(r), getAuditUsername(identity), audit.ServiceAccountRotatedDetails{
+			AccountID:   resp.Account.ID,
+			AccountName: resp.Account.Username,
+			TokenPrefix: resp.TokenPrefix,
+		})
+	}
+
+	WriteSuccess(w, resp)
+}
+
 // =============================================================================
 // Grant Management Endpoints (requires manage_users grant)
 // =============================================================================
@@ -608,6 +937,125 @@ func (s *Server) revokeGrant(w http.ResponseWriter, r *http.Request, grantID int
 	})
 }
 
+// POST /api/auth/grants/batch — Create many grants, across users, in one call
+func (s *Server) handleGrantBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageUsers,
+		SubAction: "create",
+	}) {
+		return
+	}
+
+	var req struct {
+		Grants []services.CreateGrantRequest `json:"grants"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	grants, err := s.app.Services.Auth.CreateGrants(identity, req.Grants)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	s.auditGrantBatch(r, identity, constants.AuthGrantBatchModeList, 0, grants)
+
+	WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"grants": grants,
+		"count":  len(grants),
+	})
+}
+
+// POST /api/auth/users/{id}/grants/copy-from/{src} — Copy another user's
+// active grants, optionally replacing constraints per action
+func (s *Server) handleCopyGrants(w http.ResponseWriter, r *http.Request, userID int64, srcPart string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sourceID, err := strconv.ParseInt(srcPart, 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid source user ID", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageUsers,
+		SubAction: "create",
+	}) {
+		return
+	}
+
+	// Body is optional: {"overrides": {"<action>": "<constraints_json>" | null}}
+	var req struct {
+		Overrides map[string]*string `json:"overrides"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	grants, err := s.app.Services.Auth.CopyGrants(identity, userID, sourceID, req.Overrides)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	s.auditGrantBatch(r, identity, constants.AuthGrantBatchModeCopy, sourceID, grants)
+
+	WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"grants": grants,
+		"count":  len(grants),
+	})
+}
+
+// auditGrantBatch records all grants created by one request as a single
+// grant_batch entry.
+func (s *Server) auditGrantBatch(r *http.Request, identity *auth.Identity, mode string, sourceUserID int64, grants []auth.Grant) {
+	if s.app.AuditLogger == nil {
+		return
+	}
+
+	details := audit.GrantBatchDetails{
+		Mode:         mode,
+		SourceUserID: sourceUserID,
+		Count:        len(grants),
+	}
+	seenUser := make(map[int64]bool)
+	seenAction := make(map[string]bool)
+	for _, g := range grants {
+		details.GrantIDs = append(details.GrantIDs, g.ID)
+		if !seenUser[g.UserID] {
+			seenUser[g.UserID] = true
+			details.TargetUserIDs = append(details.TargetUserIDs, g.UserID)
+		}
+		if !seenAction[g.Action] {
+			seenAction[g.Action] = true
+			details.Actions = append(details.Actions, g.Action)
+		}
+	}
+
+	s.app.AuditLogger.Log(constants.AuditActionGrantBatch, getClientIP(r), getAuditUsername(identity), details)
+}
+
 // =============================================================================
 // Quota Endpoints
 // =============================================================================
@@ -640,6 +1088,243 @@ func (s *Server) handleUserQuota(w http.ResponseWriter, r *http.Request, userID
 	})
 }
 
+// =============================================================================
+// Activity Timeline Endpoint
+// =============================================================================
+
+// GET /api/auth/users/{id}/activity — Admin: chronological timeline of a
+// user's sessions, logins, sampled API-key usage and audited actions.
+// Query params: limit, offset, since, until (unix seconds), kind.
+func (s *Server) handleUserActivity(w http.ResponseWriter, r *http.Request, userID int64) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageUsers}) {
+		return
+	}
+
+	// The timeline exposes audit entries, so audit visibility rules apply too
+	result, ok := s.authorizeWithResult(w, identity, &auth.ActionContext{Action: constants.AuthActionViewAudit})
+	if !ok {
+		return
+	}
+	canViewAll := auth.CanViewAllAudit(identity, result.MatchedGrant)
+	if !canViewAll && identity.User.ID != userID {
+		WriteError(w, http.StatusForbidden, "Viewing another user's activity requires view_audit with can_view_all",
+			constants.ErrCodeAuthForbidden)
+		return
+	}
+
+	q := r.URL.Query()
+	var opts auth.ActivityOptions
+	opts.Limit, _ = strconv.Atoi(q.Get("limit"))
+	opts.Offset, _ = strconv.Atoi(q.Get("offset"))
+	opts.Since, _ = strconv.ParseInt(q.Get("since"), 10, 64)
+	opts.Until, _ = strconv.ParseInt(q.Get("until"), 10, 64)
+	opts.Kind = q.Get("kind")
+
+	activity, err := s.app.Services.Auth.GetUserActivity(userID, opts)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, activity)
+}
+
+// =============================================================================
+// API Usage Endpoints
+// =============================================================================
+
+// GET /api/auth/me/usage — The current user's request counts, error rate,
+// bytes transferred and top endpoints. Query param: window (1h, 24h, 7d, 30d).
+func (s *Server) handleAuthMeUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	usage, err := s.app.Services.Auth.GetUserUsage(identity.User.ID, r.URL.Query().Get("window"))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, usage)
+}
+
+// GET /api/auth/users/{id}/usage — Admin: a user's API usage, as
+// /api/auth/me/usage reports it to the user
+func (s *Server) handleUserUsage(w http.ResponseWriter, r *http.Request, userID int64) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageUsers}) {
+		return
+	}
+
+	usage, err := s.app.Services.Auth.GetUserUsage(userID, r.URL.Query().Get("window"))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, usage)
+}
+
+// =============================================================================
+// Auth Snapshot Endpoints
+// =============================================================================
+
+// GET /api/auth/export — Admin: a snapshot of every account but the
+// bootstrap user and its active grants, without secrets. Query param:
+// format (yaml, the default, or json).
+func (s *Server) handleAuthExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageUsers}) {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = constants.AuthSnapshotFormatYAML
+	}
+	if format != constants.AuthSnapshotFormatYAML && format != constants.AuthSnapshotFormatJSON {
+		WriteError(w, http.StatusBadRequest, "format must be yaml or json", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	snap, err := s.app.Services.Auth.ExportSnapshot()
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if format == constants.AuthSnapshotFormatJSON {
+		WriteSuccess(w, snap)
+		return
+	}
+
+	data, err := yaml.Marshal(snap)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "Failed to encode snapshot", constants.ErrCodeInternalError)
+		return
+	}
+	w.Header().Set(constants.HeaderContentType, constants.ContentTypeYAML)
+	w.Write(data)
+}
+
+// POST /api/auth/import — Admin: make accounts and grants match a snapshot
+// (YAML or JSON body). Query params: dry_run=true to only report the
+// changes, prune=true to disable accounts missing from the snapshot.
+func (s *Server) handleAuthImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	q := r.URL.Query()
+	dryRun := q.Get("dry_run") == "true"
+	prune := q.Get("prune") == "true"
+
+	// An import may create, edit and, with prune, disable accounts
+	subActions := []string{"create", "edit"}
+	if prune {
+		subActions = append(subActions, "disable")
+	}
+	for _, sub := range subActions {
+		if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageUsers, SubAction: sub}) {
+			return
+		}
+	}
+
+	// JSON is valid YAML, so one decoder reads both
+	r.Body = http.MaxBytesReader(w, r.Body, constants.AuthSnapshotMaxBytes)
+	var snap auth.Snapshot
+	dec := yaml.NewDecoder(r.Body)
+	dec.KnownFields(true)
+	if err := dec.Decode(&snap); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			WriteError(w, http.StatusRequestEntityTooLarge, "Snapshot too large", constants.ErrCodeInvalidRequest)
+			return
+		}
+		WriteError(w, http.StatusBadRequest, "Invalid snapshot: "+err.Error(), constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	result, err := s.app.Services.Auth.ImportSnapshot(identity, &snap, dryRun, prune)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if !dryRun && len(result.Changes) > 0 {
+		s.auditAuthImport(r, identity, result)
+	}
+
+	WriteSuccess(w, result)
+}
+
+// auditAuthImport records the changes of one snapshot import as a single
+// auth_imported entry.
+func (s *Server) auditAuthImport(r *http.Request, identity *auth.Identity, result *services.SnapshotImportResult) {
+	if s.app.AuditLogger == nil {
+		return
+	}
+
+	details := audit.AuthImportedDetails{Prune: result.Prune}
+	for _, c := range result.Changes {
+		switch c.Type {
+		case constants.AuthSnapshotChangeUserCreated:
+			details.UsersCreated = append(details.UsersCreated, c.Username)
+		case constants.AuthSnapshotChangeUserUpdated:
+			details.UsersUpdated = append(details.UsersUpdated, c.Username)
+		case constants.AuthSnapshotChangeUserDisabled:
+			details.UsersDisabled = append(details.UsersDisabled, c.Username)
+		case constants.AuthSnapshotChangeGrantCreated:
+			details.GrantsCreated++
+		case constants.AuthSnapshotChangeGrantRevoked:
+			details.GrantsRevoked++
+		}
+	}
+
+	s.app.AuditLogger.Log(constants.AuditActionAuthImported, getClientIP(r), getAuditUsername(identity), details)
+}
+
 // =============================================================================
 // Auth Route Dispatcher
 // =============================================================================
@@ -669,6 +1354,22 @@ func (s *Server) handleAuthRoutes(w http.ResponseWriter, r *http.Request) {
 	case remaining == "logout":
 		s.handleAuthLogout(w, r)
 
+	// /api/auth/recover
+	case remaining == "recover":
+		s.handleAuthRecover(w, r)
+
+	// /api/auth/oidc/login
+	case remaining == "oidc/login":
+		s.handleSSOLogin(w, r)
+
+	// /api/auth/oidc/callback
+	case remaining == "oidc/callback":
+		s.handleSSOCallback(w, r)
+
+	// /api/auth/oidc/exchange
+	case remaining == "oidc/exchange":
+		s.handleSSOExchange(w, r)
+
 	// /api/auth/me
 	case remaining == "me":
 		s.handleAuthMe(w, r)
@@ -677,6 +1378,30 @@ func (s *Server) handleAuthRoutes(w http.ResponseWriter, r *http.Request) {
 	case remaining == "me/quota":
 		s.handleAuthMeQuota(w, r)
 
+	// /api/auth/me/usage
+	case remaining == "me/usage":
+		s.handleAuthMeUsage(w, r)
+
+	// /api/auth/me/capabilities
+	case remaining == "me/capabilities":
+		s.handleAuthMeCapabilities(w, r)
+
+	// /api/auth/me/preflight
+	case remaining == "me/preflight":
+		s.handleAuthPreflight(w, r)
+
+	// /api/auth/me/api-keys[/{id|primary}[/rotate]]
+	case remaining == "me/api-keys" || strings.HasPrefix(remaining, "me/api-keys/"):
+		s.handleAuthMeAPIKeys(w, r, strings.TrimPrefix(strings.TrimPrefix(remaining, "me/api-keys"), "/"))
+
+	// /api/auth/export
+	case remaining == "export":
+		s.handleAuthExport(w, r)
+
+	// /api/auth/import
+	case remaining == "import":
+		s.handleAuthImport(w, r)
+
 	// /api/auth/users
 	case remaining == "users":
 		s.handleAuthUsers(w, r)
@@ -685,9 +1410,37 @@ func (s *Server) handleAuthRoutes(w http.ResponseWriter, r *http.Request) {
 	// /api/auth/users/{id}/api-key
 	// /api/auth/users/{id}/grants
 	// /api/auth/users/{id}/quota
+	// /api/auth/users/{id}/activity
+	// /api/auth/users/{id}/usage
+	// /api/auth/users/{id}/grants/copy-from/{src}
 	case strings.HasPrefix(remaining, "users/"):
 		s.routeAuthUserSub(w, r, strings.TrimPrefix(remaining, "users/"))
 
+	// /api/auth/service-accounts
+	case remaining == "service-accounts":
+		s.handleServiceAccounts(w, r)
+
+	// /api/auth/service-accounts/{id}
+	// /api/auth/service-accounts/{id}/rotate
+	case strings.HasPrefix(remaining, "service-accounts/"):
+		s.routeServiceAccountSub(w, r, strings.TrimPrefix(remaining, "service-accounts/"))
+
+	// /api/auth/workspace-tokens
+	case remaining == "workspace-tokens":
+		s.handleWorkspaceTokens(w, r)
+
+	// /api/auth/workspace-tokens/revoke
+	case remaining == "workspace-tokens/revoke":
+		s.handleRevokeWorkspaceTokens(w, r)
+
+	// /api/auth/workspace-tokens/exchange
+	case remaining == "workspace-tokens/exchange":
+		s.handleExchangeWorkspaceToken(w, r)
+
+	// /api/auth/grants/batch
+	case remaining == "grants/batch":
+		s.handleGrantBatch(w, r)
+
 	// /api/auth/grants/{id}
 	case strings.HasPrefix(remaining, "grants/"):
 		s.routeAuthGrantSub(w, r, strings.TrimPrefix(remaining, "grants/"))
@@ -718,6 +1471,16 @@ func (s *Server) routeAuthUserSub(w http.ResponseWriter, r *http.Request, remain
 	}
 
 	subResource := parts[1]
+	if src, ok := strings.CutPrefix(subResource, "grants/copy-from/"); ok {
+		s.handleCopyGrants(w, r, userID, src)
+		return
+	}
+
+	if subResource == "api-keys" || strings.HasPrefix(subResource, "api-keys/") {
+		s.handleUserAPIKeys(w, r, userID, strings.TrimPrefix(strings.TrimPrefix(subResource, "api-keys"), "/"))
+		return
+	}
+
 	switch subResource {
 	case "api-key":
 		s.handleRegenerateAPIKey(w, r, userID)
@@ -725,6 +1488,29 @@ func (s *Server) routeAuthUserSub(w http.ResponseWriter, r *http.Request, remain
 		s.handleUserGrants(w, r, userID)
 	case "quota":
 		s.handleUserQuota(w, r, userID)
+	case "activity":
+		s.handleUserActivity(w, r, userID)
+	case "usage":
+		s.handleUserUsage(w, r, userID)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// routeServiceAccountSub handles /api/auth/service-accounts/{id}[/rotate]
+func (s *Server) routeServiceAccountSub(w http.ResponseWriter, r *http.Request, remaining string) {
+	idPart, sub, _ := strings.Cut(remaining, "/")
+	accountID, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid service account ID", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	switch sub {
+	case "":
+		s.handleServiceAccountByID(w, r, accountID)
+	case "rotate":
+		s.handleRotateServiceAccountToken(w, r, accountID)
 	default:
 		http.NotFound(w, r)
 	}
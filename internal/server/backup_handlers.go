@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Backup Handlers
+// =============================================================================
+
+// POST /api/backup - Download a point-in-time tar archive of the orchestrator
+// database, the topic databases and the .dat files, for "silobang restore"
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	filename := fmt.Sprintf("%s%s.tar", constants.BackupFilenamePrefix, time.Now().UTC().Format("20060102-150405"))
+	bw := &backupResponseWriter{w: w, filename: filename}
+
+	username := getAuditUsername(identity)
+	manifest, err := s.app.Services.Backup.Write(r.Context(), bw, username, getClientIP(r))
+	if err != nil {
+		if !bw.started {
+			s.handleServiceError(w, err)
+			return
+		}
+		// The archive ends without a manifest, so restore rejects it
+		s.logger.Error("Backup by %s failed after %d bytes: %v", username, bw.n, err)
+		return
+	}
+
+	s.logger.Info("Backup of %d topic(s), %d bytes downloaded by %s", len(manifest.Topics), bw.n, username)
+}
+
+// backupResponseWriter sends the attachment headers with the first byte of
+// the archive, so a backup that fails before writing anything can still be
+// answered with a JSON error.
+type backupResponseWriter struct {
+	w        http.ResponseWriter
+	filename string
+	started  bool
+	n        int64
+}
+
+func (b *backupResponseWriter) Write(p []byte) (int, error) {
+	if !b.started {
+		b.started = true
+		b.w.Header().Set(constants.HeaderContentType, constants.BackupContentType)
+		b.w.Header().Set(constants.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, b.filename))
+		b.w.WriteHeader(http.StatusOK)
+	}
+	n, err := b.w.Write(p)
+	b.n += int64(n)
+	return n, err
+}
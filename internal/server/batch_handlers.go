@@ -93,6 +93,15 @@ func (s *Server) handleBatchMetadata(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Every key must fall inside the caller's metadata namespaces
+	keys := make([]string, len(req.Operations))
+	for i, op := range req.Operations {
+		keys[i] = op.Key
+	}
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionMetadata, MetadataKeys: keys}) {
+		return
+	}
+
 	if req.Processor == "" {
 		req.Processor = constants.ProcessorAPI
 	}
@@ -157,6 +166,15 @@ func (s *Server) handleBatchMetadata(w http.ResponseWriter, r *http.Request) {
 	// Group operations by topic
 	grouped, notFound := database.GroupOperationsByTopic(s.app.OrchestratorDB, dbOperations)
 
+	// Reject the whole batch before writing if any topic would be denied
+	if result := s.preflightMetadata(identity, grouped, notFound); !result.Allowed {
+		writePreflightDenied(w, result)
+		return
+	}
+	if s.rejectFrozenTopics(w, grouped) {
+		return
+	}
+
 	s.logger.Info("Batch metadata: %d operations across %d topics, %d not found", len(dbOperations), len(grouped), len(notFound))
 
 	// Execute operations per topic atomically
@@ -164,65 +182,7 @@ func (s *Server) handleBatchMetadata(w http.ResponseWriter, r *http.Request) {
 	allResults = append(allResults, notFound...)
 
 	for _, group := range grouped {
-		s.logger.Debug("Processing batch for topic %s: %d operations", group.Topic, len(group.Operations))
-
-		topicDB, err := s.app.GetTopicDB(group.Topic)
-		if err != nil {
-			s.logger.Warn("Topic DB unavailable for batch: %s", group.Topic)
-			// Topic DB unavailable, mark all as failed
-			for _, op := range group.Operations {
-				allResults = append(allResults, database.BatchOperationResult{
-					Hash:    op.Hash,
-					Success: false,
-					Error:   "topic database unavailable",
-				})
-			}
-			continue
-		}
-
-		// Begin transaction for atomic execution
-		tx, err := topicDB.Begin()
-		if err != nil {
-			s.logger.Error("Failed to begin transaction for topic %s: %v", group.Topic, err)
-			for _, op := range group.Operations {
-				allResults = append(allResults, database.BatchOperationResult{
-					Hash:    op.Hash,
-					Success: false,
-					Error:   "failed to begin transaction",
-				})
-			}
-			continue
-		}
-
-		results, err := database.ExecuteBatchMetadataTx(tx, group.Operations, s.app.Config.Metadata.MaxValueBytes)
-		if err != nil {
-			tx.Rollback()
-			s.logger.Error("Batch execution failed for topic %s: %v", group.Topic, err)
-			for _, op := range group.Operations {
-				allResults = append(allResults, database.BatchOperationResult{
-					Hash:    op.Hash,
-					Success: false,
-					Error:   "batch execution failed: " + err.Error(),
-				})
-			}
-			continue
-		}
-
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			s.logger.Error("Commit failed for topic %s: %v", group.Topic, err)
-			for _, op := range group.Operations {
-				allResults = append(allResults, database.BatchOperationResult{
-					Hash:    op.Hash,
-					Success: false,
-					Error:   "commit failed: " + err.Error(),
-				})
-			}
-			continue
-		}
-
-		s.logger.Debug("Batch completed for topic %s: %d operations", group.Topic, len(results))
-		allResults = append(allResults, results...)
+		allResults = append(allResults, s.applyMetadataOperations(identity, group.Topic, group.Operations)...)
 	}
 
 	// Count successes and failures
@@ -268,6 +228,55 @@ func (s *Server) handleBatchMetadata(w http.ResponseWriter, r *http.Request) {
 	WriteSuccess(w, response)
 }
 
+// applyMetadataOperations executes operations on one topic in a single
+// transaction and notifies subscribers of the changes. It returns one result
+// per operation; when the transaction cannot run, every operation fails.
+func (s *Server) applyMetadataOperations(identity *auth.Identity, topic string, operations []database.BatchOperation) []database.BatchOperationResult {
+	s.logger.Debug("Processing batch for topic %s: %d operations", topic, len(operations))
+
+	failAll := func(message string) []database.BatchOperationResult {
+		results := make([]database.BatchOperationResult, len(operations))
+		for i, op := range operations {
+			results[i] = database.BatchOperationResult{
+				Hash:    op.Hash,
+				Success: false,
+				Error:   message,
+			}
+		}
+		return results
+	}
+
+	topicDB, err := s.app.GetTopicDB(topic)
+	if err != nil {
+		s.logger.Warn("Topic DB unavailable for batch: %s", topic)
+		return failAll("topic database unavailable")
+	}
+
+	// Begin transaction for atomic execution
+	tx, err := topicDB.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction for topic %s: %v", topic, err)
+		return failAll("failed to begin transaction")
+	}
+
+	results, err := database.ExecuteBatchMetadataTx(tx, operations, s.app.Config.Metadata.MaxValueBytes)
+	if err != nil {
+		tx.Rollback()
+		s.logger.Error("Batch execution failed for topic %s: %v", topic, err)
+		return failAll("batch execution failed: " + err.Error())
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Commit failed for topic %s: %v", topic, err)
+		return failAll("commit failed: " + err.Error())
+	}
+
+	s.logger.Debug("Batch completed for topic %s: %d operations", topic, len(results))
+	s.notifyMetadataChanged(identity, topic, operations, results)
+	return results
+}
+
 // handleApplyMetadata handles POST /api/metadata/apply
 func (s *Server) handleApplyMetadata(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -315,6 +324,10 @@ func (s *Server) handleApplyMetadata(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionMetadata, MetadataKeys: []string{req.Key}}) {
+		return
+	}
+
 	// Check disk usage limit before apply write (set operations grow SQLite)
 	if req.Op == constants.BatchMetadataOpSet {
 		if !s.checkDiskLimit(w, r, identity, "metadata_apply") {
@@ -452,6 +465,14 @@ func (s *Server) handleApplyMetadata(w http.ResponseWriter, r *http.Request) {
 	// Group operations by topic (re-lookup to ensure correctness)
 	grouped, notFound := database.GroupOperationsByTopic(s.app.OrchestratorDB, operations)
 
+	if result := s.preflightMetadata(identity, grouped, notFound); !result.Allowed {
+		writePreflightDenied(w, result)
+		return
+	}
+	if s.rejectFrozenTopics(w, grouped) {
+		return
+	}
+
 	s.logger.Info("Apply metadata: preset=%s, key=%s, %d operations across %d topics", req.QueryPreset, req.Key, len(operations), len(grouped))
 
 	// Execute operations per topic atomically
@@ -508,6 +529,7 @@ func (s *Server) handleApplyMetadata(w http.ResponseWriter, r *http.Request) {
 		}
 
 		allResults = append(allResults, results...)
+		s.notifyMetadataChanged(identity, group.Topic, group.Operations, results)
 	}
 
 	// Count successes and failures
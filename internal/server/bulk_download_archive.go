@@ -0,0 +1,165 @@
+package server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// bulkArchive is the container a bulk download is written into.
+type bulkArchive interface {
+	// Create starts the entry at name and returns its writer, valid until
+	// the next Create. size is the exact number of bytes that will be
+	// written; compress asks for per-entry compression where the format
+	// has it.
+	Create(name string, size int64, modTime time.Time, compress bool) (io.Writer, error)
+	// Flush writes buffered data to the underlying writer.
+	Flush() error
+	// Close completes the archive. The underlying writer is not closed.
+	Close() error
+}
+
+// validateArchiveFormat returns the archive format of a request, ZIP when
+// none is given.
+func validateArchiveFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return constants.ArchiveFormatZIP, nil
+	case constants.ArchiveFormatZIP, constants.ArchiveFormatTar, constants.ArchiveFormatTarGz:
+		return format, nil
+	}
+	return "", services.NewServiceError(constants.ErrCodeInvalidRequest, fmt.Sprintf("archive_format must be %s, %s or %s",
+		constants.ArchiveFormatZIP, constants.ArchiveFormatTar, constants.ArchiveFormatTarGz))
+}
+
+// newBulkArchive returns an archive of the given format writing to w.
+func newBulkArchive(format string, w io.Writer) bulkArchive {
+	switch format {
+	case constants.ArchiveFormatTar:
+		return &tarArchive{tw: tar.NewWriter(w)}
+	case constants.ArchiveFormatTarGz:
+		gz, _ := gzip.NewWriterLevel(w, constants.CompressionLevel)
+		return &tarArchive{tw: tar.NewWriter(gz), gz: gz}
+	default:
+		return zipArchive{zip.NewWriter(w)}
+	}
+}
+
+// archiveContentType returns the Content-Type of an archive format.
+func archiveContentType(format string) string {
+	switch format {
+	case constants.ArchiveFormatTar:
+		return constants.MimeTypeTar
+	case constants.ArchiveFormatTarGz:
+		return constants.MimeTypeGzip
+	default:
+		return constants.MimeTypeZIP
+	}
+}
+
+// archiveDisposition returns the Content-Disposition of a bulk download in
+// an archive format.
+func archiveDisposition(format string) string {
+	if format == "" {
+		format = constants.ArchiveFormatZIP
+	}
+	return fmt.Sprintf(constants.ContentDispositionFormat, constants.BulkDownloadFilenameBase+"."+format)
+}
+
+// zipArchive writes ZIP archives. Entries are stored unless compressed.
+type zipArchive struct {
+	*zip.Writer
+}
+
+func (z zipArchive) Create(name string, size int64, modTime time.Time, compress bool) (io.Writer, error) {
+	header := &zip.FileHeader{
+		Name:   name,
+		Method: zip.Store,
+	}
+	if compress {
+		header.Method = zip.Deflate
+	}
+	header.SetModTime(modTime)
+	return z.CreateHeader(header)
+}
+
+// tarArchive writes tar archives, gzip-compressed when gz is set. An entry
+// left short, for example by a failed read, is padded with zeros when the
+// next one starts, so the rest of the archive stays readable.
+type tarArchive struct {
+	tw    *tar.Writer
+	gz    *gzip.Writer
+	entry *tarEntryWriter
+}
+
+// tarEntryWriter counts the bytes still owed to the current entry.
+type tarEntryWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (e *tarEntryWriter) Write(p []byte) (int, error) {
+	n, err := e.w.Write(p)
+	e.remaining -= int64(n)
+	return n, err
+}
+
+func (t *tarArchive) Create(name string, size int64, modTime time.Time, compress bool) (io.Writer, error) {
+	if err := t.padEntry(); err != nil {
+		return nil, err
+	}
+	if err := t.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     constants.TarEntryMode,
+		ModTime:  modTime,
+	}); err != nil {
+		return nil, err
+	}
+	t.entry = &tarEntryWriter{w: t.tw, remaining: size}
+	return t.entry, nil
+}
+
+// padEntry fills the rest of a short entry with zeros.
+func (t *tarArchive) padEntry() error {
+	if t.entry == nil || t.entry.remaining <= 0 {
+		return nil
+	}
+	_, err := io.CopyN(t.entry, zeroReader{}, t.entry.remaining)
+	return err
+}
+
+// Flush flushes the gzip stream; tar.Writer writes entry data through.
+func (t *tarArchive) Flush() error {
+	if t.gz != nil {
+		return t.gz.Flush()
+	}
+	return nil
+}
+
+func (t *tarArchive) Close() error {
+	if err := t.padEntry(); err != nil {
+		return err
+	}
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	if t.gz != nil {
+		return t.gz.Close()
+	}
+	return nil
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
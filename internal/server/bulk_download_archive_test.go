@@ -0,0 +1,93 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// readTar returns the entries of a tar stream by name.
+func readTar(t *testing.T, r io.Reader) map[string][]byte {
+	t.Helper()
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatalf("reading tar: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("reading %s: %v", header.Name, err)
+		}
+		entries[header.Name] = data
+	}
+}
+
+func TestTarArchive_Formats(t *testing.T) {
+	for _, format := range []string{constants.ArchiveFormatTar, constants.ArchiveFormatTarGz} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			archive := newBulkArchive(format, &buf)
+
+			w, err := archive.Create("assets/a.txt", 5, time.Unix(1700000000, 0), true)
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			w.Write([]byte("hello"))
+
+			// A short entry is padded so the next one stays readable
+			w, _ = archive.Create("assets/short.bin", 4, time.Now(), false)
+			w.Write([]byte("ab"))
+			if err := writeJSONToArchive(archive, constants.ManifestFilename, map[string]int{"asset_count": 1}); err != nil {
+				t.Fatalf("writeJSONToArchive: %v", err)
+			}
+			if err := archive.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			var r io.Reader = &buf
+			if format == constants.ArchiveFormatTarGz {
+				gz, err := gzip.NewReader(&buf)
+				if err != nil {
+					t.Fatalf("expected a gzip stream: %v", err)
+				}
+				r = gz
+			}
+			entries := readTar(t, r)
+			if string(entries["assets/a.txt"]) != "hello" {
+				t.Errorf("unexpected entry content %q", entries["assets/a.txt"])
+			}
+			if !bytes.Equal(entries["assets/short.bin"], []byte{'a', 'b', 0, 0}) {
+				t.Errorf("expected the short entry padded with zeros, got %v", entries["assets/short.bin"])
+			}
+			if !bytes.Contains(entries[constants.ManifestFilename], []byte(`"asset_count": 1`)) {
+				t.Errorf("unexpected manifest %q", entries[constants.ManifestFilename])
+			}
+		})
+	}
+}
+
+func TestValidateArchiveFormat(t *testing.T) {
+	if got, err := validateArchiveFormat(""); err != nil || got != constants.ArchiveFormatZIP {
+		t.Errorf("expected zip by default, got %q, %v", got, err)
+	}
+	for _, format := range []string{constants.ArchiveFormatZIP, constants.ArchiveFormatTar, constants.ArchiveFormatTarGz} {
+		if got, err := validateArchiveFormat(format); err != nil || got != format {
+			t.Errorf("validateArchiveFormat(%q) = %q, %v", format, got, err)
+		}
+	}
+	for _, format := range []string{"tgz", "ZIP", "rar"} {
+		if _, err := validateArchiveFormat(format); err == nil {
+			t.Errorf("expected %q to be rejected", format)
+		}
+	}
+}
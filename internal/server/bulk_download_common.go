@@ -1,12 +1,11 @@
 package server
 
 import (
-	"archive/zip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"path"
 	"strings"
 	"time"
 
@@ -24,7 +23,58 @@ type BulkDownloadRequest struct {
 	Topics          []string               `json:"topics"`           // for mode="query", optional
 	AssetIDs        []string               `json:"asset_ids"`        // for mode="ids"
 	IncludeMetadata bool                   `json:"include_metadata"` // include metadata files
-	FilenameFormat  string                 `json:"filename_format"`  // "hash" | "original" | "hash_original"
+	FilenameFormat  string                 `json:"filename_format"`  // "hash" | "original" | "hash_original" | "path"
+	Collection      string                 `json:"collection"`       // optional collection filter
+	CollectionPaths bool                   `json:"collection_paths"` // place assets under assets/<collection>/
+	Recipients      []BulkRecipient        `json:"recipients"`       // optional: encrypt entries to these public keys
+	Destination     string                 `json:"destination"`      // "" = stream now | "inbox" = build in the background (POST only)
+	ArchiveFormat   string                 `json:"archive_format"`   // "zip" (default) | "tar" | "tar.gz"
+
+	// Metadata files carry metadata=full|keys|none, optionally limited to metadata_keys
+	services.MetadataSelection
+}
+
+// resolveBulkDownload validates a request and resolves its assets. The
+// request's filename and archive formats are updated to the validated values.
+func (s *Server) resolveBulkDownload(req *BulkDownloadRequest) ([]*services.ResolvedAsset, error) {
+	serviceReq := &services.BulkResolveRequest{
+		Mode:           req.Mode,
+		Preset:         req.Preset,
+		Params:         req.Params,
+		Topics:         req.Topics,
+		AssetIDs:       req.AssetIDs,
+		FilenameFormat: req.FilenameFormat,
+
+		Collection:      req.Collection,
+		CollectionPaths: req.CollectionPaths,
+	}
+
+	if err := s.app.Services.Bulk.ValidateRequest(serviceReq); err != nil {
+		return nil, err
+	}
+	if _, err := parseBulkRecipients(req.Recipients); err != nil {
+		return nil, err
+	}
+	if err := req.MetadataSelection.Validate(); err != nil {
+		return nil, err
+	}
+	archiveFormat, err := validateArchiveFormat(req.ArchiveFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	assets, err := s.app.Services.Bulk.ResolveAssets(serviceReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.app.Services.Bulk.ValidateAssetCount(len(assets)); err != nil {
+		return nil, err
+	}
+
+	req.FilenameFormat = serviceReq.FilenameFormat
+	req.ArchiveFormat = archiveFormat
+	return assets, nil
 }
 
 // ManifestAsset represents an asset entry in the manifest
@@ -35,6 +85,7 @@ type ManifestAsset struct {
 	Extension  string `json:"extension"`
 	OriginName string `json:"origin_name"`
 	Topic      string `json:"topic"`
+	Collection string `json:"collection,omitempty"`
 }
 
 // FailedAsset represents a failed asset in the manifest
@@ -50,6 +101,9 @@ type BulkDownloadManifest struct {
 	AssetCount      int             `json:"asset_count"`
 	TotalSize       int64           `json:"total_size"`
 	IncludeMetadata bool            `json:"include_metadata"`
+	MetadataMode    string          `json:"metadata_mode,omitempty"` // selection applied to metadata files
+	MetadataKeys    []string        `json:"metadata_keys,omitempty"`
+	Encrypted       bool            `json:"encrypted,omitempty"`
 	Assets          []ManifestAsset `json:"assets"`
 	FailedAssets    []FailedAsset   `json:"failed_assets,omitempty"`
 }
@@ -57,7 +111,8 @@ type BulkDownloadManifest struct {
 // AssetMetadataFile represents the per-asset metadata JSON file content
 type AssetMetadataFile struct {
 	Asset            BulkAssetInfo          `json:"asset"`
-	ComputedMetadata map[string]interface{} `json:"computed_metadata"`
+	ComputedMetadata map[string]interface{} `json:"computed_metadata,omitzero"` // absent with metadata=keys or none
+	MetadataKeys     []string               `json:"metadata_keys,omitempty"`    // set with metadata=keys
 }
 
 // BulkAssetInfo contains asset information for metadata files
@@ -73,30 +128,51 @@ type BulkAssetInfo struct {
 }
 
 // ZIPBuildCallbacks provides optional hooks for progress tracking and cancellation
-// during ZIP archive generation. Both fields are optional — pass nil for the entire
+// during archive generation. Both fields are optional — pass nil for the entire
 // struct when no callbacks are needed (e.g., direct streaming).
 type ZIPBuildCallbacks struct {
 	// OnAssetProcessed is called after each asset is written (or fails).
 	// index is 0-based, filename is the resolved filename in the ZIP.
 	OnAssetProcessed func(index int, asset *services.ResolvedAsset, filename string, processedBytes int64)
-	// CheckCancelled returns true if the operation should abort.
+	// CheckCancelled returns true if the operation should abort. It is also
+	// polled while an asset is copied, so large assets stop mid-stream.
 	CheckCancelled func() bool
 }
 
-// ZIPBuildResult contains the output of a buildZIPArchive operation.
+// errZIPCancelled aborts an asset copy once CheckCancelled reports true.
+var errZIPCancelled = errors.New("bulk download cancelled")
+
+// cancellableReader fails reads with errZIPCancelled once cancelled reports
+// true.
+type cancellableReader struct {
+	r         io.Reader
+	cancelled func() bool
+}
+
+func (c *cancellableReader) Read(p []byte) (int, error) {
+	if c.cancelled() {
+		return 0, errZIPCancelled
+	}
+	return c.r.Read(p)
+}
+
+// ZIPBuildResult contains the output of a buildArchive operation.
 type ZIPBuildResult struct {
 	Manifest    BulkDownloadManifest
 	FailedCount int
 	Topics      []string
 	TotalSize   int64
 	Cancelled   bool
+	Recipients  []string // set for encrypted archives
 }
 
-// buildZIPArchive writes assets into a ZIP archive with manifest and optional metadata.
-// The caller is responsible for creating and closing the zip.Writer.
+// buildArchive writes assets into an archive with manifest and optional metadata.
+// When the request has recipients, asset and metadata entries are encrypted and
+// their wrapped keys are written to keys.json.
+// The caller is responsible for creating and closing the archive.
 // Progress and cancellation are handled via optional callbacks.
-func (s *Server) buildZIPArchive(
-	zipWriter *zip.Writer,
+func (s *Server) buildArchive(
+	archive bulkArchive,
 	assets []*services.ResolvedAsset,
 	req BulkDownloadRequest,
 	callbacks *ZIPBuildCallbacks,
@@ -107,10 +183,19 @@ func (s *Server) buildZIPArchive(
 		IncludeMetadata: req.IncludeMetadata,
 		Assets:          make([]ManifestAsset, 0, len(assets)),
 		FailedAssets:    make([]FailedAsset, 0),
+		Encrypted:       len(req.Recipients) > 0,
 	}
+	if req.IncludeMetadata && !req.MetadataSelection.IsFull() {
+		manifest.MetadataMode = req.MetadataSelection.Mode
+		manifest.MetadataKeys = req.MetadataSelection.Keys
+	}
+
+	// Never fall back to plaintext: if the recipients cannot be used, every
+	// asset fails
+	keys, keyErr := newBulkKeyRing(req.Recipients)
 
-	// Track used filenames for collision handling
-	usedNames := make(map[string]int)
+	// Track used filenames per directory for collision handling
+	usedNames := make(map[string]map[string]int)
 
 	// Collect unique topics
 	topicSet := make(map[string]struct{})
@@ -118,10 +203,15 @@ func (s *Server) buildZIPArchive(
 	var processedBytes int64
 	failedCount := 0
 
+	var cancelled func() bool
+	if callbacks != nil {
+		cancelled = callbacks.CheckCancelled
+	}
+
 	// Write each asset
 	for i, resolved := range assets {
 		// Check cancellation
-		if callbacks != nil && callbacks.CheckCancelled != nil && callbacks.CheckCancelled() {
+		if cancelled != nil && cancelled() {
 			return ZIPBuildResult{
 				Manifest:    manifest,
 				FailedCount: failedCount,
@@ -131,11 +221,23 @@ func (s *Server) buildZIPArchive(
 			}
 		}
 
-		filename := buildFilename(resolved.Asset, req.FilenameFormat, usedNames)
-		fullPath := constants.BulkDownloadAssetsDir + "/" + filename
+		filename := assetPath(resolved, req.FilenameFormat, usedNames)
+		fullPath := keys.entryPath(constants.BulkDownloadAssetsDir + "/" + filename)
 
 		// Write asset file
-		err := s.writeAssetToZip(zipWriter, resolved, fullPath)
+		err := keyErr
+		if err == nil {
+			err = s.writeAssetToArchive(archive, resolved, fullPath, keys, cancelled)
+		}
+		if errors.Is(err, errZIPCancelled) {
+			return ZIPBuildResult{
+				Manifest:    manifest,
+				FailedCount: failedCount,
+				Topics:      collectTopics(topicSet),
+				TotalSize:   manifest.TotalSize,
+				Cancelled:   true,
+			}
+		}
 		if err != nil {
 			manifest.FailedAssets = append(manifest.FailedAssets, FailedAsset{
 				Hash:  resolved.Hash,
@@ -143,7 +245,7 @@ func (s *Server) buildZIPArchive(
 				Topic: resolved.Topic,
 			})
 			failedCount++
-			s.logger.Error("Failed to write asset %s to ZIP: %v", resolved.Hash, err)
+			s.logger.Error("Failed to write asset %s to archive: %v", resolved.Hash, err)
 
 			// Notify progress even for failed assets
 			if callbacks != nil && callbacks.OnAssetProcessed != nil {
@@ -160,6 +262,7 @@ func (s *Server) buildZIPArchive(
 			Extension:  resolved.Asset.Extension,
 			OriginName: resolved.Asset.OriginName,
 			Topic:      resolved.Topic,
+			Collection: resolved.Collection,
 		})
 		manifest.TotalSize += resolved.Asset.AssetSize
 		processedBytes += resolved.Asset.AssetSize
@@ -171,8 +274,8 @@ func (s *Server) buildZIPArchive(
 			if cleanExt := sanitize.Extension(resolved.Asset.Extension); cleanExt != "" {
 				metadataBaseName = strings.TrimSuffix(filename, "."+cleanExt)
 			}
-			metadataPath := constants.BulkDownloadMetadataDir + "/" + metadataBaseName + ".json"
-			if err := s.writeMetadataToZip(zipWriter, resolved, metadataPath); err != nil {
+			metadataPath := keys.entryPath(constants.BulkDownloadMetadataDir + "/" + metadataBaseName + ".json")
+			if err := s.writeMetadataToArchive(archive, resolved, metadataPath, keys, &req.MetadataSelection); err != nil {
 				s.logger.Error("Failed to write metadata for %s: %v", resolved.Hash, err)
 			}
 		}
@@ -187,15 +290,21 @@ func (s *Server) buildZIPArchive(
 	manifest.AssetCount = len(manifest.Assets)
 
 	// Write manifest
-	if err := writeManifestToZip(zipWriter, manifest); err != nil {
+	if err := writeManifestToArchive(archive, manifest); err != nil {
 		s.logger.Error("Failed to write manifest: %v", err)
 	}
+	if keys != nil {
+		if err := writeJSONToArchive(archive, constants.BulkDownloadKeysFilename, keys.manifest); err != nil {
+			s.logger.Error("Failed to write keys manifest: %v", err)
+		}
+	}
 
 	return ZIPBuildResult{
 		Manifest:    manifest,
 		FailedCount: failedCount,
 		Topics:      collectTopics(topicSet),
 		TotalSize:   manifest.TotalSize,
+		Recipients:  keys.recipientIDs(),
 	}
 }
 
@@ -208,6 +317,26 @@ func collectTopics(topicSet map[string]struct{}) []string {
 	return topics
 }
 
+// assetPath returns the slash-separated path of an asset below the assets
+// directory: its collection and upload folder, if any, then its filename.
+// usedNames tracks the names taken in each directory.
+func assetPath(resolved *services.ResolvedAsset, format string, usedNames map[string]map[string]int) string {
+	dir := resolved.Collection
+	if resolved.RelativeDir != "" {
+		dir = path.Join(dir, resolved.RelativeDir)
+	}
+	dirNames, ok := usedNames[dir]
+	if !ok {
+		dirNames = make(map[string]int)
+		usedNames[dir] = dirNames
+	}
+	filename := buildFilename(resolved.Asset, format, dirNames)
+	if dir != "" {
+		filename = dir + "/" + filename
+	}
+	return filename
+}
+
 func buildFilename(asset *database.Asset, format string, usedNames map[string]int) string {
 	// Defense-in-depth: sanitize origin name and extension at output even though
 	// input is sanitized at upload, in case of pre-existing unsanitized data
@@ -219,7 +348,7 @@ func buildFilename(asset *database.Asset, format string, usedNames map[string]in
 	switch format {
 	case constants.FilenameFormatHash:
 		baseName = asset.AssetID
-	case constants.FilenameFormatOriginal:
+	case constants.FilenameFormatOriginal, constants.FilenameFormatPath:
 		baseName = cleanOrigin
 		if baseName == "" {
 			baseName = asset.AssetID
@@ -240,8 +369,8 @@ func buildFilename(asset *database.Asset, format string, usedNames map[string]in
 		filename = baseName + "." + cleanExt
 	}
 
-	// Handle collisions for original format
-	if format == constants.FilenameFormatOriginal {
+	// Handle collisions for original-name formats
+	if format == constants.FilenameFormatOriginal || format == constants.FilenameFormatPath {
 		originalFilename := filename
 		count := usedNames[originalFilename]
 		if count > 0 {
@@ -257,44 +386,40 @@ func buildFilename(asset *database.Asset, format string, usedNames map[string]in
 	return filename
 }
 
-func (s *Server) writeAssetToZip(zipWriter *zip.Writer, resolved *services.ResolvedAsset, path string) error {
-	// Create ZIP entry header with Store method (no compression for streaming)
-	header := &zip.FileHeader{
-		Name:   path,
-		Method: zip.Store,
-	}
-	header.SetModTime(time.Unix(resolved.Asset.CreatedAt, 0))
-
-	// Create writer for this entry
-	entryWriter, err := zipWriter.CreateHeader(header)
-	if err != nil {
-		return fmt.Errorf("failed to create zip entry: %w", err)
-	}
-
-	// Open .dat file
-	datPath := filepath.Join(resolved.TopicPath, resolved.Asset.BlobName)
-	f, err := os.Open(datPath)
+func (s *Server) writeAssetToArchive(archive bulkArchive, resolved *services.ResolvedAsset, path string, keys *bulkKeyRing, cancelled func() bool) error {
+	// Open the asset data in its .dat file, locally or in the topic's blob
+	// store, before the entry is started
+	f, err := s.app.Services.BlobStores.OpenData(resolved.Topic, resolved.Asset)
 	if err != nil {
 		return fmt.Errorf("failed to open data file: %w", err)
 	}
 	defer f.Close()
 
-	// Seek to data start (skip header)
-	dataStart := resolved.Asset.ByteOffset + int64(constants.HeaderSize)
-	if _, err := f.Seek(dataStart, io.SeekStart); err != nil {
-		return fmt.Errorf("failed to seek in data file: %w", err)
+	// Store entries as-is unless the extension's storage policy enables
+	// compression
+	compress := s.app.Config.StoragePolicy(resolved.Asset.Extension).Compression
+	entryWriter, err := archive.Create(path, keys.sealedSize(resolved.Asset.AssetSize), time.Unix(resolved.Asset.CreatedAt, 0), compress)
+	if err != nil {
+		return fmt.Errorf("failed to create archive entry: %w", err)
 	}
 
-	// Stream data to zip entry
-	_, err = io.CopyN(entryWriter, f, resolved.Asset.AssetSize)
+	// Stream data to the entry
+	w, err := keys.wrap(entryWriter, path, resolved.Hash)
 	if err != nil {
+		return fmt.Errorf("failed to encrypt entry: %w", err)
+	}
+	var src io.Reader = f
+	if cancelled != nil {
+		src = &cancellableReader{r: f, cancelled: cancelled}
+	}
+	if _, err := io.CopyN(w, src, resolved.Asset.AssetSize); err != nil {
 		return fmt.Errorf("failed to stream data: %w", err)
 	}
 
-	return nil
+	return w.Close()
 }
 
-func (s *Server) writeMetadataToZip(zipWriter *zip.Writer, resolved *services.ResolvedAsset, path string) error {
+func (s *Server) writeMetadataToArchive(archive bulkArchive, resolved *services.ResolvedAsset, path string, keys *bulkKeyRing, selection *services.MetadataSelection) error {
 	// Get computed metadata
 	computedMetadata, err := database.GetMetadataComputed(resolved.TopicDB, resolved.Hash)
 	if err != nil {
@@ -316,7 +441,12 @@ func (s *Server) writeMetadataToZip(zipWriter *zip.Writer, resolved *services.Re
 			Topic:      resolved.Topic,
 			BlobName:   resolved.Asset.BlobName,
 		},
-		ComputedMetadata: computedMetadata,
+	}
+	if selection.IncludesValues() {
+		metadataFile.ComputedMetadata = selection.Values(computedMetadata)
+	}
+	if selection.IncludesKeyNames() {
+		metadataFile.MetadataKeys = selection.KeyNames(computedMetadata)
 	}
 
 	// Serialize to JSON
@@ -325,37 +455,35 @@ func (s *Server) writeMetadataToZip(zipWriter *zip.Writer, resolved *services.Re
 		return fmt.Errorf("failed to serialize metadata: %w", err)
 	}
 
-	// Create ZIP entry
-	header := &zip.FileHeader{
-		Name:   path,
-		Method: zip.Store,
+	entryWriter, err := archive.Create(path, keys.sealedSize(int64(len(jsonBytes))), time.Now(), false)
+	if err != nil {
+		return fmt.Errorf("failed to create metadata archive entry: %w", err)
 	}
-	header.SetModTime(time.Now())
 
-	entryWriter, err := zipWriter.CreateHeader(header)
+	w, err := keys.wrap(entryWriter, path, resolved.Hash)
 	if err != nil {
-		return fmt.Errorf("failed to create metadata zip entry: %w", err)
+		return fmt.Errorf("failed to encrypt metadata entry: %w", err)
+	}
+	if _, err := w.Write(jsonBytes); err != nil {
+		return err
 	}
+	return w.Close()
+}
 
-	_, err = entryWriter.Write(jsonBytes)
-	return err
+func writeManifestToArchive(archive bulkArchive, manifest BulkDownloadManifest) error {
+	return writeJSONToArchive(archive, constants.ManifestFilename, manifest)
 }
 
-func writeManifestToZip(zipWriter *zip.Writer, manifest BulkDownloadManifest) error {
-	jsonBytes, err := json.MarshalIndent(manifest, "", "  ")
+// writeJSONToArchive writes v as an indented JSON entry at name.
+func writeJSONToArchive(archive bulkArchive, name string, v interface{}) error {
+	jsonBytes, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to serialize manifest: %w", err)
-	}
-
-	header := &zip.FileHeader{
-		Name:   constants.ManifestFilename,
-		Method: zip.Store,
+		return fmt.Errorf("failed to serialize %s: %w", name, err)
 	}
-	header.SetModTime(time.Now())
 
-	entryWriter, err := zipWriter.CreateHeader(header)
+	entryWriter, err := archive.Create(name, int64(len(jsonBytes)), time.Now(), false)
 	if err != nil {
-		return fmt.Errorf("failed to create manifest zip entry: %w", err)
+		return fmt.Errorf("failed to create %s archive entry: %w", name, err)
 	}
 
 	_, err = entryWriter.Write(jsonBytes)
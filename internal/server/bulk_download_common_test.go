@@ -342,7 +342,7 @@ func TestMetadataFilename_MatchesAssetFilename(t *testing.T) {
 	}
 }
 
-func TestWriteManifestToZip(t *testing.T) {
+func TestWriteManifestToArchive(t *testing.T) {
 	t.Run("writes correct JSON content", func(t *testing.T) {
 		var buf bytes.Buffer
 		zipWriter := zip.NewWriter(&buf)
@@ -359,9 +359,9 @@ func TestWriteManifestToZip(t *testing.T) {
 			FailedAssets: []FailedAsset{},
 		}
 
-		err := writeManifestToZip(zipWriter, manifest)
+		err := writeManifestToArchive(zipArchive{zipWriter}, manifest)
 		if err != nil {
-			t.Fatalf("writeManifestToZip failed: %v", err)
+			t.Fatalf("writeManifestToArchive failed: %v", err)
 		}
 
 		if err := zipWriter.Close(); err != nil {
@@ -427,9 +427,9 @@ func TestWriteManifestToZip(t *testing.T) {
 			FailedAssets:    []FailedAsset{},
 		}
 
-		err := writeManifestToZip(zipWriter, manifest)
+		err := writeManifestToArchive(zipArchive{zipWriter}, manifest)
 		if err != nil {
-			t.Fatalf("writeManifestToZip failed: %v", err)
+			t.Fatalf("writeManifestToArchive failed: %v", err)
 		}
 
 		if err := zipWriter.Close(); err != nil {
@@ -478,9 +478,9 @@ func TestWriteManifestToZip(t *testing.T) {
 			},
 		}
 
-		err := writeManifestToZip(zipWriter, manifest)
+		err := writeManifestToArchive(zipArchive{zipWriter}, manifest)
 		if err != nil {
-			t.Fatalf("writeManifestToZip failed: %v", err)
+			t.Fatalf("writeManifestToArchive failed: %v", err)
 		}
 
 		if err := zipWriter.Close(); err != nil {
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"fmt"
+	"io"
+
+	"silobang/internal/constants"
+	"silobang/internal/envelope"
+	"silobang/internal/services"
+)
+
+// BulkRecipient is an X25519 public key that encrypted bulk download entries
+// are wrapped to.
+type BulkRecipient struct {
+	ID        string `json:"id"`
+	PublicKey string `json:"public_key"` // base64, 32 bytes
+}
+
+// BulkKeysManifest represents the keys.json content of an encrypted archive
+type BulkKeysManifest struct {
+	Version    int                        `json:"version"`
+	Scheme     string                     `json:"scheme"`
+	ChunkSize  int                        `json:"chunk_size"`
+	Recipients []envelope.RecipientHeader `json:"recipients"`
+	Entries    []BulkKeyEntry             `json:"entries"`
+}
+
+// BulkKeyEntry holds the wrapped keys of one encrypted archive entry
+type BulkKeyEntry struct {
+	Path        string            `json:"path"`
+	Hash        string            `json:"hash"`
+	WrappedKeys map[string]string `json:"wrapped_keys"` // recipient ID -> base64(nonce || sealed key)
+}
+
+// parseBulkRecipients validates the recipients of an encrypted bulk download.
+func parseBulkRecipients(recipients []BulkRecipient) ([]envelope.Recipient, error) {
+	if len(recipients) > constants.BulkDownloadMaxRecipients {
+		return nil, services.NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("too many recipients (max %d)", constants.BulkDownloadMaxRecipients))
+	}
+
+	parsed := make([]envelope.Recipient, 0, len(recipients))
+	seen := make(map[string]bool, len(recipients))
+	for _, r := range recipients {
+		if seen[r.ID] {
+			return nil, services.NewServiceError(constants.ErrCodeInvalidRequest, "duplicate recipient id: "+r.ID)
+		}
+		seen[r.ID] = true
+
+		recipient, err := envelope.ParseRecipient(r.ID, r.PublicKey)
+		if err != nil {
+			return nil, services.NewServiceError(constants.ErrCodeInvalidRequest, err.Error())
+		}
+		parsed = append(parsed, recipient)
+	}
+	return parsed, nil
+}
+
+// bulkKeyRing encrypts archive entries and collects their wrapped keys. A nil
+// ring writes entries in plaintext.
+type bulkKeyRing struct {
+	sealer   *envelope.Sealer
+	manifest BulkKeysManifest
+}
+
+// newBulkKeyRing returns nil when the request has no recipients.
+func newBulkKeyRing(recipients []BulkRecipient) (*bulkKeyRing, error) {
+	if len(recipients) == 0 {
+		return nil, nil
+	}
+
+	parsed, err := parseBulkRecipients(recipients)
+	if err != nil {
+		return nil, err
+	}
+	sealer, err := envelope.NewSealer(parsed)
+	if err != nil {
+		return nil, services.NewServiceError(constants.ErrCodeInvalidRequest, err.Error())
+	}
+
+	return &bulkKeyRing{
+		sealer: sealer,
+		manifest: BulkKeysManifest{
+			Version:    constants.EnvelopeVersion,
+			Scheme:     constants.EnvelopeScheme,
+			ChunkSize:  constants.EnvelopeChunkSize,
+			Recipients: sealer.Recipients(),
+			Entries:    make([]BulkKeyEntry, 0),
+		},
+	}, nil
+}
+
+// entryPath returns the archive path of an entry.
+func (k *bulkKeyRing) entryPath(path string) string {
+	if k == nil {
+		return path
+	}
+	return path + constants.BulkDownloadEncryptedSuffix
+}
+
+// wrap returns a writer for the entry at path. Closing it completes the
+// entry and records its wrapped keys.
+func (k *bulkKeyRing) wrap(dst io.Writer, path, hash string) (io.WriteCloser, error) {
+	if k == nil {
+		return nopWriteCloser{dst}, nil
+	}
+
+	w, wrapped, err := k.sealer.NewEntry(dst, path)
+	if err != nil {
+		return nil, err
+	}
+	return &keyedEntryWriter{WriteCloser: w, onClose: func() {
+		k.manifest.Entries = append(k.manifest.Entries, BulkKeyEntry{Path: path, Hash: hash, WrappedKeys: wrapped})
+	}}, nil
+}
+
+// sealedSize returns the size of an entry of n plaintext bytes in the
+// archive.
+func (k *bulkKeyRing) sealedSize(n int64) int64 {
+	if k == nil {
+		return n
+	}
+	return envelope.SealedSize(n)
+}
+
+// recipientIDs lists the recipients for audit logging.
+func (k *bulkKeyRing) recipientIDs() []string {
+	if k == nil {
+		return nil
+	}
+	ids := make([]string, len(k.manifest.Recipients))
+	for i, r := range k.manifest.Recipients {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// keyedEntryWriter records an entry's keys once it is completely written.
+type keyedEntryWriter struct {
+	io.WriteCloser
+	onClose func()
+}
+
+func (w *keyedEntryWriter) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		return err
+	}
+	w.onClose()
+	return nil
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/envelope"
+)
+
+func newTestRecipient(t *testing.T, id string) (*ecdh.PrivateKey, BulkRecipient) {
+	t.Helper()
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return priv, BulkRecipient{ID: id, PublicKey: base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes())}
+}
+
+func TestParseBulkRecipients(t *testing.T) {
+	_, alice := newTestRecipient(t, "alice")
+
+	if parsed, err := parseBulkRecipients(nil); err != nil || len(parsed) != 0 {
+		t.Errorf("no recipients: parsed=%v err=%v", parsed, err)
+	}
+	if _, err := parseBulkRecipients([]BulkRecipient{alice, alice}); err == nil {
+		t.Error("expected duplicate recipient id to be rejected")
+	}
+	if _, err := parseBulkRecipients([]BulkRecipient{{ID: "bad", PublicKey: "short"}}); err == nil {
+		t.Error("expected invalid public key to be rejected")
+	}
+
+	tooMany := make([]BulkRecipient, constants.BulkDownloadMaxRecipients+1)
+	for i := range tooMany {
+		_, tooMany[i] = newTestRecipient(t, fmt.Sprintf("r%d", i))
+	}
+	if _, err := parseBulkRecipients(tooMany); err == nil {
+		t.Error("expected too many recipients to be rejected")
+	}
+}
+
+func TestBulkKeyRing_NilWritesPlaintext(t *testing.T) {
+	var keys *bulkKeyRing
+	if keys.entryPath("assets/a.png") != "assets/a.png" {
+		t.Error("nil key ring should not rename entries")
+	}
+
+	var buf bytes.Buffer
+	w, _ := keys.wrap(&buf, "assets/a.png", "hash")
+	w.Write([]byte("plain"))
+	w.Close()
+	if buf.String() != "plain" || keys.recipientIDs() != nil {
+		t.Errorf("unexpected plaintext output %q", buf.String())
+	}
+}
+
+func TestBulkKeyRing_RecordsCompletedEntries(t *testing.T) {
+	priv, alice := newTestRecipient(t, "alice")
+	keys, err := newBulkKeyRing([]BulkRecipient{alice})
+	if err != nil {
+		t.Fatalf("newBulkKeyRing: %v", err)
+	}
+
+	path := keys.entryPath("assets/a.png")
+	if path != "assets/a.png"+constants.BulkDownloadEncryptedSuffix {
+		t.Errorf("unexpected entry path %q", path)
+	}
+
+	var buf bytes.Buffer
+	w, err := keys.wrap(&buf, path, "hash-a")
+	if err != nil {
+		t.Fatalf("wrap: %v", err)
+	}
+	w.Write([]byte("secret"))
+	if len(keys.manifest.Entries) != 0 {
+		t.Error("entry should only be recorded once closed")
+	}
+	w.Close()
+
+	if len(keys.manifest.Entries) != 1 || keys.manifest.Entries[0].Hash != "hash-a" {
+		t.Fatalf("unexpected entries: %+v", keys.manifest.Entries)
+	}
+	entry := keys.manifest.Entries[0]
+	key, err := envelope.UnwrapKey(priv, keys.manifest.Recipients[0].EphemeralPublicKey, entry.Path, entry.WrappedKeys["alice"])
+	if err != nil {
+		t.Fatalf("UnwrapKey: %v", err)
+	}
+	plaintext, err := envelope.Open(key, buf.Bytes())
+	if err != nil || string(plaintext) != "secret" {
+		t.Errorf("Open = %q, %v", plaintext, err)
+	}
+}
@@ -1,11 +1,11 @@
 package server
 
 import (
-	"archive/zip"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -64,6 +64,15 @@ type DownloadCompleteData struct {
 	ExpiresAt    int64  `json:"expires_at"`
 }
 
+type DownloadCancelledData struct {
+	DownloadID      string `json:"download_id"`
+	Reason          string `json:"reason"` // constants.BulkDownloadCancelReason*
+	ProcessedAssets int    `json:"processed_assets"`
+	TotalAssets     int    `json:"total_assets"`
+	ProcessedBytes  int64  `json:"processed_bytes"`
+	TotalBytes      int64  `json:"total_bytes"`
+}
+
 type DownloadErrorData struct {
 	DownloadID string `json:"download_id"`
 	Message    string `json:"message"`
@@ -73,11 +82,12 @@ type DownloadErrorData struct {
 // BulkDownloadSession tracks an in-progress or completed download
 type BulkDownloadSession struct {
 	ID          string
-	Status      string // "pending", "processing", "complete", "error"
+	Status      string // "pending", "processing", "complete", "error", "cancelled"
 	CreatedAt   time.Time
 	CompletedAt *time.Time
-	ZIPPath     string
+	ZIPPath     string // the archive, whatever its format
 	ZIPSize     int64
+	Format      string // archive format, constants.ArchiveFormat*
 	Error       string
 
 	// Progress tracking
@@ -86,6 +96,18 @@ type BulkDownloadSession struct {
 	TotalBytes      int64
 	ProcessedBytes  int64
 	FailedAssets    int
+
+	done chan struct{} // closed once ZIP generation has finished
+}
+
+// errBulkDownloadCancelRequested is the cancellation cause of downloads
+// stopped on request rather than by the client going away.
+var errBulkDownloadCancelRequested = errors.New("bulk download cancelled by request")
+
+// cancelRequested returns a cancel function that marks the download as
+// cancelled on request.
+func cancelRequested(cancel context.CancelCauseFunc) context.CancelFunc {
+	return func() { cancel(errBulkDownloadCancelRequested) }
 }
 
 // DownloadSessionManager manages active download sessions with cleanup
@@ -169,6 +191,7 @@ func (m *DownloadSessionManager) CreateSession() (*BulkDownloadSession, error) {
 		ID:        id,
 		Status:    "pending",
 		CreatedAt: time.Now(),
+		done:      make(chan struct{}),
 	}
 
 	m.mu.Lock()
@@ -185,6 +208,17 @@ func (m *DownloadSessionManager) GetSession(id string) *BulkDownloadSession {
 	return m.sessions[id]
 }
 
+// Snapshot returns a copy of a session, safe to read while it is updated.
+func (m *DownloadSessionManager) Snapshot(id string) (BulkDownloadSession, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return BulkDownloadSession{}, false
+	}
+	return *session, true
+}
+
 // UpdateSession updates session fields
 func (m *DownloadSessionManager) UpdateSession(id string, updater func(*BulkDownloadSession)) {
 	m.mu.Lock()
@@ -247,6 +281,27 @@ func CleanBulkDownloadDirectory(workingDir string) error {
 	return nil
 }
 
+// progressSender delivers typed progress events to a client.
+// Implemented by the SSE writer and by the progress WebSocket hub.
+type progressSender interface {
+	Send(eventType string, data interface{}) error
+}
+
+// teeProgressSender sends events to a primary sender and mirrors them to the
+// owning user's progress WebSocket connections.
+type teeProgressSender struct {
+	primary progressSender
+	hub     *ProgressHub
+	userID  int64
+}
+
+// Send delivers to the primary sender first; hub delivery is best effort.
+func (t *teeProgressSender) Send(eventType string, data interface{}) error {
+	err := t.primary.Send(eventType, data)
+	t.hub.Publish(t.userID, eventType, data)
+	return err
+}
+
 // BulkDownloadSSEWriter handles SSE for bulk downloads
 type BulkDownloadSSEWriter struct {
 	w       http.ResponseWriter
@@ -308,6 +363,12 @@ func (s *Server) handleBulkDownloadSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	release := s.acquireSSE(w, r, constants.SSEEndpointBulkDownload, identity)
+	if release == nil {
+		return
+	}
+	defer release()
+
 	// Set up SSE writer FIRST so all errors go through SSE format
 	// This ensures EventSource receives proper SSE events, not JSON
 	sse, err := NewBulkDownloadSSEWriter(w)
@@ -331,11 +392,6 @@ func (s *Server) handleBulkDownloadSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Ensure download manager is initialized
-	if s.downloadManager == nil {
-		s.downloadManager = NewDownloadSessionManager(s.app.Config.WorkingDirectory, s.app.Config.BulkDownload.SessionTTLMins)
-	}
-
 	// Parse request from query params
 	req, err := s.parseBulkDownloadSSEParams(r)
 	if err != nil {
@@ -343,45 +399,46 @@ func (s *Server) handleBulkDownloadSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Convert to service request and validate
-	serviceReq := &services.BulkResolveRequest{
-		Mode:           req.Mode,
-		Preset:         req.Preset,
-		Params:         req.Params,
-		Topics:         req.Topics,
-		AssetIDs:       req.AssetIDs,
-		FilenameFormat: req.FilenameFormat,
+	session, assets, err := s.prepareBulkDownload(identity, req)
+	if err != nil {
+		sendError(bulkErrorParts(err))
+		return
 	}
 
-	// Validate via service
-	if err := s.app.Services.Bulk.ValidateRequest(serviceReq); err != nil {
-		if svcErr, ok := err.(*services.ServiceError); ok {
-			sendError(svcErr.Message, svcErr.Code)
-		} else {
-			sendError(err.Error(), constants.ErrCodeInvalidRequest)
-		}
-		return
+	// Make the download cancellable from the progress WebSocket and mirror
+	// its events there
+	ctx, cancel := context.WithCancelCause(r.Context())
+	defer cancel(nil)
+	userID := identity.User.ID
+	if op := s.progressHub.Track(userID, session.ID, progressKindDownload, cancelRequested(cancel)); op != nil {
+		defer s.progressHub.Untrack(session.ID, op)
 	}
+	sender := &teeProgressSender{primary: sse, hub: s.progressHub, userID: userID}
+
+	// Run ZIP generation with progress events
+	s.generateZIPWithProgress(ctx, sender, session, assets, req, getClientIP(r), getAuditUsername(identity))
+}
 
-	// Resolve assets via service
-	assets, err := s.app.Services.Bulk.ResolveAssets(serviceReq)
+// prepareBulkDownload validates a request, resolves its assets, runs the
+// pre-flight permission check and creates a processing session. Shared by the
+// SSE and WebSocket entry points.
+func (s *Server) prepareBulkDownload(identity *auth.Identity, req BulkDownloadRequest) (*BulkDownloadSession, []*services.ResolvedAsset, error) {
+	// Ensure download manager is initialized
+	if s.downloadManager == nil {
+		s.downloadManager = NewDownloadSessionManager(s.app.Config.WorkingDirectory, s.app.Config.BulkDownload.SessionTTLMins)
+	}
+
+	if req.Destination != "" {
+		return nil, nil, services.NewServiceError(constants.ErrCodeInvalidRequest, "destination is only supported by POST /api/download/bulk")
+	}
+
+	assets, err := s.resolveBulkDownload(&req)
 	if err != nil {
-		if svcErr, ok := err.(*services.ServiceError); ok {
-			sendError(svcErr.Message, svcErr.Code)
-		} else {
-			sendError(err.Error(), constants.ErrCodeInvalidRequest)
-		}
-		return
+		return nil, nil, err
 	}
 
-	// Validate asset count via service
-	if err := s.app.Services.Bulk.ValidateAssetCount(len(assets)); err != nil {
-		if svcErr, ok := err.(*services.ServiceError); ok {
-			sendError(svcErr.Message, svcErr.Code)
-		} else {
-			sendError(err.Error(), constants.ErrCodeInvalidRequest)
-		}
-		return
+	if result := s.preflightBulkDownload(identity, assets); !result.Allowed {
+		return nil, nil, preflightDeniedError(result)
 	}
 
 	// Calculate total size
@@ -390,11 +447,9 @@ func (s *Server) handleBulkDownloadSSE(w http.ResponseWriter, r *http.Request) {
 		totalBytes += asset.Asset.AssetSize
 	}
 
-	// Create session
 	session, err := s.downloadManager.CreateSession()
 	if err != nil {
-		sendError("Failed to create download session", constants.ErrCodeInternalError)
-		return
+		return nil, nil, services.NewServiceError(constants.ErrCodeInternalError, "Failed to create download session")
 	}
 
 	// Update session with initial info
@@ -404,8 +459,16 @@ func (s *Server) handleBulkDownloadSSE(w http.ResponseWriter, r *http.Request) {
 		sess.TotalBytes = totalBytes
 	})
 
-	// Run ZIP generation with progress events
-	s.generateZIPWithProgress(r.Context(), sse, session, assets, req, getClientIP(r), getAuditUsername(identity))
+	return session, assets, nil
+}
+
+// bulkErrorParts extracts a message and error code for progress error events.
+// Non-service errors are reported as invalid requests.
+func bulkErrorParts(err error) (string, string) {
+	if svcErr, ok := err.(*services.ServiceError); ok {
+		return svcErr.Message, svcErr.Code
+	}
+	return err.Error(), constants.ErrCodeInvalidRequest
 }
 
 // parseBulkDownloadSSEParams parses query parameters for SSE bulk download
@@ -417,6 +480,9 @@ func (s *Server) parseBulkDownloadSSEParams(r *http.Request) (BulkDownloadReques
 		Preset:          q.Get("preset"),
 		FilenameFormat:  q.Get("filename_format"),
 		IncludeMetadata: q.Get("include_metadata") == "true",
+		ArchiveFormat:   q.Get("archive_format"),
+
+		MetadataSelection: metadataSelectionFromQuery(r),
 	}
 
 	// Parse topics
@@ -442,6 +508,13 @@ func (s *Server) parseBulkDownloadSSEParams(r *http.Request) (BulkDownloadReques
 		}
 	}
 
+	// Parse recipients (JSON-encoded)
+	if recipients := q.Get("recipients"); recipients != "" {
+		if err := json.Unmarshal([]byte(recipients), &req.Recipients); err != nil {
+			return req, fmt.Errorf("invalid recipients JSON: %w", err)
+		}
+	}
+
 	// Validate mode
 	if req.Mode == "" {
 		return req, fmt.Errorf("mode is required")
@@ -453,7 +526,7 @@ func (s *Server) parseBulkDownloadSSEParams(r *http.Request) (BulkDownloadReques
 // generateZIPWithProgress creates ZIP file with progress events
 func (s *Server) generateZIPWithProgress(
 	ctx context.Context,
-	sse *BulkDownloadSSEWriter,
+	sse progressSender,
 	session *BulkDownloadSession,
 	assets []*services.ResolvedAsset,
 	req BulkDownloadRequest,
@@ -461,6 +534,7 @@ func (s *Server) generateZIPWithProgress(
 	username string,
 ) {
 	startTime := time.Now()
+	defer close(session.done)
 
 	s.logger.Info("Bulk download started: id=%s, assets=%d, bytes=%d, mode=%s", session.ID, len(assets), session.TotalBytes, req.Mode)
 
@@ -472,7 +546,8 @@ func (s *Server) generateZIPWithProgress(
 		Mode:        req.Mode,
 	})
 
-	// Create temp ZIP file
+	// Create the temp archive; it keeps the .zip name the cleanup matches
+	// whatever its format
 	zipPath := filepath.Join(s.downloadManager.GetTempDir(), session.ID+".zip")
 	zipFile, err := os.Create(zipPath)
 	if err != nil {
@@ -484,10 +559,10 @@ func (s *Server) generateZIPWithProgress(
 		return
 	}
 
-	zipWriter := zip.NewWriter(zipFile)
+	archive := newBulkArchive(req.ArchiveFormat, zipFile)
 
-	// Build ZIP with progress callbacks for SSE events
-	result := s.buildZIPArchive(zipWriter, assets, req, &ZIPBuildCallbacks{
+	// Build the archive with progress callbacks for SSE events
+	result := s.buildArchive(archive, assets, req, &ZIPBuildCallbacks{
 		OnAssetProcessed: func(index int, asset *services.ResolvedAsset, filename string, processedBytes int64) {
 			// Send asset progress event every N assets
 			if index%constants.BulkDownloadProgressInterval == 0 || index == len(assets)-1 {
@@ -531,20 +606,13 @@ func (s *Server) generateZIPWithProgress(
 
 	// Handle cancellation
 	if result.Cancelled {
-		zipWriter.Close()
-		zipFile.Close()
-		os.Remove(zipPath)
-		s.sendDownloadError(sse, session.ID, "Download cancelled", constants.ErrCodeStreamingError)
-		s.downloadManager.UpdateSession(session.ID, func(sess *BulkDownloadSession) {
-			sess.Status = "error"
-			sess.Error = "cancelled"
-		})
+		s.cancelZIPWithProgress(ctx, sse, session, result, req, archive, zipFile, clientIP, username)
 		return
 	}
 
-	// Close ZIP
-	if err := zipWriter.Close(); err != nil {
-		s.logger.Error("Failed to close ZIP writer: %v", err)
+	// Close the archive
+	if err := archive.Close(); err != nil {
+		s.logger.Error("Failed to close archive writer: %v", err)
 	}
 
 	// Get final file size
@@ -565,6 +633,7 @@ func (s *Server) generateZIPWithProgress(
 		sess.CompletedAt = &completedAt
 		sess.ZIPPath = zipPath
 		sess.ZIPSize = zipSize
+		sess.Format = req.ArchiveFormat
 		sess.FailedAssets = result.FailedCount
 	})
 
@@ -589,12 +658,78 @@ func (s *Server) generateZIPWithProgress(
 			TotalSize:  result.TotalSize,
 			Topics:     result.Topics,
 			Preset:     req.Preset,
+			Recipients: result.Recipients,
+		})
+	}
+}
+
+// cancelZIPWithProgress removes the partial ZIP of a cancelled download,
+// reports the progress made before cancellation and audits it.
+func (s *Server) cancelZIPWithProgress(
+	ctx context.Context,
+	sse progressSender,
+	session *BulkDownloadSession,
+	result ZIPBuildResult,
+	req BulkDownloadRequest,
+	archive bulkArchive,
+	zipFile *os.File,
+	clientIP string,
+	username string,
+) {
+	// The archive is discarded, so only buffered entries are flushed to
+	// measure it; no central directory is written
+	archive.Flush()
+	var zipBytes int64
+	if fileInfo, err := zipFile.Stat(); err == nil {
+		zipBytes = fileInfo.Size()
+	}
+	zipFile.Close()
+	if err := os.Remove(zipFile.Name()); err != nil && !os.IsNotExist(err) {
+		s.logger.Error("Failed to remove partial ZIP %s: %v", zipFile.Name(), err)
+	}
+
+	reason := constants.BulkDownloadCancelReasonDisconnected
+	if errors.Is(context.Cause(ctx), errBulkDownloadCancelRequested) {
+		reason = constants.BulkDownloadCancelReasonRequested
+	}
+	processedAssets := len(result.Manifest.Assets)
+
+	s.downloadManager.UpdateSession(session.ID, func(sess *BulkDownloadSession) {
+		sess.Status = "cancelled"
+		sess.Error = "cancelled"
+		sess.ProcessedAssets = processedAssets
+		sess.ProcessedBytes = result.TotalSize
+	})
+
+	s.logger.Info("Bulk download cancelled: id=%s, reason=%s, assets=%d/%d, bytes=%d/%d", session.ID, reason, processedAssets, session.TotalAssets, result.TotalSize, session.TotalBytes)
+
+	sse.Send("cancelled", DownloadCancelledData{
+		DownloadID:      session.ID,
+		Reason:          reason,
+		ProcessedAssets: processedAssets,
+		TotalAssets:     session.TotalAssets,
+		ProcessedBytes:  result.TotalSize,
+		TotalBytes:      session.TotalBytes,
+	})
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionDownloadCancelled, clientIP, username, audit.DownloadCancelledDetails{
+			DownloadID:      session.ID,
+			Mode:            req.Mode,
+			Reason:          reason,
+			TotalAssets:     session.TotalAssets,
+			TotalBytes:      session.TotalBytes,
+			ProcessedAssets: processedAssets,
+			ProcessedBytes:  result.TotalSize,
+			ZIPBytes:        zipBytes,
+			Topics:          result.Topics,
+			Preset:          req.Preset,
 		})
 	}
 }
 
 // sendDownloadError sends an error event
-func (s *Server) sendDownloadError(sse *BulkDownloadSSEWriter, downloadID, message, code string) {
+func (s *Server) sendDownloadError(sse progressSender, downloadID, message, code string) {
 	sse.Send("error", DownloadErrorData{
 		DownloadID: downloadID,
 		Message:    message,
@@ -602,8 +737,19 @@ func (s *Server) sendDownloadError(sse *BulkDownloadSSEWriter, downloadID, messa
 	})
 }
 
-// handleBulkDownloadFetch handles GET /api/download/bulk/{id}
+// handleBulkDownloadFetch handles GET /api/download/bulk/{id} and
+// DELETE /api/download/bulk/{id}/cancel
 func (s *Server) handleBulkDownloadFetch(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/download/bulk/"), "/")
+	if downloadID, ok := strings.CutSuffix(path, "/cancel"); ok {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleBulkDownloadCancel(w, r, downloadID)
+		return
+	}
+
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -619,8 +765,7 @@ func (s *Server) handleBulkDownloadFetch(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Extract download ID from path
-	path := strings.TrimPrefix(r.URL.Path, "/api/download/bulk/")
-	downloadID := strings.TrimSuffix(path, "/")
+	downloadID := path
 
 	if downloadID == "" {
 		WriteError(w, http.StatusBadRequest, "Download ID is required", constants.ErrCodeInvalidRequest)
@@ -648,6 +793,9 @@ func (s *Server) handleBulkDownloadFetch(w http.ResponseWriter, r *http.Request)
 	case "error":
 		WriteError(w, http.StatusInternalServerError, "Download failed: "+session.Error, constants.ErrCodeDownloadSessionNotFound)
 		return
+	case "cancelled":
+		WriteError(w, http.StatusGone, "Download was cancelled", constants.ErrCodeDownloadCancelled)
+		return
 	}
 
 	// Check if expired
@@ -673,8 +821,8 @@ func (s *Server) handleBulkDownloadFetch(w http.ResponseWriter, r *http.Request)
 	defer zipFile.Close()
 
 	// Set response headers
-	w.Header().Set(constants.HeaderContentType, constants.MimeTypeZIP)
-	w.Header().Set(constants.HeaderContentDisposition, fmt.Sprintf(constants.ContentDispositionFormat, constants.BulkDownloadZipFilename))
+	w.Header().Set(constants.HeaderContentType, archiveContentType(session.Format))
+	w.Header().Set(constants.HeaderContentDisposition, archiveDisposition(session.Format))
 	if session.ZIPSize > 0 {
 		w.Header().Set("Content-Length", fmt.Sprintf("%d", session.ZIPSize))
 	}
@@ -693,3 +841,68 @@ func (s *Server) handleBulkDownloadFetch(w http.ResponseWriter, r *http.Request)
 		downloadSuccessful = true
 	}
 }
+
+// BulkDownloadCancelResponse reports a cancelled download's progress.
+type BulkDownloadCancelResponse struct {
+	DownloadID      string `json:"download_id"`
+	Status          string `json:"status"` // "cancelled", or "processing" while cleanup is still running
+	ProcessedAssets int    `json:"processed_assets"`
+	TotalAssets     int    `json:"total_assets"`
+	ProcessedBytes  int64  `json:"processed_bytes"`
+	TotalBytes      int64  `json:"total_bytes"`
+}
+
+// handleBulkDownloadCancel handles DELETE /api/download/bulk/{id}/cancel.
+// Only the user who started a download can cancel it. The request waits
+// briefly for the partial ZIP to be removed and reports how far the
+// download got.
+func (s *Server) handleBulkDownloadCancel(w http.ResponseWriter, r *http.Request, downloadID string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionBulkDownload}) {
+		return
+	}
+
+	if downloadID == "" {
+		WriteError(w, http.StatusBadRequest, "Download ID is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	var session *BulkDownloadSession
+	if s.downloadManager != nil {
+		session = s.downloadManager.GetSession(downloadID)
+	}
+	if session == nil {
+		WriteError(w, http.StatusNotFound, "Download session not found", constants.ErrCodeDownloadSessionNotFound)
+		return
+	}
+
+	if _, ok := s.progressHub.Cancel(identity.User.ID, downloadID); !ok {
+		// Downloads of other users are not revealed
+		if snapshot, _ := s.downloadManager.Snapshot(downloadID); snapshot.Status == "pending" || snapshot.Status == "processing" {
+			WriteError(w, http.StatusNotFound, "Download session not found", constants.ErrCodeDownloadSessionNotFound)
+			return
+		}
+		WriteError(w, http.StatusConflict, "Download is not in progress", constants.ErrCodeDownloadNotInProgress)
+		return
+	}
+
+	select {
+	case <-session.done:
+	case <-time.After(constants.BulkDownloadCancelWait):
+		s.logger.Warn("Bulk download %s still cleaning up %s after cancellation", downloadID, constants.BulkDownloadCancelWait)
+	}
+
+	snapshot, _ := s.downloadManager.Snapshot(downloadID)
+	WriteSuccess(w, BulkDownloadCancelResponse{
+		DownloadID:      downloadID,
+		Status:          snapshot.Status,
+		ProcessedAssets: snapshot.ProcessedAssets,
+		TotalAssets:     snapshot.TotalAssets,
+		ProcessedBytes:  snapshot.ProcessedBytes,
+		TotalBytes:      snapshot.TotalBytes,
+	})
+}
@@ -1,9 +1,9 @@
 package server
 
 import (
-	"archive/zip"
+	"context"
 	"encoding/json"
-	"fmt"
+	"io"
 	"net/http"
 
 	"silobang/internal/audit"
@@ -12,7 +12,10 @@ import (
 	"silobang/internal/services"
 )
 
-// POST /api/download/bulk - Bulk download assets as ZIP
+// POST /api/download/bulk - Bulk download assets as a ZIP or tar archive.
+// The archive is written straight to the response as it is built, without
+// a temporary file or progress events; use /api/download/bulk/start for
+// those.
 func (s *Server) handleBulkDownload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -41,54 +44,92 @@ func (s *Server) handleBulkDownload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Convert to service request
-	serviceReq := &services.BulkResolveRequest{
-		Mode:           req.Mode,
-		Preset:         req.Preset,
-		Params:         req.Params,
-		Topics:         req.Topics,
-		AssetIDs:       req.AssetIDs,
-		FilenameFormat: req.FilenameFormat,
+	if req.Destination != "" && req.Destination != constants.ExportDestinationInbox {
+		WriteError(w, http.StatusBadRequest, "destination must be empty or \""+constants.ExportDestinationInbox+"\"", constants.ErrCodeInvalidRequest)
+		return
 	}
 
-	// Validate request via service
-	if err := s.app.Services.Bulk.ValidateRequest(serviceReq); err != nil {
+	assets, err := s.resolveBulkDownload(&req)
+	if err != nil {
 		s.handleServiceError(w, err)
 		return
 	}
+	if req.Destination == constants.ExportDestinationInbox && req.ArchiveFormat != constants.ArchiveFormatZIP {
+		WriteError(w, http.StatusBadRequest, "exports to the inbox are always ZIP archives", constants.ErrCodeInvalidRequest)
+		return
+	}
 
-	// Resolve assets via service
-	assets, err := s.app.Services.Bulk.ResolveAssets(serviceReq)
-	if err != nil {
-		s.handleServiceError(w, err)
+	// Reject before streaming if any asset would be denied
+	if result := s.preflightBulkDownload(identity, assets); !result.Allowed {
+		writePreflightDenied(w, result)
 		return
 	}
 
-	// Validate asset count via service
-	if err := s.app.Services.Bulk.ValidateAssetCount(len(assets)); err != nil {
-		s.handleServiceError(w, err)
+	if req.Destination == constants.ExportDestinationInbox {
+		s.startExport(w, identity, assets, req, getClientIP(r), getAuditUsername(identity))
 		return
 	}
 
-	// Use validated filename format from service (may have been set to default)
-	req.FilenameFormat = serviceReq.FilenameFormat
+	// Stream the archive
+	s.streamArchive(r.Context(), w, assets, req, getClientIP(r), getAuditUsername(identity))
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
 
-	// Stream ZIP response
-	s.streamZIPArchive(w, assets, req, getClientIP(r), getAuditUsername(identity))
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
 }
 
-func (s *Server) streamZIPArchive(w http.ResponseWriter, assets []*services.ResolvedAsset, req BulkDownloadRequest, clientIP string, username string) {
+// streamArchive writes the archive of assets to w as it is built. Building
+// stops, mid-asset if need be, once the client goes away, and is audited
+// as a download_cancelled rather than a downloaded_bulk.
+func (s *Server) streamArchive(ctx context.Context, w http.ResponseWriter, assets []*services.ResolvedAsset, req BulkDownloadRequest, clientIP string, username string) {
 	// Set response headers for streaming
-	w.Header().Set(constants.HeaderContentType, constants.MimeTypeZIP)
-	w.Header().Set(constants.HeaderContentDisposition, fmt.Sprintf(constants.ContentDispositionFormat, constants.BulkDownloadZipFilename))
+	w.Header().Set(constants.HeaderContentType, archiveContentType(req.ArchiveFormat))
+	w.Header().Set(constants.HeaderContentDisposition, archiveDisposition(req.ArchiveFormat))
 	w.Header().Set(constants.HeaderTransferEncoding, constants.TransferEncodingChunked)
 
-	// Create zip writer
-	zipWriter := zip.NewWriter(w)
-	defer zipWriter.Close()
-
-	// Delegate to shared ZIP building logic
-	result := s.buildZIPArchive(zipWriter, assets, req, nil)
+	// Flushing switches buffering middleware, such as gzip, to pass writes
+	// through, so the archive is never held in memory
+	w.WriteHeader(http.StatusOK)
+	http.NewResponseController(w).Flush()
+
+	counter := &countingWriter{w: w}
+	archive := newBulkArchive(req.ArchiveFormat, counter)
+
+	// Delegate to shared archive building logic
+	result := s.buildArchive(archive, assets, req, &ZIPBuildCallbacks{
+		CheckCancelled: func() bool { return ctx.Err() != nil },
+	})
+
+	if result.Cancelled {
+		var totalBytes int64
+		for _, asset := range assets {
+			totalBytes += asset.Asset.AssetSize
+		}
+		s.logger.Info("Streamed bulk download cancelled: client disconnected after %d/%d assets", len(result.Manifest.Assets), len(assets))
+		if s.app.AuditLogger != nil {
+			s.app.AuditLogger.Log(constants.AuditActionDownloadCancelled, clientIP, username, audit.DownloadCancelledDetails{
+				Mode:            req.Mode,
+				Reason:          constants.BulkDownloadCancelReasonDisconnected,
+				TotalAssets:     len(assets),
+				TotalBytes:      totalBytes,
+				ProcessedAssets: len(result.Manifest.Assets),
+				ProcessedBytes:  result.TotalSize,
+				ZIPBytes:        counter.n,
+				Topics:          result.Topics,
+				Preset:          req.Preset,
+			})
+		}
+		return
+	}
+	archive.Close()
 
 	// Audit log for bulk download
 	if s.app.AuditLogger != nil {
@@ -98,6 +139,7 @@ func (s *Server) streamZIPArchive(w http.ResponseWriter, assets []*services.Reso
 			TotalSize:  result.TotalSize,
 			Topics:     result.Topics,
 			Preset:     req.Preset,
+			Recipients: result.Recipients,
 		})
 	}
 }
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Collection Routes Handler
+// =============================================================================
+
+// /api/topics/:name/collections/... routes
+// subPath is everything after /api/topics/:name/ (starts with "collections")
+func (s *Server) handleCollectionRoutes(w http.ResponseWriter, r *http.Request, topicName, subPath string) {
+	remaining := strings.TrimPrefix(subPath, "collections")
+	remaining = strings.TrimPrefix(remaining, "/")
+
+	if remaining == "" {
+		switch r.Method {
+		case http.MethodGet:
+			s.listCollections(w, r, topicName)
+		case http.MethodPost:
+			s.createCollection(w, r, topicName)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	parts := strings.SplitN(remaining, "/", 2)
+	collection := parts[0]
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			s.getCollection(w, r, topicName, collection)
+		case http.MethodPatch:
+			s.updateCollection(w, r, topicName, collection)
+		case http.MethodDelete:
+			s.deleteCollection(w, r, topicName, collection)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if parts[1] != "assets" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.changeCollectionAssets(w, r, topicName, collection, "add")
+	case http.MethodDelete:
+		s.changeCollectionAssets(w, r, topicName, collection, "remove")
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /api/topics/:name/collections - List collections in a topic
+func (s *Server) listCollections(w http.ResponseWriter, r *http.Request, topicName string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionQuery,
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	collections, err := s.app.Services.Collection.List(topicName)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"topic":       topicName,
+		"collections": collections,
+	})
+}
+
+// POST /api/topics/:name/collections - Create a collection
+func (s *Server) createCollection(w http.ResponseWriter, r *http.Request, topicName string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionMetadata,
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	var req services.CollectionCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if !s.checkDiskLimit(w, r, identity, "collection_create") {
+		return
+	}
+
+	collection, err := s.app.Services.Collection.Create(topicName, &req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.Services.Auth != nil {
+		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionMetadata, 0)
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionCollectionCreated, getClientIP(r), getAuditUsername(identity), audit.CollectionCreatedDetails{
+			TopicName:  topicName,
+			Collection: collection.Name,
+		})
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"success":    true,
+		"collection": collection,
+	})
+}
+
+// GET /api/topics/:name/collections/:collection - Get collection with member asset IDs
+func (s *Server) getCollection(w http.ResponseWriter, r *http.Request, topicName, collection string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionQuery,
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	var limit, offset int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, _ = strconv.Atoi(v)
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, _ = strconv.Atoi(v)
+	}
+
+	detail, err := s.app.Services.Collection.Get(topicName, collection, limit, offset)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, detail)
+}
+
+// PATCH /api/topics/:name/collections/:collection - Update collection description
+func (s *Server) updateCollection(w http.ResponseWriter, r *http.Request, topicName, collection string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionMetadata,
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	var req services.CollectionUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	updated, err := s.app.Services.Collection.Update(topicName, collection, &req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.Services.Auth != nil {
+		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionMetadata, 0)
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionCollectionUpdated, getClientIP(r), getAuditUsername(identity), audit.CollectionUpdatedDetails{
+			TopicName:  topicName,
+			Collection: collection,
+		})
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"success":    true,
+		"collection": updated,
+	})
+}
+
+// DELETE /api/topics/:name/collections/:collection - Delete collection (assets are kept)
+func (s *Server) deleteCollection(w http.ResponseWriter, r *http.Request, topicName, collection string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionMetadata,
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	freed, err := s.app.Services.Collection.Delete(topicName, collection)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.Services.Auth != nil {
+		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionMetadata, 0)
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionCollectionDeleted, getClientIP(r), getAuditUsername(identity), audit.CollectionDeletedDetails{
+			TopicName:   topicName,
+			Collection:  collection,
+			AssetsFreed: freed,
+		})
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"success":      true,
+		"assets_freed": freed,
+	})
+}
+
+// POST /api/topics/:name/collections/:collection/assets - Add assets to collection
+// DELETE /api/topics/:name/collections/:collection/assets - Remove assets from collection
+func (s *Server) changeCollectionAssets(w http.ResponseWriter, r *http.Request, topicName, collection, op string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionMetadata,
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	var req services.CollectionAssetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	var result *services.CollectionAssetsResult
+	var err error
+	if op == "add" {
+		if !s.checkDiskLimit(w, r, identity, "collection_add") {
+			return
+		}
+		result, err = s.app.Services.Collection.AddAssets(topicName, collection, &req)
+	} else {
+		result, err = s.app.Services.Collection.RemoveAssets(topicName, collection, &req)
+	}
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.Services.Auth != nil {
+		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionMetadata, 0)
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionCollectionAssets, getClientIP(r), getAuditUsername(identity), audit.CollectionAssetsDetails{
+			TopicName:  topicName,
+			Collection: collection,
+			Op:         op,
+			Requested:  result.Requested,
+			Changed:    result.Changed,
+		})
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"success":   true,
+		"requested": result.Requested,
+		"changed":   result.Changed,
+		"missing":   result.Missing,
+	})
+}
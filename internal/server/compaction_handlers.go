@@ -0,0 +1,146 @@
+package server
+
+import (
+	"net/http"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Asset Deletion and Compaction Handlers
+// =============================================================================
+
+// DELETE /api/assets/:hash - Move an asset nothing references to the trash,
+// from where it is restored or purged, leaving a tombstone until its .dat
+// file is compacted (requires manage_topics delete on the asset's topic)
+func (s *Server) deleteAsset(w http.ResponseWriter, r *http.Request, hash string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	info, err := s.app.Services.Asset.GetInfo(hash)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageTopics,
+		SubAction: "delete",
+		TopicName: info.TopicName,
+	}) {
+		return
+	}
+
+	entry, err := s.app.Services.Trash.Trash(hash, getAuditUsername(identity), getClientIP(r))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, entry)
+}
+
+// GET  /api/topics/:name/compaction - Tombstones awaiting compaction and the latest run
+// POST /api/topics/:name/compaction - Compact every .dat file holding tombstones
+func (s *Server) handleTopicCompaction(w http.ResponseWriter, r *http.Request, topicName string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageTopics,
+		SubAction: "delete",
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		status, err := s.app.Services.Compaction.Status(topicName)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, status)
+		return
+	}
+
+	if err := s.app.Services.Compaction.Trigger(topicName); err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	s.logger.Info("Compaction of topic %s started by %s", topicName, getAuditUsername(identity))
+	WriteJSON(w, http.StatusAccepted, map[string]interface{}{
+		"started": true,
+		"topic":   topicName,
+	})
+}
+
+// GET /api/topics/:name/compaction/stream - SSE progress of the topic's
+// compaction runs, starting with the current status
+func (s *Server) streamTopicCompaction(w http.ResponseWriter, r *http.Request, topicName string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageTopics,
+		SubAction: "delete",
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	status, err := s.app.Services.Compaction.Status(topicName)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	release := s.acquireSSE(w, r, constants.SSEEndpointCompaction, identity)
+	if release == nil {
+		return
+	}
+	defer release()
+
+	sse, err := NewSSEWriter(w)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "Streaming not supported",
+			constants.ErrCodeStreamingError)
+		return
+	}
+
+	ch := s.app.Services.Compaction.Subscribe(topicName)
+	defer s.app.Services.Compaction.Unsubscribe(ch)
+
+	sse.Send("status", status)
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			sse.Send(event.Type, event.Data)
+		}
+	}
+}
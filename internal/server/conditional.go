@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Conditional Requests for Content-Addressed Data
+// =============================================================================
+
+// assetETag returns the strong ETag for a content-addressed asset.
+// The BLAKE3 hash identifies the bytes exactly, so it is used verbatim.
+func assetETag(hash string) string {
+	return `"` + hash + `"`
+}
+
+// assetETagVariant returns the strong ETag for bytes derived from an asset,
+// such as a gzip encoding or a preview. They differ from the stored bytes,
+// so they must not share its ETag.
+func assetETagVariant(hash, variant string) string {
+	return `"` + hash + "-" + variant + `"`
+}
+
+// setAssetCacheHeaders sets validators and caching policy for immutable asset bytes.
+// Overrides SecurityHeaders' "Cache-Control: no-store". Any endpoint serving bytes
+// derived purely from an asset hash (previews, thumbnails) should use the same headers.
+func setAssetCacheHeaders(w http.ResponseWriter, etag string, modTime time.Time) {
+	w.Header().Set(constants.HeaderETag, etag)
+	w.Header().Set(constants.HeaderCacheControl, constants.CacheControlAsset)
+	if !modTime.IsZero() {
+		w.Header().Set(constants.HeaderLastModified, modTime.UTC().Format(http.TimeFormat))
+	}
+}
+
+// checkNotModified evaluates If-None-Match and If-Modified-Since (RFC 9110 §13.1)
+// and writes a 304 response when the client's cached copy is still valid.
+// If-Modified-Since is only consulted when If-None-Match is absent.
+// Returns true if the response has been written.
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string, modTime time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	notModified := false
+	if inm := r.Header.Get(constants.HeaderIfNoneMatch); inm != "" {
+		notModified = etagListMatches(inm, etag)
+	} else if ims := r.Header.Get(constants.HeaderIfModifiedSince); ims != "" && !modTime.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil {
+			notModified = !modTime.Truncate(time.Second).After(t)
+		}
+	}
+
+	if !notModified {
+		return false
+	}
+
+	// 304 must not carry a body or content headers
+	h := w.Header()
+	delete(h, constants.HeaderContentType)
+	delete(h, "Content-Length")
+	delete(h, constants.HeaderContentDisposition)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagListMatches reports whether an If-None-Match header value matches etag.
+// Uses weak comparison as required for If-None-Match, and honours "*".
+func etagListMatches(header, etag string) bool {
+	target := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+func TestEtagListMatches(t *testing.T) {
+	etag := assetETag("abc")
+
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`*`, true},
+		{`"xyz"`, false},
+		{`abc`, false},
+	}
+
+	for _, tt := range tests {
+		if got := etagListMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagListMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCheckNotModified_IfNoneMatch(t *testing.T) {
+	etag := assetETag("abc")
+	modTime := time.Unix(1700000000, 0)
+
+	req := httptest.NewRequest("GET", "/api/assets/abc/download", nil)
+	req.Header.Set(constants.HeaderIfNoneMatch, etag)
+	rec := httptest.NewRecorder()
+	rec.Header().Set(constants.HeaderContentType, "image/png")
+
+	if !checkNotModified(rec, req, etag, modTime) {
+		t.Fatal("expected not modified")
+	}
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", rec.Code)
+	}
+	if rec.Header().Get(constants.HeaderContentType) != "" {
+		t.Error("304 response must not carry Content-Type")
+	}
+}
+
+func TestCheckNotModified_IfNoneMatchTakesPrecedence(t *testing.T) {
+	etag := assetETag("abc")
+	modTime := time.Unix(1700000000, 0)
+
+	// Mismatching ETag wins over a satisfied If-Modified-Since
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(constants.HeaderIfNoneMatch, `"other"`)
+	req.Header.Set(constants.HeaderIfModifiedSince, modTime.Add(time.Hour).UTC().Format(http.TimeFormat))
+	rec := httptest.NewRecorder()
+
+	if checkNotModified(rec, req, etag, modTime) {
+		t.Error("expected modified when ETag does not match")
+	}
+}
+
+func TestCheckNotModified_IfModifiedSince(t *testing.T) {
+	etag := assetETag("abc")
+	modTime := time.Unix(1700000000, 0)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(constants.HeaderIfModifiedSince, modTime.UTC().Format(http.TimeFormat))
+	if !checkNotModified(httptest.NewRecorder(), req, etag, modTime) {
+		t.Error("expected not modified for equal timestamp")
+	}
+
+	req.Header.Set(constants.HeaderIfModifiedSince, modTime.Add(-time.Hour).UTC().Format(http.TimeFormat))
+	if checkNotModified(httptest.NewRecorder(), req, etag, modTime) {
+		t.Error("expected modified for older timestamp")
+	}
+}
+
+func TestCheckNotModified_IgnoresUnsafeMethods(t *testing.T) {
+	etag := assetETag("abc")
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set(constants.HeaderIfNoneMatch, etag)
+	if checkNotModified(httptest.NewRecorder(), req, etag, time.Time{}) {
+		t.Error("conditional GET handling must not apply to POST")
+	}
+}
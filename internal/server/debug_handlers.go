@@ -0,0 +1,119 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Debug Handlers
+// =============================================================================
+
+// Every debug route requires the debug grant and answers 404 unless
+// debug.enabled is set, so profiling stays off unless opted into. The
+// handlers net/http/pprof registers on http.DefaultServeMux are never
+// served: the server uses its own mux.
+
+// GET|POST /api/admin/debug/pprof/:name - net/http/pprof, served from the
+// admin API instead of /debug/pprof
+func (s *Server) handleDebugPprof(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if !s.debugEnabled(w) {
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, constants.DebugPprofPrefix)
+	switch name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		// Named runtime profiles; unknown names get 404 from pprof
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
+
+// GET /api/admin/debug/metrics - Process info and every runtime metric
+func (s *Server) handleDebugMetrics(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if !s.debugEnabled(w) {
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"info":    s.app.Services.Debug.Info(),
+		"metrics": s.app.Services.Debug.RuntimeMetrics(),
+	})
+}
+
+// GET /api/admin/debug/bundle?seconds=30 - Capture a CPU profile, then
+// download it as a zip with the heap and other runtime profiles, the
+// goroutine dump and the runtime metrics, ready to attach to a support ticket
+func (s *Server) handleDebugBundle(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if !s.debugEnabled(w) {
+		return
+	}
+
+	seconds := constants.DebugBundleDefaultSeconds
+	if raw := r.URL.Query().Get("seconds"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid seconds", constants.ErrCodeInvalidRequest)
+			return
+		}
+		seconds = n
+	}
+
+	cpuProfile, err := s.app.Services.Debug.CaptureCPUProfile(r.Context(), seconds)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return // Client went away
+		}
+		s.handleServiceError(w, err)
+		return
+	}
+
+	// Built in memory so a failure can still be reported as JSON
+	var buf bytes.Buffer
+	if err := s.app.Services.Debug.WriteBundle(&buf, cpuProfile); err != nil {
+		s.logger.Error("Failed to write debug bundle: %v", err)
+		WriteError(w, http.StatusInternalServerError, "Failed to write debug bundle", constants.ErrCodeInternalError)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionDebugBundle, getClientIP(r), getAuditUsername(identity), audit.DebugBundleDetails{
+			Seconds: seconds,
+			Size:    int64(buf.Len()),
+		})
+	}
+
+	filename := fmt.Sprintf("%s%s.zip", constants.DebugBundleFilenamePrefix, time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Write(buf.Bytes())
+}
+
+// debugEnabled reports whether debug.enabled is set, writing a 404 when not.
+func (s *Server) debugEnabled(w http.ResponseWriter) bool {
+	if !s.app.Config.Debug.Enabled {
+		WriteError(w, http.StatusNotFound, "Debug endpoints are disabled", constants.ErrCodeDebugDisabled)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Deletion Request Handlers
+// =============================================================================
+
+// Every route requires authentication only: proposing a request and deciding
+// it each require manage_topics delete on every topic of its assets, which
+// the service checks once it has resolved them. Users see the requests they
+// made and the ones they may decide.
+
+// GET  /api/deletion-requests - Newest requests. Query params: status.
+// POST /api/deletion-requests - Propose deleting assets by IDs or by query
+func (s *Server) handleDeletionRequests(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if r.Method == http.MethodGet {
+		requests, err := s.app.Services.Deletions.List(r.URL.Query().Get("status"), identity.User, s.allowedFor(identity))
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"requests": requests,
+		})
+		return
+	}
+
+	var req services.DeletionRequestCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+	created, notFound, err := s.app.Services.Deletions.Create(&req, identity.User, getClientIP(r), s.allowedFor(identity))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"request":   created,
+		"not_found": notFound,
+	})
+}
+
+// GET  /api/deletion-requests/:id         - Request with its assets
+// POST /api/deletion-requests/:id/approve - Approve and run the deletion; {"comment": "..."}
+// POST /api/deletion-requests/:id/reject  - Reject; {"comment": "..."}
+// POST /api/deletion-requests/:id/cancel  - Withdraw, by the requester
+func (s *Server) handleDeletionRequestRoutes(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	idPart, action, _ := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/deletion-requests/"), "/"), "/")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid deletion request ID", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		detail, err := s.app.Services.Deletions.Get(id, identity.User, s.allowedFor(identity))
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, detail)
+		return
+	case action == "approve" || action == "reject" || action == "cancel":
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+	case action == "":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	default:
+		WriteError(w, http.StatusNotFound, "Not found", constants.ErrCodeDeletionRequestNotFound)
+		return
+	}
+
+	var req struct {
+		Comment string `json:"comment"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+			return
+		}
+	}
+
+	deletions := s.app.Services.Deletions
+	var result interface{}
+	switch action {
+	case "approve":
+		result, err = deletions.Approve(id, identity.User, req.Comment, getClientIP(r), s.allowedFor(identity))
+	case "reject":
+		result, err = deletions.Reject(id, identity.User, req.Comment, getClientIP(r), s.allowedFor(identity))
+	default:
+		result, err = deletions.Cancel(id, identity.User, getClientIP(r))
+	}
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"request": result,
+	})
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Storage Event Handlers
+// =============================================================================
+
+// GET /api/events/stream - Typed storage events (SSE): asset.created,
+// topic.created, metadata.changed, user.updated, ... Query params: types
+// (comma-separated, default all), last_event_id. A client reconnecting with
+// Last-Event-ID, or last_event_id, is first sent the events it missed.
+// Requires view_audit stream; users who cannot view every audit entry only
+// receive their own events.
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	result, ok := s.authorizeWithResult(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionViewAudit,
+		SubAction: "stream",
+	})
+	if !ok {
+		return
+	}
+
+	if s.app.AuditLogger == nil {
+		WriteError(w, http.StatusBadRequest, "Audit logging not configured",
+			constants.ErrCodeNotConfigured)
+		return
+	}
+
+	events := s.app.Services.Events
+	types, err := events.ParseTypes(r.URL.Query().Get("types"))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	var lastID int64
+	resume := r.Header.Get(constants.HeaderLastEventID)
+	if resume == "" {
+		resume = r.URL.Query().Get("last_event_id")
+	}
+	if resume != "" {
+		lastID, err = strconv.ParseInt(resume, 10, 64)
+		if err != nil || lastID < 0 {
+			WriteError(w, http.StatusBadRequest, "Invalid last event ID", constants.ErrCodeInvalidRequest)
+			return
+		}
+	}
+
+	// Only events of the user themself unless they can view all audit entries
+	username := ""
+	if !auth.CanViewAllAudit(identity, result.MatchedGrant) {
+		username = getAuditUsername(identity)
+	}
+
+	release := s.acquireSSE(w, r, constants.SSEEndpointEvents, identity)
+	if release == nil {
+		return
+	}
+	defer release()
+
+	sse, err := NewSSEWriter(w)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "Streaming not supported",
+			constants.ErrCodeStreamingError)
+		return
+	}
+
+	// Subscribe before replaying so nothing recorded in between is lost;
+	// entries delivered by both are skipped by ID
+	ch := s.app.AuditLogger.Subscribe()
+	defer s.app.AuditLogger.Unsubscribe(ch)
+
+	sse.Send(constants.EventStreamEventConnected, map[string]interface{}{
+		"types": types,
+	})
+
+	if resume != "" {
+		missed, truncated, err := events.Replay(lastID, types, username)
+		if err != nil {
+			s.logger.Error("Event stream replay after %d failed: %v", lastID, err)
+			return
+		}
+		for _, event := range missed {
+			if sse.SendWithID(event.ID, event) != nil {
+				return
+			}
+			lastID = event.ID
+		}
+		if truncated {
+			sse.Send(constants.EventStreamEventReplayTruncated, map[string]interface{}{
+				"replayed":      len(missed),
+				"last_event_id": lastID,
+			})
+		}
+	}
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry, ok := <-ch:
+			if !ok {
+				return
+			}
+			if entry.ID <= lastID || (username != "" && entry.Username != username) {
+				continue
+			}
+			event, ok := events.FromEntry(entry, types)
+			if !ok {
+				continue
+			}
+			if sse.SendWithID(event.ID, event) != nil {
+				return
+			}
+			lastID = event.ID
+		}
+	}
+}
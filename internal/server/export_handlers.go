@@ -0,0 +1,206 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Export Inbox Handlers
+// =============================================================================
+
+// GET /api/exports - Current user's export inbox
+func (s *Server) handleExports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	exports, ok := s.exportService(w)
+	if !ok {
+		return
+	}
+
+	inbox, err := exports.List(identity.User.ID)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, inbox)
+}
+
+// /api/exports/{id} - GET (download, supports Range) or DELETE
+func (s *Server) handleExportRoutes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	exportID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/exports/"), "/")
+	if exportID == "" {
+		WriteError(w, http.StatusBadRequest, "Export ID is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	exports, ok := s.exportService(w)
+	if !ok {
+		return
+	}
+
+	// Owners may always clean up their inbox, even after losing the grant
+	if r.Method == http.MethodDelete {
+		if err := exports.Delete(identity.User.ID, exportID); err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"success": true,
+			"id":      exportID,
+		})
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionBulkDownload}) {
+		return
+	}
+
+	export, f, err := exports.Open(identity.User.ID, exportID)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	defer f.Close()
+
+	// ServeContent answers Range and If-Range requests, so interrupted
+	// downloads can be resumed
+	w.Header().Set(constants.HeaderContentType, constants.MimeTypeZIP)
+	w.Header().Set(constants.HeaderContentDisposition,
+		fmt.Sprintf(constants.ContentDispositionFormat, fmt.Sprintf(constants.ExportFilenameFormat, export.ID)))
+	w.Header().Set("ETag", `"`+export.ID+`"`)
+	http.ServeContent(streamingResponseWriter{w}, r, "", time.Unix(*export.CompletedAt, 0), f)
+}
+
+// streamingResponseWriter flushes as soon as the status is written, which
+// switches buffering middleware (gzip) to streaming before a large body.
+type streamingResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w streamingResponseWriter) WriteHeader(code int) {
+	w.ResponseWriter.WriteHeader(code)
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w streamingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// startExport queues a bulk download into the user's export inbox and builds
+// it in the background. Responds 202 with the processing export.
+func (s *Server) startExport(w http.ResponseWriter, identity *auth.Identity, assets []*services.ResolvedAsset, req BulkDownloadRequest, clientIP, username string) {
+	exports, ok := s.exportService(w)
+	if !ok {
+		return
+	}
+
+	var totalBytes int64
+	for _, asset := range assets {
+		totalBytes += asset.Asset.AssetSize
+	}
+
+	export, err := exports.Create(identity.User.ID, services.ExportRequest{
+		Mode:           req.Mode,
+		Preset:         req.Preset,
+		Topics:         req.Topics,
+		AssetCount:     len(assets),
+		EstimatedBytes: totalBytes,
+	})
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	go s.buildExport(exports, export, assets, req, clientIP, username)
+
+	WriteJSON(w, http.StatusAccepted, map[string]interface{}{
+		"success": true,
+		"export":  export,
+	})
+}
+
+// buildExport writes the ZIP of an inbox export and records the outcome.
+func (s *Server) buildExport(exports *services.ExportService, export *database.Export, assets []*services.ResolvedAsset, req BulkDownloadRequest, clientIP, username string) {
+	zipPath := exports.Path(export)
+	if err := os.MkdirAll(filepath.Dir(zipPath), constants.DirPermissions); err != nil {
+		exports.Fail(export, "failed to create export directory")
+		return
+	}
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		exports.Fail(export, "failed to create ZIP file")
+		return
+	}
+
+	archive := newBulkArchive(constants.ArchiveFormatZIP, zipFile)
+	result := s.buildArchive(archive, assets, req, nil)
+	if err := archive.Close(); err != nil {
+		zipFile.Close()
+		exports.Fail(export, "failed to write ZIP file")
+		return
+	}
+
+	var zipSize int64
+	if info, err := zipFile.Stat(); err == nil {
+		zipSize = info.Size()
+	}
+	if err := zipFile.Close(); err != nil {
+		exports.Fail(export, "failed to write ZIP file")
+		return
+	}
+
+	exports.Complete(export, result.Manifest.AssetCount, result.FailedCount, zipSize)
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionDownloadedBulk, clientIP, username, audit.DownloadedBulkDetails{
+			Mode:       req.Mode,
+			AssetCount: result.Manifest.AssetCount,
+			TotalSize:  result.TotalSize,
+			Topics:     result.Topics,
+			Preset:     req.Preset,
+			Recipients: result.Recipients,
+			ExportID:   export.ID,
+		})
+	}
+}
+
+// exportService returns the export service, writing a 503 when it is
+// unavailable (no working directory configured yet).
+func (s *Server) exportService(w http.ResponseWriter) (*services.ExportService, bool) {
+	if s.app.Services.Export == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Export inbox not available", constants.ErrCodeNotConfigured)
+		return nil, false
+	}
+	return s.app.Services.Export, true
+}
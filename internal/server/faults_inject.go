@@ -0,0 +1,134 @@
+//go:build faultinject
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/faults"
+)
+
+// faultInjector is the active fault rule set in test builds.
+type faultInjector = faults.Injector
+
+func newFaultInjector() *faultInjector {
+	return faults.NewInjector()
+}
+
+// faultInjection applies the fault rules matching each request: latency
+// before the handler, error responses instead of it, and truncated or slowed
+// response bodies. It sits outside compression so truncation counts the bytes
+// that actually go on the wire. The fault admin API itself is never faulted.
+func (s *Server) faultInjection(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, constants.FaultsAPIPath) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		plan := s.faults.Match(r.Method, r.URL.Path)
+		if plan.Delay > 0 && !faults.Sleep(r.Context(), plan.Delay) {
+			return
+		}
+		if plan.Status != 0 {
+			WriteError(w, plan.Status, "Injected fault", constants.ErrCodeFaultInjected)
+			return
+		}
+		if !plan.WrapsResponse() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		fw := faults.NewWriter(r.Context(), w, plan)
+		next.ServeHTTP(fw, r)
+		if fw.Truncated() {
+			// Abort without finishing the response so the client sees a
+			// broken transfer even when no Content-Length was sent.
+			panic(http.ErrAbortHandler)
+		}
+	})
+}
+
+// registerFaultRoutes exposes the fault admin API.
+func (s *Server) registerFaultRoutes(mux *http.ServeMux) {
+	mux.HandleFunc(constants.FaultsAPIPath, s.handleFaults)
+	mux.HandleFunc(constants.FaultsAPIPath+"/", s.handleFaultRoute)
+}
+
+// requireFaultAdmin authenticates the caller and requires manage_config.
+func (s *Server) requireFaultAdmin(w http.ResponseWriter, r *http.Request) bool {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return false
+	}
+	return s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageConfig})
+}
+
+// GET /api/admin/faults - List fault rules
+// POST /api/admin/faults - Add a fault rule
+// DELETE /api/admin/faults - Remove every fault rule
+func (s *Server) handleFaults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.requireFaultAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		WriteSuccess(w, map[string]interface{}{
+			"rules": s.faults.List(),
+		})
+
+	case http.MethodPost:
+		var rule faults.Rule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+			return
+		}
+		added, err := s.faults.Add(rule)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error(), constants.ErrCodeInvalidRequest)
+			return
+		}
+		s.logger.Warn("Fault injection: added %s fault %s on %s", added.Kind, added.ID, added.Route)
+		WriteJSON(w, http.StatusCreated, added)
+
+	case http.MethodDelete:
+		removed := s.faults.Reset()
+		s.logger.Warn("Fault injection: cleared %d fault(s)", removed)
+		WriteSuccess(w, map[string]interface{}{
+			"removed": removed,
+		})
+	}
+}
+
+// DELETE /api/admin/faults/{id} - Remove one fault rule
+func (s *Server) handleFaultRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.requireFaultAdmin(w, r) {
+		return
+	}
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, constants.FaultsAPIPath+"/"), "/")
+	if !s.faults.Remove(id) {
+		WriteError(w, http.StatusNotFound, "Fault rule not found", constants.ErrCodeFaultNotFound)
+		return
+	}
+
+	s.logger.Warn("Fault injection: removed fault %s", id)
+	WriteSuccess(w, map[string]interface{}{
+		"removed": id,
+	})
+}
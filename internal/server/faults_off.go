@@ -0,0 +1,21 @@
+//go:build !faultinject
+
+package server
+
+import "net/http"
+
+// faultInjector is empty in regular builds: fault injection and its admin
+// API only exist in binaries built with -tags faultinject.
+type faultInjector struct{}
+
+func newFaultInjector() *faultInjector {
+	return nil
+}
+
+// faultInjection is a pass-through in regular builds.
+func (s *Server) faultInjection(next http.Handler) http.Handler {
+	return next
+}
+
+// registerFaultRoutes registers nothing in regular builds.
+func (s *Server) registerFaultRoutes(mux *http.ServeMux) {}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// POST /api/federation/query/:preset - Run a read-only preset on this
+// instance and every configured peer, merging the rows
+func (s *Server) handleFederatedQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	presetName := strings.TrimPrefix(r.URL.Path, "/api/federation/query/")
+	if presetName == "" {
+		WriteError(w, http.StatusBadRequest, "Preset name is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:     constants.AuthActionQuery,
+		PresetName: presetName,
+	}) {
+		return
+	}
+
+	var req services.QueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		// Empty body is OK - use defaults
+		req = services.QueryRequest{}
+	}
+
+	// The local part attaches federated preset topics: check them like a
+	// regular query. Peers enforce their own grants for the configured key.
+	federatedTopics, err := s.app.Services.Query.FederatedTopics(presetName, &req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	for _, topic := range federatedTopics {
+		if !s.authorize(w, identity, &auth.ActionContext{
+			Action:     constants.AuthActionQuery,
+			PresetName: presetName,
+			TopicName:  topic,
+		}) {
+			return
+		}
+	}
+	if federatedTopics == nil {
+		// Topic names are sent to every peer as is, so a caller limited to
+		// some topics must name them rather than have the fan-out narrowed
+		topics, ok := s.authorizeQueryTopics(w, identity, presetName, req.Topics)
+		if !ok {
+			return
+		}
+		if len(req.Topics) == 0 && topics != nil {
+			WriteError(w, http.StatusForbidden, "name the topics to query: grants do not cover every topic", constants.ErrCodeAuthConstraintViolation)
+			return
+		}
+	}
+
+	result, err := s.app.Services.Federation.Query(presetName, &req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.Services.Auth != nil {
+		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionQuery, 0)
+	}
+
+	if s.app.AuditLogger != nil {
+		var answered []string
+		for _, instance := range result.Instances {
+			if instance.Status == constants.FederationPeerStatusOK {
+				answered = append(answered, instance.Name)
+			}
+		}
+		s.app.AuditLogger.Log(constants.AuditActionQuerying, getClientIP(r), getAuditUsername(identity), audit.QueryingDetails{
+			Preset:    presetName,
+			Topics:    req.Topics,
+			RowCount:  result.RowCount,
+			Instances: answered,
+		})
+	}
+
+	WriteSuccess(w, result)
+}
+
+// GET /api/federation/peers - Probe every configured peer for reachability
+// and latency
+func (s *Server) handleFederationPeers(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	WriteSuccess(w, map[string]interface{}{
+		"instance_name": s.app.Config.Federation.InstanceName,
+		"enabled":       s.app.Config.Federation.Enabled(),
+		"peers":         s.app.Services.Federation.Health(),
+	})
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Remote Fetch Handlers
+// =============================================================================
+
+// FetchRequest is the body of POST /api/topics/:name/assets/fetch.
+type FetchRequest struct {
+	URLs []string `json:"urls"`
+}
+
+// FetchURLResult is the outcome of one URL of a fetch request.
+type FetchURLResult struct {
+	URL           string `json:"url"`
+	Success       bool   `json:"success"`
+	Status        string `json:"status"` // created, deduplicated, aliased or rejected
+	Hash          string `json:"hash,omitempty"`
+	Size          int64  `json:"size,omitempty"`
+	Filename      string `json:"filename,omitempty"`
+	ExistingTopic string `json:"existing_topic,omitempty"`
+	Quarantined   bool   `json:"quarantined,omitempty"`
+	Attempts      int    `json:"attempts,omitempty"`
+	Error         string `json:"error,omitempty"`
+	Code          string `json:"code,omitempty"`
+}
+
+// FetchResponse reports every URL of a fetch request, in request order.
+type FetchResponse struct {
+	Success   bool             `json:"success"`
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []FetchURLResult `json:"results"`
+}
+
+// POST /api/topics/:name/assets/fetch - Download files from URLs into a
+// topic. Each file is stored as an upload by the caller: its extension and
+// size are checked against their upload grant once known, and it is hashed
+// and deduplicated as usual. One URL failing does not stop the others.
+func (s *Server) fetchAssets(w http.ResponseWriter, r *http.Request, topicName string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionUpload,
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	if s.app.Config.WorkingDirectory == "" {
+		WriteError(w, http.StatusBadRequest, "Working directory not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+	if !s.app.TopicExists(topicName) {
+		WriteError(w, http.StatusNotFound, "Topic not found: "+topicName, constants.ErrCodeTopicNotFound)
+		return
+	}
+	if s.app.Config.IsTopicFrozen(topicName) {
+		WriteError(w, http.StatusConflict, "Topic "+topicName+" is frozen", constants.ErrCodeTopicFrozen)
+		return
+	}
+
+	var req FetchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+	if len(req.URLs) == 0 {
+		WriteError(w, http.StatusBadRequest, "No URLs provided", constants.ErrCodeInvalidRequest)
+		return
+	}
+	if len(req.URLs) > s.app.Config.Fetch.MaxURLs {
+		WriteError(w, http.StatusBadRequest, "Too many URLs", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if !s.checkDiskLimit(w, r, identity, "fetch") {
+		return
+	}
+
+	s.logger.Info("Fetch: %d URLs into topic %s by %s", len(req.URLs), topicName, getAuditUsername(identity))
+
+	response := FetchResponse{Total: len(req.URLs), Results: make([]FetchURLResult, 0, len(req.URLs))}
+	for _, rawURL := range req.URLs {
+		if r.Context().Err() != nil {
+			return // client went away
+		}
+		result := s.fetchAsset(r, identity, topicName, rawURL)
+		if result.Success {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+		response.Results = append(response.Results, result)
+	}
+	response.Success = response.Failed == 0
+
+	s.logger.Info("Fetch complete: %d succeeded, %d failed", response.Succeeded, response.Failed)
+	WriteSuccess(w, response)
+}
+
+// fetchAsset downloads one URL and stores it in the topic.
+func (s *Server) fetchAsset(r *http.Request, identity *auth.Identity, topicName, rawURL string) FetchURLResult {
+	result := FetchURLResult{URL: rawURL, Status: constants.UploadStatusRejected}
+	reject := func(err error) FetchURLResult {
+		result.Error, result.Code = bulkErrorParts(err)
+		return result
+	}
+
+	file, err := s.app.Services.Fetch.Download(r.Context(), rawURL)
+	if err != nil {
+		return reject(err)
+	}
+	defer os.Remove(file.Path)
+	result.Filename = file.Filename
+	result.Attempts = file.Attempts
+
+	// Extension and size are only known now
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+	policy := s.app.Services.Auth.GetEvaluator().Evaluate(identity, &auth.ActionContext{
+		Action:    constants.AuthActionUpload,
+		TopicName: topicName,
+		Extension: ext,
+		FileSize:  file.Size,
+	})
+	if !policy.Allowed {
+		result.Error, result.Code = policy.Reason, policy.DeniedCode
+		return result
+	}
+	if _, err := services.CheckStorageHeadroom(s.app.Config.WorkingDirectory, s.app.Config.MaxDiskUsage, file.Size+int64(constants.HeaderSize)); err != nil {
+		return reject(err)
+	}
+
+	upload, err := s.app.Services.Asset.UploadStaged(r.Context(), topicName, file.Path, file.Hash, file.Size, file.Filename, nil, "", getAuditUsername(identity))
+	if err != nil {
+		return reject(err)
+	}
+	s.recordUpload(r, identity, topicName, file.Filename, "", upload)
+
+	result.Success = true
+	result.Status = upload.Status
+	result.Hash = upload.Hash
+	result.Size = upload.Size
+	result.ExistingTopic = upload.ExistingTopic
+	result.Quarantined = upload.Quarantined
+	return result
+}
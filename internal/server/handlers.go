@@ -1,19 +1,26 @@
 package server
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"silobang/internal/audit"
 	"silobang/internal/auth"
+	"silobang/internal/config"
 	"silobang/internal/constants"
 	"silobang/internal/sanitize"
 	"silobang/internal/services"
+	"silobang/internal/storage"
 )
 
 var topicNameRegex = regexp.MustCompile(constants.TopicNameRegex)
@@ -72,54 +79,156 @@ func (s *Server) postConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response, ok := s.applyWorkingDirectory(w, r, req.WorkingDirectory)
+	if !ok {
+		return
+	}
+	WriteSuccess(w, response)
+}
+
+// applyWorkingDirectory switches to dir, initializes it and bootstraps the
+// admin account of a new instance, returning the response body with the
+// bootstrap credentials, if any. Writes the error and returns false on
+// failure.
+func (s *Server) applyWorkingDirectory(w http.ResponseWriter, r *http.Request, dir string) (map[string]interface{}, bool) {
 	// Call service
-	if err := s.app.Services.Config.SetWorkingDirectory(req.WorkingDirectory, s.app.Config.Port); err != nil {
+	if err := s.app.Services.Config.SetWorkingDirectory(dir, s.app.Config.Port); err != nil {
 		s.handleServiceError(w, err)
-		return
+		return nil, false
 	}
 
+	// The config service started a new startup report; start the rest here
+	startup := s.app.GetStartupRecorder()
+	supervisor := services.NewSupervisor(startup, s.app.Config.Features, s.logger)
+
 	// Initialize audit logger (needs to be done in handler as it's server-specific)
-	s.app.AuditLogger = s.app.Services.Config.SetAuditLogger()
+	s.app.AuditLogger = nil
+	supervisor.Start(services.Subsystem{
+		Name:     constants.StartupStepAudit,
+		Requires: []string{constants.StartupStepOrchestratorDB},
+		Start: func() (string, error) {
+			s.app.AuditLogger = s.app.Services.Config.SetAuditLogger()
+			return "", nil
+		},
+	})
 
 	// Re-initialize services so AuthService picks up the new orchestrator DB
 	s.app.ReinitServices()
 
+	// Check .dat files for regions left behind by crashes
+	supervisor.Start(services.Subsystem{
+		Name:     constants.StartupStepIntegrityScan,
+		Feature:  constants.FeatureIntegrityScan,
+		Requires: []string{constants.StartupStepTopicDiscovery},
+		Start: func() (string, error) {
+			s.app.Services.Integrity.ScanAll()
+			return "", nil
+		},
+	})
+
 	// Build stats cache after working directory setup
-	s.app.Services.StatsCache.BuildAll()
+	supervisor.Start(services.Subsystem{
+		Name:     constants.StartupStepStatsCache,
+		Required: true,
+		Requires: []string{constants.StartupStepTopicDiscovery},
+		Start: func() (string, error) {
+			s.app.Services.StatsCache.BuildAll()
+			return "built", nil
+		},
+	})
 
 	// Bootstrap auth if this is first-time setup (no users yet)
 	response := map[string]interface{}{"success": true}
 	isBootstrap := false
-	if s.app.Services.Auth != nil {
-		bootstrapResult, err := auth.Bootstrap(s.app.Services.Auth.GetStore(), s.logger)
-		if err != nil {
-			s.logger.Error("Auth bootstrap failed during config: %v", err)
-		} else if bootstrapResult != nil {
-			isBootstrap = true
-			s.logger.Info("Auth: bootstrap completed via config API — admin user created")
-			response["bootstrap"] = map[string]interface{}{
-				"username": bootstrapResult.Username,
-				"password": bootstrapResult.Password,
-				"api_key":  bootstrapResult.APIKey,
+	supervisor.Start(services.Subsystem{
+		Name:     constants.StartupStepAuthBootstrap,
+		Required: true,
+		Requires: []string{constants.StartupStepOrchestratorDB},
+		Start: func() (string, error) {
+			bootstrapResult, err := auth.Bootstrap(s.app.Services.Auth.GetStore(), s.logger)
+			if err != nil {
+				return "", err
 			}
+			if bootstrapResult != nil {
+				isBootstrap = true
+				s.logger.Info("Auth: bootstrap completed via config API — admin user created")
+				response["bootstrap"] = map[string]interface{}{
+					"username": bootstrapResult.Username,
+					"password": bootstrapResult.Password,
+					"api_key":  bootstrapResult.APIKey,
+				}
+			}
+			return "", nil
+		},
+	})
+
+	if err := s.app.Services.Health.SaveStartupReport(startup); err != nil {
+		s.logger.Warn("Failed to save startup report: %v", err)
+	}
+
+	// Audit config change (audit logger was just initialized above). The
+	// whole config changes with the working directory, so it is diffed
+	// against the history of the new directory.
+	auditUsername := ""
+	if s.isAuthAvailable() {
+		if identity, ok := auth.RequireAuth(r); ok {
+			auditUsername = getAuditUsername(identity)
 		}
 	}
+	source := constants.ConfigChangeSourceAPI
+	if isBootstrap {
+		source = constants.ConfigChangeSourceBootstrap
+	}
+	s.app.Services.Config.RecordChange(getClientIP(r), auditUsername, source, nil, []string{"working_directory"})
 
-	// Audit config change (audit logger was just initialized above)
-	if s.app.AuditLogger != nil {
-		auditUsername := ""
-		if s.isAuthAvailable() {
-			if identity, ok := auth.RequireAuth(r); ok {
-				auditUsername = getAuditUsername(identity)
-			}
+	return response, true
+}
+
+// POST /api/config/validate - Dry-run a candidate configuration without applying it
+func (s *Server) handleConfigValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Auth check: manage_config required (skip if auth not available — initial setup)
+	if s.isAuthAvailable() {
+		identity := s.requireAuth(w, r)
+		if identity == nil {
+			return
+		}
+		if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageConfig}) {
+			return
 		}
-		s.app.AuditLogger.Log(constants.AuditActionConfigChanged, getClientIP(r), auditUsername, audit.ConfigChangedDetails{
-			WorkingDirectory: req.WorkingDirectory,
-			IsBootstrap:      isBootstrap,
-		})
 	}
 
-	WriteSuccess(w, response)
+	var req services.ConfigValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	report := s.app.Services.Config.Validate(&req)
+	WriteSuccess(w, report)
+}
+
+// GET /api/config/history - Configuration as of ?at= (Unix seconds, default
+// now) rebuilt from config_changed audit entries (requires manage_config)
+func (s *Server) handleConfigHistory(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	q := r.URL.Query()
+	at, _ := strconv.ParseInt(q.Get("at"), 10, 64)
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		limit = constants.ConfigHistoryDefaultLimit
+	}
+	limit = min(limit, constants.ConfigHistoryMaxLimit)
+
+	history, err := s.app.Services.Config.History(at, limit)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	WriteSuccess(w, history)
 }
 
 // =============================================================================
@@ -146,8 +255,9 @@ func (s *Server) handleTopics(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) listTopics(w http.ResponseWriter, r *http.Request) {
-	// Auth: any authenticated user can list topics (no specific action required)
-	identity := s.requireAuth(w, r)
+	// Auth: any authenticated user can list topics (no specific action required);
+	// public mode also lists them anonymously
+	identity := s.requireAuthOrPublic(w, r)
 	if identity == nil {
 		return
 	}
@@ -170,12 +280,21 @@ func (s *Server) listTopics(w http.ResponseWriter, r *http.Request) {
 			ti := services.TopicInfo{
 				Name:    name,
 				Healthy: healthy,
+				Frozen:  s.app.Config.IsTopicFrozen(name),
 			}
 			if !healthy {
 				ti.Error = errMsg
-			} else if stats, ok := cachedStats[name]; ok {
-				ti.Stats = stats
-				allStats[name] = stats
+			} else {
+				if stats, ok := cachedStats[name]; ok {
+					ti.Stats = stats
+					allStats[name] = stats
+				}
+				ti.IntegrityIssues = s.app.Services.Integrity.IssueCount(name)
+				if policies, err := s.app.Services.Policy.ForTopic(name); err != nil {
+					s.logger.Warn("Failed to get storage policies for topic %s: %v", name, err)
+				} else {
+					ti.StoragePolicies = policies
+				}
 			}
 			topics = append(topics, ti)
 		}
@@ -234,40 +353,49 @@ func (s *Server) createTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !s.addTopic(w, r, identity, req.Name) {
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"success": true,
+		"name":    req.Name,
+	})
+}
+
+// addTopic authorizes and creates a topic. Writes the error and returns
+// false on failure.
+func (s *Server) addTopic(w http.ResponseWriter, r *http.Request, identity *auth.Identity, name string) bool {
 	// Authorize: manage_topics with create sub-action
 	if !s.authorize(w, identity, &auth.ActionContext{
 		Action:    constants.AuthActionManageTopics,
 		SubAction: "create",
-		TopicName: req.Name,
+		TopicName: name,
 	}) {
-		return
+		return false
 	}
 
 	// Check disk usage limit before creating topic
 	if !s.checkDiskLimit(w, r, identity, "create_topic") {
-		return
+		return false
 	}
 
 	// Call service
-	if err := s.app.Services.Config.CreateTopic(req.Name); err != nil {
+	if err := s.app.Services.Config.CreateTopic(name); err != nil {
 		s.handleServiceError(w, err)
-		return
+		return false
 	}
 
 	// Audit log
 	if s.app.AuditLogger != nil {
 		s.app.AuditLogger.Log(constants.AuditActionAddingTopic, getClientIP(r), getAuditUsername(identity), audit.AddingTopicDetails{
-			TopicName: req.Name,
+			TopicName: name,
 		})
 	}
 
 	// Initialize cache entry for new topic
-	s.app.Services.StatsCache.InvalidateTopic(req.Name)
-
-	WriteSuccess(w, map[string]interface{}{
-		"success": true,
-		"name":    req.Name,
-	})
+	s.app.Services.StatsCache.InvalidateTopic(name)
+	return true
 }
 
 // =============================================================================
@@ -314,6 +442,10 @@ func (s *Server) handleTopicRoutes(w http.ResponseWriter, r *http.Request) {
 
 	// Route to sub-handler
 	if len(parts) == 1 {
+		if r.Method == http.MethodPatch {
+			s.patchTopic(w, r, topicName)
+			return
+		}
 		// /api/topics/:name - topic detail (future)
 		http.NotFound(w, r)
 		return
@@ -324,11 +456,130 @@ func (s *Server) handleTopicRoutes(w http.ResponseWriter, r *http.Request) {
 	switch {
 	case subPath == "assets" && r.Method == http.MethodPost:
 		s.uploadAsset(w, r, topicName)
+	case subPath == "assets" && r.Method == http.MethodGet:
+		s.listTopicAssets(w, r, topicName)
+	case subPath == "assets/fetch" && r.Method == http.MethodPost:
+		s.fetchAssets(w, r, topicName)
+	case subPath == "collections" || strings.HasPrefix(subPath, "collections/"):
+		s.handleCollectionRoutes(w, r, topicName, subPath)
+	case subPath == "integrity":
+		s.handleTopicIntegrity(w, r, topicName)
+	case subPath == "compaction":
+		s.handleTopicCompaction(w, r, topicName)
+	case subPath == "compaction/stream":
+		s.streamTopicCompaction(w, r, topicName)
+	case subPath == "retention":
+		s.handleTopicRetention(w, r, topicName)
+	case subPath == "freeze":
+		s.handleTopicFreeze(w, r, topicName, true)
+	case subPath == "unfreeze":
+		s.handleTopicFreeze(w, r, topicName, false)
+	case subPath == "subscribe":
+		s.handleTopicSubscription(w, r, topicName)
+	case subPath == "archive":
+		s.handleTopicArchive(w, r, topicName)
+	case strings.HasPrefix(subPath, constants.AssetByNamePathPrefix) && r.Method == http.MethodGet:
+		s.getAssetsByName(w, r, topicName, strings.TrimPrefix(subPath, constants.AssetByNamePathPrefix))
 	default:
 		http.NotFound(w, r)
 	}
 }
 
+// GET /api/topics/:name/assets - A page of the topic's assets. Query
+// params: sort (created_at, size, name), order (asc, desc), extension,
+// has_key, since, until (unix seconds, inclusive), limit, cursor.
+func (s *Server) listTopicAssets(w http.ResponseWriter, r *http.Request, topicName string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionQuery, TopicName: topicName}) {
+		return
+	}
+
+	q := r.URL.Query()
+	opts := services.BrowseOptions{
+		Sort:           q.Get("sort"),
+		Order:          q.Get("order"),
+		Extension:      q.Get("extension"),
+		HasMetadataKey: q.Get("has_key"),
+		Cursor:         q.Get("cursor"),
+		Limit:          discoveryParam(r, "limit", constants.TopicAssetsDefaultLimit, constants.TopicAssetsMaxLimit),
+	}
+	for name, bound := range map[string]*int64{"since": &opts.Since, "until": &opts.Until} {
+		raw := q.Get(name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 0 {
+			WriteError(w, http.StatusBadRequest, name+" must be a unix timestamp", constants.ErrCodeInvalidRequest)
+			return
+		}
+		*bound = n
+	}
+
+	page, err := s.app.Services.Asset.Browse(topicName, opts)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, page)
+}
+
+// GET /api/topics/:name/assets/by-name/:origin_name - Assets stored under a
+// filename or origin name, newest first. With latest=true the newest match
+// is downloaded directly, like GET /api/assets/:hash/download.
+func (s *Server) getAssetsByName(w http.ResponseWriter, r *http.Request, topicName, name string) {
+	latest := r.URL.Query().Get(constants.AssetByNameLatestParam) == "true"
+
+	var identity *auth.Identity
+	if latest {
+		identity = s.requireAuthOrPublic(w, r)
+	} else {
+		identity = s.requireAuth(w, r)
+	}
+	if identity == nil {
+		return
+	}
+
+	action := constants.AuthActionQuery
+	if latest {
+		action = constants.AuthActionDownload
+	}
+	if !s.authorize(w, identity, &auth.ActionContext{Action: action, TopicName: topicName}) {
+		return
+	}
+
+	if name == "" || strings.Contains(name, "/") {
+		WriteError(w, http.StatusBadRequest, "A single file name is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	assets, err := s.app.Services.Asset.FindByName(topicName, name)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if latest {
+		if len(assets) == 0 {
+			WriteError(w, http.StatusNotFound, "No asset named "+name+" in topic "+topicName, constants.ErrCodeAssetNotFound)
+			return
+		}
+		s.downloadAsset(w, r, assets[0].Hash)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"topic":  topicName,
+		"name":   name,
+		"count":  len(assets),
+		"assets": assets,
+	})
+}
+
 // =============================================================================
 // Asset Upload Handler
 // =============================================================================
@@ -340,18 +591,43 @@ func (s *Server) uploadAsset(w http.ResponseWriter, r *http.Request, topicName s
 		return
 	}
 
+	// Optional progress reporting and cancellation via the progress WebSocket
+	ctx, uploadID, untrack, ok := s.trackUpload(w, r, identity)
+	if !ok {
+		return
+	}
+	defer untrack()
+
+	// Reject before accepting the body when the declared size cannot fit
+	if !s.checkDiskLimit(w, r, identity, "upload") {
+		return
+	}
+	if r.ContentLength > 0 && !s.checkUploadHeadroom(w, r, identity, r.ContentLength) {
+		return
+	}
+
 	// Parse multipart form with streaming
 	// MaxMemory = 0 means all files go to disk (no memory buffering)
 	if err := r.ParseMultipartForm(0); err != nil {
-		WriteError(w, http.StatusBadRequest, "Failed to parse multipart form", constants.ErrCodeInvalidRequest)
+		if ctx.Err() != nil {
+			s.writeUploadCancelled(w, identity, uploadID)
+			return
+		}
+		writeUploadRejected(w, http.StatusBadRequest, "Failed to parse multipart form", constants.ErrCodeInvalidRequest)
 		return
 	}
 	defer r.MultipartForm.RemoveAll()
 
+	// Several files in one request are stored as a batch
+	if headers := r.MultipartForm.File[constants.FormFieldFile]; len(headers) > 1 {
+		s.uploadAssetBatch(ctx, w, r, identity, topicName, headers)
+		return
+	}
+
 	// Get the file
 	file, header, err := r.FormFile(constants.FormFieldFile)
 	if err != nil {
-		WriteError(w, http.StatusBadRequest, "No file provided", constants.ErrCodeInvalidRequest)
+		writeUploadRejected(w, http.StatusBadRequest, "No file provided", constants.ErrCodeInvalidRequest)
 		return
 	}
 	defer file.Close()
@@ -369,18 +645,15 @@ func (s *Server) uploadAsset(w http.ResponseWriter, r *http.Request, topicName s
 		return
 	}
 
-	// Check file size against max_dat_size (early rejection)
-	maxSize := s.app.Config.MaxDatSize
-	if maxSize == 0 {
-		maxSize = constants.DefaultMaxDatSize
-	}
-	if header.Size > maxSize-int64(constants.HeaderSize) {
-		WriteError(w, http.StatusRequestEntityTooLarge, "File exceeds maximum size", constants.ErrCodeAssetTooLarge)
+	// Check file size against the extension's storage policy, which is capped
+	// by max_dat_size (early rejection)
+	if header.Size > s.app.Config.StoragePolicy(ext).MaxSizeBytes {
+		writeUploadRejected(w, http.StatusRequestEntityTooLarge, "File exceeds maximum size", constants.ErrCodeAssetTooLarge)
 		return
 	}
 
-	// Check disk usage limit before writing
-	if !s.checkDiskLimit(w, r, identity, "upload") {
+	// Without a Content-Length the size is only known now
+	if r.ContentLength <= 0 && !s.checkUploadHeadroom(w, r, identity, header.Size+int64(constants.HeaderSize)) {
 		return
 	}
 
@@ -390,38 +663,65 @@ func (s *Server) uploadAsset(w http.ResponseWriter, r *http.Request, topicName s
 		parentID = &pid
 	}
 
+	// Get optional relative_path (folder uploads)
+	var relativePath string
+	if raw := r.FormValue(constants.FormFieldRelativePath); raw != "" {
+		relativePath = sanitize.RelativePath(raw)
+		if relativePath == "" {
+			writeUploadRejected(w, http.StatusBadRequest, "Invalid relative_path", constants.ErrCodeInvalidRequest)
+			return
+		}
+	}
+
 	// Call service
-	result, err := s.app.Services.Asset.Upload(r.Context(), topicName, file, header.Filename, parentID)
+	result, err := s.app.Services.Asset.Upload(ctx, topicName, file, header.Filename, parentID, relativePath, getAuditUsername(identity))
 	if err != nil {
-		s.handleServiceError(w, err)
+		if ctx.Err() != nil {
+			s.writeUploadCancelled(w, identity, uploadID)
+			return
+		}
+		if uploadID != "" {
+			message, code := bulkErrorParts(err)
+			s.progressHub.Publish(identity.User.ID, "upload_error", UploadErrorData{
+				UploadID: uploadID,
+				Status:   constants.UploadStatusRejected,
+				Message:  message,
+				Code:     code,
+			})
+		}
+		s.writeUploadServiceError(w, err)
 		return
 	}
 
-	// Increment quota after successful upload
-	if s.app.Services.Auth != nil {
-		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionUpload, result.Size)
-	}
-
-	// Audit log
-	if !result.Skipped && s.app.AuditLogger != nil {
-		s.app.AuditLogger.Log(constants.AuditActionAddingFile, getClientIP(r), getAuditUsername(identity), audit.AddingFileDetails{
-			Hash:      result.Hash,
-			TopicName: topicName,
-			Filename:  header.Filename,
-			Size:      result.Size,
-			Skipped:   result.Skipped,
+	if uploadID != "" {
+		s.progressHub.Publish(identity.User.ID, "upload_complete", UploadCompleteData{
+			UploadID: uploadID,
+			Hash:     result.Hash,
+			Status:   result.Status,
+			Reason:   result.Reason,
+			Skipped:  result.Skipped,
+			Size:     result.Size,
 		})
 	}
 
-	// Invalidate stats cache for this topic
-	if !result.Skipped {
-		s.app.Services.StatsCache.InvalidateTopic(topicName)
+	s.completeUpload(w, r, identity, topicName, header.Filename, relativePath, result)
+}
+
+// completeUpload accounts for an upload and writes the upload response.
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request, identity *auth.Identity, topicName, filename, relativePath string, result *services.UploadResult) {
+	// Only new assets are stamped with the folder path; existing ones keep theirs
+	if result.Status != constants.UploadStatusCreated {
+		relativePath = ""
 	}
 
-	// Format response
+	s.recordUpload(r, identity, topicName, filename, relativePath, result)
+
+	// Format response ("skipped" is deprecated in favour of "status")
 	response := map[string]interface{}{
 		"success": true,
 		"hash":    result.Hash,
+		"status":  result.Status,
+		"reason":  result.Reason,
 		"skipped": result.Skipped,
 	}
 	if result.Skipped {
@@ -430,9 +730,73 @@ func (s *Server) uploadAsset(w http.ResponseWriter, r *http.Request, topicName s
 		response["size"] = result.Size
 		response["blob"] = result.BlobName
 	}
+	if result.Quarantined {
+		response["quarantined"] = true
+	}
+	if relativePath != "" {
+		response["relative_path"] = relativePath
+	}
 	WriteSuccess(w, response)
 }
 
+// recordUpload accounts for a successful upload: the uploader's quota, the
+// audit log, the topic's cached stats and its subscribers.
+func (s *Server) recordUpload(r *http.Request, identity *auth.Identity, topicName, filename, relativePath string, result *services.UploadResult) {
+	if s.app.Services.Auth != nil {
+		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionUpload, result.Size)
+	}
+
+	// Audit log
+	if !result.Skipped && s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionAddingFile, getClientIP(r), getAuditUsername(identity), audit.AddingFileDetails{
+			Hash:         result.Hash,
+			TopicName:    topicName,
+			Filename:     filename,
+			RelativePath: relativePath,
+			Size:         result.Size,
+			Skipped:      result.Skipped,
+		})
+	}
+
+	// Invalidate stats cache for this topic
+	if !result.Skipped {
+		s.app.Services.StatsCache.InvalidateTopic(topicName)
+	}
+
+	// Notify topic subscribers of new content
+	if result.Status == constants.UploadStatusCreated {
+		s.notifyAssetAdded(identity, topicName, result.Hash, filename)
+	}
+}
+
+// UploadRejection is the error response of a refused upload. It extends the
+// standard error with the upload status so clients can branch on status alone.
+type UploadRejection struct {
+	APIError
+	Status   string                    `json:"status"`
+	Reason   string                    `json:"reason"`
+	Headroom *services.StorageHeadroom `json:"headroom,omitempty"` // set for STORAGE_FULL
+}
+
+// writeUploadRejected writes an upload rejection; the reason is the error code.
+func writeUploadRejected(w http.ResponseWriter, status int, message, code string) {
+	WriteJSON(w, status, UploadRejection{
+		APIError: APIError{Error: true, Message: message, Code: code},
+		Status:   constants.UploadStatusRejected,
+		Reason:   code,
+	})
+}
+
+// writeUploadServiceError maps a service error to an upload rejection.
+func (s *Server) writeUploadServiceError(w http.ResponseWriter, err error) {
+	code, isServiceErr := services.IsServiceError(err)
+	if !isServiceErr {
+		writeUploadRejected(w, http.StatusInternalServerError, err.Error(), constants.ErrCodeInternalError)
+		return
+	}
+	writeUploadRejected(w, serviceErrorStatus(code), err.Error(), code)
+}
+
 // =============================================================================
 // Asset Routes Handler
 // =============================================================================
@@ -445,7 +809,7 @@ func (s *Server) handleAssetRoutes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse path: /api/assets/:hash/download or /api/assets/:hash/metadata
+	// Parse path: /api/assets/:hash, /api/assets/:hash/download or /api/assets/:hash/metadata
 	path := r.URL.Path
 	prefix := "/api/assets/"
 
@@ -471,6 +835,10 @@ func (s *Server) handleAssetRoutes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(parts) == 1 {
+		if r.Method == http.MethodDelete {
+			s.deleteAsset(w, r, hash)
+			return
+		}
 		http.NotFound(w, r)
 		return
 	}
@@ -484,6 +852,22 @@ func (s *Server) handleAssetRoutes(w http.ResponseWriter, r *http.Request) {
 		s.getMetadata(w, r, hash)
 	case action == "metadata" && r.Method == http.MethodPost:
 		s.postMetadata(w, r, hash)
+	case action == "bom" && r.Method == http.MethodGet:
+		s.getAssetBOM(w, r, hash)
+	case action == "references" && r.Method == http.MethodGet:
+		s.getAssetReferences(w, r, hash)
+	case action == "timeline" && r.Method == http.MethodGet:
+		s.getAssetTimeline(w, r, hash)
+	case action == "related" && r.Method == http.MethodGet:
+		s.getAssetRelated(w, r, hash)
+	case action == "preview" && r.Method == http.MethodGet:
+		s.getAssetPreview(w, r, hash)
+	case action == "quarantine" && r.Method == http.MethodPost:
+		s.quarantineAsset(w, r, hash)
+	case action == "release" && r.Method == http.MethodPost:
+		s.releaseAsset(w, r, hash)
+	case action == "recall" && r.Method == http.MethodPost:
+		s.recallAsset(w, r, hash)
 	default:
 		http.NotFound(w, r)
 	}
@@ -495,13 +879,26 @@ func (s *Server) handleAssetRoutes(w http.ResponseWriter, r *http.Request) {
 
 // GET /api/assets/:hash/download - Download asset
 func (s *Server) downloadAsset(w http.ResponseWriter, r *http.Request, hash string) {
-	identity := s.requireAuth(w, r)
+	identity := s.requireAuthOrPublic(w, r)
 	if identity == nil {
 		return
 	}
 
 	// Call service to get reader (need info for auth context)
 	reader, err := s.app.Services.Asset.GetReader(hash)
+	originCache := s.app.Services.OriginCache
+	fetched := false
+	if code, _ := services.IsServiceError(err); code == constants.ErrCodeAssetNotFound && originCache.Enabled() {
+		// Assets missing here are fetched through from the origin, for
+		// users allowed to download from the cache topic
+		if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionDownload, TopicName: originCache.Topic()}) {
+			return
+		}
+		if err = originCache.Fetch(r.Context(), hash); err == nil {
+			fetched = true
+			reader, err = s.app.Services.Asset.GetReader(hash)
+		}
+	}
 	if err != nil {
 		s.handleServiceError(w, err)
 		return
@@ -511,17 +908,75 @@ func (s *Server) downloadAsset(w http.ResponseWriter, r *http.Request, hash stri
 	info := reader.Info
 
 	// Authorize: download with topic constraint
-	if !s.authorize(w, identity, &auth.ActionContext{
+	result, ok := s.authorizeWithResult(w, identity, &auth.ActionContext{
 		Action:      constants.AuthActionDownload,
 		TopicName:   info.TopicName,
 		VolumeBytes: info.Size,
-	}) {
+	})
+	if !ok {
+		return
+	}
+	if !fetched && originCache.Enabled() && info.TopicName == originCache.Topic() {
+		originCache.RecordHit(hash)
+	}
+
+	// Watermarked downloads are transformed in memory; the stored asset and
+	// its hash are untouched. Non-image assets are served unchanged.
+	var watermarked []byte
+	profileName, err := s.downloadWatermark(r, identity, result)
+	if err != nil {
+		WriteError(w, http.StatusForbidden, err.Error(), constants.ErrCodeAuthConstraintViolation)
 		return
 	}
+	if profileName != "" {
+		profile, err := s.app.Services.Watermark.Resolve(profileName)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		if s.app.Services.Watermark.Supports(info.ContentType) {
+			watermarked, err = s.app.Services.Watermark.Apply(profile, reader, info.Size, info.ContentType)
+			if err != nil {
+				s.handleServiceError(w, err)
+				return
+			}
+		} else {
+			profileName = ""
+		}
+	}
+
+	// The extension's storage policy decides whether the stored bytes are
+	// gzip-encoded for clients that accept it
+	compress := watermarked == nil && s.app.Config.StoragePolicy(info.Extension).Compression
+	if compress {
+		w.Header().Set("Vary", "Accept-Encoding")
+		compress = strings.Contains(r.Header.Get("Accept-Encoding"), "gzip")
+	}
+
+	if watermarked == nil {
+		// Content-addressed bytes never change: serve validators and answer
+		// conditional requests without streaming the body again
+		etag := assetETag(hash)
+		if compress {
+			etag = assetETagVariant(hash, "gzip")
+		}
+		modTime := time.Unix(info.CreatedAt, 0)
+		setAssetCacheHeaders(w, etag, modTime)
+		if checkNotModified(w, r, etag, modTime) {
+			return
+		}
+	}
 
 	// Set response headers
 	w.Header().Set(constants.HeaderContentType, info.ContentType)
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size))
+	if watermarked != nil {
+		w.Header().Set(constants.HeaderXWatermark, profileName)
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(watermarked)))
+	} else if compress {
+		w.Header().Set("Content-Encoding", "gzip")
+	} else {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size))
+	}
 
 	// Build filename for Content-Disposition (defense-in-depth: sanitize at output
 	// even though input is sanitized at upload, in case of pre-existing data)
@@ -539,29 +994,79 @@ func (s *Server) downloadAsset(w http.ResponseWriter, r *http.Request, hash stri
 	w.Header().Set(constants.HeaderContentDisposition, fmt.Sprintf(constants.ContentDispositionFormat, safeFilename))
 
 	// Stream data
-	io.Copy(w, reader)
+	if watermarked != nil {
+		w.Write(watermarked)
+	} else if compress {
+		gz, _ := gzip.NewWriterLevel(w, constants.CompressionLevel)
+		io.Copy(gz, reader)
+		gz.Close()
+	} else {
+		copyAssetBody(w, reader.ReadCloser)
+	}
 
-	// Increment quota after successful download
-	if s.app.Services.Auth != nil {
+	s.recordDownload(r, identity, info, filename, profileName)
+}
+
+// copyAssetBody streams an unmodified asset body. When the bytes come
+// straight from a .dat file the range is passed to the connection's
+// ReadFrom, which sends it with sendfile(2) on plain HTTP/1.1 connections;
+// other readers, and TLS or HTTP/2 connections, are copied through
+// userspace as before.
+func copyAssetBody(w io.Writer, reader io.Reader) (int64, error) {
+	if fr, ok := reader.(storage.FileRange); ok {
+		f, offset, length := fr.FileRange()
+		if _, err := f.Seek(offset, io.SeekStart); err == nil {
+			return io.Copy(w, &io.LimitedReader{R: f, N: length})
+		}
+	}
+	return io.Copy(w, reader)
+}
+
+// recordDownload accounts for a served download: the downloader's quota
+// and the audit log.
+func (s *Server) recordDownload(r *http.Request, identity *auth.Identity, info *services.AssetInfo, filename, watermark string) {
+	if s.app.Services.Auth != nil && !identity.IsAnonymous() {
 		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionDownload, info.Size)
 	}
 
-	// Audit log for download
 	if s.app.AuditLogger != nil {
 		s.app.AuditLogger.Log(constants.AuditActionDownloaded, getClientIP(r), getAuditUsername(identity), audit.DownloadedDetails{
-			Hash:     hash,
-			Topic:    info.TopicName,
-			Filename: filename,
-			Size:     info.Size,
+			Hash:      info.Hash,
+			Topic:     info.TopicName,
+			Filename:  filename,
+			Size:      info.Size,
+			Watermark: watermark,
 		})
 	}
 }
 
+// downloadWatermark returns the watermark profile to apply to a download.
+// A profile forced by the matched grant, or by public mode for anonymous
+// users, overrides the ?watermark= query parameter so the caller cannot
+// opt out of it.
+func (s *Server) downloadWatermark(r *http.Request, identity *auth.Identity, result *auth.PolicyResult) (string, error) {
+	if identity.IsAnonymous() {
+		if cfg := s.app.Config; cfg != nil && cfg.Public.Watermark != "" {
+			return cfg.Public.Watermark, nil
+		}
+	} else if result.MatchedGrant != nil && result.MatchedGrant.ConstraintsJSON != nil {
+		var c auth.DownloadConstraints
+		if err := json.Unmarshal([]byte(*result.MatchedGrant.ConstraintsJSON), &c); err != nil {
+			return "", fmt.Errorf("malformed grant constraints") // Fail-closed: the forced watermark cannot be read
+		}
+		if c.Watermark != "" {
+			return c.Watermark, nil
+		}
+	}
+	return r.URL.Query().Get(constants.WatermarkQueryParam), nil
+}
+
 // =============================================================================
 // Metadata Handler
 // =============================================================================
 
-// GET /api/assets/:hash/metadata - Get asset info and computed metadata
+// GET /api/assets/:hash/metadata - Get asset info and computed metadata,
+// or with ?as_of=<unix time> the metadata the asset had at that time
 func (s *Server) getMetadata(w http.ResponseWriter, r *http.Request, hash string) {
 	identity := s.requireAuth(w, r)
 	if identity == nil {
@@ -572,26 +1077,50 @@ func (s *Server) getMetadata(w http.ResponseWriter, r *http.Request, hash string
 		return
 	}
 
-	result, err := s.app.Services.Metadata.Get(hash)
+	selection := metadataSelectionFromQuery(r)
+	if err := selection.Validate(); err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	var asOf int64
+	if v := r.URL.Query().Get(constants.MetadataAsOfParam); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed <= 0 {
+			WriteError(w, http.StatusBadRequest, "as_of must be a unix timestamp", constants.ErrCodeInvalidRequest)
+			return
+		}
+		asOf = parsed
+	}
+
+	result, err := s.app.Services.Metadata.GetAsOf(hash, asOf)
 	if err != nil {
 		s.handleServiceError(w, err)
 		return
 	}
+	selection.ApplyToAsset(result)
 
 	// Increment quota
 	if s.app.Services.Auth != nil {
 		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionMetadata, 0)
 	}
 
-	WriteSuccess(w, map[string]interface{}{
-		"asset":                   result.Asset,
-		"computed_metadata":       result.ComputedMetadata,
-		"metadata_with_processor": result.MetadataWithProcessor,
-	})
+	response := map[string]interface{}{
+		"asset": result.Asset,
+	}
+	if selection.IncludesValues() {
+		response["computed_metadata"] = result.ComputedMetadata
+		response["metadata_with_processor"] = result.MetadataWithProcessor
+	}
+	if selection.IncludesKeyNames() {
+		response["metadata_keys"] = result.MetadataKeys
+	}
+	WriteSuccess(w, response)
 }
 
-// POST /api/assets/:hash/metadata - Add/delete metadata
-func (s *Server) postMetadata(w http.ResponseWriter, r *http.Request, hash string) {
+// GET /api/assets/:hash/bom - Bill of materials: every source asset the
+// asset was derived from. ?format=spdx returns an SPDX-like JSON document.
+func (s *Server) getAssetBOM(w http.ResponseWriter, r *http.Request, hash string) {
 	identity := s.requireAuth(w, r)
 	if identity == nil {
 		return
@@ -601,13 +1130,323 @@ func (s *Server) postMetadata(w http.ResponseWriter, r *http.Request, hash strin
 		return
 	}
 
-	var req services.MetadataSetRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+	format := r.URL.Query().Get(constants.BOMQueryParamFormat)
+	if format == "" {
+		format = constants.BOMFormatJSON
+	}
+	if format != constants.BOMFormatJSON && format != constants.BOMFormatSPDX {
+		WriteError(w, http.StatusBadRequest,
+			fmt.Sprintf("format must be %q or %q", constants.BOMFormatJSON, constants.BOMFormatSPDX),
+			constants.ErrCodeInvalidRequest)
 		return
 	}
 
-	// Check disk usage limit before writing metadata (set operations grow SQLite)
+	bom, err := s.app.Services.Lineage.BillOfMaterials(hash)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	// The manifest exposes assets and metadata from every topic in the
+	// lineage, so each of them must be readable by the caller
+	for _, topic := range bom.Topics {
+		if !s.authorize(w, identity, &auth.ActionContext{
+			Action:    constants.AuthActionMetadata,
+			TopicName: topic,
+		}) {
+			return
+		}
+	}
+
+	// Increment quota
+	if s.app.Services.Auth != nil {
+		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionMetadata, 0)
+	}
+
+	if format == constants.BOMFormatSPDX {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s%s\"", hash, constants.SPDXFileExt))
+		WriteSuccess(w, bom.ToSPDX())
+		return
+	}
+
+	WriteSuccess(w, bom)
+}
+
+// GET /api/assets/:hash/references - Every holder keeping the asset alive
+// (collections, derived assets) and whether it could be deleted.
+func (s *Server) getAssetReferences(w http.ResponseWriter, r *http.Request, hash string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionMetadata}) {
+		return
+	}
+
+	refs, err := s.app.Services.References.References(hash)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	// Holders name collections and assets in other topics
+	for _, topic := range refs.Topics {
+		if !s.authorize(w, identity, &auth.ActionContext{
+			Action:    constants.AuthActionMetadata,
+			TopicName: topic,
+		}) {
+			return
+		}
+	}
+
+	WriteSuccess(w, refs)
+}
+
+// GET /api/assets/:hash/timeline - The asset's history in one feed, newest
+// first. Query params: limit, offset, since, until (unix seconds), kind.
+//
+// Downloads, other audit entries and the users behind events come from the
+// audit log and follow its visibility rules: they are left out without
+// view_audit, and limited to the caller's own entries without can_view_all.
+func (s *Server) getAssetTimeline(w http.ResponseWriter, r *http.Request, hash string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	info, err := s.app.Services.Asset.GetInfo(hash)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionMetadata,
+		TopicName: info.TopicName,
+	}) {
+		return
+	}
+
+	q := r.URL.Query()
+	var opts services.TimelineOptions
+	opts.Limit, _ = strconv.Atoi(q.Get("limit"))
+	opts.Offset, _ = strconv.Atoi(q.Get("offset"))
+	opts.Since, _ = strconv.ParseInt(q.Get("since"), 10, 64)
+	opts.Until, _ = strconv.ParseInt(q.Get("until"), 10, 64)
+	opts.Kind = q.Get("kind")
+
+	evaluator := s.app.Services.Auth.GetEvaluator()
+	if result := evaluator.Evaluate(identity, &auth.ActionContext{Action: constants.AuthActionViewAudit}); result.Allowed {
+		opts.IncludeAudit = true
+		if !auth.CanViewAllAudit(identity, result.MatchedGrant) {
+			opts.AuditUsername = identity.User.Username
+		}
+	}
+	opts.TopicVisible = func(topic string) bool {
+		return evaluator.HasTopicAccess(identity, constants.AuthActionMetadata, topic)
+	}
+
+	timeline, err := s.app.Services.Timeline.Timeline(hash, opts)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, timeline)
+}
+
+// GET /api/assets/:hash/related - Assets downloaded together with this one
+// by the same users, and assets of its topic sharing the most metadata
+// values with it. Query param: limit.
+func (s *Server) getAssetRelated(w http.ResponseWriter, r *http.Request, hash string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	info, err := s.app.Services.Asset.GetInfo(hash)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionQuery,
+		TopicName: info.TopicName,
+	}) {
+		return
+	}
+
+	limit := discoveryParam(r, "limit", constants.DiscoveryDefaultLimit, constants.DiscoveryMaxLimit)
+	evaluator := s.app.Services.Auth.GetEvaluator()
+	related, err := s.app.Services.Discovery.Related(hash, info.TopicName, limit, func(topic string) bool {
+		return evaluator.HasTopicAccess(identity, constants.AuthActionQuery, topic)
+	})
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, related)
+}
+
+// GET /api/popular - Most downloaded assets of each topic the caller can
+// query. Query params: days (default 30), limit (per topic), topic.
+func (s *Server) handlePopular(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	days := discoveryParam(r, "days", constants.DiscoveryDefaultDays, constants.DiscoveryMaxDays)
+	limit := discoveryParam(r, "limit", constants.DiscoveryDefaultLimit, constants.DiscoveryMaxLimit)
+	only := r.URL.Query().Get("topic")
+
+	evaluator := s.app.Services.Auth.GetEvaluator()
+	popular, err := s.app.Services.Discovery.Popular(days, limit, func(topic string) bool {
+		return (only == "" || topic == only) && evaluator.HasTopicAccess(identity, constants.AuthActionQuery, topic)
+	})
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, popular)
+}
+
+// GET /api/search - Assets whose origin name, extension or metadata values
+// match every word of ?q=, best first, across the topics the identity may
+// query. ?topic= restricts the search to one topic.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	limit := discoveryParam(r, "limit", constants.SearchDefaultLimit, constants.SearchMaxLimit)
+	only := r.URL.Query().Get("topic")
+
+	evaluator := s.app.Services.Auth.GetEvaluator()
+	results, err := s.app.Services.Search.Search(r.URL.Query().Get("q"), limit, func(topic string) bool {
+		return (only == "" || topic == only) && evaluator.HasTopicAccess(identity, constants.AuthActionQuery, topic)
+	})
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, results)
+}
+
+// discoveryParam reads a positive integer query param, clamped to ceiling.
+func discoveryParam(r *http.Request, name string, def, ceiling int) int {
+	n, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || n <= 0 {
+		return def
+	}
+	return min(n, ceiling)
+}
+
+// GET /api/assets/:hash/preview - Downscaled image or model wireframe, when
+// the extension's storage policy enables previews. ?size= sets the longest
+// edge. Previews are cached after the first request.
+func (s *Server) getAssetPreview(w http.ResponseWriter, r *http.Request, hash string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	size := constants.PreviewDefaultSize
+	if raw := r.URL.Query().Get(constants.PreviewQueryParamSize); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > constants.PreviewMaxSize {
+			WriteError(w, http.StatusBadRequest, fmt.Sprintf("size must be between 1 and %d", constants.PreviewMaxSize), constants.ErrCodeInvalidRequest)
+			return
+		}
+		size = n
+	}
+
+	info, err := s.app.Services.Asset.GetInfo(hash)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	// Previews expose the asset's content: same rules as a download,
+	// including the watermark a grant forces
+	result, ok := s.authorizeWithResult(w, identity, &auth.ActionContext{
+		Action:      constants.AuthActionDownload,
+		TopicName:   info.TopicName,
+		VolumeBytes: info.Size,
+	})
+	if !ok {
+		return
+	}
+	profileName, err := s.downloadWatermark(r, identity, result)
+	if err != nil {
+		WriteError(w, http.StatusForbidden, err.Error(), constants.ErrCodeAuthConstraintViolation)
+		return
+	}
+	var profile *config.WatermarkConfig
+	if profileName != "" {
+		if profile, err = s.app.Services.Watermark.Resolve(profileName); err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+	}
+	if err := s.app.Services.Previews.Check(info); err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if profile == nil {
+		// Plain previews are derived purely from the hash and size: answer
+		// conditional requests before rendering
+		etag := assetETagVariant(hash, fmt.Sprintf("preview-%d", size))
+		modTime := time.Unix(info.CreatedAt, 0)
+		setAssetCacheHeaders(w, etag, modTime)
+		if checkNotModified(w, r, etag, modTime) {
+			return
+		}
+	}
+
+	data, contentType, err := s.app.Services.Previews.Get(info, size)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	if profile != nil {
+		// A preview that cannot carry the watermark is not served at all
+		if !s.app.Services.Watermark.Supports(contentType) {
+			WriteError(w, http.StatusForbidden, fmt.Sprintf("preview cannot carry watermark %q", profileName), constants.ErrCodeAuthConstraintViolation)
+			return
+		}
+		if data, err = s.app.Services.Watermark.Apply(profile, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		w.Header().Set(constants.HeaderXWatermark, profileName)
+	}
+
+	w.Header().Set(constants.HeaderContentType, contentType)
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.Write(data)
+}
+
+// POST /api/assets/:hash/metadata - Add/delete metadata
+func (s *Server) postMetadata(w http.ResponseWriter, r *http.Request, hash string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionMetadata}) {
+		return
+	}
+
+	var req services.MetadataSetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	// The key must fall inside the caller's metadata namespaces
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionMetadata, MetadataKeys: []string{req.Key}}) {
+		return
+	}
+
+	// Check disk usage limit before writing metadata (set operations grow SQLite)
 	if req.Op == constants.BatchMetadataOpSet {
 		if !s.checkDiskLimit(w, r, identity, "metadata_set") {
 			return
@@ -634,9 +1473,13 @@ func (s *Server) postMetadata(w http.ResponseWriter, r *http.Request, hash strin
 		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionMetadata, 0)
 	}
 
-	// Invalidate stats cache for the affected topic
+	// Invalidate stats cache and notify subscribers of the affected topic
 	if result.TopicName != "" {
 		s.app.Services.StatsCache.InvalidateTopic(result.TopicName)
+		if s.app.Services.Notification != nil {
+			s.app.Services.Notification.MetadataChanged(result.TopicName,
+				[]services.MetadataChange{{AssetID: hash, Key: req.Key}}, identity.User.ID, identity.User.Username)
+		}
 	}
 
 	WriteSuccess(w, map[string]interface{}{
@@ -657,7 +1500,7 @@ func (s *Server) handleQueries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	identity := s.requireAuth(w, r)
+	identity := s.requireAuthOrPublic(w, r)
 	if identity == nil {
 		return
 	}
@@ -672,11 +1515,55 @@ func (s *Server) handleQueries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Anonymous callers only see the presets public mode exposes
+	if identity.IsAnonymous() {
+		visible := presets[:0]
+		for _, p := range presets {
+			if slices.Contains(s.app.Config.Public.AllowedPresets, p.Name) {
+				visible = append(visible, p)
+			}
+		}
+		presets = visible
+	}
+
 	WriteSuccess(w, map[string]interface{}{
 		"presets": presets,
 	})
 }
 
+// POST /api/queries/validate - Check a preset definition without registering it
+func (s *Server) handleQueryValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	// Presets are part of the server configuration
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageConfig}) {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, constants.PresetSandboxMaxBytes)
+	var req services.PresetValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	report, err := s.app.Services.Query.Validate(&req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, report)
+}
+
 // POST /api/query/:preset - Run a preset query
 func (s *Server) handleQueryExecution(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -684,7 +1571,7 @@ func (s *Server) handleQueryExecution(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	identity := s.requireAuth(w, r)
+	identity := s.requireAuthOrPublic(w, r)
 	if identity == nil {
 		return
 	}
@@ -719,6 +1606,30 @@ func (s *Server) handleQueryExecution(w http.ResponseWriter, r *http.Request) {
 		req = services.QueryRequest{}
 	}
 
+	// Federated presets attach whole topic databases: check each against
+	// the caller's allowed topics before anything is opened
+	federatedTopics, err := s.app.Services.Query.FederatedTopics(presetName, &req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	for _, topic := range federatedTopics {
+		if !s.authorize(w, identity, &auth.ActionContext{
+			Action:     constants.AuthActionQuery,
+			PresetName: presetName,
+			TopicName:  topic,
+		}) {
+			return
+		}
+	}
+	if federatedTopics == nil {
+		topics, ok := s.authorizeQueryTopics(w, identity, presetName, req.Topics)
+		if !ok {
+			return
+		}
+		req.Topics = topics
+	}
+
 	// Execute query via service
 	result, topicNames, err := s.app.Services.Query.Execute(presetName, &req)
 	if err != nil {
@@ -727,7 +1638,7 @@ func (s *Server) handleQueryExecution(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Increment quota
-	if s.app.Services.Auth != nil {
+	if s.app.Services.Auth != nil && !identity.IsAnonymous() {
 		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionQuery, 0)
 	}
 
@@ -742,3 +1653,132 @@ func (s *Server) handleQueryExecution(w http.ResponseWriter, r *http.Request) {
 
 	WriteSuccess(w, result)
 }
+
+// authorizeQueryTopics checks the topics a regular preset fans out over
+// against the caller's allowed topics. Every named topic must be allowed;
+// with none named, the fan-out is narrowed to the topics the caller may
+// query. Returns the topics to query (nil for all) and false after writing
+// a denial.
+func (s *Server) authorizeQueryTopics(w http.ResponseWriter, identity *auth.Identity, presetName string, topics []string) ([]string, bool) {
+	if len(topics) > 0 {
+		for _, topic := range topics {
+			if !s.authorize(w, identity, &auth.ActionContext{
+				Action:     constants.AuthActionQuery,
+				PresetName: presetName,
+				TopicName:  topic,
+			}) {
+				return nil, false
+			}
+		}
+		return topics, true
+	}
+
+	evaluator := s.app.Services.Auth.GetEvaluator()
+	all := s.app.ListTopics()
+	allowed := make([]string, 0, len(all))
+	for _, topic := range all {
+		if evaluator.Evaluate(identity, &auth.ActionContext{
+			Action:     constants.AuthActionQuery,
+			PresetName: presetName,
+			TopicName:  topic,
+		}).Allowed {
+			allowed = append(allowed, topic)
+		}
+	}
+	if len(allowed) == len(all) {
+		return nil, true
+	}
+	if len(allowed) == 0 {
+		WriteError(w, http.StatusForbidden, "no topics allowed for this query", constants.ErrCodeAuthConstraintViolation)
+		return nil, false
+	}
+	return allowed, true
+}
+
+// GET /api/query/schema - Fields, operators and limits of the query builder,
+// with the most used metadata keys of the topics (?topic=, repeatable) the
+// caller may query. Grants see it as the preset "build".
+func (s *Server) handleQueryBuilderSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuthOrPublic(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:     constants.AuthActionQuery,
+		PresetName: constants.QueryBuilderPreset,
+	}) {
+		return
+	}
+
+	topics, ok := s.authorizeQueryTopics(w, identity, constants.QueryBuilderPreset, r.URL.Query()["topic"])
+	if !ok {
+		return
+	}
+
+	schema, err := s.app.Services.Query.BuilderSchema(topics)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, schema)
+}
+
+// POST /api/query/build - Run a structured filter tree from the query
+// builder. Every value is bound as a parameter; clients never send SQL.
+func (s *Server) handleQueryBuild(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuthOrPublic(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:     constants.AuthActionQuery,
+		PresetName: constants.QueryBuilderPreset,
+	}) {
+		return
+	}
+
+	var req services.QueryBuildRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	topics, ok := s.authorizeQueryTopics(w, identity, constants.QueryBuilderPreset, req.Topics)
+	if !ok {
+		return
+	}
+	req.Topics = topics
+
+	result, topicNames, err := s.app.Services.Query.Build(&req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.Services.Auth != nil && !identity.IsAnonymous() {
+		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionQuery, 0)
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionQuerying, getClientIP(r), getAuditUsername(identity), audit.QueryingDetails{
+			Preset:   constants.QueryBuilderPreset,
+			Topics:   topicNames,
+			RowCount: result.RowCount,
+		})
+	}
+
+	WriteSuccess(w, result)
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Chunk Dedup Analysis Handlers
+// =============================================================================
+
+// GET  /api/stats/chunk-dedup - Latest analysis report and running state
+// POST /api/stats/chunk-dedup - Start an analysis in the background
+func (s *Server) handleChunkDedup(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if s.app.Config.WorkingDirectory == "" {
+		WriteError(w, http.StatusBadRequest, "Working directory not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		WriteSuccess(w, s.app.Services.ChunkDedup.Status())
+		return
+	}
+
+	// Empty body = analyze all topics with the default sample size
+	var opts services.ChunkDedupOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && err != io.EOF {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if err := s.app.Services.ChunkDedup.Start(opts); err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	s.logger.Info("Chunk dedup analysis started by %s", getAuditUsername(identity))
+	WriteJSON(w, http.StatusAccepted, map[string]interface{}{
+		"started": true,
+	})
+}
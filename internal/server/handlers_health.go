@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Health Handlers
+// =============================================================================
+
+// GET /healthz - Liveness probe. Answers as long as the process serves HTTP.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"status":         constants.HealthStatusOK,
+		"uptime_seconds": int64(time.Since(s.app.StartedAt).Seconds()),
+	})
+}
+
+// GET /readyz - Readiness probe. 200 once fully initialized, 503 otherwise,
+// with the state of each component and the reason it is not ready.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := s.app.Services.Health.Readiness()
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	WriteJSON(w, status, report)
+}
+
+// GET /api/health/startup - Current and past startup reports
+func (s *Server) handleStartupReports(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, _ = strconv.Atoi(v)
+	}
+
+	current, history, err := s.app.Services.Health.StartupReports(limit)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"current": current,
+		"history": history,
+	})
+}
@@ -13,21 +13,7 @@ import (
 // =============================================================================
 
 // GET /api/monitoring - System monitoring info
-func (s *Server) handleMonitoring(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	identity := s.requireAuth(w, r)
-	if identity == nil {
-		return
-	}
-
-	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageConfig}) {
-		return
-	}
-
+func (s *Server) handleMonitoring(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
 	// Check if configured
 	if s.app.Config.WorkingDirectory == "" {
 		WriteError(w, http.StatusBadRequest, "Working directory not configured", constants.ErrCodeNotConfigured)
@@ -41,26 +27,39 @@ func (s *Server) handleMonitoring(w http.ResponseWriter, r *http.Request) {
 		s.handleServiceError(w, err)
 		return
 	}
+	info.Streams = s.streamStats()
+	if s.app.Services.OriginCache.Enabled() {
+		info.OriginCache, err = s.app.Services.OriginCache.Status(0)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+	}
+	if s.app.Services.Validation.Enabled() {
+		info.Validation = s.app.Services.Validation.Status()
+	}
+	info.Watch = s.app.Services.WatchFolders.Status()
 
 	WriteSuccess(w, info)
 }
 
-// GET /api/monitoring/logs/:level/:filename - Read log file content
-func (s *Server) handleMonitoringLogFile(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	identity := s.requireAuth(w, r)
-	if identity == nil {
+// GET /api/origin-cache - Size, hit and miss counters and the most recently
+// fetched entries of the origin cache
+func (s *Server) handleOriginCache(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if s.app.Services.OriginCache == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Origin cache not available", constants.ErrCodeNotConfigured)
 		return
 	}
-
-	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageConfig}) {
+	status, err := s.app.Services.OriginCache.Status(constants.OriginStatsRecentEntries)
+	if err != nil {
+		s.handleServiceError(w, err)
 		return
 	}
+	WriteSuccess(w, status)
+}
 
+// GET /api/monitoring/logs/:level/:filename - Read log file content
+func (s *Server) handleMonitoringLogFile(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
 	// Check if configured
 	if s.app.Config.WorkingDirectory == "" {
 		WriteError(w, http.StatusBadRequest, "Working directory not configured", constants.ErrCodeNotConfigured)
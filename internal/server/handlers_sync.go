@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Sync Manifest Diff Handler
+// =============================================================================
+
+// POST /api/sync/diff - Compare a client hash manifest against the server
+//
+// The manifest is the request body: a JSON array of hashes (or of
+// {"hash","topic"} objects, optionally wrapped as {"hashes": [...]}), a text
+// file with one "<hash> [topic]" per line, or a multipart upload whose
+// "manifest" part is either. ?topic= sets the expected topic for entries
+// that do not name one.
+//
+// Results stream back as NDJSON, one line per manifest entry in order,
+// flushed every constants.SyncDiffBatchSize entries, followed by a
+// {"summary": {...}} line. A failure after streaming started is reported as
+// a final {"error": true, ...} line instead of a summary.
+func (s *Server) handleSyncDiff(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	defaultTopic := r.URL.Query().Get(constants.SyncDiffQueryParamTopic)
+	manifest, err := s.openSyncManifest(r, defaultTopic)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	// Topic names are only revealed for topics the caller may query
+	evaluator := s.app.Services.Auth.GetEvaluator()
+	topicVisible := make(map[string]bool)
+	canSee := func(topic string) bool {
+		visible, ok := topicVisible[topic]
+		if !ok {
+			visible = evaluator.Evaluate(identity, &auth.ActionContext{
+				Action:    constants.AuthActionQuery,
+				TopicName: topic,
+			}).Allowed
+			topicVisible[topic] = visible
+		}
+		return visible
+	}
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	streaming := false
+
+	summary, err := s.app.Services.Sync.Diff(manifest, func(results []services.SyncDiffResult) error {
+		if !streaming {
+			w.Header().Set(constants.HeaderContentType, constants.ContentTypeNDJSON)
+			w.WriteHeader(http.StatusOK)
+			streaming = true
+		}
+		for i := range results {
+			if results[i].Topic != "" && !canSee(results[i].Topic) {
+				results[i].Topic = ""
+			}
+			if err := enc.Encode(&results[i]); err != nil {
+				return err
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	if err != nil {
+		if !streaming {
+			s.handleServiceError(w, err)
+			return
+		}
+		code, ok := services.IsServiceError(err)
+		if !ok {
+			code = constants.ErrCodeStreamingError
+		}
+		s.logger.Warn("Sync diff aborted after %d entries: %v", summary.Total, err)
+		enc.Encode(map[string]interface{}{"error": true, "message": err.Error(), "code": code})
+		return
+	}
+
+	if !streaming {
+		w.Header().Set(constants.HeaderContentType, constants.ContentTypeNDJSON)
+		w.WriteHeader(http.StatusOK)
+	}
+	enc.Encode(map[string]interface{}{"summary": summary})
+
+	if s.app.Services.Auth != nil {
+		evaluator.IncrementQuota(identity.User.ID, constants.AuthActionQuery, 0)
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionSyncDiff, getClientIP(r), getAuditUsername(identity), audit.SyncDiffDetails{
+			Topic:     defaultTopic,
+			Total:     summary.Total,
+			Present:   summary.Present,
+			Missing:   summary.Missing,
+			Elsewhere: summary.Elsewhere,
+			Invalid:   summary.Invalid,
+		})
+	}
+}
+
+// openSyncManifest picks a manifest reader for the request body based on its
+// content type. Multipart uploads are streamed part by part, never buffered.
+func (s *Server) openSyncManifest(r *http.Request, defaultTopic string) (services.SyncManifestReader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(constants.HeaderContentType))
+
+	switch mediaType {
+	case constants.ContentTypeJSON:
+		return services.NewJSONManifestReader(r.Body, defaultTopic), nil
+	case "multipart/form-data":
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return nil, services.WrapServiceError(constants.ErrCodeInvalidManifest, "invalid multipart body", err)
+		}
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				return nil, services.NewServiceError(constants.ErrCodeInvalidManifest,
+					"multipart body has no "+constants.SyncDiffManifestField+" part")
+			}
+			if part.FormName() != constants.SyncDiffManifestField {
+				part.Close()
+				continue
+			}
+			partType, _, _ := mime.ParseMediaType(part.Header.Get(constants.HeaderContentType))
+			if partType == constants.ContentTypeJSON {
+				return services.NewJSONManifestReader(part, defaultTopic), nil
+			}
+			return services.NewTextManifestReader(part, defaultTopic), nil
+		}
+	default:
+		return services.NewTextManifestReader(r.Body, defaultTopic), nil
+	}
+}
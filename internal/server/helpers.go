@@ -80,3 +80,53 @@ func (s *Server) checkDiskLimit(w http.ResponseWriter, r *http.Request, identity
 	s.handleServiceError(w, err)
 	return false
 }
+
+// checkUploadHeadroom verifies that an upload of required bytes fits in the
+// free space and below max_disk_usage. Returns true if the upload should
+// proceed; otherwise writes a 507 STORAGE_FULL rejection carrying the current
+// headroom and audit-logs the hit.
+func (s *Server) checkUploadHeadroom(w http.ResponseWriter, r *http.Request, identity *auth.Identity, required int64) bool {
+	workDir := s.app.Config.WorkingDirectory
+	if workDir == "" {
+		return true // Not configured yet, other checks will catch this
+	}
+
+	headroom, err := services.CheckStorageHeadroom(workDir, s.app.Config.MaxDiskUsage, required)
+	if err == nil {
+		return true
+	}
+
+	s.logger.Warn("Upload rejected, storage full: user=%s ip=%s required=%d %v",
+		getAuditUsername(identity), getClientIP(r), required, err)
+
+	if s.app.AuditLogger != nil && headroom != nil {
+		usedBytes, _ := services.GetDiskUsageBytes(workDir)
+		s.app.AuditLogger.Log(constants.AuditActionDiskLimitHit, getClientIP(r), getAuditUsername(identity), audit.DiskLimitHitDetails{
+			Operation:      "upload",
+			DiskUsedBytes:  usedBytes,
+			DiskLimitBytes: s.app.Config.MaxDiskUsage,
+			RequiredBytes:  required,
+			HeadroomBytes:  headroom.HeadroomBytes,
+		})
+	}
+
+	WriteJSON(w, http.StatusInsufficientStorage, UploadRejection{
+		APIError: APIError{Error: true, Message: err.Error(), Code: constants.ErrCodeStorageFull},
+		Status:   constants.UploadStatusRejected,
+		Reason:   constants.ErrCodeStorageFull,
+		Headroom: headroom,
+	})
+	return false
+}
+
+// metadataSelectionFromQuery reads ?metadata= and ?metadata_keys= (comma-separated).
+func metadataSelectionFromQuery(r *http.Request) services.MetadataSelection {
+	q := r.URL.Query()
+	sel := services.MetadataSelection{Mode: q.Get(constants.MetadataModeParam)}
+	if keys := q.Get(constants.MetadataKeysParam); keys != "" {
+		for _, key := range strings.Split(keys, ",") {
+			sel.Keys = append(sel.Keys, strings.TrimSpace(key))
+		}
+	}
+	return sel
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/zeebo/blake3"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// idempotency replays the stored response of a mutating API request retried
+// with the same Idempotency-Key instead of executing it again. Keys are
+// scoped per user; requests without a key, or without an authenticated
+// user, pass through unchanged. Must run after auth.Middleware.Authenticate.
+func (s *Server) idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(constants.HeaderIdempotencyKey)
+		if key == "" || !isMutatingMethod(r.Method) || !strings.HasPrefix(r.URL.Path, constants.CompressionAPIPathPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		svc := s.app.Services.Idempotency
+		identity, ok := auth.RequireAuth(r)
+		if svc == nil || !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		digest, spool, err := spoolRequestBody(r)
+		if spool != nil {
+			defer os.Remove(spool.Name())
+			defer spool.Close()
+		}
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "Failed to read request body", constants.ErrCodeInvalidRequest)
+			return
+		}
+
+		path := r.URL.RequestURI()
+		stored, err := svc.Begin(identity.User.ID, key, r.Method, path, digest)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		if stored != nil {
+			if stored.ContentType != "" {
+				w.Header().Set(constants.HeaderContentType, stored.ContentType)
+			}
+			w.Header().Set(constants.HeaderIdempotentReplayed, "true")
+			w.WriteHeader(*stored.Status)
+			w.Write(stored.Body) //nolint:errcheck
+			return
+		}
+
+		// Release the key unless the response is stored, so a retry runs
+		// again after a server error or panic
+		rec := newIdempotencyRecorder(w, s.app.Config.Idempotency.MaxResponseBytes)
+		completed := false
+		defer func() {
+			if !completed {
+				svc.Release(identity.User.ID, key)
+			}
+		}()
+
+		r.Body = spool
+		next.ServeHTTP(rec, r)
+
+		if rec.status < http.StatusInternalServerError && !rec.overflow && !rec.streamed {
+			svc.Complete(identity.User.ID, key, rec.status, rec.Header().Get(constants.HeaderContentType), rec.buf.Bytes())
+			completed = true
+		}
+	})
+}
+
+// isMutatingMethod reports whether method may change server state.
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// spoolRequestBody copies the request body to a temporary file while
+// hashing it, so large uploads are digested without holding them in memory.
+// Multipart bodies are hashed part by part: clients choose a new boundary on
+// every attempt, so the raw bytes of a retry differ.
+func spoolRequestBody(r *http.Request) (string, *os.File, error) {
+	spool, err := os.CreateTemp("", constants.IdempotencySpoolPattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	h := blake3.New()
+	body := io.TeeReader(r.Body, spool)
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get(constants.HeaderContentType))
+	fmt.Fprintf(h, "%s\n", mediaType)
+
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", spool, err
+			}
+			fmt.Fprintf(h, "part %q %q %q\n", part.FormName(), part.FileName(), part.Header.Get(constants.HeaderContentType))
+			n, err := io.Copy(h, part)
+			if err != nil {
+				return "", spool, err
+			}
+			fmt.Fprintf(h, "\n%d\n", n)
+		}
+		// Spool any epilogue after the closing boundary
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return "", spool, err
+		}
+	} else if _, err := io.Copy(h, body); err != nil {
+		return "", spool, err
+	}
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return "", spool, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), spool, nil
+}
+
+// idempotencyRecorder passes the response through while keeping a copy of
+// the body for replay. Bodies over limit and flushed (streamed) responses
+// are not kept.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buf         bytes.Buffer
+	limit       int64
+	overflow    bool
+	streamed    bool
+}
+
+func newIdempotencyRecorder(w http.ResponseWriter, limit int64) *idempotencyRecorder {
+	return &idempotencyRecorder{
+		ResponseWriter: w,
+		status:         http.StatusOK,
+		limit:          limit,
+	}
+}
+
+// WriteHeader records the status code.
+func (r *idempotencyRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write copies b into the replay buffer until the limit is exceeded.
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if !r.overflow {
+		if int64(r.buf.Len()+len(b)) > r.limit {
+			r.overflow = true
+			r.buf = bytes.Buffer{}
+		} else {
+			r.buf.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush marks the response as streamed; streams are never replayed.
+func (r *idempotencyRecorder) Flush() {
+	r.streamed = true
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (r *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Dat Integrity Handlers
+// =============================================================================
+
+// GET  /api/topics/:name/integrity - Findings of the latest .dat integrity scan
+// POST /api/topics/:name/integrity - Rescan the topic now and return the report
+func (s *Server) handleTopicIntegrity(w http.ResponseWriter, r *http.Request, topicName string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionVerify,
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		findings, err := s.app.Services.Integrity.Findings(topicName)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"topic":    topicName,
+			"findings": findings,
+		})
+		return
+	}
+
+	report, err := s.app.Services.Integrity.ScanTopic(topicName)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.Services.Auth != nil {
+		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionVerify, 0)
+	}
+
+	s.logger.Info("Integrity scan of topic %s by %s: %d finding(s)", topicName, getAuditUsername(identity), len(report.Findings))
+	WriteSuccess(w, report)
+}
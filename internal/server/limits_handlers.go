@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// GET /api/limits - Effective request and result limits
+// PUT /api/limits - Change limits at runtime (requires manage_config)
+func (s *Server) handleLimits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		WriteSuccess(w, s.app.Services.Limits.Get())
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageConfig}) {
+		return
+	}
+
+	var req services.LimitsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	before := s.app.Services.Config.Snapshot()
+	limits, changed, err := s.app.Services.Limits.Update(&req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if len(changed) > 0 {
+		s.app.Services.Config.RecordChange(getClientIP(r), getAuditUsername(identity), constants.ConfigChangeSourceAPI, before, changed)
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"limits":  limits,
+		"changed": changed,
+	})
+}
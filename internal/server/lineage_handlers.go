@@ -0,0 +1,174 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// lineageReparentRequest is the body of POST /api/lineage/reparent. Changes
+// are given either as pairs or as the rows of a query preset, mapped through
+// the named child and parent columns.
+type lineageReparentRequest struct {
+	Changes []services.ReparentChange `json:"changes,omitempty"`
+
+	QueryPreset  string                 `json:"query_preset,omitempty"`
+	QueryParams  map[string]interface{} `json:"query_params,omitempty"`
+	Topics       []string               `json:"topics,omitempty"`
+	ChildColumn  string                 `json:"child_column,omitempty"` // defaults to hash or asset_id
+	ParentColumn string                 `json:"parent_column,omitempty"`
+
+	Reason string `json:"reason,omitempty"`
+	DryRun bool   `json:"dry_run,omitempty"`
+}
+
+// lineageReparentRejectedResponse reports the changes that failed
+// validation alongside the error.
+type lineageReparentRejectedResponse struct {
+	APIError
+	Result *services.ReparentResult `json:"result"`
+}
+
+// POST /api/lineage/reparent - Replace the parents of existing assets
+// (requires metadata on the topics of the assets and of their new parents,
+// and query on the preset when changes come from one)
+func (s *Server) handleLineageReparent(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	var req lineageReparentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+	if (len(req.Changes) > 0) == (req.QueryPreset != "") {
+		WriteError(w, http.StatusBadRequest, "exactly one of changes or query_preset is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+	if len(req.Reason) > constants.LineageReparentMaxReasonLength {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("reason exceeds maximum length of %d characters", constants.LineageReparentMaxReasonLength), constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	changes := req.Changes
+	if req.QueryPreset != "" {
+		var ok bool
+		if changes, ok = s.reparentChangesFromQuery(w, identity, &req); !ok {
+			return
+		}
+	}
+	if len(changes) > s.app.Config.Batch.MaxOperations {
+		WriteError(w, http.StatusBadRequest, "Too many changes", constants.ErrCodeBatchTooManyOperations)
+		return
+	}
+
+	plan, err := s.app.Services.Lineage.PlanReparent(changes)
+	if err != nil {
+		writeReparentError(s, w, plan, err)
+		return
+	}
+	for _, topic := range plan.Topics {
+		if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionMetadata, TopicName: topic}) {
+			return
+		}
+	}
+	if req.DryRun {
+		WriteSuccess(w, plan)
+		return
+	}
+
+	username := getAuditUsername(identity)
+	result, err := s.app.Services.Lineage.Reparent(changes, username, req.Reason)
+	if err != nil {
+		writeReparentError(s, w, result, err)
+		return
+	}
+
+	if s.app.Services.Auth != nil {
+		s.app.Services.Auth.GetEvaluator().IncrementQuota(identity.User.ID, constants.AuthActionMetadata, 0)
+	}
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionLineageReparented, getClientIP(r), username, audit.LineageReparentedDetails{
+			Topics:      result.Topics,
+			Changed:     result.Changed,
+			Unchanged:   result.Unchanged,
+			QueryPreset: req.QueryPreset,
+			Reason:      req.Reason,
+		})
+	}
+
+	WriteSuccess(w, result)
+}
+
+// reparentChangesFromQuery runs the request's preset and maps each row to a
+// change. An empty or NULL parent column removes the parent.
+func (s *Server) reparentChangesFromQuery(w http.ResponseWriter, identity *auth.Identity, req *lineageReparentRequest) ([]services.ReparentChange, bool) {
+	if req.ParentColumn == "" {
+		WriteError(w, http.StatusBadRequest, "parent_column is required with query_preset", constants.ErrCodeInvalidRequest)
+		return nil, false
+	}
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionQuery, PresetName: req.QueryPreset}) {
+		return nil, false
+	}
+
+	queryReq := &services.QueryRequest{Params: req.QueryParams, Topics: req.Topics}
+	federatedTopics, err := s.app.Services.Query.FederatedTopics(req.QueryPreset, queryReq)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return nil, false
+	}
+	for _, topic := range federatedTopics {
+		if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionQuery, PresetName: req.QueryPreset, TopicName: topic}) {
+			return nil, false
+		}
+	}
+
+	result, _, err := s.app.Services.Query.Execute(req.QueryPreset, queryReq)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return nil, false
+	}
+
+	childIdx, parentIdx := -1, -1
+	for i, col := range result.Columns {
+		switch {
+		case col == req.ParentColumn:
+			parentIdx = i
+		case req.ChildColumn != "" && col == req.ChildColumn,
+			req.ChildColumn == "" && childIdx == -1 && (col == "hash" || col == "asset_id"):
+			childIdx = i
+		}
+	}
+	if childIdx == -1 {
+		WriteError(w, http.StatusBadRequest, "Query must return the child column (hash or asset_id by default)", constants.ErrCodeInvalidRequest)
+		return nil, false
+	}
+	if parentIdx == -1 {
+		WriteError(w, http.StatusBadRequest, "Query does not return column "+req.ParentColumn, constants.ErrCodeInvalidRequest)
+		return nil, false
+	}
+
+	changes := make([]services.ReparentChange, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		hash, _ := row[childIdx].(string)
+		parent, _ := row[parentIdx].(string)
+		changes = append(changes, services.ReparentChange{Hash: hash, Parent: parent})
+	}
+	return changes, true
+}
+
+// writeReparentError reports rejected changes with the validation result,
+// and other errors as usual.
+func writeReparentError(s *Server, w http.ResponseWriter, result *services.ReparentResult, err error) {
+	svcErr, ok := err.(*services.ServiceError)
+	if !ok || result == nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	WriteJSON(w, serviceErrorStatus(svcErr.Code), lineageReparentRejectedResponse{
+		APIError: APIError{Error: true, Message: svcErr.Message, Code: svcErr.Code},
+		Result:   result,
+	})
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Link Export Handlers
+// =============================================================================
+
+// LinkSelectionRequest selects assets into a link export the way a bulk
+// download selects them.
+type LinkSelectionRequest struct {
+	Mode            string                 `json:"mode"`             // "query" | "ids"
+	Preset          string                 `json:"preset"`           // for mode="query"
+	Params          map[string]interface{} `json:"params"`           // for mode="query"
+	Topics          []string               `json:"topics"`           // for mode="query", optional
+	AssetIDs        []string               `json:"asset_ids"`        // for mode="ids"
+	Collection      string                 `json:"collection"`       // optional collection filter
+	CollectionPaths bool                   `json:"collection_paths"` // place links under <collection>/
+	Replace         bool                   `json:"replace"`          // release linked assets missing from the selection
+}
+
+// CreateLinkExportRequest creates a link export, optionally with a first
+// selection (mode set).
+type CreateLinkExportRequest struct {
+	Label          string `json:"label"`
+	FilenameFormat string `json:"filename_format"` // "hash" | "original" | "hash_original" | "path"
+	LinkSelectionRequest
+}
+
+// /api/exports/links - GET lists the current user's link exports, POST
+// creates one
+func (s *Server) handleLinkExports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	links, ok := s.linkExportService(w)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		exports, err := links.List(identity.User.ID)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"link_exports": exports,
+		})
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionBulkDownload}) {
+		return
+	}
+
+	var req CreateLinkExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body: "+err.Error(), constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	// Resolve the first selection before anything is created, so a bad
+	// request leaves no empty export behind
+	var assets []services.LinkAsset
+	if req.Mode != "" {
+		if assets, req.FilenameFormat, ok = s.resolveLinkSelection(w, identity, &req.LinkSelectionRequest, req.FilenameFormat); !ok {
+			return
+		}
+	}
+
+	export, err := links.Create(identity.User.ID, req.Label, req.FilenameFormat)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	result := &services.LinkSelectResult{Export: export, Failed: []services.LinkFailure{}}
+	if req.Mode != "" {
+		if result, err = links.Select(identity.User.ID, export.ID, assets, false); err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		s.auditLinkSelection(r, identity, &req.LinkSelectionRequest, result)
+	}
+
+	WriteJSON(w, http.StatusCreated, result)
+}
+
+// /api/exports/links/{id}[/select|/release] - GET details, DELETE, POST
+// select (bulk download selection) or POST release ({"asset_ids": [...]})
+func (s *Server) handleLinkExportRoutes(w http.ResponseWriter, r *http.Request) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	id, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/exports/links/"), "/"), "/")
+	if id == "" {
+		WriteError(w, http.StatusBadRequest, "Link export ID is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	links, ok := s.linkExportService(w)
+	if !ok {
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		export, err := links.Get(identity.User.ID, id)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, export)
+
+	case action == "" && r.Method == http.MethodDelete:
+		// Owners may always remove their exports, even after losing the grant
+		released, err := links.Delete(identity.User.ID, id)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		s.auditLinkRelease(r, identity, id, released, true)
+		WriteSuccess(w, map[string]interface{}{
+			"success":  true,
+			"id":       id,
+			"released": released,
+		})
+
+	case action == "select" && r.Method == http.MethodPost:
+		s.selectLinks(w, r, identity, links, id)
+
+	case action == "release" && r.Method == http.MethodPost:
+		var req struct {
+			AssetIDs []string `json:"asset_ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.AssetIDs) == 0 {
+			WriteError(w, http.StatusBadRequest, "asset_ids is required", constants.ErrCodeInvalidRequest)
+			return
+		}
+		released, err := links.Release(identity.User.ID, id, req.AssetIDs)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		s.auditLinkRelease(r, identity, id, released, false)
+		WriteSuccess(w, map[string]interface{}{
+			"success":  true,
+			"id":       id,
+			"released": released,
+		})
+
+	case action != "" && action != "select" && action != "release":
+		http.NotFound(w, r)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// selectLinks links a bulk download selection into an existing export.
+func (s *Server) selectLinks(w http.ResponseWriter, r *http.Request, identity *auth.Identity, links *services.LinkExportService, id string) {
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionBulkDownload}) {
+		return
+	}
+
+	export, err := links.Get(identity.User.ID, id)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	var req LinkSelectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body: "+err.Error(), constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	assets, _, ok := s.resolveLinkSelection(w, identity, &req, export.FilenameFormat)
+	if !ok {
+		return
+	}
+
+	result, err := links.Select(identity.User.ID, id, assets, req.Replace)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	s.auditLinkSelection(r, identity, &req, result)
+
+	WriteSuccess(w, result)
+}
+
+// resolveLinkSelection resolves a selection like a bulk download and checks
+// the caller may download every asset, then names each asset's link the way
+// archives name its entry. Returns the validated filename format; writes the
+// error response and returns false on failure.
+func (s *Server) resolveLinkSelection(w http.ResponseWriter, identity *auth.Identity, sel *LinkSelectionRequest, filenameFormat string) ([]services.LinkAsset, string, bool) {
+	if s.app.Config.WorkingDirectory == "" {
+		WriteError(w, http.StatusBadRequest, "Working directory not configured", constants.ErrCodeNotConfigured)
+		return nil, "", false
+	}
+
+	req := BulkDownloadRequest{
+		Mode:            sel.Mode,
+		Preset:          sel.Preset,
+		Params:          sel.Params,
+		Topics:          sel.Topics,
+		AssetIDs:        sel.AssetIDs,
+		FilenameFormat:  filenameFormat,
+		Collection:      sel.Collection,
+		CollectionPaths: sel.CollectionPaths,
+	}
+	resolved, err := s.resolveBulkDownload(&req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return nil, "", false
+	}
+	if result := s.preflightBulkDownload(identity, resolved); !result.Allowed {
+		writePreflightDenied(w, result)
+		return nil, "", false
+	}
+
+	usedNames := make(map[string]map[string]int)
+	assets := make([]services.LinkAsset, len(resolved))
+	for i, asset := range resolved {
+		assets[i] = services.LinkAsset{ResolvedAsset: asset, Path: assetPath(asset, req.FilenameFormat, usedNames)}
+	}
+	return assets, req.FilenameFormat, true
+}
+
+func (s *Server) auditLinkSelection(r *http.Request, identity *auth.Identity, sel *LinkSelectionRequest, result *services.LinkSelectResult) {
+	if s.app.AuditLogger == nil {
+		return
+	}
+	s.app.AuditLogger.Log(constants.AuditActionLinkExportSelected, getClientIP(r), getAuditUsername(identity), audit.LinkExportSelectedDetails{
+		ExportID:   result.Export.ID,
+		Mode:       sel.Mode,
+		Preset:     sel.Preset,
+		Topics:     sel.Topics,
+		Linked:     result.Linked,
+		Unchanged:  result.Unchanged,
+		Released:   result.Released,
+		Failed:     len(result.Failed),
+		LinkedSize: result.LinkedSize,
+	})
+}
+
+func (s *Server) auditLinkRelease(r *http.Request, identity *auth.Identity, id string, released int, deleted bool) {
+	if s.app.AuditLogger == nil {
+		return
+	}
+	s.app.AuditLogger.Log(constants.AuditActionLinkExportReleased, getClientIP(r), getAuditUsername(identity), audit.LinkExportReleasedDetails{
+		ExportID: id,
+		Released: released,
+		Deleted:  deleted,
+	})
+}
+
+// linkExportService returns the link export service, writing a 503 when it
+// is unavailable (no working directory configured yet).
+func (s *Server) linkExportService(w http.ResponseWriter) (*services.LinkExportService, bool) {
+	if s.app.Services.LinkExports == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Link exports not available", constants.ErrCodeNotConfigured)
+		return nil, false
+	}
+	return s.app.Services.LinkExports, true
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Metadata Import Handlers
+// =============================================================================
+
+// MetadataImportResponse represents the response for POST /api/metadata/import
+type MetadataImportResponse struct {
+	Success   bool                           `json:"success"`
+	ImportID  string                         `json:"import_id"`
+	ResultURL string                         `json:"result_url"`
+	KeyColumn string                         `json:"key_column"`
+	Keys      []string                       `json:"keys"`
+	Summary   services.MetadataImportSummary `json:"summary"`
+	Rows      []*services.MetadataImportRow  `json:"rows"`
+}
+
+// handleMetadataImport handles POST /api/metadata/import
+//
+// The body is a CSV sheet, either sent as-is or as the "file" part of a
+// multipart form. processor and processor_version may be given as query
+// parameters.
+func (s *Server) handleMetadataImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionMetadata}) {
+		return
+	}
+
+	// Check if configured
+	if s.app.Config.WorkingDirectory == "" {
+		WriteError(w, http.StatusBadRequest, "Working directory not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, constants.MetadataImportMaxBytes)
+	body, err := openMetadataImportCSV(r)
+	if err != nil {
+		s.handleMetadataImportError(w, err)
+		return
+	}
+
+	imports := s.app.Services.Import
+	sheet, err := imports.Parse(body)
+	if err != nil {
+		s.handleMetadataImportError(w, err)
+		return
+	}
+
+	// Every column must fall inside the caller's metadata namespaces
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionMetadata, MetadataKeys: sheet.Keys}) {
+		return
+	}
+
+	if !s.checkDiskLimit(w, r, identity, "metadata_import") {
+		return
+	}
+
+	if err := imports.Resolve(sheet); err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	processor := r.URL.Query().Get("processor")
+	processorVersion := r.URL.Query().Get("processor_version")
+	if processor == "" {
+		processor = constants.ProcessorImport
+		processorVersion = constants.ProcessorImportVersion
+	}
+	if processorVersion == "" {
+		processorVersion = "1.0"
+	}
+
+	batchSize := min(constants.MetadataImportBatchSize, s.app.Config.Batch.MaxOperations)
+	batches := imports.Batches(sheet, processor, processorVersion, batchSize)
+
+	// Reject the whole import before writing if any topic would be denied
+	grouped := make([]database.GroupedOperations, len(batches))
+	for i, batch := range batches {
+		grouped[i] = database.GroupedOperations{Topic: batch.Topic, Operations: batch.Operations}
+	}
+	if result := s.preflightMetadata(identity, grouped, nil); !result.Allowed {
+		writePreflightDenied(w, result)
+		return
+	}
+	if s.rejectFrozenTopics(w, grouped) {
+		return
+	}
+
+	s.logger.Info("Metadata import: %d rows in %d batches", len(sheet.Rows), len(batches))
+
+	affected := make(map[string]bool)
+	for i := range batches {
+		batch := &batches[i]
+		batch.Record(s.applyMetadataOperations(identity, batch.Topic, batch.Operations))
+		affected[batch.Topic] = true
+	}
+
+	summary := imports.Finish(sheet)
+
+	importID, err := imports.Save(identity.User.ID, sheet)
+	if err != nil {
+		// The metadata is already written; only the downloadable copy is lost
+		s.logger.Error("Metadata import: failed to save result: %v", err)
+	}
+
+	s.logger.Info("Metadata import complete: %d applied, %d partial, %d failed, %d not found, %d ambiguous",
+		summary.Applied, summary.Partial, summary.Failed, summary.NotFound, summary.Ambiguous)
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionMetadataImport, getClientIP(r), getAuditUsername(identity), audit.MetadataImportDetails{
+			ImportID:       importID,
+			RowCount:       summary.Rows,
+			OperationCount: summary.Operations,
+			Succeeded:      summary.Applied,
+			Failed:         summary.Partial + summary.Failed,
+			NotFound:       summary.NotFound,
+			Ambiguous:      summary.Ambiguous,
+			Processor:      processor,
+		})
+	}
+
+	// Invalidate stats cache for affected topics
+	if len(affected) > 0 {
+		topics := make([]string, 0, len(affected))
+		for topic := range affected {
+			topics = append(topics, topic)
+		}
+		s.app.Services.StatsCache.InvalidateTopics(topics)
+	}
+
+	response := MetadataImportResponse{
+		Success:   summary.Applied == summary.Rows-summary.Skipped,
+		ImportID:  importID,
+		KeyColumn: sheet.KeyColumn,
+		Keys:      sheet.Keys,
+		Summary:   summary,
+		Rows:      sheet.Rows,
+	}
+	if importID != "" {
+		response.ResultURL = "/api/metadata/import/" + importID
+	}
+
+	WriteSuccess(w, response)
+}
+
+// handleMetadataImportResult handles GET /api/metadata/import/{id}, the
+// per-row result of an import as CSV. Only the user who ran it can fetch it.
+func (s *Server) handleMetadataImportResult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	importID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/metadata/import/"), "/")
+	if importID == "" {
+		WriteError(w, http.StatusBadRequest, "Import ID is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if s.app.Config.WorkingDirectory == "" {
+		WriteError(w, http.StatusBadRequest, "Working directory not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	f, modTime, err := s.app.Services.Import.Open(identity.User.ID, importID)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set(constants.HeaderContentType, constants.ContentTypeCSV)
+	w.Header().Set(constants.HeaderContentDisposition,
+		fmt.Sprintf(constants.ContentDispositionFormat, fmt.Sprintf(constants.MetadataImportFilenameFormat, importID)))
+	http.ServeContent(w, r, "", modTime, f)
+}
+
+// openMetadataImportCSV returns the CSV sheet of an import request: the
+// "file" part of a multipart form, or else the raw body.
+func openMetadataImportCSV(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(constants.HeaderContentType))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, services.WrapServiceError(constants.ErrCodeMetadataImportInvalid, "invalid multipart body", err)
+	}
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return nil, services.WrapServiceError(constants.ErrCodeMetadataImportInvalid,
+				"multipart body has no "+constants.FormFieldFile+" part", err)
+		}
+		if part.FormName() == constants.FormFieldFile {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// handleMetadataImportError answers 413 when the sheet exceeded the size
+// limit and otherwise maps the service error.
+func (s *Server) handleMetadataImportError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		WriteError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("CSV exceeds maximum size of %d bytes", constants.MetadataImportMaxBytes), constants.ErrCodeMetadataImportInvalid)
+		return
+	}
+	s.handleServiceError(w, err)
+}
@@ -79,6 +79,19 @@ func GzipCompress(next http.Handler) http.Handler {
 			return
 		}
 
+		// WebSocket handshakes hijack the connection; nothing to compress
+		if isWebSocketUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Asset downloads stream from disk and are compressed by the handler
+		// when the extension's storage policy asks for it
+		if isAssetDownload(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		// Only if client accepts gzip
 		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
 			next.ServeHTTP(w, r)
@@ -95,6 +108,11 @@ func GzipCompress(next http.Handler) http.Handler {
 	})
 }
 
+// isAssetDownload reports whether r is GET /api/assets/:hash/download.
+func isAssetDownload(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/assets/") && strings.HasSuffix(r.URL.Path, "/download")
+}
+
 // gzipResponseWriter buffers response data and conditionally compresses it.
 // If the response exceeds the size threshold and has a compressible content type,
 // the data is gzip-compressed before being sent to the client.
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"compress/gzip"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 
@@ -439,3 +441,76 @@ func TestIsCompressibleContentType(t *testing.T) {
 		}
 	}
 }
+
+// =============================================================================
+// Idempotency — Request Digest
+// =============================================================================
+
+// multipartBody builds a single-file multipart body with the given boundary.
+func multipartBody(t *testing.T, boundary string, content string) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if err := mw.SetBoundary(boundary); err != nil {
+		t.Fatalf("SetBoundary: %v", err)
+	}
+	part, _ := mw.CreateFormFile("file", "scan.png")
+	part.Write([]byte(content))
+	mw.Close()
+	return &buf, mw.FormDataContentType()
+}
+
+func TestSpoolRequestBody_MultipartIgnoresBoundary(t *testing.T) {
+	digestOf := func(boundary, content string) (string, string) {
+		body, contentType := multipartBody(t, boundary, content)
+		raw := body.String()
+		req := httptest.NewRequest(http.MethodPost, "/api/topics/t/assets", body)
+		req.Header.Set(constants.HeaderContentType, contentType)
+
+		digest, spool, err := spoolRequestBody(req)
+		if err != nil {
+			t.Fatalf("spoolRequestBody: %v", err)
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+
+		// The spool replays the exact original body
+		replayed, _ := io.ReadAll(spool)
+		if string(replayed) != raw {
+			t.Errorf("spooled body differs from the original")
+		}
+		return digest, raw
+	}
+
+	first, _ := digestOf("boundary-one", "content")
+	retry, _ := digestOf("boundary-two", "content")
+	changed, _ := digestOf("boundary-one", "other content")
+
+	if first != retry {
+		t.Error("expected the same digest for a retry with a new boundary")
+	}
+	if first == changed {
+		t.Error("expected a different digest for different content")
+	}
+}
+
+func TestSpoolRequestBody_JSON(t *testing.T) {
+	digest := func(body string) string {
+		req := httptest.NewRequest(http.MethodPost, "/api/metadata/batch", strings.NewReader(body))
+		req.Header.Set(constants.HeaderContentType, constants.ContentTypeJSON)
+		d, spool, err := spoolRequestBody(req)
+		if err != nil {
+			t.Fatalf("spoolRequestBody: %v", err)
+		}
+		spool.Close()
+		os.Remove(spool.Name())
+		return d
+	}
+
+	if digest(`{"a":1}`) != digest(`{"a":1}`) {
+		t.Error("expected identical bodies to share a digest")
+	}
+	if digest(`{"a":1}`) == digest(`{"a":2}`) {
+		t.Error("expected different bodies to differ")
+	}
+}
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Topic Subscription Handler
+// =============================================================================
+
+// /api/topics/:name/subscribe - POST (subscribe) or DELETE (unsubscribe)
+func (s *Server) handleTopicSubscription(w http.ResponseWriter, r *http.Request, topicName string) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	notifications, ok := s.notificationService(w)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := notifications.Unsubscribe(identity.User.ID, topicName); err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"success": true,
+			"topic":   topicName,
+		})
+		return
+	}
+
+	// Subscribers must be able to read the topic
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionQuery,
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	// An empty body subscribes to every event
+	var req services.SubscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	sub, err := notifications.Subscribe(identity.User.ID, topicName, &req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"success":      true,
+		"subscription": sub,
+	})
+}
+
+// =============================================================================
+// Notification Feed Handlers
+// =============================================================================
+
+// GET /api/notifications - Current user's notification feed
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	notifications, ok := s.notificationService(w)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	var limit, offset int
+	if v := q.Get("limit"); v != "" {
+		limit, _ = strconv.Atoi(v)
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, _ = strconv.Atoi(v)
+	}
+
+	feed, err := notifications.Feed(identity.User.ID, q.Get("unread") == "true", limit, offset)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, feed)
+}
+
+// POST /api/notifications/read - Mark notifications as read
+// Body: {"ids": [1, 2]}; omit ids to mark everything read.
+func (s *Server) handleNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	notifications, ok := s.notificationService(w)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	marked, err := notifications.MarkRead(identity.User.ID, req.IDs)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"success": true,
+		"marked":  marked,
+	})
+}
+
+// GET /api/notifications/stream - New notifications of the current user and
+// unread count changes (SSE), starting with the unread counts
+func (s *Server) handleNotificationStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	notifications, ok := s.notificationService(w)
+	if !ok {
+		return
+	}
+
+	unread, byEvent, err := notifications.UnreadCounts(identity.User.ID)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	release := s.acquireSSE(w, r, constants.SSEEndpointNotifications, identity)
+	if release == nil {
+		return
+	}
+	defer release()
+
+	sse, err := NewSSEWriter(w)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "Streaming not supported",
+			constants.ErrCodeStreamingError)
+		return
+	}
+
+	ch := notifications.OpenStream(identity.User.ID)
+	defer notifications.CloseStream(ch)
+
+	sse.Send(constants.NotificationStreamEventUnread, map[string]interface{}{
+		"unread":          unread,
+		"unread_by_event": byEvent,
+	})
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			sse.Send(event.Type, event)
+		}
+	}
+}
+
+// GET /api/notifications/subscriptions - Current user's topic subscriptions
+func (s *Server) handleNotificationSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	notifications, ok := s.notificationService(w)
+	if !ok {
+		return
+	}
+
+	subs, err := notifications.ListSubscriptions(identity.User.ID)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"subscriptions": subs,
+	})
+}
+
+// /api/notifications/preferences - GET or PUT the current user's delivery preferences
+func (s *Server) handleNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	notifications, ok := s.notificationService(w)
+	if !ok {
+		return
+	}
+
+	var prefs *database.NotificationPreferences
+	var err error
+	if r.Method == http.MethodGet {
+		prefs, err = notifications.GetPreferences(identity.User.ID)
+	} else {
+		var req services.NotificationPreferencesRequest
+		if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+			return
+		}
+		prefs, err = notifications.UpdatePreferences(identity.User.ID, &req)
+	}
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"preferences":   prefs,
+		"email_enabled": s.app.Config.Notifications.EmailEnabled(),
+	})
+}
+
+// notificationService returns the notification service, writing a 503 when
+// it is unavailable (no working directory configured yet).
+func (s *Server) notificationService(w http.ResponseWriter) (*services.NotificationService, bool) {
+	if s.app.Services.Notification == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Notifications not available", constants.ErrCodeNotConfigured)
+		return nil, false
+	}
+	return s.app.Services.Notification, true
+}
+
+// =============================================================================
+// Event Publishing
+// =============================================================================
+
+// notifyAssetAdded publishes an asset_added event for a newly stored asset.
+func (s *Server) notifyAssetAdded(identity *auth.Identity, topicName, hash, filename string) {
+	if s.app.Services.Notification == nil {
+		return
+	}
+	s.app.Services.Notification.AssetAdded(topicName, hash, filename, identity.User.ID, identity.User.Username)
+}
+
+// notifyMetadataChanged publishes a metadata_changed event for the
+// successful operations of one topic.
+func (s *Server) notifyMetadataChanged(identity *auth.Identity, topicName string, ops []database.BatchOperation, results []database.BatchOperationResult) {
+	if s.app.Services.Notification == nil {
+		return
+	}
+	changes := make([]services.MetadataChange, 0, len(ops))
+	for i, op := range ops {
+		if i < len(results) && results[i].Success {
+			changes = append(changes, services.MetadataChange{AssetID: op.Hash, Key: op.Key})
+		}
+	}
+	s.app.Services.Notification.MetadataChanged(topicName, changes, identity.User.ID, identity.User.Username)
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Bulk Operation Pre-flight
+// =============================================================================
+
+// PreflightRequest represents the request body for POST /api/auth/me/preflight.
+// Hashes are used for metadata; BulkDownload for bulk_download.
+type PreflightRequest struct {
+	Action       string               `json:"action"`
+	Hashes       []string             `json:"hashes,omitempty"`
+	BulkDownload *BulkDownloadRequest `json:"bulk_download,omitempty"`
+}
+
+// PreflightDeniedResponse is returned when a bulk operation is rejected
+// before execution.
+type PreflightDeniedResponse struct {
+	APIError
+	Preflight *auth.PreflightResult `json:"preflight"`
+}
+
+// POST /api/auth/me/preflight — Report which targets of a bulk operation the
+// caller's grants would deny, without executing it.
+func (s *Server) handleAuthPreflight(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.isAuthAvailable() {
+		WriteError(w, http.StatusServiceUnavailable, "Auth system not available", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if s.app.Config.WorkingDirectory == "" {
+		WriteError(w, http.StatusBadRequest, "Working directory not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	var req PreflightRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if req.Action != constants.AuthActionMetadata && req.Action != constants.AuthActionBulkDownload {
+		WriteError(w, http.StatusBadRequest,
+			fmt.Sprintf("action must be %q or %q", constants.AuthActionMetadata, constants.AuthActionBulkDownload),
+			constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	// Without any grant for the action, report that alone rather than
+	// revealing where the requested assets live
+	evaluator := s.app.Services.Auth.GetEvaluator()
+	if base := evaluator.Evaluate(identity, &auth.ActionContext{Action: req.Action}); !base.Allowed &&
+		base.DeniedCode != constants.ErrCodeAuthQuotaExceeded {
+		WriteSuccess(w, &auth.PreflightResult{
+			Action:        req.Action,
+			RequestDenied: &auth.PreflightItem{Reason: base.Reason, Code: base.DeniedCode},
+			DeniedTopics:  []auth.PreflightItem{},
+			Denied:        []auth.PreflightItem{},
+			NotFound:      []string{},
+		})
+		return
+	}
+
+	var result *auth.PreflightResult
+	switch req.Action {
+	case constants.AuthActionMetadata:
+		if len(req.Hashes) == 0 {
+			WriteError(w, http.StatusBadRequest, "hashes is required for action metadata", constants.ErrCodeInvalidRequest)
+			return
+		}
+		if len(req.Hashes) > s.app.Config.Batch.MaxOperations {
+			WriteError(w, http.StatusBadRequest, "Too many hashes", constants.ErrCodeBatchTooManyOperations)
+			return
+		}
+		operations := make([]database.BatchOperation, len(req.Hashes))
+		for i, hash := range req.Hashes {
+			operations[i] = database.BatchOperation{Hash: hash}
+		}
+		grouped, notFound := database.GroupOperationsByTopic(s.app.OrchestratorDB, operations)
+		result = s.preflightMetadata(identity, grouped, notFound)
+
+	case constants.AuthActionBulkDownload:
+		if req.BulkDownload == nil {
+			WriteError(w, http.StatusBadRequest, "bulk_download is required for action bulk_download", constants.ErrCodeInvalidRequest)
+			return
+		}
+		assets, err := s.resolveBulkDownload(req.BulkDownload)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		result = s.preflightBulkDownload(identity, assets)
+	}
+
+	WriteSuccess(w, result)
+}
+
+// preflightMetadata evaluates metadata grants for grouped batch operations.
+func (s *Server) preflightMetadata(identity *auth.Identity, grouped []database.GroupedOperations, notFound []database.BatchOperationResult) *auth.PreflightResult {
+	var targets []auth.PreflightTarget
+	for _, group := range grouped {
+		for _, op := range group.Operations {
+			targets = append(targets, auth.PreflightTarget{Hash: op.Hash, Topic: group.Topic})
+		}
+	}
+
+	missing := make([]string, len(notFound))
+	for i, nf := range notFound {
+		missing[i] = nf.Hash
+	}
+
+	return s.app.Services.Auth.GetEvaluator().Preflight(identity, constants.AuthActionMetadata, targets, missing)
+}
+
+// preflightBulkDownload evaluates bulk_download grants for resolved assets.
+func (s *Server) preflightBulkDownload(identity *auth.Identity, assets []*services.ResolvedAsset) *auth.PreflightResult {
+	targets := make([]auth.PreflightTarget, len(assets))
+	for i, asset := range assets {
+		targets[i] = auth.PreflightTarget{Hash: asset.Hash, Topic: asset.Topic, Size: asset.Asset.AssetSize}
+	}
+
+	return s.app.Services.Auth.GetEvaluator().Preflight(identity, constants.AuthActionBulkDownload, targets, nil)
+}
+
+// writePreflightDenied rejects a bulk operation with the full pre-flight
+// report so clients can trim the request. Quota denials return 429.
+func writePreflightDenied(w http.ResponseWriter, result *auth.PreflightResult) {
+	status := http.StatusForbidden
+	if result.QuotaExceeded() {
+		status = http.StatusTooManyRequests
+	}
+
+	WriteJSON(w, status, PreflightDeniedResponse{
+		APIError: APIError{
+			Error:   true,
+			Message: preflightDeniedMessage(result),
+			Code:    constants.ErrCodeAuthPreflightDenied,
+		},
+		Preflight: result,
+	})
+}
+
+// preflightDeniedError converts a denied report into a service error for
+// progress streams that cannot carry the full report.
+func preflightDeniedError(result *auth.PreflightResult) error {
+	return services.NewServiceError(constants.ErrCodeAuthPreflightDenied, preflightDeniedMessage(result))
+}
+
+func preflightDeniedMessage(result *auth.PreflightResult) string {
+	if result.RequestDenied != nil {
+		return "request denied: " + result.RequestDenied.Reason
+	}
+	return fmt.Sprintf("%d of %d assets denied", result.DeniedCount, result.Total)
+}
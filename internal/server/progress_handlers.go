@@ -0,0 +1,361 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// Progress operation kinds
+const (
+	progressKindUpload   = "upload"
+	progressKindDownload = "download"
+)
+
+// =============================================================================
+// Progress WebSocket Message Types
+// =============================================================================
+
+// progressCommand is a client → server message on the progress WebSocket.
+//
+//	{"type": "ping"}
+//	{"type": "cancel", "id": "<upload_id or download_id>"}
+//	{"type": "bulk_download", "request": {...BulkDownloadRequest}}
+type progressCommand struct {
+	Type    string               `json:"type"`
+	ID      string               `json:"id,omitempty"`
+	Request *BulkDownloadRequest `json:"request,omitempty"`
+}
+
+type ProgressConnectedData struct {
+	Username string `json:"username"`
+}
+
+type UploadProgressData struct {
+	UploadID        string `json:"upload_id"`
+	BytesReceived   int64  `json:"bytes_received"`
+	TotalBytes      int64  `json:"total_bytes"`
+	PercentComplete int    `json:"percent_complete"`
+}
+
+type UploadCompleteData struct {
+	UploadID string `json:"upload_id"`
+	Hash     string `json:"hash"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason"`
+	Skipped  bool   `json:"skipped"` // Deprecated: use Status
+	Size     int64  `json:"size,omitempty"`
+}
+
+type UploadErrorData struct {
+	UploadID string `json:"upload_id"`
+	Status   string `json:"status,omitempty"`
+	Message  string `json:"message"`
+	Code     string `json:"code"`
+}
+
+type CancelledData struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+}
+
+type ProgressErrorData struct {
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+// =============================================================================
+// Progress WebSocket Handler
+// =============================================================================
+
+// GET /api/ws/progress - Bidirectional progress channel (WebSocket).
+// Authenticates like any other endpoint; browsers pass ?token= since they
+// cannot set headers on WebSocket handshakes.
+func (s *Server) handleProgressSocket(w http.ResponseWriter, r *http.Request) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	client := s.progressHub.Subscribe(identity.User.ID)
+	if client == nil {
+		WriteError(w, http.StatusTooManyRequests, "Too many progress connections", constants.ErrCodeTooManyConnections)
+		return
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		s.progressHub.Unsubscribe(client)
+		return
+	}
+
+	// Downloads started from this socket are cancelled when it closes
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go s.progressWriteLoop(conn, client)
+
+	s.progressHub.SendTo(client, "connected", ProgressConnectedData{
+		Username: getAuditUsername(identity),
+	})
+
+	clientIP := getClientIP(r)
+	for {
+		conn.SetReadDeadline(time.Now().Add(constants.WSReadTimeoutSecs * time.Second))
+		op, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		if op != wsOpText {
+			s.progressHub.SendTo(client, "error", ProgressErrorData{
+				Message: "Only text messages are supported",
+				Code:    constants.ErrCodeInvalidWSCommand,
+			})
+			continue
+		}
+		s.handleProgressCommand(ctx, client, identity, clientIP, data)
+	}
+
+	s.progressHub.Unsubscribe(client)
+	conn.CloseWithCode(wsCloseGoingAway, "")
+}
+
+// progressWriteLoop drains the client's event queue to the socket and sends
+// keepalive pings. Exits when the client is unsubscribed or a write fails.
+func (s *Server) progressWriteLoop(conn *wsConn, client *progressClient) {
+	ticker := time.NewTicker(constants.WSPingIntervalSecs * time.Second)
+	defer ticker.Stop()
+	defer conn.CloseWithCode(wsCloseGoingAway, "")
+
+	for {
+		select {
+		case msg, ok := <-client.send:
+			if !ok {
+				return
+			}
+			if err := conn.WriteText(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.Ping(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleProgressCommand dispatches a single client command.
+// Replies go to the sending connection; progress and cancellation events go
+// to all of the user's connections.
+func (s *Server) handleProgressCommand(ctx context.Context, client *progressClient, identity *auth.Identity, clientIP string, data []byte) {
+	userID := identity.User.ID
+
+	var cmd progressCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		s.progressHub.SendTo(client, "error", ProgressErrorData{
+			Message: "Invalid JSON",
+			Code:    constants.ErrCodeInvalidWSCommand,
+		})
+		return
+	}
+
+	switch cmd.Type {
+	case "ping":
+		s.progressHub.SendTo(client, "pong", nil)
+
+	case "cancel":
+		kind, ok := s.progressHub.Cancel(userID, cmd.ID)
+		if !ok {
+			s.progressHub.SendTo(client, "error", ProgressErrorData{
+				ID:      cmd.ID,
+				Message: "No active operation with this id",
+				Code:    constants.ErrCodeOperationNotFound,
+			})
+			return
+		}
+		s.logger.Info("Progress: %s %s cancelled by %s", kind, cmd.ID, getAuditUsername(identity))
+		// Downloads announce their own cancelled event once the partial ZIP is removed
+		if kind != progressKindDownload {
+			s.progressHub.Publish(userID, "cancelled", CancelledData{ID: cmd.ID, Kind: kind})
+		}
+
+	case "bulk_download":
+		s.startBulkDownloadFromSocket(ctx, client, identity, clientIP, cmd.Request)
+
+	default:
+		s.progressHub.SendTo(client, "error", ProgressErrorData{
+			Message: "Unknown command type: " + cmd.Type,
+			Code:    constants.ErrCodeInvalidWSCommand,
+		})
+	}
+}
+
+// startBulkDownloadFromSocket runs a bulk download whose progress is reported
+// over the user's progress sockets. The ZIP is fetched afterwards from
+// /api/download/bulk/:id, exactly as with the SSE flow.
+func (s *Server) startBulkDownloadFromSocket(ctx context.Context, client *progressClient, identity *auth.Identity, clientIP string, req *BulkDownloadRequest) {
+	userID := identity.User.ID
+	sendError := func(message, code string) {
+		s.progressHub.SendTo(client, "error", DownloadErrorData{Message: message, Code: code})
+	}
+
+	if req == nil {
+		sendError("request is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if s.app.Services.Auth == nil {
+		sendError("Auth system not available", constants.ErrCodeAuthRequired)
+		return
+	}
+	result := s.app.Services.Auth.GetEvaluator().Evaluate(identity, &auth.ActionContext{Action: constants.AuthActionBulkDownload})
+	if !result.Allowed {
+		sendError(result.Reason, result.DeniedCode)
+		return
+	}
+
+	if s.app.Config.WorkingDirectory == "" {
+		sendError("Working directory not configured", constants.ErrCodeNotConfigured)
+		return
+	}
+	if req.Mode == "" {
+		sendError("mode is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	session, assets, err := s.prepareBulkDownload(identity, *req)
+	if err != nil {
+		sendError(bulkErrorParts(err))
+		return
+	}
+
+	sender := &hubProgressSender{hub: s.progressHub, userID: userID}
+	opCtx, cancel := context.WithCancelCause(ctx)
+	op := s.progressHub.Track(userID, session.ID, progressKindDownload, cancelRequested(cancel))
+
+	username := getAuditUsername(identity)
+	go func() {
+		defer cancel(nil)
+		defer s.progressHub.Untrack(session.ID, op)
+		s.generateZIPWithProgress(opCtx, sender, session, assets, *req, clientIP, username)
+	}()
+}
+
+// hubProgressSender publishes events to a user's progress sockets.
+type hubProgressSender struct {
+	hub    *ProgressHub
+	userID int64
+}
+
+// Send publishes the event; delivery is best effort.
+func (h *hubProgressSender) Send(eventType string, data interface{}) error {
+	h.hub.Publish(h.userID, eventType, data)
+	return nil
+}
+
+// =============================================================================
+// Upload Progress Tracking
+// =============================================================================
+
+// uploadProgressReader wraps a request body, reporting bytes received to the
+// progress hub and aborting the read when the upload is cancelled.
+type uploadProgressReader struct {
+	ctx          context.Context
+	r            io.Reader
+	hub          *ProgressHub
+	userID       int64
+	uploadID     string
+	total        int64
+	received     int64
+	lastReported int64
+}
+
+func (p *uploadProgressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := p.r.Read(b)
+	p.received += int64(n)
+
+	if p.received-p.lastReported >= constants.WSUploadProgressBytes || (err == io.EOF && p.received != p.lastReported) {
+		p.lastReported = p.received
+		percent := 0
+		if p.total > 0 {
+			percent = int(p.received * 100 / p.total)
+		}
+		p.hub.Publish(p.userID, "upload_progress", UploadProgressData{
+			UploadID:        p.uploadID,
+			BytesReceived:   p.received,
+			TotalBytes:      p.total,
+			PercentComplete: percent,
+		})
+	}
+	return n, err
+}
+
+// trackUpload registers a client-tagged upload with the progress hub.
+// The upload ID comes from the upload_id query parameter or X-Upload-ID header.
+// Returns the context the upload must run under, the upload ID ("" when the
+// request is untagged), and a cleanup func. Returns ok=false after writing an
+// error response.
+func (s *Server) trackUpload(w http.ResponseWriter, r *http.Request, identity *auth.Identity) (context.Context, string, func(), bool) {
+	uploadID := r.URL.Query().Get(constants.QueryParamUploadID)
+	if uploadID == "" {
+		uploadID = r.Header.Get(constants.HeaderXUploadID)
+	}
+	if uploadID == "" {
+		return r.Context(), "", func() {}, true
+	}
+
+	if err := ValidateProgressID(uploadID); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid upload_id: "+err.Error(), constants.ErrCodeInvalidRequest)
+		return nil, "", nil, false
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	op := s.progressHub.Track(identity.User.ID, uploadID, progressKindUpload, cancel)
+	if op == nil {
+		cancel()
+		WriteError(w, http.StatusConflict, "upload_id is already in use", constants.ErrCodeProgressIDInUse)
+		return nil, "", nil, false
+	}
+
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: &uploadProgressReader{
+			ctx:      ctx,
+			r:        r.Body,
+			hub:      s.progressHub,
+			userID:   identity.User.ID,
+			uploadID: uploadID,
+			total:    r.ContentLength,
+		},
+		Closer: r.Body,
+	}
+
+	cleanup := func() {
+		s.progressHub.Untrack(uploadID, op)
+		cancel()
+	}
+	return ctx, uploadID, cleanup, true
+}
+
+// writeUploadCancelled responds to an upload aborted by a cancel command.
+func (s *Server) writeUploadCancelled(w http.ResponseWriter, identity *auth.Identity, uploadID string) {
+	s.progressHub.Publish(identity.User.ID, "upload_error", UploadErrorData{
+		UploadID: uploadID,
+		Status:   constants.UploadStatusRejected,
+		Message:  "Upload cancelled",
+		Code:     constants.ErrCodeOperationCancelled,
+	})
+	writeUploadRejected(w, http.StatusBadRequest, "Upload cancelled", constants.ErrCodeOperationCancelled)
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Progress Hub
+// =============================================================================
+//
+// ProgressHub fans out upload/download progress events to a user's WebSocket
+// connections and tracks cancellable operations by ID. Operations belong to
+// the user that started them; only that user can cancel them.
+
+var progressIDRegex = regexp.MustCompile(constants.WSProgressIDRegex)
+
+// ProgressEvent is the envelope sent over the progress WebSocket.
+// Matches the shape of bulk download SSE events.
+type ProgressEvent struct {
+	Type      string      `json:"type"`
+	Timestamp int64       `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// progressClient is a single WebSocket subscriber.
+type progressClient struct {
+	userID int64
+	send   chan []byte
+}
+
+// trackedOperation is a cancellable upload or download.
+type trackedOperation struct {
+	userID int64
+	kind   string // "upload" or "download"
+	cancel context.CancelFunc
+}
+
+// ProgressHub routes progress events and cancel commands between HTTP
+// handlers and WebSocket clients.
+type ProgressHub struct {
+	mu         sync.RWMutex
+	clients    map[int64]map[*progressClient]struct{}
+	operations map[string]*trackedOperation
+}
+
+// NewProgressHub creates an empty hub.
+func NewProgressHub() *ProgressHub {
+	return &ProgressHub{
+		clients:    make(map[int64]map[*progressClient]struct{}),
+		operations: make(map[string]*trackedOperation),
+	}
+}
+
+// ValidateProgressID checks a client-supplied operation ID.
+func ValidateProgressID(id string) error {
+	if id == "" || len(id) > constants.WSProgressIDMaxLen || !progressIDRegex.MatchString(id) {
+		return fmt.Errorf("id must be 1-%d characters of letters, digits, '-' or '_'", constants.WSProgressIDMaxLen)
+	}
+	return nil
+}
+
+// Subscribe registers a new client for the user. Returns nil if the user
+// already has the maximum number of connections.
+func (h *ProgressHub) Subscribe(userID int64) *progressClient {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	set := h.clients[userID]
+	if len(set) >= constants.WSMaxConnectionsPerUser {
+		return nil
+	}
+	if set == nil {
+		set = make(map[*progressClient]struct{})
+		h.clients[userID] = set
+	}
+
+	c := &progressClient{
+		userID: userID,
+		send:   make(chan []byte, constants.WSSendBufferSize),
+	}
+	set[c] = struct{}{}
+	return c
+}
+
+// Unsubscribe removes a client and closes its send channel.
+func (h *ProgressHub) Unsubscribe(c *progressClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	set := h.clients[c.userID]
+	if _, ok := set[c]; !ok {
+		return
+	}
+	delete(set, c)
+	if len(set) == 0 {
+		delete(h.clients, c.userID)
+	}
+	close(c.send)
+}
+
+// Publish sends an event to every connection of the user (non-blocking).
+// Events are dropped for clients whose buffer is full: progress is
+// superseded by the next event, and terminal events are also reported by
+// the originating HTTP response.
+func (h *ProgressHub) Publish(userID int64, eventType string, data interface{}) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	set := h.clients[userID]
+	if len(set) == 0 {
+		return
+	}
+
+	payload, err := marshalProgressEvent(eventType, data)
+	if err != nil {
+		return
+	}
+
+	for c := range set {
+		select {
+		case c.send <- payload:
+		default:
+		}
+	}
+}
+
+// SendTo sends an event to a single client (non-blocking). Used for replies
+// to commands, which only concern the connection that sent them.
+func (h *ProgressHub) SendTo(c *progressClient, eventType string, data interface{}) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if _, ok := h.clients[c.userID][c]; !ok {
+		return
+	}
+
+	payload, err := marshalProgressEvent(eventType, data)
+	if err != nil {
+		return
+	}
+
+	select {
+	case c.send <- payload:
+	default:
+	}
+}
+
+// marshalProgressEvent encodes an event envelope.
+func marshalProgressEvent(eventType string, data interface{}) ([]byte, error) {
+	return json.Marshal(ProgressEvent{
+		Type:      eventType,
+		Timestamp: time.Now().Unix(),
+		Data:      data,
+	})
+}
+
+// Track registers a cancellable operation and returns a handle for Untrack.
+// Returns nil if the ID is in use.
+func (h *ProgressHub) Track(userID int64, id, kind string, cancel context.CancelFunc) *trackedOperation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, exists := h.operations[id]; exists {
+		return nil
+	}
+	op := &trackedOperation{userID: userID, kind: kind, cancel: cancel}
+	h.operations[id] = op
+	return op
+}
+
+// Untrack removes an operation once it has finished. The handle guards
+// against removing a newer operation that reused the same ID.
+func (h *ProgressHub) Untrack(id string, op *trackedOperation) {
+	h.mu.Lock()
+	if h.operations[id] == op {
+		delete(h.operations, id)
+	}
+	h.mu.Unlock()
+}
+
+// Cancel cancels an operation owned by the user. Returns the operation kind
+// and true if found.
+func (h *ProgressHub) Cancel(userID int64, id string) (string, bool) {
+	h.mu.Lock()
+	op, ok := h.operations[id]
+	if ok && op.userID == userID {
+		delete(h.operations, id)
+	}
+	h.mu.Unlock()
+
+	if !ok || op.userID != userID {
+		return "", false
+	}
+	op.cancel()
+	return op.kind, true
+}
+
+// CloseAll disconnects all clients (used during shutdown).
+func (h *ProgressHub) CloseAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for userID, set := range h.clients {
+		for c := range set {
+			close(c.send)
+		}
+		delete(h.clients, userID)
+	}
+}
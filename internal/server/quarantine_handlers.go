@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+type quarantineRequest struct {
+	Reason string `json:"reason"`
+}
+
+type releaseRequest struct {
+	Justification string `json:"justification"`
+}
+
+// GET /api/quarantine - Quarantined assets, newest first (requires verify)
+// Query params: topic, include_released=true for the release history.
+func (s *Server) handleQuarantine(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	topic := r.URL.Query().Get("topic")
+	entries, err := s.app.Services.Quarantine.List(topic, r.URL.Query().Get("include_released") == "true")
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"entries": entries,
+	})
+}
+
+// POST /api/assets/:hash/quarantine - Withhold an asset from downloads,
+// queries and bulk exports (requires verify on the asset's topic)
+func (s *Server) quarantineAsset(w http.ResponseWriter, r *http.Request, hash string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	info, err := s.app.Services.Asset.GetInfo(hash)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionVerify, TopicName: info.TopicName}) {
+		return
+	}
+
+	var req quarantineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	entry, err := s.app.Services.Quarantine.Quarantine(hash, constants.QuarantineSourceAdmin, req.Reason,
+		getAuditUsername(identity), getClientIP(r))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, entry)
+}
+
+// POST /api/assets/:hash/release - Release a quarantined asset with a
+// justification (requires manage_config)
+func (s *Server) releaseAsset(w http.ResponseWriter, r *http.Request, hash string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageConfig}) {
+		return
+	}
+
+	var req releaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	entry, err := s.app.Services.Quarantine.Release(hash, req.Justification, getAuditUsername(identity), getClientIP(r))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, entry)
+}
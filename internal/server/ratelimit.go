@@ -0,0 +1,209 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// tokenBucket tracks the remaining request allowance of one client.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// ipRateLimiter is a per-client token bucket limiter. Buckets refill
+// continuously at perMin/60 tokens per second up to burst, and buckets left
+// idle for constants.PublicRateLimitIdleTTL are dropped lazily.
+type ipRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// newIPRateLimiter creates an empty limiter.
+func newIPRateLimiter() *ipRateLimiter {
+	return &ipRateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// rateLimitDecision is the outcome of taking a token from a bucket.
+type rateLimitDecision struct {
+	allowed   bool
+	remaining int           // whole tokens left
+	wait      time.Duration // until a token is available, when denied
+	reset     time.Duration // until the bucket is full again
+}
+
+// allow takes one token from key's bucket. When the bucket is empty it
+// returns false and how long until a token becomes available.
+func (l *ipRateLimiter) allow(key string, perMin, burst int) (bool, time.Duration) {
+	d := l.take(key, perMin, burst)
+	return d.allowed, d.wait
+}
+
+// take takes one token from key's bucket and reports what is left.
+func (l *ipRateLimiter) take(key string, perMin, burst int) rateLimitDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= constants.PublicRateLimitSweepInterval {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) >= constants.PublicRateLimitIdleTTL {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	rate := float64(perMin) / 60 // tokens per second
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+		b.last = now
+	}
+
+	d := rateLimitDecision{}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.allowed = true
+	} else {
+		d.wait = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	d.remaining = int(b.tokens)
+	d.reset = time.Duration((float64(burst) - b.tokens) / rate * float64(time.Second))
+	return d
+}
+
+// publicRateLimit throttles unauthenticated API requests per client IP while
+// public read-only mode is enabled. Authenticated requests are not limited.
+// Must run after auth.Middleware.Authenticate so the identity is resolved.
+func (s *Server) publicRateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := s.app.Config
+		if cfg == nil || !cfg.Public.Enabled || !strings.HasPrefix(r.URL.Path, "/api/") || auth.GetIdentity(r) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ok, wait := s.rateLimiter.allow(rateLimitKey(r, cfg.Public.TrustForwardedFor), cfg.Public.RateLimitPerMin, cfg.Public.RateLimitBurst)
+		if !ok {
+			w.Header().Set(constants.HeaderRetryAfter, strconv.Itoa(ceilSeconds(wait)))
+			WriteError(w, http.StatusTooManyRequests, "Rate limit exceeded", constants.ErrCodeAuthRateLimited)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitActions maps each rate limit class to the grant whose
+// constraints can override its rate.
+var rateLimitActions = map[string]string{
+	constants.RateLimitClassUploads:   constants.AuthActionUpload,
+	constants.RateLimitClassQueries:   constants.AuthActionQuery,
+	constants.RateLimitClassDownloads: constants.AuthActionDownload,
+}
+
+// requestRateLimit throttles uploads, queries and downloads while
+// rate_limit.enabled is set, with a bucket per class and user, or per class
+// and client IP for unauthenticated requests. Limited responses carry the
+// X-RateLimit headers. Must run after auth.Middleware.Authenticate so the
+// identity is resolved.
+func (s *Server) requestRateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := s.app.Config
+		class := rateLimitClass(r)
+		if cfg == nil || !cfg.RateLimit.Enabled || class == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rule := cfg.RateLimit.Rule(class)
+		key := class + "|ip:" + rateLimitKey(r, cfg.RateLimit.TrustForwardedFor)
+		if identity := auth.GetIdentity(r); identity != nil && identity.User != nil {
+			key = fmt.Sprintf("%s|user:%d", class, identity.User.ID)
+			if perMin, burst, ok := auth.RateLimitOverride(identity, rateLimitActions[class]); ok {
+				if perMin > 0 {
+					rule.PerMin = perMin
+				}
+				if burst > 0 {
+					rule.Burst = burst
+				}
+			}
+		}
+
+		d := s.requestLimiter.take(key, rule.PerMin, rule.Burst)
+		w.Header().Set(constants.HeaderRateLimitLimit, strconv.Itoa(rule.Burst))
+		w.Header().Set(constants.HeaderRateLimitRemaining, strconv.Itoa(d.remaining))
+		w.Header().Set(constants.HeaderRateLimitReset, strconv.Itoa(ceilSeconds(d.reset)))
+		if !d.allowed {
+			s.logger.Debug("Rate limit: %s throttled on %s %s", key, r.Method, r.URL.Path)
+			w.Header().Set(constants.HeaderRetryAfter, strconv.Itoa(ceilSeconds(d.wait)))
+			WriteError(w, http.StatusTooManyRequests, "Rate limit exceeded", constants.ErrCodeAuthRateLimited)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitClass returns the rate limit class of a request, or "" when it
+// is not rate limited.
+func rateLimitClass(r *http.Request) string {
+	path := r.URL.Path
+	switch r.Method {
+	case http.MethodPost:
+		switch {
+		case path == "/api/uploads",
+			strings.HasPrefix(path, "/api/topics/") && strings.HasSuffix(path, "/assets"):
+			return constants.RateLimitClassUploads
+		case strings.HasPrefix(path, "/api/query/"), strings.HasPrefix(path, "/api/federation/query/"):
+			return constants.RateLimitClassQueries
+		case path == "/api/download/bulk":
+			return constants.RateLimitClassDownloads
+		}
+	case http.MethodGet:
+		switch {
+		case path == "/api/search":
+			return constants.RateLimitClassQueries
+		case path == "/api/download/bulk/start",
+			strings.HasPrefix(path, "/api/assets/") && (strings.HasSuffix(path, "/download") || strings.HasSuffix(path, "/preview")):
+			return constants.RateLimitClassDownloads
+		}
+	}
+	return ""
+}
+
+// ceilSeconds rounds a wait up to whole seconds, at least one.
+func ceilSeconds(d time.Duration) int {
+	return max(int(math.Ceil(d.Seconds())), 1)
+}
+
+// rateLimitKey identifies the client of a request. Forwarding headers are
+// client-controlled, so they are only honoured behind a trusted proxy.
+func rateLimitKey(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		return getClientIP(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
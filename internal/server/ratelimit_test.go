@@ -0,0 +1,112 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+func TestIPRateLimiter_BurstThenRefill(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := newIPRateLimiter()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("10.0.0.1", 60, 3); !ok {
+			t.Fatalf("request %d within burst should be allowed", i+1)
+		}
+	}
+
+	ok, wait := l.allow("10.0.0.1", 60, 3)
+	if ok {
+		t.Fatal("request beyond burst should be denied")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("expected wait in (0, 1s], got %v", wait)
+	}
+
+	// Other clients have their own bucket
+	if ok, _ := l.allow("10.0.0.2", 60, 3); !ok {
+		t.Fatal("different IP should not share the bucket")
+	}
+
+	// 60/min refills one token per second
+	now = now.Add(time.Second)
+	if ok, _ := l.allow("10.0.0.1", 60, 3); !ok {
+		t.Fatal("request after refill should be allowed")
+	}
+}
+
+func TestIPRateLimiter_SweepsIdleBuckets(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := newIPRateLimiter()
+	l.now = func() time.Time { return now }
+
+	l.allow("10.0.0.1", 60, 3)
+	now = now.Add(constants.PublicRateLimitIdleTTL)
+	l.allow("10.0.0.2", 60, 3)
+
+	if _, ok := l.buckets["10.0.0.1"]; ok {
+		t.Error("idle bucket should have been swept")
+	}
+	if _, ok := l.buckets["10.0.0.2"]; !ok {
+		t.Error("active bucket should be kept")
+	}
+}
+
+func TestRateLimitKey_ForwardedForRequiresTrust(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/topics", nil)
+	r.RemoteAddr = "192.0.2.10:51234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	if got := rateLimitKey(r, false); got != "192.0.2.10" {
+		t.Errorf("untrusted key = %q, want remote address", got)
+	}
+	if got := rateLimitKey(r, true); got != "203.0.113.7" {
+		t.Errorf("trusted key = %q, want forwarded address", got)
+	}
+}
+
+func TestIPRateLimiter_TakeReportsRemainingAndReset(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := newIPRateLimiter()
+	l.now = func() time.Time { return now }
+
+	d := l.take("queries|user:1", 60, 3)
+	if !d.allowed || d.remaining != 2 || d.reset != time.Second {
+		t.Fatalf("unexpected first decision: %+v", d)
+	}
+	l.take("queries|user:1", 60, 3)
+	l.take("queries|user:1", 60, 3)
+	d = l.take("queries|user:1", 60, 3)
+	if d.allowed || d.remaining != 0 || d.wait != time.Second || d.reset != 3*time.Second {
+		t.Errorf("unexpected denied decision: %+v", d)
+	}
+}
+
+func TestRateLimitClass(t *testing.T) {
+	hash := strings.Repeat("a", constants.HashLength)
+	tests := []struct {
+		method, path, want string
+	}{
+		{"POST", "/api/topics/inbox/assets", constants.RateLimitClassUploads},
+		{"POST", "/api/uploads", constants.RateLimitClassUploads},
+		{"PATCH", "/api/uploads/abc", ""},
+		{"POST", "/api/query/recent", constants.RateLimitClassQueries},
+		{"POST", "/api/query/build", constants.RateLimitClassQueries},
+		{"GET", "/api/query/schema", ""},
+		{"GET", "/api/search", constants.RateLimitClassQueries},
+		{"GET", "/api/assets/" + hash + "/download", constants.RateLimitClassDownloads},
+		{"GET", "/api/assets/" + hash + "/metadata", ""},
+		{"POST", "/api/download/bulk", constants.RateLimitClassDownloads},
+		{"GET", "/api/topics", ""},
+	}
+	for _, tt := range tests {
+		if got := rateLimitClass(httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+			t.Errorf("%s %s: got %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
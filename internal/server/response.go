@@ -45,47 +45,88 @@ func (s *Server) handleServiceError(w http.ResponseWriter, err error) {
 		return
 	}
 
-	// Map error codes to HTTP status codes
+	WriteError(w, serviceErrorStatus(code), err.Error(), code)
+}
+
+// serviceErrorStatus maps a service error code to its HTTP status code.
+func serviceErrorStatus(code string) int {
 	status := http.StatusInternalServerError
 	switch code {
 	case constants.ErrCodeAssetNotFound, constants.ErrCodeTopicNotFound, constants.ErrCodePresetNotFound, constants.ErrCodePromptNotFound,
-		constants.ErrCodeLogFileNotFound:
+		constants.ErrCodeLogFileNotFound, constants.ErrCodeCollectionNotFound, constants.ErrCodeSubscriptionNotFound,
+		constants.ErrCodeExportNotFound, constants.ErrCodeMetadataImportNotFound, constants.ErrCodeStoragePolicyNotFound,
+		constants.ErrCodeDeletionRequestNotFound, constants.ErrCodeArchivePolicyNotFound,
+		constants.ErrCodeUploadSessionNotFound, constants.ErrCodeWatchFolderNotFound, constants.ErrCodeWebhookNotFound,
+		constants.ErrCodeRuleNotFound, constants.ErrCodeLinkExportNotFound, constants.ErrCodeRetentionPolicyNotFound:
 		status = http.StatusNotFound
 	case constants.ErrCodeAuthRequired, constants.ErrCodeAuthInvalidCredentials,
-		constants.ErrCodeAuthSessionExpired:
+		constants.ErrCodeAuthSessionExpired, constants.ErrCodeAuthRecoveryInvalid,
+		constants.ErrCodeAuthWorkspaceTokenInvalid, constants.ErrCodeAuthAPIKeyExpired,
+		constants.ErrCodeAuthSSOInvalid:
 		status = http.StatusUnauthorized
 	case constants.ErrCodeAuthForbidden, constants.ErrCodeAuthConstraintViolation,
 		constants.ErrCodeAuthEscalationDenied, constants.ErrCodeAuthBootstrapProtected,
 		constants.ErrCodeAuthUserDisabled, constants.ErrCodeLogLevelNotAllowed,
-		constants.ErrCodeAuthGrantActionDenied:
+		constants.ErrCodeAuthGrantActionDenied, constants.ErrCodeAuthPreflightDenied,
+		constants.ErrCodeDeletionSelfApproval, constants.ErrCodeAuthSSODenied:
 		status = http.StatusForbidden
-	case constants.ErrCodeAuthQuotaExceeded, constants.ErrCodeAuthAccountLocked:
+	case constants.ErrCodeAuthQuotaExceeded, constants.ErrCodeAuthAccountLocked, constants.ErrCodeUploadSessionLimit,
+		constants.ErrCodeAuthAPIKeyLimit, constants.ErrCodeAuthSSOBusy:
 		status = http.StatusTooManyRequests
-	case constants.ErrCodeAuthUserNotFound:
+	case constants.ErrCodeAuthUserNotFound, constants.ErrCodeAuthAPIKeyNotFound, constants.ErrCodeAuthSSONotConfigured:
 		status = http.StatusNotFound
 	case constants.ErrCodeAuthInvalidGrant, constants.ErrCodeAuthInvalidAPIKey,
 		constants.ErrCodeAuthPasswordTooWeak, constants.ErrCodeAuthUsernameInvalid,
-		constants.ErrCodeAuthInvalidConstraints:
+		constants.ErrCodeAuthInvalidConstraints, constants.ErrCodeAuthServiceAccount,
+		constants.ErrCodeAuthPipelineAccount:
 		status = http.StatusBadRequest
-	case constants.ErrCodeAssetDuplicate, constants.ErrCodeTopicAlreadyExists,
-		constants.ErrCodeAuthUserExists:
+	case constants.ErrCodeAssetDuplicate, constants.ErrCodeTopicAlreadyExists, constants.ErrCodeTopicCreationInProgress,
+		constants.ErrCodeAuthUserExists, constants.ErrCodeCollectionAlreadyExists,
+		constants.ErrCodeAnalysisInProgress, constants.ErrCodeIdempotencyKeyInProgress, constants.ErrCodeExportNotReady,
+		constants.ErrCodeAssetNotQuarantined, constants.ErrCodeAssetReferenced, constants.ErrCodeCompactionInProgress,
+		constants.ErrCodeDeletionRequestNotPending, constants.ErrCodeRetrievalRequired, constants.ErrCodeAssetNotArchived,
+		constants.ErrCodeArchiveHistoryIncomplete,
+		constants.ErrCodeAssetNotTrashed,
+		constants.ErrCodeUploadSessionBusy, constants.ErrCodeUploadOffsetMismatch, constants.ErrCodeUploadIncomplete,
+		constants.ErrCodeSetupStepBlocked, constants.ErrCodeProfileInProgress, constants.ErrCodeScrubInProgress,
+		constants.ErrCodeBackupInProgress, constants.ErrCodeAuthSSOUsernameTaken, constants.ErrCodeTopicFrozen:
 		status = http.StatusConflict
-	case constants.ErrCodeAssetTooLarge:
+	case constants.ErrCodeAssetQuarantined:
+		status = http.StatusLocked
+	case constants.ErrCodeAssetTrashed:
+		status = http.StatusGone
+	case constants.ErrCodeIdempotencyKeyConflict, constants.ErrCodeWatermarkFailed,
+		constants.ErrCodeUploadScanRejected, constants.ErrCodePreviewUnavailable, constants.ErrCodeUploadHashMismatch,
+		constants.ErrCodeUploadInvalid:
+		status = http.StatusUnprocessableEntity
+	case constants.ErrCodeAssetTooLarge, constants.ErrCodeManifestTooLarge, constants.ErrCodeTopicQuotaExceeded:
 		status = http.StatusRequestEntityTooLarge
-	case constants.ErrCodeInvalidRequest, constants.ErrCodeInvalidHash, constants.ErrCodeInvalidTopicName,
+	case constants.ErrCodeInvalidRequest, constants.ErrCodeInvalidHash, constants.ErrCodeInvalidTopicName, constants.ErrCodeInvalidManifest,
+		constants.ErrCodeInvalidFetchURL, constants.ErrCodeFetchBlocked,
 		constants.ErrCodeParentNotFound, constants.ErrCodeMissingParam, constants.ErrCodeMetadataKeyTooLong,
 		constants.ErrCodeMetadataValueTooLong, constants.ErrCodeBatchInvalidOperation, constants.ErrCodeBatchTooManyOperations,
 		constants.ErrCodeTopicUnhealthy,
 		constants.ErrCodeBulkDownloadEmpty, constants.ErrCodeBulkDownloadTooLarge,
-		constants.ErrCodeInvalidFilenameFormat, constants.ErrCodeInvalidDownloadMode:
+		constants.ErrCodeInvalidFilenameFormat, constants.ErrCodeInvalidDownloadMode,
+		constants.ErrCodeInvalidCollectionName, constants.ErrCodePresetNotReadOnly, constants.ErrCodeIdempotencyKeyInvalid,
+		constants.ErrCodeInvalidLimits, constants.ErrCodeWatermarkNotFound, constants.ErrCodeInvalidMetadataSelection,
+		constants.ErrCodeMetadataImportInvalid, constants.ErrCodeInvalidStoragePolicy, constants.ErrCodeInvalidTopicQuota, constants.ErrCodeInvalidWatchFolder,
+		constants.ErrCodeLineageReparentInvalid, constants.ErrCodeLineageCycle, constants.ErrCodeInvalidSetupStep, constants.ErrCodeInvalidWebhook,
+		constants.ErrCodeInvalidRule, constants.ErrCodeInvalidQueryFilter:
 		status = http.StatusBadRequest
-	case constants.ErrCodeNotConfigured:
+	case constants.ErrCodeNotConfigured, constants.ErrCodeFederationDisabled:
 		status = http.StatusBadRequest
 	case constants.ErrCodeQueryError, constants.ErrCodeMetadataError:
 		status = http.StatusInternalServerError
-	case constants.ErrCodeDiskLimitExceeded:
+	case constants.ErrCodeDiskLimitExceeded, constants.ErrCodeStorageFull, constants.ErrCodeExportInboxFull:
 		status = http.StatusInsufficientStorage
+	case constants.ErrCodeQueryTimeout, constants.ErrCodeUploadScanFailed, constants.ErrCodeSearchUnavailable,
+		constants.ErrCodeUploadValidationFailed, constants.ErrCodeUploadIndexFailed, constants.ErrCodeFeatureDisabled:
+		status = http.StatusServiceUnavailable
+	case constants.ErrCodeOriginFetchFailed, constants.ErrCodeOriginHashMismatch, constants.ErrCodeAuthSSOProviderError,
+		constants.ErrCodeFetchFailed:
+		status = http.StatusBadGateway
 	}
 
-	WriteError(w, status, err.Error(), code)
+	return status
 }
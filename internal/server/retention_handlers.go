@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Retention Policy Handlers
+// =============================================================================
+
+// POST /api/topics/:name/retention - Apply the topic's retention policy now
+// (requires manage_topics delete on the topic). Body {dry_run} is optional;
+// a dry run only reports the assets that would be removed.
+func (s *Server) handleTopicRetention(w http.ResponseWriter, r *http.Request, topicName string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageTopics,
+		SubAction: "delete",
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	var req struct {
+		DryRun bool `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	result, err := s.app.Services.Retention.Apply(topicName, req.DryRun, getAuditUsername(identity), getClientIP(r))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, result)
+}
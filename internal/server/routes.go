@@ -0,0 +1,495 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// routeHandler serves a request that passed its route's policy. identity is
+// the caller the policy authenticated, nil for routes that do not.
+type routeHandler func(w http.ResponseWriter, r *http.Request, identity *auth.Identity)
+
+// route declares one API endpoint together with the policy applied before
+// its handler runs. The same table registers the mux and answers the
+// capabilities and explain endpoints, so what they report is what is enforced.
+type route struct {
+	Pattern string   // ServeMux pattern; a trailing slash matches the subtree
+	Methods []string // Accepted methods; others get 405 before authentication
+	Auth    string   // RouteAuth* mode
+	Action  string   // Grant evaluated before the handler; empty checks authentication only
+
+	// Resource fills in what the action applies to (topic, preset...)
+	// from the request. Nil evaluates the action without a resource.
+	Resource func(r *http.Request, ctx *auth.ActionContext)
+
+	Audit   []string // Audit actions the handler records
+	Handler routeHandler
+}
+
+// topicQueryResource scopes the action to the topic named by a query param.
+func topicQueryResource(param string) func(r *http.Request, ctx *auth.ActionContext) {
+	return func(r *http.Request, ctx *auth.ActionContext) {
+		ctx.TopicName = r.URL.Query().Get(param)
+	}
+}
+
+// topicDeleteResource scopes manage_topics to deleting in the topic named by
+// a query param.
+func topicDeleteResource(param string) func(r *http.Request, ctx *auth.ActionContext) {
+	return func(r *http.Request, ctx *auth.ActionContext) {
+		ctx.TopicName = r.URL.Query().Get(param)
+		ctx.SubAction = "delete"
+	}
+}
+
+// handlerRoute declares a route whose handler authenticates and authorizes
+// itself, for endpoints whose action depends on the path below the pattern
+// or on the request body.
+func handlerRoute(pattern string, h http.HandlerFunc) route {
+	return route{
+		Pattern: pattern,
+		Auth:    constants.RouteAuthHandler,
+		Handler: func(w http.ResponseWriter, r *http.Request, _ *auth.Identity) { h(w, r) },
+	}
+}
+
+// routeTable returns every API route in registration order.
+func (s *Server) routeTable() []route {
+	get := []string{http.MethodGet}
+	post := []string{http.MethodPost}
+
+	return []route{
+		handlerRoute("/api/config", s.handleConfig),
+		handlerRoute("/api/config/validate", s.handleConfigValidate),
+		{Pattern: "/api/config/history", Methods: get, Auth: constants.RouteAuthRequired, Action: constants.AuthActionManageConfig, Handler: s.handleConfigHistory},
+		handlerRoute("/api/limits", s.handleLimits),
+		{Pattern: "/api/storage-policies", Methods: get, Auth: constants.RouteAuthRequired, Handler: s.handleStoragePolicies},
+		handlerRoute("/api/storage-policies/", s.handleStoragePolicy),
+		handlerRoute("/api/topics", s.handleTopics),
+		handlerRoute("/api/topics/", s.handleTopicRoutes),
+		handlerRoute("/api/assets/", s.handleAssetRoutes),
+		{Pattern: "/api/popular", Methods: get, Auth: constants.RouteAuthRequired, Handler: s.handlePopular},
+		{Pattern: "/api/search", Methods: get, Auth: constants.RouteAuthRequired, Handler: s.handleSearch},
+		{
+			Pattern:  "/api/quarantine",
+			Methods:  get,
+			Auth:     constants.RouteAuthRequired,
+			Action:   constants.AuthActionVerify,
+			Resource: topicQueryResource("topic"),
+			Handler:  s.handleQuarantine,
+		},
+		{
+			Pattern: "/api/deletion-requests",
+			Methods: []string{http.MethodGet, http.MethodPost},
+			Auth:    constants.RouteAuthRequired,
+			Audit:   []string{constants.AuditActionDeletionRequested},
+			Handler: s.handleDeletionRequests,
+		},
+		{
+			Pattern: "/api/deletion-requests/",
+			Methods: []string{http.MethodGet, http.MethodPost},
+			Auth:    constants.RouteAuthRequired,
+			Audit: []string{constants.AuditActionDeletionApproved, constants.AuditActionDeletionRejected,
+				constants.AuditActionDeletionCancelled, constants.AuditActionDeletionCompleted},
+			Handler: s.handleDeletionRequestRoutes,
+		},
+		{
+			Pattern:  "/api/trash",
+			Methods:  get,
+			Auth:     constants.RouteAuthRequired,
+			Action:   constants.AuthActionManageTopics,
+			Resource: topicDeleteResource("topic"),
+			Handler:  s.handleTrash,
+		},
+		handlerRoute("/api/trash/", s.handleTrashRoutes),
+		handlerRoute("/api/queries", s.handleQueries),
+		handlerRoute("/api/queries/validate", s.handleQueryValidate),
+		handlerRoute("/api/query/schema", s.handleQueryBuilderSchema),
+		handlerRoute("/api/query/build", s.handleQueryBuild),
+		handlerRoute("/api/query/", s.handleQueryExecution),
+		handlerRoute("/api/federation/query/", s.handleFederatedQuery),
+		{Pattern: "/api/federation/peers", Methods: get, Auth: constants.RouteAuthRequired, Action: constants.AuthActionManageConfig, Handler: s.handleFederationPeers},
+		handlerRoute("/api/verify", s.handleVerify),
+		handlerRoute("/api/download/bulk", s.handleBulkDownload),
+		handlerRoute("/api/download/bulk/start", s.handleBulkDownloadSSE),
+		handlerRoute("/api/download/bulk/", s.handleBulkDownloadFetch),
+		handlerRoute("/api/exports", s.handleExports),
+		handlerRoute("/api/exports/links", s.handleLinkExports),
+		handlerRoute("/api/exports/links/", s.handleLinkExportRoutes),
+		handlerRoute("/api/exports/", s.handleExportRoutes),
+
+		// Setup wizard routes
+		{Pattern: "/api/setup/status", Methods: get, Auth: constants.RouteAuthNone, Handler: s.handleSetupStatus},
+		handlerRoute("/api/setup/working-directory", s.handleSetupWorkingDirectory),
+		{Pattern: "/api/setup/credentials", Methods: post, Auth: constants.RouteAuthRequired, Action: constants.AuthActionManageConfig, Handler: s.handleSetupCredentials},
+		handlerRoute("/api/setup/topic", s.handleSetupTopic),
+		{Pattern: "/api/setup/tls", Methods: post, Auth: constants.RouteAuthRequired, Action: constants.AuthActionManageConfig, Handler: s.handleSetupTLS},
+
+		// Resumable upload routes
+		handlerRoute("/api/uploads", s.handleUploadSessions),
+		handlerRoute("/api/uploads/", s.handleUploadSessionRoutes),
+
+		// Watch folder routes
+		{Pattern: "/api/watch-folders", Methods: get, Auth: constants.RouteAuthRequired, Action: constants.AuthActionManageConfig, Handler: s.handleWatchFolders},
+		handlerRoute("/api/watch-folders/", s.handleWatchFolderRoutes),
+
+		// Webhook routes
+		{
+			Pattern: "/api/webhooks",
+			Methods: []string{http.MethodGet, http.MethodPost},
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionManageConfig,
+			Audit:   []string{constants.AuditActionWebhookCreated},
+			Handler: s.handleWebhooks,
+		},
+		{
+			Pattern: "/api/webhooks/",
+			Methods: []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionManageConfig,
+			Audit:   []string{constants.AuditActionWebhookUpdated, constants.AuditActionWebhookDeleted},
+			Handler: s.handleWebhookRoutes,
+		},
+
+		// Rule routes
+		{
+			Pattern: "/api/rules",
+			Methods: []string{http.MethodGet, http.MethodPost},
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionManageConfig,
+			Audit:   []string{constants.AuditActionRuleCreated},
+			Handler: s.handleRules,
+		},
+		{
+			Pattern: "/api/rules/",
+			Methods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionManageConfig,
+			Audit:   []string{constants.AuditActionRuleUpdated, constants.AuditActionRuleDeleted, constants.AuditActionRuleExecuted},
+			Handler: s.handleRuleRoutes,
+		},
+
+		// Debug routes, 404 unless debug.enabled is set
+		{
+			Pattern: constants.DebugPprofPrefix,
+			Methods: []string{http.MethodGet, http.MethodPost},
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionDebug,
+			Handler: s.handleDebugPprof,
+		},
+		{Pattern: constants.DebugRoutePrefix + "metrics", Methods: get, Auth: constants.RouteAuthRequired, Action: constants.AuthActionDebug, Handler: s.handleDebugMetrics},
+		{
+			Pattern: constants.DebugRoutePrefix + "bundle",
+			Methods: get,
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionDebug,
+			Audit:   []string{constants.AuditActionDebugBundle},
+			Handler: s.handleDebugBundle,
+		},
+
+		// Progress WebSocket (upload/download progress and cancellation)
+		handlerRoute("/api/ws/progress", s.handleProgressSocket),
+
+		// Audit log routes
+		handlerRoute("/api/audit", s.handleAuditQuery),
+		handlerRoute("/api/audit/stream", s.handleAuditStream),
+		handlerRoute("/api/events/stream", s.handleEventStream),
+		handlerRoute("/api/audit/export", s.handleAuditExport),
+		{Pattern: "/api/audit/actions", Methods: get, Auth: constants.RouteAuthRequired, Action: constants.AuthActionViewAudit, Handler: s.handleAuditActions},
+		{Pattern: "/api/audit/verify", Methods: get, Auth: constants.RouteAuthRequired, Action: constants.AuthActionViewAudit, Handler: s.handleAuditVerify},
+		{
+			Pattern: "/api/audit/purge",
+			Methods: post,
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionManageConfig,
+			Audit:   []string{constants.AuditActionAuditPurged},
+			Handler: s.handleAuditPurge,
+		},
+		{Pattern: "/api/audit/purge/preview", Methods: get, Auth: constants.RouteAuthRequired, Action: constants.AuthActionManageConfig, Handler: s.handleAuditPurgePreview},
+		{
+			Pattern: "/api/audit/holds",
+			Methods: []string{http.MethodGet, http.MethodPost},
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionManageConfig,
+			Audit:   []string{constants.AuditActionAuditHoldCreated},
+			Handler: s.handleAuditHolds,
+		},
+		{
+			Pattern: "/api/audit/holds/",
+			Methods: []string{http.MethodDelete},
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionManageConfig,
+			Audit:   []string{constants.AuditActionAuditHoldReleased},
+			Handler: s.handleAuditHoldRelease,
+		},
+
+		// Batch metadata routes
+		handlerRoute("/api/metadata/batch", s.handleBatchMetadata),
+		handlerRoute("/api/metadata/apply", s.handleApplyMetadata),
+		handlerRoute("/api/metadata/import", s.handleMetadataImport),
+		handlerRoute("/api/metadata/import/", s.handleMetadataImportResult),
+
+		// Lineage routes
+		{
+			Pattern: "/api/lineage/reparent",
+			Methods: post,
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionMetadata,
+			Audit:   []string{constants.AuditActionLineageReparented},
+			Handler: s.handleLineageReparent,
+		},
+
+		// Notification routes
+		handlerRoute("/api/notifications", s.handleNotifications),
+		handlerRoute("/api/notifications/read", s.handleNotificationsRead),
+		handlerRoute("/api/notifications/stream", s.handleNotificationStream),
+		handlerRoute("/api/notifications/subscriptions", s.handleNotificationSubscriptions),
+		handlerRoute("/api/notifications/preferences", s.handleNotificationPreferences),
+
+		// API schema and prompts routes
+		handlerRoute("/api/schema", s.handleSchema),
+		handlerRoute("/api/prompts", s.handlePrompts),
+		handlerRoute("/api/prompts/", s.handlePrompts),
+
+		// Auth routes
+		handlerRoute("/api/auth/", s.handleAuthRoutes),
+		{Pattern: "/api/auth/me/explain", Methods: get, Auth: constants.RouteAuthRequired, Handler: s.handleAuthMeExplain},
+
+		// Monitoring routes
+		{Pattern: "/api/monitoring", Methods: get, Auth: constants.RouteAuthRequired, Action: constants.AuthActionManageConfig, Handler: s.handleMonitoring},
+		{Pattern: "/api/monitoring/logs/", Methods: get, Auth: constants.RouteAuthRequired, Action: constants.AuthActionManageConfig, Handler: s.handleMonitoringLogFile},
+		{Pattern: "/api/origin-cache", Methods: get, Auth: constants.RouteAuthRequired, Action: constants.AuthActionManageConfig, Handler: s.handleOriginCache},
+		{
+			Pattern: "/api/stats/chunk-dedup",
+			Methods: []string{http.MethodGet, http.MethodPost},
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionManageConfig,
+			Handler: s.handleChunkDedup,
+		},
+
+		// Integrity scrubber
+		{
+			Pattern: "/api/maintenance/scrub",
+			Methods: []string{http.MethodGet, http.MethodPost},
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionVerify,
+			Audit:   []string{constants.AuditActionVerified},
+			Handler: s.handleScrub,
+		},
+
+		// Backup
+		{
+			Pattern: "/api/backup",
+			Methods: post,
+			Auth:    constants.RouteAuthRequired,
+			Action:  constants.AuthActionManageConfig,
+			Audit:   []string{constants.AuditActionBackupCreated},
+			Handler: s.handleBackup,
+		},
+
+		// Health probes (unauthenticated) and startup reports
+		handlerRoute(constants.HealthPath, s.handleHealthz),
+		handlerRoute(constants.ReadinessPath, s.handleReadyz),
+		{Pattern: "/api/health/startup", Methods: get, Auth: constants.RouteAuthRequired, Action: constants.AuthActionManageConfig, Handler: s.handleStartupReports},
+
+		// Sync tooling
+		{
+			Pattern:  "/api/sync/diff",
+			Methods:  post,
+			Auth:     constants.RouteAuthRequired,
+			Action:   constants.AuthActionQuery,
+			Resource: topicQueryResource(constants.SyncDiffQueryParamTopic),
+			Handler:  s.handleSyncDiff,
+		},
+
+		// S3 gateway, authenticated by request signatures
+		handlerRoute(constants.S3PathPrefix, s.handleS3),
+	}
+}
+
+// policyHandler applies a route's policy in order: method, authentication,
+// authorization, then the handler.
+func (s *Server) policyHandler(rt route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rt.Auth == constants.RouteAuthHandler {
+			rt.Handler(w, r, nil)
+			return
+		}
+
+		if !rt.allowsMethod(r.Method) {
+			w.Header().Set("Allow", strings.Join(rt.Methods, ", "))
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var identity *auth.Identity
+		switch rt.Auth {
+		case constants.RouteAuthRequired:
+			identity = s.requireAuth(w, r)
+		case constants.RouteAuthPublic:
+			identity = s.requireAuthOrPublic(w, r)
+		}
+		if identity == nil && rt.Auth != constants.RouteAuthNone {
+			return
+		}
+
+		if ctx := rt.actionContext(r); ctx != nil && !s.authorize(w, identity, ctx) {
+			return
+		}
+
+		rt.Handler(w, r, identity)
+	}
+}
+
+// allowsMethod reports whether the route accepts method.
+func (rt *route) allowsMethod(method string) bool {
+	if len(rt.Methods) == 0 {
+		return true
+	}
+	for _, m := range rt.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// actionContext builds the context the route's action is evaluated
+// against, or nil when the route declares no action.
+func (rt *route) actionContext(r *http.Request) *auth.ActionContext {
+	if rt.Action == "" {
+		return nil
+	}
+	ctx := &auth.ActionContext{Action: rt.Action}
+	if rt.Resource != nil {
+		rt.Resource(r, ctx)
+	}
+	return ctx
+}
+
+// matchRoute returns the route serving path, with the same precedence as
+// ServeMux: an exact pattern, else the longest subtree pattern.
+func (s *Server) matchRoute(path string) *route {
+	var best *route
+	for i := range s.routes {
+		rt := &s.routes[i]
+		if rt.Pattern == path {
+			return rt
+		}
+		if strings.HasSuffix(rt.Pattern, "/") && strings.HasPrefix(path, rt.Pattern) &&
+			(best == nil || len(rt.Pattern) > len(best.Pattern)) {
+			best = rt
+		}
+	}
+	return best
+}
+
+// routeExplanation is the policy applied to one request and its outcome
+// for the caller.
+type routeExplanation struct {
+	Method       string   `json:"method"`
+	Path         string   `json:"path"`
+	Pattern      string   `json:"pattern"`
+	Methods      []string `json:"methods,omitempty"`
+	Auth         string   `json:"auth"`
+	Action       string   `json:"action,omitempty"`
+	TopicName    string   `json:"topic,omitempty"`
+	AuditActions []string `json:"audit_actions,omitempty"`
+	Allowed      *bool    `json:"allowed,omitempty"` // Unset when the handler decides
+	Reason       string   `json:"reason,omitempty"`
+	DeniedCode   string   `json:"denied_code,omitempty"`
+}
+
+// explainRoute evaluates the policy of the route serving method and target
+// for identity, without running the handler.
+func (s *Server) explainRoute(identity *auth.Identity, method, target string) (*routeExplanation, bool) {
+	u, err := url.ParseRequestURI(target)
+	if err != nil {
+		return nil, false
+	}
+	rt := s.matchRoute(u.Path)
+	if rt == nil {
+		return nil, false
+	}
+
+	ex := &routeExplanation{
+		Method:       method,
+		Path:         u.Path,
+		Pattern:      rt.Pattern,
+		Methods:      rt.Methods,
+		Auth:         rt.Auth,
+		Action:       rt.Action,
+		AuditActions: rt.Audit,
+	}
+	allowed := func(ok bool, reason, code string) {
+		ex.Allowed, ex.Reason, ex.DeniedCode = &ok, reason, code
+	}
+
+	switch {
+	case rt.Auth == constants.RouteAuthHandler:
+		ex.Reason = "Authorization is checked by the handler"
+	case !rt.allowsMethod(method):
+		allowed(false, "Method not allowed", "")
+	default:
+		ctx := rt.actionContext(&http.Request{Method: method, URL: u})
+		if ctx == nil {
+			allowed(true, "", "")
+			break
+		}
+		ex.TopicName = ctx.TopicName
+		result := s.app.Services.Auth.GetEvaluator().Evaluate(identity, ctx)
+		allowed(result.Allowed, result.Reason, result.DeniedCode)
+	}
+	return ex, true
+}
+
+// allowedEndpoints lists the declared routes whose policy lets identity in,
+// as "METHOD pattern", evaluating resource-scoped actions without a resource.
+func (s *Server) allowedEndpoints(identity *auth.Identity) []string {
+	evaluator := s.app.Services.Auth.GetEvaluator()
+	endpoints := []string{}
+	for i := range s.routes {
+		rt := &s.routes[i]
+		if rt.Auth == constants.RouteAuthHandler {
+			continue
+		}
+		if rt.Action != "" && !evaluator.Evaluate(identity, &auth.ActionContext{Action: rt.Action}).Allowed {
+			continue
+		}
+		for _, m := range rt.Methods {
+			endpoints = append(endpoints, m+" "+rt.Pattern)
+		}
+	}
+	return endpoints
+}
+
+// GET /api/auth/me/explain?method=&path= — The policy of the route serving
+// a request and whether it would admit the current user
+func (s *Server) handleAuthMeExplain(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if !s.isAuthAvailable() {
+		WriteError(w, http.StatusServiceUnavailable, "Auth system not available", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	q := r.URL.Query()
+	target := q.Get(constants.RouteExplainPath)
+	if target == "" {
+		WriteError(w, http.StatusBadRequest, "path is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+	method := strings.ToUpper(q.Get(constants.RouteExplainMethod))
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	ex, ok := s.explainRoute(identity, method, target)
+	if !ok {
+		WriteError(w, http.StatusNotFound, "No route serves "+target, constants.ErrCodeRouteNotFound)
+		return
+	}
+	WriteSuccess(w, ex)
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestRouteTable_Consistent(t *testing.T) {
+	s := &Server{}
+	routes := s.routeTable()
+
+	seen := make(map[string]bool)
+	for _, rt := range routes {
+		if seen[rt.Pattern] {
+			t.Errorf("pattern %s is declared twice", rt.Pattern)
+		}
+		seen[rt.Pattern] = true
+
+		if rt.Handler == nil {
+			t.Errorf("%s has no handler", rt.Pattern)
+		}
+		switch rt.Auth {
+		case constants.RouteAuthHandler:
+			if len(rt.Methods) > 0 || rt.Action != "" || rt.Resource != nil {
+				t.Errorf("%s authorizes in its handler but declares a policy", rt.Pattern)
+			}
+		case constants.RouteAuthNone, constants.RouteAuthRequired, constants.RouteAuthPublic:
+			if len(rt.Methods) == 0 {
+				t.Errorf("%s declares a policy without methods", rt.Pattern)
+			}
+		default:
+			t.Errorf("%s has unknown auth mode %q", rt.Pattern, rt.Auth)
+		}
+		if rt.Action != "" && !slices.Contains(constants.AllAuthActions, rt.Action) {
+			t.Errorf("%s requires unknown action %q", rt.Pattern, rt.Action)
+		}
+	}
+}
+
+func TestMatchRoute_ServeMuxPrecedence(t *testing.T) {
+	s := &Server{}
+	s.routes = s.routeTable()
+
+	cases := map[string]string{
+		"/api/audit/holds":      "/api/audit/holds",
+		"/api/audit/holds/12":   "/api/audit/holds/",
+		"/api/auth/me/explain":  "/api/auth/me/explain",
+		"/api/auth/me":          "/api/auth/",
+		"/api/download/bulk/x1": "/api/download/bulk/",
+		"/api/monitoring/logs/": "/api/monitoring/logs/",
+	}
+	for path, want := range cases {
+		rt := s.matchRoute(path)
+		if rt == nil || rt.Pattern != want {
+			t.Errorf("%s: expected %s, got %+v", path, want, rt)
+		}
+	}
+	if rt := s.matchRoute("/api/unknown"); rt != nil {
+		t.Errorf("expected no route, got %s", rt.Pattern)
+	}
+}
+
+func TestRouteActionContext_Resource(t *testing.T) {
+	s := &Server{}
+	s.routes = s.routeTable()
+
+	r, _ := http.NewRequest(http.MethodPost, "/api/sync/diff?topic=models", nil)
+	ctx := s.matchRoute(r.URL.Path).actionContext(r)
+	if ctx == nil || ctx.Action != constants.AuthActionQuery || ctx.TopicName != "models" {
+		t.Errorf("unexpected action context: %+v", ctx)
+	}
+	if ctx := s.matchRoute("/api/storage-policies").actionContext(r); ctx != nil {
+		t.Errorf("expected no action for an authentication-only route, got %+v", ctx)
+	}
+}
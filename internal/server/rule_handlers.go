@@ -0,0 +1,196 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Rule Handlers
+// =============================================================================
+
+// Rules act on any asset as the system actor, so every route requires
+// manage_config. Saving a rule also requires the grant its action needs by
+// hand on each of its topics.
+
+// GET /api/rules - Rules with their schedule
+// POST /api/rules - Create a rule
+func (s *Server) handleRules(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	rules, ok := s.ruleService(w)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		list, err := rules.List()
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"rules": list,
+		})
+		return
+	}
+
+	var req services.RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+	rule, err := rules.Create(&req, getAuditUsername(identity), s.ruleAllowed(identity))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	s.auditRuleChange(r, identity, constants.AuditActionRuleCreated, rule)
+	WriteSuccess(w, map[string]interface{}{
+		"rule": rule,
+	})
+}
+
+// POST /api/rules/preview - What an unsaved rule would do now
+// GET /api/rules/:id - Rule with its newest runs
+// PUT /api/rules/:id - Update the set fields of a rule
+// DELETE /api/rules/:id - Delete a rule and its run history
+// POST /api/rules/:id/run - Run a rule now; {"dry_run": true} only reports
+func (s *Server) handleRuleRoutes(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	rules, ok := s.ruleService(w)
+	if !ok {
+		return
+	}
+
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/rules/"), "/")
+	if path == "preview" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req services.RuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+			return
+		}
+		run, err := rules.Preview(&req, s.ruleAllowed(identity))
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"run": run,
+		})
+		return
+	}
+
+	idPart, sub, _ := strings.Cut(path, "/")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid rule ID", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if sub == "run" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			DryRun bool `json:"dry_run"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+				return
+			}
+		}
+		run, err := rules.Run(id, req.DryRun)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"run": run,
+		})
+		return
+	}
+	if sub != "" {
+		WriteError(w, http.StatusNotFound, "Not found", constants.ErrCodeRuleNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rule, err := rules.Get(id)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, rule)
+	case http.MethodDelete:
+		rule, err := rules.Delete(id)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		s.auditRuleChange(r, identity, constants.AuditActionRuleDeleted, rule)
+		WriteSuccess(w, map[string]interface{}{
+			"deleted": id,
+		})
+	case http.MethodPut:
+		var req services.RuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+			return
+		}
+		rule, err := rules.Update(id, &req, s.ruleAllowed(identity))
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		s.auditRuleChange(r, identity, constants.AuditActionRuleUpdated, rule)
+		WriteSuccess(w, map[string]interface{}{
+			"rule": rule,
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ruleService returns the rule service, or writes an error when the
+// orchestrator DB is not available.
+func (s *Server) ruleService(w http.ResponseWriter) (*services.RuleService, bool) {
+	if s.app.Services.Rules == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Rules not available", constants.ErrCodeNotConfigured)
+		return nil, false
+	}
+	return s.app.Services.Rules, true
+}
+
+// ruleAllowed reports whether the identity may perform an action by hand.
+func (s *Server) ruleAllowed(identity *auth.Identity) func(*auth.ActionContext) bool {
+	return func(ctx *auth.ActionContext) bool {
+		return s.app.Services.Auth.GetEvaluator().Evaluate(identity, ctx).Allowed
+	}
+}
+
+// auditRuleChange records a rule change in the audit log.
+func (s *Server) auditRuleChange(r *http.Request, identity *auth.Identity, action string, rule *database.Rule) {
+	if s.app.AuditLogger == nil {
+		return
+	}
+	s.app.AuditLogger.Log(action, getClientIP(r), getAuditUsername(identity), audit.RuleDetails{
+		RuleID:  rule.ID,
+		Name:    rule.Name,
+		Trigger: rule.Trigger.Type,
+		Action:  rule.Action.Type,
+		Enabled: rule.Enabled,
+	})
+}
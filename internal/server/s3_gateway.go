@@ -0,0 +1,648 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// The S3 gateway serves a path-style subset of the S3 API: buckets are
+// topics and object keys are the filenames assets were uploaded under, or
+// their hashes. Requests are signed with Signature Version 4 using the
+// credentials of a user's API key, and authorized by the same grants as
+// the API: query to list, download to read, upload to write.
+
+// s3ListingParams are the query parameters of ListObjects and
+// ListObjectsV2. Other bucket subresources are not implemented.
+var s3ListingParams = map[string]bool{
+	"list-type": true, "prefix": true, "marker": true, "start-after": true, "continuation-token": true,
+	"max-keys": true, "delimiter": true, "encoding-type": true, "fetch-owner": true,
+}
+
+type s3ErrorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string
+	Message   string
+	Resource  string
+	RequestID string `xml:"RequestId"`
+}
+
+type s3Owner struct {
+	ID          string
+	DisplayName string
+}
+
+type s3Bucket struct {
+	Name         string
+	CreationDate string
+}
+
+type s3ListAllMyBucketsResult struct {
+	XMLName xml.Name   `xml:"ListAllMyBucketsResult"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Owner   s3Owner    `xml:"Owner"`
+	Buckets []s3Bucket `xml:"Buckets>Bucket"`
+}
+
+type s3Object struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int64
+	StorageClass string
+}
+
+type s3ListBucketResult struct {
+	XMLName      xml.Name `xml:"ListBucketResult"`
+	Xmlns        string   `xml:"xmlns,attr"`
+	Name         string
+	Prefix       string
+	Marker       string
+	NextMarker   string `xml:",omitempty"`
+	MaxKeys      int
+	EncodingType string `xml:",omitempty"`
+	IsTruncated  bool
+	Contents     []s3Object
+}
+
+type s3ListBucketV2Result struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Xmlns                 string   `xml:"xmlns,attr"`
+	Name                  string
+	Prefix                string
+	StartAfter            string `xml:",omitempty"`
+	ContinuationToken     string `xml:",omitempty"`
+	NextContinuationToken string `xml:",omitempty"`
+	KeyCount              int
+	MaxKeys               int
+	EncodingType          string `xml:",omitempty"`
+	IsTruncated           bool
+	Contents              []s3Object
+}
+
+type s3LocationConstraint struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Region  string   `xml:",chardata"`
+}
+
+// handleS3 serves the gateway mounted under /s3/ on the main listener.
+func (s *Server) handleS3(w http.ResponseWriter, r *http.Request) {
+	s.serveS3(w, r, strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(constants.S3PathPrefix, "/")))
+}
+
+// s3Handler serves the gateway at the root of the dedicated s3.port
+// listener.
+func (s *Server) s3Handler() http.Handler {
+	return Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.serveS3(w, r, r.URL.Path)
+	}), RequestID, SecurityHeaders)
+}
+
+// serveS3 authenticates an S3 request and dispatches it on path, the
+// request path relative to the gateway root.
+func (s *Server) serveS3(w http.ResponseWriter, r *http.Request, path string) {
+	cfg := s.app.Config
+	if !cfg.S3.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set(constants.S3HeaderRequestID, w.Header().Get(requestIDHeaderKey))
+
+	if cfg.WorkingDirectory == "" || !s.isAuthAvailable() {
+		s.writeS3Error(w, r, newS3Error(http.StatusServiceUnavailable, constants.S3ErrServiceUnavailable, "working directory not configured"))
+		return
+	}
+
+	var identity *auth.Identity
+	signed, err := verifySigV4(r, cfg.S3.Region, time.Now(), func(accessKeyID string) (string, error) {
+		id, secret, err := s.app.Services.Auth.ResolveS3AccessKey(accessKeyID)
+		if err != nil {
+			return "", newS3Error(http.StatusForbidden, constants.S3ErrInvalidAccessKeyID, "the access key ID does not exist in our records")
+		}
+		identity = id
+		return secret, nil
+	})
+	if err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	query := r.URL.Query()
+
+	switch {
+	case bucket == "" && r.Method == http.MethodGet:
+		s.s3ListBuckets(w, r, identity)
+	case bucket == "":
+		s.writeS3Error(w, r, errS3NotImplemented)
+	case key == "" && r.Method == http.MethodHead:
+		s.s3HeadBucket(w, r, identity, bucket)
+	case key == "" && r.Method == http.MethodGet && query.Has("location"):
+		s.s3GetBucketLocation(w, r, identity, bucket)
+	case key == "" && r.Method == http.MethodGet && s3IsListing(query):
+		s.s3ListObjects(w, r, identity, bucket)
+	case key == "":
+		s.writeS3Error(w, r, errS3NotImplemented)
+	case len(query) > 0:
+		// Object subresources (ACLs, tagging, multipart uploads...)
+		s.writeS3Error(w, r, errS3NotImplemented)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.s3GetObject(w, r, identity, bucket, key)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") == "":
+		s.s3PutObject(w, r, identity, signed, bucket, key)
+	default:
+		s.writeS3Error(w, r, errS3NotImplemented)
+	}
+}
+
+var errS3NotImplemented = newS3Error(http.StatusNotImplemented, constants.S3ErrNotImplemented,
+	"this operation is not supported by the gateway")
+
+// s3IsListing reports whether a bucket GET is a ListObjects request.
+func s3IsListing(query url.Values) bool {
+	for name := range query {
+		if !s3ListingParams[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// s3Authorize evaluates action on topicName for identity, returning the
+// matched policy or an AccessDenied error.
+func (s *Server) s3Authorize(identity *auth.Identity, ctx *auth.ActionContext) (*auth.PolicyResult, error) {
+	result := s.app.Services.Auth.GetEvaluator().Evaluate(identity, ctx)
+	if !result.Allowed {
+		return nil, newS3Error(http.StatusForbidden, constants.S3ErrAccessDenied, result.Reason)
+	}
+	return result, nil
+}
+
+// s3Bucket checks that a bucket names an existing topic.
+func (s *Server) s3Bucket(bucket string) error {
+	if !s.app.TopicExists(bucket) {
+		return newS3Error(http.StatusNotFound, constants.S3ErrNoSuchBucket, "the specified bucket does not exist")
+	}
+	return nil
+}
+
+// GET / - The topics the user may list or read
+func (s *Server) s3ListBuckets(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	result := s3ListAllMyBucketsResult{
+		Xmlns:   constants.S3XMLNamespace,
+		Owner:   s3Owner{ID: strconv.FormatInt(identity.User.ID, 10), DisplayName: identity.User.Username},
+		Buckets: []s3Bucket{},
+	}
+	evaluator := s.app.Services.Auth.GetEvaluator()
+	for _, topic := range s.app.ListTopics() {
+		if !evaluator.Evaluate(identity, &auth.ActionContext{Action: constants.AuthActionQuery, TopicName: topic}).Allowed &&
+			!evaluator.Evaluate(identity, &auth.ActionContext{Action: constants.AuthActionDownload, TopicName: topic}).Allowed {
+			continue
+		}
+		var created time.Time
+		if info, err := os.Stat(s.app.GetTopicPath(topic)); err == nil {
+			created = info.ModTime()
+		}
+		result.Buckets = append(result.Buckets, s3Bucket{Name: topic, CreationDate: created.UTC().Format(constants.S3TimeFormat)})
+	}
+	writeS3XML(w, result)
+}
+
+// HEAD /:bucket - Whether the topic exists and may be listed
+func (s *Server) s3HeadBucket(w http.ResponseWriter, r *http.Request, identity *auth.Identity, bucket string) {
+	if err := s.s3Bucket(bucket); err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	if _, err := s.s3Authorize(identity, &auth.ActionContext{Action: constants.AuthActionQuery, TopicName: bucket}); err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	w.Header().Set("X-Amz-Bucket-Region", s.app.Config.S3.Region)
+	w.WriteHeader(http.StatusOK)
+}
+
+// GET /:bucket?location - The configured region
+func (s *Server) s3GetBucketLocation(w http.ResponseWriter, r *http.Request, identity *auth.Identity, bucket string) {
+	if err := s.s3Bucket(bucket); err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	if _, err := s.s3Authorize(identity, &auth.ActionContext{Action: constants.AuthActionQuery, TopicName: bucket}); err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	writeS3XML(w, s3LocationConstraint{Xmlns: constants.S3XMLNamespace, Region: s.app.Config.S3.Region})
+}
+
+// GET /:bucket - ListObjects, or ListObjectsV2 with list-type=2. Keys
+// never contain slashes, so a delimiter groups nothing and is ignored.
+func (s *Server) s3ListObjects(w http.ResponseWriter, r *http.Request, identity *auth.Identity, bucket string) {
+	if err := s.s3Bucket(bucket); err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	if _, err := s.s3Authorize(identity, &auth.ActionContext{Action: constants.AuthActionQuery, TopicName: bucket}); err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+
+	q := r.URL.Query()
+	maxKeys := constants.S3MaxKeys
+	if v := q.Get("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.writeS3Error(w, r, newS3Error(http.StatusBadRequest, constants.S3ErrInvalidArgument, "max-keys must be a non-negative integer"))
+			return
+		}
+		maxKeys = min(n, constants.S3MaxKeys)
+	}
+	encode := func(v string) string { return v }
+	encodingType := q.Get("encoding-type")
+	if encodingType == "url" {
+		encode = url.QueryEscape
+	} else if encodingType != "" {
+		s.writeS3Error(w, r, newS3Error(http.StatusBadRequest, constants.S3ErrInvalidArgument, "encoding-type must be url"))
+		return
+	}
+
+	v2 := q.Get("list-type") == "2"
+	prefix := q.Get("prefix")
+	after := q.Get("marker")
+	if v2 {
+		after = q.Get("start-after")
+		if token := q.Get("continuation-token"); token != "" {
+			key, err := base64.RawURLEncoding.DecodeString(token)
+			if err != nil {
+				s.writeS3Error(w, r, newS3Error(http.StatusBadRequest, constants.S3ErrInvalidArgument, "the continuation token is not valid"))
+				return
+			}
+			after = string(key)
+		}
+	}
+
+	var assets []services.NamedAsset
+	var truncated bool
+	if maxKeys > 0 {
+		var err error
+		assets, truncated, err = s.app.Services.Asset.ListObjects(bucket, prefix, after, maxKeys)
+		if err != nil {
+			s.writeS3Error(w, r, err)
+			return
+		}
+	}
+
+	contents := make([]s3Object, 0, len(assets))
+	for _, a := range assets {
+		contents = append(contents, s3Object{
+			Key:          encode(a.ObjectKey()),
+			LastModified: time.Unix(a.CreatedAt, 0).UTC().Format(constants.S3TimeFormat),
+			ETag:         assetETag(a.Hash),
+			Size:         a.Size,
+			StorageClass: constants.S3StorageClass,
+		})
+	}
+	lastKey := ""
+	if truncated {
+		lastKey = assets[len(assets)-1].ObjectKey()
+	}
+
+	if !v2 {
+		writeS3XML(w, s3ListBucketResult{
+			Xmlns:        constants.S3XMLNamespace,
+			Name:         bucket,
+			Prefix:       encode(prefix),
+			Marker:       encode(after),
+			NextMarker:   encode(lastKey),
+			MaxKeys:      maxKeys,
+			EncodingType: encodingType,
+			IsTruncated:  truncated,
+			Contents:     contents,
+		})
+		return
+	}
+	result := s3ListBucketV2Result{
+		Xmlns:             constants.S3XMLNamespace,
+		Name:              bucket,
+		Prefix:            encode(prefix),
+		StartAfter:        encode(q.Get("start-after")),
+		ContinuationToken: q.Get("continuation-token"),
+		KeyCount:          len(contents),
+		MaxKeys:           maxKeys,
+		EncodingType:      encodingType,
+		IsTruncated:       truncated,
+		Contents:          contents,
+	}
+	if truncated {
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+	}
+	writeS3XML(w, result)
+}
+
+// GET|HEAD /:bucket/:key - Read an object, or only its headers. A single
+// byte range is honoured; watermarks forced by the download grant apply
+// as they do to API downloads.
+func (s *Server) s3GetObject(w http.ResponseWriter, r *http.Request, identity *auth.Identity, bucket, key string) {
+	if err := s.s3Bucket(bucket); err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	asset, err := s.app.Services.Asset.ResolveObject(bucket, key)
+	if err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	result, err := s.s3Authorize(identity, &auth.ActionContext{
+		Action:      constants.AuthActionDownload,
+		TopicName:   bucket,
+		VolumeBytes: asset.Size,
+	})
+	if err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+
+	reader, err := s.app.Services.Asset.GetReader(asset.Hash)
+	if err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	defer reader.Close()
+	info := reader.Info
+
+	var body io.Reader = reader
+	size := info.Size
+	etag := assetETag(info.Hash)
+	profileName, err := s.downloadWatermark(r, identity, result)
+	if err != nil {
+		s.writeS3Error(w, r, newS3Error(http.StatusForbidden, constants.S3ErrAccessDenied, err.Error()))
+		return
+	}
+	if profileName != "" && s.app.Services.Watermark.Supports(info.ContentType) {
+		profile, err := s.app.Services.Watermark.Resolve(profileName)
+		if err != nil {
+			s.writeS3Error(w, r, err)
+			return
+		}
+		watermarked, err := s.app.Services.Watermark.Apply(profile, reader, info.Size, info.ContentType)
+		if err != nil {
+			s.writeS3Error(w, r, err)
+			return
+		}
+		body, size = bytes.NewReader(watermarked), int64(len(watermarked))
+		etag = assetETagVariant(info.Hash, profileName)
+		w.Header().Set(constants.HeaderXWatermark, profileName)
+	} else {
+		profileName = ""
+	}
+
+	// The key may later resolve to another asset, so unlike hash-addressed
+	// downloads the response is not cacheable as immutable
+	modTime := time.Unix(info.CreatedAt, 0)
+	w.Header().Set(constants.HeaderETag, etag)
+	w.Header().Set(constants.HeaderLastModified, modTime.UTC().Format(http.TimeFormat))
+	w.Header().Set(constants.HeaderContentType, info.ContentType)
+	w.Header().Set("Accept-Ranges", "bytes")
+	if checkNotModified(w, r, etag, modTime) {
+		return
+	}
+
+	status := http.StatusOK
+	start, length := int64(0), size
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		var ok bool
+		start, length, ok = parseS3Range(rangeHeader, size)
+		if !ok {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			s.writeS3Error(w, r, newS3Error(http.StatusRequestedRangeNotSatisfiable, constants.S3ErrInvalidRange, "the requested range is not satisfiable"))
+			return
+		}
+		status = http.StatusPartialContent
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, size))
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if _, err := io.CopyN(io.Discard, body, start); err != nil {
+		return
+	}
+	io.CopyN(w, body, length)
+
+	s.recordDownload(r, identity, info, key, profileName)
+}
+
+// parseS3Range parses a single "bytes=" range against an object of size
+// bytes, returning its start and length.
+func parseS3Range(header string, size int64) (start, length int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	first, last, found := strings.Cut(spec, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	end := size - 1
+	if first == "" {
+		// Suffix range: the last n bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		start = max(size-n, 0)
+	} else {
+		var err error
+		if start, err = strconv.ParseInt(first, 10, 64); err != nil || start < 0 || start >= size {
+			return 0, 0, false
+		}
+		if last != "" {
+			e, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || e < start {
+				return 0, 0, false
+			}
+			end = min(e, end)
+		}
+	}
+	return start, end - start + 1, size > 0
+}
+
+// PUT /:bucket/:key - Upload an object under a filename key
+func (s *Server) s3PutObject(w http.ResponseWriter, r *http.Request, identity *auth.Identity, signed *sigV4Request, bucket, key string) {
+	if err := s.s3Bucket(bucket); err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	if !services.IsObjectKey(key) {
+		s.writeS3Error(w, r, newS3Error(http.StatusBadRequest, constants.S3ErrInvalidArgument,
+			"object keys must be plain filenames without path components or characters that uploads rewrite"))
+		return
+	}
+
+	body, size, err := signed.payload(r)
+	if err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	if size < 0 {
+		s.writeS3Error(w, r, newS3Error(http.StatusLengthRequired, constants.S3ErrMissingContentLength, "you must provide the Content-Length HTTP header"))
+		return
+	}
+
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(key)), ".")
+	if _, err := s.s3Authorize(identity, &auth.ActionContext{
+		Action:    constants.AuthActionUpload,
+		TopicName: bucket,
+		Extension: ext,
+		FileSize:  size,
+	}); err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	if size > s.app.Config.StoragePolicy(ext).MaxSizeBytes {
+		s.writeS3Error(w, r, newS3Error(http.StatusBadRequest, constants.S3ErrEntityTooLarge, "your proposed upload exceeds the maximum allowed size"))
+		return
+	}
+	if _, err := services.CheckStorageHeadroom(s.app.Config.WorkingDirectory, s.app.Config.MaxDiskUsage, size+int64(constants.HeaderSize)); err != nil {
+		s.logger.Warn("S3 upload rejected, storage full: user=%s ip=%s required=%d %v",
+			getAuditUsername(identity), getClientIP(r), size, err)
+		s.writeS3Error(w, r, err)
+		return
+	}
+
+	result, err := s.app.Services.Asset.Upload(r.Context(), bucket, body, key, nil, "", getAuditUsername(identity))
+	if err != nil {
+		s.writeS3Error(w, r, err)
+		return
+	}
+	s.recordUpload(r, identity, bucket, key, "", result)
+
+	w.Header().Set(constants.HeaderETag, assetETag(result.Hash))
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeS3XML writes a 200 XML document.
+func writeS3XML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set(constants.HeaderContentType, "application/xml")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+// writeS3Error writes err as an S3 error document. Service errors are
+// mapped to the closest S3 error code.
+func (s *Server) writeS3Error(w http.ResponseWriter, r *http.Request, err error) {
+	var e *s3Error
+	if !errors.As(err, &e) {
+		e = s3ServiceError(err)
+	}
+	if e.Status >= http.StatusInternalServerError {
+		s.logger.Warn("S3 gateway: %s %s: %v", r.Method, r.URL.Path, err)
+	}
+
+	w.Header().Set(constants.HeaderContentType, "application/xml")
+	w.WriteHeader(e.Status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(s3ErrorResponse{
+		Code:      e.Code,
+		Message:   e.Message,
+		Resource:  r.URL.Path,
+		RequestID: w.Header().Get(requestIDHeaderKey),
+	})
+}
+
+// s3ServiceError maps a service error to an S3 error.
+func s3ServiceError(err error) *s3Error {
+	code, _ := services.IsServiceError(err)
+	status := serviceErrorStatus(code)
+	switch code {
+	case constants.ErrCodeTopicNotFound:
+		return newS3Error(http.StatusNotFound, constants.S3ErrNoSuchBucket, err.Error())
+	case constants.ErrCodeAssetNotFound:
+		return newS3Error(http.StatusNotFound, constants.S3ErrNoSuchKey, "the specified key does not exist")
+	case constants.ErrCodeAssetTooLarge:
+		return newS3Error(http.StatusBadRequest, constants.S3ErrEntityTooLarge, err.Error())
+	case constants.ErrCodeRetrievalRequired:
+		return newS3Error(http.StatusForbidden, constants.S3ErrInvalidObjectState, err.Error())
+	case constants.ErrCodeTopicFrozen:
+		return newS3Error(http.StatusForbidden, constants.S3ErrAccessDenied, err.Error())
+	case constants.ErrCodeTopicUnhealthy, constants.ErrCodeNotConfigured,
+		constants.ErrCodeStorageFull, constants.ErrCodeDiskLimitExceeded, constants.ErrCodeUploadScanFailed,
+		constants.ErrCodeUploadValidationFailed, constants.ErrCodeUploadIndexFailed:
+		return newS3Error(http.StatusServiceUnavailable, constants.S3ErrServiceUnavailable, err.Error())
+	}
+	switch {
+	case status == http.StatusForbidden || status == http.StatusTooManyRequests || status == http.StatusLocked:
+		return newS3Error(http.StatusForbidden, constants.S3ErrAccessDenied, err.Error())
+	case status < http.StatusInternalServerError:
+		return newS3Error(http.StatusBadRequest, constants.S3ErrInvalidRequest, err.Error())
+	default:
+		return newS3Error(http.StatusInternalServerError, constants.S3ErrInternalError, "we encountered an internal error, please try again")
+	}
+}
+
+// s3CredentialsResponse is the S3 gateway configuration of a newly issued
+// API key on a user's account.
+type s3CredentialsResponse struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	Region          string `json:"region"`
+	Endpoint        string `json:"endpoint"`
+}
+
+// issuedS3Credentials returns the S3 credentials of the API key just issued
+// on userID's account, or nil when the gateway is disabled. They are only
+// returned here, next to the key itself: like the key, they are shown once.
+func (s *Server) issuedS3Credentials(r *http.Request, userID int64) *s3CredentialsResponse {
+	cfg := s.app.Config
+	if !cfg.S3.Enabled {
+		return nil
+	}
+	creds, err := s.app.Services.Auth.S3Credentials(userID)
+	if err != nil {
+		s.logger.Error("Failed to derive S3 credentials for user %d: %v", userID, err)
+		return nil
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	endpoint := scheme + "://" + r.Host + strings.TrimSuffix(constants.S3PathPrefix, "/")
+	if cfg.S3.Port != 0 {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+		endpoint = scheme + "://" + net.JoinHostPort(host, strconv.Itoa(cfg.S3.Port))
+	}
+
+	return &s3CredentialsResponse{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		Region:          cfg.S3.Region,
+		Endpoint:        endpoint,
+	}
+}
@@ -0,0 +1,439 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// s3Error is an S3 API error, written as the XML error document S3
+// clients expect.
+type s3Error struct {
+	Status  int
+	Code    string // constants.S3Err*
+	Message string
+}
+
+func (e *s3Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func newS3Error(status int, code, message string) *s3Error {
+	return &s3Error{Status: status, Code: code, Message: message}
+}
+
+// emptySHA256 is the hex SHA-256 of an empty payload.
+var emptySHA256 = hex.EncodeToString(sha256.New().Sum(nil))
+
+// sigV4Authorization is the parsed Authorization header of a Signature
+// Version 4 request.
+type sigV4Authorization struct {
+	AccessKeyID   string
+	Date          string // YYYYMMDD of the credential scope
+	Region        string
+	Service       string
+	SignedHeaders []string
+	Signature     string
+}
+
+// scope returns the credential scope the request was signed for.
+func (a *sigV4Authorization) scope() string {
+	return a.Date + "/" + a.Region + "/" + a.Service + "/" + constants.S3ScopeTerminator
+}
+
+// parseSigV4Authorization parses
+// "AWS4-HMAC-SHA256 Credential=<key>/<date>/<region>/<service>/aws4_request,
+// SignedHeaders=<h1;h2>, Signature=<hex>".
+func parseSigV4Authorization(header string) (*sigV4Authorization, error) {
+	rest, ok := strings.CutPrefix(header, constants.S3SigningAlgorithm+" ")
+	if !ok {
+		return nil, fmt.Errorf("unsupported authorization type, expected %s", constants.S3SigningAlgorithm)
+	}
+
+	a := &sigV4Authorization{}
+	for _, part := range strings.Split(rest, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "Credential":
+			fields := strings.Split(value, "/")
+			if len(fields) != 5 || len(fields[1]) != len("20060102") || fields[4] != constants.S3ScopeTerminator {
+				return nil, fmt.Errorf("malformed credential %q", value)
+			}
+			a.AccessKeyID, a.Date, a.Region, a.Service = fields[0], fields[1], fields[2], fields[3]
+		case "SignedHeaders":
+			a.SignedHeaders = strings.Split(value, ";")
+		case "Signature":
+			a.Signature = value
+		}
+	}
+	if a.AccessKeyID == "" || len(a.SignedHeaders) == 0 || a.Signature == "" {
+		return nil, fmt.Errorf("authorization header is missing Credential, SignedHeaders or Signature")
+	}
+	if !slices.Contains(a.SignedHeaders, "host") {
+		return nil, fmt.Errorf("the host header must be signed")
+	}
+	return a, nil
+}
+
+// sigV4RequestTime returns the signing time of r from X-Amz-Date, or from
+// Date when it is absent.
+func sigV4RequestTime(r *http.Request) (time.Time, string, error) {
+	if amzDate := r.Header.Get(constants.S3HeaderDate); amzDate != "" {
+		t, err := time.Parse(constants.S3DateFormat, amzDate)
+		return t, amzDate, err
+	}
+	t, err := http.ParseTime(r.Header.Get("Date"))
+	if err != nil {
+		return t, "", err
+	}
+	return t, t.UTC().Format(constants.S3DateFormat), nil
+}
+
+// sigV4SigningKey derives the key signing requests of one day, region
+// and service from a secret access key.
+func sigV4SigningKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, constants.S3ScopeTerminator)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// sigV4CanonicalRequest builds the canonical form of r that is hashed into
+// the string to sign.
+func sigV4CanonicalRequest(r *http.Request, signedHeaders []string, payloadHash string) string {
+	var b strings.Builder
+	b.WriteString(r.Method + "\n")
+	b.WriteString(awsURIEncode(r.URL.Path, false) + "\n")
+	b.WriteString(sigV4CanonicalQuery(r.URL.RawQuery) + "\n")
+	for _, name := range signedHeaders {
+		b.WriteString(name + ":" + sigV4HeaderValue(r, name) + "\n")
+	}
+	b.WriteString("\n" + strings.Join(signedHeaders, ";") + "\n")
+	b.WriteString(payloadHash)
+	return b.String()
+}
+
+// sigV4CanonicalQuery encodes the query parameters sorted by name, then
+// value.
+func sigV4CanonicalQuery(rawQuery string) string {
+	values, _ := url.ParseQuery(rawQuery)
+	pairs := make([]string, 0, len(values))
+	for name, vs := range values {
+		for _, v := range vs {
+			pairs = append(pairs, awsURIEncode(name, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// sigV4HeaderValue returns the canonical value of a signed header: its
+// values joined by commas, with surrounding and repeated spaces removed.
+// Go moves Host and Content-Length out of the header map.
+func sigV4HeaderValue(r *http.Request, name string) string {
+	switch name {
+	case "host":
+		return r.Host
+	case "content-length":
+		if v := r.Header.Get("Content-Length"); v != "" {
+			return v
+		}
+		return strconv.FormatInt(r.ContentLength, 10)
+	}
+	values := slices.Clone(r.Header.Values(name))
+	for i, v := range values {
+		values[i] = strings.Join(strings.Fields(v), " ")
+	}
+	return strings.Join(values, ",")
+}
+
+// awsURIEncode percent-encodes s as Signature Version 4 requires: every
+// byte except unreserved characters, and slashes unless encodeSlash is
+// false.
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// sigV4Request is a request whose Signature Version 4 signature checked
+// out. Its body still has to be read through payload to be verified.
+type sigV4Request struct {
+	AccessKeyID string
+	signingKey  []byte
+	amzDate     string
+	scope       string
+	signature   string
+}
+
+// verifySigV4 checks the signature of r against the secret of the access
+// key it names. secretFor looks the secret up; a nil error from it is
+// required for the request to proceed.
+func verifySigV4(r *http.Request, region string, now time.Time, secretFor func(accessKeyID string) (string, error)) (*sigV4Request, error) {
+	header := r.Header.Get(constants.HeaderAuthorization)
+	if header == "" {
+		return nil, newS3Error(http.StatusForbidden, constants.S3ErrAccessDenied, "anonymous requests are not supported")
+	}
+	a, err := parseSigV4Authorization(header)
+	if err != nil {
+		return nil, newS3Error(http.StatusBadRequest, constants.S3ErrAuthorizationHeaderMalformed, err.Error())
+	}
+	if a.Service != constants.S3Service || a.Region != region {
+		return nil, newS3Error(http.StatusBadRequest, constants.S3ErrAuthorizationHeaderMalformed,
+			fmt.Sprintf("credential scope must be for service %s in region %s", constants.S3Service, region))
+	}
+
+	payloadHash := r.Header.Get(constants.S3HeaderContentSHA256)
+	if payloadHash == "" {
+		return nil, newS3Error(http.StatusBadRequest, constants.S3ErrMissingSecurityHeader, "missing "+constants.S3HeaderContentSHA256)
+	}
+
+	signedAt, amzDate, err := sigV4RequestTime(r)
+	if err != nil || !strings.HasPrefix(amzDate, a.Date) {
+		return nil, newS3Error(http.StatusForbidden, constants.S3ErrAccessDenied, "missing or invalid request date")
+	}
+	if skew := now.Sub(signedAt); skew > constants.S3MaxClockSkew || skew < -constants.S3MaxClockSkew {
+		return nil, newS3Error(http.StatusForbidden, constants.S3ErrRequestTimeTooSkewed,
+			"the difference between the request time and the server's time is too large")
+	}
+
+	secret, err := secretFor(a.AccessKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey := sigV4SigningKey(secret, a.Date, a.Region, a.Service)
+	canonical := sigV4CanonicalRequest(r, a.SignedHeaders, payloadHash)
+	stringToSign := strings.Join([]string{constants.S3SigningAlgorithm, amzDate, a.scope(), sha256Hex([]byte(canonical))}, "\n")
+	expected := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	if !hmac.Equal([]byte(expected), []byte(a.Signature)) {
+		return nil, newS3Error(http.StatusForbidden, constants.S3ErrSignatureMismatch,
+			"the request signature does not match the signature calculated with your secret key")
+	}
+
+	return &sigV4Request{
+		AccessKeyID: a.AccessKeyID,
+		signingKey:  signingKey,
+		amzDate:     amzDate,
+		scope:       a.scope(),
+		signature:   a.Signature,
+	}, nil
+}
+
+// payload returns the body of r decoded and verified as its
+// X-Amz-Content-Sha256 header declares, and the length of the decoded
+// body, or -1 when it is unknown. Verification failures surface as read
+// errors at the end of the body or of the failing chunk.
+func (v *sigV4Request) payload(r *http.Request) (io.Reader, int64, error) {
+	payloadHash := r.Header.Get(constants.S3HeaderContentSHA256)
+	switch payloadHash {
+	case constants.S3UnsignedPayload:
+		return r.Body, r.ContentLength, nil
+	case constants.S3StreamingPayload, constants.S3StreamingTrailer, constants.S3StreamingUnsigned:
+		decoded, err := strconv.ParseInt(r.Header.Get(constants.S3HeaderDecodedLength), 10, 64)
+		if err != nil || decoded < 0 {
+			return nil, 0, newS3Error(http.StatusLengthRequired, constants.S3ErrMissingContentLength,
+				"chunked uploads must declare "+constants.S3HeaderDecodedLength)
+		}
+		c := &awsChunkedReader{r: bufio.NewReaderSize(r.Body, constants.S3MaxChunkHeaderBytes), declared: decoded, hash: sha256.New()}
+		if payloadHash != constants.S3StreamingUnsigned {
+			c.signer = v
+			c.prevSignature = v.signature
+		}
+		return c, decoded, nil
+	}
+	if len(payloadHash) != sha256.Size*2 {
+		return nil, 0, newS3Error(http.StatusBadRequest, constants.S3ErrInvalidArgument, "invalid "+constants.S3HeaderContentSHA256)
+	}
+	return &sha256VerifyingReader{r: r.Body, hash: sha256.New(), expected: payloadHash}, r.ContentLength, nil
+}
+
+// sha256VerifyingReader fails at the end of the body unless its SHA-256
+// matches the signed payload hash.
+type sha256VerifyingReader struct {
+	r        io.Reader
+	hash     hash.Hash
+	expected string
+}
+
+func (s *sha256VerifyingReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.hash.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(s.hash.Sum(nil)) != s.expected {
+		return n, newS3Error(http.StatusBadRequest, constants.S3ErrContentSHA256Mismatch,
+			"the provided "+constants.S3HeaderContentSHA256+" does not match the payload")
+	}
+	return n, err
+}
+
+// awsChunkedReader decodes an aws-chunked body: chunks of
+// "<hex size>[;chunk-signature=<sig>]\r\n<data>\r\n" ending with an empty
+// chunk and optional trailing headers. With a signer every chunk signature
+// is checked, each chaining on the previous one from the seed signature of
+// the request. Trailing checksums are not verified.
+type awsChunkedReader struct {
+	r        *bufio.Reader
+	declared int64 // X-Amz-Decoded-Content-Length
+	total    int64
+
+	signer        *sigV4Request // nil for unsigned chunks
+	prevSignature string
+	signature     string // of the chunk being read
+	hash          hash.Hash
+
+	started   bool
+	remaining int64 // bytes left in the chunk being read
+	err       error
+}
+
+func (c *awsChunkedReader) Read(p []byte) (int, error) {
+	for c.err == nil && c.remaining == 0 {
+		c.err = c.nextChunk()
+	}
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.hash.Write(p[:n])
+	c.remaining -= int64(n)
+	c.total += int64(n)
+	if c.total > c.declared {
+		c.err = newS3Error(http.StatusBadRequest, constants.S3ErrIncompleteBody, "body is longer than "+constants.S3HeaderDecodedLength)
+		return n, c.err
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		c.err = err
+	}
+	return n, nil
+}
+
+// nextChunk finishes the chunk just read, if any, and starts the next
+// one. After the final chunk it returns io.EOF.
+func (c *awsChunkedReader) nextChunk() error {
+	if c.started {
+		if line, err := c.readLine(); err != nil || line != "" {
+			return c.malformed()
+		}
+		if err := c.verifyChunk(); err != nil {
+			return err
+		}
+	}
+	c.started = true
+
+	line, err := c.readLine()
+	if err != nil {
+		return c.malformed()
+	}
+	sizeField, ext, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(sizeField, 16, 64)
+	if err != nil || size < 0 {
+		return c.malformed()
+	}
+	c.signature, _ = strings.CutPrefix(ext, "chunk-signature=")
+	if c.signer != nil && c.signature == "" {
+		return newS3Error(http.StatusForbidden, constants.S3ErrSignatureMismatch, "chunk is not signed")
+	}
+	c.hash.Reset()
+	c.remaining = size
+	if size > 0 {
+		return nil
+	}
+
+	// The final chunk carries no data
+	if err := c.verifyChunk(); err != nil {
+		return err
+	}
+	for {
+		line, err := c.readLine()
+		if err == io.EOF || (err == nil && line == "") {
+			break
+		}
+		if err != nil {
+			return c.malformed()
+		}
+	}
+	if c.total != c.declared {
+		return newS3Error(http.StatusBadRequest, constants.S3ErrIncompleteBody, "body is shorter than "+constants.S3HeaderDecodedLength)
+	}
+	return io.EOF
+}
+
+// verifyChunk checks the signature of the chunk whose data was just read.
+func (c *awsChunkedReader) verifyChunk() error {
+	if c.signer == nil {
+		return nil
+	}
+	stringToSign := strings.Join([]string{
+		constants.S3ChunkAlgorithm,
+		c.signer.amzDate,
+		c.signer.scope,
+		c.prevSignature,
+		emptySHA256,
+		hex.EncodeToString(c.hash.Sum(nil)),
+	}, "\n")
+	expected := hex.EncodeToString(hmacSHA256(c.signer.signingKey, stringToSign))
+	if !hmac.Equal([]byte(expected), []byte(c.signature)) {
+		return newS3Error(http.StatusForbidden, constants.S3ErrSignatureMismatch, "chunk signature does not match")
+	}
+	c.prevSignature = c.signature
+	return nil
+}
+
+// readLine reads one CRLF-terminated line, refusing lines longer than the
+// reader's buffer.
+func (c *awsChunkedReader) readLine() (string, error) {
+	line, err := c.r.ReadSlice('\n')
+	if err != nil {
+		if err == io.EOF && len(line) == 0 {
+			return "", io.EOF
+		}
+		return "", c.malformed()
+	}
+	return string(bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))), nil
+}
+
+func (c *awsChunkedReader) malformed() error {
+	return newS3Error(http.StatusBadRequest, constants.S3ErrIncompleteBody, "malformed aws-chunked body")
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http"
+
+	"silobang/internal/auth"
+)
+
+// =============================================================================
+// Integrity Scrubber Handlers
+// =============================================================================
+
+// GET  /api/maintenance/scrub - Scrubber schedule, progress and damaged assets
+// POST /api/maintenance/scrub - Start a pass over all healthy topics now
+func (s *Server) handleScrub(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	if r.Method == http.MethodGet {
+		status, err := s.app.Services.Scrub.Status()
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, status)
+		return
+	}
+
+	username := getAuditUsername(identity)
+	if err := s.app.Services.Scrub.Trigger(username, getClientIP(r)); err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	s.logger.Info("Scrub pass started by %s", username)
+	WriteJSON(w, http.StatusAccepted, map[string]interface{}{
+		"started": true,
+	})
+}
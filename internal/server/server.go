@@ -2,25 +2,37 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"io/fs"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"time"
 
 	"silobang/internal/auth"
 	"silobang/internal/constants"
 	"silobang/internal/logger"
+	"silobang/plugin"
 )
 
 // Server wraps the HTTP server with graceful shutdown
 type Server struct {
 	httpServer      *http.Server
+	redirectServer  *http.Server // Plain HTTP listener redirecting to HTTPS, nil unless configured
+	s3Server        *http.Server // Dedicated S3 gateway listener, nil unless s3.port is set
 	app             *App
 	logger          *logger.Logger
 	webFS           fs.FS
 	downloadManager *DownloadSessionManager
+	progressHub     *ProgressHub
+	rateLimiter     *ipRateLimiter
+	requestLimiter  *ipRateLimiter // rate_limit buckets, per class and client
+	sseLimiter      *sseLimiter
+	routes          []route        // Declared API routes and their policies
+	faults          *faultInjector // nil unless built with -tags faultinject
 
 	// Pre-computed caches for immutable endpoints (schema, prompts list).
 	// Populated lazily on first successful request, never invalidated.
@@ -35,15 +47,21 @@ func NewServer(app *App, addr string, webFS fs.FS) *Server {
 	mux := http.NewServeMux()
 
 	s := &Server{
-		app:    app,
-		logger: app.Logger,
-		webFS:  webFS,
+		app:            app,
+		logger:         app.Logger,
+		webFS:          webFS,
+		progressHub:    NewProgressHub(),
+		rateLimiter:    newIPRateLimiter(),
+		requestLimiter: newIPRateLimiter(),
+		sseLimiter:     newSSELimiter(),
+		faults:         newFaultInjector(),
 	}
 
 	// Register routes
+	s.routes = s.routeTable()
 	s.registerRoutes(mux)
 
-	// Build middleware chain: RequestID → SecurityHeaders → GzipCompress → Authenticate → handler
+	// Build middleware chain: RequestID → SecurityHeaders → FaultInjection (test builds) → GzipCompress → Authenticate → UsageTracking → PublicRateLimit → RequestRateLimit → Idempotency → handler
 	// Auth middleware uses a dynamic store provider so it adapts when the auth
 	// system is initialised after server start (e.g. POST /api/config).
 	authMW := auth.NewMiddleware(func() *auth.Store {
@@ -52,19 +70,90 @@ func NewServer(app *App, addr string, webFS fs.FS) *Server {
 		}
 		return nil
 	}, app.Logger)
-	handler := Chain(mux, RequestID, SecurityHeaders, GzipCompress, authMW.Authenticate)
+	authMW.SetProviders(authProviders(app))
+	handler := Chain(mux, RequestID, SecurityHeaders, s.faultInjection, GzipCompress, authMW.Authenticate, s.usageTracking, s.publicRateLimit, s.requestRateLimit, s.idempotency)
 
 	// Start periodic reconciliation to detect manually-removed topic folders
 	if app.Services.Reconcile != nil {
 		app.Services.Reconcile.Start(time.Duration(constants.ReconcileIntervalMins) * time.Minute)
 	}
 
+	// Start scheduled compaction of .dat files holding deleted entries
+	if app.Services.Compaction != nil {
+		app.Services.Compaction.Start(time.Duration(constants.CompactionIntervalMins) * time.Minute)
+	}
+
+	// Start expiry of undecided deletion requests and resume approved ones
+	if app.Services.Deletions != nil {
+		app.Services.Deletions.Start(time.Duration(constants.DeletionRequestSweepIntervalMins) * time.Minute)
+	}
+
+	// Start scheduled archiving of idle assets and recall retries
+	if app.Services.Archive != nil {
+		app.Services.Archive.Start(time.Duration(constants.ArchiveIntervalMins) * time.Minute)
+	}
+
+	// Start scheduled purge of assets trashed longer than the retention
+	if app.Services.Trash != nil {
+		app.Services.Trash.Start(time.Duration(constants.TrashPurgeIntervalMins) * time.Minute)
+	}
+
+	// Start scheduled evaluation of the topic retention policies
+	if app.Services.Retention != nil {
+		app.Services.Retention.Start(time.Duration(constants.RetentionIntervalMins) * time.Minute)
+	}
+
+	// Start scheduled integrity scrubbing unless disabled
+	if app.Services.Scrub != nil && !app.Config.Scrub.Disabled {
+		app.Services.Scrub.Start(app.Config.Scrub.Interval())
+	}
+
+	// Start scheduled offload of sealed .dat files to blob stores
+	if app.Services.BlobStores != nil {
+		app.Services.BlobStores.Start(time.Duration(constants.BlobStoreOffloadIntervalMins) * time.Minute)
+	}
+
+	// HTTP/2 multiplexes the event streams of many dashboard tabs over one
+	// connection per client: negotiated over TLS, or cleartext with prior
+	// knowledge (e.g. from a reverse proxy) without a certificate.
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if !app.Config.HTTP.DisableHTTP2 {
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+	}
+
 	s.httpServer = &http.Server{
 		Addr:         addr,
 		Handler:      handler,
 		ReadTimeout:  0, // No timeout for streaming uploads
 		WriteTimeout: 0, // No timeout for streaming downloads
 		IdleTimeout:  constants.HTTPIdleTimeout,
+		Protocols:    protocols,
+		HTTP2: &http.HTTP2Config{
+			MaxConcurrentStreams: app.Config.HTTP.MaxConcurrentStreams,
+			SendPingTimeout:      constants.HTTP2PingInterval,
+		},
+	}
+
+	if redirectPort := app.Config.HTTP.RedirectHTTPPort; redirectPort != 0 && app.Config.HTTP.TLSEnabled() {
+		httpsPort := constants.HTTPSDefaultPort
+		if _, port, err := net.SplitHostPort(addr); err == nil {
+			httpsPort, _ = strconv.Atoi(port)
+		}
+		s.redirectServer = &http.Server{
+			Addr:        fmt.Sprintf(":%d", redirectPort),
+			Handler:     httpsRedirect(httpsPort),
+			IdleTimeout: constants.HTTPIdleTimeout,
+		}
+	}
+
+	if app.Config.S3.Enabled && app.Config.S3.Port != 0 {
+		s.s3Server = &http.Server{
+			Addr:        fmt.Sprintf(":%d", app.Config.S3.Port),
+			Handler:     s.s3Handler(),
+			IdleTimeout: constants.HTTPIdleTimeout,
+		}
 	}
 
 	return s
@@ -72,38 +161,13 @@ func NewServer(app *App, addr string, webFS fs.FS) *Server {
 
 // registerRoutes sets up all API routes
 func (s *Server) registerRoutes(mux *http.ServeMux) {
-	// API routes
-	mux.HandleFunc("/api/config", s.handleConfig)
-	mux.HandleFunc("/api/topics", s.handleTopics)
-	mux.HandleFunc("/api/topics/", s.handleTopicRoutes)
-	mux.HandleFunc("/api/assets/", s.handleAssetRoutes)
-	mux.HandleFunc("/api/queries", s.handleQueries)
-	mux.HandleFunc("/api/query/", s.handleQueryExecution)
-	mux.HandleFunc("/api/verify", s.handleVerify)
-	mux.HandleFunc("/api/download/bulk", s.handleBulkDownload)
-	mux.HandleFunc("/api/download/bulk/start", s.handleBulkDownloadSSE)
-	mux.HandleFunc("/api/download/bulk/", s.handleBulkDownloadFetch)
-
-	// Audit log routes
-	mux.HandleFunc("/api/audit", s.handleAuditQuery)
-	mux.HandleFunc("/api/audit/stream", s.handleAuditStream)
-	mux.HandleFunc("/api/audit/actions", s.handleAuditActions)
-
-	// Batch metadata routes
-	mux.HandleFunc("/api/metadata/batch", s.handleBatchMetadata)
-	mux.HandleFunc("/api/metadata/apply", s.handleApplyMetadata)
-
-	// API schema and prompts routes
-	mux.HandleFunc("/api/schema", s.handleSchema)
-	mux.HandleFunc("/api/prompts", s.handlePrompts)
-	mux.HandleFunc("/api/prompts/", s.handlePrompts)
-
-	// Auth routes
-	mux.HandleFunc("/api/auth/", s.handleAuthRoutes)
-
-	// Monitoring routes
-	mux.HandleFunc("/api/monitoring", s.handleMonitoring)
-	mux.HandleFunc("/api/monitoring/logs/", s.handleMonitoringLogFile)
+	// API routes, each behind its declared policy
+	for _, rt := range s.routes {
+		mux.HandleFunc(rt.Pattern, s.policyHandler(rt))
+	}
+
+	// Fault injection admin API (test builds only)
+	s.registerFaultRoutes(mux)
 
 	// Static files (frontend) with pre-compressed asset support.
 	// Serves brotli (.br) or gzip (.gz) variants when available and accepted by the client.
@@ -114,18 +178,65 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 
 // Start runs the server and blocks until shutdown signal
 func (s *Server) Start() error {
+	return s.Run(nil)
+}
+
+// Run serves until a shutdown signal arrives, done is closed, or the
+// listener fails, then shuts down gracefully. A nil done waits for signals
+// only; service managers that stop processes without signals close it.
+func (s *Server) Run(done <-chan struct{}) error {
 	// Channel for shutdown signals
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, shutdownSignals...)
+	defer signal.Stop(stop)
+
+	if s.app.Config.HTTP.TLSEnabled() {
+		if err := s.configureTLS(); err != nil {
+			return err
+		}
+	}
 
 	// Start server in goroutine
-	errChan := make(chan error, 1)
+	errChan := make(chan error, 3)
 	go func() {
-		s.logger.Info("Server listening on %s", s.httpServer.Addr)
-		if err := s.httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		var err error
+		if s.httpServer.TLSConfig != nil {
+			s.logger.Info("Server listening on %s (TLS)", s.httpServer.Addr)
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			s.logger.Info("Server listening on %s", s.httpServer.Addr)
+			err = s.httpServer.ListenAndServe()
+		}
+		if err != http.ErrServerClosed {
 			errChan <- err
 		}
 	}()
+	if s.redirectServer != nil {
+		go func() {
+			s.logger.Info("Redirecting HTTP on %s to HTTPS", s.redirectServer.Addr)
+			if err := s.redirectServer.ListenAndServe(); err != http.ErrServerClosed {
+				errChan <- err
+			}
+		}()
+	}
+
+	if s.s3Server != nil {
+		// The gateway shares the main listener's certificate
+		s.s3Server.TLSConfig = s.httpServer.TLSConfig
+		go func() {
+			var err error
+			if s.s3Server.TLSConfig != nil {
+				s.logger.Info("S3 gateway listening on %s (TLS)", s.s3Server.Addr)
+				err = s.s3Server.ListenAndServeTLS("", "")
+			} else {
+				s.logger.Info("S3 gateway listening on %s", s.s3Server.Addr)
+				err = s.s3Server.ListenAndServe()
+			}
+			if err != http.ErrServerClosed {
+				errChan <- err
+			}
+		}()
+	}
 
 	// Wait for shutdown signal or error
 	select {
@@ -133,6 +244,8 @@ func (s *Server) Start() error {
 		return err
 	case sig := <-stop:
 		s.logger.Info("Received signal %v, shutting down...", sig)
+	case <-done:
+		s.logger.Info("Stop requested, shutting down...")
 	}
 
 	// Graceful shutdown
@@ -142,12 +255,52 @@ func (s *Server) Start() error {
 	if err := s.httpServer.Shutdown(ctx); err != nil {
 		s.logger.Error("Shutdown error: %v", err)
 	}
+	if s.redirectServer != nil {
+		s.redirectServer.Shutdown(ctx)
+	}
+	if s.s3Server != nil {
+		s.s3Server.Shutdown(ctx)
+	}
+
+	// Disconnect progress WebSocket clients (hijacked connections are not
+	// closed by http.Server.Shutdown)
+	s.progressHub.CloseAll()
 
 	// Stop auth service cleanup goroutine
 	if s.app.Services.Auth != nil {
 		s.app.Services.Auth.Stop()
 	}
 
+	// Stop notification delivery goroutine
+	if s.app.Services.Notification != nil {
+		s.app.Services.Notification.Stop()
+	}
+
+	// Stop export inbox expiry goroutine
+	if s.app.Services.Export != nil {
+		s.app.Services.Export.Stop()
+	}
+
+	// Stop upload session expiry goroutine
+	if s.app.Services.Uploads != nil {
+		s.app.Services.Uploads.Stop()
+	}
+
+	// Stop watch folder scan goroutine
+	if s.app.Services.WatchFolders != nil {
+		s.app.Services.WatchFolders.Stop()
+	}
+
+	// Stop webhook delivery goroutine
+	if s.app.Services.Webhooks != nil {
+		s.app.Services.Webhooks.Stop()
+	}
+
+	// Stop rule scheduler goroutine
+	if s.app.Services.Rules != nil {
+		s.app.Services.Rules.Stop()
+	}
+
 	// Stop download manager cleanup goroutine
 	if s.downloadManager != nil {
 		s.downloadManager.Stop()
@@ -158,7 +311,47 @@ func (s *Server) Start() error {
 		s.app.Services.Reconcile.Stop()
 	}
 
-	// Stop audit logger cleanup goroutine
+	// Stop scheduled compaction goroutine
+	if s.app.Services.Compaction != nil {
+		s.app.Services.Compaction.Stop()
+	}
+
+	// Stop deletion request sweep goroutine
+	if s.app.Services.Deletions != nil {
+		s.app.Services.Deletions.Stop()
+	}
+
+	// Stop scheduled archiving goroutine
+	if s.app.Services.Archive != nil {
+		s.app.Services.Archive.Stop()
+	}
+
+	// Stop scheduled trash purge goroutine
+	if s.app.Services.Trash != nil {
+		s.app.Services.Trash.Stop()
+	}
+
+	// Stop scheduled retention goroutine
+	if s.app.Services.Retention != nil {
+		s.app.Services.Retention.Stop()
+	}
+
+	// Stop background preview rendering goroutine
+	if s.app.Services.Previews != nil {
+		s.app.Services.Previews.Stop()
+	}
+
+	// Stop scheduled scrubbing and cancel a running pass
+	if s.app.Services.Scrub != nil {
+		s.app.Services.Scrub.Stop()
+	}
+
+	// Stop scheduled offload goroutine
+	if s.app.Services.BlobStores != nil {
+		s.app.Services.BlobStores.Stop()
+	}
+
+	// Stop audit logger cleanup goroutine and flush queued entries
 	if s.app.AuditLogger != nil {
 		s.app.AuditLogger.Stop()
 	}
@@ -177,3 +370,18 @@ func (s *Server) Start() error {
 func (s *Server) Handler() http.Handler {
 	return s.httpServer.Handler
 }
+
+// authProviders creates the auth provider plugins of auth_providers. The
+// configuration was validated on load; a provider failing here is skipped.
+func authProviders(app *App) []auth.ExternalProvider {
+	var providers []auth.ExternalProvider
+	for i, p := range app.Config.AuthProviders {
+		provider, err := plugin.NewAuthProvider(p.Type, p.Options)
+		if err != nil {
+			app.Logger.Error("auth_providers[%d]: %v", i, err)
+			continue
+		}
+		providers = append(providers, auth.ExternalProvider{Type: p.Type, Provider: provider})
+	}
+	return providers
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Setup Wizard Handlers
+// =============================================================================
+
+// GET /api/setup/status - Setup steps and which remain (unauthenticated, so
+// the dashboard can start the wizard before any account exists)
+func (s *Server) handleSetupStatus(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	status, ok := s.setupStatus(w)
+	if !ok {
+		return
+	}
+	WriteSuccess(w, status)
+}
+
+// POST /api/setup/working-directory - Check and set the working directory.
+// Like POST /api/config, this needs no credentials until the working
+// directory exists, and manage_config afterwards. The first call bootstraps
+// the admin account and returns its credentials, shown only once.
+func (s *Server) handleSetupWorkingDirectory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Auth check: manage_config required (skip if auth not available — initial setup)
+	if s.isAuthAvailable() {
+		identity := s.requireAuth(w, r)
+		if identity == nil {
+			return
+		}
+		if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageConfig}) {
+			return
+		}
+	}
+
+	var req struct {
+		WorkingDirectory string `json:"working_directory"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	checks, err := s.app.Services.Setup.CheckWorkingDirectory(req.WorkingDirectory)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	response, ok := s.applyWorkingDirectory(w, r, req.WorkingDirectory)
+	if !ok {
+		return
+	}
+	response["checks"] = checks
+	s.writeSetupStep(w, response)
+}
+
+// POST /api/setup/credentials - Confirm the bootstrap credentials were saved
+// (requires manage_config)
+func (s *Server) handleSetupCredentials(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	var req struct {
+		Saved bool `json:"saved"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+	if !req.Saved {
+		WriteError(w, http.StatusBadRequest, "saved must be true once the credentials are stored safely", constants.ErrCodeInvalidSetupStep)
+		return
+	}
+
+	if err := s.app.Services.Setup.ConfirmCredentials(identity.User.ID); err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	s.writeSetupStep(w, map[string]interface{}{"success": true})
+}
+
+// POST /api/setup/topic - Create the first topic (requires manage_topics,
+// like POST /api/topics)
+func (s *Server) handleSetupTopic(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.app.Config.WorkingDirectory == "" {
+		WriteError(w, http.StatusConflict, "the working directory must be set first", constants.ErrCodeSetupStepBlocked)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if !s.addTopic(w, r, identity, req.Name) {
+		return
+	}
+	s.writeSetupStep(w, map[string]interface{}{
+		"success": true,
+		"name":    req.Name,
+	})
+}
+
+// POST /api/setup/tls - Save TLS settings, applied at the next restart, or
+// skip the step with {"skip": true} (requires manage_config)
+func (s *Server) handleSetupTLS(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	var req services.SetupTLSRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	before := s.app.Services.Config.Snapshot()
+	if err := s.app.Services.Setup.ConfigureTLS(req, identity.User.ID); err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	if !req.Skip {
+		s.app.Services.Config.RecordChange(getClientIP(r), getAuditUsername(identity), constants.ConfigChangeSourceAPI, before,
+			[]string{"http"})
+	}
+
+	s.writeSetupStep(w, map[string]interface{}{
+		"success":          true,
+		"requires_restart": !req.Skip,
+	})
+}
+
+// setupStatus returns the setup status, or writes the error.
+func (s *Server) setupStatus(w http.ResponseWriter) (*services.SetupStatus, bool) {
+	tlsActive := s.httpServer != nil && s.httpServer.TLSConfig != nil
+	status, err := s.app.Services.Setup.Status(tlsActive)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return nil, false
+	}
+	return status, true
+}
+
+// writeSetupStep writes a completed step's response with the updated setup
+// status, so the wizard can move on without polling.
+func (s *Server) writeSetupStep(w http.ResponseWriter, response map[string]interface{}) {
+	status, ok := s.setupStatus(w)
+	if !ok {
+		return
+	}
+	response["setup"] = status
+	WriteSuccess(w, response)
+}
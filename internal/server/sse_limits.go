@@ -0,0 +1,132 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// sseStream identifies one open SSE stream for the counters.
+type sseStream struct {
+	endpoint string
+	client   int64
+	protocol string
+}
+
+// sseLimiter counts open SSE streams per endpoint, user and protocol, and
+// enforces the server-wide and per-user caps of the http config.
+type sseLimiter struct {
+	mu         sync.Mutex
+	open       int
+	byEndpoint map[string]int
+	byClient   map[int64]int
+	byProtocol map[string]int
+	rejected   uint64
+}
+
+// newSSELimiter creates a limiter with no open streams.
+func newSSELimiter() *sseLimiter {
+	return &sseLimiter{
+		byEndpoint: make(map[string]int),
+		byClient:   make(map[int64]int),
+		byProtocol: make(map[string]int),
+	}
+}
+
+// acquire opens a stream slot. It returns false with the cap that was hit,
+// server-wide or per user, when there is no room.
+func (l *sseLimiter) acquire(st sseStream, maxOpen, maxPerClient int) (ok, serverFull bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.open >= maxOpen {
+		l.rejected++
+		return false, true
+	}
+	if l.byClient[st.client] >= maxPerClient {
+		l.rejected++
+		return false, false
+	}
+	l.open++
+	l.byEndpoint[st.endpoint]++
+	l.byClient[st.client]++
+	l.byProtocol[st.protocol]++
+	return true, false
+}
+
+// release closes a slot taken by acquire.
+func (l *sseLimiter) release(st sseStream) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.open--
+	decrement(l.byEndpoint, st.endpoint)
+	decrement(l.byClient, st.client)
+	decrement(l.byProtocol, st.protocol)
+}
+
+// decrement lowers m[key], dropping keys that reach zero.
+func decrement[K comparable](m map[K]int, key K) {
+	if m[key] <= 1 {
+		delete(m, key)
+		return
+	}
+	m[key]--
+}
+
+// stats returns the open stream counts.
+func (l *sseLimiter) stats() services.StreamStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	st := services.StreamStats{
+		Open:       l.open,
+		ByEndpoint: make(map[string]int, len(l.byEndpoint)),
+		ByProtocol: make(map[string]int, len(l.byProtocol)),
+		Rejected:   l.rejected,
+	}
+	for k, v := range l.byEndpoint {
+		st.ByEndpoint[k] = v
+	}
+	for k, v := range l.byProtocol {
+		st.ByProtocol[k] = v
+	}
+	return st
+}
+
+// acquireSSE reserves a stream slot for an SSE request before any event is
+// written. When a cap is reached it writes the rejection and returns nil:
+// 429 when the user holds too many streams, 503 with Retry-After when the
+// server does. The caller must call the returned release when the stream ends.
+func (s *Server) acquireSSE(w http.ResponseWriter, r *http.Request, endpoint string, identity *auth.Identity) func() {
+	cfg := s.app.Config.HTTP
+	st := sseStream{endpoint: endpoint, client: identity.User.ID, protocol: r.Proto}
+
+	ok, serverFull := s.sseLimiter.acquire(st, cfg.MaxSSEConnections, cfg.MaxSSEPerClient)
+	if !ok {
+		if serverFull {
+			s.logger.Warn("SSE: rejected %s stream for user %d, %d streams open", endpoint, identity.User.ID, cfg.MaxSSEConnections)
+			w.Header().Set(constants.HeaderRetryAfter, strconv.Itoa(constants.SSERetryAfterSecs))
+			WriteError(w, http.StatusServiceUnavailable, "Too many open event streams", constants.ErrCodeTooManyConnections)
+		} else {
+			WriteError(w, http.StatusTooManyRequests, "Too many event streams for this user", constants.ErrCodeTooManyConnections)
+		}
+		return nil
+	}
+	return func() { s.sseLimiter.release(st) }
+}
+
+// streamStats reports the open SSE streams with the configured caps.
+func (s *Server) streamStats() *services.StreamStats {
+	cfg := s.app.Config.HTTP
+	st := s.sseLimiter.stats()
+	st.MaxOpen = cfg.MaxSSEConnections
+	st.MaxPerClient = cfg.MaxSSEPerClient
+	st.HTTP2Enabled = !cfg.DisableHTTP2
+	st.TLSEnabled = cfg.TLSEnabled()
+	return &st
+}
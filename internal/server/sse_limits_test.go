@@ -0,0 +1,41 @@
+package server
+
+import (
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestSSELimiter_CapsAndRelease(t *testing.T) {
+	l := newSSELimiter()
+	alice := sseStream{endpoint: constants.SSEEndpointAuditStream, client: 1, protocol: "HTTP/2.0"}
+	bob := sseStream{endpoint: constants.SSEEndpointVerify, client: 2, protocol: "HTTP/1.1"}
+
+	if ok, _ := l.acquire(alice, 2, 1); !ok {
+		t.Fatal("first stream should be allowed")
+	}
+	if ok, serverFull := l.acquire(alice, 2, 1); ok || serverFull {
+		t.Fatalf("second stream of the same user should hit the per-user cap, got ok=%v serverFull=%v", ok, serverFull)
+	}
+	if ok, _ := l.acquire(bob, 2, 1); !ok {
+		t.Fatal("another user should have their own allowance")
+	}
+	if ok, serverFull := l.acquire(sseStream{client: 3}, 2, 1); ok || !serverFull {
+		t.Fatalf("third user should hit the server-wide cap, got ok=%v serverFull=%v", ok, serverFull)
+	}
+
+	st := l.stats()
+	if st.Open != 2 || st.Rejected != 2 || st.ByEndpoint[constants.SSEEndpointVerify] != 1 || st.ByProtocol["HTTP/2.0"] != 1 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+
+	l.release(alice)
+	if ok, _ := l.acquire(alice, 2, 1); !ok {
+		t.Fatal("a released slot should be reusable")
+	}
+	l.release(alice)
+	l.release(bob)
+	if st := l.stats(); st.Open != 0 || len(st.ByEndpoint) != 0 || len(st.ByProtocol) != 0 {
+		t.Errorf("expected no open streams, got %+v", st)
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Single Sign-On Endpoints (OpenID Connect)
+// =============================================================================
+
+// GET /api/auth/oidc/login?redirect=/path — Redirect the browser to the
+// identity provider. redirect is the local path returned to after sign-in.
+func (s *Server) handleSSOLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.isAuthAvailable() {
+		WriteError(w, http.StatusServiceUnavailable, "Auth system not available", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	target, err := s.app.Services.Auth.BeginSSOLogin(r.Context(), r.URL.Query().Get("redirect"))
+	if err != nil {
+		s.logger.Warn("Auth: SSO sign-in could not start: %v", err)
+		s.handleServiceError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// GET /api/auth/oidc/callback — The identity provider's redirect back.
+// Redirects the browser to the sign-in's return path with a one-time login
+// code (#sso_code=...) or an error code (#sso_error=...) in the fragment.
+func (s *Server) handleSSOCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.isAuthAvailable() {
+		WriteError(w, http.StatusServiceUnavailable, "Auth system not available", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	q := r.URL.Query()
+	providerError := q.Get("error")
+	if desc := q.Get("error_description"); providerError != "" && desc != "" {
+		providerError += ": " + desc
+	}
+
+	result, err := s.app.Services.Auth.CompleteSSOLogin(r.Context(), q.Get("state"), q.Get("code"), providerError)
+	if err != nil {
+		code, ok := services.IsServiceError(err)
+		if !ok {
+			code = constants.ErrCodeInternalError
+		}
+		s.logger.Warn("Auth: SSO sign-in failed for subject=%q: %v", result.Subject, err)
+		if s.app.AuditLogger != nil {
+			s.app.AuditLogger.Log(constants.AuditActionLoginFailed, getClientIP(r), result.Username, audit.LoginFailedDetails{
+				AttemptedUsername: result.Username,
+				Reason:            code,
+				UserAgent:         r.UserAgent(),
+				Method:            constants.OIDCLoginMethod,
+			})
+		}
+		http.Redirect(w, r, result.ReturnTo+"#"+constants.OIDCErrorFragment+"="+url.QueryEscape(code), http.StatusFound)
+		return
+	}
+
+	if result.Provisioned && s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionUserCreated, getClientIP(r), result.Username, audit.UserCreatedDetails{
+			CreatedUserID:   result.UserID,
+			CreatedUsername: result.Username,
+		})
+	}
+
+	http.Redirect(w, r, result.ReturnTo+"#"+constants.OIDCCodeFragment+"="+url.QueryEscape(result.Code), http.StatusFound)
+}
+
+// POST /api/auth/oidc/exchange — Exchange a one-time login code for a
+// session token, answering like /api/auth/login
+func (s *Server) handleSSOExchange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.isAuthAvailable() {
+		WriteError(w, http.StatusServiceUnavailable, "Auth system not available", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+	if req.Code == "" {
+		WriteError(w, http.StatusBadRequest, "Login code is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	token, user, err := s.app.Services.Auth.ExchangeSSOCode(req.Code, getClientIP(r), r.UserAgent())
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionLoginSuccess, getClientIP(r), user.Username, audit.LoginSuccessDetails{
+			UserAgent: r.UserAgent(),
+			Method:    constants.OIDCLoginMethod,
+		})
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"token": token,
+		"user":  user,
+	})
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"silobang/internal/auth"
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+// GET /api/storage-policies - Configured per-extension storage policies
+func (s *Server) handleStoragePolicies(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	WriteSuccess(w, s.app.Services.Policy.List())
+}
+
+// GET /api/storage-policies/:ext - Effective policy of an extension
+// PUT /api/storage-policies/:ext - Set the extension's policy (requires manage_config)
+// DELETE /api/storage-policies/:ext - Remove it (requires manage_config)
+//
+// The extension "*" is the fallback for extensions without a policy.
+func (s *Server) handleStoragePolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	ext := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/storage-policies/"), "/")
+	if ext == "" {
+		WriteError(w, http.StatusBadRequest, "Extension is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		WriteSuccess(w, s.app.Services.Policy.Effective(ext))
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageConfig}) {
+		return
+	}
+
+	before := s.app.Services.Config.Snapshot()
+	if r.Method == http.MethodDelete {
+		if err := s.app.Services.Policy.Delete(ext); err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		s.auditStoragePolicyChange(r, identity, ext, before)
+		WriteSuccess(w, map[string]interface{}{
+			"deleted": ext,
+		})
+		return
+	}
+
+	var req config.StoragePolicyConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	policy, err := s.app.Services.Policy.Set(ext, req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	s.auditStoragePolicyChange(r, identity, policy.Extension, before)
+
+	WriteSuccess(w, map[string]interface{}{
+		"policy": policy,
+	})
+}
+
+// auditStoragePolicyChange records a policy change as a config change.
+func (s *Server) auditStoragePolicyChange(r *http.Request, identity *auth.Identity, ext string, before map[string]interface{}) {
+	s.app.Services.Config.RecordChange(getClientIP(r), getAuditUsername(identity), constants.ConfigChangeSourceAPI, before,
+		[]string{"storage_policies." + strings.ToLower(strings.TrimPrefix(ext, "."))})
+}
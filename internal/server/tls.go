@@ -0,0 +1,212 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+// certReloader serves the certificate of a cert/key file pair, reloading
+// it when either file changes so renewed certificates apply to new
+// connections without a restart.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration // Files are checked for changes at most this often
+	logger   *logger.Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+	checked time.Time
+}
+
+// newCertReloader loads the pair, failing when it cannot be used.
+func newCertReloader(certFile, keyFile string, log *logger.Logger) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile, interval: constants.TLSReloadCheckInterval, logger: log}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load reads the pair and records the modification times it was read at.
+func (c *certReloader) load() error {
+	certMod, keyMod, err := c.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	c.cert, c.certMod, c.keyMod = &cert, certMod, keyMod
+	return nil
+}
+
+func (c *certReloader) modTimes() (certMod, keyMod time.Time, err error) {
+	certInfo, err := os.Stat(c.certFile)
+	if err != nil {
+		return certMod, keyMod, fmt.Errorf("failed to read TLS certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(c.keyFile)
+	if err != nil {
+		return certMod, keyMod, fmt.Errorf("failed to read TLS key: %w", err)
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+// GetCertificate is the tls.Config callback. A pair that fails to load,
+// for instance while only one file has been replaced, keeps the previous
+// certificate in use until the next check.
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checked) < c.interval {
+		return c.cert, nil
+	}
+	c.checked = time.Now()
+
+	certMod, keyMod, err := c.modTimes()
+	if err != nil || (certMod.Equal(c.certMod) && keyMod.Equal(c.keyMod)) {
+		return c.cert, nil
+	}
+	if err := c.load(); err != nil {
+		c.logger.Warn("TLS: keeping the current certificate: %v", err)
+		return c.cert, nil
+	}
+	c.logger.Info("TLS: reloaded certificate from %s", c.certFile)
+	return c.cert, nil
+}
+
+// ensureSelfSignedCert writes a self-signed certificate and key for hosts
+// unless the existing pair is valid for longer than the renewal margin.
+// It reports whether a new pair was written.
+func ensureSelfSignedCert(certFile, keyFile string, hosts []string) (bool, error) {
+	if pair, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil && pair.Leaf != nil &&
+		time.Until(pair.Leaf.NotAfter) > constants.TLSSelfSignedRenewBefore {
+		return false, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return false, fmt.Errorf("failed to generate TLS key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return false, fmt.Errorf("failed to generate certificate serial: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{constants.AppDisplayName}, CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour), // Tolerates clients with a slow clock
+		NotAfter:              now.Add(constants.TLSSelfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode TLS key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certFile), constants.DirPermissions); err != nil {
+		return false, fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+	// The key is written first: the reloader only picks up a pair once the
+	// certificate matching it is in place
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), constants.SecretFilePermissions); err != nil {
+		return false, fmt.Errorf("failed to write TLS key: %w", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), constants.FilePermissions); err != nil {
+		return false, fmt.Errorf("failed to write TLS certificate: %w", err)
+	}
+	return true, nil
+}
+
+// selfSignedHosts lists the names a self-signed certificate is issued for:
+// this machine's hostname and the loopback addresses.
+func selfSignedHosts() []string {
+	hosts := []string{"localhost"}
+	if name, err := os.Hostname(); err == nil && name != "" && name != "localhost" {
+		hosts = append(hosts, name)
+	}
+	return append(hosts, "127.0.0.1", "::1")
+}
+
+// configureTLS loads the configured or self-signed certificate into the
+// server's TLS config.
+func (s *Server) configureTLS() error {
+	cfg := s.app.Config.HTTP
+	certFile, keyFile := cfg.TLSFiles()
+
+	if cfg.TLSSelfSigned {
+		generated, err := ensureSelfSignedCert(certFile, keyFile, selfSignedHosts())
+		if err != nil {
+			return err
+		}
+		if generated {
+			s.logger.Info("TLS: generated self-signed certificate %s", certFile)
+		}
+	}
+
+	reloader, err := newCertReloader(certFile, keyFile, s.logger)
+	if err != nil {
+		return err
+	}
+	s.httpServer.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	return nil
+}
+
+// httpsRedirect redirects every request to the same URL over HTTPS on
+// httpsPort. 308 keeps the method and body of non-GET requests.
+func httpsRedirect(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+		if httpsPort != constants.HTTPSDefaultPort {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+func TestEnsureSelfSignedCert_GeneratesOnceAndLoads(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls", constants.TLSSelfSignedCertFile)
+	keyFile := filepath.Join(dir, "tls", constants.TLSSelfSignedKeyFile)
+
+	generated, err := ensureSelfSignedCert(certFile, keyFile, []string{"localhost", "127.0.0.1"})
+	if err != nil || !generated {
+		t.Fatalf("expected a certificate to be generated, got %v %v", generated, err)
+	}
+	if info, err := os.Stat(keyFile); err != nil || info.Mode().Perm() != constants.SecretFilePermissions {
+		t.Errorf("expected the key to be private, got %v %v", info, err)
+	}
+
+	if generated, err := ensureSelfSignedCert(certFile, keyFile, []string{"localhost"}); err != nil || generated {
+		t.Errorf("expected the valid certificate to be reused, got %v %v", generated, err)
+	}
+
+	reloader, err := newCertReloader(certFile, keyFile, logger.NewLogger(logger.LevelError))
+	if err != nil {
+		t.Fatalf("failed to load generated pair: %v", err)
+	}
+	leaf := reloader.cert.Leaf
+	if leaf == nil || len(leaf.IPAddresses) != 1 || leaf.DNSNames[0] != "localhost" {
+		t.Errorf("unexpected certificate: %+v", leaf)
+	}
+}
+
+func TestCertReloader_PicksUpReplacedFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if _, err := ensureSelfSignedCert(certFile, keyFile, []string{"first.example"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reloader, err := newCertReloader(certFile, keyFile, logger.NewLogger(logger.LevelError))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloader.interval = 0
+
+	// Replace the pair, as a certificate renewal would
+	os.Remove(certFile)
+	if _, err := ensureSelfSignedCert(certFile, keyFile, []string{"second.example"}); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+
+	cert, err := reloader.GetCertificate(nil)
+	if err != nil || cert.Leaf.DNSNames[0] != "second.example" {
+		t.Fatalf("expected the replaced certificate, got %v %v", cert.Leaf.DNSNames, err)
+	}
+
+	// A broken pair keeps the current certificate
+	os.WriteFile(certFile, []byte("not a certificate"), constants.FilePermissions)
+	later = later.Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	if cert, err := reloader.GetCertificate(nil); err != nil || cert.Leaf.DNSNames[0] != "second.example" {
+		t.Errorf("expected the current certificate to be kept, got %v %v", cert, err)
+	}
+}
+
+func TestHTTPSRedirect(t *testing.T) {
+	cases := []struct {
+		port     int
+		host     string
+		target   string
+		location string
+	}{
+		{8443, "assets.example:8080", "/api/topics?x=1", "https://assets.example:8443/api/topics?x=1"},
+		{443, "assets.example", "/", "https://assets.example/"},
+		{443, "[::1]:80", "/a", "https://[::1]/a"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPost, c.target, nil)
+		r.Host = c.host
+		w := httptest.NewRecorder()
+		httpsRedirect(c.port).ServeHTTP(w, r)
+
+		if w.Code != http.StatusPermanentRedirect || w.Header().Get("Location") != c.location {
+			t.Errorf("%s%s: expected 308 to %s, got %d %s", c.host, c.target, c.location, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+)
+
+// =============================================================================
+// Topic Freeze Handlers
+// =============================================================================
+
+// POST /api/topics/:name/freeze and /api/topics/:name/unfreeze - Make a
+// topic read-only or writable again (requires manage_topics with
+// can_freeze). Frozen topics refuse uploads and metadata writes with 409
+// TOPIC_FROZEN; downloads and queries keep working. Audited as
+// config_changed when the state changes.
+func (s *Server) handleTopicFreeze(w http.ResponseWriter, r *http.Request, topicName string, frozen bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageTopics,
+		SubAction: "freeze",
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	before := s.app.Services.Config.Snapshot()
+	changed, err := s.app.Services.Freeze.SetFrozen(topicName, frozen)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	if changed {
+		s.app.Services.Config.RecordChange(getClientIP(r), getAuditUsername(identity), constants.ConfigChangeSourceAPI, before,
+			[]string{"frozen_topics"})
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"topic":   topicName,
+		"frozen":  frozen,
+		"changed": changed,
+	})
+}
+
+// rejectFrozenTopics refuses a metadata write touching a frozen topic with
+// 409 TOPIC_FROZEN before anything is written. It reports whether the
+// request was rejected.
+func (s *Server) rejectFrozenTopics(w http.ResponseWriter, grouped []database.GroupedOperations) bool {
+	for _, group := range grouped {
+		if s.app.Config.IsTopicFrozen(group.Topic) {
+			WriteError(w, http.StatusConflict, "Topic "+group.Topic+" is frozen", constants.ErrCodeTopicFrozen)
+			return true
+		}
+	}
+	return false
+}
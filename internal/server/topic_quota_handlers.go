@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Topic Quota Handlers
+// =============================================================================
+
+// PATCH /api/topics/:name - Change topic settings (requires manage_config).
+// Body {"quota": {soft_bytes, hard_bytes, soft_assets, hard_assets}}: set
+// limits replace the current ones, 0 removes a limit and omitted ones are
+// kept. Uploads past a hard limit are refused with 413
+// TOPIC_QUOTA_EXCEEDED. Audited as config_changed.
+func (s *Server) patchTopic(w http.ResponseWriter, r *http.Request, topicName string) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageConfig}) {
+		return
+	}
+
+	var req struct {
+		Quota *services.TopicQuotaPatch `json:"quota"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+	if req.Quota == nil {
+		WriteError(w, http.StatusBadRequest, "quota is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	before := s.app.Services.Config.Snapshot()
+	usage, err := s.app.Services.Quotas.Set(topicName, *req.Quota)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	s.app.Services.Config.RecordChange(getClientIP(r), getAuditUsername(identity), constants.ConfigChangeSourceAPI, before,
+		[]string{"topic_quotas." + topicName})
+
+	// Dashboards read quota usage from the topic stats
+	s.app.Services.StatsCache.InvalidateTopic(topicName)
+
+	WriteSuccess(w, map[string]interface{}{
+		"topic": topicName,
+		"quota": usage,
+	})
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Trash Handlers
+// =============================================================================
+
+// GET /api/trash - Trashed assets, most recently trashed first (requires
+// manage_topics delete). Query params: topic.
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	entries, err := s.app.Services.Trash.List(r.URL.Query().Get("topic"))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"entries":        entries,
+		"retention_days": s.app.Config.Trash.RetentionDays,
+	})
+}
+
+// POST   /api/trash/:hash/restore - Take an asset out of the trash
+// DELETE /api/trash/:hash         - Delete a trashed asset permanently
+// Both require manage_topics delete on the asset's topic.
+func (s *Server) handleTrashRoutes(w http.ResponseWriter, r *http.Request) {
+	hash, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/trash/"), "/")
+
+	switch {
+	case action == "" && r.Method == http.MethodDelete:
+	case action == "restore" && r.Method == http.MethodPost:
+	case action == "" || action == "restore":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	entry, err := s.app.Services.Trash.Get(hash)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageTopics,
+		SubAction: "delete",
+		TopicName: entry.Topic,
+	}) {
+		return
+	}
+
+	if action == "restore" {
+		restored, err := s.app.Services.Trash.Restore(hash, getAuditUsername(identity), getClientIP(r))
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, restored)
+		return
+	}
+
+	result, err := s.app.Services.Trash.Purge(hash, getAuditUsername(identity), getClientIP(r))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	WriteSuccess(w, result)
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/sanitize"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Multi-File Upload Handlers
+// =============================================================================
+
+// UploadFileResult is the outcome of one file of a multi-file upload.
+type UploadFileResult struct {
+	Filename      string `json:"filename"`
+	RelativePath  string `json:"relative_path,omitempty"`
+	Success       bool   `json:"success"`
+	Status        string `json:"status"` // created, deduplicated, aliased or rejected
+	Hash          string `json:"hash,omitempty"`
+	Size          int64  `json:"size,omitempty"`
+	ExistingTopic string `json:"existing_topic,omitempty"`
+	Quarantined   bool   `json:"quarantined,omitempty"`
+	Error         string `json:"error,omitempty"`
+	Code          string `json:"code,omitempty"`
+}
+
+// UploadBatchResponse is the response of a multi-file upload.
+type UploadBatchResponse struct {
+	Success   bool               `json:"success"`
+	Total     int                `json:"total"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []UploadFileResult `json:"results"`
+}
+
+// uploadAssetBatch stores the files of an upload request carrying several
+// "file" parts. relative_path, when sent, is repeated once per file in the
+// same order; an empty value leaves that file without a folder path. Each
+// file is checked against the upload grant and stored on its own, and one
+// file failing does not stop the others.
+func (s *Server) uploadAssetBatch(ctx context.Context, w http.ResponseWriter, r *http.Request, identity *auth.Identity, topicName string, headers []*multipart.FileHeader) {
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionUpload,
+		TopicName: topicName,
+	}) {
+		return
+	}
+
+	if len(headers) > constants.MaxUploadBatchFiles {
+		writeUploadRejected(w, http.StatusBadRequest,
+			fmt.Sprintf("Too many files: at most %d per request", constants.MaxUploadBatchFiles), constants.ErrCodeInvalidRequest)
+		return
+	}
+	paths := r.MultipartForm.Value[constants.FormFieldRelativePath]
+	if len(paths) > 0 && len(paths) != len(headers) {
+		writeUploadRejected(w, http.StatusBadRequest, "relative_path must be sent once per file", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	var parentID *string
+	if pid := r.FormValue(constants.FormFieldParentID); pid != "" {
+		parentID = &pid
+	}
+
+	response := UploadBatchResponse{Total: len(headers), Results: make([]UploadFileResult, 0, len(headers))}
+	for i, header := range headers {
+		if ctx.Err() != nil {
+			return // client went away or cancelled the upload
+		}
+		var rawPath string
+		if len(paths) > 0 {
+			rawPath = paths[i]
+		}
+		result := s.uploadBatchFile(ctx, r, identity, topicName, header, parentID, rawPath)
+		if result.Success {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+		response.Results = append(response.Results, result)
+	}
+	response.Success = response.Failed == 0
+
+	WriteSuccess(w, response)
+}
+
+// uploadBatchFile stores one file of a multi-file upload.
+func (s *Server) uploadBatchFile(ctx context.Context, r *http.Request, identity *auth.Identity, topicName string, header *multipart.FileHeader, parentID *string, rawPath string) UploadFileResult {
+	result := UploadFileResult{Filename: header.Filename, Status: constants.UploadStatusRejected}
+	reject := func(err error) UploadFileResult {
+		result.Error, result.Code = bulkErrorParts(err)
+		return result
+	}
+
+	var relativePath string
+	if rawPath != "" {
+		if relativePath = sanitize.RelativePath(rawPath); relativePath == "" {
+			result.Error, result.Code = "Invalid relative_path", constants.ErrCodeInvalidRequest
+			return result
+		}
+	}
+
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	policy := s.app.Services.Auth.GetEvaluator().Evaluate(identity, &auth.ActionContext{
+		Action:    constants.AuthActionUpload,
+		TopicName: topicName,
+		Extension: ext,
+		FileSize:  header.Size,
+	})
+	if !policy.Allowed {
+		result.Error, result.Code = policy.Reason, policy.DeniedCode
+		return result
+	}
+	if header.Size > s.app.Config.StoragePolicy(ext).MaxSizeBytes {
+		return reject(services.ErrAssetTooLarge)
+	}
+	if _, err := services.CheckStorageHeadroom(s.app.Config.WorkingDirectory, s.app.Config.MaxDiskUsage, header.Size+int64(constants.HeaderSize)); err != nil {
+		return reject(err)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return reject(services.WrapInternalError(err))
+	}
+	defer file.Close()
+
+	upload, err := s.app.Services.Asset.Upload(ctx, topicName, file, header.Filename, parentID, relativePath, getAuditUsername(identity))
+	if err != nil {
+		return reject(err)
+	}
+	if upload.Status != constants.UploadStatusCreated {
+		relativePath = ""
+	}
+	s.recordUpload(r, identity, topicName, header.Filename, relativePath, upload)
+
+	result.Success = true
+	result.RelativePath = relativePath
+	result.Status = upload.Status
+	result.Hash = upload.Hash
+	result.Size = upload.Size
+	result.ExistingTopic = upload.ExistingTopic
+	result.Quarantined = upload.Quarantined
+	return result
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/sanitize"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Resumable Upload Handlers
+// =============================================================================
+
+// uploadSessionRequest is the body of POST /api/uploads
+type uploadSessionRequest struct {
+	Topic        string `json:"topic"`
+	Filename     string `json:"filename"`
+	Size         int64  `json:"size"`
+	ParentID     string `json:"parent_id,omitempty"`
+	RelativePath string `json:"relative_path,omitempty"`
+	Hash         string `json:"hash,omitempty"` // expected BLAKE3, checked at finalize
+}
+
+// POST /api/uploads - Open a resumable upload session (requires upload on
+// the topic, for the file's extension and size)
+func (s *Server) handleUploadSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	uploads, ok := s.uploadSessionService(w)
+	if !ok {
+		return
+	}
+
+	var req uploadSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	if !s.authorizeSessionUpload(w, identity, req.Topic, req.Filename, req.Size) {
+		return
+	}
+	if !s.checkDiskLimit(w, r, identity, "upload") {
+		return
+	}
+	if !s.checkUploadHeadroom(w, r, identity, req.Size+int64(constants.HeaderSize)) {
+		return
+	}
+
+	var parentID *string
+	if req.ParentID != "" {
+		parentID = &req.ParentID
+	}
+	var relativePath string
+	if req.RelativePath != "" {
+		relativePath = sanitize.RelativePath(req.RelativePath)
+		if relativePath == "" {
+			WriteError(w, http.StatusBadRequest, "Invalid relative_path", constants.ErrCodeInvalidRequest)
+			return
+		}
+	}
+
+	session, err := uploads.Create(identity.User.ID, services.UploadSessionRequest{
+		Topic:        req.Topic,
+		Filename:     req.Filename,
+		Size:         req.Size,
+		ParentID:     parentID,
+		RelativePath: relativePath,
+		Hash:         req.Hash,
+	})
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	w.Header().Set(constants.HeaderLocation, "/api/uploads/"+session.ID)
+	setUploadOffsetHeaders(w, session)
+	WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"session": session,
+	})
+}
+
+// /api/uploads/{id} - GET or HEAD (offset to resume from), PATCH (append a
+// chunk at Upload-Offset) or DELETE (abort)
+// /api/uploads/{id}/finalize - POST (verify and store the file)
+func (s *Server) handleUploadSessionRoutes(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/uploads/"), "/")
+	sessionID, action, _ := strings.Cut(rest, "/")
+	if sessionID == "" {
+		WriteError(w, http.StatusBadRequest, "Upload session ID is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	switch {
+	case action == "finalize" && r.Method == http.MethodPost:
+	case action == "" && (r.Method == http.MethodGet || r.Method == http.MethodHead ||
+		r.Method == http.MethodPatch || r.Method == http.MethodDelete):
+	case action != "" && action != "finalize":
+		http.NotFound(w, r)
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	uploads, ok := s.uploadSessionService(w)
+	if !ok {
+		return
+	}
+
+	// Sessions are only visible to the user who opened them
+	switch {
+	case action == "finalize":
+		s.finalizeUploadSession(w, r, identity, uploads, sessionID)
+	case r.Method == http.MethodPatch:
+		s.appendUploadSession(w, r, identity, uploads, sessionID)
+	case r.Method == http.MethodDelete:
+		if err := uploads.Abort(identity.User.ID, sessionID); err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"success": true,
+			"id":      sessionID,
+		})
+	default:
+		session, err := uploads.Get(identity.User.ID, sessionID)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		w.Header().Set(constants.HeaderCacheControl, "no-store")
+		setUploadOffsetHeaders(w, session)
+		WriteSuccess(w, session)
+	}
+}
+
+// appendUploadSession writes the request body at the Upload-Offset header.
+// The response carries the offset to resume from, also when the body was cut
+// short.
+func (s *Server) appendUploadSession(w http.ResponseWriter, r *http.Request, identity *auth.Identity, uploads *services.UploadSessionService, sessionID string) {
+	if r.Header.Get(constants.HeaderContentType) != constants.MimeTypeOffsetOctetStream {
+		WriteError(w, http.StatusUnsupportedMediaType,
+			"Content-Type must be "+constants.MimeTypeOffsetOctetStream, constants.ErrCodeInvalidRequest)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get(constants.HeaderUploadOffset), 10, 64)
+	if err != nil || offset < 0 {
+		WriteError(w, http.StatusBadRequest, "Upload-Offset header must be a non-negative byte offset", constants.ErrCodeInvalidRequest)
+		return
+	}
+	if !s.checkDiskLimit(w, r, identity, "upload") {
+		return
+	}
+
+	session, err := uploads.Append(identity.User.ID, sessionID, offset, r.Body)
+	if session != nil {
+		setUploadOffsetHeaders(w, session)
+	}
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, session)
+}
+
+// finalizeUploadSession stores a complete session in its topic and responds
+// like a single-shot upload.
+func (s *Server) finalizeUploadSession(w http.ResponseWriter, r *http.Request, identity *auth.Identity, uploads *services.UploadSessionService, sessionID string) {
+	session, err := uploads.Get(identity.User.ID, sessionID)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	// Grants may have changed since the session was opened
+	if !s.authorizeSessionUpload(w, identity, session.Topic, session.Filename, session.Size) {
+		return
+	}
+	if !s.checkDiskLimit(w, r, identity, "upload") {
+		return
+	}
+	if !s.checkUploadHeadroom(w, r, identity, session.Size+int64(constants.HeaderSize)) {
+		return
+	}
+
+	result, session, err := uploads.Finalize(r.Context(), identity.User.ID, sessionID, getAuditUsername(identity))
+	if err != nil {
+		if session != nil {
+			setUploadOffsetHeaders(w, session)
+		}
+		s.writeUploadServiceError(w, err)
+		return
+	}
+
+	s.completeUpload(w, r, identity, session.Topic, session.Filename, session.RelativePath, result)
+}
+
+// authorizeSessionUpload checks the upload grant for a session's file.
+func (s *Server) authorizeSessionUpload(w http.ResponseWriter, identity *auth.Identity, topicName, filename string, size int64) bool {
+	return s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionUpload,
+		TopicName: topicName,
+		Extension: strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), "."),
+		FileSize:  size,
+	})
+}
+
+// setUploadOffsetHeaders reports a session's progress the way tus clients
+// expect it.
+func setUploadOffsetHeaders(w http.ResponseWriter, session *database.UploadSession) {
+	w.Header().Set(constants.HeaderUploadOffset, strconv.FormatInt(session.ReceivedBytes, 10))
+	w.Header().Set(constants.HeaderUploadLength, strconv.FormatInt(session.Size, 10))
+}
+
+// uploadSessionService returns the upload session service, writing a 503
+// when it is unavailable (no working directory configured yet).
+func (s *Server) uploadSessionService(w http.ResponseWriter) (*services.UploadSessionService, bool) {
+	if s.app.Services.Uploads == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Resumable uploads not available", constants.ErrCodeNotConfigured)
+		return nil, false
+	}
+	return s.app.Services.Uploads, true
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// usageTracking counts every authenticated API request, its status and the
+// request and response body bytes into the caller's aggregated usage, shown
+// at GET /api/auth/me/usage. Must run after auth.Middleware.Authenticate.
+func (s *Server) usageTracking(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		svc := s.app.Services.Auth
+		identity, ok := auth.RequireAuth(r)
+		if svc == nil || !ok || !strings.HasPrefix(r.URL.Path, constants.CompressionAPIPathPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body := &countingReadCloser{ReadCloser: r.Body}
+		r.Body = body
+		rec := newUsageRecorder(w)
+
+		next.ServeHTTP(rec, r)
+
+		svc.RecordUsage(identity.User.ID, usageEndpoint(r.Method, r.URL.Path), rec.status, body.n, rec.n)
+	})
+}
+
+// usageEndpoint reduces a request to its route, with asset hashes, IDs and
+// topic names replaced by placeholders so usage aggregates per endpoint
+// rather than per resource.
+func usageEndpoint(method, path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		switch {
+		case seg == "":
+		case i == 3 && segments[2] == "topics":
+			segments[i] = constants.AuthUsageEndpointTopic
+		case len(seg) == constants.HashLength && isHexString(seg):
+			segments[i] = constants.AuthUsageEndpointHash
+		case isDigits(seg), len(seg) >= constants.AuthUsageEndpointIDMinLength && isHexString(seg):
+			segments[i] = constants.AuthUsageEndpointID
+		}
+	}
+	return method + " " + strings.Join(segments, "/")
+}
+
+// isHexString reports whether s contains only hex digits.
+func isHexString(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigits reports whether s contains only decimal digits.
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// countingReadCloser counts the bytes read from a request body.
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// usageRecorder passes the response through while recording its status and
+// the number of body bytes written.
+type usageRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	n           int64
+}
+
+func newUsageRecorder(w http.ResponseWriter) *usageRecorder {
+	return &usageRecorder{
+		ResponseWriter: w,
+		status:         http.StatusOK,
+	}
+}
+
+// WriteHeader records the status code.
+func (r *usageRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write counts the bytes written.
+func (r *usageRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.n += int64(n)
+	return n, err
+}
+
+// ReadFrom counts the bytes copied and passes the copy to the underlying
+// writer, so file-backed downloads keep using sendfile.
+func (r *usageRecorder) ReadFrom(src io.Reader) (int64, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	var n int64
+	var err error
+	if rf, ok := r.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(r.ResponseWriter, src)
+	}
+	r.n += n
+	return n, err
+}
+
+// Flush passes through to the underlying writer for streamed responses.
+func (r *usageRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (r *usageRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUsageEndpoint(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	tests := []struct {
+		method, path, want string
+	}{
+		{"GET", "/api/topics", "GET /api/topics"},
+		{"POST", "/api/topics/photos/assets", "POST /api/topics/:topic/assets"},
+		{"GET", "/api/topics/12345", "GET /api/topics/:topic"},
+		{"GET", "/api/assets/" + hash + "/download", "GET /api/assets/:hash/download"},
+		{"DELETE", "/api/download/bulk/0123456789abcdef/cancel", "DELETE /api/download/bulk/:id/cancel"},
+		{"GET", "/api/auth/users/42/usage", "GET /api/auth/users/:id/usage"},
+		{"POST", "/api/query/recent-imports", "POST /api/query/recent-imports"},
+	}
+	for _, tt := range tests {
+		if got := usageEndpoint(tt.method, tt.path); got != tt.want {
+			t.Errorf("usageEndpoint(%s, %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
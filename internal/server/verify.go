@@ -40,11 +40,12 @@ type DatProgressData struct {
 }
 
 type DatCompleteData struct {
-	Topic   string `json:"topic"`
-	DatFile string `json:"dat_file"`
-	Valid   bool   `json:"valid"`
-	Entries int    `json:"entries"`
-	Error   string `json:"error,omitempty"`
+	Topic       string   `json:"topic"`
+	DatFile     string   `json:"dat_file"`
+	Valid       bool     `json:"valid"`
+	Entries     int      `json:"entries"`
+	Error       string   `json:"error,omitempty"`
+	Quarantined []string `json:"quarantined,omitempty"`
 }
 
 type TopicCompleteData struct {
@@ -138,6 +139,22 @@ func (s *SSEWriter) Send(eventType string, data interface{}) error {
 	return nil
 }
 
+// SendWithID sends data as an SSE event with an id, which clients send back
+// as Last-Event-ID when they reconnect
+func (s *SSEWriter) SendWithID(id int64, data interface{}) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(s.w, "id: %d\ndata: %s\n\n", id, jsonData); err != nil {
+		return err
+	}
+
+	s.flusher.Flush()
+	return nil
+}
+
 // handleVerify handles GET /api/verify with SSE streaming
 func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -176,6 +193,12 @@ func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
 		opts.Topics = s.app.ListTopics()
 	}
 
+	release := s.acquireSSE(w, r, constants.SSEEndpointVerify, identity)
+	if release == nil {
+		return
+	}
+	defer release()
+
 	// Set up SSE writer
 	sse, err := NewSSEWriter(w)
 	if err != nil {
@@ -332,11 +355,12 @@ func (s *Server) verifyTopic(ctx context.Context, sse *SSEWriter, topicName stri
 		result, _ := s.app.Services.Verify.VerifyDatFile(ctx, topicName, datFile, progressInterval, progressCallback)
 
 		sse.Send("dat_complete", DatCompleteData{
-			Topic:   topicName,
-			DatFile: datFile,
-			Valid:   result.Valid,
-			Entries: result.EntryCount,
-			Error:   result.Error,
+			Topic:       topicName,
+			DatFile:     datFile,
+			Valid:       result.Valid,
+			Entries:     result.EntryCount,
+			Error:       result.Error,
+			Quarantined: result.Quarantined,
 		})
 
 		datFilesChecked++
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"silobang/internal/auth"
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Watch Folder Handlers
+// =============================================================================
+
+// GET /api/watch-folders - Configured watch folders with their counters
+// (requires manage_config)
+func (s *Server) handleWatchFolders(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	folders, ok := s.watchFolderService(w)
+	if !ok {
+		return
+	}
+	WriteSuccess(w, map[string]interface{}{
+		"folders": folders.List(),
+	})
+}
+
+// GET /api/watch-folders/:name - Folder with counters and failed files
+// PUT /api/watch-folders/:name - Create or replace the folder
+// DELETE /api/watch-folders/:name - Stop watching the folder
+// POST /api/watch-folders/:name/scan - Scan the folder now
+// POST /api/watch-folders/:name/retry - Move failed files back for the next scan
+//
+// Watch folders expose server paths, so every route requires manage_config.
+func (s *Server) handleWatchFolderRoutes(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/watch-folders/"), "/")
+	name, action, _ := strings.Cut(rest, "/")
+	if name == "" {
+		WriteError(w, http.StatusBadRequest, "Watch folder name is required", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	switch {
+	case (action == "scan" || action == "retry") && r.Method == http.MethodPost:
+	case action == "" && (r.Method == http.MethodGet || r.Method == http.MethodPut || r.Method == http.MethodDelete):
+	case action != "" && action != "scan" && action != "retry":
+		http.NotFound(w, r)
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageConfig}) {
+		return
+	}
+
+	folders, ok := s.watchFolderService(w)
+	if !ok {
+		return
+	}
+	before := s.app.Services.Config.Snapshot()
+
+	switch {
+	case action == "scan":
+		folder, err := folders.Scan(name)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, folder)
+	case action == "retry":
+		moved, err := folders.Retry(name)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"requeued": moved,
+		})
+	case r.Method == http.MethodGet:
+		folder, err := folders.Get(name)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, folder)
+	case r.Method == http.MethodDelete:
+		if err := folders.Delete(name); err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		s.auditWatchFolderChange(r, identity, name, before)
+		WriteSuccess(w, map[string]interface{}{
+			"deleted": name,
+		})
+	default:
+		var req config.WatchFolderConfig
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+			return
+		}
+		folder, err := folders.Set(name, req)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		s.auditWatchFolderChange(r, identity, name, before)
+		WriteSuccess(w, map[string]interface{}{
+			"folder": folder,
+		})
+	}
+}
+
+// watchFolderService returns the watch folder service, or writes an error
+// when the orchestrator DB is not available.
+func (s *Server) watchFolderService(w http.ResponseWriter) (*services.WatchFolderService, bool) {
+	if s.app.Services.WatchFolders == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Watch folders not available", constants.ErrCodeNotConfigured)
+		return nil, false
+	}
+	return s.app.Services.WatchFolders, true
+}
+
+// auditWatchFolderChange records a watch folder change as a config change.
+func (s *Server) auditWatchFolderChange(r *http.Request, identity *auth.Identity, name string, before map[string]interface{}) {
+	s.app.Services.Config.RecordChange(getClientIP(r), getAuditUsername(identity), constants.ConfigChangeSourceAPI, before,
+		[]string{"watch_folders." + name})
+}
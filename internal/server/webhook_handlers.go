@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Webhook Handlers
+// =============================================================================
+
+// GET /api/webhooks - Webhooks with their delivery counts
+// POST /api/webhooks - Create a webhook; the response holds its secret, which
+// is not returned again
+//
+// Webhooks receive audit entries, so every route requires manage_config.
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	webhooks, ok := s.webhookService(w)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		hooks, err := webhooks.List()
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, map[string]interface{}{
+			"webhooks": hooks,
+		})
+		return
+	}
+
+	var req services.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+	hook, secret, err := webhooks.Create(&req, getAuditUsername(identity))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+	s.auditWebhookChange(r, identity, constants.AuditActionWebhookCreated, hook)
+	WriteSuccess(w, map[string]interface{}{
+		"webhook": hook,
+		"secret":  secret,
+	})
+}
+
+// GET /api/webhooks/:id - Webhook with its delivery counts and newest deliveries
+// PUT /api/webhooks/:id - Update the set fields of a webhook
+// DELETE /api/webhooks/:id - Delete a webhook and its queued deliveries
+func (s *Server) handleWebhookRoutes(w http.ResponseWriter, r *http.Request, identity *auth.Identity) {
+	id, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/webhooks/"), "/"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid webhook ID", constants.ErrCodeInvalidRequest)
+		return
+	}
+	webhooks, ok := s.webhookService(w)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		hook, err := webhooks.Get(id)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		WriteSuccess(w, hook)
+	case http.MethodDelete:
+		hook, err := webhooks.Delete(id)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		s.auditWebhookChange(r, identity, constants.AuditActionWebhookDeleted, hook)
+		WriteSuccess(w, map[string]interface{}{
+			"deleted": id,
+		})
+	default:
+		var req services.WebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+			return
+		}
+		hook, err := webhooks.Update(id, &req)
+		if err != nil {
+			s.handleServiceError(w, err)
+			return
+		}
+		s.auditWebhookChange(r, identity, constants.AuditActionWebhookUpdated, hook)
+		WriteSuccess(w, map[string]interface{}{
+			"webhook": hook,
+		})
+	}
+}
+
+// webhookService returns the webhook service, or writes an error when the
+// orchestrator DB is not available.
+func (s *Server) webhookService(w http.ResponseWriter) (*services.WebhookService, bool) {
+	if s.app.Services.Webhooks == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Webhooks not available", constants.ErrCodeNotConfigured)
+		return nil, false
+	}
+	return s.app.Services.Webhooks, true
+}
+
+// auditWebhookChange records a webhook change in the audit log.
+func (s *Server) auditWebhookChange(r *http.Request, identity *auth.Identity, action string, hook *database.Webhook) {
+	if s.app.AuditLogger == nil {
+		return
+	}
+	s.app.AuditLogger.Log(action, getClientIP(r), getAuditUsername(identity), audit.WebhookDetails{
+		WebhookID: hook.ID,
+		Name:      hook.Name,
+		Events:    hook.Events,
+		Active:    hook.Active,
+	})
+}
@@ -0,0 +1,311 @@
+package server
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// =============================================================================
+// Minimal WebSocket (RFC 6455) Server Implementation
+// =============================================================================
+//
+// Only what the progress channel needs: server-side handshake, text/binary
+// messages with fragmentation, ping/pong and close. No extensions or subprotocols.
+
+// WebSocket opcodes
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// WebSocket close status codes
+const (
+	wsCloseNormal        = 1000
+	wsCloseGoingAway     = 1001
+	wsCloseProtocolError = 1002
+	wsCloseTooLarge      = 1009
+)
+
+var (
+	errWSClosed       = errors.New("websocket: connection closed")
+	errWSTooLarge     = errors.New("websocket: message too large")
+	errWSProtocol     = errors.New("websocket: protocol error")
+	errWSNotWebSocket = errors.New("websocket: not a websocket handshake")
+)
+
+// wsConn is a server-side WebSocket connection.
+// Reads must come from a single goroutine; writes are serialized internally.
+type wsConn struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	writeMu sync.Mutex
+	maxSize int64
+
+	closeOnce sync.Once
+}
+
+// isWebSocketUpgrade reports whether r is a WebSocket handshake request.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, constants.HeaderConnection, "upgrade") &&
+		headerContainsToken(r.Header, constants.HeaderUpgrade, constants.WebSocketUpgradeToken)
+}
+
+// headerContainsToken reports whether a comma-separated header contains token (case-insensitive).
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsAcceptKey computes the Sec-WebSocket-Accept value for a client key.
+func wsAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + constants.WebSocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// upgradeWebSocket validates the handshake, hijacks the connection and
+// returns a wsConn. On failure an HTTP error has already been written.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if r.Method != http.MethodGet || !isWebSocketUpgrade(r) {
+		WriteError(w, http.StatusBadRequest, "WebSocket upgrade required", constants.ErrCodeInvalidRequest)
+		return nil, errWSNotWebSocket
+	}
+	if r.Header.Get(constants.HeaderSecWebSocketVer) != constants.WebSocketVersion {
+		w.Header().Set(constants.HeaderSecWebSocketVer, constants.WebSocketVersion)
+		WriteError(w, http.StatusUpgradeRequired, "Unsupported WebSocket version", constants.ErrCodeInvalidRequest)
+		return nil, errWSNotWebSocket
+	}
+	key := r.Header.Get(constants.HeaderSecWebSocketKey)
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		WriteError(w, http.StatusBadRequest, "Invalid Sec-WebSocket-Key", constants.ErrCodeInvalidRequest)
+		return nil, errWSNotWebSocket
+	}
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "WebSocket not supported", constants.ErrCodeStreamingError)
+		return nil, err
+	}
+
+	// Clear any deadlines inherited from the HTTP server
+	conn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		constants.HeaderSecWebSocketAccept + ": " + wsAcceptKey(key) + "\r\n\r\n"
+	if _, err := brw.WriteString(response); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := brw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{
+		conn:    conn,
+		br:      brw.Reader,
+		maxSize: constants.WSMaxMessageSize,
+	}, nil
+}
+
+// ReadMessage returns the next complete text or binary message.
+// Control frames are handled transparently: pings are answered, pongs are
+// ignored and close frames are echoed before returning errWSClosed.
+func (c *wsConn) ReadMessage() (int, []byte, error) {
+	var (
+		msgOp   = -1
+		payload []byte
+	)
+
+	for {
+		fin, op, data, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, data); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			code := wsCloseNormal
+			if len(data) >= 2 {
+				code = int(binary.BigEndian.Uint16(data))
+			}
+			c.CloseWithCode(code, "")
+			return 0, nil, errWSClosed
+		case wsOpText, wsOpBinary:
+			if msgOp != -1 {
+				c.CloseWithCode(wsCloseProtocolError, "expected continuation frame")
+				return 0, nil, errWSProtocol
+			}
+			msgOp = op
+		case wsOpContinuation:
+			if msgOp == -1 {
+				c.CloseWithCode(wsCloseProtocolError, "unexpected continuation frame")
+				return 0, nil, errWSProtocol
+			}
+		default:
+			c.CloseWithCode(wsCloseProtocolError, "unknown opcode")
+			return 0, nil, errWSProtocol
+		}
+
+		if int64(len(payload)+len(data)) > c.maxSize {
+			c.CloseWithCode(wsCloseTooLarge, "message too large")
+			return 0, nil, errWSTooLarge
+		}
+		payload = append(payload, data...)
+
+		if fin {
+			return msgOp, payload, nil
+		}
+	}
+}
+
+// readFrame reads a single frame and unmasks its payload.
+func (c *wsConn) readFrame() (fin bool, op int, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.br, header[:]); err != nil {
+		return
+	}
+
+	fin = header[0]&0x80 != 0
+	op = int(header[0] & 0x0F)
+	if header[0]&0x70 != 0 {
+		c.CloseWithCode(wsCloseProtocolError, "reserved bits set")
+		return false, 0, nil, errWSProtocol
+	}
+
+	// Client frames must be masked (RFC 6455 §5.1)
+	masked := header[1]&0x80 != 0
+	if !masked {
+		c.CloseWithCode(wsCloseProtocolError, "client frames must be masked")
+		return false, 0, nil, errWSProtocol
+	}
+
+	length := int64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+
+	isControl := op >= wsOpClose
+	if isControl && (length > 125 || !fin) {
+		c.CloseWithCode(wsCloseProtocolError, "invalid control frame")
+		return false, 0, nil, errWSProtocol
+	}
+	if length < 0 || length > c.maxSize {
+		c.CloseWithCode(wsCloseTooLarge, "frame too large")
+		return false, 0, nil, errWSTooLarge
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// WriteText sends a single unfragmented text message.
+func (c *wsConn) WriteText(data []byte) error {
+	return c.writeFrame(wsOpText, data)
+}
+
+// Ping sends a ping control frame.
+func (c *wsConn) Ping() error {
+	return c.writeFrame(wsOpPing, nil)
+}
+
+// writeFrame writes one unmasked frame (server frames are never masked).
+func (c *wsConn) writeFrame(op int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := make([]byte, 0, 10)
+	header = append(header, 0x80|byte(op))
+	switch n := len(payload); {
+	case n <= 125:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, byte(n>>8), byte(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(constants.WSWriteTimeoutSecs * time.Second))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return fmt.Errorf("websocket write: %w", err)
+	}
+	return nil
+}
+
+// SetReadDeadline bounds how long the next read may block.
+func (c *wsConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// CloseWithCode sends a close frame (best effort) and closes the connection.
+// Safe to call multiple times.
+func (c *wsConn) CloseWithCode(code int, reason string) {
+	c.closeOnce.Do(func() {
+		payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+		if len(reason) > 123 {
+			reason = reason[:123]
+		}
+		payload = append(payload, reason...)
+		c.writeFrame(wsOpClose, payload) //nolint:errcheck
+		c.conn.Close()
+	})
+}
+
+// Close closes the connection with a normal closure status.
+func (c *wsConn) Close() {
+	c.CloseWithCode(wsCloseNormal, "")
+}
@@ -0,0 +1,250 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// newPipeWSConn returns a server-side wsConn and the raw client end of a pipe.
+func newPipeWSConn() (*wsConn, net.Conn) {
+	server, client := net.Pipe()
+	return &wsConn{
+		conn:    server,
+		br:      bufio.NewReader(server),
+		maxSize: constants.WSMaxMessageSize,
+	}, client
+}
+
+// maskedFrame builds a client frame (always masked, as required by RFC 6455).
+func maskedFrame(fin bool, op int, payload []byte) []byte {
+	b0 := byte(op)
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126, byte(n>>8), byte(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, mask...)
+	for i, c := range payload {
+		frame = append(frame, c^mask[i%4])
+	}
+	return frame
+}
+
+func TestWSAcceptKey(t *testing.T) {
+	// Example from RFC 6455 §1.3
+	if got := wsAcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("unexpected accept key: %s", got)
+	}
+}
+
+func TestIsWebSocketUpgrade(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/api/ws/progress", nil)
+	if isWebSocketUpgrade(r) {
+		t.Error("plain request detected as upgrade")
+	}
+	r.Header.Set("Connection", "keep-alive, Upgrade")
+	r.Header.Set("Upgrade", "WebSocket")
+	if !isWebSocketUpgrade(r) {
+		t.Error("upgrade request not detected")
+	}
+}
+
+func TestWSConn_ReadFragmentedMessage(t *testing.T) {
+	conn, client := newPipeWSConn()
+	defer client.Close()
+
+	go func() {
+		client.Write(maskedFrame(false, wsOpText, []byte("hel")))
+		client.Write(maskedFrame(true, wsOpContinuation, []byte("lo")))
+	}()
+
+	op, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if op != wsOpText || string(data) != "hello" {
+		t.Errorf("expected text 'hello', got op=%d data=%q", op, data)
+	}
+}
+
+func TestWSConn_AnswersPing(t *testing.T) {
+	conn, client := newPipeWSConn()
+	defer client.Close()
+
+	go func() {
+		client.Write(maskedFrame(true, wsOpPing, []byte("hi")))
+		// Read the pong before sending the message so the pipe doesn't block
+		header := make([]byte, 4)
+		client.Read(header)
+		if header[0] != 0x80|wsOpPong || string(header[2:]) != "hi" {
+			t.Errorf("expected pong with payload, got %v", header)
+		}
+		client.Write(maskedFrame(true, wsOpText, []byte("after")))
+	}()
+
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(data) != "after" {
+		t.Errorf("expected message after ping, got %q", data)
+	}
+}
+
+func TestWSConn_RejectsUnmaskedFrame(t *testing.T) {
+	conn, client := newPipeWSConn()
+	defer client.Close()
+
+	go func() {
+		client.Write([]byte{0x80 | wsOpText, 2, 'h', 'i'})
+		// Drain the close frame
+		buf := make([]byte, 64)
+		client.Read(buf)
+	}()
+
+	if _, _, err := conn.ReadMessage(); err != errWSProtocol {
+		t.Errorf("expected protocol error, got %v", err)
+	}
+}
+
+func TestWSConn_RejectsOversizedMessage(t *testing.T) {
+	conn, client := newPipeWSConn()
+	conn.maxSize = 8
+	defer client.Close()
+
+	go func() {
+		client.Write(maskedFrame(true, wsOpText, make([]byte, 16)))
+		buf := make([]byte, 64)
+		client.Read(buf)
+	}()
+
+	if _, _, err := conn.ReadMessage(); err != errWSTooLarge {
+		t.Errorf("expected too large error, got %v", err)
+	}
+}
+
+func TestWSConn_WriteText(t *testing.T) {
+	conn, client := newPipeWSConn()
+	defer client.Close()
+
+	payload := make([]byte, 300) // forces 16-bit extended length
+	go conn.WriteText(payload)
+
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	header := make([]byte, 4)
+	if _, err := client.Read(header); err != nil {
+		t.Fatalf("read header: %v", err)
+	}
+	if header[0] != 0x80|wsOpText || header[1] != 126 || binary.BigEndian.Uint16(header[2:]) != 300 {
+		t.Errorf("unexpected frame header: %v", header)
+	}
+}
+
+func TestProgressHub_CancelRequiresOwner(t *testing.T) {
+	hub := NewProgressHub()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if hub.Track(1, "up-1", progressKindUpload, cancel) == nil {
+		t.Fatal("track failed")
+	}
+	if hub.Track(2, "up-1", progressKindUpload, cancel) != nil {
+		t.Error("duplicate id should be rejected")
+	}
+
+	if _, ok := hub.Cancel(2, "up-1"); ok {
+		t.Error("another user must not cancel the operation")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("operation cancelled by non-owner")
+	}
+
+	kind, ok := hub.Cancel(1, "up-1")
+	if !ok || kind != progressKindUpload {
+		t.Errorf("expected owner cancel to succeed, got %q %v", kind, ok)
+	}
+	if ctx.Err() == nil {
+		t.Error("expected context to be cancelled")
+	}
+}
+
+func TestProgressHub_UntrackKeepsNewerOperation(t *testing.T) {
+	hub := NewProgressHub()
+	noop := func() {}
+
+	old := hub.Track(1, "id", progressKindUpload, noop)
+	hub.Cancel(1, "id")
+	newer := hub.Track(1, "id", progressKindUpload, noop)
+
+	hub.Untrack("id", old)
+	if _, ok := hub.Cancel(1, "id"); !ok {
+		t.Error("stale untrack removed the newer operation")
+	}
+	hub.Untrack("id", newer)
+}
+
+func TestProgressHub_PublishPerUser(t *testing.T) {
+	hub := NewProgressHub()
+	a := hub.Subscribe(1)
+	b := hub.Subscribe(2)
+
+	hub.Publish(1, "upload_progress", nil)
+
+	select {
+	case <-a.send:
+	default:
+		t.Error("expected event for user 1")
+	}
+	select {
+	case <-b.send:
+		t.Error("user 2 must not receive user 1 events")
+	default:
+	}
+
+	hub.Unsubscribe(a)
+	hub.Unsubscribe(a) // idempotent
+	if _, ok := <-a.send; ok {
+		t.Error("expected send channel to be closed")
+	}
+}
+
+func TestProgressHub_ConnectionLimit(t *testing.T) {
+	hub := NewProgressHub()
+	for i := 0; i < constants.WSMaxConnectionsPerUser; i++ {
+		if hub.Subscribe(1) == nil {
+			t.Fatalf("subscribe %d failed", i)
+		}
+	}
+	if hub.Subscribe(1) != nil {
+		t.Error("expected connection limit to be enforced")
+	}
+}
+
+func TestValidateProgressID(t *testing.T) {
+	for _, id := range []string{"abc", "upload_1-x"} {
+		if err := ValidateProgressID(id); err != nil {
+			t.Errorf("%q should be valid: %v", id, err)
+		}
+	}
+	for _, id := range []string{"", "a b", "../x", string(make([]byte, constants.WSProgressIDMaxLen+1))} {
+		if err := ValidateProgressID(id); err == nil {
+			t.Errorf("%q should be invalid", id)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/services"
+)
+
+// =============================================================================
+// Workspace Token Endpoints (CI pipelines)
+// =============================================================================
+
+// /api/auth/workspace-tokens — GET (list) or POST (create), requires manage_users
+func (s *Server) handleWorkspaceTokens(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listWorkspaceTokens(w, r)
+	case http.MethodPost:
+		s.createWorkspaceToken(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) listWorkspaceTokens(w http.ResponseWriter, r *http.Request) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{Action: constants.AuthActionManageUsers}) {
+		return
+	}
+
+	tokens, err := s.app.Services.Auth.ListWorkspaceTokens(r.URL.Query().Get("pipeline"))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"workspace_tokens": tokens,
+	})
+}
+
+func (s *Server) createWorkspaceToken(w http.ResponseWriter, r *http.Request) {
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageUsers,
+		SubAction: "create",
+	}) {
+		return
+	}
+
+	var req services.CreateWorkspaceTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	resp, err := s.app.Services.Auth.CreateWorkspaceToken(identity, req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		actions := make([]string, 0, len(resp.Scopes))
+		for _, scope := range resp.Scopes {
+			actions = append(actions, scope.Action)
+		}
+		s.app.AuditLogger.Log(constants.AuditActionWorkspaceTokenCreated, getClientIP(r), getAuditUsername(identity), audit.WorkspaceTokenCreatedDetails{
+			TokenID:     resp.ID,
+			Pipeline:    resp.Pipeline,
+			TokenPrefix: resp.TokenPrefix,
+			Actions:     actions,
+			ExpiresAt:   resp.ExpiresAt,
+		})
+	}
+
+	WriteJSON(w, http.StatusCreated, resp)
+}
+
+// POST /api/auth/workspace-tokens/revoke — Revoke workspace tokens by ID,
+// by pipeline or all at once, with their job tokens
+func (s *Server) handleRevokeWorkspaceTokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity := s.requireAuth(w, r)
+	if identity == nil {
+		return
+	}
+
+	if !s.authorize(w, identity, &auth.ActionContext{
+		Action:    constants.AuthActionManageUsers,
+		SubAction: "disable",
+	}) {
+		return
+	}
+
+	var req services.RevokeWorkspaceTokensRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	ids, err := s.app.Services.Auth.RevokeWorkspaceTokens(identity, req)
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil && len(ids) > 0 {
+		s.app.AuditLogger.Log(constants.AuditActionWorkspaceTokenRevoked, getClientIP(r), getAuditUsername(identity), audit.WorkspaceTokenRevokedDetails{
+			TokenIDs: ids,
+			Pipeline: req.Pipeline,
+			All:      req.All,
+		})
+	}
+
+	WriteSuccess(w, map[string]interface{}{
+		"revoked": ids,
+	})
+}
+
+// POST /api/auth/workspace-tokens/exchange — Trade the workspace token sent
+// as "Authorization: Bearer mbw_..." for a short-lived job token. The body
+// {job, ttl_secs} is optional.
+func (s *Server) handleExchangeWorkspaceToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.isAuthAvailable() {
+		WriteError(w, http.StatusServiceUnavailable, "Auth system not available", constants.ErrCodeNotConfigured)
+		return
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get(constants.HeaderAuthorization), constants.AuthBearerPrefix)
+	if !ok || token == "" {
+		WriteError(w, http.StatusUnauthorized, "Workspace token is required", constants.ErrCodeAuthRequired)
+		return
+	}
+
+	var req struct {
+		Job     string `json:"job"`
+		TTLSecs int64  `json:"ttl_secs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON", constants.ErrCodeInvalidRequest)
+		return
+	}
+
+	resp, err := s.app.Services.Auth.ExchangeWorkspaceToken(token, req.Job, req.TTLSecs, getClientIP(r))
+	if err != nil {
+		s.handleServiceError(w, err)
+		return
+	}
+
+	if s.app.AuditLogger != nil {
+		s.app.AuditLogger.Log(constants.AuditActionWorkspaceTokenExchanged, getClientIP(r), resp.Pipeline, audit.WorkspaceTokenExchangedDetails{
+			TokenID:        resp.WorkspaceTokenID,
+			TokenPrefix:    resp.WorkspacePrefix,
+			Job:            resp.Job,
+			JobTokenPrefix: resp.TokenPrefix,
+			ExpiresAt:      resp.ExpiresAt,
+		})
+	}
+
+	WriteSuccess(w, resp)
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package service
+
+// Run serves in the foreground. systemd supervises the process directly
+// and stops it with SIGTERM, which serve handles itself.
+func Run(name string, serve func(stop <-chan struct{})) error {
+	serve(nil)
+	return nil
+}
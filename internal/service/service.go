@@ -0,0 +1,117 @@
+// Package service registers the server with the platform service manager:
+// a systemd unit on Linux, a Windows service on Windows.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"silobang/internal/constants"
+)
+
+// ErrUnsupported is returned on platforms without a supported service manager.
+var ErrUnsupported = errors.New("service management is not supported on this platform")
+
+// ErrNotInstalled is returned when uninstalling a service that does not exist.
+var ErrNotInstalled = errors.New("service is not installed")
+
+// Config describes the service to install.
+type Config struct {
+	Name       string // Unit or service name
+	Executable string // Absolute path of the silobang binary
+	ConfigDir  string // Config directory, passed to the server as SILOBANG_CONFIG_DIR
+	WorkDir    string // Process working directory
+	User       string // systemd only: account to run as (empty runs as root)
+}
+
+// Status reports the state of an installed service.
+type Status struct {
+	Name      string `json:"name"`
+	Installed bool   `json:"installed"`
+	State     string `json:"state"`              // As reported by the service manager, e.g. "active" or "RUNNING"
+	Location  string `json:"location,omitempty"` // Unit file path or registered command line
+}
+
+// Validate checks that the paths are absolute, since service managers
+// start processes outside any shell or login directory.
+func (c Config) Validate() error {
+	if c.Name == "" {
+		return errors.New("service name is required")
+	}
+	if strings.ContainsAny(c.Name, `/\ `) {
+		return fmt.Errorf("invalid service name %q", c.Name)
+	}
+	paths := []struct{ field, path string }{
+		{"executable", c.Executable},
+		{"config directory", c.ConfigDir},
+		{"working directory", c.WorkDir},
+	}
+	for _, p := range paths {
+		if !filepath.IsAbs(p.path) {
+			return fmt.Errorf("%s must be an absolute path: %q", p.field, p.path)
+		}
+	}
+	return nil
+}
+
+// SystemdUnit renders the systemd unit for c. The server restarts on
+// failure, and its output goes to the journal under the service name.
+func SystemdUnit(c Config) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", constants.ServiceDescription)
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	fmt.Fprintf(&b, "ExecStart=%s\n", systemdQuote(c.Executable))
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", c.WorkDir)
+	fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(constants.ServiceConfigDirEnv+"="+c.ConfigDir))
+	if c.User != "" {
+		fmt.Fprintf(&b, "User=%s\n", c.User)
+	}
+	b.WriteString("Restart=on-failure\n")
+	fmt.Fprintf(&b, "RestartSec=%d\n", constants.ServiceRestartDelaySecs)
+	fmt.Fprintf(&b, "TimeoutStopSec=%d\n", constants.ShutdownTimeoutSecs+constants.ServiceRestartDelaySecs)
+	b.WriteString("StandardOutput=journal\n")
+	b.WriteString("StandardError=journal\n")
+	fmt.Fprintf(&b, "SyslogIdentifier=%s\n", c.Name)
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// systemdQuote double-quotes s for a unit file, escaping quotes,
+// backslashes and the % specifier prefix.
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "%", "%%")
+	return `"` + s + `"`
+}
+
+// WindowsCommandLine returns the command line the Windows service manager
+// runs for c. Windows services start in the system directory, without the
+// installing user's environment and without a console, so the directories
+// are passed as flags and output goes to a log file in the config directory.
+func WindowsCommandLine(c Config) string {
+	return fmt.Sprintf(`"%s" service run --name %s --config-dir "%s" --workdir "%s" --log-file "%s"`,
+		c.Executable, c.Name, c.ConfigDir, c.WorkDir, filepath.Join(c.ConfigDir, constants.ServiceLogFile))
+}
+
+// runCommand runs a service manager command and returns its trimmed
+// combined output, which is included in the error on failure.
+func runCommand(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	output := strings.TrimSpace(string(out))
+	if err != nil {
+		if output != "" {
+			return output, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, output)
+		}
+		return output, fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return output, nil
+}
@@ -0,0 +1,77 @@
+//go:build linux
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"silobang/internal/constants"
+)
+
+// unitPath returns the path of the systemd unit file for name.
+func unitPath(name string) string {
+	return filepath.Join(constants.ServiceSystemdUnitDir, name+constants.ServiceSystemdUnitSuffix)
+}
+
+// Install writes the systemd unit, then enables and starts it. An existing
+// unit of the same name is replaced.
+func Install(c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if os.Geteuid() != 0 {
+		return errors.New("installing a systemd unit requires root")
+	}
+	if err := os.MkdirAll(c.ConfigDir, constants.DirPermissions); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(unitPath(c.Name), []byte(SystemdUnit(c)), constants.FilePermissions); err != nil {
+		return fmt.Errorf("failed to write unit file: %w", err)
+	}
+	if _, err := runCommand("systemctl", "daemon-reload"); err != nil {
+		return err
+	}
+	_, err := runCommand("systemctl", "enable", "--now", c.Name)
+	return err
+}
+
+// Uninstall stops and disables the unit, then removes its file.
+func Uninstall(name string) error {
+	path := unitPath(name)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return ErrNotInstalled
+	}
+	if os.Geteuid() != 0 {
+		return errors.New("removing a systemd unit requires root")
+	}
+	if _, err := runCommand("systemctl", "disable", "--now", name); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove unit file: %w", err)
+	}
+	_, err := runCommand("systemctl", "daemon-reload")
+	return err
+}
+
+// QueryStatus reports whether the unit is installed and its active state.
+func QueryStatus(name string) (*Status, error) {
+	path := unitPath(name)
+	status := &Status{Name: name, State: constants.ServiceStateNotInstalled}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return status, nil
+	}
+	status.Installed = true
+	status.Location = path
+
+	// is-active exits non-zero for inactive units but still prints the state
+	state, err := runCommand("systemctl", "is-active", name)
+	if state == "" && err != nil {
+		return nil, err
+	}
+	status.State = state
+	return status, nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !windows
+
+package service
+
+// Install is not supported on this platform.
+func Install(c Config) error {
+	return ErrUnsupported
+}
+
+// Uninstall is not supported on this platform.
+func Uninstall(name string) error {
+	return ErrUnsupported
+}
+
+// QueryStatus is not supported on this platform.
+func QueryStatus(name string) (*Status, error) {
+	return nil, ErrUnsupported
+}
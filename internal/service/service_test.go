@@ -0,0 +1,57 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestSystemdUnit(t *testing.T) {
+	unit := SystemdUnit(Config{
+		Name:       "silobang",
+		Executable: "/opt/silo bang/silobang",
+		ConfigDir:  "/etc/silobang",
+		WorkDir:    "/srv/assets",
+		User:       "silobang",
+	})
+
+	for _, line := range []string{
+		`ExecStart="/opt/silo bang/silobang"`,
+		"WorkingDirectory=/srv/assets",
+		`Environment="` + constants.ServiceConfigDirEnv + `=/etc/silobang"`,
+		"User=silobang",
+		"Restart=on-failure",
+		"StandardOutput=journal",
+		"SyslogIdentifier=silobang",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(unit, line+"\n") {
+			t.Errorf("unit is missing %q:\n%s", line, unit)
+		}
+	}
+
+	if unit := SystemdUnit(Config{Name: "silobang", Executable: "/bin/silo%bang"}); !strings.Contains(unit, `ExecStart="/bin/silo%%bang"`) ||
+		strings.Contains(unit, "User=") {
+		t.Errorf("unexpected unit without a user:\n%s", unit)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{Name: "silobang", Executable: "/usr/bin/silobang", ConfigDir: "/etc/silobang", WorkDir: "/srv"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+
+	relative := valid
+	relative.WorkDir = "assets"
+	if err := relative.Validate(); err == nil || !strings.Contains(err.Error(), "working directory") {
+		t.Errorf("expected a relative working directory to be rejected, got %v", err)
+	}
+
+	badName := valid
+	badName.Name = "silo/bang"
+	if err := badName.Validate(); err == nil {
+		t.Error("expected a name with a slash to be rejected")
+	}
+}
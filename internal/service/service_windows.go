@@ -0,0 +1,252 @@
+//go:build windows
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"silobang/internal/constants"
+)
+
+// Service control manager values (winsvc.h)
+const (
+	serviceWin32OwnProcess = 0x10
+
+	serviceStopped      = 1
+	serviceStartPending = 2
+	serviceStopPending  = 3
+	serviceRunning      = 4
+
+	serviceAcceptStop     = 0x1
+	serviceAcceptShutdown = 0x4
+
+	serviceControlStop        = 1
+	serviceControlInterrogate = 4
+	serviceControlShutdown    = 5
+
+	errorCallNotImplemented             = 120
+	errorServiceDoesNotExist            = "1060"
+	errorFailedServiceControllerConnect = syscall.Errno(1063)
+)
+
+var (
+	advapi32                          = syscall.NewLazyDLL("advapi32.dll")
+	procStartServiceCtrlDispatcherW   = advapi32.NewProc("StartServiceCtrlDispatcherW")
+	procRegisterServiceCtrlHandlerExW = advapi32.NewProc("RegisterServiceCtrlHandlerExW")
+	procSetServiceStatus              = advapi32.NewProc("SetServiceStatus")
+)
+
+// Install registers the service with the service control manager, sets
+// it to start automatically and restart on failure, and starts it. An
+// existing service of the same name is reconfigured.
+func Install(c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.ConfigDir, constants.DirPermissions); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	existing, err := QueryStatus(c.Name)
+	if err != nil {
+		return err
+	}
+	verb := "create"
+	if existing.Installed {
+		verb = "config"
+	}
+	if _, err := runCommand("sc.exe", verb, c.Name,
+		"binPath=", WindowsCommandLine(c),
+		"start=", "auto",
+		"DisplayName=", constants.AppDisplayName); err != nil {
+		return err
+	}
+	if _, err := runCommand("sc.exe", "description", c.Name, constants.ServiceDescription); err != nil {
+		return err
+	}
+	delay := fmt.Sprintf("restart/%d", constants.ServiceRestartDelaySecs*1000)
+	if _, err := runCommand("sc.exe", "failure", c.Name,
+		"reset=", fmt.Sprint(constants.ServiceFailureResetSecs),
+		"actions=", strings.Join([]string{delay, delay, delay}, "/")); err != nil {
+		return err
+	}
+	if existing.Installed && strings.Contains(existing.State, "RUNNING") {
+		return nil
+	}
+	_, err = runCommand("sc.exe", "start", c.Name)
+	return err
+}
+
+// Uninstall stops the service and removes its registration.
+func Uninstall(name string) error {
+	status, err := QueryStatus(name)
+	if err != nil {
+		return err
+	}
+	if !status.Installed {
+		return ErrNotInstalled
+	}
+	// Fails when the service is already stopped
+	runCommand("sc.exe", "stop", name)
+	_, err = runCommand("sc.exe", "delete", name)
+	return err
+}
+
+// QueryStatus reports whether the service is registered, its state and
+// the command line it runs.
+func QueryStatus(name string) (*Status, error) {
+	status := &Status{Name: name, State: constants.ServiceStateNotInstalled}
+	out, err := runCommand("sc.exe", "query", name)
+	if err != nil {
+		if strings.Contains(out, errorServiceDoesNotExist) {
+			return status, nil
+		}
+		return nil, err
+	}
+	status.Installed = true
+	status.State = scField(out, "STATE")
+
+	if out, err := runCommand("sc.exe", "qc", name); err == nil {
+		status.Location = scField(out, "BINARY_PATH_NAME")
+	}
+	return status, nil
+}
+
+// scField returns the value of a "NAME : value" line of sc.exe output.
+// For STATE, whose value is "4  RUNNING", only the state name is kept.
+func scField(out, field string) string {
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) != field {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if field == "STATE" {
+			if parts := strings.Fields(value); len(parts) > 1 {
+				return parts[1]
+			}
+		}
+		return value
+	}
+	return ""
+}
+
+type serviceStatus struct {
+	ServiceType             uint32
+	CurrentState            uint32
+	ControlsAccepted        uint32
+	Win32ExitCode           uint32
+	ServiceSpecificExitCode uint32
+	CheckPoint              uint32
+	WaitHint                uint32
+}
+
+type serviceTableEntry struct {
+	ServiceName *uint16
+	ServiceProc uintptr
+}
+
+// windowsService is the service this process runs. The service manager
+// callbacks carry no Go context, so it is held in a package variable.
+type windowsService struct {
+	name     *uint16
+	serve    func(stop <-chan struct{})
+	handle   uintptr
+	mu       sync.Mutex
+	state    uint32
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+var current *windowsService
+
+// Run hands the process to the service control manager and serves until
+// it sends a stop or shutdown control. Started outside the service
+// manager, it serves in the foreground instead.
+func Run(name string, serve func(stop <-chan struct{})) error {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	current = &windowsService{name: namePtr, serve: serve, stop: make(chan struct{})}
+
+	table := []serviceTableEntry{
+		{ServiceName: namePtr, ServiceProc: syscall.NewCallback(serviceMain)},
+		{},
+	}
+	// Blocks until the service has stopped
+	r1, _, err := procStartServiceCtrlDispatcherW.Call(uintptr(unsafe.Pointer(&table[0])))
+	if r1 == 0 {
+		if errors.Is(err, errorFailedServiceControllerConnect) {
+			serve(nil)
+			return nil
+		}
+		return fmt.Errorf("failed to connect to the service control manager: %w", err)
+	}
+	return nil
+}
+
+// serviceMain is the ServiceMain callback, run by the dispatcher.
+func serviceMain(argc, argv uintptr) uintptr {
+	s := current
+	handle, _, _ := procRegisterServiceCtrlHandlerExW.Call(
+		uintptr(unsafe.Pointer(s.name)),
+		syscall.NewCallback(serviceHandler),
+		0,
+	)
+	if handle == 0 {
+		return 0
+	}
+	s.handle = handle
+
+	s.setState(serviceStartPending)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.serve(s.stop)
+	}()
+	s.setState(serviceRunning)
+	<-done
+	s.setState(serviceStopped)
+	return 0
+}
+
+// serviceHandler is the HandlerEx callback receiving control requests.
+func serviceHandler(control, eventType, eventData, context uintptr) uintptr {
+	s := current
+	switch control {
+	case serviceControlStop, serviceControlShutdown:
+		s.setState(serviceStopPending)
+		s.stopOnce.Do(func() { close(s.stop) })
+	case serviceControlInterrogate:
+		s.mu.Lock()
+		state := s.state
+		s.mu.Unlock()
+		s.setState(state)
+	default:
+		return errorCallNotImplemented
+	}
+	return 0
+}
+
+// setState reports state to the service control manager.
+func (s *windowsService) setState(state uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+
+	status := serviceStatus{ServiceType: serviceWin32OwnProcess, CurrentState: state}
+	switch state {
+	case serviceRunning:
+		status.ControlsAccepted = serviceAcceptStop | serviceAcceptShutdown
+	case serviceStartPending, serviceStopPending:
+		status.WaitHint = uint32(constants.ShutdownTimeoutSecs * 1000)
+	}
+	procSetServiceStatus.Call(s.handle, uintptr(unsafe.Pointer(&status)))
+}
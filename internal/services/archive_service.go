@@ -0,0 +1,682 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/zeebo/blake3"
+
+	"silobang/internal/audit"
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/storage"
+	"silobang/plugin"
+)
+
+// ArchiveService moves assets nobody downloaded for a topic's idle period
+// (topic_archive) to an external archive, leaving a stub row in the topic,
+// and brings them back on request. Downloads are read from the audit log.
+type ArchiveService struct {
+	app    AppState
+	logger *logger.Logger
+	assets *AssetService
+	stats  *StatsCache
+
+	applyMu  sync.Mutex // one archive pass at a time, so passes never race on the same assets
+	recallMu sync.Mutex // one recall attempt at a time, so an asset is written back once
+
+	mu      sync.Mutex
+	running bool
+	stopCh  chan struct{}
+}
+
+// ArchiveResult is the outcome of one archive pass over a topic.
+type ArchiveResult struct {
+	Topic     string   `json:"topic"`
+	Archive   string   `json:"archive"`
+	DryRun    bool     `json:"dry_run"`
+	Hashes    []string `json:"hashes"` // assets archived, or that a dry run would archive
+	Bytes     int64    `json:"bytes"`
+	Skipped   []string `json:"skipped,omitempty"`   // idle assets that could not be archived
+	Truncated bool     `json:"truncated,omitempty"` // more assets are idle than one pass archives
+}
+
+// RecallResult is the state of an asset after a recall was requested.
+type RecallResult struct {
+	Hash        string `json:"hash"`
+	Topic       string `json:"topic"`
+	Status      string `json:"status"` // recalling, or stored once back in the topic
+	RecallError string `json:"recall_error,omitempty"`
+}
+
+// NewArchiveService creates a new archive service instance.
+func NewArchiveService(app AppState, log *logger.Logger, assets *AssetService, stats *StatsCache) *ArchiveService {
+	return &ArchiveService{
+		app:    app,
+		logger: log,
+		assets: assets,
+		stats:  stats,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Archive exports the idle assets of topic to the archive of its policy. A
+// dry run, requested or set on the policy, only reports the assets that
+// would be archived. by and ipAddress identify the actor for the audit log.
+func (s *ArchiveService) Archive(topic string, dryRun bool, by, ipAddress string) (*ArchiveResult, error) {
+	cfg := s.app.GetConfig()
+	if cfg == nil {
+		return nil, ErrNotConfigured
+	}
+	policy, ok := cfg.TopicArchive[topic]
+	if !ok {
+		return nil, ErrArchivePolicyNotFoundForTopic(topic)
+	}
+	return s.archive(topic, policy, dryRun || policy.DryRun, constants.ArchiveTriggerManual, by, ipAddress)
+}
+
+// ArchiveAll runs an archive pass over every configured topic. Unhealthy
+// topics are skipped until they recover.
+func (s *ArchiveService) ArchiveAll() {
+	cfg := s.app.GetConfig()
+	if cfg == nil || s.app.GetOrchestratorDB() == nil {
+		return
+	}
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicArchive)) {
+		if !s.app.TopicExists(topic) {
+			continue
+		}
+		if healthy, _ := s.app.IsTopicHealthy(topic); !healthy {
+			s.logger.Debug("[archive] skipping unhealthy topic %s", topic)
+			continue
+		}
+		policy := cfg.TopicArchive[topic]
+		if _, err := s.archive(topic, policy, policy.DryRun, constants.ArchiveTriggerScheduled, "", constants.AuditActorSystem); err != nil {
+			s.logger.Error("[archive] failed to archive idle assets of topic %s: %v", topic, err)
+		}
+	}
+}
+
+// List returns the archived assets of topic, most recently archived first.
+// status filters when not empty.
+func (s *ArchiveService) List(topic, status string) ([]database.ArchivedAsset, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+	if !s.app.TopicExists(topic) {
+		return nil, ErrTopicNotFoundWithName(topic)
+	}
+	if status != "" && status != constants.ArchiveStatusArchived && status != constants.ArchiveStatusRecalling {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("status must be %q or %q", constants.ArchiveStatusArchived, constants.ArchiveStatusRecalling))
+	}
+	archived, err := database.ListArchivedAssets(orchDB, topic, status, constants.ArchiveListMaxResults)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return archived, nil
+}
+
+func (s *ArchiveService) archive(topic string, policy config.ArchivePolicy, dryRun bool, trigger, by, ipAddress string) (*ArchiveResult, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+	if !s.app.TopicExists(topic) {
+		return nil, ErrTopicNotFoundWithName(topic)
+	}
+	if healthy, errMsg := s.app.IsTopicHealthy(topic); !healthy {
+		return nil, ErrTopicUnhealthyWithReason(topic, errMsg)
+	}
+
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+
+	// An asset counts as idle only if the log covers the whole period
+	cutoff := time.Now().Add(-policy.IdleTime()).Unix()
+	purged, err := audit.DownloadsPurgedSince(orchDB, cutoff)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if purged {
+		return nil, NewServiceError(constants.ErrCodeArchiveHistoryIncomplete,
+			fmt.Sprintf("the audit log no longer holds every download of the last %d days; keep downloaded entries at least that long", policy.IdleDays))
+	}
+
+	topicDB, err := s.app.GetTopicDB(topic)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	candidates, err := database.ListArchiveCandidates(topicDB, cutoff)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	downloaded, err := audit.DownloadedSince(orchDB, topic, cutoff)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	// Quarantined assets are held for review and trashed ones are on their
+	// way out
+	withheld, err := withheldHashes(s.app)
+	if err != nil {
+		return nil, err
+	}
+
+	var target plugin.Archive
+	if !dryRun {
+		if target, err = s.open(policy.Archive); err != nil {
+			return nil, WrapInternalError(err)
+		}
+	}
+
+	result := &ArchiveResult{
+		Topic:   topic,
+		Archive: policy.Archive,
+		DryRun:  dryRun,
+		Hashes:  []string{},
+	}
+	for _, a := range candidates {
+		if downloaded[a.AssetID] || withheld[a.AssetID] {
+			continue
+		}
+		if len(result.Hashes) >= constants.ArchiveMaxAssetsPerPass {
+			result.Truncated = true
+			break
+		}
+		if !dryRun {
+			if err := s.archiveAsset(topic, policy.Archive, target, a, by); err != nil {
+				s.logger.Warn("[archive] kept %s of topic %s: %v", a.AssetID, topic, err)
+				result.Skipped = append(result.Skipped, a.AssetID)
+				continue
+			}
+		}
+		result.Hashes = append(result.Hashes, a.AssetID)
+		result.Bytes += a.AssetSize
+	}
+
+	if len(result.Hashes) == 0 && len(result.Skipped) == 0 {
+		return result, nil
+	}
+	if !dryRun && s.stats != nil {
+		s.stats.InvalidateTopic(topic)
+	}
+
+	verb := "archived"
+	if dryRun {
+		verb = "would archive"
+	}
+	s.logger.Info("[archive] %s %d asset(s) (%d bytes) of topic %s to %s, %d kept", verb, len(result.Hashes), result.Bytes, topic, policy.Archive, len(result.Skipped))
+	if l := s.app.GetAuditLogger(); l != nil {
+		if err := l.Log(constants.AuditActionAssetsArchived, ipAddress, by, audit.AssetsArchivedDetails{
+			Topic:     topic,
+			Archive:   policy.Archive,
+			Trigger:   trigger,
+			DryRun:    dryRun,
+			Hashes:    result.Hashes,
+			Bytes:     result.Bytes,
+			Skipped:   result.Skipped,
+			Truncated: result.Truncated,
+		}); err != nil {
+			s.logger.Error("Failed to write audit entry for archive of topic %s: %v", topic, err)
+		}
+	}
+	return result, nil
+}
+
+// archiveAsset copies one asset to the archive, outside the topic write
+// lock, then turns its row into a stub under it. The copy is dropped if the
+// asset was deleted meanwhile.
+func (s *ArchiveService) archiveAsset(topic, archiveName string, target plugin.Archive, asset database.Asset, by string) error {
+	topicDB, err := s.app.GetTopicDB(topic)
+	if err != nil {
+		return err
+	}
+	reader, err := s.assets.blobStores.OpenData(topic, &asset)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Compaction removed the .dat file after the assets were listed;
+		// the row now names the entry's new location
+		if moved, getErr := database.GetAsset(topicDB, asset.AssetID); getErr == nil && moved != nil {
+			reader, err = s.assets.blobStores.OpenData(topic, moved)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open data file: %w", err)
+	}
+	tempPath, err := copyVerified(reader, asset.AssetID, asset.AssetSize)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempPath)
+
+	key := topic + "/" + asset.AssetID
+	if err := putFile(target, key, tempPath, asset.AssetSize); err != nil {
+		return fmt.Errorf("failed to write to archive %s: %w", archiveName, err)
+	}
+
+	topicMu := s.app.GetTopicWriteMu(topic)
+	topicMu.Lock()
+	defer topicMu.Unlock()
+
+	current, err := database.GetAsset(topicDB, asset.AssetID)
+	if err != nil {
+		return err
+	}
+	if current == nil || current.BlobName == constants.ArchiveBlobName {
+		if current == nil {
+			target.Delete(key)
+		}
+		return ErrAssetNotFoundWithHash(asset.AssetID)
+	}
+
+	txTopic, err := topicDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer txTopic.Rollback()
+
+	orchDB := s.app.GetOrchestratorDB()
+	txOrch, err := orchDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer txOrch.Rollback()
+
+	now := time.Now().Unix()
+	if err := database.StubAsset(txTopic, *current, now, by); err != nil {
+		return fmt.Errorf("failed to stub asset: %w", err)
+	}
+	if err := s.app.GetAssetIndex().Move(txOrch, asset.AssetID, constants.ArchiveBlobName); err != nil {
+		return fmt.Errorf("failed to update asset index: %w", err)
+	}
+	if err := database.InsertArchivedAssetTx(txOrch, database.ArchivedAsset{
+		Hash:       asset.AssetID,
+		Topic:      topic,
+		Archive:    archiveName,
+		ArchiveKey: key,
+		Size:       asset.AssetSize,
+		ArchivedAt: now,
+		ArchivedBy: by,
+	}); err != nil {
+		return err
+	}
+
+	// The archive record is committed first: a stub without one could not
+	// be recalled, while a record for an asset still in its topic is
+	// dropped by the next recall
+	if err := txOrch.Commit(); err != nil {
+		return fmt.Errorf("failed to commit orchestrator transaction: %w", err)
+	}
+	if err := txTopic.Commit(); err != nil {
+		s.forget(asset.AssetID, current.BlobName)
+		return fmt.Errorf("failed to commit topic transaction: %w", err)
+	}
+	s.assets.cache.Invalidate(asset.AssetID)
+	return nil
+}
+
+// forget drops the archive record of an asset whose topic still stores it
+// and points its index back at its .dat file.
+func (s *ArchiveService) forget(hash, datFile string) {
+	tx, err := s.app.GetOrchestratorDB().Begin()
+	if err == nil {
+		defer tx.Rollback()
+		if err = s.app.GetAssetIndex().Move(tx, hash, datFile); err == nil {
+			if err = database.DeleteArchivedAssetTx(tx, hash); err == nil {
+				err = tx.Commit()
+			}
+		}
+	}
+	if err != nil {
+		s.logger.Error("[archive] failed to drop the archive record of %s: %v", hash, err)
+	}
+}
+
+// Recall asks the archive for an archived asset and writes it back into its
+// topic as soon as the archive has it ready, which may be right away. A
+// recall that is not ready yet is retried by the scheduled pass. by and
+// ipAddress identify the actor for the audit log.
+func (s *ArchiveService) Recall(hash, by, ipAddress string) (*RecallResult, error) {
+	if len(hash) != constants.HashLength {
+		return nil, ErrInvalidHash
+	}
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+
+	rec, err := database.GetArchivedAsset(orchDB, hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if rec == nil {
+		exists, _, _, err := s.app.GetAssetIndex().Lookup(hash)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		if !exists {
+			return nil, ErrAssetNotFoundWithHash(hash)
+		}
+		return nil, ErrAssetNotArchivedWithHash(hash)
+	}
+
+	if rec.Status == constants.ArchiveStatusArchived {
+		if rec, err = database.RequestRecall(orchDB, hash, by, time.Now().Unix()); err != nil {
+			return nil, WrapInternalError(err)
+		}
+		if rec == nil {
+			return nil, ErrAssetNotArchivedWithHash(hash)
+		}
+		s.logger.Info("[archive] recall of %s from %s requested by %s", hash, rec.Archive, by)
+		if l := s.app.GetAuditLogger(); l != nil {
+			if err := l.Log(constants.AuditActionAssetRecallRequested, ipAddress, by, audit.AssetRecallDetails{
+				Hash:    hash,
+				Topic:   rec.Topic,
+				Archive: rec.Archive,
+				Size:    rec.Size,
+			}); err != nil {
+				s.logger.Error("Failed to write audit entry for recall of %s: %v", hash, err)
+			}
+		}
+	}
+
+	result := &RecallResult{Hash: hash, Topic: rec.Topic, Status: constants.ArchiveStatusRecalling}
+	done, err := s.advance(rec.Hash, ipAddress)
+	if err != nil {
+		result.RecallError = err.Error()
+	}
+	if done {
+		result.Status = constants.ArchiveStatusStored
+	}
+	return result, nil
+}
+
+// AdvanceRecalls retries every recall under way.
+func (s *ArchiveService) AdvanceRecalls() {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return
+	}
+	recalls, err := database.ListRecallingAssets(orchDB)
+	if err != nil {
+		s.logger.Error("[archive] %v", err)
+		return
+	}
+	for _, rec := range recalls {
+		if _, err := s.advance(rec.Hash, constants.AuditActorSystem); err != nil {
+			s.logger.Warn("[archive] recall of %s from %s: %v", rec.Hash, rec.Archive, err)
+		}
+	}
+}
+
+// advance moves a recall forward: it asks the archive again and, once the
+// object is readable, writes the asset back into its topic. Reports whether
+// the asset is stored in its topic again. A failure is recorded on the
+// archive record.
+func (s *ArchiveService) advance(hash, ipAddress string) (bool, error) {
+	s.recallMu.Lock()
+	defer s.recallMu.Unlock()
+
+	orchDB := s.app.GetOrchestratorDB()
+	rec, err := database.GetArchivedAsset(orchDB, hash)
+	if err != nil {
+		return false, err
+	}
+	if rec == nil {
+		return true, nil
+	}
+	if rec.Status != constants.ArchiveStatusRecalling {
+		return false, nil
+	}
+
+	done, err := s.restore(rec)
+	if err != nil {
+		msg := err.Error()
+		if len(msg) > constants.ArchiveRecallErrorMaxLen {
+			msg = msg[:constants.ArchiveRecallErrorMaxLen]
+		}
+		if setErr := database.SetRecallError(orchDB, hash, msg); setErr != nil {
+			s.logger.Error("[archive] %v", setErr)
+		}
+		return false, err
+	}
+	if !done {
+		return false, nil
+	}
+
+	s.logger.Info("[archive] %s recalled from %s into topic %s", hash, rec.Archive, rec.Topic)
+	if l := s.app.GetAuditLogger(); l != nil {
+		if err := l.Log(constants.AuditActionAssetRecalled, ipAddress, rec.RecallRequestedBy, audit.AssetRecallDetails{
+			Hash:    hash,
+			Topic:   rec.Topic,
+			Archive: rec.Archive,
+			Size:    rec.Size,
+		}); err != nil {
+			s.logger.Error("Failed to write audit entry for recall of %s: %v", hash, err)
+		}
+	}
+	return true, nil
+}
+
+// restore writes a recalled asset back into a new entry of its topic once
+// the archive has it ready, then removes it from the archive.
+func (s *ArchiveService) restore(rec *database.ArchivedAsset) (bool, error) {
+	if healthy, errMsg := s.app.IsTopicHealthy(rec.Topic); !healthy {
+		return false, ErrTopicUnhealthyWithReason(rec.Topic, errMsg)
+	}
+	source, err := s.open(rec.Archive)
+	if err != nil {
+		return false, err
+	}
+	ready, err := source.Recall(rec.ArchiveKey)
+	if err != nil || !ready {
+		return false, err
+	}
+
+	reader, err := source.Open(rec.ArchiveKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from archive %s: %w", rec.Archive, err)
+	}
+	tempPath, err := copyVerified(reader, rec.Hash, rec.Size)
+	reader.Close()
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tempPath)
+
+	topicMu := s.app.GetTopicWriteMu(rec.Topic)
+	topicMu.Lock()
+	defer topicMu.Unlock()
+
+	topicDB, err := s.app.GetTopicDB(rec.Topic)
+	if err != nil {
+		return false, err
+	}
+	current, err := database.GetAsset(topicDB, rec.Hash)
+	if err != nil {
+		return false, err
+	}
+	if current != nil && current.BlobName != constants.ArchiveBlobName {
+		// The stub was never committed; the topic still stores the asset
+		s.forget(rec.Hash, current.BlobName)
+		return true, nil
+	}
+	if current == nil {
+		return false, ErrAssetNotFoundWithHash(rec.Hash)
+	}
+
+	maxDatSize := s.app.GetConfig().MaxDatSize
+	if maxDatSize == 0 {
+		maxDatSize = constants.DefaultMaxDatSize
+	}
+	topicPath := s.app.GetTopicPath(rec.Topic)
+	datFile, _, err := storage.DetermineTargetDatFile(topicPath, int64(constants.HeaderSize)+rec.Size, maxDatSize)
+	if err != nil {
+		return false, fmt.Errorf("failed to determine dat file: %w", err)
+	}
+
+	txTopic, err := topicDB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer txTopic.Rollback()
+
+	txOrch, err := s.app.GetOrchestratorDB().Begin()
+	if err != nil {
+		return false, err
+	}
+	defer txOrch.Rollback()
+
+	byteOffset, err := s.assets.appendFromTempFile(filepath.Join(topicPath, datFile), rec.Hash, tempPath, rec.Size)
+	if err != nil {
+		return false, fmt.Errorf("failed to append to dat file: %w", err)
+	}
+	if err := database.MoveAsset(txTopic, rec.Hash, datFile, byteOffset); err != nil {
+		return false, fmt.Errorf("failed to move asset: %w", err)
+	}
+	prevHash, entryCount, err := database.GetDatHashTx(txTopic, datFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to get dat hash: %w", err)
+	}
+	if prevHash == "" {
+		prevHash = storage.GenesisHash(datFile)
+		entryCount = 0
+	}
+	runningHash, err := storage.ComputeRunningHash(prevHash, rec.Hash, byteOffset, rec.Size)
+	if err != nil {
+		return false, fmt.Errorf("failed to compute running hash: %w", err)
+	}
+	if err := database.UpdateDatHash(txTopic, datFile, runningHash, entryCount+1); err != nil {
+		return false, fmt.Errorf("failed to update dat hash: %w", err)
+	}
+	if err := s.app.GetAssetIndex().Move(txOrch, rec.Hash, datFile); err != nil {
+		return false, fmt.Errorf("failed to update asset index: %w", err)
+	}
+	if err := database.DeleteArchivedAssetTx(txOrch, rec.Hash); err != nil {
+		return false, err
+	}
+
+	if err := txTopic.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit topic transaction: %w", err)
+	}
+	// A record left behind is dropped by the next attempt, which finds the
+	// asset stored again
+	if err := txOrch.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit orchestrator transaction: %w", err)
+	}
+	if s.stats != nil {
+		s.stats.InvalidateTopic(rec.Topic)
+	}
+	if err := source.Delete(rec.ArchiveKey); err != nil {
+		s.logger.Warn("[archive] failed to remove %s from %s after its recall: %v", rec.ArchiveKey, rec.Archive, err)
+	}
+	return true, nil
+}
+
+// discard removes the archived copy of a deleted asset.
+func (s *ArchiveService) discard(rec *database.ArchivedAsset) {
+	target, err := s.open(rec.Archive)
+	if err == nil {
+		err = target.Delete(rec.ArchiveKey)
+	}
+	if err != nil {
+		s.logger.Warn("[archive] failed to remove %s from %s after its deletion: %v", rec.ArchiveKey, rec.Archive, err)
+	}
+}
+
+// open returns the configured archive name.
+func (s *ArchiveService) open(name string) (plugin.Archive, error) {
+	archive, ok := s.app.GetConfig().Archives[name]
+	if !ok {
+		return nil, fmt.Errorf("archive %q is not configured", name)
+	}
+	return plugin.NewArchive(archive.Type, archive.Options)
+}
+
+// copyVerified copies an asset's content to a temp file and checks it
+// against the asset's hash and size. Returns the temp file path.
+func copyVerified(r io.Reader, hash string, size int64) (string, error) {
+	f, err := os.CreateTemp("", constants.ArchiveTempPattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	hasher := blake3.New()
+	n, err := io.Copy(io.MultiWriter(f, hasher), r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n != size {
+		err = fmt.Errorf("read %d of %d bytes", n, size)
+	}
+	if sum := hex.EncodeToString(hasher.Sum(nil)); err == nil && sum != hash {
+		err = fmt.Errorf("content hashes to %s", sum)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to copy %s: %w", hash, err)
+	}
+	return f.Name(), nil
+}
+
+// putFile stores a file in an archive.
+func putFile(target plugin.Archive, key, path string, size int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return target.Put(key, f, size)
+}
+
+// Start launches the scheduled archive passes and recall retries.
+// Safe to call multiple times — subsequent calls are no-ops.
+func (s *ArchiveService) Start(interval time.Duration) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info("[archive] scheduled archiving started (interval: %v)", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				s.logger.Info("[archive] scheduled archiving stopped")
+				return
+			case <-ticker.C:
+				s.AdvanceRecalls()
+				s.ArchiveAll()
+			}
+		}
+	}()
+}
+
+// Stop signals the scheduled archiving goroutine to exit.
+func (s *ArchiveService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stopCh)
+		s.running = false
+	}
+}
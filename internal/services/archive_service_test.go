@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+)
+
+func TestArchiveService_SelectsIdleAssets(t *testing.T) {
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+
+	now := time.Now().Unix()
+	idle := strings.Repeat("a", constants.HashLength)
+	downloaded := strings.Repeat("b", constants.HashLength)
+	trashed := strings.Repeat("c", constants.HashLength)
+	recent := strings.Repeat("d", constants.HashLength)
+	var assets []testAsset
+	var entries []orchestratorEntry
+	for _, a := range []struct {
+		hash    string
+		created int64
+	}{{idle, 1700000000}, {downloaded, 1700000001}, {trashed, 1700000002}, {recent, now}} {
+		assets = append(assets, testAsset{id: a.hash, size: 10, ext: "bin", blobName: "001.dat", createdAt: a.created})
+		entries = append(entries, orchestratorEntry{hash: a.hash, topic: "photos", datFile: "001.dat"})
+	}
+	topicDB := setupTopicDir(t, workDir, "photos", assets)
+	if _, err := topicDB.Exec("UPDATE assets SET origin_name = 'photo'"); err != nil {
+		t.Fatalf("failed to name assets: %v", err)
+	}
+	mock.StoreTopicDB("photos", topicDB)
+	mock.RegisterTopic("photos", true, "")
+	mock.orchestratorDB = setupOrchestratorDB(t, workDir, entries)
+
+	addAudit := func(action, details string) {
+		t.Helper()
+		if _, err := mock.orchestratorDB.Exec(
+			"INSERT INTO audit_log (timestamp, action, ip_address, username, details_json) VALUES (?, ?, '127.0.0.1', 'alice', ?)",
+			now, action, details); err != nil {
+			t.Fatalf("failed to insert audit entry: %v", err)
+		}
+	}
+	addAudit(constants.AuditActionDownloaded, fmt.Sprintf(`{"hash":%q,"topic":"photos"}`, downloaded))
+	if _, _, err := database.TrashAsset(mock.orchestratorDB, database.TrashEntry{Hash: trashed, Topic: "photos", TrashedAt: now}); err != nil {
+		t.Fatalf("failed to trash asset: %v", err)
+	}
+
+	svc := NewArchiveService(mock, mock.log, NewAssetService(mock, mock.log), nil)
+
+	if _, err := svc.Archive("photos", true, "alice", "127.0.0.1"); !isServiceErrorCode(err, constants.ErrCodeArchivePolicyNotFound) {
+		t.Fatalf("expected ARCHIVE_POLICY_NOT_FOUND, got %v", err)
+	}
+	mock.cfg.Archives = map[string]config.PluginConfig{
+		"tape": {Type: constants.PluginArchiveFS, Options: map[string]string{"path": t.TempDir()}},
+	}
+	mock.cfg.TopicArchive = map[string]config.ArchivePolicy{"photos": {Archive: "tape", IdleDays: 30}}
+
+	// Only the asset nobody downloaded, and that is not on its way out, is idle
+	result, err := svc.Archive("photos", true, "alice", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if !result.DryRun || result.Archive != "tape" || !slices.Equal(result.Hashes, []string{idle}) || result.Bytes != 10 {
+		t.Errorf("unexpected dry run result: %+v", result)
+	}
+	if list, err := svc.List("photos", ""); err != nil || len(list) != 0 {
+		t.Errorf("dry run archived assets: %+v, %v", list, err)
+	}
+
+	if _, err := svc.Recall(recent, "alice", "127.0.0.1"); !isServiceErrorCode(err, constants.ErrCodeAssetNotArchived) {
+		t.Errorf("expected ASSET_NOT_ARCHIVED, got %v", err)
+	}
+	if _, err := svc.Recall(strings.Repeat("e", constants.HashLength), "alice", "127.0.0.1"); !isServiceErrorCode(err, constants.ErrCodeAssetNotFound) {
+		t.Errorf("expected ASSET_NOT_FOUND, got %v", err)
+	}
+
+	// Once purges removed downloads within the idle period, nothing is idle
+	addAudit(constants.AuditActionAuditPurged, fmt.Sprintf(`{"ranges":[{"action":%q,"last_timestamp":%d}]}`, constants.AuditActionDownloaded, now-60))
+	if _, err := svc.Archive("photos", true, "alice", "127.0.0.1"); !isServiceErrorCode(err, constants.ErrCodeArchiveHistoryIncomplete) {
+		t.Errorf("expected ARCHIVE_HISTORY_INCOMPLETE, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"container/list"
+	"sync"
+)
+
+// AssetCacheStatus reports the size and effectiveness of the asset cache.
+type AssetCacheStatus struct {
+	Enabled       bool    `json:"enabled"`
+	Entries       int     `json:"entries"`
+	Bytes         int64   `json:"bytes"`
+	MaxBytes      int64   `json:"max_bytes"`
+	MaxAssetBytes int64   `json:"max_asset_bytes"`
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRatio      float64 `json:"hit_ratio"` // hits / (hits + misses); 0 before the first lookup
+	Evictions     uint64  `json:"evictions"`
+}
+
+// cachedAsset is one asset held in memory.
+type cachedAsset struct {
+	info AssetInfo
+	data []byte
+}
+
+// AssetCache is an in-memory LRU cache of small asset contents keyed by hash.
+// Asset bytes are immutable once written, so entries never go stale; they
+// only leave the cache when evicted for space or invalidated because the
+// asset (or its whole topic) is gone.
+type AssetCache struct {
+	maxBytes      int64
+	maxAssetBytes int64
+
+	mu        sync.Mutex
+	entries   map[string]*list.Element // hash -> element holding *cachedAsset
+	order     *list.List               // front = most recently used
+	bytes     int64
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// NewAssetCache creates a cache holding at most maxBytes of asset data, and
+// no asset larger than maxAssetBytes. A maxBytes of 0 disables the cache.
+func NewAssetCache(maxBytes, maxAssetBytes int64) *AssetCache {
+	return &AssetCache{
+		maxBytes:      maxBytes,
+		maxAssetBytes: maxAssetBytes,
+		entries:       make(map[string]*list.Element),
+		order:         list.New(),
+	}
+}
+
+// Enabled reports whether the cache stores anything.
+func (c *AssetCache) Enabled() bool {
+	return c != nil && c.maxBytes > 0
+}
+
+// Cacheable reports whether an asset of the given size may be cached.
+func (c *AssetCache) Cacheable(size int64) bool {
+	return c.Enabled() && size <= c.maxAssetBytes && size <= c.maxBytes
+}
+
+// Get returns the cached info and contents of an asset and marks it as
+// recently used. The returned slice must not be modified.
+func (c *AssetCache) Get(hash string) (AssetInfo, []byte, bool) {
+	if !c.Enabled() {
+		return AssetInfo{}, nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[hash]
+	if !ok {
+		c.misses++
+		return AssetInfo{}, nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	entry := elem.Value.(*cachedAsset)
+	return entry.info, entry.data, true
+}
+
+// Put stores an asset, evicting the least recently used entries to make
+// room. Assets that are not Cacheable are ignored.
+func (c *AssetCache) Put(info AssetInfo, data []byte) {
+	size := int64(len(data))
+	if !c.Cacheable(size) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[info.Hash]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	for c.bytes+size > c.maxBytes {
+		c.removeElement(c.order.Back())
+		c.evictions++
+	}
+
+	c.entries[info.Hash] = c.order.PushFront(&cachedAsset{info: info, data: data})
+	c.bytes += size
+}
+
+// Invalidate drops an asset from the cache.
+func (c *AssetCache) Invalidate(hash string) {
+	if !c.Enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[hash]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// InvalidateTopic drops every cached asset stored in the topic.
+func (c *AssetCache) InvalidateTopic(topicName string) {
+	if !c.Enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*cachedAsset).info.TopicName == topicName {
+			c.removeElement(elem)
+		}
+		elem = next
+	}
+}
+
+// Status returns the current size and hit/miss counters.
+func (c *AssetCache) Status() AssetCacheStatus {
+	if c == nil {
+		return AssetCacheStatus{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := AssetCacheStatus{
+		Enabled:       c.maxBytes > 0,
+		Entries:       len(c.entries),
+		Bytes:         c.bytes,
+		MaxBytes:      c.maxBytes,
+		MaxAssetBytes: c.maxAssetBytes,
+		Hits:          c.hits,
+		Misses:        c.misses,
+		Evictions:     c.evictions,
+	}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		status.HitRatio = float64(c.hits) / float64(lookups)
+	}
+	return status
+}
+
+// removeElement unlinks an entry; the caller holds mu.
+func (c *AssetCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*cachedAsset)
+	c.order.Remove(elem)
+	delete(c.entries, entry.info.Hash)
+	c.bytes -= int64(len(entry.data))
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+)
+
+func cacheTestAsset(hash, topic string) AssetInfo {
+	return AssetInfo{Hash: hash, TopicName: topic}
+}
+
+func TestAssetCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewAssetCache(10, 10)
+
+	cache.Put(cacheTestAsset("a", "t"), []byte("aaaa"))
+	cache.Put(cacheTestAsset("b", "t"), []byte("bbbb"))
+
+	// Touch "a" so "b" becomes the eviction candidate
+	if _, _, ok := cache.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	cache.Put(cacheTestAsset("c", "t"), []byte("cccc"))
+
+	if _, _, ok := cache.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, data, ok := cache.Get("a"); !ok || string(data) != "aaaa" {
+		t.Errorf("expected a to survive, got %q ok=%v", data, ok)
+	}
+
+	status := cache.Status()
+	if status.Entries != 2 || status.Bytes != 8 || status.Evictions != 1 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestAssetCache_HitRatio(t *testing.T) {
+	cache := NewAssetCache(100, 100)
+	cache.Put(cacheTestAsset("a", "t"), []byte("data"))
+
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("missing")
+
+	status := cache.Status()
+	if status.Hits != 3 || status.Misses != 1 {
+		t.Fatalf("expected 3 hits and 1 miss, got %+v", status)
+	}
+	if status.HitRatio != 0.75 {
+		t.Errorf("expected hit ratio 0.75, got %v", status.HitRatio)
+	}
+}
+
+func TestAssetCache_SkipsLargeAssets(t *testing.T) {
+	cache := NewAssetCache(100, 4)
+
+	cache.Put(cacheTestAsset("big", "t"), []byte("too large"))
+	if _, _, ok := cache.Get("big"); ok {
+		t.Error("assets over max_asset_bytes should not be cached")
+	}
+	if cache.Status().Bytes != 0 {
+		t.Error("expected no bytes held")
+	}
+}
+
+func TestAssetCache_Invalidate(t *testing.T) {
+	cache := NewAssetCache(100, 100)
+	cache.Put(cacheTestAsset("a", "one"), []byte("a"))
+	cache.Put(cacheTestAsset("b", "one"), []byte("b"))
+	cache.Put(cacheTestAsset("c", "two"), []byte("c"))
+
+	cache.Invalidate("a")
+	if _, _, ok := cache.Get("a"); ok {
+		t.Error("expected a to be invalidated")
+	}
+
+	cache.InvalidateTopic("one")
+	if _, _, ok := cache.Get("b"); ok {
+		t.Error("expected topic one to be invalidated")
+	}
+	if _, _, ok := cache.Get("c"); !ok {
+		t.Error("expected topic two to stay cached")
+	}
+	if status := cache.Status(); status.Entries != 1 || status.Bytes != 1 {
+		t.Errorf("unexpected status after invalidation: %+v", status)
+	}
+}
+
+func TestAssetCache_Disabled(t *testing.T) {
+	cache := NewAssetCache(0, 0)
+	cache.Put(cacheTestAsset("a", "t"), []byte("a"))
+
+	if _, _, ok := cache.Get("a"); ok {
+		t.Error("disabled cache should not store assets")
+	}
+	if status := cache.Status(); status.Enabled || status.Misses != 0 {
+		t.Errorf("disabled cache should not count lookups: %+v", status)
+	}
+}
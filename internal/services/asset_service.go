@@ -1,32 +1,53 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/zeebo/blake3"
 
+	"silobang/internal/audit"
 	"silobang/internal/constants"
 	"silobang/internal/database"
 	"silobang/internal/logger"
+	"silobang/internal/media"
 	"silobang/internal/sanitize"
 	"silobang/internal/storage"
 )
 
 // UploadResult contains the result of an asset upload operation.
+// Status is one of constants.UploadStatus*, Reason one of
+// constants.UploadReason*.
 type UploadResult struct {
 	Hash          string `json:"hash"`
 	Size          int64  `json:"size"`
 	BlobName      string `json:"blob"`
-	Skipped       bool   `json:"skipped"`
+	Status        string `json:"status"`
+	Reason        string `json:"reason"`
 	ExistingTopic string `json:"existing_topic,omitempty"`
+
+	// Quarantined is set when the scanner flagged the content and it was
+	// stored quarantined (scan.quarantine_infected) instead of rejected.
+	Quarantined bool `json:"quarantined,omitempty"`
+
+	// Deprecated: use Status. True for deduplicated and aliased uploads;
+	// kept in API responses for one release.
+	Skipped bool `json:"skipped"`
 }
 
 // AssetInfo contains information about an asset for download.
@@ -37,6 +58,7 @@ type AssetInfo struct {
 	Extension   string
 	ContentType string
 	TopicName   string
+	CreatedAt   int64 // unix timestamp of first upload
 }
 
 // AssetReader wraps a file reader with asset metadata.
@@ -47,62 +69,189 @@ type AssetReader struct {
 
 // AssetService handles asset upload, download, and management operations.
 type AssetService struct {
-	app    AppState
-	logger *logger.Logger
+	app        AppState
+	logger     *logger.Logger
+	cache      *AssetCache
+	archives   *ArchiveService
+	blobStores *BlobStoreService
+	validation *ValidationService
+	previews   *PreviewService
 }
 
 // NewAssetService creates a new asset service instance.
 func NewAssetService(app AppState, log *logger.Logger) *AssetService {
+	var maxBytes, maxAssetBytes int64
+	if cfg := app.GetConfig(); cfg != nil && !cfg.AssetCache.Disabled {
+		maxBytes = cfg.AssetCache.MaxBytes
+		maxAssetBytes = cfg.AssetCache.MaxAssetBytes
+	}
+
 	return &AssetService{
 		app:    app,
 		logger: log,
+		cache:  NewAssetCache(maxBytes, maxAssetBytes),
 	}
 }
 
+// Cache returns the in-memory cache of small asset contents.
+func (s *AssetService) Cache() *AssetCache {
+	return s.cache
+}
+
+// SetArchiveService sets the service whose archived copies are removed with
+// the assets deleted.
+func (s *AssetService) SetArchiveService(archives *ArchiveService) {
+	s.archives = archives
+}
+
+// SetBlobStoreService sets the service reading .dat files offloaded to blob
+// stores. Called after BlobStoreService is initialized in the services
+// container.
+func (s *AssetService) SetBlobStoreService(blobStores *BlobStoreService) {
+	s.blobStores = blobStores
+}
+
+// SetValidationService sets the service validating uploads before they are
+// stored. Called after ValidationService is initialized in the services
+// container.
+func (s *AssetService) SetValidationService(validation *ValidationService) {
+	s.validation = validation
+}
+
+// SetPreviewService sets the service rendering the previews of new uploads
+// and removing those of deleted assets. Called after PreviewService is
+// initialized in the services container.
+func (s *AssetService) SetPreviewService(previews *PreviewService) {
+	s.previews = previews
+}
+
 // Upload handles the complete upload workflow for an asset.
 // It streams the file to disk while computing the hash, checks for duplicates,
-// and atomically writes to the DAT file and database.
-func (s *AssetService) Upload(ctx context.Context, topicName string, reader io.Reader, filename string, parentID *string) (*UploadResult, error) {
-	// Get max size from config
-	maxSize := s.app.GetConfig().MaxDatSize
-	if maxSize == 0 {
-		maxSize = constants.DefaultMaxDatSize
+// and atomically writes to the DAT file and database. New assets are stamped
+// with the configured default metadata and relativePath, when given, in the
+// same commit; uploader fills the {{username}} placeholder. relativePath must
+// already be sanitized.
+func (s *AssetService) Upload(ctx context.Context, topicName string, reader io.Reader, filename string, parentID *string, relativePath, uploader string) (*UploadResult, error) {
+	// Frozen topics are refused before the body is read
+	if err := checkTopicWritable(s.app, topicName); err != nil {
+		return nil, err
 	}
-
-	// Validate parent if provided
-	if parentID != nil && *parentID != "" {
-		exists, _, _, err := database.CheckHashExists(s.app.GetOrchestratorDB(), *parentID)
-		if err != nil {
-			return nil, WrapInternalError(err)
-		}
-		if !exists {
-			return nil, NewServiceError(constants.ErrCodeParentNotFound, "parent asset not found")
-		}
+	if err := s.ValidateParent(parentID); err != nil {
+		return nil, err
 	}
 
-	// Sanitize filename to prevent path traversal, header injection, and control character attacks
-	cleanFilename := sanitize.Filename(filename)
-	ext := ""
-	originName := ""
-	if idx := strings.LastIndex(cleanFilename, "."); idx != -1 {
-		ext = sanitize.Extension(cleanFilename[idx+1:])
-		originName = sanitize.OriginName(cleanFilename[:idx])
-	} else {
-		originName = sanitize.OriginName(cleanFilename)
-	}
-	s.logger.Debug("Sanitized upload filename: original=%q sanitized=%q originName=%q ext=%q",
-		filename, cleanFilename, originName, ext)
+	// The extension's storage policy sets the size limit
+	_, ext := splitFilename(sanitize.Filename(filename))
+	maxSize := s.app.GetConfig().StoragePolicy(ext).MaxSizeBytes + int64(constants.HeaderSize)
 
 	// Stream file to temp file while computing hash (outside lock - I/O intensive and safe)
 	tempFile, hash, size, err := s.streamToTempWithHash(reader, maxSize)
 	if err != nil {
-		if err.Error() == "file too large" {
+		if errors.Is(err, errStreamTooLarge) {
 			return nil, ErrAssetTooLarge
 		}
 		return nil, WrapInternalError(err)
 	}
 	defer os.Remove(tempFile)
 
+	return s.storeTempFile(ctx, topicName, tempFile, hash, size, filename, parentID, relativePath, uploader)
+}
+
+// UploadStaged stores a complete file already on disk, such as the bytes
+// received by a resumable upload session, exactly as Upload stores a stream.
+// hash is the file's BLAKE3 hash. The file is left in place.
+func (s *AssetService) UploadStaged(ctx context.Context, topicName, path, hash string, size int64, filename string, parentID *string, relativePath, uploader string) (*UploadResult, error) {
+	if err := checkTopicWritable(s.app, topicName); err != nil {
+		return nil, err
+	}
+	if err := s.ValidateParent(parentID); err != nil {
+		return nil, err
+	}
+	_, ext := splitFilename(sanitize.Filename(filename))
+	if size > s.app.GetConfig().StoragePolicy(ext).MaxSizeBytes {
+		return nil, ErrAssetTooLarge
+	}
+	return s.storeTempFile(ctx, topicName, path, hash, size, filename, parentID, relativePath, uploader)
+}
+
+// ValidateParent checks that a lineage parent, when given, is indexed.
+func (s *AssetService) ValidateParent(parentID *string) error {
+	if parentID == nil || *parentID == "" {
+		return nil
+	}
+	exists, _, _, err := s.app.GetAssetIndex().Lookup(*parentID)
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	if !exists {
+		return NewServiceError(constants.ErrCodeParentNotFound, "parent asset not found")
+	}
+	return nil
+}
+
+// storeTempFile scans a hashed file and commits it to the topic, or reports
+// the asset already holding its content.
+func (s *AssetService) storeTempFile(ctx context.Context, topicName, tempFile, hash string, size int64, filename string, parentID *string, relativePath, uploader string) (*UploadResult, error) {
+	// Sanitize filename to prevent path traversal, header injection, and control character attacks
+	cleanFilename := sanitize.Filename(filename)
+	originName, ext := splitFilename(cleanFilename)
+	s.logger.Debug("Sanitized upload filename: original=%q sanitized=%q originName=%q ext=%q",
+		filename, cleanFilename, originName, ext)
+
+	// The extension's storage policy decides on scanning
+	cfg := s.app.GetConfig()
+	policy := cfg.StoragePolicy(ext)
+
+	// Scan before anything is stored (outside lock - may be slow). With
+	// scan.quarantine_infected, flagged content is stored quarantined
+	// instead of rejected.
+	var quarantine *database.QuarantineEntry
+	if policy.Scan {
+		if err := scanUpload(ctx, cfg.Scan, tempFile); err != nil {
+			var svcErr *ServiceError
+			if !cfg.Scan.QuarantineInfected || !errors.As(err, &svcErr) || svcErr.Code != constants.ErrCodeUploadScanRejected {
+				s.logger.Warn("Upload scan of %s (%s) did not pass: %v", cleanFilename, hash, err)
+				return nil, err
+			}
+			s.logger.Warn("Upload scan flagged %s (%s), storing it quarantined: %v", cleanFilename, hash, err)
+			quarantine = &database.QuarantineEntry{
+				Hash:   hash,
+				Topic:  topicName,
+				Source: constants.QuarantineSourceScan,
+				Reason: svcErr.Message,
+			}
+		} else {
+			s.logger.Debug("Upload scan passed for %s", hash)
+		}
+	}
+
+	// Validate the content of extensions with a validator (outside lock -
+	// may be slow). Lenient topics store invalid uploads marked invalid.
+	var validation *ValidationOutcome
+	if s.validation != nil {
+		var err error
+		validation, err = s.validation.Validate(ctx, topicName, ext, tempFile)
+		if err != nil {
+			s.logger.Warn("Upload validation of %s (%s) did not pass: %v", cleanFilename, hash, err)
+			return nil, err
+		}
+		if validation != nil && !validation.Valid {
+			s.logger.Warn("Upload validation found %s (%s) invalid, storing it: %s", cleanFilename, hash,
+				strings.Join(validation.Errors, constants.ValidationErrorSeparator))
+		}
+	}
+
+	// Sniff the content type and read media properties with
+	// metadata.media_info (outside lock). An upload that cannot be
+	// inspected is stored without them.
+	var mediaInfo *media.Info
+	if cfg.Metadata.MediaInfo {
+		var err error
+		if mediaInfo, err = media.InspectFile(tempFile); err != nil {
+			s.logger.Warn("Media inspection of %s (%s) failed: %v", cleanFilename, hash, err)
+		}
+	}
+
 	// Acquire per-topic write mutex for the critical section:
 	// duplicate check + dat file write + DB commit must be serialized
 	// to prevent byte offset collisions and duplicate detection races
@@ -113,17 +262,43 @@ func (s *AssetService) Upload(ctx context.Context, topicName string, reader io.R
 	s.logger.Debug("Acquired write lock for topic %s, hash %s", topicName, hash)
 
 	// Check for duplicate (inside lock to prevent race)
-	exists, existingTopic, _, err := database.CheckHashExists(s.app.GetOrchestratorDB(), hash)
+	exists, existingTopic, _, err := s.app.GetAssetIndex().Lookup(hash)
 	if err != nil {
 		return nil, WrapInternalError(err)
 	}
 	if exists {
 		s.logger.Debug("Duplicate detected for hash %s in topic %s, skipping", hash, existingTopic)
+		status, reason := constants.UploadStatusAliased, constants.UploadReasonOtherTopic
+		if existingTopic == topicName {
+			status, reason = constants.UploadStatusDeduplicated, constants.UploadReasonSameTopic
+		}
+		// Content stored before scanning was enabled is quarantined where it is
+		if quarantine != nil {
+			quarantine.Topic = existingTopic
+			quarantine.QuarantinedAt = time.Now().Unix()
+			entry, created, err := database.QuarantineAsset(s.app.GetOrchestratorDB(), *quarantine)
+			if err != nil {
+				return nil, WrapInternalError(err)
+			}
+			if created {
+				logQuarantined(s.app, s.logger, entry, constants.AuditActorSystem)
+			}
+		}
+		// Uploading trashed content again takes it out of the trash
+		entry, err := database.RestoreTrash(s.app.GetOrchestratorDB(), hash)
+		if err == nil {
+			logRestored(s.app, s.logger, entry, uploader, constants.AuditActorSystem)
+		} else if !errors.Is(err, database.ErrNotTrashed) {
+			return nil, WrapInternalError(err)
+		}
 		return &UploadResult{
 			Hash:          hash,
+			Status:        status,
+			Reason:        reason,
 			Skipped:       true,
 			ExistingTopic: existingTopic,
 			Size:          size,
+			Quarantined:   quarantine != nil,
 		}, nil
 	}
 
@@ -133,21 +308,72 @@ func (s *AssetService) Upload(ctx context.Context, topicName string, reader io.R
 		return nil, s.wrapTopicError(topicName, err)
 	}
 
+	// An earlier upload of this content may have reached the topic without
+	// being indexed (UPLOAD_INDEX_FAILED); the retry indexes it instead of
+	// storing it twice
+	stored, err := database.GetAsset(topicDB, hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if stored != nil {
+		if quarantine != nil {
+			quarantine.QuarantinedAt = time.Now().Unix()
+		}
+		if err := s.indexStoredAsset(stored, topicName, quarantine); err != nil {
+			return nil, WrapServiceError(constants.ErrCodeUploadIndexFailed,
+				fmt.Sprintf("asset %s is stored in topic %s but could not be indexed; retry the upload", hash, topicName), err)
+		}
+		s.logger.Info("Indexed %s, stored in topic %s by an earlier upload", hash, topicName)
+		if quarantine != nil {
+			logQuarantined(s.app, s.logger, quarantine, constants.AuditActorSystem)
+		}
+		return &UploadResult{
+			Hash:          hash,
+			Status:        constants.UploadStatusDeduplicated,
+			Reason:        constants.UploadReasonSameTopic,
+			Skipped:       true,
+			ExistingTopic: topicName,
+			Size:          size,
+			Quarantined:   quarantine != nil,
+		}, nil
+	}
+
+	// New content counts toward the topic's quota
+	if err := checkTopicQuota(s.app, s.logger, topicName, topicDB, size); err != nil {
+		return nil, err
+	}
+
 	topicPath := s.app.GetTopicPath(topicName)
 
 	// Write asset using pipeline (inside lock - dat file write + DB commit)
-	asset, err := s.writeAssetFromTempFile(topicDB, topicName, topicPath, tempFile, hash, size, ext, originName, parentID)
+	asset, err := s.writeAssetFromTempFile(topicDB, topicName, topicPath, tempFile, hash, size, ext, originName, parentID, relativePath, uploader, cleanFilename, quarantine, validation, mediaInfo)
 	if err != nil {
+		var svcErr *ServiceError
+		if errors.As(err, &svcErr) {
+			return nil, err
+		}
+		if storage.IsNoSpace(err) {
+			return nil, WrapServiceError(constants.ErrCodeStorageFull,
+				fmt.Sprintf("storage full: no room to write %d bytes to topic %s", size, topicName), err)
+		}
 		return nil, WrapInternalError(err)
 	}
 
 	s.logger.Debug("Uploaded asset %s to topic %s", hash, topicName)
+	if quarantine != nil {
+		logQuarantined(s.app, s.logger, quarantine, constants.AuditActorSystem)
+	} else if s.previews != nil {
+		s.previews.Enqueue(asset.AssetID)
+	}
 
 	return &UploadResult{
-		Hash:     asset.AssetID,
-		Size:     asset.AssetSize,
-		BlobName: asset.BlobName,
-		Skipped:  false,
+		Hash:        asset.AssetID,
+		Size:        asset.AssetSize,
+		BlobName:    asset.BlobName,
+		Status:      constants.UploadStatusCreated,
+		Reason:      constants.UploadReasonNewContent,
+		Skipped:     false,
+		Quarantined: quarantine != nil,
 	}, nil
 }
 
@@ -160,7 +386,7 @@ func (s *AssetService) GetReader(hash string) (*AssetReader, error) {
 	}
 
 	// Look up in orchestrator to find topic
-	exists, topicName, _, err := database.CheckHashExists(s.app.GetOrchestratorDB(), hash)
+	exists, topicName, _, err := s.app.GetAssetIndex().Lookup(hash)
 	if err != nil {
 		return nil, WrapInternalError(err)
 	}
@@ -168,12 +394,33 @@ func (s *AssetService) GetReader(hash string) (*AssetReader, error) {
 		return nil, ErrAssetNotFoundWithHash(hash)
 	}
 
+	// Quarantined content is withheld until released
+	if err := checkQuarantine(s.app, hash); err != nil {
+		return nil, err
+	}
+	// Trashed content is withheld until restored
+	if err := checkTrash(s.app, hash); err != nil {
+		return nil, err
+	}
+
 	// Check topic health
 	healthy, errMsg := s.app.IsTopicHealthy(topicName)
 	if !healthy {
 		return nil, ErrTopicUnhealthyWithReason(topicName, errMsg)
 	}
 
+	// Serve hot small assets from memory. An entry recorded under another
+	// topic (e.g. before a working directory switch) is refreshed from disk.
+	if info, data, ok := s.cache.Get(hash); ok {
+		if info.TopicName == topicName {
+			return &AssetReader{
+				ReadCloser: io.NopCloser(bytes.NewReader(data)),
+				Info:       &info,
+			}, nil
+		}
+		s.cache.Invalidate(hash)
+	}
+
 	// Get asset details from topic DB
 	topicDB, err := s.app.GetTopicDB(topicName)
 	if err != nil {
@@ -187,6 +434,10 @@ func (s *AssetService) GetReader(hash string) (*AssetReader, error) {
 	if asset == nil {
 		return nil, ErrAssetNotFoundWithHash(hash)
 	}
+	// Archived content is read back only after a recall
+	if asset.BlobName == constants.ArchiveBlobName {
+		return nil, ErrRetrievalRequiredForHash(hash)
+	}
 
 	// Determine content type
 	contentType := constants.DefaultMimeType
@@ -194,38 +445,48 @@ func (s *AssetService) GetReader(hash string) (*AssetReader, error) {
 		contentType = mimeType
 	}
 
-	// Open the DAT file
-	topicPath := s.app.GetTopicPath(topicName)
-	datPath := filepath.Join(topicPath, asset.BlobName)
-
-	f, err := os.Open(datPath)
+	// Open the asset data in its DAT file, locally or in the topic's blob store
+	f, err := s.blobStores.OpenData(topicName, asset)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Compaction removed the .dat file after the row was read; the row
+		// now names the entry's new location
+		if asset, err = database.GetAsset(topicDB, hash); err == nil && asset != nil {
+			f, err = s.blobStores.OpenData(topicName, asset)
+		} else if err == nil {
+			return nil, ErrAssetNotFoundWithHash(hash)
+		}
+	}
 	if err != nil {
 		return nil, WrapInternalError(fmt.Errorf("failed to open data file: %w", err))
 	}
 
-	// Seek to data start (skip header)
-	dataStart := asset.ByteOffset + int64(constants.HeaderSize)
-	if _, err := f.Seek(dataStart, io.SeekStart); err != nil {
-		f.Close()
-		return nil, WrapInternalError(fmt.Errorf("failed to seek in data file: %w", err))
+	info := &AssetInfo{
+		Hash:        hash,
+		Size:        asset.AssetSize,
+		OriginName:  asset.OriginName,
+		Extension:   asset.Extension,
+		ContentType: contentType,
+		TopicName:   topicName,
+		CreatedAt:   asset.CreatedAt,
 	}
 
-	// Create limited reader that only reads the asset data
-	limitedReader := io.LimitReader(f, asset.AssetSize)
+	// Small assets are read whole so the next download comes from memory
+	if s.cache.Cacheable(asset.AssetSize) {
+		defer f.Close()
+		data := make([]byte, asset.AssetSize)
+		if _, err := io.ReadFull(f, data); err != nil {
+			return nil, WrapInternalError(fmt.Errorf("failed to read asset data: %w", err))
+		}
+		s.cache.Put(*info, data)
+		return &AssetReader{
+			ReadCloser: io.NopCloser(bytes.NewReader(data)),
+			Info:       info,
+		}, nil
+	}
 
 	return &AssetReader{
-		ReadCloser: &assetFileReader{
-			Reader: limitedReader,
-			Closer: f,
-		},
-		Info: &AssetInfo{
-			Hash:        hash,
-			Size:        asset.AssetSize,
-			OriginName:  asset.OriginName,
-			Extension:   asset.Extension,
-			ContentType: contentType,
-			TopicName:   topicName,
-		},
+		ReadCloser: f,
+		Info:       info,
 	}, nil
 }
 
@@ -237,7 +498,7 @@ func (s *AssetService) GetInfo(hash string) (*AssetInfo, error) {
 	}
 
 	// Look up in orchestrator to find topic
-	exists, topicName, _, err := database.CheckHashExists(s.app.GetOrchestratorDB(), hash)
+	exists, topicName, _, err := s.app.GetAssetIndex().Lookup(hash)
 	if err != nil {
 		return nil, WrapInternalError(err)
 	}
@@ -278,9 +539,494 @@ func (s *AssetService) GetInfo(hash string) (*AssetInfo, error) {
 		Extension:   asset.Extension,
 		ContentType: contentType,
 		TopicName:   topicName,
+		CreatedAt:   asset.CreatedAt,
 	}, nil
 }
 
+// DeleteResult describes a deleted asset. Its entry stays in DatFile until
+// the file is compacted.
+type DeleteResult struct {
+	Hash      string `json:"hash"`
+	Topic     string `json:"topic"`
+	Size      int64  `json:"size"`
+	DatFile   string `json:"dat_file"`
+	DeletedAt int64  `json:"deleted_at"`
+}
+
+// Delete removes an asset that nothing references: its row, metadata,
+// index and trash entries are dropped and a tombstone marks its .dat entry
+// for compaction; the copy of an archived asset is removed from its archive.
+// by and ipAddress identify the actor for the audit log.
+func (s *AssetService) Delete(hash, by, ipAddress string) (*DeleteResult, error) {
+	if len(hash) != constants.HashLength {
+		return nil, ErrInvalidHash
+	}
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+
+	exists, topicName, _, err := s.app.GetAssetIndex().Lookup(hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if !exists {
+		return nil, ErrAssetNotFoundWithHash(hash)
+	}
+	if healthy, errMsg := s.app.IsTopicHealthy(topicName); !healthy {
+		return nil, ErrTopicUnhealthyWithReason(topicName, errMsg)
+	}
+
+	// Serialized with uploads and compaction of the topic, so the entry is
+	// not moved while it is tombstoned
+	topicMu := s.app.GetTopicWriteMu(topicName)
+	topicMu.Lock()
+	defer topicMu.Unlock()
+
+	refs, err := database.ListAssetReferences(orchDB, hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if len(refs) > 0 {
+		return nil, NewServiceError(constants.ErrCodeAssetReferenced,
+			fmt.Sprintf("asset %s is referenced by %d holder(s)", hash, len(refs)))
+	}
+
+	topicDB, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, s.wrapTopicError(topicName, err)
+	}
+	asset, err := database.GetAsset(topicDB, hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if asset == nil {
+		return nil, ErrAssetNotFoundWithHash(hash)
+	}
+	archived, err := database.GetArchivedAsset(orchDB, hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	txTopic, err := topicDB.Begin()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	defer txTopic.Rollback()
+
+	txOrch, err := orchDB.Begin()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	defer txOrch.Rollback()
+
+	now := time.Now().Unix()
+	if err := database.DeleteAsset(txTopic, *asset, now, by); err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to delete asset: %w", err))
+	}
+	if err := s.app.GetAssetIndex().Delete(txOrch, hash); err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to delete asset index: %w", err))
+	}
+	if err := database.DeleteTrashEntryTx(txOrch, hash); err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if err := database.DeleteArchivedAssetTx(txOrch, hash); err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if asset.ParentID != nil && *asset.ParentID != "" {
+		if err := database.DeleteAssetReference(txOrch, database.AssetReference{
+			Hash:   *asset.ParentID,
+			Kind:   constants.ReferenceKindLineage,
+			Topic:  topicName,
+			Holder: hash,
+		}); err != nil {
+			return nil, WrapInternalError(fmt.Errorf("failed to release lineage reference: %w", err))
+		}
+	}
+
+	if err := txTopic.Commit(); err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to commit topic transaction: %w", err))
+	}
+	if err := txOrch.Commit(); err != nil {
+		s.logger.Warn("Orchestrator commit failed after deleting %s: %v", hash, err)
+	}
+	s.cache.Invalidate(hash)
+	if archived != nil && s.archives != nil {
+		s.archives.discard(archived)
+	}
+	if s.previews != nil {
+		s.previews.Remove(hash)
+	}
+
+	s.logger.Info("Asset %s deleted from topic %s by %s", hash, topicName, by)
+	if l := s.app.GetAuditLogger(); l != nil {
+		if err := l.Log(constants.AuditActionAssetDeleted, ipAddress, by, audit.AssetDeletedDetails{
+			Hash:       hash,
+			Topic:      topicName,
+			Size:       asset.AssetSize,
+			OriginName: asset.OriginName,
+			Extension:  asset.Extension,
+			DatFile:    asset.BlobName,
+		}); err != nil {
+			s.logger.Error("Failed to write audit entry for deletion of %s: %v", hash, err)
+		}
+	}
+
+	return &DeleteResult{
+		Hash:      hash,
+		Topic:     topicName,
+		Size:      asset.AssetSize,
+		DatFile:   asset.BlobName,
+		DeletedAt: now,
+	}, nil
+}
+
+// NamedAsset is one asset stored in a topic under a looked-up name.
+type NamedAsset struct {
+	Hash       string  `json:"hash"`
+	OriginName string  `json:"origin_name"`
+	Extension  string  `json:"extension"`
+	Size       int64   `json:"size"`
+	ParentID   *string `json:"parent_id,omitempty"`
+	CreatedAt  int64   `json:"created_at"`
+}
+
+// FindByName returns the assets of topicName stored under name, newest
+// first. name is either a filename as uploaded ("model.glb") or an origin
+// name without its extension ("model", or "archive.tar" for
+// archive.tar.gz), and is sanitized like upload filenames. Quarantined
+// and trashed assets are left out, as they are from query results.
+func (s *AssetService) FindByName(topicName, name string) ([]NamedAsset, error) {
+	cleanName := sanitize.Filename(name)
+	if cleanName == "" {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "asset name is required")
+	}
+
+	topicDB, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	// The whole name as an origin name, with any extension
+	matches, err := database.GetAssetsByOriginName(topicDB, sanitize.OriginName(cleanName), "", constants.AssetByNameMaxResults)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	// The name split like an uploaded filename
+	if idx := strings.LastIndex(cleanName, "."); idx != -1 {
+		ext := sanitize.Extension(cleanName[idx+1:])
+		if ext != "" {
+			byFilename, err := database.GetAssetsByOriginName(topicDB, sanitize.OriginName(cleanName[:idx]), ext, constants.AssetByNameMaxResults)
+			if err != nil {
+				return nil, WrapInternalError(err)
+			}
+			matches = append(matches, byFilename...)
+		}
+	}
+
+	withheld, err := withheldHashes(s.app)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(matches))
+	assets := make([]NamedAsset, 0, len(matches))
+	for _, a := range matches {
+		if seen[a.AssetID] || withheld[a.AssetID] {
+			continue
+		}
+		seen[a.AssetID] = true
+		assets = append(assets, NamedAsset{
+			Hash:       a.AssetID,
+			OriginName: a.OriginName,
+			Extension:  a.Extension,
+			Size:       a.AssetSize,
+			ParentID:   a.ParentID,
+			CreatedAt:  a.CreatedAt,
+		})
+	}
+
+	slices.SortFunc(assets, func(a, b NamedAsset) int {
+		if a.CreatedAt != b.CreatedAt {
+			return int(b.CreatedAt - a.CreatedAt)
+		}
+		return strings.Compare(a.Hash, b.Hash)
+	})
+	if len(assets) > constants.AssetByNameMaxResults {
+		assets = assets[:constants.AssetByNameMaxResults]
+	}
+	return assets, nil
+}
+
+// splitFilename splits a sanitized filename into the origin name and
+// extension an upload is stored under.
+func splitFilename(cleanFilename string) (originName, ext string) {
+	if idx := strings.LastIndex(cleanFilename, "."); idx != -1 {
+		return sanitize.OriginName(cleanFilename[:idx]), sanitize.Extension(cleanFilename[idx+1:])
+	}
+	return sanitize.OriginName(cleanFilename), ""
+}
+
+// ObjectKey returns the key the asset is addressed by through the S3
+// gateway.
+func (a NamedAsset) ObjectKey() string {
+	return database.Asset{AssetID: a.Hash, OriginName: a.OriginName, Extension: a.Extension}.ObjectKey()
+}
+
+// IsObjectKey reports whether an upload named key is stored under that
+// same object key, so the object can be read back by it. Keys with path
+// components or characters that sanitization rewrites are not.
+func IsObjectKey(key string) bool {
+	originName, ext := splitFilename(sanitize.Filename(key))
+	return originName != "" && database.Asset{OriginName: originName, Extension: ext}.ObjectKey() == key
+}
+
+// ListObjects returns the newest asset stored under each object key of
+// topicName starting with prefix and sorting after after, at most limit
+// of them, and whether more keys follow. Quarantined and trashed assets
+// are left out.
+func (s *AssetService) ListObjects(topicName, prefix, after string, limit int) ([]NamedAsset, bool, error) {
+	if !s.app.TopicExists(topicName) {
+		return nil, false, ErrTopicNotFoundWithName(topicName)
+	}
+	topicDB, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, false, s.wrapTopicError(topicName, err)
+	}
+	withheld, err := withheldHashes(s.app)
+	if err != nil {
+		return nil, false, err
+	}
+
+	matches, err := database.ListAssetsByObjectKey(topicDB, prefix, after, limit+1, withheld)
+	if err != nil {
+		return nil, false, WrapInternalError(err)
+	}
+	truncated := len(matches) > limit
+	if truncated {
+		matches = matches[:limit]
+	}
+
+	assets := make([]NamedAsset, 0, len(matches))
+	for _, a := range matches {
+		assets = append(assets, NamedAsset{
+			Hash:       a.AssetID,
+			OriginName: a.OriginName,
+			Extension:  a.Extension,
+			Size:       a.AssetSize,
+			ParentID:   a.ParentID,
+			CreatedAt:  a.CreatedAt,
+		})
+	}
+	return assets, truncated, nil
+}
+
+// BrowseOptions selects a page of a topic's assets. Sort is one of the
+// database.BrowseSort columns, Order asc or desc, and Cursor the
+// NextCursor of the previous page.
+type BrowseOptions struct {
+	Sort           string
+	Order          string
+	Extension      string
+	HasMetadataKey string
+	Since          int64 // Unix timestamp, inclusive (0 = unbounded)
+	Until          int64 // Unix timestamp, inclusive (0 = unbounded)
+	Cursor         string
+	Limit          int
+}
+
+// AssetPage is a page of a topic's assets. Total counts every asset
+// matching the filters, not only this page; NextCursor is empty on the
+// last page.
+type AssetPage struct {
+	Topic      string       `json:"topic"`
+	Sort       string       `json:"sort"`
+	Order      string       `json:"order"`
+	Assets     []NamedAsset `json:"assets"`
+	Total      int64        `json:"total"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// browseCursor is what a page cursor encodes: the sort order it belongs to
+// and the position of the last asset of the page.
+type browseCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	database.BrowseCursor
+}
+
+// Browse returns a page of the assets of topicName, with the total number
+// of assets matching the filters. Quarantined and trashed assets are left
+// out.
+func (s *AssetService) Browse(topicName string, opts BrowseOptions) (*AssetPage, error) {
+	if opts.Sort == "" {
+		opts.Sort = database.BrowseSortCreated
+	}
+	if !database.IsBrowseSort(opts.Sort) {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, fmt.Sprintf("sort must be one of %s, %s, %s",
+			database.BrowseSortCreated, database.BrowseSortSize, database.BrowseSortName))
+	}
+	if opts.Order == "" {
+		opts.Order = constants.TopicAssetsOrderDesc
+		if opts.Sort == database.BrowseSortName {
+			opts.Order = constants.TopicAssetsOrderAsc
+		}
+	}
+	if opts.Order != constants.TopicAssetsOrderAsc && opts.Order != constants.TopicAssetsOrderDesc {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "order must be asc or desc")
+	}
+	if opts.Since > 0 && opts.Until > 0 && opts.Since > opts.Until {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "since must not be after until")
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = constants.TopicAssetsDefaultLimit
+	}
+	opts.Limit = min(opts.Limit, constants.TopicAssetsMaxLimit)
+
+	filter := database.BrowseFilter{
+		Sort:           opts.Sort,
+		Descending:     opts.Order == constants.TopicAssetsOrderDesc,
+		HasMetadataKey: opts.HasMetadataKey,
+		Since:          opts.Since,
+		Until:          opts.Until,
+	}
+	if opts.Extension != "" {
+		if filter.Extension = sanitize.Extension(opts.Extension); filter.Extension == "" {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest, "invalid extension")
+		}
+	}
+	if opts.Cursor != "" {
+		cursor, err := decodeBrowseCursor(opts.Cursor)
+		if err != nil || cursor.Sort != opts.Sort || cursor.Order != opts.Order {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest, "invalid cursor for this sort order")
+		}
+		filter.After = &cursor.BrowseCursor
+	}
+
+	if !s.app.TopicExists(topicName) {
+		return nil, ErrTopicNotFoundWithName(topicName)
+	}
+	topicDB, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, s.wrapTopicError(topicName, err)
+	}
+	if filter.Exclude, err = withheldHashes(s.app); err != nil {
+		return nil, err
+	}
+
+	// One extra row tells whether another page follows
+	rows, total, err := database.ListTopicAssets(topicDB, filter, opts.Limit+1)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	page := &AssetPage{
+		Topic:  topicName,
+		Sort:   opts.Sort,
+		Order:  opts.Order,
+		Assets: make([]NamedAsset, 0, min(len(rows), opts.Limit)),
+		Total:  total,
+	}
+	if len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+		last := browseCursor{Sort: opts.Sort, Order: opts.Order, BrowseCursor: rows[len(rows)-1].Cursor}
+		if page.NextCursor, err = encodeBrowseCursor(last); err != nil {
+			return nil, WrapInternalError(err)
+		}
+	}
+	for _, a := range rows {
+		page.Assets = append(page.Assets, NamedAsset{
+			Hash:       a.AssetID,
+			OriginName: a.OriginName,
+			Extension:  a.Extension,
+			Size:       a.AssetSize,
+			ParentID:   a.ParentID,
+			CreatedAt:  a.CreatedAt,
+		})
+	}
+	return page, nil
+}
+
+func encodeBrowseCursor(c browseCursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeBrowseCursor(token string) (browseCursor, error) {
+	var c browseCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// ResolveObject returns the asset of topicName an object key addresses: the
+// asset with that hash, or else the newest asset stored under that
+// filename. Quarantined and trashed assets are not found.
+func (s *AssetService) ResolveObject(topicName, key string) (*NamedAsset, error) {
+	if !s.app.TopicExists(topicName) {
+		return nil, ErrTopicNotFoundWithName(topicName)
+	}
+	withheld, err := withheldHashes(s.app)
+	if err != nil {
+		return nil, err
+	}
+
+	if isHexHash(key) && !withheld[key] {
+		info, err := s.GetInfo(key)
+		if err == nil && info.TopicName == topicName {
+			return &NamedAsset{
+				Hash:       info.Hash,
+				OriginName: info.OriginName,
+				Extension:  info.Extension,
+				Size:       info.Size,
+				CreatedAt:  info.CreatedAt,
+			}, nil
+		}
+		if code, _ := IsServiceError(err); err != nil && code != constants.ErrCodeAssetNotFound {
+			return nil, err
+		}
+	}
+
+	if !IsObjectKey(key) {
+		return nil, ErrAssetNotFound
+	}
+	topicDB, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, s.wrapTopicError(topicName, err)
+	}
+	originName, ext := splitFilename(key)
+	matches, err := database.GetAssetsByOriginName(topicDB, originName, ext, constants.AssetByNameMaxResults)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	for _, a := range matches {
+		// Without an extension in the key, only assets without one match
+		if a.Extension != ext || withheld[a.AssetID] {
+			continue
+		}
+		return &NamedAsset{
+			Hash:       a.AssetID,
+			OriginName: a.OriginName,
+			Extension:  a.Extension,
+			Size:       a.AssetSize,
+			ParentID:   a.ParentID,
+			CreatedAt:  a.CreatedAt,
+		}, nil
+	}
+	return nil, ErrAssetNotFound
+}
+
+// errStreamTooLarge is returned by streamToTempWithHash for data over its
+// size limit.
+var errStreamTooLarge = errors.New("file too large")
+
 // streamToTempWithHash streams data to a temp file while computing BLAKE3 hash.
 // Returns temp file path, hash, size, or error.
 func (s *AssetService) streamToTempWithHash(r io.Reader, maxSize int64) (tempPath string, hash string, size int64, err error) {
@@ -310,7 +1056,7 @@ func (s *AssetService) streamToTempWithHash(r io.Reader, maxSize int64) (tempPat
 
 	if size > maxSize-int64(constants.HeaderSize) {
 		os.Remove(tempPath)
-		return "", "", 0, fmt.Errorf("file too large")
+		return "", "", 0, errStreamTooLarge
 	}
 
 	// Get hash
@@ -321,6 +1067,8 @@ func (s *AssetService) streamToTempWithHash(r io.Reader, maxSize int64) (tempPat
 }
 
 // writeAssetFromTempFile writes an asset from a temp file using the pipeline.
+// A non-nil quarantine is recorded in the same orchestrator commit, so the
+// asset is never served before it is withheld.
 func (s *AssetService) writeAssetFromTempFile(
 	topicDB *sql.DB,
 	topicName string,
@@ -331,6 +1079,12 @@ func (s *AssetService) writeAssetFromTempFile(
 	extension string,
 	originName string,
 	parentID *string,
+	relativePath string,
+	uploader string,
+	filename string,
+	quarantine *database.QuarantineEntry,
+	validation *ValidationOutcome,
+	info *media.Info,
 ) (*database.Asset, error) {
 	maxDatSize := s.app.GetConfig().MaxDatSize
 	if maxDatSize == 0 {
@@ -381,8 +1135,11 @@ func (s *AssetService) writeAssetFromTempFile(
 		return nil, fmt.Errorf("failed to insert asset: %w", err)
 	}
 
-	if err := database.InsertAssetIndex(txOrch, hash, topicName, datFile); err != nil {
-		return nil, fmt.Errorf("failed to insert asset index: %w", err)
+	if quarantine != nil {
+		quarantine.QuarantinedAt = asset.CreatedAt
+	}
+	if err := s.indexAssetTx(txOrch, &asset, topicName, quarantine); err != nil {
+		return nil, err
 	}
 
 	// Compute new running hash - O(1) operation
@@ -405,18 +1162,184 @@ func (s *AssetService) writeAssetFromTempFile(
 		return nil, fmt.Errorf("failed to update dat hash: %w", err)
 	}
 
+	// Stamp the folder path, the default metadata, the validation result
+	// and the media properties so the asset is never visible without them
+	maxValueBytes := s.app.GetConfig().Metadata.MaxValueBytes
+	var ops []database.BatchOperation
+	if relativePath != "" {
+		ops = append(ops, database.BatchOperation{
+			Hash:             hash,
+			Op:               constants.BatchMetadataOpSet,
+			Key:              constants.MetadataKeyRelativePath,
+			Value:            relativePath,
+			Processor:        constants.ProcessorUpload,
+			ProcessorVersion: constants.ProcessorUploadVersion,
+		})
+	}
+	ops = append(ops, s.defaultMetadataOps(topicName, uploader, filename, &asset)...)
+	if validation != nil {
+		ops = append(ops, validationMetadataOps(hash, validation, maxValueBytes)...)
+	}
+	if info != nil {
+		ops = append(ops, mediaMetadataOps(hash, info)...)
+	}
+	if len(ops) > 0 {
+		results, err := database.ExecuteBatchMetadataTx(txTopic, ops, maxValueBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stamp upload metadata: %w", err)
+		}
+		for i, result := range results {
+			if result.Error == "" {
+				continue
+			}
+			// The folder path is part of the upload, the rest is best effort
+			if relativePath != "" && i == 0 {
+				return nil, NewServiceError(constants.ErrCodeInvalidRequest, "relative_path not recorded: "+result.Error)
+			}
+			s.logger.Warn("Metadata not stamped on %s: %s", hash, result.Error)
+		}
+	}
+
 	// Commit transactions
 	if err := txTopic.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit topic transaction: %w", err)
 	}
 
+	// The asset is acknowledged only once it is indexed, so a query or
+	// download issued after the upload returns always finds it
 	if err := txOrch.Commit(); err != nil {
-		s.logger.Warn("Orchestrator commit failed (will recover on restart): %v", err)
+		s.logger.Warn("Orchestrator commit failed for %s, retrying the index: %v", hash, err)
+		if err := s.retryIndexAsset(&asset, topicName, quarantine); err != nil {
+			return nil, WrapServiceError(constants.ErrCodeUploadIndexFailed,
+				fmt.Sprintf("asset %s was stored in topic %s but could not be indexed; retry the upload", hash, topicName), err)
+		}
 	}
 
 	return &asset, nil
 }
 
+// indexAssetTx records a new asset in the orchestrator: its index entry,
+// the lineage reference to its parent and its quarantine, if any.
+func (s *AssetService) indexAssetTx(tx *sql.Tx, asset *database.Asset, topicName string, quarantine *database.QuarantineEntry) error {
+	if err := s.app.GetAssetIndex().Insert(tx, asset.AssetID, topicName, asset.BlobName); err != nil {
+		return fmt.Errorf("failed to insert asset index: %w", err)
+	}
+
+	if asset.ParentID != nil && *asset.ParentID != "" {
+		if err := database.InsertAssetReference(tx, database.AssetReference{
+			Hash:      *asset.ParentID,
+			Kind:      constants.ReferenceKindLineage,
+			Topic:     topicName,
+			Holder:    asset.AssetID,
+			CreatedAt: asset.CreatedAt,
+		}); err != nil {
+			return fmt.Errorf("failed to register lineage reference: %w", err)
+		}
+	}
+
+	if quarantine != nil {
+		if _, _, err := database.QuarantineAssetTx(tx, *quarantine); err != nil {
+			return fmt.Errorf("failed to quarantine asset: %w", err)
+		}
+	}
+	return nil
+}
+
+// indexStoredAsset indexes an asset already committed to its topic in one
+// orchestrator transaction.
+func (s *AssetService) indexStoredAsset(asset *database.Asset, topicName string, quarantine *database.QuarantineEntry) error {
+	tx, err := s.app.GetOrchestratorDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.indexAssetTx(tx, asset, topicName, quarantine); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// retryIndexAsset repeats the orchestrator writes of an asset already
+// committed to its topic, in fresh transactions with a growing backoff.
+func (s *AssetService) retryIndexAsset(asset *database.Asset, topicName string, quarantine *database.QuarantineEntry) error {
+	backoff := constants.UploadIndexRetryBackoff
+	var err error
+	for attempt := 1; attempt <= constants.UploadIndexRetryAttempts; attempt++ {
+		time.Sleep(backoff)
+		backoff *= 2
+
+		if err = s.indexStoredAsset(asset, topicName, quarantine); err == nil {
+			s.logger.Info("Indexed %s in topic %s on retry %d", asset.AssetID, topicName, attempt)
+			return nil
+		}
+		s.logger.Warn("Index retry %d/%d for %s failed: %v", attempt, constants.UploadIndexRetryAttempts, asset.AssetID, err)
+	}
+	return err
+}
+
+// defaultMetadataOps expands the default metadata template of topicName for
+// a new asset.
+func (s *AssetService) defaultMetadataOps(topicName, uploader, filename string, asset *database.Asset) []database.BatchOperation {
+	defaults := s.app.GetConfig().Metadata.DefaultsFor(topicName)
+	if len(defaults) == 0 {
+		return nil
+	}
+
+	replacer := strings.NewReplacer(
+		constants.DefaultMetadataPlaceholderUsername, uploader,
+		constants.DefaultMetadataPlaceholderTopic, topicName,
+		constants.DefaultMetadataPlaceholderUploadTime, time.Unix(asset.CreatedAt, 0).UTC().Format(time.RFC3339),
+		constants.DefaultMetadataPlaceholderFilename, filename,
+		constants.DefaultMetadataPlaceholderExtension, asset.Extension,
+		constants.DefaultMetadataPlaceholderHash, asset.AssetID,
+	)
+
+	ops := make([]database.BatchOperation, 0, len(defaults))
+	for _, key := range slices.Sorted(maps.Keys(defaults)) {
+		ops = append(ops, database.BatchOperation{
+			Hash:             asset.AssetID,
+			Op:               constants.BatchMetadataOpSet,
+			Key:              key,
+			Value:            replacer.Replace(defaults[key]),
+			Processor:        constants.ProcessorDefaults,
+			ProcessorVersion: constants.ProcessorDefaultsVersion,
+		})
+	}
+	return ops
+}
+
+// mediaMetadataOps records the media properties found by inspecting an
+// upload. Properties its format does not have are left unset.
+func mediaMetadataOps(hash string, info *media.Info) []database.BatchOperation {
+	set := func(key, value string) database.BatchOperation {
+		return database.BatchOperation{
+			Hash:             hash,
+			Op:               constants.BatchMetadataOpSet,
+			Key:              key,
+			Value:            value,
+			Processor:        constants.ProcessorMedia,
+			ProcessorVersion: constants.ProcessorMediaVersion,
+		}
+	}
+
+	ops := []database.BatchOperation{set(constants.MetadataKeyMediaContentType, info.ContentType)}
+	if info.Width > 0 && info.Height > 0 {
+		ops = append(ops,
+			set(constants.MetadataKeyMediaWidth, strconv.Itoa(info.Width)),
+			set(constants.MetadataKeyMediaHeight, strconv.Itoa(info.Height)))
+	}
+	if info.Model {
+		ops = append(ops,
+			set(constants.MetadataKeyMediaVertices, strconv.Itoa(info.Vertices)),
+			set(constants.MetadataKeyMediaMaterials, strconv.Itoa(info.Materials)))
+	}
+	if info.Duration > 0 {
+		ops = append(ops, set(constants.MetadataKeyMediaDurationSecs, strconv.FormatFloat(info.Duration, 'f', 3, 64)))
+	}
+	return ops
+}
+
 // appendFromTempFile appends data from temp file to .dat file.
 func (s *AssetService) appendFromTempFile(datPath string, hash string, tempFile string, size int64) (byteOffset int64, err error) {
 	// Serialize header
@@ -425,6 +1348,14 @@ func (s *AssetService) appendFromTempFile(datPath string, hash string, tempFile
 		return 0, err
 	}
 
+	if s.app.GetConfig().DirectIO() {
+		byteOffset, err = s.appendDirect(datPath, header, tempFile, size)
+		if !errors.Is(err, storage.ErrDirectIOUnsupported) {
+			return byteOffset, err
+		}
+		s.logger.Debug("Direct I/O unavailable for %s, appending buffered: %v", datPath, err)
+	}
+
 	// Open .dat file for appending
 	datFile, err := os.OpenFile(datPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -439,8 +1370,22 @@ func (s *AssetService) appendFromTempFile(datPath string, hash string, tempFile
 	}
 	byteOffset = stat.Size()
 
+	// Reserve the extension up front so a full disk fails before any byte is written
+	if err := storage.Preallocate(datFile, byteOffset, int64(len(header))+size); err != nil {
+		return 0, err
+	}
+
+	// A failed write must not leave a partial entry at the end of the .dat file
+	defer func() {
+		if err != nil {
+			if truncErr := datFile.Truncate(byteOffset); truncErr != nil {
+				s.logger.Error("Failed to truncate %s back to %d after write error: %v", datPath, byteOffset, truncErr)
+			}
+		}
+	}()
+
 	// Write header
-	if _, err := datFile.Write(header); err != nil {
+	if _, err = datFile.Write(header); err != nil {
 		return 0, fmt.Errorf("failed to write header: %w", err)
 	}
 
@@ -451,18 +1396,29 @@ func (s *AssetService) appendFromTempFile(datPath string, hash string, tempFile
 	}
 	defer srcFile.Close()
 
-	if _, err := io.Copy(datFile, srcFile); err != nil {
+	if _, err = io.Copy(datFile, srcFile); err != nil {
 		return 0, fmt.Errorf("failed to copy data: %w", err)
 	}
 
 	// Sync to ensure durability
-	if err := datFile.Sync(); err != nil {
+	if err = datFile.Sync(); err != nil {
 		return 0, fmt.Errorf("failed to sync dat file: %w", err)
 	}
 
 	return byteOffset, nil
 }
 
+// appendDirect appends data from temp file to .dat file with O_DIRECT.
+func (s *AssetService) appendDirect(datPath string, header []byte, tempFile string, size int64) (int64, error) {
+	srcFile, err := os.Open(tempFile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open temp file: %w", err)
+	}
+	defer srcFile.Close()
+
+	return storage.AppendDirect(datPath, header, srcFile, size)
+}
+
 // wrapTopicError wraps topic-related errors with appropriate service errors.
 func (s *AssetService) wrapTopicError(topicName string, err error) *ServiceError {
 	errStr := err.Error()
@@ -474,13 +1430,3 @@ func (s *AssetService) wrapTopicError(topicName string, err error) *ServiceError
 	}
 	return WrapInternalError(err)
 }
-
-// assetFileReader combines a limited reader with a file closer.
-type assetFileReader struct {
-	io.Reader
-	Closer io.Closer
-}
-
-func (r *assetFileReader) Close() error {
-	return r.Closer.Close()
-}
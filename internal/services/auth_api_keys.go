@@ -0,0 +1,310 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// ============================================================================
+// API Keys (expiration and rotation)
+// ============================================================================
+
+// CreateAPIKeyRequest contains the fields for creating a labelled API key.
+// Without TTLDays the key gets auth.api_key_ttl_days; 0 asks for a key that
+// never expires, allowed only without auth.api_key_max_ttl_days.
+type CreateAPIKeyRequest struct {
+	Label   string `json:"label"`
+	TTLDays *int   `json:"ttl_days,omitempty"`
+}
+
+// RotateAPIKeyRequest contains the fields for rotating an API key. Without
+// GraceSecs the old key keeps working for auth.api_key_rotation_grace_hours;
+// TTLDays applies to the new key as for CreateAPIKeyRequest.
+type RotateAPIKeyRequest struct {
+	GraceSecs *int64 `json:"grace_secs,omitempty"`
+	TTLDays   *int   `json:"ttl_days,omitempty"`
+}
+
+// APIKeyResponse is an API key and, right after creation or rotation, its
+// plaintext key (shown once).
+type APIKeyResponse struct {
+	*auth.APIKey
+	Key string `json:"key,omitempty"`
+}
+
+// APIKeyRotationResponse is the key issued by a rotation, with the key it
+// replaces, which keeps working until GraceUntil.
+type APIKeyRotationResponse struct {
+	APIKeyResponse
+	Previous   *auth.APIKey `json:"previous,omitempty"`
+	GraceUntil int64        `json:"grace_until"`
+}
+
+// ListAPIKeys returns a user's API keys: the key on the account first, then
+// the labelled keys, newest first, including expired and revoked keys
+// until they are purged.
+func (s *AuthService) ListAPIKeys(userID int64) ([]auth.APIKey, error) {
+	user, err := s.apiKeyOwner(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	labelled, err := s.store.ListAPIKeys(userID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	keys := make([]auth.APIKey, 0, len(labelled)+1)
+	if primary := auth.PrimaryAPIKey(user); primary != nil {
+		keys = append(keys, *primary)
+	}
+	return append(keys, labelled...), nil
+}
+
+// CreateAPIKey issues a labelled API key to a user, carrying the user's
+// grants like the key on the account.
+func (s *AuthService) CreateAPIKey(actor *auth.Identity, userID int64, req CreateAPIKeyRequest) (*APIKeyResponse, error) {
+	user, err := s.apiKeyOwner(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	label := strings.TrimSpace(req.Label)
+	if label == "" || len(label) > constants.AuthAPIKeyMaxLabelLength {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("label must be 1 to %d characters", constants.AuthAPIKeyMaxLabelLength))
+	}
+	if label == constants.AuthAPIKeyPrimaryLabel {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("label %q is reserved for the key on the account", constants.AuthAPIKeyPrimaryLabel))
+	}
+
+	expiresAt, err := s.apiKeyExpiry(req.TTLDays)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.store.CountActiveAPIKeys(userID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if active >= constants.AuthAPIKeyMaxPerUser {
+		return nil, NewServiceError(constants.ErrCodeAuthAPIKeyLimit,
+			fmt.Sprintf("at most %d active API keys per user; revoke one first", constants.AuthAPIKeyMaxPerUser))
+	}
+
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	key, err := s.store.CreateAPIKey(userID, label, auth.HashToken(apiKey), auth.ExtractTokenPrefix(apiKey), expiresAt, actor.User.ID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Auth: API key id=%d label=%q created for user=%s by=%s",
+		key.ID, label, user.Username, actor.User.Username)
+
+	return &APIKeyResponse{APIKey: key, Key: apiKey}, nil
+}
+
+// RotateAPIKey issues the replacement of a user's API key, keyRef being a
+// labelled key's ID or constants.AuthAPIKeyPrimaryLabel for the key on the
+// account. The old key keeps working until the end of the grace period,
+// so clients can switch over without downtime.
+func (s *AuthService) RotateAPIKey(actor *auth.Identity, userID int64, keyRef string, req RotateAPIKeyRequest) (*APIKeyRotationResponse, error) {
+	user, err := s.apiKeyOwner(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	grace := s.app.GetConfig().Auth.APIKeyRotationGrace()
+	if req.GraceSecs != nil {
+		grace = time.Duration(*req.GraceSecs) * time.Second
+		if *req.GraceSecs < 0 || grace > constants.AuthAPIKeyMaxRotationGrace {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+				fmt.Sprintf("grace_secs must be between 0 and %d", int64(constants.AuthAPIKeyMaxRotationGrace.Seconds())))
+		}
+	}
+	graceUntil := time.Now().Add(grace).Unix()
+
+	expiresAt, err := s.apiKeyExpiry(req.TTLDays)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	keyHash, keyPrefix := auth.HashToken(apiKey), auth.ExtractTokenPrefix(apiKey)
+
+	resp := &APIKeyRotationResponse{APIKeyResponse: APIKeyResponse{Key: apiKey}, GraceUntil: graceUntil}
+	if keyRef == constants.AuthAPIKeyPrimaryLabel {
+		old, err := s.store.RotatePrimaryAPIKey(userID, keyHash, keyPrefix, expiresAt, graceUntil, actor.User.ID)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		resp.Previous = old
+		resp.APIKey = &auth.APIKey{
+			UserID:    userID,
+			Label:     constants.AuthAPIKeyPrimaryLabel,
+			Primary:   true,
+			KeyPrefix: keyPrefix,
+			ExpiresAt: expiresAt,
+			Status:    constants.AuthAPIKeyStatusActive,
+		}
+	} else {
+		id, err := strconv.ParseInt(keyRef, 10, 64)
+		if err != nil {
+			return nil, NewServiceError(constants.ErrCodeAuthAPIKeyNotFound, "API key not found")
+		}
+		created, old, err := s.store.RotateAPIKey(userID, id, keyHash, keyPrefix, expiresAt, graceUntil, actor.User.ID)
+		if err == auth.ErrAPIKeyNotFound {
+			return nil, NewServiceError(constants.ErrCodeAuthAPIKeyNotFound, "no active API key with this ID that was not rotated already")
+		}
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		resp.APIKey, resp.Previous = created, old
+	}
+	if resp.Previous != nil && resp.Previous.ExpiresAt != nil {
+		resp.GraceUntil = *resp.Previous.ExpiresAt
+	}
+
+	s.logger.Info("Auth: API key %s rotated for user=%s by=%s (old key valid until %d)",
+		keyRef, user.Username, actor.User.Username, resp.GraceUntil)
+
+	return resp, nil
+}
+
+// RevokeAPIKey revokes a labelled API key of a user at once. The key on
+// the account is replaced with RegenerateAPIKey or RotateAPIKey instead.
+func (s *AuthService) RevokeAPIKey(actor *auth.Identity, userID int64, keyRef string) (*auth.APIKey, error) {
+	user, err := s.apiKeyOwner(userID)
+	if err != nil {
+		return nil, err
+	}
+	if keyRef == constants.AuthAPIKeyPrimaryLabel {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			"the key on the account cannot be revoked; regenerate or rotate it")
+	}
+	id, err := strconv.ParseInt(keyRef, 10, 64)
+	if err != nil {
+		return nil, NewServiceError(constants.ErrCodeAuthAPIKeyNotFound, "API key not found")
+	}
+
+	key, err := s.store.RevokeAPIKey(userID, id, actor.User.ID)
+	if err == auth.ErrAPIKeyNotFound {
+		return nil, NewServiceError(constants.ErrCodeAuthAPIKeyNotFound, "no API key with this ID that is not revoked already")
+	}
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Auth: API key id=%d revoked for user=%s by=%s", id, user.Username, actor.User.Username)
+	return key, nil
+}
+
+// S3Credentials returns the S3 gateway credentials of the key on a user's
+// account. They work like the key itself, so they are handed out only with
+// a newly issued key.
+func (s *AuthService) S3Credentials(userID int64) (*auth.S3Credentials, error) {
+	signingKey, err := s.s3SigningKey()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return nil, NewServiceError(constants.ErrCodeAuthUserNotFound, "user not found")
+	}
+	creds, ok := auth.S3CredentialsFor(user, signingKey)
+	if !ok {
+		return nil, NewServiceError(constants.ErrCodeAuthInvalidAPIKey, "user has no API key")
+	}
+	return creds, nil
+}
+
+// ResolveS3AccessKey returns the identity behind an S3 access key ID and the
+// secret its requests are signed with.
+func (s *AuthService) ResolveS3AccessKey(accessKeyID string) (*auth.Identity, string, error) {
+	signingKey, err := s.s3SigningKey()
+	if err != nil {
+		return nil, "", err
+	}
+	return auth.ResolveS3AccessKey(s.store, signingKey, accessKeyID)
+}
+
+// s3SigningKey loads the server key under the working directory's internal
+// dir, reloading it when the working directory changes.
+func (s *AuthService) s3SigningKey() ([]byte, error) {
+	path := filepath.Join(s.app.GetWorkingDirectory(), constants.InternalDir, constants.S3SigningKeyFile)
+
+	s.s3KeyMu.Lock()
+	defer s.s3KeyMu.Unlock()
+	if s.s3Key != nil && s.s3KeyPath == path {
+		return s.s3Key, nil
+	}
+	key, err := auth.LoadS3SigningKey(path)
+	if err != nil {
+		return nil, err
+	}
+	s.s3Key, s.s3KeyPath = key, path
+	return key, nil
+}
+
+// apiKeyOwner loads the user whose API keys are managed. Pipelines hold no
+// API keys.
+func (s *AuthService) apiKeyOwner(userID int64) (*auth.UserWithSensitive, error) {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return nil, NewServiceError(constants.ErrCodeAuthUserNotFound, "user not found")
+	}
+	if user.IsPipeline() {
+		return nil, NewServiceError(constants.ErrCodeAuthPipelineAccount, "pipeline accounts authenticate only with workspace tokens")
+	}
+	return user, nil
+}
+
+// apiKeyExpiry returns the expiry of a key created now with the requested
+// lifetime in days, checked against the expiration policy. nil never
+// expires.
+func (s *AuthService) apiKeyExpiry(ttlDays *int) (*int64, error) {
+	policy := s.app.GetConfig().Auth
+	days := policy.APIKeyTTLDays
+	if ttlDays != nil {
+		days = *ttlDays
+	}
+
+	if days < 0 || (policy.APIKeyMaxTTLDays > 0 && (days == 0 || days > policy.APIKeyMaxTTLDays)) {
+		if policy.APIKeyMaxTTLDays > 0 {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+				fmt.Sprintf("ttl_days must be between 1 and %d", policy.APIKeyMaxTTLDays))
+		}
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "ttl_days must not be negative")
+	}
+	if days == 0 {
+		return nil, nil
+	}
+
+	expiresAt := time.Now().Add(time.Duration(days) * 24 * time.Hour).Unix()
+	return &expiresAt, nil
+}
+
+// cleanupAPIKeys purges labelled API keys expired or revoked longer than
+// constants.AuthAPIKeyRetention ago.
+func (s *AuthService) cleanupAPIKeys() {
+	removed, err := s.store.CleanupAPIKeys(time.Now().Add(-constants.AuthAPIKeyRetention).Unix())
+	if err != nil {
+		s.logger.Error("Auth: api key cleanup failed: %v", err)
+	} else if removed > 0 {
+		s.logger.Info("Auth: api key cleanup removed %d expired or revoked keys", removed)
+	}
+}
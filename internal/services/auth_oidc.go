@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+// ============================================================================
+// OpenID Connect Single Sign-On
+// ============================================================================
+
+// ssoAttempt is a sign-in in progress at the identity provider.
+type ssoAttempt struct {
+	nonce     string
+	verifier  string
+	returnTo  string
+	expiresAt time.Time
+}
+
+// ssoLogin is a completed sign-in awaiting the exchange of its login code
+// for a session.
+type ssoLogin struct {
+	userID    int64
+	expiresAt time.Time
+}
+
+// SSOCallbackResult is the outcome of a provider callback. ReturnTo is set
+// even when the sign-in failed, once the sign-in state is known.
+type SSOCallbackResult struct {
+	ReturnTo    string
+	Code        string // one-time login code
+	UserID      int64
+	Username    string
+	Subject     string
+	Provisioned bool // the user was created by this sign-in
+	Linked      bool // an existing user was linked by this sign-in
+}
+
+// SSOEnabled reports whether an OpenID Connect provider is configured.
+func (s *AuthService) SSOEnabled() bool {
+	return s.app.GetConfig().OIDC.Enabled()
+}
+
+// BeginSSOLogin starts a sign-in at the provider and returns the URL to
+// send the browser to. returnTo is the local path the browser comes back
+// to; anything but a local path falls back to "/".
+func (s *AuthService) BeginSSOLogin(ctx context.Context, returnTo string) (string, error) {
+	cfg := s.app.GetConfig().OIDC
+	if !cfg.Enabled() {
+		return "", NewServiceError(constants.ErrCodeAuthSSONotConfigured, "single sign-on is not configured")
+	}
+
+	provider, err := s.oidcProvider(ctx, &cfg)
+	if err != nil {
+		return "", err
+	}
+
+	var values [3]string
+	for i := range values {
+		if values[i], err = auth.GenerateOIDCSecret(); err != nil {
+			return "", WrapInternalError(err)
+		}
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	now := time.Now()
+	s.ssoMu.Lock()
+	for k, a := range s.ssoPending {
+		if now.After(a.expiresAt) {
+			delete(s.ssoPending, k)
+		}
+	}
+	if len(s.ssoPending) >= constants.OIDCMaxPendingLogins {
+		s.ssoMu.Unlock()
+		return "", NewServiceError(constants.ErrCodeAuthSSOBusy, "too many sign-ins in progress, try again shortly")
+	}
+	s.ssoPending[state] = &ssoAttempt{
+		nonce:     nonce,
+		verifier:  verifier,
+		returnTo:  localReturnPath(returnTo),
+		expiresAt: now.Add(constants.OIDCStateTTL),
+	}
+	s.ssoMu.Unlock()
+
+	return provider.AuthCodeURL(cfg.ClientID, cfg.RedirectURL, cfg.Scopes, state, nonce, verifier), nil
+}
+
+// CompleteSSOLogin handles the provider's callback: it redeems the
+// authorization code, verifies the ID token and maps its claims to a local
+// user, provisioning or linking one as configured. The returned login code
+// is exchanged for a session by ExchangeSSOCode. providerError is the
+// callback's error parameter, if any.
+func (s *AuthService) CompleteSSOLogin(ctx context.Context, state, code, providerError string) (*SSOCallbackResult, error) {
+	result := &SSOCallbackResult{ReturnTo: "/"}
+
+	cfg := s.app.GetConfig().OIDC
+	if !cfg.Enabled() {
+		return result, NewServiceError(constants.ErrCodeAuthSSONotConfigured, "single sign-on is not configured")
+	}
+
+	// States are single use
+	s.ssoMu.Lock()
+	attempt := s.ssoPending[state]
+	delete(s.ssoPending, state)
+	s.ssoMu.Unlock()
+	if attempt == nil || time.Now().After(attempt.expiresAt) {
+		return result, NewServiceError(constants.ErrCodeAuthSSOInvalid, "unknown or expired sign-in, start again")
+	}
+	result.ReturnTo = attempt.returnTo
+
+	if providerError != "" {
+		return result, NewServiceError(constants.ErrCodeAuthSSOProviderError, "identity provider refused the sign-in: "+providerError)
+	}
+	if code == "" {
+		return result, NewServiceError(constants.ErrCodeAuthSSOInvalid, "callback has no authorization code")
+	}
+
+	provider, err := s.oidcProvider(ctx, &cfg)
+	if err != nil {
+		return result, err
+	}
+	rawIDToken, err := provider.Exchange(ctx, cfg.ClientID, cfg.ClientSecret, cfg.RedirectURL, code, attempt.verifier)
+	if err != nil {
+		return result, WrapServiceError(constants.ErrCodeAuthSSOProviderError, "authorization code exchange failed", err)
+	}
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, cfg.ClientID, attempt.nonce, time.Now())
+	if err != nil {
+		if errors.Is(err, auth.ErrOIDCProvider) {
+			return result, WrapServiceError(constants.ErrCodeAuthSSOProviderError, "fetching the provider's signing keys failed", err)
+		}
+		return result, WrapServiceError(constants.ErrCodeAuthSSOInvalid, "invalid ID token: "+err.Error(), err)
+	}
+	result.Subject = claims.Subject()
+
+	user, err := s.ssoUser(&cfg, provider.Issuer, claims, result)
+	if err != nil {
+		return result, err
+	}
+	result.UserID = user.ID
+	result.Username = user.Username
+
+	if result.Code, err = auth.GenerateOIDCSecret(); err != nil {
+		return result, WrapInternalError(err)
+	}
+	now := time.Now()
+	s.ssoMu.Lock()
+	for k, l := range s.ssoCodes {
+		if now.After(l.expiresAt) {
+			delete(s.ssoCodes, k)
+		}
+	}
+	s.ssoCodes[result.Code] = &ssoLogin{userID: user.ID, expiresAt: now.Add(constants.OIDCLoginCodeTTL)}
+	s.ssoMu.Unlock()
+
+	s.logger.Info("Auth: SSO sign-in of subject=%s as user=%s (provisioned=%t linked=%t)",
+		result.Subject, user.Username, result.Provisioned, result.Linked)
+	return result, nil
+}
+
+// ExchangeSSOCode exchanges a one-time login code for a session, the way
+// Login exchanges a password.
+func (s *AuthService) ExchangeSSOCode(code, ipAddress, userAgent string) (string, *auth.User, error) {
+	s.ssoMu.Lock()
+	login := s.ssoCodes[code]
+	delete(s.ssoCodes, code)
+	s.ssoMu.Unlock()
+	if login == nil || time.Now().After(login.expiresAt) {
+		return "", nil, NewServiceError(constants.ErrCodeAuthSSOInvalid, "unknown or expired login code")
+	}
+
+	// The account may have been disabled since the callback
+	user, err := s.store.GetUserByID(login.userID)
+	if err != nil {
+		return "", nil, WrapInternalError(err)
+	}
+	if !user.IsActive {
+		return "", nil, NewServiceError(constants.ErrCodeAuthUserDisabled, "account is disabled")
+	}
+
+	token, err := s.createSession(user.ID, ipAddress, userAgent)
+	if err != nil {
+		return "", nil, err
+	}
+
+	s.logger.Info("Auth: user=%s logged in via SSO from ip=%s", user.Username, ipAddress)
+
+	return token, &user.User, nil
+}
+
+// ssoUser returns the local user of a verified identity. Unknown identities
+// are linked to the user of the same username when link_existing_users is
+// on and their email is verified, or get a new user without grants when
+// auto_provision is on. Administrators are never linked automatically.
+func (s *AuthService) ssoUser(cfg *config.OIDCConfig, issuer string, claims auth.OIDCClaims, result *SSOCallbackResult) (*auth.User, error) {
+	email := claims.String(constants.OIDCEmailClaim)
+	if len(cfg.AllowedDomains) > 0 {
+		_, domain, _ := strings.Cut(email, "@")
+		allowed := slices.ContainsFunc(cfg.AllowedDomains, func(d string) bool { return strings.EqualFold(d, domain) })
+		if domain == "" || !allowed || !claims.EmailVerified() {
+			return nil, NewServiceError(constants.ErrCodeAuthSSODenied, "email domain not allowed to sign in")
+		}
+	}
+
+	identity, err := s.store.GetOIDCIdentity(issuer, result.Subject)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if identity != nil {
+		user, err := s.store.GetUserByID(identity.UserID)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		result.Username = user.Username
+		if !user.IsActive {
+			return nil, NewServiceError(constants.ErrCodeAuthUserDisabled, "account is disabled")
+		}
+		if err := s.store.TouchOIDCIdentity(issuer, result.Subject, email); err != nil {
+			s.logger.Warn("Auth: failed to record SSO sign-in of user=%s: %v", user.Username, err)
+		}
+		return &user.User, nil
+	}
+
+	username := ssoUsername(claims.String(cfg.UsernameClaim), email)
+	result.Username = username
+	if !usernameRegex.MatchString(username) {
+		return nil, NewServiceError(constants.ErrCodeAuthSSODenied,
+			fmt.Sprintf("cannot map the identity to a username matching %s", constants.AuthUsernameRegex))
+	}
+
+	if existing, err := s.store.GetUserByUsername(username); err == nil && existing != nil {
+		if !cfg.LinkExistingUsers || existing.IsServiceAccount() || existing.IsPipeline() {
+			return nil, NewServiceError(constants.ErrCodeAuthSSOUsernameTaken,
+				fmt.Sprintf("username %q belongs to an account not linked to this identity", username))
+		}
+		// A name at the provider proves nothing; require a verified email
+		if email == "" || !claims.EmailVerified() {
+			return nil, NewServiceError(constants.ErrCodeAuthSSODenied,
+				"linking to an existing user requires a verified email")
+		}
+		admin, err := s.isAdminUser(&existing.User)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		if admin {
+			return nil, NewServiceError(constants.ErrCodeAuthSSOUsernameTaken,
+				fmt.Sprintf("username %q belongs to an administrator and is never linked automatically", username))
+		}
+		// Never move a user from one identity to another
+		linked, err := s.store.HasOIDCIdentity(issuer, existing.ID)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		if linked {
+			return nil, NewServiceError(constants.ErrCodeAuthSSOUsernameTaken,
+				fmt.Sprintf("username %q is linked to another identity", username))
+		}
+		if !existing.IsActive {
+			return nil, NewServiceError(constants.ErrCodeAuthUserDisabled, "account is disabled")
+		}
+		if err := s.store.LinkOIDCIdentity(issuer, result.Subject, existing.ID, email); err != nil {
+			return nil, WrapInternalError(err)
+		}
+		result.Linked = true
+		return &existing.User, nil
+	}
+
+	if !cfg.Provisions() {
+		return nil, NewServiceError(constants.ErrCodeAuthSSODenied, "no user is linked to this identity")
+	}
+	user, err := s.store.CreateOIDCUser(username, claims.String(cfg.DisplayNameClaim), issuer, result.Subject, email)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	result.Provisioned = true
+	return user, nil
+}
+
+// isAdminUser reports whether user is the bootstrap admin or holds an
+// active grant for one of the admin actions.
+func (s *AuthService) isAdminUser(user *auth.User) (bool, error) {
+	if user.IsBootstrap {
+		return true, nil
+	}
+	grants, err := s.store.GetActiveGrantsForUser(user.ID)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(grants, func(g auth.Grant) bool {
+		return slices.Contains(constants.AdminAuthActions, g.Action)
+	}), nil
+}
+
+// oidcProvider returns the discovered provider of the configured issuer.
+func (s *AuthService) oidcProvider(ctx context.Context, cfg *config.OIDCConfig) (*auth.OIDCProvider, error) {
+	s.ssoProviderMu.Lock()
+	defer s.ssoProviderMu.Unlock()
+
+	issuer := strings.TrimSuffix(cfg.Issuer, "/")
+	if s.ssoProvider != nil && strings.TrimSuffix(s.ssoProvider.Issuer, "/") == issuer &&
+		time.Since(s.ssoDiscovered) < constants.OIDCProviderCacheTTL {
+		return s.ssoProvider, nil
+	}
+
+	provider, err := auth.DiscoverOIDCProvider(ctx, s.ssoClient, issuer)
+	if err != nil {
+		return nil, WrapServiceError(constants.ErrCodeAuthSSOProviderError, "identity provider discovery failed", err)
+	}
+	s.ssoProvider = provider
+	s.ssoDiscovered = time.Now()
+	return provider, nil
+}
+
+// ssoUsername maps a username claim, or the local part of the email when
+// the claim is empty, to a username: lowercased, with characters usernames
+// may not hold replaced by "-".
+func ssoUsername(claim, email string) string {
+	name := claim
+	if name == "" {
+		name = email
+	}
+	if local, _, ok := strings.Cut(name, "@"); ok {
+		name = local
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '-'
+	}, strings.ToLower(name))
+}
+
+// localReturnPath returns p when it is a path on this host, and "/"
+// otherwise, so sign-ins cannot redirect elsewhere.
+func localReturnPath(p string) string {
+	u, err := url.Parse(p)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(p, "/") ||
+		strings.HasPrefix(p, "//") || strings.Contains(p, `\`) {
+		return "/"
+	}
+	u.Fragment = ""
+	return u.String()
+}
@@ -2,8 +2,11 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"regexp"
+	"sync"
 	"time"
 
 	"silobang/internal/auth"
@@ -20,6 +23,41 @@ type AuthService struct {
 	store     *auth.Store
 	evaluator *auth.PolicyEvaluator
 	stopClean chan struct{} // For session cleanup goroutine shutdown
+
+	// Last API-key usage sample per "userID|ip", unix seconds
+	usageSamples sync.Map
+
+	// Usage recorded since the last flush to the auth_usage table
+	usageMu      sync.Mutex
+	usagePending map[usageKey]*auth.UsageCounters
+
+	notifications *NotificationService
+
+	// OpenID Connect sign-ins in progress by state, and login codes awaiting
+	// exchange
+	ssoMu      sync.Mutex
+	ssoPending map[string]*ssoAttempt
+	ssoCodes   map[string]*ssoLogin
+
+	// Discovered OpenID Connect provider, refreshed after
+	// OIDCProviderCacheTTL or when the configured issuer changes
+	ssoProviderMu sync.Mutex
+	ssoProvider   *auth.OIDCProvider
+	ssoDiscovered time.Time
+	ssoClient     *http.Client
+
+	// Server key S3 gateway secrets are derived with, loaded on first use
+	// from the working directory it was read from
+	s3KeyMu   sync.Mutex
+	s3KeyPath string
+	s3Key     []byte
+}
+
+// usageKey identifies one row of the aggregated usage table.
+type usageKey struct {
+	userID      int64
+	bucketStart int64
+	endpoint    string
 }
 
 // NewAuthService creates a new auth service.
@@ -35,19 +73,30 @@ func NewAuthService(app AppState, log *logger.Logger) *AuthService {
 	evaluator := auth.NewPolicyEvaluator(store, log)
 
 	svc := &AuthService{
-		app:       app,
-		logger:    log,
-		store:     store,
-		evaluator: evaluator,
-		stopClean: make(chan struct{}),
+		app:          app,
+		logger:       log,
+		store:        store,
+		evaluator:    evaluator,
+		stopClean:    make(chan struct{}),
+		usagePending: make(map[usageKey]*auth.UsageCounters),
+		ssoPending:   make(map[string]*ssoAttempt),
+		ssoCodes:     make(map[string]*ssoLogin),
+		ssoClient:    &http.Client{Timeout: constants.OIDCHTTPTimeout},
 	}
 
-	// Start session cleanup goroutine
+	// Start session cleanup and usage flush goroutines
 	go svc.sessionCleanupLoop()
+	go svc.usageFlushLoop()
 
 	return svc
 }
 
+// SetNotificationService sets the service notifying users of changes to
+// their grants.
+func (s *AuthService) SetNotificationService(notifications *NotificationService) {
+	s.notifications = notifications
+}
+
 // GetStore returns the underlying auth store (for middleware initialization).
 func (s *AuthService) GetStore() *auth.Store {
 	return s.store
@@ -74,6 +123,13 @@ func (s *AuthService) Login(username, password, ipAddress, userAgent string) (st
 		return "", nil, NewServiceError(constants.ErrCodeAuthInvalidCredentials, "invalid credentials")
 	}
 
+	// Service and pipeline accounts have no password; they authenticate only
+	// with their tokens
+	if user.IsServiceAccount() || user.IsPipeline() {
+		s.logger.Info("Auth: login denied for service account=%s", username)
+		return "", nil, NewServiceError(constants.ErrCodeAuthInvalidCredentials, "invalid credentials")
+	}
+
 	if !user.IsActive {
 		s.logger.Info("Auth: login denied for disabled user=%s", username)
 		return "", nil, NewServiceError(constants.ErrCodeAuthUserDisabled, "account is disabled")
@@ -102,23 +158,30 @@ func (s *AuthService) Login(username, password, ipAddress, userAgent string) (st
 		s.store.ResetFailedLogin(user.ID)
 	}
 
-	// Create session
-	token, err := auth.GenerateSessionToken()
+	token, err := s.createSession(user.ID, ipAddress, userAgent)
 	if err != nil {
-		return "", nil, WrapInternalError(err)
+		return "", nil, err
 	}
 
-	tokenHash := auth.HashToken(token)
-	tokenPrefix := auth.ExtractTokenPrefix(token)
+	s.logger.Info("Auth: user=%s logged in from ip=%s", username, ipAddress)
+
+	return token, &user.User, nil
+}
 
-	_, err = s.store.CreateSession(tokenHash, tokenPrefix, user.ID, ipAddress, userAgent)
+// createSession starts a session for a user and returns its plaintext token.
+func (s *AuthService) createSession(userID int64, ipAddress, userAgent string) (string, error) {
+	token, err := auth.GenerateSessionToken()
 	if err != nil {
-		return "", nil, WrapInternalError(err)
+		return "", WrapInternalError(err)
 	}
 
-	s.logger.Info("Auth: user=%s logged in from ip=%s", username, ipAddress)
+	tokenHash := auth.HashToken(token)
+	tokenPrefix := auth.ExtractTokenPrefix(token)
 
-	return token, &user.User, nil
+	if _, err := s.store.CreateSession(tokenHash, tokenPrefix, userID, ipAddress, userAgent); err != nil {
+		return "", WrapInternalError(err)
+	}
+	return token, nil
 }
 
 // Logout invalidates a session by its token.
@@ -136,6 +199,34 @@ func (s *AuthService) IsBootstrapped() (bool, error) {
 	return count > 0, nil
 }
 
+// RecoveryResult contains the credentials issued by a break-glass recovery.
+type RecoveryResult struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Password string `json:"password"` // plaintext, shown once
+	APIKey   string `json:"api_key"`  // plaintext, shown once
+}
+
+// RecoverAdmin exchanges a one-time recovery token (issued by the
+// "silobang recover-admin" CLI command) for new bootstrap admin credentials.
+func (s *AuthService) RecoverAdmin(token, ipAddress string) (*RecoveryResult, error) {
+	result, userID, err := auth.RecoverBootstrapAdmin(s.store, token, ipAddress, s.logger)
+	if err == auth.ErrInvalidRecoveryToken {
+		s.logger.Warn("Auth: rejected admin recovery attempt from ip=%s", ipAddress)
+		return nil, NewServiceError(constants.ErrCodeAuthRecoveryInvalid, err.Error())
+	}
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	return &RecoveryResult{
+		UserID:   userID,
+		Username: result.Username,
+		Password: result.Password,
+		APIKey:   result.APIKey,
+	}, nil
+}
+
 // ============================================================================
 // User Management
 // ============================================================================
@@ -267,6 +358,12 @@ func (s *AuthService) UpdateUser(actor *auth.Identity, userID int64, req UpdateU
 	}
 
 	if req.NewPassword != nil {
+		if user.IsServiceAccount() {
+			return NewServiceError(constants.ErrCodeAuthServiceAccount, "service accounts have no password")
+		}
+		if user.IsPipeline() {
+			return NewServiceError(constants.ErrCodeAuthPipelineAccount, "pipeline accounts have no password")
+		}
 		if len(*req.NewPassword) < constants.AuthMinPasswordLength {
 			return NewServiceError(constants.ErrCodeAuthPasswordTooWeak,
 				fmt.Sprintf("password must be at least %d characters", constants.AuthMinPasswordLength))
@@ -294,6 +391,9 @@ func (s *AuthService) RegenerateAPIKey(actor *auth.Identity, userID int64) (stri
 	if err != nil {
 		return "", NewServiceError(constants.ErrCodeAuthUserNotFound, "user not found")
 	}
+	if user.IsPipeline() {
+		return "", NewServiceError(constants.ErrCodeAuthPipelineAccount, "pipeline accounts authenticate only with workspace tokens")
+	}
 
 	apiKey, err := auth.GenerateAPIKey()
 	if err != nil {
@@ -311,6 +411,167 @@ func (s *AuthService) RegenerateAPIKey(actor *auth.Identity, userID int64) (stri
 	return apiKey, nil
 }
 
+// ============================================================================
+// Service Accounts
+// ============================================================================
+
+// ServiceAccountScope is one grant given to a service account.
+type ServiceAccountScope struct {
+	Action          string  `json:"action"`
+	ConstraintsJSON *string `json:"constraints_json,omitempty"`
+}
+
+// CreateServiceAccountRequest contains the fields for creating a service account.
+type CreateServiceAccountRequest struct {
+	Name        string                `json:"name"`
+	DisplayName string                `json:"display_name"`
+	Scopes      []ServiceAccountScope `json:"scopes"`
+}
+
+// ServiceAccountResponse is a service account with its scope grants and,
+// right after creation or rotation, its plaintext token (shown once).
+type ServiceAccountResponse struct {
+	Account     *auth.User   `json:"account"`
+	Scopes      []auth.Grant `json:"scopes"`
+	Token       string       `json:"token,omitempty"`
+	TokenPrefix string       `json:"token_prefix,omitempty"`
+}
+
+// CreateServiceAccount creates a non-interactive account for a processor or
+// other automation. Every scope is checked as a grant the actor creates, and
+// only processor actions may be granted. The account and its grants are
+// created together.
+func (s *AuthService) CreateServiceAccount(actor *auth.Identity, req CreateServiceAccountRequest) (*ServiceAccountResponse, error) {
+	s.logger.Info("Auth: user=%s creating service account=%s", actor.User.Username, req.Name)
+
+	if !usernameRegex.MatchString(req.Name) {
+		return nil, NewServiceError(constants.ErrCodeAuthUsernameInvalid,
+			fmt.Sprintf("name must match pattern: %s", constants.AuthUsernameRegex))
+	}
+	if len(req.Scopes) == 0 {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "at least one scope is required")
+	}
+
+	specs := make([]auth.GrantSpec, 0, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		if !isServiceAccountAction(scope.Action) {
+			return nil, NewServiceError(constants.ErrCodeAuthServiceAccount,
+				fmt.Sprintf("scopes[%d]: action %s cannot be granted to a service account", i, scope.Action))
+		}
+		if err := s.checkGrantable(actor, CreateGrantRequest{Action: scope.Action, ConstraintsJSON: scope.ConstraintsJSON}); err != nil {
+			var svcErr *ServiceError
+			if errors.As(err, &svcErr) {
+				return nil, NewServiceError(svcErr.Code, fmt.Sprintf("scopes[%d]: %s", i, svcErr.Message))
+			}
+			return nil, err
+		}
+		specs = append(specs, auth.GrantSpec{Action: scope.Action, ConstraintsJSON: scope.ConstraintsJSON})
+	}
+
+	existing, err := s.store.GetUserByUsername(req.Name)
+	if err == nil && existing != nil {
+		return nil, NewServiceError(constants.ErrCodeAuthUserExists, "name already taken")
+	}
+
+	token, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	tokenPrefix := auth.ExtractTokenPrefix(token)
+
+	account, grants, err := s.store.CreateServiceAccount(req.Name, req.DisplayName,
+		auth.HashToken(token), tokenPrefix, specs, actor.User.ID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Auth: service account=%s created by=%s (id=%d, %d scopes)",
+		req.Name, actor.User.Username, account.ID, len(grants))
+
+	return &ServiceAccountResponse{
+		Account:     account,
+		Scopes:      grants,
+		Token:       token,
+		TokenPrefix: tokenPrefix,
+	}, nil
+}
+
+// ListServiceAccounts returns all service accounts.
+func (s *AuthService) ListServiceAccounts() ([]auth.User, error) {
+	return s.store.ListServiceAccounts()
+}
+
+// GetServiceAccount returns a service account and its grants.
+func (s *AuthService) GetServiceAccount(id int64) (*ServiceAccountResponse, error) {
+	account, err := s.getServiceAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := s.store.GetAllGrantsForUser(id)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return &ServiceAccountResponse{
+		Account:     &account.User,
+		Scopes:      grants,
+		TokenPrefix: account.APIKeyPrefix,
+	}, nil
+}
+
+// RotateServiceAccountToken replaces the token of a service account. The old
+// token stops working immediately.
+func (s *AuthService) RotateServiceAccountToken(actor *auth.Identity, id int64) (*ServiceAccountResponse, error) {
+	account, err := s.getServiceAccount(id)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	tokenPrefix := auth.ExtractTokenPrefix(token)
+
+	if err := s.store.UpdateUserAPIKey(id, auth.HashToken(token), tokenPrefix); err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Auth: token rotated for service account=%s by=%s", account.Username, actor.User.Username)
+
+	return &ServiceAccountResponse{
+		Account:     &account.User,
+		Token:       token,
+		TokenPrefix: tokenPrefix,
+	}, nil
+}
+
+// DisableServiceAccount deactivates a service account; its token is
+// rejected from then on.
+func (s *AuthService) DisableServiceAccount(actor *auth.Identity, id int64) (*auth.User, error) {
+	account, err := s.getServiceAccount(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.UpdateUser(id, account.DisplayName, false); err != nil {
+		return nil, WrapInternalError(err)
+	}
+	account.IsActive = false
+
+	s.logger.Info("Auth: service account=%s disabled by=%s", account.Username, actor.User.Username)
+
+	return &account.User, nil
+}
+
+// getServiceAccount loads an account and checks it is a service account.
+func (s *AuthService) getServiceAccount(id int64) (*auth.UserWithSensitive, error) {
+	account, err := s.store.GetUserByID(id)
+	if err != nil || !account.IsServiceAccount() {
+		return nil, NewServiceError(constants.ErrCodeAuthUserNotFound, "service account not found")
+	}
+	return account, nil
+}
+
 // ============================================================================
 // Grant Management
 // ============================================================================
@@ -324,24 +585,55 @@ type CreateGrantRequest struct {
 
 // CreateGrant adds a permission grant to a user.
 func (s *AuthService) CreateGrant(actor *auth.Identity, req CreateGrantRequest) (*auth.Grant, error) {
+	if err := s.checkGrantable(actor, req); err != nil {
+		return nil, err
+	}
+
+	grant, err := s.store.CreateGrant(req.UserID, req.Action, req.ConstraintsJSON, actor.User.ID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Auth: grant created id=%d action=%s for user_id=%d by=%s",
+		grant.ID, req.Action, req.UserID, actor.User.Username)
+	s.notifyGrant(actor, constants.NotificationEventGrantCreated, grant.UserID, grant.ID, grant.Action, grant.ConstraintsJSON)
+
+	return grant, nil
+}
+
+// checkGrantable validates a grant request and checks the actor may create it.
+func (s *AuthService) checkGrantable(actor *auth.Identity, req CreateGrantRequest) error {
 	// Validate action
 	if !isValidAction(req.Action) {
-		return nil, NewServiceError(constants.ErrCodeAuthInvalidGrant,
+		return NewServiceError(constants.ErrCodeAuthInvalidGrant,
 			fmt.Sprintf("invalid action: %s", req.Action))
 	}
 
+	// Service accounts only ever hold the narrow set of processor actions;
+	// pipelines hold none, their workspace tokens carry the scopes
+	if target, err := s.store.GetUserByID(req.UserID); err == nil {
+		if target.IsServiceAccount() && !isServiceAccountAction(req.Action) {
+			return NewServiceError(constants.ErrCodeAuthServiceAccount,
+				fmt.Sprintf("action %s cannot be granted to a service account", req.Action))
+		}
+		if target.IsPipeline() {
+			return NewServiceError(constants.ErrCodeAuthPipelineAccount,
+				"pipeline accounts hold no grants; scope their workspace tokens instead")
+		}
+	}
+
 	// Validate constraints JSON schema (reject unknown fields / typos)
 	if err := auth.ValidateConstraintsJSON(req.Action, req.ConstraintsJSON); err != nil {
 		s.logger.Warn("Auth: invalid constraints for grant action=%s by user=%s: %v",
 			req.Action, actor.User.Username, err)
-		return nil, NewServiceError(constants.ErrCodeAuthInvalidConstraints, err.Error())
+		return NewServiceError(constants.ErrCodeAuthInvalidConstraints, err.Error())
 	}
 
 	// Check can_grant_actions restriction on the actor's manage_users grant
 	if !s.actorCanGrantAction(actor, req.Action) {
 		s.logger.Warn("Auth: grant action denied - user=%s tried to grant action=%s outside can_grant_actions",
 			actor.User.Username, req.Action)
-		return nil, NewServiceError(constants.ErrCodeAuthGrantActionDenied,
+		return NewServiceError(constants.ErrCodeAuthGrantActionDenied,
 			fmt.Sprintf("not permitted to grant action: %s", req.Action))
 	}
 
@@ -351,20 +643,99 @@ func (s *AuthService) CreateGrant(actor *auth.Identity, req CreateGrantRequest)
 		if !s.actorHasEscalation(actor) {
 			s.logger.Warn("Auth: escalation denied - user=%s tried to grant action=%s they don't have",
 				actor.User.Username, req.Action)
-			return nil, NewServiceError(constants.ErrCodeAuthEscalationDenied,
+			return NewServiceError(constants.ErrCodeAuthEscalationDenied,
 				"cannot grant permissions you don't have")
 		}
 	}
 
-	grant, err := s.store.CreateGrant(req.UserID, req.Action, req.ConstraintsJSON, actor.User.ID)
+	return nil
+}
+
+// CreateGrants creates many grants, possibly for different users, in one
+// transaction. Every request is checked as in CreateGrant before anything is
+// written; the first failure is returned prefixed with its index and no
+// grant is created.
+func (s *AuthService) CreateGrants(actor *auth.Identity, reqs []CreateGrantRequest) ([]auth.Grant, error) {
+	if len(reqs) == 0 {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "no grants provided")
+	}
+	if len(reqs) > constants.AuthGrantBatchMaxSize {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("too many grants: %d (max %d)", len(reqs), constants.AuthGrantBatchMaxSize))
+	}
+
+	knownUsers := make(map[int64]bool)
+	specs := make([]auth.GrantSpec, 0, len(reqs))
+	for i, req := range reqs {
+		if !knownUsers[req.UserID] {
+			if _, err := s.store.GetUserByID(req.UserID); err != nil {
+				return nil, NewServiceError(constants.ErrCodeAuthUserNotFound,
+					fmt.Sprintf("grants[%d]: user %d not found", i, req.UserID))
+			}
+			knownUsers[req.UserID] = true
+		}
+		if err := s.checkGrantable(actor, req); err != nil {
+			var svcErr *ServiceError
+			if errors.As(err, &svcErr) {
+				return nil, NewServiceError(svcErr.Code, fmt.Sprintf("grants[%d]: %s", i, svcErr.Message))
+			}
+			return nil, err
+		}
+		specs = append(specs, auth.GrantSpec{UserID: req.UserID, Action: req.Action, ConstraintsJSON: req.ConstraintsJSON})
+	}
+
+	grants, err := s.store.CreateGrants(specs, actor.User.ID)
 	if err != nil {
 		return nil, WrapInternalError(err)
 	}
 
-	s.logger.Info("Auth: grant created id=%d action=%s for user_id=%d by=%s",
-		grant.ID, req.Action, req.UserID, actor.User.Username)
+	s.logger.Info("Auth: %d grants created in batch for %d user(s) by=%s",
+		len(grants), len(knownUsers), actor.User.Username)
+	for _, g := range grants {
+		s.notifyGrant(actor, constants.NotificationEventGrantCreated, g.UserID, g.ID, g.Action, g.ConstraintsJSON)
+	}
 
-	return grant, nil
+	return grants, nil
+}
+
+// CopyGrants duplicates the active grants of sourceUserID onto
+// targetUserID. overrides replaces the constraints of copied grants by
+// action; a nil value copies the grant without constraints. The copy is
+// subject to the same checks as CreateGrants and is all-or-nothing.
+func (s *AuthService) CopyGrants(actor *auth.Identity, targetUserID, sourceUserID int64, overrides map[string]*string) ([]auth.Grant, error) {
+	if targetUserID == sourceUserID {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "source and target user are the same")
+	}
+	if _, err := s.store.GetUserByID(sourceUserID); err != nil {
+		return nil, NewServiceError(constants.ErrCodeAuthUserNotFound, "source user not found")
+	}
+
+	source, err := s.store.GetActiveGrantsForUser(sourceUserID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if len(source) == 0 {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "source user has no active grants")
+	}
+
+	copied := make(map[string]bool)
+	reqs := make([]CreateGrantRequest, 0, len(source))
+	for _, g := range source {
+		constraints := g.ConstraintsJSON
+		if override, ok := overrides[g.Action]; ok {
+			constraints = override
+		}
+		copied[g.Action] = true
+		reqs = append(reqs, CreateGrantRequest{UserID: targetUserID, Action: g.Action, ConstraintsJSON: constraints})
+	}
+	for action := range overrides {
+		if !copied[action] {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+				fmt.Sprintf("override for action %s, which the source user has no active grant for", action))
+		}
+	}
+
+	return s.CreateGrants(actor, reqs)
 }
 
 // GetUserGrants returns all grants for a user.
@@ -398,6 +769,7 @@ func (s *AuthService) UpdateGrant(actor *auth.Identity, grantID int64, newConstr
 	}
 
 	s.logger.Info("Auth: grant id=%d updated by=%s", grantID, actor.User.Username)
+	s.notifyGrant(actor, constants.NotificationEventGrantUpdated, grant.UserID, grant.ID, grant.Action, newConstraintsJSON)
 	return grant, nil
 }
 
@@ -425,9 +797,26 @@ func (s *AuthService) RevokeGrant(actor *auth.Identity, grantID int64) (*auth.Gr
 	}
 
 	s.logger.Info("Auth: grant id=%d revoked by=%s", grantID, actor.User.Username)
+	s.notifyGrant(actor, constants.NotificationEventGrantRevoked, grant.UserID, grant.ID, grant.Action, nil)
 	return grant, nil
 }
 
+// notifyGrant tells the holder of a grant that it changed. A grant ID of 0
+// is left out. Users are not notified of changes they make themselves.
+func (s *AuthService) notifyGrant(actor *auth.Identity, event string, userID, grantID int64, action string, constraints *string) {
+	if s.notifications == nil || userID == actor.User.ID {
+		return
+	}
+	details := map[string]interface{}{"action": action}
+	if grantID != 0 {
+		details["grant_id"] = grantID
+	}
+	if constraints != nil {
+		details["constraints_json"] = *constraints
+	}
+	s.notifications.Notify(userID, event, actor.User.Username, details)
+}
+
 // ============================================================================
 // Quota
 // ============================================================================
@@ -437,6 +826,205 @@ func (s *AuthService) GetUserQuota(userID int64) ([]auth.QuotaUsage, error) {
 	return s.store.GetAllQuotaUsage(userID)
 }
 
+// ============================================================================
+// Activity Timeline
+// ============================================================================
+
+// UserActivity is one page of a user's activity timeline.
+type UserActivity struct {
+	UserID     int64                `json:"user_id"`
+	Username   string               `json:"username"`
+	Events     []auth.ActivityEvent `json:"events"`
+	Total      int64                `json:"total"`
+	Limit      int                  `json:"limit"`
+	Offset     int                  `json:"offset"`
+	TopActions []auth.ActionCount   `json:"top_actions"`
+}
+
+// SampleAPIKeyUsage records a request authenticated with an API key, at most
+// once per user and client IP every constants.AuthAPIKeyUsageSampleInterval.
+// Failures are logged; sampling never fails a request.
+func (s *AuthService) SampleAPIKeyUsage(userID int64, ipAddress, userAgent, method, path string) {
+	now := time.Now().Unix()
+	key := fmt.Sprintf("%d|%s", userID, ipAddress)
+	if last, ok := s.usageSamples.Load(key); ok && now-last.(int64) < int64(constants.AuthAPIKeyUsageSampleInterval.Seconds()) {
+		return
+	}
+	s.usageSamples.Store(key, now)
+
+	if err := s.store.RecordAPIKeyUsage(userID, ipAddress, userAgent, method, path); err != nil {
+		s.logger.Warn("Auth: %v", err)
+	}
+}
+
+// ============================================================================
+// API Usage Analytics
+// ============================================================================
+
+// usageWindows are the windows GetUserUsage accepts.
+var usageWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// UserUsage is a user's API usage over a window, with the most requested
+// endpoints.
+type UserUsage struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Window   string `json:"window"`
+	Since    int64  `json:"since"`
+	Until    int64  `json:"until"`
+	auth.UsageCounters
+	ErrorRate    float64              `json:"error_rate"`
+	TopEndpoints []auth.EndpointUsage `json:"top_endpoints"`
+}
+
+// RecordUsage counts one request of a user against an endpoint pattern.
+// Requests answered with a 4xx or 5xx status count as errors. Usage is
+// buffered in memory and written by the flush loop.
+func (s *AuthService) RecordUsage(userID int64, endpoint string, status int, bytesIn, bytesOut int64) {
+	bucket := int64(constants.AuthUsageBucket.Seconds())
+	key := usageKey{userID: userID, bucketStart: time.Now().Unix() / bucket * bucket, endpoint: endpoint}
+	delta := auth.UsageCounters{Requests: 1, BytesIn: bytesIn, BytesOut: bytesOut}
+	if status >= 400 {
+		delta.Errors = 1
+	}
+
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	if c, ok := s.usagePending[key]; ok {
+		c.Add(delta)
+	} else {
+		s.usagePending[key] = &delta
+	}
+}
+
+// FlushUsage writes buffered usage to the auth_usage table. Usage that
+// cannot be written is kept for the next flush.
+func (s *AuthService) FlushUsage() {
+	s.usageMu.Lock()
+	pending := s.usagePending
+	s.usagePending = make(map[usageKey]*auth.UsageCounters)
+	s.usageMu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	records := make([]auth.UsageRecord, 0, len(pending))
+	for k, c := range pending {
+		records = append(records, auth.UsageRecord{UserID: k.userID, BucketStart: k.bucketStart, Endpoint: k.endpoint, UsageCounters: *c})
+	}
+	if err := s.store.AddUsage(records); err != nil {
+		s.logger.Warn("Auth: %v", err)
+		s.usageMu.Lock()
+		for k, c := range pending {
+			if cur, ok := s.usagePending[k]; ok {
+				cur.Add(*c)
+			} else {
+				s.usagePending[k] = c
+			}
+		}
+		s.usageMu.Unlock()
+	}
+}
+
+// GetUserUsage returns a user's request counts, error rate, bytes
+// transferred and most requested endpoints over window (1h, 24h, 7d or 30d;
+// 24h when empty). Windows start on a bucket boundary, so they reach back
+// up to one bucket further than their length.
+func (s *AuthService) GetUserUsage(userID int64, window string) (*UserUsage, error) {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return nil, NewServiceError(constants.ErrCodeAuthUserNotFound, "user not found")
+	}
+
+	if window == "" {
+		window = constants.AuthUsageDefaultWindow
+	}
+	length, ok := usageWindows[window]
+	if !ok {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "invalid usage window: "+window+" (expected 1h, 24h, 7d or 30d)")
+	}
+
+	// Include usage still waiting in memory
+	s.FlushUsage()
+
+	now := time.Now().Unix()
+	bucket := int64(constants.AuthUsageBucket.Seconds())
+	since := (now - int64(length.Seconds())) / bucket * bucket
+
+	totals, err := s.store.GetUsageTotals(userID, since)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	top, err := s.store.TopUsageEndpoints(userID, since, constants.AuthUsageTopEndpointsLimit)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	return &UserUsage{
+		UserID:        user.ID,
+		Username:      user.Username,
+		Window:        window,
+		Since:         since,
+		Until:         now,
+		UsageCounters: totals,
+		ErrorRate:     totals.ErrorRate(),
+		TopEndpoints:  top,
+	}, nil
+}
+
+// GetUserActivity merges the user's sessions, API-key usage samples and audit
+// entries into one timeline, newest first, with the user's most frequent
+// audit actions over the same window.
+func (s *AuthService) GetUserActivity(userID int64, opts auth.ActivityOptions) (*UserActivity, error) {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return nil, NewServiceError(constants.ErrCodeAuthUserNotFound, "user not found")
+	}
+
+	if opts.Kind != "" && !auth.IsValidActivityKind(opts.Kind) {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "invalid activity kind: "+opts.Kind)
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = constants.AuthActivityDefaultLimit
+	}
+	if opts.Limit > constants.AuthActivityMaxLimit {
+		opts.Limit = constants.AuthActivityMaxLimit
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+
+	// Include audit entries still waiting in the write queue
+	if l := s.app.GetAuditLogger(); l != nil {
+		l.Flush()
+	}
+
+	events, total, err := s.store.ListActivity(user.ID, user.Username, opts)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	top, err := s.store.TopAuditActions(user.Username, opts.Since, opts.Until, constants.AuthActivityTopActionsLimit)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	return &UserActivity{
+		UserID:     user.ID,
+		Username:   user.Username,
+		Events:     events,
+		Total:      total,
+		Limit:      opts.Limit,
+		Offset:     opts.Offset,
+		TopActions: top,
+	}, nil
+}
+
 // ============================================================================
 // Helpers
 // ============================================================================
@@ -450,6 +1038,15 @@ func isValidAction(action string) bool {
 	return false
 }
 
+func isServiceAccountAction(action string) bool {
+	for _, a := range constants.ServiceAccountActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *AuthService) actorHasAction(actor *auth.Identity, action string) bool {
 	for _, g := range actor.Grants {
 		if g.Action == action && g.IsActive {
@@ -521,12 +1118,15 @@ func parseJSON(data string, v interface{}) error {
 // Session Cleanup
 // ============================================================================
 
-// Stop stops the session cleanup goroutine (call during graceful shutdown).
+// Stop stops the session cleanup and usage flush goroutines and writes
+// buffered usage (call during graceful shutdown).
 func (s *AuthService) Stop() {
 	close(s.stopClean)
+	s.FlushUsage()
 }
 
-// sessionCleanupLoop periodically purges expired sessions from the database.
+// sessionCleanupLoop periodically purges expired sessions, usage samples,
+// job tokens and API keys from the database.
 func (s *AuthService) sessionCleanupLoop() {
 	ticker := time.NewTicker(constants.AuthSessionCleanupInterval)
 	defer ticker.Stop()
@@ -549,6 +1149,55 @@ func (s *AuthService) sessionCleanupLoop() {
 			} else {
 				s.logger.Debug("Auth: session cleanup found no expired sessions")
 			}
+			s.cleanupAPIKeyUsage()
+			s.cleanupUsage()
+			s.cleanupJobTokens()
+			s.cleanupAPIKeys()
 		}
 	}
 }
+
+// cleanupAPIKeyUsage purges usage samples past retention and forgets
+// sampling state that can no longer suppress a sample.
+func (s *AuthService) cleanupAPIKeyUsage() {
+	now := time.Now()
+	removed, err := s.store.CleanupAPIKeyUsage(now.Add(-constants.AuthAPIKeyUsageRetention).Unix())
+	if err != nil {
+		s.logger.Error("Auth: api key usage cleanup failed: %v", err)
+	} else if removed > 0 {
+		s.logger.Info("Auth: api key usage cleanup removed %d samples", removed)
+	}
+
+	cutoff := now.Add(-constants.AuthAPIKeyUsageSampleInterval).Unix()
+	s.usageSamples.Range(func(key, last interface{}) bool {
+		if last.(int64) < cutoff {
+			s.usageSamples.Delete(key)
+		}
+		return true
+	})
+}
+
+// usageFlushLoop periodically writes buffered usage to the database.
+func (s *AuthService) usageFlushLoop() {
+	ticker := time.NewTicker(constants.AuthUsageFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopClean:
+			return
+		case <-ticker.C:
+			s.FlushUsage()
+		}
+	}
+}
+
+// cleanupUsage purges usage buckets past retention.
+func (s *AuthService) cleanupUsage() {
+	removed, err := s.store.CleanupUsage(time.Now().Add(-constants.AuthUsageRetention).Unix())
+	if err != nil {
+		s.logger.Error("Auth: usage cleanup failed: %v", err)
+	} else if removed > 0 {
+		s.logger.Info("Auth: usage cleanup removed %d buckets", removed)
+	}
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// SnapshotChange is one difference between an auth snapshot and the
+// current accounts, as applied (or planned, in a dry run) by an import.
+type SnapshotChange struct {
+	Type        string  `json:"type"` // constants.AuthSnapshotChange*
+	Username    string  `json:"username"`
+	Action      string  `json:"action,omitempty"`
+	Constraints *string `json:"constraints_json,omitempty"`
+	Detail      string  `json:"detail,omitempty"`
+}
+
+// SnapshotCredential is the API key (or service account token) issued to an
+// account created by an import, shown once.
+type SnapshotCredential struct {
+	Username string `json:"username"`
+	APIKey   string `json:"api_key"`
+}
+
+// SnapshotImportResult describes an import.
+type SnapshotImportResult struct {
+	DryRun      bool                 `json:"dry_run"`
+	Prune       bool                 `json:"prune"`
+	Changes     []SnapshotChange     `json:"changes"`
+	Credentials []SnapshotCredential `json:"credentials,omitempty"`
+}
+
+// Count returns the number of changes of the given type.
+func (r *SnapshotImportResult) Count(changeType string) int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Type == changeType {
+			n++
+		}
+	}
+	return n
+}
+
+// ExportSnapshot returns every account but the bootstrap user, sorted by
+// username, with its active grants. Roles are never exported: an instance
+// only knows the grants they expanded to.
+func (s *AuthService) ExportSnapshot() (*auth.Snapshot, error) {
+	accounts, err := s.allAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &auth.Snapshot{Version: constants.AuthSnapshotVersion, Users: []auth.SnapshotUser{}}
+	for _, u := range accounts {
+		if u.IsBootstrap {
+			continue
+		}
+		grants, err := s.store.GetActiveGrantsForUser(u.ID)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		active := u.IsActive
+		su := auth.SnapshotUser{
+			Username:    u.Username,
+			DisplayName: u.DisplayName,
+			AccountType: u.AccountType,
+			Active:      &active,
+		}
+		for _, g := range grants {
+			su.Grants = append(su.Grants, auth.SnapshotGrantOf(g))
+		}
+		snap.Users = append(snap.Users, su)
+	}
+	return snap, nil
+}
+
+// snapshotAccount is a snapshot user with its roles expanded and its
+// grants in stored form.
+type snapshotAccount struct {
+	auth.SnapshotUser
+	accountType string
+	active      bool
+	grants      []auth.GrantSpec
+}
+
+// ImportSnapshot makes the accounts and grants of the instance match snap.
+// Accounts missing from the instance are created, display names, active
+// flags and grants of existing ones are brought in line. Accounts absent
+// from the snapshot are left alone unless prune is set, in which case they
+// are disabled. Every grant created or revoked is checked as if the actor
+// made it by hand. Nothing is written on a dry run, or when any check
+// fails; otherwise all changes are applied in one transaction. Importing
+// the same snapshot again changes nothing.
+func (s *AuthService) ImportSnapshot(actor *auth.Identity, snap *auth.Snapshot, dryRun, prune bool) (*SnapshotImportResult, error) {
+	wanted, err := s.expandSnapshot(snap)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.allAccounts()
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]auth.User, len(accounts))
+	for _, u := range accounts {
+		existing[u.Username] = u
+	}
+
+	result := &SnapshotImportResult{DryRun: dryRun, Prune: prune, Changes: []SnapshotChange{}}
+	var changes auth.SnapshotChanges
+	for _, a := range wanted {
+		current, ok := existing[a.Username]
+		if !ok {
+			for _, g := range a.grants {
+				if err := s.checkSnapshotGrant(actor, a, CreateGrantRequest{Action: g.Action, ConstraintsJSON: g.ConstraintsJSON}); err != nil {
+					return nil, err
+				}
+			}
+			changes.Create = append(changes.Create, auth.SnapshotNewAccount{
+				Username:    a.Username,
+				DisplayName: a.DisplayName,
+				AccountType: a.accountType,
+				IsActive:    a.active,
+				Grants:      a.grants,
+			})
+			result.Changes = append(result.Changes, SnapshotChange{
+				Type: constants.AuthSnapshotChangeUserCreated, Username: a.Username, Detail: a.accountType,
+			})
+			for _, g := range a.grants {
+				result.Changes = append(result.Changes, SnapshotChange{
+					Type: constants.AuthSnapshotChangeGrantCreated, Username: a.Username, Action: g.Action, Constraints: g.ConstraintsJSON,
+				})
+			}
+			continue
+		}
+
+		if current.IsBootstrap {
+			return nil, NewServiceError(constants.ErrCodeAuthBootstrapProtected,
+				fmt.Sprintf("user %s: the bootstrap user cannot be imported", a.Username))
+		}
+		if current.AccountType != a.accountType {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+				fmt.Sprintf("user %s: account type is %s, snapshot has %s", a.Username, current.AccountType, a.accountType))
+		}
+
+		if current.DisplayName != a.DisplayName || current.IsActive != a.active {
+			if !a.active && current.ID == actor.User.ID {
+				return nil, NewServiceError(constants.ErrCodeInvalidRequest, "an import cannot disable the importing user")
+			}
+			changes.Update = append(changes.Update, auth.SnapshotAccountUpdate{
+				UserID: current.ID, DisplayName: a.DisplayName, IsActive: a.active,
+			})
+			changeType := constants.AuthSnapshotChangeUserUpdated
+			if current.IsActive && !a.active {
+				changeType = constants.AuthSnapshotChangeUserDisabled
+			}
+			result.Changes = append(result.Changes, SnapshotChange{Type: changeType, Username: a.Username})
+		}
+
+		revoke, create, err := s.diffSnapshotGrants(current.ID, a.grants)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range revoke {
+			if !s.actorHasAction(actor, g.Action) && !s.actorHasEscalation(actor) {
+				return nil, NewServiceError(constants.ErrCodeAuthEscalationDenied,
+					fmt.Sprintf("user %s: cannot revoke grants for actions you don't have", a.Username))
+			}
+			changes.RevokeGrants = append(changes.RevokeGrants, g)
+			result.Changes = append(result.Changes, SnapshotChange{
+				Type: constants.AuthSnapshotChangeGrantRevoked, Username: a.Username, Action: g.Action, Constraints: g.ConstraintsJSON,
+			})
+		}
+		for _, g := range create {
+			if err := s.checkSnapshotGrant(actor, a, CreateGrantRequest{UserID: current.ID, Action: g.Action, ConstraintsJSON: g.ConstraintsJSON}); err != nil {
+				return nil, err
+			}
+			changes.CreateGrants = append(changes.CreateGrants, auth.GrantSpec{UserID: current.ID, Action: g.Action, ConstraintsJSON: g.ConstraintsJSON})
+			result.Changes = append(result.Changes, SnapshotChange{
+				Type: constants.AuthSnapshotChangeGrantCreated, Username: a.Username, Action: g.Action, Constraints: g.ConstraintsJSON,
+			})
+		}
+	}
+
+	if prune {
+		listed := make(map[string]bool, len(wanted))
+		for _, a := range wanted {
+			listed[a.Username] = true
+		}
+		for _, u := range accounts {
+			if listed[u.Username] || u.IsBootstrap || !u.IsActive {
+				continue
+			}
+			if u.ID == actor.User.ID {
+				return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+					fmt.Sprintf("user %s: an import cannot disable the importing user", u.Username))
+			}
+			changes.Update = append(changes.Update, auth.SnapshotAccountUpdate{
+				UserID: u.ID, DisplayName: u.DisplayName, IsActive: false,
+			})
+			result.Changes = append(result.Changes, SnapshotChange{
+				Type: constants.AuthSnapshotChangeUserDisabled, Username: u.Username, Detail: "not in snapshot",
+			})
+		}
+	}
+
+	if dryRun || len(result.Changes) == 0 {
+		return result, nil
+	}
+
+	for i := range changes.Create {
+		apiKey, err := auth.GenerateAPIKey()
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		changes.Create[i].APIKeyHash = auth.HashToken(apiKey)
+		changes.Create[i].APIKeyPrefix = auth.ExtractTokenPrefix(apiKey)
+		result.Credentials = append(result.Credentials, SnapshotCredential{Username: changes.Create[i].Username, APIKey: apiKey})
+	}
+
+	if _, err := s.store.ApplySnapshot(changes, actor.User.ID); err != nil {
+		return nil, WrapInternalError(err)
+	}
+	for _, g := range changes.RevokeGrants {
+		s.notifyGrant(actor, constants.NotificationEventGrantRevoked, g.UserID, g.ID, g.Action, nil)
+	}
+	for _, g := range changes.CreateGrants {
+		s.notifyGrant(actor, constants.NotificationEventGrantCreated, g.UserID, 0, g.Action, g.ConstraintsJSON)
+	}
+
+	s.logger.Info("Auth: snapshot imported by=%s (%d created, %d updated, %d grants revoked, %d grants created)",
+		actor.User.Username, len(changes.Create), len(changes.Update), len(changes.RevokeGrants),
+		result.Count(constants.AuthSnapshotChangeGrantCreated))
+
+	return result, nil
+}
+
+// allAccounts returns users and service accounts, sorted by username.
+func (s *AuthService) allAccounts() ([]auth.User, error) {
+	users, err := s.store.ListUsers()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	serviceAccounts, err := s.store.ListServiceAccounts()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	accounts := append(users, serviceAccounts...)
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Username < accounts[j].Username })
+	return accounts, nil
+}
+
+// expandSnapshot validates a snapshot and resolves the roles of its users
+// into grants. Grants a user holds both directly and through a role are
+// kept once.
+func (s *AuthService) expandSnapshot(snap *auth.Snapshot) ([]snapshotAccount, error) {
+	if snap.Version != constants.AuthSnapshotVersion {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("unsupported snapshot version %d (expected %d)", snap.Version, constants.AuthSnapshotVersion))
+	}
+
+	roles := make(map[string][]auth.SnapshotGrant, len(snap.Roles))
+	for _, r := range snap.Roles {
+		if r.Name == "" {
+			return nil, ErrMissingParamWithName("roles[].name")
+		}
+		if _, dup := roles[r.Name]; dup {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest, fmt.Sprintf("role %s is defined twice", r.Name))
+		}
+		roles[r.Name] = r.Grants
+	}
+
+	seen := make(map[string]bool, len(snap.Users))
+	accounts := make([]snapshotAccount, 0, len(snap.Users))
+	for _, u := range snap.Users {
+		if !usernameRegex.MatchString(u.Username) {
+			return nil, NewServiceError(constants.ErrCodeAuthUsernameInvalid,
+				fmt.Sprintf("user %q: username must match pattern: %s", u.Username, constants.AuthUsernameRegex))
+		}
+		if seen[u.Username] {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest, fmt.Sprintf("user %s is listed twice", u.Username))
+		}
+		seen[u.Username] = true
+
+		a := snapshotAccount{SnapshotUser: u, accountType: u.AccountType, active: u.Active == nil || *u.Active}
+		if a.accountType == "" {
+			a.accountType = constants.AuthAccountTypeUser
+		}
+		if a.accountType != constants.AuthAccountTypeUser && a.accountType != constants.AuthAccountTypeService {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+				fmt.Sprintf("user %s: invalid account type %q", u.Username, u.AccountType))
+		}
+
+		grants := append([]auth.SnapshotGrant{}, u.Grants...)
+		for _, name := range u.Roles {
+			roleGrants, ok := roles[name]
+			if !ok {
+				return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+					fmt.Sprintf("user %s: unknown role %s", u.Username, name))
+			}
+			grants = append(grants, roleGrants...)
+		}
+
+		keys := make(map[string]bool, len(grants))
+		for _, g := range grants {
+			constraints, err := g.ConstraintsJSON()
+			if err != nil {
+				return nil, NewServiceError(constants.ErrCodeAuthInvalidConstraints, fmt.Sprintf("user %s: %v", u.Username, err))
+			}
+			spec := auth.GrantSpec{Action: g.Action, ConstraintsJSON: constraints}
+			if key := snapshotGrantKey(spec.Action, spec.ConstraintsJSON); !keys[key] {
+				keys[key] = true
+				a.grants = append(a.grants, spec)
+			}
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, nil
+}
+
+// checkSnapshotGrant checks a grant of a snapshot account as CreateGrant
+// would, including for accounts the import is about to create.
+func (s *AuthService) checkSnapshotGrant(actor *auth.Identity, a snapshotAccount, req CreateGrantRequest) error {
+	if a.accountType == constants.AuthAccountTypeService && !isServiceAccountAction(req.Action) {
+		return NewServiceError(constants.ErrCodeAuthServiceAccount,
+			fmt.Sprintf("user %s: action %s cannot be granted to a service account", a.Username, req.Action))
+	}
+	if err := s.checkGrantable(actor, req); err != nil {
+		var svcErr *ServiceError
+		if errors.As(err, &svcErr) {
+			return NewServiceError(svcErr.Code, fmt.Sprintf("user %s: %s", a.Username, svcErr.Message))
+		}
+		return err
+	}
+	return nil
+}
+
+// diffSnapshotGrants compares the active grants of a user with the wanted
+// ones. Grants are equal when their action and constraints are.
+func (s *AuthService) diffSnapshotGrants(userID int64, wanted []auth.GrantSpec) ([]auth.Grant, []auth.GrantSpec, error) {
+	current, err := s.store.GetActiveGrantsForUser(userID)
+	if err != nil {
+		return nil, nil, WrapInternalError(err)
+	}
+
+	want := make(map[string]bool, len(wanted))
+	for _, g := range wanted {
+		want[snapshotGrantKey(g.Action, g.ConstraintsJSON)] = true
+	}
+
+	var revoke []auth.Grant
+	have := make(map[string]bool, len(current))
+	for _, g := range current {
+		constraints, err := auth.SnapshotGrantOf(g).ConstraintsJSON()
+		if err != nil {
+			return nil, nil, WrapInternalError(err)
+		}
+		key := snapshotGrantKey(g.Action, constraints)
+		if !want[key] || have[key] {
+			revoke = append(revoke, g)
+			continue
+		}
+		have[key] = true
+	}
+
+	var create []auth.GrantSpec
+	for _, g := range wanted {
+		if !have[snapshotGrantKey(g.Action, g.ConstraintsJSON)] {
+			create = append(create, g)
+		}
+	}
+	return revoke, create, nil
+}
+
+// snapshotGrantKey identifies a grant by action and canonical constraints.
+func snapshotGrantKey(action string, constraintsJSON *string) string {
+	if constraintsJSON == nil {
+		return action
+	}
+	return action + " " + *constraintsJSON
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+)
+
+// ============================================================================
+// Workspace Tokens (CI pipelines)
+// ============================================================================
+
+// CreateWorkspaceTokenRequest contains the fields for issuing a workspace
+// token to a pipeline.
+type CreateWorkspaceTokenRequest struct {
+	Pipeline    string                `json:"pipeline"`
+	Description string                `json:"description"`
+	TTLHours    int                   `json:"ttl_hours"`
+	Scopes      []ServiceAccountScope `json:"scopes"`
+}
+
+// WorkspaceTokenResponse is a workspace token and, right after creation,
+// its plaintext token (shown once).
+type WorkspaceTokenResponse struct {
+	*auth.WorkspaceToken
+	Token string `json:"token,omitempty"`
+}
+
+// JobTokenResponse is a job token exchanged for a workspace token.
+type JobTokenResponse struct {
+	Token            string   `json:"token"`
+	TokenPrefix      string   `json:"token_prefix"`
+	ExpiresAt        int64    `json:"expires_at"`
+	Pipeline         string   `json:"pipeline"`
+	Job              string   `json:"job,omitempty"`
+	Actions          []string `json:"actions"`
+	WorkspaceTokenID int64    `json:"workspace_token_id"`
+	WorkspacePrefix  string   `json:"workspace_token_prefix"`
+}
+
+// RevokeWorkspaceTokensRequest selects the workspace tokens to revoke.
+// Exactly one of IDs, Pipeline and All must be set.
+type RevokeWorkspaceTokensRequest struct {
+	IDs      []int64 `json:"ids,omitempty"`
+	Pipeline string  `json:"pipeline,omitempty"`
+	All      bool    `json:"all,omitempty"`
+}
+
+// CreateWorkspaceToken issues a workspace token to a pipeline, creating the
+// pipeline account on first use. Scopes follow the service account rules:
+// only processor actions, each checked as a grant the actor creates.
+func (s *AuthService) CreateWorkspaceToken(actor *auth.Identity, req CreateWorkspaceTokenRequest) (*WorkspaceTokenResponse, error) {
+	s.logger.Info("Auth: user=%s creating workspace token for pipeline=%s", actor.User.Username, req.Pipeline)
+
+	if !usernameRegex.MatchString(req.Pipeline) {
+		return nil, NewServiceError(constants.ErrCodeAuthUsernameInvalid,
+			fmt.Sprintf("pipeline must match pattern: %s", constants.AuthUsernameRegex))
+	}
+	if len(req.Description) > constants.AuthWorkspaceTokenMaxDescription {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("description must be at most %d characters", constants.AuthWorkspaceTokenMaxDescription))
+	}
+	ttl := time.Duration(req.TTLHours) * time.Hour
+	if req.TTLHours <= 0 || ttl > constants.AuthWorkspaceTokenMaxTTL {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("ttl_hours must be between 1 and %d", int(constants.AuthWorkspaceTokenMaxTTL.Hours())))
+	}
+	if len(req.Scopes) == 0 {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "at least one scope is required")
+	}
+
+	scopes := make([]auth.WorkspaceScope, 0, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		if !isServiceAccountAction(scope.Action) {
+			return nil, NewServiceError(constants.ErrCodeAuthPipelineAccount,
+				fmt.Sprintf("scopes[%d]: action %s cannot be given to a pipeline", i, scope.Action))
+		}
+		if err := s.checkGrantable(actor, CreateGrantRequest{Action: scope.Action, ConstraintsJSON: scope.ConstraintsJSON}); err != nil {
+			var svcErr *ServiceError
+			if errors.As(err, &svcErr) {
+				return nil, NewServiceError(svcErr.Code, fmt.Sprintf("scopes[%d]: %s", i, svcErr.Message))
+			}
+			return nil, err
+		}
+		scopes = append(scopes, auth.WorkspaceScope{Action: scope.Action, ConstraintsJSON: scope.ConstraintsJSON})
+	}
+
+	account, err := s.store.EnsurePipelineAccount(req.Pipeline, actor.User.ID)
+	if err == auth.ErrPipelineNameTaken {
+		return nil, NewServiceError(constants.ErrCodeAuthUserExists, "name already taken by a user or service account")
+	}
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	token, err := auth.GenerateWorkspaceToken()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	wt, err := s.store.CreateWorkspaceToken(account, req.Description, auth.HashToken(token),
+		auth.ExtractTokenPrefix(token), scopes, ttl, actor.User.ID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Auth: workspace token id=%d issued to pipeline=%s by=%s (%d scopes, expires %d)",
+		wt.ID, account.Username, actor.User.Username, len(scopes), wt.ExpiresAt)
+
+	return &WorkspaceTokenResponse{WorkspaceToken: wt, Token: token}, nil
+}
+
+// ListWorkspaceTokens returns all workspace tokens, or those of one
+// pipeline, with their status and last use.
+func (s *AuthService) ListWorkspaceTokens(pipeline string) ([]auth.WorkspaceToken, error) {
+	tokens, err := s.store.ListWorkspaceTokens(pipeline)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return tokens, nil
+}
+
+// ExchangeWorkspaceToken trades an active workspace token for a job token
+// carrying its scopes. The job token lives ttlSecs seconds (default
+// constants.AuthJobTokenDefaultTTL), never longer than
+// constants.AuthJobTokenMaxTTL or the workspace token itself.
+func (s *AuthService) ExchangeWorkspaceToken(token, job string, ttlSecs int64, ipAddress string) (*JobTokenResponse, error) {
+	invalid := NewServiceError(constants.ErrCodeAuthWorkspaceTokenInvalid, "invalid, expired or revoked workspace token")
+
+	if len(job) > constants.AuthJobNameMaxLength {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("job must be at most %d characters", constants.AuthJobNameMaxLength))
+	}
+	if ttlSecs < 0 {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "ttl_secs must not be negative")
+	}
+	if !auth.IsWorkspaceToken(token) {
+		return nil, invalid
+	}
+
+	wt, err := s.store.GetWorkspaceTokenByHash(auth.HashToken(token))
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if wt == nil || wt.Status != constants.AuthWorkspaceTokenStatusActive {
+		s.logger.Warn("Auth: rejected workspace token exchange from ip=%s", ipAddress)
+		return nil, invalid
+	}
+	account, err := s.store.GetUserByID(wt.AccountID)
+	if err != nil || !account.IsActive {
+		s.logger.Warn("Auth: rejected workspace token exchange for disabled pipeline id=%d", wt.AccountID)
+		return nil, invalid
+	}
+
+	ttl := constants.AuthJobTokenDefaultTTL
+	if ttlSecs > 0 {
+		ttl = min(time.Duration(ttlSecs)*time.Second, constants.AuthJobTokenMaxTTL)
+	}
+	expiresAt := min(time.Now().Add(ttl).Unix(), wt.ExpiresAt)
+
+	jobToken, err := auth.GenerateJobToken()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	jobPrefix := auth.ExtractTokenPrefix(jobToken)
+
+	ok, err := s.store.RecordWorkspaceTokenExchange(wt.ID, auth.HashToken(jobToken), jobPrefix, job, ipAddress, expiresAt)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if !ok {
+		return nil, invalid
+	}
+
+	actions := make([]string, 0, len(wt.Scopes))
+	for _, scope := range wt.Scopes {
+		actions = append(actions, scope.Action)
+	}
+
+	s.logger.Info("Auth: workspace token id=%d exchanged by pipeline=%s job=%q from ip=%s",
+		wt.ID, wt.Pipeline, job, ipAddress)
+
+	return &JobTokenResponse{
+		Token:            jobToken,
+		TokenPrefix:      jobPrefix,
+		ExpiresAt:        expiresAt,
+		Pipeline:         wt.Pipeline,
+		Job:              job,
+		Actions:          actions,
+		WorkspaceTokenID: wt.ID,
+		WorkspacePrefix:  wt.TokenPrefix,
+	}, nil
+}
+
+// RevokeWorkspaceTokens revokes the selected workspace tokens and every job
+// token exchanged for them. Returns the IDs of the tokens revoked; tokens
+// already revoked are skipped.
+func (s *AuthService) RevokeWorkspaceTokens(actor *auth.Identity, req RevokeWorkspaceTokensRequest) ([]int64, error) {
+	selectors := 0
+	if len(req.IDs) > 0 {
+		selectors++
+	}
+	if req.Pipeline != "" {
+		selectors++
+	}
+	if req.All {
+		selectors++
+	}
+	if selectors != 1 {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "set exactly one of ids, pipeline and all")
+	}
+
+	ids, err := s.store.RevokeWorkspaceTokens(auth.WorkspaceTokenRevocation{
+		IDs:      req.IDs,
+		Pipeline: req.Pipeline,
+		All:      req.All,
+	}, actor.User.ID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Auth: %d workspace token(s) revoked by=%s", len(ids), actor.User.Username)
+	return ids, nil
+}
+
+// cleanupJobTokens purges expired job tokens.
+func (s *AuthService) cleanupJobTokens() {
+	removed, err := s.store.CleanupExpiredJobTokens()
+	if err != nil {
+		s.logger.Error("Auth: job token cleanup failed: %v", err)
+	} else if removed > 0 {
+		s.logger.Info("Auth: job token cleanup removed %d expired job tokens", removed)
+	}
+}
@@ -0,0 +1,541 @@
+package services
+
+import (
+	"archive/tar"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeebo/blake3"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/storage"
+	"silobang/internal/version"
+)
+
+// BackupService writes point-in-time archives of the working directory. The
+// orchestrator database is snapshotted first, then each healthy topic in
+// turn: its database is snapshotted and its .dat files are opened under the
+// topic write lock, so the archived files hold exactly the entries the
+// archived database records. Uploads to a topic wait while its database is
+// copied; the .dat files are read after the lock is released.
+type BackupService struct {
+	app    AppState
+	logger *logger.Logger
+
+	mu      sync.Mutex
+	running bool
+}
+
+// BackupFile is a file in a backup archive.
+type BackupFile struct {
+	Path string `json:"path"` // slash-separated, relative to the working directory
+	Size int64  `json:"size"`
+	Hash string `json:"hash"` // BLAKE3, hex
+}
+
+// BackupOffload is a .dat file left out of a backup because its only copy is
+// in a blob store. The archived topic database still points at it.
+type BackupOffload struct {
+	Topic     string `json:"topic"`
+	DatFile   string `json:"dat_file"`
+	BlobStore string `json:"blob_store"`
+}
+
+// BackupManifest describes a backup archive. It is the last entry of the
+// archive, so an archive cut short has none.
+type BackupManifest struct {
+	FormatVersion int               `json:"format_version"`
+	Version       string            `json:"version"` // server version that wrote the archive
+	CreatedAt     int64             `json:"created_at"`
+	Topics        []string          `json:"topics"`
+	SkippedTopics map[string]string `json:"skipped_topics,omitempty"` // unhealthy topics, with the reason
+	Offloaded     []BackupOffload   `json:"offloaded,omitempty"`
+	Files         []BackupFile      `json:"files"`
+	TotalBytes    int64             `json:"total_bytes"`
+}
+
+// NewBackupService creates a new backup service instance.
+func NewBackupService(app AppState, log *logger.Logger) *BackupService {
+	return &BackupService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// Write writes a backup archive to w and records it as a backup_created
+// audit entry. Only one backup is written at a time. Nothing is written to w
+// before the orchestrator database has been snapshotted, so an error with
+// nothing written can still be reported to the caller.
+func (s *BackupService) Write(ctx context.Context, w io.Writer, username, ipAddress string) (*BackupManifest, error) {
+	workDir := s.app.GetWorkingDirectory()
+	orchDB := s.app.GetOrchestratorDB()
+	if workDir == "" || orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil, NewServiceError(constants.ErrCodeBackupInProgress, "a backup is already being written")
+	}
+	s.running = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	start := time.Now()
+	tempDir := filepath.Join(workDir, constants.InternalDir, constants.BackupTempDir)
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, WrapInternalError(err)
+	}
+	snapshotDir, err := os.MkdirTemp(tempDir, "")
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	defer os.RemoveAll(snapshotDir)
+
+	b := &backupWriter{
+		tw: tar.NewWriter(w),
+		manifest: &BackupManifest{
+			FormatVersion: constants.BackupFormatVersion,
+			Version:       version.Version,
+			CreatedAt:     start.Unix(),
+			Topics:        []string{},
+			Files:         []BackupFile{},
+		},
+	}
+	err = s.write(ctx, b, orchDB, snapshotDir)
+	s.audit(b.manifest, time.Since(start), err, username, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("[backup] wrote %d file(s) of %d topic(s), %d bytes in %s",
+		len(b.manifest.Files), len(b.manifest.Topics), b.manifest.TotalBytes, time.Since(start).Round(time.Millisecond))
+	return b.manifest, nil
+}
+
+func (s *BackupService) write(ctx context.Context, b *backupWriter, orchDB *sql.DB, snapshotDir string) error {
+	// The orchestrator goes first: asset_index rows of assets uploaded while
+	// the topics are copied are filled in at startup, but rows pointing at
+	// assets missing from the archived topics would not be removed
+	orchSnapshot := filepath.Join(snapshotDir, constants.OrchestratorDB)
+	if err := database.BackupDatabase(ctx, orchDB, orchSnapshot); err != nil {
+		return WrapInternalError(fmt.Errorf("failed to snapshot orchestrator database: %w", err))
+	}
+	if err := b.addFile(path.Join(constants.InternalDir, constants.OrchestratorDB), orchSnapshot); err != nil {
+		return err
+	}
+
+	topics := s.app.ListTopics()
+	sort.Strings(topics)
+	for _, topicName := range topics {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if healthy, errMsg := s.app.IsTopicHealthy(topicName); !healthy {
+			if b.manifest.SkippedTopics == nil {
+				b.manifest.SkippedTopics = make(map[string]string)
+			}
+			b.manifest.SkippedTopics[topicName] = errMsg
+			s.logger.Warn("[backup] skipping unhealthy topic %s: %s", topicName, errMsg)
+			continue
+		}
+		if err := s.writeTopic(ctx, b, topicName, snapshotDir); err != nil {
+			return err
+		}
+		b.manifest.Topics = append(b.manifest.Topics, topicName)
+	}
+
+	data, err := json.MarshalIndent(b.manifest, "", "  ")
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	if err := b.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     constants.BackupManifestFile,
+		Size:     int64(len(data)),
+		Mode:     0644,
+		ModTime:  time.Unix(b.manifest.CreatedAt, 0),
+	}); err != nil {
+		return err
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		return err
+	}
+	return b.tw.Close()
+}
+
+// datSection is a .dat file opened under the topic write lock, archived up
+// to its length at that time.
+type datSection struct {
+	name string
+	f    *os.File
+	size int64
+}
+
+// writeTopic archives a topic's database snapshot and .dat files.
+func (s *BackupService) writeTopic(ctx context.Context, b *backupWriter, topicName, snapshotDir string) error {
+	db, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	topicPath := s.app.GetTopicPath(topicName)
+	dbSnapshot := filepath.Join(snapshotDir, topicName+".db")
+
+	mu := s.app.GetTopicWriteMu(topicName)
+	mu.Lock()
+	sections, err := func() ([]datSection, error) {
+		if err := database.BackupDatabase(ctx, db, dbSnapshot); err != nil {
+			return nil, fmt.Errorf("failed to snapshot database of topic %s: %w", topicName, err)
+		}
+		offloads, err := database.ListDatOffloads(db)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range slices.Sorted(maps.Keys(offloads)) {
+			b.manifest.Offloaded = append(b.manifest.Offloaded, BackupOffload{
+				Topic:     topicName,
+				DatFile:   name,
+				BlobStore: offloads[name].BlobStore,
+			})
+		}
+
+		datFiles, err := storage.ListDatFiles(topicPath)
+		if err != nil {
+			return nil, err
+		}
+		var sections []datSection
+		for _, name := range datFiles {
+			if _, ok := offloads[name]; ok {
+				continue
+			}
+			f, err := os.Open(filepath.Join(topicPath, name))
+			if err != nil {
+				closeDatSections(sections)
+				return nil, err
+			}
+			info, err := f.Stat()
+			if err != nil {
+				f.Close()
+				closeDatSections(sections)
+				return nil, err
+			}
+			sections = append(sections, datSection{name: name, f: f, size: info.Size()})
+		}
+		return sections, nil
+	}()
+	mu.Unlock()
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	defer closeDatSections(sections)
+
+	if err := b.addFile(path.Join(topicName, constants.InternalDir, topicName+".db"), dbSnapshot); err != nil {
+		return err
+	}
+	for _, sec := range sections {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := b.add(path.Join(topicName, sec.name), io.NewSectionReader(sec.f, 0, sec.size), sec.size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func closeDatSections(sections []datSection) {
+	for _, sec := range sections {
+		sec.f.Close()
+	}
+}
+
+// audit records a backup_created entry, with the error of a failed backup.
+func (s *BackupService) audit(m *BackupManifest, elapsed time.Duration, backupErr error, username, ipAddress string) {
+	l := s.app.GetAuditLogger()
+	if l == nil {
+		return
+	}
+	details := audit.BackupCreatedDetails{
+		Topics:     len(m.Topics),
+		Files:      len(m.Files),
+		Bytes:      m.TotalBytes,
+		DurationMs: elapsed.Milliseconds(),
+	}
+	if backupErr != nil {
+		details.Error = backupErr.Error()
+	}
+	if err := l.Log(constants.AuditActionBackupCreated, ipAddress, username, details); err != nil {
+		s.logger.Warn("[backup] failed to record audit entry: %v", err)
+	}
+}
+
+// backupWriter writes files to a backup archive, recording each one in the
+// manifest with the hash of the bytes written.
+type backupWriter struct {
+	tw       *tar.Writer
+	manifest *BackupManifest
+}
+
+// addFile archives the file at src under name.
+func (b *backupWriter) addFile(name, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	return b.add(name, f, info.Size())
+}
+
+// add archives size bytes of r under name.
+func (b *backupWriter) add(name string, r io.Reader, size int64) error {
+	if err := b.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  time.Unix(b.manifest.CreatedAt, 0),
+	}); err != nil {
+		return err
+	}
+	hasher := blake3.New()
+	if _, err := io.CopyN(io.MultiWriter(b.tw, hasher), r, size); err != nil {
+		return err
+	}
+	b.manifest.Files = append(b.manifest.Files, BackupFile{
+		Path: name,
+		Size: size,
+		Hash: hex.EncodeToString(hasher.Sum(nil)),
+	})
+	b.manifest.TotalBytes += size
+	return nil
+}
+
+// RestoreBackup unpacks a backup archive into targetDir, which must not
+// exist or be empty. The archive is unpacked into a staging directory next to
+// targetDir and moved into place only once every file matches the hash in
+// the manifest, the databases pass an integrity check and the .dat files
+// match the hash chains their topic database records; otherwise the staging
+// directory is removed and targetDir is left untouched. Returns the
+// manifest of the restored archive.
+func RestoreBackup(r io.Reader, targetDir string) (*BackupManifest, error) {
+	targetDir, err := filepath.Abs(targetDir)
+	if err != nil {
+		return nil, err
+	}
+	if entries, err := os.ReadDir(targetDir); err == nil {
+		if len(entries) > 0 {
+			return nil, fmt.Errorf("target directory %s is not empty", targetDir)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	staging := targetDir + constants.RestoreStagingSuffix
+	if _, err := os.Stat(staging); err == nil {
+		return nil, fmt.Errorf("staging directory %s already exists; remove it first", staging)
+	}
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		return nil, err
+	}
+
+	manifest, err := restoreInto(r, staging)
+	if err != nil {
+		os.RemoveAll(staging)
+		return nil, err
+	}
+
+	// An empty target directory is replaced by the staging directory
+	if err := os.Remove(targetDir); err != nil && !os.IsNotExist(err) {
+		os.RemoveAll(staging)
+		return nil, err
+	}
+	if err := os.Rename(staging, targetDir); err != nil {
+		os.RemoveAll(staging)
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// restoreInto unpacks an archive into dir and checks it.
+func restoreInto(r io.Reader, dir string) (*BackupManifest, error) {
+	tr := tar.NewReader(r)
+	unpacked := make(map[string]BackupFile)
+	var manifest *BackupManifest
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected archive entry %s", hdr.Name)
+		}
+
+		if hdr.Name == constants.BackupManifestFile {
+			if manifest != nil {
+				return nil, errors.New("archive holds more than one manifest")
+			}
+			manifest = &BackupManifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid backup manifest: %w", err)
+			}
+			continue
+		}
+
+		if !validBackupPath(hdr.Name) {
+			return nil, fmt.Errorf("unexpected archive entry %s", hdr.Name)
+		}
+		if _, ok := unpacked[hdr.Name]; ok {
+			return nil, fmt.Errorf("archive entry %s appears more than once", hdr.Name)
+		}
+		file, err := unpackBackupFile(tr, filepath.Join(dir, filepath.FromSlash(hdr.Name)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack %s: %w", hdr.Name, err)
+		}
+		file.Path = hdr.Name
+		unpacked[hdr.Name] = file
+	}
+
+	if manifest == nil {
+		return nil, errors.New("archive has no manifest; it is not a backup or was cut short")
+	}
+	if manifest.FormatVersion != constants.BackupFormatVersion {
+		return nil, fmt.Errorf("unsupported backup format version %d", manifest.FormatVersion)
+	}
+
+	// Every file must match the manifest, and nothing else may be present
+	for _, want := range manifest.Files {
+		got, ok := unpacked[want.Path]
+		if !ok {
+			return nil, fmt.Errorf("%s is listed in the manifest but missing from the archive", want.Path)
+		}
+		if got.Size != want.Size || got.Hash != want.Hash {
+			return nil, fmt.Errorf("%s does not match the hash in the manifest", want.Path)
+		}
+		delete(unpacked, want.Path)
+	}
+	for name := range unpacked {
+		return nil, fmt.Errorf("%s is not listed in the manifest", name)
+	}
+
+	orchPath := filepath.Join(dir, constants.InternalDir, constants.OrchestratorDB)
+	if err := checkBackupDatabase(orchPath, nil); err != nil {
+		return nil, fmt.Errorf("orchestrator database: %w", err)
+	}
+	for _, topicName := range manifest.Topics {
+		if !topicNameRegex.MatchString(topicName) {
+			return nil, fmt.Errorf("invalid topic name %q in manifest", topicName)
+		}
+		topicPath := filepath.Join(dir, topicName)
+		dbPath := filepath.Join(topicPath, constants.InternalDir, topicName+".db")
+		err := checkBackupDatabase(dbPath, func(db *sql.DB) error {
+			mismatched, err := database.VerifyAllDatHashes(db, topicPath)
+			if err != nil {
+				return err
+			}
+			if len(mismatched) > 0 {
+				return fmt.Errorf("hash chain mismatch in %s", strings.Join(mismatched, ", "))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("topic %s: %w", topicName, err)
+		}
+	}
+	return manifest, nil
+}
+
+// validBackupPath reports whether name is a file a backup archive may hold:
+// the orchestrator database, a topic database or a .dat file.
+func validBackupPath(name string) bool {
+	if name == path.Join(constants.InternalDir, constants.OrchestratorDB) {
+		return true
+	}
+	parts := strings.Split(name, "/")
+	if !topicNameRegex.MatchString(parts[0]) {
+		return false
+	}
+	switch len(parts) {
+	case 2:
+		return storage.IsDatFilename(parts[1])
+	case 3:
+		return parts[1] == constants.InternalDir && parts[2] == parts[0]+".db"
+	}
+	return false
+}
+
+// unpackBackupFile writes an archive entry to dst and returns its size and
+// hash.
+func unpackBackupFile(r io.Reader, dst string) (BackupFile, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return BackupFile{}, err
+	}
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return BackupFile{}, err
+	}
+	defer f.Close()
+
+	hasher := blake3.New()
+	n, err := io.Copy(io.MultiWriter(f, hasher), r)
+	if err != nil {
+		return BackupFile{}, err
+	}
+	if err := f.Sync(); err != nil {
+		return BackupFile{}, err
+	}
+	return BackupFile{Size: n, Hash: hex.EncodeToString(hasher.Sum(nil))}, nil
+}
+
+// checkBackupDatabase runs SQLite's quick_check on a restored database, then
+// check, if any.
+func checkBackupDatabase(dbPath string, check func(db *sql.DB) error) error {
+	if _, err := os.Stat(dbPath); err != nil {
+		return err
+	}
+	db, err := database.OpenDatabase(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA quick_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	if check != nil {
+		return check(db)
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/storage"
+	"silobang/plugin"
+)
+
+// BlobStoreService moves the sealed .dat files of topics assigned to a blob
+// store (an S3-compatible bucket, or a plugin store type) out of the working directory, and reads entries
+// back from wherever their file lives. Uploads always append to the topic's
+// newest local .dat file, which is never offloaded.
+type BlobStoreService struct {
+	app        AppState
+	logger     *logger.Logger
+	statsCache *StatsCache
+
+	mu      sync.Mutex
+	running map[string]bool // topics being offloaded
+
+	schedMu   sync.Mutex
+	stopCh    chan struct{}
+	scheduled bool
+}
+
+// OffloadResult is the outcome of an offload pass over a topic.
+type OffloadResult struct {
+	Topic     string   `json:"topic"`
+	BlobStore string   `json:"blob_store"`
+	DatFiles  []string `json:"dat_files"` // files moved to the store
+	Bytes     int64    `json:"bytes"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// NewBlobStoreService creates a new blob store service instance.
+func NewBlobStoreService(app AppState, log *logger.Logger) *BlobStoreService {
+	return &BlobStoreService{
+		app:     app,
+		logger:  log,
+		running: make(map[string]bool),
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// SetStatsCache sets the stats cache refreshed after files are offloaded.
+func (s *BlobStoreService) SetStatsCache(cache *StatsCache) {
+	s.statsCache = cache
+}
+
+// OpenData returns a reader over an asset's data, skipping its entry header.
+// The topic directory is tried first; a .dat file that is no longer there is
+// read from the blob store its offload was recorded in. Errors wrap
+// fs.ErrNotExist when the file is in neither place. The stub of an archived
+// asset has no data to read until it is recalled.
+func (s *BlobStoreService) OpenData(topicName string, asset *database.Asset) (io.ReadCloser, error) {
+	if asset.BlobName == constants.ArchiveBlobName {
+		return nil, ErrRetrievalRequiredForHash(asset.AssetID)
+	}
+	cfg := s.app.GetConfig()
+	local := storage.NewLocalBlobStore(s.app.GetTopicPath(topicName), cfg.DirectIO())
+	dataStart := asset.ByteOffset + int64(constants.HeaderSize)
+
+	reader, err := local.Open(asset.BlobName, dataStart, asset.AssetSize)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return reader, err
+	}
+
+	db, dbErr := s.app.GetTopicDB(topicName)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	offload, dbErr := database.GetDatOffload(db, asset.BlobName)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	if offload == nil {
+		return nil, err
+	}
+	remote, err := s.remote(offload.BlobStore, topicName)
+	if err != nil {
+		return nil, err
+	}
+	return remote.Open(asset.BlobName, dataStart, asset.AssetSize)
+}
+
+// Store returns the blob store a topic's sealed .dat files are offloaded to,
+// or nil when the topic keeps them in its directory.
+func (s *BlobStoreService) Store(topicName string) (storage.BlobStore, error) {
+	name := s.app.GetConfig().TopicBlobStores[topicName]
+	if name == "" {
+		return nil, nil
+	}
+	return s.remote(name, topicName)
+}
+
+// remote returns the topic's view of the configured blob store name.
+func (s *BlobStoreService) remote(name, topicName string) (storage.BlobStore, error) {
+	store, ok := s.app.GetConfig().BlobStores[name]
+	if !ok {
+		return nil, fmt.Errorf("blob store %q is not configured", name)
+	}
+	return plugin.NewBlobStore(store.StoreType(), store.PluginConfig(name, topicName))
+}
+
+// Offload uploads the topic's sealed .dat files to its blob store and removes
+// the local copies. Each file's running hash is checked before upload. The
+// upload happens outside the topic write lock; the switch to the remote copy
+// is made under it, and abandoned if compaction removed the file meanwhile.
+// Returns a nil result when the topic has no blob store or a pass over it is
+// already running.
+func (s *BlobStoreService) Offload(topicName string) (*OffloadResult, error) {
+	if s.app.GetWorkingDirectory() == "" {
+		return nil, ErrNotConfigured
+	}
+	if !s.app.TopicExists(topicName) {
+		return nil, ErrTopicNotFoundWithName(topicName)
+	}
+	if healthy, errMsg := s.app.IsTopicHealthy(topicName); !healthy {
+		return nil, ErrTopicUnhealthyWithReason(topicName, errMsg)
+	}
+	remote, err := s.Store(topicName)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if remote == nil {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	if s.running[topicName] {
+		s.mu.Unlock()
+		return nil, nil
+	}
+	s.running[topicName] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, topicName)
+		s.mu.Unlock()
+	}()
+
+	db, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	topicPath := s.app.GetTopicPath(topicName)
+	datFiles, err := storage.ListDatFiles(topicPath)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	offloads, err := database.ListDatOffloads(db)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	result := &OffloadResult{Topic: topicName, BlobStore: remote.Name(), DatFiles: []string{}}
+	if len(datFiles) < 2 {
+		return result, nil
+	}
+	mu := s.app.GetTopicWriteMu(topicName)
+
+	for _, datFile := range datFiles[:len(datFiles)-1] {
+		datPath := filepath.Join(topicPath, datFile)
+
+		// A pass interrupted after recording the offload left the local copy
+		if _, ok := offloads[datFile]; ok {
+			mu.Lock()
+			err := os.Remove(datPath)
+			mu.Unlock()
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", datFile, err))
+			}
+			continue
+		}
+
+		size, err := s.offloadDat(db, remote, mu, topicPath, datFile)
+		if err != nil {
+			s.logger.Error("[blob-store] %s/%s: %v", topicName, datFile, err)
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", datFile, err))
+			continue
+		}
+		if size == 0 {
+			continue
+		}
+		s.logger.Info("[blob-store] %s/%s: offloaded %d bytes to %s", topicName, datFile, size, remote.Name())
+		result.DatFiles = append(result.DatFiles, datFile)
+		result.Bytes += size
+	}
+
+	if len(result.DatFiles) > 0 && s.statsCache != nil {
+		s.statsCache.InvalidateTopic(topicName)
+	}
+	return result, nil
+}
+
+// offloadDat moves one sealed .dat file to the store. Returns 0 when
+// compaction removed the file during the upload.
+func (s *BlobStoreService) offloadDat(db *sql.DB, remote storage.BlobStore, mu *sync.Mutex, topicPath, datFile string) (int64, error) {
+	datPath := filepath.Join(topicPath, datFile)
+	f, err := os.Open(datPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	match, err := database.VerifyDatHash(db, datFile, topicPath)
+	if err != nil {
+		f.Close()
+		return 0, fmt.Errorf("failed to verify running hash: %w", err)
+	}
+	if !match {
+		f.Close()
+		return 0, fmt.Errorf("running hash mismatch, not offloaded")
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+	size := info.Size()
+	err = remote.Put(datFile, f, size)
+	f.Close()
+	if err != nil {
+		return 0, fmt.Errorf("upload failed: %w", err)
+	}
+	stored, err := remote.Size(datFile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check upload: %w", err)
+	}
+	if stored != size {
+		return 0, fmt.Errorf("uploaded object holds %d bytes, expected %d", stored, size)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	current, err := storage.GetDatFileSize(datPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Compacted away during the upload: the remote copy is stale
+		if err := remote.Delete(datFile); err != nil {
+			s.logger.Warn("[blob-store] failed to delete stale %s: %v", datFile, err)
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if current != size {
+		return 0, fmt.Errorf("file grew from %d to %d bytes during upload", size, current)
+	}
+
+	if err := database.InsertDatOffload(db, database.DatOffload{
+		DatFile:     datFile,
+		BlobStore:   remote.Name(),
+		Size:        size,
+		OffloadedAt: time.Now().Unix(),
+	}); err != nil {
+		return 0, err
+	}
+	if err := os.Remove(datPath); err != nil {
+		return 0, fmt.Errorf("offloaded but failed to remove local copy: %w", err)
+	}
+	return size, nil
+}
+
+// Start launches the scheduled offload pass over the topics assigned to a
+// blob store. Safe to call multiple times — subsequent calls are no-ops.
+func (s *BlobStoreService) Start(interval time.Duration) {
+	s.schedMu.Lock()
+	if s.scheduled {
+		s.schedMu.Unlock()
+		return
+	}
+	s.scheduled = true
+	s.schedMu.Unlock()
+
+	s.logger.Info("[blob-store] scheduled offload started (interval: %v)", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				s.logger.Info("[blob-store] scheduled offload stopped")
+				return
+			case <-ticker.C:
+				s.offloadAll()
+			}
+		}
+	}()
+}
+
+// Stop signals the scheduled offload goroutine to exit. A file being
+// uploaded is finished first.
+func (s *BlobStoreService) Stop() {
+	s.schedMu.Lock()
+	defer s.schedMu.Unlock()
+
+	if s.scheduled {
+		close(s.stopCh)
+		s.scheduled = false
+	}
+}
+
+// offloadAll runs a scheduled pass over every healthy topic with a blob store.
+func (s *BlobStoreService) offloadAll() {
+	for _, name := range s.app.ListTopics() {
+		if s.app.GetConfig().TopicBlobStores[name] == "" {
+			continue
+		}
+		if healthy, _ := s.app.IsTopicHealthy(name); !healthy {
+			continue
+		}
+		if _, err := s.Offload(name); err != nil {
+			s.logger.Error("[blob-store] offload of topic %s failed: %v", name, err)
+		}
+	}
+}
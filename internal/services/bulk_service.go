@@ -3,17 +3,20 @@ package services
 import (
 	"database/sql"
 	"fmt"
+	"maps"
 
 	"silobang/internal/constants"
 	"silobang/internal/database"
 	"silobang/internal/logger"
 	"silobang/internal/queries"
+	"silobang/internal/sanitize"
 )
 
 // BulkService handles bulk download asset resolution and validation.
 type BulkService struct {
-	app    AppState
-	logger *logger.Logger
+	app         AppState
+	logger      *logger.Logger
+	collections *CollectionService
 }
 
 // NewBulkService creates a new bulk service instance.
@@ -24,6 +27,12 @@ func NewBulkService(app AppState, log *logger.Logger) *BulkService {
 	}
 }
 
+// SetCollectionService sets the collection service used for collection filters and paths.
+// Called after CollectionService is initialized in the services container.
+func (s *BulkService) SetCollectionService(cs *CollectionService) {
+	s.collections = cs
+}
+
 // ResolvedAsset contains all info needed to download an asset.
 type ResolvedAsset struct {
 	Hash      string
@@ -31,6 +40,14 @@ type ResolvedAsset struct {
 	Asset     *database.Asset
 	TopicPath string
 	TopicDB   *sql.DB
+
+	// Collection is the collection used as the asset's directory in archives.
+	// Empty when collection paths are not requested or the asset has no collection.
+	Collection string
+
+	// RelativeDir is the folder the asset was uploaded from, used as its
+	// directory in archives with filename_format=path. Empty otherwise.
+	RelativeDir string
 }
 
 // BulkResolveRequest contains parameters for resolving assets.
@@ -40,7 +57,10 @@ type BulkResolveRequest struct {
 	Params         map[string]interface{} // for mode="query"
 	Topics         []string               // for mode="query", optional
 	AssetIDs       []string               // for mode="ids"
-	FilenameFormat string                 // "hash" | "original" | "hash_original"
+	FilenameFormat string                 // "hash" | "original" | "hash_original" | "path"
+
+	Collection      string // optional: only include assets in this collection
+	CollectionPaths bool   // place assets under their collection directory
 }
 
 // ValidateRequest validates a bulk download request.
@@ -52,7 +72,13 @@ func (s *BulkService) ValidateRequest(req *BulkResolveRequest) error {
 
 	if !s.isValidFilenameFormat(req.FilenameFormat) {
 		return NewServiceError(constants.ErrCodeInvalidFilenameFormat,
-			"invalid filename_format: must be hash, original, or hash_original")
+			"invalid filename_format: must be hash, original, hash_original, or path")
+	}
+
+	if req.Collection != "" {
+		if err := ValidateCollectionName(req.Collection); err != nil {
+			return err
+		}
 	}
 
 	// Validate mode
@@ -75,14 +101,118 @@ func (s *BulkService) ValidateRequest(req *BulkResolveRequest) error {
 // ResolveAssets resolves assets based on the request mode.
 // Returns the resolved assets and any error.
 func (s *BulkService) ResolveAssets(req *BulkResolveRequest) ([]*ResolvedAsset, error) {
+	var assets []*ResolvedAsset
+	var err error
+
 	switch req.Mode {
 	case "query":
-		return s.resolveFromQuery(req)
+		assets, err = s.resolveFromQuery(req)
 	case "ids":
-		return s.resolveFromIDs(req.AssetIDs)
+		assets, err = s.resolveFromIDs(req.AssetIDs)
 	default:
 		return nil, NewServiceError(constants.ErrCodeInvalidDownloadMode, "invalid mode: must be query or ids")
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	if assets, err = s.excludeWithheld(assets); err != nil {
+		return nil, err
+	}
+
+	if req.FilenameFormat == constants.FilenameFormatPath {
+		if err := s.applyRelativeDirs(assets); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Collection == "" && !req.CollectionPaths {
+		return assets, nil
+	}
+	return s.applyCollections(assets, req)
+}
+
+// excludeWithheld drops quarantined and trashed assets, which are never
+// exported.
+func (s *BulkService) excludeWithheld(assets []*ResolvedAsset) ([]*ResolvedAsset, error) {
+	withheld, err := withheldHashes(s.app)
+	if err != nil || withheld == nil {
+		return assets, err
+	}
+
+	filtered := make([]*ResolvedAsset, 0, len(assets))
+	for _, resolved := range assets {
+		if withheld[resolved.Hash] {
+			s.logger.Debug("Bulk download: skipping withheld asset %s", resolved.Hash)
+			continue
+		}
+		filtered = append(filtered, resolved)
+	}
+	return filtered, nil
+}
+
+// applyRelativeDirs assigns each asset the folder recorded in its
+// relative_path metadata. Assets uploaded without one stay at the root.
+func (s *BulkService) applyRelativeDirs(assets []*ResolvedAsset) error {
+	for _, resolved := range assets {
+		computed, err := database.GetMetadataComputed(resolved.TopicDB, resolved.Hash)
+		if err != nil {
+			return WrapInternalError(fmt.Errorf("failed to get computed metadata: %w", err))
+		}
+		if relativePath, ok := computed[constants.MetadataKeyRelativePath].(string); ok {
+			// Re-sanitized: the key can also be written through the metadata API
+			resolved.RelativeDir = sanitize.RelativeDir(relativePath)
+		}
+	}
+	return nil
+}
+
+// applyCollections filters resolved assets by collection and assigns the
+// collection directory used in archives. When a collection filter is given it
+// is used as the directory; otherwise the alphabetically first collection the
+// asset belongs to is used.
+func (s *BulkService) applyCollections(assets []*ResolvedAsset, req *BulkResolveRequest) ([]*ResolvedAsset, error) {
+	if s.collections == nil {
+		return nil, WrapInternalError(nil)
+	}
+
+	sets := make(map[string]map[string]struct{})
+	filtered := make([]*ResolvedAsset, 0, len(assets))
+
+	for _, resolved := range assets {
+		if req.Collection != "" {
+			set, ok := sets[resolved.Topic]
+			if !ok {
+				var err error
+				set, err = s.collections.MemberSet(resolved.Topic, req.Collection)
+				if err != nil {
+					return nil, err
+				}
+				sets[resolved.Topic] = set
+			}
+			if _, member := set[resolved.Hash]; !member {
+				continue
+			}
+		}
+
+		if req.CollectionPaths {
+			if req.Collection != "" {
+				resolved.Collection = req.Collection
+			} else {
+				names, err := database.GetAssetCollections(resolved.TopicDB, resolved.Hash)
+				if err != nil {
+					return nil, WrapInternalError(fmt.Errorf("failed to get asset collections: %w", err))
+				}
+				if len(names) > 0 {
+					resolved.Collection = names[0]
+				}
+			}
+		}
+
+		filtered = append(filtered, resolved)
+	}
+
+	return filtered, nil
 }
 
 // resolveFromQuery resolves assets from a query preset.
@@ -106,6 +236,17 @@ func (s *BulkService) resolveFromQuery(req *BulkResolveRequest) ([]*ResolvedAsse
 		return nil, NewServiceError(constants.ErrCodeInvalidRequest, err.Error())
 	}
 
+	// The collection scopes the tables the preset reads, so its own LIMIT
+	// applies to the collection's assets
+	if req.Collection != "" {
+		if err := checkCollectionScope(req.Preset, preset, req.Collection); err != nil {
+			return nil, err
+		}
+		params = maps.Clone(params)
+		params[constants.CollectionSQLParam] = req.Collection
+		preset = preset.Scoped(queries.Scope{Collection: true})
+	}
+
 	// Get topic databases
 	topicDBs, topicNames, err := s.app.GetTopicDBsForQuery(req.Topics)
 	if err != nil {
@@ -189,7 +330,7 @@ func (s *BulkService) resolveAsset(hash, knownTopic string) (*ResolvedAsset, err
 		topicName = knownTopic
 	} else {
 		// Look up in orchestrator to find topic
-		exists, topic, _, err := database.CheckHashExists(s.app.GetOrchestratorDB(), hash)
+		exists, topic, _, err := s.app.GetAssetIndex().Lookup(hash)
 		if err != nil {
 			return nil, WrapInternalError(fmt.Errorf("failed to lookup asset: %w", err))
 		}
@@ -248,5 +389,6 @@ func (s *BulkService) ValidateAssetCount(count int) error {
 func (s *BulkService) isValidFilenameFormat(format string) bool {
 	return format == constants.FilenameFormatHash ||
 		format == constants.FilenameFormatOriginal ||
-		format == constants.FilenameFormatHashOriginal
+		format == constants.FilenameFormatHashOriginal ||
+		format == constants.FilenameFormatPath
 }
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zeebo/blake3"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/storage"
+)
+
+// ChunkDedupService estimates how much space chunk-level deduplication would
+// save. It content-defines-chunks a sample of assets per topic and counts
+// repeated chunks. Assets are still stored whole; this only informs whether
+// chunked storage is worth building.
+type ChunkDedupService struct {
+	app        AppState
+	logger     *logger.Logger
+	statsCache *StatsCache
+	blobStores *BlobStoreService
+
+	mu      sync.Mutex
+	running bool
+}
+
+// NewChunkDedupService creates a new chunk dedup analysis service.
+func NewChunkDedupService(app AppState, log *logger.Logger) *ChunkDedupService {
+	return &ChunkDedupService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// SetStatsCache sets the stats cache that stores the latest report.
+func (s *ChunkDedupService) SetStatsCache(cache *StatsCache) {
+	s.statsCache = cache
+}
+
+// SetBlobStoreService sets the service reading .dat files offloaded to blob
+// stores.
+func (s *ChunkDedupService) SetBlobStoreService(blobStores *BlobStoreService) {
+	s.blobStores = blobStores
+}
+
+// ChunkDedupOptions selects what to analyze.
+type ChunkDedupOptions struct {
+	Topics     []string `json:"topics"`      // Empty = all healthy topics
+	SampleSize int      `json:"sample_size"` // Assets per topic; 0 = default
+}
+
+// ChunkDedupTopicResult holds the analysis of one topic's sample.
+type ChunkDedupTopicResult struct {
+	Topic                 string  `json:"topic"`
+	TotalAssets           int64   `json:"total_assets"`
+	TotalBytes            int64   `json:"total_bytes"`
+	SampledAssets         int     `json:"sampled_assets"`
+	SampledBytes          int64   `json:"sampled_bytes"`
+	TotalChunks           int64   `json:"total_chunks"`
+	UniqueChunks          int64   `json:"unique_chunks"`
+	UniqueBytes           int64   `json:"unique_bytes"`
+	SavingsBytes          int64   `json:"savings_bytes"`
+	SavingsRatio          float64 `json:"savings_ratio"`
+	EstimatedSavingsBytes int64   `json:"estimated_savings_bytes"`
+	Error                 string  `json:"error,omitempty"`
+}
+
+// ChunkDedupTotals aggregates all sampled topics. Chunks are deduplicated
+// across topics, so savings can exceed the sum of per-topic savings.
+type ChunkDedupTotals struct {
+	SampledAssets         int     `json:"sampled_assets"`
+	SampledBytes          int64   `json:"sampled_bytes"`
+	TotalChunks           int64   `json:"total_chunks"`
+	UniqueChunks          int64   `json:"unique_chunks"`
+	UniqueBytes           int64   `json:"unique_bytes"`
+	SavingsBytes          int64   `json:"savings_bytes"`
+	SavingsRatio          float64 `json:"savings_ratio"`
+	EstimatedSavingsBytes int64   `json:"estimated_savings_bytes"`
+}
+
+// ChunkDedupReport is the result of an analysis run.
+type ChunkDedupReport struct {
+	StartedAt    int64                   `json:"started_at"`
+	CompletedAt  int64                   `json:"completed_at"`
+	DurationMs   int64                   `json:"duration_ms"`
+	SampleSize   int                     `json:"sample_size"`
+	MinChunkSize int                     `json:"min_chunk_size"`
+	AvgChunkSize int                     `json:"avg_chunk_size"`
+	MaxChunkSize int                     `json:"max_chunk_size"`
+	Topics       []ChunkDedupTopicResult `json:"topics"`
+	Overall      ChunkDedupTotals        `json:"overall"`
+}
+
+// ChunkDedupStatus is the analysis state returned by the report endpoint.
+type ChunkDedupStatus struct {
+	Running bool              `json:"running"`
+	Report  *ChunkDedupReport `json:"report"`
+}
+
+// chunkSet records the BLAKE3 hashes of chunks already seen.
+type chunkSet map[[32]byte]struct{}
+
+// Status returns whether an analysis is running and the latest report.
+func (s *ChunkDedupService) Status() *ChunkDedupStatus {
+	s.mu.Lock()
+	running := s.running
+	s.mu.Unlock()
+
+	status := &ChunkDedupStatus{Running: running}
+	if s.statsCache != nil {
+		status.Report = s.statsCache.GetChunkDedupReport()
+	}
+	return status
+}
+
+// Start validates the options and runs the analysis in the background.
+// Only one analysis runs at a time.
+func (s *ChunkDedupService) Start(opts ChunkDedupOptions) error {
+	topics, err := s.resolveOptions(&opts)
+	if err != nil {
+		return err
+	}
+
+	if !s.acquire() {
+		return NewServiceError(constants.ErrCodeAnalysisInProgress, "chunk dedup analysis already running")
+	}
+
+	go func() {
+		defer s.release()
+		report := s.analyze(context.Background(), topics, opts.SampleSize)
+		if s.statsCache != nil {
+			s.statsCache.SetChunkDedupReport(report)
+		}
+	}()
+	return nil
+}
+
+// Run performs the analysis synchronously and stores the report.
+func (s *ChunkDedupService) Run(ctx context.Context, opts ChunkDedupOptions) (*ChunkDedupReport, error) {
+	topics, err := s.resolveOptions(&opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.acquire() {
+		return nil, NewServiceError(constants.ErrCodeAnalysisInProgress, "chunk dedup analysis already running")
+	}
+	defer s.release()
+
+	report := s.analyze(ctx, topics, opts.SampleSize)
+	if s.statsCache != nil {
+		s.statsCache.SetChunkDedupReport(report)
+	}
+	return report, nil
+}
+
+func (s *ChunkDedupService) acquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return false
+	}
+	s.running = true
+	return true
+}
+
+func (s *ChunkDedupService) release() {
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+}
+
+// resolveOptions applies defaults and validates topics and sample size.
+func (s *ChunkDedupService) resolveOptions(opts *ChunkDedupOptions) ([]string, error) {
+	if s.app.GetWorkingDirectory() == "" {
+		return nil, ErrNotConfigured
+	}
+
+	if opts.SampleSize == 0 {
+		opts.SampleSize = constants.ChunkDedupDefaultSampleAssets
+	}
+	if opts.SampleSize < 1 || opts.SampleSize > constants.ChunkDedupMaxSampleAssets {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("sample_size must be between 1 and %d", constants.ChunkDedupMaxSampleAssets))
+	}
+
+	if len(opts.Topics) == 0 {
+		topics := s.app.ListTopics()
+		sort.Strings(topics)
+		return topics, nil
+	}
+	for _, topic := range opts.Topics {
+		if !s.app.TopicExists(topic) {
+			return nil, ErrTopicNotFoundWithName(topic)
+		}
+	}
+	return opts.Topics, nil
+}
+
+// analyze chunks the sample of every topic. Per-topic failures are recorded in
+// the topic result rather than aborting the run.
+func (s *ChunkDedupService) analyze(ctx context.Context, topics []string, sampleSize int) *ChunkDedupReport {
+	started := time.Now()
+	s.logger.Info("[chunk-dedup] analysis started: %d topics, sample_size=%d", len(topics), sampleSize)
+
+	report := &ChunkDedupReport{
+		StartedAt:    started.Unix(),
+		SampleSize:   sampleSize,
+		MinChunkSize: constants.ChunkDedupMinChunkSize,
+		AvgChunkSize: constants.ChunkDedupAvgChunkSize,
+		MaxChunkSize: constants.ChunkDedupMaxChunkSize,
+		Topics:       make([]ChunkDedupTopicResult, 0, len(topics)),
+	}
+
+	global := make(chunkSet)
+	var overallTotalBytes int64
+
+	for _, topic := range topics {
+		if ctx.Err() != nil {
+			break
+		}
+
+		result := ChunkDedupTopicResult{Topic: topic}
+		if healthy, errMsg := s.app.IsTopicHealthy(topic); !healthy {
+			result.Error = "topic unhealthy: " + errMsg
+			report.Topics = append(report.Topics, result)
+			continue
+		}
+
+		if err := s.analyzeTopic(ctx, topic, sampleSize, &result, &report.Overall, global); err != nil {
+			s.logger.Warn("[chunk-dedup] topic %s: %v", topic, err)
+			result.Error = err.Error()
+		}
+		overallTotalBytes += result.TotalBytes
+		report.Topics = append(report.Topics, result)
+	}
+
+	o := &report.Overall
+	o.UniqueChunks = int64(len(global))
+	o.SavingsBytes = o.SampledBytes - o.UniqueBytes
+	o.SavingsRatio = savingsRatio(o.SavingsBytes, o.SampledBytes)
+	o.EstimatedSavingsBytes = int64(o.SavingsRatio * float64(overallTotalBytes))
+
+	completed := time.Now()
+	report.CompletedAt = completed.Unix()
+	report.DurationMs = completed.Sub(started).Milliseconds()
+
+	s.logger.Info("[chunk-dedup] analysis complete: sampled=%d bytes, savings_ratio=%.4f, duration=%dms",
+		o.SampledBytes, o.SavingsRatio, report.DurationMs)
+
+	return report
+}
+
+// analyzeTopic chunks a topic's sample, updating both the topic result and
+// the cross-topic totals.
+func (s *ChunkDedupService) analyzeTopic(ctx context.Context, topic string, sampleSize int, result *ChunkDedupTopicResult, overall *ChunkDedupTotals, global chunkSet) error {
+	db, err := s.app.GetTopicDB(topic)
+	if err != nil {
+		return err
+	}
+
+	result.TotalAssets, result.TotalBytes, err = database.GetAssetTotals(db)
+	if err != nil {
+		return fmt.Errorf("failed to count assets: %w", err)
+	}
+
+	assets, err := database.SampleAssets(db, sampleSize)
+	if err != nil {
+		return fmt.Errorf("failed to sample assets: %w", err)
+	}
+
+	local := make(chunkSet)
+
+	for _, asset := range assets {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if result.SampledBytes >= constants.ChunkDedupMaxSampleBytes {
+			break
+		}
+
+		err := s.chunkAsset(topic, asset, func(sum [32]byte, size int) {
+			result.TotalChunks++
+			overall.TotalChunks++
+			if _, seen := local[sum]; !seen {
+				local[sum] = struct{}{}
+				result.UniqueBytes += int64(size)
+			}
+			if _, seen := global[sum]; !seen {
+				global[sum] = struct{}{}
+				overall.UniqueBytes += int64(size)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read asset %s: %w", asset.AssetID, err)
+		}
+
+		result.SampledAssets++
+		result.SampledBytes += asset.AssetSize
+		overall.SampledAssets++
+		overall.SampledBytes += asset.AssetSize
+	}
+
+	result.UniqueChunks = int64(len(local))
+	result.SavingsBytes = result.SampledBytes - result.UniqueBytes
+	result.SavingsRatio = savingsRatio(result.SavingsBytes, result.SampledBytes)
+	result.EstimatedSavingsBytes = int64(result.SavingsRatio * float64(result.TotalBytes))
+	return nil
+}
+
+// chunkAsset streams an asset from its DAT file through the chunker.
+func (s *ChunkDedupService) chunkAsset(topic string, asset database.Asset, onChunk func(sum [32]byte, size int)) error {
+	reader, err := s.blobStores.OpenData(topic, &asset)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	chunker, err := storage.NewChunker(reader,
+		constants.ChunkDedupMinChunkSize, constants.ChunkDedupAvgChunkSize, constants.ChunkDedupMaxChunkSize)
+	if err != nil {
+		return err
+	}
+
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		onChunk(blake3.Sum256(chunk), len(chunk))
+	}
+}
+
+// savingsRatio returns saved/total, or 0 when nothing was sampled.
+func savingsRatio(saved, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(saved) / float64(total)
+}
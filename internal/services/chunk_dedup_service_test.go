@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/storage"
+)
+
+// storeChunkTestAsset appends data to the topic's 000001.dat and indexes it.
+func storeChunkTestAsset(t *testing.T, mock *mockAppState, topic string, data []byte) {
+	t.Helper()
+	hash := storage.ComputeBlake3Hex(data)
+	datName := "000001.dat"
+	offset, err := storage.AppendEntry(filepath.Join(mock.GetTopicPath(topic), datName), hash, data)
+	if err != nil {
+		t.Fatalf("failed to append entry: %v", err)
+	}
+	_, err = mock.topicDBs[topic].Exec(
+		`INSERT INTO assets (asset_id, asset_size, extension, blob_name, byte_offset, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		hash, len(data), "bin", datName, offset, 1,
+	)
+	if err != nil {
+		t.Fatalf("failed to insert asset: %v", err)
+	}
+}
+
+// newChunkDedupTest creates topics and a service wired to a stats cache.
+func newChunkDedupTest(t *testing.T, topics ...string) (*ChunkDedupService, *mockAppState) {
+	t.Helper()
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+	for _, topic := range topics {
+		db := setupTopicDir(t, workDir, topic, nil)
+		mock.StoreTopicDB(topic, db)
+		mock.RegisterTopic(topic, true, "")
+	}
+	svc := NewChunkDedupService(mock, mock.log)
+	svc.SetStatsCache(newTestStatsCache(mock))
+	svc.SetBlobStoreService(NewBlobStoreService(mock, mock.log))
+	return svc, mock
+}
+
+func randomBytes(seed int64, n int) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(seed)).Read(b)
+	return b
+}
+
+func TestChunkDedup_DetectsSharedContent(t *testing.T) {
+	svc, mock := newChunkDedupTest(t, "models")
+
+	// Two assets that differ only by a small prefix share almost all chunks
+	base := randomBytes(1, 256*1024)
+	storeChunkTestAsset(t, mock, "models", base)
+	storeChunkTestAsset(t, mock, "models", append([]byte("v2 header"), base...))
+
+	report, err := svc.Run(context.Background(), ChunkDedupOptions{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(report.Topics) != 1 {
+		t.Fatalf("expected 1 topic, got %d", len(report.Topics))
+	}
+
+	topic := report.Topics[0]
+	if topic.Error != "" {
+		t.Fatalf("unexpected topic error: %s", topic.Error)
+	}
+	if topic.SampledAssets != 2 || topic.TotalAssets != 2 {
+		t.Errorf("expected 2 sampled of 2 assets, got %d of %d", topic.SampledAssets, topic.TotalAssets)
+	}
+	if topic.SavingsRatio < 0.4 {
+		t.Errorf("expected ~50%% savings for near-duplicate assets, got %.3f", topic.SavingsRatio)
+	}
+	if topic.SampledBytes != topic.UniqueBytes+topic.SavingsBytes {
+		t.Error("sampled bytes must equal unique + savings")
+	}
+
+	if svc.Status().Report != report {
+		t.Error("expected report to be stored in the stats cache")
+	}
+}
+
+func TestChunkDedup_CrossTopicSavings(t *testing.T) {
+	svc, mock := newChunkDedupTest(t, "audio", "textures")
+
+	shared := randomBytes(2, 128*1024)
+	storeChunkTestAsset(t, mock, "audio", shared)
+	storeChunkTestAsset(t, mock, "textures", append(shared, 'x'))
+
+	report, err := svc.Run(context.Background(), ChunkDedupOptions{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	for _, topic := range report.Topics {
+		if topic.SavingsBytes != 0 {
+			t.Errorf("topic %s: expected no savings within a single asset, got %d", topic.Topic, topic.SavingsBytes)
+		}
+	}
+	if report.Overall.SavingsRatio < 0.4 {
+		t.Errorf("expected cross-topic savings, got %.3f", report.Overall.SavingsRatio)
+	}
+}
+
+func TestChunkDedup_SampleSizeLimitsAssets(t *testing.T) {
+	svc, mock := newChunkDedupTest(t, "docs")
+	for i := 0; i < 5; i++ {
+		storeChunkTestAsset(t, mock, "docs", randomBytes(int64(10+i), 4096))
+	}
+
+	report, err := svc.Run(context.Background(), ChunkDedupOptions{SampleSize: 3})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := report.Topics[0].SampledAssets; got != 3 {
+		t.Errorf("expected 3 sampled assets, got %d", got)
+	}
+	if report.Topics[0].TotalAssets != 5 {
+		t.Errorf("expected total_assets 5, got %d", report.Topics[0].TotalAssets)
+	}
+}
+
+func TestChunkDedup_InvalidOptions(t *testing.T) {
+	svc, _ := newChunkDedupTest(t, "docs")
+
+	if _, err := svc.Run(context.Background(), ChunkDedupOptions{SampleSize: -1}); err == nil {
+		t.Error("expected error for negative sample_size")
+	}
+	_, err := svc.Run(context.Background(), ChunkDedupOptions{Topics: []string{"missing"}})
+	if code, _ := IsServiceError(err); code != constants.ErrCodeTopicNotFound {
+		t.Errorf("expected TOPIC_NOT_FOUND, got %v", err)
+	}
+}
+
+func TestChunkDedup_SingleRunAtATime(t *testing.T) {
+	svc, _ := newChunkDedupTest(t, "docs")
+
+	if !svc.acquire() {
+		t.Fatal("first acquire failed")
+	}
+	if err := svc.Start(ChunkDedupOptions{}); err == nil {
+		t.Error("expected ANALYSIS_IN_PROGRESS while running")
+	}
+	if !svc.Status().Running {
+		t.Error("expected status to report running")
+	}
+	svc.release()
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+var collectionNameRegex = regexp.MustCompile(constants.CollectionNameRegex)
+
+// CollectionService handles named asset groupings within a topic.
+type CollectionService struct {
+	app    AppState
+	logger *logger.Logger
+}
+
+// NewCollectionService creates a new collection service instance.
+func NewCollectionService(app AppState, log *logger.Logger) *CollectionService {
+	return &CollectionService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// CollectionCreateRequest represents a request to create a collection.
+type CollectionCreateRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// CollectionUpdateRequest represents a request to update a collection.
+type CollectionUpdateRequest struct {
+	Description *string `json:"description"`
+}
+
+// CollectionAssetsRequest represents a request to add or remove collection members.
+type CollectionAssetsRequest struct {
+	AssetIDs []string `json:"asset_ids"`
+}
+
+// CollectionDetail contains a collection and a page of its member asset IDs.
+type CollectionDetail struct {
+	database.Collection
+	AssetIDs []string `json:"asset_ids"`
+	Limit    int      `json:"limit"`
+	Offset   int      `json:"offset"`
+}
+
+// CollectionAssetsResult contains the outcome of a membership change.
+type CollectionAssetsResult struct {
+	Requested int      `json:"requested"`
+	Changed   int64    `json:"changed"`
+	Missing   []string `json:"missing,omitempty"`
+}
+
+// ValidateCollectionName checks a collection name against the naming rules.
+func ValidateCollectionName(name string) error {
+	if name == "" {
+		return NewServiceError(constants.ErrCodeInvalidRequest, "collection name is required")
+	}
+	if len(name) < constants.MinCollectionNameLen || len(name) > constants.MaxCollectionNameLen {
+		return NewServiceError(constants.ErrCodeInvalidCollectionName,
+			fmt.Sprintf("collection name must be between %d and %d characters", constants.MinCollectionNameLen, constants.MaxCollectionNameLen))
+	}
+	if !collectionNameRegex.MatchString(name) {
+		return NewServiceError(constants.ErrCodeInvalidCollectionName,
+			"collection name must contain only lowercase letters, numbers, hyphens, and underscores")
+	}
+	return nil
+}
+
+// List returns all collections in a topic.
+func (s *CollectionService) List(topicName string) ([]database.Collection, error) {
+	db, err := s.topicDB(topicName)
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := database.ListCollections(db)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return collections, nil
+}
+
+// Create adds a new, empty collection to a topic.
+func (s *CollectionService) Create(topicName string, req *CollectionCreateRequest) (*database.Collection, error) {
+	if err := ValidateCollectionName(req.Name); err != nil {
+		return nil, err
+	}
+	if len(req.Description) > constants.MaxCollectionDescriptionLen {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("description exceeds %d characters", constants.MaxCollectionDescriptionLen))
+	}
+
+	db, err := s.topicDB(topicName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.InsertCollection(db, req.Name, req.Description, time.Now().Unix()); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, NewServiceError(constants.ErrCodeCollectionAlreadyExists,
+				fmt.Sprintf("collection already exists: %s", req.Name))
+		}
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Created collection %s in topic %s", req.Name, topicName)
+
+	return s.get(db, req.Name)
+}
+
+// Get returns a collection with a page of its member asset IDs.
+func (s *CollectionService) Get(topicName, name string, limit, offset int) (*CollectionDetail, error) {
+	db, err := s.topicDB(topicName)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, err := s.get(db, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = constants.DefaultPageSize
+	}
+	if limit > constants.MaxPageSize {
+		limit = constants.MaxPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	ids, err := database.GetCollectionAssetIDs(db, name, limit, offset)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	return &CollectionDetail{
+		Collection: *collection,
+		AssetIDs:   ids,
+		Limit:      limit,
+		Offset:     offset,
+	}, nil
+}
+
+// Update changes the mutable fields of a collection.
+func (s *CollectionService) Update(topicName, name string, req *CollectionUpdateRequest) (*database.Collection, error) {
+	db, err := s.topicDB(topicName)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description == nil {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "no fields to update")
+	}
+	if len(*req.Description) > constants.MaxCollectionDescriptionLen {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("description exceeds %d characters", constants.MaxCollectionDescriptionLen))
+	}
+
+	found, err := database.UpdateCollectionDescription(db, name, *req.Description, time.Now().Unix())
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if !found {
+		return nil, ErrCollectionNotFoundWithName(name)
+	}
+
+	return s.get(db, name)
+}
+
+// Delete removes a collection. Member assets are left untouched.
+// Returns the number of memberships that were removed.
+func (s *CollectionService) Delete(topicName, name string) (int64, error) {
+	db, err := s.topicDB(topicName)
+	if err != nil {
+		return 0, err
+	}
+
+	freed, found, err := database.DeleteCollection(db, name)
+	if err != nil {
+		return 0, WrapInternalError(err)
+	}
+	if !found {
+		return 0, ErrCollectionNotFoundWithName(name)
+	}
+
+	if orchDB := s.app.GetOrchestratorDB(); orchDB != nil {
+		if _, err := database.RemoveHolderReferences(orchDB, constants.ReferenceKindCollection, topicName, name); err != nil {
+			s.logger.Warn("Failed to release references of collection %s in topic %s (rebuilt on restart): %v", name, topicName, err)
+		}
+	}
+
+	s.logger.Info("Deleted collection %s in topic %s (%d memberships removed)", name, topicName, freed)
+	return freed, nil
+}
+
+// AddAssets adds assets to a collection. Only assets stored in the same topic
+// can be added; unknown hashes are reported back as missing.
+func (s *CollectionService) AddAssets(topicName, name string, req *CollectionAssetsRequest) (*CollectionAssetsResult, error) {
+	db, err := s.topicDB(topicName)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCollectionAssetIDs(req.AssetIDs); err != nil {
+		return nil, err
+	}
+	if _, err := s.get(db, name); err != nil {
+		return nil, err
+	}
+
+	found, missing, err := database.FilterExistingAssets(db, req.AssetIDs)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	now := time.Now().Unix()
+	added, err := database.AddCollectionAssets(db, name, found, now)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	if orchDB := s.app.GetOrchestratorDB(); orchDB != nil {
+		refs := make([]database.AssetReference, 0, len(found))
+		for _, id := range found {
+			refs = append(refs, database.AssetReference{
+				Hash:      id,
+				Kind:      constants.ReferenceKindCollection,
+				Topic:     topicName,
+				Holder:    name,
+				CreatedAt: now,
+			})
+		}
+		if err := database.AddAssetReferences(orchDB, refs); err != nil {
+			s.logger.Warn("Failed to register references of collection %s in topic %s (rebuilt on restart): %v", name, topicName, err)
+		}
+	}
+
+	return &CollectionAssetsResult{
+		Requested: len(req.AssetIDs),
+		Changed:   added,
+		Missing:   missing,
+	}, nil
+}
+
+// RemoveAssets removes assets from a collection.
+func (s *CollectionService) RemoveAssets(topicName, name string, req *CollectionAssetsRequest) (*CollectionAssetsResult, error) {
+	db, err := s.topicDB(topicName)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCollectionAssetIDs(req.AssetIDs); err != nil {
+		return nil, err
+	}
+	if _, err := s.get(db, name); err != nil {
+		return nil, err
+	}
+
+	removed, err := database.RemoveCollectionAssets(db, name, req.AssetIDs, time.Now().Unix())
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	if orchDB := s.app.GetOrchestratorDB(); orchDB != nil {
+		if err := database.RemoveAssetReferences(orchDB, constants.ReferenceKindCollection, topicName, name, req.AssetIDs); err != nil {
+			s.logger.Warn("Failed to release references of collection %s in topic %s (rebuilt on restart): %v", name, topicName, err)
+		}
+	}
+
+	return &CollectionAssetsResult{
+		Requested: len(req.AssetIDs),
+		Changed:   removed,
+	}, nil
+}
+
+// MemberSet returns the asset IDs of a collection in the given topic as a set.
+// A topic that has no such collection yields an empty set rather than an error,
+// so cross-topic filters simply match nothing in that topic.
+func (s *CollectionService) MemberSet(topicName, name string) (map[string]struct{}, error) {
+	db, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	set, err := database.GetCollectionAssetSet(db, name)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return set, nil
+}
+
+// topicDB returns the database of a healthy topic.
+func (s *CollectionService) topicDB(topicName string) (*sql.DB, error) {
+	if s.app.GetWorkingDirectory() == "" {
+		return nil, ErrNotConfigured
+	}
+	if !s.app.TopicExists(topicName) {
+		return nil, ErrTopicNotFoundWithName(topicName)
+	}
+	healthy, errMsg := s.app.IsTopicHealthy(topicName)
+	if !healthy {
+		return nil, ErrTopicUnhealthyWithReason(topicName, errMsg)
+	}
+
+	db, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return db, nil
+}
+
+// get returns a collection or a not-found service error.
+func (s *CollectionService) get(db *sql.DB, name string) (*database.Collection, error) {
+	collection, err := database.GetCollection(db, name)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if collection == nil {
+		return nil, ErrCollectionNotFoundWithName(name)
+	}
+	return collection, nil
+}
+
+// validateCollectionAssetIDs checks the size and format of a membership change.
+func validateCollectionAssetIDs(ids []string) error {
+	if len(ids) == 0 {
+		return NewServiceError(constants.ErrCodeInvalidRequest, "asset_ids is required")
+	}
+	if len(ids) > constants.MaxCollectionAssetsPerChange {
+		return NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("too many asset_ids: %d (max: %d)", len(ids), constants.MaxCollectionAssetsPerChange))
+	}
+	for _, id := range ids {
+		if len(id) != constants.HashLength {
+			return NewServiceError(constants.ErrCodeInvalidHash, fmt.Sprintf("invalid hash format: %s", id))
+		}
+	}
+	return nil
+}
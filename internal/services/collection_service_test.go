@@ -0,0 +1,119 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// newCollectionTestService creates a collection service backed by a real topic DB.
+func newCollectionTestService(t *testing.T, topic string) (*CollectionService, *mockAppState) {
+	t.Helper()
+	mockApp := newMockAppState()
+	mockApp.workingDir = t.TempDir()
+
+	db, err := database.InitTopicDB(filepath.Join(mockApp.workingDir, topic+".db"))
+	if err != nil {
+		t.Fatalf("failed to init topic db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mockApp.StoreTopicDB(topic, db)
+	mockApp.RegisterTopic(topic, true, "")
+
+	return NewCollectionService(mockApp, logger.NewLogger("debug")), mockApp
+}
+
+func insertTestAsset(t *testing.T, mockApp *mockAppState, topic, hash string) {
+	t.Helper()
+	db := mockApp.topicDBs[topic]
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := database.InsertAsset(tx, database.Asset{
+		AssetID: hash, AssetSize: 1, Extension: "bin", BlobName: constants.FirstDatFilename,
+	}); err != nil {
+		t.Fatalf("insert asset: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+}
+
+func TestValidateCollectionName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantCode string
+	}{
+		{"valid", "heroes_v2-final", ""},
+		{"empty", "", constants.ErrCodeInvalidRequest},
+		{"uppercase", "Heroes", constants.ErrCodeInvalidCollectionName},
+		{"slash", "a/b", constants.ErrCodeInvalidCollectionName},
+		{"too long", strings.Repeat("a", constants.MaxCollectionNameLen+1), constants.ErrCodeInvalidCollectionName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCollectionName(tt.input)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			code, ok := IsServiceError(err)
+			if !ok || code != tt.wantCode {
+				t.Errorf("expected code %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+}
+
+func TestCollectionService_TopicNotFound(t *testing.T) {
+	svc, _ := newCollectionTestService(t, "models")
+
+	_, err := svc.List("missing")
+	if code, _ := IsServiceError(err); code != constants.ErrCodeTopicNotFound {
+		t.Errorf("expected %s, got %v", constants.ErrCodeTopicNotFound, err)
+	}
+}
+
+func TestCollectionService_AddAssetsReportsMissing(t *testing.T) {
+	svc, mockApp := newCollectionTestService(t, "models")
+	known := strings.Repeat("a", constants.HashLength)
+	unknown := strings.Repeat("b", constants.HashLength)
+	insertTestAsset(t, mockApp, "models", known)
+
+	if _, err := svc.Create("models", &CollectionCreateRequest{Name: "heroes"}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	result, err := svc.AddAssets("models", "heroes", &CollectionAssetsRequest{AssetIDs: []string{known, unknown}})
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if result.Changed != 1 {
+		t.Errorf("expected 1 added, got %d", result.Changed)
+	}
+	if len(result.Missing) != 1 || result.Missing[0] != unknown {
+		t.Errorf("expected unknown hash reported missing, got %v", result.Missing)
+	}
+}
+
+func TestCollectionService_AddAssetsInvalidHash(t *testing.T) {
+	svc, _ := newCollectionTestService(t, "models")
+	if _, err := svc.Create("models", &CollectionCreateRequest{Name: "heroes"}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	_, err := svc.AddAssets("models", "heroes", &CollectionAssetsRequest{AssetIDs: []string{"short"}})
+	if code, _ := IsServiceError(err); code != constants.ErrCodeInvalidHash {
+		t.Errorf("expected %s, got %v", constants.ErrCodeInvalidHash, err)
+	}
+}
@@ -0,0 +1,550 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/zeebo/blake3"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/storage"
+)
+
+// CompactionService reclaims the space of deleted assets. A .dat file holding
+// tombstones is compacted by appending its live entries to the end of the
+// topic, the way uploads are written, then removing the file once the topic
+// database points at the new copies. Files are never rewritten in place, so a
+// reader that opened one before the switch keeps reading valid bytes.
+type CompactionService struct {
+	app        AppState
+	logger     *logger.Logger
+	statsCache *StatsCache
+
+	mu      sync.Mutex
+	running map[string]bool              // topics being compacted
+	last    map[string]*CompactionResult // latest run per topic
+	subs    map[chan CompactionEvent]string
+
+	schedMu   sync.Mutex
+	stopCh    chan struct{}
+	scheduled bool
+}
+
+// CompactionEvent reports the progress of a compaction run to stream
+// subscribers. Type is one of constants.CompactionEvent*.
+type CompactionEvent struct {
+	Type  string
+	Topic string
+	Data  interface{}
+}
+
+// CompactionStart is the payload of the compaction_start event.
+type CompactionStart struct {
+	Topic    string                   `json:"topic"`
+	Trigger  string                   `json:"trigger"`
+	DatFiles []database.TombstonedDat `json:"dat_files"`
+}
+
+// DatCompactionProgress is the payload of the dat_progress event.
+type DatCompactionProgress struct {
+	Topic        string `json:"topic"`
+	DatFile      string `json:"dat_file"`
+	EntriesMoved int    `json:"entries_moved"`
+	TotalEntries int    `json:"total_entries"`
+	BytesMoved   int64  `json:"bytes_moved"`
+}
+
+// DatCompaction is the outcome of compacting one .dat file, and the payload
+// of the dat_complete event.
+type DatCompaction struct {
+	Topic          string `json:"topic"`
+	DatFile        string `json:"dat_file"`
+	Tombstones     int    `json:"tombstones"`
+	EntriesMoved   int    `json:"entries_moved"`
+	BytesMoved     int64  `json:"bytes_moved"`
+	BytesReclaimed int64  `json:"bytes_reclaimed"`
+	Error          string `json:"error,omitempty"`
+}
+
+// CompactionResult is the outcome of a compaction run over a topic, and the
+// payload of the compaction_complete event.
+type CompactionResult struct {
+	Topic          string          `json:"topic"`
+	Trigger        string          `json:"trigger"`
+	DatFiles       []DatCompaction `json:"dat_files"`
+	EntriesMoved   int             `json:"entries_moved"`
+	BytesReclaimed int64           `json:"bytes_reclaimed"`
+	StartedAt      int64           `json:"started_at"`
+	CompletedAt    int64           `json:"completed_at"`
+	DurationMs     int64           `json:"duration_ms"`
+	Error          string          `json:"error,omitempty"`
+}
+
+// CompactionStatus is what a topic has to compact and how the latest run went.
+type CompactionStatus struct {
+	Topic            string                   `json:"topic"`
+	Running          bool                     `json:"running"`
+	Tombstones       int                      `json:"tombstones"`
+	ReclaimableBytes int64                    `json:"reclaimable_bytes"`
+	DatFiles         []database.TombstonedDat `json:"dat_files"`
+	LastResult       *CompactionResult        `json:"last_result"`
+}
+
+// NewCompactionService creates a new compaction service instance.
+func NewCompactionService(app AppState, log *logger.Logger) *CompactionService {
+	return &CompactionService{
+		app:     app,
+		logger:  log,
+		running: make(map[string]bool),
+		last:    make(map[string]*CompactionResult),
+		subs:    make(map[chan CompactionEvent]string),
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// SetStatsCache sets the stats cache refreshed after a topic is compacted.
+func (s *CompactionService) SetStatsCache(cache *StatsCache) {
+	s.statsCache = cache
+}
+
+// Status returns the tombstones awaiting compaction in a topic.
+func (s *CompactionService) Status(topicName string) (*CompactionStatus, error) {
+	db, err := s.topicDB(topicName)
+	if err != nil {
+		return nil, err
+	}
+	dats, err := database.ListTombstonedDats(db)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	status := &CompactionStatus{Topic: topicName, DatFiles: dats}
+	for _, d := range dats {
+		status.Tombstones += d.Tombstones
+		status.ReclaimableBytes += d.DeadBytes
+	}
+	s.mu.Lock()
+	status.Running = s.running[topicName]
+	status.LastResult = s.last[topicName]
+	s.mu.Unlock()
+	return status, nil
+}
+
+// Trigger compacts every .dat file of the topic holding tombstones, in the
+// background. Only one run per topic at a time.
+func (s *CompactionService) Trigger(topicName string) error {
+	if _, err := s.topicDB(topicName); err != nil {
+		return err
+	}
+	if !s.acquire(topicName) {
+		return NewServiceError(constants.ErrCodeCompactionInProgress,
+			fmt.Sprintf("topic %s is already being compacted", topicName))
+	}
+
+	go func() {
+		defer s.release(topicName)
+		s.compactTopic(topicName, constants.CompactionTriggerManual)
+	}()
+	return nil
+}
+
+// Run compacts the topic synchronously. Scheduled runs skip .dat files
+// where deleted entries take less than constants.CompactionMinDeadPercent.
+func (s *CompactionService) Run(topicName, trigger string) (*CompactionResult, error) {
+	if _, err := s.topicDB(topicName); err != nil {
+		return nil, err
+	}
+	if !s.acquire(topicName) {
+		return nil, NewServiceError(constants.ErrCodeCompactionInProgress,
+			fmt.Sprintf("topic %s is already being compacted", topicName))
+	}
+	defer s.release(topicName)
+
+	return s.compactTopic(topicName, trigger), nil
+}
+
+// Start launches the scheduled compaction pass over all healthy topics.
+// Safe to call multiple times — subsequent calls are no-ops.
+func (s *CompactionService) Start(interval time.Duration) {
+	s.schedMu.Lock()
+	if s.scheduled {
+		s.schedMu.Unlock()
+		return
+	}
+	s.scheduled = true
+	s.schedMu.Unlock()
+
+	s.logger.Info("[compaction] scheduled compaction started (interval: %v)", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				s.logger.Info("[compaction] scheduled compaction stopped")
+				return
+			case <-ticker.C:
+				s.compactAll()
+			}
+		}
+	}()
+}
+
+// Stop signals the scheduled compaction goroutine to exit. A .dat file being
+// compacted is finished first.
+func (s *CompactionService) Stop() {
+	s.schedMu.Lock()
+	defer s.schedMu.Unlock()
+
+	if s.scheduled {
+		close(s.stopCh)
+		s.scheduled = false
+	}
+}
+
+// Subscribe returns a channel receiving the compaction events of a topic.
+// Events are dropped for subscribers that fall behind.
+func (s *CompactionService) Subscribe(topicName string) chan CompactionEvent {
+	ch := make(chan CompactionEvent, constants.CompactionEventBufferSize)
+	s.mu.Lock()
+	s.subs[ch] = topicName
+	s.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe removes and closes a subscriber channel.
+func (s *CompactionService) Unsubscribe(ch chan CompactionEvent) {
+	s.mu.Lock()
+	if _, ok := s.subs[ch]; ok {
+		delete(s.subs, ch)
+		close(ch)
+	}
+	s.mu.Unlock()
+}
+
+func (s *CompactionService) publish(eventType, topicName string, data interface{}) {
+	event := CompactionEvent{Type: eventType, Topic: topicName, Data: data}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch, topic := range s.subs {
+		if topic != topicName {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (s *CompactionService) acquire(topicName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[topicName] {
+		return false
+	}
+	s.running[topicName] = true
+	return true
+}
+
+func (s *CompactionService) release(topicName string) {
+	s.mu.Lock()
+	delete(s.running, topicName)
+	s.mu.Unlock()
+}
+
+// topicDB returns the database of a configured, healthy topic.
+func (s *CompactionService) topicDB(topicName string) (*sql.DB, error) {
+	if s.app.GetWorkingDirectory() == "" {
+		return nil, ErrNotConfigured
+	}
+	if !s.app.TopicExists(topicName) {
+		return nil, ErrTopicNotFoundWithName(topicName)
+	}
+	if healthy, errMsg := s.app.IsTopicHealthy(topicName); !healthy {
+		return nil, ErrTopicUnhealthyWithReason(topicName, errMsg)
+	}
+	db, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return db, nil
+}
+
+// compactAll runs a scheduled pass over every healthy topic, skipping topics
+// already being compacted.
+func (s *CompactionService) compactAll() {
+	for _, name := range s.app.ListTopics() {
+		if healthy, _ := s.app.IsTopicHealthy(name); !healthy {
+			continue
+		}
+		if !s.acquire(name) {
+			continue
+		}
+		s.compactTopic(name, constants.CompactionTriggerScheduled)
+		s.release(name)
+	}
+}
+
+// compactTopic compacts the topic's .dat files holding tombstones, one at a
+// time, and records the result as the topic's latest run.
+func (s *CompactionService) compactTopic(topicName, trigger string) *CompactionResult {
+	started := time.Now()
+	result := &CompactionResult{
+		Topic:     topicName,
+		Trigger:   trigger,
+		DatFiles:  []DatCompaction{},
+		StartedAt: started.Unix(),
+	}
+
+	finish := func() *CompactionResult {
+		result.CompletedAt = time.Now().Unix()
+		result.DurationMs = time.Since(started).Milliseconds()
+		s.mu.Lock()
+		s.last[topicName] = result
+		s.mu.Unlock()
+		if result.Error != "" {
+			s.publish(constants.CompactionEventError, topicName, result)
+		} else {
+			s.publish(constants.CompactionEventComplete, topicName, result)
+		}
+		return result
+	}
+
+	db, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		result.Error = err.Error()
+		return finish()
+	}
+	dats, err := database.ListTombstonedDats(db)
+	if err != nil {
+		result.Error = err.Error()
+		return finish()
+	}
+	topicPath := s.app.GetTopicPath(topicName)
+
+	if trigger == constants.CompactionTriggerScheduled {
+		due := dats[:0]
+		for _, d := range dats {
+			size, err := storage.GetDatFileSize(filepath.Join(topicPath, d.DatFile))
+			if err == nil && d.DeadBytes*100 >= size*constants.CompactionMinDeadPercent {
+				due = append(due, d)
+			}
+		}
+		if len(due) == 0 {
+			return result
+		}
+		dats = due
+	}
+
+	s.publish(constants.CompactionEventStart, topicName, CompactionStart{Topic: topicName, Trigger: trigger, DatFiles: dats})
+	s.logger.Info("[compaction] compacting %d .dat file(s) of topic %s (%s)", len(dats), topicName, trigger)
+
+	for _, d := range dats {
+		dc, err := s.compactDat(topicName, db, topicPath, d)
+		if err != nil {
+			dc.Error = err.Error()
+			s.logger.Error("[compaction] %s/%s: %v", topicName, d.DatFile, err)
+		} else {
+			s.logger.Info("[compaction] %s/%s: moved %d entries, reclaimed %d bytes", topicName, d.DatFile, dc.EntriesMoved, dc.BytesReclaimed)
+		}
+		result.DatFiles = append(result.DatFiles, dc)
+		result.EntriesMoved += dc.EntriesMoved
+		result.BytesReclaimed += dc.BytesReclaimed
+		s.publish(constants.CompactionEventDatComplete, topicName, dc)
+	}
+
+	if s.statsCache != nil {
+		s.statsCache.InvalidateTopic(topicName)
+	}
+	return finish()
+}
+
+// compactedEntry is a live entry copied to its new location.
+type compactedEntry struct {
+	asset  database.Asset
+	target string
+	offset int64
+}
+
+// compactDat moves the live entries of one .dat file and removes it. The
+// topic write lock is held throughout, so uploads and deletions wait and the
+// running hashes of target files advance as they would for uploads. Any
+// failure before the topic commit truncates the targets back and leaves the
+// file as it was.
+func (s *CompactionService) compactDat(topicName string, db *sql.DB, topicPath string, dead database.TombstonedDat) (dc DatCompaction, err error) {
+	dc = DatCompaction{Topic: topicName, DatFile: dead.DatFile, Tombstones: dead.Tombstones}
+
+	mu := s.app.GetTopicWriteMu(topicName)
+	mu.Lock()
+	defer mu.Unlock()
+
+	live, err := database.ListAssetsInDat(db, dead.DatFile)
+	if err != nil {
+		return dc, err
+	}
+
+	sourcePath := filepath.Join(topicPath, dead.DatFile)
+	src, err := os.Open(sourcePath)
+	if err != nil {
+		return dc, fmt.Errorf("failed to open dat file: %w", err)
+	}
+	defer src.Close()
+	stat, err := src.Stat()
+	if err != nil {
+		return dc, fmt.Errorf("failed to stat dat file: %w", err)
+	}
+
+	maxDatSize := s.app.GetConfig().MaxDatSize
+	if maxDatSize == 0 {
+		maxDatSize = constants.DefaultMaxDatSize
+	}
+
+	// Sizes of the target files before compaction, to truncate back to
+	committed := false
+	targets := make(map[string]int64)
+	defer func() {
+		if committed {
+			return
+		}
+		for target, size := range targets {
+			if terr := os.Truncate(filepath.Join(topicPath, target), size); terr != nil {
+				s.logger.Error("[compaction] failed to truncate %s back to %d: %v", target, size, terr)
+			}
+		}
+	}()
+
+	moved := make([]compactedEntry, 0, len(live))
+	for _, asset := range live {
+		entrySize := int64(constants.HeaderSize) + asset.AssetSize
+		target, _, err := storage.DetermineTargetDatFile(topicPath, entrySize, maxDatSize)
+		if err == nil && target == dead.DatFile {
+			target, err = storage.GetNextDatFilename(topicPath)
+		}
+		if err != nil {
+			return dc, fmt.Errorf("failed to determine dat file: %w", err)
+		}
+		targetPath := filepath.Join(topicPath, target)
+		if _, ok := targets[target]; !ok {
+			size, err := storage.GetDatFileSize(targetPath)
+			if err != nil {
+				return dc, err
+			}
+			targets[target] = size
+		}
+
+		// Entries are rehashed while copied so damaged content is never
+		// carried over as valid
+		hasher := blake3.New()
+		data := io.TeeReader(io.NewSectionReader(src, asset.ByteOffset+int64(constants.HeaderSize), asset.AssetSize), hasher)
+		offset, err := storage.AppendEntryFromReader(targetPath, asset.AssetID, asset.AssetSize, data)
+		if err != nil {
+			return dc, fmt.Errorf("failed to copy %s: %w", asset.AssetID, err)
+		}
+		if sum := hex.EncodeToString(hasher.Sum(nil)); sum != asset.AssetID {
+			return dc, fmt.Errorf("entry of %s does not match its hash (got %s)", asset.AssetID, sum)
+		}
+
+		moved = append(moved, compactedEntry{asset: asset, target: target, offset: offset})
+		dc.EntriesMoved++
+		dc.BytesMoved += entrySize
+		if dc.EntriesMoved%constants.CompactionProgressInterval == 0 {
+			s.publish(constants.CompactionEventDatProgress, topicName, DatCompactionProgress{
+				Topic:        topicName,
+				DatFile:      dead.DatFile,
+				EntriesMoved: dc.EntriesMoved,
+				TotalEntries: len(live),
+				BytesMoved:   dc.BytesMoved,
+			})
+		}
+	}
+
+	if err := s.commitMoves(db, dead.DatFile, moved); err != nil {
+		return dc, err
+	}
+	committed = true
+
+	// The rows now point at the copies; the file only holds dead entries
+	src.Close()
+	if err := os.Remove(sourcePath); err != nil {
+		s.logger.Warn("[compaction] %s/%s compacted but could not be removed: %v", topicName, dead.DatFile, err)
+		return dc, nil
+	}
+	dc.BytesReclaimed = stat.Size() - dc.BytesMoved
+	return dc, nil
+}
+
+// commitMoves points the moved assets at their copies, advances the running
+// hashes of the target files and forgets the compacted file.
+func (s *CompactionService) commitMoves(db *sql.DB, datFile string, moved []compactedEntry) error {
+	txTopic, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin topic transaction: %w", err)
+	}
+	defer txTopic.Rollback()
+
+	txOrch, err := s.app.GetOrchestratorDB().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin orchestrator transaction: %w", err)
+	}
+	defer txOrch.Rollback()
+
+	type chain struct {
+		hash  string
+		count int64
+	}
+	chains := make(map[string]*chain)
+	for _, m := range moved {
+		if err := database.MoveAsset(txTopic, m.asset.AssetID, m.target, m.offset); err != nil {
+			return fmt.Errorf("failed to move asset: %w", err)
+		}
+		if err := s.app.GetAssetIndex().Move(txOrch, m.asset.AssetID, m.target); err != nil {
+			return fmt.Errorf("failed to move asset index: %w", err)
+		}
+
+		c, ok := chains[m.target]
+		if !ok {
+			prevHash, entryCount, err := database.GetDatHashTx(txTopic, m.target)
+			if err != nil {
+				return fmt.Errorf("failed to get dat hash: %w", err)
+			}
+			if prevHash == "" {
+				prevHash = storage.GenesisHash(m.target)
+				entryCount = 0
+			}
+			c = &chain{hash: prevHash, count: entryCount}
+			chains[m.target] = c
+		}
+		if c.hash, err = storage.ComputeRunningHash(c.hash, m.asset.AssetID, m.offset, m.asset.AssetSize); err != nil {
+			return fmt.Errorf("failed to compute running hash: %w", err)
+		}
+		c.count++
+	}
+
+	for target, c := range chains {
+		if err := database.UpdateDatHash(txTopic, target, c.hash, c.count); err != nil {
+			return fmt.Errorf("failed to update dat hash: %w", err)
+		}
+	}
+	if err := database.DropDatRecords(txTopic, datFile); err != nil {
+		return fmt.Errorf("failed to drop dat records: %w", err)
+	}
+
+	if err := txTopic.Commit(); err != nil {
+		return fmt.Errorf("failed to commit topic transaction: %w", err)
+	}
+	if err := txOrch.Commit(); err != nil {
+		s.logger.Warn("[compaction] orchestrator commit failed (will recover on restart): %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+// ConfigHistory is the configuration as it was at a point in time, rebuilt
+// from the config_changed audit entries.
+type ConfigHistory struct {
+	At      int64                  `json:"at"`
+	Config  map[string]interface{} `json:"config"`  // flattened keys, credentials redacted
+	Changes []audit.ConfigChange   `json:"changes"` // newest first
+	// Partial is set when entries written before changes were recorded are
+	// part of the history, so keys they touched may be missing or stale.
+	Partial bool `json:"partial"`
+}
+
+// Snapshot returns the running config flattened, to diff against after a change.
+func (s *ConfigService) Snapshot() map[string]interface{} {
+	return config.Flatten(s.app.GetConfig())
+}
+
+// RecordChange writes a config_changed audit entry with the keys that differ
+// from before. A nil before diffs against the configuration rebuilt from the
+// audit log, for changes that replace the whole config at once.
+func (s *ConfigService) RecordChange(ipAddress, username, source string, before map[string]interface{}, fields []string) {
+	auditLogger := s.app.GetAuditLogger()
+	if auditLogger == nil {
+		return
+	}
+	cfg := s.app.GetConfig()
+	after := config.Flatten(cfg)
+	if before == nil {
+		before = s.replay()
+		after = config.Redact(after)
+	}
+
+	auditLogger.Log(constants.AuditActionConfigChanged, ipAddress, username, audit.ConfigChangedDetails{
+		WorkingDirectory: cfg.WorkingDirectory,
+		IsBootstrap:      source == constants.ConfigChangeSourceBootstrap,
+		Fields:           fields,
+		Source:           source,
+		Changes:          config.Diff(before, after),
+		Warnings:         configWarnings(cfg),
+	})
+}
+
+// RecordStartup records the changes made to the config file while the server
+// was stopped, attributed to the command line. Credentials are compared by
+// presence only, since the audit log never holds their values.
+func (s *ConfigService) RecordStartup(bootstrap bool) {
+	auditLogger := s.app.GetAuditLogger()
+	if auditLogger == nil || s.app.GetOrchestratorDB() == nil {
+		return
+	}
+	cfg := s.app.GetConfig()
+	changes := config.Diff(s.replay(), config.Redact(config.Flatten(cfg)))
+	if len(changes) == 0 {
+		return
+	}
+
+	source := constants.ConfigChangeSourceCLI
+	if bootstrap {
+		source = constants.ConfigChangeSourceBootstrap
+	}
+	s.logger.Info("Config changed since last run: %d keys", len(changes))
+	auditLogger.Log(constants.AuditActionConfigChanged, "", "", audit.ConfigChangedDetails{
+		WorkingDirectory: cfg.WorkingDirectory,
+		IsBootstrap:      bootstrap,
+		Source:           source,
+		Changes:          changes,
+		Warnings:         configWarnings(cfg),
+	})
+}
+
+// History rebuilds the configuration as of at (Unix seconds, 0 = now) and
+// lists the latest limit changes leading up to it.
+func (s *ConfigService) History(at int64, limit int) (*ConfigHistory, error) {
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return nil, NewServiceError(constants.ErrCodeNotConfigured, "orchestrator database not available")
+	}
+	if l := s.app.GetAuditLogger(); l != nil {
+		l.Flush()
+	}
+	if at <= 0 {
+		at = time.Now().Unix()
+	}
+
+	changes, err := audit.ListConfigChanges(db, at)
+	if err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to load config history: %w", err))
+	}
+
+	history := &ConfigHistory{
+		At:      at,
+		Config:  applyConfigChanges(configDefaults(), changes),
+		Changes: make([]audit.ConfigChange, 0, min(len(changes), limit)),
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		if len(history.Changes) < limit {
+			history.Changes = append(history.Changes, changes[i])
+		}
+		if changes[i].Details.Source == "" && len(changes[i].Details.Changes) == 0 {
+			history.Partial = true
+		}
+	}
+	return history, nil
+}
+
+// replay rebuilds the current redacted configuration from the audit log.
+func (s *ConfigService) replay() map[string]interface{} {
+	state := configDefaults()
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return state
+	}
+	if l := s.app.GetAuditLogger(); l != nil {
+		l.Flush()
+	}
+	changes, err := audit.ListConfigChanges(db, 0)
+	if err != nil {
+		s.logger.Warn("Failed to load config history: %v", err)
+		return state
+	}
+	return applyConfigChanges(state, changes)
+}
+
+// configDefaults is the redacted default configuration history starts from.
+func configDefaults() map[string]interface{} {
+	cfg := &config.Config{}
+	cfg.ApplyDefaults()
+	return config.Redact(config.Flatten(cfg))
+}
+
+func applyConfigChanges(state map[string]interface{}, changes []audit.ConfigChange) map[string]interface{} {
+	for _, change := range changes {
+		for _, fc := range change.Details.Changes {
+			if fc.After == nil {
+				delete(state, fc.Key)
+			} else {
+				state[fc.Key] = fc.After
+			}
+		}
+	}
+	return state
+}
+
+// configWarnings lists the warnings the dry run would report for cfg.
+func configWarnings(cfg *config.Config) []string {
+	dirChecks := checkWorkingDirectory(cfg.WorkingDirectory)
+	dirOK := true
+	for _, check := range dirChecks {
+		if check.Status == constants.ConfigCheckError {
+			dirOK = false
+		}
+	}
+
+	warnings := []string{}
+	for _, check := range append(dirChecks, checkDiskSpace(cfg, dirOK)...) {
+		if check.Status == constants.ConfigCheckWarning {
+			warnings = append(warnings, check.Name+": "+check.Message)
+		}
+	}
+	return warnings
+}
@@ -1,18 +1,22 @@
 package services
 
 import (
+	"crypto/rand"
 	stdsql "database/sql"
+	"encoding/hex"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"time"
 
 	"silobang/internal/audit"
 	"silobang/internal/config"
 	"silobang/internal/constants"
 	"silobang/internal/database"
 	"silobang/internal/logger"
-	"silobang/internal/prompts"
 	"silobang/internal/queries"
 	"silobang/internal/storage"
 )
@@ -44,6 +48,7 @@ type ConfigStatus struct {
 	Audit            config.AuditConfig      `json:"audit"`
 	Metadata         config.MetadataConfig   `json:"metadata"`
 	Batch            config.BatchConfig      `json:"batch"`
+	Query            config.QueryConfig      `json:"query"`
 	Monitoring       config.MonitoringConfig `json:"monitoring"`
 }
 
@@ -60,12 +65,14 @@ func (s *ConfigService) GetStatus() *ConfigStatus {
 		Audit:            cfg.Audit,
 		Metadata:         cfg.Metadata,
 		Batch:            cfg.Batch,
+		Query:            cfg.Query,
 		Monitoring:       cfg.Monitoring,
 	}
 }
 
 // SetWorkingDirectory changes the working directory and reinitializes all databases.
 // This is equivalent to a "project restart" - all existing connections are closed.
+// Initialization steps are recorded to a new startup recorder on the app.
 func (s *ConfigService) SetWorkingDirectory(workingDir string, serverPort int) error {
 	if workingDir == "" {
 		return NewServiceError(constants.ErrCodeInvalidRequest, "working_directory is required")
@@ -76,6 +83,12 @@ func (s *ConfigService) SetWorkingDirectory(workingDir string, serverPort int) e
 		return WrapServiceError(constants.ErrCodeInvalidRequest, err.Error(), err)
 	}
 
+	// Readiness reports "initialization in progress" from here on
+	startup := NewStartupRecorder(constants.StartupTriggerConfigAPI)
+	s.app.SetStartupRecorder(startup)
+	cfg := s.app.GetConfig()
+	supervisor := NewSupervisor(startup, cfg.Features, s.logger)
+
 	// Close existing connections (project restart behavior)
 	s.app.CloseAllTopicDBs()
 	s.app.ClearTopicRegistry()
@@ -84,68 +97,64 @@ func (s *ConfigService) SetWorkingDirectory(workingDir string, serverPort int) e
 		s.app.SetOrchestratorDB(nil)
 	}
 
-	// Initialize new working directory
-	if err := config.InitializeWorkingDirectory(workingDir); err != nil {
-		return WrapInternalError(err)
-	}
-
-	// Update and save config
-	cfg := s.app.GetConfig()
-	cfg.WorkingDirectory = workingDir
-	if err := config.SaveConfig(cfg); err != nil {
-		return WrapInternalError(fmt.Errorf("failed to save config: %w", err))
+	// Initialize new working directory, then update and save config
+	if !supervisor.Start(Subsystem{
+		Name:     constants.StartupStepWorkingDirectory,
+		Required: true,
+		Start: func() (string, error) {
+			if err := config.InitializeWorkingDirectory(workingDir); err != nil {
+				return "", err
+			}
+			cfg.WorkingDirectory = workingDir
+			if err := config.SaveConfig(cfg); err != nil {
+				return "", fmt.Errorf("failed to save config: %w", err)
+			}
+			return "", nil
+		},
+	}) {
+		return subsystemError(startup, constants.StartupStepWorkingDirectory)
 	}
 
 	// Open orchestrator DB
-	orchPath := filepath.Join(workingDir, constants.InternalDir, constants.OrchestratorDB)
-	orchDB, err := database.InitOrchestratorDB(orchPath)
-	if err != nil {
-		return WrapInternalError(fmt.Errorf("failed to open orchestrator database: %w", err))
+	if !supervisor.Start(Subsystem{
+		Name:     constants.StartupStepOrchestratorDB,
+		Required: true,
+		Requires: []string{constants.StartupStepWorkingDirectory},
+		Start: func() (string, error) {
+			orchPath := filepath.Join(workingDir, constants.InternalDir, constants.OrchestratorDB)
+			orchDB, err := database.InitOrchestratorDB(orchPath)
+			if err != nil {
+				return "", fmt.Errorf("failed to open orchestrator database: %w", err)
+			}
+			s.app.SetOrchestratorDB(orchDB)
+			return "", nil
+		},
+	}) {
+		return subsystemError(startup, constants.StartupStepOrchestratorDB)
 	}
-	s.app.SetOrchestratorDB(orchDB)
 
-	// Initialize audit logger (need to get the actual logger interface)
-	// Note: This requires the App to expose a method to set the audit logger
-	// For now, we'll handle this in the handler
+	// The audit logger is initialized by the handler, which owns it
 
-	// Discover and register topics
-	topics, err := config.DiscoverTopics(workingDir)
-	if err != nil {
-		s.logger.Warn("Topic discovery error: %v", err)
-	}
+	// Search: topic databases opened from here on keep or drop their index
+	database.SetSearchIndexing(cfg.Features.Enabled(constants.FeatureSearch))
+	supervisor.Start(SearchSubsystem(s.app))
 
-	for _, topic := range topics {
-		s.app.RegisterTopic(topic.Name, topic.Healthy, topic.Error)
-		if topic.Healthy {
-			// Index to orchestrator
-			if err := config.IndexTopicToOrchestrator(topic.Path, topic.Name, s.app.GetOrchestratorDB()); err != nil {
-				s.logger.Warn("Failed to index topic %s: %v", topic.Name, err)
-			}
-		}
-	}
+	// Discover, register and index topics
+	supervisor.Start(TopicDiscoverySubsystem(s.app, workingDir, s.logger))
 
 	// Load queries from .internal/queries/ directory (auto-generates if missing)
-	queriesConfig, err := queries.LoadQueries(workingDir, s.logger)
-	if err != nil {
-		s.logger.Warn("Failed to load queries: %v, using defaults", err)
-		queriesConfig = queries.GetDefaultConfig()
-	}
-	s.app.SetQueriesConfig(queriesConfig)
+	s.app.SetQueriesConfig(queries.GetDefaultConfig())
+	supervisor.Start(QueriesSubsystem(s.app, workingDir, s.logger))
 
 	// Initialize prompts manager
 	port := serverPort
 	if port == 0 {
 		port = constants.DefaultPort
 	}
-	baseURL := fmt.Sprintf("http://localhost:%d", port)
-	promptsManager := prompts.NewManager(workingDir, baseURL)
-	if err := promptsManager.EnsurePromptsDir(workingDir, s.logger); err != nil {
-		s.logger.Warn("Failed to initialize prompts directory: %v", err)
-	}
-	if err := promptsManager.LoadPrompts(s.logger); err != nil {
-		s.logger.Warn("Failed to load prompts: %v", err)
-	}
-	s.app.SetPromptsManager(promptsManager)
+	s.app.SetPromptsManager(nil)
+	supervisor.Start(PromptsSubsystem(s.app, workingDir, fmt.Sprintf("http://localhost:%d", port), s.logger))
+
+	supervisor.Start(PreviewsSubsystem())
 
 	// Enable file logging for the new working directory
 	if err := s.logger.SetWorkDir(workingDir); err != nil {
@@ -154,17 +163,348 @@ func (s *ConfigService) SetWorkingDirectory(workingDir string, serverPort int) e
 		s.logger.Info("File logging enabled in %s", workingDir)
 	}
 
-	s.logger.Info("Working directory changed to: %s, discovered %d topics", workingDir, len(topics))
+	s.logger.Info("Working directory changed to: %s, discovered %d topics", workingDir, len(s.app.ListTopics()))
 
 	return nil
 }
 
+// ConfigValidateRequest is a candidate configuration for a dry-run check.
+// Omitted fields keep their current value. Sections replace the current
+// section as a whole; zero fields inside a section fall back to defaults,
+// exactly as they would in config.yaml.
+type ConfigValidateRequest struct {
+	WorkingDirectory *string                    `json:"working_directory"`
+	Port             *int                       `json:"port"`
+	MaxDatSize       *int64                     `json:"max_dat_size"`
+	MaxDiskUsage     *int64                     `json:"max_disk_usage"`
+	Auth             *config.AuthConfig         `json:"auth"`
+	BulkDownload     *config.BulkDownloadConfig `json:"bulk_download"`
+	Audit            *config.AuditConfig        `json:"audit"`
+	Metadata         *config.MetadataConfig     `json:"metadata"`
+	Batch            *config.BatchConfig        `json:"batch"`
+	Query            *config.QueryConfig        `json:"query"`
+	Monitoring       *config.MonitoringConfig   `json:"monitoring"`
+}
+
+// ConfigCheck is the outcome of a single validation check.
+type ConfigCheck struct {
+	Name    string `json:"name"`
+	Field   string `json:"field,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// ConfigValidationReport is the result of a config dry run.
+type ConfigValidationReport struct {
+	Valid           bool          `json:"valid"`
+	RequiresRestart bool          `json:"requires_restart"`
+	RestartFields   []string      `json:"restart_fields"`
+	Checks          []ConfigCheck `json:"checks"`
+}
+
+// Validate runs every check that applying the candidate configuration would
+// depend on (value ranges, working directory writability, disk space, port
+// availability) without changing anything. The working directory and the
+// limits (see LimitsService) can be applied at runtime; every other changed
+// field is listed in RestartFields.
+func (s *ConfigService) Validate(req *ConfigValidateRequest) *ConfigValidationReport {
+	current := s.app.GetConfig()
+	candidate := *current
+	if req.WorkingDirectory != nil {
+		candidate.WorkingDirectory = *req.WorkingDirectory
+	}
+	if req.Port != nil {
+		candidate.Port = *req.Port
+	}
+	if req.MaxDatSize != nil {
+		candidate.MaxDatSize = *req.MaxDatSize
+	}
+	if req.MaxDiskUsage != nil {
+		candidate.MaxDiskUsage = *req.MaxDiskUsage
+	}
+	if req.Auth != nil {
+		candidate.Auth = *req.Auth
+	}
+	if req.BulkDownload != nil {
+		candidate.BulkDownload = *req.BulkDownload
+	}
+	if req.Audit != nil {
+		candidate.Audit = *req.Audit
+	}
+	if req.Metadata != nil {
+		candidate.Metadata = *req.Metadata
+	}
+	if req.Batch != nil {
+		candidate.Batch = *req.Batch
+	}
+	if req.Query != nil {
+		candidate.Query = *req.Query
+	}
+	if req.Monitoring != nil {
+		candidate.Monitoring = *req.Monitoring
+	}
+	candidate.ApplyDefaults()
+
+	report := &ConfigValidationReport{
+		RestartFields: restartFields(current, &candidate),
+		Checks:        []ConfigCheck{},
+	}
+	report.RequiresRestart = len(report.RestartFields) > 0
+
+	report.Checks = append(report.Checks, checkConfigRanges(&candidate)...)
+	dirOK := true
+	for _, check := range checkWorkingDirectory(candidate.WorkingDirectory) {
+		if check.Status == constants.ConfigCheckError {
+			dirOK = false
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	report.Checks = append(report.Checks, checkDiskSpace(&candidate, dirOK)...)
+	report.Checks = append(report.Checks, checkPort(candidate.Port, current.Port))
+
+	report.Valid = true
+	for _, check := range report.Checks {
+		if check.Status == constants.ConfigCheckError {
+			report.Valid = false
+			break
+		}
+	}
+
+	s.logger.Debug("Config dry run: valid=%v requires_restart=%v checks=%d",
+		report.Valid, report.RequiresRestart, len(report.Checks))
+
+	return report
+}
+
+// restartFields lists the fields that differ from the running config and are
+// only read at startup.
+func restartFields(current, candidate *config.Config) []string {
+	fields := []string{}
+	if candidate.Port != current.Port {
+		fields = append(fields, "port")
+	}
+	if candidate.MaxDatSize != current.MaxDatSize {
+		fields = append(fields, "max_dat_size")
+	}
+	if candidate.MaxDiskUsage != current.MaxDiskUsage {
+		fields = append(fields, "max_disk_usage")
+	}
+	if candidate.Auth != current.Auth {
+		fields = append(fields, "auth")
+	}
+	if candidate.BulkDownload.SessionTTLMins != current.BulkDownload.SessionTTLMins {
+		fields = append(fields, "bulk_download.session_ttl_mins")
+	}
+	if !reflect.DeepEqual(candidate.Audit, current.Audit) {
+		fields = append(fields, "audit")
+	}
+	if candidate.Monitoring != current.Monitoring {
+		fields = append(fields, "monitoring")
+	}
+	if !reflect.DeepEqual(candidate.Public, current.Public) {
+		fields = append(fields, "public")
+	}
+	return fields
+}
+
+// checkConfigRanges reports every out-of-range value.
+func checkConfigRanges(cfg *config.Config) []ConfigCheck {
+	fieldErrs := cfg.FieldErrors()
+	if len(fieldErrs) == 0 {
+		return []ConfigCheck{{
+			Name:    "value_ranges",
+			Status:  constants.ConfigCheckOK,
+			Message: "all values are within allowed ranges",
+		}}
+	}
+
+	checks := make([]ConfigCheck, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		checks = append(checks, ConfigCheck{
+			Name:    "value_ranges",
+			Field:   fe.Field,
+			Status:  constants.ConfigCheckError,
+			Message: fe.Message,
+		})
+	}
+	return checks
+}
+
+// checkWorkingDirectory verifies the directory exists and is writable.
+func checkWorkingDirectory(dir string) []ConfigCheck {
+	const field = "working_directory"
+
+	if dir == "" {
+		return []ConfigCheck{{
+			Name:    "working_directory",
+			Field:   field,
+			Status:  constants.ConfigCheckSkipped,
+			Message: "working directory is not set",
+		}}
+	}
+
+	if err := config.ValidateWorkingDirectory(dir); err != nil {
+		return []ConfigCheck{{
+			Name:    "working_directory",
+			Field:   field,
+			Status:  constants.ConfigCheckError,
+			Message: err.Error(),
+		}}
+	}
+
+	checks := []ConfigCheck{}
+	if !filepath.IsAbs(dir) {
+		checks = append(checks, ConfigCheck{
+			Name:    "working_directory",
+			Field:   field,
+			Status:  constants.ConfigCheckWarning,
+			Message: "path is relative and will resolve against the server's current directory",
+		})
+	} else {
+		checks = append(checks, ConfigCheck{
+			Name:    "working_directory",
+			Field:   field,
+			Status:  constants.ConfigCheckOK,
+			Message: "directory exists",
+		})
+	}
+
+	// Probe with a real file: permission bits alone miss read-only mounts and ACLs
+	probe, err := os.CreateTemp(dir, constants.ConfigWriteProbePattern)
+	if err != nil {
+		checks = append(checks, ConfigCheck{
+			Name:    "writable",
+			Field:   field,
+			Status:  constants.ConfigCheckError,
+			Message: fmt.Sprintf("directory is not writable: %v", err),
+		})
+		return checks
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	checks = append(checks, ConfigCheck{
+		Name:    "writable",
+		Field:   field,
+		Status:  constants.ConfigCheckOK,
+		Message: "directory is writable",
+	})
+
+	orchPath := filepath.Join(dir, constants.InternalDir, constants.OrchestratorDB)
+	if _, err := os.Stat(orchPath); err == nil {
+		checks = append(checks, ConfigCheck{
+			Name:    "existing_data",
+			Field:   field,
+			Status:  constants.ConfigCheckOK,
+			Message: "existing working directory will be reopened",
+		})
+	} else {
+		checks = append(checks, ConfigCheck{
+			Name:    "existing_data",
+			Field:   field,
+			Status:  constants.ConfigCheckOK,
+			Message: "new working directory will be initialized",
+		})
+	}
+
+	return checks
+}
+
+// checkDiskSpace verifies there is room for at least a few DAT files and that
+// the disk usage limit would not immediately reject uploads.
+func checkDiskSpace(cfg *config.Config, dirOK bool) []ConfigCheck {
+	if cfg.WorkingDirectory == "" || !dirOK {
+		return []ConfigCheck{{
+			Name:    "disk_space",
+			Status:  constants.ConfigCheckSkipped,
+			Message: "requires a valid working directory",
+		}}
+	}
+
+	checks := []ConfigCheck{}
+
+	free, err := GetDiskFreeBytes(cfg.WorkingDirectory)
+	switch {
+	case err != nil:
+		checks = append(checks, ConfigCheck{
+			Name:    "disk_space",
+			Status:  constants.ConfigCheckWarning,
+			Message: fmt.Sprintf("unable to read disk stats: %v", err),
+		})
+	case free < uint64(cfg.MaxDatSize)*constants.ConfigMinFreeDatMultiples:
+		checks = append(checks, ConfigCheck{
+			Name:    "disk_space",
+			Field:   "max_dat_size",
+			Status:  constants.ConfigCheckWarning,
+			Message: fmt.Sprintf("only %d bytes free, less than %d x max_dat_size (%d bytes)", free, constants.ConfigMinFreeDatMultiples, cfg.MaxDatSize),
+		})
+	default:
+		checks = append(checks, ConfigCheck{
+			Name:    "disk_space",
+			Status:  constants.ConfigCheckOK,
+			Message: fmt.Sprintf("%d bytes free", free),
+		})
+	}
+
+	if cfg.MaxDiskUsage > 0 {
+		if err := CheckDiskLimit(cfg.WorkingDirectory, cfg.MaxDiskUsage); err != nil {
+			checks = append(checks, ConfigCheck{
+				Name:    "disk_limit",
+				Field:   "max_disk_usage",
+				Status:  constants.ConfigCheckError,
+				Message: fmt.Sprintf("uploads would be rejected: %v", err),
+			})
+		} else {
+			checks = append(checks, ConfigCheck{
+				Name:    "disk_limit",
+				Field:   "max_disk_usage",
+				Status:  constants.ConfigCheckOK,
+				Message: "current disk usage is below the limit",
+			})
+		}
+	}
+
+	return checks
+}
+
+// checkPort verifies the port is valid and, when it differs from the running
+// port, that it can be bound.
+func checkPort(port, runningPort int) ConfigCheck {
+	check := ConfigCheck{Name: "port", Field: "port"}
+
+	if port < constants.MinPort || port > constants.MaxPort {
+		check.Status = constants.ConfigCheckError
+		check.Message = fmt.Sprintf("port must be between %d and %d", constants.MinPort, constants.MaxPort)
+		return check
+	}
+
+	if port == runningPort {
+		check.Status = constants.ConfigCheckOK
+		check.Message = "port unchanged"
+		return check
+	}
+
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		check.Status = constants.ConfigCheckError
+		check.Message = fmt.Sprintf("port is not available: %v", err)
+		return check
+	}
+	ln.Close()
+
+	check.Status = constants.ConfigCheckOK
+	check.Message = "port is available (takes effect after restart)"
+	return check
+}
+
 // TopicInfo represents information about a topic.
 type TopicInfo struct {
-	Name    string                 `json:"name"`
-	Stats   map[string]interface{} `json:"stats,omitempty"`
-	Healthy bool                   `json:"healthy"`
-	Error   string                 `json:"error,omitempty"`
+	Name            string                 `json:"name"`
+	Stats           map[string]interface{} `json:"stats,omitempty"`
+	Healthy         bool                   `json:"healthy"`
+	Error           string                 `json:"error,omitempty"`
+	IntegrityIssues int                    `json:"integrity_issues,omitempty"` // findings of the latest .dat integrity scan
+	StoragePolicies []config.StoragePolicy `json:"storage_policies,omitempty"` // effective policy of each stored extension
+	Frozen          bool                   `json:"frozen,omitempty"`           // uploads and metadata writes are refused
 }
 
 // TopicsListResult contains the list of topics and their stats for aggregation.
@@ -192,6 +532,7 @@ func (s *ConfigService) ListTopics() (*TopicsListResult, error) {
 		ti := TopicInfo{
 			Name:    name,
 			Healthy: healthy,
+			Frozen:  s.app.GetConfig().IsTopicFrozen(name),
 		}
 
 		if !healthy {
@@ -205,6 +546,12 @@ func (s *ConfigService) ListTopics() (*TopicsListResult, error) {
 				ti.Stats = stats
 				allStats[name] = stats
 			}
+			ti.IntegrityIssues = topicIntegrityIssues(s.app, name)
+			if policies, err := topicStoragePolicies(s.app, name); err != nil {
+				s.logger.Warn("Failed to get storage policies for topic %s: %v", name, err)
+			} else {
+				ti.StoragePolicies = policies
+			}
 		}
 
 		topics = append(topics, ti)
@@ -229,7 +576,11 @@ func (s *ConfigService) GetTopicStats(topicName string) (map[string]interface{},
 	// If no queries config, use hardcoded defaults
 	qc := s.app.GetQueriesConfig()
 	if qc == nil || len(qc.TopicStats) == 0 {
-		return s.getDefaultTopicStats(db, topicName, topicPath)
+		stats, err := s.getDefaultTopicStats(db, topicName, topicPath)
+		if quota := topicQuotaStat(s.app, topicName, db); quota != nil && err == nil {
+			stats["quota"] = quota
+		}
+		return stats, err
 	}
 
 	// Execute each stat query
@@ -293,6 +644,11 @@ func (s *ConfigService) GetTopicStats(topicName string) (map[string]interface{},
 		stats[stat.Name] = value
 	}
 
+	// Usage of the topic's quota, for dashboards
+	if quota := topicQuotaStat(s.app, topicName, db); quota != nil {
+		stats["quota"] = quota
+	}
+
 	return stats, nil
 }
 
@@ -367,7 +723,11 @@ func (s *ConfigService) getDefaultTopicStats(db *stdsql.DB, topicName string, to
 	return stats, nil
 }
 
-// CreateTopic creates a new topic with the given name.
+// CreateTopic creates a new topic with the given name. The name is reserved
+// in the orchestrator database for the duration of the creation; a concurrent
+// creation of the same name fails with TOPIC_CREATION_IN_PROGRESS, and the
+// partial folder of a failed or interrupted creation is removed, so the
+// request can simply be retried.
 func (s *ConfigService) CreateTopic(name string) error {
 	if s.app.GetWorkingDirectory() == "" {
 		return ErrNotConfigured
@@ -386,36 +746,68 @@ func (s *ConfigService) CreateTopic(name string) error {
 		return NewServiceError(constants.ErrCodeInvalidTopicName, "topic name must contain only lowercase letters, numbers, hyphens, and underscores")
 	}
 
-	// Acquire global topic creation lock to prevent filesystem races
-	// when concurrent requests try to create the same topic simultaneously
-	mu := s.app.GetTopicCreateMu()
-	mu.Lock()
-	defer mu.Unlock()
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return ErrNotConfigured
+	}
+
+	if s.app.TopicExists(name) {
+		return ErrTopicAlreadyExists
+	}
+
+	// Reserve the name before touching the filesystem, so concurrent
+	// creators of the same topic cannot collide on its folder and database
+	token, err := generateTopicReservationToken()
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	now := time.Now()
+	reserved, err := database.ReserveTopicName(orchDB, name, token, now.Unix(), now.Add(constants.TopicReservationTTL).Unix())
+	if err != nil {
+		return WrapInternalError(fmt.Errorf("failed to reserve topic name: %w", err))
+	}
+	if !reserved {
+		return NewServiceError(constants.ErrCodeTopicCreationInProgress, "topic is being created by another request, retry shortly")
+	}
+	defer func() {
+		if err := database.ReleaseTopicName(orchDB, name, token); err != nil {
+			s.logger.Warn("Failed to release reservation of topic %s: %v", name, err)
+		}
+	}()
 
-	s.logger.Debug("Acquired topic creation lock for topic %s", name)
+	s.logger.Debug("Reserved topic name %s", name)
 
-	// Check if already exists in registry (inside lock to prevent race)
+	// The previous holder of the reservation may have created it meanwhile
 	if s.app.TopicExists(name) {
 		return ErrTopicAlreadyExists
 	}
 
 	topicPath := s.app.GetTopicPath(name)
+	internalPath := filepath.Join(topicPath, constants.InternalDir)
+	markerPath := filepath.Join(internalPath, constants.TopicCreatingMarker)
 
-	// Check if folder already exists on disk
+	// A folder still carrying the creation marker was left by an interrupted
+	// creation; nobody else works on it while we hold the reservation, so a
+	// retry starts over
 	if _, err := os.Stat(topicPath); err == nil {
-		return NewServiceError(constants.ErrCodeTopicAlreadyExists, "topic folder already exists")
+		if _, err := os.Stat(markerPath); err != nil {
+			return NewServiceError(constants.ErrCodeTopicAlreadyExists, "topic folder already exists")
+		}
+		s.logger.Warn("Removing partial folder of topic %s left by an interrupted creation", name)
+		if err := os.RemoveAll(topicPath); err != nil {
+			return WrapInternalError(fmt.Errorf("failed to remove partial topic folder: %w", err))
+		}
 	}
 
-	// Create topic folder structure
-	if err := os.MkdirAll(topicPath, constants.DirPermissions); err != nil {
+	// Create topic folder structure, marked as incomplete until the
+	// database is ready
+	if err := os.MkdirAll(internalPath, constants.DirPermissions); err != nil {
+		os.RemoveAll(topicPath) // Cleanup on failure
 		return WrapInternalError(fmt.Errorf("failed to create topic folder: %w", err))
 	}
-
-	// Create .internal folder
-	internalPath := filepath.Join(topicPath, constants.InternalDir)
-	if err := os.MkdirAll(internalPath, constants.DirPermissions); err != nil {
+	if err := os.WriteFile(markerPath, nil, constants.FilePermissions); err != nil {
 		os.RemoveAll(topicPath) // Cleanup on failure
-		return WrapInternalError(fmt.Errorf("failed to create internal folder: %w", err))
+		return WrapInternalError(fmt.Errorf("failed to mark topic creation: %w", err))
 	}
 
 	// Create topic database with schema
@@ -426,15 +818,34 @@ func (s *ConfigService) CreateTopic(name string) error {
 		return WrapInternalError(fmt.Errorf("failed to create topic database: %w", err))
 	}
 
+	if err := os.Remove(markerPath); err != nil {
+		topicDB.Close()
+		os.RemoveAll(topicPath) // Cleanup on failure
+		return WrapInternalError(fmt.Errorf("failed to complete topic creation: %w", err))
+	}
+
 	// Store the DB connection and register topic
+	mu := s.app.GetTopicCreateMu()
+	mu.Lock()
 	s.app.StoreTopicDB(name, topicDB)
 	s.app.RegisterTopic(name, true, "")
+	mu.Unlock()
 
 	s.logger.Info("Created new topic: %s", name)
 
 	return nil
 }
 
+// generateTopicReservationToken creates a random token identifying one
+// topic creation.
+func generateTopicReservationToken() (string, error) {
+	bytes := make([]byte, constants.TopicReservationTokenLength/2)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
 // SetAuditLogger initializes the audit logger after working directory is set.
 // This should be called from the handler after SetWorkingDirectory.
 func (s *ConfigService) SetAuditLogger() *audit.Logger {
@@ -443,7 +854,10 @@ func (s *ConfigService) SetAuditLogger() *audit.Logger {
 		return nil
 	}
 	cfg := s.app.GetConfig()
-	return audit.NewLogger(orchDB, cfg.Audit.MaxLogSizeBytes, cfg.Audit.PurgePercentage)
+	l := audit.NewLogger(orchDB, cfg.Audit.MaxLogSizeBytes, cfg.Audit.PurgePercentage)
+	l.SetRetention(cfg.Audit.Retention())
+	l.SetQueue(cfg.Audit.QueueSize, cfg.Audit.OverflowPolicy)
+	return l
 }
 
 // wrapTopicError wraps topic-related errors with appropriate service errors.
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+// newConfigMock creates a mockAppState with a default config rooted at workDir.
+func newConfigMock(workDir string) *mockAppState {
+	m := newMockAppState()
+	m.workingDir = workDir
+	cfg := &config.Config{WorkingDirectory: workDir}
+	cfg.ApplyDefaults()
+	m.cfg = cfg
+	m.log = logger.NewLogger(logger.LevelError)
+	return m
+}
+
+// findCheck returns the first check with the given name and field.
+func findCheck(report *ConfigValidationReport, name, field string) *ConfigCheck {
+	for i := range report.Checks {
+		if report.Checks[i].Name == name && report.Checks[i].Field == field {
+			return &report.Checks[i]
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// Validate Tests
+// =============================================================================
+
+func TestConfigValidate_CurrentConfigIsValid(t *testing.T) {
+	m := newConfigMock(t.TempDir())
+	svc := NewConfigService(m, m.log)
+
+	report := svc.Validate(&ConfigValidateRequest{})
+	if !report.Valid {
+		t.Fatalf("expected current config to be valid, got %+v", report.Checks)
+	}
+	if report.RequiresRestart || len(report.RestartFields) != 0 {
+		t.Errorf("expected no restart, got %v", report.RestartFields)
+	}
+	if c := findCheck(report, "writable", "working_directory"); c == nil || c.Status != constants.ConfigCheckOK {
+		t.Errorf("expected writable check to pass, got %+v", c)
+	}
+	if c := findCheck(report, "existing_data", "working_directory"); c == nil || c.Message != "new working directory will be initialized" {
+		t.Errorf("expected new working directory, got %+v", c)
+	}
+}
+
+func TestConfigValidate_DoesNotApply(t *testing.T) {
+	workDir := t.TempDir()
+	m := newConfigMock(workDir)
+	svc := NewConfigService(m, m.log)
+
+	other := t.TempDir()
+	port := m.cfg.Port + 1
+	svc.Validate(&ConfigValidateRequest{WorkingDirectory: &other, Port: &port})
+
+	if m.cfg.WorkingDirectory != workDir || m.cfg.Port == port {
+		t.Error("validate must not modify the running config")
+	}
+	entries, _ := os.ReadDir(other)
+	if len(entries) != 0 {
+		t.Errorf("validate must not write to the candidate directory, found %d entries", len(entries))
+	}
+}
+
+func TestConfigValidate_MissingDirectory(t *testing.T) {
+	m := newConfigMock(t.TempDir())
+	svc := NewConfigService(m, m.log)
+
+	missing := filepath.Join(t.TempDir(), "does-not-exist")
+	report := svc.Validate(&ConfigValidateRequest{WorkingDirectory: &missing})
+
+	if report.Valid {
+		t.Fatal("expected missing directory to be invalid")
+	}
+	if c := findCheck(report, "working_directory", "working_directory"); c == nil || c.Status != constants.ConfigCheckError {
+		t.Errorf("expected working_directory error, got %+v", c)
+	}
+	if c := findCheck(report, "disk_space", ""); c == nil || c.Status != constants.ConfigCheckSkipped {
+		t.Errorf("expected disk check to be skipped, got %+v", c)
+	}
+}
+
+func TestConfigValidate_ReadOnlyDirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission bits are not enforced for root")
+	}
+	m := newConfigMock(t.TempDir())
+	svc := NewConfigService(m, m.log)
+
+	readOnly := t.TempDir()
+	if err := os.Chmod(readOnly, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(readOnly, 0755) })
+
+	report := svc.Validate(&ConfigValidateRequest{WorkingDirectory: &readOnly})
+	if c := findCheck(report, "writable", "working_directory"); c == nil || c.Status != constants.ConfigCheckError {
+		t.Errorf("expected writable error, got %+v", c)
+	}
+}
+
+func TestConfigValidate_RangeErrors(t *testing.T) {
+	m := newConfigMock(t.TempDir())
+	svc := NewConfigService(m, m.log)
+
+	audit := m.cfg.Audit
+	audit.PurgePercentage = 150
+	report := svc.Validate(&ConfigValidateRequest{Audit: &audit})
+
+	if report.Valid {
+		t.Fatal("expected out-of-range value to be invalid")
+	}
+	if c := findCheck(report, "value_ranges", "audit.purge_percentage"); c == nil || c.Status != constants.ConfigCheckError {
+		t.Errorf("expected purge_percentage error, got %+v", c)
+	}
+	if len(report.RestartFields) != 1 || report.RestartFields[0] != "audit" {
+		t.Errorf("expected audit to require restart, got %v", report.RestartFields)
+	}
+}
+
+func TestConfigValidate_DiskLimitAlreadyExceeded(t *testing.T) {
+	m := newConfigMock(t.TempDir())
+	svc := NewConfigService(m, m.log)
+
+	// A limit equal to current usage is always exceeded (usage >= limit)
+	used, err := GetDiskUsageBytes(m.cfg.WorkingDirectory)
+	if err != nil || used < uint64(constants.MinMaxDiskUsageBytes) {
+		t.Skip("filesystem too small to exercise the disk limit")
+	}
+	limit := int64(used)
+	report := svc.Validate(&ConfigValidateRequest{MaxDiskUsage: &limit})
+
+	if c := findCheck(report, "disk_limit", "max_disk_usage"); c == nil || c.Status != constants.ConfigCheckError {
+		t.Errorf("expected disk_limit error, got %+v", c)
+	}
+}
+
+func TestConfigValidate_PortInUse(t *testing.T) {
+	m := newConfigMock(t.TempDir())
+	svc := NewConfigService(m, m.log)
+
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	busy := ln.Addr().(*net.TCPAddr).Port
+
+	report := svc.Validate(&ConfigValidateRequest{Port: &busy})
+	if c := findCheck(report, "port", "port"); c == nil || c.Status != constants.ConfigCheckError {
+		t.Errorf("expected port error, got %+v", c)
+	}
+	if !report.RequiresRestart {
+		t.Error("expected port change to require restart")
+	}
+
+	invalid := 70000
+	report = svc.Validate(&ConfigValidateRequest{Port: &invalid})
+	if c := findCheck(report, "port", "port"); c == nil || c.Status != constants.ConfigCheckError {
+		t.Errorf("expected out-of-range port error, got %+v", c)
+	}
+}
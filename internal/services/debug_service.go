@@ -0,0 +1,223 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+	"silobang/internal/version"
+)
+
+// DebugService reads runtime metrics and captures profile bundles for the
+// debug endpoints. The HTTP layer checks debug.enabled before calling it.
+type DebugService struct {
+	app    AppState
+	logger *logger.Logger
+
+	// cpuMu is held while a CPU profile is captured; the runtime allows
+	// only one at a time.
+	cpuMu sync.Mutex
+}
+
+// NewDebugService creates a new debug service.
+func NewDebugService(app AppState, log *logger.Logger) *DebugService {
+	return &DebugService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// DebugInfo describes the running process.
+type DebugInfo struct {
+	Version    string `json:"version"`
+	GoVersion  string `json:"go_version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	NumCPU     int    `json:"num_cpu"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	Goroutines int    `json:"goroutines"`
+	StartedAt  int64  `json:"started_at"`
+	UptimeSecs int64  `json:"uptime_secs"`
+}
+
+// HistogramSummary condenses a runtime histogram. Quantiles are bucket
+// bounds, so they are approximate.
+type HistogramSummary struct {
+	Count uint64  `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+}
+
+// Info returns the process description.
+func (s *DebugService) Info() DebugInfo {
+	started := s.app.GetStartedAt()
+	return DebugInfo{
+		Version:    version.Version,
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+		StartedAt:  started.Unix(),
+		UptimeSecs: int64(time.Since(started).Seconds()),
+	}
+}
+
+// RuntimeMetrics returns every metric the runtime supports, keyed by name.
+// Histograms are returned as a HistogramSummary.
+func (s *DebugService) RuntimeMetrics() map[string]interface{} {
+	descs := metrics.All()
+	samples := make([]metrics.Sample, len(descs))
+	for i, d := range descs {
+		samples[i].Name = d.Name
+	}
+	metrics.Read(samples)
+
+	result := make(map[string]interface{}, len(samples))
+	for _, sample := range samples {
+		switch sample.Value.Kind() {
+		case metrics.KindUint64:
+			result[sample.Name] = sample.Value.Uint64()
+		case metrics.KindFloat64:
+			result[sample.Name] = sample.Value.Float64()
+		case metrics.KindFloat64Histogram:
+			result[sample.Name] = summarizeHistogram(sample.Value.Float64Histogram())
+		}
+	}
+	return result
+}
+
+// summarizeHistogram computes the count and quantiles of h. A quantile
+// falling in an unbounded bucket reports its finite bound instead, as JSON
+// cannot encode infinities.
+func summarizeHistogram(h *metrics.Float64Histogram) HistogramSummary {
+	var summary HistogramSummary
+	for _, c := range h.Counts {
+		summary.Count += c
+	}
+	if summary.Count == 0 {
+		return summary
+	}
+
+	quantile := func(q float64) float64 {
+		target := uint64(math.Ceil(q * float64(summary.Count)))
+		var seen uint64
+		for i, c := range h.Counts {
+			seen += c
+			if seen < target || c == 0 {
+				continue
+			}
+			if upper := h.Buckets[i+1]; !math.IsInf(upper, 0) {
+				return upper
+			}
+			if lower := h.Buckets[i]; !math.IsInf(lower, 0) {
+				return lower
+			}
+			return 0
+		}
+		return 0
+	}
+	summary.P50 = quantile(0.50)
+	summary.P90 = quantile(0.90)
+	summary.P99 = quantile(0.99)
+	return summary
+}
+
+// CaptureCPUProfile records a CPU profile for the given number of seconds,
+// or until ctx is done. Only one profile can be captured at a time.
+func (s *DebugService) CaptureCPUProfile(ctx context.Context, seconds int) ([]byte, error) {
+	if seconds < 1 || seconds > constants.DebugBundleMaxSeconds {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("seconds must be between 1 and %d", constants.DebugBundleMaxSeconds))
+	}
+	if !s.cpuMu.TryLock() {
+		return nil, NewServiceError(constants.ErrCodeProfileInProgress, "a CPU profile is already being captured")
+	}
+	defer s.cpuMu.Unlock()
+
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		// Profiling was started outside this service, e.g. via /pprof/profile
+		return nil, NewServiceError(constants.ErrCodeProfileInProgress, err.Error())
+	}
+
+	timer := time.NewTimer(time.Duration(seconds) * time.Second)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+	}
+	pprof.StopCPUProfile()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteBundle writes a zip of the CPU profile, every runtime profile, the
+// runtime metrics and the process info to w.
+func (s *DebugService) WriteBundle(w io.Writer, cpuProfile []byte) error {
+	zw := zip.NewWriter(w)
+
+	addFile := func(name string, write func(io.Writer) error) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		return write(f)
+	}
+	addJSON := func(name string, v interface{}) error {
+		return addFile(name, func(f io.Writer) error {
+			enc := json.NewEncoder(f)
+			enc.SetIndent("", "  ")
+			return enc.Encode(v)
+		})
+	}
+
+	if err := addFile("cpu.pprof", func(f io.Writer) error {
+		_, err := f.Write(cpuProfile)
+		return err
+	}); err != nil {
+		return err
+	}
+	// The heap profile reflects the last GC; run one so it is current
+	runtime.GC()
+	for _, p := range pprof.Profiles() {
+		if err := addFile(p.Name()+".pprof", func(f io.Writer) error {
+			return p.WriteTo(f, 0)
+		}); err != nil {
+			return err
+		}
+	}
+	if err := addFile("goroutines.txt", func(f io.Writer) error {
+		return pprof.Lookup("goroutine").WriteTo(f, constants.DebugGoroutineDumpDebug)
+	}); err != nil {
+		return err
+	}
+	if err := addJSON("metrics.json", s.RuntimeMetrics()); err != nil {
+		return err
+	}
+	if err := addJSON("info.json", s.Info()); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
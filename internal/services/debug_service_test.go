@@ -0,0 +1,26 @@
+package services
+
+import (
+	"math"
+	"runtime/metrics"
+	"testing"
+)
+
+func TestSummarizeHistogram(t *testing.T) {
+	h := &metrics.Float64Histogram{
+		Counts:  []uint64{10, 80, 9, 1},
+		Buckets: []float64{math.Inf(-1), 1, 2, 4, math.Inf(1)},
+	}
+	got := summarizeHistogram(h)
+	want := HistogramSummary{Count: 100, P50: 2, P90: 2, P99: 4}
+	if got != want {
+		t.Errorf("summarizeHistogram = %+v, want %+v", got, want)
+	}
+
+	if got := summarizeHistogram(&metrics.Float64Histogram{
+		Counts:  []uint64{0, 0},
+		Buckets: []float64{0, 1, math.Inf(1)},
+	}); got != (HistogramSummary{}) {
+		t.Errorf("expected an empty summary, got %+v", got)
+	}
+}
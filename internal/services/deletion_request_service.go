@@ -0,0 +1,631 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// DeletionRequestService runs the two-person deletion workflow. A user
+// allowed to delete in every topic of a set of assets proposes deleting
+// them, by IDs or by query; another user with the same rights approves or
+// rejects the request before deletion_requests.approval_window_hours pass,
+// or it expires. Only an approved request runs the deletion, which deletes
+// each asset permanently as the approver. Every transition is audited and
+// notified to the users concerned.
+type DeletionRequestService struct {
+	app           AppState
+	logger        *logger.Logger
+	bulk          *BulkService
+	assets        *AssetService
+	notifications *NotificationService
+	auth          *AuthService
+	stats         *StatsCache
+
+	mu      sync.Mutex
+	running bool
+	stopCh  chan struct{}
+
+	jobsMu sync.Mutex
+	jobs   map[int64]bool // requests whose deletion is running
+}
+
+// DeletionRequestCreate proposes a set of assets for deletion.
+type DeletionRequestCreate struct {
+	Mode     string                 `json:"mode"`             // "ids" | "query"
+	AssetIDs []string               `json:"asset_ids"`        // for mode="ids"
+	Preset   string                 `json:"preset"`           // for mode="query"
+	Params   map[string]interface{} `json:"params,omitempty"` // for mode="query"
+	Topics   []string               `json:"topics,omitempty"` // for mode="query", optional
+	Reason   string                 `json:"reason"`
+}
+
+// DeletionRequestDetail is a deletion request with its assets.
+type DeletionRequestDetail struct {
+	database.DeletionRequest
+	Assets []database.DeletionRequestAsset `json:"assets"`
+}
+
+// NewDeletionRequestService creates a new deletion request service instance.
+func NewDeletionRequestService(app AppState, log *logger.Logger, bulk *BulkService, assets *AssetService,
+	notifications *NotificationService, authService *AuthService, stats *StatsCache) *DeletionRequestService {
+	return &DeletionRequestService{
+		app:           app,
+		logger:        log,
+		bulk:          bulk,
+		assets:        assets,
+		notifications: notifications,
+		auth:          authService,
+		stats:         stats,
+		stopCh:        make(chan struct{}),
+		jobs:          make(map[int64]bool),
+	}
+}
+
+// Create records a pending request to delete the assets req selects.
+// allowed reports whether the requester may perform an action by hand: they
+// must be allowed to delete in every topic of the set. IDs that match no
+// asset are returned as notFound rather than failing the request.
+func (s *DeletionRequestService) Create(req *DeletionRequestCreate, requester *auth.User, ipAddress string,
+	allowed func(*auth.ActionContext) bool) (created *database.DeletionRequest, notFound []string, err error) {
+	orchDB := s.app.GetOrchestratorDB()
+	cfg := s.app.GetConfig()
+	if orchDB == nil || cfg == nil {
+		return nil, nil, ErrNotConfigured
+	}
+	if len(req.Reason) > constants.DeletionRequestMaxReasonLen {
+		return nil, nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("reason exceeds %d characters", constants.DeletionRequestMaxReasonLen))
+	}
+
+	assets, notFound, err := s.resolve(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(assets) == 0 {
+		return nil, notFound, NewServiceError(constants.ErrCodeInvalidRequest, "no assets to delete")
+	}
+	if len(assets) > constants.DeletionRequestMaxAssets {
+		return nil, nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("a deletion request may hold at most %d assets, got %d", constants.DeletionRequestMaxAssets, len(assets)))
+	}
+
+	topicSet := make(map[string]bool)
+	var totalBytes int64
+	for _, a := range assets {
+		topicSet[a.Topic] = true
+		totalBytes += a.Size
+	}
+	topics := sortedKeys(topicSet)
+	if !allowedOnTopics(allowed, topics) {
+		return nil, nil, NewServiceError(constants.ErrCodeAuthForbidden,
+			"deleting these assets requires manage_topics delete on topics "+fmt.Sprint(topics))
+	}
+
+	now := time.Now()
+	r := &database.DeletionRequest{
+		Status:        constants.DeletionRequestStatusPending,
+		Mode:          req.Mode,
+		Preset:        req.Preset,
+		Reason:        req.Reason,
+		Topics:        topics,
+		AssetCount:    len(assets),
+		TotalBytes:    totalBytes,
+		RequestedBy:   requester.Username,
+		RequestedByID: requester.ID,
+		RequestedAt:   now.Unix(),
+		ExpiresAt:     now.Add(cfg.DeletionRequests.ApprovalWindow()).Unix(),
+	}
+	id, err := database.CreateDeletionRequest(orchDB, r, assets)
+	if err != nil {
+		return nil, nil, WrapInternalError(err)
+	}
+	r.ID = id
+
+	s.logger.Info("Deletion request %d: %s proposed deleting %d asset(s) in %v", id, r.RequestedBy, r.AssetCount, topics)
+	if l := s.app.GetAuditLogger(); l != nil {
+		l.Log(constants.AuditActionDeletionRequested, ipAddress, r.RequestedBy, audit.DeletionRequestedDetails{
+			RequestID:  id,
+			Mode:       r.Mode,
+			Preset:     r.Preset,
+			Topics:     topics,
+			AssetCount: r.AssetCount,
+			Bytes:      r.TotalBytes,
+			Reason:     r.Reason,
+			ExpiresAt:  r.ExpiresAt,
+		})
+	}
+	s.notifyDeciders(r)
+
+	return r, notFound, nil
+}
+
+// resolve returns the distinct assets req selects, and the requested IDs
+// that match no asset. Trashed and quarantined assets are included: they
+// are deleted like any other.
+func (s *DeletionRequestService) resolve(req *DeletionRequestCreate) ([]database.DeletionRequestAsset, []string, error) {
+	var resolved []*ResolvedAsset
+	var err error
+	switch req.Mode {
+	case "ids":
+		if len(req.AssetIDs) > constants.DeletionRequestMaxAssets {
+			return nil, nil, NewServiceError(constants.ErrCodeInvalidRequest,
+				fmt.Sprintf("a deletion request may hold at most %d assets", constants.DeletionRequestMaxAssets))
+		}
+		resolved, err = s.bulk.resolveFromIDs(req.AssetIDs)
+	case "query":
+		if req.Preset == "" {
+			return nil, nil, NewServiceError(constants.ErrCodeInvalidRequest, "preset is required for mode=query")
+		}
+		resolved, err = s.bulk.resolveFromQuery(&BulkResolveRequest{
+			Mode:   req.Mode,
+			Preset: req.Preset,
+			Params: req.Params,
+			Topics: req.Topics,
+		})
+	default:
+		return nil, nil, NewServiceError(constants.ErrCodeInvalidRequest, "mode must be 'ids' or 'query'")
+	}
+	if err != nil {
+		var svcErr *ServiceError
+		if errors.As(err, &svcErr) {
+			return nil, nil, err
+		}
+		return nil, nil, WrapInternalError(err)
+	}
+
+	seen := make(map[string]bool)
+	assets := make([]database.DeletionRequestAsset, 0, len(resolved))
+	for _, r := range resolved {
+		if seen[r.Hash] {
+			continue
+		}
+		seen[r.Hash] = true
+		assets = append(assets, database.DeletionRequestAsset{Hash: r.Hash, Topic: r.Topic, Size: r.Asset.AssetSize})
+	}
+
+	var notFound []string
+	for _, id := range req.AssetIDs {
+		if req.Mode == "ids" && !seen[id] {
+			seen[id] = true
+			notFound = append(notFound, id)
+		}
+	}
+	return assets, notFound, nil
+}
+
+// Get returns a request with its assets. Only its requester and the users
+// who may decide it can see it.
+func (s *DeletionRequestService) Get(id int64, user *auth.User, allowed func(*auth.ActionContext) bool) (*DeletionRequestDetail, error) {
+	r, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if r.RequestedByID != user.ID && !allowedOnTopics(allowed, r.Topics) {
+		return nil, ErrDeletionRequestNotFound(id)
+	}
+	assets, err := database.ListDeletionRequestAssets(s.app.GetOrchestratorDB(), id, "")
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return &DeletionRequestDetail{DeletionRequest: *r, Assets: assets}, nil
+}
+
+// List returns the newest requests the user made or may decide. status
+// filters when not empty.
+func (s *DeletionRequestService) List(status string, user *auth.User, allowed func(*auth.ActionContext) bool) ([]database.DeletionRequest, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+	if status != "" && !slices.Contains(constants.DeletionRequestStatuses, status) {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "unknown status: "+status)
+	}
+	s.expireDue(time.Now())
+
+	requests, err := database.ListDeletionRequests(orchDB, status, constants.DeletionRequestListLimit)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	visible := make([]database.DeletionRequest, 0, len(requests))
+	for _, r := range requests {
+		if r.RequestedByID == user.ID || allowedOnTopics(allowed, r.Topics) {
+			visible = append(visible, r)
+		}
+	}
+	return visible, nil
+}
+
+// Approve approves a pending request and starts deleting its assets. The
+// approver must not be its requester and must be allowed to delete in
+// every topic of the request.
+func (s *DeletionRequestService) Approve(id int64, approver *auth.User, comment, ipAddress string,
+	allowed func(*auth.ActionContext) bool) (*database.DeletionRequest, error) {
+	r, err := s.decide(id, constants.DeletionRequestStatusApproved, approver, comment, ipAddress, allowed)
+	if err != nil {
+		return nil, err
+	}
+	go s.execute(r.ID)
+	return r, nil
+}
+
+// Reject rejects a pending request; its assets are left untouched.
+func (s *DeletionRequestService) Reject(id int64, rejecter *auth.User, comment, ipAddress string,
+	allowed func(*auth.ActionContext) bool) (*database.DeletionRequest, error) {
+	return s.decide(id, constants.DeletionRequestStatusRejected, rejecter, comment, ipAddress, allowed)
+}
+
+// Cancel withdraws a pending request. Only its requester may cancel it.
+func (s *DeletionRequestService) Cancel(id int64, user *auth.User, ipAddress string) (*database.DeletionRequest, error) {
+	r, err := s.loadPending(id)
+	if err != nil {
+		return nil, err
+	}
+	if r.RequestedByID != user.ID {
+		return nil, NewServiceError(constants.ErrCodeAuthForbidden, "only the requester can cancel a deletion request")
+	}
+	if err := s.transition(r, constants.DeletionRequestStatusCancelled, user, "", time.Now()); err != nil {
+		return nil, err
+	}
+	s.logger.Info("Deletion request %d: cancelled by %s", id, user.Username)
+	s.auditDecision(constants.AuditActionDeletionCancelled, r, ipAddress, user.Username)
+	return r, nil
+}
+
+// decide moves a pending request to approved or rejected, then audits the
+// decision and notifies the requester.
+func (s *DeletionRequestService) decide(id int64, status string, decider *auth.User, comment, ipAddress string,
+	allowed func(*auth.ActionContext) bool) (*database.DeletionRequest, error) {
+	if len(comment) > constants.DeletionRequestMaxReasonLen {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("comment exceeds %d characters", constants.DeletionRequestMaxReasonLen))
+	}
+	r, err := s.loadPending(id)
+	if err != nil {
+		return nil, err
+	}
+	if r.RequestedByID == decider.ID {
+		return nil, NewServiceError(constants.ErrCodeDeletionSelfApproval,
+			"a deletion request must be decided by someone other than its requester")
+	}
+	if !allowedOnTopics(allowed, r.Topics) {
+		return nil, NewServiceError(constants.ErrCodeAuthForbidden,
+			"deciding this request requires manage_topics delete on topics "+fmt.Sprint(r.Topics))
+	}
+	if err := s.transition(r, status, decider, comment, time.Now()); err != nil {
+		return nil, err
+	}
+
+	action, event := constants.AuditActionDeletionApproved, constants.NotificationEventDeletionApproved
+	if status == constants.DeletionRequestStatusRejected {
+		action, event = constants.AuditActionDeletionRejected, constants.NotificationEventDeletionRejected
+	}
+	s.logger.Info("Deletion request %d: %s by %s", id, status, decider.Username)
+	s.auditDecision(action, r, ipAddress, decider.Username)
+	s.notify(r.RequestedByID, event, decider.Username, r)
+	return r, nil
+}
+
+// transition records a decision on r and updates it in place. A request
+// decided concurrently yields DELETION_REQUEST_NOT_PENDING.
+func (s *DeletionRequestService) transition(r *database.DeletionRequest, status string, by *auth.User, comment string, now time.Time) error {
+	var byName string
+	var byID int64
+	if by != nil {
+		byName, byID = by.Username, by.ID
+	}
+	err := database.DecideDeletionRequest(s.app.GetOrchestratorDB(), r.ID, status, byName, byID, comment, now.Unix())
+	if errors.Is(err, database.ErrDeletionRequestNotPending) {
+		return errDeletionRequestNotPending(r.ID, "")
+	}
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	r.Status, r.DecidedBy, r.DecidedByID, r.DecidedAt, r.Comment = status, byName, byID, now.Unix(), comment
+	return nil
+}
+
+// load returns a request or DELETION_REQUEST_NOT_FOUND.
+func (s *DeletionRequestService) load(id int64) (*database.DeletionRequest, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+	r, err := database.GetDeletionRequest(orchDB, id)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if r == nil {
+		return nil, ErrDeletionRequestNotFound(id)
+	}
+	return r, nil
+}
+
+// loadPending returns a request that can still be decided. A pending
+// request past its window is expired first, so it cannot be approved late
+// while the sweep has yet to run.
+func (s *DeletionRequestService) loadPending(id int64) (*database.DeletionRequest, error) {
+	r, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if r.Status == constants.DeletionRequestStatusPending && now.Unix() >= r.ExpiresAt {
+		s.expire(r, now)
+	}
+	if r.Status != constants.DeletionRequestStatusPending {
+		return nil, errDeletionRequestNotPending(id, r.Status)
+	}
+	return r, nil
+}
+
+// Sweep expires the pending requests past their window and resumes the
+// deletion of approved requests a restart interrupted.
+func (s *DeletionRequestService) Sweep(now time.Time) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return
+	}
+	s.expireDue(now)
+
+	approved, err := database.ListDeletionRequests(orchDB, constants.DeletionRequestStatusApproved, constants.DeletionRequestListLimit)
+	if err != nil {
+		s.logger.Error("Deletion requests: failed to list approved requests: %v", err)
+		return
+	}
+	for _, r := range approved {
+		s.execute(r.ID)
+	}
+}
+
+// expireDue expires every pending request past its window.
+func (s *DeletionRequestService) expireDue(now time.Time) {
+	expired, err := database.ListExpiredDeletionRequests(s.app.GetOrchestratorDB(), now.Unix())
+	if err != nil {
+		s.logger.Error("Deletion requests: %v", err)
+		return
+	}
+	for i := range expired {
+		s.expire(&expired[i], now)
+	}
+}
+
+// expire moves a pending request past its window to expired, then audits it
+// as the system and notifies its requester. A request decided meanwhile is
+// reloaded instead.
+func (s *DeletionRequestService) expire(r *database.DeletionRequest, now time.Time) {
+	if err := s.transition(r, constants.DeletionRequestStatusExpired, nil, "", now); err != nil {
+		if current, loadErr := s.load(r.ID); loadErr == nil {
+			*r = *current
+		}
+		return
+	}
+	s.logger.Info("Deletion request %d: expired undecided", r.ID)
+	s.auditDecision(constants.AuditActionDeletionExpired, r, "", "")
+	s.notify(r.RequestedByID, constants.NotificationEventDeletionExpired, constants.AuditActorSystem, r)
+}
+
+// execute deletes the assets of an approved request that are still
+// pending, as its approver, then marks it completed. Assets that cannot be
+// deleted, such as ones that became referenced, are recorded as failed.
+// Assets deleted meanwhile by other means count as deleted.
+func (s *DeletionRequestService) execute(id int64) {
+	s.jobsMu.Lock()
+	if s.jobs[id] {
+		s.jobsMu.Unlock()
+		return
+	}
+	s.jobs[id] = true
+	s.jobsMu.Unlock()
+	defer func() {
+		s.jobsMu.Lock()
+		delete(s.jobs, id)
+		s.jobsMu.Unlock()
+	}()
+
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return
+	}
+	r, err := database.GetDeletionRequest(orchDB, id)
+	if err != nil || r == nil || r.Status != constants.DeletionRequestStatusApproved {
+		return
+	}
+	pending, err := database.ListDeletionRequestAssets(orchDB, id, constants.DeletionAssetStatusPending)
+	if err != nil {
+		s.logger.Error("Deletion request %d: %v", id, err)
+		return
+	}
+
+	touched := make(map[string]bool)
+	for _, a := range pending {
+		status, errMsg := constants.DeletionAssetStatusDeleted, ""
+		if _, err := s.assets.Delete(a.Hash, r.DecidedBy, ""); err != nil && !isAssetNotFound(err) {
+			status, errMsg = constants.DeletionAssetStatusFailed, err.Error()
+			s.logger.Warn("Deletion request %d: failed to delete %s: %v", id, a.Hash, err)
+		} else {
+			touched[a.Topic] = true
+		}
+		if err := database.SetDeletionAssetResult(orchDB, id, a.Hash, status, errMsg); err != nil {
+			s.logger.Error("Deletion request %d: %v", id, err)
+			return
+		}
+	}
+	if s.stats != nil && len(touched) > 0 {
+		s.stats.InvalidateTopics(sortedKeys(touched))
+	}
+
+	completed, err := database.CompleteDeletionRequest(orchDB, id, time.Now().Unix())
+	if err != nil || completed == nil {
+		s.logger.Error("Deletion request %d: failed to complete: %v", id, err)
+		return
+	}
+	assets, err := database.ListDeletionRequestAssets(orchDB, id, constants.DeletionAssetStatusDeleted)
+	if err != nil {
+		s.logger.Error("Deletion request %d: %v", id, err)
+	}
+	var bytes int64
+	for _, a := range assets {
+		bytes += a.Size
+	}
+
+	s.logger.Info("Deletion request %d: completed, %d deleted, %d failed", id, completed.Deleted, completed.Failed)
+	if l := s.app.GetAuditLogger(); l != nil {
+		l.Log(constants.AuditActionDeletionCompleted, "", completed.DecidedBy, audit.DeletionCompletedDetails{
+			RequestID:  id,
+			ApprovedBy: completed.DecidedBy,
+			Deleted:    completed.Deleted,
+			Failed:     completed.Failed,
+			Bytes:      bytes,
+		})
+	}
+	s.notify(completed.RequestedByID, constants.NotificationEventDeletionCompleted, completed.DecidedBy, completed)
+	s.notify(completed.DecidedByID, constants.NotificationEventDeletionCompleted, completed.DecidedBy, completed)
+}
+
+// Start launches the periodic expiry of undecided requests, and resumes
+// interrupted deletions right away.
+func (s *DeletionRequestService) Start(interval time.Duration) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info("[deletion-requests] sweep started (interval: %v)", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		s.Sweep(time.Now())
+		for {
+			select {
+			case <-s.stopCh:
+				s.logger.Info("[deletion-requests] sweep stopped")
+				return
+			case now := <-ticker.C:
+				s.Sweep(now)
+			}
+		}
+	}()
+}
+
+// Stop signals the sweep goroutine to exit.
+func (s *DeletionRequestService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stopCh)
+		s.running = false
+	}
+}
+
+// auditDecision records a transition of r made by username, or by the
+// system when empty.
+func (s *DeletionRequestService) auditDecision(action string, r *database.DeletionRequest, ipAddress, username string) {
+	if l := s.app.GetAuditLogger(); l != nil {
+		l.Log(action, ipAddress, username, audit.DeletionDecidedDetails{
+			RequestID:   r.ID,
+			RequestedBy: r.RequestedBy,
+			Topics:      r.Topics,
+			AssetCount:  r.AssetCount,
+			Comment:     r.Comment,
+		})
+	}
+}
+
+// notify sends one user a deletion request notification.
+func (s *DeletionRequestService) notify(userID int64, event, actor string, r *database.DeletionRequest) {
+	if s.notifications == nil || userID == 0 {
+		return
+	}
+	details := map[string]interface{}{
+		"request_id":   r.ID,
+		"status":       r.Status,
+		"requested_by": r.RequestedBy,
+		"topics":       r.Topics,
+		"asset_count":  r.AssetCount,
+	}
+	if r.Comment != "" {
+		details["comment"] = r.Comment
+	}
+	if r.Status == constants.DeletionRequestStatusCompleted {
+		details["deleted"] = r.Deleted
+		details["failed"] = r.Failed
+	}
+	s.notifications.Notify(userID, event, actor, details)
+}
+
+// notifyDeciders notifies the active users other than the requester who may
+// decide r.
+func (s *DeletionRequestService) notifyDeciders(r *database.DeletionRequest) {
+	if s.notifications == nil || s.auth == nil {
+		return
+	}
+	store := s.auth.GetStore()
+	users, err := store.ListUsers()
+	if err != nil {
+		s.logger.Warn("Deletion request %d: failed to list users to notify: %v", r.ID, err)
+		return
+	}
+	for i := range users {
+		user := &users[i]
+		if user.ID == r.RequestedByID || !user.IsActive {
+			continue
+		}
+		grants, err := store.GetActiveGrantsForUser(user.ID)
+		if err != nil {
+			s.logger.Warn("Deletion request %d: failed to load grants of user_id=%d: %v", r.ID, user.ID, err)
+			continue
+		}
+		identity := &auth.Identity{User: user, Grants: grants}
+		allowed := func(ctx *auth.ActionContext) bool {
+			return s.auth.GetEvaluator().Evaluate(identity, ctx).Allowed
+		}
+		if allowedOnTopics(allowed, r.Topics) {
+			s.notify(user.ID, constants.NotificationEventDeletionRequested, r.RequestedBy, r)
+		}
+	}
+}
+
+// allowedOnTopics reports whether allowed permits deleting in every topic.
+func allowedOnTopics(allowed func(*auth.ActionContext) bool, topics []string) bool {
+	for _, topic := range topics {
+		if !allowed(&auth.ActionContext{
+			Action:    constants.AuthActionManageTopics,
+			SubAction: "delete",
+			TopicName: topic,
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// errDeletionRequestNotPending reports a decision on a request that can no
+// longer be decided.
+func errDeletionRequestNotPending(id int64, status string) *ServiceError {
+	msg := fmt.Sprintf("deletion request %d is no longer pending", id)
+	if status != "" {
+		msg = fmt.Sprintf("deletion request %d is %s", id, status)
+	}
+	return NewServiceError(constants.ErrCodeDeletionRequestNotPending, msg)
+}
+
+// isAssetNotFound reports whether err is ASSET_NOT_FOUND.
+func isAssetNotFound(err error) bool {
+	var svcErr *ServiceError
+	return errors.As(err, &svcErr) && svcErr.Code == constants.ErrCodeAssetNotFound
+}
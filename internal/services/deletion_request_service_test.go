@@ -0,0 +1,100 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+)
+
+func TestDeletionRequestService_StateMachine(t *testing.T) {
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+
+	hash := strings.Repeat("a", constants.HashLength)
+	topicDB := setupTopicDir(t, workDir, "photos", []testAsset{
+		{id: hash, size: 10, ext: "bin", blobName: "001.dat", createdAt: 1700000000},
+	})
+	if _, err := topicDB.Exec("UPDATE assets SET origin_name = 'photo'"); err != nil {
+		t.Fatalf("failed to name assets: %v", err)
+	}
+	mock.StoreTopicDB("photos", topicDB)
+	mock.RegisterTopic("photos", true, "")
+	mock.orchestratorDB = setupOrchestratorDB(t, workDir, []orchestratorEntry{
+		{hash: hash, topic: "photos", datFile: "001.dat"},
+	})
+
+	svc := NewDeletionRequestService(mock, mock.log, NewBulkService(mock, mock.log), NewAssetService(mock, mock.log), nil, nil, nil)
+	alice := &auth.User{ID: 1, Username: "alice", IsActive: true}
+	bob := &auth.User{ID: 2, Username: "bob", IsActive: true}
+	allow := func(*auth.ActionContext) bool { return true }
+	deny := func(*auth.ActionContext) bool { return false }
+	create := func() *database.DeletionRequest {
+		t.Helper()
+		r, notFound, err := svc.Create(&DeletionRequestCreate{Mode: "ids", AssetIDs: []string{hash}}, alice, "", allow)
+		if err != nil || len(notFound) != 0 {
+			t.Fatalf("Create failed: %v, not found %v", err, notFound)
+		}
+		return r
+	}
+
+	if _, _, err := svc.Create(&DeletionRequestCreate{Mode: "ids", AssetIDs: []string{hash}}, alice, "", deny); !isServiceErrorCode(err, constants.ErrCodeAuthForbidden) {
+		t.Errorf("expected AUTH_FORBIDDEN without delete rights, got %v", err)
+	}
+	if _, _, err := svc.Create(&DeletionRequestCreate{Mode: "everything"}, alice, "", allow); !isServiceErrorCode(err, constants.ErrCodeInvalidRequest) {
+		t.Errorf("expected INVALID_REQUEST for an unknown mode, got %v", err)
+	}
+
+	r := create()
+	if r.AssetCount != 1 || r.TotalBytes != 10 || len(r.Topics) != 1 || r.Topics[0] != "photos" {
+		t.Errorf("unexpected request: %+v", r)
+	}
+	wantExpiry := r.RequestedAt + int64(constants.DeletionRequestDefaultWindowHours*60*60)
+	if r.ExpiresAt != wantExpiry {
+		t.Errorf("expected expiry %d, got %d", wantExpiry, r.ExpiresAt)
+	}
+	if _, err := svc.Approve(r.ID, alice, "", "", allow); !isServiceErrorCode(err, constants.ErrCodeDeletionSelfApproval) {
+		t.Errorf("expected DELETION_SELF_APPROVAL, got %v", err)
+	}
+	if _, err := svc.Reject(r.ID, bob, "", "", deny); !isServiceErrorCode(err, constants.ErrCodeAuthForbidden) {
+		t.Errorf("expected AUTH_FORBIDDEN for a decider without delete rights, got %v", err)
+	}
+	if _, err := svc.Cancel(r.ID, bob, ""); !isServiceErrorCode(err, constants.ErrCodeAuthForbidden) {
+		t.Errorf("expected AUTH_FORBIDDEN when cancelling another user's request, got %v", err)
+	}
+	if _, err := svc.Get(r.ID, bob, deny); !isServiceErrorCode(err, constants.ErrCodeDeletionRequestNotFound) {
+		t.Errorf("expected the request hidden from outsiders, got %v", err)
+	}
+	rejected, err := svc.Reject(r.ID, bob, "keep it", "", allow)
+	if err != nil || rejected.Status != constants.DeletionRequestStatusRejected || rejected.DecidedBy != "bob" {
+		t.Fatalf("Reject: unexpected %+v, %v", rejected, err)
+	}
+	if _, err := svc.Cancel(r.ID, alice, ""); !isServiceErrorCode(err, constants.ErrCodeDeletionRequestNotPending) {
+		t.Errorf("expected DELETION_REQUEST_NOT_PENDING after rejection, got %v", err)
+	}
+
+	// The sweep expires requests past their window
+	expiring := create()
+	svc.Sweep(time.Unix(expiring.ExpiresAt, 0))
+	if got, err := svc.Get(expiring.ID, alice, deny); err != nil || got.Status != constants.DeletionRequestStatusExpired {
+		t.Errorf("expected an expired request, got %+v, %v", got, err)
+	}
+	if _, err := svc.Approve(expiring.ID, bob, "", "", allow); !isServiceErrorCode(err, constants.ErrCodeDeletionRequestNotPending) {
+		t.Errorf("expected DELETION_REQUEST_NOT_PENDING after expiry, got %v", err)
+	}
+
+	list, err := svc.List("", bob, deny)
+	if err != nil || len(list) != 0 {
+		t.Errorf("expected no requests visible to an outsider, got %d, %v", len(list), err)
+	}
+	if list, err := svc.List(constants.DeletionRequestStatusExpired, alice, deny); err != nil || len(list) != 1 {
+		t.Errorf("expected the requester to see the expired request, got %+v, %v", list, err)
+	}
+	if assets, err := database.ListDeletionRequestAssets(mock.orchestratorDB, r.ID, ""); err != nil || len(assets) != 1 ||
+		assets[0].Status != constants.DeletionAssetStatusPending {
+		t.Errorf("rejected request should delete nothing, got %+v, %v", assets, err)
+	}
+}
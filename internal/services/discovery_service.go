@@ -0,0 +1,191 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// DiscoveredAsset is an asset ranked by downloads.
+type DiscoveredAsset struct {
+	audit.DownloadCount
+	OriginName string `json:"origin_name"`
+	Extension  string `json:"extension"`
+	AssetSize  int64  `json:"asset_size"`
+}
+
+// PopularAssets lists the most downloaded assets of each topic.
+type PopularAssets struct {
+	Days   int                          `json:"days"`
+	Since  int64                        `json:"since"`
+	Topics map[string][]DiscoveredAsset `json:"topics"`
+}
+
+// RelatedAssets lists the assets to look at next from an asset.
+type RelatedAssets struct {
+	Hash               string                  `json:"hash"`
+	Topic              string                  `json:"topic"`
+	DownloadedTogether []DiscoveredAsset       `json:"downloaded_together"`
+	Similar            []database.SimilarAsset `json:"similar"` // same topic, by shared metadata values
+}
+
+// DiscoveryService ranks assets by downloads and metadata overlap so users
+// find existing assets before creating duplicates. Downloads are counted
+// from the audit log, so they only reach back as far as it does. Results
+// are cached by the stats cache.
+type DiscoveryService struct {
+	app        AppState
+	logger     *logger.Logger
+	statsCache *StatsCache
+}
+
+// NewDiscoveryService creates a new DiscoveryService instance.
+func NewDiscoveryService(app AppState, log *logger.Logger, statsCache *StatsCache) *DiscoveryService {
+	return &DiscoveryService{
+		app:        app,
+		logger:     log,
+		statsCache: statsCache,
+	}
+}
+
+// Popular returns the limit most downloaded assets of each topic over the
+// last days, keeping the topics visible accepts.
+func (s *DiscoveryService) Popular(days, limit int, visible func(topic string) bool) (*PopularAssets, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, NewServiceError(constants.ErrCodeNotConfigured, "orchestrator database not available")
+	}
+
+	value, err := s.statsCache.Discovery(fmt.Sprintf("popular/%d/%d", days, limit), func() (interface{}, []string, error) {
+		since := time.Now().Unix() - int64(days)*86400
+		counts, err := audit.TopDownloads(orchDB, since, limit)
+		if err != nil {
+			return nil, nil, WrapInternalError(err)
+		}
+		popular := &PopularAssets{Days: days, Since: since, Topics: make(map[string][]DiscoveredAsset, len(counts))}
+		topics := make([]string, 0, len(counts))
+		for topic, topicCounts := range counts {
+			assets := s.describe(topic, topicCounts)
+			if len(assets) > 0 {
+				popular.Topics[topic] = assets
+				topics = append(topics, topic)
+			}
+		}
+		return popular, topics, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cached := value.(*PopularAssets)
+	popular := &PopularAssets{Days: cached.Days, Since: cached.Since, Topics: make(map[string][]DiscoveredAsset, len(cached.Topics))}
+	for topic, assets := range cached.Topics {
+		if visible(topic) {
+			popular.Topics[topic] = assets
+		}
+	}
+	return popular, nil
+}
+
+// Related returns the assets downloaded together with hash, stored in
+// topics visible accepts, and the assets of its topic sharing the most
+// metadata values with it.
+func (s *DiscoveryService) Related(hash, topic string, limit int, visible func(topic string) bool) (*RelatedAssets, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, NewServiceError(constants.ErrCodeNotConfigured, "orchestrator database not available")
+	}
+
+	value, err := s.statsCache.Discovery(fmt.Sprintf("related/%s/%d", hash, limit), func() (interface{}, []string, error) {
+		topicDB, err := s.app.GetTopicDB(topic)
+		if err != nil {
+			return nil, nil, WrapServiceError(constants.ErrCodeTopicUnhealthy, err.Error(), err)
+		}
+		similar, err := database.FindSimilarByMetadata(topicDB, hash, limit)
+		if err != nil {
+			return nil, nil, WrapInternalError(err)
+		}
+		counts, err := audit.CoDownloads(orchDB, hash, constants.DiscoveryCoDownloadWindowSecs, limit)
+		if err != nil {
+			return nil, nil, WrapInternalError(err)
+		}
+
+		related := &RelatedAssets{Hash: hash, Topic: topic, DownloadedTogether: []DiscoveredAsset{}, Similar: similar}
+		topics := []string{topic}
+		byTopic := make(map[string][]audit.DownloadCount)
+		for _, c := range counts {
+			byTopic[c.Topic] = append(byTopic[c.Topic], c)
+		}
+		described := make(map[string]DiscoveredAsset, len(counts))
+		for t, topicCounts := range byTopic {
+			for _, asset := range s.describe(t, topicCounts) {
+				described[asset.Hash] = asset
+			}
+			if t != topic {
+				topics = append(topics, t)
+			}
+		}
+		// Keep the ranking of the audit query
+		for _, c := range counts {
+			if asset, ok := described[c.Hash]; ok {
+				related.DownloadedTogether = append(related.DownloadedTogether, asset)
+			}
+		}
+		return related, topics, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cached := value.(*RelatedAssets)
+	related := *cached
+	related.DownloadedTogether = make([]DiscoveredAsset, 0, len(cached.DownloadedTogether))
+	for _, asset := range cached.DownloadedTogether {
+		if visible(asset.Topic) {
+			related.DownloadedTogether = append(related.DownloadedTogether, asset)
+		}
+	}
+	return &related, nil
+}
+
+// describe adds the name, extension and size of counted assets, dropping
+// those no longer stored in the topic.
+func (s *DiscoveryService) describe(topic string, counts []audit.DownloadCount) []DiscoveredAsset {
+	if healthy, _ := s.app.IsTopicHealthy(topic); !healthy {
+		return nil
+	}
+	topicDB, err := s.app.GetTopicDB(topic)
+	if err != nil {
+		s.logger.Warn("Discovery: failed to open topic %s: %v", topic, err)
+		return nil
+	}
+
+	hashes := make([]string, len(counts))
+	for i, c := range counts {
+		hashes[i] = c.Hash
+	}
+	stored, err := database.GetAssetsByIDs(topicDB, hashes)
+	if err != nil {
+		s.logger.Warn("Discovery: failed to read assets of topic %s: %v", topic, err)
+		return nil
+	}
+
+	assets := make([]DiscoveredAsset, 0, len(counts))
+	for _, c := range counts {
+		asset, ok := stored[c.Hash]
+		if !ok {
+			continue
+		}
+		assets = append(assets, DiscoveredAsset{
+			DownloadCount: c,
+			OriginName:    asset.OriginName,
+			Extension:     asset.Extension,
+			AssetSize:     asset.AssetSize,
+		})
+	}
+	return assets
+}
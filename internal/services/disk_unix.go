@@ -15,3 +15,13 @@ func GetDiskUsageBytes(path string) (uint64, error) {
 	free := stat.Bfree * uint64(stat.Bsize)
 	return total - free, nil
 }
+
+// GetDiskFreeBytes returns the number of bytes available to unprivileged users
+// on the filesystem containing the given path.
+func GetDiskFreeBytes(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
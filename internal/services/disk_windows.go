@@ -31,3 +31,28 @@ func GetDiskUsageBytes(path string) (uint64, error) {
 
 	return totalBytes - totalFreeBytes, nil
 }
+
+// GetDiskFreeBytes returns the number of bytes available to the calling user
+// on the filesystem containing the given path.
+func GetDiskFreeBytes(path string) (uint64, error) {
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	getDiskFreeSpaceEx := kernel32.NewProc("GetDiskFreeSpaceExW")
+
+	var freeBytesAvailable, totalBytes, totalFreeBytes uint64
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	r1, _, err := getDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(unsafe.Pointer(&freeBytesAvailable)),
+		uintptr(unsafe.Pointer(&totalBytes)),
+		uintptr(unsafe.Pointer(&totalFreeBytes)),
+	)
+	if r1 == 0 {
+		return 0, err
+	}
+
+	return freeBytesAvailable, nil
+}
@@ -137,6 +137,72 @@ func ErrAssetNotFoundWithHash(hash string) *ServiceError {
 	}
 }
 
+func ErrAssetQuarantinedWithReason(hash, source, reason string) *ServiceError {
+	return &ServiceError{
+		Code:    constants.ErrCodeAssetQuarantined,
+		Message: fmt.Sprintf("asset %s is quarantined (%s): %s", hash, source, reason),
+	}
+}
+
+// Deletion request errors with context
+func ErrDeletionRequestNotFound(id int64) *ServiceError {
+	return &ServiceError{
+		Code:    constants.ErrCodeDeletionRequestNotFound,
+		Message: fmt.Sprintf("deletion request not found: %d", id),
+	}
+}
+
+// Archive tier errors with context
+func ErrRetrievalRequiredForHash(hash string) *ServiceError {
+	return &ServiceError{
+		Code:    constants.ErrCodeRetrievalRequired,
+		Message: fmt.Sprintf("asset %s is archived; request a recall before downloading it", hash),
+	}
+}
+
+func ErrAssetNotArchivedWithHash(hash string) *ServiceError {
+	return &ServiceError{
+		Code:    constants.ErrCodeAssetNotArchived,
+		Message: fmt.Sprintf("asset is not archived: %s", hash),
+	}
+}
+
+func ErrArchivePolicyNotFoundForTopic(topic string) *ServiceError {
+	return &ServiceError{
+		Code:    constants.ErrCodeArchivePolicyNotFound,
+		Message: fmt.Sprintf("no archive policy for topic: %s", topic),
+	}
+}
+
+func ErrAssetTrashedWithHash(hash string) *ServiceError {
+	return &ServiceError{
+		Code:    constants.ErrCodeAssetTrashed,
+		Message: fmt.Sprintf("asset %s is in the trash", hash),
+	}
+}
+
+func ErrAssetNotTrashedWithHash(hash string) *ServiceError {
+	return &ServiceError{
+		Code:    constants.ErrCodeAssetNotTrashed,
+		Message: fmt.Sprintf("asset is not in the trash: %s", hash),
+	}
+}
+
+func ErrRetentionPolicyNotFoundForTopic(topic string) *ServiceError {
+	return &ServiceError{
+		Code:    constants.ErrCodeRetentionPolicyNotFound,
+		Message: fmt.Sprintf("no retention policy for topic: %s", topic),
+	}
+}
+
+// Collection errors with context
+func ErrCollectionNotFoundWithName(name string) *ServiceError {
+	return &ServiceError{
+		Code:    constants.ErrCodeCollectionNotFound,
+		Message: fmt.Sprintf("collection not found: %s", name),
+	}
+}
+
 // Query errors with context
 func ErrPresetNotFoundWithName(name string) *ServiceError {
 	return &ServiceError{
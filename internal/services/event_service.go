@@ -0,0 +1,160 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+// eventTypesByAction maps the audit actions recording storage changes to
+// the event type they are delivered as.
+var eventTypesByAction = map[string]string{
+	constants.AuditActionAddingFile:     constants.EventTypeAssetCreated,
+	constants.AuditActionAssetTrashed:   constants.EventTypeAssetTrashed,
+	constants.AuditActionAssetRestored:  constants.EventTypeAssetRestored,
+	constants.AuditActionAssetDeleted:   constants.EventTypeAssetDeleted,
+	constants.AuditActionAddingTopic:    constants.EventTypeTopicCreated,
+	constants.AuditActionMetadataSet:    constants.EventTypeMetadataChanged,
+	constants.AuditActionMetadataBatch:  constants.EventTypeMetadataChanged,
+	constants.AuditActionMetadataApply:  constants.EventTypeMetadataChanged,
+	constants.AuditActionMetadataImport: constants.EventTypeMetadataChanged,
+	constants.AuditActionUserCreated:    constants.EventTypeUserCreated,
+	constants.AuditActionUserUpdated:    constants.EventTypeUserUpdated,
+}
+
+// StorageEvent is a typed change event derived from an audit entry.
+type StorageEvent struct {
+	ID        int64                  `json:"id"` // ID of the audit entry; resume after it with Last-Event-ID
+	Type      string                 `json:"type"`
+	Timestamp int64                  `json:"timestamp"`
+	Actor     string                 `json:"actor"` // username, empty for the server itself
+	Action    string                 `json:"action"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// EventService turns audit entries into the typed events of
+// /api/events/stream and replays the events a client missed.
+type EventService struct {
+	app    AppState
+	logger *logger.Logger
+}
+
+// NewEventService creates a new event service instance.
+func NewEventService(app AppState, log *logger.Logger) *EventService {
+	return &EventService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// EventTypes returns every event type, sorted.
+func (s *EventService) EventTypes() []string {
+	types := make([]string, 0, len(eventTypesByAction))
+	for _, t := range eventTypesByAction {
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	slices.Sort(types)
+	return types
+}
+
+// ParseTypes parses a comma-separated list of event types. An empty list
+// selects every type.
+func (s *EventService) ParseTypes(raw string) ([]string, error) {
+	all := s.EventTypes()
+	if strings.TrimSpace(raw) == "" {
+		return all, nil
+	}
+	var types []string
+	for _, t := range strings.Split(raw, ",") {
+		t = strings.TrimSpace(t)
+		if !slices.Contains(all, t) {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+				fmt.Sprintf("unknown event type %q; valid types: %s", t, strings.Join(all, ", ")))
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+// FromEntry returns the event of entry when it records a storage change of
+// one of types. Uploads of content already stored are not events.
+func (s *EventService) FromEntry(entry audit.Entry, types []string) (StorageEvent, bool) {
+	eventType, ok := eventTypesByAction[entry.Action]
+	if !ok || !slices.Contains(types, eventType) {
+		return StorageEvent{}, false
+	}
+
+	// Live entries carry detail structs, replayed ones decoded JSON; both
+	// are delivered as the same object
+	data := map[string]interface{}{}
+	if entry.Details != nil {
+		raw, err := json.Marshal(entry.Details)
+		if err == nil {
+			err = json.Unmarshal(raw, &data)
+		}
+		if err != nil {
+			s.logger.Warn("[events] unreadable details of audit entry %d: %v", entry.ID, err)
+		}
+	}
+	if entry.Action == constants.AuditActionAddingFile && data["skipped"] == true {
+		return StorageEvent{}, false
+	}
+
+	return StorageEvent{
+		ID:        entry.ID,
+		Type:      eventType,
+		Timestamp: entry.Timestamp,
+		Actor:     entry.Username,
+		Action:    entry.Action,
+		Data:      data,
+	}, true
+}
+
+// Replay returns the events of types recorded after the audit entry
+// afterID, oldest first, and whether more than constants.EventReplayMaxEvents
+// were left out. A non-empty username restricts them to that user's events.
+func (s *EventService) Replay(afterID int64, types []string, username string) ([]StorageEvent, bool, error) {
+	if s.app.GetOrchestratorDB() == nil {
+		return nil, false, ErrNotConfigured
+	}
+	store := s.app.GetAuditStore()
+
+	var actions []string
+	for action, t := range eventTypesByAction {
+		if slices.Contains(types, t) {
+			actions = append(actions, action)
+		}
+	}
+	slices.Sort(actions)
+
+	events := []StorageEvent{}
+	for {
+		entries, err := store.QueryAfter(afterID, actions, username, constants.EventReplayPageSize)
+		if err != nil {
+			return nil, false, WrapInternalError(err)
+		}
+		for _, entry := range entries {
+			afterID = entry.ID
+			event, ok := s.FromEntry(entry, types)
+			if !ok {
+				continue
+			}
+			if len(events) >= constants.EventReplayMaxEvents {
+				return events, true, nil
+			}
+			events = append(events, event)
+		}
+		if len(entries) < constants.EventReplayPageSize {
+			return events, false, nil
+		}
+	}
+}
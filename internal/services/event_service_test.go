@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+func TestEventService_ParseTypes(t *testing.T) {
+	svc := NewEventService(newMockAppState(), logger.NewLogger(logger.LevelError))
+
+	all, err := svc.ParseTypes("")
+	if err != nil || len(all) != len(svc.EventTypes()) {
+		t.Errorf("expected every type by default, got %v, %v", all, err)
+	}
+	types, err := svc.ParseTypes(" asset.created,metadata.changed,asset.created")
+	if err != nil || len(types) != 2 || types[0] != constants.EventTypeAssetCreated || types[1] != constants.EventTypeMetadataChanged {
+		t.Errorf("unexpected types: %v, %v", types, err)
+	}
+	if _, err := svc.ParseTypes("asset.created,asset.exploded"); !isServiceErrorCode(err, constants.ErrCodeInvalidRequest) {
+		t.Errorf("expected INVALID_REQUEST, got %v", err)
+	}
+}
+
+func TestEventService_ReplaysAfterID(t *testing.T) {
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+	mock.orchestratorDB = setupOrchestratorDB(t, workDir, nil)
+	auditLogger := audit.NewLogger(mock.orchestratorDB, constants.AuditMaxLogSizeBytes, constants.AuditPurgePercentage)
+	defer auditLogger.Stop()
+
+	auditLogger.Log(constants.AuditActionAddingTopic, "127.0.0.1", "alice", audit.AddingTopicDetails{TopicName: "photos"})
+	auditLogger.Log(constants.AuditActionQuerying, "127.0.0.1", "alice", audit.QueryingDetails{Preset: "count"})
+	auditLogger.Log(constants.AuditActionAddingFile, "127.0.0.1", "alice", audit.AddingFileDetails{Hash: "h1", TopicName: "photos", Size: 3})
+	auditLogger.Log(constants.AuditActionAddingFile, "127.0.0.1", "alice", audit.AddingFileDetails{Hash: "h1", TopicName: "photos", Skipped: true})
+	auditLogger.Log(constants.AuditActionMetadataSet, "127.0.0.1", "bob", audit.MetadataSetDetails{Hash: "h1", Key: "k"})
+	auditLogger.Flush()
+
+	svc := NewEventService(mock, mock.log)
+	events, truncated, err := svc.Replay(0, svc.EventTypes(), "")
+	if err != nil || truncated {
+		t.Fatalf("Replay failed: %v, truncated=%v", err, truncated)
+	}
+	// The query is not an event and the skipped upload stored nothing
+	want := []string{constants.EventTypeTopicCreated, constants.EventTypeAssetCreated, constants.EventTypeMetadataChanged}
+	if len(events) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, events)
+	}
+	for i, event := range events {
+		if event.Type != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], event.Type)
+		}
+	}
+	if events[1].Data["hash"] != "h1" || events[1].Actor != "alice" {
+		t.Errorf("unexpected asset.created event: %+v", events[1])
+	}
+
+	// Resuming after the upload only replays what came next
+	after, _, err := svc.Replay(events[1].ID, []string{constants.EventTypeAssetCreated, constants.EventTypeMetadataChanged}, "bob")
+	if err != nil || len(after) != 1 || after[0].Type != constants.EventTypeMetadataChanged {
+		t.Errorf("unexpected events after %d: %+v, %v", events[1].ID, after, err)
+	}
+	if none, _, err := svc.Replay(0, []string{constants.EventTypeUserUpdated}, ""); err != nil || len(none) != 0 {
+		t.Errorf("expected no user.updated events, got %+v, %v", none, err)
+	}
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// ExportService manages the per-user export inbox. Bulk downloads directed
+// to the inbox are recorded here, built in the background by the server and
+// kept on disk until the user deletes them or they expire.
+//
+// Exports survive restarts; exports still building when the server stopped
+// are marked failed on startup. A background loop deletes exports past
+// exports.retention_days and notifies their owners.
+type ExportService struct {
+	app           AppState
+	logger        *logger.Logger
+	notifications *NotificationService
+
+	createMu sync.Mutex // serializes inbox quota checks
+	now      func() time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// ExportRequest describes a new inbox export.
+type ExportRequest struct {
+	Mode           string
+	Preset         string
+	Topics         []string
+	AssetCount     int
+	EstimatedBytes int64 // counted against the inbox quota until the ZIP is built
+}
+
+// ExportInbox is a user's list of exports with the inbox limits.
+type ExportInbox struct {
+	Exports       []database.Export `json:"exports"`
+	UsedBytes     int64             `json:"used_bytes"`
+	MaxBytes      int64             `json:"max_bytes"`
+	RetentionDays int               `json:"retention_days"`
+}
+
+// NewExportService creates a new export service, fails exports interrupted
+// by a restart and starts the expiry loop. Owners are notified through
+// notifications, which may be nil. Returns nil if the orchestrator DB is not
+// available.
+func NewExportService(app AppState, log *logger.Logger, notifications *NotificationService) *ExportService {
+	if app.GetOrchestratorDB() == nil {
+		return nil
+	}
+
+	svc := &ExportService{
+		app:           app,
+		logger:        log,
+		notifications: notifications,
+		now:           time.Now,
+		stop:          make(chan struct{}),
+	}
+	svc.failInterrupted()
+
+	go svc.cleanupLoop()
+
+	return svc
+}
+
+// Stop stops the expiry goroutine (call during graceful shutdown).
+func (s *ExportService) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Path returns where the ZIP of an export is stored.
+func (s *ExportService) Path(e *database.Export) string {
+	return filepath.Join(s.app.GetWorkingDirectory(), constants.InternalDir, constants.ExportsDir,
+		strconv.FormatInt(e.UserID, 10), e.ID+".zip")
+}
+
+// Create records a new processing export after checking that its estimated
+// size fits in the user's inbox.
+func (s *ExportService) Create(userID int64, req ExportRequest) (*database.Export, error) {
+	cfg := s.app.GetConfig().Exports
+	db := s.app.GetOrchestratorDB()
+
+	s.createMu.Lock()
+	defer s.createMu.Unlock()
+
+	used, err := database.SumExportBytes(db, userID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if used+req.EstimatedBytes > cfg.MaxInboxBytes {
+		return nil, NewServiceError(constants.ErrCodeExportInboxFull,
+			fmt.Sprintf("export needs %d bytes but only %d of %d bytes are free in the inbox; delete older exports first",
+				req.EstimatedBytes, max(cfg.MaxInboxBytes-used, 0), cfg.MaxInboxBytes))
+	}
+
+	id, err := generateExportID()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	now := s.now()
+	export := &database.Export{
+		ID:         id,
+		UserID:     userID,
+		Status:     constants.ExportStatusProcessing,
+		Mode:       req.Mode,
+		Preset:     req.Preset,
+		Topics:     req.Topics,
+		AssetCount: req.AssetCount,
+		SizeBytes:  req.EstimatedBytes,
+		CreatedAt:  now.Unix(),
+		ExpiresAt:  now.Add(cfg.Retention()).Unix(),
+	}
+	if err := database.InsertExport(db, *export); err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Exports: user_id=%d queued export id=%s assets=%d estimated_bytes=%d", userID, id, req.AssetCount, req.EstimatedBytes)
+	return export, nil
+}
+
+// Complete marks an export ready with the size of its ZIP and notifies the owner.
+func (s *ExportService) Complete(export *database.Export, assetCount, failedAssets int, size int64) {
+	completedAt := s.now().Unix()
+	export.Status = constants.ExportStatusReady
+	export.AssetCount = assetCount
+	export.FailedAssets = failedAssets
+	export.SizeBytes = size
+	export.CompletedAt = &completedAt
+	if err := database.FinishExport(s.app.GetOrchestratorDB(), *export); err != nil {
+		s.logger.Error("Exports: failed to mark export id=%s ready: %v", export.ID, err)
+		return
+	}
+
+	s.logger.Info("Exports: export id=%s ready (assets=%d, size=%d, failed=%d)", export.ID, assetCount, size, failedAssets)
+	s.notify(export, constants.NotificationEventExportReady)
+}
+
+// Fail marks an export failed, removes any partial ZIP and notifies the owner.
+func (s *ExportService) Fail(export *database.Export, message string) {
+	os.Remove(s.Path(export))
+
+	completedAt := s.now().Unix()
+	export.Status = constants.ExportStatusFailed
+	export.SizeBytes = 0
+	export.Error = message
+	export.CompletedAt = &completedAt
+	if err := database.FinishExport(s.app.GetOrchestratorDB(), *export); err != nil {
+		s.logger.Error("Exports: failed to mark export id=%s failed: %v", export.ID, err)
+		return
+	}
+
+	s.logger.Warn("Exports: export id=%s failed: %s", export.ID, message)
+	s.notify(export, constants.NotificationEventExportFailed)
+}
+
+// List returns the user's exports, newest first, with the inbox limits.
+func (s *ExportService) List(userID int64) (*ExportInbox, error) {
+	db := s.app.GetOrchestratorDB()
+	exports, err := database.ListExports(db, userID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	used, err := database.SumExportBytes(db, userID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	cfg := s.app.GetConfig().Exports
+	return &ExportInbox{
+		Exports:       exports,
+		UsedBytes:     used,
+		MaxBytes:      cfg.MaxInboxBytes,
+		RetentionDays: cfg.RetentionDays,
+	}, nil
+}
+
+// Open returns a ready export of the user and its opened ZIP file. The caller
+// closes the file.
+func (s *ExportService) Open(userID int64, id string) (*database.Export, *os.File, error) {
+	export, err := database.GetExport(s.app.GetOrchestratorDB(), userID, id)
+	if err != nil {
+		return nil, nil, WrapInternalError(err)
+	}
+	if export == nil {
+		return nil, nil, NewServiceError(constants.ErrCodeExportNotFound, "export not found: "+id)
+	}
+	if export.Status != constants.ExportStatusReady {
+		return nil, nil, NewServiceError(constants.ErrCodeExportNotReady, fmt.Sprintf("export %s is %s", id, export.Status))
+	}
+
+	f, err := os.Open(s.Path(export))
+	if os.IsNotExist(err) {
+		return nil, nil, NewServiceError(constants.ErrCodeExportNotFound, "export file no longer available: "+id)
+	}
+	if err != nil {
+		return nil, nil, WrapInternalError(err)
+	}
+	return export, f, nil
+}
+
+// Delete removes an export of the user and its ZIP. Exports that are still
+// building cannot be deleted.
+func (s *ExportService) Delete(userID int64, id string) error {
+	db := s.app.GetOrchestratorDB()
+	export, err := database.GetExport(db, userID, id)
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	if export == nil {
+		return NewServiceError(constants.ErrCodeExportNotFound, "export not found: "+id)
+	}
+	if export.Status == constants.ExportStatusProcessing {
+		return NewServiceError(constants.ErrCodeExportNotReady, "export is still being built: "+id)
+	}
+
+	if _, err := database.DeleteExport(db, userID, id); err != nil {
+		return WrapInternalError(err)
+	}
+	if err := os.Remove(s.Path(export)); err != nil && !os.IsNotExist(err) {
+		s.logger.Warn("Exports: failed to remove file of export id=%s: %v", id, err)
+	}
+	s.logger.Info("Exports: user_id=%d deleted export id=%s", userID, id)
+	return nil
+}
+
+// PurgeExpired deletes exports past their expiry and notifies their owners.
+// Exports still building are left until they finish. Returns the number of
+// exports removed.
+func (s *ExportService) PurgeExpired(now time.Time) int {
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return 0 // working directory is being switched
+	}
+	expired, err := database.ListExportsExpiredBefore(db, now.Unix())
+	if err != nil {
+		s.logger.Error("Exports: failed to list expired exports: %v", err)
+		return 0
+	}
+
+	var removed int
+	for i := range expired {
+		export := &expired[i]
+		if export.Status == constants.ExportStatusProcessing {
+			continue
+		}
+		if _, err := database.DeleteExport(db, export.UserID, export.ID); err != nil {
+			s.logger.Error("Exports: failed to delete expired export id=%s: %v", export.ID, err)
+			continue
+		}
+		if err := os.Remove(s.Path(export)); err != nil && !os.IsNotExist(err) {
+			s.logger.Warn("Exports: failed to remove file of export id=%s: %v", export.ID, err)
+		}
+		removed++
+		if export.Status == constants.ExportStatusReady {
+			s.notify(export, constants.NotificationEventExportExpired)
+		}
+	}
+
+	if removed > 0 {
+		s.logger.Info("Exports: retention cleanup removed %d export(s)", removed)
+	}
+	return removed
+}
+
+// cleanupLoop periodically removes expired exports.
+func (s *ExportService) cleanupLoop() {
+	ticker := time.NewTicker(constants.ExportCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			s.logger.Info("Exports: cleanup goroutine stopped")
+			return
+		case now := <-ticker.C:
+			s.PurgeExpired(now)
+		}
+	}
+}
+
+// failInterrupted marks exports left processing by a previous run as failed.
+// Their build goroutine is gone, so they would otherwise never finish.
+func (s *ExportService) failInterrupted() {
+	interrupted, err := database.ListExportsByStatus(s.app.GetOrchestratorDB(), constants.ExportStatusProcessing)
+	if err != nil {
+		s.logger.Error("Exports: failed to list interrupted exports: %v", err)
+		return
+	}
+	for i := range interrupted {
+		s.Fail(&interrupted[i], constants.ExportInterruptedMessage)
+	}
+}
+
+// notify sends an export event to the export's owner.
+func (s *ExportService) notify(export *database.Export, event string) {
+	if s.notifications == nil {
+		return
+	}
+	s.notifications.Notify(export.UserID, event, "", map[string]interface{}{
+		"export_id":   export.ID,
+		"mode":        export.Mode,
+		"asset_count": export.AssetCount,
+		"size_bytes":  export.SizeBytes,
+		"expires_at":  export.ExpiresAt,
+		"error":       export.Error,
+	})
+}
+
+// generateExportID creates a random export ID.
+func generateExportID() (string, error) {
+	bytes := make([]byte, constants.ExportIDLength/2)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
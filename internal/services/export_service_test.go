@@ -0,0 +1,177 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+)
+
+// newExportTestService wires an export service to a notification service
+// backed by a fresh orchestrator DB.
+func newExportTestService(t *testing.T) (*ExportService, *NotificationService, *auth.Store) {
+	t.Helper()
+	notifications, store := newNotificationTestService(t)
+	svc := NewExportService(notifications.app, notifications.logger, notifications)
+	t.Cleanup(svc.Stop)
+	return svc, notifications, store
+}
+
+// writeExportFile stands in for the server building the ZIP.
+func writeExportFile(t *testing.T, svc *ExportService, export *database.Export, size int) {
+	t.Helper()
+	path := svc.Path(export)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func TestExportCreate_EnforcesInboxQuota(t *testing.T) {
+	svc, _, store := newExportTestService(t)
+	user := createNotificationUser(t, store, "alice")
+	svc.app.GetConfig().Exports.MaxInboxBytes = 1000
+
+	first, err := svc.Create(user.ID, ExportRequest{Mode: "ids", AssetCount: 1, EstimatedBytes: 600})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	_, err = svc.Create(user.ID, ExportRequest{Mode: "ids", AssetCount: 1, EstimatedBytes: 500})
+	if !isServiceErrorCode(err, constants.ErrCodeExportInboxFull) {
+		t.Fatalf("expected %s, got %v", constants.ErrCodeExportInboxFull, err)
+	}
+
+	// Other users have their own inbox
+	bob := createNotificationUser(t, store, "bob")
+	if _, err := svc.Create(bob.ID, ExportRequest{Mode: "ids", AssetCount: 1, EstimatedBytes: 500}); err != nil {
+		t.Errorf("other user Create: %v", err)
+	}
+
+	// Failed exports free their space
+	svc.Fail(first, "boom")
+	if _, err := svc.Create(user.ID, ExportRequest{Mode: "ids", AssetCount: 1, EstimatedBytes: 500}); err != nil {
+		t.Errorf("Create after failure: %v", err)
+	}
+}
+
+func TestExportLifecycle(t *testing.T) {
+	svc, notifications, store := newExportTestService(t)
+	user := createNotificationUser(t, store, "alice")
+
+	export, err := svc.Create(user.ID, ExportRequest{Mode: "query", Preset: "recent-imports", Topics: []string{"photos"}, AssetCount: 3, EstimatedBytes: 300})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if export.Status != constants.ExportStatusProcessing {
+		t.Errorf("status = %s, want %s", export.Status, constants.ExportStatusProcessing)
+	}
+
+	if _, _, err := svc.Open(user.ID, export.ID); !isServiceErrorCode(err, constants.ErrCodeExportNotReady) {
+		t.Errorf("Open while processing: expected %s, got %v", constants.ErrCodeExportNotReady, err)
+	}
+	if err := svc.Delete(user.ID, export.ID); !isServiceErrorCode(err, constants.ErrCodeExportNotReady) {
+		t.Errorf("Delete while processing: expected %s, got %v", constants.ErrCodeExportNotReady, err)
+	}
+
+	writeExportFile(t, svc, export, 250)
+	svc.Complete(export, 3, 0, 250)
+
+	_, f, err := svc.Open(user.ID, export.ID)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	f.Close()
+
+	// Exports are private to their owner
+	if _, _, err := svc.Open(user.ID+1, export.ID); !isServiceErrorCode(err, constants.ErrCodeExportNotFound) {
+		t.Errorf("Open by other user: expected %s, got %v", constants.ErrCodeExportNotFound, err)
+	}
+
+	inbox, err := svc.List(user.ID)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(inbox.Exports) != 1 || inbox.Exports[0].Status != constants.ExportStatusReady || inbox.UsedBytes != 250 {
+		t.Errorf("unexpected inbox: %+v", inbox)
+	}
+	if got := inbox.Exports[0].Topics; len(got) != 1 || got[0] != "photos" {
+		t.Errorf("topics = %v, want [photos]", got)
+	}
+
+	feed := feedOf(t, notifications, user.ID)
+	if len(feed.Notifications) != 1 || feed.Notifications[0].Event != constants.NotificationEventExportReady {
+		t.Errorf("expected one %s notification, got %+v", constants.NotificationEventExportReady, feed.Notifications)
+	}
+
+	if err := svc.Delete(user.ID, export.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(svc.Path(export)); !os.IsNotExist(err) {
+		t.Errorf("export file still exists after delete: %v", err)
+	}
+	if err := svc.Delete(user.ID, export.ID); !isServiceErrorCode(err, constants.ErrCodeExportNotFound) {
+		t.Errorf("second Delete: expected %s, got %v", constants.ErrCodeExportNotFound, err)
+	}
+}
+
+func TestExportPurgeExpired(t *testing.T) {
+	svc, notifications, store := newExportTestService(t)
+	user := createNotificationUser(t, store, "alice")
+
+	ready, _ := svc.Create(user.ID, ExportRequest{Mode: "ids", AssetCount: 1, EstimatedBytes: 10})
+	writeExportFile(t, svc, ready, 10)
+	svc.Complete(ready, 1, 0, 10)
+	processing, _ := svc.Create(user.ID, ExportRequest{Mode: "ids", AssetCount: 1, EstimatedBytes: 10})
+
+	retention := svc.app.GetConfig().Exports.Retention()
+	if removed := svc.PurgeExpired(time.Now().Add(retention - time.Hour)); removed != 0 {
+		t.Errorf("removed %d exports before expiry", removed)
+	}
+	if removed := svc.PurgeExpired(time.Now().Add(retention + time.Minute)); removed != 1 {
+		t.Fatalf("removed %d exports, want 1", removed)
+	}
+
+	if _, err := os.Stat(svc.Path(ready)); !os.IsNotExist(err) {
+		t.Errorf("expired export file still exists: %v", err)
+	}
+	inbox, _ := svc.List(user.ID)
+	if len(inbox.Exports) != 1 || inbox.Exports[0].ID != processing.ID {
+		t.Errorf("expected only the processing export to remain, got %+v", inbox.Exports)
+	}
+
+	feed := feedOf(t, notifications, user.ID)
+	if len(feed.Notifications) != 2 || feed.Notifications[0].Event != constants.NotificationEventExportExpired {
+		t.Errorf("expected newest notification %s, got %+v", constants.NotificationEventExportExpired, feed.Notifications)
+	}
+}
+
+func TestNewExportService_FailsInterruptedExports(t *testing.T) {
+	svc, notifications, store := newExportTestService(t)
+	user := createNotificationUser(t, store, "alice")
+
+	export, _ := svc.Create(user.ID, ExportRequest{Mode: "ids", AssetCount: 1, EstimatedBytes: 10})
+	writeExportFile(t, svc, export, 5)
+
+	// A restart leaves the export processing with a partial file
+	restarted := NewExportService(svc.app, svc.logger, notifications)
+	t.Cleanup(restarted.Stop)
+
+	inbox, _ := restarted.List(user.ID)
+	if len(inbox.Exports) != 1 || inbox.Exports[0].Status != constants.ExportStatusFailed ||
+		inbox.Exports[0].Error != constants.ExportInterruptedMessage {
+		t.Errorf("expected interrupted export to be failed, got %+v", inbox.Exports)
+	}
+	if inbox.UsedBytes != 0 {
+		t.Errorf("failed export still counts %d bytes", inbox.UsedBytes)
+	}
+	if _, err := os.Stat(svc.Path(export)); !os.IsNotExist(err) {
+		t.Errorf("partial export file still exists: %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+	"silobang/internal/queries"
+)
+
+// FederationService runs read-only query presets on this instance and every
+// configured peer instance, merging the rows under an origin column.
+type FederationService struct {
+	app    AppState
+	logger *logger.Logger
+	query  *QueryService
+	client *http.Client
+}
+
+// NewFederationService creates a new federation service instance.
+func NewFederationService(app AppState, log *logger.Logger) *FederationService {
+	return &FederationService{
+		app:    app,
+		logger: log,
+		client: &http.Client{},
+	}
+}
+
+// SetQueryService sets the query service used for the local part of a
+// federated query. Called after QueryService is initialized in the services container.
+func (s *FederationService) SetQueryService(qs *QueryService) {
+	s.query = qs
+}
+
+// FederatedQueryResult is a preset's result merged across instances. The
+// first column is always the origin instance name.
+type FederatedQueryResult struct {
+	Preset    string                     `json:"preset"`
+	RowCount  int                        `json:"row_count"`
+	Columns   []string                   `json:"columns"`
+	Rows      [][]interface{}            `json:"rows"`
+	Truncated bool                       `json:"truncated,omitempty"`
+	Partial   bool                       `json:"partial"` // at least one instance failed
+	Instances []FederationInstanceResult `json:"instances"`
+}
+
+// FederationInstanceResult reports one instance's part in a federated query.
+type FederationInstanceResult struct {
+	Name      string `json:"name"`
+	URL       string `json:"url,omitempty"` // empty for the local instance
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	RowCount  int    `json:"row_count"`
+	Error     string `json:"error,omitempty"`
+}
+
+// FederationPeerHealth is the result of probing one peer.
+type FederationPeerHealth struct {
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+	CheckedAt  int64  `json:"checked_at"`
+}
+
+// instanceResult is one instance's raw query outcome before merging.
+type instanceResult struct {
+	status FederationInstanceResult
+	result *queries.QueryResult
+}
+
+// Query runs a preset locally and on every peer and merges the results.
+// Instances that fail are reported in Instances and leave the result Partial;
+// request errors (unknown preset, missing params) fail before any peer is called.
+func (s *FederationService) Query(presetName string, req *QueryRequest) (*FederatedQueryResult, error) {
+	cfg := s.app.GetConfig().Federation
+	if !cfg.Enabled() {
+		return nil, NewServiceError(constants.ErrCodeFederationDisabled, "federation has no peers configured")
+	}
+	if req == nil {
+		req = &QueryRequest{}
+	}
+
+	qc := s.app.GetQueriesConfig()
+	if qc == nil {
+		return nil, ErrNotConfigured
+	}
+	preset, err := qc.GetPreset(presetName)
+	if err != nil {
+		return nil, ErrPresetNotFoundWithName(presetName)
+	}
+	if !preset.IsReadOnly() {
+		return nil, NewServiceError(constants.ErrCodePresetNotReadOnly, fmt.Sprintf("preset %s is not a read-only query", presetName))
+	}
+	if _, err := queries.ValidateParams(preset, queries.ParamsToStrings(req.Params)); err != nil {
+		return nil, WrapServiceError(constants.ErrCodeMissingParam, err.Error(), err)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	// Index 0 is the local instance, followed by peers in config order
+	results := make([]instanceResult, len(cfg.Peers)+1)
+	var wg sync.WaitGroup
+	for i, peer := range cfg.Peers {
+		wg.Add(1)
+		go func(i int, peer config.FederationPeer) {
+			defer wg.Done()
+			results[i+1] = s.queryPeer(peer, presetName, body, cfg.Timeout())
+		}(i, peer)
+	}
+	results[0] = s.queryLocal(cfg.InstanceName, presetName, req)
+	wg.Wait()
+
+	merged := mergeFederatedResults(presetName, results, s.app.GetConfig().Query.FederatedMaxRows)
+	s.logger.Debug("Federated query %s across %d instances returned %d rows (partial=%v)", presetName, len(results), merged.RowCount, merged.Partial)
+	return merged, nil
+}
+
+// queryLocal runs the preset on this instance.
+func (s *FederationService) queryLocal(name, presetName string, req *QueryRequest) instanceResult {
+	start := time.Now()
+	result, _, err := s.query.Execute(presetName, req)
+	return newInstanceResult(name, "", start, result, err)
+}
+
+// queryPeer runs the preset on one peer through its query API.
+func (s *FederationService) queryPeer(peer config.FederationPeer, presetName string, body []byte, timeout time.Duration) instanceResult {
+	start := time.Now()
+	var result queries.QueryResult
+	_, err := s.peerRequest(peer, http.MethodPost, constants.FederationQueryPath+url.PathEscape(presetName), body, timeout, &result)
+	if err != nil {
+		s.logger.Warn("Federated query %s failed on peer %s: %v", presetName, peer.Name, err)
+		return newInstanceResult(peer.Name, peer.URL, start, nil, err)
+	}
+	return newInstanceResult(peer.Name, peer.URL, start, &result, nil)
+}
+
+func newInstanceResult(name, peerURL string, start time.Time, result *queries.QueryResult, err error) instanceResult {
+	r := instanceResult{
+		status: FederationInstanceResult{
+			Name:      name,
+			URL:       peerURL,
+			Status:    constants.FederationPeerStatusOK,
+			LatencyMs: time.Since(start).Milliseconds(),
+		},
+		result: result,
+	}
+	if err != nil {
+		r.status.Status = constants.FederationPeerStatusError
+		r.status.Error = err.Error()
+		r.result = nil
+	} else {
+		r.status.RowCount = result.RowCount
+	}
+	return r
+}
+
+// mergeFederatedResults concatenates instance rows in order, prefixing each
+// with its origin. The first successful instance sets the columns; instances
+// returning different columns are reported as failed. At most maxRows rows
+// are kept.
+func mergeFederatedResults(presetName string, results []instanceResult, maxRows int) *FederatedQueryResult {
+	merged := &FederatedQueryResult{
+		Preset:    presetName,
+		Columns:   []string{constants.FederationOriginColumn},
+		Rows:      [][]interface{}{},
+		Instances: make([]FederationInstanceResult, 0, len(results)),
+	}
+
+	var columns []string
+	for _, r := range results {
+		status := r.status
+		if r.result != nil && columns != nil && !slices.Equal(columns, r.result.Columns) {
+			status.Status = constants.FederationPeerStatusError
+			status.Error = fmt.Sprintf("columns %v do not match %v", r.result.Columns, columns)
+			status.RowCount = 0
+			r.result = nil
+		}
+		if r.result == nil {
+			merged.Partial = true
+			merged.Instances = append(merged.Instances, status)
+			continue
+		}
+		if columns == nil {
+			columns = r.result.Columns
+			merged.Columns = append(merged.Columns, columns...)
+		}
+		merged.Truncated = merged.Truncated || r.result.Truncated
+
+		for _, row := range r.result.Rows {
+			if len(merged.Rows) >= maxRows {
+				merged.Truncated = true
+				break
+			}
+			merged.Rows = append(merged.Rows, append([]interface{}{status.Name}, row...))
+		}
+		merged.Instances = append(merged.Instances, status)
+	}
+
+	merged.RowCount = len(merged.Rows)
+	return merged
+}
+
+// Health probes every peer concurrently, checking that it is reachable and
+// accepts the configured API key.
+func (s *FederationService) Health() []FederationPeerHealth {
+	cfg := s.app.GetConfig().Federation
+	health := make([]FederationPeerHealth, len(cfg.Peers))
+
+	var wg sync.WaitGroup
+	for i, peer := range cfg.Peers {
+		wg.Add(1)
+		go func(i int, peer config.FederationPeer) {
+			defer wg.Done()
+			start := time.Now()
+			statusCode, err := s.peerRequest(peer, http.MethodGet, constants.FederationHealthPath, nil, cfg.Timeout(), nil)
+			h := FederationPeerHealth{
+				Name:       peer.Name,
+				URL:        peer.URL,
+				Status:     constants.FederationPeerStatusOK,
+				StatusCode: statusCode,
+				LatencyMs:  time.Since(start).Milliseconds(),
+				CheckedAt:  time.Now().Unix(),
+			}
+			if err != nil {
+				h.Status = constants.FederationPeerStatusError
+				h.Error = err.Error()
+			}
+			health[i] = h
+		}(i, peer)
+	}
+	wg.Wait()
+
+	return health
+}
+
+// peerRequest sends an authenticated request to a peer and decodes a
+// successful JSON response into target (when non-nil). Returns the HTTP
+// status code, or 0 when the peer could not be reached.
+func (s *FederationService) peerRequest(peer config.FederationPeer, method, path string, body []byte, timeout time.Duration, target interface{}) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(peer.URL, "/")+path, reqBody)
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set(constants.HeaderXAPIKey, peer.APIKey)
+	httpReq.Header.Set("User-Agent", constants.FederationUserAgent)
+	if body != nil {
+		httpReq.Header.Set(constants.HeaderContentType, constants.ContentTypeJSON)
+	}
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, constants.FederationMaxResponseBytes+1))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if len(data) > constants.FederationMaxResponseBytes {
+		return resp.StatusCode, fmt.Errorf("response exceeds %d bytes", constants.FederationMaxResponseBytes)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+			Code    string `json:"code"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return resp.StatusCode, fmt.Errorf("peer returned %d %s: %s", resp.StatusCode, apiErr.Code, apiErr.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("peer returned %d", resp.StatusCode)
+	}
+
+	if target != nil {
+		// Keep numbers exact when rows are re-encoded
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(target); err != nil {
+			return resp.StatusCode, fmt.Errorf("invalid response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/queries"
+)
+
+func newFederationTestService(t *testing.T, peers ...config.FederationPeer) *FederationService {
+	t.Helper()
+	mock := newStatsCacheMock(t.TempDir())
+	mock.cfg.Federation.Peers = peers
+	mock.SetQueriesConfig(&queries.QueriesConfig{Presets: map[string]queries.Preset{
+		"assets": {SQL: "SELECT asset_id FROM assets WHERE origin_name = :name", Params: []queries.PresetParam{{Name: "name", Required: true}}},
+		"purge":  {SQL: "DELETE FROM assets"},
+	}})
+	svc := NewFederationService(mock, mock.log)
+	svc.SetQueryService(NewQueryService(mock, mock.log))
+	return svc
+}
+
+func TestFederationQuery_RejectsInvalidRequests(t *testing.T) {
+	svc := newFederationTestService(t)
+	if _, err := svc.Query("assets", nil); !isServiceErrorCode(err, constants.ErrCodeFederationDisabled) {
+		t.Errorf("expected %s without peers, got %v", constants.ErrCodeFederationDisabled, err)
+	}
+
+	svc = newFederationTestService(t, config.FederationPeer{Name: "berlin", URL: "http://127.0.0.1:1", APIKey: "key"})
+	tests := []struct {
+		preset string
+		req    *QueryRequest
+		code   string
+	}{
+		{"missing", nil, constants.ErrCodePresetNotFound},
+		{"purge", nil, constants.ErrCodePresetNotReadOnly},
+		{"assets", nil, constants.ErrCodeMissingParam},
+	}
+	for _, tt := range tests {
+		if _, err := svc.Query(tt.preset, tt.req); !isServiceErrorCode(err, tt.code) {
+			t.Errorf("%s: expected %s, got %v", tt.preset, tt.code, err)
+		}
+	}
+}
+
+func TestMergeFederatedResults(t *testing.T) {
+	ok := func(name string, columns []string, rows ...[]interface{}) instanceResult {
+		return instanceResult{
+			status: FederationInstanceResult{Name: name, Status: constants.FederationPeerStatusOK, RowCount: len(rows)},
+			result: &queries.QueryResult{Columns: columns, Rows: rows, RowCount: len(rows)},
+		}
+	}
+	failed := instanceResult{status: FederationInstanceResult{Name: "down", Status: constants.FederationPeerStatusError, Error: "connection refused"}}
+
+	merged := mergeFederatedResults("assets", []instanceResult{
+		ok("paris", []string{"asset_id"}, []interface{}{"a"}),
+		failed,
+		ok("berlin", []string{"asset_id"}, []interface{}{"b"}, []interface{}{"c"}),
+		ok("tokyo", []string{"asset_id", "extra"}, []interface{}{"d", 1}),
+	}, constants.FederatedQueryMaxRows)
+
+	if len(merged.Columns) != 2 || merged.Columns[0] != constants.FederationOriginColumn || merged.Columns[1] != "asset_id" {
+		t.Fatalf("unexpected columns: %v", merged.Columns)
+	}
+	if merged.RowCount != 3 || merged.Rows[0][0] != "paris" || merged.Rows[2][0] != "berlin" || merged.Rows[2][1] != "c" {
+		t.Fatalf("unexpected rows: %v", merged.Rows)
+	}
+	if !merged.Partial {
+		t.Error("expected partial result")
+	}
+	if len(merged.Instances) != 4 {
+		t.Fatalf("expected 4 instances, got %d", len(merged.Instances))
+	}
+	if tokyo := merged.Instances[3]; tokyo.Status != constants.FederationPeerStatusError || tokyo.RowCount != 0 {
+		t.Errorf("expected column mismatch to fail tokyo, got %+v", tokyo)
+	}
+	if berlin := merged.Instances[2]; berlin.Status != constants.FederationPeerStatusOK || berlin.RowCount != 2 {
+		t.Errorf("unexpected berlin status: %+v", berlin)
+	}
+}
+
+func TestFederationHealth(t *testing.T) {
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != constants.FederationHealthPath || r.Header.Get(constants.HeaderXAPIKey) != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":true,"message":"invalid API key","code":"AUTH_REQUIRED"}`))
+			return
+		}
+		w.Write([]byte(`{"presets":[]}`))
+	}))
+	defer peer.Close()
+
+	svc := newFederationTestService(t,
+		config.FederationPeer{Name: "berlin", URL: peer.URL + "/", APIKey: "good"},
+		config.FederationPeer{Name: "madrid", URL: peer.URL, APIKey: "bad"},
+		config.FederationPeer{Name: "down", URL: "http://127.0.0.1:1", APIKey: "good"},
+	)
+
+	health := svc.Health()
+	if len(health) != 3 {
+		t.Fatalf("expected 3 peers, got %d", len(health))
+	}
+	if health[0].Status != constants.FederationPeerStatusOK || health[0].StatusCode != http.StatusOK {
+		t.Errorf("berlin: %+v", health[0])
+	}
+	if health[1].Status != constants.FederationPeerStatusError || health[1].StatusCode != http.StatusUnauthorized || health[1].Error == "" {
+		t.Errorf("madrid: %+v", health[1])
+	}
+	if health[2].Status != constants.FederationPeerStatusError || health[2].StatusCode != 0 {
+		t.Errorf("down: %+v", health[2])
+	}
+}
+
+func isServiceErrorCode(err error, code string) bool {
+	got, ok := IsServiceError(err)
+	return ok && got == code
+}
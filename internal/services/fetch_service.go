@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"syscall"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+	"silobang/internal/sanitize"
+)
+
+// errFetchBlocked is returned by the dialer for addresses fetch may not
+// connect to.
+var errFetchBlocked = errors.New("address not allowed")
+
+// FetchedFile is a file FetchService downloaded to a temp file, which the
+// caller removes.
+type FetchedFile struct {
+	Path     string
+	Hash     string
+	Size     int64
+	Filename string // from Content-Disposition, else the last URL path segment
+	Attempts int
+}
+
+// FetchService downloads files from remote URLs so they can be stored as
+// uploads without passing through the client. Only the schemes of
+// fetch.allowed_schemes are fetched, and loopback, private and link-local
+// addresses are refused unless fetch.allow_private_networks is set. Network
+// errors, 429 and 5xx responses are retried with exponential backoff.
+type FetchService struct {
+	app    AppState
+	logger *logger.Logger
+	assets *AssetService
+	client *http.Client
+}
+
+// NewFetchService creates a new fetch service instance.
+func NewFetchService(app AppState, log *logger.Logger, assets *AssetService) *FetchService {
+	s := &FetchService{
+		app:    app,
+		logger: log,
+		assets: assets,
+	}
+
+	// Dial the remote directly so the address checked is the one connected to,
+	// after DNS resolution and on every redirect
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   s.checkDialAddress,
+	}).DialContext
+	s.client = &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= constants.FetchMaxRedirects {
+				return NewServiceError(constants.ErrCodeFetchFailed, fmt.Sprintf("stopped after %d redirects", constants.FetchMaxRedirects))
+			}
+			return s.checkScheme(req.URL)
+		},
+	}
+	return s
+}
+
+// CheckURL parses rawURL and returns INVALID_FETCH_URL unless it is an
+// absolute URL with an allowed scheme.
+func (s *FetchService) CheckURL(rawURL string) (*url.URL, error) {
+	if len(rawURL) > constants.FetchMaxURLLength {
+		return nil, NewServiceError(constants.ErrCodeInvalidFetchURL, fmt.Sprintf("URL is longer than %d characters", constants.FetchMaxURLLength))
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, NewServiceError(constants.ErrCodeInvalidFetchURL, "not an absolute URL")
+	}
+	if err := s.checkScheme(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Download fetches rawURL to a temp file, retrying failed attempts. Files
+// larger than the storage policy of their extension, or fetch.max_size_bytes,
+// are refused with ASSET_TOO_LARGE.
+func (s *FetchService) Download(ctx context.Context, rawURL string) (*FetchedFile, error) {
+	u, err := s.CheckURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	retries := s.app.GetConfig().Fetch.RetryCount()
+	delay := constants.FetchRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		file, retry, err := s.attempt(ctx, u)
+		if err == nil {
+			file.Attempts = attempt
+			return file, nil
+		}
+		if !retry || attempt > retries {
+			if attempt > 1 {
+				s.logger.Warn("Fetch: %s failed after %d attempts: %v", u.Redacted(), attempt, err)
+			}
+			return nil, err
+		}
+
+		s.logger.Debug("Fetch: attempt %d of %s failed, retrying in %s: %v", attempt, u.Redacted(), delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		delay = min(delay*2, constants.FetchRetryMaxDelay)
+	}
+}
+
+// attempt downloads u once. It reports whether a failure is worth retrying.
+func (s *FetchService) attempt(ctx context.Context, u *url.URL) (*FetchedFile, bool, error) {
+	cfg := s.app.GetConfig()
+	ctx, cancel := context.WithTimeout(ctx, cfg.Fetch.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, false, WrapServiceError(constants.ErrCodeInvalidFetchURL, "invalid URL", err)
+	}
+	req.Header.Set("User-Agent", constants.FetchUserAgent)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		var svcErr *ServiceError
+		switch {
+		case errors.As(err, &svcErr):
+			return nil, false, svcErr
+		case errors.Is(err, errFetchBlocked):
+			return nil, false, NewServiceError(constants.ErrCodeFetchBlocked, fmt.Sprintf("%s resolves to a loopback, private or link-local address", u.Hostname()))
+		case ctx.Err() != nil && ctx.Err() != context.DeadlineExceeded:
+			return nil, false, ctx.Err()
+		}
+		return nil, true, WrapServiceError(constants.ErrCodeFetchFailed, "remote unreachable", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		return nil, retry, NewServiceError(constants.ErrCodeFetchFailed, fmt.Sprintf("remote returned %d", resp.StatusCode))
+	}
+
+	filename := fetchFilename(resp)
+	_, ext := splitFilename(sanitize.Filename(filename))
+	maxSize := cfg.StoragePolicy(ext).MaxSizeBytes
+	if cfg.Fetch.MaxSizeBytes > 0 {
+		maxSize = min(maxSize, cfg.Fetch.MaxSizeBytes)
+	}
+	if resp.ContentLength > maxSize {
+		return nil, false, ErrAssetTooLarge
+	}
+
+	tempFile, hash, size, err := s.assets.streamToTempWithHash(resp.Body, maxSize+int64(constants.HeaderSize))
+	if err != nil {
+		if errors.Is(err, errStreamTooLarge) {
+			return nil, false, ErrAssetTooLarge
+		}
+		return nil, true, WrapServiceError(constants.ErrCodeFetchFailed, "failed to read the file from the remote", err)
+	}
+	if resp.ContentLength >= 0 && size != resp.ContentLength {
+		os.Remove(tempFile)
+		return nil, true, NewServiceError(constants.ErrCodeFetchFailed, fmt.Sprintf("remote sent %d of %d bytes", size, resp.ContentLength))
+	}
+
+	return &FetchedFile{Path: tempFile, Hash: hash, Size: size, Filename: filename}, false, nil
+}
+
+// checkScheme returns INVALID_FETCH_URL unless u has an allowed scheme.
+func (s *FetchService) checkScheme(u *url.URL) error {
+	if !slices.Contains(s.app.GetConfig().Fetch.AllowedSchemes, u.Scheme) {
+		return NewServiceError(constants.ErrCodeInvalidFetchURL, fmt.Sprintf("scheme %q is not allowed", u.Scheme))
+	}
+	return nil
+}
+
+// checkDialAddress refuses connections to loopback, private, link-local and
+// unspecified addresses unless fetch.allow_private_networks is set.
+func (s *FetchService) checkDialAddress(network, address string, _ syscall.RawConn) error {
+	if s.app.GetConfig().Fetch.AllowPrivateNetworks {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return errFetchBlocked
+	}
+	return nil
+}
+
+// fetchFilename names a downloaded file after its Content-Disposition, the
+// last segment of the final URL's path, or constants.FetchDefaultFilename.
+func fetchFilename(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get(constants.HeaderContentDisposition)); err == nil && params["filename"] != "" {
+		return params["filename"]
+	}
+	if name := path.Base(resp.Request.URL.Path); name != "/" && name != "." {
+		return name
+	}
+	return constants.FetchDefaultFilename
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func newFetchTestService(t *testing.T) (*FetchService, *mockAppState) {
+	t.Helper()
+	mock := newStatsCacheMock(t.TempDir())
+	mock.cfg.Fetch.AllowPrivateNetworks = true // httptest listens on loopback
+	return NewFetchService(mock, mock.log, NewAssetService(mock, mock.log)), mock
+}
+
+func TestFetchCheckURL_RejectsSchemesAndRelativeURLs(t *testing.T) {
+	svc, mock := newFetchTestService(t)
+
+	for _, raw := range []string{"ftp://example.com/a.bin", "/relative/a.bin", "file:///etc/passwd", "https://" + strings.Repeat("a", constants.FetchMaxURLLength)} {
+		if _, err := svc.CheckURL(raw); !isServiceErrorCode(err, constants.ErrCodeInvalidFetchURL) {
+			t.Errorf("%.40s: expected INVALID_FETCH_URL, got %v", raw, err)
+		}
+	}
+	if _, err := svc.CheckURL("http://example.com/a.bin"); err != nil {
+		t.Errorf("expected http to be allowed by default, got %v", err)
+	}
+	mock.cfg.Fetch.AllowedSchemes = []string{"https"}
+	if _, err := svc.CheckURL("http://example.com/a.bin"); !isServiceErrorCode(err, constants.ErrCodeInvalidFetchURL) {
+		t.Errorf("expected http to be refused, got %v", err)
+	}
+}
+
+func TestFetchDownload_BlocksPrivateAddresses(t *testing.T) {
+	svc, mock := newFetchTestService(t)
+	mock.cfg.Fetch.AllowPrivateNetworks = false
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	if _, err := svc.Download(context.Background(), srv.URL+"/a.bin"); !isServiceErrorCode(err, constants.ErrCodeFetchBlocked) {
+		t.Errorf("expected FETCH_BLOCKED, got %v", err)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("expected no request to reach the server, got %d", hits.Load())
+	}
+}
+
+func TestFetchDownload_RetriesServerErrors(t *testing.T) {
+	svc, mock := newFetchTestService(t)
+	retries := 1
+	mock.cfg.Fetch.Retries = &retries
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/missing.bin":
+			http.NotFound(w, r)
+		case hits.Add(1) == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Header().Set(constants.HeaderContentDisposition, `attachment; filename="scan.obj"`)
+			w.Write([]byte("v 0 0 0\n"))
+		}
+	}))
+	defer srv.Close()
+
+	file, err := svc.Download(context.Background(), srv.URL+"/download?id=7")
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	defer os.Remove(file.Path)
+	if file.Attempts != 2 || file.Filename != "scan.obj" || file.Size != 8 || len(file.Hash) != constants.HashLength {
+		t.Errorf("unexpected file: %+v", file)
+	}
+
+	// Client errors are not retried
+	before := hits.Load()
+	if _, err := svc.Download(context.Background(), srv.URL+"/missing.bin"); !isServiceErrorCode(err, constants.ErrCodeFetchFailed) {
+		t.Errorf("expected FETCH_FAILED, got %v", err)
+	}
+	if hits.Load() != before {
+		t.Errorf("expected 404 not to be retried")
+	}
+}
+
+func TestFetchDownload_SizeLimit(t *testing.T) {
+	svc, mock := newFetchTestService(t)
+	mock.cfg.Fetch.MaxSizeBytes = 16
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Without a Content-Length the limit applies while reading
+		w.(http.Flusher).Flush()
+		w.Write([]byte(strings.Repeat("x", 17)))
+	}))
+	defer srv.Close()
+
+	if _, err := svc.Download(context.Background(), srv.URL+"/big.bin"); !isServiceErrorCode(err, constants.ErrCodeAssetTooLarge) {
+		t.Errorf("expected ASSET_TOO_LARGE, got %v", err)
+	}
+}
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/version"
+)
+
+// =============================================================================
+// Startup Report
+// =============================================================================
+
+// StartupStep is the outcome of one initialization step.
+type StartupStep struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"` // ok, warning, failed, skipped
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// StartupReport describes one initialization of the working directory.
+type StartupReport struct {
+	ID          int64         `json:"id,omitempty"` // set once persisted
+	Trigger     string        `json:"trigger"`      // boot or config_api
+	Version     string        `json:"version"`
+	StartedAt   int64         `json:"started_at"`
+	CompletedAt int64         `json:"completed_at,omitempty"`
+	DurationMs  int64         `json:"duration_ms"`
+	Completed   bool          `json:"completed"`
+	Steps       []StartupStep `json:"steps"`
+
+	Subsystems []SubsystemStatus `json:"subsystems,omitempty"` // started by a Supervisor
+}
+
+// StartupRecorder collects the steps of an initialization in progress.
+// It is safe for concurrent use so readiness probes can read it while
+// initialization runs. A nil recorder ignores all calls.
+type StartupRecorder struct {
+	mu      sync.Mutex
+	started time.Time
+	report  StartupReport
+}
+
+// NewStartupRecorder starts recording an initialization.
+func NewStartupRecorder(trigger string) *StartupRecorder {
+	now := time.Now()
+	return &StartupRecorder{
+		started: now,
+		report: StartupReport{
+			Trigger:   trigger,
+			Version:   version.Version,
+			StartedAt: now.Unix(),
+			Steps:     []StartupStep{},
+		},
+	}
+}
+
+// Record adds a step that began at start.
+func (r *StartupRecorder) Record(name string, start time.Time, status, detail string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.Steps = append(r.report.Steps, StartupStep{
+		Name:       name,
+		Status:     status,
+		Detail:     detail,
+		DurationMs: time.Since(start).Milliseconds(),
+	})
+}
+
+// RecordError adds a step that succeeded when err is nil and otherwise
+// ended with the given failure status.
+func (r *StartupRecorder) RecordError(name string, start time.Time, err error, failStatus string) {
+	if err != nil {
+		r.Record(name, start, failStatus, err.Error())
+		return
+	}
+	r.Record(name, start, constants.StartupStepOK, "")
+}
+
+// SetSubsystem records the status of a subsystem, replacing an earlier one.
+func (r *StartupRecorder) SetSubsystem(status SubsystemStatus) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.report.Subsystems {
+		if existing.Name == status.Name {
+			r.report.Subsystems[i] = status
+			return
+		}
+	}
+	r.report.Subsystems = append(r.report.Subsystems, status)
+}
+
+// Subsystem returns the recorded status of a subsystem.
+func (r *StartupRecorder) Subsystem(name string) (SubsystemStatus, bool) {
+	if r == nil {
+		return SubsystemStatus{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, status := range r.report.Subsystems {
+		if status.Name == name {
+			return status, true
+		}
+	}
+	return SubsystemStatus{}, false
+}
+
+// Complete marks the initialization as finished and returns the report.
+func (r *StartupRecorder) Complete() StartupReport {
+	if r == nil {
+		return StartupReport{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.Completed = true
+	r.report.CompletedAt = time.Now().Unix()
+	r.report.DurationMs = time.Since(r.started).Milliseconds()
+	return r.snapshot()
+}
+
+// SetID records the ID under which the report was persisted.
+func (r *StartupRecorder) SetID(id int64) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.ID = id
+}
+
+// Report returns a copy of the report so far.
+func (r *StartupRecorder) Report() StartupReport {
+	if r == nil {
+		return StartupReport{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := r.snapshot()
+	if !report.Completed {
+		report.DurationMs = time.Since(r.started).Milliseconds()
+	}
+	return report
+}
+
+// snapshot copies the report; the caller holds mu.
+func (r *StartupRecorder) snapshot() StartupReport {
+	report := r.report
+	report.Steps = append([]StartupStep(nil), r.report.Steps...)
+	report.Subsystems = append([]SubsystemStatus(nil), r.report.Subsystems...)
+	return report
+}
+
+// =============================================================================
+// Health Service
+// =============================================================================
+
+// ComponentHealth is the readiness of one component.
+type ComponentHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // ok, degraded, failed
+	Reason string `json:"reason,omitempty"`
+}
+
+// ReadinessReport is the response of GET /readyz.
+type ReadinessReport struct {
+	Ready      bool              `json:"ready"`
+	CheckedAt  int64             `json:"checked_at"`
+	Components []ComponentHealth `json:"components"`
+	Subsystems map[string]string `json:"subsystems,omitempty"` // subsystem -> state
+}
+
+// HealthService reports liveness, readiness and startup history.
+type HealthService struct {
+	app        AppState
+	logger     *logger.Logger
+	statsCache *StatsCache
+}
+
+// NewHealthService creates a new health service instance.
+func NewHealthService(app AppState, log *logger.Logger) *HealthService {
+	return &HealthService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// SetStatsCache sets the stats cache whose build state gates readiness.
+func (s *HealthService) SetStatsCache(cache *StatsCache) {
+	s.statsCache = cache
+}
+
+// Readiness checks every component needed to serve requests. The instance
+// is ready when no component has failed; degraded components are reported
+// but do not block traffic.
+func (s *HealthService) Readiness() *ReadinessReport {
+	startup := s.app.GetStartupRecorder()
+	report := startup.Report()
+
+	components := []ComponentHealth{
+		s.checkStartup(startup, report),
+		s.checkWorkingDirectory(),
+		s.checkOrchestratorDB(),
+		s.checkTopicDiscovery(report),
+		s.checkStatsCache(),
+		s.checkTopics(),
+		s.checkSubsystems(report),
+	}
+
+	ready := true
+	for _, c := range components {
+		if c.Status == constants.HealthStatusFailed {
+			ready = false
+		}
+	}
+
+	var subsystems map[string]string
+	if len(report.Subsystems) > 0 {
+		subsystems = make(map[string]string, len(report.Subsystems))
+		for _, sub := range report.Subsystems {
+			subsystems[sub.Name] = sub.State
+		}
+	}
+
+	return &ReadinessReport{
+		Ready:      ready,
+		CheckedAt:  time.Now().Unix(),
+		Components: components,
+		Subsystems: subsystems,
+	}
+}
+
+func (s *HealthService) checkStartup(startup *StartupRecorder, report StartupReport) ComponentHealth {
+	c := ComponentHealth{Name: constants.HealthComponentStartup, Status: constants.HealthStatusOK}
+	if startup == nil || !report.Completed {
+		c.Status = constants.HealthStatusFailed
+		c.Reason = "initialization in progress"
+		return c
+	}
+
+	var problems []string
+	for _, step := range report.Steps {
+		if step.Status == constants.StartupStepWarning || step.Status == constants.StartupStepFailed {
+			problems = append(problems, step.Name)
+		}
+	}
+	if len(problems) > 0 {
+		c.Status = constants.HealthStatusDegraded
+		c.Reason = "steps with errors: " + strings.Join(problems, ", ")
+	}
+	return c
+}
+
+func (s *HealthService) checkWorkingDirectory() ComponentHealth {
+	c := ComponentHealth{Name: constants.HealthComponentWorkingDirectory, Status: constants.HealthStatusOK}
+	if s.app.GetWorkingDirectory() == "" {
+		c.Status = constants.HealthStatusFailed
+		c.Reason = "working directory not configured"
+	}
+	return c
+}
+
+func (s *HealthService) checkOrchestratorDB() ComponentHealth {
+	c := ComponentHealth{Name: constants.HealthComponentOrchestratorDB, Status: constants.HealthStatusOK}
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		c.Status = constants.HealthStatusFailed
+		c.Reason = "orchestrator database not open"
+		return c
+	}
+	if err := db.Ping(); err != nil {
+		c.Status = constants.HealthStatusFailed
+		c.Reason = "orchestrator database unreachable"
+		s.logger.Warn("Readiness: orchestrator ping failed: %v", err)
+	}
+	return c
+}
+
+func (s *HealthService) checkTopicDiscovery(report StartupReport) ComponentHealth {
+	c := ComponentHealth{Name: constants.HealthComponentTopicDiscovery, Status: constants.HealthStatusFailed}
+	for _, step := range report.Steps {
+		if step.Name != constants.StartupStepTopicDiscovery {
+			continue
+		}
+		switch step.Status {
+		case constants.StartupStepOK:
+			c.Status = constants.HealthStatusOK
+		case constants.StartupStepWarning:
+			c.Status = constants.HealthStatusDegraded
+			c.Reason = "some topics could not be indexed"
+		default:
+			c.Reason = "topic discovery failed"
+		}
+		return c
+	}
+	c.Reason = "topic discovery has not run"
+	return c
+}
+
+// checkSubsystems fails when a required subsystem is down and degrades when
+// an optional one is. Subsystems disabled by configuration are fine.
+func (s *HealthService) checkSubsystems(report StartupReport) ComponentHealth {
+	c := ComponentHealth{Name: constants.HealthComponentSubsystems, Status: constants.HealthStatusOK}
+	var down, degraded []string
+	for _, sub := range report.Subsystems {
+		switch {
+		case sub.State == constants.SubsystemStateDisabled:
+		case sub.Required && !sub.Running():
+			down = append(down, sub.Name)
+		case sub.State != constants.SubsystemStateRunning:
+			degraded = append(degraded, sub.Name)
+		}
+	}
+	switch {
+	case len(down) > 0:
+		c.Status = constants.HealthStatusFailed
+		c.Reason = "required subsystems not running: " + strings.Join(down, ", ")
+	case len(degraded) > 0:
+		c.Status = constants.HealthStatusDegraded
+		c.Reason = "subsystems with errors: " + strings.Join(degraded, ", ")
+	}
+	return c
+}
+
+func (s *HealthService) checkStatsCache() ComponentHealth {
+	c := ComponentHealth{Name: constants.HealthComponentStatsCache, Status: constants.HealthStatusOK}
+	if s.statsCache == nil || !s.statsCache.IsInitialized() {
+		c.Status = constants.HealthStatusFailed
+		c.Reason = "stats cache not built"
+		return c
+	}
+	if s.statsCache.Status().Stale {
+		c.Status = constants.HealthStatusDegraded
+		c.Reason = "serving restored stats until reconciliation completes"
+	}
+	return c
+}
+
+func (s *HealthService) checkTopics() ComponentHealth {
+	c := ComponentHealth{Name: constants.HealthComponentTopics, Status: constants.HealthStatusOK}
+	unhealthy := 0
+	for _, name := range s.app.ListTopics() {
+		if healthy, _ := s.app.IsTopicHealthy(name); !healthy {
+			unhealthy++
+		}
+	}
+	if unhealthy > 0 {
+		c.Status = constants.HealthStatusDegraded
+		c.Reason = fmt.Sprintf("%d unhealthy topic(s)", unhealthy)
+	}
+	return c
+}
+
+// SaveStartupReport completes the recorder and persists its report to the
+// orchestrator database. Without an orchestrator the report is kept in
+// memory only.
+func (s *HealthService) SaveStartupReport(startup *StartupRecorder) error {
+	report := startup.Complete()
+
+	db := s.app.GetOrchestratorDB()
+	if db == nil || startup == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return WrapInternalError(err)
+	}
+
+	id, err := database.InsertStartupReport(db, database.StartupReportRow{
+		Trigger:     report.Trigger,
+		StartedAt:   report.StartedAt,
+		CompletedAt: report.CompletedAt,
+		ReportJSON:  string(data),
+	}, constants.StartupReportHistoryLimit)
+	if err != nil {
+		return WrapInternalError(fmt.Errorf("failed to save startup report: %w", err))
+	}
+	startup.SetID(id)
+	return nil
+}
+
+// StartupReports returns the current initialization and up to limit
+// persisted reports, newest first.
+func (s *HealthService) StartupReports(limit int) (*StartupReport, []StartupReport, error) {
+	if limit <= 0 || limit > constants.StartupReportHistoryLimit {
+		limit = constants.StartupReportDefaultListing
+	}
+
+	var current *StartupReport
+	if startup := s.app.GetStartupRecorder(); startup != nil {
+		report := startup.Report()
+		current = &report
+	}
+
+	history := []StartupReport{}
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return current, history, nil
+	}
+
+	rows, err := database.ListStartupReports(db, limit)
+	if err != nil {
+		return nil, nil, WrapInternalError(err)
+	}
+	for _, row := range rows {
+		var report StartupReport
+		if err := json.Unmarshal([]byte(row.ReportJSON), &report); err != nil {
+			s.logger.Warn("Skipping unreadable startup report %d: %v", row.ID, err)
+			continue
+		}
+		report.ID = row.ID
+		history = append(history, report)
+	}
+
+	return current, history, nil
+}
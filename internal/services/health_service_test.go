@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+func TestStartupRecorder_RecordsSteps(t *testing.T) {
+	startup := NewStartupRecorder(constants.StartupTriggerBoot)
+	startup.RecordError(constants.StartupStepOrchestratorDB, time.Now(), nil, constants.StartupStepFailed)
+	startup.RecordError(constants.StartupStepQueries, time.Now(), errors.New("bad yaml"), constants.StartupStepWarning)
+
+	if report := startup.Report(); report.Completed || len(report.Steps) != 2 {
+		t.Fatalf("unexpected report before completion: %+v", report)
+	}
+
+	report := startup.Complete()
+	if !report.Completed || report.CompletedAt == 0 {
+		t.Fatalf("expected a completed report, got %+v", report)
+	}
+	if report.Steps[1].Status != constants.StartupStepWarning || report.Steps[1].Detail != "bad yaml" {
+		t.Errorf("unexpected queries step: %+v", report.Steps[1])
+	}
+}
+
+func TestStartupRecorder_NilIsNoop(t *testing.T) {
+	var startup *StartupRecorder
+	startup.Record(constants.StartupStepStatsCache, time.Now(), constants.StartupStepOK, "")
+	if report := startup.Complete(); report.Completed {
+		t.Error("nil recorder should not produce a report")
+	}
+}
+
+func TestHealthService_ReadinessDuringStartup(t *testing.T) {
+	m := newMockAppState()
+	m.log = logger.NewLogger(logger.LevelError)
+	svc := NewHealthService(m, m.log)
+
+	m.SetStartupRecorder(NewStartupRecorder(constants.StartupTriggerBoot))
+	report := svc.Readiness()
+	if report.Ready {
+		t.Fatal("expected not ready while initialization is in progress")
+	}
+
+	byName := map[string]ComponentHealth{}
+	for _, c := range report.Components {
+		byName[c.Name] = c
+	}
+	if byName[constants.HealthComponentStartup].Reason != "initialization in progress" {
+		t.Errorf("unexpected startup component: %+v", byName[constants.HealthComponentStartup])
+	}
+	if byName[constants.HealthComponentTopicDiscovery].Status != constants.HealthStatusFailed {
+		t.Errorf("expected topic discovery to fail before it runs: %+v", byName[constants.HealthComponentTopicDiscovery])
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// IdempotencyService records mutating requests sent with an Idempotency-Key
+// so that retries replay the first response instead of executing again.
+// Keys are scoped per user and expire after idempotency.ttl_hours.
+type IdempotencyService struct {
+	app    AppState
+	logger *logger.Logger
+
+	sweepMu   sync.Mutex
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewIdempotencyService creates a new idempotency service instance.
+// Returns nil if the orchestrator DB is not available.
+func NewIdempotencyService(app AppState, log *logger.Logger) *IdempotencyService {
+	if app.GetOrchestratorDB() == nil {
+		return nil
+	}
+	return &IdempotencyService{
+		app:    app,
+		logger: log,
+		now:    time.Now,
+	}
+}
+
+// Begin claims key for a request identified by method, path and digest.
+// Returns (nil, nil) when the request should execute, or the stored record
+// whose response must be replayed. Fails when the key is malformed, was used
+// for a different request, or its first request is still running.
+func (s *IdempotencyService) Begin(userID int64, key, method, path, digest string) (*database.IdempotencyRecord, error) {
+	if err := validateIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	s.sweepExpired(now)
+
+	existing, err := database.ReserveIdempotencyKey(s.app.GetOrchestratorDB(), database.IdempotencyRecord{
+		UserID:        userID,
+		Key:           key,
+		Method:        method,
+		Path:          path,
+		RequestDigest: digest,
+		CreatedAt:     now.Unix(),
+		ExpiresAt:     now.Add(s.app.GetConfig().Idempotency.TTL()).Unix(),
+	})
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.Method != method || existing.Path != path || existing.RequestDigest != digest {
+		return nil, NewServiceError(constants.ErrCodeIdempotencyKeyConflict,
+			fmt.Sprintf("idempotency key was used for a different request (%s %s)", existing.Method, existing.Path))
+	}
+	if existing.Status == nil {
+		return nil, NewServiceError(constants.ErrCodeIdempotencyKeyInProgress, "a request with this idempotency key is still in progress")
+	}
+	return existing, nil
+}
+
+// Complete stores the response of the request holding key.
+func (s *IdempotencyService) Complete(userID int64, key string, status int, contentType string, body []byte) {
+	if err := database.CompleteIdempotencyKey(s.app.GetOrchestratorDB(), userID, key, status, contentType, body); err != nil {
+		s.logger.Error("Failed to store idempotent response for key %q: %v", key, err)
+	}
+}
+
+// Release forgets key so that a retry executes the request again. Used when
+// the response is not worth replaying (server errors, streams, oversized bodies).
+func (s *IdempotencyService) Release(userID int64, key string) {
+	if err := database.DeleteIdempotencyKey(s.app.GetOrchestratorDB(), userID, key); err != nil {
+		s.logger.Error("Failed to release idempotency key %q: %v", key, err)
+	}
+}
+
+// sweepExpired purges expired keys at most once per sweep interval.
+func (s *IdempotencyService) sweepExpired(now time.Time) {
+	s.sweepMu.Lock()
+	if now.Sub(s.lastSweep) < constants.IdempotencySweepInterval {
+		s.sweepMu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.sweepMu.Unlock()
+
+	deleted, err := database.DeleteExpiredIdempotencyKeys(s.app.GetOrchestratorDB(), now.Unix())
+	if err != nil {
+		s.logger.Warn("Failed to purge expired idempotency keys: %v", err)
+		return
+	}
+	if deleted > 0 {
+		s.logger.Debug("Purged %d expired idempotency keys", deleted)
+	}
+}
+
+// validateIdempotencyKey accepts 1 to IdempotencyKeyMaxLength printable ASCII characters.
+func validateIdempotencyKey(key string) error {
+	if len(key) == 0 || len(key) > constants.IdempotencyKeyMaxLength {
+		return NewServiceError(constants.ErrCodeIdempotencyKeyInvalid,
+			fmt.Sprintf("idempotency key must be 1 to %d characters", constants.IdempotencyKeyMaxLength))
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return NewServiceError(constants.ErrCodeIdempotencyKeyInvalid, "idempotency key must be printable ASCII")
+		}
+	}
+	return nil
+}
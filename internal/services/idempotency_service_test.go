@@ -0,0 +1,93 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+func newIdempotencyTestService(t *testing.T) *IdempotencyService {
+	t.Helper()
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+	mock.SetOrchestratorDB(setupOrchestratorDB(t, workDir, nil))
+	return NewIdempotencyService(mock, mock.log)
+}
+
+func TestIdempotencyBegin_ReplaysCompletedRequest(t *testing.T) {
+	svc := newIdempotencyTestService(t)
+
+	stored, err := svc.Begin(1, "key-1", http.MethodPost, "/api/metadata/batch", "digest")
+	if err != nil || stored != nil {
+		t.Fatalf("first Begin = %v, %v; want claim", stored, err)
+	}
+
+	// A retry while the first request runs is rejected
+	_, err = svc.Begin(1, "key-1", http.MethodPost, "/api/metadata/batch", "digest")
+	if !isServiceErrorCode(err, constants.ErrCodeIdempotencyKeyInProgress) {
+		t.Fatalf("expected %s, got %v", constants.ErrCodeIdempotencyKeyInProgress, err)
+	}
+
+	svc.Complete(1, "key-1", http.StatusCreated, constants.ContentTypeJSON, []byte(`{"ok":true}`))
+
+	stored, err = svc.Begin(1, "key-1", http.MethodPost, "/api/metadata/batch", "digest")
+	if err != nil || stored == nil {
+		t.Fatalf("retry Begin = %v, %v; want stored response", stored, err)
+	}
+	if *stored.Status != http.StatusCreated || stored.ContentType != constants.ContentTypeJSON || string(stored.Body) != `{"ok":true}` {
+		t.Errorf("unexpected stored response: %+v", stored)
+	}
+
+	// Keys are scoped per user
+	if stored, err := svc.Begin(2, "key-1", http.MethodPost, "/api/metadata/batch", "digest"); err != nil || stored != nil {
+		t.Errorf("other user Begin = %v, %v; want claim", stored, err)
+	}
+}
+
+func TestIdempotencyBegin_Conflicts(t *testing.T) {
+	svc := newIdempotencyTestService(t)
+	svc.Begin(1, "key-1", http.MethodPost, "/api/metadata/batch", "digest")
+	svc.Complete(1, "key-1", http.StatusOK, "", nil)
+
+	for _, tc := range []struct{ method, path, digest string }{
+		{http.MethodPost, "/api/metadata/batch", "other-digest"},
+		{http.MethodPost, "/api/metadata/apply", "digest"},
+		{http.MethodPut, "/api/metadata/batch", "digest"},
+	} {
+		_, err := svc.Begin(1, "key-1", tc.method, tc.path, tc.digest)
+		if !isServiceErrorCode(err, constants.ErrCodeIdempotencyKeyConflict) {
+			t.Errorf("%+v: expected %s, got %v", tc, constants.ErrCodeIdempotencyKeyConflict, err)
+		}
+	}
+}
+
+func TestIdempotencyBegin_ReleaseAndExpiry(t *testing.T) {
+	svc := newIdempotencyTestService(t)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	svc.Begin(1, "released", http.MethodPost, "/api/topics", "digest")
+	svc.Release(1, "released")
+	if stored, err := svc.Begin(1, "released", http.MethodPost, "/api/topics", "changed"); err != nil || stored != nil {
+		t.Errorf("released key: Begin = %v, %v; want claim", stored, err)
+	}
+
+	svc.Begin(1, "expiring", http.MethodPost, "/api/topics", "digest")
+	svc.Complete(1, "expiring", http.StatusOK, "", nil)
+	now = now.Add(svc.app.GetConfig().Idempotency.TTL())
+	if stored, err := svc.Begin(1, "expiring", http.MethodPost, "/api/topics", "changed"); err != nil || stored != nil {
+		t.Errorf("expired key: Begin = %v, %v; want claim", stored, err)
+	}
+}
+
+func TestIdempotencyBegin_InvalidKey(t *testing.T) {
+	svc := newIdempotencyTestService(t)
+	for _, key := range []string{strings.Repeat("k", constants.IdempotencyKeyMaxLength+1), "bad\nkey", "ключ"} {
+		if _, err := svc.Begin(1, key, http.MethodPost, "/api/topics", "digest"); !isServiceErrorCode(err, constants.ErrCodeIdempotencyKeyInvalid) {
+			t.Errorf("key %q: expected %s, got %v", key, constants.ErrCodeIdempotencyKeyInvalid, err)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/storage"
+)
+
+// IntegrityReport is the outcome of scanning one topic's .dat files.
+type IntegrityReport struct {
+	Topic     string                      `json:"topic"`
+	DatFiles  int                         `json:"dat_files"`
+	Extents   int                         `json:"extents"`
+	Findings  []database.IntegrityFinding `json:"findings"`
+	ScannedAt int64                       `json:"scanned_at"`
+}
+
+// IntegrityService cross-references .dat file lengths with the asset extents
+// recorded in each topic database. A crash between appending to a .dat file
+// and committing the asset row leaves bytes no row explains; a damaged
+// database or truncated file leaves rows pointing at bytes that are not
+// there. Findings of the latest scan are stored in the topic's dat_integrity
+// table and surfaced in the topic list.
+type IntegrityService struct {
+	app    AppState
+	logger *logger.Logger
+}
+
+// NewIntegrityService creates a new integrity service instance.
+func NewIntegrityService(app AppState, log *logger.Logger) *IntegrityService {
+	return &IntegrityService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// ScanAll scans every healthy topic. Failures are logged and skipped so one
+// broken topic does not hide findings in the others.
+func (s *IntegrityService) ScanAll() {
+	for _, name := range s.app.ListTopics() {
+		if healthy, _ := s.app.IsTopicHealthy(name); !healthy {
+			continue
+		}
+		if _, err := s.ScanTopic(name); err != nil {
+			s.logger.Warn("[integrity] scan of topic %s failed: %v", name, err)
+		}
+	}
+}
+
+// ScanTopic compares the topic's .dat files with its recorded extents and
+// replaces the stored findings. The topic write lock is held while extents
+// and file sizes are read, so an in-flight upload is never mistaken for an
+// orphan.
+func (s *IntegrityService) ScanTopic(topicName string) (*IntegrityReport, error) {
+	if s.app.GetWorkingDirectory() == "" {
+		return nil, ErrNotConfigured
+	}
+	if !s.app.TopicExists(topicName) {
+		return nil, ErrTopicNotFoundWithName(topicName)
+	}
+	if healthy, errMsg := s.app.IsTopicHealthy(topicName); !healthy {
+		return nil, ErrTopicUnhealthyWithReason(topicName, errMsg)
+	}
+
+	db, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	topicPath := s.app.GetTopicPath(topicName)
+	now := time.Now().Unix()
+
+	mu := s.app.GetTopicWriteMu(topicName)
+	mu.Lock()
+	extents, err := database.ListDatExtents(db)
+	if err != nil {
+		mu.Unlock()
+		return nil, WrapInternalError(err)
+	}
+	datFiles, err := storage.ListDatFiles(topicPath)
+	if err != nil {
+		mu.Unlock()
+		return nil, WrapInternalError(err)
+	}
+	offloads, err := database.ListDatOffloads(db)
+	if err != nil {
+		mu.Unlock()
+		return nil, WrapInternalError(err)
+	}
+
+	// Files offloaded to a blob store are not scanned
+	byBlob := make(map[string][]database.DatExtent)
+	for _, e := range extents {
+		if _, ok := offloads[e.BlobName]; ok {
+			continue
+		}
+		byBlob[e.BlobName] = append(byBlob[e.BlobName], e)
+	}
+	names := append([]string{}, datFiles...)
+	for blob := range byBlob {
+		if !slices.Contains(datFiles, blob) {
+			names = append(names, blob)
+		}
+	}
+	sort.Strings(names)
+
+	findings := []database.IntegrityFinding{}
+	for _, name := range names {
+		findings = append(findings, analyzeDatFile(filepath.Join(topicPath, name), name, byBlob[name], now)...)
+	}
+	mu.Unlock()
+
+	if err := database.ReplaceIntegrityFindings(db, findings); err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	if len(findings) > 0 {
+		s.logger.Warn("[integrity] topic %s: %d unexplained or conflicting .dat region(s)", topicName, len(findings))
+		for _, f := range findings {
+			s.logger.Warn("[integrity]   %s %s [%d, %d): %s", f.DatFile, f.Kind, f.StartOffset, f.EndOffset, f.Detail)
+		}
+	} else {
+		s.logger.Debug("[integrity] topic %s: %d extents in %d .dat file(s) consistent", topicName, len(extents), len(datFiles))
+	}
+
+	return &IntegrityReport{
+		Topic:     topicName,
+		DatFiles:  len(datFiles),
+		Extents:   len(extents),
+		Findings:  findings,
+		ScannedAt: now,
+	}, nil
+}
+
+// Findings returns the stored findings of the topic's latest scan.
+func (s *IntegrityService) Findings(topicName string) ([]database.IntegrityFinding, error) {
+	if !s.app.TopicExists(topicName) {
+		return nil, ErrTopicNotFoundWithName(topicName)
+	}
+	db, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	findings, err := database.ListIntegrityFindings(db)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return findings, nil
+}
+
+// IssueCount returns the number of findings stored for a topic, or 0 when
+// they cannot be read.
+func (s *IntegrityService) IssueCount(topicName string) int {
+	return topicIntegrityIssues(s.app, topicName)
+}
+
+// topicIntegrityIssues counts stored findings for a healthy topic.
+func topicIntegrityIssues(app AppState, topicName string) int {
+	db, err := app.GetTopicDB(topicName)
+	if err != nil {
+		return 0
+	}
+	count, err := database.CountIntegrityFindings(db)
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// analyzeDatFile walks the recorded extents of one .dat file in offset order
+// and reports gaps, overlaps, extents past EOF and unrecorded trailing bytes.
+func analyzeDatFile(datPath, datFile string, extents []database.DatExtent, now int64) []database.IntegrityFinding {
+	var findings []database.IntegrityFinding
+	add := func(kind string, start, end int64, assetID, detail string) {
+		findings = append(findings, database.IntegrityFinding{
+			DatFile:     datFile,
+			Kind:        kind,
+			StartOffset: start,
+			EndOffset:   end,
+			AssetID:     assetID,
+			Detail:      detail,
+			DetectedAt:  now,
+		})
+	}
+
+	info, err := os.Stat(datPath)
+	if err != nil {
+		var end int64
+		for _, e := range extents {
+			end = max(end, extentEnd(e))
+		}
+		add(constants.IntegrityKindMissingDat, 0, end, "",
+			fmt.Sprintf("%d recorded asset(s) reference a .dat file that cannot be read: %v", len(extents), err))
+		return findings
+	}
+	fileSize := info.Size()
+
+	var cursor int64
+	var prev *database.DatExtent
+	for i := range extents {
+		e := &extents[i]
+		end := extentEnd(*e)
+
+		// A gap lying wholly past EOF is already reported as beyond_eof
+		switch {
+		case e.Offset > cursor && cursor < fileSize:
+			assetID, detail := probeRegion(datPath, cursor, min(e.Offset, fileSize))
+			add(constants.IntegrityKindGap, cursor, min(e.Offset, fileSize), assetID, detail)
+		case e.Offset < cursor && prev != nil:
+			add(constants.IntegrityKindOverlap, e.Offset, min(cursor, end), e.AssetID,
+				fmt.Sprintf("extent of %s overlaps extent of %s", e.AssetID, prev.AssetID))
+		}
+
+		if end > fileSize {
+			add(constants.IntegrityKindBeyondEOF, max(e.Offset, fileSize), end, e.AssetID,
+				fmt.Sprintf("extent of %s ends at %d but the file is %d bytes", e.AssetID, end, fileSize))
+		}
+
+		if end > cursor {
+			cursor = end
+			prev = e
+		}
+	}
+
+	if fileSize > cursor {
+		assetID, detail := probeRegion(datPath, cursor, fileSize)
+		add(constants.IntegrityKindTrailing, cursor, fileSize, assetID, detail)
+	}
+
+	return findings
+}
+
+// extentEnd returns one past the last byte of a recorded entry.
+func extentEnd(e database.DatExtent) int64 {
+	return e.Offset + int64(constants.HeaderSize) + e.Size
+}
+
+// probeRegion describes unrecorded bytes. When they start with a valid entry
+// header (typically an append whose database commit never happened) the
+// orphaned hash is returned with the description.
+func probeRegion(datPath string, start, end int64) (string, string) {
+	length := end - start
+	if length >= int64(constants.HeaderSize) {
+		if entry, err := storage.ReadHeader(datPath, start); err == nil {
+			complete := "complete"
+			if int64(constants.HeaderSize)+int64(entry.DataLength) > length {
+				complete = "truncated"
+			}
+			return entry.Hash, fmt.Sprintf("%d unrecorded bytes starting with a %s orphaned entry for %s (%d data bytes)",
+				length, complete, entry.Hash, entry.DataLength)
+		}
+	}
+	return "", fmt.Sprintf("%d unrecorded bytes", length)
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/storage"
+)
+
+// newIntegrityTest creates a topic directory and database for scanning.
+func newIntegrityTest(t *testing.T) (*IntegrityService, *sql.DB, string) {
+	t.Helper()
+	mock := newMockAppState()
+	mock.workingDir = t.TempDir()
+	mock.log = logger.NewLogger("error")
+
+	topicPath := mock.GetTopicPath("assets")
+	if err := os.MkdirAll(topicPath, 0755); err != nil {
+		t.Fatalf("mkdir topic: %v", err)
+	}
+	db, err := database.InitTopicDB(filepath.Join(mock.workingDir, "assets.db"))
+	if err != nil {
+		t.Fatalf("init topic db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mock.StoreTopicDB("assets", db)
+	mock.RegisterTopic("assets", true, "")
+
+	return NewIntegrityService(mock, mock.log), db, topicPath
+}
+
+// appendAsset writes an entry to the .dat file and optionally records it.
+func appendAsset(t *testing.T, db *sql.DB, topicPath, blob, hash string, data []byte, record bool) int64 {
+	t.Helper()
+	offset, err := storage.AppendEntry(filepath.Join(topicPath, blob), hash, data)
+	if err != nil {
+		t.Fatalf("append entry: %v", err)
+	}
+	if record {
+		recordAsset(t, db, blob, hash, offset, int64(len(data)))
+	}
+	return offset
+}
+
+// recordAsset inserts an asset row without touching the .dat file.
+func recordAsset(t *testing.T, db *sql.DB, blob, hash string, offset, size int64) {
+	t.Helper()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := database.InsertAsset(tx, database.Asset{
+		AssetID: hash, AssetSize: size, OriginName: hash[:4], Extension: "bin",
+		BlobName: blob, ByteOffset: offset, CreatedAt: 1,
+	}); err != nil {
+		tx.Rollback()
+		t.Fatalf("insert asset: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+}
+
+// scanFindings runs a scan and returns its findings.
+func scanFindings(t *testing.T, svc *IntegrityService) []database.IntegrityFinding {
+	t.Helper()
+	report, err := svc.ScanTopic("assets")
+	if err != nil {
+		t.Fatalf("ScanTopic: %v", err)
+	}
+	return report.Findings
+}
+
+func TestIntegrityScan_Clean(t *testing.T) {
+	svc, db, topicPath := newIntegrityTest(t)
+	blob := storage.FormatDatFilename(1)
+	appendAsset(t, db, topicPath, blob, lineageHash("a"), []byte("first"), true)
+	appendAsset(t, db, topicPath, blob, lineageHash("b"), []byte("second"), true)
+
+	if findings := scanFindings(t, svc); len(findings) != 0 {
+		t.Errorf("expected no findings, got %+v", findings)
+	}
+	if n := svc.IssueCount("assets"); n != 0 {
+		t.Errorf("IssueCount = %d, want 0", n)
+	}
+}
+
+func TestIntegrityScan_TrailingOrphan(t *testing.T) {
+	svc, db, topicPath := newIntegrityTest(t)
+	blob := storage.FormatDatFilename(1)
+	appendAsset(t, db, topicPath, blob, lineageHash("a"), []byte("recorded"), true)
+	orphanOffset := appendAsset(t, db, topicPath, blob, lineageHash("b"), []byte("never committed"), false)
+
+	findings := scanFindings(t, svc)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", findings)
+	}
+	f := findings[0]
+	if f.Kind != constants.IntegrityKindTrailing || f.StartOffset != orphanOffset {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.AssetID != lineageHash("b") {
+		t.Errorf("orphaned hash should be identified from its header, got %q", f.AssetID)
+	}
+
+	stored, err := svc.Findings("assets")
+	if err != nil {
+		t.Fatalf("Findings: %v", err)
+	}
+	if len(stored) != 1 || stored[0].Kind != constants.IntegrityKindTrailing {
+		t.Errorf("stored findings = %+v", stored)
+	}
+	if n := svc.IssueCount("assets"); n != 1 {
+		t.Errorf("IssueCount = %d, want 1", n)
+	}
+
+	// Recording the orphan clears the finding on the next scan
+	recordAsset(t, db, blob, lineageHash("b"), orphanOffset, int64(len("never committed")))
+	if findings := scanFindings(t, svc); len(findings) != 0 {
+		t.Errorf("expected findings to be replaced, got %+v", findings)
+	}
+}
+
+func TestIntegrityScan_GapAndOverlap(t *testing.T) {
+	svc, db, topicPath := newIntegrityTest(t)
+	blob := storage.FormatDatFilename(1)
+	appendAsset(t, db, topicPath, blob, lineageHash("a"), []byte("gap bytes"), false)
+	second := appendAsset(t, db, topicPath, blob, lineageHash("b"), []byte("recorded"), true)
+	// A second row claiming bytes inside the recorded entry
+	recordAsset(t, db, blob, lineageHash("c"), second+4, 2)
+
+	findings := scanFindings(t, svc)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if findings[0].Kind != constants.IntegrityKindGap || findings[0].StartOffset != 0 || findings[0].EndOffset != second {
+		t.Errorf("unexpected gap finding: %+v", findings[0])
+	}
+	if findings[0].AssetID != lineageHash("a") {
+		t.Errorf("gap should identify the orphaned entry, got %q", findings[0].AssetID)
+	}
+	if findings[1].Kind != constants.IntegrityKindOverlap || findings[1].AssetID != lineageHash("c") {
+		t.Errorf("unexpected overlap finding: %+v", findings[1])
+	}
+}
+
+func TestIntegrityScan_BeyondEOFAndMissingDat(t *testing.T) {
+	svc, db, topicPath := newIntegrityTest(t)
+	blob := storage.FormatDatFilename(1)
+	offset := appendAsset(t, db, topicPath, blob, lineageHash("a"), []byte("short"), false)
+	recordAsset(t, db, blob, lineageHash("a"), offset, 1000)
+	recordAsset(t, db, storage.FormatDatFilename(2), lineageHash("b"), 0, 10)
+
+	findings := scanFindings(t, svc)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if findings[0].Kind != constants.IntegrityKindBeyondEOF || findings[0].AssetID != lineageHash("a") {
+		t.Errorf("unexpected beyond_eof finding: %+v", findings[0])
+	}
+	if findings[1].Kind != constants.IntegrityKindMissingDat || findings[1].DatFile != storage.FormatDatFilename(2) {
+		t.Errorf("unexpected missing_dat finding: %+v", findings[1])
+	}
+}
+
+func TestIntegrityScan_Errors(t *testing.T) {
+	svc, _, _ := newIntegrityTest(t)
+	if _, err := svc.ScanTopic("nope"); err == nil {
+		t.Error("expected error for unknown topic")
+	}
+
+	unconfigured := NewIntegrityService(newMockAppState(), logger.NewLogger("error"))
+	if _, err := unconfigured.ScanTopic("assets"); err != ErrNotConfigured {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+// LimitsService exposes the effective request and result limits and applies
+// changes to them at runtime, without a restart.
+type LimitsService struct {
+	app    AppState
+	logger *logger.Logger
+	mu     sync.Mutex
+}
+
+// NewLimitsService creates a new limits service instance.
+func NewLimitsService(app AppState, log *logger.Logger) *LimitsService {
+	return &LimitsService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// Limits lists the limits clients must respect. The read-only fields are
+// fixed at build time or startup and cannot be changed via Update.
+type Limits struct {
+	QueryMaxRows          int `json:"query_max_rows"`
+	FederatedQueryMaxRows int `json:"federated_query_max_rows"`
+	BatchMaxOperations    int `json:"batch_max_operations"`
+	MetadataMaxValueBytes int `json:"metadata_max_value_bytes"`
+	BulkDownloadMaxAssets int `json:"bulk_download_max_assets"`
+
+	// Read-only
+	MaxDatSize                   int64 `json:"max_dat_size"`
+	MetadataMaxKeyLength         int   `json:"metadata_max_key_length"`
+	CollectionMaxAssetsPerChange int   `json:"collection_max_assets_per_change"`
+	QueryMaxRowsCeiling          int   `json:"query_max_rows_ceiling"`
+}
+
+// LimitsUpdate changes the given limits. Omitted fields keep their value.
+type LimitsUpdate struct {
+	QueryMaxRows          *int `json:"query_max_rows"`
+	FederatedQueryMaxRows *int `json:"federated_query_max_rows"`
+	BatchMaxOperations    *int `json:"batch_max_operations"`
+	MetadataMaxValueBytes *int `json:"metadata_max_value_bytes"`
+	BulkDownloadMaxAssets *int `json:"bulk_download_max_assets"`
+}
+
+// Get returns the effective limits.
+func (s *LimitsService) Get() *Limits {
+	cfg := s.app.GetConfig()
+	return &Limits{
+		QueryMaxRows:                 cfg.Query.MaxRows,
+		FederatedQueryMaxRows:        cfg.Query.FederatedMaxRows,
+		BatchMaxOperations:           cfg.Batch.MaxOperations,
+		MetadataMaxValueBytes:        cfg.Metadata.MaxValueBytes,
+		BulkDownloadMaxAssets:        cfg.BulkDownload.MaxAssets,
+		MaxDatSize:                   cfg.MaxDatSize,
+		MetadataMaxKeyLength:         constants.MaxMetadataKeyLength,
+		CollectionMaxAssetsPerChange: constants.MaxCollectionAssetsPerChange,
+		QueryMaxRowsCeiling:          constants.QueryMaxRowsCeiling,
+	}
+}
+
+// Update validates the changed limits, applies them to the running config
+// and saves it. Returns the effective limits and the config fields that changed.
+func (s *LimitsService) Update(req *LimitsUpdate) (*Limits, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg := s.app.GetConfig()
+	candidate := *cfg
+	var changed []string
+	set := func(field string, dst *int, value *int) {
+		if value != nil && *value != *dst {
+			*dst = *value
+			changed = append(changed, field)
+		}
+	}
+	set("query.max_rows", &candidate.Query.MaxRows, req.QueryMaxRows)
+	set("query.federated_max_rows", &candidate.Query.FederatedMaxRows, req.FederatedQueryMaxRows)
+	set("batch.max_operations", &candidate.Batch.MaxOperations, req.BatchMaxOperations)
+	set("metadata.max_value_bytes", &candidate.Metadata.MaxValueBytes, req.MetadataMaxValueBytes)
+	set("bulk_download.max_assets", &candidate.BulkDownload.MaxAssets, req.BulkDownloadMaxAssets)
+
+	if len(changed) == 0 {
+		return s.Get(), []string{}, nil
+	}
+
+	var problems []string
+	for _, fe := range candidate.FieldErrors() {
+		for _, field := range changed {
+			if fe.Field == field {
+				problems = append(problems, fe.Message)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, nil, NewServiceError(constants.ErrCodeInvalidLimits, strings.Join(problems, "; "))
+	}
+
+	cfg.Query = candidate.Query
+	cfg.Batch = candidate.Batch
+	cfg.Metadata = candidate.Metadata
+	cfg.BulkDownload = candidate.BulkDownload
+	if err := config.SaveConfig(cfg); err != nil {
+		return nil, nil, WrapInternalError(fmt.Errorf("failed to save config: %w", err))
+	}
+
+	s.logger.Info("Limits updated: %s", strings.Join(changed, ", "))
+	return s.Get(), changed, nil
+}
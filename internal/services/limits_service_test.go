@@ -0,0 +1,58 @@
+package services
+
+import (
+	"os"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestLimitsUpdate_AppliesAndSaves(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newConfigMock(t.TempDir())
+	svc := NewLimitsService(m, m.log)
+
+	limits, changed, err := svc.Update(&LimitsUpdate{
+		QueryMaxRows:       intPtr(500),
+		BatchMaxOperations: intPtr(m.cfg.Batch.MaxOperations), // unchanged
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if len(changed) != 1 || changed[0] != "query.max_rows" {
+		t.Errorf("expected only query.max_rows to change, got %v", changed)
+	}
+	if limits.QueryMaxRows != 500 || m.cfg.Query.MaxRows != 500 {
+		t.Errorf("expected running config updated, got %d / %d", limits.QueryMaxRows, m.cfg.Query.MaxRows)
+	}
+
+	if _, err := os.Stat(config.GetConfigPath()); err != nil {
+		t.Errorf("expected config to be saved: %v", err)
+	}
+}
+
+func TestLimitsUpdate_RejectsInsaneValues(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newConfigMock(t.TempDir())
+	svc := NewLimitsService(m, m.log)
+
+	for _, req := range []*LimitsUpdate{
+		{QueryMaxRows: intPtr(0)},
+		{FederatedQueryMaxRows: intPtr(constants.QueryMaxRowsCeiling + 1)},
+		{BatchMaxOperations: intPtr(-5)},
+		{MetadataMaxValueBytes: intPtr(constants.MetadataMaxValueBytesCeiling + 1)},
+		{QueryMaxRows: intPtr(100), BulkDownloadMaxAssets: intPtr(0)},
+	} {
+		if _, _, err := svc.Update(req); !isServiceErrorCode(err, constants.ErrCodeInvalidLimits) {
+			t.Errorf("%+v: expected %s, got %v", req, constants.ErrCodeInvalidLimits, err)
+		}
+	}
+
+	// Rejected updates change nothing, even their valid fields
+	if m.cfg.Query.MaxRows != constants.QueryDefaultMaxRows {
+		t.Errorf("expected query.max_rows unchanged, got %d", m.cfg.Query.MaxRows)
+	}
+}
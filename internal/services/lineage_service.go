@@ -0,0 +1,637 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/version"
+)
+
+// BOMEntry is one asset in a bill of materials.
+type BOMEntry struct {
+	Hash       string                 `json:"hash"`
+	Topic      string                 `json:"topic"`
+	OriginName string                 `json:"origin_name"`
+	Extension  string                 `json:"extension"`
+	Size       int64                  `json:"size"`
+	CreatedAt  int64                  `json:"created_at"`
+	ParentIDs  []string               `json:"parent_ids"`
+	Depth      int                    `json:"depth"` // shortest lineage distance from the root
+	Metadata   map[string]interface{} `json:"metadata"`
+}
+
+// BOMMissing is an ancestor referenced by lineage that could not be resolved.
+type BOMMissing struct {
+	Hash   string `json:"hash"`
+	Reason string `json:"reason"`
+}
+
+// BOM is the flattened, deduplicated set of source assets a derived asset
+// was built from, across topics.
+type BOM struct {
+	Root        BOMEntry     `json:"root"`
+	Sources     []BOMEntry   `json:"sources"`
+	SourceCount int          `json:"source_count"`
+	TotalSize   int64        `json:"total_size"` // sum of source sizes
+	Topics      []string     `json:"topics"`     // topics of the root and every source
+	Missing     []BOMMissing `json:"missing"`
+	Truncated   bool         `json:"truncated"`
+	GeneratedAt int64        `json:"generated_at"`
+}
+
+// LineageService walks asset lineage across topics.
+type LineageService struct {
+	app    AppState
+	logger *logger.Logger
+
+	reparentMu sync.Mutex // serializes re-parenting runs
+}
+
+// NewLineageService creates a new lineage service instance.
+func NewLineageService(app AppState, log *logger.Logger) *LineageService {
+	return &LineageService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// BillOfMaterials returns every ancestor of hash, each listed once at its
+// shortest distance, with a snapshot of its computed metadata. Ancestors that
+// are no longer indexed or live in unhealthy topics are reported in Missing.
+// At most constants.BOMMaxAssets sources are collected.
+func (s *LineageService) BillOfMaterials(hash string) (*BOM, error) {
+	if len(hash) != constants.HashLength {
+		return nil, ErrInvalidHash
+	}
+	if s.app.GetWorkingDirectory() == "" {
+		return nil, ErrNotConfigured
+	}
+
+	root, err := s.loadEntry(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	bom := &BOM{
+		Root:        *root,
+		Sources:     []BOMEntry{},
+		Missing:     []BOMMissing{},
+		GeneratedAt: time.Now().Unix(),
+	}
+
+	topics := map[string]bool{root.Topic: true}
+	visited := map[string]bool{hash: true}
+	queue := []*BOMEntry{root}
+
+	// Breadth-first so each ancestor is recorded at its shortest depth
+	for len(queue) > 0 && !bom.Truncated {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, parentID := range current.ParentIDs {
+			if visited[parentID] {
+				continue
+			}
+			visited[parentID] = true
+
+			if len(bom.Sources) >= constants.BOMMaxAssets {
+				bom.Truncated = true
+				break
+			}
+
+			entry, err := s.loadEntry(parentID)
+			if err != nil {
+				if svcErr, ok := err.(*ServiceError); ok && svcErr.Code != constants.ErrCodeInternalError {
+					bom.Missing = append(bom.Missing, BOMMissing{Hash: parentID, Reason: svcErr.Message})
+					continue
+				}
+				return nil, err
+			}
+			entry.Depth = current.Depth + 1
+
+			bom.Sources = append(bom.Sources, *entry)
+			bom.TotalSize += entry.Size
+			topics[entry.Topic] = true
+			queue = append(queue, entry)
+		}
+	}
+
+	bom.SourceCount = len(bom.Sources)
+	bom.Topics = make([]string, 0, len(topics))
+	for topic := range topics {
+		bom.Topics = append(bom.Topics, topic)
+	}
+	sort.Strings(bom.Topics)
+
+	if bom.Truncated {
+		s.logger.Warn("Bill of materials for %s truncated at %d sources", hash, constants.BOMMaxAssets)
+	}
+
+	return bom, nil
+}
+
+// loadEntry resolves an asset through the orchestrator index and reads its
+// row and computed metadata from the owning topic.
+func (s *LineageService) loadEntry(hash string) (*BOMEntry, error) {
+	asset, topicName, err := s.loadAsset(hash)
+	if err != nil {
+		return nil, err
+	}
+	topicDB, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	computed, err := database.GetMetadataComputed(topicDB, hash)
+	if err != nil {
+		s.logger.Warn("Failed to get computed metadata for %s: %v", hash, err)
+		computed = make(map[string]interface{})
+	}
+
+	parents := []string{}
+	if asset.ParentID != nil && *asset.ParentID != "" {
+		parents = append(parents, *asset.ParentID)
+	}
+
+	return &BOMEntry{
+		Hash:       hash,
+		Topic:      topicName,
+		OriginName: asset.OriginName,
+		Extension:  asset.Extension,
+		Size:       asset.AssetSize,
+		CreatedAt:  asset.CreatedAt,
+		ParentIDs:  parents,
+		Metadata:   computed,
+	}, nil
+}
+
+// =============================================================================
+// SPDX export
+// =============================================================================
+
+// SPDXDocument is an SPDX 2.3 style document describing a BOM. Each asset is
+// an SPDX file; lineage edges are GENERATED_FROM relationships.
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	DocumentDescribes []string           `json:"documentDescribes"`
+	Files             []SPDXFile         `json:"files"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+// SPDXCreationInfo records when and by what the document was produced.
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// SPDXFile describes one asset.
+type SPDXFile struct {
+	SPDXID           string                 `json:"SPDXID"`
+	FileName         string                 `json:"fileName"`
+	Checksums        []SPDXChecksum         `json:"checksums"`
+	LicenseConcluded string                 `json:"licenseConcluded"`
+	CopyrightText    string                 `json:"copyrightText"`
+	FileSize         int64                  `json:"silobang:size"`
+	Topic            string                 `json:"silobang:topic"`
+	MetadataSnapshot map[string]interface{} `json:"silobang:metadata,omitempty"`
+}
+
+// SPDXChecksum is a file checksum.
+type SPDXChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+// SPDXRelationship links two SPDX elements.
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// ToSPDX converts the BOM to an SPDX-like JSON document.
+func (b *BOM) ToSPDX() *SPDXDocument {
+	created := time.Unix(b.GeneratedAt, 0).UTC()
+	doc := &SPDXDocument{
+		SPDXVersion:       constants.SPDXVersion,
+		DataLicense:       constants.SPDXDataLicense,
+		SPDXID:            constants.SPDXDocumentID,
+		Name:              bomFileName(&b.Root),
+		DocumentNamespace: fmt.Sprintf("%s%s:%d", constants.SPDXNamespacePrefix, b.Root.Hash, b.GeneratedAt),
+		CreationInfo: SPDXCreationInfo{
+			Created:  created.Format(time.RFC3339),
+			Creators: []string{fmt.Sprintf("Tool: %s-%s", constants.AppName, version.Version)},
+		},
+		DocumentDescribes: []string{spdxFileID(b.Root.Hash)},
+		Files:             make([]SPDXFile, 0, len(b.Sources)+1),
+		Relationships:     []SPDXRelationship{},
+	}
+
+	doc.Relationships = append(doc.Relationships, SPDXRelationship{
+		SPDXElementID:      constants.SPDXDocumentID,
+		RelationshipType:   "DESCRIBES",
+		RelatedSPDXElement: spdxFileID(b.Root.Hash),
+	})
+
+	included := make(map[string]bool, len(b.Sources)+1)
+	entries := append([]BOMEntry{b.Root}, b.Sources...)
+	for i := range entries {
+		included[entries[i].Hash] = true
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		doc.Files = append(doc.Files, SPDXFile{
+			SPDXID:           spdxFileID(entry.Hash),
+			FileName:         bomFileName(entry),
+			Checksums:        []SPDXChecksum{{Algorithm: constants.SPDXChecksumAlgorithm, ChecksumValue: entry.Hash}},
+			LicenseConcluded: constants.SPDXNoAssertion,
+			CopyrightText:    constants.SPDXNoAssertion,
+			FileSize:         entry.Size,
+			Topic:            entry.Topic,
+			MetadataSnapshot: entry.Metadata,
+		})
+
+		for _, parentID := range entry.ParentIDs {
+			if !included[parentID] {
+				continue
+			}
+			doc.Relationships = append(doc.Relationships, SPDXRelationship{
+				SPDXElementID:      spdxFileID(entry.Hash),
+				RelationshipType:   "GENERATED_FROM",
+				RelatedSPDXElement: spdxFileID(parentID),
+			})
+		}
+	}
+
+	return doc
+}
+
+// spdxFileID returns the SPDX element ID for an asset hash.
+func spdxFileID(hash string) string {
+	return constants.SPDXFileIDPrefix + hash
+}
+
+// bomFileName returns the original filename of an entry, or its hash.
+func bomFileName(entry *BOMEntry) string {
+	name := entry.OriginName
+	if name == "" {
+		name = entry.Hash
+	}
+	if entry.Extension != "" {
+		name += "." + entry.Extension
+	}
+	return name
+}
+
+// =============================================================================
+// Re-parenting
+// =============================================================================
+
+// ReparentChange sets the parent of an asset. An empty Parent removes it.
+type ReparentChange struct {
+	Hash   string `json:"hash"`
+	Parent string `json:"parent"`
+}
+
+// ReparentItem is one validated change.
+type ReparentItem struct {
+	Hash        string  `json:"hash"`
+	Topic       string  `json:"topic"`
+	OldParent   *string `json:"old_parent"`
+	NewParent   *string `json:"new_parent"`
+	ParentTopic string  `json:"parent_topic,omitempty"`
+	Changed     bool    `json:"changed"` // false when the asset already has the parent
+
+	index int // position in the request
+}
+
+// ReparentError explains why one change was rejected.
+type ReparentError struct {
+	Index   int    `json:"index"`
+	Hash    string `json:"hash"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ReparentResult is the outcome of a re-parenting run. When Errors is not
+// empty nothing was applied.
+type ReparentResult struct {
+	DryRun    bool            `json:"dry_run"`
+	Total     int             `json:"total"`
+	Changed   int             `json:"changed"`
+	Unchanged int             `json:"unchanged"`
+	Topics    []string        `json:"topics"` // topics of the changed assets and of their new parents
+	Items     []ReparentItem  `json:"items"`
+	Errors    []ReparentError `json:"errors"`
+}
+
+// PlanReparent validates a set of changes without applying them. Every asset
+// and new parent must be indexed, each asset may appear once, and no change
+// may make an asset its own ancestor once the whole set is applied. Returns
+// the result with an ErrCodeLineageReparentInvalid error when any change is
+// rejected.
+func (s *LineageService) PlanReparent(changes []ReparentChange) (*ReparentResult, error) {
+	if s.app.GetWorkingDirectory() == "" {
+		return nil, ErrNotConfigured
+	}
+	if len(changes) == 0 {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "no changes given")
+	}
+
+	index := s.app.GetAssetIndex()
+	result := &ReparentResult{
+		DryRun: true,
+		Total:  len(changes),
+		Topics: []string{},
+		Items:  []ReparentItem{},
+		Errors: []ReparentError{},
+	}
+	reject := func(i int, hash, code, message string) {
+		result.Errors = append(result.Errors, ReparentError{Index: i, Hash: hash, Code: code, Message: message})
+	}
+
+	seen := make(map[string]bool, len(changes))
+	for i, change := range changes {
+		if !isHexHash(change.Hash) {
+			reject(i, change.Hash, constants.ErrCodeInvalidHash, "invalid asset hash")
+			continue
+		}
+		if change.Parent != "" && !isHexHash(change.Parent) {
+			reject(i, change.Hash, constants.ErrCodeInvalidHash, "invalid parent hash")
+			continue
+		}
+		if seen[change.Hash] {
+			reject(i, change.Hash, constants.ErrCodeInvalidRequest, "asset appears more than once")
+			continue
+		}
+		seen[change.Hash] = true
+		if change.Parent == change.Hash {
+			reject(i, change.Hash, constants.ErrCodeLineageCycle, "an asset cannot be its own parent")
+			continue
+		}
+
+		asset, topicName, err := s.loadAsset(change.Hash)
+		if err != nil {
+			if svcErr, ok := err.(*ServiceError); ok && svcErr.Code != constants.ErrCodeInternalError {
+				reject(i, change.Hash, svcErr.Code, svcErr.Message)
+				continue
+			}
+			return nil, err
+		}
+
+		item := ReparentItem{Hash: change.Hash, Topic: topicName, index: i}
+		if asset.ParentID != nil && *asset.ParentID != "" {
+			item.OldParent = asset.ParentID
+		}
+		if change.Parent != "" {
+			exists, parentTopic, _, err := index.Lookup(change.Parent)
+			if err != nil {
+				return nil, WrapInternalError(err)
+			}
+			if !exists {
+				reject(i, change.Hash, constants.ErrCodeParentNotFound, "parent "+change.Parent+" not found")
+				continue
+			}
+			parent := change.Parent
+			item.NewParent = &parent
+			item.ParentTopic = parentTopic
+		}
+		item.Changed = derefParent(item.OldParent) != change.Parent
+		result.Items = append(result.Items, item)
+	}
+
+	// Walk up from each new parent through the lineage as it will be once
+	// the valid changes are applied
+	parents := make(map[string]string, len(result.Items))
+	for _, item := range result.Items {
+		parents[item.Hash] = derefParent(item.NewParent)
+	}
+	known := map[string]string{}
+	for _, item := range result.Items {
+		if !item.Changed || item.NewParent == nil {
+			continue
+		}
+		cyclic, err := s.descendsFrom(*item.NewParent, item.Hash, parents, known)
+		if err != nil {
+			if svcErr, ok := err.(*ServiceError); ok && svcErr.Code != constants.ErrCodeInternalError {
+				reject(item.index, item.Hash, svcErr.Code, svcErr.Message)
+				continue
+			}
+			return nil, err
+		}
+		if cyclic {
+			reject(item.index, item.Hash, constants.ErrCodeLineageCycle,
+				"parent "+*item.NewParent+" descends from the asset")
+		}
+	}
+	sort.SliceStable(result.Errors, func(a, b int) bool { return result.Errors[a].Index < result.Errors[b].Index })
+
+	topics := map[string]bool{}
+	for _, item := range result.Items {
+		if !item.Changed {
+			result.Unchanged++
+			continue
+		}
+		result.Changed++
+		topics[item.Topic] = true
+		if item.ParentTopic != "" {
+			topics[item.ParentTopic] = true
+		}
+	}
+	for topic := range topics {
+		result.Topics = append(result.Topics, topic)
+	}
+	sort.Strings(result.Topics)
+
+	if len(result.Errors) > 0 {
+		return result, NewServiceError(constants.ErrCodeLineageReparentInvalid,
+			fmt.Sprintf("%d of %d changes are invalid; none were applied", len(result.Errors), len(changes)))
+	}
+	return result, nil
+}
+
+// Reparent validates and applies a set of changes. Nothing is applied when
+// any change is rejected. Each topic's changes commit in one transaction with
+// their lineage change log entries, and the orchestrator's lineage
+// references are moved to the new parents.
+func (s *LineageService) Reparent(changes []ReparentChange, by, reason string) (*ReparentResult, error) {
+	s.reparentMu.Lock()
+	defer s.reparentMu.Unlock()
+
+	result, err := s.PlanReparent(changes)
+	if err != nil {
+		return result, err
+	}
+	result.DryRun = false
+
+	byTopic := map[string][]ReparentItem{}
+	var topicNames []string
+	for _, item := range result.Items {
+		if !item.Changed {
+			continue
+		}
+		if _, ok := byTopic[item.Topic]; !ok {
+			topicNames = append(topicNames, item.Topic)
+		}
+		byTopic[item.Topic] = append(byTopic[item.Topic], item)
+	}
+	sort.Strings(topicNames)
+
+	now := time.Now().Unix()
+	for _, topicName := range topicNames {
+		if err := s.applyReparent(topicName, byTopic[topicName], now, by, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Changed > 0 {
+		s.logger.Info("Re-parented %d assets across %d topics by %s", result.Changed, len(topicNames), by)
+	}
+	return result, nil
+}
+
+// applyReparent commits the changes of one topic.
+func (s *LineageService) applyReparent(topicName string, items []ReparentItem, now int64, by, reason string) error {
+	// Serialized with uploads and deletions of the topic
+	topicMu := s.app.GetTopicWriteMu(topicName)
+	topicMu.Lock()
+	defer topicMu.Unlock()
+
+	topicDB, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	orchDB := s.app.GetOrchestratorDB()
+
+	txTopic, err := topicDB.Begin()
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	defer txTopic.Rollback()
+
+	txOrch, err := orchDB.Begin()
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	defer txOrch.Rollback()
+
+	for _, item := range items {
+		if err := database.UpdateAssetParent(txTopic, database.LineageChange{
+			AssetID:     item.Hash,
+			OldParentID: item.OldParent,
+			NewParentID: item.NewParent,
+			ChangedAt:   now,
+			ChangedBy:   by,
+			Reason:      reason,
+		}); err != nil {
+			return WrapInternalError(fmt.Errorf("failed to re-parent %s: %w", item.Hash, err))
+		}
+
+		ref := database.AssetReference{Kind: constants.ReferenceKindLineage, Topic: topicName, Holder: item.Hash, CreatedAt: now}
+		if item.OldParent != nil {
+			ref.Hash = *item.OldParent
+			if err := database.DeleteAssetReference(txOrch, ref); err != nil {
+				return WrapInternalError(fmt.Errorf("failed to release lineage reference: %w", err))
+			}
+		}
+		if item.NewParent != nil {
+			ref.Hash = *item.NewParent
+			if err := database.InsertAssetReference(txOrch, ref); err != nil {
+				return WrapInternalError(fmt.Errorf("failed to register lineage reference: %w", err))
+			}
+		}
+	}
+
+	if err := txTopic.Commit(); err != nil {
+		return WrapInternalError(fmt.Errorf("failed to commit topic transaction: %w", err))
+	}
+	if err := txOrch.Commit(); err != nil {
+		s.logger.Warn("Orchestrator commit failed after re-parenting %d assets of %s: %v", len(items), topicName, err)
+	}
+	return nil
+}
+
+// descendsFrom reports whether hash reaches ancestor by following parents,
+// using the planned parents before the stored ones. Stored parents are
+// memoized in known. Lineage ending at an asset that is no longer indexed
+// stops the walk.
+func (s *LineageService) descendsFrom(hash, ancestor string, planned, known map[string]string) (bool, error) {
+	visited := map[string]bool{}
+	for hash != "" {
+		if hash == ancestor {
+			return true, nil
+		}
+		if visited[hash] {
+			return false, nil
+		}
+		visited[hash] = true
+
+		if parent, ok := planned[hash]; ok {
+			hash = parent
+			continue
+		}
+		parent, ok := known[hash]
+		if !ok {
+			asset, _, err := s.loadAsset(hash)
+			if err != nil {
+				if svcErr, ok := err.(*ServiceError); ok && svcErr.Code == constants.ErrCodeAssetNotFound {
+					return false, nil
+				}
+				return false, err
+			}
+			if asset.ParentID != nil {
+				parent = *asset.ParentID
+			}
+			known[hash] = parent
+		}
+		hash = parent
+	}
+	return false, nil
+}
+
+// derefParent returns the parent hash, or "" for none.
+func derefParent(parent *string) string {
+	if parent == nil {
+		return ""
+	}
+	return *parent
+}
+
+// loadAsset resolves an asset through the orchestrator index and reads its
+// row from the owning topic.
+func (s *LineageService) loadAsset(hash string) (*database.Asset, string, error) {
+	exists, topicName, _, err := s.app.GetAssetIndex().Lookup(hash)
+	if err != nil {
+		return nil, "", WrapInternalError(err)
+	}
+	if !exists {
+		return nil, "", ErrAssetNotFoundWithHash(hash)
+	}
+	if healthy, errMsg := s.app.IsTopicHealthy(topicName); !healthy {
+		return nil, "", ErrTopicUnhealthyWithReason(topicName, errMsg)
+	}
+
+	topicDB, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, "", WrapInternalError(err)
+	}
+	asset, err := database.GetAsset(topicDB, hash)
+	if err != nil {
+		return nil, "", WrapInternalError(err)
+	}
+	if asset == nil {
+		return nil, "", ErrAssetNotFoundWithHash(hash)
+	}
+	return asset, topicName, nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// newLineageTest creates an orchestrator DB and the given topics on disk.
+func newLineageTest(t *testing.T, topics ...string) (*LineageService, *mockAppState) {
+	t.Helper()
+	mock := newMockAppState()
+	mock.workingDir = t.TempDir()
+	mock.log = logger.NewLogger("error")
+
+	orchDB, err := database.InitOrchestratorDB(filepath.Join(mock.workingDir, constants.OrchestratorDB))
+	if err != nil {
+		t.Fatalf("init orchestrator: %v", err)
+	}
+	t.Cleanup(func() { orchDB.Close() })
+	mock.orchestratorDB = orchDB
+
+	for _, topic := range topics {
+		db, err := database.InitTopicDB(filepath.Join(mock.workingDir, topic+".db"))
+		if err != nil {
+			t.Fatalf("init topic %s: %v", topic, err)
+		}
+		t.Cleanup(func() { db.Close() })
+		mock.StoreTopicDB(topic, db)
+		mock.RegisterTopic(topic, true, "")
+	}
+
+	return NewLineageService(mock, mock.log), mock
+}
+
+// addLineageAsset indexes an asset with an optional parent.
+func addLineageAsset(t *testing.T, mock *mockAppState, topic, hash, name string, size int64, parent string) {
+	t.Helper()
+	var parentID interface{}
+	if parent != "" {
+		parentID = parent
+	}
+	if _, err := mock.topicDBs[topic].Exec(
+		`INSERT INTO assets (asset_id, asset_size, origin_name, parent_id, extension, blob_name, byte_offset, created_at) VALUES (?, ?, ?, ?, 'bin', '000001.dat', 0, 1)`,
+		hash, size, name, parentID,
+	); err != nil {
+		t.Fatalf("insert asset: %v", err)
+	}
+	if err := database.InsertAssetIndexIgnore(mock.orchestratorDB, hash, topic, "000001.dat"); err != nil {
+		t.Fatalf("insert index: %v", err)
+	}
+}
+
+func lineageHash(c string) string {
+	return strings.Repeat(c, constants.HashLength)
+}
+
+func TestLineage_BillOfMaterialsAcrossTopics(t *testing.T) {
+	svc, mock := newLineageTest(t, "raw", "builds")
+
+	source, intermediate, final := lineageHash("a"), lineageHash("b"), lineageHash("c")
+	addLineageAsset(t, mock, "raw", source, "texture", 100, "")
+	addLineageAsset(t, mock, "builds", intermediate, "atlas", 50, source)
+	addLineageAsset(t, mock, "builds", final, "bundle", 10, intermediate)
+
+	if _, err := mock.topicDBs["raw"].Exec(
+		`INSERT INTO metadata_computed (asset_id, metadata_json, updated_at) VALUES (?, '{"license":"cc0"}', 1)`, source,
+	); err != nil {
+		t.Fatalf("insert metadata: %v", err)
+	}
+
+	bom, err := svc.BillOfMaterials(final)
+	if err != nil {
+		t.Fatalf("BillOfMaterials failed: %v", err)
+	}
+
+	if bom.SourceCount != 2 || bom.TotalSize != 150 {
+		t.Fatalf("expected 2 sources totalling 150 bytes, got %d / %d", bom.SourceCount, bom.TotalSize)
+	}
+	if bom.Sources[0].Hash != intermediate || bom.Sources[0].Depth != 1 {
+		t.Errorf("expected intermediate at depth 1, got %+v", bom.Sources[0])
+	}
+	if bom.Sources[1].Hash != source || bom.Sources[1].Depth != 2 || bom.Sources[1].Topic != "raw" {
+		t.Errorf("expected source at depth 2 in raw, got %+v", bom.Sources[1])
+	}
+	if bom.Sources[1].Metadata["license"] != "cc0" {
+		t.Errorf("expected metadata snapshot, got %v", bom.Sources[1].Metadata)
+	}
+	if len(bom.Topics) != 2 || bom.Topics[0] != "builds" || bom.Topics[1] != "raw" {
+		t.Errorf("topics = %v, want [builds raw]", bom.Topics)
+	}
+}
+
+func TestLineage_BillOfMaterialsMissingAncestor(t *testing.T) {
+	svc, mock := newLineageTest(t, "builds")
+
+	gone, final := lineageHash("d"), lineageHash("e")
+	addLineageAsset(t, mock, "builds", final, "bundle", 10, gone)
+
+	bom, err := svc.BillOfMaterials(final)
+	if err != nil {
+		t.Fatalf("BillOfMaterials failed: %v", err)
+	}
+	if bom.SourceCount != 0 || len(bom.Missing) != 1 || bom.Missing[0].Hash != gone {
+		t.Errorf("expected the unresolved parent in missing, got %+v", bom)
+	}
+}
+
+func TestLineage_BillOfMaterialsNotFound(t *testing.T) {
+	svc, _ := newLineageTest(t, "builds")
+
+	_, err := svc.BillOfMaterials(lineageHash("f"))
+	if code, ok := IsServiceError(err); !ok || code != constants.ErrCodeAssetNotFound {
+		t.Fatalf("expected %s, got %v", constants.ErrCodeAssetNotFound, err)
+	}
+}
+
+func TestBOM_ToSPDX(t *testing.T) {
+	svc, mock := newLineageTest(t, "builds")
+
+	source, final := lineageHash("1"), lineageHash("2")
+	addLineageAsset(t, mock, "builds", source, "mesh", 20, "")
+	addLineageAsset(t, mock, "builds", final, "model", 5, source)
+
+	bom, err := svc.BillOfMaterials(final)
+	if err != nil {
+		t.Fatalf("BillOfMaterials failed: %v", err)
+	}
+
+	doc := bom.ToSPDX()
+	if doc.SPDXVersion != constants.SPDXVersion || len(doc.Files) != 2 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	if doc.Files[0].FileName != "model.bin" || doc.Files[0].Checksums[0].ChecksumValue != final {
+		t.Errorf("unexpected root file: %+v", doc.Files[0])
+	}
+
+	var generatedFrom int
+	for _, rel := range doc.Relationships {
+		if rel.RelationshipType == "GENERATED_FROM" {
+			generatedFrom++
+			if rel.SPDXElementID != spdxFileID(final) || rel.RelatedSPDXElement != spdxFileID(source) {
+				t.Errorf("unexpected relationship: %+v", rel)
+			}
+		}
+	}
+	if generatedFrom != 1 {
+		t.Errorf("expected 1 GENERATED_FROM relationship, got %d", generatedFrom)
+	}
+}
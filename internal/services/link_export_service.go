@@ -0,0 +1,534 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zeebo/blake3"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// LinkExportService manages link exports: directories of hard links for
+// consumers that mount the host, such as render farms. Each selected asset
+// is extracted once from its .dat file into a read-only copy under
+// .internal/links/objects/, and every export selecting it gets a hard link to
+// that copy (a plain copy where the filesystem refuses links).
+//
+// Exports are refreshed incrementally: selecting adds only the assets not yet
+// linked and releasing removes single links. Every linked asset carries an
+// 'export' reference, so it cannot be deleted while an export holds it, and
+// the extracted copies are independent of the .dat files, so compaction
+// never touches them. A copy is removed once no export links it.
+type LinkExportService struct {
+	app        AppState
+	logger     *logger.Logger
+	bulk       *BulkService
+	blobStores *BlobStoreService
+
+	mu  sync.Mutex // serializes changes to exports and the shared copies
+	now func() time.Time
+}
+
+// LinkExportDetails is a link export with the directory consumers read.
+// Entries are only listed for a single export.
+type LinkExportDetails struct {
+	database.LinkExport
+	Path    string                     `json:"path"`
+	Entries []database.LinkExportEntry `json:"entries,omitempty"`
+}
+
+// LinkAsset is a resolved asset with its path in the export directory,
+// slash-separated and relative. The path is made unique within the export
+// when another asset already uses it.
+type LinkAsset struct {
+	*ResolvedAsset
+	Path string
+}
+
+// LinkFailure is an asset that could not be linked.
+type LinkFailure struct {
+	Hash  string `json:"hash"`
+	Topic string `json:"topic"`
+	Error string `json:"error"`
+}
+
+// LinkSelectResult is the outcome of selecting assets into a link export.
+type LinkSelectResult struct {
+	Export     *LinkExportDetails `json:"export"`
+	Linked     int                `json:"linked"`
+	Unchanged  int                `json:"unchanged"` // already linked
+	Released   int                `json:"released"`  // dropped by a replacing selection
+	LinkedSize int64              `json:"linked_size"`
+	Failed     []LinkFailure      `json:"failed"`
+}
+
+// NewLinkExportService creates a new link export service. Returns nil if the
+// orchestrator DB is not available.
+func NewLinkExportService(app AppState, log *logger.Logger, bulk *BulkService, blobStores *BlobStoreService) *LinkExportService {
+	if app.GetOrchestratorDB() == nil {
+		return nil
+	}
+
+	return &LinkExportService{
+		app:        app,
+		logger:     log,
+		bulk:       bulk,
+		blobStores: blobStores,
+		now:        time.Now,
+	}
+}
+
+// Dir returns the directory holding the links of an export.
+func (s *LinkExportService) Dir(id string) string {
+	return filepath.Join(s.root(), constants.LinkExportTreesDir, id)
+}
+
+// Create records a new, empty link export. Its filename format applies to
+// every asset selected into it.
+func (s *LinkExportService) Create(userID int64, label, filenameFormat string) (*LinkExportDetails, error) {
+	if filenameFormat == "" {
+		filenameFormat = constants.DefaultFilenameFormat
+	}
+	if !s.bulk.isValidFilenameFormat(filenameFormat) {
+		return nil, NewServiceError(constants.ErrCodeInvalidFilenameFormat,
+			"invalid filename_format: must be hash, original, hash_original, or path")
+	}
+	if utf8.RuneCountInString(label) > constants.LinkExportLabelMaxLen {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("label must be at most %d characters", constants.LinkExportLabelMaxLen))
+	}
+
+	id, err := generateExportID()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	now := s.now().Unix()
+	export := database.LinkExport{
+		ID:             id,
+		UserID:         userID,
+		Label:          label,
+		FilenameFormat: filenameFormat,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := database.InsertLinkExport(s.app.GetOrchestratorDB(), export); err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if err := os.MkdirAll(s.Dir(id), constants.DirPermissions); err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Link exports: user_id=%d created export id=%s", userID, id)
+	return &LinkExportDetails{LinkExport: export, Path: s.Dir(id)}, nil
+}
+
+// List returns the user's link exports, newest first.
+func (s *LinkExportService) List(userID int64) ([]LinkExportDetails, error) {
+	exports, err := database.ListLinkExports(s.app.GetOrchestratorDB(), userID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	result := make([]LinkExportDetails, len(exports))
+	for i, e := range exports {
+		result[i] = LinkExportDetails{LinkExport: e, Path: s.Dir(e.ID)}
+	}
+	return result, nil
+}
+
+// Get returns a link export of the user with its entries.
+func (s *LinkExportService) Get(userID int64, id string) (*LinkExportDetails, error) {
+	db := s.app.GetOrchestratorDB()
+	export, err := s.get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := database.ListLinkExportEntries(db, id)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return &LinkExportDetails{LinkExport: *export, Path: s.Dir(id), Entries: entries}, nil
+}
+
+// Select links assets into an export. Assets already linked are left as
+// they are. With replace, linked assets missing from the selection are
+// released, so re-running the same query refreshes the export.
+func (s *LinkExportService) Select(userID int64, id string, assets []LinkAsset, replace bool) (*LinkSelectResult, error) {
+	db := s.app.GetOrchestratorDB()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.get(userID, id); err != nil {
+		return nil, err
+	}
+	entries, err := database.ListLinkExportEntries(db, id)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	selected := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		selected[asset.Hash] = true
+	}
+
+	// Released first, so their paths are free for the new selection
+	result := &LinkSelectResult{Failed: make([]LinkFailure, 0)}
+	linked := make(map[string]bool, len(entries))
+	paths := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if replace && !selected[e.Hash] {
+			if err := s.unlink(id, e); err != nil {
+				return nil, WrapInternalError(err)
+			}
+			result.Released++
+			continue
+		}
+		linked[e.Hash] = true
+		paths[e.Path] = true
+	}
+
+	for _, asset := range assets {
+		if linked[asset.Hash] {
+			result.Unchanged++
+			continue
+		}
+
+		entry, err := s.link(id, asset, paths)
+		if err != nil {
+			s.logger.Error("Link exports: failed to link asset %s into export id=%s: %v", asset.Hash, id, err)
+			result.Failed = append(result.Failed, LinkFailure{Hash: asset.Hash, Topic: asset.Topic, Error: err.Error()})
+			continue
+		}
+		linked[asset.Hash] = true
+		paths[entry.Path] = true
+		result.Linked++
+		result.LinkedSize += entry.Size
+	}
+
+	if err := database.TouchLinkExport(db, id, s.now().Unix()); err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if result.Export, err = s.Get(userID, id); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Link exports: export id=%s linked=%d unchanged=%d released=%d failed=%d",
+		id, result.Linked, result.Unchanged, result.Released, len(result.Failed))
+	return result, nil
+}
+
+// Release removes the links of the given assets from an export and frees
+// their references. Hashes not linked into the export are ignored. Returns
+// the number of links removed.
+func (s *LinkExportService) Release(userID int64, id string, hashes []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.get(userID, id); err != nil {
+		return 0, err
+	}
+	db := s.app.GetOrchestratorDB()
+	entries, err := database.ListLinkExportEntries(db, id)
+	if err != nil {
+		return 0, WrapInternalError(err)
+	}
+
+	release := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		release[hash] = true
+	}
+
+	var released int
+	for _, e := range entries {
+		if !release[e.Hash] {
+			continue
+		}
+		if err := s.unlink(id, e); err != nil {
+			return released, WrapInternalError(err)
+		}
+		released++
+	}
+
+	if released > 0 {
+		if err := database.TouchLinkExport(db, id, s.now().Unix()); err != nil {
+			return released, WrapInternalError(err)
+		}
+	}
+	s.logger.Info("Link exports: export id=%s released %d link(s)", id, released)
+	return released, nil
+}
+
+// Delete releases every link of an export and removes it with its
+// directory. Returns the number of links released.
+func (s *LinkExportService) Delete(userID int64, id string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.get(userID, id); err != nil {
+		return 0, err
+	}
+	db := s.app.GetOrchestratorDB()
+	entries, err := database.ListLinkExportEntries(db, id)
+	if err != nil {
+		return 0, WrapInternalError(err)
+	}
+	for i, e := range entries {
+		if err := s.unlink(id, e); err != nil {
+			return i, WrapInternalError(err)
+		}
+	}
+
+	if err := database.DeleteLinkExport(db, id); err != nil {
+		return len(entries), WrapInternalError(err)
+	}
+	if err := os.RemoveAll(s.Dir(id)); err != nil {
+		s.logger.Warn("Link exports: failed to remove directory of export id=%s: %v", id, err)
+	}
+
+	s.logger.Info("Link exports: user_id=%d deleted export id=%s (%d link(s))", userID, id, len(entries))
+	return len(entries), nil
+}
+
+// get returns a link export of the user or a not found error.
+func (s *LinkExportService) get(userID int64, id string) (*database.LinkExport, error) {
+	export, err := database.GetLinkExport(s.app.GetOrchestratorDB(), userID, id)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if export == nil {
+		return nil, NewServiceError(constants.ErrCodeLinkExportNotFound, "link export not found: "+id)
+	}
+	return export, nil
+}
+
+// link extracts an asset if needed, links it into the export under a free
+// path and records the entry with its reference. Must hold s.mu.
+func (s *LinkExportService) link(id string, asset LinkAsset, paths map[string]bool) (*database.LinkExportEntry, error) {
+	relPath := uniqueLinkPath(asset.Path, paths)
+	linkPath, err := s.linkPath(id, relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	objectPath, err := s.extract(asset.ResolvedAsset)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(linkPath), constants.DirPermissions); err != nil {
+		s.removeUnusedObject(asset.Hash)
+		return nil, err
+	}
+	method, err := linkObject(objectPath, linkPath)
+	if err != nil {
+		s.removeUnusedObject(asset.Hash)
+		return nil, err
+	}
+
+	entry := &database.LinkExportEntry{
+		Hash:     asset.Hash,
+		Topic:    asset.Topic,
+		Path:     relPath,
+		Size:     asset.Asset.AssetSize,
+		Method:   method,
+		LinkedAt: s.now().Unix(),
+	}
+
+	// Registered under the topic's write lock, which asset deletion holds
+	// while it checks references, so the asset is either still there and
+	// kept alive from now on, or gone and not linked
+	topicMu := s.app.GetTopicWriteMu(asset.Topic)
+	topicMu.Lock()
+	exists, _, _, err := s.app.GetAssetIndex().Lookup(asset.Hash)
+	if err == nil && !exists {
+		err = ErrAssetNotFoundWithHash(asset.Hash)
+	}
+	if err == nil {
+		err = database.AddLinkExportEntry(s.app.GetOrchestratorDB(), id, *entry)
+	}
+	topicMu.Unlock()
+
+	if err != nil {
+		os.Remove(linkPath)
+		s.removeUnusedObject(asset.Hash)
+		return nil, err
+	}
+	return entry, nil
+}
+
+// unlink removes an entry's link, its record and reference, then the
+// extracted copy if no export links it anymore. Must hold s.mu.
+func (s *LinkExportService) unlink(id string, entry database.LinkExportEntry) error {
+	if linkPath, err := s.linkPath(id, entry.Path); err == nil {
+		if err := os.Remove(linkPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		removeEmptyDirs(filepath.Dir(linkPath), s.Dir(id))
+	}
+	if err := database.RemoveLinkExportEntry(s.app.GetOrchestratorDB(), id, entry.Hash); err != nil {
+		return err
+	}
+	s.removeUnusedObject(entry.Hash)
+	return nil
+}
+
+// extract writes the read-only copy of an asset linked by exports, unless
+// it is already there, and returns its path. The content is checked against
+// the hash before the copy is published. Must hold s.mu.
+func (s *LinkExportService) extract(asset *ResolvedAsset) (string, error) {
+	objectPath := s.objectPath(asset.Hash)
+	if info, err := os.Stat(objectPath); err == nil && info.Size() == asset.Asset.AssetSize {
+		return objectPath, nil
+	}
+
+	reader, err := s.blobStores.OpenData(asset.Topic, asset.Asset)
+	if errors.Is(err, fs.ErrNotExist) && asset.TopicDB != nil {
+		// Compaction moved the entry since the asset was resolved
+		if moved, getErr := database.GetAsset(asset.TopicDB, asset.Hash); getErr == nil && moved != nil {
+			reader, err = s.blobStores.OpenData(asset.Topic, moved)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to open data file: %w", err)
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(filepath.Dir(objectPath), constants.DirPermissions); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(objectPath), asset.Hash+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := blake3.New()
+	if _, err := io.CopyN(io.MultiWriter(tmp, hasher), reader, asset.Asset.AssetSize); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to extract data: %w", err)
+	}
+	if sum := hex.EncodeToString(hasher.Sum(nil)); sum != asset.Hash {
+		tmp.Close()
+		return "", fmt.Errorf("extracted content hashes to %s", sum)
+	}
+	if err := tmp.Chmod(constants.LinkObjectPermissions); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), objectPath); err != nil {
+		return "", err
+	}
+	return objectPath, nil
+}
+
+// removeUnusedObject deletes the extracted copy of an asset once no export
+// entry links it. Must hold s.mu.
+func (s *LinkExportService) removeUnusedObject(hash string) {
+	count, err := database.CountLinkExportEntries(s.app.GetOrchestratorDB(), hash)
+	if err != nil {
+		s.logger.Warn("Link exports: failed to count links of asset %s: %v", hash, err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+	if err := os.Remove(s.objectPath(hash)); err != nil && !os.IsNotExist(err) {
+		s.logger.Warn("Link exports: failed to remove extracted copy of asset %s: %v", hash, err)
+	}
+}
+
+// root returns the managed link export area.
+func (s *LinkExportService) root() string {
+	return filepath.Join(s.app.GetWorkingDirectory(), constants.InternalDir, constants.LinkExportsDir)
+}
+
+// objectPath returns where the extracted copy of an asset is kept.
+func (s *LinkExportService) objectPath(hash string) string {
+	return filepath.Join(s.root(), constants.LinkExportObjectsDir, hash[:2], hash)
+}
+
+// linkPath resolves an entry path inside the export directory, refusing
+// paths that would leave it.
+func (s *LinkExportService) linkPath(id, relPath string) (string, error) {
+	dir := s.Dir(id)
+	full := filepath.Join(dir, filepath.FromSlash(relPath))
+	if relPath == "" || !strings.HasPrefix(full, dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid link path %q", relPath)
+	}
+	return full, nil
+}
+
+// uniqueLinkPath returns p, or p with a numeric suffix before its extension
+// when another entry of the export already uses it.
+func uniqueLinkPath(p string, taken map[string]bool) string {
+	if !taken[p] {
+		return p
+	}
+	ext := path.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, n, ext)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// linkObject hard links the extracted copy at linkPath, replacing a file
+// left by an interrupted run. Falls back to a read-only copy when the
+// filesystem does not support hard links. Returns the method used.
+func linkObject(objectPath, linkPath string) (string, error) {
+	if err := os.Remove(linkPath); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if err := os.Link(objectPath, linkPath); err == nil {
+		return constants.LinkExportMethodHardlink, nil
+	}
+
+	src, err := os.Open(objectPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(linkPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, constants.LinkObjectPermissions)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(linkPath)
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(linkPath)
+		return "", err
+	}
+	return constants.LinkExportMethodCopy, nil
+}
+
+// removeEmptyDirs removes dir and its empty parents up to, but not
+// including, stop.
+func removeEmptyDirs(dir, stop string) {
+	for dir != stop && strings.HasPrefix(dir, stop+string(filepath.Separator)) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,577 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// MetadataImportService imports metadata from CSV sheets. Each row names an
+// asset by hash or by origin name; every other column is a metadata key.
+// Rows are resolved to assets up front so ambiguous and unknown names are
+// reported instead of guessed, and the per-row outcome is written to a
+// result file the caller can download afterward.
+//
+// Applying the operations is left to the caller, which owns transactions
+// and change notifications for metadata writes.
+type MetadataImportService struct {
+	app    AppState
+	logger *logger.Logger
+	now    func() time.Time
+}
+
+// MetadataImportSheet is a parsed CSV sheet and the outcome of each row.
+type MetadataImportSheet struct {
+	KeyColumn string               // hash or origin_name
+	Keys      []string             // metadata keys, in column order
+	Rows      []*MetadataImportRow // data rows, in file order
+}
+
+// MetadataImportRow is one data row of a sheet.
+type MetadataImportRow struct {
+	Line       int      `json:"line"`
+	Key        string   `json:"key"` // hash or origin name identifying the asset
+	Topic      string   `json:"topic,omitempty"`
+	Hash       string   `json:"hash,omitempty"`
+	Status     string   `json:"status"`
+	Applied    int      `json:"applied"`
+	Failed     int      `json:"failed"`
+	Candidates []string `json:"candidates,omitempty"` // topic/hash of each match of an ambiguous row
+	Error      string   `json:"error,omitempty"`
+
+	values []string // aligned with MetadataImportSheet.Keys; empty cells are not applied
+}
+
+// MetadataImportBatch is a group of operations applied in one transaction.
+type MetadataImportBatch struct {
+	Topic      string
+	Operations []database.BatchOperation
+
+	rows []*MetadataImportRow // row of each operation
+}
+
+// MetadataImportSummary counts the rows of a sheet by outcome.
+type MetadataImportSummary struct {
+	Rows       int `json:"rows"`
+	Operations int `json:"operations"`
+	Applied    int `json:"applied"`
+	Partial    int `json:"partial"`
+	Failed     int `json:"failed"`
+	NotFound   int `json:"not_found"`
+	Ambiguous  int `json:"ambiguous"`
+	Invalid    int `json:"invalid"`
+	Skipped    int `json:"skipped"`
+}
+
+// metadataImportCandidate is an asset matching a row.
+type metadataImportCandidate struct {
+	topic string
+	hash  string
+}
+
+// NewMetadataImportService creates a new metadata import service instance.
+func NewMetadataImportService(app AppState, log *logger.Logger) *MetadataImportService {
+	return &MetadataImportService{
+		app:    app,
+		logger: log,
+		now:    time.Now,
+	}
+}
+
+// Parse reads a CSV sheet. The header must contain exactly one of the hash
+// and origin_name columns and at least one metadata column; an optional
+// topic column restricts where each row is looked up.
+func (s *MetadataImportService) Parse(r io.Reader) (*MetadataImportSheet, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 0 // every row must have as many fields as the header
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, NewServiceError(constants.ErrCodeMetadataImportInvalid, "CSV is empty")
+	}
+	if err != nil {
+		return nil, WrapServiceError(constants.ErrCodeMetadataImportInvalid, "invalid CSV", err)
+	}
+
+	// Spreadsheet exports often start with a UTF-8 byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	sheet := &MetadataImportSheet{}
+	keyIndex, topicIndex := -1, -1
+	var valueIndexes []int
+	seen := make(map[string]bool, len(header))
+
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, NewServiceError(constants.ErrCodeMetadataImportInvalid, fmt.Sprintf("column %d has no name", i+1))
+		}
+		if seen[name] {
+			return nil, NewServiceError(constants.ErrCodeMetadataImportInvalid, "duplicate column: "+name)
+		}
+		seen[name] = true
+
+		switch strings.ToLower(name) {
+		case constants.MetadataImportColumnHash, constants.MetadataImportColumnOriginName:
+			if keyIndex >= 0 {
+				return nil, NewServiceError(constants.ErrCodeMetadataImportInvalid,
+					"only one of the hash and origin_name columns may be given")
+			}
+			keyIndex = i
+			sheet.KeyColumn = strings.ToLower(name)
+		case constants.MetadataImportColumnTopic:
+			topicIndex = i
+		default:
+			if len(name) > constants.MaxMetadataKeyLength {
+				return nil, NewServiceError(constants.ErrCodeMetadataKeyTooLong,
+					fmt.Sprintf("column %q exceeds maximum key length of %d characters", name, constants.MaxMetadataKeyLength))
+			}
+			sheet.Keys = append(sheet.Keys, name)
+			valueIndexes = append(valueIndexes, i)
+		}
+	}
+
+	if keyIndex < 0 {
+		return nil, NewServiceError(constants.ErrCodeMetadataImportInvalid, "CSV needs a hash or origin_name column")
+	}
+	if len(sheet.Keys) == 0 {
+		return nil, NewServiceError(constants.ErrCodeMetadataImportInvalid, "CSV has no metadata columns")
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, WrapServiceError(constants.ErrCodeMetadataImportInvalid, "invalid CSV", err)
+		}
+		if len(sheet.Rows) == constants.MetadataImportMaxRows {
+			return nil, NewServiceError(constants.ErrCodeBatchTooManyOperations,
+				fmt.Sprintf("CSV exceeds maximum of %d rows", constants.MetadataImportMaxRows))
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &MetadataImportRow{
+			Line:   line,
+			Key:    strings.TrimSpace(record[keyIndex]),
+			values: make([]string, len(valueIndexes)),
+		}
+		if topicIndex >= 0 {
+			row.Topic = strings.TrimSpace(record[topicIndex])
+		}
+		for j, idx := range valueIndexes {
+			row.values[j] = record[idx]
+		}
+		sheet.Rows = append(sheet.Rows, row)
+	}
+
+	if len(sheet.Rows) == 0 {
+		return nil, NewServiceError(constants.ErrCodeMetadataImportInvalid, "CSV has no data rows")
+	}
+
+	return sheet, nil
+}
+
+// Resolve finds the asset of every row. Rows that match no asset, several
+// assets, or have nothing to apply get their final status here; the others
+// are left for Batches.
+func (s *MetadataImportService) Resolve(sheet *MetadataImportSheet) error {
+	var pending []*MetadataImportRow
+	for _, row := range sheet.Rows {
+		switch {
+		case row.Key == "":
+			row.Status = constants.MetadataImportStatusInvalid
+			row.Error = sheet.KeyColumn + " is empty"
+		case !row.hasValues():
+			row.Status = constants.MetadataImportStatusSkipped
+		case sheet.KeyColumn == constants.MetadataImportColumnHash && !isHexHash(strings.ToLower(row.Key)):
+			row.Status = constants.MetadataImportStatusInvalid
+			row.Error = "invalid hash"
+		default:
+			pending = append(pending, row)
+		}
+	}
+
+	var matches map[*MetadataImportRow][]metadataImportCandidate
+	var err error
+	if sheet.KeyColumn == constants.MetadataImportColumnHash {
+		matches, err = s.resolveHashes(pending)
+	} else {
+		matches, err = s.resolveOriginNames(pending)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, row := range pending {
+		candidates := matches[row]
+		switch len(candidates) {
+		case 0:
+			row.Status = constants.MetadataImportStatusNotFound
+			row.Error = "asset not found"
+			if row.Topic != "" {
+				row.Error = "asset not found in topic " + row.Topic
+			}
+		case 1:
+			row.Topic = candidates[0].topic
+			row.Hash = candidates[0].hash
+		default:
+			row.Status = constants.MetadataImportStatusAmbiguous
+			row.Error = fmt.Sprintf("%d assets match; use the hash column or add a topic column", len(candidates))
+			sort.Slice(candidates, func(i, j int) bool {
+				if candidates[i].topic != candidates[j].topic {
+					return candidates[i].topic < candidates[j].topic
+				}
+				return candidates[i].hash < candidates[j].hash
+			})
+			for i, c := range candidates {
+				if i == constants.MetadataImportCandidateLimit {
+					break
+				}
+				row.Candidates = append(row.Candidates, c.topic+"/"+c.hash)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveHashes looks rows up in the orchestrator's asset index.
+func (s *MetadataImportService) resolveHashes(rows []*MetadataImportRow) (map[*MetadataImportRow][]metadataImportCandidate, error) {
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return nil, NewServiceError(constants.ErrCodeNotConfigured, "working directory not configured")
+	}
+
+	matches := make(map[*MetadataImportRow][]metadataImportCandidate, len(rows))
+	for start := 0; start < len(rows); start += constants.MetadataImportLookupBatchSize {
+		chunk := rows[start:min(start+constants.MetadataImportLookupBatchSize, len(rows))]
+
+		hashes := make([]string, len(chunk))
+		for i, row := range chunk {
+			hashes[i] = strings.ToLower(row.Key)
+		}
+		found, err := s.app.GetAssetIndex().LookupTopics(hashes)
+		if err != nil {
+			return nil, WrapInternalError(fmt.Errorf("failed to resolve hashes: %w", err))
+		}
+
+		for i, row := range chunk {
+			topic, ok := found[hashes[i]]
+			if !ok || (row.Topic != "" && row.Topic != topic) {
+				continue
+			}
+			matches[row] = []metadataImportCandidate{{topic: topic, hash: hashes[i]}}
+		}
+	}
+
+	return matches, nil
+}
+
+// resolveOriginNames searches every healthy topic (or the row's topic) for
+// assets whose original filename equals the row key, with or without its
+// extension.
+func (s *MetadataImportService) resolveOriginNames(rows []*MetadataImportRow) (map[*MetadataImportRow][]metadataImportCandidate, error) {
+	matches := make(map[*MetadataImportRow][]metadataImportCandidate, len(rows))
+	if len(rows) == 0 {
+		return matches, nil
+	}
+
+	// A key such as "hero.png" may be the name "hero" with extension "png"
+	// or a name that itself contains a dot, so both forms are looked up.
+	nameSet := make(map[string]bool)
+	for _, row := range rows {
+		nameSet[row.Key] = true
+		if ext := filepath.Ext(row.Key); ext != "" {
+			nameSet[strings.TrimSuffix(row.Key, ext)] = true
+		}
+	}
+	names := make([]string, 0, len(nameSet))
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	topics := s.app.ListTopics()
+	sort.Strings(topics)
+
+	for _, topic := range topics {
+		if healthy, _ := s.app.IsTopicHealthy(topic); !healthy {
+			continue
+		}
+		db, err := s.app.GetTopicDB(topic)
+		if err != nil {
+			s.logger.Warn("Metadata import: skipping topic %s: %v", topic, err)
+			continue
+		}
+
+		// filename -> hashes in this topic, under both the bare and full name
+		byName := make(map[string][]string)
+		for start := 0; start < len(names); start += constants.MetadataImportLookupBatchSize {
+			chunk := names[start:min(start+constants.MetadataImportLookupBatchSize, len(names))]
+			assets, err := database.FindAssetsByOriginNames(db, chunk)
+			if err != nil {
+				return nil, WrapInternalError(fmt.Errorf("failed to resolve names in topic %s: %w", topic, err))
+			}
+			for _, asset := range assets {
+				byName[asset.OriginName] = append(byName[asset.OriginName], asset.AssetID)
+				if asset.Extension != "" {
+					full := asset.OriginName + "." + asset.Extension
+					byName[full] = append(byName[full], asset.AssetID)
+				}
+			}
+		}
+
+		for _, row := range rows {
+			if row.Topic != "" && row.Topic != topic {
+				continue
+			}
+			for _, hash := range byName[row.Key] {
+				matches[row] = append(matches[row], metadataImportCandidate{topic: topic, hash: hash})
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// Batches turns the resolved rows into set operations, grouped by topic and
+// split into batches of at most size operations. Empty cells are skipped.
+func (s *MetadataImportService) Batches(sheet *MetadataImportSheet, processor, processorVersion string, size int) []MetadataImportBatch {
+	byTopic := make(map[string]*MetadataImportBatch)
+	var topics []string
+	for _, row := range sheet.Rows {
+		if row.Status != "" {
+			continue
+		}
+		batch, ok := byTopic[row.Topic]
+		if !ok {
+			batch = &MetadataImportBatch{Topic: row.Topic}
+			byTopic[row.Topic] = batch
+			topics = append(topics, row.Topic)
+		}
+		for i, value := range row.values {
+			if value == "" {
+				continue
+			}
+			batch.Operations = append(batch.Operations, database.BatchOperation{
+				Hash:             row.Hash,
+				Op:               constants.BatchMetadataOpSet,
+				Key:              sheet.Keys[i],
+				Value:            value,
+				Processor:        processor,
+				ProcessorVersion: processorVersion,
+			})
+			batch.rows = append(batch.rows, row)
+		}
+	}
+
+	var batches []MetadataImportBatch
+	for _, topic := range topics {
+		group := byTopic[topic]
+		for start := 0; start < len(group.Operations); start += size {
+			end := min(start+size, len(group.Operations))
+			batches = append(batches, MetadataImportBatch{
+				Topic:      topic,
+				Operations: group.Operations[start:end],
+				rows:       group.rows[start:end],
+			})
+		}
+	}
+	return batches
+}
+
+// Record tallies the results of an applied batch, one per operation, onto
+// the rows they came from.
+func (b *MetadataImportBatch) Record(results []database.BatchOperationResult) {
+	for i, result := range results {
+		if i >= len(b.rows) {
+			break
+		}
+		row := b.rows[i]
+		if result.Success {
+			row.Applied++
+			continue
+		}
+		row.Failed++
+		if row.Error == "" {
+			row.Error = b.Operations[i].Key + ": " + result.Error
+		}
+	}
+}
+
+// Finish sets the status of the rows that were applied and counts every
+// row by outcome.
+func (s *MetadataImportService) Finish(sheet *MetadataImportSheet) MetadataImportSummary {
+	summary := MetadataImportSummary{Rows: len(sheet.Rows)}
+	for _, row := range sheet.Rows {
+		if row.Status == "" {
+			summary.Operations += row.Applied + row.Failed
+			switch {
+			case row.Failed == 0:
+				row.Status = constants.MetadataImportStatusApplied
+			case row.Applied == 0:
+				row.Status = constants.MetadataImportStatusFailed
+			default:
+				row.Status = constants.MetadataImportStatusPartial
+			}
+		}
+
+		switch row.Status {
+		case constants.MetadataImportStatusApplied:
+			summary.Applied++
+		case constants.MetadataImportStatusPartial:
+			summary.Partial++
+		case constants.MetadataImportStatusFailed:
+			summary.Failed++
+		case constants.MetadataImportStatusNotFound:
+			summary.NotFound++
+		case constants.MetadataImportStatusAmbiguous:
+			summary.Ambiguous++
+		case constants.MetadataImportStatusInvalid:
+			summary.Invalid++
+		case constants.MetadataImportStatusSkipped:
+			summary.Skipped++
+		}
+	}
+	return summary
+}
+
+// Save writes the per-row result of a sheet for the user and returns its ID.
+// Result files older than the retention period are removed first.
+func (s *MetadataImportService) Save(userID int64, sheet *MetadataImportSheet) (string, error) {
+	dir := s.userDir(userID)
+	if err := os.MkdirAll(dir, constants.DirPermissions); err != nil {
+		return "", WrapInternalError(fmt.Errorf("failed to create import directory: %w", err))
+	}
+	s.purgeExpired(dir)
+
+	id, err := generateMetadataImportID()
+	if err != nil {
+		return "", WrapInternalError(err)
+	}
+
+	f, err := os.Create(filepath.Join(dir, id+".csv"))
+	if err != nil {
+		return "", WrapInternalError(fmt.Errorf("failed to create import result: %w", err))
+	}
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"line", sheet.KeyColumn, "topic", "hash", "status", "applied", "failed", "candidates", "error"})
+	for _, row := range sheet.Rows {
+		w.Write([]string{
+			strconv.Itoa(row.Line),
+			row.Key,
+			row.Topic,
+			row.Hash,
+			row.Status,
+			strconv.Itoa(row.Applied),
+			strconv.Itoa(row.Failed),
+			strings.Join(row.Candidates, ";"),
+			row.Error,
+		})
+	}
+	w.Flush()
+
+	if err := errors.Join(w.Error(), f.Close()); err != nil {
+		os.Remove(f.Name())
+		return "", WrapInternalError(fmt.Errorf("failed to write import result: %w", err))
+	}
+
+	s.logger.Info("Metadata import: user_id=%d saved result id=%s rows=%d", userID, id, len(sheet.Rows))
+	return id, nil
+}
+
+// Open returns the user's result file of an import. The caller closes it.
+func (s *MetadataImportService) Open(userID int64, id string) (*os.File, time.Time, error) {
+	notFound := NewServiceError(constants.ErrCodeMetadataImportNotFound, "metadata import not found: "+id)
+	if len(id) != constants.MetadataImportIDLength || !isLowerHex(id) {
+		return nil, time.Time{}, notFound
+	}
+
+	f, err := os.Open(filepath.Join(s.userDir(userID), id+".csv"))
+	if os.IsNotExist(err) {
+		return nil, time.Time{}, notFound
+	}
+	if err != nil {
+		return nil, time.Time{}, WrapInternalError(err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, time.Time{}, WrapInternalError(err)
+	}
+	if s.now().Sub(info.ModTime()) > constants.MetadataImportRetention {
+		f.Close()
+		return nil, time.Time{}, notFound
+	}
+	return f, info.ModTime(), nil
+}
+
+// userDir returns where a user's import results are stored.
+func (s *MetadataImportService) userDir(userID int64) string {
+	return filepath.Join(s.app.GetWorkingDirectory(), constants.InternalDir, constants.MetadataImportDir,
+		strconv.FormatInt(userID, 10))
+}
+
+// purgeExpired removes result files past the retention period from dir.
+func (s *MetadataImportService) purgeExpired(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	cutoff := s.now().Add(-constants.MetadataImportRetention)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			s.logger.Warn("Metadata import: failed to remove expired result %s: %v", entry.Name(), err)
+		}
+	}
+}
+
+// hasValues reports whether the row has at least one non-empty value.
+func (r *MetadataImportRow) hasValues() bool {
+	for _, v := range r.values {
+		if v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// generateMetadataImportID creates a random import ID.
+func generateMetadataImportID() (string, error) {
+	bytes := make([]byte, constants.MetadataImportIDLength/2)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// isLowerHex reports whether s contains only lowercase hex digits.
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
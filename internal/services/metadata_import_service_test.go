@@ -0,0 +1,75 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+func newTestMetadataImportService() *MetadataImportService {
+	m := newMockAppState()
+	m.log = logger.NewLogger(logger.LevelError)
+	return NewMetadataImportService(m, m.log)
+}
+
+func TestMetadataImportParse_Columns(t *testing.T) {
+	svc := newTestMetadataImportService()
+
+	sheet, err := svc.Parse(strings.NewReader("\ufeffOrigin_Name, topic ,artist,lod\nhero.png,chars,\"Smith, J\",2\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if sheet.KeyColumn != constants.MetadataImportColumnOriginName {
+		t.Errorf("expected origin_name key column, got %q", sheet.KeyColumn)
+	}
+	if strings.Join(sheet.Keys, ",") != "artist,lod" {
+		t.Errorf("unexpected keys: %v", sheet.Keys)
+	}
+	row := sheet.Rows[0]
+	if row.Line != 2 || row.Key != "hero.png" || row.Topic != "chars" || row.values[0] != "Smith, J" {
+		t.Errorf("unexpected row: %+v %v", row, row.values)
+	}
+}
+
+func TestMetadataImportParse_Rejects(t *testing.T) {
+	svc := newTestMetadataImportService()
+
+	cases := map[string]string{
+		"no key column": "artist\nalice\n",
+		"two keys":      "hash,origin_name,artist\nx,y,z\n",
+		"no values":     "hash,topic\nx,y\n",
+		"empty column":  "hash,,artist\nx,y,z\n",
+		"long key":      "hash," + strings.Repeat("k", constants.MaxMetadataKeyLength+1) + "\nx,y\n",
+	}
+	for name, sheet := range cases {
+		if _, err := svc.Parse(strings.NewReader(sheet)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMetadataImportBatches_SplitsByTopicAndSize(t *testing.T) {
+	svc := newTestMetadataImportService()
+
+	sheet := &MetadataImportSheet{KeyColumn: constants.MetadataImportColumnHash, Keys: []string{"a", "b"}}
+	for i := 0; i < 3; i++ {
+		sheet.Rows = append(sheet.Rows, &MetadataImportRow{Key: "h", Hash: "h", Topic: "one", values: []string{"1", "2"}})
+	}
+	sheet.Rows = append(sheet.Rows,
+		&MetadataImportRow{Hash: "g", Topic: "two", values: []string{"", "2"}},
+		&MetadataImportRow{Status: constants.MetadataImportStatusNotFound, values: []string{"1", "1"}},
+	)
+
+	batches := svc.Batches(sheet, constants.ProcessorImport, constants.ProcessorImportVersion, 4)
+	if len(batches) != 3 {
+		t.Fatalf("expected 3 batches, got %d", len(batches))
+	}
+	if batches[0].Topic != "one" || len(batches[0].Operations) != 4 || len(batches[1].Operations) != 2 {
+		t.Errorf("unexpected batches for topic one: %+v", batches[:2])
+	}
+	if batches[2].Topic != "two" || len(batches[2].Operations) != 1 || batches[2].Operations[0].Key != "b" {
+		t.Errorf("empty cells should be skipped: %+v", batches[2])
+	}
+}
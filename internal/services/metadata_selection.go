@@ -0,0 +1,165 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/queries"
+)
+
+// MetadataSelection chooses how much computed metadata a response carries:
+// every value (the default), only the values of the listed keys, only the key
+// names, or nothing. It is embedded in request bodies so the JSON fields are
+// shared by queries and bulk downloads.
+type MetadataSelection struct {
+	Mode string   `json:"metadata,omitempty"`      // full (default), keys or none
+	Keys []string `json:"metadata_keys,omitempty"` // restricts full and keys modes to these keys
+}
+
+// Validate checks the selection and defaults an empty mode to full.
+func (m *MetadataSelection) Validate() error {
+	if m.Mode == "" {
+		m.Mode = constants.MetadataModeFull
+	}
+	switch m.Mode {
+	case constants.MetadataModeFull, constants.MetadataModeKeys:
+	case constants.MetadataModeNone:
+		if len(m.Keys) > 0 {
+			return NewServiceError(constants.ErrCodeInvalidMetadataSelection, "metadata_keys cannot be combined with metadata=none")
+		}
+	default:
+		return NewServiceError(constants.ErrCodeInvalidMetadataSelection,
+			fmt.Sprintf("invalid metadata mode %q: must be full, keys or none", m.Mode))
+	}
+
+	if len(m.Keys) > constants.MetadataSelectMaxKeys {
+		return NewServiceError(constants.ErrCodeInvalidMetadataSelection,
+			fmt.Sprintf("too many metadata_keys: %d (max %d)", len(m.Keys), constants.MetadataSelectMaxKeys))
+	}
+	for _, key := range m.Keys {
+		if key == "" || len(key) > constants.MaxMetadataKeyLength {
+			return NewServiceError(constants.ErrCodeInvalidMetadataSelection,
+				fmt.Sprintf("metadata_keys entries must be 1-%d characters", constants.MaxMetadataKeyLength))
+		}
+	}
+	return nil
+}
+
+// IsFull reports whether the selection returns all metadata unchanged.
+func (m *MetadataSelection) IsFull() bool {
+	return (m.Mode == "" || m.Mode == constants.MetadataModeFull) && len(m.Keys) == 0
+}
+
+// IncludesValues reports whether metadata values are returned.
+func (m *MetadataSelection) IncludesValues() bool {
+	return m.Mode == "" || m.Mode == constants.MetadataModeFull
+}
+
+// IncludesKeyNames reports whether only the key names are returned.
+func (m *MetadataSelection) IncludesKeyNames() bool {
+	return m.Mode == constants.MetadataModeKeys
+}
+
+// selects reports whether key passes the metadata_keys filter.
+func (m *MetadataSelection) selects(key string) bool {
+	return len(m.Keys) == 0 || slices.Contains(m.Keys, key)
+}
+
+// Values returns the selected entries of metadata. Returns metadata itself
+// when no keys are listed.
+func (m *MetadataSelection) Values(metadata map[string]interface{}) map[string]interface{} {
+	if len(m.Keys) == 0 || metadata == nil {
+		return metadata
+	}
+	selected := make(map[string]interface{}, len(m.Keys))
+	for key, value := range metadata {
+		if m.selects(key) {
+			selected[key] = value
+		}
+	}
+	return selected
+}
+
+// KeyNames returns the sorted names of the selected keys present in metadata.
+func (m *MetadataSelection) KeyNames(metadata map[string]interface{}) []string {
+	names := make([]string, 0, len(metadata))
+	for key := range metadata {
+		if m.selects(key) {
+			names = append(names, key)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ApplyToAsset trims an asset info response to the selection.
+func (m *MetadataSelection) ApplyToAsset(result *AssetMetadata) {
+	if m.IsFull() {
+		return
+	}
+	if m.IncludesKeyNames() {
+		result.MetadataKeys = m.KeyNames(result.ComputedMetadata)
+	}
+	if !m.IncludesValues() {
+		result.ComputedMetadata = nil
+		result.MetadataWithProcessor = nil
+		return
+	}
+
+	result.ComputedMetadata = m.Values(result.ComputedMetadata)
+	withProcessor := make([]database.MetadataWithProcessor, 0, len(result.MetadataWithProcessor))
+	for _, entry := range result.MetadataWithProcessor {
+		if m.selects(entry.Key) {
+			withProcessor = append(withProcessor, entry)
+		}
+	}
+	result.MetadataWithProcessor = withProcessor
+}
+
+// ApplyToResult rewrites the metadata_json column of a query result: values
+// are filtered to the selected keys, replaced by a JSON array of key names,
+// or the column is dropped.
+func (m *MetadataSelection) ApplyToResult(result *queries.QueryResult) {
+	if m.IsFull() {
+		return
+	}
+	col := slices.Index(result.Columns, constants.MetadataJSONColumn)
+	if col < 0 {
+		return
+	}
+
+	if m.Mode == constants.MetadataModeNone {
+		result.Columns = slices.Delete(result.Columns, col, col+1)
+		for i, row := range result.Rows {
+			if col < len(row) {
+				result.Rows[i] = slices.Delete(row, col, col+1)
+			}
+		}
+		return
+	}
+
+	for _, row := range result.Rows {
+		if col >= len(row) {
+			continue
+		}
+		raw, ok := row[col].(string)
+		if !ok {
+			continue
+		}
+		var metadata map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+			continue // Not a metadata object: leave the value as returned by the preset
+		}
+
+		var selected interface{} = m.Values(metadata)
+		if m.IncludesKeyNames() {
+			selected = m.KeyNames(metadata)
+		}
+		if encoded, err := json.Marshal(selected); err == nil {
+			row[col] = string(encoded)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/queries"
+)
+
+func TestMetadataSelectionValidate(t *testing.T) {
+	sel := MetadataSelection{}
+	if err := sel.Validate(); err != nil || sel.Mode != constants.MetadataModeFull {
+		t.Fatalf("expected empty selection to default to full, got %q %v", sel.Mode, err)
+	}
+
+	tooMany := make([]string, constants.MetadataSelectMaxKeys+1)
+	for i := range tooMany {
+		tooMany[i] = "k"
+	}
+	tests := []struct {
+		name string
+		sel  MetadataSelection
+	}{
+		{"unknown mode", MetadataSelection{Mode: "partial"}},
+		{"none with keys", MetadataSelection{Mode: constants.MetadataModeNone, Keys: []string{"a"}}},
+		{"empty key", MetadataSelection{Keys: []string{""}}},
+		{"long key", MetadataSelection{Keys: []string{strings.Repeat("k", constants.MaxMetadataKeyLength+1)}}},
+		{"too many keys", MetadataSelection{Keys: tooMany}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.sel.Validate(); !isServiceErrorCode(err, constants.ErrCodeInvalidMetadataSelection) {
+				t.Errorf("expected %s, got %v", constants.ErrCodeInvalidMetadataSelection, err)
+			}
+		})
+	}
+}
+
+func TestMetadataSelectionApplyToResult(t *testing.T) {
+	newResult := func() *queries.QueryResult {
+		return &queries.QueryResult{
+			Columns: []string{"asset_id", constants.MetadataJSONColumn, "updated_at"},
+			Rows: [][]interface{}{
+				{"h1", `{"status":"approved","thumbnail":"AAAA","width":10}`, int64(1)},
+				{"h2", `{"width":20}`, int64(2)},
+			},
+			RowCount: 2,
+		}
+	}
+
+	result := newResult()
+	(&MetadataSelection{Keys: []string{"status", "width"}}).ApplyToResult(result)
+	if result.Rows[0][1] != `{"status":"approved","width":10}` || result.Rows[1][1] != `{"width":20}` {
+		t.Errorf("unexpected filtered values: %v", result.Rows)
+	}
+
+	result = newResult()
+	(&MetadataSelection{Mode: constants.MetadataModeKeys}).ApplyToResult(result)
+	if result.Rows[0][1] != `["status","thumbnail","width"]` {
+		t.Errorf("unexpected key names: %v", result.Rows[0][1])
+	}
+
+	result = newResult()
+	(&MetadataSelection{Mode: constants.MetadataModeNone}).ApplyToResult(result)
+	if !reflect.DeepEqual(result.Columns, []string{"asset_id", "updated_at"}) ||
+		!reflect.DeepEqual(result.Rows[1], []interface{}{"h2", int64(2)}) {
+		t.Errorf("expected metadata column dropped, got %v %v", result.Columns, result.Rows)
+	}
+
+	// Results without a metadata column are untouched
+	plain := &queries.QueryResult{Columns: []string{"asset_id"}, Rows: [][]interface{}{{"h1"}}}
+	(&MetadataSelection{Mode: constants.MetadataModeNone}).ApplyToResult(plain)
+	if len(plain.Columns) != 1 || len(plain.Rows[0]) != 1 {
+		t.Errorf("expected result without metadata column untouched, got %v", plain)
+	}
+}
+
+func TestMetadataSelectionApplyToAsset(t *testing.T) {
+	newAsset := func() *AssetMetadata {
+		return &AssetMetadata{
+			ComputedMetadata: map[string]interface{}{"status": "approved", "thumbnail": "AAAA"},
+			MetadataWithProcessor: []database.MetadataWithProcessor{
+				{Key: "status", Value: "approved"},
+				{Key: "thumbnail", Value: "AAAA"},
+			},
+		}
+	}
+
+	asset := newAsset()
+	(&MetadataSelection{Keys: []string{"status"}}).ApplyToAsset(asset)
+	if len(asset.ComputedMetadata) != 1 || asset.ComputedMetadata["status"] != "approved" ||
+		len(asset.MetadataWithProcessor) != 1 || asset.MetadataWithProcessor[0].Key != "status" {
+		t.Errorf("unexpected filtered asset: %+v", asset)
+	}
+
+	asset = newAsset()
+	(&MetadataSelection{Mode: constants.MetadataModeKeys}).ApplyToAsset(asset)
+	if asset.ComputedMetadata != nil || asset.MetadataWithProcessor != nil ||
+		!reflect.DeepEqual(asset.MetadataKeys, []string{"status", "thumbnail"}) {
+		t.Errorf("unexpected keys-only asset: %+v", asset)
+	}
+
+	asset = newAsset()
+	(&MetadataSelection{Mode: constants.MetadataModeNone}).ApplyToAsset(asset)
+	if asset.ComputedMetadata != nil || asset.MetadataKeys != nil {
+		t.Errorf("expected no metadata, got %+v", asset)
+	}
+}
@@ -35,6 +35,7 @@ type AssetMetadata struct {
 	} `json:"asset"`
 	ComputedMetadata      map[string]interface{}           `json:"computed_metadata"`
 	MetadataWithProcessor []database.MetadataWithProcessor `json:"metadata_with_processor"`
+	MetadataKeys          []string                         `json:"metadata_keys,omitempty"` // set with metadata=keys
 }
 
 // MetadataSetRequest represents a request to set or delete metadata.
@@ -55,13 +56,24 @@ type MetadataSetResult struct {
 
 // Get retrieves asset info and metadata for a hash.
 func (s *MetadataService) Get(hash string) (*AssetMetadata, error) {
+	return s.GetAsOf(hash, 0)
+}
+
+// GetAsOf retrieves asset info and the metadata the asset had at the unix
+// time asOf, rebuilt from its metadata log. asOf 0 returns the current
+// metadata. Assets created after asOf are reported as not found.
+func (s *MetadataService) GetAsOf(hash string, asOf int64) (*AssetMetadata, error) {
+	if asOf < 0 {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "as_of must be a unix timestamp")
+	}
+
 	// Validate hash
 	if len(hash) != constants.HashLength {
 		return nil, ErrInvalidHash
 	}
 
 	// Look up in orchestrator to find topic
-	exists, topicName, _, err := database.CheckHashExists(s.app.GetOrchestratorDB(), hash)
+	exists, topicName, _, err := s.app.GetAssetIndex().Lookup(hash)
 	if err != nil {
 		return nil, WrapInternalError(err)
 	}
@@ -90,6 +102,18 @@ func (s *MetadataService) Get(hash string) (*AssetMetadata, error) {
 		return nil, ErrAssetNotFoundWithHash(hash)
 	}
 
+	if asOf > 0 {
+		if asset.CreatedAt > asOf {
+			return nil, NewServiceError(constants.ErrCodeAssetNotFound,
+				fmt.Sprintf("asset %s did not exist at %d", hash, asOf))
+		}
+		computed, withProcessor, err := database.GetMetadataAsOf(topicDB, hash, asOf)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		return newAssetMetadata(asset, computed, withProcessor), nil
+	}
+
 	// Get computed metadata
 	computed, err := database.GetMetadataComputed(topicDB, hash)
 	if err != nil {
@@ -104,6 +128,11 @@ func (s *MetadataService) Get(hash string) (*AssetMetadata, error) {
 		withProcessor = []database.MetadataWithProcessor{}
 	}
 
+	return newAssetMetadata(asset, computed, withProcessor), nil
+}
+
+// newAssetMetadata assembles the metadata response of an asset.
+func newAssetMetadata(asset *database.Asset, computed map[string]interface{}, withProcessor []database.MetadataWithProcessor) *AssetMetadata {
 	result := &AssetMetadata{
 		ComputedMetadata:      computed,
 		MetadataWithProcessor: withProcessor,
@@ -113,8 +142,7 @@ func (s *MetadataService) Get(hash string) (*AssetMetadata, error) {
 	result.Asset.Size = asset.AssetSize
 	result.Asset.CreatedAt = asset.CreatedAt
 	result.Asset.ParentID = asset.ParentID
-
-	return result, nil
+	return result
 }
 
 // Set sets or deletes metadata for an asset.
@@ -130,7 +158,7 @@ func (s *MetadataService) Set(hash string, req *MetadataSetRequest) (*MetadataSe
 	}
 
 	// Look up in orchestrator to find topic
-	exists, topicName, _, err := database.CheckHashExists(s.app.GetOrchestratorDB(), hash)
+	exists, topicName, _, err := s.app.GetAssetIndex().Lookup(hash)
 	if err != nil {
 		return nil, WrapInternalError(err)
 	}
@@ -143,6 +171,9 @@ func (s *MetadataService) Set(hash string, req *MetadataSetRequest) (*MetadataSe
 	if !healthy {
 		return nil, ErrTopicUnhealthyWithReason(topicName, errMsg)
 	}
+	if err := checkTopicWritable(s.app, topicName); err != nil {
+		return nil, err
+	}
 
 	// Convert value to string
 	valueStr, err := s.convertValueToString(req.Op, req.Value)
@@ -239,7 +270,7 @@ func (s *MetadataService) convertValueToString(op string, value interface{}) (st
 // GetTopicForHash returns the topic name for a given hash.
 // This is a helper for batch operations.
 func (s *MetadataService) GetTopicForHash(hash string) (string, error) {
-	exists, topicName, _, err := database.CheckHashExists(s.app.GetOrchestratorDB(), hash)
+	exists, topicName, _, err := s.app.GetAssetIndex().Lookup(hash)
 	if err != nil {
 		return "", WrapInternalError(err)
 	}
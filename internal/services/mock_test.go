@@ -7,6 +7,7 @@ import (
 
 	"silobang/internal/audit"
 	"silobang/internal/config"
+	"silobang/internal/database"
 	"silobang/internal/logger"
 	"silobang/internal/prompts"
 	"silobang/internal/queries"
@@ -28,6 +29,7 @@ type mockAppState struct {
 	log            *logger.Logger
 	auditLogger    *audit.Logger
 	startedAt      time.Time
+	startup        *StartupRecorder
 
 	// Concurrency control
 	topicWriteMu   map[string]*sync.Mutex
@@ -50,6 +52,10 @@ func newMockAppState() *mockAppState {
 }
 
 func (m *mockAppState) GetOrchestratorDB() *sql.DB                   { return m.orchestratorDB }
+func (m *mockAppState) GetAssetIndex() database.AssetIndex {
+	return database.NewSQLiteAssetIndex(m.orchestratorDB)
+}
+func (m *mockAppState) GetAuditStore() audit.Store { return audit.NewSQLiteStore(m.orchestratorDB) }
 func (m *mockAppState) GetTopicDB(topicName string) (*sql.DB, error) { return m.topicDBs[topicName], nil }
 func (m *mockAppState) GetTopicDBsForQuery(topicNames []string) (map[string]*sql.DB, []string, error) {
 	if len(topicNames) == 0 {
@@ -99,6 +105,8 @@ func (m *mockAppState) SetPromptsManager(pm *prompts.Manager)        { m.prompts
 func (m *mockAppState) GetAuditLogger() *audit.Logger                { return m.auditLogger }
 func (m *mockAppState) SetOrchestratorDB(db *sql.DB) { m.orchestratorDB = db }
 func (m *mockAppState) GetStartedAt() time.Time     { return m.startedAt }
+func (m *mockAppState) GetStartupRecorder() *StartupRecorder { return m.startup }
+func (m *mockAppState) SetStartupRecorder(r *StartupRecorder) { m.startup = r }
 func (m *mockAppState) GetTopicWriteMu(topicName string) *sync.Mutex {
 	m.topicWriteMuMu.Lock()
 	defer m.topicWriteMuMu.Unlock()
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"silobang/internal/audit"
 	"silobang/internal/constants"
 	"silobang/internal/logger"
 	"silobang/internal/sanitize"
@@ -19,6 +20,7 @@ type MonitoringService struct {
 	app    AppState
 	logger *logger.Logger
 	statsCache *StatsCache
+	assetCache *AssetCache
 }
 
 // NewMonitoringService creates a new monitoring service instance.
@@ -35,6 +37,11 @@ func (s *MonitoringService) SetStatsCache(cache *StatsCache) {
 	s.statsCache = cache
 }
 
+// SetAssetCache sets the asset cache reference for monitoring.
+func (s *MonitoringService) SetAssetCache(cache *AssetCache) {
+	s.assetCache = cache
+}
+
 // =============================================================================
 // Response Types
 // =============================================================================
@@ -45,6 +52,27 @@ type MonitoringInfo struct {
 	Application ApplicationInfo `json:"application"`
 	Logs        LogsSummary     `json:"logs"`
 	Service     *ServiceInfoSnapshot `json:"service,omitempty"`
+	StatsCache  *StatsCacheStatus    `json:"stats_cache,omitempty"`
+	AssetCache  *AssetCacheStatus    `json:"asset_cache,omitempty"`
+	OriginCache *OriginCacheStatus   `json:"origin_cache,omitempty"`
+	Watch       *WatchFolderStatus   `json:"watch_folders,omitempty"`
+	AuditQueue  *audit.QueueStats    `json:"audit_queue,omitempty"`
+	Streams     *StreamStats         `json:"streams,omitempty"`
+	Validation  *ValidationStatus    `json:"validation,omitempty"`
+	Subsystems  []SubsystemStatus    `json:"subsystems,omitempty"`
+}
+
+// StreamStats reports the open Server-Sent Events streams against the
+// configured caps. It is filled in by the HTTP layer, which holds them.
+type StreamStats struct {
+	Open         int            `json:"open"`
+	MaxOpen      int            `json:"max_open"`
+	MaxPerClient int            `json:"max_per_client"`
+	ByEndpoint   map[string]int `json:"by_endpoint"`
+	ByProtocol   map[string]int `json:"by_protocol"` // e.g. "HTTP/1.1", "HTTP/2.0"
+	Rejected     uint64         `json:"rejected"`    // since startup, over either cap
+	HTTP2Enabled bool           `json:"http2_enabled"`
+	TLSEnabled   bool           `json:"tls_enabled"`
 }
 
 // SystemInfo holds OS-level resource metrics.
@@ -140,6 +168,27 @@ func (s *MonitoringService) GetMonitoringInfo() (*MonitoringInfo, error) {
 		info.Service = s.statsCache.GetServiceInfo()
 	}
 
+	// Restored stats are served as-is until background reconciliation completes
+	if s.statsCache != nil {
+		status := s.statsCache.Status()
+		info.StatsCache = &status
+	}
+
+	// Asset cache hit/miss ratios
+	if s.assetCache != nil {
+		status := s.assetCache.Status()
+		info.AssetCache = &status
+	}
+
+	// Subsystems of the latest initialization, disabled ones included
+	info.Subsystems = s.app.GetStartupRecorder().Report().Subsystems
+
+	// Audit write queue depth and drops
+	if l := s.app.GetAuditLogger(); l != nil {
+		stats := l.QueueStats()
+		info.AuditQueue = &stats
+	}
+
 	s.logger.Debug("Monitoring: metrics collected successfully")
 	return info, nil
 }
@@ -186,6 +235,51 @@ func CheckDiskLimit(path string, maxDiskUsage int64) error {
 	return nil
 }
 
+// StorageHeadroom describes how many more bytes the working directory can
+// take, bounded by free space on its filesystem and by max_disk_usage.
+type StorageHeadroom struct {
+	RequiredBytes       int64  `json:"required_bytes"`
+	HeadroomBytes       int64  `json:"headroom_bytes"`
+	FreeBytes           int64  `json:"free_bytes"`                      // available on the filesystem
+	LimitRemainingBytes *int64 `json:"limit_remaining_bytes,omitempty"` // below max_disk_usage, when set
+	ReserveBytes        int64  `json:"reserve_bytes"`                   // kept free for database and log writes
+}
+
+// CheckStorageHeadroom verifies that required more bytes fit in the working
+// directory. Returns the headroom with ErrCodeStorageFull when they do not.
+// Fails closed: returns an error if disk stats cannot be read.
+func CheckStorageHeadroom(path string, maxDiskUsage int64, required int64) (*StorageHeadroom, error) {
+	free, err := GetDiskFreeBytes(path)
+	if err != nil {
+		return nil, NewServiceError(constants.ErrCodeStorageFull,
+			"storage headroom check failed: unable to read disk stats")
+	}
+
+	h := &StorageHeadroom{
+		RequiredBytes: required,
+		FreeBytes:     int64(free),
+		ReserveBytes:  constants.StorageReserveBytes,
+	}
+	h.HeadroomBytes = max(0, h.FreeBytes-constants.StorageReserveBytes)
+
+	if maxDiskUsage > 0 {
+		used, err := GetDiskUsageBytes(path)
+		if err != nil {
+			return nil, NewServiceError(constants.ErrCodeStorageFull,
+				"storage headroom check failed: unable to read disk stats")
+		}
+		remaining := max(0, maxDiskUsage-int64(used))
+		h.LimitRemainingBytes = &remaining
+		h.HeadroomBytes = min(h.HeadroomBytes, remaining)
+	}
+
+	if required > h.HeadroomBytes || h.HeadroomBytes == 0 {
+		return h, NewServiceError(constants.ErrCodeStorageFull,
+			fmt.Sprintf("storage full: upload needs %d bytes but only %d bytes of headroom remain", required, h.HeadroomBytes))
+	}
+	return h, nil
+}
+
 // calculateDirSize walks the directory tree and sums all regular file sizes.
 // Returns 0 if the directory cannot be walked.
 func (s *MonitoringService) calculateDirSize(dirPath string) uint64 {
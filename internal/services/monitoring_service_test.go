@@ -641,3 +641,51 @@ func TestGetMonitoringInfo_NoRuntimeField(t *testing.T) {
 		t.Error("MonitoringInfo should not contain 'runtime' field")
 	}
 }
+
+// =============================================================================
+// CheckStorageHeadroom Tests
+// =============================================================================
+
+func TestCheckStorageHeadroom_Fits(t *testing.T) {
+	h, err := CheckStorageHeadroom(t.TempDir(), 0, 1024)
+	if err != nil {
+		t.Fatalf("Expected 1KB to fit, got: %v", err)
+	}
+	if h.LimitRemainingBytes != nil || h.HeadroomBytes != h.FreeBytes-constants.StorageReserveBytes {
+		t.Errorf("Expected headroom to be free space minus reserve, got %+v", h)
+	}
+}
+
+func TestCheckStorageHeadroom_ExceedsFreeSpace(t *testing.T) {
+	h, err := CheckStorageHeadroom(t.TempDir(), 0, 1<<62)
+	if !isServiceErrorCode(err, constants.ErrCodeStorageFull) {
+		t.Fatalf("Expected %s, got: %v", constants.ErrCodeStorageFull, err)
+	}
+	if h == nil || h.RequiredBytes != 1<<62 || h.FreeBytes <= 0 {
+		t.Errorf("Expected headroom details, got %+v", h)
+	}
+}
+
+func TestCheckStorageHeadroom_BoundedByDiskLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+	used, err := GetDiskUsageBytes(tmpDir)
+	if err != nil {
+		t.Fatalf("GetDiskUsageBytes failed: %v", err)
+	}
+
+	// Limit leaves ~1MB: a 4MB upload must be rejected even if the disk has room
+	h, err := CheckStorageHeadroom(tmpDir, int64(used)+1<<20, 4<<20)
+	if !isServiceErrorCode(err, constants.ErrCodeStorageFull) {
+		t.Fatalf("Expected %s, got: %v", constants.ErrCodeStorageFull, err)
+	}
+	if h.LimitRemainingBytes == nil || h.HeadroomBytes > 1<<20 {
+		t.Errorf("Expected headroom bounded by the limit, got %+v", h)
+	}
+}
+
+func TestCheckStorageHeadroom_InvalidPath_FailsClosed(t *testing.T) {
+	_, err := CheckStorageHeadroom("/nonexistent/path/that/does/not/exist", 0, 1)
+	if !isServiceErrorCode(err, constants.ErrCodeStorageFull) {
+		t.Errorf("Expected %s for unreadable disk stats, got: %v", constants.ErrCodeStorageFull, err)
+	}
+}
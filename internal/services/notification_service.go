@@ -0,0 +1,882 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/plugin"
+)
+
+// NotificationService manages topic subscriptions, the in-app notification
+// feed and webhook/email delivery.
+//
+// Notifications are written to the feed when an event is published and sent
+// at once to the user's open notification streams and to the notifier
+// plugins of the configuration. A background loop then
+// delivers undelivered notifications to users that have a webhook URL or
+// email address set: immediately on the next tick, or batched into one
+// message per digest interval.
+type NotificationService struct {
+	app       AppState
+	logger    *logger.Logger
+	auth      *AuthService
+	client    *http.Client
+	notifiers []namedNotifier
+
+	// sendMail is smtp.SendMail, replaceable in tests
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+	deliverMu sync.Mutex // serializes delivery passes
+	stop      chan struct{}
+	stopOnce  sync.Once
+
+	streamMu sync.Mutex
+	streams  map[chan NotificationStreamEvent]int64 // open streams → user ID
+}
+
+// SubscribeRequest selects the events of a topic subscription.
+type SubscribeRequest struct {
+	Events       []string `json:"events"`        // empty = all events
+	MetadataKeys []string `json:"metadata_keys"` // empty = any key
+}
+
+// NotificationPreferencesRequest updates delivery preferences. Nil fields
+// are left unchanged; an empty webhook URL or email disables that channel.
+type NotificationPreferencesRequest struct {
+	Delivery   *string `json:"delivery"`
+	WebhookURL *string `json:"webhook_url"`
+	Email      *string `json:"email"`
+}
+
+// NotificationFeed is one page of a user's notification feed.
+type NotificationFeed struct {
+	Notifications []database.Notification `json:"notifications"`
+	Total         int64                   `json:"total"`
+	Unread        int64                   `json:"unread"`
+	UnreadByEvent map[string]int64        `json:"unread_by_event"`
+	Limit         int                     `json:"limit"`
+	Offset        int                     `json:"offset"`
+}
+
+// NotificationStreamEvent is sent to a user's open notification streams.
+// Type is one of constants.NotificationStreamEvent*.
+type NotificationStreamEvent struct {
+	Type         string                 `json:"-"`
+	Notification *database.Notification `json:"notification,omitempty"`
+	Marked       int64                  `json:"marked,omitempty"`
+	Unread       int64                  `json:"unread"`
+}
+
+// NotificationDelivery is the JSON body posted to a user's webhook.
+type NotificationDelivery struct {
+	Username      string                  `json:"username"`
+	Delivery      string                  `json:"delivery"`
+	SentAt        int64                   `json:"sent_at"`
+	Notifications []database.Notification `json:"notifications"`
+}
+
+// namedNotifier is a notifier plugin enabled in notifiers.
+type namedNotifier struct {
+	typ      string
+	notifier plugin.Notifier
+}
+
+// MetadataChange identifies one metadata key changed on one asset.
+type MetadataChange struct {
+	AssetID string
+	Key     string
+}
+
+// NewNotificationService creates a new notification service and starts its
+// delivery loop. Returns nil if the orchestrator DB is not available.
+func NewNotificationService(app AppState, log *logger.Logger) *NotificationService {
+	if app.GetOrchestratorDB() == nil {
+		return nil
+	}
+
+	svc := &NotificationService{
+		app:      app,
+		logger:   log,
+		client:   &http.Client{Timeout: app.GetConfig().Notifications.WebhookTimeout()},
+		sendMail: smtp.SendMail,
+		stop:     make(chan struct{}),
+		streams:  make(map[chan NotificationStreamEvent]int64),
+	}
+
+	// The configuration was validated on load; a notifier failing here is skipped
+	for i, p := range app.GetConfig().Notifiers {
+		notifier, err := plugin.NewNotifier(p.Type, p.Options)
+		if err != nil {
+			log.Error("Notifications: notifiers[%d]: %v", i, err)
+			continue
+		}
+		svc.notifiers = append(svc.notifiers, namedNotifier{typ: p.Type, notifier: notifier})
+	}
+
+	go svc.deliveryLoop()
+
+	return svc
+}
+
+// SetAuthService sets the auth service used to check that subscribers can
+// still read a topic before notifying them.
+func (s *NotificationService) SetAuthService(authService *AuthService) {
+	s.auth = authService
+}
+
+// Stop stops the delivery goroutine (call during graceful shutdown).
+func (s *NotificationService) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// ============================================================================
+// Subscriptions and preferences
+// ============================================================================
+
+// Subscribe creates or replaces the user's subscription to a topic.
+func (s *NotificationService) Subscribe(userID int64, topic string, req *SubscribeRequest) (*database.NotificationSubscription, error) {
+	events, err := normalizeNotificationEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := normalizeNotificationKeys(req.MetadataKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.app.GetOrchestratorDB()
+	now := time.Now().Unix()
+	if err := database.UpsertNotificationSubscription(db, database.NotificationSubscription{
+		UserID:       userID,
+		Topic:        topic,
+		Events:       events,
+		MetadataKeys: keys,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}); err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	sub, err := database.GetNotificationSubscription(db, userID, topic)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	s.logger.Info("Notifications: user_id=%d subscribed to topic=%s events=%v", userID, topic, events)
+	return sub, nil
+}
+
+// Unsubscribe removes the user's subscription to a topic.
+func (s *NotificationService) Unsubscribe(userID int64, topic string) error {
+	removed, err := database.DeleteNotificationSubscription(s.app.GetOrchestratorDB(), userID, topic)
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	if !removed {
+		return NewServiceError(constants.ErrCodeSubscriptionNotFound, "not subscribed to topic: "+topic)
+	}
+	s.logger.Info("Notifications: user_id=%d unsubscribed from topic=%s", userID, topic)
+	return nil
+}
+
+// ListSubscriptions returns the user's subscriptions.
+func (s *NotificationService) ListSubscriptions(userID int64) ([]database.NotificationSubscription, error) {
+	subs, err := database.ListNotificationSubscriptions(s.app.GetOrchestratorDB(), userID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return subs, nil
+}
+
+// RemoveTopic drops all subscriptions to a topic that no longer exists.
+func (s *NotificationService) RemoveTopic(topic string) {
+	if err := database.DeleteTopicNotificationSubscriptions(s.app.GetOrchestratorDB(), topic); err != nil {
+		s.logger.Error("Notifications: failed to remove subscriptions for topic=%s: %v", topic, err)
+	}
+}
+
+// GetPreferences returns the user's delivery preferences, or the defaults
+// (immediate, no webhook or email) if never set.
+func (s *NotificationService) GetPreferences(userID int64) (*database.NotificationPreferences, error) {
+	prefs, err := database.GetNotificationPreferences(s.app.GetOrchestratorDB(), userID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if prefs == nil {
+		prefs = &database.NotificationPreferences{UserID: userID, Delivery: constants.NotificationDeliveryImmediate}
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences validates and stores the user's delivery preferences.
+func (s *NotificationService) UpdatePreferences(userID int64, req *NotificationPreferencesRequest) (*database.NotificationPreferences, error) {
+	prefs, err := s.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Delivery != nil {
+		if *req.Delivery != constants.NotificationDeliveryImmediate && *req.Delivery != constants.NotificationDeliveryDigest {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+				fmt.Sprintf("delivery must be %q or %q", constants.NotificationDeliveryImmediate, constants.NotificationDeliveryDigest))
+		}
+		prefs.Delivery = *req.Delivery
+	}
+	if req.WebhookURL != nil {
+		webhookURL := strings.TrimSpace(*req.WebhookURL)
+		if err := validateWebhookURL(webhookURL); err != nil {
+			return nil, err
+		}
+		prefs.WebhookURL = webhookURL
+	}
+	if req.Email != nil {
+		email, err := s.validateEmail(strings.TrimSpace(*req.Email))
+		if err != nil {
+			return nil, err
+		}
+		prefs.Email = email
+	}
+
+	prefs.UpdatedAt = time.Now().Unix()
+	if err := database.UpsertNotificationPreferences(s.app.GetOrchestratorDB(), *prefs); err != nil {
+		return nil, WrapInternalError(err)
+	}
+	s.logger.Info("Notifications: user_id=%d updated preferences (delivery=%s webhook=%t email=%t)",
+		userID, prefs.Delivery, prefs.WebhookURL != "", prefs.Email != "")
+	return prefs, nil
+}
+
+// ============================================================================
+// Feed
+// ============================================================================
+
+// Feed returns a page of the user's notifications, newest first.
+func (s *NotificationService) Feed(userID int64, unreadOnly bool, limit, offset int) (*NotificationFeed, error) {
+	if limit <= 0 {
+		limit = constants.NotificationFeedDefaultLimit
+	}
+	if limit > constants.NotificationFeedMaxLimit {
+		limit = constants.NotificationFeedMaxLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	db := s.app.GetOrchestratorDB()
+	notifications, total, err := database.ListNotifications(db, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	unread, byEvent, err := s.UnreadCounts(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotificationFeed{
+		Notifications: notifications,
+		Total:         total,
+		Unread:        unread,
+		UnreadByEvent: byEvent,
+		Limit:         limit,
+		Offset:        offset,
+	}, nil
+}
+
+// UnreadCounts returns the number of unread notifications of the user, in
+// total and per event.
+func (s *NotificationService) UnreadCounts(userID int64) (int64, map[string]int64, error) {
+	byEvent, err := database.CountUnreadNotificationsByEvent(s.app.GetOrchestratorDB(), userID)
+	if err != nil {
+		return 0, nil, WrapInternalError(err)
+	}
+	var unread int64
+	for _, count := range byEvent {
+		unread += count
+	}
+	return unread, byEvent, nil
+}
+
+// MarkRead marks the given notifications (or all, when ids is empty) as read
+// and returns how many were marked.
+func (s *NotificationService) MarkRead(userID int64, ids []int64) (int64, error) {
+	if len(ids) > constants.NotificationFeedMaxLimit {
+		return 0, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("too many ids (max %d)", constants.NotificationFeedMaxLimit))
+	}
+	db := s.app.GetOrchestratorDB()
+	marked, err := database.MarkNotificationsRead(db, userID, ids, time.Now().Unix())
+	if err != nil {
+		return 0, WrapInternalError(err)
+	}
+
+	// Other open clients of the user update their unread badge
+	if marked > 0 && s.hasStream(userID) {
+		if unread, err := database.CountUnreadNotifications(db, userID); err == nil {
+			s.send(userID, NotificationStreamEvent{Type: constants.NotificationStreamEventRead, Marked: marked, Unread: unread})
+		}
+	}
+	return marked, nil
+}
+
+// ============================================================================
+// Streams
+// ============================================================================
+
+// OpenStream returns a channel receiving the user's new notifications and
+// read markers. Events are dropped for streams that fall behind; clients
+// catch up through the feed.
+func (s *NotificationService) OpenStream(userID int64) chan NotificationStreamEvent {
+	ch := make(chan NotificationStreamEvent, constants.NotificationStreamBufferSize)
+	s.streamMu.Lock()
+	s.streams[ch] = userID
+	s.streamMu.Unlock()
+	return ch
+}
+
+// CloseStream removes and closes a stream channel.
+func (s *NotificationService) CloseStream(ch chan NotificationStreamEvent) {
+	s.streamMu.Lock()
+	if _, ok := s.streams[ch]; ok {
+		delete(s.streams, ch)
+		close(ch)
+	}
+	s.streamMu.Unlock()
+}
+
+func (s *NotificationService) hasStream(userID int64) bool {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	for _, id := range s.streams {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *NotificationService) send(userID int64, event NotificationStreamEvent) {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	for ch, id := range s.streams {
+		if id != userID {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// stream sends stored notifications to the open streams of their users.
+func (s *NotificationService) stream(notifications []database.Notification) {
+	db := s.app.GetOrchestratorDB()
+	for i := range notifications {
+		n := &notifications[i]
+		if !s.hasStream(n.UserID) {
+			continue
+		}
+		unread, err := database.CountUnreadNotifications(db, n.UserID)
+		if err != nil {
+			s.logger.Warn("Notifications: failed to count unread notifications of user_id=%d: %v", n.UserID, err)
+			continue
+		}
+		s.send(n.UserID, NotificationStreamEvent{Type: constants.NotificationStreamEventNotification, Notification: n, Unread: unread})
+	}
+}
+
+// dispatch hands stored notifications to the notifier plugins in the
+// background. Failures are logged and not retried.
+func (s *NotificationService) dispatch(notifications []database.Notification) {
+	if len(s.notifiers) == 0 {
+		return
+	}
+
+	go func() {
+		usernames := make(map[int64]string)
+		for _, n := range notifications {
+			username, ok := usernames[n.UserID]
+			if !ok && s.auth != nil {
+				if user, err := s.auth.GetStore().GetUserByID(n.UserID); err == nil {
+					username = user.Username
+				}
+				usernames[n.UserID] = username
+			}
+
+			event := plugin.Notification{
+				ID:        n.ID,
+				UserID:    n.UserID,
+				Username:  username,
+				Event:     n.Event,
+				Topic:     n.Topic,
+				Actor:     n.Actor,
+				Details:   n.Details,
+				CreatedAt: n.CreatedAt,
+			}
+			if n.AssetID != nil {
+				event.AssetID = *n.AssetID
+			}
+			for _, target := range s.notifiers {
+				ctx, cancel := context.WithTimeout(context.Background(), constants.PluginNotifierTimeout)
+				if err := target.notifier.Notify(ctx, event); err != nil {
+					s.logger.Warn("Notifications: %s notifier failed for notification id=%d: %v", target.typ, n.ID, err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// ============================================================================
+// Publishing
+// ============================================================================
+
+// AssetAdded notifies the subscribers of a topic about a newly uploaded asset.
+// The uploader is not notified of their own upload.
+func (s *NotificationService) AssetAdded(topic, assetID, originName string, actorID int64, actor string) {
+	details, _ := json.Marshal(map[string]string{"origin_name": originName})
+	s.publish(topic, constants.NotificationEventAssetAdded, actorID, func(sub database.TopicSubscriber) *database.Notification {
+		return &database.Notification{AssetID: &assetID, Actor: actor, Details: details}
+	})
+}
+
+// MetadataChanged notifies the subscribers of a topic about changed metadata
+// keys. All changes of one request are summarized in a single notification
+// per subscriber, limited to the keys the subscriber filters on.
+func (s *NotificationService) MetadataChanged(topic string, changes []MetadataChange, actorID int64, actor string) {
+	if len(changes) == 0 {
+		return
+	}
+
+	s.publish(topic, constants.NotificationEventMetadataChanged, actorID, func(sub database.TopicSubscriber) *database.Notification {
+		keys := make(map[string]bool)
+		assets := make(map[string]bool)
+		var assetIDs []string
+		for _, c := range changes {
+			if len(sub.MetadataKeys) > 0 && !containsKey(sub.MetadataKeys, c.Key) {
+				continue
+			}
+			keys[c.Key] = true
+			if !assets[c.AssetID] {
+				assets[c.AssetID] = true
+				assetIDs = append(assetIDs, c.AssetID)
+			}
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+
+		details := map[string]interface{}{
+			"keys":        sortedKeys(keys),
+			"asset_count": len(assetIDs),
+			"asset_ids":   assetIDs[:min(len(assetIDs), constants.NotificationMaxAssetIDs)],
+		}
+		data, _ := json.Marshal(details)
+
+		n := &database.Notification{Actor: actor, Details: data}
+		if len(assetIDs) == 1 {
+			n.AssetID = &assetIDs[0]
+		}
+		return n
+	})
+}
+
+// Notify adds a notification about the user's own activity or account, such
+// as an inbox export becoming ready or a grant given by actor. No topic
+// subscription is involved.
+func (s *NotificationService) Notify(userID int64, event, actor string, details interface{}) {
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return
+	}
+	data, _ := json.Marshal(details)
+
+	// Without a channel there is nothing to deliver beyond the feed
+	prefs, err := database.GetNotificationPreferences(db, userID)
+	if err != nil {
+		s.logger.Error("Notifications: failed to load preferences of user_id=%d: %v", userID, err)
+		return
+	}
+	hasChannel := prefs != nil && (prefs.WebhookURL != "" || prefs.Email != "")
+
+	notifications := []database.Notification{{UserID: userID, Event: event, Actor: actor, Details: data, CreatedAt: time.Now().Unix()}}
+	if err := database.InsertNotifications(db, notifications, map[int64]bool{userID: !hasChannel}); err != nil {
+		s.logger.Error("Notifications: failed to store %s notification for user_id=%d: %v", event, userID, err)
+		return
+	}
+	s.stream(notifications)
+	s.dispatch(notifications)
+	s.logger.Debug("Notifications: %s notified user_id=%d", event, userID)
+}
+
+// publish writes one notification per subscriber of the topic event. build
+// returns nil to skip a subscriber.
+func (s *NotificationService) publish(topic, event string, actorID int64, build func(database.TopicSubscriber) *database.Notification) {
+	db := s.app.GetOrchestratorDB()
+	subscribers, err := database.ListTopicSubscribers(db, topic, event)
+	if err != nil {
+		s.logger.Error("Notifications: failed to list subscribers of topic=%s: %v", topic, err)
+		return
+	}
+
+	now := time.Now().Unix()
+	var notifications []database.Notification
+	delivered := make(map[int64]bool)
+	for _, sub := range subscribers {
+		if sub.UserID == actorID || !s.canRead(sub.UserID, topic) {
+			continue
+		}
+		n := build(sub)
+		if n == nil {
+			continue
+		}
+		n.UserID = sub.UserID
+		n.Topic = topic
+		n.Event = event
+		n.CreatedAt = now
+		notifications = append(notifications, *n)
+		delivered[sub.UserID] = !sub.HasChannel
+	}
+
+	if len(notifications) == 0 {
+		return
+	}
+	if err := database.InsertNotifications(db, notifications, delivered); err != nil {
+		s.logger.Error("Notifications: failed to store %s notifications for topic=%s: %v", event, topic, err)
+		return
+	}
+	s.stream(notifications)
+	s.dispatch(notifications)
+	s.logger.Debug("Notifications: %s on topic=%s notified %d user(s)", event, topic, len(notifications))
+}
+
+// canRead reports whether the user may still query the topic. Grants can be
+// revoked after subscribing, so this is checked on every event.
+func (s *NotificationService) canRead(userID int64, topic string) bool {
+	if s.auth == nil {
+		return false
+	}
+	store := s.auth.GetStore()
+	user, err := store.GetUserByID(userID)
+	if err != nil {
+		return false
+	}
+	grants, err := store.GetActiveGrantsForUser(userID)
+	if err != nil {
+		s.logger.Warn("Notifications: failed to load grants of user_id=%d: %v", userID, err)
+		return false
+	}
+	identity := &auth.Identity{User: &user.User, Grants: grants}
+	return s.auth.GetEvaluator().HasTopicAccess(identity, constants.AuthActionQuery, topic)
+}
+
+// ============================================================================
+// Delivery
+// ============================================================================
+
+// deliveryLoop periodically delivers pending notifications and purges feed
+// entries past retention.
+func (s *NotificationService) deliveryLoop() {
+	deliver := time.NewTicker(constants.NotificationDeliveryInterval)
+	defer deliver.Stop()
+	cleanup := time.NewTicker(constants.NotificationCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			s.logger.Info("Notifications: delivery goroutine stopped")
+			return
+		case now := <-deliver.C:
+			s.DeliverPending(now)
+		case now := <-cleanup.C:
+			s.purgeExpired(now)
+		}
+	}
+}
+
+// DeliverPending sends undelivered notifications to every user with a
+// webhook URL or email address. Users on digest delivery are sent at most
+// one message per digest interval.
+func (s *NotificationService) DeliverPending(now time.Time) {
+	s.deliverMu.Lock()
+	defer s.deliverMu.Unlock()
+
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return // working directory is being switched
+	}
+	pending, err := database.ListUsersWithUndeliveredNotifications(db)
+	if err != nil {
+		s.logger.Error("Notifications: failed to list pending deliveries: %v", err)
+		return
+	}
+
+	for _, prefs := range pending {
+		s.deliverUser(prefs, now)
+	}
+}
+
+func (s *NotificationService) deliverUser(prefs database.NotificationPreferences, now time.Time) {
+	db := s.app.GetOrchestratorDB()
+	cfg := s.app.GetConfig().Notifications
+	sendEmail := prefs.Email != "" && cfg.EmailEnabled()
+
+	// The user removed every channel since these were stored
+	if prefs.WebhookURL == "" && !sendEmail {
+		if err := database.MarkUserNotificationsDelivered(db, prefs.UserID, now.Unix()); err != nil {
+			s.logger.Error("Notifications: failed to skip delivery for user_id=%d: %v", prefs.UserID, err)
+		}
+		return
+	}
+
+	if prefs.Delivery == constants.NotificationDeliveryDigest &&
+		now.Sub(time.Unix(prefs.LastDeliveredAt, 0)) < cfg.DigestInterval() {
+		return
+	}
+
+	batch, err := database.ListUndeliveredNotifications(db, prefs.UserID, constants.NotificationMaxDeliveryBatch)
+	if err != nil || len(batch) == 0 {
+		if err != nil {
+			s.logger.Error("Notifications: failed to load pending notifications for user_id=%d: %v", prefs.UserID, err)
+		}
+		return
+	}
+	ids := make([]int64, len(batch))
+	for i, n := range batch {
+		ids[i] = n.ID
+	}
+
+	username := strconv.FormatInt(prefs.UserID, 10)
+	if s.auth != nil {
+		if user, err := s.auth.GetStore().GetUserByID(prefs.UserID); err == nil {
+			username = user.Username
+		}
+	}
+
+	// Delivered once any channel accepts the batch
+	var sent bool
+	if prefs.WebhookURL != "" {
+		if err := s.sendWebhook(prefs.WebhookURL, NotificationDelivery{
+			Username:      username,
+			Delivery:      prefs.Delivery,
+			SentAt:        now.Unix(),
+			Notifications: batch,
+		}); err != nil {
+			s.logger.Warn("Notifications: webhook delivery for user=%s failed: %v", username, err)
+		} else {
+			sent = true
+		}
+	}
+	if sendEmail {
+		if err := s.sendEmailDelivery(prefs.Email, username, batch, now); err != nil {
+			s.logger.Warn("Notifications: email delivery for user=%s failed: %v", username, err)
+		} else {
+			sent = true
+		}
+	}
+
+	if !sent {
+		abandoned, err := database.RecordNotificationDeliveryFailure(db, ids, constants.NotificationMaxDeliveryAttempts, now.Unix())
+		if err != nil {
+			s.logger.Error("Notifications: failed to record delivery failure for user=%s: %v", username, err)
+		} else if abandoned > 0 {
+			s.logger.Warn("Notifications: gave up delivering %d notification(s) to user=%s after %d attempts",
+				abandoned, username, constants.NotificationMaxDeliveryAttempts)
+		}
+		return
+	}
+
+	if err := database.MarkNotificationsDelivered(db, ids, now.Unix()); err != nil {
+		s.logger.Error("Notifications: failed to mark notifications delivered for user=%s: %v", username, err)
+		return
+	}
+	if err := database.SetNotificationsLastDelivered(db, prefs.UserID, now.Unix()); err != nil {
+		s.logger.Error("Notifications: failed to record delivery time for user=%s: %v", username, err)
+	}
+	s.logger.Debug("Notifications: delivered %d notification(s) to user=%s", len(batch), username)
+}
+
+func (s *NotificationService) sendWebhook(webhookURL string, payload NotificationDelivery) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", constants.NotificationUserAgent)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *NotificationService) sendEmailDelivery(to, username string, batch []database.Notification, now time.Time) error {
+	smtpCfg := s.app.GetConfig().Notifications.SMTP
+	var smtpAuth smtp.Auth
+	if smtpCfg.Username != "" {
+		smtpAuth = smtp.PlainAuth("", smtpCfg.Username, smtpCfg.Password, smtpCfg.Host)
+	}
+	addr := net.JoinHostPort(smtpCfg.Host, strconv.Itoa(smtpCfg.Port))
+	return s.sendMail(addr, smtpAuth, smtpCfg.From, []string{to}, formatNotificationEmail(smtpCfg.From, to, username, batch, now))
+}
+
+// formatNotificationEmail renders a plain-text message listing the batch.
+func formatNotificationEmail(from, to, username string, batch []database.Notification, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: [%s] %d new notification(s)\r\n", constants.AppDisplayName, len(batch))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.UTC().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	fmt.Fprintf(&b, "Hello %s,\r\n\r\n", username)
+	for _, n := range batch {
+		fmt.Fprintf(&b, "- [%s] %s %s", time.Unix(n.CreatedAt, 0).UTC().Format(time.RFC3339), n.Topic, n.Event)
+		if n.AssetID != nil {
+			fmt.Fprintf(&b, " %s", *n.AssetID)
+		}
+		if n.Actor != "" {
+			fmt.Fprintf(&b, " by %s", n.Actor)
+		}
+		if len(n.Details) > 0 {
+			fmt.Fprintf(&b, " %s", n.Details)
+		}
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
+
+// purgeExpired deletes feed entries older than the retention period.
+func (s *NotificationService) purgeExpired(now time.Time) {
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return
+	}
+	retention := time.Duration(s.app.GetConfig().Notifications.RetentionDays) * 24 * time.Hour
+	removed, err := database.DeleteNotificationsBefore(db, now.Add(-retention).Unix())
+	if err != nil {
+		s.logger.Error("Notifications: retention cleanup failed: %v", err)
+		return
+	}
+	if removed > 0 {
+		s.logger.Info("Notifications: retention cleanup removed %d notification(s)", removed)
+	}
+}
+
+// ============================================================================
+// Validation
+// ============================================================================
+
+func normalizeNotificationEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return append([]string{}, constants.NotificationEvents...), nil
+	}
+	seen := make(map[string]bool)
+	for _, e := range events {
+		if !containsKey(constants.NotificationEvents, e) {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+				fmt.Sprintf("unknown event %q (valid: %s)", e, strings.Join(constants.NotificationEvents, ", ")))
+		}
+		seen[e] = true
+	}
+	return sortedKeys(seen), nil
+}
+
+func normalizeNotificationKeys(keys []string) ([]string, error) {
+	if len(keys) > constants.NotificationMaxMetadataKeys {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("too many metadata keys (max %d)", constants.NotificationMaxMetadataKeys))
+	}
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		if k == "" {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest, "metadata key cannot be empty")
+		}
+		if len(k) > constants.MaxMetadataKeyLength {
+			return nil, NewServiceError(constants.ErrCodeMetadataKeyTooLong,
+				fmt.Sprintf("metadata key exceeds %d characters", constants.MaxMetadataKeyLength))
+		}
+		seen[k] = true
+	}
+	if len(seen) == 0 {
+		return nil, nil
+	}
+	return sortedKeys(seen), nil
+}
+
+func validateWebhookURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	if len(raw) > constants.NotificationWebhookMaxURLLength {
+		return NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("webhook_url exceeds %d characters", constants.NotificationWebhookMaxURLLength))
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NewServiceError(constants.ErrCodeInvalidRequest, "webhook_url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+func (s *NotificationService) validateEmail(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	if !s.app.GetConfig().Notifications.EmailEnabled() {
+		return "", NewServiceError(constants.ErrCodeInvalidRequest, "email delivery is not configured on this server")
+	}
+	if len(raw) > constants.NotificationEmailMaxLength {
+		return "", NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("email exceeds %d characters", constants.NotificationEmailMaxLength))
+	}
+	addr, err := mail.ParseAddress(raw)
+	if err != nil || addr.Address != raw {
+		return "", NewServiceError(constants.ErrCodeInvalidRequest, "email must be a plain address such as user@example.com")
+	}
+	return addr.Address, nil
+}
+
+func containsKey(list []string, target string) bool {
+	for _, s := range list {
+		if s == target {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
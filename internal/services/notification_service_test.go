@@ -0,0 +1,256 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+)
+
+// newNotificationTestService wires a notification service to an auth
+// service backed by a fresh orchestrator DB.
+func newNotificationTestService(t *testing.T) (*NotificationService, *auth.Store) {
+	t.Helper()
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+	mock.SetOrchestratorDB(setupOrchestratorDB(t, workDir, nil))
+
+	authService := NewAuthService(mock, mock.log)
+	t.Cleanup(authService.Stop)
+	svc := NewNotificationService(mock, mock.log)
+	t.Cleanup(svc.Stop)
+	svc.SetAuthService(authService)
+	return svc, authService.GetStore()
+}
+
+// createNotificationUser creates a user with a query grant, optionally
+// limited to the given topics.
+func createNotificationUser(t *testing.T, store *auth.Store, username string, topics ...string) *auth.User {
+	t.Helper()
+	user, err := store.CreateUser(username, username, "x", nil)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	var constraints *string
+	if len(topics) > 0 {
+		data, _ := json.Marshal(auth.QueryConstraints{AllowedTopics: topics})
+		s := string(data)
+		constraints = &s
+	}
+	if _, err := store.CreateGrant(user.ID, constants.AuthActionQuery, constraints, user.ID); err != nil {
+		t.Fatalf("CreateGrant: %v", err)
+	}
+	return user
+}
+
+func feedOf(t *testing.T, svc *NotificationService, userID int64) *NotificationFeed {
+	t.Helper()
+	feed, err := svc.Feed(userID, false, 0, 0)
+	if err != nil {
+		t.Fatalf("Feed: %v", err)
+	}
+	return feed
+}
+
+func TestNotificationSubscribe_Validation(t *testing.T) {
+	svc, store := newNotificationTestService(t)
+	alice := createNotificationUser(t, store, "alice")
+
+	if _, err := svc.Subscribe(alice.ID, "photos", &SubscribeRequest{Events: []string{"bogus"}}); err == nil {
+		t.Error("expected unknown event to be rejected")
+	}
+	if _, err := svc.Subscribe(alice.ID, "photos", &SubscribeRequest{MetadataKeys: []string{""}}); err == nil {
+		t.Error("expected empty metadata key to be rejected")
+	}
+
+	sub, err := svc.Subscribe(alice.ID, "photos", &SubscribeRequest{})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if len(sub.Events) != len(constants.NotificationEvents) {
+		t.Errorf("empty events should subscribe to all, got %v", sub.Events)
+	}
+
+	if err := svc.Unsubscribe(alice.ID, "photos"); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	err = svc.Unsubscribe(alice.ID, "photos")
+	if svcErr, ok := err.(*ServiceError); !ok || svcErr.Code != constants.ErrCodeSubscriptionNotFound {
+		t.Errorf("expected %s, got %v", constants.ErrCodeSubscriptionNotFound, err)
+	}
+}
+
+func TestNotificationPublish_FiltersSubscribers(t *testing.T) {
+	svc, store := newNotificationTestService(t)
+	alice := createNotificationUser(t, store, "alice")
+	bob := createNotificationUser(t, store, "bob")
+	carol := createNotificationUser(t, store, "carol", "other-topic") // lost access to photos
+
+	svc.Subscribe(alice.ID, "photos", &SubscribeRequest{MetadataKeys: []string{"review_status"}})
+	svc.Subscribe(bob.ID, "photos", &SubscribeRequest{Events: []string{constants.NotificationEventAssetAdded}})
+	svc.Subscribe(carol.ID, "photos", &SubscribeRequest{})
+
+	svc.AssetAdded("photos", "hash1", "cat.png", bob.ID, "bob")
+	svc.MetadataChanged("photos", []MetadataChange{
+		{AssetID: "hash1", Key: "caption"},
+		{AssetID: "hash1", Key: "review_status"},
+		{AssetID: "hash2", Key: "review_status"},
+	}, bob.ID, "bob")
+	svc.MetadataChanged("photos", []MetadataChange{{AssetID: "hash1", Key: "caption"}}, bob.ID, "bob")
+
+	feed := feedOf(t, svc, alice.ID)
+	if feed.Total != 2 || feed.Unread != 2 {
+		t.Fatalf("alice: expected 2 notifications, got %+v", feed)
+	}
+	changed := feed.Notifications[0]
+	if changed.Event != constants.NotificationEventMetadataChanged || changed.AssetID != nil {
+		t.Errorf("unexpected metadata notification: %+v", changed)
+	}
+	var details struct {
+		Keys       []string `json:"keys"`
+		AssetCount int      `json:"asset_count"`
+	}
+	json.Unmarshal(changed.Details, &details)
+	if len(details.Keys) != 1 || details.Keys[0] != "review_status" || details.AssetCount != 2 {
+		t.Errorf("unexpected details: %s", changed.Details)
+	}
+	if added := feed.Notifications[1]; added.Event != constants.NotificationEventAssetAdded || *added.AssetID != "hash1" || added.Actor != "bob" {
+		t.Errorf("unexpected asset notification: %+v", added)
+	}
+
+	// The actor is not notified of their own changes
+	if feed := feedOf(t, svc, bob.ID); feed.Total != 0 {
+		t.Errorf("bob: expected no notifications, got %d", feed.Total)
+	}
+	// Subscribers that can no longer read the topic are skipped
+	if feed := feedOf(t, svc, carol.ID); feed.Total != 0 {
+		t.Errorf("carol: expected no notifications, got %d", feed.Total)
+	}
+
+	marked, err := svc.MarkRead(alice.ID, nil)
+	if err != nil || marked != 2 {
+		t.Fatalf("MarkRead = %d, %v", marked, err)
+	}
+	if feed := feedOf(t, svc, alice.ID); feed.Unread != 0 || feed.Notifications[0].ReadAt == nil {
+		t.Errorf("expected all read, got %+v", feed)
+	}
+}
+
+func TestNotificationDelivery_WebhookDigest(t *testing.T) {
+	svc, store := newNotificationTestService(t)
+	alice := createNotificationUser(t, store, "alice")
+	bob := createNotificationUser(t, store, "bob")
+	svc.Subscribe(alice.ID, "photos", &SubscribeRequest{})
+
+	var received []NotificationDelivery
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d NotificationDelivery
+		json.NewDecoder(r.Body).Decode(&d)
+		if status == http.StatusOK {
+			received = append(received, d)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	digest := constants.NotificationDeliveryDigest
+	webhookURL := server.URL
+	if _, err := svc.UpdatePreferences(alice.ID, &NotificationPreferencesRequest{Delivery: &digest, WebhookURL: &webhookURL}); err != nil {
+		t.Fatalf("UpdatePreferences: %v", err)
+	}
+
+	svc.AssetAdded("photos", "hash1", "a.png", bob.ID, "bob")
+	svc.AssetAdded("photos", "hash2", "b.png", bob.ID, "bob")
+
+	// A failing webhook keeps notifications pending
+	now := time.Now()
+	svc.DeliverPending(now)
+	status = http.StatusOK
+	svc.DeliverPending(now)
+	if len(received) != 1 || len(received[0].Notifications) != 2 || received[0].Username != "alice" {
+		t.Fatalf("expected one digest with 2 notifications, got %+v", received)
+	}
+
+	// The next digest waits for the interval
+	svc.AssetAdded("photos", "hash3", "c.png", bob.ID, "bob")
+	svc.DeliverPending(now.Add(time.Minute))
+	if len(received) != 1 {
+		t.Fatalf("digest sent before interval elapsed")
+	}
+	svc.DeliverPending(now.Add(svc.app.GetConfig().Notifications.DigestInterval()))
+	if len(received) != 2 || len(received[1].Notifications) != 1 {
+		t.Fatalf("expected second digest, got %+v", received)
+	}
+}
+
+func TestNotificationDelivery_Email(t *testing.T) {
+	svc, store := newNotificationTestService(t)
+	alice := createNotificationUser(t, store, "alice")
+	bob := createNotificationUser(t, store, "bob")
+	svc.Subscribe(alice.ID, "photos", &SubscribeRequest{})
+
+	email := "alice@example.com"
+	if _, err := svc.UpdatePreferences(alice.ID, &NotificationPreferencesRequest{Email: &email}); err == nil {
+		t.Fatal("expected email to be rejected without SMTP configured")
+	}
+
+	smtpCfg := &svc.app.GetConfig().Notifications.SMTP
+	smtpCfg.Host, smtpCfg.Port, smtpCfg.From = "mail.example.com", 25, "silobang@example.com"
+
+	var sentTo []string
+	var message string
+	svc.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sentTo, message = to, string(msg)
+		return nil
+	}
+
+	if _, err := svc.UpdatePreferences(alice.ID, &NotificationPreferencesRequest{Email: &email}); err != nil {
+		t.Fatalf("UpdatePreferences: %v", err)
+	}
+	svc.AssetAdded("photos", "hash1", "a.png", bob.ID, "bob")
+	svc.DeliverPending(time.Now())
+
+	if len(sentTo) != 1 || sentTo[0] != email {
+		t.Fatalf("expected email to %s, got %v", email, sentTo)
+	}
+	if !strings.Contains(message, "Subject: [SiloBang] 1 new notification(s)") || !strings.Contains(message, "photos asset_added hash1 by bob") {
+		t.Errorf("unexpected message:\n%s", message)
+	}
+
+	pending, _ := database.ListUndeliveredNotifications(svc.app.GetOrchestratorDB(), alice.ID, 10)
+	if len(pending) != 0 {
+		t.Errorf("expected no pending notifications, got %d", len(pending))
+	}
+}
+
+func TestNotificationPreferences_Validation(t *testing.T) {
+	svc, store := newNotificationTestService(t)
+	alice := createNotificationUser(t, store, "alice")
+
+	prefs, err := svc.GetPreferences(alice.ID)
+	if err != nil || prefs.Delivery != constants.NotificationDeliveryImmediate {
+		t.Fatalf("unexpected default preferences: %+v, %v", prefs, err)
+	}
+
+	for _, req := range []NotificationPreferencesRequest{
+		{Delivery: strPtr("hourly")},
+		{WebhookURL: strPtr("ftp://example.com/hook")},
+		{WebhookURL: strPtr("/relative")},
+	} {
+		if _, err := svc.UpdatePreferences(alice.ID, &req); err == nil {
+			t.Errorf("expected %+v to be rejected", req)
+		}
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// OriginCacheStatus reports the size and effectiveness of the origin cache.
+// Counters start at zero when the server starts.
+type OriginCacheStatus struct {
+	Enabled      bool                        `json:"enabled"`
+	Origin       string                      `json:"origin,omitempty"`
+	Topic        string                      `json:"topic"`
+	Eviction     string                      `json:"eviction"`
+	Entries      int                         `json:"entries"`
+	Bytes        int64                       `json:"bytes"`
+	MaxBytes     int64                       `json:"max_bytes"` // 0 = unlimited
+	Hits         uint64                      `json:"hits"`
+	Misses       uint64                      `json:"misses"`
+	HitRatio     float64                     `json:"hit_ratio"` // hits / (hits + misses); 0 before the first download
+	FetchErrors  uint64                      `json:"fetch_errors"`
+	BytesFetched int64                       `json:"bytes_fetched"`
+	Evictions    uint64                      `json:"evictions"`
+	Recent       []database.OriginCacheEntry `json:"recent,omitempty"` // most recently fetched first
+}
+
+// originFetch is a fetch in progress; concurrent downloads of the same
+// missing asset wait for it instead of fetching again.
+type originFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// OriginCacheService turns this instance into a cache of an origin
+// instance. Downloads of assets missing here are fetched from the origin,
+// verified against their hash and stored in the cache topic before being
+// served; an origin may itself be a cache, forming a hierarchy. Past the
+// configured size, fetched assets are deleted by the eviction policy and
+// fetched again when next downloaded. Assets stored here by other means are
+// never evicted.
+type OriginCacheService struct {
+	app    AppState
+	logger *logger.Logger
+	assets *AssetService
+	config *ConfigService
+	stats  *StatsCache
+	client *http.Client
+
+	mu           sync.Mutex
+	inflight     map[string]*originFetch
+	hits         uint64
+	misses       uint64
+	fetchErrors  uint64
+	bytesFetched int64
+	evictions    uint64
+
+	evictMu sync.Mutex // one eviction pass at a time
+}
+
+// NewOriginCacheService creates a new OriginCacheService instance. Returns
+// nil when the orchestrator DB is not available.
+func NewOriginCacheService(app AppState, log *logger.Logger, assets *AssetService, config *ConfigService, stats *StatsCache) *OriginCacheService {
+	if app.GetOrchestratorDB() == nil {
+		return nil
+	}
+	return &OriginCacheService{
+		app:      app,
+		logger:   log,
+		assets:   assets,
+		config:   config,
+		stats:    stats,
+		client:   &http.Client{},
+		inflight: make(map[string]*originFetch),
+	}
+}
+
+// Enabled reports whether an origin is configured.
+func (s *OriginCacheService) Enabled() bool {
+	return s != nil && s.app.GetConfig().Origin.Enabled()
+}
+
+// Topic returns the topic fetched assets are stored in.
+func (s *OriginCacheService) Topic() string {
+	return s.app.GetConfig().Origin.Topic
+}
+
+// Fetch fetches a missing asset from the origin and stores it in the cache
+// topic. Returns ASSET_NOT_FOUND when the origin does not have it either.
+// ctx only bounds the wait: a fetch in progress completes for the other
+// downloads waiting on it.
+func (s *OriginCacheService) Fetch(ctx context.Context, hash string) error {
+	if len(hash) != constants.HashLength {
+		return ErrInvalidHash
+	}
+
+	s.mu.Lock()
+	s.misses++
+	f, ok := s.inflight[hash]
+	if !ok {
+		f = &originFetch{done: make(chan struct{})}
+		s.inflight[hash] = f
+		go s.run(hash, f)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *OriginCacheService) run(hash string, f *originFetch) {
+	f.err = s.fetch(hash)
+
+	s.mu.Lock()
+	delete(s.inflight, hash)
+	if f.err != nil {
+		if code, _ := IsServiceError(f.err); code != constants.ErrCodeAssetNotFound {
+			s.fetchErrors++
+		}
+	}
+	s.mu.Unlock()
+	close(f.done)
+}
+
+// fetch downloads an asset from the origin, checks its hash and stores it.
+func (s *OriginCacheService) fetch(hash string) error {
+	cfg := s.app.GetConfig().Origin
+	if err := s.ensureTopic(cfg.Topic); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(cfg.URL, "/")+fmt.Sprintf(constants.OriginDownloadPath, hash), nil)
+	if err != nil {
+		return WrapServiceError(constants.ErrCodeOriginFetchFailed, "invalid origin URL", err)
+	}
+	req.Header.Set(constants.HeaderXAPIKey, cfg.APIKey)
+	req.Header.Set("User-Agent", constants.OriginUserAgent)
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.Warn("Origin cache: fetching %s failed: %v", hash, err)
+		return WrapServiceError(constants.ErrCodeOriginFetchFailed, "origin unreachable", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrAssetNotFoundWithHash(hash)
+	}
+	if resp.StatusCode != http.StatusOK {
+		message := fmt.Sprintf("origin returned %d", resp.StatusCode)
+		var apiErr struct {
+			Message string `json:"message"`
+			Code    string `json:"code"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			message = fmt.Sprintf("origin returned %d %s: %s", resp.StatusCode, apiErr.Code, apiErr.Message)
+		}
+		s.logger.Warn("Origin cache: fetching %s failed: %s", hash, message)
+		return NewServiceError(constants.ErrCodeOriginFetchFailed, message)
+	}
+
+	// The origin's file name keeps the origin name and extension
+	filename := hash
+	if _, params, err := mime.ParseMediaType(resp.Header.Get(constants.HeaderContentDisposition)); err == nil && params["filename"] != "" {
+		filename = params["filename"]
+	}
+	_, ext := splitFilename(filename)
+	maxSize := s.app.GetConfig().StoragePolicy(ext).MaxSizeBytes
+	if cfg.MaxCacheBytes > 0 {
+		maxSize = min(maxSize, cfg.MaxCacheBytes)
+	}
+
+	tempFile, gotHash, size, err := s.assets.streamToTempWithHash(resp.Body, maxSize+int64(constants.HeaderSize))
+	if err != nil {
+		if errors.Is(err, errStreamTooLarge) {
+			return ErrAssetTooLarge
+		}
+		return WrapServiceError(constants.ErrCodeOriginFetchFailed, "failed to read the asset from the origin", err)
+	}
+	defer os.Remove(tempFile)
+	if gotHash != hash {
+		s.logger.Error("Origin cache: origin sent content hashing to %s for %s", gotHash, hash)
+		return NewServiceError(constants.ErrCodeOriginHashMismatch, fmt.Sprintf("origin sent content hashing to %s", gotHash))
+	}
+
+	result, err := s.assets.UploadStaged(ctx, cfg.Topic, tempFile, hash, size, filename, nil, "", constants.AuditActorSystem)
+	if err != nil {
+		return err
+	}
+	if result.Skipped {
+		return nil // stored here meanwhile by other means
+	}
+
+	orchDB := s.app.GetOrchestratorDB()
+	if err := database.InsertOriginCacheEntry(orchDB, database.OriginCacheEntry{
+		Hash:      hash,
+		Topic:     cfg.Topic,
+		Size:      size,
+		FetchedAt: time.Now().Unix(),
+	}); err != nil {
+		s.logger.Warn("Origin cache: failed to record %s: %v", hash, err)
+	}
+
+	s.mu.Lock()
+	s.bytesFetched += size
+	s.mu.Unlock()
+	s.logger.Info("Origin cache: fetched %s (%d bytes) into topic %s in %s", hash, size, cfg.Topic, time.Since(start).Round(time.Millisecond))
+
+	if l := s.app.GetAuditLogger(); l != nil {
+		l.Log(constants.AuditActionAddingFile, "", "", audit.AddingFileDetails{
+			Hash:      hash,
+			TopicName: cfg.Topic,
+			Filename:  filename,
+			Size:      size,
+		})
+	}
+	if s.stats != nil {
+		s.stats.InvalidateTopic(cfg.Topic)
+	}
+
+	s.evict(cfg.MaxCacheBytes, cfg.Eviction, hash)
+	return nil
+}
+
+// ensureTopic creates the cache topic on first use.
+func (s *OriginCacheService) ensureTopic(topic string) error {
+	if s.app.TopicExists(topic) {
+		return nil
+	}
+	if err := s.config.CreateTopic(topic); err != nil {
+		if code, _ := IsServiceError(err); code == constants.ErrCodeTopicAlreadyExists {
+			return nil
+		}
+		return err
+	}
+	s.logger.Info("Origin cache: created topic %s", topic)
+	if l := s.app.GetAuditLogger(); l != nil {
+		l.Log(constants.AuditActionAddingTopic, "", "", audit.AddingTopicDetails{TopicName: topic})
+	}
+	if s.stats != nil {
+		s.stats.InvalidateTopic(topic)
+	}
+	return nil
+}
+
+// evict deletes fetched assets, in the order the policy gives them up,
+// until the cache holds at most maxBytes. keep, the asset just fetched, is
+// never evicted. Assets still referenced, for example by a collection, are
+// skipped.
+func (s *OriginCacheService) evict(maxBytes int64, policy, keep string) {
+	if maxBytes <= 0 {
+		return
+	}
+	s.evictMu.Lock()
+	defer s.evictMu.Unlock()
+
+	orchDB := s.app.GetOrchestratorDB()
+	_, used, err := database.OriginCacheUsage(orchDB)
+	if err != nil || used <= maxBytes {
+		return
+	}
+	entries, err := database.ListOriginCacheEvictionOrder(orchDB, policy, -1)
+	if err != nil {
+		s.logger.Warn("Origin cache: failed to list eviction candidates: %v", err)
+		return
+	}
+
+	for _, e := range entries {
+		if used <= maxBytes {
+			break
+		}
+		if e.Hash == keep {
+			continue
+		}
+		if _, err := s.assets.Delete(e.Hash, "", ""); err != nil {
+			code, _ := IsServiceError(err)
+			if code != constants.ErrCodeAssetNotFound {
+				s.logger.Debug("Origin cache: not evicting %s: %v", e.Hash, err)
+				continue
+			}
+			// Deleted by other means: only the entry is left
+		} else {
+			s.mu.Lock()
+			s.evictions++
+			s.mu.Unlock()
+			s.logger.Debug("Origin cache: evicted %s (%d bytes, %d hits)", e.Hash, e.Size, e.Hits)
+		}
+		if err := database.DeleteOriginCacheEntry(orchDB, e.Hash); err != nil {
+			s.logger.Warn("Origin cache: failed to forget %s: %v", e.Hash, err)
+		}
+		used -= e.Size
+		if s.stats != nil {
+			s.stats.InvalidateTopic(e.Topic)
+		}
+	}
+}
+
+// RecordHit counts a download served from a fetched asset.
+func (s *OriginCacheService) RecordHit(hash string) {
+	hit, err := database.RecordOriginCacheHit(s.app.GetOrchestratorDB(), hash, time.Now().Unix())
+	if err != nil {
+		s.logger.Warn("Origin cache: failed to record hit of %s: %v", hash, err)
+		return
+	}
+	if hit {
+		s.mu.Lock()
+		s.hits++
+		s.mu.Unlock()
+	}
+}
+
+// Status returns the cache size and counters, with the recent most
+// recently fetched entries.
+func (s *OriginCacheService) Status(recent int) (*OriginCacheStatus, error) {
+	cfg := s.app.GetConfig().Origin
+	orchDB := s.app.GetOrchestratorDB()
+	entries, bytes, err := database.OriginCacheUsage(orchDB)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	status := &OriginCacheStatus{
+		Enabled:  cfg.Enabled(),
+		Origin:   cfg.URL,
+		Topic:    cfg.Topic,
+		Eviction: cfg.Eviction,
+		Entries:  entries,
+		Bytes:    bytes,
+		MaxBytes: cfg.MaxCacheBytes,
+	}
+	if recent > 0 {
+		status.Recent, err = database.ListRecentOriginCacheEntries(orchDB, recent)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+	}
+
+	s.mu.Lock()
+	status.Hits = s.hits
+	status.Misses = s.misses
+	status.FetchErrors = s.fetchErrors
+	status.BytesFetched = s.bytesFetched
+	status.Evictions = s.evictions
+	s.mu.Unlock()
+	if total := status.Hits + status.Misses; total > 0 {
+		status.HitRatio = float64(status.Hits) / float64(total)
+	}
+	return status, nil
+}
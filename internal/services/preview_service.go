@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+	"silobang/internal/preview"
+)
+
+// PreviewService renders the previews of assets whose storage policy
+// enables them and caches them by hash and size under .internal/previews.
+// With previews.on_upload, the default-size preview of new uploads is
+// rendered in the background.
+type PreviewService struct {
+	app    AppState
+	logger *logger.Logger
+	assets *AssetService
+	policy *StoragePolicyService
+
+	queue    chan string
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewPreviewService creates a new preview service and starts the loop
+// rendering the previews of new uploads.
+func NewPreviewService(app AppState, log *logger.Logger, assets *AssetService, policy *StoragePolicyService) *PreviewService {
+	svc := &PreviewService{
+		app:    app,
+		logger: log,
+		assets: assets,
+		policy: policy,
+		queue:  make(chan string, constants.PreviewQueueSize),
+		stop:   make(chan struct{}),
+	}
+
+	go svc.renderLoop()
+
+	return svc
+}
+
+// Stop stops the background rendering goroutine (call during graceful
+// shutdown).
+func (s *PreviewService) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Check returns the error Get would return for an asset that has no
+// preview, without rendering one. PREVIEW_UNAVAILABLE is returned when the
+// extension's policy disables previews or no renderer handles it.
+func (s *PreviewService) Check(info *AssetInfo) error {
+	if !s.app.GetConfig().Features.Enabled(constants.FeaturePreviews) {
+		return NewServiceError(constants.ErrCodeFeatureDisabled, "previews are disabled by features.disabled")
+	}
+	if !s.policy.Effective(info.Extension).Preview {
+		return NewServiceError(constants.ErrCodePreviewUnavailable, fmt.Sprintf("previews are disabled for %q files", info.Extension))
+	}
+	if preview.For(info.Extension) == nil {
+		return NewServiceError(constants.ErrCodePreviewUnavailable, fmt.Sprintf("previews are not supported for %q files", info.Extension))
+	}
+	// Withheld content has no previews either
+	if err := checkQuarantine(s.app, info.Hash); err != nil {
+		return err
+	}
+	return checkTrash(s.app, info.Hash)
+}
+
+// Get returns the preview of an asset at most size pixels on its longest
+// edge and its content type, rendering and caching it on first request.
+// It fails with the errors of Check.
+func (s *PreviewService) Get(info *AssetInfo, size int) ([]byte, string, error) {
+	if err := s.Check(info); err != nil {
+		return nil, "", err
+	}
+	renderer := preview.For(info.Extension)
+
+	path := s.path(info.Hash, size)
+	if data, err := os.ReadFile(path); err == nil {
+		return data, http.DetectContentType(data), nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		s.logger.Warn("Previews: failed to read cached preview %s: %v", path, err)
+	}
+
+	if info.Size > constants.PreviewMaxSourceBytes {
+		return nil, "", NewServiceError(constants.ErrCodePreviewUnavailable,
+			fmt.Sprintf("asset size %d exceeds preview limit %d", info.Size, constants.PreviewMaxSourceBytes))
+	}
+
+	reader, err := s.assets.GetReader(info.Hash)
+	if err != nil {
+		return nil, "", err
+	}
+	defer reader.Close()
+
+	data, contentType, err := renderer.Render(reader, size)
+	if errors.Is(err, preview.ErrTooLarge) {
+		return nil, "", WrapServiceError(constants.ErrCodePreviewUnavailable, "asset exceeds preview limits", err)
+	}
+	if err != nil {
+		return nil, "", WrapServiceError(constants.ErrCodePreviewUnavailable, "failed to render preview", err)
+	}
+
+	// A failed write only costs a render on the next request
+	if err := s.store(path, data); err != nil {
+		s.logger.Warn("Previews: failed to cache preview of %s: %v", info.Hash, err)
+	}
+	return data, contentType, nil
+}
+
+// Enqueue schedules the default-size preview of an asset to be rendered in
+// the background when previews.on_upload is set. Assets are dropped while
+// the queue is full; their preview is rendered on first request instead.
+func (s *PreviewService) Enqueue(hash string) {
+	if cfg := s.app.GetConfig(); cfg == nil || !cfg.Previews.OnUpload {
+		return
+	}
+	select {
+	case s.queue <- hash:
+	default:
+		s.logger.Debug("Previews: queue full, skipping %s", hash)
+	}
+}
+
+// Remove deletes the cached previews of an asset.
+func (s *PreviewService) Remove(hash string) {
+	paths, err := filepath.Glob(filepath.Join(s.dir(hash), hash+"-*"))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.Warn("Previews: failed to remove %s: %v", path, err)
+		}
+	}
+}
+
+// renderLoop renders queued previews until Stop is called.
+func (s *PreviewService) renderLoop() {
+	for {
+		select {
+		case <-s.stop:
+			return
+		case hash := <-s.queue:
+			s.render(hash)
+		}
+	}
+}
+
+// render caches the default-size preview of an asset. Assets without
+// previews are skipped silently.
+func (s *PreviewService) render(hash string) {
+	info, err := s.assets.GetInfo(hash)
+	if err != nil {
+		s.logger.Debug("Previews: skipping %s: %v", hash, err)
+		return
+	}
+	if _, _, err := s.Get(info, constants.PreviewDefaultSize); err != nil {
+		var svcErr *ServiceError
+		if errors.As(err, &svcErr) && (svcErr.Code == constants.ErrCodePreviewUnavailable || svcErr.Code == constants.ErrCodeFeatureDisabled) {
+			s.logger.Debug("Previews: no preview for %s: %v", hash, err)
+			return
+		}
+		s.logger.Warn("Previews: failed to render preview of %s: %v", hash, err)
+	}
+}
+
+// dir returns the directory holding the previews of a hash, sharded by its
+// first two characters.
+func (s *PreviewService) dir(hash string) string {
+	return filepath.Join(s.app.GetWorkingDirectory(), constants.InternalDir, constants.PreviewsDir, hash[:2])
+}
+
+// path returns where the preview of a hash at size is cached.
+func (s *PreviewService) path(hash string, size int) string {
+	return filepath.Join(s.dir(hash), fmt.Sprintf("%s-%d", hash, size))
+}
+
+// store writes a preview through a temporary file, so readers never see a
+// partial one.
+func (s *PreviewService) store(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), constants.DirPermissions); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
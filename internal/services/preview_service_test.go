@@ -0,0 +1,72 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+func TestPreviewService_ServesCacheAndRemovesOnDelete(t *testing.T) {
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+
+	hash := strings.Repeat("a", constants.HashLength)
+	topicDB := setupTopicDir(t, workDir, "photos", []testAsset{
+		{id: hash, size: 10, ext: "png", blobName: "001.dat", createdAt: 1700000000},
+	})
+	if _, err := topicDB.Exec("UPDATE assets SET origin_name = 'photo'"); err != nil {
+		t.Fatalf("failed to name asset: %v", err)
+	}
+	mock.StoreTopicDB("photos", topicDB)
+	mock.RegisterTopic("photos", true, "")
+	mock.orchestratorDB = setupOrchestratorDB(t, workDir, []orchestratorEntry{{hash: hash, topic: "photos", datFile: "001.dat"}})
+
+	assets := NewAssetService(mock, mock.log)
+	policy := NewStoragePolicyService(mock, mock.log)
+	svc := NewPreviewService(mock, mock.log, assets, policy)
+	defer svc.Stop()
+	assets.SetPreviewService(svc)
+
+	// A cached preview is served without reading the asset
+	var cached bytes.Buffer
+	if err := png.Encode(&cached, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	path := filepath.Join(workDir, constants.InternalDir, constants.PreviewsDir, hash[:2], hash+"-64")
+	if err := svc.store(path, cached.Bytes()); err != nil {
+		t.Fatalf("failed to cache preview: %v", err)
+	}
+	info, err := assets.GetInfo(hash)
+	if err != nil {
+		t.Fatalf("GetInfo failed: %v", err)
+	}
+	data, contentType, err := svc.Get(info, 64)
+	if err != nil || contentType != "image/png" || !bytes.Equal(data, cached.Bytes()) {
+		t.Fatalf("expected the cached preview, got %s, %v", contentType, err)
+	}
+
+	// The policy is checked before the cache
+	disabled := false
+	mock.cfg.StoragePolicies = map[string]config.StoragePolicyConfig{"png": {Preview: &disabled}}
+	if _, _, err := svc.Get(info, 64); !isServiceErrorCode(err, constants.ErrCodePreviewUnavailable) {
+		t.Errorf("expected PREVIEW_UNAVAILABLE, got %v", err)
+	}
+	mock.cfg.StoragePolicies = nil
+	if _, _, err := svc.Get(&AssetInfo{Hash: hash, Extension: "txt"}, 64); !isServiceErrorCode(err, constants.ErrCodePreviewUnavailable) {
+		t.Errorf("expected PREVIEW_UNAVAILABLE for txt, got %v", err)
+	}
+
+	if _, err := assets.Delete(hash, "alice", "127.0.0.1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the cached preview to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// QuarantineService flags assets as suspect and releases them again.
+// Quarantined assets stay stored but are withheld from downloads, queries
+// and bulk exports until an admin releases them with a justification.
+type QuarantineService struct {
+	app    AppState
+	logger *logger.Logger
+}
+
+// NewQuarantineService creates a new quarantine service instance.
+func NewQuarantineService(app AppState, log *logger.Logger) *QuarantineService {
+	return &QuarantineService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// Quarantine withholds an indexed asset. source is one of
+// constants.QuarantineSource*; by and ipAddress identify the actor for the
+// audit log. Quarantining an asset that already is returns its active entry.
+func (s *QuarantineService) Quarantine(hash, source, reason, by, ipAddress string) (*database.QuarantineEntry, error) {
+	if len(hash) != constants.HashLength {
+		return nil, ErrInvalidHash
+	}
+	reason = strings.TrimSpace(reason)
+	if len(reason) > constants.QuarantineMaxReasonLength {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("reason must be at most %d characters", constants.QuarantineMaxReasonLength))
+	}
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+
+	exists, topic, _, err := s.app.GetAssetIndex().Lookup(hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if !exists {
+		return nil, ErrAssetNotFoundWithHash(hash)
+	}
+
+	entry, created, err := database.QuarantineAsset(orchDB, database.QuarantineEntry{
+		Hash:          hash,
+		Topic:         topic,
+		Source:        source,
+		Reason:        reason,
+		QuarantinedBy: by,
+		QuarantinedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if created {
+		logQuarantined(s.app, s.logger, entry, ipAddress)
+	}
+	return entry, nil
+}
+
+// Release ends the active quarantine of an asset. A justification is
+// required and kept with the entry.
+func (s *QuarantineService) Release(hash, justification, by, ipAddress string) (*database.QuarantineEntry, error) {
+	if len(hash) != constants.HashLength {
+		return nil, ErrInvalidHash
+	}
+	justification = strings.TrimSpace(justification)
+	if justification == "" {
+		return nil, ErrMissingParamWithName("justification")
+	}
+	if len(justification) > constants.QuarantineMaxReasonLength {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("justification must be at most %d characters", constants.QuarantineMaxReasonLength))
+	}
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+
+	entry, err := database.ReleaseQuarantine(orchDB, hash, by, justification, time.Now().Unix())
+	if errors.Is(err, database.ErrNotQuarantined) {
+		return nil, NewServiceError(constants.ErrCodeAssetNotQuarantined, fmt.Sprintf("asset is not quarantined: %s", hash))
+	}
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Asset %s released from quarantine by %s: %s", hash, by, justification)
+	if l := s.app.GetAuditLogger(); l != nil {
+		if err := l.Log(constants.AuditActionAssetReleased, ipAddress, by, audit.AssetReleasedDetails{
+			Hash:          entry.Hash,
+			Topic:         entry.Topic,
+			Source:        entry.Source,
+			Justification: justification,
+		}); err != nil {
+			s.logger.Error("Failed to write audit entry for release of %s: %v", hash, err)
+		}
+	}
+	return entry, nil
+}
+
+// List returns quarantine entries, newest first. Released entries are
+// included only when includeReleased is set; topic filters when not empty.
+func (s *QuarantineService) List(topic string, includeReleased bool) ([]database.QuarantineEntry, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+	entries, err := database.ListQuarantine(orchDB, topic, includeReleased)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return entries, nil
+}
+
+// logQuarantined records a new quarantine in the log and audit log. Shared
+// with the upload path, which quarantines inside its own transaction.
+func logQuarantined(app AppState, log *logger.Logger, entry *database.QuarantineEntry, ipAddress string) {
+	log.Warn("Asset %s in topic %s quarantined (%s): %s", entry.Hash, entry.Topic, entry.Source, entry.Reason)
+	if l := app.GetAuditLogger(); l != nil {
+		if err := l.Log(constants.AuditActionAssetQuarantined, ipAddress, entry.QuarantinedBy, audit.AssetQuarantinedDetails{
+			Hash:   entry.Hash,
+			Topic:  entry.Topic,
+			Source: entry.Source,
+			Reason: entry.Reason,
+		}); err != nil {
+			log.Error("Failed to write audit entry for quarantine of %s: %v", entry.Hash, err)
+		}
+	}
+}
+
+// checkQuarantine returns ASSET_QUARANTINED when hash has an active
+// quarantine.
+func checkQuarantine(app AppState, hash string) error {
+	orchDB := app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil
+	}
+	entry, err := database.GetActiveQuarantine(orchDB, hash)
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	if entry != nil {
+		return ErrAssetQuarantinedWithReason(hash, entry.Source, entry.Reason)
+	}
+	return nil
+}
+
+// quarantinedHashes returns the set of quarantined hashes, or nil when
+// nothing is quarantined.
+func quarantinedHashes(app AppState) (map[string]bool, error) {
+	orchDB := app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, nil
+	}
+	hashes, err := database.ActiveQuarantineHashes(orchDB)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	return hashes, nil
+}
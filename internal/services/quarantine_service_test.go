@@ -0,0 +1,107 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/queries"
+)
+
+func TestQuarantineService_QuarantineAndRelease(t *testing.T) {
+	db := setupReconcileTestDB(t)
+	defer db.Close()
+
+	flagged := strings.Repeat("a", constants.HashLength)
+	clean := strings.Repeat("b", constants.HashLength)
+	for _, hash := range []string{flagged, clean} {
+		if _, err := db.Exec("INSERT INTO asset_index (hash, topic, dat_file) VALUES (?, 'photos', '001.dat')", hash); err != nil {
+			t.Fatalf("failed to index asset: %v", err)
+		}
+	}
+
+	mockApp := newMockAppState()
+	mockApp.orchestratorDB = db
+	svc := NewQuarantineService(mockApp, logger.NewLogger("debug"))
+
+	if _, err := svc.Quarantine(strings.Repeat("c", constants.HashLength), constants.QuarantineSourceAdmin, "", "alice", "127.0.0.1"); !isServiceErrorCode(err, constants.ErrCodeAssetNotFound) {
+		t.Fatalf("expected ASSET_NOT_FOUND for an unindexed asset, got %v", err)
+	}
+
+	entry, err := svc.Quarantine(flagged, constants.QuarantineSourceAdmin, "reported as malware", "alice", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Quarantine failed: %v", err)
+	}
+	if entry.Topic != "photos" || entry.Source != constants.QuarantineSourceAdmin || entry.QuarantinedBy != "alice" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	// A second flag keeps the first entry
+	again, err := svc.Quarantine(flagged, constants.QuarantineSourceIntegrity, "hash mismatch", "", "system")
+	if err != nil {
+		t.Fatalf("repeated Quarantine failed: %v", err)
+	}
+	if again.ID != entry.ID || again.Source != constants.QuarantineSourceAdmin {
+		t.Errorf("expected the active entry %d back, got %+v", entry.ID, again)
+	}
+
+	if err := checkQuarantine(mockApp, flagged); !isServiceErrorCode(err, constants.ErrCodeAssetQuarantined) {
+		t.Errorf("expected ASSET_QUARANTINED, got %v", err)
+	}
+	if err := checkQuarantine(mockApp, clean); err != nil {
+		t.Errorf("clean asset reported: %v", err)
+	}
+
+	// Queries drop the quarantined row unless asked to keep it
+	query := NewQueryService(mockApp, logger.NewLogger("debug"))
+	result := &queries.QueryResult{
+		Columns:  []string{"asset_id", "_topic"},
+		Rows:     [][]interface{}{{flagged, "photos"}, {clean, "photos"}},
+		RowCount: 2,
+	}
+	if err := query.applyWithheldFilter(result, withheldHashes); err != nil {
+		t.Fatalf("applyWithheldFilter failed: %v", err)
+	}
+	if result.RowCount != 1 || result.Rows[0][0] != clean {
+		t.Errorf("expected only the clean row, got %v", result.Rows)
+	}
+
+	// Release needs a justification and an active quarantine
+	if _, err := svc.Release(flagged, "  ", "admin", "127.0.0.1"); !isServiceErrorCode(err, constants.ErrCodeMissingParam) {
+		t.Errorf("expected MISSING_PARAM without justification, got %v", err)
+	}
+	if _, err := svc.Release(clean, "not needed", "admin", "127.0.0.1"); !isServiceErrorCode(err, constants.ErrCodeAssetNotQuarantined) {
+		t.Errorf("expected ASSET_NOT_QUARANTINED, got %v", err)
+	}
+	released, err := svc.Release(flagged, "false positive", "admin", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if released.ReleasedBy == nil || *released.ReleasedBy != "admin" || released.Justification == nil || *released.Justification != "false positive" {
+		t.Errorf("release not recorded: %+v", released)
+	}
+	if err := checkQuarantine(mockApp, flagged); err != nil {
+		t.Errorf("released asset still withheld: %v", err)
+	}
+
+	// The released entry is history; the asset can be quarantined again
+	active, err := svc.List("", false)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(active) != 0 {
+		t.Errorf("expected no active entries, got %d", len(active))
+	}
+	if _, err := svc.Quarantine(flagged, constants.QuarantineSourceScan, "flagged again", "", "system"); err != nil {
+		t.Fatalf("re-quarantine failed: %v", err)
+	}
+	all, err := database.ListQuarantine(db, "photos", true)
+	if err != nil {
+		t.Fatalf("ListQuarantine failed: %v", err)
+	}
+	if len(all) != 2 || all[0].ReleasedAt != nil || all[1].ReleasedAt == nil {
+		t.Errorf("expected a new active entry above the released one, got %+v", all)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"cmp"
+	"slices"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/queries"
+)
+
+// QueryBuildRequest is a structured query over assets, their current
+// metadata and lineage, run on the selected topics like a preset.
+type QueryBuildRequest struct {
+	queries.BuilderQuery
+	Topics     []string `json:"topics"`
+	Collection string   `json:"collection,omitempty"` // optional: keep only rows whose asset is in this collection
+
+	// IncludeQuarantined keeps rows of quarantined assets, which are
+	// otherwise dropped.
+	IncludeQuarantined bool `json:"include_quarantined,omitempty"`
+}
+
+// BuilderSchema describes what builder queries can filter, select and sort
+// on, with the most used metadata keys of the given topics (all healthy
+// topics when empty).
+func (s *QueryService) BuilderSchema(topicNames []string) (*queries.BuilderSchema, error) {
+	if s.app.GetWorkingDirectory() == "" {
+		return nil, ErrNotConfigured
+	}
+
+	topicDBs, validNames, err := s.app.GetTopicDBsForQuery(topicNames)
+	if err != nil {
+		return nil, WrapServiceError(constants.ErrCodeTopicUnhealthy, err.Error(), err)
+	}
+
+	counts := make(map[string]int64)
+	for _, name := range validNames {
+		keys, err := database.ListMetadataKeys(topicDBs[name], constants.QueryBuilderMaxSchemaKeys)
+		if err != nil {
+			s.logger.Warn("Query builder: failed to list metadata keys of topic %s: %v", name, err)
+			continue
+		}
+		for _, k := range keys {
+			counts[k.Key] += k.Assets
+		}
+	}
+
+	keys := make([]queries.BuilderMetadataKey, 0, len(counts))
+	for key, assets := range counts {
+		keys = append(keys, queries.BuilderMetadataKey{Key: key, Assets: assets})
+	}
+	slices.SortFunc(keys, func(a, b queries.BuilderMetadataKey) int {
+		return cmp.Or(cmp.Compare(b.Assets, a.Assets), cmp.Compare(a.Key, b.Key))
+	})
+	if len(keys) > constants.QueryBuilderMaxSchemaKeys {
+		keys = keys[:constants.QueryBuilderMaxSchemaKeys]
+	}
+
+	return queries.Schema(keys, s.app.GetConfig().Query.MaxRows), nil
+}
+
+// Build compiles a builder query into a parameterized SELECT and runs it on
+// the selected topics (all healthy topics when none are named). Rows are
+// merged across topics in the query's order, then filtered like preset
+// results.
+func (s *QueryService) Build(req *QueryBuildRequest) (*queries.QueryResult, []string, error) {
+	if s.app.GetWorkingDirectory() == "" {
+		return nil, nil, ErrNotConfigured
+	}
+
+	maxRows := s.app.GetConfig().Query.MaxRows
+	compiled, err := req.Compile(maxRows)
+	if err != nil {
+		return nil, nil, WrapServiceError(constants.ErrCodeInvalidQueryFilter, err.Error(), err)
+	}
+
+	topicDBs, validNames, err := s.app.GetTopicDBsForQuery(req.Topics)
+	if err != nil {
+		return nil, nil, WrapServiceError(constants.ErrCodeTopicUnhealthy, err.Error(), err)
+	}
+
+	result := &queries.QueryResult{Rows: [][]interface{}{}}
+	if len(validNames) > 0 {
+		result, err = queries.ExecuteCrossTopicQuery(compiled.Preset, compiled.Params, topicDBs, validNames)
+		if err != nil {
+			return nil, nil, WrapQueryError(err)
+		}
+	}
+	compiled.Finish(result)
+
+	return s.finishResult(constants.QueryBuilderPreset, &QueryRequest{
+		Topics:             req.Topics,
+		Collection:         req.Collection,
+		IncludeQuarantined: req.IncludeQuarantined,
+	}, result, validNames, maxRows)
+}
@@ -1,6 +1,16 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"silobang/internal/collate"
 	"silobang/internal/constants"
 	"silobang/internal/logger"
 	"silobang/internal/queries"
@@ -22,8 +32,18 @@ func NewQueryService(app AppState, log *logger.Logger) *QueryService {
 
 // QueryRequest represents a request to execute a query.
 type QueryRequest struct {
-	Params map[string]interface{} `json:"params"`
-	Topics []string               `json:"topics"`
+	Params     map[string]interface{} `json:"params"`
+	Topics     []string               `json:"topics"`
+	Collection string                 `json:"collection,omitempty"` // optional: only read assets in this collection
+
+	// IncludeQuarantined keeps rows of quarantined assets, which are
+	// otherwise dropped from results that expose asset_id.
+	IncludeQuarantined bool `json:"include_quarantined,omitempty"`
+
+	// AsOf, a unix timestamp, runs a metadata-aware preset against assets
+	// and metadata as they were at that time, rebuilt from metadata_log.
+	AsOf int64 `json:"as_of,omitempty"`
+	MetadataSelection
 }
 
 // ListPresets returns all available query presets.
@@ -63,6 +83,40 @@ func (s *QueryService) Execute(presetName string, req *QueryRequest) (*queries.Q
 		return nil, nil, WrapServiceError(constants.ErrCodeMissingParam, err.Error(), err)
 	}
 
+	if req != nil {
+		if err := req.MetadataSelection.Validate(); err != nil {
+			return nil, nil, err
+		}
+		// As of times and collections scope the tables the preset reads,
+		// so its own LIMIT applies to the rows in scope
+		var scope queries.Scope
+		if req.AsOf != 0 {
+			if err := checkAsOf(presetName, preset, req.AsOf); err != nil {
+				return nil, nil, err
+			}
+			params = maps.Clone(params)
+			params[constants.MetadataAsOfSQLParam] = strconv.FormatInt(req.AsOf, 10)
+			scope.AsOf = true
+		}
+		if req.Collection != "" {
+			if err := checkCollectionScope(presetName, preset, req.Collection); err != nil {
+				return nil, nil, err
+			}
+			params = maps.Clone(params)
+			params[constants.CollectionSQLParam] = req.Collection
+			scope.Collection = true
+		}
+		preset = preset.Scoped(scope)
+	}
+
+	if preset.Federated {
+		result, topicNames, err := s.executeFederated(preset, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s.finishResult(presetName, req, result, topicNames, s.app.GetConfig().Query.FederatedMaxRows)
+	}
+
 	// Get topic databases
 	var topicNames []string
 	if req != nil {
@@ -85,14 +139,329 @@ func (s *QueryService) Execute(presetName string, req *QueryRequest) (*queries.Q
 	}
 
 	// Execute query across topics
-	result, err := queries.ExecuteCrossTopicQuery(preset, params, topicDBs, validNames)
+	result, err := queries.ExecuteCollatedCrossTopicQuery(preset, params, topicDBs, validNames, s.topicCollations(validNames))
 	if err != nil {
 		return nil, nil, WrapQueryError(err)
 	}
 
+	return s.finishResult(presetName, req, result, validNames, s.app.GetConfig().Query.MaxRows)
+}
+
+// checkAsOf checks that the preset can read metadata as of asOf. Only
+// presets that read metadata, and are not federated, support it.
+func checkAsOf(presetName string, preset *queries.Preset, asOf int64) error {
+	if asOf < 0 {
+		return NewServiceError(constants.ErrCodeInvalidRequest, "as_of must be a unix timestamp")
+	}
+	if preset.Federated || !preset.UsesMetadata() {
+		return NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("preset %s does not read metadata and cannot be queried as_of a past time", presetName))
+	}
+	return nil
+}
+
+// checkCollectionScope checks that the preset can be scoped to collection.
+// Federated presets read attached databases by name and cannot be.
+func checkCollectionScope(presetName string, preset *queries.Preset, collection string) error {
+	if err := ValidateCollectionName(collection); err != nil {
+		return err
+	}
+	if preset.Federated {
+		return NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("federated preset %s cannot be filtered by collection", presetName))
+	}
+	return nil
+}
+
+// topicCollations returns the collation spec of each topic that opted in
+// via topic_collation. Presets receive it as the :_collation parameter.
+func (s *QueryService) topicCollations(topicNames []string) map[string]string {
+	configured := s.app.GetConfig().TopicCollation
+	if len(configured) == 0 {
+		return nil
+	}
+
+	specs := make(map[string]string)
+	for _, name := range topicNames {
+		if c, ok := configured[name]; ok {
+			specs[name] = collate.Options{
+				Locale:         c.Locale,
+				FoldCase:       c.FoldCase,
+				FoldDiacritics: c.FoldDiacritics,
+			}.Spec()
+		}
+	}
+	return specs
+}
+
+// finishResult names the result, drops withheld assets, applies the
+// request's metadata selection, and truncates it to maxRows.
+func (s *QueryService) finishResult(presetName string, req *QueryRequest, result *queries.QueryResult, topicNames []string, maxRows int) (*queries.QueryResult, []string, error) {
 	result.Preset = presetName
 
-	s.logger.Debug("Executed query %s across %d topics, returned %d rows", presetName, len(validNames), result.RowCount)
+	// Trashed assets are dropped even when quarantined ones are kept
+	withheld := withheldHashes
+	if req != nil && req.IncludeQuarantined {
+		withheld = trashedHashes
+	}
+	if err := s.applyWithheldFilter(result, withheld); err != nil {
+		return nil, nil, err
+	}
+
+	if req != nil {
+		req.MetadataSelection.ApplyToResult(result)
+	}
 
-	return result, validNames, nil
+	if len(result.Rows) > maxRows {
+		result.Rows = result.Rows[:maxRows]
+		result.RowCount = maxRows
+		result.Truncated = true
+	}
+	if result.Truncated {
+		s.logger.Warn("Query %s truncated at %d rows", presetName, result.RowCount)
+	}
+
+	s.logger.Debug("Executed query %s across %d topics, returned %d rows", presetName, len(topicNames), result.RowCount)
+
+	return result, topicNames, nil
+}
+
+// FederatedTopics returns the topics a federated preset would attach for the
+// given params, so callers can authorize them before execution. Returns nil
+// for regular presets.
+func (s *QueryService) FederatedTopics(presetName string, req *QueryRequest) ([]string, error) {
+	qc := s.app.GetQueriesConfig()
+	if qc == nil {
+		return nil, WrapInternalError(nil)
+	}
+
+	preset, err := qc.GetPreset(presetName)
+	if err != nil {
+		return nil, ErrPresetNotFoundWithName(presetName)
+	}
+	if !preset.Federated {
+		return nil, nil
+	}
+
+	var stringParams map[string]string
+	if req != nil && req.Params != nil {
+		stringParams = queries.ParamsToStrings(req.Params)
+	}
+
+	params, err := queries.ValidateParams(preset, stringParams)
+	if err != nil {
+		return nil, WrapServiceError(constants.ErrCodeMissingParam, err.Error(), err)
+	}
+
+	topics := make([]string, 0, len(preset.TopicParams))
+	for _, name := range preset.TopicParams {
+		topics = append(topics, params[name])
+	}
+	return topics, nil
+}
+
+// executeFederated runs a federated preset against the orchestrator DB and
+// the topics named by its topic params, all attached read-only.
+func (s *QueryService) executeFederated(preset *queries.Preset, params map[string]string) (*queries.QueryResult, []string, error) {
+	sources, topicNames, err := s.federatedSources(preset, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.FederatedQueryTimeout)
+	defer cancel()
+
+	result, err := queries.ExecuteFederatedQuery(ctx, preset, params, sources, s.app.GetConfig().Query.FederatedMaxRows)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, nil, WrapServiceError(constants.ErrCodeQueryTimeout,
+				fmt.Sprintf("federated query exceeded %s", constants.FederatedQueryTimeout), err)
+		}
+		return nil, nil, WrapQueryError(err)
+	}
+
+	return result, topicNames, nil
+}
+
+// federatedSources returns the databases a federated preset attaches: the
+// orchestrator DB and the topics named by its topic params.
+func (s *QueryService) federatedSources(preset *queries.Preset, params map[string]string) ([]queries.FederatedSource, []string, error) {
+	sources := []queries.FederatedSource{{
+		Alias: constants.FederatedOrchestratorAlias,
+		Path:  filepath.Join(s.app.GetWorkingDirectory(), constants.InternalDir, constants.OrchestratorDB),
+	}}
+
+	topicNames := make([]string, 0, len(preset.TopicParams))
+	for _, param := range preset.TopicParams {
+		topicName := params[param]
+		if topicName == "" {
+			return nil, nil, NewServiceError(constants.ErrCodeMissingParam, "required parameter missing: "+param)
+		}
+		if err := s.checkQueryTopic(topicName); err != nil {
+			return nil, nil, err
+		}
+
+		sources = append(sources, queries.FederatedSource{
+			Alias: param,
+			Path:  s.topicDBPath(topicName),
+		})
+		topicNames = append(topicNames, topicName)
+	}
+	return sources, topicNames, nil
+}
+
+// checkQueryTopic returns an error unless the topic exists and is healthy.
+func (s *QueryService) checkQueryTopic(topicName string) error {
+	if !s.app.TopicExists(topicName) {
+		return ErrTopicNotFoundWithName(topicName)
+	}
+	if healthy, reason := s.app.IsTopicHealthy(topicName); !healthy {
+		return ErrTopicUnhealthyWithReason(topicName, reason)
+	}
+	return nil
+}
+
+// topicDBPath returns the path of a topic's database file.
+func (s *QueryService) topicDBPath(topicName string) string {
+	return filepath.Join(s.app.GetTopicPath(topicName), constants.InternalDir, topicName+".db")
+}
+
+// PresetValidateRequest is a preset definition to check in the sandbox.
+type PresetValidateRequest struct {
+	Name       string                 `json:"name,omitempty"`  // checked against the preset naming rules
+	Definition string                 `json:"definition"`      // preset YAML, as stored under queries/presets
+	Topic      string                 `json:"topic,omitempty"` // topic to compile a regular preset against
+	Params     map[string]interface{} `json:"params,omitempty"`
+}
+
+// PresetValidation reports whether a preset definition is usable and, when
+// it compiles, what it would return and how expensive it is.
+type PresetValidation struct {
+	Valid    bool                 `json:"valid"`
+	Errors   []string             `json:"errors"`
+	Warnings []string             `json:"warnings"`
+	Topics   []string             `json:"topics"` // topic databases the plan was computed against
+	Columns  []queries.ColumnInfo `json:"columns"`
+	Plan     []queries.PlanStep   `json:"plan"`
+	Cost     *queries.QueryCost   `json:"cost,omitempty"`
+}
+
+// Validate checks a preset definition without registering it: it parses the
+// YAML, checks the parameter declarations against the SQL, and compiles the
+// query with EXPLAIN against the selected topic (or, for federated presets,
+// the topics named by its topic params) on a query-only connection. No rows
+// are read. Problems with the preset are reported in the result; errors
+// are returned only for bad requests.
+func (s *QueryService) Validate(req *PresetValidateRequest) (*PresetValidation, error) {
+	if s.app.GetWorkingDirectory() == "" {
+		return nil, ErrNotConfigured
+	}
+	if strings.TrimSpace(req.Definition) == "" {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "definition is required")
+	}
+
+	report := &PresetValidation{
+		Errors:   []string{},
+		Warnings: []string{},
+		Topics:   []string{},
+		Columns:  []queries.ColumnInfo{},
+		Plan:     []queries.PlanStep{},
+	}
+
+	preset, err := queries.ParsePresetDefinition([]byte(req.Definition))
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return report, nil
+	}
+
+	name := req.Name
+	if name == "" {
+		name = constants.PresetSandboxName
+	}
+	problems, warnings := queries.CheckPreset(preset, name)
+	report.Errors = append(report.Errors, problems...)
+	report.Warnings = append(report.Warnings, warnings...)
+	if len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	// Missing required params are bound as NULL: the plan does not depend on them
+	params := make(map[string]string)
+	values := queries.ParamsToStrings(req.Params)
+	for _, p := range preset.Params {
+		if v := values[p.Name]; v != "" {
+			params[p.Name] = v
+		} else if p.Default != "" {
+			params[p.Name] = p.Default
+		}
+	}
+
+	var mainPath string
+	var sources []queries.FederatedSource
+	if preset.Federated {
+		sources, report.Topics, err = s.federatedSources(preset, params)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if req.Topic == "" {
+			return nil, NewServiceError(constants.ErrCodeInvalidRequest, "topic is required to compile a regular preset")
+		}
+		if err := s.checkQueryTopic(req.Topic); err != nil {
+			return nil, err
+		}
+		if spec, ok := s.topicCollations([]string{req.Topic})[req.Topic]; ok {
+			params[constants.CollationParam] = spec
+		}
+		mainPath = s.topicDBPath(req.Topic)
+		report.Topics = []string{req.Topic}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.PresetSandboxTimeout)
+	defer cancel()
+
+	explained, err := queries.ExplainPreset(ctx, preset, params, mainPath, sources)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("compiling the preset exceeded %s", constants.PresetSandboxTimeout)
+		}
+		report.Errors = append(report.Errors, err.Error())
+		return report, nil
+	}
+
+	report.Columns = explained.Columns
+	if !preset.Federated {
+		// Regular presets get the topic name appended to every row
+		report.Columns = append(report.Columns, queries.ColumnInfo{Name: "_topic"})
+	}
+	report.Plan = explained.Plan
+	report.Cost = &explained.Cost
+	report.Valid = true
+
+	s.logger.Debug("Validated preset %s: %d columns, %d plan steps", name, len(report.Columns), len(report.Plan))
+	return report, nil
+}
+
+// applyWithheldFilter drops rows whose asset_id is in the set withheld
+// returns. Results without an asset_id column (e.g. aggregates) are left as
+// they are.
+func (s *QueryService) applyWithheldFilter(result *queries.QueryResult, withheld func(AppState) (map[string]bool, error)) error {
+	assetIdx := slices.Index(result.Columns, "asset_id")
+	if assetIdx == -1 {
+		return nil
+	}
+	hashes, err := withheld(s.app)
+	if err != nil || hashes == nil {
+		return err
+	}
+
+	filtered := result.Rows[:0]
+	for _, row := range result.Rows {
+		if hash, _ := row[assetIdx].(string); !hashes[hash] {
+			filtered = append(filtered, row)
+		}
+	}
+	result.Rows = filtered
+	result.RowCount = len(filtered)
+	return nil
 }
@@ -1,9 +1,12 @@
 package services
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"silobang/internal/constants"
+	"silobang/internal/database"
 	"silobang/internal/logger"
 	"silobang/internal/queries"
 )
@@ -285,3 +288,266 @@ func TestQueryService_ListPresets_WithPresets(t *testing.T) {
 		t.Error("expected to find query-two")
 	}
 }
+
+// setupFederatedWorkDir creates an orchestrator DB and two topic DBs on disk
+// in the layout the federated executor attaches.
+func setupFederatedWorkDir(t *testing.T) *mockAppState {
+	t.Helper()
+	mockApp := newMockAppState()
+	mockApp.workingDir = t.TempDir()
+
+	internalDir := filepath.Join(mockApp.workingDir, constants.InternalDir)
+	if err := os.MkdirAll(internalDir, constants.DirPermissions); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	orchDB, err := database.InitOrchestratorDB(filepath.Join(internalDir, constants.OrchestratorDB))
+	if err != nil {
+		t.Fatalf("init orchestrator: %v", err)
+	}
+	t.Cleanup(func() { orchDB.Close() })
+	mockApp.orchestratorDB = orchDB
+
+	assets := map[string][]string{
+		"alpha": {"shared", "only-alpha"},
+		"beta":  {"shared"},
+	}
+	for topic, names := range assets {
+		topicInternal := filepath.Join(mockApp.workingDir, topic, constants.InternalDir)
+		if err := os.MkdirAll(topicInternal, constants.DirPermissions); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		db, err := database.InitTopicDB(filepath.Join(topicInternal, topic+".db"))
+		if err != nil {
+			t.Fatalf("init topic %s: %v", topic, err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		for _, name := range names {
+			hash := topic + "-" + name
+			if _, err := db.Exec("INSERT INTO assets (asset_id, asset_size, origin_name, extension, blob_name, byte_offset, created_at) VALUES (?, 1, ?, 'bin', '000001.dat', 0, 0)", hash, name); err != nil {
+				t.Fatalf("insert asset: %v", err)
+			}
+			if _, err := orchDB.Exec("INSERT INTO asset_index (hash, topic, dat_file) VALUES (?, ?, '000001.dat')", hash, topic); err != nil {
+				t.Fatalf("insert index: %v", err)
+			}
+		}
+		mockApp.StoreTopicDB(topic, db)
+		mockApp.RegisterTopic(topic, true, "")
+	}
+
+	mockApp.queriesConfig = queries.GetDefaultConfig()
+	return mockApp
+}
+
+func TestQueryService_Execute_FederatedDifference(t *testing.T) {
+	mockApp := setupFederatedWorkDir(t)
+	svc := NewQueryService(mockApp, logger.NewLogger("debug"))
+
+	result, topics, err := svc.Execute("topic-difference", &QueryRequest{
+		Params: map[string]interface{}{"topic_a": "alpha", "topic_b": "beta"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(topics) != 2 || topics[0] != "alpha" || topics[1] != "beta" {
+		t.Errorf("topics = %v, want [alpha beta]", topics)
+	}
+	if result.RowCount != 1 || result.Rows[0][0] != "alpha-only-alpha" {
+		t.Errorf("expected only alpha-only-alpha, got %v", result.Rows)
+	}
+}
+
+func TestQueryService_Execute_FederatedIndexDrift(t *testing.T) {
+	mockApp := setupFederatedWorkDir(t)
+	svc := NewQueryService(mockApp, logger.NewLogger("debug"))
+
+	if _, err := mockApp.orchestratorDB.Exec("INSERT INTO asset_index (hash, topic, dat_file) VALUES ('ghost', 'alpha', '000001.dat')"); err != nil {
+		t.Fatalf("insert index: %v", err)
+	}
+
+	result, _, err := svc.Execute("index-drift", &QueryRequest{
+		Params: map[string]interface{}{"topic": "alpha"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RowCount != 1 || result.Rows[0][0] != "ghost" || result.Rows[0][1] != "missing_from_topic" {
+		t.Errorf("expected ghost missing_from_topic, got %v", result.Rows)
+	}
+}
+
+func TestQueryService_Execute_FederatedReadOnly(t *testing.T) {
+	mockApp := setupFederatedWorkDir(t)
+	mockApp.queriesConfig.Presets["wipe"] = queries.Preset{
+		Description: "Attempted write",
+		SQL:         "DELETE FROM topic.assets",
+		Params:      []queries.PresetParam{{Name: "topic", Required: true}},
+		Federated:   true,
+		TopicParams: []string{"topic"},
+	}
+	svc := NewQueryService(mockApp, logger.NewLogger("debug"))
+
+	_, _, err := svc.Execute("wipe", &QueryRequest{Params: map[string]interface{}{"topic": "alpha"}})
+	if code, ok := IsServiceError(err); !ok || code != constants.ErrCodeQueryError {
+		t.Fatalf("expected %s, got %v", constants.ErrCodeQueryError, err)
+	}
+
+	var count int
+	mockApp.topicDBs["alpha"].QueryRow("SELECT COUNT(*) FROM assets").Scan(&count)
+	if count != 2 {
+		t.Errorf("asset count = %d, want 2 (write must be rejected)", count)
+	}
+}
+
+func TestQueryService_Execute_FederatedUnknownTopic(t *testing.T) {
+	mockApp := setupFederatedWorkDir(t)
+	svc := NewQueryService(mockApp, logger.NewLogger("debug"))
+
+	_, _, err := svc.Execute("topic-difference", &QueryRequest{
+		Params: map[string]interface{}{"topic_a": "alpha", "topic_b": "missing"},
+	})
+	if code, ok := IsServiceError(err); !ok || code != constants.ErrCodeTopicNotFound {
+		t.Fatalf("expected %s, got %v", constants.ErrCodeTopicNotFound, err)
+	}
+}
+
+func TestQueryService_FederatedTopics(t *testing.T) {
+	mockApp := setupFederatedWorkDir(t)
+	svc := NewQueryService(mockApp, logger.NewLogger("debug"))
+
+	topics, err := svc.FederatedTopics("topic-difference", &QueryRequest{
+		Params: map[string]interface{}{"topic_a": "beta", "topic_b": "alpha"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(topics) != 2 || topics[0] != "beta" || topics[1] != "alpha" {
+		t.Errorf("topics = %v, want [beta alpha]", topics)
+	}
+
+	topics, err = svc.FederatedTopics("count", &QueryRequest{})
+	if err != nil || topics != nil {
+		t.Errorf("expected nil topics for regular preset, got %v, %v", topics, err)
+	}
+}
+
+func TestQueryService_FinishResult_TruncatesToMaxRows(t *testing.T) {
+	svc := NewQueryService(newMockAppState(), logger.NewLogger("debug"))
+	result := &queries.QueryResult{
+		Columns:  []string{"n"},
+		Rows:     [][]interface{}{{1}, {2}, {3}},
+		RowCount: 3,
+	}
+
+	result, _, err := svc.finishResult("count", nil, result, nil, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Truncated || result.RowCount != 2 || len(result.Rows) != 2 {
+		t.Errorf("expected 2 rows and truncated, got %+v", result)
+	}
+}
+
+func TestQueryService_Validate_ExplainsWithoutRows(t *testing.T) {
+	mockApp := setupFederatedWorkDir(t)
+	svc := NewQueryService(mockApp, logger.NewLogger("debug"))
+
+	report, err := svc.Validate(&PresetValidateRequest{
+		Definition: `description: "By name"
+sql: |
+  SELECT asset_id, origin_name, COUNT(*) AS n FROM assets
+  WHERE origin_name LIKE :pattern GROUP BY asset_id LIMIT :limit
+params:
+  - name: pattern
+    required: true
+  - name: limit
+    default: "10"
+  - name: unused
+`,
+		Topic: "alpha",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.Valid || len(report.Errors) != 0 {
+		t.Fatalf("expected valid preset, got errors %v", report.Errors)
+	}
+	if len(report.Warnings) != 1 {
+		t.Errorf("expected a warning for the unused param, got %v", report.Warnings)
+	}
+
+	want := []queries.ColumnInfo{
+		{Name: "asset_id", Type: "TEXT"}, {Name: "origin_name", Type: "TEXT"}, {Name: "n"}, {Name: "_topic"},
+	}
+	if len(report.Columns) != len(want) {
+		t.Fatalf("columns = %v, want %v", report.Columns, want)
+	}
+	for i := range want {
+		if report.Columns[i] != want[i] {
+			t.Errorf("column %d = %v, want %v", i, report.Columns[i], want[i])
+		}
+	}
+	if len(report.Plan) == 0 || report.Cost == nil || report.Cost.Opcodes == 0 {
+		t.Errorf("expected a plan and cost, got %v %+v", report.Plan, report.Cost)
+	}
+}
+
+func TestQueryService_Validate_ReportsProblems(t *testing.T) {
+	mockApp := setupFederatedWorkDir(t)
+	svc := NewQueryService(mockApp, logger.NewLogger("debug"))
+
+	cases := map[string]string{
+		"bad yaml":          "sql: [unclosed",
+		"unknown field":     "sql: SELECT 1\nparamz: []",
+		"undeclared param":  "sql: SELECT * FROM assets WHERE origin_name = :name",
+		"write statement":   "sql: DELETE FROM assets",
+		"syntax error":      "sql: SELECT FROM WHERE",
+		"unknown table":     "sql: SELECT * FROM no_such_table",
+		"duplicate param":   "sql: SELECT :a\nparams:\n  - name: a\n  - name: a",
+		"topic params only": "sql: SELECT 1\ntopic_params: [a]",
+	}
+	for name, definition := range cases {
+		report, err := svc.Validate(&PresetValidateRequest{Definition: definition, Topic: "alpha"})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if report.Valid || len(report.Errors) == 0 {
+			t.Errorf("%s: expected errors, got %+v", name, report)
+		}
+	}
+
+	// Bad requests are errors, not reports
+	if _, err := svc.Validate(&PresetValidateRequest{Definition: "sql: SELECT 1"}); !isServiceErrorCode(err, constants.ErrCodeInvalidRequest) {
+		t.Errorf("missing topic: expected %s, got %v", constants.ErrCodeInvalidRequest, err)
+	}
+	if _, err := svc.Validate(&PresetValidateRequest{Definition: "sql: SELECT 1", Topic: "gamma"}); !isServiceErrorCode(err, constants.ErrCodeTopicNotFound) {
+		t.Errorf("unknown topic: expected %s, got %v", constants.ErrCodeTopicNotFound, err)
+	}
+}
+
+func TestQueryService_Validate_Federated(t *testing.T) {
+	mockApp := setupFederatedWorkDir(t)
+	svc := NewQueryService(mockApp, logger.NewLogger("debug"))
+
+	report, err := svc.Validate(&PresetValidateRequest{
+		Definition: `sql: SELECT a.asset_id FROM a.assets a JOIN orchestrator.asset_index i ON i.hash = a.asset_id
+federated: true
+topic_params: [a]
+params:
+  - name: a
+    required: true
+`,
+		Params: map[string]interface{}{"a": "beta"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.Valid || len(report.Topics) != 1 || report.Topics[0] != "beta" {
+		t.Errorf("expected valid preset compiled against beta, got %+v", report)
+	}
+	if len(report.Columns) != 1 || report.Columns[0].Name != "asset_id" {
+		t.Errorf("federated presets get no _topic column, got %v", report.Columns)
+	}
+}
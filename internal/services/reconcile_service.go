@@ -16,6 +16,9 @@ type ReconcileResult struct {
 	TopicsRemoved int      // Number of orphaned topics cleaned up
 	EntriesPurged int64    // Total asset_index entries deleted
 	RemovedTopics []string // Names of removed topics
+	// References held in other topics on assets of removed topics; they
+	// were dropped from the registry with the assets
+	DanglingReferences []database.AssetReference
 }
 
 // ReconcileService detects topic folders that have been manually removed
@@ -25,6 +28,9 @@ type ReconcileService struct {
 	app        AppState
 	logger     *logger.Logger
 	statsCache *StatsCache
+	assetCache *AssetCache
+
+	notifications *NotificationService
 
 	stopCh  chan struct{}
 	running bool
@@ -46,6 +52,18 @@ func (s *ReconcileService) SetStatsCache(cache *StatsCache) {
 	s.statsCache = cache
 }
 
+// SetAssetCache sets the asset cache so contents of removed topics are
+// dropped from memory.
+func (s *ReconcileService) SetAssetCache(cache *AssetCache) {
+	s.assetCache = cache
+}
+
+// SetNotificationService sets the notification service so subscriptions to
+// removed topics are dropped.
+func (s *ReconcileService) SetNotificationService(notifications *NotificationService) {
+	s.notifications = notifications
+}
+
 // Reconcile performs a single reconciliation pass.
 // It compares topics referenced in asset_index against topics actually
 // present on disk and purges entries for any that no longer exist.
@@ -62,7 +80,7 @@ func (s *ReconcileService) Reconcile() (*ReconcileResult, error) {
 	s.logger.Debug("[reconcile] starting reconciliation pass")
 
 	// 1. Get all distinct topics referenced in asset_index
-	indexedTopics, err := database.ListIndexedTopics(orchDB)
+	indexedTopics, err := s.app.GetAssetIndex().ListTopics()
 	if err != nil {
 		s.logger.Error("[reconcile] failed to list indexed topics: %v", err)
 		return nil, err
@@ -84,8 +102,24 @@ func (s *ReconcileService) Reconcile() (*ReconcileResult, error) {
 			continue // Folder exists on disk, topic is fine
 		}
 
-		// Folder is gone — purge the orphaned index entries
-		purged, err := database.DeleteAssetIndexByTopic(orchDB, topic)
+		// Folder is gone — consult the reference registry before purging:
+		// holders in other topics that referenced its assets now dangle
+		dangling, err := database.ListReferencesIntoTopic(orchDB, topic)
+		if err != nil {
+			s.logger.Error("[reconcile] failed to list references into topic %q: %v", topic, err)
+			continue
+		}
+		for _, ref := range dangling {
+			s.logger.Warn("[reconcile] %s reference from %s/%s to asset %s of removed topic %q now dangles",
+				ref.Kind, ref.Topic, ref.Holder, ref.Hash, topic)
+		}
+		if _, err := database.DeleteReferencesByTopic(orchDB, topic); err != nil {
+			s.logger.Error("[reconcile] failed to purge references for topic %q: %v", topic, err)
+			continue
+		}
+
+		// Purge the orphaned index entries
+		purged, err := s.app.GetAssetIndex().DeleteTopic(topic)
 		if err != nil {
 			s.logger.Error("[reconcile] failed to purge asset_index entries for topic %q: %v", topic, err)
 			continue // best-effort: continue with other topics
@@ -99,6 +133,12 @@ func (s *ReconcileService) Reconcile() (*ReconcileResult, error) {
 			s.statsCache.RemoveTopic(topic)
 			s.logger.Debug("[reconcile] evicted topic %q from stats cache", topic)
 		}
+		if s.assetCache != nil {
+			s.assetCache.InvalidateTopic(topic)
+		}
+		if s.notifications != nil {
+			s.notifications.RemoveTopic(topic)
+		}
 
 		s.logger.Info("[reconcile] purged %d orphaned asset_index entries for removed topic %q", purged, topic)
 
@@ -110,8 +150,9 @@ func (s *ReconcileService) Reconcile() (*ReconcileResult, error) {
 				"system",
 				"system",
 				audit.ReconcileTopicRemovedDetails{
-					TopicName:     topic,
-					EntriesPurged: purged,
+					TopicName:          topic,
+					EntriesPurged:      purged,
+					DanglingReferences: len(dangling),
 				},
 			); auditErr != nil {
 				s.logger.Error("[reconcile] failed to write audit entry for topic %q removal: %v", topic, auditErr)
@@ -121,6 +162,7 @@ func (s *ReconcileService) Reconcile() (*ReconcileResult, error) {
 		result.TopicsRemoved++
 		result.EntriesPurged += purged
 		result.RemovedTopics = append(result.RemovedTopics, topic)
+		result.DanglingReferences = append(result.DanglingReferences, dangling...)
 	}
 
 	if result.TopicsRemoved > 0 {
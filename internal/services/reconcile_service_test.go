@@ -203,6 +203,7 @@ func TestReconcile_OrphanedTopicPurged(t *testing.T) {
 	}
 
 	// Verify audit log entry was created
+	auditLogger.Flush()
 	var auditCount int
 	if err := db.QueryRow("SELECT COUNT(*) FROM audit_log WHERE action = ?",
 		constants.AuditActionReconcileTopicRemoved).Scan(&auditCount); err != nil {
@@ -309,6 +310,7 @@ func TestReconcile_MultipleOrphanedTopics(t *testing.T) {
 	}
 
 	// Verify 3 audit entries
+	auditLogger.Flush()
 	var auditCount int
 	if err := db.QueryRow("SELECT COUNT(*) FROM audit_log WHERE action = ?",
 		constants.AuditActionReconcileTopicRemoved).Scan(&auditCount); err != nil {
@@ -370,3 +372,58 @@ func TestReconcile_StartStop(t *testing.T) {
 	// Double stop should not panic
 	svc.Stop()
 }
+
+func TestReconcile_RemovedTopicReportsDanglingReferences(t *testing.T) {
+	db := setupReconcileTestDB(t)
+	defer db.Close()
+
+	workDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workDir, "topic-a"), constants.DirPermissions); err != nil {
+		t.Fatalf("failed to create topic-a dir: %v", err)
+	}
+
+	// hash2 in topic-a is derived from hash1 in topic-b, which is removed;
+	// topic-b also holds a collection reference on its own asset
+	_, err := db.Exec(`INSERT INTO asset_index (hash, topic, dat_file) VALUES
+		('hash1', 'topic-b', '001.dat'),
+		('hash2', 'topic-a', '001.dat')`)
+	if err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	err = database.AddAssetReferences(db, []database.AssetReference{
+		{Hash: "hash1", Kind: constants.ReferenceKindLineage, Topic: "topic-a", Holder: "hash2", CreatedAt: 1},
+		{Hash: "hash1", Kind: constants.ReferenceKindCollection, Topic: "topic-b", Holder: "favs", CreatedAt: 1},
+		{Hash: "hash2", Kind: constants.ReferenceKindCollection, Topic: "topic-a", Holder: "favs", CreatedAt: 1},
+	})
+	if err != nil {
+		t.Fatalf("failed to add references: %v", err)
+	}
+
+	mockApp := newMockAppState()
+	mockApp.orchestratorDB = db
+	mockApp.workingDir = workDir
+	mockApp.RegisterTopic("topic-a", true, "")
+
+	svc := NewReconcileService(mockApp, logger.NewLogger("debug"))
+	result, err := svc.Reconcile()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.DanglingReferences) != 1 || result.DanglingReferences[0].Holder != "hash2" {
+		t.Errorf("expected the lineage reference from hash2 to dangle, got %+v", result.DanglingReferences)
+	}
+
+	// Only topic-a's reference on its own asset survives
+	refs, err := database.ListAssetReferences(db, "hash1")
+	if err != nil {
+		t.Fatalf("failed to list references: %v", err)
+	}
+	if len(refs) != 0 {
+		t.Errorf("expected references to removed assets to be purged, got %+v", refs)
+	}
+	refs, _ = database.ListAssetReferences(db, "hash2")
+	if len(refs) != 1 {
+		t.Errorf("expected topic-a reference to survive, got %+v", refs)
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"sort"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// AssetReferences lists every holder keeping an asset alive. An asset is
+// deletable only when nothing references it.
+type AssetReferences struct {
+	Hash       string                    `json:"hash"`
+	Topic      string                    `json:"topic"`
+	Count      int                       `json:"reference_count"`
+	ByKind     map[string]int            `json:"by_kind"`
+	Topics     []string                  `json:"topics"` // topics of the asset and every holder
+	References []database.AssetReference `json:"references"`
+	Deletable  bool                      `json:"deletable"`
+}
+
+// ReferenceService answers which holders reference an asset, from the
+// reference registry in the orchestrator database.
+type ReferenceService struct {
+	app    AppState
+	logger *logger.Logger
+}
+
+// NewReferenceService creates a new reference service instance.
+func NewReferenceService(app AppState, log *logger.Logger) *ReferenceService {
+	return &ReferenceService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// References returns the references registered for an indexed asset.
+func (s *ReferenceService) References(hash string) (*AssetReferences, error) {
+	if len(hash) != constants.HashLength {
+		return nil, ErrInvalidHash
+	}
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+
+	exists, topic, _, err := s.app.GetAssetIndex().Lookup(hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if !exists {
+		return nil, ErrAssetNotFoundWithHash(hash)
+	}
+
+	refs, err := database.ListAssetReferences(orchDB, hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	result := &AssetReferences{
+		Hash:       hash,
+		Topic:      topic,
+		Count:      len(refs),
+		ByKind:     make(map[string]int),
+		References: refs,
+		Deletable:  len(refs) == 0,
+	}
+
+	topics := map[string]bool{topic: true}
+	for _, ref := range refs {
+		result.ByKind[ref.Kind]++
+		if ref.Topic != "" { // link exports live outside topics
+			topics[ref.Topic] = true
+		}
+	}
+	result.Topics = make([]string, 0, len(topics))
+	for t := range topics {
+		result.Topics = append(result.Topics, t)
+	}
+	sort.Strings(result.Topics)
+
+	return result, nil
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// RetentionService enforces the retention policies of topics
+// (topic_retention): the oldest assets over a policy's age, total size or
+// count limit are moved to the trash or deleted permanently.
+type RetentionService struct {
+	app    AppState
+	logger *logger.Logger
+	trash  *TrashService
+	assets *AssetService
+	stats  *StatsCache
+
+	applyMu sync.Mutex // one pass at a time, so passes never race on the same assets
+
+	mu      sync.Mutex
+	running bool
+	stopCh  chan struct{}
+}
+
+// RetentionResult is the outcome of applying one topic's retention policy.
+type RetentionResult struct {
+	Topic     string   `json:"topic"`
+	Action    string   `json:"action"`
+	DryRun    bool     `json:"dry_run"`
+	Hashes    []string `json:"hashes"` // assets removed, or that a dry run would remove
+	Bytes     int64    `json:"bytes"`
+	Skipped   []string `json:"skipped,omitempty"`   // assets over a limit that could not be removed
+	Truncated bool     `json:"truncated,omitempty"` // more assets are over a limit than one pass removes
+}
+
+// NewRetentionService creates a new retention service instance.
+func NewRetentionService(app AppState, log *logger.Logger, trash *TrashService, assets *AssetService, stats *StatsCache) *RetentionService {
+	return &RetentionService{
+		app:    app,
+		logger: log,
+		trash:  trash,
+		assets: assets,
+		stats:  stats,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Apply enforces the retention policy of topic. A dry run, requested or set
+// on the policy, only reports the assets that would be removed. by and
+// ipAddress identify the actor for the audit log.
+func (s *RetentionService) Apply(topic string, dryRun bool, by, ipAddress string) (*RetentionResult, error) {
+	cfg := s.app.GetConfig()
+	if cfg == nil {
+		return nil, ErrNotConfigured
+	}
+	policy, ok := cfg.TopicRetention[topic]
+	if !ok {
+		return nil, ErrRetentionPolicyNotFoundForTopic(topic)
+	}
+	return s.apply(topic, policy, dryRun || policy.DryRun, constants.RetentionTriggerManual, by, ipAddress)
+}
+
+// ApplyAll enforces the retention policies of every configured topic.
+// Unhealthy topics are skipped until they recover.
+func (s *RetentionService) ApplyAll() {
+	cfg := s.app.GetConfig()
+	if cfg == nil || s.app.GetOrchestratorDB() == nil {
+		return
+	}
+	for _, topic := range slices.Sorted(maps.Keys(cfg.TopicRetention)) {
+		if !s.app.TopicExists(topic) {
+			continue
+		}
+		if healthy, _ := s.app.IsTopicHealthy(topic); !healthy {
+			s.logger.Debug("[retention] skipping unhealthy topic %s", topic)
+			continue
+		}
+		policy := cfg.TopicRetention[topic]
+		if _, err := s.apply(topic, policy, policy.DryRun, constants.RetentionTriggerScheduled, "", constants.AuditActorSystem); err != nil {
+			s.logger.Error("[retention] failed to apply the policy of topic %s: %v", topic, err)
+		}
+	}
+}
+
+func (s *RetentionService) apply(topic string, policy config.RetentionConfig, dryRun bool, trigger, by, ipAddress string) (*RetentionResult, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+	if !s.app.TopicExists(topic) {
+		return nil, ErrTopicNotFoundWithName(topic)
+	}
+	if healthy, errMsg := s.app.IsTopicHealthy(topic); !healthy {
+		return nil, ErrTopicUnhealthyWithReason(topic, errMsg)
+	}
+
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+
+	topicDB, err := s.app.GetTopicDB(topic)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	assets, err := database.ListAssetsByAge(topicDB)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	trashed, err := trashedHashes(s.app)
+	if err != nil {
+		return nil, err
+	}
+
+	// Trashed assets are already on their way out and do not count
+	var count int
+	var total int64
+	live := assets[:0]
+	for _, a := range assets {
+		if trashed[a.AssetID] {
+			continue
+		}
+		live = append(live, a)
+		count++
+		total += a.AssetSize
+	}
+
+	result := &RetentionResult{
+		Topic:  topic,
+		Action: policy.Removal(),
+		DryRun: dryRun,
+		Hashes: []string{},
+	}
+	cutoff := int64(0)
+	if policy.MaxAgeDays > 0 {
+		cutoff = time.Now().Add(-policy.MaxAge()).Unix()
+	}
+
+	// Assets are oldest first: once one is within every limit, so are the
+	// newer ones
+	for _, a := range live {
+		over := a.CreatedAt < cutoff ||
+			(policy.MaxTotalBytes > 0 && total > policy.MaxTotalBytes) ||
+			(policy.MaxAssets > 0 && count > policy.MaxAssets)
+		if !over {
+			break
+		}
+		if len(result.Hashes) >= constants.RetentionMaxAssetsPerPass {
+			result.Truncated = true
+			break
+		}
+		if err := s.remove(a.AssetID, result.Action, dryRun, by, ipAddress); err != nil {
+			s.logger.Warn("[retention] kept %s of topic %s: %v", a.AssetID, topic, err)
+			result.Skipped = append(result.Skipped, a.AssetID)
+			continue
+		}
+		result.Hashes = append(result.Hashes, a.AssetID)
+		result.Bytes += a.AssetSize
+		count--
+		total -= a.AssetSize
+	}
+
+	if len(result.Hashes) == 0 && len(result.Skipped) == 0 {
+		return result, nil
+	}
+	if !dryRun && result.Action == constants.RetentionActionDelete && s.stats != nil {
+		s.stats.InvalidateTopic(topic)
+	}
+
+	verb := "removed"
+	if dryRun {
+		verb = "would remove"
+	}
+	s.logger.Info("[retention] %s %d asset(s) (%d bytes) of topic %s by %s, %d kept", verb, len(result.Hashes), result.Bytes, topic, result.Action, len(result.Skipped))
+	if l := s.app.GetAuditLogger(); l != nil {
+		if err := l.Log(constants.AuditActionRetentionApplied, ipAddress, by, audit.RetentionAppliedDetails{
+			Topic:     topic,
+			Action:    result.Action,
+			Trigger:   trigger,
+			DryRun:    dryRun,
+			Hashes:    result.Hashes,
+			Bytes:     result.Bytes,
+			Skipped:   result.Skipped,
+			Truncated: result.Truncated,
+		}); err != nil {
+			s.logger.Error("Failed to write audit entry for retention of topic %s: %v", topic, err)
+		}
+	}
+	return result, nil
+}
+
+// remove trashes or deletes one asset. A dry run only checks that nothing
+// references it, as trashing and deleting would.
+func (s *RetentionService) remove(hash, action string, dryRun bool, by, ipAddress string) error {
+	if dryRun {
+		refs, err := database.ListAssetReferences(s.app.GetOrchestratorDB(), hash)
+		if err != nil {
+			return WrapInternalError(err)
+		}
+		if len(refs) > 0 {
+			return NewServiceError(constants.ErrCodeAssetReferenced, "asset is referenced")
+		}
+		return nil
+	}
+	if action == constants.RetentionActionDelete {
+		_, err := s.assets.Delete(hash, by, ipAddress)
+		return err
+	}
+	_, err := s.trash.Trash(hash, by, ipAddress)
+	return err
+}
+
+// Start launches the scheduled evaluation of the retention policies.
+// Safe to call multiple times — subsequent calls are no-ops.
+func (s *RetentionService) Start(interval time.Duration) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info("[retention] scheduled evaluation started (interval: %v)", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				s.logger.Info("[retention] scheduled evaluation stopped")
+				return
+			case <-ticker.C:
+				s.ApplyAll()
+			}
+		}
+	}()
+}
+
+// Stop signals the scheduled evaluation goroutine to exit.
+func (s *RetentionService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stopCh)
+		s.running = false
+	}
+}
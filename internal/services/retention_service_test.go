@@ -0,0 +1,86 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+)
+
+func TestRetentionService_RemovesOldestOverLimits(t *testing.T) {
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+
+	// Oldest first; the oldest is referenced and must be kept
+	referenced := strings.Repeat("a", constants.HashLength)
+	older := strings.Repeat("b", constants.HashLength)
+	newer := strings.Repeat("c", constants.HashLength)
+	newest := strings.Repeat("d", constants.HashLength)
+	var assets []testAsset
+	var entries []orchestratorEntry
+	for i, hash := range []string{referenced, older, newer, newest} {
+		assets = append(assets, testAsset{id: hash, size: 10, ext: "bin", blobName: "001.dat", createdAt: int64(1700000000 + i)})
+		entries = append(entries, orchestratorEntry{hash: hash, topic: "photos", datFile: "001.dat"})
+	}
+	topicDB := setupTopicDir(t, workDir, "photos", assets)
+	if _, err := topicDB.Exec("UPDATE assets SET origin_name = 'photo'"); err != nil {
+		t.Fatalf("failed to name assets: %v", err)
+	}
+	mock.StoreTopicDB("photos", topicDB)
+	mock.RegisterTopic("photos", true, "")
+	mock.orchestratorDB = setupOrchestratorDB(t, workDir, entries)
+	if err := database.AddAssetReferences(mock.orchestratorDB, []database.AssetReference{
+		{Hash: referenced, Kind: constants.ReferenceKindCollection, Topic: "photos", Holder: "favs", CreatedAt: 1},
+	}); err != nil {
+		t.Fatalf("failed to add reference: %v", err)
+	}
+
+	assetSvc := NewAssetService(mock, mock.log)
+	trash := NewTrashService(mock, mock.log, assetSvc, nil)
+	svc := NewRetentionService(mock, mock.log, trash, assetSvc, nil)
+
+	if _, err := svc.Apply("photos", true, "alice", "127.0.0.1"); !isServiceErrorCode(err, constants.ErrCodeRetentionPolicyNotFound) {
+		t.Fatalf("expected RETENTION_POLICY_NOT_FOUND, got %v", err)
+	}
+	mock.cfg.TopicRetention = map[string]config.RetentionConfig{"photos": {MaxAssets: 2}}
+
+	// A dry run reports what would go without trashing anything
+	result, err := svc.Apply("photos", true, "alice", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if !result.DryRun || result.Action != constants.RetentionActionTrash ||
+		!slices.Equal(result.Hashes, []string{older, newer}) || !slices.Equal(result.Skipped, []string{referenced}) || result.Bytes != 20 {
+		t.Errorf("unexpected dry run result: %+v", result)
+	}
+	if list, err := trash.List(""); err != nil || len(list) != 0 {
+		t.Fatalf("dry run trashed assets: %+v, %v", list, err)
+	}
+
+	if result, err = svc.Apply("photos", false, "alice", "127.0.0.1"); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if !slices.Equal(result.Hashes, []string{older, newer}) {
+		t.Errorf("unexpected removed assets: %+v", result)
+	}
+	for _, hash := range result.Hashes {
+		if err := checkTrash(mock, hash); !isServiceErrorCode(err, constants.ErrCodeAssetTrashed) {
+			t.Errorf("expected %s in the trash, got %v", hash, err)
+		}
+	}
+
+	// Trashed assets no longer count toward the limits
+	if result, err = svc.Apply("photos", false, "alice", "127.0.0.1"); err != nil || len(result.Hashes) != 0 {
+		t.Errorf("expected nothing left to remove, got %+v, %v", result, err)
+	}
+
+	// Every remaining asset is past the age limit; only the referenced one stays
+	mock.cfg.TopicRetention["photos"] = config.RetentionConfig{MaxAgeDays: 1}
+	if result, err = svc.Apply("photos", false, "alice", "127.0.0.1"); err != nil ||
+		!slices.Equal(result.Hashes, []string{newest}) || !slices.Equal(result.Skipped, []string{referenced}) {
+		t.Errorf("unexpected age-based result: %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,907 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/auth"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/queries"
+)
+
+// RuleService runs automation rules: admin-defined "when <trigger>, for
+// assets matching <condition>, do <action>" definitions stored in the
+// orchestrator DB.
+//
+// Schedule rules run every interval over the assets their query preset
+// returns. Event rules keep a cursor into the audit log, like webhooks, and
+// run over the assets named by new entries of the actions they select. The
+// condition then narrows the assets by topic and current metadata values,
+// and the action tags, notifies about, tombstones or quarantines them as the
+// system actor. Every run is recorded with its counts and a sample of the
+// matched hashes.
+type RuleService struct {
+	app           AppState
+	logger        *logger.Logger
+	bulk          *BulkService
+	assets        *AssetService
+	metadata      *MetadataService
+	quarantine    *QuarantineService
+	notifications *NotificationService
+	auth          *AuthService
+	stats         *StatsCache
+
+	runMu    sync.Mutex // serializes rule runs
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// RuleRequest creates or updates a rule. Nil fields are left unchanged on
+// update; name, trigger and action are required on create.
+type RuleRequest struct {
+	Name      *string                 `json:"name"`
+	Enabled   *bool                   `json:"enabled"` // default true
+	Trigger   *database.RuleTrigger   `json:"trigger"`
+	Condition *database.RuleCondition `json:"condition"`
+	Action    *database.RuleAction    `json:"action"`
+}
+
+// RuleStatus is a rule with its schedule.
+type RuleStatus struct {
+	database.Rule
+	NextRunAt int64 `json:"next_run_at,omitempty"` // enabled schedule rules
+
+	// RecentRuns lists the newest runs; only set for a single rule.
+	RecentRuns []database.RuleRun `json:"recent_runs,omitempty"`
+}
+
+// ruleMatch is an asset a rule's condition selected.
+type ruleMatch struct {
+	hash  string
+	topic string
+	db    *sql.DB
+}
+
+// NewRuleService creates a new rule service and starts its scheduler.
+// Returns nil if the orchestrator DB is not available.
+func NewRuleService(app AppState, log *logger.Logger, bulk *BulkService, assets *AssetService, metadata *MetadataService,
+	quarantine *QuarantineService, notifications *NotificationService, authService *AuthService, stats *StatsCache) *RuleService {
+	if app.GetOrchestratorDB() == nil {
+		return nil
+	}
+
+	svc := &RuleService{
+		app:           app,
+		logger:        log,
+		bulk:          bulk,
+		assets:        assets,
+		metadata:      metadata,
+		quarantine:    quarantine,
+		notifications: notifications,
+		auth:          authService,
+		stats:         stats,
+		stop:          make(chan struct{}),
+	}
+
+	go svc.schedulerLoop()
+
+	return svc
+}
+
+// Stop stops the scheduler goroutine (call during graceful shutdown).
+func (s *RuleService) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// ============================================================================
+// CRUD
+// ============================================================================
+
+// List returns every rule.
+func (s *RuleService) List() ([]RuleStatus, error) {
+	rules, err := database.ListRules(s.app.GetOrchestratorDB())
+	if err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to list rules: %w", err))
+	}
+	statuses := make([]RuleStatus, 0, len(rules))
+	for _, rule := range rules {
+		statuses = append(statuses, newRuleStatus(rule))
+	}
+	return statuses, nil
+}
+
+// Get returns a rule with its newest runs.
+func (s *RuleService) Get(id int64) (*RuleStatus, error) {
+	rule, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := database.ListRuleRuns(s.app.GetOrchestratorDB(), id, constants.RuleRecentRuns)
+	if err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to list rule runs: %w", err))
+	}
+	status := newRuleStatus(*rule)
+	status.RecentRuns = runs
+	return &status, nil
+}
+
+// Create adds a rule. allowed reports whether the author may perform an
+// action by hand: a rule may only do to a topic what its author could.
+// Schedule rules first run one interval after creation; event rules act on
+// audit entries logged from now on.
+func (s *RuleService) Create(req *RuleRequest, createdBy string, allowed func(*auth.ActionContext) bool) (*database.Rule, error) {
+	if req.Name == nil || req.Trigger == nil || req.Action == nil {
+		return nil, NewServiceError(constants.ErrCodeInvalidRule, "name, trigger and action are required")
+	}
+
+	now := time.Now().Unix()
+	rule := database.Rule{Enabled: true, CreatedBy: createdBy, CreatedAt: now, UpdatedAt: now}
+	if err := s.applyRuleRequest(&rule, req, allowed); err != nil {
+		return nil, err
+	}
+	if err := s.checkNameFree(rule.Name, 0); err != nil {
+		return nil, err
+	}
+
+	if err := database.InsertRule(s.app.GetOrchestratorDB(), &rule); err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to create rule: %w", err))
+	}
+	s.logger.Info("Rules: created rule id=%d name=%s trigger=%s action=%s", rule.ID, rule.Name, rule.Trigger.Type, rule.Action.Type)
+	return &rule, nil
+}
+
+// Update changes a rule. A rule that is re-enabled or given a new trigger
+// starts over: it skips what happened before the update.
+func (s *RuleService) Update(id int64, req *RuleRequest, allowed func(*auth.ActionContext) bool) (*database.Rule, error) {
+	rule, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	wasEnabled := rule.Enabled
+	if err := s.applyRuleRequest(rule, req, allowed); err != nil {
+		return nil, err
+	}
+	if err := s.checkNameFree(rule.Name, rule.ID); err != nil {
+		return nil, err
+	}
+	rule.UpdatedAt = time.Now().Unix()
+
+	restart := (rule.Enabled && !wasEnabled) || req.Trigger != nil
+	if err := database.UpdateRule(s.app.GetOrchestratorDB(), *rule, restart); err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to update rule: %w", err))
+	}
+	if restart {
+		rule.LastRunAt = rule.UpdatedAt
+	}
+	s.logger.Info("Rules: updated rule id=%d name=%s enabled=%t", rule.ID, rule.Name, rule.Enabled)
+	return rule, nil
+}
+
+// Delete removes a rule and its run history and returns it.
+func (s *RuleService) Delete(id int64) (*database.Rule, error) {
+	rule, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := database.DeleteRule(s.app.GetOrchestratorDB(), id)
+	if err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to delete rule: %w", err))
+	}
+	if !removed {
+		return nil, NewServiceError(constants.ErrCodeRuleNotFound, "rule not found")
+	}
+	s.logger.Info("Rules: deleted rule id=%d name=%s", rule.ID, rule.Name)
+	return rule, nil
+}
+
+func (s *RuleService) load(id int64) (*database.Rule, error) {
+	rule, err := database.GetRule(s.app.GetOrchestratorDB(), id)
+	if err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to load rule: %w", err))
+	}
+	if rule == nil {
+		return nil, NewServiceError(constants.ErrCodeRuleNotFound, "rule not found")
+	}
+	return rule, nil
+}
+
+func (s *RuleService) checkNameFree(name string, id int64) error {
+	existing, err := database.GetRuleByName(s.app.GetOrchestratorDB(), name)
+	if err != nil {
+		return WrapInternalError(fmt.Errorf("failed to look up rule: %w", err))
+	}
+	if existing != nil && existing.ID != id {
+		return NewServiceError(constants.ErrCodeInvalidRule, fmt.Sprintf("a rule named %q already exists", name))
+	}
+	return nil
+}
+
+func newRuleStatus(rule database.Rule) RuleStatus {
+	status := RuleStatus{Rule: rule}
+	if rule.Enabled && rule.Trigger.Type == constants.RuleTriggerSchedule {
+		status.NextRunAt = rule.LastRunAt + rule.Trigger.IntervalSecs
+	}
+	return status
+}
+
+// ============================================================================
+// Runs
+// ============================================================================
+
+// Preview reports what an unsaved rule would do now, without doing it or
+// recording a run. Event rules are previewed over the newest audit entries
+// they select.
+func (s *RuleService) Preview(req *RuleRequest, allowed func(*auth.ActionContext) bool) (*database.RuleRun, error) {
+	if req.Trigger == nil || req.Action == nil {
+		return nil, NewServiceError(constants.ErrCodeInvalidRule, "trigger and action are required")
+	}
+	rule := database.Rule{Name: "preview", Enabled: true}
+	if err := s.applyRuleRequest(&rule, req, allowed); err != nil {
+		return nil, err
+	}
+	return s.execute(&rule, constants.RuleRunManual, true, time.Now()), nil
+}
+
+// Run runs a rule now, even when disabled, and records the run. A dry run
+// reports what the rule would do without doing it; an event rule then looks
+// at the newest audit entries it selects instead of consuming new ones.
+func (s *RuleService) Run(id int64, dryRun bool) (*database.RuleRun, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	rule, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	run := s.execute(rule, constants.RuleRunManual, dryRun, time.Now())
+	if err := s.record(rule, run); err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to record rule run: %w", err))
+	}
+	return run, nil
+}
+
+// schedulerLoop periodically runs due rules and purges runs past retention.
+func (s *RuleService) schedulerLoop() {
+	tick := time.NewTicker(constants.RuleTickInterval)
+	defer tick.Stop()
+	cleanup := time.NewTicker(constants.RuleCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			s.logger.Info("Rules: scheduler goroutine stopped")
+			return
+		case now := <-tick.C:
+			s.RunDue(now)
+		case now := <-cleanup.C:
+			s.purgeRuns(now)
+		}
+	}
+}
+
+// RunDue runs the enabled schedule rules whose interval has elapsed at now
+// and the enabled event rules with new audit entries.
+func (s *RuleService) RunDue(now time.Time) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return // working directory is being switched
+	}
+	rules, err := database.ListRules(db)
+	if err != nil {
+		s.logger.Error("Rules: failed to list rules: %v", err)
+		return
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Enabled {
+			continue
+		}
+		switch rule.Trigger.Type {
+		case constants.RuleTriggerSchedule:
+			if now.Unix() < rule.LastRunAt+rule.Trigger.IntervalSecs {
+				continue
+			}
+			if err := database.SetRuleLastRun(db, rule.ID, now.Unix()); err != nil {
+				s.logger.Error("Rules: failed to schedule rule id=%d: %v", rule.ID, err)
+				continue
+			}
+			run := s.execute(rule, constants.RuleRunSchedule, false, now)
+			if err := s.record(rule, run); err != nil {
+				s.logger.Error("Rules: failed to record run of rule id=%d: %v", rule.ID, err)
+			}
+		case constants.RuleTriggerEvent:
+			run := s.execute(rule, constants.RuleRunEvent, false, now)
+			if run.Matched == 0 && run.Error == "" {
+				continue // nothing happened; not worth a history entry
+			}
+			if err := s.record(rule, run); err != nil {
+				s.logger.Error("Rules: failed to record run of rule id=%d: %v", rule.ID, err)
+			}
+		}
+	}
+}
+
+// record stores a run and audits it when it changed anything.
+func (s *RuleService) record(rule *database.Rule, run *database.RuleRun) error {
+	if err := database.InsertRuleRun(s.app.GetOrchestratorDB(), run); err != nil {
+		return err
+	}
+	if run.DryRun || run.Applied+run.Failed == 0 {
+		return nil
+	}
+	if l := s.app.GetAuditLogger(); l != nil {
+		l.Log(constants.AuditActionRuleExecuted, "", "", audit.RuleExecutedDetails{
+			RuleID:  rule.ID,
+			Name:    rule.Name,
+			RunID:   run.ID,
+			Trigger: run.Trigger,
+			Action:  rule.Action.Type,
+			Matched: run.Matched,
+			Applied: run.Applied,
+			Failed:  run.Failed,
+		})
+	}
+	return nil
+}
+
+// execute matches a rule's assets and applies its action to them. Failures
+// are reported in the returned run rather than as an error.
+func (s *RuleService) execute(rule *database.Rule, trigger string, dryRun bool, now time.Time) *database.RuleRun {
+	run := &database.RuleRun{
+		RuleID:    rule.ID,
+		Trigger:   trigger,
+		DryRun:    dryRun,
+		StartedAt: now.Unix(),
+		Assets:    []string{},
+	}
+	started := time.Now()
+	defer func() { run.DurationMs = time.Since(started).Milliseconds() }()
+
+	matches, err := s.match(rule, dryRun)
+	if err != nil {
+		run.Error = err.Error()
+		s.logger.Warn("Rules: rule id=%d name=%s failed to match assets: %v", rule.ID, rule.Name, err)
+		return run
+	}
+	run.Matched = len(matches)
+	for _, m := range matches[:min(len(matches), constants.RuleRunSampleSize)] {
+		run.Assets = append(run.Assets, m.hash)
+	}
+	if len(matches) == 0 {
+		return run
+	}
+
+	s.apply(rule, matches, run)
+	if !dryRun && run.Applied+run.Failed > 0 {
+		s.logger.Info("Rules: rule id=%d name=%s matched %d asset(s): %d applied, %d skipped, %d failed",
+			rule.ID, rule.Name, run.Matched, run.Applied, run.Skipped, run.Failed)
+	}
+	return run
+}
+
+// ============================================================================
+// Matching
+// ============================================================================
+
+// match returns the assets the rule's trigger and condition select.
+func (s *RuleService) match(rule *database.Rule, dryRun bool) ([]ruleMatch, error) {
+	var candidates []ruleMatch
+	var err error
+	if rule.Trigger.Type == constants.RuleTriggerEvent {
+		candidates, err = s.eventCandidates(rule, dryRun)
+	} else {
+		candidates, err = s.presetCandidates(rule.Condition)
+	}
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+	return s.filterMetadata(candidates, rule.Condition.Metadata)
+}
+
+// presetCandidates runs the condition's preset over its topics.
+// Quarantined assets are never returned.
+func (s *RuleService) presetCandidates(cond database.RuleCondition) ([]ruleMatch, error) {
+	resolved, err := s.bulk.ResolveAssets(&BulkResolveRequest{
+		Mode:           "query",
+		Preset:         cond.Preset,
+		Params:         cond.Params,
+		Topics:         cond.Topics,
+		FilenameFormat: constants.DefaultFilenameFormat,
+	})
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]ruleMatch, 0, len(resolved))
+	for _, r := range resolved {
+		matches = append(matches, ruleMatch{hash: r.Hash, topic: r.Topic, db: r.TopicDB})
+	}
+	return matches, nil
+}
+
+// eventCandidates returns the assets named by the audit entries the rule has
+// not seen yet, moving its cursor past them; for a dry run, by the newest
+// entries it selects. Assets no longer indexed are dropped, and so are
+// those outside the condition's topics or preset.
+func (s *RuleService) eventCandidates(rule *database.Rule, dryRun bool) ([]ruleMatch, error) {
+	db := s.app.GetOrchestratorDB()
+
+	var entries []database.WebhookAuditEntry
+	var err error
+	if dryRun {
+		entries, err = database.ListRecentAuditEntries(db, rule.Trigger.Events, constants.RuleMaxEventBatch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit entries: %w", err)
+		}
+	} else {
+		var head int64
+		entries, head, err = database.ListWebhookAuditEntries(db, rule.LastAuditID, rule.Trigger.Events, constants.RuleMaxEventBatch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit entries: %w", err)
+		}
+		// Entries the rule does not select are skipped up to the head
+		cursor := head
+		if len(entries) == constants.RuleMaxEventBatch {
+			cursor = entries[len(entries)-1].ID
+		}
+		if cursor <= rule.LastAuditID {
+			return nil, nil
+		}
+		advanced, err := database.AdvanceRuleCursor(db, rule.ID, rule.LastAuditID, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance cursor: %w", err)
+		}
+		if !advanced {
+			return nil, nil // updated or deleted meanwhile
+		}
+	}
+
+	topics := make(map[string]bool, len(rule.Condition.Topics))
+	for _, t := range rule.Condition.Topics {
+		topics[t] = true
+	}
+	var inPreset map[string]bool
+	if rule.Condition.Preset != "" {
+		presetMatches, err := s.presetCandidates(rule.Condition)
+		if err != nil {
+			return nil, err
+		}
+		inPreset = make(map[string]bool, len(presetMatches))
+		for _, m := range presetMatches {
+			inPreset[m.hash] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var matches []ruleMatch
+	for _, e := range entries {
+		var details struct {
+			Hash string `json:"hash"`
+		}
+		if len(e.Details) == 0 || json.Unmarshal(e.Details, &details) != nil || details.Hash == "" || seen[details.Hash] {
+			continue
+		}
+		seen[details.Hash] = true
+		if inPreset != nil && !inPreset[details.Hash] {
+			continue
+		}
+
+		exists, topic, _, err := s.app.GetAssetIndex().Lookup(details.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up asset %s: %w", details.Hash, err)
+		}
+		if !exists || (len(topics) > 0 && !topics[topic]) {
+			continue
+		}
+		if healthy, _ := s.app.IsTopicHealthy(topic); !healthy {
+			continue
+		}
+		topicDB, err := s.app.GetTopicDB(topic)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, ruleMatch{hash: details.Hash, topic: topic, db: topicDB})
+	}
+	return matches, nil
+}
+
+// filterMetadata keeps the candidates whose current metadata has every
+// wanted value.
+func (s *RuleService) filterMetadata(candidates []ruleMatch, want map[string]string) ([]ruleMatch, error) {
+	if len(want) == 0 {
+		return candidates, nil
+	}
+	matches := make([]ruleMatch, 0, len(candidates))
+	for _, c := range candidates {
+		computed, err := database.GetMetadataComputed(c.db, c.hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metadata of %s: %w", c.hash, err)
+		}
+		if metadataMatches(computed, want) {
+			matches = append(matches, c)
+		}
+	}
+	return matches, nil
+}
+
+func metadataMatches(computed map[string]interface{}, want map[string]string) bool {
+	for key, value := range want {
+		got, ok := computed[key]
+		if !ok || fmt.Sprint(got) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================================
+// Actions
+// ============================================================================
+
+// apply performs the rule's action on the matched assets, counting the
+// outcome in run. Assets already in the target state are skipped, and at
+// most constants.RuleMaxAssetsPerRun are acted on; later runs pick up the
+// rest. A dry run counts what would be applied.
+func (s *RuleService) apply(rule *database.Rule, matches []ruleMatch, run *database.RuleRun) {
+	if rule.Action.Type == constants.RuleActionNotify {
+		s.notify(rule, matches[:min(len(matches), constants.RuleMaxAssetsPerRun)], run)
+		return
+	}
+
+	db := s.app.GetOrchestratorDB()
+	touched := make(map[string]bool)
+	changes := make(map[string][]MetadataChange)
+	for _, m := range matches {
+		if run.Applied+run.Failed >= constants.RuleMaxAssetsPerRun {
+			break
+		}
+
+		var skip bool
+		var err error
+		switch rule.Action.Type {
+		case constants.RuleActionTag:
+			computed, lookupErr := database.GetMetadataComputed(m.db, m.hash)
+			if lookupErr != nil {
+				err = lookupErr
+				break
+			}
+			skip = metadataMatches(computed, map[string]string{rule.Action.Key: rule.Action.Value})
+			if !skip && !run.DryRun {
+				if err = s.tag(rule, m); err == nil {
+					changes[m.topic] = append(changes[m.topic], MetadataChange{AssetID: m.hash, Key: rule.Action.Key})
+				}
+			}
+		case constants.RuleActionTombstone:
+			if !run.DryRun {
+				_, err = s.assets.Delete(m.hash, "", constants.AuditActorSystem)
+			}
+		case constants.RuleActionQuarantine:
+			active, lookupErr := database.GetActiveQuarantine(db, m.hash)
+			if lookupErr != nil {
+				err = lookupErr
+				break
+			}
+			skip = active != nil
+			if !skip && !run.DryRun {
+				_, err = s.quarantine.Quarantine(m.hash, constants.QuarantineSourceRule,
+					rule.Action.Reason, "", constants.AuditActorSystem)
+			}
+		}
+
+		switch {
+		case err != nil:
+			run.Failed++
+			s.logger.Warn("Rules: rule id=%d name=%s failed to %s %s: %v", rule.ID, rule.Name, rule.Action.Type, m.hash, err)
+		case skip:
+			run.Skipped++
+		default:
+			run.Applied++
+			touched[m.topic] = true
+		}
+	}
+
+	if run.DryRun {
+		return
+	}
+	if s.stats != nil && len(touched) > 0 {
+		s.stats.InvalidateTopics(sortedKeys(touched))
+	}
+	if s.notifications != nil {
+		for topic, topicChanges := range changes {
+			s.notifications.MetadataChanged(topic, topicChanges, 0, constants.AuditActorSystem)
+		}
+	}
+}
+
+// tag sets the rule's metadata value on an asset, audited as the system
+// actor.
+func (s *RuleService) tag(rule *database.Rule, m ruleMatch) error {
+	if _, err := s.metadata.Set(m.hash, &MetadataSetRequest{
+		Op:               constants.BatchMetadataOpSet,
+		Key:              rule.Action.Key,
+		Value:            rule.Action.Value,
+		Processor:        constants.ProcessorRules,
+		ProcessorVersion: constants.ProcessorRulesVersion,
+	}); err != nil {
+		return err
+	}
+	if l := s.app.GetAuditLogger(); l != nil {
+		l.Log(constants.AuditActionMetadataSet, "", "", audit.MetadataSetDetails{
+			Hash: m.hash,
+			Op:   constants.BatchMetadataOpSet,
+			Key:  rule.Action.Key,
+		})
+	}
+	return nil
+}
+
+// notify sends each of the rule's users one notification listing the
+// matched assets they can read. Users who can read none are skipped.
+func (s *RuleService) notify(rule *database.Rule, matches []ruleMatch, run *database.RuleRun) {
+	if run.DryRun {
+		run.Applied = len(matches)
+		return
+	}
+	if s.notifications == nil || s.auth == nil {
+		run.Failed = len(matches)
+		return
+	}
+
+	notified := false
+	for _, username := range rule.Action.Users {
+		user, err := s.auth.GetStore().GetUserByUsername(username)
+		if err != nil {
+			s.logger.Warn("Rules: rule id=%d name=%s cannot notify unknown user %s", rule.ID, rule.Name, username)
+			continue
+		}
+		readable := make(map[string]bool)
+		var assetIDs []string
+		for _, m := range matches {
+			if _, checked := readable[m.topic]; !checked {
+				readable[m.topic] = s.notifications.canRead(user.ID, m.topic)
+			}
+			if readable[m.topic] {
+				assetIDs = append(assetIDs, m.hash)
+			}
+		}
+		if len(assetIDs) == 0 {
+			continue
+		}
+		s.notifications.Notify(user.ID, constants.NotificationEventRuleMatched, constants.AuditActorSystem, map[string]interface{}{
+			"rule_id":     rule.ID,
+			"rule":        rule.Name,
+			"asset_count": len(assetIDs),
+			"asset_ids":   assetIDs[:min(len(assetIDs), constants.NotificationMaxAssetIDs)],
+		})
+		notified = true
+	}
+	if notified {
+		run.Applied = len(matches)
+	} else {
+		run.Skipped = len(matches)
+	}
+}
+
+// purgeRuns deletes runs past retention.
+func (s *RuleService) purgeRuns(now time.Time) {
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return
+	}
+	removed, err := database.DeleteRuleRunsBefore(db, now.Add(-constants.RuleRunRetention).Unix())
+	if err != nil {
+		s.logger.Error("Rules: retention cleanup failed: %v", err)
+		return
+	}
+	if removed > 0 {
+		s.logger.Info("Rules: retention cleanup removed %d run(s)", removed)
+	}
+}
+
+// ============================================================================
+// Validation
+// ============================================================================
+
+// applyRuleRequest applies the set fields of req, then validates the
+// resulting rule and checks its author is allowed its action.
+func (s *RuleService) applyRuleRequest(rule *database.Rule, req *RuleRequest, allowed func(*auth.ActionContext) bool) error {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > constants.RuleMaxNameLength {
+			return NewServiceError(constants.ErrCodeInvalidRule,
+				fmt.Sprintf("name must be 1-%d characters", constants.RuleMaxNameLength))
+		}
+		rule.Name = name
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.Trigger != nil {
+		rule.Trigger = *req.Trigger
+	}
+	if req.Condition != nil {
+		rule.Condition = *req.Condition
+	}
+	if req.Action != nil {
+		rule.Action = *req.Action
+	}
+
+	if err := s.validateTrigger(&rule.Trigger, rule.Condition); err != nil {
+		return err
+	}
+	if err := s.validateCondition(&rule.Condition); err != nil {
+		return err
+	}
+	if err := s.validateAction(&rule.Action, rule.Condition); err != nil {
+		return err
+	}
+	return checkRuleAllowed(rule, allowed)
+}
+
+func (s *RuleService) validateTrigger(trigger *database.RuleTrigger, cond database.RuleCondition) error {
+	switch trigger.Type {
+	case constants.RuleTriggerSchedule:
+		if trigger.IntervalSecs < constants.RuleMinIntervalSecs || trigger.IntervalSecs > constants.RuleMaxIntervalSecs {
+			return NewServiceError(constants.ErrCodeInvalidRule,
+				fmt.Sprintf("trigger.interval_secs must be %d-%d", constants.RuleMinIntervalSecs, constants.RuleMaxIntervalSecs))
+		}
+		if len(trigger.Events) > 0 {
+			return NewServiceError(constants.ErrCodeInvalidRule, "trigger.events only applies to event triggers")
+		}
+		if cond.Preset == "" {
+			return NewServiceError(constants.ErrCodeInvalidRule, "schedule rules require condition.preset")
+		}
+	case constants.RuleTriggerEvent:
+		if trigger.IntervalSecs != 0 {
+			return NewServiceError(constants.ErrCodeInvalidRule, "trigger.interval_secs only applies to schedule triggers")
+		}
+		if len(trigger.Events) == 0 {
+			return NewServiceError(constants.ErrCodeInvalidRule, "event rules require trigger.events")
+		}
+		seen := make(map[string]bool)
+		for _, e := range trigger.Events {
+			if !containsKey(constants.RuleEventActions, e) {
+				return NewServiceError(constants.ErrCodeInvalidRule,
+					fmt.Sprintf("unsupported event %q: must be one of %s", e, strings.Join(constants.RuleEventActions, ", ")))
+			}
+			seen[e] = true
+		}
+		trigger.Events = sortedKeys(seen)
+	default:
+		return NewServiceError(constants.ErrCodeInvalidRule, "trigger.type must be schedule or event")
+	}
+	return nil
+}
+
+func (s *RuleService) validateCondition(cond *database.RuleCondition) error {
+	if cond.Preset != "" {
+		qc := s.app.GetQueriesConfig()
+		if qc == nil {
+			return NewServiceError(constants.ErrCodeNotConfigured, "queries config not loaded")
+		}
+		preset, err := qc.GetPreset(cond.Preset)
+		if err != nil {
+			return NewServiceError(constants.ErrCodeInvalidRule, fmt.Sprintf("preset not found: %s", cond.Preset))
+		}
+		if _, err := queries.ValidateParams(preset, queries.ParamsToStrings(cond.Params)); err != nil {
+			return NewServiceError(constants.ErrCodeInvalidRule, fmt.Sprintf("condition.params: %v", err))
+		}
+	} else if len(cond.Params) > 0 {
+		return NewServiceError(constants.ErrCodeInvalidRule, "condition.params requires condition.preset")
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range cond.Topics {
+		if !s.app.TopicExists(t) {
+			return NewServiceError(constants.ErrCodeInvalidRule, fmt.Sprintf("topic not found: %s", t))
+		}
+		seen[t] = true
+	}
+	cond.Topics = sortedKeys(seen)
+	if len(cond.Topics) == 0 {
+		cond.Topics = nil
+	}
+
+	if len(cond.Metadata) > constants.RuleMaxMetadataKeys {
+		return NewServiceError(constants.ErrCodeInvalidRule,
+			fmt.Sprintf("condition.metadata may list at most %d keys", constants.RuleMaxMetadataKeys))
+	}
+	for key := range cond.Metadata {
+		if key == "" || len(key) > constants.MaxMetadataKeyLength {
+			return NewServiceError(constants.ErrCodeInvalidRule,
+				fmt.Sprintf("condition.metadata keys must be 1-%d characters", constants.MaxMetadataKeyLength))
+		}
+	}
+	return nil
+}
+
+func (s *RuleService) validateAction(action *database.RuleAction, cond database.RuleCondition) error {
+	switch action.Type {
+	case constants.RuleActionTag:
+		if action.Key == "" || len(action.Key) > constants.MaxMetadataKeyLength {
+			return NewServiceError(constants.ErrCodeInvalidRule,
+				fmt.Sprintf("action.key must be 1-%d characters", constants.MaxMetadataKeyLength))
+		}
+		if action.Value == "" || len(action.Value) > s.app.GetConfig().Metadata.MaxValueBytes {
+			return NewServiceError(constants.ErrCodeInvalidRule,
+				fmt.Sprintf("action.value must be 1-%d bytes", s.app.GetConfig().Metadata.MaxValueBytes))
+		}
+	case constants.RuleActionNotify:
+		if len(action.Users) == 0 || len(action.Users) > constants.RuleMaxNotifyUsers {
+			return NewServiceError(constants.ErrCodeInvalidRule,
+				fmt.Sprintf("action.users must list 1-%d users", constants.RuleMaxNotifyUsers))
+		}
+		if s.auth == nil {
+			return NewServiceError(constants.ErrCodeNotConfigured, "authentication is not configured")
+		}
+		seen := make(map[string]bool)
+		for _, username := range action.Users {
+			if _, err := s.auth.GetStore().GetUserByUsername(username); err != nil {
+				return NewServiceError(constants.ErrCodeInvalidRule, fmt.Sprintf("user not found: %s", username))
+			}
+			seen[username] = true
+		}
+		action.Users = sortedKeys(seen)
+	case constants.RuleActionTombstone:
+	case constants.RuleActionQuarantine:
+		action.Reason = strings.TrimSpace(action.Reason)
+		if len(action.Reason) > constants.QuarantineMaxReasonLength {
+			return NewServiceError(constants.ErrCodeInvalidRule,
+				fmt.Sprintf("action.reason must be at most %d characters", constants.QuarantineMaxReasonLength))
+		}
+	default:
+		return NewServiceError(constants.ErrCodeInvalidRule,
+			"action.type must be tag, notify, tombstone or quarantine")
+	}
+
+	if action.Type != constants.RuleActionTag && (action.Key != "" || action.Value != "") {
+		return NewServiceError(constants.ErrCodeInvalidRule, "action.key and action.value only apply to tag actions")
+	}
+	if action.Type != constants.RuleActionNotify && len(action.Users) > 0 {
+		return NewServiceError(constants.ErrCodeInvalidRule, "action.users only applies to notify actions")
+	}
+	if action.Type != constants.RuleActionQuarantine && action.Reason != "" {
+		return NewServiceError(constants.ErrCodeInvalidRule, "action.reason only applies to quarantine actions")
+	}
+	if action.Type != constants.RuleActionNotify && len(cond.Topics) == 0 {
+		return NewServiceError(constants.ErrCodeInvalidRule,
+			fmt.Sprintf("%s rules require condition.topics", action.Type))
+	}
+	return nil
+}
+
+// checkRuleAllowed reports a forbidden error unless the author may perform
+// the rule's action by hand on each of its topics. Notify rules only read,
+// and what each user is told is limited to the topics they can query.
+func checkRuleAllowed(rule *database.Rule, allowed func(*auth.ActionContext) bool) error {
+	var ctx auth.ActionContext
+	switch rule.Action.Type {
+	case constants.RuleActionTag:
+		ctx = auth.ActionContext{Action: constants.AuthActionMetadata, MetadataKeys: []string{rule.Action.Key}}
+	case constants.RuleActionTombstone:
+		ctx = auth.ActionContext{Action: constants.AuthActionManageTopics, SubAction: "delete"}
+	case constants.RuleActionQuarantine:
+		ctx = auth.ActionContext{Action: constants.AuthActionVerify}
+	default:
+		return nil
+	}
+	for _, topic := range rule.Condition.Topics {
+		ctx.TopicName = topic
+		if !allowed(&ctx) {
+			return NewServiceError(constants.ErrCodeAuthForbidden,
+				fmt.Sprintf("you are not allowed to %s assets of topic %s", rule.Action.Type, topic))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+)
+
+func TestMetadataMatches(t *testing.T) {
+	computed := map[string]interface{}{"status": "final", "frames": float64(24), "approved": true}
+
+	cases := []struct {
+		want  map[string]string
+		match bool
+	}{
+		{map[string]string{"status": "final"}, true},
+		{map[string]string{"status": "final", "frames": "24", "approved": "true"}, true},
+		{map[string]string{"status": "draft"}, false},
+		{map[string]string{"missing": ""}, false},
+		{nil, true},
+	}
+	for _, c := range cases {
+		if got := metadataMatches(computed, c.want); got != c.match {
+			t.Errorf("metadataMatches(%v) = %t, want %t", c.want, got, c.match)
+		}
+	}
+}
+
+func TestRuleService_ValidateTrigger(t *testing.T) {
+	svc := &RuleService{}
+	withPreset := database.RuleCondition{Preset: "recent-imports"}
+
+	invalid := []struct {
+		trigger database.RuleTrigger
+		cond    database.RuleCondition
+	}{
+		{database.RuleTrigger{Type: "cron"}, withPreset},
+		{database.RuleTrigger{Type: constants.RuleTriggerSchedule, IntervalSecs: constants.RuleMinIntervalSecs - 1}, withPreset},
+		{database.RuleTrigger{Type: constants.RuleTriggerSchedule, IntervalSecs: constants.RuleMinIntervalSecs}, database.RuleCondition{}},
+		{database.RuleTrigger{Type: constants.RuleTriggerSchedule, IntervalSecs: constants.RuleMinIntervalSecs, Events: []string{constants.AuditActionAddingFile}}, withPreset},
+		{database.RuleTrigger{Type: constants.RuleTriggerEvent}, withPreset},
+		{database.RuleTrigger{Type: constants.RuleTriggerEvent, Events: []string{constants.AuditActionAddingTopic}}, withPreset},
+		{database.RuleTrigger{Type: constants.RuleTriggerEvent, IntervalSecs: 60, Events: []string{constants.AuditActionAddingFile}}, withPreset},
+	}
+	for _, c := range invalid {
+		trigger := c.trigger
+		if err := svc.validateTrigger(&trigger, c.cond); !isServiceErrorCode(err, constants.ErrCodeInvalidRule) {
+			t.Errorf("validateTrigger(%+v) = %v, want %s", c.trigger, err, constants.ErrCodeInvalidRule)
+		}
+	}
+
+	trigger := database.RuleTrigger{Type: constants.RuleTriggerEvent, Events: []string{
+		constants.AuditActionMetadataSet, constants.AuditActionAddingFile, constants.AuditActionMetadataSet,
+	}}
+	if err := svc.validateTrigger(&trigger, database.RuleCondition{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{constants.AuditActionAddingFile, constants.AuditActionMetadataSet}; !reflect.DeepEqual(trigger.Events, want) {
+		t.Errorf("expected sorted, deduplicated events %v, got %v", want, trigger.Events)
+	}
+}
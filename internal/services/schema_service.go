@@ -68,6 +68,9 @@ func (s *SchemaService) GetAPISchema() *APISchema {
 
 // ListPrompts returns all prompts with full templates.
 func (s *SchemaService) ListPrompts() ([]prompts.RenderedPrompt, error) {
+	if !s.app.GetConfig().Features.Enabled(constants.FeaturePrompts) {
+		return nil, NewServiceError(constants.ErrCodeFeatureDisabled, "prompts are disabled by features.disabled")
+	}
 	pm := s.app.GetPromptsManager()
 	if pm == nil {
 		return nil, NewServiceError(constants.ErrCodeNotConfigured, "prompts not available - working directory not configured")
@@ -77,6 +80,9 @@ func (s *SchemaService) ListPrompts() ([]prompts.RenderedPrompt, error) {
 
 // GetPrompt returns a specific prompt by name.
 func (s *SchemaService) GetPrompt(name string) (*prompts.RenderedPrompt, error) {
+	if !s.app.GetConfig().Features.Enabled(constants.FeaturePrompts) {
+		return nil, NewServiceError(constants.ErrCodeFeatureDisabled, "prompts are disabled by features.disabled")
+	}
 	pm := s.app.GetPromptsManager()
 	if pm == nil {
 		return nil, NewServiceError(constants.ErrCodeNotConfigured, "prompts not available - working directory not configured")
@@ -127,206 +133,2788 @@ func buildAPISchema() *APISchema {
 					},
 				},
 			},
-
-			// Topics
+			{
+				Method:      "POST",
+				Path:        "/api/config/validate",
+				Description: "Dry-run a candidate configuration: value ranges, working directory writability, disk space and port availability. Nothing is applied",
+				Category:    "config",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"working_directory": "string (optional, defaults to current)",
+						"port":              "number (optional, change requires restart)",
+						"max_dat_size":      "number (optional, change requires restart)",
+						"max_disk_usage":    "number (optional, change requires restart)",
+						"auth":              "object (optional, same shape as GET /api/config)",
+						"bulk_download":     "object (optional)",
+						"audit":             "object (optional)",
+						"metadata":          "object (optional)",
+						"batch":             "object (optional)",
+						"query":             "object (optional)",
+						"monitoring":        "object (optional)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"valid":            "boolean",
+						"requires_restart": "boolean",
+						"restart_fields":   "[]string",
+						"checks":           "[]{name, field, status: ok|warning|error|skipped, message}",
+					},
+				},
+			},
 			{
 				Method:      "GET",
-				Path:        "/api/topics",
-				Description: "List all topics with stats and service info",
-				Category:    "topics",
+				Path:        "/api/config/history",
+				Description: "Configuration as it was at a point in time, rebuilt from config_changed audit entries, with the changes leading up to it (requires manage_config). Credentials are shown as [redacted]",
+				Category:    "config",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "at", Type: "integer", Description: "Unix timestamp to rebuild the configuration at (default now)"},
+						{Name: "limit", Type: "integer", Description: "Changes to list (default 50, max 500)"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"at":      "number",
+						"config":  "object (dotted key -> value)",
+						"changes": "[]{id, timestamp, ip_address, username, details: {working_directory, fields, source: api|bootstrap|cli, changes: [{key, before, after}], warnings}} (newest first)",
+						"partial": "boolean (entries written before changes were recorded are part of the history)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/setup/status",
+				Description: "First-run setup steps in order (working_directory, credentials, first_topic, optional tls) and which remain. Unauthenticated",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"complete":  "boolean (every required step is complete)",
+						"next_step": "string (first step neither complete nor skipped, omitted when none)",
+						"steps":     "[]{name, required, status: complete|incomplete|skipped, detail}",
+					},
+				},
 			},
 			{
 				Method:      "POST",
-				Path:        "/api/topics",
-				Description: "Create a new topic",
-				Category:    "topics",
+				Path:        "/api/setup/working-directory",
+				Description: "Check and set the working directory. Needs no credentials until one is set, then manage_config. Returns the bootstrap admin credentials of a new instance once",
+				Category:    "config",
 				Request: &RequestSpec{
 					ContentType: "application/json",
 					Body: map[string]interface{}{
-						"name": "string (required, lowercase alphanumeric with - and _)",
+						"working_directory": "string (required)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"bootstrap": "{username, password, api_key} (first setup only)",
+						"checks":    "[]{name, field, status, message}",
+						"setup":     "object (same shape as GET /api/setup/status)",
 					},
 				},
 			},
 			{
 				Method:      "POST",
-				Path:        "/api/topics/:name/assets",
-				Description: "Upload an asset to a topic",
-				Category:    "topics",
+				Path:        "/api/setup/credentials",
+				Description: "Confirm the bootstrap admin credentials were saved (requires manage_config)",
+				Category:    "config",
 				Request: &RequestSpec{
-					ContentType: "multipart/form-data",
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"saved": "boolean (required, must be true)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/setup/topic",
+				Description: "Create the first topic, as POST /api/topics (requires manage_topics)",
+				Category:    "config",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"name": "string (required)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/setup/tls",
+				Description: "Save TLS settings to config.yaml, applied at the next restart, or skip the optional step (requires manage_config). Certificate files must load as a pair",
+				Category:    "config",
+				Request: &RequestSpec{
+					ContentType: "application/json",
 					Body: map[string]interface{}{
-						"file":      "file (required)",
-						"parent_id": "string (optional, 64-char hash)",
+						"self_signed":        "boolean (generate a certificate for localhost)",
+						"cert_file":          "string (with key_file, instead of self_signed)",
+						"key_file":           "string",
+						"redirect_http_port": "number (optional)",
+						"skip":               "boolean (decline the step instead)",
 					},
 				},
 				Response: &ResponseSpec{
 					ContentType: "application/json",
 					Body: map[string]interface{}{
-						"success":        "boolean",
-						"hash":           "string (64-char BLAKE3 hash)",
-						"skipped":        "boolean (true if duplicate)",
-						"existing_topic": "string (if skipped)",
+						"requires_restart": "boolean",
+						"setup":            "object (same shape as GET /api/setup/status)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/limits",
+				Description: "Effective request and result limits, so clients can size queries, batches and metadata values. Available to any authenticated user",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"query_max_rows":                   "number (rows per query; larger results are truncated)",
+						"federated_query_max_rows":         "number",
+						"batch_max_operations":             "number",
+						"metadata_max_value_bytes":         "number",
+						"bulk_download_max_assets":         "number",
+						"max_dat_size":                     "number (read-only)",
+						"metadata_max_key_length":          "number (read-only)",
+						"collection_max_assets_per_change": "number (read-only)",
+						"query_max_rows_ceiling":           "number (read-only)",
+					},
+				},
+			},
+			{
+				Method:      "PUT",
+				Path:        "/api/limits",
+				Description: "Change limits at runtime without a restart; values are checked against sanity bounds and saved to config.yaml (requires manage_config)",
+				Category:    "config",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"query_max_rows":           "number (optional)",
+						"federated_query_max_rows": "number (optional)",
+						"batch_max_operations":     "number (optional)",
+						"metadata_max_value_bytes": "number (optional)",
+						"bulk_download_max_assets": "number (optional)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"limits":  "object (same shape as GET /api/limits)",
+						"changed": "[]string (config fields updated)",
 					},
 				},
 			},
 
-			// Assets
+			// Storage Policies
 			{
 				Method:      "GET",
-				Path:        "/api/assets/:hash/download",
-				Description: "Download an asset by hash",
-				Category:    "assets",
+				Path:        "/api/storage-policies",
+				Description: "Per-extension storage policies: compression of downloads, image previews, upload scanning and a max size override. Available to any authenticated user",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"defaults":     "object (effective policy of extensions without one)",
+						"policies":     "object (extension or * → {compression, preview, scan, max_size_bytes}; unset fields fall back to *, then the defaults)",
+						"scan_enabled": "boolean (scan.command is configured)",
+					},
+				},
 			},
 			{
 				Method:      "GET",
-				Path:        "/api/assets/:hash/metadata",
-				Description: "Get asset info and computed metadata",
-				Category:    "metadata",
+				Path:        "/api/storage-policies/:ext",
+				Description: "Effective policy applied to files with an extension",
+				Category:    "config",
 				Response: &ResponseSpec{
 					ContentType: "application/json",
 					Body: map[string]interface{}{
-						"asset": map[string]interface{}{
-							"origin_name": "string",
-							"extension":   "string",
-							"created_at":  "number (unix timestamp)",
-							"parent_id":   "string|null",
-						},
-						"computed_metadata": "object (key-value pairs)",
+						"extension":      "string",
+						"compression":    "boolean (downloads are gzip-encoded for clients that accept it; ZIP entries are deflated)",
+						"preview":        "boolean (GET /api/assets/:hash/preview is served for PNG, JPEG, GLB and OBJ)",
+						"scan":           "boolean (uploads are run through scan.command before they are stored)",
+						"max_size_bytes": "number (largest upload accepted, capped by max_dat_size)",
 					},
 				},
 			},
 			{
-				Method:      "POST",
-				Path:        "/api/assets/:hash/metadata",
-				Description: "Set or delete metadata for an asset",
-				Category:    "metadata",
+				Method:      "PUT",
+				Path:        "/api/storage-policies/:ext",
+				Description: "Set the policy of an extension, or * for every extension without one, and save it to config.yaml (requires manage_config). Enabling scan requires scan.command",
+				Category:    "config",
 				Request: &RequestSpec{
 					ContentType: "application/json",
 					Body: map[string]interface{}{
-						"op":                "string (required: 'set' or 'delete')",
-						"key":               "string (required)",
-						"value":             "any (required for 'set')",
-						"processor":         "string (optional, default: 'api')",
-						"processor_version": "string (optional, default: '1.0')",
+						"compression":    "boolean (optional)",
+						"preview":        "boolean (optional)",
+						"scan":           "boolean (optional)",
+						"max_size_bytes": "number (optional, 0 = max_dat_size)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"policy": "object (same shape as GET /api/storage-policies/:ext)",
 					},
 				},
 			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/storage-policies/:ext",
+				Description: "Remove the policy of an extension (requires manage_config)",
+				Category:    "config",
+			},
 
-			// Batch Metadata
+			// Watch Folders
 			{
-				Method:      "POST",
-				Path:        "/api/metadata/batch",
-				Description: "Set or delete metadata on multiple assets atomically",
-				Category:    "metadata",
+				Method:      "GET",
+				Path:        "/api/watch-folders",
+				Description: "Server-side folders whose files are ingested into a topic, with counters since the server started (requires manage_config)",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"folders": "[{name, path, topic, after_ingest, settle_secs, paused, stats: {ingested, deduplicated, ingested_bytes, failed, pending, quarantined, last_scan_at, last_ingest_at, last_error, watching}}]",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/watch-folders/:name",
+				Description: "A watch folder with its counters and the files moved to its failed/ folder with the reason (requires manage_config)",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"failures": "[{path, size, error, failed_at}] (besides the fields listed by GET /api/watch-folders)",
+					},
+				},
+			},
+			{
+				Method:      "PUT",
+				Path:        "/api/watch-folders/:name",
+				Description: "Create or replace a watch folder and save it to config.yaml (requires manage_config). The path must be an existing directory outside the working directory and other watch folders and, with symlinks resolved, under one of the watch_folder_roots set in config.yaml, which the API cannot change. Files are ingested once unmodified for settle_secs, then moved to processed/ or deleted; files that cannot be stored are moved to failed/ with a .error note",
+				Category:    "config",
 				Request: &RequestSpec{
 					ContentType: "application/json",
 					Body: map[string]interface{}{
-						"operations": []map[string]interface{}{
-							{
-								"hash":  "string (64-char hash)",
-								"op":    "string ('set' or 'delete')",
-								"key":   "string",
-								"value": "any (for 'set')",
-							},
-						},
-						"processor":         "string (optional)",
-						"processor_version": "string (optional)",
+						"path":         "string (required, absolute)",
+						"topic":        "string (required)",
+						"after_ingest": "string (optional, move or delete; default move)",
+						"settle_secs":  "integer (optional, 0-3600; 0 = 10)",
+						"paused":       "boolean (optional, skip the periodic scan)",
 					},
 				},
 				Response: &ResponseSpec{
 					ContentType: "application/json",
 					Body: map[string]interface{}{
-						"success":   "boolean",
-						"total":     "number",
-						"succeeded": "number",
-						"failed":    "number",
-						"results": []map[string]interface{}{
-							{
-								"hash":    "string",
-								"success": "boolean",
-								"error":   "string (if failed)",
-								"log_id":  "number (if succeeded)",
-							},
-						},
+						"folder": "object (same shape as an entry of GET /api/watch-folders)",
 					},
 				},
 			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/watch-folders/:name",
+				Description: "Stop watching a folder (requires manage_config). Files in the directory are left in place",
+				Category:    "config",
+			},
 			{
 				Method:      "POST",
-				Path:        "/api/metadata/apply",
-				Description: "Apply metadata to assets matching a query",
-				Category:    "metadata",
-				Request: &RequestSpec{
+				Path:        "/api/watch-folders/:name/scan",
+				Description: "Scan a watch folder now, even when paused, and return it like GET /api/watch-folders/:name (requires manage_config)",
+				Category:    "config",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/watch-folders/:name/retry",
+				Description: "Move the files in a watch folder's failed/ back into the folder for the next scan (requires manage_config)",
+				Category:    "config",
+				Response: &ResponseSpec{
 					ContentType: "application/json",
 					Body: map[string]interface{}{
-						"query_preset":      "string (required, e.g. 'recent-imports')",
-						"query_params":      "object (optional, preset parameters)",
-						"topics":            "array of strings (optional, defaults to all)",
-						"op":                "string (required: 'set' or 'delete')",
-						"key":               "string (required)",
-						"value":             "any (required for 'set')",
-						"processor":         "string (optional)",
-						"processor_version": "string (optional)",
+						"requeued": "integer (files moved back)",
 					},
 				},
 			},
 
-			// Queries
+			// Webhooks
 			{
 				Method:      "GET",
-				Path:        "/api/queries",
-				Description: "List available query presets",
-				Category:    "queries",
+				Path:        "/api/webhooks",
+				Description: "Outgoing webhooks with their delivery counts (requires manage_config). Secrets are never returned after creation",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"webhooks": "[{id, name, url, events, active, created_by, created_at, updated_at, deliveries: {pending, delivered, failed}}]",
+					},
+				},
 			},
 			{
 				Method:      "POST",
-				Path:        "/api/query/:preset",
-				Description: "Execute a query preset",
-				Category:    "queries",
+				Path:        "/api/webhooks",
+				Description: "Create a webhook (requires manage_config). Audit entries logged from then on whose action is in events are POSTed to the url as JSON {webhook_id, event, audit_id, timestamp, username, ip_address, actor_type, details}, one request per entry, oldest first. Each request carries X-SiloBang-Event, X-SiloBang-Delivery (delivery ID), X-SiloBang-Timestamp and X-SiloBang-Signature: sha256= followed by the hex HMAC-SHA256 of \"<timestamp>.<body>\" keyed with the secret. Responses other than 2xx are retried with exponential backoff from 30 seconds up to an hour, and the delivery is given up on after 8 attempts",
+				Category:    "config",
 				Request: &RequestSpec{
 					ContentType: "application/json",
 					Body: map[string]interface{}{
-						"topics": "array of strings (optional)",
-						"params": "object (preset-specific parameters)",
+						"name":   "string (required, up to 100 characters)",
+						"url":    "string (required, absolute http or https URL)",
+						"secret": "string (optional, 16-256 characters; generated when omitted)",
+						"events": "[]string (optional, audit actions such as adding_file, adding_topic, metadata_set, user_created; empty = every action)",
+						"active": "boolean (optional, default true)",
 					},
 				},
 				Response: &ResponseSpec{
 					ContentType: "application/json",
 					Body: map[string]interface{}{
-						"preset":    "string",
-						"row_count": "number",
-						"columns":   "array of strings",
-						"rows":      "array of arrays",
+						"webhook": "object (same shape as an entry of GET /api/webhooks, without deliveries)",
+						"secret":  "string (shown only once)",
 					},
 				},
 			},
-
-			// Bulk Download
 			{
-				Method:      "POST",
-				Path:        "/api/download/bulk",
-				Description: "Download multiple assets as ZIP",
-				Category:    "download",
+				Method:      "GET",
+				Path:        "/api/webhooks/:id",
+				Description: "A webhook with its delivery counts and newest deliveries (requires manage_config)",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"recent_deliveries": "[{id, audit_id, event, attempts, next_attempt_at, last_status, last_error, created_at, delivered_at, failed_at}] (besides the fields listed by GET /api/webhooks)",
+					},
+				},
 			},
 			{
-				Method:      "POST",
-				Path:        "/api/download/bulk/start",
-				Description: "Start SSE bulk download session",
-				Category:    "download",
+				Method:      "PUT",
+				Path:        "/api/webhooks/:id",
+				Description: "Update the fields set in the body, as accepted by POST /api/webhooks (requires manage_config). A webhook set active again skips the entries logged while it was inactive",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"webhook": "object (same shape as an entry of GET /api/webhooks, without deliveries)",
+					},
+				},
 			},
 			{
-				Method:      "GET",
-				Path:        "/api/download/bulk/:sessionID",
-				Description: "Fetch completed bulk download ZIP",
-				Category:    "download",
+				Method:      "DELETE",
+				Path:        "/api/webhooks/:id",
+				Description: "Delete a webhook and its queued deliveries (requires manage_config)",
+				Category:    "config",
 			},
 
-			// Verification
+			// Rules
 			{
 				Method:      "GET",
-				Path:        "/api/verify",
-				Description: "Verify topic integrity (SSE stream)",
-				Category:    "system",
+				Path:        "/api/rules",
+				Description: "Automation rules (requires manage_config). next_run_at is set for enabled schedule rules",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"rules": "[{id, name, enabled, trigger, condition, action, last_run_at, next_run_at, created_by, created_at, updated_at}]",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/rules",
+				Description: "Create a rule (requires manage_config, plus on each condition topic the grant its action needs by hand: metadata for the tag key, manage_topics delete for tombstone, verify for quarantine). Schedule rules run every interval_secs over the assets their preset returns; event rules run every 10 seconds over the assets named by new audit entries of their events. Matched assets are narrowed to the condition's topics and metadata values, then acted on as the system actor: tag sets a metadata value, notify sends the listed users one rule_matched notification per run with the assets they can query, tombstone deletes the asset, quarantine withholds it. Assets already in the target state are skipped, and at most 1000 are acted on per run",
+				Category:    "config",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"name":      "string (required, unique, up to 100 characters)",
+						"enabled":   "boolean (optional, default true)",
+						"trigger":   "{type: schedule|event, interval_secs (schedule: 60 to 2592000), events (event: adding_file, metadata_set, asset_quarantined, asset_released)} (required)",
+						"condition": "{preset (required for schedule rules; must return asset_id), params, topics (required except for notify), metadata: {key: value}} (optional)",
+						"action":    "{type: tag|notify|tombstone|quarantine, key and value (tag), users (notify: up to 20 usernames), reason (quarantine)} (required)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"rule": "object (same shape as an entry of GET /api/rules)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/rules/preview",
+				Description: "Report what an unsaved rule, given as accepted by POST /api/rules, would do now without doing it (requires manage_config). Event rules are previewed over the newest 500 audit entries of their events",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"run": "{trigger, dry_run, started_at, duration_ms, matched, applied, skipped, failed, error, assets (up to 100 matched hashes)}",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/rules/:id",
+				Description: "A rule with its newest runs (requires manage_config). Runs are kept for 30 days",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"recent_runs": "[{id, rule_id, trigger, dry_run, started_at, duration_ms, matched, applied, skipped, failed, error, assets}] (besides the fields listed by GET /api/rules)",
+					},
+				},
+			},
+			{
+				Method:      "PUT",
+				Path:        "/api/rules/:id",
+				Description: "Update the fields set in the body, as accepted by POST /api/rules (requires manage_config). A rule that is enabled again or given a new trigger starts over, skipping what happened before",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"rule": "object (same shape as an entry of GET /api/rules)",
+					},
+				},
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/rules/:id",
+				Description: "Delete a rule and its run history (requires manage_config)",
+				Category:    "config",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/rules/:id/run",
+				Description: "Run a rule now, even when disabled, and record the run with trigger manual (requires manage_config). With dry_run the run only reports what the rule would do",
+				Category:    "config",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"dry_run": "boolean (optional, default false)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"run": "object (same shape as an entry of recent_runs)",
+					},
+				},
+			},
+
+			// Debug
+			{
+				Method:      "GET",
+				Path:        "/api/admin/debug/pprof/:profile",
+				Description: "net/http/pprof under the admin API (requires debug). Without a profile, the index of profiles; otherwise cmdline, profile?seconds=N (CPU), symbol, trace?seconds=N or a named runtime profile such as heap, allocs, goroutine, block, mutex or threadcreate. Every debug route returns 404 DEBUG_DISABLED unless debug.enabled is set",
+				Category:    "config",
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/admin/debug/metrics",
+				Description: "Process info and every Go runtime metric (requires debug). Histogram metrics are summarized by count and approximate p50, p90 and p99",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"info":    "{version, go_version, os, arch, num_cpu, gomaxprocs, goroutines, started_at, uptime_secs}",
+						"metrics": "object (runtime/metrics name -> number or {count, p50, p90, p99})",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/admin/debug/bundle",
+				Description: "Capture a CPU profile for seconds (default 30, up to 120), then download a zip of it with the heap, allocs, goroutine, block, mutex and threadcreate profiles, a full goroutine dump, metrics.json and info.json, for support tickets (requires debug). Only one CPU profile runs at a time: 409 PROFILE_IN_PROGRESS otherwise",
+				Category:    "config",
+				Response: &ResponseSpec{
+					ContentType: "application/zip",
+				},
+			},
+
+			// Topics
+			{
+				Method:      "GET",
+				Path:        "/api/topics",
+				Description: "List all topics with stats and service info. Each healthy topic lists the effective storage policy of every extension it stores",
+				Category:    "topics",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/topics",
+				Description: "Create a new topic. The name is reserved while the folder and database are created: a concurrent creation of the same name returns 409 TOPIC_CREATION_IN_PROGRESS, an existing topic or foreign folder 409 TOPIC_ALREADY_EXISTS. A failed or interrupted creation leaves no topic behind and can be retried",
+				Category:    "topics",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"name": "string (required, lowercase alphanumeric with - and _)",
+					},
+				},
+			},
+			{
+				Method:      "PATCH",
+				Path:        "/api/topics/:name",
+				Description: "Set the quota of a topic and save it to config.yaml (requires manage_config). Uploads of new content past a hard limit are refused with 413 TOPIC_QUOTA_EXCEEDED; passing a soft limit is only reported. Quota usage is listed in the topic's stats as quota",
+				Category:    "topics",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"quota": "object ({soft_bytes, hard_bytes, soft_assets, hard_assets}; omitted limits are kept, 0 removes one)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic": "string",
+						"quota": "object ({quota, bytes, assets, bytes_percent, assets_percent, soft_exceeded, hard_reached})",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/topics/:name/assets",
+				Description: "Upload an asset to a topic. Send an Idempotency-Key header to make retries safe: a retry with the same key and file replays the first response. Uploads whose Content-Length does not fit under max_disk_usage or the free disk space are rejected before the body is read with 507 STORAGE_FULL and the current headroom. The extension's storage policy can lower the size limit (413 ASSET_TOO_LARGE) and require a scan: flagged files are rejected with 422 UPLOAD_SCAN_REJECTED (or stored quarantined with quarantined: true when scan.quarantine_infected is set), and 503 UPLOAD_SCAN_FAILED is returned when the scanner cannot run. Extensions with a validator (validation.validators) are rejected with 422 UPLOAD_INVALID and the errors when invalid in strict topics, stored with validation_status=invalid in lenient ones, and refused with 503 UPLOAD_VALIDATION_FAILED when the validator cannot run. The response is sent once the asset is indexed and the topic stats refreshed, so queries and downloads issued afterwards see it; 503 UPLOAD_INDEX_FAILED means the asset was stored but not indexed, and retrying the upload indexes it. Several file parts in one request are stored one by one (at most 1000) and answered with a per-file result list instead; relative_path is then repeated once per file in the same order",
+				Category:    "topics",
+				Request: &RequestSpec{
+					ContentType: "multipart/form-data",
+					Body: map[string]interface{}{
+						"file":          "file (required; repeat for a multi-file upload)",
+						"parent_id":     "string (optional, 64-char hash)",
+						"relative_path": "string (optional, folder-relative path such as textures/wood/oak.png; stored as relative_path metadata on new assets in the same commit; once per file for a multi-file upload)",
+					},
+					Params: []ParamSpec{
+						{Name: "upload_id", Type: "string", Description: "Client-chosen ID for progress events and cancellation over /api/ws/progress (also accepted as X-Upload-ID header)"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"success":        "boolean",
+						"hash":           "string (64-char BLAKE3 hash)",
+						"status":         "string (created, deduplicated, aliased; rejected on error responses)",
+						"reason":         "string (new_content, duplicate_in_topic, duplicate_in_other_topic; the error code when rejected)",
+						"skipped":        "boolean (deprecated, use status; true if deduplicated or aliased)",
+						"existing_topic": "string (if deduplicated or aliased)",
+						"relative_path":  "string (if recorded for a new asset)",
+						"results":        "array (multi-file uploads only, replaces the fields above: {filename, relative_path, success, status, hash, size, existing_topic, quarantined, error, code}; with total, succeeded and failed counts)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/uploads",
+				Description: "Open a resumable upload session for a file of known size. Requires upload permission on the topic for the file's extension and size. Sessions are private to their user, expire 24 hours after their last chunk and are limited to 100 per user (429 UPLOAD_SESSION_LIMIT). Responds 201 with a Location header and Upload-Offset/Upload-Length headers",
+				Category:    "topics",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic":         "string (required)",
+						"filename":      "string (required)",
+						"size":          "integer (required, total bytes)",
+						"parent_id":     "string (optional, 64-char hash)",
+						"relative_path": "string (optional, folder-relative path recorded like a single-shot upload)",
+						"hash":          "string (optional, expected BLAKE3; a mismatch at finalize discards the session with 422 UPLOAD_HASH_MISMATCH)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"success": "boolean",
+						"session": "{id, topic, filename, parent_id, relative_path, size, offset, hash, created_at, updated_at, expires_at}",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/uploads/:id",
+				Description: "Get an upload session. offset (also the Upload-Offset header, and HEAD returns only the headers) is the byte count to resume from",
+				Category:    "topics",
+			},
+			{
+				Method:      "PATCH",
+				Path:        "/api/uploads/:id",
+				Description: "Append a chunk. Content-Type must be application/offset+octet-stream (415 otherwise) and the Upload-Offset header must equal the session's offset (409 UPLOAD_OFFSET_MISMATCH otherwise). Bytes received before an interrupted body are kept; chunks past the declared size are refused whole. The response carries the new Upload-Offset; 409 UPLOAD_SESSION_BUSY while another request writes to the session",
+				Category:    "topics",
+				Request: &RequestSpec{
+					ContentType: "application/offset+octet-stream",
+				},
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/uploads/:id",
+				Description: "Abort an upload session and delete its staged bytes",
+				Category:    "topics",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/uploads/:id/finalize",
+				Description: "Verify a complete session and store it in its topic. Responds like POST /api/topics/:name/assets (dedup, aliasing, policy, scan and lineage apply the same way); 409 UPLOAD_INCOMPLETE until all bytes are received. The session is removed once the asset is stored",
+				Category:    "topics",
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/topics/:name/integrity",
+				Description: "List .dat integrity findings (gaps, overlaps, extents past EOF, trailing bytes) from the latest scan",
+				Category:    "topics",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic":    "string",
+						"findings": "[]{id, dat_file, kind, start_offset, end_offset, asset_id, detail, detected_at}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/topics/:name/integrity",
+				Description: "Rescan the topic's .dat files against recorded asset extents and replace the stored findings",
+				Category:    "topics",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic":      "string",
+						"dat_files":  "integer",
+						"extents":    "integer",
+						"findings":   "[]{id, dat_file, kind, start_offset, end_offset, asset_id, detail, detected_at}",
+						"scanned_at": "integer (unix timestamp)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/topics/:name/compaction",
+				Description: "Tombstones of deleted assets awaiting compaction, per .dat file, and the latest compaction run (requires manage_topics delete)",
+				Category:    "topics",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic":             "string",
+						"running":           "boolean",
+						"tombstones":        "integer",
+						"reclaimable_bytes": "integer (entry headers included)",
+						"dat_files":         "[]{dat_file, tombstones, dead_bytes}",
+						"last_result":       "object|null {topic, trigger, dat_files[], entries_moved, bytes_reclaimed, started_at, completed_at, duration_ms, error}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/topics/:name/compaction",
+				Description: "Compact every .dat file of the topic holding tombstones in the background: live entries are appended to the end of the topic and the file is removed. 409 COMPACTION_IN_PROGRESS while a run is active. Files where deleted entries take at least 25% are also compacted hourly (requires manage_topics delete)",
+				Category:    "topics",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"started": "boolean",
+						"topic":   "string",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/topics/:name/compaction/stream",
+				Description: "Compaction progress (SSE stream): status, then compaction_start, dat_progress, dat_complete and compaction_complete or compaction_error events of the topic's runs (requires manage_topics delete)",
+				Category:    "topics",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/topics/:name/retention",
+				Description: "Apply the topic's retention policy (topic_retention) now: the oldest assets over its max_age_days, max_total_bytes or max_assets are trashed or deleted, at most 1000 per pass; referenced assets are kept. A dry run, requested or set on the policy, only reports them. Policies are also applied hourly; passes that affect assets are audited as retention_applied. 404 RETENTION_POLICY_NOT_FOUND when the topic has none (requires manage_topics delete)",
+				Category:    "topics",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"dry_run": "boolean (optional)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic":     "string",
+						"action":    "string (trash|delete)",
+						"dry_run":   "boolean",
+						"hashes":    "[]string removed, or that would be",
+						"bytes":     "integer",
+						"skipped":   "[]string (optional) over a limit but referenced",
+						"truncated": "boolean (optional) more remain over a limit",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/topics/:name/freeze",
+				Description: "Freeze a finalized topic and save it to config.yaml (frozen_topics): uploads, upload sessions and metadata writes are refused with 409 TOPIC_FROZEN, and metadata batches, applies and imports touching it are refused as a whole, while downloads and queries keep working. Listed as frozen in GET /api/topics. Audited as config_changed (requires manage_topics with can_freeze)",
+				Category:    "topics",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic":   "string",
+						"frozen":  "boolean",
+						"changed": "boolean (false when the topic already was)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/topics/:name/unfreeze",
+				Description: "Make a frozen topic writable again (requires manage_topics with can_freeze)",
+				Category:    "topics",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic":   "string",
+						"frozen":  "boolean",
+						"changed": "boolean (false when the topic was not frozen)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/topics/:name/assets/fetch",
+				Description: "Download files from remote URLs into a topic as uploads by the caller: each is checked against their upload grant, hashed and deduplicated like an upload. Only fetch.allowed_schemes are fetched, loopback and private addresses are refused with FETCH_BLOCKED unless fetch.allow_private_networks is set, and network errors, 429 and 5xx responses are retried. One URL failing does not stop the others (requires upload)",
+				Category:    "assets",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"urls": "[]string (required) at most fetch.max_urls",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"success":   "boolean (false when any URL failed)",
+						"total":     "integer",
+						"succeeded": "integer",
+						"failed":    "integer",
+						"results":   "[]{url, success, status (created|deduplicated|aliased|rejected), hash, size, filename, existing_topic, quarantined, attempts, error, code} in request order",
+					},
+				},
+			},
+
+			// Notifications
+			{
+				Method:      "POST",
+				Path:        "/api/topics/:name/subscribe",
+				Description: "Subscribe to notifications for a topic, replacing any existing subscription (requires query on the topic)",
+				Category:    "topics",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"events":        "[]string (optional) asset_added, metadata_changed; default all",
+						"metadata_keys": "[]string (optional, max 50) only notify metadata_changed for these keys, e.g. review_status; default any key",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"success":      "boolean",
+						"subscription": "{topic, events, metadata_keys, created_at, updated_at}",
+					},
+				},
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/topics/:name/subscribe",
+				Description: "Unsubscribe from a topic's notifications",
+				Category:    "topics",
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/topics/:name/archive",
+				Description: "The topic's archived assets, most recently archived first, at most 1000. Their rows and metadata stay in the topic as stubs; downloads return 409 RETRIEVAL_REQUIRED until they are recalled (requires query)",
+				Category:    "topics",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "status", Type: "string", Description: "archived or recalling"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic":  "string",
+						"assets": "[]{hash, topic, archive, archive_key, size, archived_at, archived_by, status (archived|recalling), recall_requested_by, recall_requested_at, recall_error}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/topics/:name/archive",
+				Description: "Archive the topic's idle assets now (topic_archive): assets uploaded and not downloaded for idle_days are copied to the policy's archive, verified, and replaced by stubs whose .dat entries are tombstoned, at most 1000 per pass. Downloads are read from the audit log: 409 ARCHIVE_HISTORY_INCOMPLETE when purges removed downloaded entries within idle_days. Quarantined and trashed assets are kept. A dry run, requested or set on the policy, only reports them. Passes also run hourly; passes that affect assets are audited as assets_archived. 404 ARCHIVE_POLICY_NOT_FOUND when the topic has none (requires manage_topics delete)",
+				Category:    "topics",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"dry_run": "boolean (optional)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic":     "string",
+						"archive":   "string",
+						"dry_run":   "boolean",
+						"hashes":    "[]string archived, or that would be",
+						"bytes":     "integer",
+						"skipped":   "[]string (optional) idle but not archived",
+						"truncated": "boolean (optional) more assets are idle",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/notifications",
+				Description: "Current user's notification feed, newest first: events of subscribed topics, inbox exports of the user and changes to the user's grants (grant_created, grant_updated, grant_revoked). Changes made by the user are not notified, and subscribers who lose query access to a topic stop receiving its notifications",
+				Category:    "system",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "limit", Type: "integer", Description: "Notifications per page (default 50, max 500)"},
+						{Name: "offset", Type: "integer", Description: "Notifications to skip"},
+						{Name: "unread", Type: "boolean", Description: "Only unread notifications"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"notifications":   "[]{id, topic, event, asset_id, actor, details, created_at, read_at}",
+						"total":           "number",
+						"unread":          "number",
+						"unread_by_event": "object (event -> unread count)",
+						"limit":           "number",
+						"offset":          "number",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/notifications/stream",
+				Description: "Current user's notifications as they are added (SSE stream): unread {unread, unread_by_event} first, then notification {notification, unread} for each new notification and read {marked, unread} when notifications are marked read",
+				Category:    "system",
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/events/stream",
+				Description: "Storage events as they happen (SSE stream): connected {types} first, then one event per change, each with an SSE id: {id, type, timestamp, actor, action, data}. Types: asset.created, asset.trashed, asset.restored, asset.deleted, topic.created, metadata.changed, user.created, user.updated; filter with ?types=a,b. Reconnecting with the Last-Event-ID header (or ?last_event_id=) first replays the events missed since, at most 10000, followed by replay_truncated when more were skipped. Users who cannot view all audit entries only receive their own events (requires view_audit stream)",
+				Category:    "system",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/notifications/read",
+				Description: "Mark notifications as read",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"ids": "[]number (optional) notifications to mark; omit to mark all read",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"success": "boolean",
+						"marked":  "number",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/notifications/subscriptions",
+				Description: "List the current user's topic subscriptions",
+				Category:    "system",
+			},
+			{
+				Method:      "PUT",
+				Path:        "/api/notifications/preferences",
+				Description: "Set how notifications are delivered besides the feed. Immediate delivery sends pending notifications within a minute; digest sends one message per notifications.digest_interval_mins. GET returns the current preferences",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"delivery":    "string (optional) immediate or digest",
+						"webhook_url": "string (optional) http(s) URL receiving POSTed JSON {username, delivery, sent_at, notifications}; empty disables",
+						"email":       "string (optional) address to email; requires notifications.smtp; empty disables",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"preferences":   "{delivery, webhook_url, email, last_delivered_at, updated_at}",
+						"email_enabled": "boolean",
+					},
+				},
+			},
+
+			// Collections
+			{
+				Method:      "GET",
+				Path:        "/api/topics/:name/collections",
+				Description: "List collections in a topic with asset counts",
+				Category:    "collections",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/topics/:name/collections",
+				Description: "Create a collection in a topic",
+				Category:    "collections",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"name":        "string (required, lowercase alphanumeric with - and _)",
+						"description": "string (optional)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/topics/:name/collections/:collection",
+				Description: "Get a collection and a page of its member asset IDs",
+				Category:    "collections",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "limit", Type: "number", Description: "Maximum asset IDs to return", Default: "100"},
+						{Name: "offset", Type: "number", Description: "Asset IDs to skip", Default: "0"},
+					},
+				},
+			},
+			{
+				Method:      "PATCH",
+				Path:        "/api/topics/:name/collections/:collection",
+				Description: "Update a collection description",
+				Category:    "collections",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"description": "string (required)",
+					},
+				},
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/topics/:name/collections/:collection",
+				Description: "Delete a collection (member assets are kept)",
+				Category:    "collections",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/topics/:name/collections/:collection/assets",
+				Description: "Add assets of the same topic to a collection",
+				Category:    "collections",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"asset_ids": "array of strings (required, 64-char hashes)",
+					},
+				},
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/topics/:name/collections/:collection/assets",
+				Description: "Remove assets from a collection",
+				Category:    "collections",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"asset_ids": "array of strings (required, 64-char hashes)",
+					},
+				},
+			},
+
+			// Assets
+			{
+				Method:      "GET",
+				Path:        "/api/assets/:hash/download",
+				Description: "Download an asset by hash. PNG and JPEG assets can be served with a configured watermark applied; the stored asset is never modified. When the extension's storage policy enables compression, the body is gzip-encoded for clients sending Accept-Encoding: gzip. Quarantined assets return 423 ASSET_QUARANTINED, trashed ones 410 ASSET_TRASHED and archived ones 409 RETRIEVAL_REQUIRED until recalled. When an origin is configured, assets missing here are fetched from it, verified and stored in the origin cache topic first (requires download on that topic); 502 ORIGIN_FETCH_FAILED or ORIGIN_HASH_MISMATCH when that fails",
+				Category:    "assets",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "watermark", Type: "string", Description: "Watermark profile from config; overridden by a profile forced by the download grant or public mode. Applied profiles are reported in the X-Watermark header"},
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/topics/:name/assets",
+				Description: "Browse a topic's assets a page at a time, excluding quarantined assets. Pages continue from the previous page's next_cursor, which stays fast however deep the page; a cursor only fits the sort and order it was issued for. total counts every asset matching the filters. Requires the query grant",
+				Category:    "assets",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "sort", Type: "string", Description: "created_at, size or name", Default: "created_at"},
+						{Name: "order", Type: "string", Description: "asc or desc", Default: "desc (asc for name)"},
+						{Name: "extension", Type: "string", Description: "Only assets with this extension"},
+						{Name: "has_key", Type: "string", Description: "Only assets whose current metadata has this key"},
+						{Name: "since", Type: "number", Description: "Only assets created at or after this unix timestamp"},
+						{Name: "until", Type: "number", Description: "Only assets created at or before this unix timestamp"},
+						{Name: "limit", Type: "number", Description: "Assets per page (max 1000)", Default: "50"},
+						{Name: "cursor", Type: "string", Description: "next_cursor of the previous page"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic":       "string",
+						"sort":        "string",
+						"order":       "string",
+						"assets":      "[]{hash, origin_name, extension, size, parent_id, created_at}",
+						"total":       "number",
+						"next_cursor": "string (omitted on the last page)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/topics/:name/assets/by-name/:origin_name",
+				Description: "Assets of a topic stored under a filename (e.g. model.glb) or an origin name without extension, newest first, excluding quarantined assets. Requires the query grant; with latest=true the newest match is downloaded directly as by GET /api/assets/:hash/download and requires the download grant instead (404 ASSET_NOT_FOUND when nothing matches)",
+				Category:    "assets",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "latest", Type: "boolean", Description: "Download the newest match instead of listing the matches", Default: "false"},
+						{Name: "watermark", Type: "string", Description: "With latest=true: watermark profile, as for downloads by hash"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topic":  "string",
+						"name":   "string",
+						"count":  "number",
+						"assets": "[]{hash, origin_name, extension, size, parent_id, created_at} (at most 1000)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/assets/:hash/preview",
+				Description: "Downscaled copy of a PNG or JPEG asset in its own format, or a PNG wireframe of a GLB or OBJ model, when the extension's storage policy enables previews; otherwise 422 PREVIEW_UNAVAILABLE; 503 FEATURE_DISABLED when features.disabled lists previews. Previews are cached under .internal/previews per hash and size. Requires the download grant",
+				Category:    "assets",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "size", Type: "number", Description: "Longest edge in pixels (max 1024); smaller images are not enlarged", Default: "256"},
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/assets/:hash/metadata",
+				Description: "Get asset info and computed metadata. With as_of, the metadata is rebuilt from metadata_log as it was at that time; assets created later are not found",
+				Category:    "metadata",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "metadata", Type: "string", Description: "full (values), keys (key names only) or none", Default: "full"},
+						{Name: "metadata_keys", Type: "string", Description: "Comma-separated keys to return; others are omitted"},
+						{Name: "as_of", Type: "number", Description: "Unix timestamp to read the metadata at"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"asset": map[string]interface{}{
+							"origin_name": "string",
+							"extension":   "string",
+							"created_at":  "number (unix timestamp)",
+							"parent_id":   "string|null",
+						},
+						"computed_metadata": "object (key-value pairs; omitted with metadata=keys or none)",
+						"metadata_keys":     "array of strings (metadata=keys only)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/assets/:hash/metadata",
+				Description: "Set or delete metadata for an asset",
+				Category:    "metadata",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"op":                "string (required: 'set' or 'delete')",
+						"key":               "string (required)",
+						"value":             "any (required for 'set')",
+						"processor":         "string (optional, default: 'api')",
+						"processor_version": "string (optional, default: '1.0')",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/assets/:hash/bom",
+				Description: "Bill of materials: every ancestor of an asset across topics, deduplicated, with sizes and a metadata snapshot (?format=spdx for an SPDX-like document)",
+				Category:    "metadata",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"root":         "object (hash, topic, origin_name, extension, size, created_at, parent_ids, depth, metadata)",
+						"sources":      "array of objects (same shape as root, shortest depth first)",
+						"source_count": "number",
+						"total_size":   "number (bytes, sources only)",
+						"topics":       "array of strings",
+						"missing":      "array of {hash, reason} (ancestors that could not be resolved)",
+						"truncated":    "boolean",
+						"generated_at": "number (unix timestamp)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/assets/:hash/references",
+				Description: "Every holder keeping an asset alive (collection memberships, derived assets naming it as parent) from the reference registry, and whether it could be deleted",
+				Category:    "metadata",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"hash":            "string",
+						"topic":           "string (topic storing the asset)",
+						"reference_count": "number",
+						"by_kind":         "object (kind → count; kinds: collection, lineage)",
+						"topics":          "array of strings (asset topic and every holder topic)",
+						"references":      "array of {hash, kind, topic, holder, created_at}",
+						"deletable":       "boolean (true when nothing references the asset)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/lineage/reparent",
+				Description: "Replace the parents of existing assets, given as pairs or as the rows of a query preset. All changes are validated first (assets and parents exist, no cycles) and none are applied if any fails, with 400 LINEAGE_REPARENT_INVALID listing the errors. Each topic's changes commit together and the previous parent is kept in the topic's append-only lineage change log (requires metadata on the topics of the assets and their new parents, and query on the preset)",
+				Category:    "metadata",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"changes":       "array of {hash, parent} (an empty parent removes it; or use query_preset)",
+						"query_preset":  "string (rows map to changes)",
+						"query_params":  "object (optional, preset parameters)",
+						"topics":        "array of strings (optional, defaults to all)",
+						"child_column":  "string (optional, default: hash or asset_id)",
+						"parent_column": "string (required with query_preset)",
+						"reason":        "string (optional, recorded in the change log)",
+						"dry_run":       "boolean (optional, validate without applying)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"dry_run":   "boolean",
+						"total":     "number",
+						"changed":   "number",
+						"unchanged": "number (assets that already had the parent)",
+						"topics":    "array of strings",
+						"items":     "array of {hash, topic, old_parent, new_parent, parent_topic, changed}",
+						"errors":    "array of {index, hash, code, message}",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/assets/:hash/timeline",
+				Description: "The asset's history in one feed, newest first: its upload, metadata log entries, parent changes, derived assets, and the audit entries mentioning it (re-uploads, downloads, anything else). Audit-derived events and actors require view_audit and are limited to the caller's own entries without can_view_all",
+				Category:    "metadata",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "limit", Type: "integer", Description: "Events per page (default 100, max 1000)"},
+						{Name: "offset", Type: "integer", Description: "Events to skip"},
+						{Name: "since", Type: "integer", Description: "Unix timestamp lower bound (inclusive)"},
+						{Name: "until", Type: "integer", Description: "Unix timestamp upper bound (inclusive)"},
+						{Name: "kind", Type: "string", Description: "Only events of this kind: upload, reupload, metadata, lineage, download, derived, audit"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"hash":           "string",
+						"topic":          "string",
+						"events":         "array of {timestamp, kind, action, actor {username, actor_type, ip_address, processor, processor_version}, details}",
+						"total":          "number (events matching the filters)",
+						"limit":          "number",
+						"offset":         "number",
+						"audit_included": "boolean (false when the caller cannot view the audit log)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/assets/:hash/related",
+				Description: "Assets to look at before creating a duplicate: those the same users downloaded within an hour of this one, and those of its topic sharing the most metadata values with it. Requires query on the asset's topic; downloaded-together assets are limited to topics the caller can query. Cached by the stats cache for up to 10 minutes",
+				Category:    "metadata",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "limit", Type: "integer", Description: "Assets per list (default 10, max 100)"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"hash":                "string",
+						"topic":               "string",
+						"downloaded_together": "array of {asset_id, topic, downloads, users, origin_name, extension, asset_size} (most users first)",
+						"similar":             "array of {asset_id, origin_name, extension, asset_size, shared_keys} (most shared values first)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/popular",
+				Description: "Most downloaded assets of each topic the caller can query, counted from the audit log. Cached by the stats cache for up to 10 minutes",
+				Category:    "metadata",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "days", Type: "integer", Description: "Days to count downloads over (default 30, max 366)"},
+						{Name: "limit", Type: "integer", Description: "Assets per topic (default 10, max 100)"},
+						{Name: "topic", Type: "string", Description: "Only this topic"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"days":   "number",
+						"since":  "number (unix timestamp)",
+						"topics": "object (topic -> array of {asset_id, topic, downloads, users, origin_name, extension, asset_size}, most downloaded first)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/search",
+				Description: "Full-text search of origin names, extensions and metadata values across the topics the caller can query. Every word must match, as a prefix; matches are highlighted with <mark> in the snippet. 503 SEARCH_UNAVAILABLE when built without the sqlite_fts5 tag, 503 FEATURE_DISABLED when features.disabled lists search",
+				Category:    "metadata",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "q", Type: "string", Required: true, Description: "Words to search for (max 200 characters, 16 words)"},
+						{Name: "limit", Type: "integer", Description: "Results (default 20, max 100)"},
+						{Name: "topic", Type: "string", Description: "Only this topic"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"query":           "string",
+						"results":         "array of {hash, topic, origin_name, extension, asset_size, created_at, score, snippet} (best first)",
+						"topics_searched": "array of string",
+					},
+				},
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/assets/:hash",
+				Description: "Move an asset to the trash: it stays stored but is withheld from downloads (410 ASSET_TRASHED), queries and bulk exports until restored, and is deleted permanently trash.retention_days after. Uploading its content again restores it. Trashing a trashed asset returns its entry; 409 ASSET_REFERENCED while collections, derived assets or other holders reference it (requires manage_topics delete on the asset's topic)",
+				Category:    "assets",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"hash":        "string",
+						"topic":       "string",
+						"origin_name": "string",
+						"extension":   "string",
+						"size":        "number",
+						"trashed_by":  "string",
+						"trashed_at":  "number (unix timestamp)",
+						"purge_at":    "number (unix timestamp)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/assets/:hash/quarantine",
+				Description: "Quarantine an asset: it stays stored but is withheld from downloads (423 ASSET_QUARANTINED), queries and bulk exports until released. Quarantining a quarantined asset returns its active entry (requires verify on the asset's topic)",
+				Category:    "assets",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"reason": "string (optional, max 1024 characters)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"id":             "number",
+						"hash":           "string",
+						"topic":          "string",
+						"source":         "string (admin, integrity or scan)",
+						"reason":         "string",
+						"quarantined_by": "string (empty for integrity and scan)",
+						"quarantined_at": "number (unix timestamp)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/assets/:hash/release",
+				Description: "Release a quarantined asset. The justification is kept with the entry and in the audit log; 409 ASSET_NOT_QUARANTINED when the asset is not quarantined (requires manage_config)",
+				Category:    "assets",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"justification": "string (required, max 1024 characters)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"released_by":   "string",
+						"released_at":   "number (unix timestamp)",
+						"justification": "string",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/assets/:hash/recall",
+				Description: "Recall an archived asset. It is written back into its topic, verified against its hash, as soon as its archive has it readable: right away for filesystem archives with the file in place, after the restore for S3 archival classes. Until then the status stays recalling and the recall is retried hourly; the last failure is reported in recall_error. Audited as asset_recall_requested and asset_recalled. 409 ASSET_NOT_ARCHIVED when the asset is stored in its topic (requires download on the asset's topic)",
+				Category:    "assets",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"hash":         "string",
+						"topic":        "string",
+						"status":       "string (recalling|stored)",
+						"recall_error": "string (optional)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/quarantine",
+				Description: "Quarantined assets, newest first. Assets are quarantined by admins, by verification when their content fails its hash check, and by the upload scanner with scan.quarantine_infected (requires verify)",
+				Category:    "assets",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "topic", Type: "string", Description: "Only entries of this topic"},
+						{Name: "include_released", Type: "boolean", Description: "Include released entries"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"entries": "array of {id, hash, topic, source, reason, quarantined_by, quarantined_at, released_by, released_at, justification}",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/trash",
+				Description: "Trashed assets, most recently trashed first, with when each is due to be purged (requires manage_topics delete)",
+				Category:    "assets",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "topic", Type: "string", Description: "Only entries of this topic"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"entries":        "array of {hash, topic, origin_name, extension, size, trashed_by, trashed_at, purge_at}",
+						"retention_days": "number",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/trash/:hash/restore",
+				Description: "Take an asset out of the trash; 409 ASSET_NOT_TRASHED when it is not in the trash (requires manage_topics delete on the asset's topic)",
+				Category:    "assets",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"hash":       "string",
+						"topic":      "string",
+						"trashed_by": "string",
+						"trashed_at": "number (unix timestamp)",
+					},
+				},
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/trash/:hash",
+				Description: "Delete a trashed asset permanently with its metadata. Its .dat entry is tombstoned until the file is compacted; 409 ASSET_NOT_TRASHED when it is not in the trash, 409 ASSET_REFERENCED when something references it again (requires manage_topics delete on the asset's topic)",
+				Category:    "assets",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"hash":       "string",
+						"topic":      "string",
+						"size":       "number",
+						"dat_file":   "string",
+						"deleted_at": "number (unix timestamp)",
+					},
+				},
+			},
+
+			// Deletion Requests
+			{
+				Method:      "GET",
+				Path:        "/api/deletion-requests",
+				Description: "Newest deletion requests the caller made or may decide",
+				Category:    "assets",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "status", Type: "string", Description: "Only requests in this state: pending, approved, completed, rejected, cancelled or expired"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"requests": "array of {id, status, mode, preset, reason, topics, asset_count, total_bytes, requested_by, requested_at, expires_at, decided_by, decided_at, comment, completed_at, deleted, failed}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/deletion-requests",
+				Description: "Propose deleting a set of assets permanently. Another user must approve it within deletion_requests.approval_window_hours before anything is deleted (requires manage_topics delete on every topic of the set)",
+				Category:    "assets",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"mode":      "string ('ids' or 'query')",
+						"asset_ids": "array of strings (for mode 'ids')",
+						"preset":    "string (for mode 'query')",
+						"params":    "object (for mode 'query', optional)",
+						"topics":    "array of strings (for mode 'query', optional)",
+						"reason":    "string (optional)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"request":   "{id, status, mode, preset, reason, topics, asset_count, total_bytes, requested_by, requested_at, expires_at, decided_by, decided_at, comment, completed_at, deleted, failed}",
+						"not_found": "array of requested IDs matching no asset",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/deletion-requests/:id",
+				Description: "Deletion request with its assets and the outcome of each deletion (requester, or users who may decide it)",
+				Category:    "assets",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"id":     "number",
+						"status": "string",
+						"assets": "array of {hash, topic, size, status, error}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/deletion-requests/:id/approve",
+				Description: "Approve a pending request and delete its assets as the approver in the background. 403 DELETION_SELF_APPROVAL for its requester, 409 DELETION_REQUEST_NOT_PENDING once decided or expired (requires manage_topics delete on every topic of the request)",
+				Category:    "assets",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"comment": "string (optional)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"request": "{id, status, mode, preset, reason, topics, asset_count, total_bytes, requested_by, requested_at, expires_at, decided_by, decided_at, comment, completed_at, deleted, failed}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/deletion-requests/:id/reject",
+				Description: "Reject a pending request; its assets are left untouched. Same rules as approve",
+				Category:    "assets",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"comment": "string (optional)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"request": "{id, status, mode, preset, reason, topics, asset_count, total_bytes, requested_by, requested_at, expires_at, decided_by, decided_at, comment, completed_at, deleted, failed}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/deletion-requests/:id/cancel",
+				Description: "Withdraw a pending request (requester only)",
+				Category:    "assets",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"request": "{id, status, mode, preset, reason, topics, asset_count, total_bytes, requested_by, requested_at, expires_at, decided_by, decided_at, comment, completed_at, deleted, failed}",
+					},
+				},
+			},
+
+			// Batch Metadata
+			{
+				Method:      "POST",
+				Path:        "/api/metadata/batch",
+				Description: "Set or delete metadata on multiple assets atomically. Send an Idempotency-Key header to make retries safe: a retry with the same key and body replays the first response",
+				Category:    "metadata",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"operations": []map[string]interface{}{
+							{
+								"hash":  "string (64-char hash)",
+								"op":    "string ('set' or 'delete')",
+								"key":   "string",
+								"value": "any (for 'set')",
+							},
+						},
+						"processor":         "string (optional)",
+						"processor_version": "string (optional)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"success":   "boolean",
+						"total":     "number",
+						"succeeded": "number",
+						"failed":    "number",
+						"results": []map[string]interface{}{
+							{
+								"hash":    "string",
+								"success": "boolean",
+								"error":   "string (if failed)",
+								"log_id":  "number (if succeeded)",
+							},
+						},
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/metadata/apply",
+				Description: "Apply metadata to assets matching a query. Accepts an Idempotency-Key header like /api/metadata/batch",
+				Category:    "metadata",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"query_preset":      "string (required, e.g. 'recent-imports')",
+						"query_params":      "object (optional, preset parameters)",
+						"topics":            "array of strings (optional, defaults to all)",
+						"op":                "string (required: 'set' or 'delete')",
+						"key":               "string (required)",
+						"value":             "any (required for 'set')",
+						"processor":         "string (optional)",
+						"processor_version": "string (optional)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/metadata/import",
+				Description: "Import metadata from a CSV sheet. The header holds a hash or origin_name column, an optional topic column, and one column per metadata key; origin names match with or without the extension. Rows matching no asset or several assets are reported, not applied, and empty cells are skipped. The per-row result is kept for 7 days at result_url",
+				Category:    "metadata",
+				Request: &RequestSpec{
+					ContentType: "text/csv or multipart/form-data (CSV in the 'file' part, max 32MB)",
+					Params: []ParamSpec{
+						{Name: "processor", Type: "string", Description: "Processor recorded on every write", Default: "csv-import"},
+						{Name: "processor_version", Type: "string", Description: "Processor version recorded on every write", Default: "1.0"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"success":    "boolean (every row with values was applied)",
+						"import_id":  "string",
+						"result_url": "string (GET for the per-row result as CSV)",
+						"key_column": "string ('hash' or 'origin_name')",
+						"keys":       "array of strings (metadata columns)",
+						"summary":    "object {rows, operations, applied, partial, failed, not_found, ambiguous, invalid, skipped}",
+						"rows":       "array of {line, key, topic, hash, status, applied, failed, candidates, error}",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/metadata/import/:id",
+				Description: "Download the per-row result of a metadata import as CSV (line, key column, topic, hash, status, applied, failed, candidates, error). Only the user who ran the import can fetch it",
+				Category:    "metadata",
+				Response: &ResponseSpec{
+					ContentType: "text/csv",
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/auth/me/preflight",
+				Description: "Check the caller's grants against every target of a bulk metadata or bulk download request without executing it",
+				Category:    "metadata",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"action":        "string (required: 'metadata' or 'bulk_download')",
+						"hashes":        "array of strings (for 'metadata')",
+						"bulk_download": "object (for 'bulk_download', same body as /api/download/bulk)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"action":         "string",
+						"allowed":        "boolean",
+						"total":          "number",
+						"allowed_count":  "number",
+						"denied_count":   "number",
+						"request_denied": "object (if the request as a whole is denied)",
+						"denied_topics":  "array of {topic, reason, code}",
+						"denied":         "array of {hash, topic, reason, code}",
+						"not_found":      "array of strings",
+					},
+				},
+			},
+
+			// Queries
+			{
+				Method:      "GET",
+				Path:        "/api/queries",
+				Description: "List available query presets. Presets that read metadata_computed or metadata_log have supports_as_of set",
+				Category:    "queries",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/queries/validate",
+				Description: "Check a preset definition without registering it: parses the YAML, checks param declarations against the SQL and compiles it with EXPLAIN on a query-only connection, reading no rows (requires manage_config)",
+				Category:    "queries",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"definition": "string (preset YAML, as stored under queries/presets)",
+						"name":       "string (optional, checked against the preset naming rules)",
+						"topic":      "string (topic to compile a regular preset against; federated presets use their topic_params)",
+						"params":     "object (optional param values; missing ones use defaults or NULL)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"valid":    "boolean",
+						"errors":   "array of strings (problems that make the preset unusable)",
+						"warnings": "array of strings",
+						"topics":   "array of strings (topic databases the plan was computed against)",
+						"columns":  "array of {name, type} (type is the declared column type, empty for expressions)",
+						"plan":     "array of {id, parent, detail} (EXPLAIN QUERY PLAN)",
+						"cost":     "object {opcodes, full_scans, index_searches, temp_b_trees}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/query/:preset",
+				Description: "Execute a query preset (federated presets run once against the orchestrator and the topics named by their topic_params, attached read-only)",
+				Category:    "queries",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topics":              "array of strings (optional, ignored by federated presets)",
+						"params":              "object (preset-specific parameters)",
+						"collection":          "string (optional, the preset reads only assets in this collection, before its LIMIT; not for federated presets)",
+						"metadata":            "string (optional: full, keys or none; rewrites or drops the metadata_json column)",
+						"metadata_keys":       "array of strings (optional, keep only these keys in metadata_json)",
+						"include_quarantined": "boolean (optional, keep rows of quarantined assets, which are otherwise dropped from results with an asset_id column)",
+						"as_of":               "number (optional unix timestamp, presets with supports_as_of only: assets, metadata_log and metadata_computed as they were at that time)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"preset":    "string",
+						"row_count": "number",
+						"columns":   "array of strings",
+						"rows":      "array of arrays",
+						"truncated": "boolean (optional, federated row cap reached)",
+					},
+				},
+			},
+
+			{
+				Method:      "GET",
+				Path:        "/api/query/schema",
+				Description: "Describe what the query builder can use: the assets, metadata and lineage entities with each field's type, operators and whether it can be selected or sorted on, the operators, the limits and the most used metadata keys of the topics the caller may query (requires query for the preset build)",
+				Category:    "queries",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "topic", Type: "string", Description: "Only count metadata keys of this topic; repeatable"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"entities":  "[]{name, description, columns: []{field, type, description, operators, selectable, sortable}, keys}",
+						"operators": "[]{name, value (none, scalar or list), description}",
+						"logical":   "[]string (and, or, not)",
+						"limits":    "{max_depth, max_conditions, max_in_values, max_select, max_sort, max_limit}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/query/build",
+				Description: "Run a structured query from the query builder on the selected topics: a filter tree of and/or/not nodes over comparisons of asset fields, metadata.<key> values and lineage fields, compiled to a parameterized SELECT with every value bound. Rows are sorted across topics; asset_id is always returned. Grants and the audit log see it as the preset build",
+				Category:    "queries",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"filter":              "{op, field, value, children} (optional; op and/or take children, not one child, comparisons a field and value)",
+						"select":              "array of strings (optional, default asset_id, origin_name, extension, asset_size, created_at)",
+						"sort":                "[]{field, desc} (optional, default created_at newest first)",
+						"limit":               "number (optional, default 100, at most query.max_rows)",
+						"topics":              "array of strings (optional)",
+						"collection":          "string (optional, only assets in this collection)",
+						"include_quarantined": "boolean (optional)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"preset":    "build",
+						"row_count": "number",
+						"columns":   "array of strings (the selected fields, then _topic)",
+						"rows":      "array of arrays",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/federation/query/:preset",
+				Description: "Run a read-only query preset on this instance and every peer under federation.peers, merging the rows. Failed peers are reported in instances without failing the request",
+				Category:    "queries",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topics":     "array of strings (optional, sent to every instance)",
+						"params":     "object (preset-specific parameters)",
+						"collection": "string (optional, filtered by each instance's own collection)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"preset":    "string",
+						"row_count": "number",
+						"columns":   "array of strings (origin_instance first)",
+						"rows":      "array of arrays",
+						"truncated": "boolean (optional, row cap reached)",
+						"partial":   "boolean (at least one instance failed)",
+						"instances": "array of {name, url, status (ok|error), latency_ms, row_count, error}",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/federation/peers",
+				Description: "Probe every federation peer for reachability, API key validity and latency (requires manage_config)",
+				Category:    "queries",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"instance_name": "string",
+						"enabled":       "boolean",
+						"peers":         "array of {name, url, status (ok|error), status_code, latency_ms, error, checked_at}",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/origin-cache",
+				Description: "Size, counters and most recently fetched entries of the origin cache. Counters start at zero when the server starts (requires manage_config)",
+				Category:    "queries",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"enabled":       "boolean",
+						"origin":        "string (origin base URL)",
+						"topic":         "string",
+						"eviction":      "string (lru|lfu)",
+						"entries":       "number",
+						"bytes":         "number",
+						"max_bytes":     "number (0 = unlimited)",
+						"hits":          "number",
+						"misses":        "number",
+						"hit_ratio":     "number",
+						"fetch_errors":  "number",
+						"bytes_fetched": "number",
+						"evictions":     "number",
+						"recent":        "array of {hash, topic, size, fetched_at, last_hit_at, hits} (newest first, at most 20)",
+					},
+				},
+			},
+
+			// Bulk Download
+			{
+				Method:      "POST",
+				Path:        "/api/download/bulk",
+				Description: "Download multiple assets as a ZIP, tar or tar.gz archive (archive_format), written straight to the response as an attachment without a temporary file or progress events; the download stops, audited as download_cancelled, if the client disconnects. Quarantined assets are left out. With recipients, every asset and metadata entry is encrypted with its own key, wrapped to each recipient's X25519 public key and listed in keys.json. With destination=inbox, responds 202 with the queued export and builds the ZIP in the background into the user's export inbox",
+				Category:    "download",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"mode":             "string (required: query, ids)",
+						"preset":           "string (mode=query)",
+						"params":           "object (mode=query)",
+						"topics":           "[]string (mode=query, optional)",
+						"asset_ids":        "[]string (mode=ids)",
+						"include_metadata": "boolean",
+						"metadata":         "string (optional: full, keys or none; applies to metadata files)",
+						"metadata_keys":    "[]string (optional, keep only these keys in metadata files)",
+						"filename_format":  "string (hash, original, hash_original, path; path rebuilds upload folders from relative_path)",
+						"recipients":       "[]{id, public_key} (optional, max 32; base64 X25519 public keys)",
+						"destination":      "string (optional: inbox = build in the background for later download)",
+						"archive_format":   "string (optional: zip (default), tar or tar.gz; inbox exports are always zip)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/download/bulk/start",
+				Description: "Start SSE bulk download session, with the request as query parameters (archive_format selects zip, tar or tar.gz). The archive is built in a temporary file under .internal/downloads with progress events, then fetched with GET /api/download/bulk/:sessionID",
+				Category:    "download",
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/download/bulk/:sessionID",
+				Description: "Fetch completed bulk download ZIP",
+				Category:    "download",
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/download/bulk/:sessionID/cancel",
+				Description: "Cancel an in-progress SSE or progress-socket bulk download started by the caller. Assembly stops mid-asset, the partial ZIP is removed, a cancelled event is sent on the stream and a download_cancelled audit entry records the progress made. Responds with the processed and total assets and bytes; 409 if the download already finished",
+				Category:    "download",
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/exports",
+				Description: "List the current user's export inbox, newest first, with used_bytes, max_bytes and retention_days. Exports are deleted retention_days after they were requested; owners are notified with export_ready, export_failed and export_expired",
+				Category:    "download",
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/exports/:id",
+				Description: "Download a ready export. Supports Range and If-Range requests to resume interrupted downloads",
+				Category:    "download",
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/exports/:id",
+				Description: "Delete a finished or failed export and free its inbox space",
+				Category:    "download",
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/exports/links",
+				Description: "List the current user's link exports, newest first, with their directory path, asset count and size",
+				Category:    "download",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/exports/links",
+				Description: "Create a link export: a directory of hard links under .internal/links/exports/ for consumers on the same host, laid out like a bulk download. With mode set, the selection is linked at once (requires bulk_download on every asset). Responds 201 with the export and the linked counts",
+				Category:    "download",
+				Request: &RequestSpec{
+					Body: map[string]interface{}{
+						"label":            "string (optional)",
+						"filename_format":  "string (optional: original (default), hash, hash_original or path)",
+						"mode":             "string (optional: query or ids, as for bulk downloads)",
+						"preset":           "string (for mode=query)",
+						"params":           "object (for mode=query)",
+						"topics":           "[]string (for mode=query, optional)",
+						"asset_ids":        "[]string (for mode=ids)",
+						"collection":       "string (optional collection filter)",
+						"collection_paths": "boolean (optional: links under <collection>/)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/exports/links/:id",
+				Description: "Get a link export with its entries: each linked asset's path, size and method (hardlink, or copy where the filesystem refuses links)",
+				Category:    "download",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/exports/links/:id/select",
+				Description: "Link a selection into the export, skipping assets already linked. With replace, linked assets missing from the selection are released, refreshing the export. Linked assets hold an export reference and cannot be deleted",
+				Category:    "download",
+				Request: &RequestSpec{
+					Body: map[string]interface{}{
+						"mode":      "string (query or ids, with the bulk download selection fields)",
+						"replace":   "boolean (optional: release linked assets missing from the selection)",
+						"asset_ids": "[]string (for mode=ids)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/exports/links/:id/release",
+				Description: "Remove the links of the given assets and release their references. Extracted copies no export links anymore are removed",
+				Category:    "download",
+				Request: &RequestSpec{
+					Body: map[string]interface{}{
+						"asset_ids": "[]string (required)",
+					},
+				},
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/exports/links/:id",
+				Description: "Delete a link export with its directory, releasing every link",
+				Category:    "download",
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/ws/progress",
+				Description: "WebSocket for upload/bulk-download progress and cancellation. Commands: ping, cancel {id}, bulk_download {request}",
+				Category:    "download",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "token", Type: "string", Description: "Session token or API key (browsers cannot set headers on WebSocket handshakes)"},
+					},
+				},
+			},
+
+			// Verification
+			{
+				Method:      "GET",
+				Path:        "/api/verify",
+				Description: "Verify topic integrity (SSE stream)",
+				Category:    "system",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/stats/chunk-dedup",
+				Description: "Start a background chunk-level dedup analysis: content-defined chunks a sample of assets per topic and estimates potential savings",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"topics":      "[]string (optional, default all topics)",
+						"sample_size": "number (optional, assets per topic, default 200, max 10000)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/stats/chunk-dedup",
+				Description: "Latest chunk dedup analysis report and whether an analysis is running",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"running": "boolean",
+						"report":  "object|null {started_at, completed_at, duration_ms, sample_size, min/avg/max_chunk_size, topics[], overall}",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/maintenance/scrub",
+				Description: "Integrity scrubber schedule, progress of the running pass, the latest completed pass and the assets found damaged (requires verify)",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"enabled":           "boolean",
+						"interval_hours":    "number",
+						"max_bytes_per_sec": "number (0 = unthrottled)",
+						"running":           "boolean",
+						"progress":          "object|null {trigger, started_at, topic, topics_done, topics_total, assets_checked, bytes_read, corrupt, missing}",
+						"last_run":          "object|null {trigger, started_at, completed_at, duration_ms, cancelled, topics[], assets_checked, bytes_read, corrupt, missing}",
+						"unhealthy_total":   "number",
+						"unhealthy":         "[]{hash, topic, status (corrupt|missing), detail, detected_at, checked_at} (newest first, up to 100)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/maintenance/scrub",
+				Description: "Start a scrub pass now: re-reads every asset of the healthy topics, recomputes its BLAKE3 hash, quarantines corrupt assets and records a verified audit entry per topic. 202 when started, 409 SCRUB_IN_PROGRESS while a pass runs (requires verify)",
+				Category:    "system",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/backup",
+				Description: "Download a point-in-time tar archive of the working directory for 'silobang restore': SQLite backup API snapshots of the orchestrator and healthy topic databases, each topic's .dat files up to the entries its snapshot records, and backup-manifest.json with every file's size and BLAKE3 hash as the last entry. .dat files offloaded to blob stores are listed, not archived. 409 BACKUP_IN_PROGRESS while another backup is written; an archive cut short by an error has no manifest. Audited as backup_created (requires manage_config)",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/x-tar",
+				},
+			},
+
+			// Sync tooling
+			{
+				Method:      "POST",
+				Path:        "/api/sync/diff",
+				Description: "Compare a hash manifest against the server; streams one NDJSON line per entry (present, missing, elsewhere or invalid) and a final summary line",
+				Category:    "queries",
+				Request: &RequestSpec{
+					ContentType: "application/json | text/plain | multipart/form-data",
+					Params: []ParamSpec{
+						{Name: "topic", Type: "string", Description: "Expected topic for entries that do not name one; hashes found in other topics are reported as elsewhere"},
+					},
+					Body: map[string]interface{}{
+						"hashes":    "[]string or []{hash, topic} (JSON; a bare array is also accepted)",
+						"text":      "one '<hash> [topic]' per line, # comments allowed",
+						"multipart": "'manifest' file part in either format",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/x-ndjson",
+					Body: map[string]interface{}{
+						"hash":           "string",
+						"status":         "string (present, missing, elsewhere, invalid)",
+						"topic":          "string (topic holding the asset, omitted if not visible to the caller)",
+						"expected_topic": "string",
+						"summary":        "object (last line) {total, present, missing, elsewhere, invalid}",
+					},
+				},
+			},
+
+			// Health
+			{
+				Method:      "GET",
+				Path:        "/healthz",
+				Description: "Liveness probe; answers 200 as long as the process serves HTTP. No authentication",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"status":         "string (ok)",
+						"uptime_seconds": "int",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/readyz",
+				Description: "Readiness probe; 200 once the working directory, orchestrator database, topic discovery and stats cache are initialized and every required subsystem runs, 503 otherwise. Degraded components and optional subsystems that failed are reported but do not fail readiness; subsystems turned off by features.disabled are reported as disabled. No authentication",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"ready":      "boolean",
+						"checked_at": "int (unix timestamp)",
+						"components": "[]{name (startup, working_directory, orchestrator_db, topic_discovery, stats_cache, topics, subsystems), status (ok, degraded, failed), reason}",
+						"subsystems": "map[subsystem]state (running, degraded, failed, disabled, skipped)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/health/startup",
+				Description: "Startup report of the current initialization and of past ones (process boot or POST /api/config), with the outcome and duration of each step",
+				Category:    "system",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "limit", Type: "int", Description: "Past reports to return (default 10, max 50)"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"current": "object|null {id, trigger (boot, config_api), version, started_at, completed_at, duration_ms, completed, steps[]{name, status (ok, warning, failed, skipped), detail, duration_ms}}",
+						"history": "[]object (same shape, newest first)",
+					},
+				},
+			},
+
+			// Capabilities
+			{
+				Method:      "GET",
+				Path:        "/api/auth/me/capabilities",
+				Description: "Capability map for the current user, computed server-side by the same policy evaluation the endpoints enforce. Topic and preset lists only name existing topics and presets; actions over their daily quota are reported as unavailable",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"can_upload_topics":   "array of strings",
+						"can_download_topics": "array of strings",
+						"can_metadata_topics": "array of strings",
+						"can_query_topics":    "array of strings",
+						"can_run_presets":     "array of strings",
+						"can_bulk_download":   "boolean",
+						"can_verify":          "boolean",
+						"can_create_topics":   "boolean",
+						"can_manage_users":    "boolean",
+						"can_create_users":    "boolean",
+						"can_edit_users":      "boolean",
+						"can_view_audit":      "boolean",
+						"can_view_all_audit":  "boolean",
+						"can_stream_audit":    "boolean",
+						"can_manage_config":   "boolean",
+						"limits":              "object keyed by action: {max_file_size_bytes, allowed_extensions, max_assets_per_request, daily_count_limit, daily_volume_bytes, watermark, rate_limit_per_min, rate_limit_burst, used_count_today, used_bytes_today, quota_exceeded}",
+						"endpoints":           "array of \"METHOD pattern\" for the routes with a declared policy that admits the user",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/auth/me/explain",
+				Description: "Explain the policy of the route serving a request: its accepted methods, authentication mode, required grant and recorded audit actions, and whether it would admit the current user. Routes whose handler authorizes itself report no outcome",
+				Category:    "system",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "path", Type: "string", Description: "Request path, with its query string (required)"},
+						{Name: "method", Type: "string", Description: "HTTP method", Default: "GET"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"method":        "string",
+						"path":          "string",
+						"pattern":       "string (route pattern; a trailing slash matches the subtree)",
+						"methods":       "array of strings",
+						"auth":          "string (none, required, public, handler)",
+						"action":        "string (grant required, omitted when authentication is enough)",
+						"topic":         "string (topic the action was evaluated for)",
+						"audit_actions": "array of strings",
+						"allowed":       "boolean (omitted when the handler decides)",
+						"reason":        "string",
+						"denied_code":   "string",
+					},
+				},
+			},
+			// S3 gateway (path-style, under /s3 or at the root of s3.port)
+			{
+				Method:      "GET",
+				Path:        "/s3/",
+				Description: "S3 ListBuckets: the topics the user may query or download from. Gateway requests are signed with Signature Version 4 and answered with S3 XML documents and error codes",
+				Category:    "assets",
+				Response:    &ResponseSpec{ContentType: "application/xml"},
+			},
+			{
+				Method:      "GET",
+				Path:        "/s3/:bucket",
+				Description: "S3 ListObjects and ListObjectsV2 (list-type=2) of a topic: the newest asset under each key, in key order, up to 1000 per page (requires query). Keys are filenames as uploaded, or the hash of assets uploaded without a name. HEAD checks the bucket; ?location returns the region",
+				Category:    "assets",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "prefix", Type: "string", Description: "Only keys starting with this prefix"},
+						{Name: "continuation-token", Type: "string", Description: "NextContinuationToken of the previous page (V2); marker for V1"},
+						{Name: "max-keys", Type: "integer", Description: "Keys per page", Default: "1000"},
+					},
+				},
+				Response: &ResponseSpec{ContentType: "application/xml"},
+			},
+			{
+				Method:      "GET",
+				Path:        "/s3/:bucket/:key",
+				Description: "S3 GetObject (HEAD for HeadObject): the newest non-quarantined asset stored under the filename, or the asset with that hash (requires download). One byte range is honoured and forced watermarks apply",
+				Category:    "assets",
+			},
+			{
+				Method:      "PUT",
+				Path:        "/s3/:bucket/:key",
+				Description: "S3 PutObject: upload the body as an asset named key (requires upload). Signed, unsigned and aws-chunked payloads are verified; keys with path components are refused. The ETag header carries the asset hash. Other S3 operations answer 501 NotImplemented",
+				Category:    "assets",
+			},
+
+			// Break-glass recovery
+			{
+				Method:      "POST",
+				Path:        "/api/auth/recover",
+				Description: "Exchange a one-time recovery token from 'silobang recover-admin' for new bootstrap admin credentials (no auth required)",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"token": "string (required, mbr_ prefix)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"user_id":  "number",
+						"username": "string",
+						"password": "string (shown once)",
+						"api_key":  "string (shown once)",
+					},
+				},
+			},
+
+			// Single sign-on (OpenID Connect)
+			{
+				Method:      "GET",
+				Path:        "/api/auth/oidc/login",
+				Description: "Start a single sign-on: redirects to the identity provider configured under oidc (no auth required)",
+				Category:    "system",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "redirect", Type: "string", Description: "Local path to return to after sign-in (default /)"},
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/auth/oidc/callback",
+				Description: "Identity provider redirect target: verifies the sign-in, provisions or links the user, then redirects to the return path with #sso_code=<one-time code> or #sso_error=<error code>",
+				Category:    "system",
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/auth/oidc/exchange",
+				Description: "Exchange a one-time single sign-on login code (valid one minute) for a session token (no auth required)",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"code": "string (required)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"token": "string (session token)",
+						"user":  "object",
+					},
+				},
+			},
+
+			// User activity
+			{
+				Method:      "GET",
+				Path:        "/api/auth/users/:id/activity",
+				Description: "Chronological timeline of a user's sessions, logins, sampled API-key usage and audited actions, newest first, with the user's most frequent audit actions (requires manage_users and view_audit with can_view_all)",
+				Category:    "system",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "limit", Type: "integer", Description: "Events per page (default 100, max 1000)"},
+						{Name: "offset", Type: "integer", Description: "Events to skip"},
+						{Name: "since", Type: "integer", Description: "Unix timestamp lower bound (inclusive)"},
+						{Name: "until", Type: "integer", Description: "Unix timestamp upper bound (inclusive)"},
+						{Name: "kind", Type: "string", Description: "Only events of this kind: session, login, api_key, audit"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"user_id":     "number",
+						"username":    "string",
+						"events":      "[]{timestamp, kind, action, ip_address, details}",
+						"total":       "number",
+						"limit":       "number",
+						"offset":      "number",
+						"top_actions": "[]{action, count} (within since/until)",
+					},
+				},
+			},
+
+			// API usage
+			{
+				Method:      "GET",
+				Path:        "/api/auth/me/usage",
+				Description: "The current user's authenticated API requests over a window: request and error counts (4xx and 5xx responses), error rate, request and response body bytes (before compression) and the most requested endpoints, with hashes, IDs and topic names replaced by :hash, :id and :topic. Usage is aggregated per hour, so windows start on an hour boundary",
+				Category:    "system",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "window", Type: "string", Description: "1h, 24h (default), 7d or 30d"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"user_id":       "number",
+						"username":      "string",
+						"window":        "string",
+						"since":         "number (unix timestamp, start of the first hour counted)",
+						"until":         "number (unix timestamp)",
+						"requests":      "number",
+						"errors":        "number",
+						"error_rate":    "number (0-1)",
+						"bytes_in":      "number",
+						"bytes_out":     "number",
+						"top_endpoints": "[]{endpoint, requests, errors, error_rate, bytes_in, bytes_out} (top 10)",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/auth/users/:id/usage",
+				Description: "A user's API usage, as GET /api/auth/me/usage reports it (requires manage_users)",
+				Category:    "system",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "window", Type: "string", Description: "1h, 24h (default), 7d or 30d"},
+					},
+				},
+			},
+
+			// Auth snapshots
+			{
+				Method:      "GET",
+				Path:        "/api/auth/export",
+				Description: "Snapshot of every account but the bootstrap user, with display name, account type, active flag and active grants (constraints as objects). Holds no passwords, API keys or tokens. Roles are not exported: imports expand them into per-user grants (requires manage_users)",
+				Category:    "system",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "format", Type: "string", Description: "yaml (default) or json"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/yaml",
+					Body: map[string]interface{}{
+						"version": "number (1)",
+						"users":   "[]{username, display_name, account_type, active, grants: []{action, constraints}}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/auth/import",
+				Description: "Make accounts and grants match a snapshot in YAML or JSON, as exported plus optional roles (named grant sets users list under roles). Missing accounts are created and issued an API key, shown once; display names, active flags and grants of existing accounts are brought in line, each grant checked as if created by hand. Unknown fields, roles or actions reject the whole import. Importing the same snapshot again changes nothing. Audited as auth_imported (requires manage_users with can_create and can_edit, and can_disable with prune)",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/yaml",
+					Params: []ParamSpec{
+						{Name: "dry_run", Type: "boolean", Description: "true to report the changes without applying them"},
+						{Name: "prune", Type: "boolean", Description: "true to disable accounts missing from the snapshot"},
+					},
+					Body: map[string]interface{}{
+						"version": "number (required, 1)",
+						"roles":   "[]{name, grants: []{action, constraints}} (optional)",
+						"users":   "[]{username, display_name, account_type (user|service), active (default true), roles: []string, grants: []{action, constraints}}",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"dry_run":     "boolean",
+						"prune":       "boolean",
+						"changes":     "[]{type (user_created|user_updated|user_disabled|grant_created|grant_revoked), username, action, constraints_json, detail}",
+						"credentials": "[]{username, api_key} (created accounts, shown once)",
+					},
+				},
+			},
+
+			// Grant batches
+			{
+				Method:      "POST",
+				Path:        "/api/auth/grants/batch",
+				Description: "Create many grants, across users, in one transaction; any invalid entry rejects the whole batch (requires manage_users)",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"grants": "[]{user_id, action, constraints_json} (required, max 500)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"grants": "[]Grant",
+						"count":  "number",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/auth/users/:id/grants/copy-from/:src",
+				Description: "Copy another user's active grants to this user, optionally replacing constraints per action (requires manage_users)",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"overrides": "object (optional) {action: constraints_json or null}",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"grants": "[]Grant",
+						"count":  "number",
+					},
+				},
+			},
+
+			// Service accounts
+			{
+				Method:      "POST",
+				Path:        "/api/auth/service-accounts",
+				Description: "Create a non-interactive service account for a processor. Scopes may only use upload, download, query, metadata, bulk_download and verify; metadata scopes can limit writes to key namespaces with allowed_key_prefixes. Service accounts cannot log in with a password and their audit entries carry actor_type service (requires manage_users with can_create)",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"name":         "string (required, same rules as usernames)",
+						"display_name": "string (optional)",
+						"scopes":       "[]{action, constraints_json} (required)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"account":      "User (account_type service)",
+						"scopes":       "[]Grant",
+						"token":        "string (shown once)",
+						"token_prefix": "string",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/auth/service-accounts",
+				Description: "List service accounts; they are not included in /api/auth/users (requires manage_users)",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"service_accounts": "[]User",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/auth/service-accounts/:id",
+				Description: "Get a service account with its scopes and token prefix (requires manage_users)",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"account":      "User",
+						"scopes":       "[]Grant",
+						"token_prefix": "string",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/auth/service-accounts/:id/rotate",
+				Description: "Replace the service account's token; the old token stops working immediately (requires manage_users with can_edit)",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"account":      "User",
+						"token":        "string (shown once)",
+						"token_prefix": "string",
+					},
+				},
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/auth/service-accounts/:id",
+				Description: "Disable a service account; its token is rejected from then on (requires manage_users with can_disable)",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"success": "boolean",
+					},
+				},
+			},
+
+			// Workspace tokens (CI pipelines)
+			{
+				Method:      "POST",
+				Path:        "/api/auth/workspace-tokens",
+				Description: "Issue a workspace token to a CI pipeline, creating the pipeline account on first use. Scopes follow the service account rules. The token is only accepted by /api/auth/workspace-tokens/exchange, which each job calls at start for a short-lived job token; audit entries of jobs carry actor_type pipeline (requires manage_users with can_create)",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"pipeline":    "string (required, same rules as usernames; must not name a user or service account)",
+						"description": "string (optional, at most 200 characters)",
+						"ttl_hours":   "int (required, 1 to 2160)",
+						"scopes":      "[]{action, constraints_json} (required)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"id":           "int",
+						"pipeline":     "string",
+						"token":        "string (mbw_..., shown once)",
+						"token_prefix": "string",
+						"scopes":       "[]{action, constraints_json}",
+						"expires_at":   "int (unix seconds)",
+						"status":       "active",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/auth/workspace-tokens",
+				Description: "List workspace tokens, newest first, with status (active, expired or revoked), exchange count and last use by any of their job tokens (requires manage_users)",
+				Category:    "system",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "pipeline", Type: "string", Description: "Only the tokens of this pipeline"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"workspace_tokens": "[]{id, account_id, pipeline, description, token_prefix, scopes, created_by, created_at, expires_at, last_used_at, last_used_ip, exchange_count, revoked_at, revoked_by, status}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/auth/workspace-tokens/revoke",
+				Description: "Revoke workspace tokens in bulk, by ID, by pipeline or all of them; job tokens exchanged for them stop working immediately. Set exactly one selector (requires manage_users with can_disable)",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"ids":      "[]int (optional)",
+						"pipeline": "string (optional)",
+						"all":      "boolean (optional)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"revoked": "[]int (IDs of the tokens revoked; already revoked tokens are skipped)",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/auth/workspace-tokens/exchange",
+				Description: "Exchange the workspace token sent as Authorization: Bearer mbw_... for a job token (mbj_...) carrying its scopes, sent as Authorization: Bearer or the token query parameter. Job tokens last ttl_secs (default 1 hour, at most 24 hours) and never outlive the workspace token. Recorded as workspace_token_exchanged under the pipeline's name",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"job":      "string (optional, CI job name or URL, at most 200 characters)",
+						"ttl_secs": "int (optional)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"token":                  "string (mbj_...)",
+						"token_prefix":           "string",
+						"expires_at":             "int (unix seconds)",
+						"pipeline":               "string",
+						"job":                    "string",
+						"actions":                "[]string",
+						"workspace_token_id":     "int",
+						"workspace_token_prefix": "string",
+					},
+				},
+			},
+
+			// API keys (expiration and rotation)
+			{
+				Method:      "GET",
+				Path:        "/api/auth/me/api-keys",
+				Description: "List the caller's API keys: the key on the account (label primary) first, then labelled keys, newest first, with status (active, expired or revoked). Expired and revoked keys are listed for 30 days. Admins use /api/auth/users/:id/api-keys (requires manage_users)",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"api_keys": "[]{id, user_id, label, primary, key_prefix, created_by, created_at, expires_at, revoked_at, revoked_by, replaced_by, status}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/auth/me/api-keys",
+				Description: "Issue an additional API key carrying the caller's grants, at most 20 active keys per user. Without ttl_days the key lasts auth.api_key_ttl_days; requests with an expired key are refused with 401 AUTH_API_KEY_EXPIRED and responses to key requests carry X-API-Key-Expires-At. Admins use /api/auth/users/:id/api-keys (requires manage_users with can_edit)",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"label":    "string (required, at most 64 characters, not primary)",
+						"ttl_days": "int (optional, 0 for no expiry unless auth.api_key_max_ttl_days is set)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"id":         "int",
+						"label":      "string",
+						"key":        "string (shown once)",
+						"key_prefix": "string",
+						"expires_at": "int (unix seconds, omitted when the key never expires)",
+						"status":     "active",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/auth/me/api-keys/:id/rotate",
+				Description: "Replace an API key, :id being a labelled key's ID or primary for the key on the account. Rotating the key on the account also returns its S3 gateway credentials when s3.enabled, which are not shown again. The old key keeps working until grace_until (default auth.api_key_rotation_grace_hours, at most 30 days) so clients can switch over. Admins use /api/auth/users/:id/api-keys/:key/rotate (requires manage_users with can_edit)",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"grace_secs": "int (optional)",
+						"ttl_days":   "int (optional, lifetime of the new key)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"id":          "int (omitted for the key on the account)",
+						"label":       "string",
+						"key":         "string (shown once)",
+						"key_prefix":  "string",
+						"expires_at":  "int (unix seconds, omitted when the key never expires)",
+						"previous":    "APIKey (the replaced key)",
+						"grace_until": "int (unix seconds)",
+						"s3_credentials": map[string]interface{}{
+							"access_key_id":     "string (SBAK followed by the zero-padded user ID)",
+							"secret_access_key": "string (shown once)",
+							"region":            "string",
+							"endpoint":          "string (/s3 on this server, or the s3.port listener)",
+						},
+					},
+				},
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/auth/me/api-keys/:id",
+				Description: "Revoke a labelled API key immediately; the key on the account is regenerated or rotated instead. Admins use /api/auth/users/:id/api-keys/:key (requires manage_users with can_edit)",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"id":         "int",
+						"label":      "string",
+						"key_prefix": "string",
+						"revoked_at": "int (unix seconds)",
+						"status":     "revoked",
+					},
+				},
+			},
+
+			// Audit export
+			{
+				Method:      "GET",
+				Path:        "/api/audit/export",
+				Description: "Stream every audit entry matching the /api/audit filters, oldest first and without pagination, for archiving outside the orchestrator DB (requires view_audit; without can_view_all, filter=others|all is narrowed to the caller's entries). Each export is recorded as an audit_exported entry",
+				Category:    "system",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "format", Type: "string", Description: "csv or jsonl", Default: "jsonl"},
+						{Name: "action", Type: "string", Description: "Only this action"},
+						{Name: "username", Type: "string", Description: "Only this username"},
+						{Name: "ip", Type: "string", Description: "Only this IP address"},
+						{Name: "actor_type", Type: "string", Description: "user, service, pipeline or system"},
+						{Name: "filter", Type: "string", Description: "me, others or all"},
+						{Name: "since", Type: "integer", Description: "Unix timestamp lower bound (inclusive)"},
+						{Name: "until", Type: "integer", Description: "Unix timestamp upper bound (inclusive)"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/x-ndjson or text/csv",
+					Body: map[string]interface{}{
+						"line": "{id, timestamp, action, ip_address, username, actor_type, details, prev_hash, entry_hash} (CSV: header row, details as details_json)",
+					},
+				},
+			},
+
+			{
+				Method:      "GET",
+				Path:        "/api/audit/verify",
+				Description: "Walk the audit hash chain oldest first and report the first broken link: an entry edited after it was written, a prev_hash not matching the entry before it, or entries missing without a recorded purge (requires view_audit). Entries written before chaining are counted but not checked",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"intact":         "boolean",
+						"verified":       "number (chained entries checked)",
+						"legacy_entries": "number (entries written before chaining)",
+						"purged_runs":    "number (runs of entries removed by purges, crossed by their recorded hashes)",
+						"purged_entries": "number",
+						"head_id":        "number",
+						"head_hash":      "string",
+						"broken":         "{id, reason, expected, actual} (only when intact is false)",
+					},
+				},
+			},
+
+			// Audit retention
+			{
+				Method:      "GET",
+				Path:        "/api/audit/purge/preview",
+				Description: "Report what an audit purge would remove now (entries past their per-action retention, then the oldest entries if the size limit is hit), without deleting anything (requires manage_config)",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"dry_run":        "boolean (true)",
+						"ran_at":         "number",
+						"deleted":        "number (entries that would be removed)",
+						"held_entries":   "number (entries protected by active legal holds)",
+						"size_limit_hit": "boolean",
+						"ranges":         "[]{reason (retention|size), action, count, first_id, last_id, first_timestamp, last_timestamp}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/audit/purge",
+				Description: "Run an audit purge now; the run is recorded as an audit_purged entry with the removed ranges (requires manage_config)",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"trigger":        "string (manual)",
+						"deleted":        "number",
+						"held_entries":   "number",
+						"size_limit_hit": "boolean",
+						"ranges":         "[]{reason, action, count, first_id, last_id, first_timestamp, last_timestamp}",
+					},
+				},
+			},
+			{
+				Method:      "GET",
+				Path:        "/api/audit/holds",
+				Description: "List legal holds (requires manage_config)",
+				Category:    "system",
+				Request: &RequestSpec{
+					Params: []ParamSpec{
+						{Name: "include_released", Type: "boolean", Description: "Include released holds"},
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"holds": "[]{id, name, reason, username, action, since, until, created_by, created_at, released_by, released_at}",
+					},
+				},
+			},
+			{
+				Method:      "POST",
+				Path:        "/api/audit/holds",
+				Description: "Create a legal hold: audit entries matching every given criterion are never purged until the hold is released (requires manage_config)",
+				Category:    "system",
+				Request: &RequestSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"name":     "string (required, max 128)",
+						"reason":   "string (optional, max 1024)",
+						"username": "string (optional)",
+						"action":   "string (optional, audit action)",
+						"since":    "number (optional, Unix timestamp)",
+						"until":    "number (optional, Unix timestamp)",
+					},
+				},
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"id":   "number",
+						"name": "string",
+					},
+				},
+			},
+			{
+				Method:      "DELETE",
+				Path:        "/api/audit/holds/:id",
+				Description: "Release a legal hold; its entries become eligible for the next purge (requires manage_config)",
+				Category:    "system",
+				Response: &ResponseSpec{
+					ContentType: "application/json",
+					Body: map[string]interface{}{
+						"id":          "number",
+						"released_by": "string",
+						"released_at": "number",
+					},
+				},
 			},
 		},
 	}
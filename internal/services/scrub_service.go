@@ -0,0 +1,499 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zeebo/blake3"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// ScrubService is the integrity scrubber. A pass re-reads every live asset
+// of the healthy topics from its .dat file, locally or in the topic's blob
+// store, and recomputes its BLAKE3 hash. Damaged assets are recorded in the
+// asset_health table, and assets whose content no longer matches their hash
+// are quarantined. Each topic checked is recorded as a verified audit entry.
+type ScrubService struct {
+	app        AppState
+	logger     *logger.Logger
+	quarantine *QuarantineService
+	blobStores *BlobStoreService
+
+	mu       sync.Mutex
+	progress *ScrubProgress // pass in progress, nil when idle
+	last     *ScrubResult
+
+	schedMu   sync.Mutex
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+	scheduled bool
+}
+
+// ScrubProgress reports how far the running pass has come.
+type ScrubProgress struct {
+	Trigger       string `json:"trigger"`
+	StartedAt     int64  `json:"started_at"`
+	Topic         string `json:"topic"` // topic being read
+	TopicsDone    int    `json:"topics_done"`
+	TopicsTotal   int    `json:"topics_total"`
+	AssetsChecked int    `json:"assets_checked"`
+	BytesRead     int64  `json:"bytes_read"`
+	Corrupt       int    `json:"corrupt"`
+	Missing       int    `json:"missing"`
+}
+
+// ScrubTopicResult is the outcome of scrubbing one topic.
+type ScrubTopicResult struct {
+	Topic         string `json:"topic"`
+	AssetsChecked int    `json:"assets_checked"`
+	BytesRead     int64  `json:"bytes_read"`
+	Corrupt       int    `json:"corrupt"`
+	Missing       int    `json:"missing"`
+	Quarantined   int    `json:"quarantined"`
+	DurationMs    int64  `json:"duration_ms"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ScrubResult is the outcome of a pass.
+type ScrubResult struct {
+	Trigger       string             `json:"trigger"`
+	StartedAt     int64              `json:"started_at"`
+	CompletedAt   int64              `json:"completed_at"`
+	DurationMs    int64              `json:"duration_ms"`
+	Cancelled     bool               `json:"cancelled,omitempty"`
+	Topics        []ScrubTopicResult `json:"topics"`
+	AssetsChecked int                `json:"assets_checked"`
+	BytesRead     int64              `json:"bytes_read"`
+	Corrupt       int                `json:"corrupt"`
+	Missing       int                `json:"missing"`
+}
+
+// ScrubStatus is the scrubber's schedule, the running pass, the latest
+// completed one and the damaged assets recorded so far.
+type ScrubStatus struct {
+	Enabled        bool                        `json:"enabled"`
+	IntervalHours  int                         `json:"interval_hours"`
+	MaxBytesPerSec int64                       `json:"max_bytes_per_sec"`
+	Running        bool                        `json:"running"`
+	Progress       *ScrubProgress              `json:"progress"`
+	LastRun        *ScrubResult                `json:"last_run"`
+	UnhealthyTotal int                         `json:"unhealthy_total"`
+	Unhealthy      []database.AssetHealthEntry `json:"unhealthy"` // newest first, up to constants.ScrubMaxFindingsInStatus
+}
+
+// NewScrubService creates a new scrub service instance.
+func NewScrubService(app AppState, log *logger.Logger) *ScrubService {
+	return &ScrubService{
+		app:    app,
+		logger: log,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// SetQuarantineService sets the quarantine service used to withhold assets
+// whose content no longer matches their hash.
+func (s *ScrubService) SetQuarantineService(q *QuarantineService) {
+	s.quarantine = q
+}
+
+// SetBlobStoreService sets the service reading .dat files offloaded to blob
+// stores.
+func (s *ScrubService) SetBlobStoreService(blobStores *BlobStoreService) {
+	s.blobStores = blobStores
+}
+
+// Status returns the scrubber's state and the recorded damaged assets.
+func (s *ScrubService) Status() (*ScrubStatus, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+	cfg := s.app.GetConfig().Scrub
+	status := &ScrubStatus{
+		Enabled:        !cfg.Disabled,
+		IntervalHours:  cfg.IntervalHours,
+		MaxBytesPerSec: cfg.MaxBytesPerSec,
+	}
+
+	var err error
+	status.Unhealthy, status.UnhealthyTotal, err = database.ListAssetHealth(orchDB, constants.ScrubMaxFindingsInStatus)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.progress != nil {
+		progress := *s.progress
+		status.Running = true
+		status.Progress = &progress
+	}
+	status.LastRun = s.last
+	return status, nil
+}
+
+// Trigger starts a pass over all healthy topics in the background.
+// username and ipAddress identify the caller in the audit log. Only one
+// pass runs at a time.
+func (s *ScrubService) Trigger(username, ipAddress string) error {
+	if s.app.GetOrchestratorDB() == nil {
+		return ErrNotConfigured
+	}
+	if !s.acquire(constants.ScrubTriggerManual) {
+		return NewServiceError(constants.ErrCodeScrubInProgress, "a scrub pass is already running")
+	}
+
+	go func() {
+		defer s.release()
+		ctx, cancel := s.passContext()
+		defer cancel()
+		s.scrubAll(ctx, username, ipAddress)
+	}()
+	return nil
+}
+
+// Run performs a pass synchronously.
+func (s *ScrubService) Run(ctx context.Context, trigger string) (*ScrubResult, error) {
+	if s.app.GetOrchestratorDB() == nil {
+		return nil, ErrNotConfigured
+	}
+	if !s.acquire(trigger) {
+		return nil, NewServiceError(constants.ErrCodeScrubInProgress, "a scrub pass is already running")
+	}
+	defer s.release()
+
+	return s.scrubAll(ctx, "", constants.AuditActorSystem), nil
+}
+
+// Start launches the scheduled scrub pass. Safe to call multiple times —
+// subsequent calls are no-ops.
+func (s *ScrubService) Start(interval time.Duration) {
+	s.schedMu.Lock()
+	if s.scheduled {
+		s.schedMu.Unlock()
+		return
+	}
+	s.scheduled = true
+	s.schedMu.Unlock()
+
+	s.logger.Info("[scrub] scheduled scrubbing started (interval: %v)", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				s.logger.Info("[scrub] scheduled scrubbing stopped")
+				return
+			case <-ticker.C:
+				ctx, cancel := s.passContext()
+				if _, err := s.Run(ctx, constants.ScrubTriggerScheduled); err != nil {
+					s.logger.Warn("[scrub] scheduled pass skipped: %v", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop ends scheduled scrubbing and cancels a running pass. Topics finished
+// before the cancellation keep their findings.
+func (s *ScrubService) Stop() {
+	s.schedMu.Lock()
+	s.scheduled = false
+	s.schedMu.Unlock()
+	s.stopOnce.Do(func() { close(s.stopCh) })
+}
+
+// passContext returns a context cancelled by Stop.
+func (s *ScrubService) passContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-s.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+func (s *ScrubService) acquire(trigger string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.progress != nil {
+		return false
+	}
+	s.progress = &ScrubProgress{Trigger: trigger, StartedAt: time.Now().Unix()}
+	return true
+}
+
+func (s *ScrubService) release() {
+	s.mu.Lock()
+	s.progress = nil
+	s.mu.Unlock()
+}
+
+// scrubAll scrubs every healthy topic in name order and records the result
+// as the latest pass.
+func (s *ScrubService) scrubAll(ctx context.Context, username, ipAddress string) *ScrubResult {
+	started := time.Now()
+	var topics []string
+	for _, name := range s.app.ListTopics() {
+		if healthy, _ := s.app.IsTopicHealthy(name); healthy {
+			topics = append(topics, name)
+		}
+	}
+	sort.Strings(topics)
+
+	s.mu.Lock()
+	result := &ScrubResult{Trigger: s.progress.Trigger, StartedAt: started.Unix(), Topics: make([]ScrubTopicResult, 0, len(topics))}
+	s.progress.TopicsTotal = len(topics)
+	s.mu.Unlock()
+
+	throttle := &scrubThrottle{rate: s.app.GetConfig().Scrub.MaxBytesPerSec, start: started}
+	for _, topic := range topics {
+		if ctx.Err() != nil {
+			result.Cancelled = true
+			break
+		}
+		s.updateProgress(func(p *ScrubProgress) { p.Topic = topic })
+
+		tr := s.scrubTopic(ctx, topic, throttle)
+		if ctx.Err() != nil {
+			result.Cancelled = true
+		}
+		result.Topics = append(result.Topics, tr)
+		result.AssetsChecked += tr.AssetsChecked
+		result.BytesRead += tr.BytesRead
+		result.Corrupt += tr.Corrupt
+		result.Missing += tr.Missing
+		s.updateProgress(func(p *ScrubProgress) { p.TopicsDone++ })
+
+		if tr.Error == "" && !result.Cancelled {
+			s.logVerified(tr, username, ipAddress)
+		}
+	}
+
+	completed := time.Now()
+	result.CompletedAt = completed.Unix()
+	result.DurationMs = completed.Sub(started).Milliseconds()
+	s.logger.Info("[scrub] pass complete: %d topics, %d assets, %d bytes, %d corrupt, %d missing, cancelled=%t, duration=%dms",
+		len(result.Topics), result.AssetsChecked, result.BytesRead, result.Corrupt, result.Missing, result.Cancelled, result.DurationMs)
+
+	s.mu.Lock()
+	s.last = result
+	s.mu.Unlock()
+	return result
+}
+
+// scrubTopic reads and hashes every live asset of a topic, archive stubs
+// aside. The damaged assets found replace the topic's asset_health rows
+// unless the pass is cancelled before the topic is finished.
+func (s *ScrubService) scrubTopic(ctx context.Context, topicName string, throttle *scrubThrottle) ScrubTopicResult {
+	started := time.Now()
+	result := ScrubTopicResult{Topic: topicName}
+	fail := func(err error) ScrubTopicResult {
+		result.Error = err.Error()
+		result.DurationMs = time.Since(started).Milliseconds()
+		s.logger.Warn("[scrub] topic %s: %v", topicName, err)
+		return result
+	}
+
+	db, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return fail(err)
+	}
+	assets, err := database.ListAssetExtents(db)
+	if err != nil {
+		return fail(fmt.Errorf("failed to list assets: %w", err))
+	}
+
+	var findings []database.AssetHealthEntry
+	for _, asset := range assets {
+		if ctx.Err() != nil {
+			return fail(ctx.Err())
+		}
+		if asset.BlobName == constants.ArchiveBlobName {
+			continue // content lives in the archive, not in the topic
+		}
+		status, detail, n, found := s.checkAsset(ctx, topicName, db, asset, throttle)
+		if !found {
+			continue // deleted while the pass ran, or the pass was cancelled
+		}
+		result.AssetsChecked++
+		result.BytesRead += n
+
+		switch status {
+		case constants.AssetHealthCorrupt:
+			result.Corrupt++
+			if s.quarantineCorrupt(asset.AssetID, detail) {
+				result.Quarantined++
+			}
+		case constants.AssetHealthMissing:
+			result.Missing++
+		}
+		if status != "" {
+			s.logger.Warn("[scrub] asset %s in topic %s is %s: %s", asset.AssetID, topicName, status, detail)
+			findings = append(findings, database.AssetHealthEntry{Hash: asset.AssetID, Status: status, Detail: detail})
+		}
+		s.updateProgress(func(p *ScrubProgress) {
+			p.AssetsChecked++
+			p.BytesRead += n
+			switch status {
+			case constants.AssetHealthCorrupt:
+				p.Corrupt++
+			case constants.AssetHealthMissing:
+				p.Missing++
+			}
+		})
+	}
+
+	if err := database.ReplaceTopicAssetHealth(s.app.GetOrchestratorDB(), topicName, findings, started.Unix()); err != nil {
+		return fail(fmt.Errorf("failed to record asset health: %w", err))
+	}
+	result.DurationMs = time.Since(started).Milliseconds()
+	return result
+}
+
+// checkAsset reads an asset and compares its content with its hash. status
+// is empty for a healthy asset. found is false when the asset was deleted
+// after the topic's assets were listed or ctx is done.
+func (s *ScrubService) checkAsset(ctx context.Context, topicName string, db *sql.DB, asset database.Asset, throttle *scrubThrottle) (status, detail string, n int64, found bool) {
+	reader, err := s.blobStores.OpenData(topicName, &asset)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Compaction removed the .dat file after the assets were listed;
+		// the row now names the entry's new location
+		moved, getErr := database.GetAsset(db, asset.AssetID)
+		if getErr == nil && moved == nil {
+			return "", "", 0, false
+		}
+		if getErr == nil {
+			reader, err = s.blobStores.OpenData(topicName, moved)
+		}
+	}
+	if err != nil {
+		return constants.AssetHealthMissing, fmt.Sprintf("%s: %v", asset.BlobName, err), 0, true
+	}
+	defer reader.Close()
+
+	hasher := blake3.New()
+	n, err = io.Copy(&throttledWriter{ctx: ctx, w: hasher, throttle: throttle}, reader)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", "", n, false
+		}
+		return constants.AssetHealthMissing, fmt.Sprintf("%s: read failed after %d bytes: %v", asset.BlobName, n, err), n, true
+	}
+	if n != asset.AssetSize {
+		return constants.AssetHealthCorrupt, fmt.Sprintf("%s: read %d of %d bytes", asset.BlobName, n, asset.AssetSize), n, true
+	}
+	if sum := hex.EncodeToString(hasher.Sum(nil)); sum != asset.AssetID {
+		return constants.AssetHealthCorrupt, fmt.Sprintf("%s: content hashes to %s", asset.BlobName, sum), n, true
+	}
+	return "", "", n, true
+}
+
+// quarantineCorrupt withholds an asset whose content no longer matches its
+// hash. Returns whether the asset is quarantined.
+func (s *ScrubService) quarantineCorrupt(hash, detail string) bool {
+	if s.quarantine == nil {
+		return false
+	}
+	reason := "scrub: " + detail
+	if _, err := s.quarantine.Quarantine(hash, constants.QuarantineSourceIntegrity, reason, "", constants.AuditActorSystem); err != nil {
+		s.logger.Error("Failed to quarantine corrupt asset %s: %v", hash, err)
+		return false
+	}
+	return true
+}
+
+// logVerified records a scrubbed topic in the audit log.
+func (s *ScrubService) logVerified(tr ScrubTopicResult, username, ipAddress string) {
+	l := s.app.GetAuditLogger()
+	if l == nil {
+		return
+	}
+	valid := 0
+	if tr.Corrupt == 0 && tr.Missing == 0 {
+		valid = 1
+	}
+	if err := l.Log(constants.AuditActionVerified, ipAddress, username, audit.VerifiedDetails{
+		TopicsChecked: 1,
+		TopicsValid:   valid,
+		DurationMs:    int(tr.DurationMs),
+		Source:        constants.ScrubSource,
+		Topic:         tr.Topic,
+		AssetsChecked: tr.AssetsChecked,
+		Corrupt:       tr.Corrupt,
+		Missing:       tr.Missing,
+		BytesRead:     tr.BytesRead,
+	}); err != nil {
+		s.logger.Error("Failed to write audit entry for scrub of topic %s: %v", tr.Topic, err)
+	}
+}
+
+func (s *ScrubService) updateProgress(update func(p *ScrubProgress)) {
+	s.mu.Lock()
+	if s.progress != nil {
+		update(s.progress)
+	}
+	s.mu.Unlock()
+}
+
+// scrubThrottle paces a pass's reads to rate bytes per second, measured
+// from the start of the pass. A rate of 0 does not throttle.
+type scrubThrottle struct {
+	rate  int64
+	start time.Time
+	bytes int64
+}
+
+// wait blocks until n more bytes fit in the rate, or ctx is done.
+func (t *scrubThrottle) wait(ctx context.Context, n int) error {
+	if t.rate <= 0 {
+		return ctx.Err()
+	}
+	t.bytes += int64(n)
+	due := t.start.Add(time.Duration(float64(t.bytes) / float64(t.rate) * float64(time.Second)))
+	delay := time.Until(due)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledWriter passes writes through a scrubThrottle.
+type throttledWriter struct {
+	ctx      context.Context
+	w        io.Writer
+	throttle *scrubThrottle
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	if err := w.throttle.wait(w.ctx, len(p)); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// SearchResult is an asset matching a search, with the topic storing it.
+type SearchResult struct {
+	database.SearchHit
+	Topic string `json:"topic"`
+}
+
+// SearchResults lists the best matches of a search across topics.
+type SearchResults struct {
+	Query          string         `json:"query"`
+	Results        []SearchResult `json:"results"`
+	TopicsSearched []string       `json:"topics_searched"`
+}
+
+// SearchService finds assets by words of their origin names, extensions
+// and metadata values, using the full-text index each topic database keeps
+// up to date on every write. Quarantined and trashed assets are never
+// returned.
+type SearchService struct {
+	app    AppState
+	logger *logger.Logger
+}
+
+// NewSearchService creates a new SearchService instance.
+func NewSearchService(app AppState, log *logger.Logger) *SearchService {
+	return &SearchService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// Search returns the limit assets best matching q in the healthy topics
+// visible accepts, best first. Every word of q must match, as a prefix.
+func (s *SearchService) Search(q string, limit int, visible func(topic string) bool) (*SearchResults, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, NewServiceError(constants.ErrCodeNotConfigured, "orchestrator database not available")
+	}
+	if !s.app.GetConfig().Features.Enabled(constants.FeatureSearch) {
+		return nil, NewServiceError(constants.ErrCodeFeatureDisabled, "search is disabled by features.disabled")
+	}
+	if !database.FTS5Supported(orchDB) {
+		return nil, NewServiceError(constants.ErrCodeSearchUnavailable, "search requires a build with the sqlite_fts5 tag")
+	}
+
+	match, err := searchMatch(q)
+	if err != nil {
+		return nil, err
+	}
+	withheld, err := withheldHashes(s.app)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &SearchResults{
+		Query:          strings.TrimSpace(q),
+		Results:        make([]SearchResult, 0),
+		TopicsSearched: make([]string, 0),
+	}
+	for _, topic := range s.app.ListTopics() {
+		if !visible(topic) {
+			continue
+		}
+		if healthy, _ := s.app.IsTopicHealthy(topic); !healthy {
+			continue
+		}
+		topicDB, err := s.app.GetTopicDB(topic)
+		if err != nil {
+			continue
+		}
+		hits, err := database.SearchAssets(topicDB, match, limit+len(withheld))
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		results.TopicsSearched = append(results.TopicsSearched, topic)
+		for _, hit := range hits {
+			if !withheld[hit.Hash] {
+				results.Results = append(results.Results, SearchResult{SearchHit: hit, Topic: topic})
+			}
+		}
+	}
+
+	sort.SliceStable(results.Results, func(i, j int) bool {
+		return results.Results[i].Score > results.Results[j].Score
+	})
+	if len(results.Results) > limit {
+		results.Results = results.Results[:limit]
+	}
+	sort.Strings(results.TopicsSearched)
+	return results, nil
+}
+
+// searchMatch turns a user query into an FTS5 query matching every word as
+// a prefix. Words are quoted, so FTS5 operators in q are searched as text.
+func searchMatch(q string) (string, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return "", NewServiceError(constants.ErrCodeInvalidRequest, "q is required")
+	}
+	if len(q) > constants.SearchMaxQueryLength {
+		return "", NewServiceError(constants.ErrCodeInvalidRequest, "q is too long")
+	}
+	words := strings.Fields(q)
+	if len(words) > constants.SearchMaxTerms {
+		return "", NewServiceError(constants.ErrCodeInvalidRequest, "q has too many words")
+	}
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"*`
+	}
+	return strings.Join(terms, " "), nil
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestSearchMatch(t *testing.T) {
+	cases := map[string]string{
+		"dragon":           `"dragon"*`,
+		"  red   dragon ":  `"red"* "dragon"*`,
+		`say "hi" OR bye*`: `"say"* """hi"""* "OR"* "bye*"*`,
+		"café-sketch.png":  `"café-sketch.png"*`,
+	}
+	for q, want := range cases {
+		got, err := searchMatch(q)
+		if err != nil {
+			t.Fatalf("searchMatch(%q): %v", q, err)
+		}
+		if got != want {
+			t.Errorf("searchMatch(%q) = %s, want %s", q, got, want)
+		}
+	}
+
+	for _, q := range []string{"", "   ", strings.Repeat("a", constants.SearchMaxQueryLength+1), strings.Repeat("a ", constants.SearchMaxTerms+1)} {
+		if _, err := searchMatch(q); !isServiceErrorCode(err, constants.ErrCodeInvalidRequest) {
+			t.Errorf("searchMatch(%.20q) = %v, want %s", q, err, constants.ErrCodeInvalidRequest)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"silobang/internal/audit"
 	"silobang/internal/config"
+	"silobang/internal/database"
 	"silobang/internal/logger"
 	"silobang/internal/prompts"
 	"silobang/internal/queries"
@@ -20,6 +21,8 @@ import (
 type AppState interface {
 	// Database access
 	GetOrchestratorDB() *sql.DB
+	GetAssetIndex() database.AssetIndex
+	GetAuditStore() audit.Store
 	GetTopicDB(topicName string) (*sql.DB, error)
 	GetTopicDBsForQuery(topicNames []string) (map[string]*sql.DB, []string, error)
 	StoreTopicDB(name string, db *sql.DB)
@@ -47,6 +50,8 @@ type AppState interface {
 	GetAuditLogger() *audit.Logger
 	SetOrchestratorDB(db *sql.DB)
 	GetStartedAt() time.Time
+	GetStartupRecorder() *StartupRecorder
+	SetStartupRecorder(r *StartupRecorder)
 
 	// Concurrency control
 	GetTopicWriteMu(topicName string) *sync.Mutex
@@ -64,13 +69,72 @@ type Services struct {
 	Auth       *AuthService
 	Config     *ConfigService
 	Metadata   *MetadataService
+	Import     *MetadataImportService
 	Query      *QueryService
+	Collection *CollectionService
 	Bulk       *BulkService
 	Verify     *VerifyService
 	Schema     *SchemaService
 	Monitoring *MonitoringService
 	Reconcile  *ReconcileService
 	StatsCache *StatsCache
+	ChunkDedup *ChunkDedupService
+	Compaction *CompactionService
+	Scrub      *ScrubService
+	Backup     *BackupService
+	BlobStores *BlobStoreService
+	Lineage    *LineageService
+	References *ReferenceService
+	Timeline   *TimelineService
+	Sync       *SyncService
+	Integrity  *IntegrityService
+	Federation *FederationService
+	Limits     *LimitsService
+	Watermark  *WatermarkService
+	Health     *HealthService
+	Policy     *StoragePolicyService
+	Previews   *PreviewService
+	Validation *ValidationService
+	Quarantine *QuarantineService
+	Archive    *ArchiveService
+	Deletions  *DeletionRequestService
+	Trash      *TrashService
+	Retention  *RetentionService
+	Quotas     *TopicQuotaService
+	Freeze     *TopicFreezeService
+	Events     *EventService
+	Discovery  *DiscoveryService
+	Search     *SearchService
+	Setup      *SetupService
+	Debug      *DebugService
+	Fetch      *FetchService
+
+	// Notification is nil when the orchestrator DB is not available
+	Notification *NotificationService
+
+	// Idempotency is nil when the orchestrator DB is not available
+	Idempotency *IdempotencyService
+
+	// Export is nil when the orchestrator DB is not available
+	Export *ExportService
+
+	// LinkExports is nil when the orchestrator DB is not available
+	LinkExports *LinkExportService
+
+	// Uploads is nil when the orchestrator DB is not available
+	Uploads *UploadSessionService
+
+	// WatchFolders is nil when the orchestrator DB is not available
+	WatchFolders *WatchFolderService
+
+	// Webhooks is nil when the orchestrator DB is not available
+	Webhooks *WebhookService
+
+	// Rules is nil when the orchestrator DB is not available
+	Rules *RuleService
+
+	// OriginCache is nil when the orchestrator DB is not available
+	OriginCache *OriginCacheService
 }
 
 // NewServices creates a new service container with all services initialized.
@@ -85,15 +149,77 @@ func NewServices(app AppState, log *logger.Logger) *Services {
 	s.Auth = NewAuthService(app, log)
 	s.Config = NewConfigService(app, log)
 	s.Metadata = NewMetadataService(app, log)
+	s.Import = NewMetadataImportService(app, log)
 	s.Query = NewQueryService(app, log)
+	s.Collection = NewCollectionService(app, log)
 	s.Bulk = NewBulkService(app, log)
 	s.Verify = NewVerifyService(app, log)
 	s.Schema = NewSchemaService(app, log)
 	s.Monitoring = NewMonitoringService(app, log)
 	s.Reconcile = NewReconcileService(app, log)
 	s.StatsCache = NewStatsCache(app, log, s.Config)
+	s.ChunkDedup = NewChunkDedupService(app, log)
+	s.Compaction = NewCompactionService(app, log)
+	s.Scrub = NewScrubService(app, log)
+	s.Backup = NewBackupService(app, log)
+	s.BlobStores = NewBlobStoreService(app, log)
+	s.Lineage = NewLineageService(app, log)
+	s.References = NewReferenceService(app, log)
+	s.Timeline = NewTimelineService(app, log)
+	s.Sync = NewSyncService(app, log)
+	s.Integrity = NewIntegrityService(app, log)
+	s.Federation = NewFederationService(app, log)
+	s.Limits = NewLimitsService(app, log)
+	s.Watermark = NewWatermarkService(app, log)
+	s.Health = NewHealthService(app, log)
+	s.Policy = NewStoragePolicyService(app, log)
+	s.Previews = NewPreviewService(app, log, s.Asset, s.Policy)
+	s.Validation = NewValidationService(app, log)
+	s.Quarantine = NewQuarantineService(app, log)
+	s.Trash = NewTrashService(app, log, s.Asset, s.StatsCache)
+	s.Retention = NewRetentionService(app, log, s.Trash, s.Asset, s.StatsCache)
+	s.Quotas = NewTopicQuotaService(app, log)
+	s.Freeze = NewTopicFreezeService(app, log)
+	s.Events = NewEventService(app, log)
+	s.Discovery = NewDiscoveryService(app, log, s.StatsCache)
+	s.Search = NewSearchService(app, log)
+	s.Setup = NewSetupService(app, log)
+	s.Debug = NewDebugService(app, log)
+	s.Notification = NewNotificationService(app, log)
+	s.Idempotency = NewIdempotencyService(app, log)
+	s.Export = NewExportService(app, log, s.Notification)
+	s.Archive = NewArchiveService(app, log, s.Asset, s.StatsCache)
+	s.Deletions = NewDeletionRequestService(app, log, s.Bulk, s.Asset, s.Notification, s.Auth, s.StatsCache)
+	s.LinkExports = NewLinkExportService(app, log, s.Bulk, s.BlobStores)
+	s.Uploads = NewUploadSessionService(app, log, s.Asset)
+	s.Fetch = NewFetchService(app, log, s.Asset)
+	s.WatchFolders = NewWatchFolderService(app, log, s.Asset, s.Notification, s.StatsCache)
+	s.Webhooks = NewWebhookService(app, log)
+	s.Rules = NewRuleService(app, log, s.Bulk, s.Asset, s.Metadata, s.Quarantine, s.Notification, s.Auth, s.StatsCache)
+	s.OriginCache = NewOriginCacheService(app, log, s.Asset, s.Config, s.StatsCache)
+	s.Federation.SetQueryService(s.Query)
+	s.Bulk.SetCollectionService(s.Collection)
 	s.Monitoring.SetStatsCache(s.StatsCache)
+	s.Health.SetStatsCache(s.StatsCache)
+	s.Monitoring.SetAssetCache(s.Asset.Cache())
 	s.Reconcile.SetStatsCache(s.StatsCache)
+	s.Reconcile.SetAssetCache(s.Asset.Cache())
+	s.ChunkDedup.SetStatsCache(s.StatsCache)
+	s.ChunkDedup.SetBlobStoreService(s.BlobStores)
+	s.Compaction.SetStatsCache(s.StatsCache)
+	s.BlobStores.SetStatsCache(s.StatsCache)
+	s.Asset.SetBlobStoreService(s.BlobStores)
+	s.Asset.SetValidationService(s.Validation)
+	s.Asset.SetPreviewService(s.Previews)
+	s.Verify.SetQuarantineService(s.Quarantine)
+	s.Asset.SetArchiveService(s.Archive)
+	s.Scrub.SetQuarantineService(s.Quarantine)
+	s.Scrub.SetBlobStoreService(s.BlobStores)
+	if s.Notification != nil {
+		s.Notification.SetAuthService(s.Auth)
+		s.Auth.SetNotificationService(s.Notification)
+		s.Reconcile.SetNotificationService(s.Notification)
+	}
 
 	return s
 }
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// SetupStep is one step of the first-run setup.
+type SetupStep struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Status   string `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// SetupStatus lists the setup steps in the order the dashboard walks them.
+type SetupStatus struct {
+	Complete bool        `json:"complete"`            // every required step is complete
+	NextStep string      `json:"next_step,omitempty"` // first step neither complete nor skipped
+	Steps    []SetupStep `json:"steps"`
+}
+
+// SetupTLSRequest configures HTTPS during setup: either a generated
+// self-signed certificate or existing certificate and key files. Skip
+// declines the step instead.
+type SetupTLSRequest struct {
+	SelfSigned       bool   `json:"self_signed"`
+	CertFile         string `json:"cert_file"`
+	KeyFile          string `json:"key_file"`
+	RedirectHTTPPort int    `json:"redirect_http_port"`
+	Skip             bool   `json:"skip"`
+}
+
+// SetupService reports and advances the guided first-run setup: choosing
+// the working directory, confirming the bootstrap admin credentials were
+// saved, creating a first topic and, optionally, enabling TLS.
+type SetupService struct {
+	app      AppState
+	logger   *logger.Logger
+	configMu sync.Mutex
+}
+
+// NewSetupService creates a new SetupService instance.
+func NewSetupService(app AppState, log *logger.Logger) *SetupService {
+	return &SetupService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// Status reports every step. tlsActive is whether the running server serves
+// HTTPS, since TLS settings only take effect after a restart.
+func (s *SetupService) Status(tlsActive bool) (*SetupStatus, error) {
+	cfg := s.app.GetConfig()
+	db := s.app.GetOrchestratorDB()
+
+	recorded := map[string]string{}
+	if db != nil {
+		var err error
+		if recorded, err = database.GetSetupSteps(db); err != nil {
+			return nil, WrapInternalError(fmt.Errorf("failed to read setup steps: %w", err))
+		}
+	}
+
+	workdir := SetupStep{Name: constants.SetupStepWorkingDirectory, Required: true, Status: constants.SetupStatusIncomplete}
+	if cfg.WorkingDirectory != "" && db != nil {
+		workdir.Status = constants.SetupStatusComplete
+		workdir.Detail = cfg.WorkingDirectory
+	}
+
+	credentials := SetupStep{Name: constants.SetupStepCredentials, Required: true, Status: constants.SetupStatusIncomplete}
+	if recorded[constants.SetupStepCredentials] == constants.SetupStatusComplete {
+		credentials.Status = constants.SetupStatusComplete
+	}
+
+	topic := SetupStep{Name: constants.SetupStepFirstTopic, Required: true, Status: constants.SetupStatusIncomplete}
+	if topics := s.app.ListTopics(); db != nil && len(topics) > 0 {
+		topic.Status = constants.SetupStatusComplete
+		topic.Detail = fmt.Sprintf("%d topic(s)", len(topics))
+	}
+
+	tlsStep := SetupStep{Name: constants.SetupStepTLS, Status: constants.SetupStatusIncomplete}
+	switch {
+	case cfg.HTTP.TLSEnabled():
+		tlsStep.Status = constants.SetupStatusComplete
+		if !tlsActive {
+			tlsStep.Detail = "configured; takes effect after a restart"
+		}
+	case recorded[constants.SetupStepTLS] == constants.SetupStatusSkipped:
+		tlsStep.Status = constants.SetupStatusSkipped
+	}
+
+	status := &SetupStatus{Steps: []SetupStep{workdir, credentials, topic, tlsStep}}
+	status.Complete = true
+	for _, step := range status.Steps {
+		if step.Status == constants.SetupStatusIncomplete {
+			if status.NextStep == "" {
+				status.NextStep = step.Name
+			}
+			if step.Required {
+				status.Complete = false
+			}
+		}
+	}
+	return status, nil
+}
+
+// CheckWorkingDirectory runs the working directory checks of a config dry
+// run against dir. Warnings are returned with the checks; any failed check
+// is returned as an error.
+func (s *SetupService) CheckWorkingDirectory(dir string) ([]ConfigCheck, error) {
+	if dir == "" {
+		return nil, NewServiceError(constants.ErrCodeInvalidSetupStep, "working_directory is required")
+	}
+
+	candidate := *s.app.GetConfig()
+	candidate.WorkingDirectory = dir
+	checks := checkWorkingDirectory(dir)
+	dirOK := true
+	var problems []string
+	for _, check := range checks {
+		if check.Status == constants.ConfigCheckError {
+			dirOK = false
+			problems = append(problems, check.Message)
+		}
+	}
+	checks = append(checks, checkDiskSpace(&candidate, dirOK)...)
+	if len(problems) > 0 {
+		return checks, NewServiceError(constants.ErrCodeInvalidSetupStep, "working_directory: "+strings.Join(problems, "; "))
+	}
+	return checks, nil
+}
+
+// ConfirmCredentials records that the administrator saved the bootstrap
+// credentials, which are only shown once.
+func (s *SetupService) ConfirmCredentials(userID int64) error {
+	db, err := s.requireWorkingDirectory()
+	if err != nil {
+		return err
+	}
+	if err := database.SetSetupStep(db, constants.SetupStepCredentials, constants.SetupStatusComplete, userID); err != nil {
+		return WrapInternalError(fmt.Errorf("failed to record setup step: %w", err))
+	}
+	s.logger.Info("Setup: bootstrap credentials confirmed as saved by user %d", userID)
+	return nil
+}
+
+// ConfigureTLS validates and saves the TLS settings, or records the step as
+// skipped. The settings take effect after a restart.
+func (s *SetupService) ConfigureTLS(req SetupTLSRequest, userID int64) error {
+	db, err := s.requireWorkingDirectory()
+	if err != nil {
+		return err
+	}
+
+	if req.Skip {
+		if err := database.SetSetupStep(db, constants.SetupStepTLS, constants.SetupStatusSkipped, userID); err != nil {
+			return WrapInternalError(fmt.Errorf("failed to record setup step: %w", err))
+		}
+		return nil
+	}
+
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	cfg := s.app.GetConfig()
+	candidate := *cfg
+	candidate.HTTP.TLSSelfSigned = req.SelfSigned
+	candidate.HTTP.TLSCertFile = req.CertFile
+	candidate.HTTP.TLSKeyFile = req.KeyFile
+	candidate.HTTP.RedirectHTTPPort = req.RedirectHTTPPort
+
+	var problems []string
+	if !candidate.HTTP.TLSEnabled() {
+		problems = append(problems, "set self_signed, or cert_file and key_file")
+	}
+	for _, fe := range candidate.FieldErrors() {
+		if strings.HasPrefix(fe.Field, "http.") {
+			problems = append(problems, fe.Message)
+		}
+	}
+	if len(problems) == 0 && !req.SelfSigned {
+		if _, err := tls.LoadX509KeyPair(req.CertFile, req.KeyFile); err != nil {
+			problems = append(problems, fmt.Sprintf("http.tls_cert_file: %v", err))
+		}
+	}
+	if len(problems) > 0 {
+		return NewServiceError(constants.ErrCodeInvalidSetupStep, strings.Join(problems, "; "))
+	}
+
+	cfg.HTTP = candidate.HTTP
+	if err := config.SaveConfig(cfg); err != nil {
+		return WrapInternalError(fmt.Errorf("failed to save config: %w", err))
+	}
+	s.logger.Info("Setup: TLS configured (self_signed=%t), takes effect after a restart", req.SelfSigned)
+	return nil
+}
+
+// requireWorkingDirectory returns the orchestrator DB, or an error when the
+// working directory step is incomplete.
+func (s *SetupService) requireWorkingDirectory() (*sql.DB, error) {
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return nil, NewServiceError(constants.ErrCodeSetupStepBlocked, "the working directory must be set first")
+	}
+	return db, nil
+}
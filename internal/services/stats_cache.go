@@ -1,12 +1,16 @@
 package services
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
 	"silobang/internal/constants"
+	"silobang/internal/database"
 	"silobang/internal/logger"
 	"silobang/internal/storage"
 )
@@ -45,15 +49,39 @@ type StorageSummarySnapshot struct {
 	AvgAssetSize   float64 `json:"avg_asset_size"`
 }
 
+// StatsCacheStatus reports whether cached stats may be out of date.
+type StatsCacheStatus struct {
+	Initialized  bool  `json:"initialized"`
+	Stale        bool  `json:"stale"`                   // restored from disk, reconciliation pending
+	RestoredAt   int64 `json:"restored_at,omitempty"`   // Unix time the persisted cache was loaded
+	ReconciledAt int64 `json:"reconciled_at,omitempty"` // Unix time of the last full build
+}
+
 // StatsCache provides thread-safe cached access to topic stats and service info.
+// Topic stats are persisted to the orchestrator DB on change so a restart can
+// serve them immediately while a background reconciliation catches up.
 type StatsCache struct {
-	app         AppState
-	logger      *logger.Logger
-	configSvc   *ConfigService
-	mu          sync.RWMutex
-	topicStats  map[string]*TopicStatsSnapshot
-	serviceInfo *ServiceInfoSnapshot
-	initialized bool
+	app          AppState
+	logger       *logger.Logger
+	configSvc    *ConfigService
+	mu           sync.RWMutex
+	topicStats   map[string]*TopicStatsSnapshot
+	serviceInfo  *ServiceInfoSnapshot
+	chunkDedup   *ChunkDedupReport
+	initialized  bool
+	stale        bool
+	restoredAt   time.Time
+	reconciledAt time.Time
+	generation   uint64 // bumped by full builds and restores; stale reconciliations are discarded
+	discovery    map[string]*discoveryEntry
+	dropped      uint64 // bumped whenever discovery results are dropped; results computed across a drop are not cached
+}
+
+// discoveryEntry is a cached popularity or recommendation result.
+type discoveryEntry struct {
+	value   interface{}
+	topics  []string // topics the result was computed from
+	expires time.Time
 }
 
 // NewStatsCache creates a new stats cache instance.
@@ -63,6 +91,7 @@ func NewStatsCache(app AppState, log *logger.Logger, configSvc *ConfigService) *
 		logger:     log,
 		configSvc:  configSvc,
 		topicStats: make(map[string]*TopicStatsSnapshot),
+		discovery:  make(map[string]*discoveryEntry),
 	}
 }
 
@@ -95,11 +124,119 @@ func (s *StatsCache) BuildAll() {
 	}
 
 	s.serviceInfo = s.buildServiceInfo()
+	s.chunkDedup = nil // report belongs to the previous working directory
+	s.discovery = make(map[string]*discoveryEntry)
+	s.dropped++
 	s.initialized = true
+	s.stale = false
+	s.reconciledAt = time.Now()
+	s.generation++
+	s.persistAll()
 
 	s.logger.Info("[stats-cache] cache built: %d topics cached", len(s.topicStats))
 }
 
+// Restore loads the persisted cache for registered healthy topics and marks
+// it stale until Reconcile completes. Returns false if nothing was persisted.
+func (s *StatsCache) Restore() bool {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return false
+	}
+
+	entries, err := database.ListCachedTopicStats(orchDB)
+	if err != nil {
+		s.logger.Warn("[stats-cache] failed to load persisted cache: %v", err)
+		return false
+	}
+	if len(entries) == 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.topicStats = make(map[string]*TopicStatsSnapshot, len(entries))
+	for _, e := range entries {
+		if healthy, _ := s.app.IsTopicHealthy(e.Topic); !healthy {
+			continue
+		}
+		stats, err := decodeCachedStats(e.StatsJSON)
+		if err != nil {
+			s.logger.Warn("[stats-cache] discarding persisted stats for topic %s: %v", e.Topic, err)
+			continue
+		}
+		s.topicStats[e.Topic] = &TopicStatsSnapshot{
+			Stats:      stats,
+			ComputedAt: time.Unix(e.ComputedAt, 0),
+		}
+	}
+
+	s.serviceInfo = s.buildServiceInfo()
+	s.chunkDedup = nil
+	s.discovery = make(map[string]*discoveryEntry)
+	s.dropped++
+	s.initialized = true
+	s.stale = true
+	s.restoredAt = time.Now()
+	s.generation++
+
+	s.logger.Info("[stats-cache] restored %d topics from persisted cache (stale until reconciled)", len(s.topicStats))
+	return true
+}
+
+// Reconcile recomputes stats for every healthy topic without blocking readers,
+// then swaps them in and clears the stale flag. Topics refreshed by an
+// invalidation while reconciling keep their newer stats. The result is
+// discarded if a full build or restore happened in the meantime.
+func (s *StatsCache) Reconcile() {
+	s.mu.RLock()
+	generation := s.generation
+	s.mu.RUnlock()
+
+	started := time.Now()
+	fresh := make(map[string]*TopicStatsSnapshot)
+	for _, name := range s.app.ListTopics() {
+		if healthy, _ := s.app.IsTopicHealthy(name); !healthy {
+			continue
+		}
+		stats, err := s.configSvc.GetTopicStats(name)
+		if err != nil {
+			s.logger.Warn("[stats-cache] failed to get stats for topic %s: %v", name, err)
+			continue
+		}
+		fresh[name] = &TopicStatsSnapshot{Stats: stats, ComputedAt: time.Now()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.generation != generation {
+		s.logger.Info("[stats-cache] reconciliation discarded: cache was rebuilt meanwhile")
+		return
+	}
+
+	for name, snapshot := range s.topicStats {
+		if _, ok := fresh[name]; !ok && snapshot.ComputedAt.Before(started) {
+			delete(s.topicStats, name)
+		}
+	}
+	for name, snapshot := range fresh {
+		if current, ok := s.topicStats[name]; ok && current.ComputedAt.After(started) {
+			continue
+		}
+		s.topicStats[name] = snapshot
+	}
+
+	s.serviceInfo = s.buildServiceInfo()
+	s.initialized = true
+	s.stale = false
+	s.reconciledAt = time.Now()
+	s.persistAll()
+
+	s.logger.Info("[stats-cache] reconciled in %s: %d topics cached", time.Since(started).Round(time.Millisecond), len(s.topicStats))
+}
+
 // InvalidateTopic refreshes the cached stats for a single topic.
 // If the topic is healthy, its stats are recomputed; if unhealthy, it is removed from the cache.
 // Service info is recomputed after the update.
@@ -117,11 +254,14 @@ func (s *StatsCache) InvalidateTopic(topicName string) {
 				Stats:      stats,
 				ComputedAt: time.Now(),
 			}
+			s.persistTopic(topicName)
 		}
 	} else {
 		delete(s.topicStats, topicName)
+		s.forgetTopic(topicName)
 	}
 
+	s.dropDiscovery(topicName)
 	s.serviceInfo = s.buildServiceInfo()
 
 	s.logger.Info("[stats-cache] topic %s invalidated", topicName)
@@ -134,6 +274,7 @@ func (s *StatsCache) InvalidateTopics(topicNames []string) {
 	defer s.mu.Unlock()
 
 	for _, topicName := range topicNames {
+		s.dropDiscovery(topicName)
 		healthy, _ := s.app.IsTopicHealthy(topicName)
 		if healthy {
 			stats, err := s.configSvc.GetTopicStats(topicName)
@@ -145,8 +286,10 @@ func (s *StatsCache) InvalidateTopics(topicNames []string) {
 				Stats:      stats,
 				ComputedAt: time.Now(),
 			}
+			s.persistTopic(topicName)
 		} else {
 			delete(s.topicStats, topicName)
+			s.forgetTopic(topicName)
 		}
 	}
 
@@ -161,6 +304,8 @@ func (s *StatsCache) RemoveTopic(topicName string) {
 	defer s.mu.Unlock()
 
 	delete(s.topicStats, topicName)
+	s.forgetTopic(topicName)
+	s.dropDiscovery(topicName)
 	s.serviceInfo = s.buildServiceInfo()
 
 	s.logger.Info("[stats-cache] topic %s removed from cache", topicName)
@@ -200,6 +345,71 @@ func (s *StatsCache) GetServiceInfo() *ServiceInfoSnapshot {
 	return s.serviceInfo
 }
 
+// SetChunkDedupReport stores the latest chunk dedup analysis report.
+func (s *StatsCache) SetChunkDedupReport(report *ChunkDedupReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.chunkDedup = report
+}
+
+// GetChunkDedupReport returns the latest chunk dedup analysis report, or nil
+// if no analysis has completed since the cache was built.
+func (s *StatsCache) GetChunkDedupReport() *ChunkDedupReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.chunkDedup
+}
+
+// Discovery returns the cached result for key, computing it when missing or
+// older than constants.DiscoveryCacheTTL. compute also returns the topics the
+// result was computed from; invalidating one of them drops the result.
+// Results whose computation overlapped an invalidation are not cached.
+func (s *StatsCache) Discovery(key string, compute func() (interface{}, []string, error)) (interface{}, error) {
+	s.mu.RLock()
+	entry, ok := s.discovery[key]
+	dropped := s.dropped
+	s.mu.RUnlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value, nil
+	}
+
+	value, topics, err := compute()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A topic changed while computing: the result may predate the change,
+	// so it is served once but not cached
+	if s.dropped != dropped {
+		return value, nil
+	}
+
+	now := time.Now()
+	for k, e := range s.discovery {
+		if !now.Before(e.expires) {
+			delete(s.discovery, k)
+		}
+	}
+	s.discovery[key] = &discoveryEntry{value: value, topics: topics, expires: now.Add(constants.DiscoveryCacheTTL)}
+	return value, nil
+}
+
+// dropDiscovery removes the discovery results computed from a topic.
+// MUST be called with the write lock held.
+func (s *StatsCache) dropDiscovery(topicName string) {
+	s.dropped++
+	for key, entry := range s.discovery {
+		if slices.Contains(entry.topics, topicName) {
+			delete(s.discovery, key)
+		}
+	}
+}
+
 // IsInitialized reports whether the cache has completed its initial build.
 func (s *StatsCache) IsInitialized() bool {
 	s.mu.RLock()
@@ -208,6 +418,120 @@ func (s *StatsCache) IsInitialized() bool {
 	return s.initialized
 }
 
+// Status reports the cache's initialization and staleness.
+func (s *StatsCache) Status() StatsCacheStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := StatsCacheStatus{Initialized: s.initialized, Stale: s.stale}
+	if !s.restoredAt.IsZero() {
+		status.RestoredAt = s.restoredAt.Unix()
+	}
+	if !s.reconciledAt.IsZero() {
+		status.ReconciledAt = s.reconciledAt.Unix()
+	}
+	return status
+}
+
+// persistTopic writes one topic's cached stats to the orchestrator DB.
+// MUST be called with the write lock held.
+func (s *StatsCache) persistTopic(topicName string) {
+	orchDB := s.app.GetOrchestratorDB()
+	snapshot, ok := s.topicStats[topicName]
+	if orchDB == nil || !ok {
+		return
+	}
+	entry, err := cachedStatsEntry(topicName, snapshot)
+	if err == nil {
+		err = database.UpsertCachedTopicStats(orchDB, entry)
+	}
+	if err != nil {
+		s.logger.Warn("[stats-cache] failed to persist stats for topic %s: %v", topicName, err)
+	}
+}
+
+// forgetTopic removes one topic's persisted stats.
+// MUST be called with the write lock held.
+func (s *StatsCache) forgetTopic(topicName string) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return
+	}
+	if err := database.DeleteCachedTopicStats(orchDB, topicName); err != nil {
+		s.logger.Warn("[stats-cache] failed to remove persisted stats for topic %s: %v", topicName, err)
+	}
+}
+
+// persistAll replaces the persisted cache with the in-memory one.
+// MUST be called with the write lock held.
+func (s *StatsCache) persistAll() {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return
+	}
+	entries := make([]database.CachedTopicStats, 0, len(s.topicStats))
+	for name, snapshot := range s.topicStats {
+		entry, err := cachedStatsEntry(name, snapshot)
+		if err != nil {
+			s.logger.Warn("[stats-cache] failed to serialize stats for topic %s: %v", name, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := database.ReplaceCachedTopicStats(orchDB, entries); err != nil {
+		s.logger.Warn("[stats-cache] failed to persist cache: %v", err)
+	}
+}
+
+// cachedStatsEntry serializes a snapshot for persistence.
+func cachedStatsEntry(topicName string, snapshot *TopicStatsSnapshot) (database.CachedTopicStats, error) {
+	statsJSON, err := json.Marshal(snapshot.Stats)
+	if err != nil {
+		return database.CachedTopicStats{}, err
+	}
+	return database.CachedTopicStats{
+		Topic:      topicName,
+		StatsJSON:  string(statsJSON),
+		ComputedAt: snapshot.ComputedAt.Unix(),
+	}, nil
+}
+
+// decodeCachedStats parses persisted stats. Integral numbers are restored as
+// int64, matching freshly computed stats.
+func decodeCachedStats(statsJSON string) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(statsJSON)))
+	dec.UseNumber()
+	var stats map[string]interface{}
+	if err := dec.Decode(&stats); err != nil {
+		return nil, err
+	}
+	for k, v := range stats {
+		stats[k] = normalizeJSONNumbers(v)
+	}
+	return stats, nil
+}
+
+// normalizeJSONNumbers converts json.Number values to int64 or float64.
+func normalizeJSONNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = normalizeJSONNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeJSONNumbers(item)
+		}
+	}
+	return v
+}
+
 // buildServiceInfo aggregates metrics from cached topic stats into a ServiceInfoSnapshot.
 // MUST be called with the write lock held; this method does not acquire any locks.
 func (s *StatsCache) buildServiceInfo() *ServiceInfoSnapshot {
@@ -1082,6 +1082,146 @@ func TestStatsCacheServiceInfoDbSize(t *testing.T) {
 	}
 }
 
+// =============================================================================
+// Persistence Tests
+// =============================================================================
+
+// newPersistentStatsCacheMock sets up one healthy topic and an orchestrator DB.
+func newPersistentStatsCacheMock(t *testing.T) (*mockAppState, *sql.DB) {
+	t.Helper()
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+
+	db := setupTopicDir(t, workDir, "topic-a", []testAsset{
+		{id: "aaa111", size: 1000, ext: "png", blobName: "000001.dat", offset: 0, createdAt: 1700000000},
+	})
+	createDatFile(t, workDir, "topic-a", "000001.dat", 1000)
+	mock.StoreTopicDB("topic-a", db)
+	mock.RegisterTopic("topic-a", true, "")
+	mock.SetOrchestratorDB(setupOrchestratorDB(t, workDir, nil))
+	return mock, db
+}
+
+func TestStatsCacheRestore_NothingPersisted(t *testing.T) {
+	mock, _ := newPersistentStatsCacheMock(t)
+	cache := newTestStatsCache(mock)
+
+	if cache.Restore() {
+		t.Error("Restore should report false with an empty persisted cache")
+	}
+	if cache.IsInitialized() {
+		t.Error("cache should not be initialized after an empty restore")
+	}
+}
+
+func TestStatsCacheRestore_ServesPersistedStatsAsStale(t *testing.T) {
+	mock, _ := newPersistentStatsCacheMock(t)
+	newTestStatsCache(mock).BuildAll()
+
+	// A new cache (as after a restart) restores without querying topics
+	cache := newTestStatsCache(mock)
+	if !cache.Restore() {
+		t.Fatal("expected Restore to load the persisted cache")
+	}
+
+	status := cache.Status()
+	if !status.Initialized || !status.Stale || status.RestoredAt == 0 {
+		t.Errorf("unexpected status after restore: %+v", status)
+	}
+
+	stats, ok := cache.GetTopicStats("topic-a")
+	if !ok {
+		t.Fatal("expected topic-a to be restored")
+	}
+	if stats["file_count"] != int64(1) || stats["total_size"] != int64(1000) {
+		t.Errorf("restored stats should keep int64 values, got %#v", stats)
+	}
+	if info := cache.GetServiceInfo(); info == nil || info.StorageSummary.TotalAssetSize != 1000 {
+		t.Errorf("service info should aggregate restored stats, got %+v", info)
+	}
+}
+
+func TestStatsCacheReconcile_ClearsStaleAndRefreshes(t *testing.T) {
+	mock, db := newPersistentStatsCacheMock(t)
+	newTestStatsCache(mock).BuildAll()
+
+	// Data changes while the server is down
+	if _, err := db.Exec(
+		`INSERT INTO assets (asset_id, asset_size, extension, blob_name, byte_offset, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		"aaa222", 2000, "jpg", "000001.dat", 1000, 1700000001,
+	); err != nil {
+		t.Fatalf("failed to insert asset: %v", err)
+	}
+
+	cache := newTestStatsCache(mock)
+	cache.Restore()
+	if stats, _ := cache.GetTopicStats("topic-a"); stats["file_count"] != int64(1) {
+		t.Fatalf("restored file_count: got %v, want 1", stats["file_count"])
+	}
+
+	cache.Reconcile()
+
+	if status := cache.Status(); status.Stale || status.ReconciledAt == 0 {
+		t.Errorf("unexpected status after reconcile: %+v", status)
+	}
+	if stats, _ := cache.GetTopicStats("topic-a"); stats["file_count"] != int64(2) {
+		t.Errorf("reconciled file_count: got %v, want 2", stats["file_count"])
+	}
+
+	// Reconciled stats are persisted for the next restart
+	restarted := newTestStatsCache(mock)
+	restarted.Restore()
+	if stats, _ := restarted.GetTopicStats("topic-a"); stats["file_count"] != int64(2) {
+		t.Errorf("persisted file_count: got %v, want 2", stats["file_count"])
+	}
+}
+
+func TestStatsCacheRemoveTopic_ForgetsPersistedStats(t *testing.T) {
+	mock, _ := newPersistentStatsCacheMock(t)
+	cache := newTestStatsCache(mock)
+	cache.BuildAll()
+
+	cache.RemoveTopic("topic-a")
+
+	entries, err := database.ListCachedTopicStats(mock.orchestratorDB)
+	if err != nil {
+		t.Fatalf("ListCachedTopicStats: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("removed topic should not stay persisted, got %+v", entries)
+	}
+}
+
+// =============================================================================
+// Discovery Tests
+// =============================================================================
+
+func TestStatsCacheDiscovery_InvalidatedDuringCompute(t *testing.T) {
+	mock := newStatsCacheMock(t.TempDir())
+	cache := newTestStatsCache(mock)
+
+	calls := 0
+	compute := func() (interface{}, []string, error) {
+		calls++
+		if calls == 1 {
+			// An upload lands while the result is being computed
+			cache.InvalidateTopic("topic-a")
+		}
+		return calls, []string{"topic-a"}, nil
+	}
+
+	if v, _ := cache.Discovery("popular", compute); v != 1 {
+		t.Fatalf("first call: got %v, want 1", v)
+	}
+	// The first result predates the invalidation and was not cached
+	if v, _ := cache.Discovery("popular", compute); v != 2 {
+		t.Errorf("second call: got %v, want a fresh result 2", v)
+	}
+	if v, _ := cache.Discovery("popular", compute); v != 2 {
+		t.Errorf("third call: got %v, want the cached result 2", v)
+	}
+}
+
 // =============================================================================
 // Helpers for concurrent tests
 // =============================================================================
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/plugin"
+)
+
+// StoragePolicyService manages the per-extension storage policies and runs
+// the parts of the upload pipeline they switch on: upload scanning. Previews
+// are rendered by PreviewService.
+type StoragePolicyService struct {
+	app    AppState
+	logger *logger.Logger
+	mu     sync.Mutex
+}
+
+// NewStoragePolicyService creates a new storage policy service instance.
+func NewStoragePolicyService(app AppState, log *logger.Logger) *StoragePolicyService {
+	return &StoragePolicyService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// StoragePolicies lists the configured policies and the effective policy of
+// extensions without one.
+type StoragePolicies struct {
+	Defaults    config.StoragePolicy                  `json:"defaults"`
+	Policies    map[string]config.StoragePolicyConfig `json:"policies"`
+	ScanEnabled bool                                  `json:"scan_enabled"` // a scanner command is configured
+}
+
+// List returns the configured policies.
+func (s *StoragePolicyService) List() *StoragePolicies {
+	cfg := s.app.GetConfig()
+	policies := maps.Clone(cfg.StoragePolicies)
+	if policies == nil {
+		policies = map[string]config.StoragePolicyConfig{}
+	}
+	return &StoragePolicies{
+		Defaults:    cfg.StoragePolicy(""),
+		Policies:    policies,
+		ScanEnabled: cfg.Scan.Enabled(),
+	}
+}
+
+// Effective returns the policy applied to files with extension ext.
+func (s *StoragePolicyService) Effective(ext string) config.StoragePolicy {
+	return s.app.GetConfig().StoragePolicy(ext)
+}
+
+// Set validates and stores the policy of one extension ("*" for the
+// fallback), replacing any previous one, and saves the config. Returns the
+// effective policy.
+func (s *StoragePolicyService) Set(ext string, policy config.StoragePolicyConfig) (config.StoragePolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ext = normalizePolicyExtension(ext)
+	cfg := s.app.GetConfig()
+	candidate := *cfg
+	candidate.StoragePolicies = maps.Clone(cfg.StoragePolicies)
+	if candidate.StoragePolicies == nil {
+		candidate.StoragePolicies = make(map[string]config.StoragePolicyConfig)
+	}
+	candidate.StoragePolicies[ext] = policy
+
+	var problems []string
+	for _, fe := range candidate.FieldErrors() {
+		if strings.HasPrefix(fe.Field, "storage_policies") {
+			problems = append(problems, fe.Message)
+		}
+	}
+	if len(problems) > 0 {
+		return config.StoragePolicy{}, NewServiceError(constants.ErrCodeInvalidStoragePolicy, strings.Join(problems, "; "))
+	}
+
+	cfg.StoragePolicies = candidate.StoragePolicies
+	if err := config.SaveConfig(cfg); err != nil {
+		return config.StoragePolicy{}, WrapInternalError(fmt.Errorf("failed to save config: %w", err))
+	}
+
+	s.logger.Info("Storage policy set for %s", ext)
+	return s.effectiveFor(cfg, ext), nil
+}
+
+// Delete removes the policy of one extension and saves the config.
+func (s *StoragePolicyService) Delete(ext string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ext = normalizePolicyExtension(ext)
+	cfg := s.app.GetConfig()
+	if _, ok := cfg.StoragePolicies[ext]; !ok {
+		return NewServiceError(constants.ErrCodeStoragePolicyNotFound, fmt.Sprintf("no storage policy for %q", ext))
+	}
+
+	policies := maps.Clone(cfg.StoragePolicies)
+	delete(policies, ext)
+	cfg.StoragePolicies = policies
+	if err := config.SaveConfig(cfg); err != nil {
+		return WrapInternalError(fmt.Errorf("failed to save config: %w", err))
+	}
+
+	s.logger.Info("Storage policy removed for %s", ext)
+	return nil
+}
+
+// ForTopic returns the effective policy of every extension stored in a
+// topic.
+func (s *StoragePolicyService) ForTopic(topicName string) ([]config.StoragePolicy, error) {
+	return topicStoragePolicies(s.app, topicName)
+}
+
+// effectiveFor returns the effective policy of a configured key. The "*"
+// key reports the policy of extensions without their own.
+func (s *StoragePolicyService) effectiveFor(cfg *config.Config, ext string) config.StoragePolicy {
+	policy := cfg.StoragePolicy(ext)
+	policy.Extension = ext
+	return policy
+}
+
+// normalizePolicyExtension lower-cases ext and strips a leading dot.
+// Anything else invalid is left for validation to report.
+func normalizePolicyExtension(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+}
+
+// topicStoragePolicies returns the effective policy of every extension
+// stored in a healthy topic.
+func topicStoragePolicies(app AppState, topicName string) ([]config.StoragePolicy, error) {
+	db, err := app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, err
+	}
+	extensions, err := database.ListExtensions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := app.GetConfig()
+	policies := make([]config.StoragePolicy, len(extensions))
+	for i, ext := range extensions {
+		policies[i] = cfg.StoragePolicy(ext)
+		policies[i].Extension = ext
+	}
+	return policies, nil
+}
+
+// scanUpload runs the configured scanner on the file at path. Returns
+// UPLOAD_SCAN_REJECTED when the scanner flags it and UPLOAD_SCAN_FAILED when
+// the scanner is missing, fails or times out, so unscanned files are never
+// stored.
+func scanUpload(ctx context.Context, scan config.ScanConfig, path string) error {
+	if !scan.Enabled() {
+		return NewServiceError(constants.ErrCodeUploadScanFailed, "upload scanning is required but no scanner is configured")
+	}
+	scanner, err := plugin.NewScanner(scan.ScannerType(), scan.PluginConfig())
+	if err != nil {
+		return WrapServiceError(constants.ErrCodeUploadScanFailed, "upload scanner unavailable", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, scan.Timeout())
+	defer cancel()
+
+	result, err := scanner.Scan(ctx, path)
+	if err != nil {
+		if ctx.Err() != nil {
+			return WrapServiceError(constants.ErrCodeUploadScanFailed, "upload scan timed out", ctx.Err())
+		}
+		return WrapServiceError(constants.ErrCodeUploadScanFailed, "upload scan failed: "+err.Error(), err)
+	}
+	if result.Infected {
+		detail := result.Detail
+		if detail != "" {
+			detail = ": " + detail
+		}
+		return NewServiceError(constants.ErrCodeUploadScanRejected, "upload rejected by scanner"+detail)
+	}
+	return nil
+}
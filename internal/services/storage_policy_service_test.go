@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+func boolPtr(v bool) *bool { return &v }
+
+func TestStoragePolicySet_NormalizesAndSaves(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newConfigMock(t.TempDir())
+	svc := NewStoragePolicyService(m, m.log)
+
+	policy, err := svc.Set(".PNG", config.StoragePolicyConfig{Preview: boolPtr(false), MaxSizeBytes: 4096})
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if policy.Extension != "png" || policy.Preview || policy.MaxSizeBytes != 4096 {
+		t.Errorf("unexpected effective policy: %+v", policy)
+	}
+	if _, ok := m.cfg.StoragePolicies["png"]; !ok {
+		t.Errorf("expected running config updated, got %v", m.cfg.StoragePolicies)
+	}
+	if _, err := os.Stat(config.GetConfigPath()); err != nil {
+		t.Errorf("expected config to be saved: %v", err)
+	}
+
+	if err := svc.Delete("png"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := svc.Delete("png"); !isServiceErrorCode(err, constants.ErrCodeStoragePolicyNotFound) {
+		t.Errorf("expected %s, got %v", constants.ErrCodeStoragePolicyNotFound, err)
+	}
+}
+
+func TestStoragePolicySet_RejectsInvalid(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newConfigMock(t.TempDir())
+	svc := NewStoragePolicyService(m, m.log)
+
+	cases := map[string]config.StoragePolicyConfig{
+		"a.b": {},
+		"bin": {MaxSizeBytes: m.cfg.MaxDatSize},
+		"exe": {Scan: boolPtr(true)}, // no scanner configured
+	}
+	for ext, policy := range cases {
+		if _, err := svc.Set(ext, policy); !isServiceErrorCode(err, constants.ErrCodeInvalidStoragePolicy) {
+			t.Errorf("%s: expected %s, got %v", ext, constants.ErrCodeInvalidStoragePolicy, err)
+		}
+	}
+	if len(m.cfg.StoragePolicies) != 0 {
+		t.Errorf("rejected policies should not be stored, got %v", m.cfg.StoragePolicies)
+	}
+}
+
+func TestScanUpload_ExitCodes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upload")
+	if err := os.WriteFile(path, []byte("payload with EICAR marker"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scan := func(script string) config.ScanConfig {
+		return config.ScanConfig{Command: []string{"sh", "-c", script}, TimeoutSecs: 5}
+	}
+
+	if err := scanUpload(context.Background(), scan("cat >/dev/null"), path); err != nil {
+		t.Errorf("clean file: expected no error, got %v", err)
+	}
+	if err := scanUpload(context.Background(), scan("grep -q EICAR && exit 1"), path); !isServiceErrorCode(err, constants.ErrCodeUploadScanRejected) {
+		t.Errorf("infected file: expected %s, got %v", constants.ErrCodeUploadScanRejected, err)
+	}
+	if err := scanUpload(context.Background(), scan("echo broken >&2; exit 2"), path); !isServiceErrorCode(err, constants.ErrCodeUploadScanFailed) {
+		t.Errorf("scanner error: expected %s, got %v", constants.ErrCodeUploadScanFailed, err)
+	}
+	if err := scanUpload(context.Background(), config.ScanConfig{}, path); !isServiceErrorCode(err, constants.ErrCodeUploadScanFailed) {
+		t.Errorf("no scanner: expected %s, got %v", constants.ErrCodeUploadScanFailed, err)
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/prompts"
+	"silobang/internal/queries"
+)
+
+// =============================================================================
+// Subsystems Shared by Boot and the Config API
+// =============================================================================
+
+// TopicDiscoverySubsystem discovers the topics of workDir, registers them on
+// the app and indexes the healthy ones to the orchestrator. Topics that
+// cannot be indexed degrade the subsystem.
+func TopicDiscoverySubsystem(app AppState, workDir string, log *logger.Logger) Subsystem {
+	return Subsystem{
+		Name:     constants.StartupStepTopicDiscovery,
+		Required: true,
+		Requires: []string{constants.StartupStepOrchestratorDB},
+		Start: func() (string, error) {
+			topics, err := config.DiscoverTopics(workDir)
+			if err != nil {
+				return "", err
+			}
+
+			log.Info("Discovered %d topic(s)", len(topics))
+			for _, t := range topics {
+				app.RegisterTopic(t.Name, t.Healthy, t.Error)
+				if t.Healthy {
+					log.Debug("  - %s (healthy)", t.Name)
+				} else {
+					log.Warn("  - %s (unhealthy: %s)", t.Name, t.Error)
+				}
+			}
+
+			detail := fmt.Sprintf("%d topic(s) discovered", len(topics))
+			var indexErrors []string
+			for _, e := range config.IndexTopicsToOrchestrator(topics, app.GetOrchestratorDB()) {
+				log.Warn("Failed to index topic %s: %v", e.Topic, e.Err)
+				indexErrors = append(indexErrors, e.Topic)
+			}
+			if len(indexErrors) > 0 {
+				return detail, Degraded(errors.New("failed to index: " + strings.Join(indexErrors, ", ")))
+			}
+			return detail, nil
+		},
+	}
+}
+
+// QueriesSubsystem loads the query presets of workDir. The app keeps the
+// embedded defaults when they cannot be loaded.
+func QueriesSubsystem(app AppState, workDir string, log *logger.Logger) Subsystem {
+	return Subsystem{
+		Name:     constants.StartupStepQueries,
+		Requires: []string{constants.StartupStepWorkingDirectory},
+		Start: func() (string, error) {
+			queriesConfig, err := queries.LoadQueries(workDir, log)
+			if err != nil {
+				app.SetQueriesConfig(queries.GetDefaultConfig())
+				return "using defaults", Degraded(err)
+			}
+			app.SetQueriesConfig(queriesConfig)
+			return "", nil
+		},
+	}
+}
+
+// PromptsSubsystem loads the prompt templates of workDir, rendered against
+// baseURL.
+func PromptsSubsystem(app AppState, workDir, baseURL string, log *logger.Logger) Subsystem {
+	return Subsystem{
+		Name:     constants.StartupStepPrompts,
+		Feature:  constants.FeaturePrompts,
+		Requires: []string{constants.StartupStepWorkingDirectory},
+		Start: func() (string, error) {
+			promptsManager := prompts.NewManager(workDir, baseURL)
+			promptsErr := promptsManager.EnsurePromptsDir(workDir, log)
+			if promptsErr != nil {
+				log.Warn("Failed to initialize prompts directory: %v", promptsErr)
+			}
+			if err := promptsManager.LoadPrompts(log); err != nil {
+				log.Warn("Failed to load prompts: %v", err)
+				promptsErr = err
+			}
+			app.SetPromptsManager(promptsManager)
+			return "", Degraded(promptsErr)
+		},
+	}
+}
+
+// SearchSubsystem reports whether topic databases maintain their full-text
+// index. Callers turn indexing on or off with database.SetSearchIndexing
+// before opening topic databases.
+func SearchSubsystem(app AppState) Subsystem {
+	return Subsystem{
+		Name:     constants.StartupStepSearch,
+		Feature:  constants.FeatureSearch,
+		Requires: []string{constants.StartupStepOrchestratorDB},
+		Start: func() (string, error) {
+			if !database.FTS5Supported(app.GetOrchestratorDB()) {
+				return "unavailable: built without the sqlite_fts5 tag", nil
+			}
+			return "", nil
+		},
+	}
+}
+
+// PreviewsSubsystem reports whether image previews are served. Previews are
+// rendered on demand, so there is nothing to start.
+func PreviewsSubsystem() Subsystem {
+	return Subsystem{
+		Name:    constants.StartupStepPreviews,
+		Feature: constants.FeaturePreviews,
+		Start: func() (string, error) {
+			return "", nil
+		},
+	}
+}
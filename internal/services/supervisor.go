@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+// =============================================================================
+// Subsystem Supervisor
+// =============================================================================
+
+// Subsystem is a part of the application started independently of the
+// others. A subsystem that fails only takes down the subsystems requiring it.
+type Subsystem struct {
+	Name     string   // also the name of its startup step
+	Required bool     // the instance is not ready unless it runs
+	Feature  string   // feature flag that can disable it; empty = always on
+	Requires []string // subsystems that must run first
+	Start    func() (detail string, err error)
+}
+
+// SubsystemStatus is the outcome of starting one subsystem.
+type SubsystemStatus struct {
+	Name       string `json:"name"`
+	State      string `json:"state"` // running, degraded, failed, disabled, skipped
+	Required   bool   `json:"required"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Running reports whether the subsystem is up, possibly degraded.
+func (s SubsystemStatus) Running() bool {
+	return s.State == constants.SubsystemStateRunning || s.State == constants.SubsystemStateDegraded
+}
+
+// degradedError marks the error of a subsystem that started anyway.
+type degradedError struct {
+	err error
+}
+
+func (e *degradedError) Error() string { return e.err.Error() }
+func (e *degradedError) Unwrap() error { return e.err }
+
+// Degraded wraps the error of a subsystem that recovered from it, e.g. by
+// falling back to defaults, so it is reported as degraded, not failed.
+// Returns nil for a nil error.
+func Degraded(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &degradedError{err: err}
+}
+
+// Supervisor starts subsystems one at a time, each isolated from the
+// failures of the others, and records their outcome to a startup recorder.
+type Supervisor struct {
+	startup  *StartupRecorder
+	features config.FeaturesConfig
+	logger   *logger.Logger
+}
+
+// NewSupervisor creates a supervisor recording to startup. Subsystems whose
+// feature is disabled in features are not started.
+func NewSupervisor(startup *StartupRecorder, features config.FeaturesConfig, log *logger.Logger) *Supervisor {
+	return &Supervisor{
+		startup:  startup,
+		features: features,
+		logger:   log,
+	}
+}
+
+// Start starts sub unless its feature is disabled or a subsystem it
+// requires is not running, and returns whether it runs. A panic while
+// starting fails the subsystem rather than the process.
+func (s *Supervisor) Start(sub Subsystem) bool {
+	start := time.Now()
+	status := SubsystemStatus{Name: sub.Name, Required: sub.Required}
+
+	if sub.Feature != "" && !s.features.Enabled(sub.Feature) {
+		status.State = constants.SubsystemStateDisabled
+		status.Detail = "disabled by features.disabled"
+		s.logger.Info("Subsystem %s disabled by configuration", sub.Name)
+		s.finish(status, start)
+		return false
+	}
+
+	for _, name := range sub.Requires {
+		if dep, ok := s.startup.Subsystem(name); !ok || !dep.Running() {
+			status.State = constants.SubsystemStateSkipped
+			status.Detail = "requires " + name
+			s.logger.Warn("Subsystem %s skipped: %s is not running", sub.Name, name)
+			s.finish(status, start)
+			return false
+		}
+	}
+
+	detail, err := s.run(sub)
+	var degraded *degradedError
+	switch {
+	case err == nil:
+		status.State = constants.SubsystemStateRunning
+		status.Detail = detail
+	case errors.As(err, &degraded):
+		status.State = constants.SubsystemStateDegraded
+		status.Detail = joinDetail(detail, err.Error())
+		s.logger.Warn("Subsystem %s degraded: %v", sub.Name, err)
+	default:
+		status.State = constants.SubsystemStateFailed
+		status.Detail = joinDetail(detail, err.Error())
+		s.logger.Error("Subsystem %s failed: %v", sub.Name, err)
+	}
+	s.finish(status, start)
+	return status.Running()
+}
+
+// run calls sub.Start, turning a panic into an error.
+func (s *Supervisor) run(sub Subsystem) (detail string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sub.Start()
+}
+
+// finish records the subsystem and its startup step.
+func (s *Supervisor) finish(status SubsystemStatus, start time.Time) {
+	status.DurationMs = time.Since(start).Milliseconds()
+	s.startup.SetSubsystem(status)
+
+	stepStatus := constants.StartupStepOK
+	switch status.State {
+	case constants.SubsystemStateDegraded:
+		stepStatus = constants.StartupStepWarning
+	case constants.SubsystemStateFailed, constants.SubsystemStateSkipped:
+		// Without a required subsystem the instance cannot serve
+		stepStatus = constants.StartupStepWarning
+		if status.Required {
+			stepStatus = constants.StartupStepFailed
+		}
+	case constants.SubsystemStateDisabled:
+		stepStatus = constants.StartupStepSkipped
+	}
+	s.startup.Record(status.Name, start, stepStatus, status.Detail)
+}
+
+// subsystemError returns the failure of a subsystem recorded to startup as
+// an internal error.
+func subsystemError(startup *StartupRecorder, name string) error {
+	status, _ := startup.Subsystem(name)
+	return WrapInternalError(fmt.Errorf("%s %s: %s", name, status.State, status.Detail))
+}
+
+// joinDetail appends an error to a subsystem's detail.
+func joinDetail(detail, errText string) string {
+	return strings.TrimPrefix(detail+": "+errText, ": ")
+}
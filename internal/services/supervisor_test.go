@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+func TestSupervisor_IsolatesFailures(t *testing.T) {
+	startup := NewStartupRecorder(constants.StartupTriggerBoot)
+	features := config.FeaturesConfig{Disabled: []string{constants.FeaturePreviews}}
+	supervisor := NewSupervisor(startup, features, logger.NewLogger(logger.LevelError))
+
+	ok := func() (string, error) { return "", nil }
+	supervisor.Start(Subsystem{Name: "db", Required: true, Start: func() (string, error) { return "", errors.New("locked") }})
+	supervisor.Start(Subsystem{Name: "topics", Required: true, Requires: []string{"db"}, Start: ok})
+	supervisor.Start(Subsystem{Name: "queries", Start: func() (string, error) { return "using defaults", Degraded(errors.New("bad yaml")) }})
+	supervisor.Start(Subsystem{Name: "prompts", Start: func() (string, error) { panic("boom") }})
+	supervisor.Start(Subsystem{Name: constants.StartupStepPreviews, Feature: constants.FeaturePreviews, Start: ok})
+	if !supervisor.Start(Subsystem{Name: "search", Requires: []string{"queries"}, Start: ok}) {
+		t.Error("expected a subsystem requiring a degraded one to start")
+	}
+
+	want := map[string]string{
+		"db":                          constants.SubsystemStateFailed,
+		"topics":                      constants.SubsystemStateSkipped,
+		"queries":                     constants.SubsystemStateDegraded,
+		"prompts":                     constants.SubsystemStateFailed,
+		constants.StartupStepPreviews: constants.SubsystemStateDisabled,
+		"search":                      constants.SubsystemStateRunning,
+	}
+	for name, state := range want {
+		if status, _ := startup.Subsystem(name); status.State != state {
+			t.Errorf("%s: expected %s, got %+v", name, state, status)
+		}
+	}
+
+	steps := map[string]string{}
+	for _, step := range startup.Report().Steps {
+		steps[step.Name] = step.Status
+	}
+	if steps["db"] != constants.StartupStepFailed || steps["topics"] != constants.StartupStepFailed {
+		t.Errorf("expected required subsystems to fail their steps: %v", steps)
+	}
+	if steps["prompts"] != constants.StartupStepWarning || steps[constants.StartupStepPreviews] != constants.StartupStepSkipped {
+		t.Errorf("unexpected optional subsystem steps: %v", steps)
+	}
+}
+
+func TestHealthService_ReadinessFollowsSubsystems(t *testing.T) {
+	m := newMockAppState()
+	m.log = logger.NewLogger(logger.LevelError)
+	svc := NewHealthService(m, m.log)
+
+	startup := NewStartupRecorder(constants.StartupTriggerBoot)
+	m.SetStartupRecorder(startup)
+	supervisor := NewSupervisor(startup, config.FeaturesConfig{Disabled: []string{constants.FeatureSearch}}, m.log)
+	supervisor.Start(Subsystem{Name: constants.StartupStepSearch, Feature: constants.FeatureSearch, Start: func() (string, error) { return "", nil }})
+
+	subsystems := func() ComponentHealth {
+		for _, c := range svc.Readiness().Components {
+			if c.Name == constants.HealthComponentSubsystems {
+				return c
+			}
+		}
+		t.Fatal("no subsystems component")
+		return ComponentHealth{}
+	}
+	if c := subsystems(); c.Status != constants.HealthStatusOK {
+		t.Errorf("disabled subsystems should not affect readiness: %+v", c)
+	}
+
+	supervisor.Start(Subsystem{Name: constants.StartupStepPrompts, Start: func() (string, error) { return "", errors.New("unreadable") }})
+	if c := subsystems(); c.Status != constants.HealthStatusDegraded {
+		t.Errorf("expected an optional failure to degrade: %+v", c)
+	}
+
+	supervisor.Start(Subsystem{Name: constants.StartupStepAuthBootstrap, Required: true, Start: func() (string, error) { return "", errors.New("locked") }})
+	if c := subsystems(); c.Status != constants.HealthStatusFailed {
+		t.Errorf("expected a required failure to fail readiness: %+v", c)
+	}
+	if report := svc.Readiness(); report.Subsystems[constants.StartupStepSearch] != constants.SubsystemStateDisabled {
+		t.Errorf("unexpected subsystem states: %v", report.Subsystems)
+	}
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+)
+
+// SyncManifestEntry is one hash of a client manifest. Topic is the topic the
+// client expects the asset in; empty means any topic.
+type SyncManifestEntry struct {
+	Hash  string `json:"hash"`
+	Topic string `json:"topic,omitempty"`
+}
+
+// UnmarshalJSON accepts either a bare hash string or {"hash", "topic"}.
+func (e *SyncManifestEntry) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		e.Topic = ""
+		return json.Unmarshal(data, &e.Hash)
+	}
+	type plain SyncManifestEntry
+	return json.Unmarshal(data, (*plain)(e))
+}
+
+// SyncDiffResult reports where one manifest hash stands on this server.
+type SyncDiffResult struct {
+	Hash          string `json:"hash"`
+	Status        string `json:"status"`
+	Topic         string `json:"topic,omitempty"`          // topic holding the asset
+	ExpectedTopic string `json:"expected_topic,omitempty"` // topic the manifest named
+}
+
+// SyncDiffSummary counts results by status.
+type SyncDiffSummary struct {
+	Total     int `json:"total"`
+	Present   int `json:"present"`
+	Missing   int `json:"missing"`
+	Elsewhere int `json:"elsewhere"`
+	Invalid   int `json:"invalid"`
+}
+
+func (s *SyncDiffSummary) add(status string) {
+	s.Total++
+	switch status {
+	case constants.SyncDiffStatusPresent:
+		s.Present++
+	case constants.SyncDiffStatusMissing:
+		s.Missing++
+	case constants.SyncDiffStatusElsewhere:
+		s.Elsewhere++
+	case constants.SyncDiffStatusInvalid:
+		s.Invalid++
+	}
+}
+
+// SyncManifestReader yields manifest entries one at a time so arbitrarily
+// large manifests are never held in memory. Next returns io.EOF when done.
+type SyncManifestReader interface {
+	Next() (SyncManifestEntry, error)
+}
+
+// textManifestReader reads one "<hash> [topic]" entry per line. Blank lines
+// and lines starting with # are skipped.
+type textManifestReader struct {
+	scanner      *bufio.Scanner
+	defaultTopic string
+	line         int
+}
+
+// NewTextManifestReader reads a plain-text manifest. Entries without a topic
+// column get defaultTopic.
+func NewTextManifestReader(r io.Reader, defaultTopic string) SyncManifestReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 256), constants.SyncDiffMaxLineBytes)
+	return &textManifestReader{scanner: scanner, defaultTopic: defaultTopic}
+}
+
+func (t *textManifestReader) Next() (SyncManifestEntry, error) {
+	for t.scanner.Scan() {
+		t.line++
+		fields := strings.Fields(t.scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) > 2 {
+			return SyncManifestEntry{}, NewServiceError(constants.ErrCodeInvalidManifest,
+				fmt.Sprintf("line %d: expected \"<hash> [topic]\"", t.line))
+		}
+		entry := SyncManifestEntry{Hash: fields[0], Topic: t.defaultTopic}
+		if len(fields) == 2 {
+			entry.Topic = fields[1]
+		}
+		return entry, nil
+	}
+	if err := t.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return SyncManifestEntry{}, NewServiceError(constants.ErrCodeInvalidManifest,
+				fmt.Sprintf("line %d exceeds %d bytes", t.line+1, constants.SyncDiffMaxLineBytes))
+		}
+		return SyncManifestEntry{}, WrapServiceError(constants.ErrCodeInvalidManifest, "failed to read manifest", err)
+	}
+	return SyncManifestEntry{}, io.EOF
+}
+
+// jsonManifestReader streams the elements of a JSON manifest, either a bare
+// array or an object with a "hashes" array.
+type jsonManifestReader struct {
+	dec          *json.Decoder
+	defaultTopic string
+	started      bool
+}
+
+// NewJSONManifestReader reads a JSON manifest. Elements are hash strings or
+// {"hash", "topic"} objects; elements without a topic get defaultTopic.
+func NewJSONManifestReader(r io.Reader, defaultTopic string) SyncManifestReader {
+	return &jsonManifestReader{dec: json.NewDecoder(r), defaultTopic: defaultTopic}
+}
+
+func (j *jsonManifestReader) Next() (SyncManifestEntry, error) {
+	if !j.started {
+		if err := j.seekArray(); err != nil {
+			return SyncManifestEntry{}, err
+		}
+		j.started = true
+	}
+
+	if !j.dec.More() {
+		return SyncManifestEntry{}, io.EOF
+	}
+
+	var entry SyncManifestEntry
+	if err := j.dec.Decode(&entry); err != nil {
+		return SyncManifestEntry{}, WrapServiceError(constants.ErrCodeInvalidManifest, "invalid manifest entry", err)
+	}
+	if entry.Topic == "" {
+		entry.Topic = j.defaultTopic
+	}
+	return entry, nil
+}
+
+// seekArray positions the decoder on the first element of the hash array.
+func (j *jsonManifestReader) seekArray() error {
+	tok, err := j.dec.Token()
+	if err != nil {
+		return WrapServiceError(constants.ErrCodeInvalidManifest, "invalid JSON manifest", err)
+	}
+	if tok == json.Delim('[') {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return NewServiceError(constants.ErrCodeInvalidManifest, "manifest must be an array or an object with a hashes array")
+	}
+
+	for j.dec.More() {
+		key, err := j.dec.Token()
+		if err != nil {
+			return WrapServiceError(constants.ErrCodeInvalidManifest, "invalid JSON manifest", err)
+		}
+		if key == "hashes" {
+			tok, err := j.dec.Token()
+			if err != nil || tok != json.Delim('[') {
+				return NewServiceError(constants.ErrCodeInvalidManifest, "hashes must be an array")
+			}
+			return nil
+		}
+		// Skip the value of any other key
+		var skip json.RawMessage
+		if err := j.dec.Decode(&skip); err != nil {
+			return WrapServiceError(constants.ErrCodeInvalidManifest, "invalid JSON manifest", err)
+		}
+	}
+	return NewServiceError(constants.ErrCodeInvalidManifest, "manifest has no hashes array")
+}
+
+// SyncService compares client hash manifests against the orchestrator index.
+type SyncService struct {
+	app    AppState
+	logger *logger.Logger
+}
+
+// NewSyncService creates a new sync service instance.
+func NewSyncService(app AppState, log *logger.Logger) *SyncService {
+	return &SyncService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// Diff streams a manifest through the orchestrator index in batches of
+// constants.SyncDiffBatchSize, calling emit with each batch of results in
+// manifest order. Memory use is bounded by the batch size regardless of
+// manifest length. If emit returns an error, Diff stops and returns it.
+func (s *SyncService) Diff(manifest SyncManifestReader, emit func([]SyncDiffResult) error) (*SyncDiffSummary, error) {
+	if s.app.GetWorkingDirectory() == "" {
+		return nil, ErrNotConfigured
+	}
+
+	summary := &SyncDiffSummary{}
+	batch := make([]SyncManifestEntry, 0, constants.SyncDiffBatchSize)
+
+	for {
+		entry, err := manifest.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return summary, err
+		}
+		if summary.Total+len(batch) >= constants.SyncDiffMaxEntries {
+			return summary, NewServiceError(constants.ErrCodeManifestTooLarge,
+				fmt.Sprintf("manifest exceeds %d entries", constants.SyncDiffMaxEntries))
+		}
+
+		batch = append(batch, entry)
+		if len(batch) == constants.SyncDiffBatchSize {
+			if err := s.diffBatch(batch, summary, emit); err != nil {
+				return summary, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := s.diffBatch(batch, summary, emit); err != nil {
+			return summary, err
+		}
+	}
+
+	return summary, nil
+}
+
+// diffBatch resolves one batch with a single index lookup.
+func (s *SyncService) diffBatch(batch []SyncManifestEntry, summary *SyncDiffSummary, emit func([]SyncDiffResult) error) error {
+	lookup := make([]string, 0, len(batch))
+	for i := range batch {
+		batch[i].Hash = strings.ToLower(batch[i].Hash)
+		if isHexHash(batch[i].Hash) {
+			lookup = append(lookup, batch[i].Hash)
+		}
+	}
+
+	found, err := s.app.GetAssetIndex().LookupTopics(lookup)
+	if err != nil {
+		return WrapInternalError(err)
+	}
+
+	results := make([]SyncDiffResult, len(batch))
+	for i, entry := range batch {
+		result := SyncDiffResult{Hash: entry.Hash, ExpectedTopic: entry.Topic}
+		topic, ok := found[entry.Hash]
+		switch {
+		case !isHexHash(entry.Hash):
+			result.Status = constants.SyncDiffStatusInvalid
+		case !ok:
+			result.Status = constants.SyncDiffStatusMissing
+		case entry.Topic != "" && entry.Topic != topic:
+			result.Status = constants.SyncDiffStatusElsewhere
+			result.Topic = topic
+		default:
+			result.Status = constants.SyncDiffStatusPresent
+			result.Topic = topic
+		}
+		summary.add(result.Status)
+		results[i] = result
+	}
+
+	return emit(results)
+}
+
+// isHexHash reports whether s is a lowercase hex BLAKE3 hash.
+func isHexHash(s string) bool {
+	if len(s) != constants.HashLength {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
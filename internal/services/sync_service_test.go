@@ -0,0 +1,183 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// newSyncTest creates an orchestrator DB indexing hash → topic.
+func newSyncTest(t *testing.T, index map[string]string) *SyncService {
+	t.Helper()
+	mock := newMockAppState()
+	mock.workingDir = t.TempDir()
+	mock.log = logger.NewLogger("error")
+
+	orchDB, err := database.InitOrchestratorDB(filepath.Join(mock.workingDir, constants.OrchestratorDB))
+	if err != nil {
+		t.Fatalf("init orchestrator: %v", err)
+	}
+	t.Cleanup(func() { orchDB.Close() })
+	mock.orchestratorDB = orchDB
+
+	for hash, topic := range index {
+		if err := database.InsertAssetIndexIgnore(orchDB, hash, topic, "000001.dat"); err != nil {
+			t.Fatalf("insert index: %v", err)
+		}
+	}
+
+	return NewSyncService(mock, mock.log)
+}
+
+// readAll drains a manifest reader.
+func readAll(t *testing.T, r SyncManifestReader) []SyncManifestEntry {
+	t.Helper()
+	var entries []SyncManifestEntry
+	for {
+		entry, err := r.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func TestTextManifestReader(t *testing.T) {
+	input := "# manifest\n\naaaa\nbbbb  other-topic\n   cccc\n"
+	entries := readAll(t, NewTextManifestReader(strings.NewReader(input), "default"))
+
+	want := []SyncManifestEntry{
+		{Hash: "aaaa", Topic: "default"},
+		{Hash: "bbbb", Topic: "other-topic"},
+		{Hash: "cccc", Topic: "default"},
+	}
+	if fmt.Sprint(entries) != fmt.Sprint(want) {
+		t.Errorf("entries = %v, want %v", entries, want)
+	}
+
+	_, err := NewTextManifestReader(strings.NewReader("a b c\n"), "").Next()
+	if code, _ := IsServiceError(err); code != constants.ErrCodeInvalidManifest {
+		t.Errorf("expected %s for extra columns, got %v", constants.ErrCodeInvalidManifest, err)
+	}
+}
+
+func TestJSONManifestReader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"bare array", `["aaaa", {"hash": "bbbb", "topic": "other"}]`},
+		{"wrapped", `{"version": 2, "hashes": ["aaaa", {"hash": "bbbb", "topic": "other"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := readAll(t, NewJSONManifestReader(strings.NewReader(tt.input), "default"))
+			want := []SyncManifestEntry{{Hash: "aaaa", Topic: "default"}, {Hash: "bbbb", Topic: "other"}}
+			if fmt.Sprint(entries) != fmt.Sprint(want) {
+				t.Errorf("entries = %v, want %v", entries, want)
+			}
+		})
+	}
+
+	_, err := NewJSONManifestReader(strings.NewReader(`{"other": []}`), "").Next()
+	if code, _ := IsServiceError(err); code != constants.ErrCodeInvalidManifest {
+		t.Errorf("expected %s without hashes, got %v", constants.ErrCodeInvalidManifest, err)
+	}
+}
+
+func TestSyncDiff_Statuses(t *testing.T) {
+	present := lineageHash("a")
+	elsewhere := lineageHash("b")
+	missing := lineageHash("c")
+	svc := newSyncTest(t, map[string]string{present: "textures", elsewhere: "models"})
+
+	manifest := strings.Join([]string{
+		strings.ToUpper(present),
+		elsewhere,
+		missing,
+		"not-a-hash",
+		elsewhere + " models",
+	}, "\n")
+
+	var results []SyncDiffResult
+	summary, err := svc.Diff(NewTextManifestReader(strings.NewReader(manifest), "textures"), func(batch []SyncDiffResult) error {
+		results = append(results, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+
+	wantStatus := []string{
+		constants.SyncDiffStatusPresent,
+		constants.SyncDiffStatusElsewhere,
+		constants.SyncDiffStatusMissing,
+		constants.SyncDiffStatusInvalid,
+		constants.SyncDiffStatusPresent,
+	}
+	if len(results) != len(wantStatus) {
+		t.Fatalf("expected %d results, got %d", len(wantStatus), len(results))
+	}
+	for i, want := range wantStatus {
+		if results[i].Status != want {
+			t.Errorf("result %d (%s): status %s, want %s", i, results[i].Hash, results[i].Status, want)
+		}
+	}
+	if results[0].Hash != present {
+		t.Errorf("hash should be normalized to lowercase, got %s", results[0].Hash)
+	}
+	if results[1].Topic != "models" || results[1].ExpectedTopic != "textures" {
+		t.Errorf("elsewhere result = %+v", results[1])
+	}
+
+	if summary.Total != 5 || summary.Present != 2 || summary.Elsewhere != 1 || summary.Missing != 1 || summary.Invalid != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestSyncDiff_Batches(t *testing.T) {
+	svc := newSyncTest(t, nil)
+
+	n := constants.SyncDiffBatchSize*2 + 7
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "%064x\n", i)
+	}
+
+	var batches []int
+	summary, err := svc.Diff(NewTextManifestReader(strings.NewReader(b.String()), ""), func(batch []SyncDiffResult) error {
+		batches = append(batches, len(batch))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+
+	want := []int{constants.SyncDiffBatchSize, constants.SyncDiffBatchSize, 7}
+	if fmt.Sprint(batches) != fmt.Sprint(want) {
+		t.Errorf("batch sizes = %v, want %v", batches, want)
+	}
+	if summary.Missing != n {
+		t.Errorf("expected %d missing, got %d", n, summary.Missing)
+	}
+}
+
+func TestSyncDiff_NotConfigured(t *testing.T) {
+	mock := newMockAppState()
+	svc := NewSyncService(mock, logger.NewLogger("error"))
+
+	_, err := svc.Diff(NewTextManifestReader(strings.NewReader(""), ""), func([]SyncDiffResult) error { return nil })
+	if err != ErrNotConfigured {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"database/sql"
+	"sort"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// TimelineActor is who caused a timeline event: an audited user for
+// requests, a processor for metadata written without one.
+type TimelineActor struct {
+	Username         string `json:"username,omitempty"`
+	ActorType        string `json:"actor_type,omitempty"`
+	IPAddress        string `json:"ip_address,omitempty"`
+	Processor        string `json:"processor,omitempty"`
+	ProcessorVersion string `json:"processor_version,omitempty"`
+}
+
+// TimelineEvent is one entry of an asset's history.
+type TimelineEvent struct {
+	Timestamp int64          `json:"timestamp"`
+	Kind      string         `json:"kind"`             // constants.AssetTimelineKind*
+	Action    string         `json:"action,omitempty"` // audit action, or metadata op
+	Actor     *TimelineActor `json:"actor,omitempty"`
+	Details   interface{}    `json:"details,omitempty"`
+
+	rank int   // orders events sharing a timestamp: the upload comes first
+	seq  int64 // source row ID
+}
+
+// AssetTimeline is one page of an asset's history, newest first.
+type AssetTimeline struct {
+	Hash          string          `json:"hash"`
+	Topic         string          `json:"topic"`
+	Events        []TimelineEvent `json:"events"`
+	Total         int64           `json:"total"`
+	Limit         int             `json:"limit"`
+	Offset        int             `json:"offset"`
+	AuditIncluded bool            `json:"audit_included"` // downloads, audit entries and actors are shown
+}
+
+// TimelineOptions filters and paginates an asset's timeline.
+type TimelineOptions struct {
+	Limit  int
+	Offset int
+	Since  int64  // Unix timestamp, inclusive (0 = unbounded)
+	Until  int64  // Unix timestamp, inclusive (0 = unbounded)
+	Kind   string // One of constants.AssetTimelineKind* ("" = all)
+
+	// Audit-derived events and actors are only included when IncludeAudit
+	// is set, and only those recorded under AuditUsername when it is set.
+	IncludeAudit  bool
+	AuditUsername string
+
+	// TopicVisible hides derived assets stored in topics the caller may not
+	// read. Nil shows all.
+	TopicVisible func(topic string) bool
+}
+
+// IsValidTimelineKind reports whether kind is an asset timeline event kind.
+func IsValidTimelineKind(kind string) bool {
+	switch kind {
+	case constants.AssetTimelineKindUpload, constants.AssetTimelineKindReupload,
+		constants.AssetTimelineKindMetadata, constants.AssetTimelineKindDownload,
+		constants.AssetTimelineKindDerived, constants.AssetTimelineKindAudit,
+		constants.AssetTimelineKindLineage:
+		return true
+	}
+	return false
+}
+
+// TimelineService merges everything recorded about an asset into one feed.
+type TimelineService struct {
+	app    AppState
+	logger *logger.Logger
+}
+
+// NewTimelineService creates a new timeline service instance.
+func NewTimelineService(app AppState, log *logger.Logger) *TimelineService {
+	return &TimelineService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// Timeline returns one page of an asset's history: its upload, metadata log
+// entries, parent changes, derived assets, and the audit entries mentioning it (re-uploads,
+// downloads and anything else). Each source is read newest first up to the
+// end of the requested page, so the cost grows with offset+limit rather than
+// with the asset's full history.
+func (s *TimelineService) Timeline(hash string, opts TimelineOptions) (*AssetTimeline, error) {
+	if len(hash) != constants.HashLength {
+		return nil, ErrInvalidHash
+	}
+	if opts.Kind != "" && !IsValidTimelineKind(opts.Kind) {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "invalid timeline kind: "+opts.Kind)
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = constants.AssetTimelineDefaultLimit
+	}
+	if opts.Limit > constants.AssetTimelineMaxLimit {
+		opts.Limit = constants.AssetTimelineMaxLimit
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+	exists, topic, _, err := s.app.GetAssetIndex().Lookup(hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if !exists {
+		return nil, ErrAssetNotFoundWithHash(hash)
+	}
+	if healthy, reason := s.app.IsTopicHealthy(topic); !healthy {
+		return nil, ErrTopicUnhealthyWithReason(topic, reason)
+	}
+	topicDB, err := s.app.GetTopicDB(topic)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	asset, err := database.GetAsset(topicDB, hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if asset == nil {
+		return nil, ErrAssetNotFoundWithHash(hash)
+	}
+
+	// Include audit entries still waiting in the write queue
+	if l := s.app.GetAuditLogger(); l != nil && opts.IncludeAudit {
+		l.Flush()
+	}
+
+	wanted := func(kind string) bool { return opts.Kind == "" || opts.Kind == kind }
+	inWindow := func(ts int64) bool {
+		return (opts.Since <= 0 || ts >= opts.Since) && (opts.Until <= 0 || ts <= opts.Until)
+	}
+	need := opts.Offset + opts.Limit
+
+	var events []TimelineEvent
+	var total int64
+
+	// The upload, attributed to the adding_file entry that stored it
+	var uploadEntry *audit.Entry
+	if opts.IncludeAudit {
+		uploadEntry, err = s.app.GetAuditStore().FirstMention(hash, constants.AuditActionAddingFile)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+	}
+	// Parent changes, and the parent the asset was uploaded with
+	changes, err := database.ListLineageChanges(topicDB, hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	uploadParent := asset.ParentID
+	if len(changes) > 0 {
+		uploadParent = changes[0].OldParentID
+	}
+
+	if wanted(constants.AssetTimelineKindUpload) && inWindow(asset.CreatedAt) {
+		event := TimelineEvent{
+			Timestamp: asset.CreatedAt,
+			Kind:      constants.AssetTimelineKindUpload,
+			Details: map[string]interface{}{
+				"origin_name": asset.OriginName,
+				"extension":   asset.Extension,
+				"size":        asset.AssetSize,
+				"parent_id":   uploadParent,
+			},
+		}
+		if uploadEntry != nil && (opts.AuditUsername == "" || uploadEntry.Username == opts.AuditUsername) {
+			event.Actor = auditActor(uploadEntry)
+		}
+		events = append(events, event)
+		total++
+	}
+
+	if wanted(constants.AssetTimelineKindMetadata) {
+		entries, count, err := database.GetMetadataLogPage(topicDB, hash, opts.Since, opts.Until, need)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		metadataEvents := make([]TimelineEvent, len(entries))
+		for i, entry := range entries {
+			details := map[string]interface{}{"key": entry.Key}
+			if entry.Op == "set" {
+				details["value"] = entry.Value
+			}
+			metadataEvents[i] = TimelineEvent{
+				Timestamp: entry.Timestamp,
+				Kind:      constants.AssetTimelineKindMetadata,
+				Action:    entry.Op,
+				Actor:     &TimelineActor{Processor: entry.Processor, ProcessorVersion: entry.ProcessorVersion},
+				Details:   details,
+				rank:      1,
+				seq:       entry.ID,
+			}
+		}
+		if opts.IncludeAudit {
+			if err := s.attributeMetadata(orchDB, hash, metadataEvents, opts.AuditUsername); err != nil {
+				return nil, WrapInternalError(err)
+			}
+		}
+		events = append(events, metadataEvents...)
+		total += count
+	}
+
+	if wanted(constants.AssetTimelineKindLineage) {
+		for _, change := range changes {
+			if !inWindow(change.ChangedAt) {
+				continue
+			}
+			event := TimelineEvent{
+				Timestamp: change.ChangedAt,
+				Kind:      constants.AssetTimelineKindLineage,
+				Details: map[string]interface{}{
+					"old_parent_id": change.OldParentID,
+					"new_parent_id": change.NewParentID,
+					"reason":        change.Reason,
+				},
+				rank: 1,
+				seq:  change.ID,
+			}
+			if opts.IncludeAudit && (opts.AuditUsername == "" || change.ChangedBy == opts.AuditUsername) {
+				event.Actor = &TimelineActor{Username: change.ChangedBy}
+			}
+			events = append(events, event)
+			total++
+		}
+	}
+
+	if wanted(constants.AssetTimelineKindDerived) {
+		derived, err := s.derivedEvents(orchDB, hash, opts, inWindow)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		total += int64(len(derived))
+		events = append(events, derived...)
+	}
+
+	if opts.IncludeAudit && opts.Kind != constants.AssetTimelineKindUpload &&
+		opts.Kind != constants.AssetTimelineKindMetadata && opts.Kind != constants.AssetTimelineKindDerived &&
+		opts.Kind != constants.AssetTimelineKindLineage {
+		mention := audit.MentionOptions{
+			Limit:    need,
+			Since:    max(opts.Since, asset.CreatedAt), // nothing mentions the asset before it exists
+			Until:    opts.Until,
+			Username: opts.AuditUsername,
+		}
+		if uploadEntry != nil {
+			mention.ExcludeID = uploadEntry.ID
+		}
+		switch opts.Kind {
+		case constants.AssetTimelineKindReupload:
+			mention.Actions = []string{constants.AuditActionAddingFile}
+		case constants.AssetTimelineKindDownload:
+			mention.Actions = []string{constants.AuditActionDownloaded}
+		case constants.AssetTimelineKindAudit:
+			mention.ExcludeActions = []string{constants.AuditActionAddingFile, constants.AuditActionDownloaded, constants.AuditActionMetadataSet}
+		default:
+			// metadata_set entries are folded into the metadata events
+			mention.ExcludeActions = []string{constants.AuditActionMetadataSet}
+		}
+
+		entries, count, err := s.app.GetAuditStore().QueryMentions(hash, mention)
+		if err != nil {
+			return nil, WrapInternalError(err)
+		}
+		for i := range entries {
+			entry := &entries[i]
+			kind := constants.AssetTimelineKindAudit
+			switch entry.Action {
+			case constants.AuditActionAddingFile:
+				kind = constants.AssetTimelineKindReupload
+			case constants.AuditActionDownloaded:
+				kind = constants.AssetTimelineKindDownload
+			}
+			events = append(events, TimelineEvent{
+				Timestamp: entry.Timestamp,
+				Kind:      kind,
+				Action:    entry.Action,
+				Actor:     auditActor(entry),
+				Details:   entry.Details,
+				rank:      1,
+				seq:       entry.ID,
+			})
+		}
+		total += count
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if a.Timestamp != b.Timestamp {
+			return a.Timestamp > b.Timestamp
+		}
+		if a.rank != b.rank {
+			return a.rank > b.rank
+		}
+		return a.seq > b.seq
+	})
+
+	page := []TimelineEvent{}
+	if opts.Offset < len(events) {
+		page = events[opts.Offset:min(need, len(events))]
+	}
+
+	return &AssetTimeline{
+		Hash:          hash,
+		Topic:         topic,
+		Events:        page,
+		Total:         total,
+		Limit:         opts.Limit,
+		Offset:        opts.Offset,
+		AuditIncluded: opts.IncludeAudit,
+	}, nil
+}
+
+// derivedEvents returns one event per derived asset registered in the
+// lineage references, with the derived asset's name when its topic is open.
+func (s *TimelineService) derivedEvents(orchDB *sql.DB, hash string, opts TimelineOptions, inWindow func(int64) bool) ([]TimelineEvent, error) {
+	refs, err := database.ListAssetReferences(orchDB, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []TimelineEvent
+	for _, ref := range refs {
+		if ref.Kind != constants.ReferenceKindLineage || !inWindow(ref.CreatedAt) {
+			continue
+		}
+		if opts.TopicVisible != nil && !opts.TopicVisible(ref.Topic) {
+			continue
+		}
+
+		details := map[string]interface{}{
+			"hash":  ref.Holder,
+			"topic": ref.Topic,
+		}
+		if db, err := s.app.GetTopicDB(ref.Topic); err == nil {
+			if child, err := database.GetAsset(db, ref.Holder); err == nil && child != nil {
+				details["origin_name"] = child.OriginName
+				details["extension"] = child.Extension
+			}
+		}
+		events = append(events, TimelineEvent{
+			Timestamp: ref.CreatedAt,
+			Kind:      constants.AssetTimelineKindDerived,
+			Details:   details,
+			rank:      1,
+		})
+	}
+	return events, nil
+}
+
+// attributeMetadata adds the audited user to metadata events written by a
+// single-key request, matched on key and timestamp.
+func (s *TimelineService) attributeMetadata(orchDB *sql.DB, hash string, events []TimelineEvent, username string) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	// Events are newest first
+	entries, _, err := s.app.GetAuditStore().QueryMentions(hash, audit.MentionOptions{
+		Limit:    constants.AssetTimelineMaxLimit,
+		Since:    events[len(events)-1].Timestamp,
+		Until:    events[0].Timestamp,
+		Username: username,
+		Actions:  []string{constants.AuditActionMetadataSet},
+	})
+	if err != nil {
+		return err
+	}
+
+	type match struct {
+		key string
+		ts  int64
+	}
+	actors := make(map[match]*audit.Entry, len(entries))
+	for i := range entries {
+		if details, ok := entries[i].Details.(map[string]interface{}); ok {
+			key, _ := details["key"].(string)
+			actors[match{key, entries[i].Timestamp}] = &entries[i]
+		}
+	}
+	for i := range events {
+		key, _ := events[i].Details.(map[string]interface{})["key"].(string)
+		if entry, ok := actors[match{key, events[i].Timestamp}]; ok {
+			actor := auditActor(entry)
+			actor.Processor = events[i].Actor.Processor
+			actor.ProcessorVersion = events[i].Actor.ProcessorVersion
+			events[i].Actor = actor
+		}
+	}
+	return nil
+}
+
+// auditActor returns the actor recorded in an audit entry.
+func auditActor(entry *audit.Entry) *TimelineActor {
+	return &TimelineActor{
+		Username:  entry.Username,
+		ActorType: entry.ActorType,
+		IPAddress: entry.IPAddress,
+	}
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestTopicFreezeSetFrozen(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newConfigMock(t.TempDir())
+	m.RegisterTopic("photos", true, "")
+	m.RegisterTopic("renders", true, "")
+	svc := NewTopicFreezeService(m, m.log)
+
+	if _, err := svc.SetFrozen("missing", true); !isServiceErrorCode(err, constants.ErrCodeTopicNotFound) {
+		t.Errorf("expected TOPIC_NOT_FOUND, got %v", err)
+	}
+
+	for _, topic := range []string{"renders", "photos"} {
+		if changed, err := svc.SetFrozen(topic, true); err != nil || !changed {
+			t.Fatalf("freezing %s: changed=%v, err=%v", topic, changed, err)
+		}
+	}
+	if changed, err := svc.SetFrozen("photos", true); err != nil || changed {
+		t.Errorf("freezing a frozen topic should change nothing: changed=%v, err=%v", changed, err)
+	}
+	if len(m.cfg.FrozenTopics) != 2 || m.cfg.FrozenTopics[0] != "photos" {
+		t.Errorf("expected sorted frozen topics, got %v", m.cfg.FrozenTopics)
+	}
+	if err := checkTopicWritable(m, "photos"); !isServiceErrorCode(err, constants.ErrCodeTopicFrozen) {
+		t.Errorf("expected TOPIC_FROZEN, got %v", err)
+	}
+
+	if changed, err := svc.SetFrozen("photos", false); err != nil || !changed {
+		t.Fatalf("unfreezing: changed=%v, err=%v", changed, err)
+	}
+	if err := checkTopicWritable(m, "photos"); err != nil {
+		t.Errorf("expected unfrozen topic to be writable, got %v", err)
+	}
+	if err := checkTopicWritable(m, "renders"); !isServiceErrorCode(err, constants.ErrCodeTopicFrozen) {
+		t.Errorf("expected renders to stay frozen, got %v", err)
+	}
+}
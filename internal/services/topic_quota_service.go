@@ -0,0 +1,193 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// TopicQuotaService manages the quotas of topics (topic_quotas). Their hard
+// limits are enforced on uploads by AssetService.
+type TopicQuotaService struct {
+	app    AppState
+	logger *logger.Logger
+	mu     sync.Mutex
+}
+
+// TopicQuotaUsage is the quota of a topic and how much of it is used.
+type TopicQuotaUsage struct {
+	Quota         config.TopicQuotaConfig `json:"quota"`
+	Bytes         int64                   `json:"bytes"`
+	Assets        int64                   `json:"assets"`
+	BytesPercent  float64                 `json:"bytes_percent,omitempty"`  // of hard_bytes, or soft_bytes without one
+	AssetsPercent float64                 `json:"assets_percent,omitempty"` // of hard_assets, or soft_assets without one
+	SoftExceeded  bool                    `json:"soft_exceeded"`
+	HardReached   bool                    `json:"hard_reached"` // uploads of new content are refused
+}
+
+// TopicQuotaPatch changes the limits it sets; 0 removes a limit and unset
+// fields are kept.
+type TopicQuotaPatch struct {
+	SoftBytes  *int64 `json:"soft_bytes"`
+	HardBytes  *int64 `json:"hard_bytes"`
+	SoftAssets *int64 `json:"soft_assets"`
+	HardAssets *int64 `json:"hard_assets"`
+}
+
+// NewTopicQuotaService creates a new topic quota service instance.
+func NewTopicQuotaService(app AppState, log *logger.Logger) *TopicQuotaService {
+	return &TopicQuotaService{
+		app:    app,
+		logger: log,
+	}
+}
+
+// Usage returns the quota of a topic and its current usage. Topics without
+// a quota report zero limits.
+func (s *TopicQuotaService) Usage(topicName string) (*TopicQuotaUsage, error) {
+	db, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, ErrTopicNotFoundWithName(topicName)
+	}
+	assets, bytes, err := database.GetAssetTotals(db)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return newTopicQuotaUsage(s.app.GetConfig().TopicQuotas[topicName], assets, bytes), nil
+}
+
+// Set applies patch to the quota of a topic and saves the config. A quota
+// left without limits is removed.
+func (s *TopicQuotaService) Set(topicName string, patch TopicQuotaPatch) (*TopicQuotaUsage, error) {
+	if !s.app.TopicExists(topicName) {
+		return nil, ErrTopicNotFoundWithName(topicName)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg := s.app.GetConfig()
+	quota := cfg.TopicQuotas[topicName]
+	if patch.SoftBytes != nil {
+		quota.SoftBytes = *patch.SoftBytes
+	}
+	if patch.HardBytes != nil {
+		quota.HardBytes = *patch.HardBytes
+	}
+	if patch.SoftAssets != nil {
+		quota.SoftAssets = *patch.SoftAssets
+	}
+	if patch.HardAssets != nil {
+		quota.HardAssets = *patch.HardAssets
+	}
+
+	candidate := *cfg
+	candidate.TopicQuotas = maps.Clone(cfg.TopicQuotas)
+	if candidate.TopicQuotas == nil {
+		candidate.TopicQuotas = make(map[string]config.TopicQuotaConfig)
+	}
+	if quota.IsZero() {
+		delete(candidate.TopicQuotas, topicName)
+	} else {
+		candidate.TopicQuotas[topicName] = quota
+	}
+
+	field := "topic_quotas." + topicName
+	var problems []string
+	for _, fe := range candidate.FieldErrors() {
+		if fe.Field == field || strings.HasPrefix(fe.Field, field+".") {
+			problems = append(problems, fe.Message)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, NewServiceError(constants.ErrCodeInvalidTopicQuota, strings.Join(problems, "; "))
+	}
+
+	cfg.TopicQuotas = candidate.TopicQuotas
+	if err := config.SaveConfig(cfg); err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to save config: %w", err))
+	}
+
+	s.logger.Info("Topic quota of %s set: soft_bytes=%d hard_bytes=%d soft_assets=%d hard_assets=%d",
+		topicName, quota.SoftBytes, quota.HardBytes, quota.SoftAssets, quota.HardAssets)
+	return s.Usage(topicName)
+}
+
+// newTopicQuotaUsage reports the usage of assets assets totalling bytes
+// against quota.
+func newTopicQuotaUsage(quota config.TopicQuotaConfig, assets, bytes int64) *TopicQuotaUsage {
+	percent := func(used, soft, hard int64) float64 {
+		limit := hard
+		if limit == 0 {
+			limit = soft
+		}
+		if limit == 0 {
+			return 0
+		}
+		return float64(used) * 100 / float64(limit)
+	}
+	return &TopicQuotaUsage{
+		Quota:         quota,
+		Bytes:         bytes,
+		Assets:        assets,
+		BytesPercent:  percent(bytes, quota.SoftBytes, quota.HardBytes),
+		AssetsPercent: percent(assets, quota.SoftAssets, quota.HardAssets),
+		SoftExceeded: (quota.SoftBytes > 0 && bytes > quota.SoftBytes) ||
+			(quota.SoftAssets > 0 && assets > quota.SoftAssets),
+		HardReached: (quota.HardBytes > 0 && bytes >= quota.HardBytes) ||
+			(quota.HardAssets > 0 && assets >= quota.HardAssets),
+	}
+}
+
+// topicQuotaStat returns the quota usage of a topic for its stats, or nil
+// when it has no quota.
+func topicQuotaStat(app AppState, topicName string, db *sql.DB) *TopicQuotaUsage {
+	quota, ok := app.GetConfig().TopicQuotas[topicName]
+	if !ok {
+		return nil
+	}
+	assets, bytes, err := database.GetAssetTotals(db)
+	if err != nil {
+		return nil
+	}
+	return newTopicQuotaUsage(quota, assets, bytes)
+}
+
+// checkTopicQuota returns TOPIC_QUOTA_EXCEEDED when storing size more
+// bytes as one new asset would pass a hard limit of the topic's quota, and
+// warns when it passes a soft limit. Callers hold the topic's write lock so
+// concurrent uploads cannot both fit in the last slot.
+func checkTopicQuota(app AppState, log *logger.Logger, topicName string, db *sql.DB, size int64) error {
+	quota, ok := app.GetConfig().TopicQuotas[topicName]
+	if !ok {
+		return nil
+	}
+	assets, bytes, err := database.GetAssetTotals(db)
+	if err != nil {
+		return WrapInternalError(err)
+	}
+
+	if quota.HardBytes > 0 && bytes+size > quota.HardBytes {
+		return NewServiceError(constants.ErrCodeTopicQuotaExceeded,
+			fmt.Sprintf("topic %s quota exceeded: %d bytes stored, upload of %d bytes would pass hard_bytes %d", topicName, bytes, size, quota.HardBytes))
+	}
+	if quota.HardAssets > 0 && assets+1 > quota.HardAssets {
+		return NewServiceError(constants.ErrCodeTopicQuotaExceeded,
+			fmt.Sprintf("topic %s quota exceeded: %d assets stored, hard_assets is %d", topicName, assets, quota.HardAssets))
+	}
+
+	if quota.SoftBytes > 0 && bytes <= quota.SoftBytes && bytes+size > quota.SoftBytes {
+		log.Warn("Topic %s passed its soft quota of %d bytes", topicName, quota.SoftBytes)
+	}
+	if quota.SoftAssets > 0 && assets == quota.SoftAssets {
+		log.Warn("Topic %s passed its soft quota of %d assets", topicName, quota.SoftAssets)
+	}
+	return nil
+}
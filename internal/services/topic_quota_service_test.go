@@ -0,0 +1,85 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+)
+
+func int64Ptr(v int64) *int64 { return &v }
+
+func TestTopicQuotaSet_MergesAndRemoves(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+	topicDB := setupTopicDir(t, workDir, "photos", []testAsset{
+		{id: strings.Repeat("a", constants.HashLength), size: 600, ext: "png", blobName: "001.dat", createdAt: 1700000000},
+	})
+	mock.StoreTopicDB("photos", topicDB)
+	mock.RegisterTopic("photos", true, "")
+	svc := NewTopicQuotaService(mock, mock.log)
+
+	if _, err := svc.Set("missing", TopicQuotaPatch{HardAssets: int64Ptr(1)}); !isServiceErrorCode(err, constants.ErrCodeTopicNotFound) {
+		t.Errorf("expected TOPIC_NOT_FOUND, got %v", err)
+	}
+	if _, err := svc.Set("photos", TopicQuotaPatch{SoftBytes: int64Ptr(2000), HardBytes: int64Ptr(1000)}); !isServiceErrorCode(err, constants.ErrCodeInvalidTopicQuota) {
+		t.Errorf("expected INVALID_TOPIC_QUOTA, got %v", err)
+	}
+	if len(mock.cfg.TopicQuotas) != 0 {
+		t.Fatalf("rejected quota should not be stored, got %v", mock.cfg.TopicQuotas)
+	}
+
+	usage, err := svc.Set("photos", TopicQuotaPatch{SoftBytes: int64Ptr(500), HardBytes: int64Ptr(1000)})
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if usage.Bytes != 600 || usage.Assets != 1 || usage.BytesPercent != 60 || !usage.SoftExceeded || usage.HardReached {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+
+	// Omitted limits are kept
+	usage, err = svc.Set("photos", TopicQuotaPatch{HardAssets: int64Ptr(1)})
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	want := config.TopicQuotaConfig{SoftBytes: 500, HardBytes: 1000, HardAssets: 1}
+	if usage.Quota != want || !usage.HardReached {
+		t.Errorf("expected %+v reached, got %+v", want, usage)
+	}
+
+	// Clearing every limit removes the quota
+	zero := int64Ptr(0)
+	if _, err := svc.Set("photos", TopicQuotaPatch{SoftBytes: zero, HardBytes: zero, HardAssets: zero}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, ok := mock.cfg.TopicQuotas["photos"]; ok {
+		t.Errorf("expected the quota removed, got %v", mock.cfg.TopicQuotas)
+	}
+}
+
+func TestCheckTopicQuota_HardLimits(t *testing.T) {
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+	topicDB := setupTopicDir(t, workDir, "photos", []testAsset{
+		{id: strings.Repeat("a", constants.HashLength), size: 600, ext: "png", blobName: "001.dat", createdAt: 1700000000},
+	})
+
+	if err := checkTopicQuota(mock, mock.log, "photos", topicDB, 1<<30); err != nil {
+		t.Errorf("topics without a quota are unbounded, got %v", err)
+	}
+
+	mock.cfg.TopicQuotas = map[string]config.TopicQuotaConfig{"photos": {SoftBytes: 700, HardBytes: 1000}}
+	if err := checkTopicQuota(mock, mock.log, "photos", topicDB, 400); err != nil {
+		t.Errorf("an upload reaching the hard limit fits, got %v", err)
+	}
+	if err := checkTopicQuota(mock, mock.log, "photos", topicDB, 401); !isServiceErrorCode(err, constants.ErrCodeTopicQuotaExceeded) {
+		t.Errorf("expected TOPIC_QUOTA_EXCEEDED for bytes, got %v", err)
+	}
+
+	mock.cfg.TopicQuotas = map[string]config.TopicQuotaConfig{"photos": {HardAssets: 1}}
+	if err := checkTopicQuota(mock, mock.log, "photos", topicDB, 1); !isServiceErrorCode(err, constants.ErrCodeTopicQuotaExceeded) {
+		t.Errorf("expected TOPIC_QUOTA_EXCEEDED for assets, got %v", err)
+	}
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// TrashService moves deleted assets to the trash, restores them and purges
+// them. Trashed assets stay stored but are withheld from downloads, queries
+// and bulk exports; they are deleted permanently once trash.retention_days
+// have passed, or on demand.
+type TrashService struct {
+	app    AppState
+	logger *logger.Logger
+	assets *AssetService
+	stats  *StatsCache
+
+	mu      sync.Mutex
+	running bool
+	stopCh  chan struct{}
+}
+
+// NewTrashService creates a new trash service instance.
+func NewTrashService(app AppState, log *logger.Logger, assets *AssetService, stats *StatsCache) *TrashService {
+	return &TrashService{
+		app:    app,
+		logger: log,
+		assets: assets,
+		stats:  stats,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Trash moves an asset nothing references to the trash. by and ipAddress
+// identify the actor for the audit log. Trashing an asset already in the
+// trash returns its entry.
+func (s *TrashService) Trash(hash, by, ipAddress string) (*database.TrashEntry, error) {
+	if len(hash) != constants.HashLength {
+		return nil, ErrInvalidHash
+	}
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+
+	exists, topicName, _, err := s.app.GetAssetIndex().Lookup(hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if !exists {
+		return nil, ErrAssetNotFoundWithHash(hash)
+	}
+	if healthy, errMsg := s.app.IsTopicHealthy(topicName); !healthy {
+		return nil, ErrTopicUnhealthyWithReason(topicName, errMsg)
+	}
+
+	// Referenced assets could not be purged later, so they are refused
+	// here as they are by a permanent deletion
+	refs, err := database.ListAssetReferences(orchDB, hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if len(refs) > 0 {
+		return nil, NewServiceError(constants.ErrCodeAssetReferenced,
+			fmt.Sprintf("asset %s is referenced by %d holder(s)", hash, len(refs)))
+	}
+
+	topicDB, err := s.app.GetTopicDB(topicName)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	asset, err := database.GetAsset(topicDB, hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if asset == nil {
+		return nil, ErrAssetNotFoundWithHash(hash)
+	}
+
+	entry, created, err := database.TrashAsset(orchDB, database.TrashEntry{
+		Hash:       hash,
+		Topic:      topicName,
+		OriginName: asset.OriginName,
+		Extension:  asset.Extension,
+		Size:       asset.AssetSize,
+		TrashedBy:  by,
+		TrashedAt:  time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	if created {
+		s.logger.Info("Asset %s of topic %s moved to the trash by %s", hash, topicName, by)
+		if l := s.app.GetAuditLogger(); l != nil {
+			if err := l.Log(constants.AuditActionAssetTrashed, ipAddress, by, audit.AssetTrashedDetails{
+				Hash:       hash,
+				Topic:      topicName,
+				Size:       entry.Size,
+				OriginName: entry.OriginName,
+				Extension:  entry.Extension,
+			}); err != nil {
+				s.logger.Error("Failed to write audit entry for trashing of %s: %v", hash, err)
+			}
+		}
+	}
+	return s.withPurgeAt(entry), nil
+}
+
+// Restore takes an asset out of the trash.
+func (s *TrashService) Restore(hash, by, ipAddress string) (*database.TrashEntry, error) {
+	if len(hash) != constants.HashLength {
+		return nil, ErrInvalidHash
+	}
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+
+	entry, err := database.RestoreTrash(orchDB, hash)
+	if errors.Is(err, database.ErrNotTrashed) {
+		return nil, ErrAssetNotTrashedWithHash(hash)
+	}
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	logRestored(s.app, s.logger, entry, by, ipAddress)
+	return entry, nil
+}
+
+// Purge permanently deletes an asset in the trash.
+func (s *TrashService) Purge(hash, by, ipAddress string) (*DeleteResult, error) {
+	entry, err := s.Get(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.assets.Delete(hash, by, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	if s.stats != nil {
+		s.stats.InvalidateTopic(entry.Topic)
+	}
+	return result, nil
+}
+
+// Get returns the trash entry of an asset, or ASSET_NOT_TRASHED.
+func (s *TrashService) Get(hash string) (*database.TrashEntry, error) {
+	if len(hash) != constants.HashLength {
+		return nil, ErrInvalidHash
+	}
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+
+	entry, err := database.GetTrashEntry(orchDB, hash)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if entry == nil {
+		return nil, ErrAssetNotTrashedWithHash(hash)
+	}
+	return s.withPurgeAt(entry), nil
+}
+
+// List returns trashed assets, most recently trashed first. topic filters
+// when not empty.
+func (s *TrashService) List(topic string) ([]database.TrashEntry, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, ErrNotConfigured
+	}
+	entries, err := database.ListTrash(orchDB, topic)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	for i := range entries {
+		s.withPurgeAt(&entries[i])
+	}
+	return entries, nil
+}
+
+// PurgeExpired permanently deletes the assets in the trash for longer than
+// trash.retention_days, at most constants.TrashMaxPurgedPerPass at a time.
+// Assets that cannot be purged, e.g. because something references them
+// again, are left in the trash. Returns the number purged.
+func (s *TrashService) PurgeExpired() (int, error) {
+	orchDB := s.app.GetOrchestratorDB()
+	cfg := s.app.GetConfig()
+	if orchDB == nil || cfg == nil {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-cfg.Trash.Retention()).Unix()
+	expired, err := database.ListExpiredTrash(orchDB, cutoff, constants.TrashMaxPurgedPerPass)
+	if err != nil {
+		return 0, WrapInternalError(err)
+	}
+
+	purged := 0
+	for _, entry := range expired {
+		if _, err := s.Purge(entry.Hash, "", constants.AuditActorSystem); err != nil {
+			s.logger.Warn("[trash] failed to purge %s: %v", entry.Hash, err)
+			continue
+		}
+		purged++
+	}
+	if purged > 0 {
+		s.logger.Info("[trash] purged %d asset(s) trashed more than %d day(s) ago", purged, cfg.Trash.RetentionDays)
+	}
+	return purged, nil
+}
+
+// Start launches the scheduled purge of expired trash.
+// Safe to call multiple times — subsequent calls are no-ops.
+func (s *TrashService) Start(interval time.Duration) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	s.logger.Info("[trash] scheduled purge started (interval: %v)", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stopCh:
+				s.logger.Info("[trash] scheduled purge stopped")
+				return
+			case <-ticker.C:
+				if _, err := s.PurgeExpired(); err != nil {
+					s.logger.Error("[trash] scheduled purge failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop signals the scheduled purge goroutine to exit.
+func (s *TrashService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stopCh)
+		s.running = false
+	}
+}
+
+// withPurgeAt fills in when entry is due to be purged.
+func (s *TrashService) withPurgeAt(entry *database.TrashEntry) *database.TrashEntry {
+	if cfg := s.app.GetConfig(); cfg != nil {
+		purgeAt := entry.TrashedAt + int64(cfg.Trash.Retention().Seconds())
+		entry.PurgeAt = &purgeAt
+	}
+	return entry
+}
+
+// logRestored records an asset taken out of the trash in the log and audit
+// log. Shared with the upload path, which restores trashed content uploaded
+// again.
+func logRestored(app AppState, log *logger.Logger, entry *database.TrashEntry, by, ipAddress string) {
+	log.Info("Asset %s of topic %s restored from the trash by %s", entry.Hash, entry.Topic, by)
+	if l := app.GetAuditLogger(); l != nil {
+		if err := l.Log(constants.AuditActionAssetRestored, ipAddress, by, audit.AssetRestoredDetails{
+			Hash:      entry.Hash,
+			Topic:     entry.Topic,
+			TrashedBy: entry.TrashedBy,
+			TrashedAt: entry.TrashedAt,
+		}); err != nil {
+			log.Error("Failed to write audit entry for restore of %s: %v", entry.Hash, err)
+		}
+	}
+}
+
+// checkTrash returns ASSET_TRASHED when hash is in the trash.
+func checkTrash(app AppState, hash string) error {
+	orchDB := app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil
+	}
+	entry, err := database.GetTrashEntry(orchDB, hash)
+	if err != nil {
+		return WrapInternalError(err)
+	}
+	if entry != nil {
+		return ErrAssetTrashedWithHash(hash)
+	}
+	return nil
+}
+
+// trashedHashes returns the set of trashed hashes, or nil when the trash is
+// empty.
+func trashedHashes(app AppState) (map[string]bool, error) {
+	orchDB := app.GetOrchestratorDB()
+	if orchDB == nil {
+		return nil, nil
+	}
+	hashes, err := database.TrashedHashes(orchDB)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	return hashes, nil
+}
+
+// withheldHashes returns the set of quarantined and trashed hashes, or nil
+// when there are none.
+func withheldHashes(app AppState) (map[string]bool, error) {
+	withheld, err := quarantinedHashes(app)
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := trashedHashes(app)
+	if err != nil || trashed == nil {
+		return withheld, err
+	}
+	if withheld == nil {
+		return trashed, nil
+	}
+	for hash := range trashed {
+		withheld[hash] = true
+	}
+	return withheld, nil
+}
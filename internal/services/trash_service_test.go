@@ -0,0 +1,81 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+	"silobang/internal/queries"
+)
+
+func TestTrashService_TrashAndRestore(t *testing.T) {
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+
+	trashed := strings.Repeat("a", constants.HashLength)
+	quarantined := strings.Repeat("b", constants.HashLength)
+	clean := strings.Repeat("c", constants.HashLength)
+	var assets []testAsset
+	var entries []orchestratorEntry
+	for _, hash := range []string{trashed, quarantined, clean} {
+		assets = append(assets, testAsset{id: hash, size: 10, ext: "bin", blobName: "001.dat", createdAt: 1700000000})
+		entries = append(entries, orchestratorEntry{hash: hash, topic: "photos", datFile: "001.dat"})
+	}
+	topicDB := setupTopicDir(t, workDir, "photos", assets)
+	if _, err := topicDB.Exec("UPDATE assets SET origin_name = 'photo'"); err != nil {
+		t.Fatalf("failed to name assets: %v", err)
+	}
+	mock.StoreTopicDB("photos", topicDB)
+	mock.RegisterTopic("photos", true, "")
+	mock.orchestratorDB = setupOrchestratorDB(t, workDir, entries)
+
+	svc := NewTrashService(mock, mock.log, NewAssetService(mock, mock.log), nil)
+	entry, err := svc.Trash(trashed, "alice", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Trash failed: %v", err)
+	}
+	wantPurgeAt := entry.TrashedAt + int64(constants.TrashDefaultRetentionDays*24*60*60)
+	if entry.Topic != "photos" || entry.Size != 10 || entry.TrashedBy != "alice" || entry.PurgeAt == nil || *entry.PurgeAt != wantPurgeAt {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if again, err := svc.Trash(trashed, "bob", "127.0.0.1"); err != nil || again.TrashedBy != "alice" {
+		t.Errorf("trashing twice should return the first entry, got %+v, %v", again, err)
+	}
+	if err := checkTrash(mock, trashed); !isServiceErrorCode(err, constants.ErrCodeAssetTrashed) {
+		t.Errorf("expected ASSET_TRASHED, got %v", err)
+	}
+
+	// Queries drop trashed rows even when quarantined ones are kept
+	if _, err := NewQuarantineService(mock, mock.log).Quarantine(quarantined, constants.QuarantineSourceAdmin, "", "alice", "127.0.0.1"); err != nil {
+		t.Fatalf("Quarantine failed: %v", err)
+	}
+	query := NewQueryService(mock, mock.log)
+	newResult := func() *queries.QueryResult {
+		return &queries.QueryResult{
+			Columns:  []string{"asset_id"},
+			Rows:     [][]interface{}{{trashed}, {quarantined}, {clean}},
+			RowCount: 3,
+		}
+	}
+	result := newResult()
+	if err := query.applyWithheldFilter(result, withheldHashes); err != nil || result.RowCount != 1 {
+		t.Errorf("expected only the clean row, got %v, %v", result.Rows, err)
+	}
+	result = newResult()
+	if err := query.applyWithheldFilter(result, trashedHashes); err != nil || result.RowCount != 2 {
+		t.Errorf("expected the quarantined and clean rows, got %v, %v", result.Rows, err)
+	}
+
+	if _, err := svc.Restore(clean, "alice", "127.0.0.1"); !isServiceErrorCode(err, constants.ErrCodeAssetNotTrashed) {
+		t.Errorf("expected ASSET_NOT_TRASHED, got %v", err)
+	}
+	if _, err := svc.Restore(trashed, "alice", "127.0.0.1"); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if err := checkTrash(mock, trashed); err != nil {
+		t.Errorf("restored asset still withheld: %v", err)
+	}
+	if list, err := svc.List(""); err != nil || len(list) != 0 {
+		t.Errorf("expected an empty trash, got %+v, %v", list, err)
+	}
+}
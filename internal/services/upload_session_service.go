@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeebo/blake3"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/internal/sanitize"
+)
+
+// UploadSessionService manages resumable uploads. A session declares the
+// file's size up front; chunks are appended at the byte count received so
+// far and synced to a staging file, so a client that lost its connection
+// asks for the offset and continues from there. Finalizing checks the BLAKE3
+// hash of the staged bytes and stores them through AssetService exactly like
+// a single-shot upload.
+//
+// Sessions survive restarts. A background loop removes sessions left idle
+// past their expiry with their staged bytes.
+type UploadSessionService struct {
+	app    AppState
+	logger *logger.Logger
+	assets *AssetService
+
+	createMu sync.Mutex // serializes per-user session limits
+	busyMu   sync.Mutex
+	busy     map[string]bool // sessions being written, finalized or aborted
+	now      func() time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// UploadSessionRequest describes a new resumable upload.
+type UploadSessionRequest struct {
+	Topic        string
+	Filename     string
+	Size         int64
+	ParentID     *string
+	RelativePath string // already sanitized
+	Hash         string // expected BLAKE3, optional
+}
+
+// NewUploadSessionService creates a new upload session service and starts
+// the expiry loop. Returns nil if the orchestrator DB is not available.
+func NewUploadSessionService(app AppState, log *logger.Logger, assets *AssetService) *UploadSessionService {
+	if app.GetOrchestratorDB() == nil {
+		return nil
+	}
+
+	svc := &UploadSessionService{
+		app:    app,
+		logger: log,
+		assets: assets,
+		busy:   make(map[string]bool),
+		now:    time.Now,
+		stop:   make(chan struct{}),
+	}
+
+	go svc.cleanupLoop()
+
+	return svc
+}
+
+// Stop stops the expiry goroutine (call during graceful shutdown).
+func (s *UploadSessionService) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Path returns where the received bytes of a session are staged.
+func (s *UploadSessionService) Path(session *database.UploadSession) string {
+	return filepath.Join(s.app.GetWorkingDirectory(), constants.InternalDir, constants.UploadSessionsDir,
+		session.ID+constants.UploadSessionFileExt)
+}
+
+// Create opens a session after checking the topic, the size against the
+// extension's storage policy, the expected hash and the parent.
+func (s *UploadSessionService) Create(userID int64, req UploadSessionRequest) (*database.UploadSession, error) {
+	if !s.app.TopicExists(req.Topic) {
+		return nil, ErrTopicNotFoundWithName(req.Topic)
+	}
+	if healthy, errMsg := s.app.IsTopicHealthy(req.Topic); !healthy {
+		return nil, ErrTopicUnhealthyWithReason(req.Topic, errMsg)
+	}
+	if err := checkTopicWritable(s.app, req.Topic); err != nil {
+		return nil, err
+	}
+	if req.Filename == "" {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "filename is required")
+	}
+	if req.Size < 0 {
+		return nil, NewServiceError(constants.ErrCodeInvalidRequest, "size must not be negative")
+	}
+	_, ext := splitFilename(sanitize.Filename(req.Filename))
+	if req.Size > s.app.GetConfig().StoragePolicy(ext).MaxSizeBytes {
+		return nil, ErrAssetTooLarge
+	}
+	req.Hash = strings.ToLower(req.Hash)
+	if req.Hash != "" && !isHexHash(req.Hash) {
+		return nil, ErrInvalidHash
+	}
+	if err := s.assets.ValidateParent(req.ParentID); err != nil {
+		return nil, err
+	}
+
+	db := s.app.GetOrchestratorDB()
+	s.createMu.Lock()
+	defer s.createMu.Unlock()
+
+	count, err := database.CountUploadSessions(db, userID)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if count >= constants.UploadSessionMaxPerUser {
+		return nil, NewServiceError(constants.ErrCodeUploadSessionLimit,
+			fmt.Sprintf("%d upload sessions are already open; finish or abort some first", count))
+	}
+
+	id, err := generateUploadSessionID()
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	now := s.now()
+	session := &database.UploadSession{
+		ID:           id,
+		UserID:       userID,
+		Topic:        req.Topic,
+		Filename:     req.Filename,
+		ParentID:     req.ParentID,
+		RelativePath: req.RelativePath,
+		Size:         req.Size,
+		Hash:         req.Hash,
+		CreatedAt:    now.Unix(),
+		UpdatedAt:    now.Unix(),
+		ExpiresAt:    now.Add(constants.UploadSessionTTL).Unix(),
+	}
+
+	path := s.Path(session)
+	if err := os.MkdirAll(filepath.Dir(path), constants.DirPermissions); err != nil {
+		return nil, WrapInternalError(err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, constants.FilePermissions)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	f.Close()
+
+	if err := database.InsertUploadSession(db, *session); err != nil {
+		os.Remove(path)
+		return nil, WrapInternalError(err)
+	}
+
+	s.logger.Info("Uploads: user_id=%d opened session id=%s topic=%s size=%d", userID, id, req.Topic, req.Size)
+	return session, nil
+}
+
+// Get returns a session of the user.
+func (s *UploadSessionService) Get(userID int64, id string) (*database.UploadSession, error) {
+	session, err := database.GetUploadSession(s.app.GetOrchestratorDB(), userID, id)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if session == nil {
+		return nil, NewServiceError(constants.ErrCodeUploadSessionNotFound, "upload session not found: "+id)
+	}
+	return session, nil
+}
+
+// Append writes a chunk starting at offset, which must be the number of bytes
+// received so far. Bytes read before the body failed are kept, so the
+// returned session holds the offset to resume from even with an error.
+func (s *UploadSessionService) Append(userID int64, id string, offset int64, r io.Reader) (*database.UploadSession, error) {
+	release, err := s.acquire(id)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	session, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != session.ReceivedBytes {
+		return session, NewServiceError(constants.ErrCodeUploadOffsetMismatch,
+			fmt.Sprintf("chunk starts at %d but %d bytes have been received", offset, session.ReceivedBytes))
+	}
+
+	f, err := os.OpenFile(s.Path(session), os.O_WRONLY, 0)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	defer f.Close()
+
+	// Bytes past the recorded offset were never acknowledged
+	if err := f.Truncate(session.ReceivedBytes); err != nil {
+		return nil, WrapInternalError(err)
+	}
+	if _, err := f.Seek(session.ReceivedBytes, io.SeekStart); err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	remaining := session.Size - session.ReceivedBytes
+	n, copyErr := io.Copy(f, io.LimitReader(r, remaining+1))
+	if n > remaining {
+		f.Truncate(session.ReceivedBytes)
+		return session, NewServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("chunk exceeds the declared size: only %d bytes remain", remaining))
+	}
+	if err := f.Sync(); err != nil {
+		return nil, WrapInternalError(err)
+	}
+
+	if n > 0 {
+		now := s.now()
+		session.ReceivedBytes += n
+		session.UpdatedAt = now.Unix()
+		session.ExpiresAt = now.Add(constants.UploadSessionTTL).Unix()
+		if err := database.UpdateUploadSessionProgress(s.app.GetOrchestratorDB(), *session); err != nil {
+			return nil, WrapInternalError(err)
+		}
+	}
+
+	if copyErr != nil {
+		return session, WrapServiceError(constants.ErrCodeInvalidRequest,
+			fmt.Sprintf("chunk interrupted after %d bytes; resume at offset %d", n, session.ReceivedBytes), copyErr)
+	}
+	return session, nil
+}
+
+// Finalize verifies the staged bytes of a complete session and stores them
+// in the session's topic. The session is removed once the asset is stored,
+// or when the bytes do not match the declared hash; other failures keep it
+// so finalizing can be retried.
+func (s *UploadSessionService) Finalize(ctx context.Context, userID int64, id, uploader string) (*UploadResult, *database.UploadSession, error) {
+	release, err := s.acquire(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	session, err := s.Get(userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.ReceivedBytes != session.Size {
+		return nil, session, NewServiceError(constants.ErrCodeUploadIncomplete,
+			fmt.Sprintf("%d of %d bytes have been received", session.ReceivedBytes, session.Size))
+	}
+
+	path := s.Path(session)
+	hash, err := hashFile(path)
+	if err != nil {
+		return nil, session, WrapInternalError(err)
+	}
+	if session.Hash != "" && hash != session.Hash {
+		s.remove(session)
+		return nil, session, NewServiceError(constants.ErrCodeUploadHashMismatch,
+			fmt.Sprintf("received bytes hash to %s, not %s; the session was discarded", hash, session.Hash))
+	}
+
+	result, err := s.assets.UploadStaged(ctx, session.Topic, path, hash, session.Size, session.Filename, session.ParentID, session.RelativePath, uploader)
+	if err != nil {
+		return nil, session, err
+	}
+
+	s.remove(session)
+	s.logger.Info("Uploads: session id=%s finalized as %s (%s)", id, hash, result.Status)
+	return result, session, nil
+}
+
+// Abort removes a session of the user and its staged bytes.
+func (s *UploadSessionService) Abort(userID int64, id string) error {
+	release, err := s.acquire(id)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	session, err := s.Get(userID, id)
+	if err != nil {
+		return err
+	}
+	s.remove(session)
+	s.logger.Info("Uploads: user_id=%d aborted session id=%s", userID, id)
+	return nil
+}
+
+// PurgeExpired removes sessions idle past their expiry. Sessions in use are
+// left for the next pass. Returns the number of sessions removed.
+func (s *UploadSessionService) PurgeExpired(now time.Time) int {
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return 0 // working directory is being switched
+	}
+	expired, err := database.ListUploadSessionsExpiredBefore(db, now.Unix())
+	if err != nil {
+		s.logger.Error("Uploads: failed to list expired sessions: %v", err)
+		return 0
+	}
+
+	var removed int
+	for i := range expired {
+		release, err := s.acquire(expired[i].ID)
+		if err != nil {
+			continue
+		}
+		s.remove(&expired[i])
+		release()
+		removed++
+	}
+
+	if removed > 0 {
+		s.logger.Info("Uploads: expiry cleanup removed %d session(s)", removed)
+	}
+	return removed
+}
+
+// remove deletes a session and its staging file.
+func (s *UploadSessionService) remove(session *database.UploadSession) {
+	if _, err := database.DeleteUploadSession(s.app.GetOrchestratorDB(), session.ID); err != nil {
+		s.logger.Error("Uploads: failed to delete session id=%s: %v", session.ID, err)
+		return
+	}
+	if err := os.Remove(s.Path(session)); err != nil && !os.IsNotExist(err) {
+		s.logger.Warn("Uploads: failed to remove staged bytes of session id=%s: %v", session.ID, err)
+	}
+}
+
+// acquire marks a session busy for one request. Concurrent requests on the
+// same session are rejected rather than queued.
+func (s *UploadSessionService) acquire(id string) (func(), error) {
+	s.busyMu.Lock()
+	defer s.busyMu.Unlock()
+
+	if s.busy[id] {
+		return nil, NewServiceError(constants.ErrCodeUploadSessionBusy, "another request is using upload session "+id)
+	}
+	s.busy[id] = true
+	return func() {
+		s.busyMu.Lock()
+		delete(s.busy, id)
+		s.busyMu.Unlock()
+	}, nil
+}
+
+// cleanupLoop periodically removes expired sessions.
+func (s *UploadSessionService) cleanupLoop() {
+	ticker := time.NewTicker(constants.UploadSessionCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			s.logger.Info("Uploads: cleanup goroutine stopped")
+			return
+		case now := <-ticker.C:
+			s.PurgeExpired(now)
+		}
+	}
+}
+
+// hashFile returns the hex BLAKE3 hash of a file.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := blake3.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// generateUploadSessionID creates a random session ID.
+func generateUploadSessionID() (string, error) {
+	bytes := make([]byte, constants.UploadSessionIDLength/2)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+	"silobang/plugin"
+)
+
+// ValidationOutcome is the verdict of a validator on one upload, recorded
+// in the asset's metadata when it is stored.
+type ValidationOutcome struct {
+	Validator string
+	Valid     bool
+	Errors    []string
+}
+
+// ValidationCounts counts validated uploads. Counters start at zero when the
+// server starts.
+type ValidationCounts struct {
+	Validated     uint64  `json:"validated"`
+	Valid         uint64  `json:"valid"`
+	Invalid       uint64  `json:"invalid"`        // strict and lenient
+	Rejected      uint64  `json:"rejected"`       // invalid uploads refused in strict mode
+	Failed        uint64  `json:"failed"`         // validator could not run; the upload was refused
+	RejectionRate float64 `json:"rejection_rate"` // (rejected + failed) / validated; 0 before the first upload
+}
+
+// ValidationStatus reports the configured validators and their results.
+type ValidationStatus struct {
+	Mode        string                      `json:"mode"`
+	TopicModes  map[string]string           `json:"topic_modes,omitempty"`
+	Validators  map[string]string           `json:"validators"` // extension -> validator type
+	Totals      ValidationCounts            `json:"totals"`
+	ByExtension map[string]ValidationCounts `json:"by_extension"`
+	ByTopic     map[string]ValidationCounts `json:"by_topic"`
+}
+
+// ValidationService runs the content validator configured for an upload's
+// extension before the upload is stored, and counts the results.
+type ValidationService struct {
+	app    AppState
+	logger *logger.Logger
+
+	mu          sync.Mutex
+	byExtension map[string]*ValidationCounts
+	byTopic     map[string]*ValidationCounts
+}
+
+// NewValidationService creates a new validation service instance.
+func NewValidationService(app AppState, log *logger.Logger) *ValidationService {
+	return &ValidationService{
+		app:         app,
+		logger:      log,
+		byExtension: make(map[string]*ValidationCounts),
+		byTopic:     make(map[string]*ValidationCounts),
+	}
+}
+
+// Enabled reports whether any validator is configured.
+func (s *ValidationService) Enabled() bool {
+	return len(s.app.GetConfig().Validation.Validators) > 0
+}
+
+// Validate runs the validator configured for ext on the upload at path.
+// Returns nil without a validator. Invalid uploads fail with UPLOAD_INVALID
+// in strict topics and are returned marked invalid in lenient ones; a
+// validator that cannot run or times out fails the upload with
+// UPLOAD_VALIDATION_FAILED in both modes, so unvalidated files are never
+// stored.
+func (s *ValidationService) Validate(ctx context.Context, topicName, ext, path string) (*ValidationOutcome, error) {
+	cfg := s.app.GetConfig().Validation
+	pc, ok := cfg.Validators[ext]
+	if !ok {
+		return nil, nil
+	}
+	strict := cfg.ModeFor(topicName) == constants.ValidationModeStrict
+
+	validator, err := plugin.NewValidator(pc.Type, pc.Options)
+	if err != nil {
+		s.count(topicName, ext, func(c *ValidationCounts) { c.Failed++ })
+		return nil, WrapServiceError(constants.ErrCodeUploadValidationFailed, "upload validator unavailable", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout())
+	defer cancel()
+
+	result, err := validator.Validate(ctx, path)
+	if err != nil {
+		s.count(topicName, ext, func(c *ValidationCounts) { c.Failed++ })
+		if ctx.Err() != nil {
+			return nil, WrapServiceError(constants.ErrCodeUploadValidationFailed, "upload validation timed out", ctx.Err())
+		}
+		return nil, WrapServiceError(constants.ErrCodeUploadValidationFailed, "upload validation failed: "+err.Error(), err)
+	}
+
+	outcome := &ValidationOutcome{Validator: pc.Type, Valid: result.Valid, Errors: result.Errors}
+	s.count(topicName, ext, func(c *ValidationCounts) {
+		switch {
+		case outcome.Valid:
+			c.Valid++
+		case strict:
+			c.Invalid++
+			c.Rejected++
+		default:
+			c.Invalid++
+		}
+	})
+	if !outcome.Valid && strict {
+		return nil, NewServiceError(constants.ErrCodeUploadInvalid,
+			fmt.Sprintf("upload rejected by %s validator: %s", pc.Type, strings.Join(outcome.Errors, constants.ValidationErrorSeparator)))
+	}
+	return outcome, nil
+}
+
+// count applies update to the counters of a topic and an extension.
+func (s *ValidationService) count(topicName, ext string, update func(c *ValidationCounts)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range []*ValidationCounts{countsFor(s.byExtension, ext), countsFor(s.byTopic, topicName)} {
+		update(c)
+		c.Validated++
+		c.RejectionRate = float64(c.Rejected+c.Failed) / float64(c.Validated)
+	}
+}
+
+func countsFor(counts map[string]*ValidationCounts, key string) *ValidationCounts {
+	c := counts[key]
+	if c == nil {
+		c = &ValidationCounts{}
+		counts[key] = c
+	}
+	return c
+}
+
+// Status returns the configured validators and the counters since startup.
+func (s *ValidationService) Status() *ValidationStatus {
+	cfg := s.app.GetConfig().Validation
+	status := &ValidationStatus{
+		Mode:       cfg.ModeFor(""),
+		TopicModes: cfg.TopicModes,
+		Validators: make(map[string]string, len(cfg.Validators)),
+	}
+	for ext, pc := range cfg.Validators {
+		status.Validators[ext] = pc.Type
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status.ByExtension = copyCounts(s.byExtension)
+	status.ByTopic = copyCounts(s.byTopic)
+	for _, c := range s.byExtension {
+		status.Totals.Validated += c.Validated
+		status.Totals.Valid += c.Valid
+		status.Totals.Invalid += c.Invalid
+		status.Totals.Rejected += c.Rejected
+		status.Totals.Failed += c.Failed
+	}
+	if status.Totals.Validated > 0 {
+		status.Totals.RejectionRate = float64(status.Totals.Rejected+status.Totals.Failed) / float64(status.Totals.Validated)
+	}
+	return status
+}
+
+func copyCounts(counts map[string]*ValidationCounts) map[string]ValidationCounts {
+	out := make(map[string]ValidationCounts, len(counts))
+	for key, c := range counts {
+		out[key] = *c
+	}
+	return out
+}
+
+// validationMetadataOps records a validation outcome on a new asset. The
+// errors are cut to maxValueBytes.
+func validationMetadataOps(hash string, outcome *ValidationOutcome, maxValueBytes int) []database.BatchOperation {
+	set := func(key, value string) database.BatchOperation {
+		return database.BatchOperation{
+			Hash:             hash,
+			Op:               constants.BatchMetadataOpSet,
+			Key:              key,
+			Value:            value,
+			Processor:        constants.ProcessorValidation,
+			ProcessorVersion: constants.ProcessorValidationVersion,
+		}
+	}
+
+	status := constants.ValidationStatusValid
+	if !outcome.Valid {
+		status = constants.ValidationStatusInvalid
+	}
+	ops := []database.BatchOperation{
+		set(constants.MetadataKeyValidationStatus, status),
+		set(constants.MetadataKeyValidationValidator, outcome.Validator),
+	}
+	if !outcome.Valid {
+		errs := strings.Join(outcome.Errors, constants.ValidationErrorSeparator)
+		if maxValueBytes > 0 && len(errs) > maxValueBytes {
+			errs = strings.ToValidUTF8(errs[:maxValueBytes], "")
+		}
+		ops = append(ops, set(constants.MetadataKeyValidationErrors, errs))
+	}
+	return ops
+}
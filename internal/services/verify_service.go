@@ -14,8 +14,9 @@ import (
 
 // VerifyService handles verification of DAT files and index consistency.
 type VerifyService struct {
-	app    AppState
-	logger *logger.Logger
+	app        AppState
+	logger     *logger.Logger
+	quarantine *QuarantineService
 }
 
 // NewVerifyService creates a new verify service instance.
@@ -26,12 +27,22 @@ func NewVerifyService(app AppState, log *logger.Logger) *VerifyService {
 	}
 }
 
+// SetQuarantineService sets the quarantine service used to withhold assets
+// whose content fails verification.
+func (s *VerifyService) SetQuarantineService(q *QuarantineService) {
+	s.quarantine = q
+}
+
 // DatFileResult contains the result of verifying a single DAT file.
 type DatFileResult struct {
 	DatFile    string
 	Valid      bool
 	EntryCount int
 	Error      string
+
+	// Quarantined lists the assets whose content failed its hash check
+	// after the DAT file failed verification.
+	Quarantined []string
 }
 
 // TopicResult contains the result of verifying a topic.
@@ -148,19 +159,21 @@ func (s *VerifyService) VerifyDatFile(
 	if computedHash != storedHash {
 		s.logger.Warn("Hash mismatch in %s/%s: stored=%s computed=%s", topicName, datFile, storedHash[:16]+"...", computedHash[:16]+"...")
 		return &DatFileResult{
-			DatFile:    datFile,
-			Valid:      false,
-			EntryCount: computedCount,
-			Error:      "hash mismatch",
+			DatFile:     datFile,
+			Valid:       false,
+			EntryCount:  computedCount,
+			Error:       "hash mismatch",
+			Quarantined: s.quarantineCorrupt(topicName, topicDB, datFile),
 		}, nil
 	}
 	if computedCount != int(storedCount) {
 		s.logger.Warn("Entry count mismatch in %s/%s: expected %d, got %d", topicName, datFile, storedCount, computedCount)
 		return &DatFileResult{
-			DatFile:    datFile,
-			Valid:      false,
-			EntryCount: computedCount,
-			Error:      fmt.Sprintf("entry count mismatch: expected %d, got %d", storedCount, computedCount),
+			DatFile:     datFile,
+			Valid:       false,
+			EntryCount:  computedCount,
+			Error:       fmt.Sprintf("entry count mismatch: expected %d, got %d", storedCount, computedCount),
+			Quarantined: s.quarantineCorrupt(topicName, topicDB, datFile),
 		}, nil
 	}
 
@@ -171,6 +184,39 @@ func (s *VerifyService) VerifyDatFile(
 	}, nil
 }
 
+// quarantineCorrupt checks the content hash of every asset recorded in a
+// DAT file that failed verification and quarantines the assets that do not
+// match. Returns the quarantined hashes.
+func (s *VerifyService) quarantineCorrupt(topicName string, topicDB *sql.DB, datFile string) []string {
+	if s.quarantine == nil {
+		return nil
+	}
+	extents, err := database.ListDatExtents(topicDB)
+	if err != nil {
+		s.logger.Error("Failed to list assets of %s/%s for quarantine: %v", topicName, datFile, err)
+		return nil
+	}
+
+	datPath := filepath.Join(s.app.GetTopicPath(topicName), datFile)
+	var quarantined []string
+	for _, extent := range extents {
+		if extent.BlobName != datFile {
+			continue
+		}
+		checkErr := storage.ValidateEntry(datPath, extent.Offset)
+		if checkErr == nil {
+			continue
+		}
+		reason := fmt.Sprintf("%s: %v", datFile, checkErr)
+		if _, err := s.quarantine.Quarantine(extent.AssetID, constants.QuarantineSourceIntegrity, reason, "", constants.AuditActorSystem); err != nil {
+			s.logger.Error("Failed to quarantine corrupt asset %s: %v", extent.AssetID, err)
+			continue
+		}
+		quarantined = append(quarantined, extent.AssetID)
+	}
+	return quarantined
+}
+
 // VerifyTopic verifies all DAT files in a topic.
 func (s *VerifyService) VerifyTopic(
 	ctx context.Context,
@@ -0,0 +1,907 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/zeebo/blake3"
+
+	"silobang/internal/audit"
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+	"silobang/internal/sanitize"
+)
+
+// WatchFolderService ingests files dropped into the server-side directories
+// of watch_folders. A background loop scans every folder that is not paused;
+// files left unmodified for the folder's settle time are copied to staging
+// while hashed, stored through AssetService like an upload and then moved to
+// processed/ or deleted. Files that cannot be stored are moved to failed/
+// with a .error note, at once when their content is refused and after
+// repeated failures otherwise.
+//
+// Where file system events are available (fsnotify), a folder is also
+// scanned shortly after files appear in it and the periodic scan of watched
+// folders slows down to WatchFolderRescanInterval, catching only missed
+// events. Each scan that ingests or quarantines files is audited as
+// watch_folder_ingested.
+//
+// Counters are kept in memory and restart from zero with the server.
+type WatchFolderService struct {
+	app           AppState
+	logger        *logger.Logger
+	assets        *AssetService
+	notifications *NotificationService
+	stats         *StatsCache
+
+	configMu sync.Mutex // serializes config changes
+	mu       sync.Mutex
+	folders  map[string]*watchFolderState
+	watched  map[string]string      // watched directory -> folder name
+	timers   map[string]*time.Timer // event-triggered scans by folder name
+
+	// watcher is created by the first scan; nil until then and when file
+	// system events are not available (watchErr)
+	watcher  *fsnotify.Watcher
+	watchErr error
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	now      func() time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// watchFolderState is the scan state of one folder. mu is held for a whole
+// scan, so a folder is never scanned twice at once.
+type watchFolderState struct {
+	mu       sync.Mutex
+	stats    WatchFolderStats
+	attempts map[string]int // failed ingestions by relative path
+	warned   bool           // a failure to watch the folder was logged
+}
+
+// WatchFolderStats counts the files a folder handled since the server started.
+type WatchFolderStats struct {
+	Ingested      int64  `json:"ingested"`       // files stored as new assets
+	Deduplicated  int64  `json:"deduplicated"`   // files whose content was already stored
+	IngestedBytes int64  `json:"ingested_bytes"` // size of all ingested files
+	Failed        int64  `json:"failed"`         // files moved to failed/
+	Pending       int    `json:"pending"`        // files still settling at the last scan
+	Quarantined   int    `json:"quarantined"`    // files in failed/ at the last scan
+	LastScanAt    int64  `json:"last_scan_at,omitempty"`
+	LastIngestAt  int64  `json:"last_ingest_at,omitempty"`
+	LastError     string `json:"last_error,omitempty"` // cleared by the next scan without errors
+	Watching      bool   `json:"watching"`             // file system events are received for the folder
+}
+
+// WatchFolderStatus sums up the watch folders for GET /api/monitoring.
+type WatchFolderStatus struct {
+	Events        bool          `json:"events"` // file system events are received
+	Ingested      int64         `json:"ingested"`
+	Deduplicated  int64         `json:"deduplicated"`
+	IngestedBytes int64         `json:"ingested_bytes"`
+	Failed        int64         `json:"failed"`
+	Pending       int           `json:"pending"`
+	Quarantined   int           `json:"quarantined"`
+	Folders       []WatchFolder `json:"folders"`
+}
+
+// WatchFolder is a configured watch folder with its counters.
+type WatchFolder struct {
+	Name string `json:"name"`
+	config.WatchFolderConfig
+	Stats WatchFolderStats `json:"stats"`
+
+	// Failures lists the files in failed/; only set for a single folder.
+	Failures []WatchFolderFailure `json:"failures,omitempty"`
+}
+
+// WatchFolderFailure is a file moved to failed/ and the reason.
+type WatchFolderFailure struct {
+	Path     string `json:"path"` // relative to failed/
+	Size     int64  `json:"size"`
+	Error    string `json:"error"`
+	FailedAt int64  `json:"failed_at"`
+}
+
+// NewWatchFolderService creates a new watch folder service and starts the
+// scan loop. Returns nil if the orchestrator DB is not available.
+func NewWatchFolderService(app AppState, log *logger.Logger, assets *AssetService, notifications *NotificationService, stats *StatsCache) *WatchFolderService {
+	if app.GetOrchestratorDB() == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	svc := &WatchFolderService{
+		app:           app,
+		logger:        log,
+		assets:        assets,
+		notifications: notifications,
+		stats:         stats,
+		folders:       make(map[string]*watchFolderState),
+		watched:       make(map[string]string),
+		timers:        make(map[string]*time.Timer),
+		ctx:           ctx,
+		cancel:        cancel,
+		now:           time.Now,
+		stop:          make(chan struct{}),
+	}
+
+	go svc.scanLoop()
+
+	return svc
+}
+
+// Stop stops the scan goroutine and cancels ingestions in progress (call
+// during graceful shutdown).
+func (s *WatchFolderService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		s.cancel()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, timer := range s.timers {
+			timer.Stop()
+		}
+		if s.watcher != nil {
+			s.watcher.Close()
+		}
+	})
+}
+
+// List returns the configured folders with their counters, sorted by name.
+func (s *WatchFolderService) List() []WatchFolder {
+	folders := s.app.GetConfig().WatchFolders
+	list := make([]WatchFolder, 0, len(folders))
+	for _, name := range slices.Sorted(maps.Keys(folders)) {
+		list = append(list, WatchFolder{Name: name, WatchFolderConfig: folders[name], Stats: s.snapshot(name)})
+	}
+	return list
+}
+
+// Status sums up the counters of every folder, or returns nil when none is
+// configured.
+func (s *WatchFolderService) Status() *WatchFolderStatus {
+	if s == nil || len(s.app.GetConfig().WatchFolders) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	status := &WatchFolderStatus{Events: s.watcher != nil}
+	s.mu.Unlock()
+	status.Folders = s.List()
+	for _, folder := range status.Folders {
+		status.Ingested += folder.Stats.Ingested
+		status.Deduplicated += folder.Stats.Deduplicated
+		status.IngestedBytes += folder.Stats.IngestedBytes
+		status.Failed += folder.Stats.Failed
+		status.Pending += folder.Stats.Pending
+		status.Quarantined += folder.Stats.Quarantined
+	}
+	return status
+}
+
+// Get returns one folder with its counters and the files in failed/.
+func (s *WatchFolderService) Get(name string) (*WatchFolder, error) {
+	cfg, ok := s.app.GetConfig().WatchFolders[name]
+	if !ok {
+		return nil, watchFolderNotFound(name)
+	}
+	failures, err := listWatchFolderFailures(cfg.Path)
+	if err != nil {
+		return nil, WrapInternalError(err)
+	}
+	return &WatchFolder{Name: name, WatchFolderConfig: cfg, Stats: s.snapshot(name), Failures: failures}, nil
+}
+
+// Set validates and stores a folder, replacing any previous one with the
+// same name, and saves the config. The directory must exist and resolve,
+// symlinks included, under one of the watch_folder_roots, which only the
+// config file sets: a grant to manage the config must not open the rest of
+// the host's files to ingestion and deletion.
+func (s *WatchFolderService) Set(name string, folder config.WatchFolderConfig) (*WatchFolder, error) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	cfg := s.app.GetConfig()
+	candidate := *cfg
+	candidate.WatchFolders = maps.Clone(cfg.WatchFolders)
+	if candidate.WatchFolders == nil {
+		candidate.WatchFolders = make(map[string]config.WatchFolderConfig)
+	}
+	folder.Path = filepath.Clean(folder.Path)
+	if filepath.IsAbs(folder.Path) {
+		// Watch the resolved directory, so a symlink swapped later cannot
+		// move the folder outside its root
+		if resolved, err := filepath.EvalSymlinks(folder.Path); err == nil {
+			folder.Path = resolved
+		}
+	}
+	candidate.WatchFolders[name] = folder
+
+	var problems []string
+	for _, fe := range candidate.FieldErrors() {
+		if fe.Field == "watch_folders" || strings.HasPrefix(fe.Field, "watch_folders."+name) {
+			problems = append(problems, fe.Message)
+		}
+	}
+	if len(problems) == 0 {
+		if info, err := os.Stat(folder.Path); err != nil || !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("watch_folders.%s.path: %s is not a directory", name, folder.Path))
+		} else if !watchFolderUnderRoots(folder.Path, cfg.WatchFolderRoots) {
+			problems = append(problems, fmt.Sprintf("watch_folders.%s.path: %s is not under any of watch_folder_roots in config.yaml", name, folder.Path))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, NewServiceError(constants.ErrCodeInvalidWatchFolder, strings.Join(problems, "; "))
+	}
+
+	previous, existed := cfg.WatchFolders[name]
+	cfg.WatchFolders = candidate.WatchFolders
+	if err := config.SaveConfig(cfg); err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to save config: %w", err))
+	}
+	if existed && previous.Path != folder.Path {
+		s.unwatch(name)
+	}
+	if !folder.Paused {
+		// Start watching now rather than at the next periodic scan
+		s.schedule(name, constants.WatchFolderEventDelay)
+	}
+
+	s.logger.Info("Watch folders: %s set to %s -> topic %s", name, folder.Path, folder.Topic)
+	return &WatchFolder{Name: name, WatchFolderConfig: folder, Stats: s.snapshot(name)}, nil
+}
+
+// watchFolderUnderRoots reports whether path, with symlinks resolved, is
+// one of roots or inside one.
+func watchFolderUnderRoots(path string, roots []string) bool {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	for _, root := range roots {
+		resolvedRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(resolvedRoot, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete stops watching a folder and saves the config. Files in the
+// directory are left as they are.
+func (s *WatchFolderService) Delete(name string) error {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	cfg := s.app.GetConfig()
+	if _, ok := cfg.WatchFolders[name]; !ok {
+		return watchFolderNotFound(name)
+	}
+
+	folders := maps.Clone(cfg.WatchFolders)
+	delete(folders, name)
+	cfg.WatchFolders = folders
+	if err := config.SaveConfig(cfg); err != nil {
+		return WrapInternalError(fmt.Errorf("failed to save config: %w", err))
+	}
+	s.unwatch(name)
+	s.mu.Lock()
+	delete(s.folders, name)
+	s.mu.Unlock()
+
+	s.logger.Info("Watch folders: %s removed", name)
+	return nil
+}
+
+// Scan scans one folder now, paused or not, and returns it with its
+// updated counters. Files still settling are left for a later scan.
+func (s *WatchFolderService) Scan(name string) (*WatchFolder, error) {
+	cfg, ok := s.app.GetConfig().WatchFolders[name]
+	if !ok {
+		return nil, watchFolderNotFound(name)
+	}
+	s.scanFolder(name, cfg, constants.WatchFolderTriggerManual)
+	return s.Get(name)
+}
+
+// Retry moves the files in a folder's failed/ back into the folder for the
+// next scan and removes their notes. Returns the number of files moved.
+func (s *WatchFolderService) Retry(name string) (int, error) {
+	cfg, ok := s.app.GetConfig().WatchFolders[name]
+	if !ok {
+		return 0, watchFolderNotFound(name)
+	}
+	state := s.state(name)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	failedDir := filepath.Join(cfg.Path, constants.WatchFolderFailedDir)
+	moved := 0
+	err := filepath.WalkDir(failedDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, constants.WatchFolderErrorNoteExt) {
+			return nil
+		}
+		rel, err := filepath.Rel(failedDir, path)
+		if err != nil {
+			return err
+		}
+		if _, err := moveFile(path, filepath.Join(cfg.Path, rel)); err != nil {
+			return err
+		}
+		os.Remove(path + constants.WatchFolderErrorNoteExt)
+		delete(state.attempts, rel)
+		moved++
+		return nil
+	})
+	if err != nil {
+		return moved, WrapInternalError(err)
+	}
+
+	s.logger.Info("Watch folders: %s: %d failed files queued for retry", name, moved)
+	return moved, nil
+}
+
+// scanLoop periodically scans the folders that are not paused. Folders
+// whose events are received are only rescanned every
+// WatchFolderRescanInterval.
+func (s *WatchFolderService) scanLoop() {
+	ticker := time.NewTicker(constants.WatchFolderScanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			s.logger.Info("Watch folders: scan goroutine stopped")
+			return
+		case <-ticker.C:
+			folders := s.app.GetConfig().WatchFolders
+			for _, name := range slices.Sorted(maps.Keys(folders)) {
+				if folders[name].Paused {
+					continue
+				}
+				if stats := s.snapshot(name); stats.Watching && s.now().Sub(time.Unix(stats.LastScanAt, 0)) < constants.WatchFolderRescanInterval {
+					continue
+				}
+				s.scanFolder(name, folders[name], constants.WatchFolderTriggerScheduled)
+			}
+		}
+	}
+}
+
+// eventLoop schedules a scan of the folder of every file created or written
+// to in a watched directory.
+func (s *WatchFolderService) eventLoop(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			s.handleEvent(event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			// Events were lost, e.g. on queue overflow; the periodic scan catches up
+			s.logger.Warn("Watch folders: file system events: %v", err)
+		}
+	}
+}
+
+// handleEvent schedules a scan for one event. Scans are delayed until
+// events stop for WatchFolderEventDelay, so a file being written is not
+// scanned on every write.
+func (s *WatchFolderService) handleEvent(event fsnotify.Event) {
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		s.mu.Lock()
+		if _, ok := s.watched[event.Name]; ok {
+			delete(s.watched, event.Name)
+			s.watcher.Remove(event.Name) // already gone when the directory was removed
+		}
+		s.mu.Unlock()
+		return
+	}
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+		return
+	}
+
+	dir, base := filepath.Split(event.Name)
+	dir = filepath.Clean(dir)
+	if strings.HasPrefix(base, ".") {
+		return
+	}
+	s.mu.Lock()
+	name, ok := s.watched[dir]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	cfg, ok := s.app.GetConfig().WatchFolders[name]
+	if !ok || cfg.Paused {
+		return
+	}
+	if dir == cfg.Path && (base == constants.WatchFolderProcessedDir || base == constants.WatchFolderFailedDir) {
+		return // moved there by a scan
+	}
+	s.schedule(name, constants.WatchFolderEventDelay)
+}
+
+// schedule scans a folder after delay, or moves its scheduled scan there.
+func (s *WatchFolderService) schedule(name string, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx.Err() != nil || s.watchErr != nil {
+		return
+	}
+	if timer, ok := s.timers[name]; ok {
+		timer.Reset(delay)
+		return
+	}
+	s.timers[name] = time.AfterFunc(delay, func() { s.scanTriggered(name) })
+}
+
+// scanTriggered runs a scheduled scan, and schedules another when files
+// were still settling.
+func (s *WatchFolderService) scanTriggered(name string) {
+	cfg, ok := s.app.GetConfig().WatchFolders[name]
+	if !ok || cfg.Paused || s.ctx.Err() != nil {
+		return
+	}
+	if pending := s.scanFolder(name, cfg, constants.WatchFolderTriggerEvent); pending > 0 {
+		s.schedule(name, cfg.Settle())
+	}
+}
+
+// watch adds a directory of a folder to the watcher, creating the watcher
+// on first use. It reports whether events are received for it.
+func (s *WatchFolderService) watch(name, dir string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watchErr != nil || s.ctx.Err() != nil {
+		return false
+	}
+	if s.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			s.watchErr = err
+			s.logger.Warn("Watch folders: file system events not available, folders are scanned every %s: %v", constants.WatchFolderScanInterval, err)
+			return false
+		}
+		s.watcher = watcher
+		go s.eventLoop(watcher)
+	}
+	if s.watched[dir] == name {
+		return true
+	}
+	if err := s.watcher.Add(dir); err != nil {
+		s.logger.Debug("Watch folders: %s: cannot watch %s: %v", name, dir, err)
+		return false
+	}
+	s.watched[dir] = name
+	return true
+}
+
+// unwatch removes the directories of a folder from the watcher and cancels
+// its scheduled scan.
+func (s *WatchFolderService) unwatch(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for dir, folder := range s.watched {
+		if folder == name {
+			s.watcher.Remove(dir) // set when anything is watched
+			delete(s.watched, dir)
+		}
+	}
+	if timer, ok := s.timers[name]; ok {
+		timer.Stop()
+		delete(s.timers, name)
+	}
+}
+
+// scanFolder ingests the settled files of one folder and returns the number
+// of files still settling.
+func (s *WatchFolderService) scanFolder(name string, cfg config.WatchFolderConfig, trigger string) int {
+	state := s.state(name)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	before := state.stats
+	defer func() { s.recordScan(name, cfg, trigger, before, state.stats) }()
+
+	now := s.now()
+	state.stats.LastScanAt = now.Unix()
+	state.stats.LastError = ""
+	state.stats.Pending = 0
+
+	if healthy, msg := s.app.IsTopicHealthy(cfg.Topic); !s.app.TopicExists(cfg.Topic) || !healthy {
+		if msg == "" {
+			msg = "topic does not exist"
+		}
+		state.stats.LastError = fmt.Sprintf("topic %s: %s", cfg.Topic, msg)
+		return 0
+	}
+
+	state.stats.Watching = s.watch(name, cfg.Path)
+	if !state.stats.Watching && !state.warned && s.ctx.Err() == nil {
+		s.logger.Warn("Watch folders: %s: cannot watch %s for events, scanning it every %s", name, cfg.Path, constants.WatchFolderScanInterval)
+	}
+	state.warned = !state.stats.Watching
+
+	var ready []string
+	err := filepath.WalkDir(cfg.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == cfg.Path {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if filepath.Dir(path) == cfg.Path && (d.Name() == constants.WatchFolderProcessedDir || d.Name() == constants.WatchFolderFailedDir) {
+				return filepath.SkipDir
+			}
+			if state.stats.Watching {
+				s.watch(name, path)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // removed since the directory was read
+		}
+		if now.Sub(info.ModTime()) < cfg.Settle() {
+			state.stats.Pending++
+			return nil
+		}
+		ready = append(ready, path)
+		return nil
+	})
+	if err != nil {
+		state.stats.LastError = err.Error()
+		s.logger.Warn("Watch folders: %s: scan failed: %v", name, err)
+		return 0
+	}
+
+	for _, path := range ready {
+		if s.ctx.Err() != nil {
+			return state.stats.Pending
+		}
+		s.ingest(name, cfg, state, path)
+	}
+
+	if failures, err := listWatchFolderFailures(cfg.Path); err == nil {
+		state.stats.Quarantined = len(failures)
+	}
+	return state.stats.Pending
+}
+
+// recordScan audits a scan that ingested or quarantined files, from the
+// counters of the folder before and after it.
+func (s *WatchFolderService) recordScan(name string, cfg config.WatchFolderConfig, trigger string, before, after WatchFolderStats) {
+	details := audit.WatchFolderIngestedDetails{
+		Folder:       name,
+		Topic:        cfg.Topic,
+		Trigger:      trigger,
+		Ingested:     int(after.Ingested - before.Ingested),
+		Deduplicated: int(after.Deduplicated - before.Deduplicated),
+		Failed:       int(after.Failed - before.Failed),
+		Bytes:        after.IngestedBytes - before.IngestedBytes,
+	}
+	if details.Ingested == 0 && details.Deduplicated == 0 && details.Failed == 0 {
+		return
+	}
+	if l := s.app.GetAuditLogger(); l != nil {
+		if err := l.Log(constants.AuditActionWatchFolderIngested, "", constants.AuditActorSystem, details); err != nil {
+			s.logger.Error("Failed to write audit entry for watch folder %s: %v", name, err)
+		}
+	}
+}
+
+// ingest stores one settled file and moves or deletes it.
+func (s *WatchFolderService) ingest(name string, cfg config.WatchFolderConfig, state *watchFolderState, path string) {
+	rel, err := filepath.Rel(cfg.Path, path)
+	if err != nil {
+		return
+	}
+	relativePath := sanitize.RelativePath(filepath.ToSlash(rel))
+
+	staged, hash, size, changed, err := s.stage(path)
+	if staged != "" {
+		defer os.Remove(staged)
+	}
+	if changed {
+		state.stats.Pending++ // written to while it was copied
+		return
+	}
+	if err != nil {
+		s.fail(name, cfg, state, rel, err, false)
+		return
+	}
+
+	// Files at the top of the folder have no folder path
+	if !strings.Contains(relativePath, "/") {
+		relativePath = ""
+	}
+	result, err := s.assets.UploadStaged(s.ctx, cfg.Topic, staged, hash, size, filepath.Base(path), nil, relativePath, constants.AuditActorSystem)
+	if err != nil {
+		if s.ctx.Err() != nil {
+			return // shutting down; the file is picked up again on the next start
+		}
+		s.fail(name, cfg, state, rel, err, watchFolderRejected(err))
+		return
+	}
+
+	if result.Status != constants.UploadStatusCreated {
+		relativePath = ""
+	}
+	s.recordIngest(cfg.Topic, filepath.Base(path), relativePath, result)
+
+	delete(state.attempts, rel)
+	if result.Status == constants.UploadStatusCreated {
+		state.stats.Ingested++
+	} else {
+		state.stats.Deduplicated++
+	}
+	state.stats.IngestedBytes += result.Size
+	state.stats.LastIngestAt = s.now().Unix()
+	s.logger.Info("Watch folders: %s: ingested %s as %s (%s)", name, rel, result.Hash, result.Status)
+
+	if cfg.AfterIngestMode() == constants.WatchFolderAfterDelete {
+		err = os.Remove(path)
+	} else {
+		_, err = moveFile(path, filepath.Join(cfg.Path, constants.WatchFolderProcessedDir, rel))
+	}
+	if err != nil {
+		// The next scan stores the same content again as a duplicate and retries
+		state.stats.LastError = fmt.Sprintf("%s: %v", rel, err)
+		s.logger.Warn("Watch folders: %s: failed to %s %s: %v", name, cfg.AfterIngestMode(), rel, err)
+	}
+}
+
+// stage copies a file to the staging directory while hashing it, so a
+// writer reopening the file cannot change the stored bytes. changed is set
+// when the file was modified during the copy.
+func (s *WatchFolderService) stage(path string) (staged, hash string, size int64, changed bool, err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", "", 0, false, err
+	}
+	defer src.Close()
+	before, err := src.Stat()
+	if err != nil {
+		return "", "", 0, false, err
+	}
+
+	dir := filepath.Join(s.app.GetWorkingDirectory(), constants.InternalDir, constants.WatchFoldersDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", 0, false, err
+	}
+	dst, err := os.CreateTemp(dir, "ingest-*")
+	if err != nil {
+		return "", "", 0, false, err
+	}
+	staged = dst.Name()
+
+	hasher := blake3.New()
+	size, err = io.Copy(io.MultiWriter(dst, hasher), src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return staged, "", 0, false, err
+	}
+
+	after, err := os.Stat(path)
+	if err != nil {
+		return staged, "", 0, false, err
+	}
+	if after.Size() != before.Size() || !after.ModTime().Equal(before.ModTime()) || size != after.Size() {
+		return staged, "", 0, true, nil
+	}
+	return staged, hex.EncodeToString(hasher.Sum(nil)), size, false, nil
+}
+
+// fail records a failed ingestion. The file is moved to failed/ with a note
+// when its content was refused or it failed too often; errors that clear by
+// themselves, such as a full disk, leave it for later scans.
+func (s *WatchFolderService) fail(name string, cfg config.WatchFolderConfig, state *watchFolderState, rel string, cause error, rejected bool) {
+	if errors.Is(cause, fs.ErrNotExist) {
+		return // removed since the scan found it
+	}
+	state.stats.LastError = fmt.Sprintf("%s: %v", rel, cause)
+	s.logger.Warn("Watch folders: %s: failed to ingest %s: %v", name, rel, cause)
+	if watchFolderTransient(cause) {
+		return
+	}
+
+	state.attempts[rel]++
+	if !rejected && state.attempts[rel] < constants.WatchFolderMaxAttempts {
+		return
+	}
+	delete(state.attempts, rel)
+
+	dst, err := moveFile(filepath.Join(cfg.Path, rel), filepath.Join(cfg.Path, constants.WatchFolderFailedDir, rel))
+	if err != nil {
+		s.logger.Error("Watch folders: %s: failed to move %s to %s/: %v", name, rel, constants.WatchFolderFailedDir, err)
+		return
+	}
+	note := fmt.Sprintf("%s\n%s\n", s.now().UTC().Format(time.RFC3339), cause)
+	if err := os.WriteFile(dst+constants.WatchFolderErrorNoteExt, []byte(note), 0644); err != nil {
+		s.logger.Warn("Watch folders: %s: failed to write error note for %s: %v", name, rel, err)
+	}
+	state.stats.Failed++
+}
+
+// recordIngest audits a stored file and tells stats and subscribers about
+// it, as the upload handler does for uploads. Ingestion is done by the
+// system actor.
+func (s *WatchFolderService) recordIngest(topic, filename, relativePath string, result *UploadResult) {
+	if result.Skipped {
+		return
+	}
+	if l := s.app.GetAuditLogger(); l != nil {
+		l.Log(constants.AuditActionAddingFile, "", "", audit.AddingFileDetails{
+			Hash:         result.Hash,
+			TopicName:    topic,
+			Filename:     filename,
+			RelativePath: relativePath,
+			Size:         result.Size,
+		})
+	}
+	if s.stats != nil {
+		s.stats.InvalidateTopic(topic)
+	}
+	if s.notifications != nil && result.Status == constants.UploadStatusCreated {
+		s.notifications.AssetAdded(topic, result.Hash, filename, 0, constants.AuditActorSystem)
+	}
+}
+
+// state returns the scan state of a folder, creating it on first use.
+func (s *WatchFolderService) state(name string) *watchFolderState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.folders[name]
+	if !ok {
+		st = &watchFolderState{attempts: make(map[string]int)}
+		s.folders[name] = st
+	}
+	return st
+}
+
+// snapshot returns a copy of a folder's counters.
+func (s *WatchFolderService) snapshot(name string) WatchFolderStats {
+	st := s.state(name)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.stats
+}
+
+// listWatchFolderFailures returns the files in a folder's failed/ with the
+// reason recorded in their notes.
+func listWatchFolderFailures(root string) ([]WatchFolderFailure, error) {
+	failedDir := filepath.Join(root, constants.WatchFolderFailedDir)
+	failures := []WatchFolderFailure{}
+	err := filepath.WalkDir(failedDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, constants.WatchFolderErrorNoteExt) {
+			return nil
+		}
+		if len(failures) >= constants.WatchFolderMaxListedFailures {
+			return filepath.SkipAll
+		}
+		rel, err := filepath.Rel(failedDir, path)
+		if err != nil {
+			return err
+		}
+		failure := WatchFolderFailure{Path: filepath.ToSlash(rel)}
+		if info, err := d.Info(); err == nil {
+			failure.Size = info.Size()
+			failure.FailedAt = info.ModTime().Unix()
+		}
+		if note, err := os.ReadFile(path + constants.WatchFolderErrorNoteExt); err == nil {
+			lines := strings.SplitN(strings.TrimSpace(string(note)), "\n", 2)
+			if t, err := time.Parse(time.RFC3339, lines[0]); err == nil {
+				failure.FailedAt = t.Unix()
+			}
+			failure.Error = lines[len(lines)-1]
+		}
+		failures = append(failures, failure)
+		return nil
+	})
+	return failures, err
+}
+
+// watchFolderRejected reports whether an ingestion failed because of the
+// file's content, which no retry changes.
+func watchFolderRejected(err error) bool {
+	code, _ := IsServiceError(err)
+	switch code {
+	case constants.ErrCodeAssetTooLarge, constants.ErrCodeUploadScanRejected, constants.ErrCodeUploadInvalid:
+		return true
+	}
+	return false
+}
+
+// watchFolderTransient reports whether an ingestion failed for a reason
+// outside the file that clears by itself.
+func watchFolderTransient(err error) bool {
+	code, _ := IsServiceError(err)
+	switch code {
+	case constants.ErrCodeDiskLimitExceeded, constants.ErrCodeStorageFull,
+		constants.ErrCodeTopicNotFound, constants.ErrCodeTopicUnhealthy:
+		return true
+	}
+	return false
+}
+
+// moveFile renames src to dst, creating dst's directory, and returns the
+// new path. An existing dst is kept and src is renamed next to it with a
+// numeric suffix.
+func moveFile(src, dst string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", err
+	}
+	dst, err := uniquePath(dst)
+	if err != nil {
+		return "", err
+	}
+	return dst, os.Rename(src, dst)
+}
+
+// uniquePath returns path, or path with -1, -2, ... before the extension
+// when it already exists.
+func uniquePath(path string) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	candidate := path
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(candidate); errors.Is(err, fs.ErrNotExist) {
+			return candidate, nil
+		} else if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+func watchFolderNotFound(name string) error {
+	return NewServiceError(constants.ErrCodeWatchFolderNotFound, fmt.Sprintf("no watch folder named %q", name))
+}
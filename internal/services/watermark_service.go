@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"sync"
+
+	"silobang/internal/config"
+	"silobang/internal/constants"
+	"silobang/internal/logger"
+	"silobang/internal/watermark"
+)
+
+// WatermarkService applies the watermark profiles from config to image
+// downloads. The stored asset is never modified: the transform only applies
+// to the bytes sent to the client.
+type WatermarkService struct {
+	app    AppState
+	logger *logger.Logger
+
+	mu       sync.Mutex
+	overlays map[string]image.Image // decoded overlay images by path
+}
+
+// NewWatermarkService creates a new watermark service instance.
+func NewWatermarkService(app AppState, log *logger.Logger) *WatermarkService {
+	return &WatermarkService{
+		app:      app,
+		logger:   log,
+		overlays: make(map[string]image.Image),
+	}
+}
+
+// Resolve returns the named watermark profile.
+func (s *WatermarkService) Resolve(name string) (*config.WatermarkConfig, error) {
+	profile, ok := s.app.GetConfig().Watermarks[name]
+	if !ok {
+		return nil, NewServiceError(constants.ErrCodeWatermarkNotFound, fmt.Sprintf("watermark profile %q not found", name))
+	}
+	return &profile, nil
+}
+
+// Supports reports whether assets of contentType can be watermarked.
+// Other assets are downloaded unchanged.
+func (s *WatermarkService) Supports(contentType string) bool {
+	return watermark.Supported(contentType)
+}
+
+// Apply stamps profile onto the image read from r and returns the encoded
+// result in the same format.
+func (s *WatermarkService) Apply(profile *config.WatermarkConfig, r io.Reader, size int64, contentType string) ([]byte, error) {
+	if size > constants.WatermarkMaxSourceBytes {
+		return nil, NewServiceError(constants.ErrCodeWatermarkFailed,
+			fmt.Sprintf("asset size %d exceeds watermark limit %d", size, constants.WatermarkMaxSourceBytes))
+	}
+
+	opts := watermark.Options{
+		Text:     profile.Text,
+		Position: profile.Position,
+		Opacity:  profile.Opacity,
+		Scale:    profile.Scale,
+	}
+	if profile.Image != "" {
+		overlay, err := s.overlay(profile.Image)
+		if err != nil {
+			return nil, WrapInternalError(fmt.Errorf("failed to load watermark image: %w", err))
+		}
+		opts.Overlay = overlay
+	}
+
+	out, err := watermark.Apply(r, contentType, opts)
+	if errors.Is(err, watermark.ErrTooLarge) {
+		return nil, WrapServiceError(constants.ErrCodeWatermarkFailed, "image dimensions exceed watermark limit", err)
+	}
+	if err != nil {
+		return nil, WrapServiceError(constants.ErrCodeWatermarkFailed, "failed to watermark image", err)
+	}
+	return out, nil
+}
+
+// overlay returns the decoded overlay image at path, loading it on first use.
+func (s *WatermarkService) overlay(path string) (image.Image, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if img, ok := s.overlays[path]; ok {
+		return img, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	s.overlays[path] = img
+	return img, nil
+}
@@ -0,0 +1,477 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"silobang/internal/audit"
+	"silobang/internal/constants"
+	"silobang/internal/database"
+	"silobang/internal/logger"
+)
+
+// WebhookService feeds audit entries to outgoing webhooks, so external
+// pipelines can react to new assets, topics, metadata and users without
+// polling the audit log.
+//
+// Each webhook keeps a cursor into the audit log. Every tick, entries past
+// the cursor whose action the webhook selects are queued as deliveries in
+// the orchestrator DB, then due deliveries are POSTed in order, signed with
+// the webhook's secret. Failed deliveries are retried with exponential
+// backoff and given up on after constants.WebhookMaxDeliveryAttempts.
+type WebhookService struct {
+	app    AppState
+	logger *logger.Logger
+	client *http.Client
+
+	runMu    sync.Mutex // serializes RunPending
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// WebhookRequest creates or updates a webhook. Nil fields are left unchanged
+// on update; name and url are required on create.
+type WebhookRequest struct {
+	Name   *string   `json:"name"`
+	URL    *string   `json:"url"`
+	Secret *string   `json:"secret"` // generated on create when omitted
+	Events *[]string `json:"events"` // audit actions; empty = every action
+	Active *bool     `json:"active"` // default true
+}
+
+// WebhookStatus is a webhook with its delivery counts.
+type WebhookStatus struct {
+	database.Webhook
+	Deliveries database.WebhookDeliveryCounts `json:"deliveries"`
+
+	// RecentDeliveries lists the newest deliveries; only set for a single webhook.
+	RecentDeliveries []database.WebhookDelivery `json:"recent_deliveries,omitempty"`
+}
+
+// WebhookPayload is the JSON body POSTed for one audit entry.
+type WebhookPayload struct {
+	WebhookID int64           `json:"webhook_id"`
+	Event     string          `json:"event"` // the audit action
+	AuditID   int64           `json:"audit_id"`
+	Timestamp int64           `json:"timestamp"`
+	Username  string          `json:"username"`
+	IPAddress string          `json:"ip_address"`
+	ActorType string          `json:"actor_type"`
+	Details   json.RawMessage `json:"details,omitempty"`
+}
+
+// NewWebhookService creates a new webhook service and starts its delivery
+// loop. Returns nil if the orchestrator DB is not available.
+func NewWebhookService(app AppState, log *logger.Logger) *WebhookService {
+	if app.GetOrchestratorDB() == nil {
+		return nil
+	}
+
+	svc := &WebhookService{
+		app:    app,
+		logger: log,
+		client: &http.Client{Timeout: constants.WebhookTimeout},
+		stop:   make(chan struct{}),
+	}
+
+	go svc.deliveryLoop()
+
+	return svc
+}
+
+// Stop stops the delivery goroutine (call during graceful shutdown).
+func (s *WebhookService) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// ============================================================================
+// CRUD
+// ============================================================================
+
+// List returns every webhook with its delivery counts.
+func (s *WebhookService) List() ([]WebhookStatus, error) {
+	db := s.app.GetOrchestratorDB()
+	hooks, err := database.ListWebhooks(db)
+	if err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to list webhooks: %w", err))
+	}
+	statuses := make([]WebhookStatus, 0, len(hooks))
+	for _, hook := range hooks {
+		counts, err := database.CountWebhookDeliveries(db, hook.ID)
+		if err != nil {
+			return nil, WrapInternalError(fmt.Errorf("failed to count webhook deliveries: %w", err))
+		}
+		statuses = append(statuses, WebhookStatus{Webhook: hook, Deliveries: counts})
+	}
+	return statuses, nil
+}
+
+// Get returns a webhook with its delivery counts and newest deliveries.
+func (s *WebhookService) Get(id int64) (*WebhookStatus, error) {
+	db := s.app.GetOrchestratorDB()
+	hook, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := database.CountWebhookDeliveries(db, id)
+	if err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to count webhook deliveries: %w", err))
+	}
+	recent, err := database.ListWebhookDeliveries(db, id, constants.WebhookRecentDeliveries)
+	if err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to list webhook deliveries: %w", err))
+	}
+	return &WebhookStatus{Webhook: *hook, Deliveries: counts, RecentDeliveries: recent}, nil
+}
+
+// Create adds a webhook and returns it with its secret, which is not
+// returned again. Only audit entries logged from now on are delivered.
+func (s *WebhookService) Create(req *WebhookRequest, createdBy string) (*database.Webhook, string, error) {
+	if req.Name == nil || req.URL == nil {
+		return nil, "", NewServiceError(constants.ErrCodeInvalidWebhook, "name and url are required")
+	}
+
+	now := time.Now().Unix()
+	hook := database.Webhook{Events: []string{}, Active: true, CreatedBy: createdBy, CreatedAt: now}
+	if req.Secret == nil {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return nil, "", WrapInternalError(fmt.Errorf("failed to generate webhook secret: %w", err))
+		}
+		hook.Secret = secret
+	}
+	if err := applyWebhookRequest(&hook, req); err != nil {
+		return nil, "", err
+	}
+	hook.UpdatedAt = now
+
+	if err := database.InsertWebhook(s.app.GetOrchestratorDB(), &hook); err != nil {
+		return nil, "", WrapInternalError(fmt.Errorf("failed to create webhook: %w", err))
+	}
+	s.logger.Info("Webhooks: created webhook id=%d name=%s events=%v", hook.ID, hook.Name, hook.Events)
+	return &hook, hook.Secret, nil
+}
+
+// Update changes a webhook. A webhook that is re-activated skips the entries
+// logged while it was inactive.
+func (s *WebhookService) Update(id int64, req *WebhookRequest) (*database.Webhook, error) {
+	hook, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	wasActive := hook.Active
+	if err := applyWebhookRequest(hook, req); err != nil {
+		return nil, err
+	}
+	hook.UpdatedAt = time.Now().Unix()
+
+	if err := database.UpdateWebhook(s.app.GetOrchestratorDB(), *hook, hook.Active && !wasActive); err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to update webhook: %w", err))
+	}
+	s.logger.Info("Webhooks: updated webhook id=%d name=%s active=%t events=%v", hook.ID, hook.Name, hook.Active, hook.Events)
+	return hook, nil
+}
+
+// Delete removes a webhook and its queued deliveries and returns it.
+func (s *WebhookService) Delete(id int64) (*database.Webhook, error) {
+	hook, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := database.DeleteWebhook(s.app.GetOrchestratorDB(), id)
+	if err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to delete webhook: %w", err))
+	}
+	if !removed {
+		return nil, NewServiceError(constants.ErrCodeWebhookNotFound, "webhook not found")
+	}
+	s.logger.Info("Webhooks: deleted webhook id=%d name=%s", hook.ID, hook.Name)
+	return hook, nil
+}
+
+func (s *WebhookService) load(id int64) (*database.Webhook, error) {
+	hook, err := database.GetWebhook(s.app.GetOrchestratorDB(), id)
+	if err != nil {
+		return nil, WrapInternalError(fmt.Errorf("failed to load webhook: %w", err))
+	}
+	if hook == nil {
+		return nil, NewServiceError(constants.ErrCodeWebhookNotFound, "webhook not found")
+	}
+	return hook, nil
+}
+
+// ============================================================================
+// Delivery
+// ============================================================================
+
+// deliveryLoop periodically queues and sends deliveries and purges finished
+// ones past retention.
+func (s *WebhookService) deliveryLoop() {
+	deliver := time.NewTicker(constants.WebhookDeliveryInterval)
+	defer deliver.Stop()
+	cleanup := time.NewTicker(constants.WebhookCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			s.logger.Info("Webhooks: delivery goroutine stopped")
+			return
+		case now := <-deliver.C:
+			s.RunPending(now)
+		case now := <-cleanup.C:
+			s.purgeFinished(now)
+		}
+	}
+}
+
+// RunPending queues the audit entries logged since the last run for every
+// active webhook and sends the deliveries due at now.
+func (s *WebhookService) RunPending(now time.Time) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return // working directory is being switched
+	}
+	hooks, err := database.ListWebhooks(db)
+	if err != nil {
+		s.logger.Error("Webhooks: failed to list webhooks: %v", err)
+		return
+	}
+
+	for _, hook := range hooks {
+		if !hook.Active {
+			continue
+		}
+		s.queue(hook, now)
+		s.deliver(hook, now)
+	}
+}
+
+// queue stores a delivery for each new audit entry the webhook selects and
+// moves its cursor past them.
+func (s *WebhookService) queue(hook database.Webhook, now time.Time) {
+	db := s.app.GetOrchestratorDB()
+	entries, head, err := database.ListWebhookAuditEntries(db, hook.LastAuditID, hook.Events, constants.WebhookMaxQueueBatch)
+	if err != nil {
+		s.logger.Error("Webhooks: failed to read audit entries for webhook id=%d: %v", hook.ID, err)
+		return
+	}
+
+	// Entries the webhook does not select are skipped up to the head
+	cursor := head
+	if len(entries) == constants.WebhookMaxQueueBatch {
+		cursor = entries[len(entries)-1].ID
+	}
+	if cursor <= hook.LastAuditID {
+		return
+	}
+
+	deliveries := make([]database.WebhookDelivery, 0, len(entries))
+	for _, e := range entries {
+		payload, err := json.Marshal(WebhookPayload{
+			WebhookID: hook.ID,
+			Event:     e.Action,
+			AuditID:   e.ID,
+			Timestamp: e.Timestamp,
+			Username:  e.Username,
+			IPAddress: e.IPAddress,
+			ActorType: e.ActorType,
+			Details:   e.Details,
+		})
+		if err != nil {
+			s.logger.Error("Webhooks: failed to encode audit entry %d: %v", e.ID, err)
+			return
+		}
+		deliveries = append(deliveries, database.WebhookDelivery{
+			AuditID:       e.ID,
+			Event:         e.Action,
+			Payload:       payload,
+			NextAttemptAt: now.Unix(),
+			CreatedAt:     now.Unix(),
+		})
+	}
+
+	if _, err := database.QueueWebhookDeliveries(db, hook.ID, hook.LastAuditID, cursor, deliveries); err != nil {
+		s.logger.Error("Webhooks: failed to queue deliveries for webhook id=%d: %v", hook.ID, err)
+	}
+}
+
+// deliver sends the webhook's due deliveries oldest first, stopping at the
+// first failure so a receiver that is down is not sent the whole backlog.
+func (s *WebhookService) deliver(hook database.Webhook, now time.Time) {
+	db := s.app.GetOrchestratorDB()
+	due, err := database.ListDueWebhookDeliveries(db, hook.ID, now.Unix(), constants.WebhookMaxDeliveryBatch)
+	if err != nil {
+		s.logger.Error("Webhooks: failed to list due deliveries for webhook id=%d: %v", hook.ID, err)
+		return
+	}
+
+	for _, d := range due {
+		status, sendErr := s.send(hook, d, now)
+		d.Attempts++
+		d.LastStatus = status
+		d.LastError = ""
+		at := now.Unix()
+		if sendErr == nil {
+			d.DeliveredAt = &at
+		} else {
+			d.LastError = sendErr.Error()
+			if d.Attempts >= constants.WebhookMaxDeliveryAttempts {
+				d.FailedAt = &at
+				s.logger.Warn("Webhooks: gave up delivering audit entry %d to webhook id=%d after %d attempts: %v",
+					d.AuditID, hook.ID, d.Attempts, sendErr)
+			} else {
+				d.NextAttemptAt = now.Add(webhookRetryDelay(d.Attempts)).Unix()
+				s.logger.Warn("Webhooks: delivery of audit entry %d to webhook id=%d failed (attempt %d): %v",
+					d.AuditID, hook.ID, d.Attempts, sendErr)
+			}
+		}
+		if err := database.UpdateWebhookDeliveryAttempt(db, d); err != nil {
+			s.logger.Error("Webhooks: failed to record delivery %d: %v", d.ID, err)
+			return
+		}
+		if sendErr != nil {
+			return
+		}
+	}
+	if len(due) > 0 {
+		s.logger.Debug("Webhooks: delivered %d event(s) to webhook id=%d", len(due), hook.ID)
+	}
+}
+
+// send POSTs a delivery and returns the response status (0 without a
+// response).
+func (s *WebhookService) send(hook database.Webhook, d database.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", constants.WebhookUserAgent)
+	req.Header.Set(constants.WebhookHeaderEvent, d.Event)
+	req.Header.Set(constants.WebhookHeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(constants.WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(constants.WebhookHeaderSignature, SignWebhookPayload(hook.Secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// purgeFinished deletes delivered and abandoned deliveries past retention.
+func (s *WebhookService) purgeFinished(now time.Time) {
+	db := s.app.GetOrchestratorDB()
+	if db == nil {
+		return
+	}
+	removed, err := database.DeleteFinishedWebhookDeliveriesBefore(db, now.Add(-constants.WebhookDeliveryRetention).Unix())
+	if err != nil {
+		s.logger.Error("Webhooks: retention cleanup failed: %v", err)
+		return
+	}
+	if removed > 0 {
+		s.logger.Info("Webhooks: retention cleanup removed %d delivery(ies)", removed)
+	}
+}
+
+// SignWebhookPayload returns the signature header value of a delivery body:
+// the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret.
+// Receivers recompute it to check a delivery came from this server.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return constants.WebhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay returns the delay after the given number of failed
+// attempts: the base delay doubled after each failure, capped.
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := constants.WebhookRetryBaseDelay
+	for i := 1; i < attempts && delay < constants.WebhookRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > constants.WebhookRetryMaxDelay {
+		delay = constants.WebhookRetryMaxDelay
+	}
+	return delay
+}
+
+// ============================================================================
+// Validation
+// ============================================================================
+
+// applyWebhookRequest validates the set fields of req and applies them.
+func applyWebhookRequest(hook *database.Webhook, req *WebhookRequest) error {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > constants.WebhookMaxNameLength {
+			return NewServiceError(constants.ErrCodeInvalidWebhook,
+				fmt.Sprintf("name must be 1-%d characters", constants.WebhookMaxNameLength))
+		}
+		hook.Name = name
+	}
+	if req.URL != nil {
+		if len(*req.URL) > constants.WebhookMaxURLLength {
+			return NewServiceError(constants.ErrCodeInvalidWebhook,
+				fmt.Sprintf("url exceeds %d characters", constants.WebhookMaxURLLength))
+		}
+		u, err := url.Parse(*req.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return NewServiceError(constants.ErrCodeInvalidWebhook, "url must be an absolute http or https URL")
+		}
+		hook.URL = *req.URL
+	}
+	if req.Secret != nil {
+		if len(*req.Secret) < constants.WebhookMinSecretLength || len(*req.Secret) > constants.WebhookMaxSecretLength {
+			return NewServiceError(constants.ErrCodeInvalidWebhook,
+				fmt.Sprintf("secret must be %d-%d characters", constants.WebhookMinSecretLength, constants.WebhookMaxSecretLength))
+		}
+		hook.Secret = *req.Secret
+	}
+	if req.Events != nil {
+		seen := make(map[string]bool)
+		for _, e := range *req.Events {
+			if !audit.IsValidAction(e) {
+				return NewServiceError(constants.ErrCodeInvalidWebhook,
+					fmt.Sprintf("unknown event %q (see GET /api/audit/actions)", e))
+			}
+			seen[e] = true
+		}
+		hook.Events = sortedKeys(seen)
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, constants.WebhookSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"silobang/internal/constants"
+	"silobang/internal/database"
+)
+
+// newWebhookTestService creates a webhook service backed by a fresh
+// orchestrator DB.
+func newWebhookTestService(t *testing.T) (*WebhookService, *mockAppState) {
+	t.Helper()
+	workDir := t.TempDir()
+	mock := newStatsCacheMock(workDir)
+	mock.SetOrchestratorDB(setupOrchestratorDB(t, workDir, nil))
+
+	svc := NewWebhookService(mock, mock.log)
+	t.Cleanup(svc.Stop)
+	return svc, mock
+}
+
+// logAuditEntry inserts an audit entry as the audit logger would.
+func logAuditEntry(t *testing.T, mock *mockAppState, action string) {
+	t.Helper()
+	if _, err := mock.GetOrchestratorDB().Exec(
+		"INSERT INTO audit_log (timestamp, action, ip_address, username) VALUES (?, ?, '127.0.0.1', 'admin')",
+		time.Now().Unix(), action); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookService_GivesUpAfterMaxAttempts(t *testing.T) {
+	svc, mock := newWebhookTestService(t)
+	var attempts atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	name, target := "down", receiver.URL
+	hook, _, err := svc.Create(&WebhookRequest{Name: &name, URL: &target}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logAuditEntry(t, mock, constants.AuditActionAddingTopic)
+
+	now := time.Now()
+	for i := 0; i < constants.WebhookMaxDeliveryAttempts+2; i++ {
+		svc.RunPending(now)
+		now = now.Add(constants.WebhookRetryMaxDelay)
+	}
+
+	if got := attempts.Load(); got != constants.WebhookMaxDeliveryAttempts {
+		t.Errorf("expected %d attempts, got %d", constants.WebhookMaxDeliveryAttempts, got)
+	}
+	counts, err := database.CountWebhookDeliveries(mock.GetOrchestratorDB(), hook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts.Failed != 1 || counts.Pending != 0 {
+		t.Errorf("expected the delivery given up on, got %+v", counts)
+	}
+}
+
+func TestWebhookService_ReactivationSkipsBacklog(t *testing.T) {
+	svc, mock := newWebhookTestService(t)
+	var attempts atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+	}))
+	defer receiver.Close()
+
+	// Entries logged before the webhook existed are not delivered
+	logAuditEntry(t, mock, constants.AuditActionAddingFile)
+	name, target := "paused", receiver.URL
+	hook, _, err := svc.Create(&WebhookRequest{Name: &name, URL: &target}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inactive, active := false, true
+	if _, err := svc.Update(hook.ID, &WebhookRequest{Active: &inactive}); err != nil {
+		t.Fatal(err)
+	}
+	logAuditEntry(t, mock, constants.AuditActionAddingFile)
+	svc.RunPending(time.Now())
+	if _, err := svc.Update(hook.ID, &WebhookRequest{Active: &active}); err != nil {
+		t.Fatal(err)
+	}
+	svc.RunPending(time.Now())
+	if got := attempts.Load(); got != 0 {
+		t.Fatalf("expected no delivery of entries logged while inactive, got %d", got)
+	}
+
+	logAuditEntry(t, mock, constants.AuditActionAddingFile)
+	svc.RunPending(time.Now())
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("expected the new entry delivered, got %d attempts", got)
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, constants.WebhookRetryBaseDelay},
+		{2, 2 * constants.WebhookRetryBaseDelay},
+		{3, 4 * constants.WebhookRetryBaseDelay},
+		{20, constants.WebhookRetryMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := webhookRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("webhookRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
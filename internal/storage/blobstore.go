@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// BlobStore holds the .dat files of one topic. Files are written whole and
+// read by byte range; appends always go to the local topic directory.
+type BlobStore interface {
+	// Name identifies the store in logs and the topic database.
+	Name() string
+
+	// Open returns a reader over length bytes at offset in a .dat file.
+	// Errors wrap fs.ErrNotExist when the file is not in the store.
+	Open(datFile string, offset, length int64) (io.ReadCloser, error)
+
+	// Size returns the size of a .dat file. Errors wrap fs.ErrNotExist when
+	// the file is not in the store.
+	Size(datFile string) (int64, error)
+
+	// Put stores a complete .dat file of size bytes read from r, replacing
+	// any previous copy.
+	Put(datFile string, r io.ReadSeeker, size int64) error
+
+	// Delete removes a .dat file. Deleting a missing file is not an error.
+	Delete(datFile string) error
+}
+
+// LocalBlobStore keeps .dat files in a topic directory.
+type LocalBlobStore struct {
+	Dir      string
+	DirectIO bool // read with O_DIRECT when the filesystem supports it
+}
+
+// NewLocalBlobStore returns the store of the topic directory dir.
+func NewLocalBlobStore(dir string, directIO bool) *LocalBlobStore {
+	return &LocalBlobStore{Dir: dir, DirectIO: directIO}
+}
+
+func (s *LocalBlobStore) Name() string {
+	return "local"
+}
+
+func (s *LocalBlobStore) Open(datFile string, offset, length int64) (io.ReadCloser, error) {
+	return OpenData(filepath.Join(s.Dir, datFile), offset, length, s.DirectIO)
+}
+
+func (s *LocalBlobStore) Size(datFile string) (int64, error) {
+	info, err := os.Stat(filepath.Join(s.Dir, datFile))
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Put writes the file next to its destination and renames it into place,
+// so a partial copy is never visible under the .dat name.
+func (s *LocalBlobStore) Put(datFile string, r io.ReadSeeker, size int64) error {
+	tmp, err := os.CreateTemp(s.Dir, datFile+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if err == nil && n != size {
+		err = fmt.Errorf("copied %d bytes, expected %d", n, size)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", datFile, err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.Dir, datFile))
+}
+
+func (s *LocalBlobStore) Delete(datFile string) error {
+	if err := os.Remove(filepath.Join(s.Dir, datFile)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// =============================================================================
+// Content-Defined Chunking (FastCDC-style gear hash)
+// =============================================================================
+//
+// Splits a byte stream at content-dependent boundaries so that an insertion
+// or deletion only changes the chunks around the edit. Used to estimate how
+// much chunk-level deduplication would save; assets are still stored whole.
+
+// gearTable holds 256 pseudo-random 64-bit values. It is generated from a
+// fixed seed so chunk boundaries are stable across runs and builds.
+var gearTable = func() [256]uint64 {
+	var table [256]uint64
+	state := uint64(0x5EED_C0DE_D0D0_CAFE)
+	for i := range table {
+		// splitmix64
+		state += 0x9E3779B97F4A7C15
+		z := state
+		z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+		z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// ErrInvalidChunkSizes is returned when min <= avg <= max does not hold.
+var ErrInvalidChunkSizes = errors.New("chunk sizes must satisfy 0 < min <= avg <= max")
+
+// Chunker splits a reader into content-defined chunks.
+type Chunker struct {
+	r       io.Reader
+	min     int
+	avg     int
+	max     int
+	maskS   uint64 // stricter mask used before avg (fewer cut points)
+	maskL   uint64 // looser mask used after avg (more cut points)
+	buf     []byte
+	start   int // start of unconsumed data in buf
+	end     int // end of valid data in buf
+	eof     bool
+	readErr error
+}
+
+// NewChunker creates a chunker. avg should be a power of two; it is rounded
+// down otherwise. Normalized chunking keeps most chunks close to avg.
+func NewChunker(r io.Reader, min, avg, max int) (*Chunker, error) {
+	if min <= 0 || min > avg || avg > max {
+		return nil, ErrInvalidChunkSizes
+	}
+
+	avgBits := bits.Len(uint(avg)) - 1
+	return &Chunker{
+		r:     r,
+		min:   min,
+		avg:   avg,
+		max:   max,
+		maskS: spreadMask(avgBits + 1),
+		maskL: spreadMask(avgBits - 1),
+		buf:   make([]byte, max*2),
+	}, nil
+}
+
+// spreadMask returns a mask with n bits set, taken from the high end of the
+// hash where the gear hash mixes best.
+func spreadMask(n int) uint64 {
+	if n <= 0 {
+		return 0
+	}
+	if n >= 64 {
+		return ^uint64(0)
+	}
+	return ^uint64(0) << (64 - n)
+}
+
+// Next returns the next chunk. The slice is only valid until the following
+// call. Returns io.EOF after the last chunk.
+func (c *Chunker) Next() ([]byte, error) {
+	if err := c.fill(); err != nil {
+		return nil, err
+	}
+	if c.start == c.end {
+		if c.readErr != nil {
+			return nil, c.readErr
+		}
+		return nil, io.EOF
+	}
+
+	n := c.cut(c.buf[c.start:c.end])
+	chunk := c.buf[c.start : c.start+n]
+	c.start += n
+	return chunk, nil
+}
+
+// fill ensures at least max bytes are buffered, unless the reader is drained.
+func (c *Chunker) fill() error {
+	if c.eof || c.end-c.start >= c.max {
+		return nil
+	}
+
+	// Compact the buffer so a full max-sized chunk always fits
+	if c.start > 0 {
+		copy(c.buf, c.buf[c.start:c.end])
+		c.end -= c.start
+		c.start = 0
+	}
+
+	for c.end < len(c.buf) && !c.eof {
+		n, err := c.r.Read(c.buf[c.end:])
+		c.end += n
+		if err == io.EOF {
+			c.eof = true
+		} else if err != nil {
+			c.eof = true
+			c.readErr = err
+			if c.end == 0 {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cut returns the length of the next chunk within data.
+func (c *Chunker) cut(data []byte) int {
+	n := len(data)
+	if n <= c.min {
+		return n
+	}
+	if n > c.max {
+		n = c.max
+	}
+
+	normal := c.avg
+	if normal > n {
+		normal = n
+	}
+
+	var hash uint64
+	i := c.min
+	for ; i < normal; i++ {
+		hash = (hash << 1) + gearTable[data[i]]
+		if hash&c.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		hash = (hash << 1) + gearTable[data[i]]
+		if hash&c.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// collectChunks returns copies of every chunk produced for data.
+func collectChunks(t *testing.T, data []byte, min, avg, max int) [][]byte {
+	t.Helper()
+	c, err := NewChunker(bytes.NewReader(data), min, avg, max)
+	if err != nil {
+		t.Fatalf("NewChunker failed: %v", err)
+	}
+	var chunks [][]byte
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		chunks = append(chunks, append([]byte(nil), chunk...))
+	}
+}
+
+func TestChunker_ReassemblesAndRespectsBounds(t *testing.T) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	chunks := collectChunks(t, data, 2048, 8192, 65536)
+	if !bytes.Equal(bytes.Join(chunks, nil), data) {
+		t.Fatal("chunks do not reassemble to the input")
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > 65536 {
+			t.Errorf("chunk %d exceeds max: %d", i, len(chunk))
+		}
+		if len(chunk) < 2048 && i != len(chunks)-1 {
+			t.Errorf("chunk %d below min: %d", i, len(chunk))
+		}
+	}
+
+	// Roughly avg-sized on random data
+	avg := len(data) / len(chunks)
+	if avg < 4096 || avg > 16384 {
+		t.Errorf("average chunk size %d far from 8192", avg)
+	}
+}
+
+func TestChunker_BoundariesSurviveInsertion(t *testing.T) {
+	data := make([]byte, 512*1024)
+	rand.New(rand.NewSource(2)).Read(data)
+	shifted := append([]byte("inserted prefix"), data...)
+
+	original := make(map[string]bool)
+	for _, c := range collectChunks(t, data, 2048, 8192, 65536) {
+		original[string(c)] = true
+	}
+
+	shared, total := 0, 0
+	for _, c := range collectChunks(t, shifted, 2048, 8192, 65536) {
+		total++
+		if original[string(c)] {
+			shared++
+		}
+	}
+	if shared < total-2 {
+		t.Errorf("expected all but the first chunks to be shared, got %d/%d", shared, total)
+	}
+}
+
+func TestChunker_SmallAndEmptyInput(t *testing.T) {
+	if chunks := collectChunks(t, nil, 2048, 8192, 65536); len(chunks) != 0 {
+		t.Errorf("expected no chunks for empty input, got %d", len(chunks))
+	}
+	chunks := collectChunks(t, []byte("tiny"), 2048, 8192, 65536)
+	if len(chunks) != 1 || string(chunks[0]) != "tiny" {
+		t.Errorf("expected a single chunk, got %q", chunks)
+	}
+}
+
+func TestNewChunker_InvalidSizes(t *testing.T) {
+	if _, err := NewChunker(bytes.NewReader(nil), 0, 8, 16); err != ErrInvalidChunkSizes {
+		t.Errorf("expected ErrInvalidChunkSizes, got %v", err)
+	}
+	if _, err := NewChunker(bytes.NewReader(nil), 16, 8, 32); err != ErrInvalidChunkSizes {
+		t.Errorf("expected ErrInvalidChunkSizes, got %v", err)
+	}
+}
@@ -40,6 +40,11 @@ func ListDatFiles(topicPath string) ([]string, error) {
 	return datFiles, nil
 }
 
+// IsDatFilename reports whether name is a .dat file name, such as 000001.dat
+func IsDatFilename(name string) bool {
+	return datFileRegex.MatchString(name)
+}
+
 // extractDatNumber extracts the numeric part from a .dat filename
 func extractDatNumber(filename string) int {
 	matches := datFileRegex.FindStringSubmatch(filename)
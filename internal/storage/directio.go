@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrDirectIOUnsupported is returned when the platform or the filesystem
+// holding a file does not accept O_DIRECT transfers. Callers fall back to
+// buffered I/O.
+var ErrDirectIOUnsupported = errors.New("direct I/O not supported")
+
+// OpenData returns a reader over length bytes at offset in the .dat file at
+// datPath. With direct set the bytes are read with O_DIRECT, bypassing the
+// page cache, when the filesystem supports it and buffered otherwise.
+func OpenData(datPath string, offset, length int64, direct bool) (io.ReadCloser, error) {
+	if direct {
+		r, err := openDirectReader(datPath, offset, length)
+		if err == nil {
+			return r, nil
+		}
+		if !errors.Is(err, ErrDirectIOUnsupported) {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(datPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dat file: %w", err)
+	}
+	return &sectionReadCloser{Reader: io.NewSectionReader(f, offset, length), file: f, offset: offset, length: length}, nil
+}
+
+// FileRange is implemented by readers that return a byte range of a file
+// unchanged, so the range can be handed to the kernel (e.g. sendfile)
+// instead of being copied through userspace. Direct I/O readers do not
+// implement it: they bypass the page cache on purpose.
+type FileRange interface {
+	FileRange() (f *os.File, offset, length int64)
+}
+
+// sectionReadCloser closes the file behind a section reader.
+type sectionReadCloser struct {
+	io.Reader
+	file           *os.File
+	offset, length int64
+}
+
+func (r *sectionReadCloser) Close() error {
+	return r.file.Close()
+}
+
+// FileRange returns the file and the range the reader was opened over.
+func (r *sectionReadCloser) FileRange() (*os.File, int64, int64) {
+	return r.file, r.offset, r.length
+}
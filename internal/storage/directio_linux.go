@@ -0,0 +1,254 @@
+//go:build linux
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"silobang/internal/constants"
+)
+
+// AppendDirect appends header followed by size bytes from src to the .dat
+// file at datPath with O_DIRECT and returns the offset of the new entry.
+// Writes go through aligned buffers: the partial block at the current end of
+// the file is read back and rewritten, and the zero padding of the last
+// block is truncated away before the file is synced. Returns
+// ErrDirectIOUnsupported, with the file left as it was, when the filesystem
+// refuses direct transfers.
+//
+// Like AppendEntryFromReader, the caller must hold the topic's write lock.
+func AppendDirect(datPath string, header []byte, src io.Reader, size int64) (byteOffset int64, err error) {
+	f, err := openDirect(datPath, os.O_RDWR|os.O_CREATE)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat dat file: %w", err)
+	}
+	byteOffset = stat.Size()
+	end := byteOffset + int64(len(header)) + size
+
+	// Reserve the extension up front so a full disk fails before any byte is written
+	if err := Preallocate(f, byteOffset, end-byteOffset); err != nil {
+		return 0, err
+	}
+
+	// A failed write must not leave a partial entry or padding behind
+	defer func() {
+		if err != nil {
+			if truncErr := f.Truncate(byteOffset); truncErr != nil {
+				err = fmt.Errorf("%w (truncating back to %d failed: %v)", err, byteOffset, truncErr)
+			}
+		}
+	}()
+
+	w := &directWriter{
+		f:   f,
+		buf: alignedBuffer(constants.DirectIOBufferSize),
+		pos: alignDown(byteOffset),
+	}
+
+	// The block holding the current end of file is rewritten whole, so it
+	// starts with the bytes already there
+	if head := int(byteOffset - w.pos); head > 0 {
+		n, readErr := f.ReadAt(w.buf[:constants.DirectIOAlignment], w.pos)
+		if readErr != nil && readErr != io.EOF {
+			return 0, directErr("failed to read tail block", readErr)
+		}
+		if n < head {
+			return 0, fmt.Errorf("%w: tail block has %d of %d bytes", ErrReadTruncated, n, head)
+		}
+		w.n = head
+	}
+
+	if _, err = w.Write(header); err != nil {
+		return 0, directErr("failed to write header", err)
+	}
+	written, err := io.Copy(w, src)
+	if err != nil {
+		return 0, directErr("failed to write data", err)
+	}
+	if written != size {
+		return 0, fmt.Errorf("size mismatch: expected %d bytes, wrote %d", size, written)
+	}
+	if err = w.flush(); err != nil {
+		return 0, directErr("failed to write data", err)
+	}
+
+	// Drop the zero padding of the last block
+	if err = f.Truncate(end); err != nil {
+		return 0, fmt.Errorf("failed to trim dat file: %w", err)
+	}
+
+	// Sync to ensure durability before DB commit
+	if err = f.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync dat file: %w", err)
+	}
+
+	return byteOffset, nil
+}
+
+// directWriter stages writes in an aligned buffer and writes it out in
+// whole blocks.
+type directWriter struct {
+	f   *os.File
+	buf []byte
+	n   int   // bytes staged in buf
+	pos int64 // file offset of buf[0], always aligned
+}
+
+func (w *directWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		c := copy(w.buf[w.n:], p)
+		w.n += c
+		written += c
+		p = p[c:]
+		if w.n == len(w.buf) {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flush writes the staged bytes padded with zeros to a whole block. Only the
+// final flush may be partial.
+func (w *directWriter) flush() error {
+	if w.n == 0 {
+		return nil
+	}
+	length := int(alignUp(int64(w.n)))
+	clear(w.buf[w.n:length])
+	if _, err := w.f.WriteAt(w.buf[:length], w.pos); err != nil {
+		return err
+	}
+	w.pos += int64(length)
+	w.n = 0
+	return nil
+}
+
+// directReader reads a byte range of a file opened with O_DIRECT through an
+// aligned buffer.
+type directReader struct {
+	f         *os.File
+	buf       []byte
+	data      []byte // bytes read but not yet returned
+	pos       int64  // file offset of the next block to read, always aligned
+	skip      int    // bytes before the range at the start of the first block
+	remaining int64  // bytes of the range not yet returned, including data
+	eof       bool
+}
+
+func openDirectReader(datPath string, offset, length int64) (io.ReadCloser, error) {
+	f, err := openDirect(datPath, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &directReader{
+		f:         f,
+		buf:       alignedBuffer(constants.DirectIOBufferSize),
+		pos:       alignDown(offset),
+		skip:      int(offset - alignDown(offset)),
+		remaining: length,
+	}
+
+	// Some filesystems accept O_DIRECT at open but reject the transfer, so
+	// the first block is read before committing to direct I/O
+	if length > 0 {
+		if err := r.fill(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *directReader) fill() error {
+	if r.eof {
+		return io.ErrUnexpectedEOF
+	}
+	n, err := r.f.ReadAt(r.buf, r.pos)
+	if err == io.EOF {
+		r.eof = true
+	} else if err != nil {
+		return directErr("failed to read dat file", err)
+	}
+	r.pos += int64(n)
+
+	if n <= r.skip {
+		return io.ErrUnexpectedEOF
+	}
+	r.data = r.buf[r.skip:n]
+	r.skip = 0
+	if int64(len(r.data)) > r.remaining {
+		r.data = r.data[:r.remaining]
+	}
+	return nil
+}
+
+func (r *directReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	if len(r.data) == 0 {
+		if err := r.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	r.remaining -= int64(n)
+	return n, nil
+}
+
+func (r *directReader) Close() error {
+	return r.f.Close()
+}
+
+// openDirect opens path with O_DIRECT added to flag.
+func openDirect(path string, flag int) (*os.File, error) {
+	f, err := os.OpenFile(path, flag|syscall.O_DIRECT, 0644)
+	if err != nil {
+		return nil, directErr("failed to open dat file", err)
+	}
+	return f, nil
+}
+
+// directErr wraps err, reporting EINVAL as ErrDirectIOUnsupported: that is
+// how filesystems without O_DIRECT support refuse the open or transfer.
+func directErr(msg string, err error) error {
+	if errors.Is(err, syscall.EINVAL) {
+		return fmt.Errorf("%w: %s: %v", ErrDirectIOUnsupported, msg, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// alignedBuffer returns a buffer of size bytes whose start is aligned to
+// constants.DirectIOAlignment, as O_DIRECT requires.
+func alignedBuffer(size int) []byte {
+	buf := make([]byte, size+constants.DirectIOAlignment)
+	shift := 0
+	if rem := int(uintptr(unsafe.Pointer(&buf[0])) % constants.DirectIOAlignment); rem != 0 {
+		shift = constants.DirectIOAlignment - rem
+	}
+	return buf[shift : shift+size : shift+size]
+}
+
+func alignDown(n int64) int64 {
+	return n - n%constants.DirectIOAlignment
+}
+
+func alignUp(n int64) int64 {
+	return alignDown(n + constants.DirectIOAlignment - 1)
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package storage
+
+import "io"
+
+// AppendDirect needs O_DIRECT, which is only used on Linux; callers fall
+// back to buffered appends.
+func AppendDirect(datPath string, header []byte, src io.Reader, size int64) (int64, error) {
+	return 0, ErrDirectIOUnsupported
+}
+
+func openDirectReader(datPath string, offset, length int64) (io.ReadCloser, error) {
+	return nil, ErrDirectIOUnsupported
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// requireDirectIO skips the test when the filesystem holding dir refuses
+// O_DIRECT (e.g. tmpfs).
+func requireDirectIO(tb testing.TB, dir string) {
+	tb.Helper()
+	header, _ := SerializeHeader(ComputeBlake3Hex(nil), 0)
+	_, err := AppendDirect(filepath.Join(dir, "probe.dat"), header, bytes.NewReader(nil), 0)
+	if errors.Is(err, ErrDirectIOUnsupported) {
+		tb.Skipf("direct I/O not supported here: %v", err)
+	}
+	if err != nil {
+		tb.Fatalf("probe append failed: %v", err)
+	}
+}
+
+// appendDirectEntry appends data as a complete entry with AppendDirect.
+func appendDirectEntry(tb testing.TB, datPath string, data []byte) int64 {
+	tb.Helper()
+	hash := ComputeBlake3Hex(data)
+	header, err := SerializeHeader(hash, uint64(len(data)))
+	if err != nil {
+		tb.Fatalf("SerializeHeader failed: %v", err)
+	}
+	offset, err := AppendDirect(datPath, header, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		tb.Fatalf("AppendDirect failed: %v", err)
+	}
+	return offset
+}
+
+func randomData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+	return data
+}
+
+func TestAppendDirect_MixesWithBufferedEntries(t *testing.T) {
+	dir := t.TempDir()
+	requireDirectIO(t, dir)
+	datPath := filepath.Join(dir, FormatDatFilename(1))
+
+	// Sizes leave the file end unaligned and span several staging buffers
+	entries := [][]byte{
+		[]byte("buffered entry"),
+		randomData(constants.DirectIOBufferSize + constants.DirectIOAlignment + 37),
+		[]byte("small direct entry"),
+		randomData(3 * constants.DirectIOAlignment),
+	}
+
+	var offsets []int64
+	var want int64
+	for i, data := range entries {
+		var offset int64
+		if i == 0 {
+			var err error
+			if offset, err = AppendEntry(datPath, ComputeBlake3Hex(data), data); err != nil {
+				t.Fatalf("AppendEntry failed: %v", err)
+			}
+		} else {
+			offset = appendDirectEntry(t, datPath, data)
+		}
+		if offset != want {
+			t.Fatalf("entry %d: expected offset %d, got %d", i, want, offset)
+		}
+		offsets = append(offsets, offset)
+		want += int64(constants.HeaderSize + len(data))
+	}
+
+	// The padding of the last block must be gone
+	if size, _ := GetDatFileSize(datPath); size != want {
+		t.Fatalf("expected file size %d, got %d", want, size)
+	}
+	for i, offset := range offsets {
+		if err := ValidateEntry(datPath, offset); err != nil {
+			t.Errorf("entry %d invalid: %v", i, err)
+		}
+	}
+}
+
+func TestOpenData_DirectMatchesBuffered(t *testing.T) {
+	dir := t.TempDir()
+	requireDirectIO(t, dir)
+	datPath := filepath.Join(dir, FormatDatFilename(1))
+
+	data := randomData(2*constants.DirectIOBufferSize + 1000)
+	offset := appendDirectEntry(t, datPath, data)
+	dataStart := offset + int64(constants.HeaderSize)
+
+	ranges := []struct{ start, length int64 }{
+		{0, int64(len(data))},
+		{1, 10},
+		{int64(constants.DirectIOAlignment) - 3, 6},
+		{int64(constants.DirectIOBufferSize) - 50, 100},
+		{int64(len(data)) - 1, 1},
+		{5, 0},
+	}
+	for _, r := range ranges {
+		for _, direct := range []bool{false, true} {
+			reader, err := OpenData(datPath, dataStart+r.start, r.length, direct)
+			if err != nil {
+				t.Fatalf("OpenData(%d, %d, direct=%v) failed: %v", r.start, r.length, direct, err)
+			}
+			got, err := io.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				t.Fatalf("read (%d, %d, direct=%v) failed: %v", r.start, r.length, direct, err)
+			}
+			if !bytes.Equal(got, data[r.start:r.start+r.length]) {
+				t.Errorf("range (%d, %d, direct=%v) returned wrong bytes", r.start, r.length, direct)
+			}
+		}
+	}
+
+	// Reading past the end of the file is reported, not silently shortened
+	reader, err := OpenData(datPath, dataStart, int64(len(data))+10, true)
+	if err != nil {
+		t.Fatalf("OpenData failed: %v", err)
+	}
+	defer reader.Close()
+	if _, err := io.ReadAll(reader); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestOpenData_MissingFile(t *testing.T) {
+	for _, direct := range []bool{false, true} {
+		if _, err := OpenData(filepath.Join(t.TempDir(), "missing.dat"), 0, 10, direct); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("direct=%v: expected os.ErrNotExist, got %v", direct, err)
+		}
+	}
+}
+
+// Throughput of appends and reads with and without the page cache. On
+// network block devices direct I/O trades some single-stream throughput
+// for not evicting other workloads' cached pages; compare with
+//
+//	go test ./internal/storage -run '^$' -bench 'Append|ReadData' -benchtime 20x
+//
+// with TMPDIR on the device under test.
+
+const benchEntrySize = 8 << 20
+
+func BenchmarkAppend(b *testing.B) {
+	data := randomData(benchEntrySize)
+	header, _ := SerializeHeader(ComputeBlake3Hex(data), uint64(len(data)))
+
+	b.Run("buffered", func(b *testing.B) {
+		datPath := filepath.Join(b.TempDir(), FormatDatFilename(1))
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if _, err := AppendEntryFromReader(datPath, ComputeBlake3Hex(data), int64(len(data)), bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("direct", func(b *testing.B) {
+		dir := b.TempDir()
+		requireDirectIO(b, dir)
+		datPath := filepath.Join(dir, FormatDatFilename(1))
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if _, err := AppendDirect(datPath, header, bytes.NewReader(data), int64(len(data))); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkReadData(b *testing.B) {
+	dir := b.TempDir()
+	requireDirectIO(b, dir)
+	datPath := filepath.Join(dir, FormatDatFilename(1))
+	offset := appendDirectEntry(b, datPath, randomData(benchEntrySize))
+	dataStart := offset + int64(constants.HeaderSize)
+
+	for _, mode := range []struct {
+		name   string
+		direct bool
+	}{{"buffered", false}, {"direct", true}} {
+		b.Run(mode.name, func(b *testing.B) {
+			b.SetBytes(benchEntrySize)
+			for i := 0; i < b.N; i++ {
+				reader, err := OpenData(datPath, dataStart, benchEntrySize, mode.direct)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := io.Copy(io.Discard, reader); err != nil {
+					b.Fatal(err)
+				}
+				reader.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"errors"
+	"syscall"
+)
+
+// ErrNoSpace is returned when the filesystem has no room for a write.
+var ErrNoSpace = errors.New("no space left on device")
+
+// IsNoSpace reports whether err was caused by a full filesystem or quota.
+func IsNoSpace(err error) bool {
+	return errors.Is(err, ErrNoSpace) || errors.Is(err, syscall.ENOSPC)
+}
@@ -0,0 +1,34 @@
+//go:build linux
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fallocKeepSize reserves blocks without changing the file size, so appends
+// still land at the current end of the file.
+const fallocKeepSize = 0x01
+
+// Preallocate reserves length bytes at offset in f so a following write cannot
+// run out of space part-way. Returns ErrNoSpace when the filesystem cannot
+// hold the extension. Filesystems without fallocate support are ignored.
+func Preallocate(f *os.File, offset, length int64) error {
+	if length <= 0 {
+		return nil
+	}
+	err := syscall.Fallocate(int(f.Fd()), fallocKeepSize, offset, length)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, syscall.ENOSPC), errors.Is(err, syscall.EDQUOT):
+		return fmt.Errorf("%w: cannot reserve %d bytes", ErrNoSpace, length)
+	case errors.Is(err, syscall.EOPNOTSUPP), errors.Is(err, syscall.ENOSYS):
+		return nil
+	default:
+		return fmt.Errorf("failed to preallocate: %w", err)
+	}
+}
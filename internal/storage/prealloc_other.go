@@ -0,0 +1,11 @@
+//go:build !linux
+
+package storage
+
+import "os"
+
+// Preallocate is a no-op where fallocate is not available; a full disk is
+// then only detected by the write itself.
+func Preallocate(f *os.File, offset, length int64) error {
+	return nil
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestPreallocateKeepsFileSize(t *testing.T) {
+	f, err := os.OpenFile(filepath.Join(t.TempDir(), "001.dat"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer f.Close()
+	f.Write([]byte("existing"))
+
+	if err := Preallocate(f, 8, 1<<20); err != nil {
+		t.Fatalf("Preallocate failed: %v", err)
+	}
+
+	// Appends must still land at the logical end of the file
+	f.Write([]byte("next"))
+	info, _ := f.Stat()
+	if info.Size() != 12 {
+		t.Errorf("Expected size 12 after preallocate and append, got %d", info.Size())
+	}
+}
+
+func TestIsNoSpace(t *testing.T) {
+	if !IsNoSpace(fmt.Errorf("append: %w", ErrNoSpace)) || !IsNoSpace(&os.PathError{Op: "write", Err: syscall.ENOSPC}) {
+		t.Error("Expected no-space errors to be detected")
+	}
+	if IsNoSpace(errors.New("permission denied")) {
+		t.Error("Expected other errors not to be reported as no space")
+	}
+}
@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// s3RestoreClasses are the storage classes whose objects must be restored
+// before they can be read.
+var s3RestoreClasses = []string{"GLACIER", "DEEP_ARCHIVE"}
+
+// S3ArchiveConfig locates an archive in an S3-compatible bucket. Objects are
+// addressed path-style as <endpoint>/<bucket>/<prefix><key> and written in
+// StorageClass.
+type S3ArchiveConfig struct {
+	Endpoint        string
+	Bucket          string
+	Region          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	StorageClass    string // e.g. GLACIER or DEEP_ARCHIVE
+	RestoreDays     int    // days a restored copy stays readable
+	RestoreTier     string // Expedited, Standard or Bulk
+}
+
+// S3Archive keeps archived assets as objects of an archival storage class.
+// Reading one back takes a restore request and, depending on the tier,
+// minutes to hours before the object is readable. Requests are signed with
+// Signature Version 4.
+type S3Archive struct {
+	cfg    S3ArchiveConfig
+	client *http.Client
+}
+
+// NewS3Archive returns the archive in the configured bucket.
+func NewS3Archive(cfg S3ArchiveConfig) *S3Archive {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = constants.ArchiveS3ResponseTimeout
+	return &S3Archive{
+		cfg:    cfg,
+		client: &http.Client{Transport: transport},
+	}
+}
+
+// Put uploads the object in one request. The payload is not hashed into
+// the signature so it can be streamed; its Content-MD5 lets the bucket
+// reject a corrupted upload instead.
+func (a *S3Archive) Put(key string, r io.ReadSeeker, size int64) error {
+	sum := md5.New()
+	if _, err := io.Copy(sum, r); err != nil {
+		return fmt.Errorf("failed to checksum %s: %w", key, err)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := a.newRequest(http.MethodPut, key, io.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum.Sum(nil)))
+	req.Header.Set(constants.S3HeaderStorageClass, a.cfg.StorageClass)
+
+	resp, err := a.do(req, constants.S3UnsignedPayload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 upload of %s returned %s: %s", a.cfg.Prefix+key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Recall reports objects in a class that needs no restore, and restored
+// copies, as ready. Otherwise it requests a restore unless one is under
+// way.
+func (a *S3Archive) Recall(key string) (bool, error) {
+	req, err := a.newRequest(http.MethodHead, key, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := a.do(req, constants.S3EmptyPayloadSHA256)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("s3 head of %s returned %s", a.cfg.Prefix+key, resp.Status)
+	}
+
+	restore := resp.Header.Get(constants.S3HeaderRestore)
+	switch {
+	case strings.Contains(restore, `ongoing-request="false"`):
+		return true, nil
+	case strings.Contains(restore, `ongoing-request="true"`):
+		return false, nil
+	case !slices.Contains(s3RestoreClasses, resp.Header.Get(constants.S3HeaderStorageClass)):
+		return true, nil
+	}
+	return a.restore(key)
+}
+
+// restore requests a temporary readable copy of an object. A bucket
+// answering 200 already holds one.
+func (a *S3Archive) restore(key string) (bool, error) {
+	body := []byte(fmt.Sprintf(`<RestoreRequest xmlns="%s"><Days>%d</Days><GlacierJobParameters><Tier>%s</Tier></GlacierJobParameters></RestoreRequest>`,
+		constants.S3XMLNamespace, a.cfg.RestoreDays, a.cfg.RestoreTier))
+	req, err := a.newRequest(http.MethodPost, key, io.NopCloser(bytes.NewReader(body)))
+	if err != nil {
+		return false, err
+	}
+	req.URL.RawQuery = "restore"
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", "application/xml")
+	md5sum := md5.Sum(body)
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(md5sum[:]))
+	payloadHash := sha256.Sum256(body)
+
+	resp, err := a.do(req, hex.EncodeToString(payloadHash[:]))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusAccepted, http.StatusConflict: // restore started, or already in progress
+		return false, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return false, fmt.Errorf("s3 restore of %s returned %s: %s", a.cfg.Prefix+key, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+func (a *S3Archive) Open(key string) (io.ReadCloser, error) {
+	req, err := a.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.do(req, constants.S3EmptyPayloadSHA256)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 read of %s returned %s", a.cfg.Prefix+key, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func (a *S3Archive) Delete(key string) error {
+	req, err := a.newRequest(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.do(req, constants.S3EmptyPayloadSHA256)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 delete of %s returned %s", a.cfg.Prefix+key, resp.Status)
+	}
+	return nil
+}
+
+// newRequest returns a request for the object of key in the bucket.
+func (a *S3Archive) newRequest(method, key string, body io.ReadCloser) (*http.Request, error) {
+	u, err := url.Parse(strings.TrimSuffix(a.cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	u.Path += "/" + a.cfg.Bucket + "/" + a.cfg.Prefix + key
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Body = body
+	}
+	return req, nil
+}
+
+// do signs and sends a request. A 404 is returned as an error wrapping
+// fs.ErrNotExist.
+func (a *S3Archive) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	a.sign(req, payloadHash, time.Now().UTC())
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 object %s: %w", req.URL.Path, fs.ErrNotExist)
+	}
+	return resp, nil
+}
+
+// sign adds a Signature Version 4 Authorization header covering the host,
+// the query, the X-Amz-* headers, and Content-MD5 when present.
+func (a *S3Archive) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format(constants.S3DateFormat)
+	date := amzDate[:8]
+	req.Header.Set(constants.S3HeaderDate, amzDate)
+	req.Header.Set(constants.S3HeaderContentSHA256, payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-md5" {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3URIEncode(req.URL.Path),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + a.cfg.Region + "/" + constants.S3Service + "/" + constants.S3ScopeTerminator
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := constants.S3SigningAlgorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+a.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, a.cfg.Region)
+	key = hmacSHA256(key, constants.S3Service)
+	key = hmacSHA256(key, constants.S3ScopeTerminator)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", constants.S3SigningAlgorithm+" Credential="+a.cfg.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3CanonicalQuery returns the query parameters sorted by name, each name
+// and value percent-encoded, as Signature Version 4 requires.
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		values := append([]string{}, query[name]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, s3QueryEncode(name)+"="+s3QueryEncode(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3QueryEncode percent-encodes a query name or value: like s3URIEncode,
+// slashes included.
+func s3QueryEncode(s string) string {
+	return strings.ReplaceAll(s3URIEncode(s), "/", "%2F")
+}
+
+// s3URIEncode percent-encodes a path as Signature Version 4 requires: every
+// byte except unreserved characters and slashes.
+func s3URIEncode(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString("%" + strings.ToUpper(strconv.FormatUint(uint64(c), 16)))
+	}
+	return b.String()
+}
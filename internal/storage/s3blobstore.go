@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"silobang/internal/constants"
+)
+
+// S3BlobStoreConfig locates the objects of one topic in an S3-compatible
+// bucket (AWS S3, MinIO, ...). Objects are addressed path-style as
+// <endpoint>/<bucket>/<prefix><topic>/<dat file>.
+type S3BlobStoreConfig struct {
+	Name            string // blob store name from the configuration
+	Endpoint        string // e.g. https://s3.eu-west-1.amazonaws.com or http://minio:9000
+	Bucket          string
+	Region          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3BlobStore keeps .dat files of one topic as objects of an S3-compatible
+// bucket. Requests are signed with Signature Version 4.
+type S3BlobStore struct {
+	cfg    S3BlobStoreConfig
+	topic  string
+	client *http.Client
+}
+
+// NewS3BlobStore returns the store of a topic in the configured bucket.
+func NewS3BlobStore(cfg S3BlobStoreConfig, topic string) *S3BlobStore {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = constants.BlobStoreResponseTimeout
+	return &S3BlobStore{
+		cfg:    cfg,
+		topic:  topic,
+		client: &http.Client{Transport: transport},
+	}
+}
+
+func (s *S3BlobStore) Name() string {
+	return s.cfg.Name
+}
+
+// Key returns the object key of a .dat file.
+func (s *S3BlobStore) Key(datFile string) string {
+	return s.cfg.Prefix + s.topic + "/" + datFile
+}
+
+func (s *S3BlobStore) Open(datFile string, offset, length int64) (io.ReadCloser, error) {
+	req, err := s.newRequest(http.MethodGet, datFile, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	resp, err := s.do(req, constants.S3EmptyPayloadSHA256)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 range read of %s returned %s", s.Key(datFile), resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3BlobStore) Size(datFile string) (int64, error) {
+	req, err := s.newRequest(http.MethodHead, datFile, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := s.do(req, constants.S3EmptyPayloadSHA256)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("s3 head of %s returned %s", s.Key(datFile), resp.Status)
+	}
+	return resp.ContentLength, nil
+}
+
+// Put uploads the file in one request. The payload is not hashed into the
+// signature so it can be streamed; its Content-MD5 lets the bucket reject
+// a corrupted upload instead.
+func (s *S3BlobStore) Put(datFile string, r io.ReadSeeker, size int64) error {
+	sum := md5.New()
+	if _, err := io.Copy(sum, r); err != nil {
+		return fmt.Errorf("failed to checksum %s: %w", datFile, err)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := s.newRequest(http.MethodPut, datFile, io.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum.Sum(nil)))
+
+	resp, err := s.do(req, constants.S3UnsignedPayload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 upload of %s returned %s: %s", s.Key(datFile), resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (s *S3BlobStore) Delete(datFile string) error {
+	req, err := s.newRequest(http.MethodDelete, datFile, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, constants.S3EmptyPayloadSHA256)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 delete of %s returned %s", s.Key(datFile), resp.Status)
+	}
+	return nil
+}
+
+func (s *S3BlobStore) newRequest(method, datFile string, body io.ReadCloser) (*http.Request, error) {
+	u, err := url.Parse(strings.TrimSuffix(s.cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	u.Path += "/" + s.cfg.Bucket + "/" + s.Key(datFile)
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Body = body
+	}
+	return req, nil
+}
+
+// do signs and sends a request. A 404 is returned as an error wrapping
+// fs.ErrNotExist.
+func (s *S3BlobStore) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 object %s: %w", req.URL.Path, fs.ErrNotExist)
+	}
+	return resp, nil
+}
+
+// sign adds a Signature Version 4 Authorization header covering the host,
+// the X-Amz-* headers, and Content-MD5 and Range when present.
+func (s *S3BlobStore) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format(constants.S3DateFormat)
+	date := amzDate[:8]
+	req.Header.Set(constants.S3HeaderDate, amzDate)
+	req.Header.Set(constants.S3HeaderContentSHA256, payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-md5" || lower == "range" {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3URIEncode(req.URL.Path),
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.cfg.Region + "/" + constants.S3Service + "/" + constants.S3ScopeTerminator
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := constants.S3SigningAlgorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, constants.S3Service)
+	key = hmacSHA256(key, constants.S3ScopeTerminator)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", constants.S3SigningAlgorithm+" Credential="+s.cfg.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
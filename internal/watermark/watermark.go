@@ -0,0 +1,182 @@
+// Package watermark stamps a text or overlay image onto PNG and JPEG images.
+// It is used as a download transform: the stored asset is never modified.
+package watermark
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"silobang/internal/constants"
+)
+
+// ErrTooLarge is returned when an image exceeds constants.WatermarkMaxPixels.
+var ErrTooLarge = errors.New("image too large to watermark")
+
+// Options describes a watermark. Exactly one of Text or Overlay is set.
+type Options struct {
+	Text     string
+	Overlay  image.Image
+	Position string  // one of the constants.WatermarkPosition* values
+	Opacity  float64 // 0 < opacity <= 1
+	Scale    float64 // stamp width relative to the image width, 0 < scale <= 1
+}
+
+// Supported reports whether images of contentType can be watermarked.
+func Supported(contentType string) bool {
+	return contentType == "image/png" || contentType == "image/jpeg"
+}
+
+// Apply decodes the image read from r, stamps the watermark and re-encodes it
+// in its original format.
+func Apply(r io.Reader, contentType string, opts Options) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > constants.WatermarkMaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+
+	stamp, mask := buildStamp(opts, dst.Bounds().Dx())
+	for _, at := range placements(dst.Bounds(), stamp.Bounds(), opts.Position) {
+		draw.DrawMask(dst, stamp.Bounds().Add(at), stamp, image.Point{}, mask, image.Point{}, draw.Over)
+	}
+
+	var buf bytes.Buffer
+	switch contentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: constants.WatermarkJPEGQuality})
+	default:
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// buildStamp renders the watermark for an image imageWidth pixels wide and
+// returns it with the mask applying the configured opacity.
+func buildStamp(opts Options, imageWidth int) (image.Image, image.Image) {
+	targetWidth := max(1, int(float64(imageWidth)*opts.Scale))
+	opacity := image.NewUniform(color.Alpha{A: uint8(opts.Opacity * 255)})
+
+	if opts.Overlay != nil {
+		return resize(opts.Overlay, targetWidth), opacity
+	}
+	return renderText(opts.Text, targetWidth), opacity
+}
+
+// renderText draws text in white over a one-pixel dark shadow, with glyph
+// pixels scaled so the text is about targetWidth pixels wide.
+func renderText(text string, targetWidth int) *image.RGBA {
+	runes := []rune(text)
+	cellWidth := glyphWidth + 1
+	px := max(1, targetWidth/max(1, len(runes)*cellWidth))
+	shadow := max(1, px/2)
+
+	width := len(runes)*cellWidth*px + shadow
+	height := glyphHeight*px + shadow
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	white := image.NewUniform(color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	dark := image.NewUniform(color.RGBA{A: 160})
+	for _, layer := range []struct {
+		fill   *image.Uniform
+		offset int
+	}{{dark, shadow}, {white, 0}} {
+		for i, r := range runes {
+			glyph := glyphFor(r)
+			for y, row := range glyph {
+				for x, cell := range row {
+					if cell != '#' {
+						continue
+					}
+					x0 := (i*cellWidth+x)*px + layer.offset
+					y0 := y*px + layer.offset
+					draw.Draw(img, image.Rect(x0, y0, x0+px, y0+px), layer.fill, image.Point{}, draw.Over)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// resize scales src to width pixels, keeping its aspect ratio, using
+// nearest-neighbour sampling.
+func resize(src image.Image, width int) *image.RGBA {
+	b := src.Bounds()
+	height := max(1, b.Dy()*width/max(1, b.Dx()))
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		sy := b.Min.Y + y*b.Dy()/height
+		for x := 0; x < width; x++ {
+			dst.Set(x, y, src.At(b.Min.X+x*b.Dx()/width, sy))
+		}
+	}
+	return dst
+}
+
+// placements returns the top-left corners at which the stamp is drawn.
+func placements(img, stamp image.Rectangle, position string) []image.Point {
+	margin := int(float64(min(img.Dx(), img.Dy())) * constants.WatermarkMarginRatio)
+	left, top := margin, margin
+	right := img.Dx() - stamp.Dx() - margin
+	bottom := img.Dy() - stamp.Dy() - margin
+
+	switch position {
+	case constants.WatermarkPositionCenter:
+		return []image.Point{{(img.Dx() - stamp.Dx()) / 2, (img.Dy() - stamp.Dy()) / 2}}
+	case constants.WatermarkPositionTopLeft:
+		return []image.Point{{left, top}}
+	case constants.WatermarkPositionTopRight:
+		return []image.Point{{right, top}}
+	case constants.WatermarkPositionBottomLeft:
+		return []image.Point{{left, bottom}}
+	case constants.WatermarkPositionTile:
+		var points []image.Point
+		stepX := stamp.Dx() + max(margin, stamp.Dx()/2)
+		stepY := stamp.Dy() + max(margin, stamp.Dy()*2)
+		for y, row := 0, 0; y < img.Dy(); y, row = y+stepY, row+1 {
+			// Offset every other row so the stamps form a staggered pattern
+			for x := (row % 2) * stepX / 2; x < img.Dx(); x += stepX {
+				points = append(points, image.Point{x, y})
+			}
+		}
+		return points
+	default:
+		return []image.Point{{right, bottom}}
+	}
+}
+
+// ValidPosition reports whether position is a known placement.
+func ValidPosition(position string) bool {
+	switch position {
+	case constants.WatermarkPositionCenter, constants.WatermarkPositionTopLeft,
+		constants.WatermarkPositionTopRight, constants.WatermarkPositionBottomLeft,
+		constants.WatermarkPositionBottomRight, constants.WatermarkPositionTile:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package watermark
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+// solidImage returns a w x h image filled with c.
+func solidImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// changedPixels counts the pixels of got that differ from c inside r.
+func changedPixels(got image.Image, r image.Rectangle, c color.Color) int {
+	wr, wg, wb, _ := c.RGBA()
+	n := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			gr, gg, gb, _ := got.At(x, y).RGBA()
+			if gr != wr || gg != wg || gb != wb {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestApplyTextPNG(t *testing.T) {
+	blue := color.RGBA{B: 200, A: 255}
+	src := encodePNG(t, solidImage(200, 100, blue))
+
+	out, err := Apply(bytes.NewReader(src), "image/png", Options{
+		Text:     "Review copy",
+		Position: constants.WatermarkPositionBottomRight,
+		Opacity:  1,
+		Scale:    0.5,
+	})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("output is not a png: %v", err)
+	}
+	if img.Bounds().Dx() != 200 || img.Bounds().Dy() != 100 {
+		t.Fatalf("dimensions changed: %v", img.Bounds())
+	}
+
+	// The stamp lands in the bottom-right quadrant only
+	if n := changedPixels(img, image.Rect(100, 50, 200, 100), blue); n == 0 {
+		t.Error("expected watermark pixels in the bottom-right quadrant")
+	}
+	if n := changedPixels(img, image.Rect(0, 0, 100, 50), blue); n != 0 {
+		t.Errorf("expected top-left quadrant untouched, %d pixels changed", n)
+	}
+}
+
+func TestApplyOpacityBlends(t *testing.T) {
+	black := color.RGBA{A: 255}
+	src := encodePNG(t, solidImage(120, 60, black))
+
+	brightest := func(opacity float64) uint32 {
+		out, err := Apply(bytes.NewReader(src), "image/png", Options{
+			Text: "X", Position: constants.WatermarkPositionCenter, Opacity: opacity, Scale: 0.5,
+		})
+		if err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+		img, _ := png.Decode(bytes.NewReader(out))
+		var peak uint32
+		b := img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				r, _, _, _ := img.At(x, y).RGBA()
+				peak = max(peak, r)
+			}
+		}
+		return peak
+	}
+
+	full, half := brightest(1), brightest(0.5)
+	if full <= half || half == 0 {
+		t.Errorf("expected half opacity to be dimmer than full: full=%d half=%d", full, half)
+	}
+}
+
+func TestApplyOverlayJPEG(t *testing.T) {
+	gray := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, solidImage(160, 160, gray), nil); err != nil {
+		t.Fatalf("failed to encode jpeg: %v", err)
+	}
+
+	out, err := Apply(bytes.NewReader(buf.Bytes()), "image/jpeg", Options{
+		Overlay:  solidImage(10, 5, color.RGBA{R: 255, A: 255}),
+		Position: constants.WatermarkPositionTopLeft,
+		Opacity:  1,
+		Scale:    0.25,
+	})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("output is not a jpeg: %v", err)
+	}
+	// Overlay is scaled to 40x20 and placed at the margin
+	r, g, _, _ := img.At(20, 10).RGBA()
+	if r>>8 < 200 || g>>8 > 60 {
+		t.Errorf("expected red overlay at (20,10), got r=%d g=%d", r>>8, g>>8)
+	}
+	r, _, _, _ = img.At(120, 120).RGBA()
+	if r>>8 > 160 {
+		t.Errorf("expected bottom-right untouched, got r=%d", r>>8)
+	}
+}
+
+func TestApplyTileCoversImage(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	src := encodePNG(t, solidImage(300, 300, white))
+
+	out, err := Apply(bytes.NewReader(src), "image/png", Options{
+		Overlay: solidImage(4, 4, color.RGBA{A: 255}), Position: constants.WatermarkPositionTile, Opacity: 1, Scale: 0.1,
+	})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	img, _ := png.Decode(bytes.NewReader(out))
+	for _, q := range []image.Rectangle{
+		image.Rect(0, 0, 150, 150), image.Rect(150, 0, 300, 150),
+		image.Rect(0, 150, 150, 300), image.Rect(150, 150, 300, 300),
+	} {
+		if changedPixels(img, q, white) == 0 {
+			t.Errorf("expected tiled stamps in quadrant %v", q)
+		}
+	}
+}
+
+func TestApplyRejectsInvalidInput(t *testing.T) {
+	opts := Options{Text: "X", Position: constants.WatermarkPositionCenter, Opacity: 1, Scale: 0.5}
+	if _, err := Apply(bytes.NewReader([]byte("not an image")), "image/png", opts); err == nil {
+		t.Error("expected error for undecodable input")
+	}
+}
+
+func TestSupportedAndValidPosition(t *testing.T) {
+	if !Supported("image/png") || !Supported("image/jpeg") || Supported("image/gif") || Supported("text/plain") {
+		t.Error("unexpected Supported result")
+	}
+	if !ValidPosition(constants.WatermarkPositionTile) || ValidPosition("middle") {
+		t.Error("unexpected ValidPosition result")
+	}
+}
@@ -0,0 +1,180 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"silobang/internal/constants"
+	"silobang/internal/storage"
+)
+
+// Archive holds assets exported from their topic after a long idle period,
+// on storage too slow or too cheap to serve downloads from: a drop
+// directory picked up by tape tooling, or a bucket in an archival storage
+// class. Objects are whole assets, keyed <topic>/<hash>.
+type Archive interface {
+	// Put stores an object of size bytes read from r, replacing any
+	// previous copy.
+	Put(key string, r io.ReadSeeker, size int64) error
+
+	// Recall asks for an object to be made readable and reports whether it
+	// is. It is called again until it reports true, so a recall already
+	// under way must not be an error. Errors wrap fs.ErrNotExist when the
+	// object is not in the archive.
+	Recall(key string) (bool, error)
+
+	// Open returns a reader over an object Recall reported ready.
+	Open(key string) (io.ReadCloser, error)
+
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(key string) error
+}
+
+// ArchiveFactory returns an archive configured with opts.
+type ArchiveFactory func(opts Options) (Archive, error)
+
+var archives = newRegistry[ArchiveFactory]("archive")
+
+// RegisterArchive makes an archive type available to archives.
+func RegisterArchive(typ string, factory ArchiveFactory) {
+	archives.register(typ, factory)
+}
+
+// NewArchive returns an archive of a registered type.
+func NewArchive(typ string, opts Options) (Archive, error) {
+	factory, err := archives.lookup(typ)
+	if err != nil {
+		return nil, err
+	}
+	return factory(opts)
+}
+
+// ArchiveTypes returns the registered archive types, sorted.
+func ArchiveTypes() []string {
+	return archives.types()
+}
+
+// fsArchive writes objects as files under a directory. Tooling that moves
+// them to tape recalls a file it moved away when it finds a marker named
+// after it with the .recall suffix, and removes the marker once the file
+// is back.
+type fsArchive struct {
+	dir string
+}
+
+func (a *fsArchive) path(key string) string {
+	return filepath.Join(a.dir, filepath.FromSlash(key))
+}
+
+// Put writes the object to a temp file next to it and renames it into
+// place, so tooling never picks up a partial file.
+func (a *fsArchive) Put(key string, r io.ReadSeeker, size int64) error {
+	path := a.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if err == nil && n != size {
+		err = fmt.Errorf("copied %d bytes, expected %d", n, size)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (a *fsArchive) Recall(key string) (bool, error) {
+	path := a.path(key)
+	if _, err := os.Stat(path); err == nil {
+		os.Remove(path + constants.ArchiveFSRecallSuffix)
+		return true, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	if err := os.WriteFile(path+constants.ArchiveFSRecallSuffix, nil, 0644); err != nil {
+		return false, fmt.Errorf("failed to request recall of %s: %w", key, err)
+	}
+	return false, nil
+}
+
+func (a *fsArchive) Open(key string) (io.ReadCloser, error) {
+	return os.Open(a.path(key))
+}
+
+func (a *fsArchive) Delete(key string) error {
+	path := a.path(key)
+	os.Remove(path + constants.ArchiveFSRecallSuffix)
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// newS3Archive validates the options of an s3 archive.
+func newS3Archive(opts Options) (Archive, error) {
+	cfg := storage.S3ArchiveConfig{
+		Region:       opts.Get("region", constants.S3DefaultRegion),
+		Prefix:       opts.Get("prefix", ""),
+		StorageClass: opts.Get("storage_class", constants.ArchiveS3DefaultClass),
+		RestoreTier:  opts.Get("restore_tier", constants.ArchiveS3DefaultTier),
+		RestoreDays:  constants.ArchiveS3DefaultDays,
+	}
+	var err error
+	if cfg.Endpoint, err = opts.Require("endpoint"); err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(cfg.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("option %q must be an http or https URL", "endpoint")
+	}
+	if cfg.Bucket, err = opts.Require("bucket"); err != nil {
+		return nil, err
+	}
+	if cfg.AccessKeyID, err = opts.Require("access_key_id"); err != nil {
+		return nil, err
+	}
+	if cfg.SecretAccessKey, err = opts.Require("secret_access_key"); err != nil {
+		return nil, err
+	}
+	if cfg.Prefix != "" && !strings.HasSuffix(cfg.Prefix, "/") {
+		return nil, fmt.Errorf("option %q must end with /", "prefix")
+	}
+	if days := opts.Get("restore_days", ""); days != "" {
+		if cfg.RestoreDays, err = strconv.Atoi(days); err != nil || cfg.RestoreDays < 1 {
+			return nil, fmt.Errorf("option %q must be a positive number of days", "restore_days")
+		}
+	}
+	return storage.NewS3Archive(cfg), nil
+}
+
+func init() {
+	RegisterArchive(constants.PluginArchiveFS, func(opts Options) (Archive, error) {
+		dir, err := opts.Require("path")
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(dir) {
+			return nil, fmt.Errorf("option %q must be an absolute path", "path")
+		}
+		return &fsArchive{dir: dir}, nil
+	})
+	RegisterArchive(constants.PluginArchiveS3, newS3Archive)
+}
@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"silobang/internal/constants"
+)
+
+// AuthProvider authenticates requests that carry no valid API key or
+// session token. Providers in auth_providers are tried in order; the first
+// username returned wins. The user must exist in SiloBang and be active:
+// grants, quotas and lockouts apply as for any other login.
+type AuthProvider interface {
+	// Authenticate returns the username the request is authenticated as,
+	// or "" when it carries no credential for this provider. An error
+	// rejects the credential and leaves the request unauthenticated.
+	Authenticate(r *http.Request) (string, error)
+}
+
+// AuthProviderFactory returns a provider configured with opts.
+type AuthProviderFactory func(opts Options) (AuthProvider, error)
+
+var authProviders = newRegistry[AuthProviderFactory]("auth provider")
+
+// RegisterAuthProvider makes an auth provider type available to
+// auth_providers.
+func RegisterAuthProvider(typ string, factory AuthProviderFactory) {
+	authProviders.register(typ, factory)
+}
+
+// NewAuthProvider returns a provider of a registered type.
+func NewAuthProvider(typ string, opts Options) (AuthProvider, error) {
+	factory, err := authProviders.lookup(typ)
+	if err != nil {
+		return nil, err
+	}
+	return factory(opts)
+}
+
+// AuthProviderTypes returns the registered auth provider types, sorted.
+func AuthProviderTypes() []string {
+	return authProviders.types()
+}
+
+// headerProvider accepts the username a reverse proxy that authenticated
+// the user sets in a header. Requests from other addresses are rejected, so
+// clients cannot set the header themselves.
+type headerProvider struct {
+	header  string
+	trusted []netip.Prefix
+}
+
+func (p *headerProvider) Authenticate(r *http.Request) (string, error) {
+	username := strings.TrimSpace(r.Header.Get(p.header))
+	if username == "" {
+		return "", nil
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return "", fmt.Errorf("unparsable remote address %q", r.RemoteAddr)
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p.trusted {
+		if prefix.Contains(addr) {
+			return username, nil
+		}
+	}
+	return "", fmt.Errorf("%s header from untrusted address %s", p.header, addr)
+}
+
+func init() {
+	// Options: header (e.g. X-Remote-User) and trusted_proxies, a
+	// comma-separated list of proxy addresses or CIDR ranges.
+	RegisterAuthProvider(constants.PluginAuthHeader, func(opts Options) (AuthProvider, error) {
+		header, err := opts.Require("header")
+		if err != nil {
+			return nil, err
+		}
+		proxies, err := opts.Require("trusted_proxies")
+		if err != nil {
+			return nil, err
+		}
+		p := &headerProvider{header: http.CanonicalHeaderKey(header)}
+		for _, entry := range strings.Split(proxies, ",") {
+			entry = strings.TrimSpace(entry)
+			if !strings.Contains(entry, "/") {
+				addr, err := netip.ParseAddr(entry)
+				if err != nil {
+					return nil, fmt.Errorf("trusted_proxies: invalid address %q", entry)
+				}
+				p.trusted = append(p.trusted, netip.PrefixFrom(addr, addr.BitLen()))
+				continue
+			}
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("trusted_proxies: invalid range %q", entry)
+			}
+			p.trusted = append(p.trusted, prefix.Masked())
+		}
+		return p, nil
+	})
+}
@@ -0,0 +1,86 @@
+package plugin
+
+import (
+	"io"
+
+	"silobang/internal/constants"
+	"silobang/internal/storage"
+)
+
+// BlobStore holds the sealed .dat files of one topic, assigned to it in
+// topic_blob_stores. Files are written whole and read by byte range.
+type BlobStore interface {
+	// Name returns BlobStoreConfig.Name. It is recorded in the topic
+	// database to find offloaded files again.
+	Name() string
+
+	// Open returns a reader over length bytes at offset in a .dat file.
+	// Errors wrap fs.ErrNotExist when the file is not in the store.
+	Open(datFile string, offset, length int64) (io.ReadCloser, error)
+
+	// Size returns the size of a .dat file. Errors wrap fs.ErrNotExist when
+	// the file is not in the store.
+	Size(datFile string) (int64, error)
+
+	// Put stores a complete .dat file of size bytes read from r, replacing
+	// any previous copy.
+	Put(datFile string, r io.ReadSeeker, size int64) error
+
+	// Delete removes a .dat file. Deleting a missing file is not an error.
+	Delete(datFile string) error
+}
+
+// BlobStoreConfig is one entry of blob_stores, as seen by one topic. The
+// S3-style fields are passed to every type; other backends usually read
+// Options instead.
+type BlobStoreConfig struct {
+	Name  string // store name, the key in blob_stores
+	Topic string // topic whose files the store holds; empty during validation
+
+	Endpoint        string
+	Bucket          string
+	Region          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+
+	Options Options
+}
+
+// BlobStoreFactory returns the view of a configured store for one topic.
+type BlobStoreFactory func(cfg BlobStoreConfig) (BlobStore, error)
+
+var blobStores = newRegistry[BlobStoreFactory]("blob store")
+
+// RegisterBlobStore makes a blob store type available to blob_stores.
+func RegisterBlobStore(typ string, factory BlobStoreFactory) {
+	blobStores.register(typ, factory)
+}
+
+// NewBlobStore returns a store of a registered type.
+func NewBlobStore(typ string, cfg BlobStoreConfig) (BlobStore, error) {
+	factory, err := blobStores.lookup(typ)
+	if err != nil {
+		return nil, err
+	}
+	return factory(cfg)
+}
+
+// BlobStoreTypes returns the registered blob store types, sorted.
+func BlobStoreTypes() []string {
+	return blobStores.types()
+}
+
+func init() {
+	RegisterBlobStore(constants.PluginBlobStoreS3, func(cfg BlobStoreConfig) (BlobStore, error) {
+		return storage.NewS3BlobStore(storage.S3BlobStoreConfig{
+			Name:            cfg.Name,
+			Endpoint:        cfg.Endpoint,
+			Bucket:          cfg.Bucket,
+			Region:          cfg.Region,
+			Prefix:          cfg.Prefix,
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+		}, cfg.Topic), nil
+	})
+}
@@ -0,0 +1,334 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"silobang/internal/constants"
+)
+
+// jsonSchema is the subset of JSON Schema the json validator checks: type,
+// enum, const, properties, required, additionalProperties, items, the
+// numeric, length and size bounds, pattern, allOf, anyOf, oneOf, not and
+// $ref to definitions of the same file ("#", "#/$defs/..." or
+// "#/definitions/..."). Other keywords are ignored.
+type jsonSchema struct {
+	Type                 schemaTypes            `json:"type"`
+	Enum                 []any                  `json:"enum"`
+	Const                json.RawMessage        `json:"const"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+	Pattern              string                 `json:"pattern"`
+	AllOf                []*jsonSchema          `json:"allOf"`
+	AnyOf                []*jsonSchema          `json:"anyOf"`
+	OneOf                []*jsonSchema          `json:"oneOf"`
+	Not                  *jsonSchema            `json:"not"`
+	Ref                  string                 `json:"$ref"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+	Definitions          map[string]*jsonSchema `json:"definitions"`
+
+	always     *bool // set for the boolean schemas true and false
+	constValue any
+	pattern    *regexp.Regexp
+	ref        *jsonSchema
+}
+
+func (s *jsonSchema) UnmarshalJSON(data []byte) error {
+	var b bool
+	if err := json.Unmarshal(data, &b); err == nil {
+		s.always = &b
+		return nil
+	}
+	type plain jsonSchema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// schemaTypes is the type keyword, a single type name or a list of them.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("type must be a string or an array of strings")
+	}
+	*t = many
+	return nil
+}
+
+// compile prepares s and the schemas below it: it compiles patterns,
+// decodes const and resolves $ref against root.
+func (s *jsonSchema) compile(root *jsonSchema) error {
+	if s == nil || s.always != nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	if len(s.Const) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(s.Const))
+		dec.UseNumber()
+		if err := dec.Decode(&s.constValue); err != nil {
+			return fmt.Errorf("const: %w", err)
+		}
+	}
+	if s.Ref != "" {
+		target, err := root.resolve(s.Ref)
+		if err != nil {
+			return err
+		}
+		s.ref = target
+	}
+
+	children := []*jsonSchema{s.AdditionalProperties, s.Items, s.Not}
+	children = append(children, s.AllOf...)
+	children = append(children, s.AnyOf...)
+	children = append(children, s.OneOf...)
+	for _, defs := range []map[string]*jsonSchema{s.Properties, s.Defs, s.Definitions} {
+		for _, name := range slices.Sorted(maps.Keys(defs)) {
+			children = append(children, defs[name])
+		}
+	}
+	for _, child := range children {
+		if err := child.compile(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema a $ref of the root schema points to.
+func (s *jsonSchema) resolve(ref string) (*jsonSchema, error) {
+	if ref == "#" {
+		return s, nil
+	}
+	for prefix, defs := range map[string]map[string]*jsonSchema{"#/$defs/": s.Defs, "#/definitions/": s.Definitions} {
+		if name, ok := strings.CutPrefix(ref, prefix); ok {
+			name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+			if target := defs[name]; target != nil {
+				return target, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unsupported or unknown $ref %q", ref)
+}
+
+// schemaCheck collects the errors of one document against a schema, up to
+// limit.
+type schemaCheck struct {
+	root   *jsonSchema
+	limit  int
+	errors []string
+}
+
+func (c *schemaCheck) fail(path, format string, args ...any) {
+	if len(c.errors) >= c.limit {
+		return
+	}
+	if path == "" {
+		path = "/"
+	}
+	c.errors = append(c.errors, path+": "+fmt.Sprintf(format, args...))
+}
+
+// matches reports whether v is valid against s, without recording errors.
+func (c *schemaCheck) matches(s *jsonSchema, v any, depth int) bool {
+	sub := &schemaCheck{root: c.root, limit: 1}
+	sub.check(s, v, "", depth)
+	return len(sub.errors) == 0
+}
+
+// check validates v, found at the JSON pointer path, against s.
+func (c *schemaCheck) check(s *jsonSchema, v any, path string, depth int) {
+	if s == nil || len(c.errors) >= c.limit {
+		return
+	}
+	if depth > constants.ValidationMaxSchemaDepth {
+		c.fail(path, "schema nesting exceeds %d levels", constants.ValidationMaxSchemaDepth)
+		return
+	}
+	if s.always != nil {
+		if !*s.always {
+			c.fail(path, "no value is allowed here")
+		}
+		return
+	}
+	if s.ref != nil {
+		c.check(s.ref, v, path, depth+1)
+	}
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return jsonTypeMatches(t, v) }) {
+		c.fail(path, "expected %s, got %s", strings.Join(s.Type, " or "), jsonTypeName(v))
+		return
+	}
+	if s.Enum != nil && !slices.ContainsFunc(s.Enum, func(e any) bool { return jsonEqual(e, v) }) {
+		c.fail(path, "value is not one of the allowed values")
+	}
+	if s.Const != nil && !jsonEqual(s.constValue, v) {
+		c.fail(path, "value must be %s", s.Const)
+	}
+
+	switch val := v.(type) {
+	case json.Number:
+		f, _ := val.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			c.fail(path, "%s is less than the minimum %v", val, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			c.fail(path, "%s is greater than the maximum %v", val, *s.Maximum)
+		}
+		if s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum {
+			c.fail(path, "%s must be greater than %v", val, *s.ExclusiveMinimum)
+		}
+		if s.ExclusiveMaximum != nil && f >= *s.ExclusiveMaximum {
+			c.fail(path, "%s must be less than %v", val, *s.ExclusiveMaximum)
+		}
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.MinLength != nil && n < *s.MinLength {
+			c.fail(path, "string is shorter than %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			c.fail(path, "string is longer than %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			c.fail(path, "string does not match the pattern %q", s.Pattern)
+		}
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			c.fail(path, "array has fewer than %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			c.fail(path, "array has more than %d items", *s.MaxItems)
+		}
+		for i, item := range val {
+			c.check(s.Items, item, path+"/"+strconv.Itoa(i), depth+1)
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				c.fail(path, "missing required property %q", name)
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(val)) {
+			child := path + "/" + strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+			if prop, ok := s.Properties[name]; ok {
+				c.check(prop, val[name], child, depth+1)
+			} else if extra := s.AdditionalProperties; extra != nil && extra.always != nil && !*extra.always {
+				c.fail(path, "property %q is not allowed", name)
+			} else {
+				c.check(extra, val[name], child, depth+1)
+			}
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		c.check(sub, v, path, depth+1)
+	}
+	if len(s.AnyOf) > 0 && !slices.ContainsFunc(s.AnyOf, func(sub *jsonSchema) bool { return c.matches(sub, v, depth+1) }) {
+		c.fail(path, "value matches none of the anyOf schemas")
+	}
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, sub := range s.OneOf {
+			if c.matches(sub, v, depth+1) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			c.fail(path, "value matches %d of the oneOf schemas, expected exactly one", matched)
+		}
+	}
+	if s.Not != nil && c.matches(s.Not, v, depth+1) {
+		c.fail(path, "value must not match the not schema")
+	}
+}
+
+// jsonTypeMatches reports whether a decoded value is of a JSON Schema type.
+func jsonTypeMatches(typ string, v any) bool {
+	if n, ok := v.(json.Number); ok && typ == "integer" {
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	}
+	return jsonTypeName(v) == typ
+}
+
+// jsonTypeName returns the JSON Schema type of a decoded value.
+func jsonTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// jsonEqual compares decoded values, numbers by value.
+func jsonEqual(a, b any) bool {
+	if x, ok := jsonFloat(a); ok {
+		y, ok := jsonFloat(b)
+		return ok && x == y
+	}
+	switch x := a.(type) {
+	case []any:
+		y, ok := b.([]any)
+		return ok && slices.EqualFunc(x, y, jsonEqual)
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, xv := range x {
+			if yv, ok := y[k]; !ok || !jsonEqual(xv, yv) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func jsonFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,100 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"silobang/internal/constants"
+)
+
+// Notifier receives every notification added to a user's feed, in addition
+// to the user's own webhook and email delivery. Notify runs in the
+// background with a deadline; errors are logged and not retried.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Notification is one entry of a user's notification feed.
+type Notification struct {
+	ID        int64           `json:"id"`
+	UserID    int64           `json:"user_id"`
+	Username  string          `json:"username"`
+	Event     string          `json:"event"`
+	Topic     string          `json:"topic,omitempty"`
+	AssetID   string          `json:"asset_id,omitempty"`
+	Actor     string          `json:"actor,omitempty"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	CreatedAt int64           `json:"created_at"`
+}
+
+// NotifierFactory returns a notifier configured with opts.
+type NotifierFactory func(opts Options) (Notifier, error)
+
+var notifiers = newRegistry[NotifierFactory]("notifier")
+
+// RegisterNotifier makes a notifier type available to notifiers.
+func RegisterNotifier(typ string, factory NotifierFactory) {
+	notifiers.register(typ, factory)
+}
+
+// NewNotifier returns a notifier of a registered type.
+func NewNotifier(typ string, opts Options) (Notifier, error) {
+	factory, err := notifiers.lookup(typ)
+	if err != nil {
+		return nil, err
+	}
+	return factory(opts)
+}
+
+// NotifierTypes returns the registered notifier types, sorted.
+func NotifierTypes() []string {
+	return notifiers.types()
+}
+
+// webhookNotifier posts each notification as JSON to a fixed URL, such as
+// a chat integration or an event bus bridge.
+type webhookNotifier struct {
+	url string
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", constants.NotificationUserAgent)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func init() {
+	// Options: url, an http or https URL.
+	RegisterNotifier(constants.PluginNotifierWebhook, func(opts Options) (Notifier, error) {
+		raw, err := opts.Require("url")
+		if err != nil {
+			return nil, err
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("url must be an http or https URL")
+		}
+		return &webhookNotifier{url: raw}, nil
+	})
+}
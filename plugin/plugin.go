@@ -0,0 +1,94 @@
+// Package plugin defines the extension points of SiloBang: blob stores for
+// sealed .dat files, archives for idle assets, auth providers, upload
+// scanners and notifiers.
+//
+// Backends are compiled in. A package registers a factory under a type name
+// from its init function, and a build of cmd/silobang that imports it
+//
+//	import _ "example.com/silobang-ldap"
+//
+// can select the type in config.yaml:
+//
+//	auth_providers:
+//	  - type: ldap
+//	    options:
+//	      url: ldaps://ldap.example.com
+//
+// Factories receive the backend's options and must validate them without
+// doing I/O: they also run when the configuration is validated. The in-tree
+// implementations (s3, filesystem, command, header and webhook) are
+// registered by this package and serve as references.
+package plugin
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Options holds the settings of one configured backend, from its options
+// mapping in config.yaml.
+type Options map[string]string
+
+// Get returns the value of key, or def when it is unset or empty.
+func (o Options) Get(key, def string) string {
+	if v := o[key]; v != "" {
+		return v
+	}
+	return def
+}
+
+// Require returns the value of key, or an error when it is unset or empty.
+func (o Options) Require(key string) (string, error) {
+	if v := o[key]; v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("option %q is required", key)
+}
+
+// registry maps type names to the factories of one kind of backend.
+type registry[F any] struct {
+	kind      string
+	mu        sync.RWMutex
+	factories map[string]F
+}
+
+func newRegistry[F any](kind string) *registry[F] {
+	return &registry[F]{kind: kind, factories: make(map[string]F)}
+}
+
+// register adds a factory. Like database/sql.Register it panics on an empty
+// or duplicate type, which can only be a programming error.
+func (r *registry[F]) register(typ string, factory F) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if typ == "" {
+		panic("plugin: " + r.kind + " registered without a type")
+	}
+	if _, dup := r.factories[typ]; dup {
+		panic(fmt.Sprintf("plugin: %s type %q registered twice", r.kind, typ))
+	}
+	r.factories[typ] = factory
+}
+
+func (r *registry[F]) lookup(typ string) (F, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[typ]
+	r.mu.RUnlock()
+	if !ok {
+		return factory, fmt.Errorf("unknown %s type %q (available: %s)", r.kind, typ, strings.Join(r.types(), ", "))
+	}
+	return factory, nil
+}
+
+func (r *registry[F]) types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.factories))
+	for typ := range r.factories {
+		types = append(types, typ)
+	}
+	slices.Sort(types)
+	return types
+}
@@ -0,0 +1,279 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"silobang/internal/constants"
+)
+
+func TestRegistry_DuplicatesAndUnknownTypes(t *testing.T) {
+	reg := newRegistry[func() int]("widget")
+	reg.register("b", func() int { return 2 })
+	reg.register("a", func() int { return 1 })
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected registering a type twice to panic")
+			}
+		}()
+		reg.register("a", func() int { return 3 })
+	}()
+
+	if f, err := reg.lookup("a"); err != nil || f() != 1 {
+		t.Errorf("lookup of a registered type failed: %v", err)
+	}
+	_, err := reg.lookup("c")
+	if err == nil || !strings.Contains(err.Error(), `unknown widget type "c" (available: a, b)`) {
+		t.Errorf("unexpected error for an unknown type: %v", err)
+	}
+}
+
+func TestBuiltinTypesRegistered(t *testing.T) {
+	for kind, types := range map[string][]string{
+		constants.PluginBlobStoreS3:     BlobStoreTypes(),
+		constants.PluginScannerCommand:  ScannerTypes(),
+		constants.PluginAuthHeader:      AuthProviderTypes(),
+		constants.PluginNotifierWebhook: NotifierTypes(),
+		constants.PluginValidatorJSON:   ValidatorTypes(),
+		constants.PluginArchiveFS:       ArchiveTypes(),
+	} {
+		if !strings.Contains(","+strings.Join(types, ",")+",", ","+kind+",") {
+			t.Errorf("expected %s among %v", kind, types)
+		}
+	}
+}
+
+func TestHeaderProvider(t *testing.T) {
+	if _, err := NewAuthProvider(constants.PluginAuthHeader, Options{"header": "X-Remote-User"}); err == nil {
+		t.Error("expected trusted_proxies to be required")
+	}
+	if _, err := NewAuthProvider(constants.PluginAuthHeader, Options{"header": "X-Remote-User", "trusted_proxies": "proxy"}); err == nil {
+		t.Error("expected an invalid trusted proxy to be rejected")
+	}
+
+	provider, err := NewAuthProvider(constants.PluginAuthHeader, Options{"header": "x-remote-user", "trusted_proxies": "10.1.0.0/16, ::1"})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	tests := []struct {
+		remoteAddr string
+		username   string
+		want       string
+		wantErr    bool
+	}{
+		{"10.1.2.3:4000", "alice", "alice", false},
+		{"[::1]:4000", " bob ", "bob", false},
+		{"[::ffff:10.1.9.9]:4000", "carol", "carol", false},
+		{"10.2.0.1:4000", "mallory", "", true},
+		{"192.0.2.1:4000", "", "", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/topics", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.username != "" {
+			r.Header.Set("X-Remote-User", tt.username)
+		}
+		got, err := provider.Authenticate(r)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%s as %q: got %q, %v", tt.remoteAddr, tt.username, got, err)
+		}
+	}
+}
+
+func TestCommandScanner(t *testing.T) {
+	if _, err := NewScanner(constants.PluginScannerCommand, ScannerConfig{}); err == nil {
+		t.Error("expected a command scanner without a command to be rejected")
+	}
+
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	os.WriteFile(path, []byte("X5O!P%@AP EICAR test"), 0644)
+	scan := func(script string) (ScanResult, error) {
+		scanner, err := NewScanner(constants.PluginScannerCommand, ScannerConfig{Command: []string{"sh", "-c", script}})
+		if err != nil {
+			t.Fatalf("failed to create scanner: %v", err)
+		}
+		return scanner.Scan(context.Background(), path)
+	}
+
+	if result, err := scan("cat >/dev/null"); err != nil || result.Infected {
+		t.Errorf("clean file: %+v, %v", result, err)
+	}
+	if result, err := scan("grep -q EICAR && echo Eicar-Signature && exit 1"); err != nil || !result.Infected || result.Detail != "Eicar-Signature" {
+		t.Errorf("infected file: %+v, %v", result, err)
+	}
+	if _, err := scan("echo broken >&2; exit 2"); err == nil || !strings.HasPrefix(err.Error(), "broken: ") {
+		t.Errorf("expected the scanner output in the error, got %v", err)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	if _, err := NewNotifier(constants.PluginNotifierWebhook, Options{"url": "chat.example.com"}); err == nil {
+		t.Error("expected a URL without scheme to be rejected")
+	}
+
+	received := make(chan Notification, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		json.NewDecoder(r.Body).Decode(&n)
+		received <- n
+	}))
+	defer srv.Close()
+
+	notifier, err := NewNotifier(constants.PluginNotifierWebhook, Options{"url": srv.URL})
+	if err != nil {
+		t.Fatalf("failed to create notifier: %v", err)
+	}
+	sent := Notification{ID: 7, UserID: 2, Username: "alice", Event: "asset_added", Topic: "photos", Details: json.RawMessage(`{"origin_name":"a.png"}`)}
+	if err := notifier.Notify(context.Background(), sent); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+	if got := <-received; got.ID != 7 || got.Username != "alice" || string(got.Details) != `{"origin_name":"a.png"}` {
+		t.Errorf("unexpected notification %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	notifier, _ = NewNotifier(constants.PluginNotifierWebhook, Options{"url": failing.URL})
+	if err := notifier.Notify(context.Background(), sent); err == nil {
+		t.Error("expected a non-2xx response to fail")
+	}
+}
+
+func TestFilesystemArchive(t *testing.T) {
+	if _, err := NewArchive(constants.PluginArchiveFS, Options{"path": "relative/dir"}); err == nil {
+		t.Error("expected a relative path to be rejected")
+	}
+
+	dir := t.TempDir()
+	archive, err := NewArchive(constants.PluginArchiveFS, Options{"path": dir})
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	if err := archive.Put("photos/abc", strings.NewReader("archived bytes"), 14); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if ready, err := archive.Recall("photos/abc"); !ready || err != nil {
+		t.Fatalf("expected a file on disk to be ready, got %v, %v", ready, err)
+	}
+
+	// A file moved away by tape tooling is requested back with a marker
+	path := filepath.Join(dir, "photos", "abc")
+	os.Rename(path, path+".tape")
+	if ready, err := archive.Recall("photos/abc"); ready || err != nil {
+		t.Fatalf("expected a pending recall, got %v, %v", ready, err)
+	}
+	if _, err := os.Stat(path + constants.ArchiveFSRecallSuffix); err != nil {
+		t.Fatalf("expected a recall marker: %v", err)
+	}
+	os.Rename(path+".tape", path)
+	if ready, _ := archive.Recall("photos/abc"); !ready {
+		t.Fatal("expected the returned file to be ready")
+	}
+	if _, err := os.Stat(path + constants.ArchiveFSRecallSuffix); !os.IsNotExist(err) {
+		t.Errorf("expected the recall marker removed, got %v", err)
+	}
+
+	r, err := archive.Open("photos/abc")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "archived bytes" {
+		t.Errorf("read back %q", data)
+	}
+	if err := archive.Delete("photos/abc"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := archive.Delete("photos/abc"); err != nil {
+		t.Errorf("deleting a missing object: %v", err)
+	}
+}
+
+func TestJSONValidator(t *testing.T) {
+	if _, err := NewValidator(constants.PluginValidatorJSON, Options{"schema": "schema.json"}); err == nil {
+		t.Error("expected a relative schema path to be rejected")
+	}
+
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "gltf.schema.json")
+	os.WriteFile(schemaPath, []byte(`{
+		"type": "object",
+		"required": ["asset"],
+		"properties": {
+			"asset": {
+				"type": "object",
+				"required": ["version"],
+				"properties": {"version": {"type": "string", "pattern": "^[0-9]+\\.[0-9]+$"}}
+			},
+			"nodes": {"type": "array", "items": {"$ref": "#/$defs/node"}},
+			"scene": {"type": "integer", "minimum": 0}
+		},
+		"$defs": {
+			"node": {
+				"type": "object",
+				"properties": {"mesh": {"type": "integer"}, "name": {"type": "string", "maxLength": 8}},
+				"additionalProperties": false
+			}
+		}
+	}`), 0644)
+
+	validate := func(opts Options, doc string) (ValidationResult, error) {
+		v, err := NewValidator(constants.PluginValidatorJSON, opts)
+		if err != nil {
+			t.Fatalf("failed to create validator: %v", err)
+		}
+		path := filepath.Join(dir, "upload.gltf")
+		os.WriteFile(path, []byte(doc), 0644)
+		return v.Validate(context.Background(), path)
+	}
+
+	if result, err := validate(nil, `{"anything": [1, 2]}`); err != nil || !result.Valid {
+		t.Errorf("expected any JSON to pass without a schema: %+v, %v", result, err)
+	}
+	if result, _ := validate(nil, `{"a": 1`); result.Valid || len(result.Errors) != 1 || !strings.HasPrefix(result.Errors[0], "invalid JSON") {
+		t.Errorf("expected truncated JSON to be invalid: %+v", result)
+	}
+	if result, _ := validate(nil, `{} {}`); result.Valid {
+		t.Error("expected trailing data to be invalid")
+	}
+
+	schema := Options{"schema": schemaPath}
+	if result, err := validate(schema, `{"asset": {"version": "2.0"}, "nodes": [{"mesh": 0, "name": "root"}], "scene": 0}`); err != nil || !result.Valid {
+		t.Errorf("expected a valid document to pass: %+v, %v", result, err)
+	}
+	result, err := validate(schema, `{"asset": {"version": "two"}, "nodes": [{"mesh": 1.5, "name": "a very long name", "skin": 0}], "scene": -1}`)
+	if err != nil || result.Valid {
+		t.Fatalf("expected an invalid document: %+v, %v", result, err)
+	}
+	want := []string{
+		`/asset/version: string does not match the pattern "^[0-9]+\\.[0-9]+$"`,
+		"/nodes/0/mesh: expected integer, got number",
+		"/nodes/0/name: string is longer than 8 characters",
+		`/nodes/0: property "skin" is not allowed`,
+		"/scene: -1 is less than the minimum 0",
+	}
+	if strings.Join(result.Errors, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected errors:\n%s", strings.Join(result.Errors, "\n"))
+	}
+	if result, _ := validate(schema, `[]`); result.Valid || result.Errors[0] != "/: expected object, got array" {
+		t.Errorf("expected the root type to be checked: %+v", result)
+	}
+
+	os.WriteFile(schemaPath, []byte(`{"$ref": "other.json#/node"}`), 0644)
+	if _, err := validate(schema, `{}`); err == nil {
+		t.Error("expected a schema with an external $ref to fail")
+	}
+}
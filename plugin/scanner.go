@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"silobang/internal/constants"
+)
+
+// ContentScanner checks uploads whose storage policy enables scanning,
+// before they are stored.
+type ContentScanner interface {
+	// Scan inspects the complete upload at path. The file must not be
+	// modified. ctx carries the scan.timeout_secs deadline. An error fails
+	// the upload closed, like a flagged file without quarantine_infected.
+	Scan(ctx context.Context, path string) (ScanResult, error)
+}
+
+// ScanResult is the verdict of a scanner on one file.
+type ScanResult struct {
+	Infected bool
+	Detail   string // shown in the rejection message, e.g. the signature name
+}
+
+// ScannerConfig is the scan section of the configuration.
+type ScannerConfig struct {
+	Command []string // scan.command, for scanners running a program
+	Options Options
+}
+
+// ScannerFactory returns a configured scanner.
+type ScannerFactory func(cfg ScannerConfig) (ContentScanner, error)
+
+var scanners = newRegistry[ScannerFactory]("scanner")
+
+// RegisterScanner makes a scanner type available to scan.type.
+func RegisterScanner(typ string, factory ScannerFactory) {
+	scanners.register(typ, factory)
+}
+
+// NewScanner returns a scanner of a registered type.
+func NewScanner(typ string, cfg ScannerConfig) (ContentScanner, error) {
+	factory, err := scanners.lookup(typ)
+	if err != nil {
+		return nil, err
+	}
+	return factory(cfg)
+}
+
+// ScannerTypes returns the registered scanner types, sorted.
+func ScannerTypes() []string {
+	return scanners.types()
+}
+
+// commandScanner runs a program (e.g. clamdscan) with the upload on stdin.
+// Exit code 0 means clean and constants.ScanExitInfected means infected.
+type commandScanner struct {
+	command []string
+}
+
+func (s *commandScanner) Scan(ctx context.Context, path string) (ScanResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ScanResult{}, fmt.Errorf("failed to open upload for scanning: %w", err)
+	}
+	defer f.Close()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.command[0], s.command[1:]...)
+	cmd.Stdin = f
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err = cmd.Run()
+	if err == nil {
+		return ScanResult{}, nil
+	}
+	if ctx.Err() != nil {
+		return ScanResult{}, ctx.Err()
+	}
+
+	detail := strings.TrimSpace(output.String())
+	if len(detail) > constants.ScanMaxOutputBytes {
+		detail = detail[:constants.ScanMaxOutputBytes]
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == constants.ScanExitInfected {
+		return ScanResult{Infected: true, Detail: detail}, nil
+	}
+	if detail != "" {
+		return ScanResult{}, fmt.Errorf("%s: %w", detail, err)
+	}
+	return ScanResult{}, err
+}
+
+func init() {
+	RegisterScanner(constants.PluginScannerCommand, func(cfg ScannerConfig) (ContentScanner, error) {
+		if len(cfg.Command) == 0 {
+			return nil, errors.New("scan.command is required")
+		}
+		return &commandScanner{command: cfg.Command}, nil
+	})
+}
@@ -0,0 +1,137 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"silobang/internal/constants"
+)
+
+// ContentValidator checks that uploads with one extension are well-formed,
+// such as .gltf files against a project schema, before they are stored.
+type ContentValidator interface {
+	// Validate parses the complete upload at path. The file must not be
+	// modified. ctx carries the validation.timeout_secs deadline. Invalid
+	// content is reported in the result; an error means the validator
+	// could not run and fails the upload closed.
+	Validate(ctx context.Context, path string) (ValidationResult, error)
+}
+
+// ValidationResult is the verdict of a validator on one file.
+type ValidationResult struct {
+	Valid  bool
+	Errors []string // what is wrong, each prefixed with its location when known
+}
+
+// ValidatorFactory returns a validator configured with opts.
+type ValidatorFactory func(opts Options) (ContentValidator, error)
+
+var validators = newRegistry[ValidatorFactory]("validator")
+
+// RegisterValidator makes a validator type available to
+// validation.validators.
+func RegisterValidator(typ string, factory ValidatorFactory) {
+	validators.register(typ, factory)
+}
+
+// NewValidator returns a validator of a registered type.
+func NewValidator(typ string, opts Options) (ContentValidator, error) {
+	factory, err := validators.lookup(typ)
+	if err != nil {
+		return nil, err
+	}
+	return factory(opts)
+}
+
+// ValidatorTypes returns the registered validator types, sorted.
+func ValidatorTypes() []string {
+	return validators.types()
+}
+
+// jsonValidator parses uploads as a single JSON document and, with a
+// schema, checks them against it. The schema file is read on every
+// validation, so edits apply to the next upload.
+type jsonValidator struct {
+	schemaPath string
+}
+
+func (v *jsonValidator) Validate(ctx context.Context, path string) (ValidationResult, error) {
+	var schema *jsonSchema
+	if v.schemaPath != "" {
+		var err error
+		if schema, err = loadJSONSchema(v.schemaPath); err != nil {
+			return ValidationResult{}, err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ValidationResult{}, fmt.Errorf("failed to open upload for validation: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return invalidJSON(err, dec.InputOffset()), nil
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return ValidationResult{Errors: []string{fmt.Sprintf("invalid JSON: unexpected data after the document at offset %d", dec.InputOffset())}}, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return ValidationResult{}, err
+	}
+
+	if schema == nil {
+		return ValidationResult{Valid: true}, nil
+	}
+	c := &schemaCheck{root: schema, limit: constants.ValidationMaxErrors}
+	c.check(schema, doc, "", 0)
+	return ValidationResult{Valid: len(c.errors) == 0, Errors: c.errors}, nil
+}
+
+// invalidJSON reports a document that does not parse.
+func invalidJSON(err error, offset int64) ValidationResult {
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		offset = syntax.Offset
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		err = errors.New("unexpected end of input")
+	}
+	return ValidationResult{Errors: []string{fmt.Sprintf("invalid JSON at offset %d: %v", offset, err)}}
+}
+
+// loadJSONSchema reads and compiles a schema file.
+func loadJSONSchema(path string) (*jsonSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	if err := schema.compile(&schema); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return &schema, nil
+}
+
+func init() {
+	// Options: schema, the absolute path of a JSON Schema file (optional;
+	// without one, uploads only have to parse).
+	RegisterValidator(constants.PluginValidatorJSON, func(opts Options) (ContentValidator, error) {
+		schema := opts.Get("schema", "")
+		if schema != "" && !filepath.IsAbs(schema) {
+			return nil, errors.New("schema must be an absolute path")
+		}
+		return &jsonValidator{schemaPath: schema}, nil
+	})
+}
@@ -4,6 +4,7 @@ import { startUpload, isUploading } from '@store/upload';
 export function UploadZone({ disabled, topicName }) {
   const [isDragOver, setIsDragOver] = useState(false);
   const inputRef = useRef(null);
+  const folderInputRef = useRef(null);
 
   const handleDragOver = (e) => {
     e.preventDefault();
@@ -29,6 +30,12 @@ export function UploadZone({ disabled, topicName }) {
     if (!disabled && !isUploading.value) inputRef.current?.click();
   };
 
+  // Folder selection uploads every file below the folder with its path
+  const handleFolderClick = (e) => {
+    e.stopPropagation();
+    if (!disabled && !isUploading.value) folderInputRef.current?.click();
+  };
+
   const handleFileSelect = (e) => {
     const files = e.target.files;
     if (files.length > 0 && topicName) {
@@ -57,6 +64,14 @@ export function UploadZone({ disabled, topicName }) {
         onChange={handleFileSelect}
         disabled={isDisabled}
       />
+      <input
+        ref={folderInputRef}
+        type="file"
+        webkitdirectory
+        style={{ display: 'none' }}
+        onChange={handleFileSelect}
+        disabled={isDisabled}
+      />
 
       <div class="upload-zone-icon">
         {isUploading.value ? '...' : '\u2B06\uFE0F'}
@@ -67,6 +82,13 @@ export function UploadZone({ disabled, topicName }) {
       <div class="upload-zone-hint">
         {isUploading.value ? 'Please wait' : 'or click to browse'}
       </div>
+      {!isUploading.value && (
+        <div class="upload-zone-hint">
+          <span class="upload-zone-folder" onClick={handleFolderClick}>
+            Upload a folder
+          </span>
+        </div>
+      )}
     </div>
   );
 }
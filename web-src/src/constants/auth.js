@@ -13,6 +13,7 @@ export const AUTH_ACTIONS = {
   VIEW_AUDIT: 'view_audit',
   VERIFY: 'verify',
   MANAGE_CONFIG: 'manage_config',
+  DEBUG: 'debug',
 };
 
 export const ALL_AUTH_ACTIONS = Object.values(AUTH_ACTIONS);
@@ -28,6 +29,7 @@ export const AUTH_ACTION_LABELS = {
   [AUTH_ACTIONS.VIEW_AUDIT]: 'View Audit',
   [AUTH_ACTIONS.VERIFY]: 'Verify',
   [AUTH_ACTIONS.MANAGE_CONFIG]: 'Manage Config',
+  [AUTH_ACTIONS.DEBUG]: 'Debug',
 };
 
 export const AUTH_ACTION_DESCRIPTIONS = {
@@ -41,6 +43,7 @@ export const AUTH_ACTION_DESCRIPTIONS = {
   [AUTH_ACTIONS.VIEW_AUDIT]: 'View audit logs and stream',
   [AUTH_ACTIONS.VERIFY]: 'Run integrity verification',
   [AUTH_ACTIONS.MANAGE_CONFIG]: 'View and change system configuration',
+  [AUTH_ACTIONS.DEBUG]: 'Profile the server when debug endpoints are enabled',
 };
 
 // =============================================================================
@@ -64,6 +67,7 @@ export const AUTH_ERROR_CODES = {
   AUTH_INVALID_API_KEY: 'AUTH_INVALID_API_KEY',
   AUTH_PASSWORD_TOO_WEAK: 'AUTH_PASSWORD_TOO_WEAK',
   AUTH_USERNAME_INVALID: 'AUTH_USERNAME_INVALID',
+  AUTH_RATE_LIMITED: 'AUTH_RATE_LIMITED',
 };
 
 export const AUTH_ERROR_MESSAGES = {
@@ -148,6 +152,7 @@ export const AUTH_CONSTRAINT_FIELDS = {
     { key: 'allowed_topics', type: 'string_array', label: 'Allowed Topics', placeholder: 'Or type a custom topic...', suggest: CONSTRAINT_SUGGEST.TOPICS },
   ],
   [AUTH_ACTIONS.DOWNLOAD]: [
+    { key: 'max_file_size_bytes', type: 'bytes', label: 'Max File Size' },
     { key: 'daily_count_limit', type: 'number', label: 'Daily Download Limit' },
     { key: 'daily_volume_bytes', type: 'bytes', label: 'Daily Volume Limit' },
     { key: 'allowed_topics', type: 'string_array', label: 'Allowed Topics', placeholder: 'Or type a custom topic...', suggest: CONSTRAINT_SUGGEST.TOPICS },
@@ -186,6 +191,7 @@ export const AUTH_CONSTRAINT_FIELDS = {
     { key: 'daily_count_limit', type: 'number', label: 'Daily Verification Limit' },
   ],
   [AUTH_ACTIONS.MANAGE_CONFIG]: [],
+  [AUTH_ACTIONS.DEBUG]: [],
 };
 
 // =============================================================================
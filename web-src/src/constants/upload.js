@@ -11,6 +11,14 @@ export const FileStatus = {
   ERROR: 'error',
 };
 
+// Upload outcome reported by the server (mirrors constants.UploadStatus*)
+export const UploadStatus = {
+  CREATED: 'created',
+  DEDUPLICATED: 'deduplicated',
+  ALIASED: 'aliased',
+  REJECTED: 'rejected',
+};
+
 // Maximum concurrent upload workers
 export const MAX_CONCURRENT_UPLOADS = 3;
 
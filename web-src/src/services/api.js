@@ -161,12 +161,15 @@ export const api = {
   // ASSETS
   // =========================================================================
 
-  async uploadAsset(topicName, file, parentId = null) {
+  async uploadAsset(topicName, file, parentId = null, relativePath = null) {
     const formData = new FormData();
     formData.append('file', file);
     if (parentId) {
       formData.append('parent_id', parentId);
     }
+    if (relativePath) {
+      formData.append('relative_path', relativePath);
+    }
 
     const headers = {};
     const token = getStoredToken();
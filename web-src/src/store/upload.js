@@ -2,6 +2,7 @@ import { signal, computed } from '@preact/signals';
 import { api } from '@services/api';
 import {
   FileStatus,
+  UploadStatus,
   MAX_CONCURRENT_UPLOADS,
   MAX_DISPLAY_ITEMS,
   UPLOAD_UI_UPDATE_INTERVAL_MS,
@@ -70,9 +71,16 @@ function* createFileIterator(fileArray) {
   }
 }
 
+// Folder-relative path of a file picked with a folder selection
+// (webkitRelativePath), or null for a loose file
+export function relativePathOf(file) {
+  return file.webkitRelativePath || null;
+}
+
 // Upload single file (file reference is local, eligible for GC after function returns)
 async function uploadFile(topicName, file) {
-  const fileName = file.name;
+  const relativePath = relativePathOf(file);
+  const fileName = relativePath || file.name;
   const fileSize = file.size;
 
   addToDisplay({
@@ -83,11 +91,12 @@ async function uploadFile(topicName, file) {
   });
 
   try {
-    const result = await api.uploadAsset(topicName, file, parentId.value || null);
+    const result = await api.uploadAsset(topicName, file, parentId.value || null, relativePath);
     // file reference goes out of scope here - eligible for GC
 
-    updateDisplayStatus(fileName, result.skipped ? FileStatus.SKIPPED : FileStatus.SUCCESS);
-    _stats[result.skipped ? 'skipped' : 'added']++;
+    const created = result.status === UploadStatus.CREATED;
+    updateDisplayStatus(fileName, created ? FileStatus.SUCCESS : FileStatus.SKIPPED);
+    _stats[created ? 'added' : 'skipped']++;
     scheduleUIUpdate();
 
   } catch (err) {
@@ -97,7 +106,8 @@ async function uploadFile(topicName, file) {
   }
 }
 
-// Main upload function - streaming pattern with generator
+// Main upload function - streaming pattern with generator. Files from a
+// folder selection keep their path inside the folder.
 export async function startUpload(topicName, files) {
   if (isUploading.value || !files || files.length === 0) return;
 